@@ -26,6 +26,18 @@ const (
 	PrincipalPreparedCondition clusterv1.ConditionType = "PrincipalPrepared"
 )
 
+const (
+	// SpecValidatedCondition reports whether the spec of the KKCluster and the resources it references are valid.
+	SpecValidatedCondition clusterv1.ConditionType = "SpecValidated"
+
+	// SecretNotFoundReason used when a secret referenced by the KKCluster is not found.
+	SecretNotFoundReason = "SecretNotFound"
+	// ConfigMapNotFoundReason used when the instances ConfigMap referenced by the KKCluster is not found.
+	ConfigMapNotFoundReason = "ConfigMapNotFound"
+	// InvalidSpecReason used when the spec of the KKCluster is invalid.
+	InvalidSpecReason = "InvalidSpec"
+)
+
 const (
 	// HostReadyCondition reports whether the host is ready to be used.
 	HostReadyCondition clusterv1.ConditionType = "HostReadyCondition"
@@ -34,6 +34,20 @@ const (
 
 	// InPlaceUpgradeVersionAnnotation is the annotation that stores the version of the cluster used for in-place upgrade.
 	InPlaceUpgradeVersionAnnotation = "kkcluster.infrastructure.cluster.x-k8s.io/in-place-upgrade-version"
+
+	// ReloadPolicyAnnotation is the annotation that sets how the KKCluster reacts to the changes of the secrets, the
+	// instances ConfigMap and the private key files it references.
+	// "validate" (the default) only re-validates the KKCluster, "reconcile" also re-reconciles its KKInstances.
+	ReloadPolicyAnnotation = "kkcluster.infrastructure.cluster.x-k8s.io/reload-policy"
+	// ReloadPolicyValidate re-validates the KKCluster when the resources it references change.
+	ReloadPolicyValidate = "validate"
+	// ReloadPolicyReconcile re-validates the KKCluster and re-reconciles its KKInstances when the resources it references change.
+	ReloadPolicyReconcile = "reconcile"
+
+	// SecretsHashAnnotation is the annotation that stores the hash of the secrets, the instances ConfigMap and the
+	// private key files referenced by the KKCluster.
+	// It is also set on the KKInstances to trigger their reconciliation when the reload policy is "reconcile".
+	SecretsHashAnnotation = "kkcluster.infrastructure.cluster.x-k8s.io/secrets-hash"
 )
 
 // KKClusterSpec defines the desired state of KKCluster
@@ -69,6 +83,11 @@ type Nodes struct {
 
 	// Instances defines all instance contained in kkcluster.
 	Instances []InstanceInfo `json:"instances"`
+
+	// InstancesConfigMap is the name of a ConfigMap in the same namespace as the KKCluster, whose data.instances holds
+	// more instances in YAML, e.g. maintained by an inventory tool. They are appended to Instances.
+	// +optional
+	InstancesConfigMap string `json:"instancesConfigMap,omitempty"`
 }
 
 // InstanceInfo defines the information about the instance.
@@ -16,12 +16,19 @@
 
 package v1alpha2
 
+import "time"
+
 type Addon struct {
 	Name      string  `yaml:"name" json:"name,omitempty"`
 	Namespace string  `yaml:"namespace" json:"namespace,omitempty"`
 	Sources   Sources `yaml:"sources" json:"sources,omitempty"`
-	Retries   int     `yaml:"retries" json:"retries,omitempty"`
-	Delay     int     `yaml:"delay" json:"delay,omitempty"`
+	// Retries is the number of times the addon is installed again after a failure.
+	Retries int `yaml:"retries" json:"retries,omitempty"`
+	// Delay is the seconds between the retries.
+	Delay int `yaml:"delay" json:"delay,omitempty"`
+	// Template renders the values file, the values and the local manifests of the addon as Go templates with the facts
+	// of the hosts, e.g. {{ sumMemory .Groups.worker }}.
+	Template bool `yaml:"template" json:"template,omitempty"`
 }
 
 type Sources struct {
@@ -37,8 +44,27 @@ type Chart struct {
 	ValuesFile string   `yaml:"valuesFile" json:"valuesFile,omitempty"`
 	Values     []string `yaml:"values" json:"values,omitempty"`
 	Wait       bool     `yaml:"wait" json:"wait,omitempty"`
+	// Timeout is the time to wait for the resources of the release, e.g. 10m, 5m by default.
+	Timeout string `yaml:"timeout" json:"timeout,omitempty"`
+	// Atomic deletes a failed installation and rolls back a failed upgrade to the previous revision, it implies Wait.
+	Atomic bool `yaml:"atomic" json:"atomic,omitempty"`
+}
+
+// DefaultChartTimeout is the time to wait for the resources of a chart release without Timeout.
+const DefaultChartTimeout = 5 * time.Minute
+
+// GetTimeout returns the time to wait for the resources of the release.
+func (c *Chart) GetTimeout() time.Duration {
+	if d, err := time.ParseDuration(c.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return DefaultChartTimeout
 }
 
 type Yaml struct {
 	Path []string `yaml:"path" json:"path,omitempty"`
+	// Kustomize is the kustomization directory, whose resources are applied with the manifests of Path.
+	Kustomize string `yaml:"kustomize" json:"kustomize,omitempty"`
+	// Prune deletes the resources applied by the addon before, which are no longer in its manifests.
+	Prune bool `yaml:"prune" json:"prune,omitempty"`
 }
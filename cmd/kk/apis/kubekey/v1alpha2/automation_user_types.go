@@ -0,0 +1,73 @@
+/*
+ Copyright 2022 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha2
+
+import "regexp"
+
+// DefaultAutomationUserShell is the login shell of the automation user.
+const DefaultAutomationUserShell = "/bin/bash"
+
+// userNamePattern matches the portable names of the users and the groups.
+var userNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+
+// AutomationUser defines the dedicated user KubeKey creates on all the hosts, so the cluster bootstrapped as root
+// once can be managed with a least-privilege account: the hosts of the config are switched to the user and its key
+// afterwards.
+type AutomationUser struct {
+	// Name is the name of the user, the user isn't created if it's empty.
+	Name string `yaml:"name" json:"name,omitempty"`
+	// Group is the primary group of the user, defaults to the Name.
+	Group string `yaml:"group" json:"group,omitempty"`
+	// Shell is the login shell of the user, defaults to /bin/bash.
+	Shell string `yaml:"shell" json:"shell,omitempty"`
+	// AuthorizedKeys are the public keys added to the authorized_keys of the user.
+	AuthorizedKeys []string `yaml:"authorizedKeys" json:"authorizedKeys,omitempty"`
+	// AuthorizedKeyFiles are the local files of the public keys added to the authorized_keys of the user, e.g.
+	// ~/.ssh/id_ed25519.pub.
+	AuthorizedKeyFiles []string `yaml:"authorizedKeyFiles" json:"authorizedKeyFiles,omitempty"`
+	// Sudo grants the user the sudo without a password by a sudoers drop-in, defaults to true.
+	Sudo *bool `yaml:"sudo" json:"sudo,omitempty"`
+	// DisablePasswordAuth disables the password authentication of sshd on the hosts after the keys are added, so
+	// the hosts are only logged in with the keys.
+	DisablePasswordAuth bool `yaml:"disablePasswordAuth" json:"disablePasswordAuth,omitempty"`
+}
+
+// Enabled returns if the user is created.
+func (a AutomationUser) Enabled() bool {
+	return a.Name != ""
+}
+
+// GroupName returns the primary group of the user.
+func (a AutomationUser) GroupName() string {
+	if a.Group == "" {
+		return a.Name
+	}
+	return a.Group
+}
+
+// LoginShell returns the login shell of the user.
+func (a AutomationUser) LoginShell() string {
+	if a.Shell == "" {
+		return DefaultAutomationUserShell
+	}
+	return a.Shell
+}
+
+// SudoEnabled returns if the user is granted the sudo.
+func (a AutomationUser) SudoEnabled() bool {
+	return a.Sudo == nil || *a.Sudo
+}
@@ -0,0 +1,90 @@
+/*
+ Copyright 2022 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha2
+
+import "time"
+
+const (
+	BenchmarkDisk    = "disk"
+	BenchmarkNetwork = "network"
+
+	// DefaultMaxFsyncLatency is the 99th percentile of the fdatasync latency of the etcd disks recommended by etcd.
+	DefaultMaxFsyncLatency = 10 * time.Millisecond
+	// DefaultMaxRTT is half of the default heartbeat interval of etcd, which should be around the round-trip time
+	// between its members.
+	DefaultMaxRTT = 50 * time.Millisecond
+	// DefaultMinBandwidthMbps is half of the 1GbE etcd recommends.
+	DefaultMinBandwidthMbps = 500
+)
+
+// Benchmarks are the benchmarks of the preflight checks.
+var Benchmarks = []string{BenchmarkDisk, BenchmarkNetwork}
+
+// Benchmark defines the benchmarks run before the installation, to warn of the disks and the network unsuitable for
+// etcd and the control plane: the fdatasync latency of the etcd data dirs by fio, and the round-trip time by ping and
+// the bandwidth by iperf3 between the control-plane nodes. The benchmarks whose tool isn't installed are skipped.
+type Benchmark struct {
+	// Tests are the benchmarks to run: disk and network. None is run if it is empty.
+	Tests []string `yaml:"tests" json:"tests,omitempty"`
+	// MaxFsyncLatency is the max 99th percentile of the fdatasync latency of the etcd data dirs, defaults to 10ms.
+	MaxFsyncLatency string `yaml:"maxFsyncLatency" json:"maxFsyncLatency,omitempty"`
+	// MaxRTT is the max average round-trip time between the control-plane nodes, defaults to 50ms.
+	MaxRTT string `yaml:"maxRTT" json:"maxRTT,omitempty"`
+	// MinBandwidthMbps is the min bandwidth between the control-plane nodes in Mbit/s, defaults to 500.
+	MinBandwidthMbps int `yaml:"minBandwidthMbps" json:"minBandwidthMbps,omitempty"`
+	// Strict fails the run if any result is beyond its threshold, instead of warning.
+	Strict bool `yaml:"strict" json:"strict,omitempty"`
+}
+
+// Enabled returns whether any benchmark is run.
+func (b Benchmark) Enabled() bool {
+	return len(b.Tests) > 0
+}
+
+// Runs returns whether the benchmark is run.
+func (b Benchmark) Runs(test string) bool {
+	for _, t := range b.Tests {
+		if t == test {
+			return true
+		}
+	}
+	return false
+}
+
+// FsyncLatencyThreshold returns the max 99th percentile of the fdatasync latency.
+func (b Benchmark) FsyncLatencyThreshold() time.Duration {
+	if d, err := time.ParseDuration(b.MaxFsyncLatency); err == nil && d > 0 {
+		return d
+	}
+	return DefaultMaxFsyncLatency
+}
+
+// RTTThreshold returns the max average round-trip time.
+func (b Benchmark) RTTThreshold() time.Duration {
+	if d, err := time.ParseDuration(b.MaxRTT); err == nil && d > 0 {
+		return d
+	}
+	return DefaultMaxRTT
+}
+
+// BandwidthThreshold returns the min bandwidth in Mbit/s.
+func (b Benchmark) BandwidthThreshold() int {
+	if b.MinBandwidthMbps > 0 {
+		return b.MinBandwidthMbps
+	}
+	return DefaultMinBandwidthMbps
+}
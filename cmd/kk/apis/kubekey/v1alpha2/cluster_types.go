@@ -21,6 +21,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -34,6 +35,8 @@ import (
 // ClusterSpec defines the desired state of Cluster
 type ClusterSpec struct {
 	Hosts                []HostCfg            `yaml:"hosts" json:"hosts,omitempty"`
+	Inventory            string               `yaml:"inventory" json:"inventory,omitempty"`
+	SSHConfig            string               `yaml:"sshConfig" json:"sshConfig,omitempty"`
 	RoleGroups           map[string][]string  `yaml:"roleGroups" json:"roleGroups,omitempty"`
 	ControlPlaneEndpoint ControlPlaneEndpoint `yaml:"controlPlaneEndpoint" json:"controlPlaneEndpoint,omitempty"`
 	System               System               `yaml:"system" json:"system,omitempty"`
@@ -44,17 +47,90 @@ type ClusterSpec struct {
 	Storage              StorageConfig        `yaml:"storage" json:"storage,omitempty"`
 	Registry             RegistryConfig       `yaml:"registry" json:"registry,omitempty"`
 	Addons               []Addon              `yaml:"addons" json:"addons,omitempty"`
+	Topology             Topology             `yaml:"topology" json:"topology,omitempty"`
 	KubeSphere           KubeSphere           `json:"kubesphere,omitempty"`
+
+	// NodeGroups are the failure domains, the labels and the taints of the nodes of the role groups, by the names of
+	// the role groups.
+	NodeGroups map[string]NodeGroup `yaml:"nodeGroups" json:"nodeGroups,omitempty"`
+
+	// Offline marks the cluster without access to the internet, the chart addons are installed from the charts
+	// bundled in the artifact.
+	Offline bool `yaml:"offline" json:"offline,omitempty"`
+
+	// Deadlines are the deadlines of the runs of kk on the cluster and their phases.
+	Deadlines Deadlines `yaml:"deadlines" json:"deadlines,omitempty"`
+	// MaintenanceWindow restricts the disruptive phases of the runs of kk on the cluster to the windows.
+	MaintenanceWindow MaintenanceWindow `yaml:"maintenanceWindow" json:"maintenanceWindow,omitempty"`
+	// Lock is the lock of the cluster against the concurrent runs of kk.
+	Lock Lock `yaml:"lock" json:"lock,omitempty"`
+
+	// Hooks notify external systems of the events of the runs of kk on the cluster.
+	Hooks []Hook `yaml:"hooks" json:"hooks,omitempty"`
+
+	// TLS defines the min TLS versions and the cipher suites of kube-apiserver, etcd and kubelet.
+	TLS TLS `yaml:"tls" json:"tls,omitempty"`
+
+	// Verification is the verification of the cluster after it's created, by the smoke and the conformance tests.
+	Verification Verification `yaml:"verification" json:"verification,omitempty"`
+
+	// Benchmark is the benchmark of the disks of etcd and the network of the control plane before the installation.
+	Benchmark Benchmark `yaml:"benchmark" json:"benchmark,omitempty"`
+
+	// RBAC is the initial RBAC of the cluster bound after its installation.
+	RBAC RBAC `yaml:"rbac" json:"rbac,omitempty"`
+}
+
+// ClusterStatus defines the observed state of Cluster, it is reconciled by the operator.
+type ClusterStatus struct {
+	// ObservedGeneration is the generation of the spec, which the cluster was last reconciled to.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Version is the kubernetes version of the cluster.
+	Version string `json:"version,omitempty"`
+	// Nodes are the names of the hosts in the cluster.
+	Nodes []string `json:"nodes,omitempty"`
+	// Pipeline is the name of the last pipeline run for the cluster.
+	Pipeline string `json:"pipeline,omitempty"`
+	// Conditions are the Ready and Progressing conditions of the cluster.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Progress is the progress of the running pipeline, or the last one, on each host, so the task a pipeline is stuck
+	// in and the errors on the hosts can be told from the status.
+	Progress *ClusterProgress `json:"progress,omitempty"`
 }
 
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Version",type="string",JSONPath=".status.version"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="Pipeline",type="string",JSONPath=".status.pipeline"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.progress.phase"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
 type Cluster struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec ClusterSpec `json:"spec,omitempty"`
+	Spec   ClusterSpec   `json:"spec,omitempty"`
+	Status ClusterStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterList contains a list of Cluster
+type ClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Cluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Cluster{}, &ClusterList{})
 }
 
 // HostCfg defines host information for cluster.
+// The Address is the management address of the host, which KubeKey connects to over ssh. The InternalAddress is the
+// data-plane address of the host, which is the node IP of kubernetes and which the other hosts connect to, it defaults
+// to the Address.
 type HostCfg struct {
 	Name            string `yaml:"name,omitempty" json:"name,omitempty"`
 	Address         string `yaml:"address,omitempty" json:"address,omitempty"`
@@ -66,9 +142,63 @@ type HostCfg struct {
 	PrivateKeyPath  string `yaml:"privateKeyPath,omitempty" json:"privateKeyPath,omitempty"`
 	Arch            string `yaml:"arch,omitempty" json:"arch,omitempty"`
 	Timeout         *int64 `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	Bastion         string `yaml:"bastion,omitempty" json:"bastion,omitempty"`
+	BastionPort     int    `yaml:"bastionPort,omitempty" json:"bastionPort,omitempty"`
+	BastionUser     string `yaml:"bastionUser,omitempty" json:"bastionUser,omitempty"`
+
+	// TaskTimeout is the timeout in seconds of each attempt of a task on the host. The operations on the host are
+	// canceled when it expires, and the attempt fails and is retried like the other failures.
+	TaskTimeout int64 `yaml:"taskTimeout,omitempty" json:"taskTimeout,omitempty"`
+
+	// CredentialsFrom references the ssh credentials of the host in a credential provider instead of the plain-text
+	// password and private key, e.g. env://NODE1, ssh-agent://, secret://kubekey/node1, vault://secret/node1 or
+	// aws-sm://node1.
+	CredentialsFrom string `yaml:"credentialsFrom,omitempty" json:"credentialsFrom,omitempty"`
+
+	// TransferRateLimit limits the files copied to the host in bytes per second, e.g. 10Mi, so the artifacts pushed over
+	// a constrained link don't saturate it. It defaults to --transfer-rate-limit.
+	TransferRateLimit string `yaml:"transferRateLimit,omitempty" json:"transferRateLimit,omitempty"`
+	// TransferCompression compresses the files copied to and fetched from the host on the wire. Support: none, gzip,
+	// zstd. It defaults to --transfer-compression.
+	TransferCompression string `yaml:"transferCompression,omitempty" json:"transferCompression,omitempty"`
+
+	// Connector connects to the host. Support: ssh, docker, podman, agent [Default: ssh]
+	// The host of docker or podman is the running container of the same name, e.g. a disposable distro container the
+	// modules are tested against. The host of agent runs the kk agent, which runs the commands and transfers the files
+	// over gRPC with mutual TLS instead of ssh.
+	Connector string `yaml:"connector,omitempty" json:"connector,omitempty"`
+	// AgentPort is the port of the kk agent on the host of the agent connector. [Default: 9443]
+	AgentPort int `yaml:"agentPort,omitempty" json:"agentPort,omitempty"`
+	// AgentCertsDir is the dir of the CA and the client cert of kk trusted by the agent, written by kk agent certs.
+	// [Default: pki/agent in the work dir of the cluster]
+	AgentCertsDir string `yaml:"agentCertsDir,omitempty" json:"agentCertsDir,omitempty"`
+	// Shell runs the commands with privilege on the host. Support: bash, sh, ash, powershell [Default: bash]
+	// The hosts without bash, such as Alpine and BusyBox, use sh or ash. The commands of powershell are encoded, so
+	// they run on the Windows hosts whatever the default shell of their OpenSSH.
+	Shell string `yaml:"shell,omitempty" json:"shell,omitempty"`
+	// Become escalates the privilege of the commands on the host. Support: sudo, doas [Default: sudo]
+	// doas must be configured with nopass for the user. The commands of powershell aren't escalated, the user must be
+	// an administrator.
+	Become string `yaml:"become,omitempty" json:"become,omitempty"`
+	// Env are the environment variables of the commands on the host, e.g. the proxy of the host. They are set within
+	// the privileged shell, so they aren't reset by sudo.
+	Env map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+
+	// Aliases are the other names of the host, e.g. its name on the management network. The host can be referenced
+	// by them in the roleGroups, and they resolve to the internalAddress of the host in /etc/hosts of the nodes.
+	Aliases []string `yaml:"aliases,omitempty" json:"aliases,omitempty"`
 
 	// Labels defines the kubernetes labels for the node.
 	Labels map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	// Region, Zone and Rack define the failure domain where the host is located.
+	// They are applied as the topology labels of the node, and used to group hosts when the topology distribution strategy is rack.
+	Region string `yaml:"region,omitempty" json:"region,omitempty"`
+	Zone   string `yaml:"zone,omitempty" json:"zone,omitempty"`
+	Rack   string `yaml:"rack,omitempty" json:"rack,omitempty"`
+
+	// Taints defines the kubernetes taints of the node. The workers are registered with them, so no pod is scheduled
+	// to the node before it's tainted.
+	Taints []Taint `yaml:"taints,omitempty" json:"taints,omitempty"`
 }
 
 // ControlPlaneEndpoint defines the control plane endpoint information for cluster.
@@ -79,41 +209,186 @@ type ControlPlaneEndpoint struct {
 	Address              string  `yaml:"address" json:"address,omitempty"`
 	Port                 int     `yaml:"port" json:"port,omitempty"`
 	KubeVip              KubeVip `yaml:"kubevip" json:"kubevip,omitempty"`
+
+	// DNS manages the records of the domain, which resolve to the control-plane nodes, when the nodes are changed.
+	DNS *ControlPlaneDNS `yaml:"dns" json:"dns,omitempty"`
 }
 
 type KubeVip struct {
 	Mode string `yaml:"mode" json:"mode,omitempty"`
 }
 
+// ControlPlaneDNS defines the DNS provider which manages the records of the control-plane endpoint domain.
+type ControlPlaneDNS struct {
+	// Provider is the name of the DNS provider. Support: route53, coredns, rfc2136
+	Provider string `yaml:"provider" json:"provider,omitempty"`
+	// TTL is the time to live of the records in seconds.
+	TTL     int64        `yaml:"ttl" json:"ttl,omitempty"`
+	Route53 *Route53DNS  `yaml:"route53" json:"route53,omitempty"`
+	CoreDNS *CoreDNSEtcd `yaml:"coredns" json:"coredns,omitempty"`
+	RFC2136 *RFC2136DNS  `yaml:"rfc2136" json:"rfc2136,omitempty"`
+}
+
+// Route53DNS defines the hosted zone of AWS Route 53 which contains the domain.
+type Route53DNS struct {
+	HostedZoneID string `yaml:"hostedZoneID" json:"hostedZoneID,omitempty"`
+	Region       string `yaml:"region" json:"region,omitempty"`
+}
+
+// CoreDNSEtcd defines the etcd cluster used as the backend of the etcd plugin of CoreDNS.
+type CoreDNSEtcd struct {
+	Endpoints []string `yaml:"endpoints" json:"endpoints,omitempty"`
+	// Prefix is the path of the records in etcd, which is the path configured in the etcd plugin.
+	Prefix   string `yaml:"prefix" json:"prefix,omitempty"`
+	CAFile   string `yaml:"caFile" json:"caFile,omitempty"`
+	CertFile string `yaml:"certFile" json:"certFile,omitempty"`
+	KeyFile  string `yaml:"keyFile" json:"keyFile,omitempty"`
+}
+
+// RFC2136DNS defines the DNS server which accepts the dynamic updates of the zone containing the domain.
+type RFC2136DNS struct {
+	// Server is the address of the DNS server, in the form of host or host:port.
+	Server        string `yaml:"server" json:"server,omitempty"`
+	Zone          string `yaml:"zone" json:"zone,omitempty"`
+	TSIGKeyName   string `yaml:"tsigKeyName" json:"tsigKeyName,omitempty"`
+	TSIGSecret    string `yaml:"tsigSecret" json:"tsigSecret,omitempty"`
+	TSIGAlgorithm string `yaml:"tsigAlgorithm" json:"tsigAlgorithm,omitempty"`
+}
+
 // CustomScripts defines the custom shell scripts for each node to exec before and finished kubernetes install.
 type CustomScripts struct {
 	Name      string   `yaml:"name" json:"name,omitempty"`
 	Bash      string   `yaml:"bash" json:"bash,omitempty"`
 	Role      string   `yaml:"role" json:"role,omitempty"`
 	Materials []string `yaml:"materials" json:"materials,omitempty"`
+	Template  bool     `yaml:"template" json:"template,omitempty"`
+	// Module is the name of an external module in the modulesPath, which is run on each node instead of the bash.
+	// The module reads the args and the variables of the node as JSON on stdin, and prints its result as JSON.
+	Module string `yaml:"module" json:"module,omitempty"`
+	// Args are the args of the module.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Args runtime.RawExtension `yaml:"args" json:"args,omitempty"`
+	// TemplateFile is a local Go template rendered with the variables of each node to a file on the node, instead of
+	// the bash. The file is only written if its content changes.
+	TemplateFile *TemplateFile `yaml:"templateFile" json:"templateFile,omitempty"`
+	// Assert evaluates the expressions with the variables of each node instead of the bash, and fails on the nodes
+	// where any of them is false.
+	Assert *Assert `yaml:"assert" json:"assert,omitempty"`
+	// WaitFor waits for the condition on each node instead of the bash, e.g. a port is open or the node is Ready.
+	WaitFor *WaitFor `yaml:"waitFor" json:"waitFor,omitempty"`
+}
+
+// TemplateFile defines a file on the nodes rendered from a local Go template.
+type TemplateFile struct {
+	// Src is the local path of the template.
+	Src string `yaml:"src" json:"src,omitempty"`
+	// Dest is the absolute path of the file on the nodes.
+	Dest string `yaml:"dest" json:"dest,omitempty"`
+	// Backup copies the old file to <dest>.<timestamp>.bak on the node before it is overwritten.
+	Backup bool `yaml:"backup" json:"backup,omitempty"`
+}
+
+// Assert defines the expressions which must be true on the nodes. The expressions are the when expressions of the
+// tasks, e.g. `ge .CPUs 2` or `eq .Rc 0`, evaluated with the variables of the templates of the custom scripts.
+type Assert struct {
+	// Command is run on the node before the expressions are evaluated, its output and its exit code are the
+	// variables Stdout and Rc of the expressions.
+	Command string `yaml:"command" json:"command,omitempty"`
+	// That are the expressions which must all be true.
+	That []string `yaml:"that" json:"that,omitempty"`
+	// Msg is the message of the failure, the false expression is reported if it's empty.
+	Msg string `yaml:"msg" json:"msg,omitempty"`
+}
+
+// WaitFor defines the condition waited for on the nodes, exactly one of the port, the path, the url, the nodeReady
+// and the command is set.
+type WaitFor struct {
+	// Port is the TCP port which must accept the connections from the node.
+	Port int `yaml:"port" json:"port,omitempty"`
+	// Host is the address of the port, 127.0.0.1 by default.
+	Host string `yaml:"host" json:"host,omitempty"`
+	// Path is the path which must exist on the node.
+	Path string `yaml:"path" json:"path,omitempty"`
+	// URL is the http or https endpoint which must respond with a status below 400 to the node, e.g.
+	// https://127.0.0.1:6443/readyz. The certificate of the endpoint isn't verified.
+	URL string `yaml:"url" json:"url,omitempty"`
+	// NodeReady waits for the Kubernetes node of the host to be Ready.
+	NodeReady bool `yaml:"nodeReady" json:"nodeReady,omitempty"`
+	// Command is run on the node until the expression That is true, or until it succeeds if That is empty.
+	Command string `yaml:"command" json:"command,omitempty"`
+	// That is the expression evaluated with the output and the exit code of the command, see Assert.
+	That string `yaml:"that" json:"that,omitempty"`
+	// Timeout is the time to wait for the condition, e.g. 10m, 5m by default.
+	Timeout string `yaml:"timeout" json:"timeout,omitempty"`
+	// Interval is the interval between the checks of the condition, e.g. 10s, 5s by default.
+	Interval string `yaml:"interval" json:"interval,omitempty"`
+}
+
+const (
+	// DefaultWaitForTimeout is the time to wait for the condition without Timeout.
+	DefaultWaitForTimeout = 5 * time.Minute
+	// DefaultWaitForInterval is the interval between the checks of the condition without Interval.
+	DefaultWaitForInterval = 5 * time.Second
+)
+
+// GetTimeout returns the time to wait for the condition.
+func (w *WaitFor) GetTimeout() time.Duration {
+	if d, err := time.ParseDuration(w.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return DefaultWaitForTimeout
+}
+
+// GetInterval returns the interval between the checks of the condition.
+func (w *WaitFor) GetInterval() time.Duration {
+	if d, err := time.ParseDuration(w.Interval); err == nil && d > 0 {
+		return d
+	}
+	return DefaultWaitForInterval
 }
 
 // System defines the system config for each node in cluster.
 type System struct {
-	NtpServers      []string        `yaml:"ntpServers" json:"ntpServers,omitempty"`
-	Timezone        string          `yaml:"timezone" json:"timezone,omitempty"`
-	Rpms            []string        `yaml:"rpms" json:"rpms,omitempty"`
-	Debs            []string        `yaml:"debs" json:"debs,omitempty"`
-	PreInstall      []CustomScripts `yaml:"preInstall" json:"preInstall,omitempty"`
-	PostInstall     []CustomScripts `yaml:"postInstall" json:"postInstall,omitempty"`
-	SkipConfigureOS bool            `yaml:"skipConfigureOS" json:"skipConfigureOS,omitempty"`
+	NtpServers          []string        `yaml:"ntpServers" json:"ntpServers,omitempty"`
+	Timezone            string          `yaml:"timezone" json:"timezone,omitempty"`
+	Locale              string          `yaml:"locale" json:"locale,omitempty"`
+	Proxy               Proxy           `yaml:"proxy" json:"proxy,omitempty"`
+	Rpms                []string        `yaml:"rpms" json:"rpms,omitempty"`
+	Debs                []string        `yaml:"debs" json:"debs,omitempty"`
+	PreInstall          []CustomScripts `yaml:"preInstall" json:"preInstall,omitempty"`
+	PostInstall         []CustomScripts `yaml:"postInstall" json:"postInstall,omitempty"`
+	SkipConfigureOS     bool            `yaml:"skipConfigureOS" json:"skipConfigureOS,omitempty"`
+	InstallDependencies bool            `yaml:"installDependencies" json:"installDependencies,omitempty"`
+	PackagesPath        string          `yaml:"packagesPath" json:"packagesPath,omitempty"`
+	// Hardening is the baseline hardening of the OS of the nodes, e.g. sshd, auditd and the password policy.
+	Hardening Hardening `yaml:"hardening" json:"hardening,omitempty"`
+	// Tuning is the profile of the kernel parameters and the resource limits of the nodes.
+	Tuning Tuning `yaml:"tuning" json:"tuning,omitempty"`
+	// AutomationUser is the dedicated user of KubeKey created on the nodes.
+	AutomationUser AutomationUser `yaml:"automationUser" json:"automationUser,omitempty"`
+	// SELinux is enforcing, permissive or disabled, defaults to disabled. In enforcing, the policy packages of the
+	// containers are installed and the dirs of Kubernetes are labeled.
+	SELinux string `yaml:"selinux" json:"selinux,omitempty"`
+	// AppArmor is enabled or disabled, AppArmor is left as it is on the nodes if it's empty.
+	AppArmor string `yaml:"apparmor" json:"apparmor,omitempty"`
+	// ModulesPath is the dir of the external modules run by the custom scripts, defaults to the modules dir in the
+	// work dir, e.g. ./kubekey/modules.
+	ModulesPath string `yaml:"modulesPath" json:"modulesPath,omitempty"`
 }
 
 // RegistryConfig defines the configuration information of the image's repository.
 type RegistryConfig struct {
-	Type               string               `yaml:"type" json:"type,omitempty"`
-	RegistryMirrors    []string             `yaml:"registryMirrors" json:"registryMirrors,omitempty"`
-	InsecureRegistries []string             `yaml:"insecureRegistries" json:"insecureRegistries,omitempty"`
-	PrivateRegistry    string               `yaml:"privateRegistry" json:"privateRegistry,omitempty"`
-	DataRoot           string               `yaml:"dataRoot" json:"dataRoot,omitempty"`
-	NamespaceOverride  string               `yaml:"namespaceOverride" json:"namespaceOverride,omitempty"`
-	BridgeIP           string               `yaml:"bridgeIP" json:"bridgeIP,omitempty"`
-	Auths              runtime.RawExtension `yaml:"auths" json:"auths,omitempty"`
+	Type               string   `yaml:"type" json:"type,omitempty"`
+	RegistryMirrors    []string `yaml:"registryMirrors" json:"registryMirrors,omitempty"`
+	InsecureRegistries []string `yaml:"insecureRegistries" json:"insecureRegistries,omitempty"`
+	PrivateRegistry    string   `yaml:"privateRegistry" json:"privateRegistry,omitempty"`
+	DataRoot           string   `yaml:"dataRoot" json:"dataRoot,omitempty"`
+	NamespaceOverride  string   `yaml:"namespaceOverride" json:"namespaceOverride,omitempty"`
+	BridgeIP           string   `yaml:"bridgeIP" json:"bridgeIP,omitempty"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Auths runtime.RawExtension `yaml:"auths" json:"auths,omitempty"`
+	// PullSecret is the image pull secret of the auths created in the cluster.
+	PullSecret PullSecret `yaml:"pullSecret" json:"pullSecret,omitempty"`
 }
 
 // KubeSphere defines the configuration information of the KubeSphere.
@@ -138,7 +413,8 @@ func (cfg *ClusterSpec) GenerateCertSANs() []string {
 	for _, host := range cfg.Hosts {
 		extraCertSANs = append(extraCertSANs, host.Name)
 		extraCertSANs = append(extraCertSANs, fmt.Sprintf("%s.%s", host.Name, cfg.Kubernetes.DNSDomain))
-		if host.Address != cfg.ControlPlaneEndpoint.Address {
+		// the link-local address with a zone is only used to connect to the host over ssh
+		if host.Address != cfg.ControlPlaneEndpoint.Address && AddressZone(host.Address) == "" {
 			extraCertSANs = append(extraCertSANs, host.Address)
 		}
 
@@ -153,7 +429,7 @@ func (cfg *ClusterSpec) GenerateCertSANs() []string {
 		}
 	}
 
-	extraCertSANs = append(extraCertSANs, util.ParseIp(strings.Split(cfg.Network.KubeServiceCIDR, ",")[0])[0])
+	extraCertSANs = append(extraCertSANs, cfg.ClusterIP())
 
 	defaultCertSANs = append(defaultCertSANs, extraCertSANs...)
 
@@ -170,9 +446,13 @@ func (cfg *ClusterSpec) GroupHosts() map[string][]*KubeHost {
 	for _, hostCfg := range cfg.Hosts {
 		host := toHosts(hostCfg)
 		hostMap[host.Name] = host
+		for _, alias := range host.Aliases {
+			hostMap[alias] = host
+		}
 	}
 
 	roleGroups := cfg.ParseRolesList(hostMap)
+	cfg.applyNodeGroups(roleGroups)
 
 	//Check that the parameters under roleGroups are incorrect
 	if len(roleGroups[Master]) == 0 && len(roleGroups[ControlPlane]) == 0 {
@@ -193,7 +473,43 @@ func (cfg *ClusterSpec) GroupHosts() map[string][]*KubeHost {
 // +kubebuilder:object:generate=false
 type KubeHost struct {
 	*connector.BaseHost
-	Labels map[string]string
+	Aliases []string
+	Labels  map[string]string
+	Region  string
+	Zone    string
+	Rack    string
+	Taints  []Taint
+}
+
+// TrimAddressBrackets removes the brackets of an IPv6 literal address, e.g. [2001:db8::1] is 2001:db8::1.
+func TrimAddressBrackets(address string) string {
+	if strings.HasPrefix(address, "[") && strings.HasSuffix(address, "]") {
+		return address[1 : len(address)-1]
+	}
+	return address
+}
+
+// TrimInternalAddress removes the spaces and the brackets of the addresses of an internalAddress, e.g.
+// "192.168.0.1, [2001:db8::1]" of a dual-stack host is 192.168.0.1,2001:db8::1.
+func TrimInternalAddress(internalAddress string) string {
+	if internalAddress == "" {
+		return ""
+	}
+	addresses := strings.Split(internalAddress, ",")
+	for i, address := range addresses {
+		addresses[i] = TrimAddressBrackets(strings.TrimSpace(address))
+	}
+	return strings.Join(addresses, ",")
+}
+
+// AddressZone returns the zone of a link-local IPv6 address, e.g. eth0 of fe80::1%eth0, or an empty string if the
+// address has no zone. Such an address can only be used to connect to the host over ssh, not by the other hosts.
+func AddressZone(address string) string {
+	address = TrimAddressBrackets(address)
+	if i := strings.LastIndex(address, "%"); i >= 0 {
+		return address[i+1:]
+	}
+	return ""
 }
 
 func toHosts(cfg HostCfg) *KubeHost {
@@ -208,22 +524,40 @@ func toHosts(cfg HostCfg) *KubeHost {
 	host.PrivateKeyPath = cfg.PrivateKeyPath
 	host.Arch = cfg.Arch
 	host.Timeout = *cfg.Timeout
+	host.TaskTimeout = cfg.TaskTimeout
+	host.Bastion = cfg.Bastion
+	host.BastionPort = cfg.BastionPort
+	host.BastionUser = cfg.BastionUser
+	host.CredentialsFrom = cfg.CredentialsFrom
+	host.TransferRateLimit = cfg.TransferRateLimit
+	host.TransferCompression = cfg.TransferCompression
+	host.ConnectorType = cfg.Connector
+	host.AgentPort = cfg.AgentPort
+	host.AgentCertsDir = cfg.AgentCertsDir
+	host.Shell = cfg.Shell
+	host.Become = cfg.Become
+	host.Env = cfg.Env
 
 	kubeHost := &KubeHost{
 		BaseHost: host,
+		Aliases:  cfg.Aliases,
 		Labels:   cfg.Labels,
+		Region:   cfg.Region,
+		Zone:     cfg.Zone,
+		Rack:     cfg.Rack,
+		Taints:   cfg.Taints,
 	}
 	return kubeHost
 }
 
 // ClusterIP is used to get the kube-apiserver service address inside the cluster.
 func (cfg *ClusterSpec) ClusterIP() string {
-	return util.ParseIp(strings.Split(cfg.Network.KubeServiceCIDR, ",")[0])[0]
+	return util.CIDRAddress(strings.Split(cfg.Network.KubeServiceCIDR, ",")[0], 1)
 }
 
 // CorednsClusterIP is used to get the coredns service address inside the cluster.
 func (cfg *ClusterSpec) CorednsClusterIP() string {
-	return util.ParseIp(strings.Split(cfg.Network.KubeServiceCIDR, ",")[0])[2]
+	return util.CIDRAddress(strings.Split(cfg.Network.KubeServiceCIDR, ",")[0], 3)
 }
 
 // ClusterDNS is used to get the dns server address inside the cluster.
@@ -312,6 +646,11 @@ func (c *ControlPlaneEndpoint) EnableExternalDNS() bool {
 	return *c.ExternalDNS
 }
 
+// ManagedDNS is used to determine whether the records of the kube-apiserver domain are managed by a DNS provider.
+func (c *ControlPlaneEndpoint) ManagedDNS() bool {
+	return c.DNS != nil && c.DNS.Provider != ""
+}
+
 func (r *RegistryConfig) GetHost() string {
 	if r.PrivateRegistry == "" {
 		return ""
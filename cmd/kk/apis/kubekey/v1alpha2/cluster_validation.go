@@ -0,0 +1,1191 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	versionutil "k8s.io/apimachinery/pkg/util/version"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/event"
+)
+
+var hostsRange = regexp.MustCompile(`\[(\d+):(\d+)\]`)
+
+// timezonePattern and localePattern match the names of the tz database, e.g. Asia/Shanghai, and the locales, e.g.
+// en_US.UTF-8, which are set in the shell commands on the nodes.
+var (
+	timezonePattern = regexp.MustCompile(`^[A-Za-z0-9_+-]+(/[A-Za-z0-9_+-]+)*$`)
+	localePattern   = regexp.MustCompile(`^[A-Za-z0-9_.@-]+$`)
+)
+
+// unsafeProxyChars can't be in the proxy, which is written to the systemd units and the package manager configs.
+const unsafeProxyChars = " \t\n\"'`$\\;"
+
+// Validate validates the cluster spec before it is defaulted, so the bad specs are rejected before anything runs
+// on the hosts. The errors tell how to fix the fields.
+func (cfg *ClusterSpec) Validate(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	errs = append(errs, cfg.validateHosts(path.Child("hosts"))...)
+	errs = append(errs, cfg.validateRoleGroups(path.Child("roleGroups"))...)
+	errs = append(errs, cfg.validateNodeGroups(path.Child("nodeGroups"))...)
+	errs = append(errs, cfg.validateControlPlaneEndpoint(path.Child("controlPlaneEndpoint"))...)
+	errs = append(errs, cfg.validateNetwork(path)...)
+	errs = append(errs, cfg.validateGPU(path.Child("kubernetes"))...)
+	errs = append(errs, cfg.validateKubeadm(path.Child("kubernetes"))...)
+	errs = append(errs, cfg.validateStorage(path.Child("storage"))...)
+	errs = append(errs, cfg.validateAddons(path.Child("addons"))...)
+	errs = append(errs, cfg.validateSystem(path.Child("system"))...)
+	errs = append(errs, validateDeadlines(path.Child("deadlines"), cfg.Deadlines)...)
+	errs = append(errs, validateMaintenanceWindow(path.Child("maintenanceWindow"), cfg.MaintenanceWindow)...)
+	if cfg.Lock.LeaseDuration != "" {
+		if d, err := time.ParseDuration(cfg.Lock.LeaseDuration); err != nil || d < time.Minute {
+			errs = append(errs, field.Invalid(path.Child("lock", "leaseDuration"), cfg.Lock.LeaseDuration, "must be a duration of at least 1m"))
+		}
+	}
+	errs = append(errs, cfg.validateTLS(path.Child("tls"))...)
+	for i, hook := range cfg.Hooks {
+		errs = append(errs, validateHook(path.Child("hooks").Index(i), hook)...)
+	}
+	errs = append(errs, validateVerification(path.Child("verification"), cfg.Verification)...)
+	errs = append(errs, validateBenchmark(path.Child("benchmark"), cfg.Benchmark)...)
+	errs = append(errs, validateRBAC(path.Child("rbac"), cfg.RBAC)...)
+	errs = append(errs, validatePullSecret(path.Child("registry", "pullSecret"), cfg.Registry.PullSecret)...)
+	if cfg.Kubernetes.Version != "" {
+		if _, err := parseKubeVersion(cfg.Kubernetes.Version); err != nil {
+			errs = append(errs, field.Invalid(path.Child("kubernetes", "version"), cfg.Kubernetes.Version,
+				"must be a semantic version like v1.23.15, with an optional distribution suffix like -k3s"))
+		}
+	}
+	return errs
+}
+
+// ValidateVersionSkew validates the upgrade of a cluster from the current kubernetes version to the desired one.
+// The minor versions in between are upgraded one by one, but the cluster can't be downgraded or upgraded to
+// another major version.
+func ValidateVersionSkew(current, desired string, path *field.Path) field.ErrorList {
+	from, err := parseKubeVersion(current)
+	if err != nil {
+		return nil
+	}
+	to, err := parseKubeVersion(desired)
+	if err != nil {
+		return field.ErrorList{field.Invalid(path, desired, "must be a semantic version like v1.23.15")}
+	}
+	if to.Major() != from.Major() {
+		return field.ErrorList{field.Forbidden(path,
+			fmt.Sprintf("can't upgrade the cluster from %s to another major version %s", current, desired))}
+	}
+	if to.LessThan(from) {
+		return field.ErrorList{field.Forbidden(path,
+			fmt.Sprintf("can't downgrade the cluster from %s to %s, set a version not lower than %s", current, desired, current))}
+	}
+	return nil
+}
+
+func parseKubeVersion(version string) (*versionutil.Version, error) {
+	return versionutil.ParseSemantic(strings.Split(version, "-")[0])
+}
+
+func (cfg *ClusterSpec) validateHosts(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	names := make(map[string]struct{}, len(cfg.Hosts))
+	endpoints := make(map[string]struct{}, len(cfg.Hosts))
+	internalAddresses := make(map[string]struct{}, len(cfg.Hosts))
+	for i, host := range cfg.Hosts {
+		hostPath := path.Index(i)
+		if host.Name == "" {
+			errs = append(errs, field.Required(hostPath.Child("name"), "the name of the host is required"))
+		} else if _, ok := names[host.Name]; ok {
+			errs = append(errs, field.Duplicate(hostPath.Child("name"), host.Name))
+		}
+		names[host.Name] = struct{}{}
+		for j, alias := range host.Aliases {
+			if msgs := validation.IsDNS1123Subdomain(alias); len(msgs) != 0 {
+				errs = append(errs, field.Invalid(hostPath.Child("aliases").Index(j), alias, strings.Join(msgs, "; ")))
+			} else if _, ok := names[alias]; ok {
+				errs = append(errs, field.Duplicate(hostPath.Child("aliases").Index(j), alias))
+			}
+			names[alias] = struct{}{}
+		}
+		if host.TaskTimeout < 0 {
+			errs = append(errs, field.Invalid(hostPath.Child("taskTimeout"), host.TaskTimeout, "must be greater than or equal to 0"))
+		}
+		if _, err := connector.ParseTransferRateLimit(host.TransferRateLimit); err != nil {
+			errs = append(errs, field.Invalid(hostPath.Child("transferRateLimit"), host.TransferRateLimit,
+				"must be a positive quantity of bytes per second, e.g. 10Mi"))
+		}
+		if host.TransferCompression != "" && !containsString(connector.TransferCompressions, host.TransferCompression) {
+			errs = append(errs, field.NotSupported(hostPath.Child("transferCompression"), host.TransferCompression, connector.TransferCompressions))
+		}
+		if host.Connector != "" && !containsString(connector.Connectors, host.Connector) {
+			errs = append(errs, field.NotSupported(hostPath.Child("connector"), host.Connector, connector.Connectors))
+		}
+		if host.AgentPort < 0 || host.AgentPort > 65535 {
+			errs = append(errs, field.Invalid(hostPath.Child("agentPort"), host.AgentPort, "must be a port between 1 and 65535"))
+		}
+		if host.Shell != "" && !containsString(connector.Shells, host.Shell) {
+			errs = append(errs, field.NotSupported(hostPath.Child("shell"), host.Shell, connector.Shells))
+		}
+		if host.Become != "" && !containsString(connector.BecomeMethods, host.Become) {
+			errs = append(errs, field.NotSupported(hostPath.Child("become"), host.Become, connector.BecomeMethods))
+		} else if host.Become != "" && host.Shell == connector.ShellPowerShell {
+			errs = append(errs, field.Invalid(hostPath.Child("become"), host.Become, "the commands of powershell aren't escalated"))
+		}
+		for name := range host.Env {
+			if !connector.IsEnvName(name) {
+				errs = append(errs, field.Invalid(hostPath.Child("env").Key(name), name, "must be a name of letters, digits and underscores, not starting with a digit"))
+			}
+		}
+		errs = append(errs, validateNodeGroup(hostPath, NodeGroup{
+			Region: host.Region, Zone: host.Zone, Rack: host.Rack, Labels: host.Labels, Taints: host.Taints,
+		})...)
+
+		if host.Address == "" && host.InternalAddress == "" {
+			errs = append(errs, field.Required(hostPath.Child("address"), "the address or the internalAddress of the host is required"))
+			continue
+		}
+		// the hosts behind a NAT share the address, but not the ssh port
+		if address := TrimAddressBrackets(host.Address); address != "" {
+			if zone := AddressZone(address); zone != "" {
+				if ip := net.ParseIP(strings.TrimSuffix(address, "%"+zone)); ip == nil || ip.To4() != nil {
+					errs = append(errs, field.Invalid(hostPath.Child("address"), host.Address, "must be an IPv6 address with a zone, e.g. fe80::1%eth0"))
+				} else if host.InternalAddress == "" {
+					errs = append(errs, field.Required(hostPath.Child("internalAddress"),
+						fmt.Sprintf("the link-local address %s can't be reached by the other hosts, the internalAddress of the host is required", host.Address)))
+					continue
+				}
+			} else if strings.Contains(address, ":") && net.ParseIP(address) == nil {
+				errs = append(errs, field.Invalid(hostPath.Child("address"), host.Address, "must be an IP address or a host name"))
+			}
+			endpoint := net.JoinHostPort(address, strconv.Itoa(host.Port))
+			if _, ok := endpoints[endpoint]; ok {
+				errs = append(errs, field.Duplicate(hostPath.Child("address"), host.Address))
+			}
+			endpoints[endpoint] = struct{}{}
+		}
+		// the second address is the IPv6 address of a dual-stack node
+		if addresses := hostInternalAddresses(host); len(addresses) > 2 {
+			errs = append(errs, field.Invalid(hostPath.Child("internalAddress"), host.InternalAddress, "must be an IP address, or an IPv4 and an IPv6 address separated by a comma"))
+		} else if len(addresses) == 2 {
+			first, second := net.ParseIP(addresses[0]), net.ParseIP(addresses[1])
+			if first != nil && second != nil && (first.To4() == nil || second.To4() != nil) {
+				errs = append(errs, field.Invalid(hostPath.Child("internalAddress"), host.InternalAddress, "must be an IPv4 and an IPv6 address in this order"))
+			}
+		}
+		for _, address := range hostInternalAddresses(host) {
+			if net.ParseIP(address) == nil {
+				errs = append(errs, field.Invalid(hostPath.Child("internalAddress"), address, "must be an IP address, or an IPv4 and an IPv6 address separated by a comma"))
+				continue
+			}
+			if _, ok := internalAddresses[address]; ok {
+				errs = append(errs, field.Duplicate(hostPath.Child("internalAddress"), address))
+			}
+			internalAddresses[address] = struct{}{}
+		}
+	}
+	return errs
+}
+
+func hostInternalAddresses(host HostCfg) []string {
+	if host.InternalAddress == "" {
+		return []string{TrimAddressBrackets(host.Address)}
+	}
+	return strings.Split(TrimInternalAddress(host.InternalAddress), ",")
+}
+
+func (cfg *ClusterSpec) validateRoleGroups(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	names := make(map[string]struct{}, len(cfg.Hosts))
+	for _, host := range cfg.Hosts {
+		names[host.Name] = struct{}{}
+		for _, alias := range host.Aliases {
+			names[alias] = struct{}{}
+		}
+	}
+	for role, hosts := range cfg.RoleGroups {
+		for i, host := range hosts {
+			for _, name := range expandHostsRange(host) {
+				if _, ok := names[name]; !ok {
+					errs = append(errs, field.NotFound(path.Key(role).Index(i), name))
+				}
+			}
+		}
+	}
+
+	if len(cfg.RoleGroups[Master]) == 0 && len(cfg.RoleGroups[ControlPlane]) == 0 {
+		errs = append(errs, field.Required(path.Key(ControlPlane), "at least one host is required in the control-plane role group"))
+	}
+	if (cfg.Etcd.Type == "" || cfg.Etcd.Type == KubeKey) && len(cfg.RoleGroups[Etcd]) == 0 {
+		errs = append(errs, field.Required(path.Key(Etcd), "at least one host is required in the etcd role group, unless etcd.type is kubeadm or external"))
+	}
+	return errs
+}
+
+func (cfg *ClusterSpec) validateNodeGroups(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	names := make([]string, 0, len(cfg.NodeGroups))
+	for name := range cfg.NodeGroups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := cfg.RoleGroups[name]; !ok {
+			errs = append(errs, field.NotFound(path.Key(name), name))
+		}
+		errs = append(errs, validateNodeGroup(path.Key(name), cfg.NodeGroups[name])...)
+	}
+	return errs
+}
+
+// validateNodeGroup validates the failure domain, the labels and the taints of a host or a node group, which are
+// applied to the nodes.
+func validateNodeGroup(path *field.Path, group NodeGroup) field.ErrorList {
+	var errs field.ErrorList
+	for _, domain := range []struct{ name, value string }{{"region", group.Region}, {"zone", group.Zone}, {"rack", group.Rack}} {
+		if msgs := validation.IsValidLabelValue(domain.value); len(msgs) != 0 {
+			errs = append(errs, field.Invalid(path.Child(domain.name), domain.value, strings.Join(msgs, "; ")))
+		}
+	}
+	keys := make([]string, 0, len(group.Labels))
+	for key := range group.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		msgs := append(validation.IsQualifiedName(key), validation.IsValidLabelValue(group.Labels[key])...)
+		if len(msgs) != 0 {
+			errs = append(errs, field.Invalid(path.Child("labels").Key(key), group.Labels[key], strings.Join(msgs, "; ")))
+		}
+	}
+	for i, taint := range group.Taints {
+		taintPath := path.Child("taints").Index(i)
+		msgs := append(validation.IsQualifiedName(taint.Key), validation.IsValidLabelValue(taint.Value)...)
+		if len(msgs) != 0 {
+			errs = append(errs, field.Invalid(taintPath, taint.String(), strings.Join(msgs, "; ")))
+		}
+		if !containsString(TaintEffects, taint.Effect) {
+			errs = append(errs, field.NotSupported(taintPath.Child("effect"), taint.Effect, TaintEffects))
+		}
+	}
+	return errs
+}
+
+func expandHostsRange(host string) []string {
+	m := hostsRange.FindStringSubmatch(host)
+	if m == nil {
+		return []string{host}
+	}
+	prefix := strings.Split(host, m[0])[0]
+	start, _ := strconv.Atoi(m[1])
+	end, _ := strconv.Atoi(m[2])
+	names := make([]string, 0, end-start+1)
+	for i := start; i <= end; i++ {
+		names = append(names, fmt.Sprintf("%s%d", prefix, i))
+	}
+	return names
+}
+
+func (cfg *ClusterSpec) validateControlPlaneEndpoint(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	endpoint := cfg.ControlPlaneEndpoint
+	switch endpoint.InternalLoadbalancer {
+	case "":
+	case Haproxy:
+		if endpoint.Address != "" {
+			errs = append(errs, field.Forbidden(path.Child("address"),
+				"can't be set with the internal load balancer haproxy, remove the address or the internalLoadbalancer"))
+		}
+	case Kubevip:
+		if endpoint.Address == "" {
+			errs = append(errs, field.Required(path.Child("address"), "the virtual IP of kube-vip is required"))
+		}
+	default:
+		errs = append(errs, field.NotSupported(path.Child("internalLoadbalancer"), endpoint.InternalLoadbalancer, []string{Haproxy, Kubevip}))
+	}
+	if endpoint.Address != "" && net.ParseIP(endpoint.Address) == nil {
+		errs = append(errs, field.Invalid(path.Child("address"), endpoint.Address, "must be an IP address"))
+	}
+
+	controlPlanes := 0
+	for _, role := range []string{Master, ControlPlane} {
+		for _, host := range cfg.RoleGroups[role] {
+			controlPlanes += len(expandHostsRange(host))
+		}
+	}
+	if controlPlanes > 1 && endpoint.InternalLoadbalancer == "" && endpoint.Address == "" && !endpoint.EnableExternalDNS() {
+		errs = append(errs, field.Required(path.Child("address"),
+			"a control-plane endpoint is required with multiple control-plane nodes: set the address of a load balancer, "+
+				"set internalLoadbalancer to haproxy or kube-vip, or set externalDNS to true if the domain is resolved by your DNS server"))
+	}
+	if endpoint.ManagedDNS() {
+		errs = append(errs, cfg.validateControlPlaneDNS(path)...)
+	}
+	return errs
+}
+
+func (cfg *ClusterSpec) validateControlPlaneDNS(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	endpoint := cfg.ControlPlaneEndpoint
+	dnsPath := path.Child("dns")
+	if cfg.Kubernetes.Type != "" && cfg.Kubernetes.Type != "kubernetes" {
+		errs = append(errs, field.Forbidden(dnsPath, "the records can only be managed for the clusters of the kubernetes type"))
+	}
+	if !endpoint.EnableExternalDNS() {
+		errs = append(errs, field.Invalid(path.Child("externalDNS"), endpoint.ExternalDNS,
+			"must be true when the records of the domain are managed by a DNS provider"))
+	}
+	if endpoint.Address != "" || endpoint.InternalLoadbalancer != "" {
+		errs = append(errs, field.Forbidden(dnsPath,
+			"the records resolve to the control-plane nodes, remove the address and the internalLoadbalancer"))
+	}
+	if endpoint.Domain == "" {
+		errs = append(errs, field.Required(path.Child("domain"), "the domain of the managed records is required"))
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(endpoint.Domain) {
+			errs = append(errs, field.Invalid(path.Child("domain"), endpoint.Domain, msg))
+		}
+	}
+	if endpoint.DNS.TTL < 0 {
+		errs = append(errs, field.Invalid(dnsPath.Child("ttl"), endpoint.DNS.TTL, "must be greater than or equal to 0"))
+	}
+
+	switch endpoint.DNS.Provider {
+	case DNSProviderRoute53:
+		if endpoint.DNS.Route53 == nil || endpoint.DNS.Route53.HostedZoneID == "" {
+			errs = append(errs, field.Required(dnsPath.Child("route53", "hostedZoneID"), "the hosted zone of the domain is required"))
+		}
+	case DNSProviderCoreDNS:
+		if endpoint.DNS.CoreDNS == nil || len(endpoint.DNS.CoreDNS.Endpoints) == 0 {
+			errs = append(errs, field.Required(dnsPath.Child("coredns", "endpoints"), "the endpoints of the etcd backend are required"))
+		}
+	case DNSProviderRFC2136:
+		rfc2136 := endpoint.DNS.RFC2136
+		if rfc2136 == nil || rfc2136.Server == "" {
+			errs = append(errs, field.Required(dnsPath.Child("rfc2136", "server"), "the DNS server accepting the updates is required"))
+		}
+		if rfc2136 == nil || rfc2136.Zone == "" {
+			errs = append(errs, field.Required(dnsPath.Child("rfc2136", "zone"), "the zone of the domain is required"))
+		} else if zone := strings.TrimSuffix(rfc2136.Zone, "."); endpoint.Domain != zone && !strings.HasSuffix(endpoint.Domain, "."+zone) {
+			errs = append(errs, field.Invalid(dnsPath.Child("rfc2136", "zone"), rfc2136.Zone,
+				fmt.Sprintf("must contain the domain %s", endpoint.Domain)))
+		}
+		if rfc2136 != nil && (rfc2136.TSIGKeyName == "") != (rfc2136.TSIGSecret == "") {
+			errs = append(errs, field.Required(dnsPath.Child("rfc2136"), "tsigKeyName and tsigSecret must be set together"))
+		}
+	default:
+		errs = append(errs, field.NotSupported(dnsPath.Child("provider"), endpoint.DNS.Provider,
+			[]string{DNSProviderRoute53, DNSProviderCoreDNS, DNSProviderRFC2136}))
+	}
+	return errs
+}
+
+func (cfg *ClusterSpec) validateNetwork(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	podsPath := path.Child("network", "kubePodsCIDR")
+	servicePath := path.Child("network", "kubeServiceCIDR")
+	var pods, services []*net.IPNet
+	// the CIDRs allocated by the IPAM are checked against the hosts and each other by the allocation
+	if cfg.Network.KubePodsCIDR != "" || cfg.Network.IPAM.PodsRange == "" {
+		var podsErrs field.ErrorList
+		pods, podsErrs = parseCIDRs(cfg.Network.KubePodsCIDR, DefaultPodsCIDR, podsPath)
+		errs = append(errs, podsErrs...)
+	}
+	if cfg.Network.KubeServiceCIDR != "" || cfg.Network.IPAM.ServiceRange == "" {
+		var serviceErrs field.ErrorList
+		services, serviceErrs = parseCIDRs(cfg.Network.KubeServiceCIDR, DefaultServiceCIDR, servicePath)
+		errs = append(errs, serviceErrs...)
+	}
+
+	for _, pod := range pods {
+		for _, service := range services {
+			if cidrsOverlap(pod, service) {
+				errs = append(errs, field.Invalid(podsPath, pod.String(),
+					fmt.Sprintf("overlaps with the service CIDR %s, the pods and the services need separate ranges", service)))
+			}
+		}
+	}
+
+	errs = append(errs, validateIPFamilies(pods, podsPath)...)
+	errs = append(errs, validateIPFamilies(services, servicePath)...)
+	if len(pods) != 0 && len(services) != 0 && ipFamilies(pods) != ipFamilies(services) {
+		errs = append(errs, field.Invalid(servicePath, cidrsString(services),
+			fmt.Sprintf("must have the IP families of the pod CIDR in the same order, %s", ipFamilies(pods))))
+	}
+	if len(pods) == 2 && cfg.Kubernetes.Type == "kubernetes" {
+		if version, err := parseKubeVersion(cfg.Kubernetes.Version); err == nil && version.LessThan(versionutil.MustParseSemantic("v1.21.0")) {
+			errs = append(errs, field.Invalid(podsPath, cidrsString(pods), "the dual-stack needs kubernetes v1.21 or later"))
+		}
+	}
+
+	errs = append(errs, cfg.validateNodeIP(path.Child("network", "nodeIP"))...)
+	errs = append(errs, cfg.validateIPAM(path.Child("network", "ipam"))...)
+
+	for i, host := range cfg.Hosts {
+		// the node IPs of the kubelet must cover the IP families of the pods
+		hostFamilies := make(map[bool]bool)
+		for _, address := range hostInternalAddresses(host) {
+			if ip := net.ParseIP(address); ip != nil {
+				hostFamilies[ip.To4() != nil] = true
+			}
+		}
+		for _, pod := range pods {
+			if ipv4 := pod.IP.To4() != nil; len(hostFamilies) != 0 && !hostFamilies[ipv4] {
+				errs = append(errs, field.Invalid(path.Child("hosts").Index(i).Child("internalAddress"), host.InternalAddress,
+					fmt.Sprintf("needs an %s address for the pod CIDR %s", ipFamily(pod.IP), pod)))
+			}
+		}
+		for _, address := range hostInternalAddresses(host) {
+			ip := net.ParseIP(address)
+			if ip == nil {
+				continue
+			}
+			for _, cidr := range append(append([]*net.IPNet{}, pods...), services...) {
+				if cidr.Contains(ip) {
+					errs = append(errs, field.Invalid(path.Child("hosts").Index(i).Child("internalAddress"), address,
+						fmt.Sprintf("is in the pod or service CIDR %s, which must not overlap with the network of the hosts", cidr)))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+func (cfg *ClusterSpec) validateIPAM(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	ipam := cfg.Network.IPAM
+	pods, podsErrs := parseIPAMRange(ipam.PodsRange, ipam.PodsPrefix(), path.Child("podsRange"), path.Child("podsPrefixLength"))
+	services, serviceErrs := parseIPAMRange(ipam.ServiceRange, ipam.ServicePrefix(), path.Child("serviceRange"), path.Child("servicePrefixLength"))
+	errs = append(errs, podsErrs...)
+	errs = append(errs, serviceErrs...)
+	if pods != nil && services != nil && cidrsOverlap(pods, services) {
+		errs = append(errs, field.Invalid(path.Child("serviceRange"), ipam.ServiceRange,
+			fmt.Sprintf("overlaps with the podsRange %s, the pods and the services need separate ranges", ipam.PodsRange)))
+	}
+	return errs
+}
+
+// parseIPAMRange parses the range of the IPAM, which must be longer than the prefix length of the allocated CIDRs.
+func parseIPAMRange(value string, prefix int, path, prefixPath *field.Path) (*net.IPNet, field.ErrorList) {
+	if value == "" {
+		return nil, nil
+	}
+	_, cidr, err := net.ParseCIDR(value)
+	if err != nil {
+		return nil, field.ErrorList{field.Invalid(path, value, "must be a CIDR like 10.128.0.0/10")}
+	}
+	if ones, bits := cidr.Mask.Size(); prefix < ones || prefix > bits {
+		return cidr, field.ErrorList{field.Invalid(prefixPath, prefix, fmt.Sprintf("must be between %d and %d", ones, bits))}
+	}
+	return cidr, nil
+}
+
+func (cfg *ClusterSpec) validateNodeIP(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	nodeIP := cfg.Network.NodeIP
+	switch nodeIP.Policy {
+	case "", NodeIPPolicyExplicit, NodeIPPolicyFirstPrivate:
+	case NodeIPPolicyInterface:
+		if nodeIP.Interface == "" {
+			errs = append(errs, field.Required(path.Child("interface"), "the interface is required by the interface policy"))
+		} else if _, err := filepath.Match(nodeIP.Interface, ""); err != nil {
+			errs = append(errs, field.Invalid(path.Child("interface"), nodeIP.Interface, err.Error()))
+		}
+	case NodeIPPolicyCIDR:
+		if len(nodeIP.CIDRs) == 0 {
+			errs = append(errs, field.Required(path.Child("cidrs"), "the CIDRs are required by the cidr policy"))
+		}
+		for i, cidr := range nodeIP.CIDRs {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				errs = append(errs, field.Invalid(path.Child("cidrs").Index(i), cidr, "must be a CIDR like 172.16.0.0/16"))
+			}
+		}
+	default:
+		errs = append(errs, field.NotSupported(path.Child("policy"), nodeIP.Policy,
+			[]string{NodeIPPolicyExplicit, NodeIPPolicyInterface, NodeIPPolicyCIDR, NodeIPPolicyFirstPrivate}))
+	}
+	return errs
+}
+
+func (cfg *ClusterSpec) validateGPU(path *field.Path) field.ErrorList {
+	if !cfg.Kubernetes.EnableGPU() {
+		return nil
+	}
+	var errs field.ErrorList
+	if mode := cfg.Kubernetes.GPUMode(); mode != GPUModeToolkit && mode != GPUModeOperator {
+		errs = append(errs, field.NotSupported(path.Child("gpu", "mode"), mode, []string{GPUModeToolkit, GPUModeOperator}))
+	}
+	if manager := cfg.Kubernetes.ContainerManager; manager != Containerd && manager != Crio {
+		if manager == "" {
+			manager = Docker
+		}
+		errs = append(errs, field.Invalid(path.Child("containerManager"), manager,
+			"the GPU nodes need containerd or crio, which run the pods of the runtime class nvidia"))
+	}
+	return errs
+}
+
+// kubeadmPatchTargets and kubeadmPatchTypes are the targets and the types of the patches supported by kubeadm.
+var (
+	kubeadmPatchTargets = []string{"kube-apiserver", "kube-controller-manager", "kube-scheduler", "etcd", "kubeletconfiguration"}
+	kubeadmPatchTypes   = []string{"strategic", "merge", "json"}
+)
+
+func (cfg *ClusterSpec) validateKubeadm(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if raw := cfg.Kubernetes.ClusterConfiguration.Raw; len(raw) != 0 {
+		overlay := make(map[string]interface{})
+		if err := yaml.Unmarshal(raw, &overlay); err != nil {
+			errs = append(errs, field.Invalid(path.Child("clusterConfiguration"), string(raw), fmt.Sprintf("must be a YAML object: %v", err)))
+		}
+		for _, key := range []string{"apiVersion", "kind"} {
+			if _, ok := overlay[key]; ok {
+				errs = append(errs, field.Forbidden(path.Child("clusterConfiguration", key), "is set by KubeKey from the kubernetes version"))
+			}
+		}
+	}
+
+	if len(cfg.Kubernetes.KubeadmPatches) == 0 {
+		return errs
+	}
+	if cfg.Kubernetes.Type != "" && cfg.Kubernetes.Type != "kubernetes" {
+		errs = append(errs, field.Invalid(path.Child("kubeadmPatches"), cfg.Kubernetes.Type, "the patches are only applied by kubeadm of the kubernetes type"))
+	}
+	version, err := parseKubeVersion(cfg.Kubernetes.Version)
+	if err == nil && version.LessThan(versionutil.MustParseSemantic("v1.22.0")) {
+		errs = append(errs, field.Invalid(path.Child("kubeadmPatches"), cfg.Kubernetes.Version, "the patches need kubernetes v1.22 or later"))
+	}
+	for i, patch := range cfg.Kubernetes.KubeadmPatches {
+		p := path.Child("kubeadmPatches").Index(i)
+		if !containsString(kubeadmPatchTargets, patch.Target) {
+			errs = append(errs, field.NotSupported(p.Child("target"), patch.Target, kubeadmPatchTargets))
+		} else if patch.Target == "kubeletconfiguration" && err == nil && version.LessThan(versionutil.MustParseSemantic("v1.25.0")) {
+			errs = append(errs, field.Invalid(p.Child("target"), patch.Target, "the patches of the kubelet configuration need kubernetes v1.25 or later"))
+		}
+		if patch.Type != "" && !containsString(kubeadmPatchTypes, patch.Type) {
+			errs = append(errs, field.NotSupported(p.Child("type"), patch.Type, kubeadmPatchTypes))
+		}
+		var content interface{}
+		if strings.TrimSpace(patch.Patch) == "" {
+			errs = append(errs, field.Required(p.Child("patch"), ""))
+		} else if err := yaml.Unmarshal([]byte(patch.Patch), &content); err != nil {
+			errs = append(errs, field.Invalid(p.Child("patch"), patch.Patch, fmt.Sprintf("must be YAML or JSON: %v", err)))
+		}
+	}
+	return errs
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (cfg *ClusterSpec) validateStorage(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	switch cfg.Storage.Provider {
+	case "", StorageOpenEBS, StorageLocalPath, StorageLonghorn:
+	case StorageNFS:
+		if cfg.Storage.NFS.Server == "" {
+			errs = append(errs, field.Required(path.Child("nfs", "server"), "the address of the NFS server is required by the nfs provider"))
+		}
+		if cfg.Storage.NFS.Path == "" {
+			errs = append(errs, field.Required(path.Child("nfs", "path"), "the exported path of the NFS server is required by the nfs provider"))
+		}
+	default:
+		errs = append(errs, field.NotSupported(path.Child("provider"), cfg.Storage.Provider,
+			[]string{StorageOpenEBS, StorageLocalPath, StorageLonghorn, StorageNFS}))
+	}
+	return errs
+}
+
+func (cfg *ClusterSpec) validateSystem(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if tz := cfg.System.Timezone; tz != "" && !timezonePattern.MatchString(tz) {
+		errs = append(errs, field.Invalid(path.Child("timezone"), tz, "must be a name of the tz database, e.g. Asia/Shanghai or UTC"))
+	}
+	if locale := cfg.System.Locale; locale != "" && !localePattern.MatchString(locale) {
+		errs = append(errs, field.Invalid(path.Child("locale"), locale, "must be a locale name, e.g. en_US.UTF-8"))
+	}
+	proxyPath := path.Child("proxy")
+	errs = append(errs, validateProxyURL(proxyPath.Child("httpProxy"), cfg.System.Proxy.HTTPProxy)...)
+	errs = append(errs, validateProxyURL(proxyPath.Child("httpsProxy"), cfg.System.Proxy.HTTPSProxy)...)
+	for i, entry := range cfg.System.Proxy.NoProxy {
+		if entry == "" || strings.ContainsAny(entry, unsafeProxyChars+",") {
+			errs = append(errs, field.Invalid(proxyPath.Child("noProxy").Index(i), entry, "must be a host, a domain or a CIDR"))
+		}
+	}
+	errs = append(errs, validateCustomScripts(path.Child("preInstall"), cfg.System.PreInstall)...)
+	errs = append(errs, validateCustomScripts(path.Child("postInstall"), cfg.System.PostInstall)...)
+	errs = append(errs, validateHardening(path.Child("hardening"), cfg.System.Hardening)...)
+	errs = append(errs, validateAutomationUser(path.Child("automationUser"), cfg.System.AutomationUser)...)
+	errs = append(errs, validateTuning(path.Child("tuning"), cfg.System.Tuning)...)
+	if mode := cfg.System.SELinuxMode(); !containsString(SELinuxModes, mode) {
+		errs = append(errs, field.NotSupported(path.Child("selinux"), mode, SELinuxModes))
+	}
+	if mode := cfg.System.AppArmor; mode != "" && !containsString(AppArmorModes, mode) {
+		errs = append(errs, field.NotSupported(path.Child("apparmor"), mode, AppArmorModes))
+	}
+	return errs
+}
+
+func validateDeadlines(path *field.Path, deadlines Deadlines) field.ErrorList {
+	var errs field.ErrorList
+	if deadlines.Run != "" {
+		if d, err := time.ParseDuration(deadlines.Run); err != nil || d <= 0 {
+			errs = append(errs, field.Invalid(path.Child("run"), deadlines.Run, "must be a positive duration, e.g. 2h"))
+		}
+	}
+	for phase, value := range deadlines.Phases {
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			errs = append(errs, field.Invalid(path.Child("phases").Key(phase), value, "must be a positive duration, e.g. 20m"))
+		}
+	}
+	return errs
+}
+
+// sonobuoyVersionPattern matches the version of a release of sonobuoy, e.g. v0.57.1.
+var sonobuoyVersionPattern = regexp.MustCompile(`^v[0-9]+\.[0-9]+\.[0-9]+$`)
+
+func validateVerification(path *field.Path, verification Verification) field.ErrorList {
+	var errs field.ErrorList
+	for i, test := range verification.SmokeTests {
+		if !containsString(SmokeTests, test) {
+			errs = append(errs, field.NotSupported(path.Child("smokeTests").Index(i), test, SmokeTests))
+		}
+	}
+	if verification.Timeout != "" {
+		if d, err := time.ParseDuration(verification.Timeout); err != nil || d <= 0 {
+			errs = append(errs, field.Invalid(path.Child("timeout"), verification.Timeout, "must be a positive duration, e.g. 10m"))
+		}
+	}
+	conformance := verification.Conformance
+	conformancePath := path.Child("conformance")
+	if !containsString(ConformanceModes, conformance.ModeName()) {
+		errs = append(errs, field.NotSupported(conformancePath.Child("mode"), conformance.Mode, ConformanceModes))
+	}
+	if conformance.Version != "" && !sonobuoyVersionPattern.MatchString(conformance.Version) {
+		errs = append(errs, field.Invalid(conformancePath.Child("version"), conformance.Version, "must be a version of sonobuoy, e.g. v0.57.1"))
+	}
+	if conformance.Timeout != "" {
+		if d, err := time.ParseDuration(conformance.Timeout); err != nil || d <= 0 {
+			errs = append(errs, field.Invalid(conformancePath.Child("timeout"), conformance.Timeout, "must be a positive duration, e.g. 1h"))
+		}
+	}
+	return errs
+}
+
+func validateBenchmark(path *field.Path, benchmark Benchmark) field.ErrorList {
+	var errs field.ErrorList
+	for i, test := range benchmark.Tests {
+		if !containsString(Benchmarks, test) {
+			errs = append(errs, field.NotSupported(path.Child("tests").Index(i), test, Benchmarks))
+		}
+	}
+	for _, threshold := range [][2]string{{"maxFsyncLatency", benchmark.MaxFsyncLatency}, {"maxRTT", benchmark.MaxRTT}} {
+		if threshold[1] == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(threshold[1]); err != nil || d <= 0 {
+			errs = append(errs, field.Invalid(path.Child(threshold[0]), threshold[1], "must be a positive duration, e.g. 10ms"))
+		}
+	}
+	if benchmark.MinBandwidthMbps < 0 {
+		errs = append(errs, field.Invalid(path.Child("minBandwidthMbps"), benchmark.MinBandwidthMbps, "must not be negative"))
+	}
+	return errs
+}
+
+func validateRBAC(path *field.Path, rbac RBAC) field.ErrorList {
+	var errs field.ErrorList
+	for _, groups := range []struct {
+		name   string
+		groups []string
+	}{{"adminGroups", rbac.AdminGroups}, {"readOnlyGroups", rbac.ReadOnlyGroups}} {
+		for i, group := range groups.groups {
+			if strings.TrimSpace(group) == "" {
+				errs = append(errs, field.Required(path.Child(groups.name).Index(i), "the group must not be empty"))
+			}
+		}
+	}
+	return errs
+}
+
+func validatePullSecret(path *field.Path, secret PullSecret) field.ErrorList {
+	var errs field.ErrorList
+	if secret.Name != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(secret.Name) {
+			errs = append(errs, field.Invalid(path.Child("name"), secret.Name, msg))
+		}
+	}
+	for i, ns := range secret.Namespaces {
+		for _, msg := range validation.IsDNS1123Label(ns) {
+			errs = append(errs, field.Invalid(path.Child("namespaces").Index(i), ns, msg))
+		}
+	}
+	return errs
+}
+
+func validateMaintenanceWindow(path *field.Path, window MaintenanceWindow) field.ErrorList {
+	var errs field.ErrorList
+	for i, spec := range window.Windows {
+		if _, err := connector.ParseWindow(spec); err != nil {
+			errs = append(errs, field.Invalid(path.Child("windows").Index(i), spec, err.Error()))
+		}
+	}
+	if window.Timezone != "" {
+		if _, err := time.LoadLocation(window.Timezone); err != nil {
+			errs = append(errs, field.Invalid(path.Child("timezone"), window.Timezone, "must be an IANA timezone, e.g. Europe/Berlin"))
+		}
+	}
+	if len(window.Windows) == 0 && (window.Timezone != "" || len(window.Phases) > 0) {
+		errs = append(errs, field.Required(path.Child("windows"), "the timezone and the phases need windows"))
+	}
+	return errs
+}
+
+func validateHook(path *field.Path, hook Hook) field.ErrorList {
+	var errs field.ErrorList
+	sinks := 0
+	for _, sink := range []struct {
+		name, url string
+	}{{"webhook", hook.Webhook}, {"slack", hook.Slack}} {
+		if sink.url == "" {
+			continue
+		}
+		sinks++
+		if u, err := url.Parse(sink.url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, field.Invalid(path.Child(sink.name), "<redacted>", "must be an http or https URL"))
+		}
+	}
+	if hook.Script != "" {
+		sinks++
+	}
+	if sinks != 1 {
+		errs = append(errs, field.Invalid(path, "", "must set exactly one of webhook, slack and script"))
+	}
+	if len(hook.Headers) > 0 && hook.Webhook == "" {
+		errs = append(errs, field.Forbidden(path.Child("headers"), "headers can only be set with webhook"))
+	}
+	for i, e := range hook.Events {
+		if !containsString(event.Types, e) {
+			errs = append(errs, field.NotSupported(path.Child("events").Index(i), e, event.Types))
+		}
+	}
+	if hook.Timeout != "" {
+		if d, err := time.ParseDuration(hook.Timeout); err != nil || d <= 0 {
+			errs = append(errs, field.Invalid(path.Child("timeout"), hook.Timeout, "must be a positive duration, e.g. 30s"))
+		}
+	}
+	return errs
+}
+
+func (cfg *ClusterSpec) validateTLS(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	kubeType := cfg.Kubernetes.Type
+	if parts := strings.Split(cfg.Kubernetes.Version, "-"); kubeType == "" && len(parts) > 1 {
+		kubeType = parts[1]
+	}
+	for _, server := range []struct {
+		name   string
+		policy TLSPolicy
+	}{{"apiserver", cfg.TLS.Apiserver}, {"etcd", cfg.TLS.Etcd}, {"kubelet", cfg.TLS.Kubelet}} {
+		if !server.policy.IsSet() {
+			continue
+		}
+		errs = append(errs, validateTLSPolicy(path.Child(server.name), server.policy)...)
+		if server.name != "etcd" && kubeType != "" && kubeType != "kubernetes" {
+			errs = append(errs, field.Forbidden(path.Child(server.name),
+				fmt.Sprintf("is only applied to the kubernetes type, set the args of %s instead", kubeType)))
+		}
+	}
+	if cfg.TLS.Etcd.IsSet() && cfg.Etcd.Type == External {
+		errs = append(errs, field.Forbidden(path.Child("etcd"), "can't be applied to the external etcd"))
+	}
+	return errs
+}
+
+// tlsCipherSuites are the secure cipher suites of TLS 1.2 by their names in Go, which the servers of the cluster
+// accept in the flags.
+func tlsCipherSuites() map[string]bool {
+	suites := make(map[string]bool)
+	for _, suite := range tls.CipherSuites() {
+		for _, v := range suite.SupportedVersions {
+			if v == tls.VersionTLS12 {
+				suites[suite.Name] = true
+			}
+		}
+	}
+	return suites
+}
+
+func validateTLSPolicy(path *field.Path, policy TLSPolicy) field.ErrorList {
+	var errs field.ErrorList
+	if policy.MinVersion != "" && !containsString(TLSVersions, policy.MinVersion) {
+		errs = append(errs, field.NotSupported(path.Child("minVersion"), policy.MinVersion, TLSVersions))
+	}
+	if len(policy.CipherSuites) > 0 && policy.MinVersion == TLSVersion13 {
+		errs = append(errs, field.Forbidden(path.Child("cipherSuites"), "the cipher suites of TLS 1.3 aren't configurable"))
+	}
+	supported := tlsCipherSuites()
+	seen := make(map[string]bool)
+	for i, name := range policy.CipherSuites {
+		switch {
+		case seen[name]:
+			errs = append(errs, field.Duplicate(path.Child("cipherSuites").Index(i), name))
+		case !supported[name]:
+			errs = append(errs, field.Invalid(path.Child("cipherSuites").Index(i), name,
+				"must be a secure cipher suite of TLS 1.2 named as in Go, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"))
+		}
+		seen[name] = true
+	}
+	return errs
+}
+
+func validateHardening(path *field.Path, hardening Hardening) field.ErrorList {
+	var errs field.ErrorList
+	for i, control := range hardening.Controls {
+		if !containsString(HardeningControls, control) {
+			errs = append(errs, field.NotSupported(path.Child("controls").Index(i), control, HardeningControls))
+		}
+	}
+	if hardening.PasswordMaxDays < 0 {
+		errs = append(errs, field.Invalid(path.Child("passwordMaxDays"), hardening.PasswordMaxDays, "must not be negative"))
+	}
+	if n := hardening.PasswordMinLength; n < 0 || (n > 0 && n < 8) {
+		errs = append(errs, field.Invalid(path.Child("passwordMinLength"), n, "must be at least 8"))
+	}
+	return errs
+}
+
+func validateAutomationUser(path *field.Path, user AutomationUser) field.ErrorList {
+	var errs field.ErrorList
+	if !user.Enabled() {
+		if len(user.AuthorizedKeys) > 0 || len(user.AuthorizedKeyFiles) > 0 || user.DisablePasswordAuth {
+			errs = append(errs, field.Required(path.Child("name"), "the name of the user is required"))
+		}
+		return errs
+	}
+	if !userNamePattern.MatchString(user.Name) || user.Name == "root" {
+		errs = append(errs, field.Invalid(path.Child("name"), user.Name, "must be a user name other than root, e.g. kubekey"))
+	}
+	if user.Group != "" && !userNamePattern.MatchString(user.Group) {
+		errs = append(errs, field.Invalid(path.Child("group"), user.Group, "must be a group name, e.g. kubekey"))
+	}
+	if user.Shell != "" && !strings.HasPrefix(user.Shell, "/") {
+		errs = append(errs, field.Invalid(path.Child("shell"), user.Shell, "must be an absolute path"))
+	}
+	for i, key := range user.AuthorizedKeys {
+		if len(strings.Fields(key)) < 2 || strings.ContainsAny(key, "\n\r") {
+			errs = append(errs, field.Invalid(path.Child("authorizedKeys").Index(i), key, "must be a public key of a line, e.g. ssh-ed25519 AAAA..."))
+		}
+	}
+	// the hosts would only be logged in with the keys
+	if user.DisablePasswordAuth && len(user.AuthorizedKeys) == 0 && len(user.AuthorizedKeyFiles) == 0 {
+		errs = append(errs, field.Required(path.Child("authorizedKeys"), "disablePasswordAuth requires a key of the user"))
+	}
+	return errs
+}
+
+// sysctlPattern matches the key of a kernel parameter, e.g. net.core.somaxconn.
+var sysctlPattern = regexp.MustCompile(`^[a-z0-9_]+(\.[A-Za-z0-9_-]+)+$`)
+
+func validateTuning(path *field.Path, tuning Tuning) field.ErrorList {
+	var errs field.ErrorList
+	profile := tuning.ProfileName()
+	if !containsString(TuningProfiles, profile) {
+		errs = append(errs, field.NotSupported(path.Child("profile"), profile, TuningProfiles))
+	}
+	if profile == TuningCustom && tuning.File == "" {
+		errs = append(errs, field.Required(path.Child("file"), "the custom profile requires a file"))
+	}
+	if profile != TuningCustom && tuning.File != "" {
+		errs = append(errs, field.Invalid(path.Child("file"), tuning.File, "is only used by the custom profile"))
+	}
+	keys := make([]string, 0, len(tuning.Sysctls))
+	for key := range tuning.Sysctls {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := tuning.Sysctls[key]
+		if !sysctlPattern.MatchString(key) {
+			errs = append(errs, field.Invalid(path.Child("sysctls").Key(key), key, "must be the key of a kernel parameter, e.g. net.core.somaxconn"))
+		} else if value == "" || strings.ContainsAny(value, "'\n\"`$") {
+			errs = append(errs, field.Invalid(path.Child("sysctls").Key(key), value, "must be a non-empty value without quotes"))
+		}
+	}
+	return errs
+}
+
+// modulePattern matches the name of an external module, which is a file in the modulesPath.
+var modulePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+func validateCustomScripts(path *field.Path, scripts []CustomScripts) field.ErrorList {
+	var errs field.ErrorList
+	for i, script := range scripts {
+		scriptPath := path.Index(i)
+		if script.Assert != nil || script.WaitFor != nil {
+			errs = append(errs, validateCheckScript(scriptPath, script)...)
+			continue
+		}
+		if script.TemplateFile != nil {
+			errs = append(errs, validateTemplateFile(scriptPath, script)...)
+			continue
+		}
+		switch {
+		case script.Module == "":
+			if strings.TrimSpace(script.Bash) == "" {
+				errs = append(errs, field.Required(scriptPath.Child("bash"), "the bash, the module or the templateFile of the script is required"))
+			}
+			if len(script.Args.Raw) != 0 {
+				errs = append(errs, field.Forbidden(scriptPath.Child("args"), "the args are only passed to a module"))
+			}
+			continue
+		case script.Bash != "":
+			errs = append(errs, field.Forbidden(scriptPath.Child("bash"), "the bash and the module of a script are mutually exclusive"))
+		case !modulePattern.MatchString(script.Module):
+			errs = append(errs, field.Invalid(scriptPath.Child("module"), script.Module, "must be the file name of a module in the modulesPath"))
+		}
+		if raw := script.Args.Raw; len(raw) != 0 {
+			args := make(map[string]interface{})
+			if err := yaml.Unmarshal(raw, &args); err != nil {
+				errs = append(errs, field.Invalid(scriptPath.Child("args"), string(raw), "must be an object"))
+			}
+		}
+	}
+	return errs
+}
+
+func validateTemplateFile(path *field.Path, script CustomScripts) field.ErrorList {
+	var errs field.ErrorList
+	if script.Bash != "" || script.Module != "" {
+		errs = append(errs, field.Forbidden(path.Child("templateFile"), "the bash, the module and the templateFile of a script are mutually exclusive"))
+	}
+	if len(script.Args.Raw) != 0 {
+		errs = append(errs, field.Forbidden(path.Child("args"), "the args are only passed to a module"))
+	}
+	file := script.TemplateFile
+	if file.Src == "" {
+		errs = append(errs, field.Required(path.Child("templateFile", "src"), "the local path of the template is required"))
+	}
+	if !strings.HasPrefix(file.Dest, "/") || strings.ContainsAny(file.Dest, " \t\n'\"`$;&|") {
+		errs = append(errs, field.Invalid(path.Child("templateFile", "dest"), file.Dest, "must be an absolute path without spaces or shell characters"))
+	}
+	return errs
+}
+
+// validateCheckScript validates the script of the assert or the waitFor, which is exclusive with the other kinds.
+func validateCheckScript(path *field.Path, script CustomScripts) field.ErrorList {
+	var errs field.ErrorList
+	kind := "assert"
+	if script.Assert == nil {
+		kind = "waitFor"
+	}
+	if script.Bash != "" || script.Module != "" || script.TemplateFile != nil || (script.Assert != nil && script.WaitFor != nil) {
+		errs = append(errs, field.Forbidden(path.Child(kind), "the bash, the module, the templateFile, the assert and the waitFor of a script are mutually exclusive"))
+	}
+	if len(script.Args.Raw) != 0 {
+		errs = append(errs, field.Forbidden(path.Child("args"), "the args are only passed to a module"))
+	}
+	if script.Assert != nil {
+		errs = append(errs, validateAssert(path.Child("assert"), script.Assert)...)
+	}
+	if script.WaitFor != nil {
+		errs = append(errs, validateWaitFor(path.Child("waitFor"), script.WaitFor)...)
+	}
+	return errs
+}
+
+func validateAssert(path *field.Path, assert *Assert) field.ErrorList {
+	var errs field.ErrorList
+	if len(assert.That) == 0 {
+		errs = append(errs, field.Required(path.Child("that"), "at least one expression is required"))
+	}
+	for i, expr := range assert.That {
+		if strings.TrimSpace(expr) == "" {
+			errs = append(errs, field.Required(path.Child("that").Index(i), "the expression is required"))
+		}
+	}
+	return errs
+}
+
+func validateWaitFor(path *field.Path, wait *WaitFor) field.ErrorList {
+	var errs field.ErrorList
+	var conditions []string
+	for name, set := range map[string]bool{
+		"port":      wait.Port != 0,
+		"path":      wait.Path != "",
+		"url":       wait.URL != "",
+		"nodeReady": wait.NodeReady,
+		"command":   wait.Command != "",
+	} {
+		if set {
+			conditions = append(conditions, name)
+		}
+	}
+	sort.Strings(conditions)
+	if len(conditions) != 1 {
+		errs = append(errs, field.Invalid(path, strings.Join(conditions, ","), "exactly one of the port, the path, the url, the nodeReady and the command is required"))
+	}
+
+	if wait.Port < 0 || wait.Port > 65535 {
+		errs = append(errs, field.Invalid(path.Child("port"), wait.Port, "must be a port between 1 and 65535"))
+	}
+	if wait.Host != "" {
+		if wait.Port == 0 {
+			errs = append(errs, field.Forbidden(path.Child("host"), "the host is only the address of the port"))
+		} else if net.ParseIP(wait.Host) == nil && len(validation.IsDNS1123Subdomain(wait.Host)) != 0 {
+			errs = append(errs, field.Invalid(path.Child("host"), wait.Host, "must be an IP address or a DNS name"))
+		}
+	}
+	if wait.Path != "" && (!strings.HasPrefix(wait.Path, "/") || strings.ContainsAny(wait.Path, " \t\n'\"`$;&|")) {
+		errs = append(errs, field.Invalid(path.Child("path"), wait.Path, "must be an absolute path without spaces or shell characters"))
+	}
+	if wait.URL != "" {
+		u, err := url.Parse(wait.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.ContainsAny(wait.URL, " \t\n'\"`$;|") {
+			errs = append(errs, field.Invalid(path.Child("url"), wait.URL, "must be an http or https URL without shell characters"))
+		}
+	}
+	if wait.That != "" && wait.Command == "" {
+		errs = append(errs, field.Forbidden(path.Child("that"), "the expression is only evaluated with the output of the command"))
+	}
+	for name, value := range map[string]string{"timeout": wait.Timeout, "interval": wait.Interval} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			errs = append(errs, field.Invalid(path.Child(name), value, "must be a positive duration, e.g. 10s"))
+		}
+	}
+	return errs
+}
+
+func validateProxyURL(path *field.Path, proxy string) field.ErrorList {
+	if proxy == "" {
+		return nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.ContainsAny(proxy, unsafeProxyChars) {
+		return field.ErrorList{field.Invalid(path, proxy, "must be an http or https URL, e.g. http://proxy.example.com:3128")}
+	}
+	return nil
+}
+
+func (cfg *ClusterSpec) validateAddons(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	names := make(map[string]struct{}, len(cfg.Addons))
+	for i, addon := range cfg.Addons {
+		p := path.Index(i)
+		if addon.Name == "" {
+			errs = append(errs, field.Required(p.Child("name"), "the name of the addon is required"))
+		} else if _, ok := names[addon.Name]; ok {
+			errs = append(errs, field.Duplicate(p.Child("name"), addon.Name))
+		}
+		names[addon.Name] = struct{}{}
+		// the resources of the manifest addons are labeled with the name
+		if addon.Name != "" && (len(addon.Sources.Yaml.Path) > 0 || addon.Sources.Yaml.Kustomize != "") {
+			for _, msg := range validation.IsValidLabelValue(addon.Name) {
+				errs = append(errs, field.Invalid(p.Child("name"), addon.Name, msg))
+			}
+		}
+		if addon.Retries < 0 {
+			errs = append(errs, field.Invalid(p.Child("retries"), addon.Retries, "must be greater than or equal to 0"))
+		}
+		if timeout := addon.Sources.Chart.Timeout; timeout != "" {
+			if d, err := time.ParseDuration(timeout); err != nil || d <= 0 {
+				errs = append(errs, field.Invalid(p.Child("sources", "chart", "timeout"), timeout, "must be a positive duration, e.g. 10m"))
+			}
+		}
+	}
+	return errs
+}
+
+func parseCIDRs(value, defaultValue string, path *field.Path) ([]*net.IPNet, field.ErrorList) {
+	if value == "" {
+		value = defaultValue
+	}
+	var (
+		cidrs []*net.IPNet
+		errs  field.ErrorList
+	)
+	for _, s := range strings.Split(value, ",") {
+		_, cidr, err := net.ParseCIDR(strings.TrimSpace(s))
+		if err != nil {
+			errs = append(errs, field.Invalid(path, s, "must be a CIDR like 10.233.64.0/18, or an IPv4 and an IPv6 CIDR separated by a comma"))
+			continue
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	return cidrs, errs
+}
+
+// validateIPFamilies checks the CIDRs are single-stack, or a dual-stack pair of an IPv4 and an IPv6 CIDR.
+func validateIPFamilies(cidrs []*net.IPNet, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	switch {
+	case len(cidrs) > 2:
+		errs = append(errs, field.Invalid(path, cidrsString(cidrs), "must be a single CIDR, or an IPv4 and an IPv6 CIDR separated by a comma"))
+	case len(cidrs) == 2 && ipFamily(cidrs[0].IP) == ipFamily(cidrs[1].IP):
+		errs = append(errs, field.Invalid(path, cidrsString(cidrs), "the dual-stack needs an IPv4 and an IPv6 CIDR"))
+	}
+	return errs
+}
+
+func ipFamily(ip net.IP) string {
+	if ip.To4() != nil {
+		return "IPv4"
+	}
+	return "IPv6"
+}
+
+func ipFamilies(cidrs []*net.IPNet) string {
+	families := make([]string, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		families = append(families, ipFamily(cidr.IP))
+	}
+	return strings.Join(families, ",")
+}
+
+func cidrsString(cidrs []*net.IPNet) string {
+	s := make([]string, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		s = append(s, cidr.String())
+	}
+	return strings.Join(s, ",")
+}
+
+func cidrsOverlap(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
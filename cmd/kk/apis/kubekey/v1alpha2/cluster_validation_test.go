@@ -0,0 +1,728 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func validClusterSpec() *ClusterSpec {
+	return &ClusterSpec{
+		Hosts: []HostCfg{
+			{Name: "node1", Address: "192.168.0.1", InternalAddress: "192.168.0.1"},
+			{Name: "node2", Address: "192.168.0.2", InternalAddress: "192.168.0.2"},
+		},
+		RoleGroups: map[string][]string{
+			Etcd:         {"node1"},
+			ControlPlane: {"node1"},
+			Worker:       {"node[1:2]"},
+		},
+		Kubernetes: Kubernetes{Version: "v1.23.15"},
+	}
+}
+
+func TestClusterSpecValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *ClusterSpec)
+		fields []string
+	}{
+		{
+			name:   "valid",
+			modify: func(cfg *ClusterSpec) {},
+		},
+		{
+			name: "duplicate host addresses",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Hosts[1].Address = "192.168.0.1"
+				cfg.Hosts[1].InternalAddress = "192.168.0.1"
+			},
+			fields: []string{"spec.hosts[1].address", "spec.hosts[1].internalAddress"},
+		},
+		{
+			name: "host aliases in the role groups",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Hosts[0].Aliases = []string{"node1.mgmt.example.com"}
+				cfg.RoleGroups[Etcd] = []string{"node1.mgmt.example.com"}
+			},
+		},
+		{
+			name: "invalid and duplicate host aliases",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Hosts[0].Aliases = []string{"Node_1", "node2"}
+				cfg.Hosts[1].Aliases = []string{"node1"}
+			},
+			fields: []string{"spec.hosts[0].aliases[0]", "spec.hosts[1].name", "spec.hosts[1].aliases[0]"},
+		},
+		{
+			name: "transfer rate limit and compression",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Hosts[0].TransferRateLimit = "10Mi"
+				cfg.Hosts[0].TransferCompression = "zstd"
+				cfg.Hosts[1].TransferRateLimit = "-1"
+				cfg.Hosts[1].TransferCompression = "xz"
+			},
+			fields: []string{"spec.hosts[1].transferRateLimit", "spec.hosts[1].transferCompression"},
+		},
+		{
+			name: "container connectors",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Hosts[0].Connector = "docker"
+				cfg.Hosts[1].Connector = "lxc"
+			},
+			fields: []string{"spec.hosts[1].connector"},
+		},
+		{
+			name: "agent connector",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Hosts[0].Connector = "agent"
+				cfg.Hosts[0].AgentPort = 9443
+				cfg.Hosts[1].Connector = "agent"
+				cfg.Hosts[1].AgentPort = 70000
+			},
+			fields: []string{"spec.hosts[1].agentPort"},
+		},
+		{
+			name: "shells and become",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Hosts[0].Shell = "ash"
+				cfg.Hosts[0].Become = "doas"
+				cfg.Hosts[1].Shell = "powershell"
+				cfg.Hosts[1].Become = "sudo"
+			},
+			fields: []string{"spec.hosts[1].become"},
+		},
+		{
+			name: "host env",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Hosts[0].Env = map[string]string{"HTTPS_PROXY": "http://proxy:3128", "1NO_PROXY": "localhost"}
+			},
+			fields: []string{"spec.hosts[0].env[1NO_PROXY]"},
+		},
+		{
+			name: "ipam ranges",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Network.IPAM = IPAMConfig{PodsRange: "10.128.0.0/10", ServiceRange: "10.96.0.0/12", ServicePrefixLength: 20}
+			},
+		},
+		{
+			name: "invalid and overlapping ipam ranges",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Network.IPAM = IPAMConfig{PodsRange: "10.128.0.0/10", PodsPrefixLength: 8, ServiceRange: "10.160.0.0/12"}
+			},
+			fields: []string{"spec.network.ipam.podsPrefixLength", "spec.network.ipam.serviceRange"},
+		},
+		{
+			name: "deadlines",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Deadlines = Deadlines{Run: "2h", Phases: map[string]string{"etcd": "20m", "kubernetes": "40m"}}
+			},
+		},
+		{
+			name: "invalid deadlines",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Deadlines = Deadlines{Run: "2 hours", Phases: map[string]string{"etcd": "-20m"}}
+			},
+			fields: []string{"spec.deadlines.run", "spec.deadlines.phases[etcd]"},
+		},
+		{
+			name: "maintenance window and lease lock",
+			modify: func(cfg *ClusterSpec) {
+				cfg.MaintenanceWindow = MaintenanceWindow{Windows: []string{"Sat 01:00-05:00", "Mon-Fri 22:00-02:00"}, Timezone: "Europe/Berlin"}
+				cfg.Lock = Lock{Lease: true, LeaseDuration: "15m"}
+			},
+		},
+		{
+			name: "invalid maintenance window and lease duration",
+			modify: func(cfg *ClusterSpec) {
+				cfg.MaintenanceWindow = MaintenanceWindow{Windows: []string{"Caturday 01:00-05:00", "25:00-26:00"}, Timezone: "Mars/Olympus"}
+				cfg.Lock = Lock{LeaseDuration: "10s"}
+			},
+			fields: []string{"spec.maintenanceWindow.windows[0]", "spec.maintenanceWindow.windows[1]",
+				"spec.maintenanceWindow.timezone", "spec.lock.leaseDuration"},
+		},
+		{
+			name: "hooks",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Hooks = []Hook{
+					{Events: []string{"pipeline.failure"}, Slack: "https://hooks.slack.com/services/T0/B0/x"},
+					{Webhook: "https://cmdb.example.com/kubekey", Headers: map[string]string{"Authorization": "Bearer x"}, Timeout: "30s"},
+					{Events: []string{"phase.complete"}, Script: "logger -t kubekey \"$KUBEKEY_PHASE\""},
+				}
+			},
+		},
+		{
+			name: "invalid hooks",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Hooks = []Hook{
+					{Events: []string{"pipeline.end"}, Webhook: "cmdb.example.com", Timeout: "soon"},
+					{Slack: "https://hooks.slack.com/services/T0/B0/x", Script: "true", Headers: map[string]string{"X": "y"}},
+				}
+			},
+			fields: []string{"spec.hooks[0].webhook", "spec.hooks[0].events[0]", "spec.hooks[0].timeout", "spec.hooks[1]", "spec.hooks[1].headers"},
+		},
+		{
+			name: "negative task timeout",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Hosts[0].TaskTimeout = 600
+				cfg.Hosts[1].TaskTimeout = -1
+			},
+			fields: []string{"spec.hosts[1].taskTimeout"},
+		},
+		{
+			name: "hosts behind a NAT",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Hosts[0].Address = "1.2.3.4"
+				cfg.Hosts[0].Port = 2201
+				cfg.Hosts[1].Address = "1.2.3.4"
+				cfg.Hosts[1].Port = 2202
+			},
+		},
+		{
+			name: "IPv6 and link-local addresses",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Hosts[0].Address = "[2001:db8::1]"
+				cfg.Hosts[0].InternalAddress = ""
+				cfg.Hosts[1].Address = "fe80::2%eth0"
+				cfg.Hosts[1].InternalAddress = "2001:db8::2"
+				cfg.Network.KubePodsCIDR = "fd00:10:233::/56"
+				cfg.Network.KubeServiceCIDR = "fd00:10:96::/108"
+			},
+		},
+		{
+			name: "dual-stack",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Kubernetes.Type = "kubernetes"
+				cfg.Kubernetes.Version = "v1.20.15"
+				cfg.Hosts[0].InternalAddress = "192.168.0.1,2001:db8::1"
+				cfg.Hosts[1].InternalAddress = "2001:db8::2,192.168.0.2"
+				cfg.Network.KubePodsCIDR = "10.233.64.0/18,fd00:10:233::/56"
+				cfg.Network.KubeServiceCIDR = "fd00:10:96::/108,10.233.0.0/18"
+			},
+			fields: []string{"spec.hosts[1].internalAddress", "spec.network.kubeServiceCIDR", "spec.network.kubePodsCIDR"},
+		},
+		{
+			name: "dual-stack with a bracketed IPv6 address",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Hosts[0].InternalAddress = "192.168.0.1, [2001:db8::1]"
+				cfg.Hosts[1].InternalAddress = "192.168.0.2,2001:db8::2"
+				cfg.Network.KubePodsCIDR = "10.233.64.0/18,fd00:10:233::/56"
+				cfg.Network.KubeServiceCIDR = "10.233.0.0/18,fd00:10:96::/108"
+			},
+		},
+		{
+			name: "dual-stack of the same IP family",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Network.KubePodsCIDR = "10.233.64.0/18,10.234.64.0/18"
+				cfg.Network.KubeServiceCIDR = "10.233.0.0/18,fd00:10:96::/108"
+			},
+			fields: []string{"spec.network.kubePodsCIDR", "spec.network.kubeServiceCIDR"},
+		},
+		{
+			name: "dual-stack without the IPv6 addresses of the hosts",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Network.KubePodsCIDR = "10.233.64.0/18,fd00:10:233::/56"
+				cfg.Network.KubeServiceCIDR = "10.233.0.0/18,fd00:10:96::/108"
+			},
+			fields: []string{"spec.hosts[0].internalAddress", "spec.hosts[1].internalAddress"},
+		},
+		{
+			name: "link-local address without an internal address",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Hosts[0].Address = "192.168.0.1%eth0"
+				cfg.Hosts[1].Address = "fe80::2%eth0"
+				cfg.Hosts[1].InternalAddress = ""
+			},
+			fields: []string{"spec.hosts[0].address", "spec.hosts[1].internalAddress"},
+		},
+		{
+			name: "unknown host in role group",
+			modify: func(cfg *ClusterSpec) {
+				cfg.RoleGroups[Worker] = []string{"node[1:3]"}
+			},
+			fields: []string{"spec.roleGroups[worker][0]"},
+		},
+		{
+			name: "missing control-plane endpoint",
+			modify: func(cfg *ClusterSpec) {
+				cfg.RoleGroups[ControlPlane] = []string{"node1", "node2"}
+			},
+			fields: []string{"spec.controlPlaneEndpoint.address"},
+		},
+		{
+			name: "internal load balancer",
+			modify: func(cfg *ClusterSpec) {
+				cfg.RoleGroups[ControlPlane] = []string{"node1", "node2"}
+				cfg.ControlPlaneEndpoint.InternalLoadbalancer = Haproxy
+			},
+		},
+		{
+			name: "unsupported internal load balancer",
+			modify: func(cfg *ClusterSpec) {
+				cfg.ControlPlaneEndpoint.InternalLoadbalancer = "nginx"
+			},
+			fields: []string{"spec.controlPlaneEndpoint.internalLoadbalancer"},
+		},
+		{
+			name: "managed DNS records",
+			modify: func(cfg *ClusterSpec) {
+				externalDNS := true
+				cfg.ControlPlaneEndpoint.ExternalDNS = &externalDNS
+				cfg.ControlPlaneEndpoint.Domain = "api.cluster.example.com"
+				cfg.ControlPlaneEndpoint.DNS = &ControlPlaneDNS{
+					Provider: DNSProviderRFC2136,
+					RFC2136:  &RFC2136DNS{Server: "192.168.0.53", Zone: "example.com."},
+				}
+			},
+		},
+		{
+			name: "managed DNS records without external DNS",
+			modify: func(cfg *ClusterSpec) {
+				cfg.ControlPlaneEndpoint.Domain = "api.cluster.example.com"
+				cfg.ControlPlaneEndpoint.DNS = &ControlPlaneDNS{
+					Provider: DNSProviderRFC2136,
+					RFC2136:  &RFC2136DNS{Server: "192.168.0.53", Zone: "example.org", TSIGKeyName: "kubekey"},
+				}
+			},
+			fields: []string{"spec.controlPlaneEndpoint.externalDNS", "spec.controlPlaneEndpoint.dns.rfc2136.zone",
+				"spec.controlPlaneEndpoint.dns.rfc2136"},
+		},
+		{
+			name: "unsupported DNS provider",
+			modify: func(cfg *ClusterSpec) {
+				externalDNS := true
+				cfg.ControlPlaneEndpoint.ExternalDNS = &externalDNS
+				cfg.ControlPlaneEndpoint.Domain = "api.cluster.example.com"
+				cfg.ControlPlaneEndpoint.DNS = &ControlPlaneDNS{Provider: "cloudflare"}
+			},
+			fields: []string{"spec.controlPlaneEndpoint.dns.provider"},
+		},
+		{
+			name: "overlapping CIDRs",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Network.KubePodsCIDR = "10.233.0.0/16"
+			},
+			fields: []string{"spec.network.kubePodsCIDR"},
+		},
+		{
+			name: "host in the service CIDR",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Network.KubeServiceCIDR = "192.168.0.0/24"
+			},
+			fields: []string{"spec.hosts[0].internalAddress", "spec.hosts[1].internalAddress"},
+		},
+		{
+			name: "node IP selected by an interface pattern",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Network.NodeIP = NodeIPPolicy{Policy: NodeIPPolicyInterface, Interface: "ens*"}
+			},
+		},
+		{
+			name: "node IP selected by an invalid CIDR",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Network.NodeIP = NodeIPPolicy{Policy: NodeIPPolicyCIDR, CIDRs: []string{"172.16.0.0/16", "172.17.0.0"}}
+			},
+			fields: []string{"spec.network.nodeIP.cidrs[1]"},
+		},
+		{
+			name: "custom scripts with an external module",
+			modify: func(cfg *ClusterSpec) {
+				cfg.System.PreInstall = []CustomScripts{
+					{Name: "mount the disks", Module: "mount_disks", Args: runtime.RawExtension{Raw: []byte(`{"device":"/dev/vdb"}`)}},
+					{Name: "set the hostname", Bash: "hostnamectl set-hostname {{ .Name }}", Template: true},
+					{Name: "chrony", TemplateFile: &TemplateFile{Src: "chrony.conf.tmpl", Dest: "/etc/chrony.conf", Backup: true}},
+				}
+			},
+		},
+		{
+			name: "invalid custom scripts",
+			modify: func(cfg *ClusterSpec) {
+				cfg.System.PreInstall = []CustomScripts{{Name: "empty"}, {Name: "both", Bash: "true", Module: "mount_disks"}}
+				cfg.System.PostInstall = []CustomScripts{{Name: "path", Module: "../bin/sh", Args: runtime.RawExtension{Raw: []byte(`["/dev/vdb"]`)}}}
+			},
+			fields: []string{"spec.system.preInstall[0].bash", "spec.system.preInstall[1].bash",
+				"spec.system.postInstall[0].module", "spec.system.postInstall[0].args"},
+		},
+		{
+			name: "invalid template files",
+			modify: func(cfg *ClusterSpec) {
+				cfg.System.PreInstall = []CustomScripts{
+					{Name: "both", Bash: "true", TemplateFile: &TemplateFile{Src: "chrony.conf.tmpl", Dest: "/etc/chrony.conf"}},
+					{Name: "relative", TemplateFile: &TemplateFile{Dest: "etc/chrony.conf"}},
+				}
+			},
+			fields: []string{"spec.system.preInstall[0].templateFile", "spec.system.preInstall[1].templateFile.src",
+				"spec.system.preInstall[1].templateFile.dest"},
+		},
+		{
+			name: "assert and wait for",
+			modify: func(cfg *ClusterSpec) {
+				cfg.System.PreInstall = []CustomScripts{
+					{Name: "cpus", Assert: &Assert{That: []string{"ge .CPUs 2"}, Msg: "2 CPUs are required"}},
+					{Name: "selinux", Assert: &Assert{Command: "getenforce", That: []string{`ne (trim .Stdout) "Enforcing"`}}},
+					{Name: "registry", WaitFor: &WaitFor{Port: 5000, Host: "registry.local", Timeout: "10m", Interval: "10s"}},
+					{Name: "mounted", WaitFor: &WaitFor{Path: "/data/.mounted"}},
+				}
+				cfg.System.PostInstall = []CustomScripts{
+					{Name: "ready", WaitFor: &WaitFor{NodeReady: true}},
+					{Name: "apiserver", WaitFor: &WaitFor{URL: "https://127.0.0.1:6443/readyz"}},
+					{Name: "coredns", WaitFor: &WaitFor{Command: "kubectl -n kube-system get deploy coredns -o jsonpath='{.status.readyReplicas}'", That: "ge (atoi .Stdout) 1"}},
+				}
+			},
+		},
+		{
+			name: "invalid assert and wait for",
+			modify: func(cfg *ClusterSpec) {
+				cfg.System.PreInstall = []CustomScripts{
+					{Name: "both", Bash: "true", Assert: &Assert{That: []string{"true"}}},
+					{Name: "empty", Assert: &Assert{}},
+				}
+				cfg.System.PostInstall = []CustomScripts{
+					{Name: "two conditions", WaitFor: &WaitFor{Port: 6443, Path: "/etc/kubernetes/admin.conf"}},
+					{Name: "invalid", WaitFor: &WaitFor{Port: 70000, Host: "bad host", Timeout: "5", That: "true"}},
+					{Name: "url", WaitFor: &WaitFor{URL: "127.0.0.1:6443/readyz"}},
+				}
+			},
+			fields: []string{"spec.system.preInstall[0].assert", "spec.system.preInstall[1].assert.that",
+				"spec.system.postInstall[0].waitFor", "spec.system.postInstall[1].waitFor.port",
+				"spec.system.postInstall[1].waitFor.host", "spec.system.postInstall[1].waitFor.that",
+				"spec.system.postInstall[1].waitFor.timeout", "spec.system.postInstall[2].waitFor.url"},
+		},
+		{
+			name: "hardening",
+			modify: func(cfg *ClusterSpec) {
+				cfg.System.Hardening = Hardening{Enabled: true, Controls: []string{HardeningSSHD, HardeningAuditd}, PasswordMaxDays: 60}
+			},
+		},
+		{
+			name: "invalid hardening",
+			modify: func(cfg *ClusterSpec) {
+				cfg.System.Hardening = Hardening{Enabled: true, Controls: []string{"selinux"}, PasswordMaxDays: -1, PasswordMinLength: 6}
+			},
+			fields: []string{"spec.system.hardening.controls[0]", "spec.system.hardening.passwordMaxDays",
+				"spec.system.hardening.passwordMinLength"},
+		},
+		{
+			name: "verification",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Verification = Verification{SmokeTests: []string{SmokeTestDNS, SmokeTestPVC}, Timeout: "10m",
+					Conformance: Conformance{Enabled: true, Mode: ConformanceCertified, Version: "v0.56.16"}}
+			},
+		},
+		{
+			name: "invalid verification",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Verification = Verification{SmokeTests: []string{"nodePort"}, Timeout: "soon",
+					Conformance: Conformance{Enabled: true, Mode: "full", Version: "latest; reboot", Timeout: "-1h"}}
+			},
+			fields: []string{"spec.verification.smokeTests[0]", "spec.verification.timeout", "spec.verification.conformance.mode",
+				"spec.verification.conformance.version", "spec.verification.conformance.timeout"},
+		},
+		{
+			name: "benchmark",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Benchmark = Benchmark{Tests: []string{BenchmarkDisk, BenchmarkNetwork}, MaxFsyncLatency: "20ms", MinBandwidthMbps: 100}
+			},
+		},
+		{
+			name: "invalid benchmark",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Benchmark = Benchmark{Tests: []string{"cpu"}, MaxFsyncLatency: "fast", MaxRTT: "0s", MinBandwidthMbps: -1}
+			},
+			fields: []string{"spec.benchmark.tests[0]", "spec.benchmark.maxFsyncLatency", "spec.benchmark.maxRTT",
+				"spec.benchmark.minBandwidthMbps"},
+		},
+		{
+			name: "rbac",
+			modify: func(cfg *ClusterSpec) {
+				cfg.RBAC = RBAC{AdminGroups: []string{"oidc:platform-admins"}, ReadOnlyGroups: []string{"oidc:developers"}}
+			},
+		},
+		{
+			name: "empty rbac group",
+			modify: func(cfg *ClusterSpec) {
+				cfg.RBAC = RBAC{AdminGroups: []string{"oidc:platform-admins", " "}}
+			},
+			fields: []string{"spec.rbac.adminGroups[1]"},
+		},
+		{
+			name: "pull secret",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Registry.PullSecret = PullSecret{Namespaces: []string{"default", "apps"}, PatchServiceAccounts: true}
+			},
+		},
+		{
+			name: "invalid pull secret",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Registry.PullSecret = PullSecret{Name: "Registry_Auth", Namespaces: []string{"default", "Apps"}}
+			},
+			fields: []string{"spec.registry.pullSecret.name", "spec.registry.pullSecret.namespaces[1]"},
+		},
+		{
+			name: "automation user",
+			modify: func(cfg *ClusterSpec) {
+				cfg.System.AutomationUser = AutomationUser{Name: "kubekey", AuthorizedKeys: []string{"ssh-ed25519 AAAAC3Nza kubekey@bastion"},
+					DisablePasswordAuth: true}
+			},
+		},
+		{
+			name: "invalid automation user",
+			modify: func(cfg *ClusterSpec) {
+				cfg.System.AutomationUser = AutomationUser{Name: "root", Shell: "bash", AuthorizedKeys: []string{"AAAAC3Nza"}}
+			},
+			fields: []string{"spec.system.automationUser.name", "spec.system.automationUser.shell", "spec.system.automationUser.authorizedKeys[0]"},
+		},
+		{
+			name: "automation user without a key",
+			modify: func(cfg *ClusterSpec) {
+				cfg.System.AutomationUser = AutomationUser{Name: "kubekey", DisablePasswordAuth: true}
+			},
+			fields: []string{"spec.system.automationUser.authorizedKeys"},
+		},
+		{
+			name: "node groups",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Hosts[1].Zone = "zone-b"
+				cfg.Hosts[1].Taints = []Taint{{Key: "dedicated", Value: "gpu", Effect: TaintEffectNoSchedule}}
+				cfg.NodeGroups = map[string]NodeGroup{
+					Worker: {Region: "region-a", Labels: map[string]string{"node.example.com/pool": "general"}},
+				}
+			},
+		},
+		{
+			name: "invalid node groups",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Hosts[0].Rack = "rack a"
+				cfg.Hosts[1].Taints = []Taint{{Key: "dedicated", Value: "gpu", Effect: "NoRun"}}
+				cfg.NodeGroups = map[string]NodeGroup{
+					"gpu":  {},
+					Worker: {Labels: map[string]string{"-pool": "general"}, Taints: []Taint{{Key: "a/b/c", Effect: TaintEffectNoExecute}}},
+				}
+			},
+			fields: []string{
+				"spec.hosts[0].rack", "spec.hosts[1].taints[0].effect", "spec.nodeGroups[gpu]",
+				"spec.nodeGroups[worker].labels[-pool]", "spec.nodeGroups[worker].taints[0]",
+			},
+		},
+		{
+			name: "tuning",
+			modify: func(cfg *ClusterSpec) {
+				cfg.System.Tuning = Tuning{Profile: TuningHighDensity, Sysctls: map[string]string{"net.core.somaxconn": "65535"}}
+			},
+		},
+		{
+			name: "invalid tuning",
+			modify: func(cfg *ClusterSpec) {
+				cfg.System.Tuning = Tuning{Profile: TuningCustom, Sysctls: map[string]string{"somaxconn": "1", "vm.swappiness": "1'"}}
+			},
+			fields: []string{"spec.system.tuning.file", "spec.system.tuning.sysctls[somaxconn]",
+				"spec.system.tuning.sysctls[vm.swappiness]"},
+		},
+		{
+			name: "selinux enforcing and apparmor",
+			modify: func(cfg *ClusterSpec) {
+				cfg.System.SELinux = SELinuxEnforcing
+				cfg.System.AppArmor = AppArmorEnabled
+			},
+		},
+		{
+			name: "unknown selinux and apparmor modes",
+			modify: func(cfg *ClusterSpec) {
+				cfg.System.SELinux = "enforce"
+				cfg.System.AppArmor = "complain"
+			},
+			fields: []string{"spec.system.selinux", "spec.system.apparmor"},
+		},
+		{
+			name: "invalid version",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Kubernetes.Version = "latest"
+			},
+			fields: []string{"spec.kubernetes.version"},
+		},
+		{
+			name: "gpu with containerd",
+			modify: func(cfg *ClusterSpec) {
+				enabled := true
+				cfg.Kubernetes.GPU = GPU{Enabled: &enabled, Mode: GPUModeOperator}
+				cfg.Kubernetes.ContainerManager = Containerd
+			},
+		},
+		{
+			name: "gpu with docker and an unknown mode",
+			modify: func(cfg *ClusterSpec) {
+				enabled := true
+				cfg.Kubernetes.GPU = GPU{Enabled: &enabled, Mode: "driver"}
+			},
+			fields: []string{"spec.kubernetes.gpu.mode", "spec.kubernetes.containerManager"},
+		},
+		{
+			name: "kubeadm configuration overlay and patches",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Kubernetes.ClusterConfiguration = runtime.RawExtension{Raw: []byte(`{"apiServer":{"extraArgs":{"oidc-issuer-url":"https://dex.example.com"}}}`)}
+				cfg.Kubernetes.KubeadmPatches = []KubeadmPatch{{Target: "kube-apiserver", Patch: "spec:\n  priorityClassName: system-node-critical"}}
+			},
+		},
+		{
+			name: "invalid kubeadm configuration overlay and patches",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Kubernetes.ClusterConfiguration = runtime.RawExtension{Raw: []byte(`{"kind":"InitConfiguration"}`)}
+				cfg.Kubernetes.KubeadmPatches = []KubeadmPatch{
+					{Target: "kubeletconfiguration", Type: "merge", Patch: `{"maxPods":200}`},
+					{Target: "kube-proxy", Type: "xml", Patch: " "},
+				}
+			},
+			fields: []string{"spec.kubernetes.clusterConfiguration.kind", "spec.kubernetes.kubeadmPatches[0].target",
+				"spec.kubernetes.kubeadmPatches[1].target", "spec.kubernetes.kubeadmPatches[1].type", "spec.kubernetes.kubeadmPatches[1].patch"},
+		},
+		{
+			name: "nfs storage without the server",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Storage = StorageConfig{Provider: StorageNFS, NFS: NFSCfg{Path: "/exports/k8s"}}
+			},
+			fields: []string{"spec.storage.nfs.server"},
+		},
+		{
+			name: "unknown storage provider",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Storage.Provider = "ceph"
+			},
+			fields: []string{"spec.storage.provider"},
+		},
+		{
+			name: "duplicated addon with an invalid timeout",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Addons = []Addon{
+					{Name: "ingress-nginx", Sources: Sources{Chart: Chart{Name: "ingress-nginx", Timeout: "10m"}}},
+					{Name: "ingress-nginx", Sources: Sources{Chart: Chart{Name: "ingress-nginx", Timeout: "10"}}},
+				}
+			},
+			fields: []string{"spec.addons[1].name", "spec.addons[1].sources.chart.timeout"},
+		},
+		{
+			name: "manifest addon with an invalid label value",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Addons = []Addon{{Name: "monitoring/prometheus", Sources: Sources{Yaml: Yaml{Kustomize: "./monitoring"}}}}
+			},
+			fields: []string{"spec.addons[0].name"},
+		},
+		{
+			name: "invalid timezone and locale",
+			modify: func(cfg *ClusterSpec) {
+				cfg.System.Timezone = "Asia/Shanghai; reboot"
+				cfg.System.Locale = "en_US.UTF-8 $(id)"
+			},
+			fields: []string{"spec.system.timezone", "spec.system.locale"},
+		},
+		{
+			name: "timezone and locale",
+			modify: func(cfg *ClusterSpec) {
+				cfg.System.Timezone = "America/Argentina/Buenos_Aires"
+				cfg.System.Locale = "zh_CN.UTF-8"
+			},
+		},
+		{
+			name: "invalid proxy",
+			modify: func(cfg *ClusterSpec) {
+				cfg.System.Proxy = Proxy{
+					HTTPProxy:  "proxy.example.com:3128",
+					HTTPSProxy: "http://proxy.example.com:3128",
+					NoProxy:    []string{"10.0.0.0/8", "a.example.com,b.example.com"},
+				}
+			},
+			fields: []string{"spec.system.proxy.httpProxy", "spec.system.proxy.noProxy[1]"},
+		},
+		{
+			name: "tls policies",
+			modify: func(cfg *ClusterSpec) {
+				cfg.TLS = TLS{
+					Apiserver: TLSPolicy{MinVersion: TLSVersion12, CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}},
+					Etcd:      TLSPolicy{MinVersion: TLSVersion13},
+				}
+			},
+		},
+		{
+			name: "invalid tls policies",
+			modify: func(cfg *ClusterSpec) {
+				cfg.TLS = TLS{
+					Apiserver: TLSPolicy{MinVersion: "VersionTLS11"},
+					Etcd:      TLSPolicy{MinVersion: TLSVersion13, CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}},
+					Kubelet: TLSPolicy{CipherSuites: []string{
+						"TLS_RSA_WITH_RC4_128_SHA", "TLS_AES_128_GCM_SHA256",
+						"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384", "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+					}},
+				}
+			},
+			fields: []string{
+				"spec.tls.apiserver.minVersion", "spec.tls.etcd.cipherSuites",
+				"spec.tls.kubelet.cipherSuites[0]", "spec.tls.kubelet.cipherSuites[1]", "spec.tls.kubelet.cipherSuites[3]",
+			},
+		},
+		{
+			name: "tls policies of k3s and external etcd",
+			modify: func(cfg *ClusterSpec) {
+				cfg.Kubernetes.Version = "v1.23.15-k3s"
+				cfg.Etcd.Type = External
+				cfg.TLS = TLS{
+					Apiserver: TLSPolicy{MinVersion: TLSVersion12},
+					Etcd:      TLSPolicy{MinVersion: TLSVersion12},
+				}
+			},
+			fields: []string{"spec.tls.apiserver", "spec.tls.etcd"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validClusterSpec()
+			tt.modify(cfg)
+			errs := cfg.Validate(field.NewPath("spec"))
+			if len(errs) != len(tt.fields) {
+				t.Fatalf("Validate() = %v, want errors for %v", errs, tt.fields)
+			}
+			for i, err := range errs {
+				if err.Field != tt.fields[i] {
+					t.Errorf("Validate() error %d field = %s, want %s", i, err.Field, tt.fields[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateVersionSkew(t *testing.T) {
+	tests := []struct {
+		current string
+		desired string
+		wantErr bool
+	}{
+		{current: "v1.22.12", desired: "v1.22.12"},
+		{current: "v1.22.12", desired: "v1.24.9"},
+		{current: "v1.24.9", desired: "v1.22.12", wantErr: true},
+		{current: "v1.24.9", desired: "v2.0.0", wantErr: true},
+		{current: "v1.24.9-k3s", desired: "v1.25.3-k3s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.current+"->"+tt.desired, func(t *testing.T) {
+			errs := ValidateVersionSkew(tt.current, tt.desired, field.NewPath("spec", "kubernetes", "version"))
+			if (len(errs) > 0) != tt.wantErr {
+				t.Errorf("ValidateVersionSkew() = %v, wantErr %v", errs, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha2
+
+import "time"
+
+// Deadlines bounds the runs of kk on the cluster, so the unattended runs, e.g. the installations in CI, can't hang for
+// hours. A run which exceeds a deadline is aborted with the timing of its modules.
+type Deadlines struct {
+	// Run is the deadline of the whole run, e.g. 2h.
+	Run string `yaml:"run" json:"run,omitempty"`
+	// Phases are the deadlines of the phases of the run by the tags of their modules, e.g. 20m for etcd. A phase
+	// starts with its first module.
+	Phases map[string]string `yaml:"phases" json:"phases,omitempty"`
+}
+
+// RunTimeout returns the deadline of the run, it is zero if it isn't set.
+func (d Deadlines) RunTimeout() time.Duration {
+	timeout, _ := time.ParseDuration(d.Run)
+	return timeout
+}
+
+// PhaseTimeouts returns the deadlines of the phases.
+func (d Deadlines) PhaseTimeouts() map[string]time.Duration {
+	timeouts := make(map[string]time.Duration, len(d.Phases))
+	for phase, value := range d.Phases {
+		if timeout, err := time.ParseDuration(value); err == nil {
+			timeouts[phase] = timeout
+		}
+	}
+	return timeouts
+}
@@ -105,6 +105,12 @@ const (
 	Haproxy            = "haproxy"
 	Kubevip            = "kube-vip"
 	DefaultKubeVipMode = "ARP"
+
+	DNSProviderRoute53   = "route53"
+	DNSProviderCoreDNS   = "coredns"
+	DNSProviderRFC2136   = "rfc2136"
+	DefaultDNSTTL        = 60
+	DefaultCoreDNSPrefix = "/skydns"
 )
 
 func (cfg *ClusterSpec) SetDefaultClusterSpec() (*ClusterSpec, map[string][]*KubeHost) {
@@ -122,7 +128,10 @@ func (cfg *ClusterSpec) SetDefaultClusterSpec() (*ClusterSpec, map[string][]*Kub
 	clusterCfg.DNS = cfg.DNS
 	clusterCfg.Registry = cfg.Registry
 	clusterCfg.Addons = cfg.Addons
+	clusterCfg.Topology = SetDefaultTopologyCfg(cfg)
 	clusterCfg.KubeSphere = cfg.KubeSphere
+	clusterCfg.Offline = cfg.Offline
+	clusterCfg.TLS = cfg.TLS
 
 	if cfg.Kubernetes.ClusterName == "" {
 		clusterCfg.Kubernetes.ClusterName = DefaultClusterName
@@ -151,8 +160,11 @@ func SetDefaultHostsCfg(cfg *ClusterSpec) []HostCfg {
 		return nil
 	}
 	for _, host := range cfg.Hosts {
+		host.Address = TrimAddressBrackets(host.Address)
+		host.InternalAddress = TrimInternalAddress(host.InternalAddress)
+		// ssh connects to the first address of a dual-stack host
 		if len(host.Address) == 0 && len(host.InternalAddress) > 0 {
-			host.Address = host.InternalAddress
+			host.Address = strings.Split(host.InternalAddress, ",")[0]
 		}
 		if len(host.InternalAddress) == 0 && len(host.Address) > 0 {
 			host.InternalAddress = host.Address
@@ -164,7 +176,7 @@ func SetDefaultHostsCfg(cfg *ClusterSpec) []HostCfg {
 			host.Port = DefaultSSHPort
 		}
 		if host.PrivateKey == "" {
-			if host.Password == "" && host.PrivateKeyPath == "" {
+			if host.Password == "" && host.PrivateKeyPath == "" && host.CredentialsFrom == "" {
 				host.PrivateKeyPath = "~/.ssh/id_rsa"
 			}
 			if host.PrivateKeyPath != "" && strings.HasPrefix(strings.TrimSpace(host.PrivateKeyPath), "~/") {
@@ -214,6 +226,14 @@ func SetDefaultLBCfg(cfg *ClusterSpec, masterGroup []*KubeHost) ControlPlaneEndp
 	if cfg.ControlPlaneEndpoint.KubeVip.Mode == "" {
 		cfg.ControlPlaneEndpoint.KubeVip.Mode = DefaultKubeVipMode
 	}
+	if dns := cfg.ControlPlaneEndpoint.DNS; dns != nil {
+		if dns.TTL == 0 {
+			dns.TTL = DefaultDNSTTL
+		}
+		if dns.CoreDNS != nil && dns.CoreDNS.Prefix == "" {
+			dns.CoreDNS.Prefix = DefaultCoreDNSPrefix
+		}
+	}
 	defaultLbCfg := cfg.ControlPlaneEndpoint
 	return defaultLbCfg
 }
@@ -0,0 +1,70 @@
+/*
+ Copyright 2022 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha2
+
+const (
+	HardeningSSHD           = "sshd"
+	HardeningAuditd         = "auditd"
+	HardeningPasswordPolicy = "passwordPolicy"
+
+	DefaultPasswordMaxDays   = 90
+	DefaultPasswordMinLength = 14
+)
+
+// HardeningControls are the controls of the baseline hardening, in the order they are applied.
+var HardeningControls = []string{HardeningSSHD, HardeningAuditd, HardeningPasswordPolicy}
+
+// Hardening defines the baseline hardening of the OS of all the nodes. It's applied offline after the OS is
+// configured, with the tools already on the nodes, and the applied controls are reported.
+type Hardening struct {
+	Enabled bool `yaml:"enabled" json:"enabled,omitempty"`
+	// Controls are the controls to apply: sshd, auditd and passwordPolicy, defaults to all of them.
+	Controls []string `yaml:"controls" json:"controls,omitempty"`
+	// PasswordMaxDays is the max days a password of the new users may be used, defaults to 90.
+	PasswordMaxDays int `yaml:"passwordMaxDays" json:"passwordMaxDays,omitempty"`
+	// PasswordMinLength is the min length of a new password, defaults to 14.
+	PasswordMinLength int `yaml:"passwordMinLength" json:"passwordMinLength,omitempty"`
+}
+
+// Applies returns whether the control is applied.
+func (h Hardening) Applies(control string) bool {
+	if len(h.Controls) == 0 {
+		return true
+	}
+	for _, c := range h.Controls {
+		if c == control {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxDays returns the max days of a password.
+func (h Hardening) MaxDays() int {
+	if h.PasswordMaxDays == 0 {
+		return DefaultPasswordMaxDays
+	}
+	return h.PasswordMaxDays
+}
+
+// MinLength returns the min length of a password.
+func (h Hardening) MinLength() int {
+	if h.PasswordMinLength == 0 {
+		return DefaultPasswordMinLength
+	}
+	return h.PasswordMinLength
+}
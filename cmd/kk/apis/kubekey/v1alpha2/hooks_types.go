@@ -0,0 +1,43 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha2
+
+import "time"
+
+// Hook notifies an external system of the events of the runs of kk on the cluster, e.g. to page on the failures or
+// to update a CMDB. Exactly one of Webhook, Slack and Script is set.
+type Hook struct {
+	// Events are the events the hook fires on, pipeline.start, pipeline.finish, pipeline.failure and
+	// phase.complete. It fires on all of them if empty.
+	Events []string `yaml:"events" json:"events,omitempty"`
+	// Webhook is the URL the events are posted to as JSON.
+	Webhook string `yaml:"webhook" json:"webhook,omitempty"`
+	// Headers are the headers of the requests to the webhook, e.g. Authorization.
+	Headers map[string]string `yaml:"headers" json:"headers,omitempty"`
+	// Slack is the URL of the Slack incoming webhook the events are posted to as messages.
+	Slack string `yaml:"slack" json:"slack,omitempty"`
+	// Script is the shell script run on the machine running kk, with the event as JSON on its stdin.
+	Script string `yaml:"script" json:"script,omitempty"`
+	// Timeout bounds the delivery of an event, e.g. 30s, it is 10s by default.
+	Timeout string `yaml:"timeout" json:"timeout,omitempty"`
+}
+
+// DeliveryTimeout returns the timeout of the delivery of an event, it is zero if it isn't set.
+func (h Hook) DeliveryTimeout() time.Duration {
+	timeout, _ := time.ParseDuration(h.Timeout)
+	return timeout
+}
@@ -41,22 +41,67 @@ type Kubernetes struct {
 	ContainerRuntimeEndpoint string               `yaml:"containerRuntimeEndpoint" json:"containerRuntimeEndpoint,omitempty"`
 	NodeFeatureDiscovery     NodeFeatureDiscovery `yaml:"nodeFeatureDiscovery" json:"nodeFeatureDiscovery,omitempty"`
 	Kata                     Kata                 `yaml:"kata" json:"kata,omitempty"`
+	GPU                      GPU                  `yaml:"gpu" json:"gpu,omitempty"`
 	ApiServerArgs            []string             `yaml:"apiserverArgs" json:"apiserverArgs,omitempty"`
 	ControllerManagerArgs    []string             `yaml:"controllerManagerArgs" json:"controllerManagerArgs,omitempty"`
 	SchedulerArgs            []string             `yaml:"schedulerArgs" json:"schedulerArgs,omitempty"`
 	KubeletArgs              []string             `yaml:"kubeletArgs" json:"kubeletArgs,omitempty"`
 	KubeProxyArgs            []string             `yaml:"kubeProxyArgs" json:"kubeProxyArgs,omitempty"`
 	FeatureGates             map[string]bool      `yaml:"featureGates" json:"featureGates,omitempty"`
-	KubeletConfiguration     runtime.RawExtension `yaml:"kubeletConfiguration" json:"kubeletConfiguration,omitempty"`
-	KubeProxyConfiguration   runtime.RawExtension `yaml:"kubeProxyConfiguration" json:"kubeProxyConfiguration,omitempty"`
-	Audit                    Audit                `yaml:"audit" json:"audit,omitempty"`
+	// ClusterConfiguration is merged into the ClusterConfiguration of kubeadm generated by KubeKey, e.g. to set the
+	// extraArgs or the extraVolumes of the apiServer. The maps are merged, the lists and the other values are replaced.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	ClusterConfiguration runtime.RawExtension `yaml:"clusterConfiguration" json:"clusterConfiguration,omitempty"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	KubeletConfiguration runtime.RawExtension `yaml:"kubeletConfiguration" json:"kubeletConfiguration,omitempty"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	KubeProxyConfiguration runtime.RawExtension `yaml:"kubeProxyConfiguration" json:"kubeProxyConfiguration,omitempty"`
+	Audit                  Audit                `yaml:"audit" json:"audit,omitempty"`
+	// KubeadmPatches are applied by kubeadm to the static pod manifests of the control plane and to the kubelet
+	// configuration on the nodes when they are created, joined or upgraded.
+	KubeadmPatches []KubeadmPatch `yaml:"kubeadmPatches" json:"kubeadmPatches,omitempty"`
 }
 
+// KubeadmPatch is a patch applied by kubeadm, see
+// https://kubernetes.io/docs/setup/production-environment/tools/kubeadm/control-plane-flags/#patches
+type KubeadmPatch struct {
+	// Target is one of kube-apiserver, kube-controller-manager, kube-scheduler, etcd and kubeletconfiguration.
+	Target string `yaml:"target" json:"target"`
+	// Type is the type of the patch, one of strategic, merge and json. Defaults to strategic.
+	Type string `yaml:"type" json:"type,omitempty"`
+	// Patch is the patch in YAML or JSON.
+	Patch string `yaml:"patch" json:"patch"`
+}
+
+// KubeadmPatchesDir is the directory of the kubeadm patches on the nodes.
+const KubeadmPatchesDir = "/etc/kubernetes/patches"
+
 // Kata contains the configuration for the kata in cluster
 type Kata struct {
 	Enabled *bool `yaml:"enabled" json:"enabled,omitempty"`
 }
 
+// GPU contains the configuration for the NVIDIA GPU nodes in cluster. The nodes with an NVIDIA GPU found by lspci
+// are labeled with nvidia.com/gpu.present=true, and in the toolkit mode the driver and the container toolkit are
+// installed on them and the containerd runtime class nvidia is created. In the operator mode the NVIDIA GPU operator
+// manages them instead.
+type GPU struct {
+	Enabled *bool  `yaml:"enabled" json:"enabled,omitempty"`
+	Mode    string `yaml:"mode" json:"mode,omitempty"`
+	// DriverPackage is the package of the driver installed on the GPU nodes without a working nvidia-smi in the
+	// toolkit mode. By default the driver is installed by ubuntu-drivers on Ubuntu and the package nvidia-driver
+	// on the others.
+	DriverPackage string `yaml:"driverPackage" json:"driverPackage,omitempty"`
+	// OperatorVersion and OperatorValues are the version and the values of the gpu-operator chart in the operator mode.
+	OperatorVersion string   `yaml:"operatorVersion" json:"operatorVersion,omitempty"`
+	OperatorValues  []string `yaml:"operatorValues" json:"operatorValues,omitempty"`
+}
+
+const (
+	GPUModeToolkit  = "toolkit"
+	GPUModeOperator = "operator"
+)
+
 // NodeFeatureDiscovery contains the configuration for the node-feature-discovery in cluster
 type NodeFeatureDiscovery struct {
 	Enabled *bool `yaml:"enabled" json:"enabled,omitempty"`
@@ -91,6 +136,22 @@ func (k *Kubernetes) EnableNodeFeatureDiscovery() bool {
 	return *k.NodeFeatureDiscovery.Enabled
 }
 
+// EnableGPU is used to determine whether to enable the NVIDIA GPU nodes.
+func (k *Kubernetes) EnableGPU() bool {
+	if k.GPU.Enabled == nil {
+		return false
+	}
+	return *k.GPU.Enabled
+}
+
+// GPUMode returns how the GPU nodes are enabled, the default is toolkit.
+func (k *Kubernetes) GPUMode() string {
+	if k.GPU.Mode == "" {
+		return GPUModeToolkit
+	}
+	return k.GPU.Mode
+}
+
 // EnableAutoRenewCerts is used to determine whether to enable AutoRenewCerts.
 func (k *Kubernetes) EnableAutoRenewCerts() bool {
 	if k.AutoRenewCerts == nil {
@@ -0,0 +1,63 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha2
+
+import "time"
+
+// DefaultDisruptivePhases are the phases restricted to the maintenance windows by default, by the tags of their modules.
+var DefaultDisruptivePhases = []string{"etcd", "kubernetes", "container-runtime", "network", "certs", "loadbalancer", "os-patch"}
+
+// DefaultLeaseDuration is the duration of the lease of the cluster, it is renewed after every module of the run.
+const DefaultLeaseDuration = 10 * time.Minute
+
+// MaintenanceWindow restricts the disruptive phases of the runs of kk on the cluster to the windows, e.g. the restarts
+// of etcd and kubelet. A phase started within a window runs to its end, see --ignore-maintenance-window.
+type MaintenanceWindow struct {
+	// Windows are the weekly windows of "[<day>[-<day>]] HH:MM-HH:MM", e.g. "Sat 01:00-05:00" or
+	// "Mon-Fri 22:00-02:00". A window whose end isn't after its start ends on the next day.
+	Windows []string `yaml:"windows" json:"windows,omitempty"`
+	// Timezone is the IANA timezone of the windows, e.g. Europe/Berlin, it defaults to the local one.
+	Timezone string `yaml:"timezone" json:"timezone,omitempty"`
+	// Phases are the disruptive phases by the tags of their modules, they default to etcd, kubernetes,
+	// container-runtime, network, certs, loadbalancer and os-patch.
+	Phases []string `yaml:"phases" json:"phases,omitempty"`
+}
+
+// DisruptivePhases returns the phases restricted to the windows.
+func (m MaintenanceWindow) DisruptivePhases() []string {
+	if len(m.Phases) == 0 {
+		return DefaultDisruptivePhases
+	}
+	return m.Phases
+}
+
+// Lock is the lock of the cluster against the concurrent runs of kk. The runs on one machine are always locked by
+// the work dir of the cluster, the Lease in the cluster locks the runs from different machines too.
+type Lock struct {
+	// Lease locks the cluster by the kubekey Lease in kube-system, once the cluster is installed.
+	Lease bool `yaml:"lease" json:"lease,omitempty"`
+	// LeaseDuration is the duration of the lease, e.g. 10m. The lease of a crashed run expires after it.
+	LeaseDuration string `yaml:"leaseDuration" json:"leaseDuration,omitempty"`
+}
+
+// Duration returns the duration of the lease.
+func (l Lock) Duration() time.Duration {
+	if d, err := time.ParseDuration(l.LeaseDuration); err == nil && d > 0 {
+		return d
+	}
+	return DefaultLeaseDuration
+}
@@ -26,8 +26,22 @@ type Iso struct {
 	Url       string `yaml:"url" json:"url"`
 }
 
+// Packages describes an offline package repository built for an operating system.
+// The repository holds the packages KubeKey installs on the nodes and all their
+// dependencies, so the nodes can install them without any upstream repository.
+type Packages struct {
+	// Build enables building the package repository into the artifact.
+	Build bool `yaml:"build" json:"build,omitempty"`
+	// Image is the container image the repository is built in, it defaults to the
+	// official image of the operating system, such as ubuntu:22.04.
+	Image string `yaml:"image" json:"image,omitempty"`
+	// Extra packages to bundle besides the ones KubeKey installs by default.
+	Extra []string `yaml:"extra" json:"extra,omitempty"`
+}
+
 type Repository struct {
-	Iso Iso `yaml:"iso" json:"iso"`
+	Iso      Iso      `yaml:"iso" json:"iso"`
+	Packages Packages `yaml:"packages" json:"packages,omitempty"`
 }
 
 type OperatingSystem struct {
@@ -97,6 +111,16 @@ type Components struct {
 	Calicoctl         Calicoctl          `yaml:"calicoctl" json:"calicoctl"`
 }
 
+// ManifestChart is a helm chart bundled in the artifact as a chart archive, with its dependencies.
+type ManifestChart struct {
+	// Name is the name of the chart in the repo, or the reference of the chart in an OCI registry, e.g.
+	// oci://registry.example.com/charts/nginx.
+	Name string `yaml:"name" json:"name"`
+	// Repo is the URL of the chart repo, or the name of a repo added by helm repo add if it is empty.
+	Repo    string `yaml:"repo" json:"repo,omitempty"`
+	Version string `yaml:"version" json:"version,omitempty"`
+}
+
 type ManifestRegistry struct {
 	Auths runtime.RawExtension `yaml:"auths" json:"auths,omitempty"`
 }
@@ -108,6 +132,7 @@ type ManifestSpec struct {
 	KubernetesDistributions []KubernetesDistribution `yaml:"kubernetesDistributions" json:"kubernetesDistributions"`
 	Components              Components               `yaml:"components" json:"components"`
 	Images                  []string                 `yaml:"images" json:"images"`
+	Charts                  []ManifestChart          `yaml:"charts" json:"charts,omitempty"`
 	ManifestRegistry        ManifestRegistry         `yaml:"registry" json:"registry"`
 }
 
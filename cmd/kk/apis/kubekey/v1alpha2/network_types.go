@@ -16,6 +16,11 @@
 
 package v1alpha2
 
+import (
+	"net"
+	"strings"
+)
+
 type NetworkConfig struct {
 	Plugin          string       `yaml:"plugin" json:"plugin,omitempty"`
 	KubePodsCIDR    string       `yaml:"kubePodsCIDR" json:"kubePodsCIDR,omitempty"`
@@ -25,6 +30,67 @@ type NetworkConfig struct {
 	Kubeovn         KubeovnCfg   `yaml:"kubeovn" json:"kubeovn,omitempty"`
 	MultusCNI       MultusCNI    `yaml:"multusCNI" json:"multusCNI,omitempty"`
 	Hybridnet       HybridnetCfg `yaml:"hybridnet" json:"hybridnet,omitempty"`
+	NodeIP          NodeIPPolicy `yaml:"nodeIP" json:"nodeIP,omitempty"`
+	IPAM            IPAMConfig   `yaml:"ipam" json:"ipam,omitempty"`
+}
+
+// IPAMConfig allocates the kubePodsCIDR and kubeServiceCIDR which aren't set from the ranges shared by the clusters
+// managed from the same work dir, so the clusters of several sites don't overlap. The allocations are recorded in the
+// ipam.json of the work dir, and released when the cluster is deleted.
+type IPAMConfig struct {
+	// PodsRange and ServiceRange are the ranges the CIDRs are allocated from, e.g. 10.128.0.0/10.
+	PodsRange    string `yaml:"podsRange" json:"podsRange,omitempty"`
+	ServiceRange string `yaml:"serviceRange" json:"serviceRange,omitempty"`
+	// PodsPrefixLength and ServicePrefixLength are the prefix lengths of the allocated CIDRs, 18 by default.
+	PodsPrefixLength    int `yaml:"podsPrefixLength" json:"podsPrefixLength,omitempty"`
+	ServicePrefixLength int `yaml:"servicePrefixLength" json:"servicePrefixLength,omitempty"`
+}
+
+const DefaultIPAMPrefixLength = 18
+
+// Enabled returns whether any CIDR is allocated by the IPAM.
+func (i IPAMConfig) Enabled() bool {
+	return i.PodsRange != "" || i.ServiceRange != ""
+}
+
+// PodsPrefix returns the prefix length of the allocated pods CIDRs.
+func (i IPAMConfig) PodsPrefix() int {
+	if i.PodsPrefixLength <= 0 {
+		return DefaultIPAMPrefixLength
+	}
+	return i.PodsPrefixLength
+}
+
+// ServicePrefix returns the prefix length of the allocated service CIDRs.
+func (i IPAMConfig) ServicePrefix() int {
+	if i.ServicePrefixLength <= 0 {
+		return DefaultIPAMPrefixLength
+	}
+	return i.ServicePrefixLength
+}
+
+// NodeIPPolicy selects the node IP of the hosts without an internalAddress from the addresses of their network
+// interfaces, instead of their ssh address. The node IP is the --node-ip of kubelet and the advertise address of etcd
+// and the control plane.
+type NodeIPPolicy struct {
+	// Policy is one of explicit, interface, cidr and firstPrivate. The default explicit uses the address of the host.
+	Policy string `yaml:"policy" json:"policy,omitempty"`
+	// Interface is the network interface of the node IP in the interface policy, e.g. eth1, or a pattern like ens*.
+	Interface string `yaml:"interface" json:"interface,omitempty"`
+	// CIDRs are the networks of the node IP in the cidr policy, e.g. 172.16.0.0/16.
+	CIDRs []string `yaml:"cidrs" json:"cidrs,omitempty"`
+}
+
+const (
+	NodeIPPolicyExplicit     = "explicit"
+	NodeIPPolicyInterface    = "interface"
+	NodeIPPolicyCIDR         = "cidr"
+	NodeIPPolicyFirstPrivate = "firstPrivate"
+)
+
+// Selects returns whether the node IP of the hosts without an internalAddress is selected by the policy.
+func (n NodeIPPolicy) Selects() bool {
+	return n.Policy != "" && n.Policy != NodeIPPolicyExplicit
 }
 
 type CalicoCfg struct {
@@ -170,6 +236,46 @@ func (n *NetworkConfig) EnableMultusCNI() bool {
 	return *n.MultusCNI.Enabled
 }
 
+// SplitCIDRs returns the IPv4 and the IPv6 CIDR of a single-stack CIDR, or of a dual-stack pair separated by a comma.
+// Either is empty if the CIDRs have no such family.
+func SplitCIDRs(cidrs string) (ipv4, ipv6 string) {
+	for _, s := range strings.Split(cidrs, ",") {
+		s = strings.TrimSpace(s)
+		ip, _, err := net.ParseCIDR(s)
+		switch {
+		case err != nil:
+			continue
+		case ip.To4() != nil && ipv4 == "":
+			ipv4 = s
+		case ip.To4() == nil && ipv6 == "":
+			ipv6 = s
+		}
+	}
+	return ipv4, ipv6
+}
+
+// PodsCIDRs returns the IPv4 and the IPv6 CIDR of the pods.
+func (n *NetworkConfig) PodsCIDRs() (ipv4, ipv6 string) {
+	return SplitCIDRs(n.KubePodsCIDR)
+}
+
+// ServiceCIDRs returns the IPv4 and the IPv6 CIDR of the services.
+func (n *NetworkConfig) ServiceCIDRs() (ipv4, ipv6 string) {
+	return SplitCIDRs(n.KubeServiceCIDR)
+}
+
+// DualStack returns whether the pods have both an IPv4 and an IPv6 CIDR.
+func (n *NetworkConfig) DualStack() bool {
+	ipv4, ipv6 := n.PodsCIDRs()
+	return ipv4 != "" && ipv6 != ""
+}
+
+// IPv6Only returns whether the pods only have an IPv6 CIDR.
+func (n *NetworkConfig) IPv6Only() bool {
+	ipv4, ipv6 := n.PodsCIDRs()
+	return ipv4 == "" && ipv6 != ""
+}
+
 // EnableIPV4POOL_NAT_OUTGOING is used to determine whether to enable CALICO_IPV4POOL_NAT_OUTGOING.
 func (c *CalicoCfg) EnableIPV4POOL_NAT_OUTGOING() bool {
 	if c.Ipv4NatOutgoing == nil {
@@ -0,0 +1,40 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha2
+
+import "testing"
+
+func TestSplitCIDRs(t *testing.T) {
+	tests := []struct {
+		cidrs      string
+		ipv4, ipv6 string
+	}{
+		{cidrs: "10.233.64.0/18", ipv4: "10.233.64.0/18"},
+		{cidrs: "fd00:10:233::/56", ipv6: "fd00:10:233::/56"},
+		{cidrs: "10.233.64.0/18, fd00:10:233::/56", ipv4: "10.233.64.0/18", ipv6: "fd00:10:233::/56"},
+		{cidrs: "fd00:10:233::/56,10.233.64.0/18", ipv4: "10.233.64.0/18", ipv6: "fd00:10:233::/56"},
+		{cidrs: "10.233.64.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cidrs, func(t *testing.T) {
+			ipv4, ipv6 := SplitCIDRs(tt.cidrs)
+			if ipv4 != tt.ipv4 || ipv6 != tt.ipv6 {
+				t.Errorf("SplitCIDRs() = %s, %s, want %s, %s", ipv4, ipv6, tt.ipv4, tt.ipv6)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PipelineOperation is the pipeline run on a cluster.
+// +kubebuilder:validation:Enum=CreateCluster;AddNodes;DeleteNode;UpgradeCluster
+type PipelineOperation string
+
+const (
+	CreateClusterOperation  PipelineOperation = "CreateCluster"
+	AddNodesOperation       PipelineOperation = "AddNodes"
+	DeleteNodeOperation     PipelineOperation = "DeleteNode"
+	UpgradeClusterOperation PipelineOperation = "UpgradeCluster"
+)
+
+// PipelinePhase is the phase of a pipeline run.
+type PipelinePhase string
+
+const (
+	PipelinePending   PipelinePhase = "Pending"
+	PipelineRunning   PipelinePhase = "Running"
+	PipelineSucceeded PipelinePhase = "Succeeded"
+	PipelineFailed    PipelinePhase = "Failed"
+)
+
+// PipelineSpec defines a pipeline run on a cluster.
+type PipelineSpec struct {
+	// Cluster is the name of the Cluster in the same namespace.
+	Cluster string `json:"cluster"`
+	// Operation is the pipeline run on the cluster.
+	Operation PipelineOperation `json:"operation"`
+	// Node is the name of the node deleted by the DeleteNode operation.
+	Node string `json:"node,omitempty"`
+}
+
+// PipelineStatus defines the observed state of Pipeline.
+type PipelineStatus struct {
+	// Phase is Pending, Running, Succeeded or Failed.
+	Phase PipelinePhase `json:"phase,omitempty"`
+	// Version is the kubernetes version of the cluster spec the pipeline runs with.
+	Version string `json:"version,omitempty"`
+	// Nodes are the names of the hosts of the cluster spec the pipeline runs with.
+	Nodes []string `json:"nodes,omitempty"`
+	// Attempts is the count of the runs, a run interrupted by a restart of the operator is resumed by the next one.
+	Attempts int `json:"attempts,omitempty"`
+	// Message is the error of the failed pipeline.
+	Message        string       `json:"message,omitempty"`
+	StartTime      *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.cluster"
+// +kubebuilder:printcolumn:name="Operation",type="string",JSONPath=".spec.operation"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// Pipeline is a run of a KubeKey pipeline on a Cluster by the operator.
+type Pipeline struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PipelineSpec   `json:"spec,omitempty"`
+	Status PipelineStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PipelineList contains a list of Pipeline
+type PipelineList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Pipeline `json:"items"`
+}
+
+// IsFinished returns whether the pipeline succeeded or failed.
+func (p *Pipeline) IsFinished() bool {
+	return p.Status.Phase == PipelineSucceeded || p.Status.Phase == PipelineFailed
+}
+
+func init() {
+	SchemeBuilder.Register(&Pipeline{}, &PipelineList{})
+}
@@ -0,0 +1,83 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha2
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+const (
+	PhaseRunning   = "Running"
+	PhaseSucceeded = "Succeeded"
+	PhaseFailed    = "Failed"
+)
+
+// ClusterProgress is the progress of the pipeline running on the cluster, or the last one, on each host. It is
+// recorded by the operator while the pipeline runs.
+type ClusterProgress struct {
+	// Pipeline is the name of the pipeline.
+	Pipeline string `json:"pipeline,omitempty"`
+	// ObservedGeneration is the generation of the spec the pipeline runs with.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Phase is the running phase of the pipeline, or the last one.
+	Phase string `json:"phase,omitempty"`
+	// Hosts are the progress of the pipeline on the hosts, by their names.
+	Hosts []HostProgress `json:"hosts,omitempty"`
+	// UpdateTime is the time the progress was last updated.
+	UpdateTime *metav1.Time `json:"updateTime,omitempty"`
+}
+
+// HostProgress is the progress of a pipeline on a host.
+type HostProgress struct {
+	Name string `json:"name"`
+	// Task is the task running on the host, or the last one.
+	Task string `json:"task,omitempty"`
+	// State is the state of the task: running, changed, ok, skipped or failed.
+	State string `json:"state,omitempty"`
+	// Phases are the phases run on the host, in their order.
+	Phases []PhaseProgress `json:"phases,omitempty"`
+	// LastError is the error of the last task failed on the host.
+	LastError     string       `json:"lastError,omitempty"`
+	LastErrorTime *metav1.Time `json:"lastErrorTime,omitempty"`
+}
+
+// PhaseProgress is the progress of a phase of a pipeline on a host, i.e. of the modules with the same tag.
+type PhaseProgress struct {
+	Name string `json:"name"`
+	// State is Running, Succeeded or Failed.
+	State          string       `json:"state"`
+	StartTime      *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// Host returns the progress of the host, nil if the pipeline hasn't run on it.
+func (p *ClusterProgress) Host(name string) *HostProgress {
+	for i := range p.Hosts {
+		if p.Hosts[i].Name == name {
+			return &p.Hosts[i]
+		}
+	}
+	return nil
+}
+
+// Phase returns the progress of the phase on the host, nil if the phase hasn't run on it.
+func (h *HostProgress) Phase(name string) *PhaseProgress {
+	for i := range h.Phases {
+		if h.Phases[i].Name == name {
+			return &h.Phases[i]
+		}
+	}
+	return nil
+}
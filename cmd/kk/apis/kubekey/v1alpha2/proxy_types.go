@@ -0,0 +1,71 @@
+/*
+ Copyright 2022 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"strings"
+)
+
+// Proxy defines the HTTP(S) proxy of the nodes, which is set for the container runtime, kubelet and the package
+// manager on each node.
+type Proxy struct {
+	HTTPProxy  string `yaml:"httpProxy" json:"httpProxy,omitempty"`
+	HTTPSProxy string `yaml:"httpsProxy" json:"httpsProxy,omitempty"`
+	// NoProxy are the extra hosts, domains and CIDRs not to be proxied, besides the ones generated by NoProxy of the
+	// cluster, e.g. the addresses of the private registry.
+	NoProxy []string `yaml:"noProxy" json:"noProxy,omitempty"`
+}
+
+// Enabled returns whether the nodes are behind a proxy.
+func (p Proxy) Enabled() bool {
+	return p.HTTPProxy != "" || p.HTTPSProxy != ""
+}
+
+// NoProxy returns the comma separated no_proxy of the nodes. It covers the loopback, the cluster domain, the pod and
+// service CIDRs, the control plane endpoint and the names and addresses of all the hosts, followed by the noProxy of
+// the proxy.
+func (cfg *ClusterSpec) NoProxy() string {
+	var entries []string
+	seen := make(map[string]struct{})
+	add := func(values ...string) {
+		for _, v := range values {
+			for _, s := range strings.Split(v, ",") {
+				s = strings.TrimSpace(s)
+				if s == "" {
+					continue
+				}
+				if _, ok := seen[s]; ok {
+					continue
+				}
+				seen[s] = struct{}{}
+				entries = append(entries, s)
+			}
+		}
+	}
+
+	add("localhost", "127.0.0.1", ".svc")
+	if cfg.Kubernetes.DNSDomain != "" {
+		add("." + cfg.Kubernetes.DNSDomain)
+	}
+	add(cfg.Network.KubePodsCIDR, cfg.Network.KubeServiceCIDR)
+	add(cfg.ControlPlaneEndpoint.Domain, cfg.ControlPlaneEndpoint.Address)
+	for _, host := range cfg.Hosts {
+		add(host.Name, host.Address, host.InternalAddress)
+	}
+	add(cfg.System.Proxy.NoProxy...)
+	return strings.Join(entries, ",")
+}
@@ -0,0 +1,34 @@
+/*
+ Copyright 2022 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha2
+
+import "testing"
+
+func TestNoProxy(t *testing.T) {
+	cfg := validClusterSpec()
+	cfg.Hosts[0].InternalAddress = "192.168.0.1,fd00::1"
+	cfg.Kubernetes.DNSDomain = "cluster.local"
+	cfg.Network = NetworkConfig{KubePodsCIDR: "10.233.64.0/18", KubeServiceCIDR: "10.233.0.0/18"}
+	cfg.ControlPlaneEndpoint = ControlPlaneEndpoint{Domain: "lb.kubesphere.local", Address: "192.168.0.1"}
+	cfg.System.Proxy = Proxy{HTTPProxy: "http://proxy:3128", NoProxy: []string{"registry.local", "node1"}}
+
+	want := "localhost,127.0.0.1,.svc,.cluster.local,10.233.64.0/18,10.233.0.0/18,lb.kubesphere.local,192.168.0.1," +
+		"node1,fd00::1,node2,192.168.0.2,registry.local"
+	if got := cfg.NoProxy(); got != want {
+		t.Errorf("NoProxy() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,46 @@
+/*
+ Copyright 2022 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha2
+
+// DefaultPullSecretName is the name of the image pull secret of the registry auths.
+const DefaultPullSecretName = "kubekey-registry-auth"
+
+// PullSecret defines the kubernetes.io/dockerconfigjson Secret of the registry auths with a username and a password,
+// which is created in the namespaces after the installation and updated by each run, so the registry credentials are
+// rotated in the cluster as on the nodes.
+type PullSecret struct {
+	// Name is the name of the Secret, defaults to kubekey-registry-auth.
+	Name string `yaml:"name" json:"name,omitempty"`
+	// Namespaces are the namespaces the Secret is created in, none by default.
+	Namespaces []string `yaml:"namespaces" json:"namespaces,omitempty"`
+	// PatchServiceAccounts adds the Secret to the imagePullSecrets of the default ServiceAccount of the namespaces, so
+	// the pods don't have to reference it.
+	PatchServiceAccounts bool `yaml:"patchServiceAccounts" json:"patchServiceAccounts,omitempty"`
+}
+
+// Enabled returns if the Secret is created in any namespace.
+func (p PullSecret) Enabled() bool {
+	return len(p.Namespaces) > 0
+}
+
+// SecretName returns the name of the Secret.
+func (p PullSecret) SecretName() string {
+	if p.Name == "" {
+		return DefaultPullSecretName
+	}
+	return p.Name
+}
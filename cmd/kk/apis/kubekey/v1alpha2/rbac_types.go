@@ -0,0 +1,33 @@
+/*
+ Copyright 2022 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha2
+
+// RBAC defines the initial RBAC of the cluster bound after its installation, e.g. for the groups of the OIDC tokens
+// authenticated by the api server. The groups are bound by the ClusterRoleBindings of KubeKey, which are kept in sync
+// with them by each run.
+type RBAC struct {
+	// AdminGroups are bound to the cluster-admin ClusterRole.
+	AdminGroups []string `yaml:"adminGroups" json:"adminGroups,omitempty"`
+	// ReadOnlyGroups are bound to the view ClusterRole, which reads the most resources of all the namespaces but the
+	// secrets.
+	ReadOnlyGroups []string `yaml:"readOnlyGroups" json:"readOnlyGroups,omitempty"`
+}
+
+// Enabled returns if any group is bound.
+func (r RBAC) Enabled() bool {
+	return len(r.AdminGroups) > 0 || len(r.ReadOnlyGroups) > 0
+}
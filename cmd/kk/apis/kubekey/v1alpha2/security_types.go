@@ -0,0 +1,40 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha2
+
+const (
+	SELinuxEnforcing  = "enforcing"
+	SELinuxPermissive = "permissive"
+	SELinuxDisabled   = "disabled"
+
+	AppArmorEnabled  = "enabled"
+	AppArmorDisabled = "disabled"
+)
+
+// SELinuxModes are the modes of SELinux on the nodes.
+var SELinuxModes = []string{SELinuxEnforcing, SELinuxPermissive, SELinuxDisabled}
+
+// AppArmorModes are the modes of AppArmor on the nodes.
+var AppArmorModes = []string{AppArmorEnabled, AppArmorDisabled}
+
+// SELinuxMode returns the mode of SELinux on the nodes, which defaults to disabled.
+func (s System) SELinuxMode() string {
+	if s.SELinux == "" {
+		return SELinuxDisabled
+	}
+	return s.SELinux
+}
@@ -16,10 +16,37 @@
 
 package v1alpha2
 
+const (
+	StorageOpenEBS   = "openebs"
+	StorageLocalPath = "local-path"
+	StorageLonghorn  = "longhorn"
+	StorageNFS       = "nfs"
+)
+
 type StorageConfig struct {
-	OpenEBS OpenEBSCfg `yaml:"openebs" json:"openebs,omitempty"`
+	// Provider is the default StorageClass provider deployed in the cluster: openebs, local-path, longhorn or nfs.
+	// If it's empty, the OpenEBS local PV is deployed with --with-local-storage or KubeSphere.
+	Provider  string       `yaml:"provider" json:"provider,omitempty"`
+	OpenEBS   OpenEBSCfg   `yaml:"openebs" json:"openebs,omitempty"`
+	LocalPath LocalPathCfg `yaml:"localPath" json:"localPath,omitempty"`
+	Longhorn  LonghornCfg  `yaml:"longhorn" json:"longhorn,omitempty"`
+	NFS       NFSCfg       `yaml:"nfs" json:"nfs,omitempty"`
 }
 
 type OpenEBSCfg struct {
 	BasePath string `yaml:"basePath" json:"basePath,omitempty"`
 }
+
+type LocalPathCfg struct {
+	Path string `yaml:"path" json:"path,omitempty"`
+}
+
+type LonghornCfg struct {
+	Version  string `yaml:"version" json:"version,omitempty"`
+	Replicas int    `yaml:"replicas" json:"replicas,omitempty"`
+}
+
+type NFSCfg struct {
+	Server string `yaml:"server" json:"server,omitempty"`
+	Path   string `yaml:"path" json:"path,omitempty"`
+}
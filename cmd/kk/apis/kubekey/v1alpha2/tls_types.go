@@ -0,0 +1,71 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"strings"
+)
+
+const (
+	TLSVersion12 = "VersionTLS12"
+	TLSVersion13 = "VersionTLS13"
+)
+
+// TLSVersions are the min TLS versions of the servers of the cluster which can be set.
+var TLSVersions = []string{TLSVersion12, TLSVersion13}
+
+// TLS defines the TLS policies of the servers of the cluster, e.g. to satisfy the crypto standards of an
+// organization without patching the manifests. The policies override the defaults of KubeKey, of the security
+// enhancement and of the FIPS mode, but the apiserverArgs and the kubeletConfiguration override them.
+type TLS struct {
+	// Apiserver is the policy of kube-apiserver.
+	Apiserver TLSPolicy `yaml:"apiserver" json:"apiserver,omitempty"`
+	// Etcd is the policy of etcd installed by KubeKey or kubeadm, for the clients and the peers.
+	Etcd TLSPolicy `yaml:"etcd" json:"etcd,omitempty"`
+	// Kubelet is the policy of the kubelet on all the nodes.
+	Kubelet TLSPolicy `yaml:"kubelet" json:"kubelet,omitempty"`
+}
+
+// TLSPolicy restricts the TLS versions and cipher suites a server accepts.
+type TLSPolicy struct {
+	// MinVersion is the min TLS version, VersionTLS12 or VersionTLS13.
+	MinVersion string `yaml:"minVersion" json:"minVersion,omitempty"`
+	// CipherSuites are the cipher suites of TLS 1.2 named as in Go, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256.
+	// The cipher suites of TLS 1.3 aren't configurable.
+	CipherSuites []string `yaml:"cipherSuites" json:"cipherSuites,omitempty"`
+}
+
+// IsSet returns whether the policy sets anything.
+func (p TLSPolicy) IsSet() bool {
+	return p.MinVersion != "" || len(p.CipherSuites) > 0
+}
+
+// CipherSuitesArg returns the cipher suites as the value of a flag, e.g. --tls-cipher-suites.
+func (p TLSPolicy) CipherSuitesArg() string {
+	return strings.Join(p.CipherSuites, ",")
+}
+
+// EtcdMinVersion returns the min version in the format of etcd, e.g. TLS1.2.
+func (p TLSPolicy) EtcdMinVersion() string {
+	switch p.MinVersion {
+	case TLSVersion12:
+		return "TLS1.2"
+	case TLSVersion13:
+		return "TLS1.3"
+	}
+	return ""
+}
@@ -0,0 +1,191 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	DistributionStrategyNone   = "none"
+	DistributionStrategySubnet = "subnet"
+	DistributionStrategyRack   = "rack"
+
+	DefaultSubnetMaskSize         = 24
+	DefaultMaxTransfersPerSegment = 2
+
+	LabelTopologyRegion = "topology.kubernetes.io/region"
+	LabelTopologyZone   = "topology.kubernetes.io/zone"
+	LabelTopologyRack   = "topology.kubesphere.io/rack"
+
+	TaintEffectNoSchedule       = "NoSchedule"
+	TaintEffectPreferNoSchedule = "PreferNoSchedule"
+	TaintEffectNoExecute        = "NoExecute"
+)
+
+// TaintEffects are the effects of the taints of the nodes.
+var TaintEffects = []string{TaintEffectNoSchedule, TaintEffectPreferNoSchedule, TaintEffectNoExecute}
+
+// Topology defines how the hosts are laid out on the network.
+type Topology struct {
+	// DistributionStrategy decides how the hosts are grouped when KubeKey distributes artifacts to them.
+	// Support: none, subnet, rack [Default: none]
+	// The rack strategy groups the hosts by their failure domain (region/zone/rack), and falls back to subnet if none is declared.
+	DistributionStrategy string `yaml:"distributionStrategy" json:"distributionStrategy,omitempty"`
+	// SubnetMaskSize is the prefix length used to group IPv4 addresses into the same segment. [Default: 24]
+	SubnetMaskSize int `yaml:"subnetMaskSize" json:"subnetMaskSize,omitempty"`
+	// MaxTransfersPerSegment is the max number of the hosts of a segment receiving the artifacts at the same time, so
+	// the link of the segment isn't saturated by all its hosts at once. It only applies to the subnet and rack strategies. [Default: 2]
+	MaxTransfersPerSegment int `yaml:"maxTransfersPerSegment" json:"maxTransfersPerSegment,omitempty"`
+}
+
+// NodeGroup defines the failure domain, the labels and the taints of the nodes of a role group, e.g. the worker
+// group or a custom group of GPU nodes. The fields of a host override the ones of its groups.
+type NodeGroup struct {
+	Region string            `yaml:"region,omitempty" json:"region,omitempty"`
+	Zone   string            `yaml:"zone,omitempty" json:"zone,omitempty"`
+	Rack   string            `yaml:"rack,omitempty" json:"rack,omitempty"`
+	Labels map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Taints []Taint           `yaml:"taints,omitempty" json:"taints,omitempty"`
+}
+
+// Taint is a kubernetes taint of the node, e.g. dedicated=gpu:NoSchedule.
+type Taint struct {
+	Key   string `yaml:"key" json:"key"`
+	Value string `yaml:"value,omitempty" json:"value,omitempty"`
+	// Effect is the effect of the taint. Support: NoSchedule, PreferNoSchedule, NoExecute
+	Effect string `yaml:"effect" json:"effect"`
+}
+
+// String returns the taint as the argument of kubectl taint, e.g. dedicated=gpu:NoSchedule.
+func (t Taint) String() string {
+	if t.Value == "" {
+		return t.Key + ":" + t.Effect
+	}
+	return t.Key + "=" + t.Value + ":" + t.Effect
+}
+
+// applyNodeGroups applies the node groups to the hosts of their role groups. The groups are applied in the order of
+// their names, so a later group overrides an earlier one, and the fields declared by the host override them all.
+func (cfg *ClusterSpec) applyNodeGroups(roleGroups map[string][]*KubeHost) {
+	if len(cfg.NodeGroups) == 0 {
+		return
+	}
+	names := make([]string, 0, len(cfg.NodeGroups))
+	for name := range cfg.NodeGroups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	groups := make(map[*KubeHost][]NodeGroup)
+	var hosts []*KubeHost
+	for _, name := range names {
+		for _, host := range roleGroups[name] {
+			if _, ok := groups[host]; !ok {
+				hosts = append(hosts, host)
+			}
+			groups[host] = append(groups[host], cfg.NodeGroups[name])
+		}
+	}
+	for _, host := range hosts {
+		host.applyNodeGroups(groups[host])
+	}
+}
+
+func (h *KubeHost) applyNodeGroups(groups []NodeGroup) {
+	own := NodeGroup{Region: h.Region, Zone: h.Zone, Rack: h.Rack, Labels: h.Labels, Taints: h.Taints}
+	merged := NodeGroup{Labels: make(map[string]string)}
+	for _, g := range append(groups, own) {
+		if g.Region != "" {
+			merged.Region = g.Region
+		}
+		if g.Zone != "" {
+			merged.Zone = g.Zone
+		}
+		if g.Rack != "" {
+			merged.Rack = g.Rack
+		}
+		for k, v := range g.Labels {
+			merged.Labels[k] = v
+		}
+		merged.Taints = MergeTaints(merged.Taints, g.Taints)
+	}
+	h.Region, h.Zone, h.Rack = merged.Region, merged.Zone, merged.Rack
+	h.Labels, h.Taints = merged.Labels, merged.Taints
+}
+
+// MergeTaints returns the taints with the overrides, a taint of the same key and effect is replaced.
+func MergeTaints(taints, overrides []Taint) []Taint {
+	merged := append([]Taint(nil), taints...)
+	for _, o := range overrides {
+		replaced := false
+		for i, t := range merged {
+			if t.Key == o.Key && t.Effect == o.Effect {
+				merged[i], replaced = o, true
+			}
+		}
+		if !replaced {
+			merged = append(merged, o)
+		}
+	}
+	return merged
+}
+
+// FailureDomain returns the failure domain of the host, e.g. region-a/zone-a/rack-a.
+// An empty string is returned if none of region, zone and rack is declared.
+func (h *KubeHost) FailureDomain() string {
+	parts := make([]string, 0, 3)
+	for _, p := range []string{h.Region, h.Zone, h.Rack} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// NodeLabels returns the kubernetes labels of the node, the topology labels are generated from region, zone and rack,
+// and they can be overridden by the labels declared explicitly.
+func (h *KubeHost) NodeLabels() map[string]string {
+	labels := make(map[string]string)
+	if h.Region != "" {
+		labels[LabelTopologyRegion] = h.Region
+	}
+	if h.Zone != "" {
+		labels[LabelTopologyZone] = h.Zone
+	}
+	if h.Rack != "" {
+		labels[LabelTopologyRack] = h.Rack
+	}
+	for k, v := range h.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+func SetDefaultTopologyCfg(cfg *ClusterSpec) Topology {
+	if cfg.Topology.DistributionStrategy == "" {
+		cfg.Topology.DistributionStrategy = DistributionStrategyNone
+	}
+	if cfg.Topology.SubnetMaskSize <= 0 || cfg.Topology.SubnetMaskSize > 32 {
+		cfg.Topology.SubnetMaskSize = DefaultSubnetMaskSize
+	}
+	if cfg.Topology.MaxTransfersPerSegment <= 0 {
+		cfg.Topology.MaxTransfersPerSegment = DefaultMaxTransfersPerSegment
+	}
+	return cfg.Topology
+}
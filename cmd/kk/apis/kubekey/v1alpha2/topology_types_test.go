@@ -0,0 +1,93 @@
+/*
+ Copyright 2022 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupHostsNodeGroups(t *testing.T) {
+	cfg := validClusterSpec()
+	timeout := int64(30)
+	for i := range cfg.Hosts {
+		cfg.Hosts[i].Timeout = &timeout
+	}
+	cfg.Hosts[1].Zone = "zone-b"
+	cfg.Hosts[1].Labels = map[string]string{"node.example.com/pool": "gpu"}
+	cfg.Hosts[1].Taints = []Taint{{Key: "dedicated", Value: "gpu", Effect: TaintEffectNoSchedule}}
+	cfg.RoleGroups["gpu"] = []string{"node2"}
+	cfg.NodeGroups = map[string]NodeGroup{
+		"gpu": {Taints: []Taint{{Key: "nvidia.com/gpu", Effect: TaintEffectNoSchedule}}},
+		Worker: {
+			Region: "region-a",
+			Zone:   "zone-a",
+			Labels: map[string]string{"node.example.com/pool": "general"},
+			Taints: []Taint{{Key: "dedicated", Value: "general", Effect: TaintEffectNoSchedule}},
+		},
+	}
+
+	hosts := make(map[string]*KubeHost)
+	for _, host := range cfg.GroupHosts()[Worker] {
+		hosts[host.Name] = host
+	}
+	tests := []struct {
+		name   string
+		labels map[string]string
+		taints []Taint
+	}{
+		{
+			name: "node1",
+			labels: map[string]string{
+				LabelTopologyRegion: "region-a", LabelTopologyZone: "zone-a", "node.example.com/pool": "general",
+			},
+			taints: []Taint{{Key: "dedicated", Value: "general", Effect: TaintEffectNoSchedule}},
+		},
+		{
+			name: "node2",
+			labels: map[string]string{
+				LabelTopologyRegion: "region-a", LabelTopologyZone: "zone-b", "node.example.com/pool": "gpu",
+			},
+			taints: []Taint{
+				{Key: "nvidia.com/gpu", Effect: TaintEffectNoSchedule},
+				{Key: "dedicated", Value: "gpu", Effect: TaintEffectNoSchedule},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host := hosts[tt.name]
+			if got := host.NodeLabels(); !reflect.DeepEqual(got, tt.labels) {
+				t.Errorf("NodeLabels() = %v, want %v", got, tt.labels)
+			}
+			if !reflect.DeepEqual(host.Taints, tt.taints) {
+				t.Errorf("Taints = %v, want %v", host.Taints, tt.taints)
+			}
+		})
+	}
+}
+
+func TestTaintString(t *testing.T) {
+	for taint, want := range map[Taint]string{
+		{Key: "dedicated", Value: "gpu", Effect: TaintEffectNoSchedule}: "dedicated=gpu:NoSchedule",
+		{Key: "nvidia.com/gpu", Effect: TaintEffectNoExecute}:           "nvidia.com/gpu:NoExecute",
+	} {
+		if got := taint.String(); got != want {
+			t.Errorf("String() = %s, want %s", got, want)
+		}
+	}
+}
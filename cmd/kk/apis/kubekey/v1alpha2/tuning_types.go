@@ -0,0 +1,46 @@
+/*
+ Copyright 2022 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha2
+
+const (
+	TuningDefault     = "default"
+	TuningHighDensity = "high-density"
+	TuningLowLatency  = "low-latency"
+	TuningCustom      = "custom"
+)
+
+// TuningProfiles are the profiles of the kernel tuning.
+var TuningProfiles = []string{TuningDefault, TuningHighDensity, TuningLowLatency, TuningCustom}
+
+// Tuning selects the profile of the kernel parameters and the resource limits applied to all the nodes. The kernel
+// parameters Kubernetes relies on, e.g. net.ipv4.ip_forward, are set whatever the profile.
+type Tuning struct {
+	// Profile is default, high-density, low-latency or custom, defaults to default.
+	Profile string `yaml:"profile" json:"profile,omitempty"`
+	// File is the local file of the custom profile, with the sysctls and the limits of the nodes.
+	File string `yaml:"file" json:"file,omitempty"`
+	// Sysctls override the kernel parameters of the profile.
+	Sysctls map[string]string `yaml:"sysctls" json:"sysctls,omitempty"`
+}
+
+// ProfileName returns the name of the profile.
+func (t Tuning) ProfileName() string {
+	if t.Profile == "" {
+		return TuningDefault
+	}
+	return t.Profile
+}
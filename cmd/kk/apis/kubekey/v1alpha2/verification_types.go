@@ -0,0 +1,116 @@
+/*
+ Copyright 2022 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package v1alpha2
+
+import "time"
+
+const (
+	SmokeTestDNS          = "dns"
+	SmokeTestPodNetwork   = "podNetwork"
+	SmokeTestService      = "service"
+	SmokeTestPVC          = "pvc"
+	SmokeTestLoadBalancer = "loadBalancer"
+	SmokeTestIngress      = "ingress"
+
+	ConformanceQuick         = "quick"
+	ConformanceNonDisruptive = "non-disruptive-conformance"
+	ConformanceCertified     = "certified-conformance"
+
+	DefaultSmokeTestTimeout   = 5 * time.Minute
+	DefaultConformanceTimeout = 3 * time.Hour
+	DefaultSonobuoyVersion    = "v0.57.1"
+)
+
+// SmokeTests are the smoke tests of the verification, in the order they are run.
+var SmokeTests = []string{SmokeTestDNS, SmokeTestPodNetwork, SmokeTestService, SmokeTestPVC, SmokeTestLoadBalancer, SmokeTestIngress}
+
+// ConformanceModes are the modes of the conformance tests of sonobuoy.
+var ConformanceModes = []string{ConformanceQuick, ConformanceNonDisruptive, ConformanceCertified}
+
+// Verification defines the verification of the cluster after it's created, by the smoke tests of its DNS, network and
+// storage, and optionally by the conformance tests of sonobuoy. The result of each test is reported at the end of the
+// run, and the run fails if any test fails unless the failures are ignored.
+type Verification struct {
+	// SmokeTests are the smoke tests to run: dns, podNetwork, service, pvc, loadBalancer and ingress. None is run if
+	// it is empty.
+	SmokeTests []string `yaml:"smokeTests" json:"smokeTests,omitempty"`
+	// Timeout is the timeout of each smoke test, e.g. 10m, defaults to 5m.
+	Timeout string `yaml:"timeout" json:"timeout,omitempty"`
+	// IgnoreFailures reports the failed tests without failing the run.
+	IgnoreFailures bool `yaml:"ignoreFailures" json:"ignoreFailures,omitempty"`
+	// Conformance runs the conformance tests of sonobuoy after the smoke tests.
+	Conformance Conformance `yaml:"conformance" json:"conformance,omitempty"`
+}
+
+// Conformance defines the conformance tests run by sonobuoy on the first control-plane node. The sonobuoy on the node
+// is used, or it is downloaded from its releases.
+type Conformance struct {
+	Enabled bool `yaml:"enabled" json:"enabled,omitempty"`
+	// Mode is the mode of sonobuoy: quick, non-disruptive-conformance or certified-conformance, defaults to quick.
+	Mode string `yaml:"mode" json:"mode,omitempty"`
+	// Version is the version of sonobuoy, defaults to v0.57.1.
+	Version string `yaml:"version" json:"version,omitempty"`
+	// Timeout is the timeout of the tests, e.g. 1h, defaults to 3h.
+	Timeout string `yaml:"timeout" json:"timeout,omitempty"`
+}
+
+// Enabled returns whether any test is run.
+func (v Verification) Enabled() bool {
+	return len(v.SmokeTests) > 0 || v.Conformance.Enabled
+}
+
+// Runs returns whether the smoke test is run.
+func (v Verification) Runs(test string) bool {
+	for _, t := range v.SmokeTests {
+		if t == test {
+			return true
+		}
+	}
+	return false
+}
+
+// TestTimeout returns the timeout of each smoke test.
+func (v Verification) TestTimeout() time.Duration {
+	if timeout, err := time.ParseDuration(v.Timeout); err == nil && timeout > 0 {
+		return timeout
+	}
+	return DefaultSmokeTestTimeout
+}
+
+// ModeName returns the mode of sonobuoy.
+func (c Conformance) ModeName() string {
+	if c.Mode == "" {
+		return ConformanceQuick
+	}
+	return c.Mode
+}
+
+// SonobuoyVersion returns the version of sonobuoy.
+func (c Conformance) SonobuoyVersion() string {
+	if c.Version == "" {
+		return DefaultSonobuoyVersion
+	}
+	return c.Version
+}
+
+// TestTimeout returns the timeout of the conformance tests.
+func (c Conformance) TestTimeout() time.Duration {
+	if timeout, err := time.ParseDuration(c.Timeout); err == nil && timeout > 0 {
+		return timeout
+	}
+	return DefaultConformanceTimeout
+}
@@ -69,7 +69,10 @@ func (o *AddNodesOptions) Run() error {
 	arg := common.Argument{
 		FilePath:         o.ClusterCfgFile,
 		KsEnable:         false,
-		Debug:            o.CommonOptions.Verbose,
+		Strategy:         o.CommonOptions.Strategy,
+		Resume:           o.CommonOptions.Resume,
+		Tags:             o.CommonOptions.Tags,
+		SkipTags:         o.CommonOptions.SkipTags,
 		IgnoreErr:        o.CommonOptions.IgnoreErr,
 		SkipConfirmCheck: o.CommonOptions.SkipConfirmCheck,
 		SkipPullImages:   o.SkipPullImages,
@@ -77,16 +80,18 @@ func (o *AddNodesOptions) Run() error {
 		Artifact:         o.Artifact,
 		InstallPackages:  o.InstallPackages,
 		Namespace:        o.CommonOptions.Namespace,
+		DownloadCmd:      o.DownloadCmd,
 	}
-	return pipelines.AddNodes(arg, o.DownloadCmd)
+	o.CommonOptions.ApplyTo(&arg)
+	return pipelines.AddNodes(arg)
 }
 
 func (o *AddNodesOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file")
 	cmd.Flags().BoolVarP(&o.SkipPullImages, "skip-pull-images", "", false, "Skip pre pull images")
 	cmd.Flags().StringVarP(&o.ContainerManager, "container-manager", "", "docker", "Container manager: docker, crio, containerd and isula.")
-	cmd.Flags().StringVarP(&o.DownloadCmd, "download-cmd", "", "curl -L -o %s %s",
-		`The user defined command to download the necessary binary files. The first param '%s' is output path, the second param '%s', is the URL`)
+	cmd.Flags().StringVarP(&o.DownloadCmd, "download-cmd", "", "",
+		`The user defined command to download the necessary binary files. The first param '%s' is output path, the second param '%s', is the URL. The built-in downloader, configured by --download-policy, is used if it is empty`)
 	cmd.Flags().StringVarP(&o.Artifact, "artifact", "a", "", "Path to a KubeKey artifact")
 	cmd.Flags().BoolVarP(&o.InstallPackages, "with-packages", "", false, "install operation system packages by artifact")
 }
@@ -0,0 +1,69 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package adopt
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/options"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+type AdoptClusterOptions struct {
+	CommonOptions  *options.CommonOptions
+	ClusterCfgFile string
+}
+
+func NewAdoptClusterOptions() *AdoptClusterOptions {
+	return &AdoptClusterOptions{
+		CommonOptions: options.NewCommonOptions(),
+	}
+}
+
+// NewCmdAdoptCluster creates a new adopt cluster command
+func NewCmdAdoptCluster() *cobra.Command {
+	o := NewAdoptClusterOptions()
+	cmd := &cobra.Command{
+		Use:   "cluster",
+		Short: "Validate a cluster created by kubeadm meets the requirements of KubeKey, and adopt it for upgrades and node deletions",
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Run())
+		},
+	}
+
+	o.CommonOptions.AddCommonFlag(cmd)
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func (o *AdoptClusterOptions) Run() error {
+	arg := common.Argument{
+		FilePath: o.ClusterCfgFile,
+		Strategy: o.CommonOptions.Strategy,
+		Resume:   o.CommonOptions.Resume,
+		Tags:     o.CommonOptions.Tags,
+		SkipTags: o.CommonOptions.SkipTags,
+	}
+	o.CommonOptions.ApplyTo(&arg)
+	return pipelines.AdoptCluster(arg)
+}
+
+func (o *AdoptClusterOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file")
+}
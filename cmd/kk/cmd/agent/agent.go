@@ -0,0 +1,33 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package agent
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewCmdAgent creates a new agent command
+func NewCmdAgent() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "agent",
+		Short: "Run the kk agent on a host, and issue the certs of the agents of a cluster",
+	}
+
+	cmd.AddCommand(NewCmdAgentServe())
+	cmd.AddCommand(NewCmdAgentCerts())
+	return cmd
+}
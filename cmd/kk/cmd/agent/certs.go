@@ -0,0 +1,73 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package agent
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/options"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+type CertsOptions struct {
+	CommonOptions  *options.CommonOptions
+	ClusterCfgFile string
+	Dir            string
+}
+
+func NewCertsOptions() *CertsOptions {
+	return &CertsOptions{
+		CommonOptions: options.NewCommonOptions(),
+	}
+}
+
+// NewCmdAgentCerts creates a new agent certs command
+func NewCmdAgentCerts() *cobra.Command {
+	o := NewCertsOptions()
+	cmd := &cobra.Command{
+		Use:   "certs",
+		Short: "Issue the certs of the agents of the hosts of a cluster",
+		Long: `Issue the certs of the kk agents of all the hosts of a cluster, for their names and addresses, and the client
+cert of kk, by a CA of the cluster. The CA and the client cert are kept if they exist, so the agents deployed already
+still trust kk, while the certs of the hosts are issued again.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Run())
+		},
+	}
+
+	o.CommonOptions.AddCommonFlag(cmd)
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func (o *CertsOptions) Run() error {
+	arg := common.Argument{
+		FilePath:         o.ClusterCfgFile,
+		Debug:            o.CommonOptions.Verbose,
+		NoTUI:            true,
+		SkipConfirmCheck: true,
+		Namespace:        o.CommonOptions.Namespace,
+	}
+	return pipelines.AgentCerts(arg, o.Dir)
+}
+
+func (o *CertsOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file")
+	cmd.Flags().StringVar(&o.Dir, "dir", "", "Dir of the certs, the default certs dir of the agent connector, pki/agent in the work dir of the cluster, by default")
+}
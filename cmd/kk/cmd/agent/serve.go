@@ -0,0 +1,90 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/agent"
+)
+
+type ServeOptions struct {
+	Listen   string
+	CertFile string
+	KeyFile  string
+	CAFile   string
+}
+
+func NewServeOptions() *ServeOptions {
+	return &ServeOptions{}
+}
+
+// NewCmdAgentServe creates a new agent serve command
+func NewCmdAgentServe() *cobra.Command {
+	o := NewServeOptions()
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the kk agent on the host",
+		Long: `Serve the kk agent, which runs the commands and transfers the files of kk on the host over gRPC with mutual
+TLS, for the hosts of the agent connector. It runs the commands as its user, so it runs as root, e.g. by a systemd
+unit. Only kk with the client cert signed by the CA is served.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Run())
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func (o *ServeOptions) Run() error {
+	config, err := agent.ServerTLSConfig(o.CertFile, o.KeyFile, o.CAFile)
+	if err != nil {
+		return err
+	}
+	lis, err := net.Listen("tcp", o.Listen)
+	if err != nil {
+		return errors.Wrapf(err, "listen on %s failed", o.Listen)
+	}
+	server := agent.NewServer(config)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		klog.Info("stopping the agent")
+		server.Stop()
+	}()
+	klog.Infof("the agent is serving on %s", lis.Addr())
+	return server.Serve(lis)
+}
+
+func (o *ServeOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.Listen, "listen", ":9443", "The address the agent listens on")
+	cmd.Flags().StringVar(&o.CertFile, "cert", "/etc/kubekey/agent/agent.crt", "Path to the cert of the agent, the <host>.crt written by kk agent certs")
+	cmd.Flags().StringVar(&o.KeyFile, "key", "/etc/kubekey/agent/agent.key", "Path to the key of the agent, the <host>.key written by kk agent certs")
+	cmd.Flags().StringVar(&o.CAFile, "client-ca", "/etc/kubekey/agent/ca.crt", "Path to the CA of the client certs of kk, the ca.crt written by kk agent certs")
+}
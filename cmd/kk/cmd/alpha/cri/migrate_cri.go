@@ -65,12 +65,17 @@ func NewCmdMigrateCri() *cobra.Command {
 func (o *MigrateCriOptions) Run() error {
 	arg := common.Argument{
 		FilePath:          o.ClusterCfgFile,
-		Debug:             o.CommonOptions.Verbose,
+		Strategy:          o.CommonOptions.Strategy,
+		Resume:            o.CommonOptions.Resume,
+		Tags:              o.CommonOptions.Tags,
+		SkipTags:          o.CommonOptions.SkipTags,
 		KubernetesVersion: o.Kubernetes,
 		Type:              o.Type,
 		Role:              o.Role,
+		DownloadCmd:       o.DownloadCmd,
 	}
-	return pipelines.MigrateCri(arg, o.DownloadCmd)
+	o.CommonOptions.ApplyTo(&arg)
+	return pipelines.MigrateCri(arg)
 }
 
 func (o *MigrateCriOptions) AddFlags(cmd *cobra.Command) {
@@ -78,8 +83,8 @@ func (o *MigrateCriOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.Type, "type", "", "", "Type of target CRI. Support: docker, containerd.")
 	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file")
 	cmd.Flags().StringVarP(&o.Kubernetes, "with-kubernetes", "", "", "Specify a supported version of kubernetes")
-	cmd.Flags().StringVarP(&o.DownloadCmd, "download-cmd", "", "curl -L -o %s %s",
-		`The user defined command to download the necessary binary files. The first param '%s' is output path, the second param '%s', is the URL`)
+	cmd.Flags().StringVarP(&o.DownloadCmd, "download-cmd", "", "",
+		`The user defined command to download the necessary binary files. The first param '%s' is output path, the second param '%s', is the URL. The built-in downloader, configured by --download-policy, is used if it is empty`)
 	cmd.Flags().StringVarP(&o.Artifact, "artifact", "a", "", "Path to a KubeKey artifact")
 }
 
@@ -24,6 +24,7 @@ import (
 	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/options"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	coreutil "github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
 )
 
@@ -34,6 +35,7 @@ type ArtifactExportOptions struct {
 	Output             string
 	CriSocket          string
 	DownloadCmd        string
+	Compression        string
 	SkipRemoveArtifact bool
 }
 
@@ -63,7 +65,11 @@ func NewCmdArtifactExport() *cobra.Command {
 
 func (o *ArtifactExportOptions) Complete(_ *cobra.Command, _ []string) error {
 	if o.Output == "" {
-		o.Output = "kubekey-artifact.tar.gz"
+		c, err := coreutil.ParseCompression(o.Compression)
+		if err != nil {
+			return err
+		}
+		o.Output = "kubekey-artifact" + c.Extension()
 	}
 	return nil
 }
@@ -72,6 +78,9 @@ func (o *ArtifactExportOptions) Validate(_ []string) error {
 	if o.ManifestFile == "" {
 		return fmt.Errorf("--manifest can not be an empty string")
 	}
+	if _, err := coreutil.ParseCompression(o.Compression); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -80,19 +89,23 @@ func (o *ArtifactExportOptions) Run() error {
 		ManifestFile:       o.ManifestFile,
 		Output:             o.Output,
 		CriSocket:          o.CriSocket,
+		Compression:        o.Compression,
 		Debug:              o.CommonOptions.Verbose,
 		IgnoreErr:          o.CommonOptions.IgnoreErr,
 		SkipRemoveArtifact: o.SkipRemoveArtifact,
+		DownloadCmd:        o.DownloadCmd,
 	}
 
-	return pipelines.ArtifactExport(arg, o.DownloadCmd)
+	return pipelines.ArtifactExport(arg)
 }
 
 func (o *ArtifactExportOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.ManifestFile, "manifest", "m", "", "Path to a manifest file")
 	cmd.Flags().StringVarP(&o.Output, "output", "o", "", "Path to a output path")
-	cmd.Flags().StringVarP(&o.DownloadCmd, "download-cmd", "", "curl -L -o %s %s",
-		`The user defined command to download the necessary binary files. The first param '%s' is output path, the second param '%s', is the URL`)
+	cmd.Flags().StringVarP(&o.DownloadCmd, "download-cmd", "", "",
+		`The user defined command to download the necessary binary files. The first param '%s' is output path, the second param '%s', is the URL. The built-in downloader, configured by --download-policy, is used if it is empty`)
+	cmd.Flags().StringVarP(&o.Compression, "compression", "", string(coreutil.CompressionGzip),
+		"The compression of the artifact, gzip or zstd. zstd packs large bundles faster and smaller")
 	cmd.Flags().BoolVarP(&o.SkipRemoveArtifact, "skip-remove-artifact", "", false, "Skip remove artifact")
 
 }
@@ -92,9 +92,13 @@ func (o *ArtifactImagesPushOptions) Run() error {
 		ImagesDir: o.ImageDirPath,
 		Artifact:  o.Artifact,
 		FilePath:  o.ClusterCfgFile,
-		Debug:     o.CommonOptions.Verbose,
+		Strategy:  o.CommonOptions.Strategy,
+		Resume:    o.CommonOptions.Resume,
+		Tags:      o.CommonOptions.Tags,
+		SkipTags:  o.CommonOptions.SkipTags,
 		IgnoreErr: o.CommonOptions.IgnoreErr,
 	}
+	o.CommonOptions.ApplyTo(&arg)
 	return runPush(arg)
 }
 
@@ -57,9 +57,13 @@ func NewCmdArtifactImport() *cobra.Command {
 
 func (o *ArtifactImportOptions) Run() error {
 	arg := common.Argument{
-		Debug:    o.CommonOptions.Verbose,
+		Strategy: o.CommonOptions.Strategy,
+		Resume:   o.CommonOptions.Resume,
+		Tags:     o.CommonOptions.Tags,
+		SkipTags: o.CommonOptions.SkipTags,
 		Artifact: o.Artifact,
 	}
+	o.CommonOptions.ApplyTo(&arg)
 	return artifact.ArtifactImport(arg)
 }
 
@@ -0,0 +1,84 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package artifact
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/options"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+type ArtifactRepositoryOptions struct {
+	CommonOptions *options.CommonOptions
+
+	ManifestFile string
+	Output       string
+}
+
+func NewArtifactRepositoryOptions() *ArtifactRepositoryOptions {
+	return &ArtifactRepositoryOptions{
+		CommonOptions: options.NewCommonOptions(),
+	}
+}
+
+// NewCmdArtifactRepository creates a new `kubekey artifact repository` command
+func NewCmdArtifactRepository() *cobra.Command {
+	o := NewArtifactRepositoryOptions()
+	cmd := &cobra.Command{
+		Use:   "repository",
+		Short: "Build the offline OS package repositories of the operating systems in a manifest",
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Validate(args))
+			util.CheckErr(o.Run())
+		},
+	}
+
+	o.CommonOptions.AddCommonFlag(cmd)
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func (o *ArtifactRepositoryOptions) Validate(_ []string) error {
+	if o.ManifestFile == "" {
+		return fmt.Errorf("--manifest can not be an empty string")
+	}
+	if o.Output == "" {
+		return fmt.Errorf("--output can not be an empty string")
+	}
+	return nil
+}
+
+func (o *ArtifactRepositoryOptions) Run() error {
+	arg := common.ArtifactArgument{
+		ManifestFile: o.ManifestFile,
+		Output:       o.Output,
+		Debug:        o.CommonOptions.Verbose,
+		IgnoreErr:    o.CommonOptions.IgnoreErr,
+	}
+
+	return pipelines.ArtifactRepository(arg)
+}
+
+func (o *ArtifactRepositoryOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.ManifestFile, "manifest", "m", "", "Path to a manifest file")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "repository", "Path to the dir the repositories are built into")
+}
@@ -0,0 +1,74 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package backup
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/options"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+type BackupOptions struct {
+	CommonOptions  *options.CommonOptions
+	ClusterCfgFile string
+	To             string
+	S3Endpoint     string
+}
+
+func NewBackupOptions() *BackupOptions {
+	return &BackupOptions{
+		CommonOptions: options.NewCommonOptions(),
+	}
+}
+
+// NewCmdBackup creates a new backup command
+func NewCmdBackup() *cobra.Command {
+	o := NewBackupOptions()
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up the etcd and the control plane of a cluster",
+		Long: `Back up the control plane of a cluster: the snapshot of the etcd deployed by KubeKey is taken on the first etcd
+node, and the certificates of the etcd nodes and /etc/kubernetes of the control-plane nodes, with the PKI, the static
+pod manifests and the kubeconfigs, are archived. The files are fetched to a dir named after the cluster and the time
+in --to, a local dir or an s3://<bucket>/<prefix> of AWS S3 or of the S3-compatible --s3-endpoint.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Run())
+		},
+	}
+
+	o.CommonOptions.AddCommonFlag(cmd)
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func (o *BackupOptions) Run() error {
+	arg := common.Argument{
+		FilePath:  o.ClusterCfgFile,
+		Namespace: o.CommonOptions.Namespace,
+	}
+	o.CommonOptions.ApplyTo(&arg)
+	return pipelines.BackupCluster(arg, o.To, o.S3Endpoint)
+}
+
+func (o *BackupOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file")
+	cmd.Flags().StringVar(&o.To, "to", "", "Local dir or s3://<bucket>/<prefix> of the backups, the backups dir in the work dir of the cluster by default")
+	cmd.Flags().StringVar(&o.S3Endpoint, "s3-endpoint", "", "URL of the S3-compatible service of the bucket, AWS S3 by default")
+}
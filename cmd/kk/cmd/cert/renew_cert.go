@@ -55,8 +55,12 @@ func NewCmdCertRenew() *cobra.Command {
 func (o *CertRenewOptions) Run() error {
 	arg := common.Argument{
 		FilePath: o.ClusterCfgFile,
-		Debug:    o.CommonOptions.Verbose,
+		Strategy: o.CommonOptions.Strategy,
+		Resume:   o.CommonOptions.Resume,
+		Tags:     o.CommonOptions.Tags,
+		SkipTags: o.CommonOptions.SkipTags,
 	}
+	o.CommonOptions.ApplyTo(&arg)
 	return pipelines.RenewCerts(arg)
 }
 
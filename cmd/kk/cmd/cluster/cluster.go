@@ -0,0 +1,95 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+const (
+	// ClusterEnv sets the default of --cluster.
+	ClusterEnv = "KUBEKEY_CLUSTER"
+	// NoContextAnnotation marks the commands whose -f isn't the config file of a cluster, e.g. the output of kk create
+	// config, which aren't run against the current cluster.
+	NoContextAnnotation = "kubekey.kubesphere.io/no-context"
+)
+
+// NewCmdCluster creates a new cluster command
+func NewCmdCluster() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cluster",
+		Short: "Manage the clusters managed from the work dir",
+		Long: `Manage the clusters managed from the work dir. A cluster is managed once kk is run with its config file by -f,
+which is recorded with the artifact in the work dir of the cluster. The commands run without -f are run against the
+current cluster, or the cluster of --cluster, with its config file and its artifact.`,
+	}
+
+	cmd.AddCommand(NewCmdClusterList())
+	cmd.AddCommand(NewCmdClusterUse())
+	return cmd
+}
+
+// ApplyContext sets the -f of the command to the config file of the cluster of the name, or of the current cluster if
+// the name is empty, unless -f is set, and the --artifact to its artifact unless it's set.
+func ApplyContext(cmd *cobra.Command, name string) error {
+	filename := cmd.Flags().Lookup("filename")
+	if filename == nil || cmd.Annotations[NoContextAnnotation] == "true" {
+		if name != "" && cmd.Flags().Changed("cluster") {
+			return errors.Errorf("--cluster isn't supported by %s", cmd.CommandPath())
+		}
+		return nil
+	}
+	if filename.Changed {
+		if name != "" && cmd.Flags().Changed("cluster") {
+			return errors.New("--cluster and -f can't be both set")
+		}
+		return nil
+	}
+
+	workDir, err := connector.DefaultWorkDir()
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		if name, err = connector.CurrentCluster(workDir); err != nil || name == "" {
+			return err
+		}
+	}
+	ctx, err := connector.LoadClusterContext(workDir, name)
+	if err != nil {
+		return err
+	}
+	if ctx == nil {
+		return errors.Errorf("the cluster %s isn't managed from %s, see kk cluster list", name, workDir)
+	}
+	if err := filename.Value.Set(ctx.Config); err != nil {
+		return err
+	}
+	if artifact := cmd.Flags().Lookup("artifact"); artifact != nil && !artifact.Changed && ctx.Artifact != "" {
+		if err := artifact.Value.Set(ctx.Artifact); err != nil {
+			return err
+		}
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "Using the cluster %s of %s\n", name, ctx.Config)
+	return nil
+}
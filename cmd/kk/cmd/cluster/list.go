@@ -0,0 +1,36 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+// NewCmdClusterList creates a new cluster list command
+func NewCmdClusterList() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the clusters managed from the work dir with their config files, kubeconfigs and last runs",
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(pipelines.ListClusters())
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,71 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+type ClusterUseOptions struct {
+	Unset bool
+	name  string
+}
+
+// NewCmdClusterUse creates a new cluster use command
+func NewCmdClusterUse() *cobra.Command {
+	o := &ClusterUseOptions{}
+	cmd := &cobra.Command{
+		Use:   "use [name]",
+		Short: "Set the current cluster the commands without -f are run against",
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Complete(cmd, args))
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().BoolVar(&o.Unset, "unset", false, "Unset the current cluster, the commands without -f run against the local host again")
+	return cmd
+}
+
+func (o *ClusterUseOptions) Complete(_ *cobra.Command, args []string) error {
+	if len(args) > 1 {
+		return errors.New("only one cluster can be used")
+	}
+	if len(args) == 1 {
+		o.name = args[0]
+	}
+	return nil
+}
+
+func (o *ClusterUseOptions) Validate() error {
+	if o.name == "" && !o.Unset {
+		return errors.New("cluster name can not be empty")
+	}
+	if o.name != "" && o.Unset {
+		return errors.New("a cluster name and --unset can't be both set")
+	}
+	return nil
+}
+
+func (o *ClusterUseOptions) Run() error {
+	return pipelines.UseCluster(o.name)
+}
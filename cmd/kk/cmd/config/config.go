@@ -0,0 +1,82 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package config
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/config"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+// NewCmdConfig creates a new config command
+func NewCmdConfig() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the cluster configuration files",
+	}
+
+	cmd.AddCommand(NewCmdConfigExport())
+	return cmd
+}
+
+type ConfigExportOptions struct {
+	KubeConfig string
+	Output     string
+	SSH        bool
+	Options    config.ExportOptions
+}
+
+// NewCmdConfigExport creates a new config export command
+func NewCmdConfigExport() *cobra.Command {
+	o := &ConfigExportOptions{}
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the cluster configuration of a live cluster",
+		Long: `Export the cluster configuration of a live cluster, by which KubeKey can upgrade the cluster and add or delete
+its nodes. The Kubernetes version, the container runtime, the CNI, the etcd topology and the roles of the nodes are
+inspected by the Kubernetes API. With --ssh, the etcd servers are also inspected over SSH with the credentials of the
+flags, so the etcd installed by KubeKey on the hosts which aren't nodes is found. What the cluster couldn't tell is
+printed as notices at the top of the configuration.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Run())
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func (o *ConfigExportOptions) Run() error {
+	arg := common.Argument{
+		KubeConfig: o.KubeConfig,
+	}
+	return pipelines.ExportConfig(arg, o.Options, o.SSH, o.Output)
+}
+
+func (o *ConfigExportOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.KubeConfig, "kubeconfig", "", "Path to a kubeconfig file, ~/.kube/config by default")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "", "Path to the exported configuration file, the stdout by default")
+	cmd.Flags().StringVar(&o.Options.Name, "name", "", "The name of the cluster config, config-sample by default")
+	cmd.Flags().BoolVar(&o.SSH, "ssh", false, "Inspect the etcd servers over SSH")
+	cmd.Flags().StringVarP(&o.Options.User, "user", "u", "", "The SSH user of the hosts")
+	cmd.Flags().StringVarP(&o.Options.Password, "password", "p", "", "The SSH password of the hosts")
+	cmd.Flags().StringVarP(&o.Options.PrivateKeyPath, "private-key", "i", "", "The SSH private key of the hosts")
+	cmd.Flags().IntVar(&o.Options.Port, "port", 0, "The SSH port of the hosts, 22 by default")
+}
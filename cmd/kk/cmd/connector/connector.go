@@ -0,0 +1,32 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewCmdConnector creates a new connector command
+func NewCmdConnector() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "connector",
+		Short: "Qualify the connections to the hosts of a cluster",
+	}
+
+	cmd.AddCommand(NewCmdConnectorTest())
+	return cmd
+}
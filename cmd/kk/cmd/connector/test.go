@@ -0,0 +1,92 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+type ConnectorTestOptions struct {
+	ClusterCfgFile string
+	Debug          bool
+	FileSize       int64
+	Reboot         bool
+	RebootTimeout  time.Duration
+	host           string
+}
+
+// NewCmdConnectorTest creates a new connector test command
+func NewCmdConnectorTest() *cobra.Command {
+	o := &ConnectorTestOptions{}
+	cmd := &cobra.Command{
+		Use:   "test <host>",
+		Short: "Run the connector checks against a host of a cluster and print its capability report",
+		Long: `Run the standardized checks of the connections against a host of a cluster: the commands and their long
+output, the exit codes, the unicode paths, the copy and the fetch of a large file and sudo. With --reboot the host is
+rebooted to check the connection survives it. The report tells whether a new environment is fit for kk before a
+cluster is installed in it.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Complete(cmd, args))
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file")
+	cmd.Flags().BoolVar(&o.Debug, "debug", false, "Print detailed information")
+	cmd.Flags().Int64Var(&o.FileSize, "file-size", connector.DefaultCompatFileSize>>20, "Size in MiB of the large file copied to and fetched from the host")
+	cmd.Flags().BoolVar(&o.Reboot, "reboot", false, "Reboot the host to check the connection survives it")
+	cmd.Flags().DurationVar(&o.RebootTimeout, "reboot-timeout", connector.DefaultRebootTimeout, "How long to wait for the host to come back after the reboot")
+	return cmd
+}
+
+func (o *ConnectorTestOptions) Complete(_ *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		o.host = args[0]
+	}
+	return nil
+}
+
+func (o *ConnectorTestOptions) Validate() error {
+	if o.host == "" {
+		return errors.New("host name can not be empty")
+	}
+	if o.FileSize <= 0 {
+		return errors.New("--file-size must be positive")
+	}
+	return nil
+}
+
+func (o *ConnectorTestOptions) Run() error {
+	arg := common.Argument{
+		FilePath: o.ClusterCfgFile,
+		Debug:    o.Debug,
+	}
+	return pipelines.TestConnector(arg, o.host, connector.CompatOptions{
+		FileSize:      o.FileSize << 20,
+		Reboot:        o.Reboot,
+		RebootTimeout: o.RebootTimeout,
+	})
+}
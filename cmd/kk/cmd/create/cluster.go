@@ -46,6 +46,9 @@ type CreateClusterOptions struct {
 	Artifact            string
 	InstallPackages     bool
 	WithBuildx          bool
+	Provision           bool
+	MergeKubeConfig     bool
+	KubeConfigContext   string
 
 	localStorageChanged bool
 }
@@ -104,6 +107,9 @@ func (o *CreateClusterOptions) Validate(_ *cobra.Command, _ []string) error {
 	default:
 		return fmt.Errorf("unsupport container runtime [%s]", o.ContainerManager)
 	}
+	if o.KubeConfigContext != "" && !o.MergeKubeConfig {
+		return fmt.Errorf("--kubeconfig-context requires --merge-kubeconfig")
+	}
 	return nil
 }
 
@@ -116,7 +122,10 @@ func (o *CreateClusterOptions) Run() error {
 		SkipPullImages:      o.SkipPullImages,
 		SkipPushImages:      o.SkipPushImages,
 		SecurityEnhancement: o.SecurityEnhancement,
-		Debug:               o.CommonOptions.Verbose,
+		Strategy:            o.CommonOptions.Strategy,
+		Resume:              o.CommonOptions.Resume,
+		Tags:                o.CommonOptions.Tags,
+		SkipTags:            o.CommonOptions.SkipTags,
 		IgnoreErr:           o.CommonOptions.IgnoreErr,
 		SkipConfirmCheck:    o.CommonOptions.SkipConfirmCheck,
 		ContainerManager:    o.ContainerManager,
@@ -124,14 +133,19 @@ func (o *CreateClusterOptions) Run() error {
 		InstallPackages:     o.InstallPackages,
 		Namespace:           o.CommonOptions.Namespace,
 		WithBuildx:          o.WithBuildx,
+		Provision:           o.Provision,
+		MergeKubeConfig:     o.MergeKubeConfig,
+		KubeConfigContext:   o.KubeConfigContext,
+		DownloadCmd:         o.DownloadCmd,
 	}
+	o.CommonOptions.ApplyTo(&arg)
 
 	if o.localStorageChanged {
 		deploy := o.LocalStorage
 		arg.DeployLocalStorage = &deploy
 	}
 
-	return pipelines.CreateCluster(arg, o.DownloadCmd)
+	return pipelines.CreateCluster(arg)
 }
 
 func (o *CreateClusterOptions) AddFlags(cmd *cobra.Command) {
@@ -143,11 +157,14 @@ func (o *CreateClusterOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(&o.SkipPushImages, "skip-push-images", "", false, "Skip pre push images")
 	cmd.Flags().BoolVarP(&o.SecurityEnhancement, "with-security-enhancement", "", false, "Security enhancement")
 	cmd.Flags().StringVarP(&o.ContainerManager, "container-manager", "", "docker", "Container runtime: docker, crio, containerd and isula.")
-	cmd.Flags().StringVarP(&o.DownloadCmd, "download-cmd", "", "curl -L -o %s %s",
-		`The user defined command to download the necessary binary files. The first param '%s' is output path, the second param '%s', is the URL`)
+	cmd.Flags().StringVarP(&o.DownloadCmd, "download-cmd", "", "",
+		`The user defined command to download the necessary binary files. The first param '%s' is output path, the second param '%s', is the URL. The built-in downloader, configured by --download-policy, is used if it is empty`)
 	cmd.Flags().StringVarP(&o.Artifact, "artifact", "a", "", "Path to a KubeKey artifact")
 	cmd.Flags().BoolVarP(&o.InstallPackages, "with-packages", "", false, "install operation system packages by artifact")
 	cmd.Flags().BoolVarP(&o.WithBuildx, "with-buildx", "", false, "install buildx when Container runtime is docker")
+	cmd.Flags().BoolVar(&o.Provision, "provision", false, "Create the machines of the hosts of the inventory without an address before the installation")
+	cmd.Flags().BoolVar(&o.MergeKubeConfig, "merge-kubeconfig", false, "Merge the admin kubeconfig of the new cluster into the kubeconfig of the user, ~/.kube/config or the first file of $KUBECONFIG")
+	cmd.Flags().StringVar(&o.KubeConfigContext, "kubeconfig-context", "", "The name of the context the kubeconfig is merged as, the name of the cluster by default")
 }
 
 func completionSetting(cmd *cobra.Command) (err error) {
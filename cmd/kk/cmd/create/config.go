@@ -21,6 +21,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/cluster"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/options"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
@@ -56,6 +57,8 @@ func NewCmdCreateConfig() *cobra.Command {
 			util.CheckErr(o.Run())
 
 		},
+		// -f is the file created, not the config file of the current cluster
+		Annotations: map[string]string{cluster.NoContextAnnotation: "true"},
 	}
 
 	o.CommonOptions.AddCommonFlag(cmd)
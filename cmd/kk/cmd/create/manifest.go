@@ -26,6 +26,7 @@ import (
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/util/homedir"
 
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/cluster"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/options"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/artifact"
@@ -60,6 +61,8 @@ func NewCmdCreateManifest() *cobra.Command {
 			util.CheckErr(o.Run())
 
 		},
+		// -f is the file created, not the config file of the current cluster
+		Annotations: map[string]string{cluster.NoContextAnnotation: "true"},
 	}
 
 	o.CommonOptions.AddCommonFlag(cmd)
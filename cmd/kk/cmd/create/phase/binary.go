@@ -64,16 +64,21 @@ func (o *CreateBinaryOptions) Run() error {
 	arg := common.Argument{
 		FilePath:          o.ClusterCfgFile,
 		KubernetesVersion: o.Kubernetes,
-		Debug:             o.CommonOptions.Verbose,
+		Strategy:          o.CommonOptions.Strategy,
+		Resume:            o.CommonOptions.Resume,
+		Tags:              o.CommonOptions.Tags,
+		SkipTags:          o.CommonOptions.SkipTags,
+		DownloadCmd:       o.DownloadCmd,
 	}
-	return binary.CreateBinary(arg, o.DownloadCmd)
+	o.CommonOptions.ApplyTo(&arg)
+	return binary.CreateBinary(arg)
 }
 
 func (o *CreateBinaryOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file")
 	cmd.Flags().StringVarP(&o.Kubernetes, "with-kubernetes", "", "", "Specify a supported version of kubernetes")
-	cmd.Flags().StringVarP(&o.DownloadCmd, "download-cmd", "", "curl -L -o %s %s",
-		`The user defined command to download the necessary binary files. The first param '%s' is output path, the second param '%s', is the URL`)
+	cmd.Flags().StringVarP(&o.DownloadCmd, "download-cmd", "", "",
+		`The user defined command to download the necessary binary files. The first param '%s' is output path, the second param '%s', is the URL. The built-in downloader, configured by --download-policy, is used if it is empty`)
 
 }
 
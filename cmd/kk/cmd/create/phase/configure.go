@@ -73,9 +73,13 @@ func (o *CreateConfigureKubernetesOptions) Run() error {
 	arg := common.Argument{
 		FilePath:          o.ClusterCfgFile,
 		KubernetesVersion: o.Kubernetes,
-		Debug:             o.CommonOptions.Verbose,
+		Strategy:          o.CommonOptions.Strategy,
+		Resume:            o.CommonOptions.Resume,
+		Tags:              o.CommonOptions.Tags,
+		SkipTags:          o.CommonOptions.SkipTags,
 		Namespace:         o.CommonOptions.Namespace,
 	}
+	o.CommonOptions.ApplyTo(&arg)
 
 	if o.localStorageChanged {
 		deploy := o.LocalStorage
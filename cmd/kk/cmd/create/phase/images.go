@@ -74,8 +74,12 @@ func (o *CreateImagesOptions) Run() error {
 		FilePath:          o.ClusterCfgFile,
 		KubernetesVersion: o.Kubernetes,
 		ContainerManager:  o.ContainerManager,
-		Debug:             o.CommonOptions.Verbose,
+		Strategy:          o.CommonOptions.Strategy,
+		Resume:            o.CommonOptions.Resume,
+		Tags:              o.CommonOptions.Tags,
+		SkipTags:          o.CommonOptions.SkipTags,
 	}
+	o.CommonOptions.ApplyTo(&arg)
 	return images.CreateImages(arg)
 }
 
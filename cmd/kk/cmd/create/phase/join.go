@@ -62,9 +62,13 @@ func (o *CreateJoinNodesOptions) Run() error {
 	arg := common.Argument{
 		FilePath:          o.ClusterCfgFile,
 		KubernetesVersion: o.Kubernetes,
-		Debug:             o.CommonOptions.Verbose,
+		Strategy:          o.CommonOptions.Strategy,
+		Resume:            o.CommonOptions.Resume,
+		Tags:              o.CommonOptions.Tags,
+		SkipTags:          o.CommonOptions.SkipTags,
 		Namespace:         o.CommonOptions.Namespace,
 	}
+	o.CommonOptions.ApplyTo(&arg)
 
 	return kubernetes.CreateJoinNodes(arg)
 }
@@ -79,8 +79,12 @@ func (o *CreateKubeSphereOptions) Run() error {
 		KsEnable:         o.EnableKubeSphere,
 		KsVersion:        o.KubeSphere,
 		SkipConfirmCheck: o.CommonOptions.SkipConfirmCheck,
-		Debug:            o.CommonOptions.Verbose,
+		Strategy:         o.CommonOptions.Strategy,
+		Resume:           o.CommonOptions.Resume,
+		Tags:             o.CommonOptions.Tags,
+		SkipTags:         o.CommonOptions.SkipTags,
 	}
+	o.CommonOptions.ApplyTo(&arg)
 	return alpha.CreateKubeSphere(arg)
 }
 
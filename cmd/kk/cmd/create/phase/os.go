@@ -56,9 +56,13 @@ func NewCmdConfigOS() *cobra.Command {
 func (o *ConfigOSOptions) Run() error {
 	arg := common.Argument{
 		FilePath:        o.ClusterCfgFile,
-		Debug:           o.CommonOptions.Verbose,
+		Strategy:        o.CommonOptions.Strategy,
+		Resume:          o.CommonOptions.Resume,
+		Tags:            o.CommonOptions.Tags,
+		SkipTags:        o.CommonOptions.SkipTags,
 		InstallPackages: o.InstallPackages,
 	}
+	o.CommonOptions.ApplyTo(&arg)
 	return os.ConfigOS(arg)
 }
 
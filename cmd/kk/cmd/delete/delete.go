@@ -37,12 +37,13 @@ func NewCmdDelete() *cobra.Command {
 	o := NewDeleteOptions()
 	cmd := &cobra.Command{
 		Use:   "delete",
-		Short: "Delete node or cluster",
+		Short: "Delete node, cluster or addon",
 	}
 
 	o.CommonOptions.AddCommonFlag(cmd)
 
 	cmd.AddCommand(NewCmdDeleteCluster())
 	cmd.AddCommand(NewCmdDeleteNode())
+	cmd.AddCommand(NewCmdDeleteAddon())
 	return cmd
 }
@@ -0,0 +1,89 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package delete
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/options"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+type DeleteAddonOptions struct {
+	CommonOptions  *options.CommonOptions
+	ClusterCfgFile string
+	addonName      string
+}
+
+func NewDeleteAddonOptions() *DeleteAddonOptions {
+	return &DeleteAddonOptions{
+		CommonOptions: options.NewCommonOptions(),
+	}
+}
+
+// NewCmdDeleteAddon creates a new delete addon command
+func NewCmdDeleteAddon() *cobra.Command {
+	o := NewDeleteAddonOptions()
+	cmd := &cobra.Command{
+		Use:   "addon",
+		Short: "delete an addon",
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Complete(cmd, args))
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+		},
+	}
+
+	o.CommonOptions.AddCommonFlag(cmd)
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func (o *DeleteAddonOptions) Complete(cmd *cobra.Command, args []string) error {
+	o.addonName = strings.Join(args, "")
+	return nil
+}
+
+func (o *DeleteAddonOptions) Validate() error {
+	if o.addonName == "" {
+		return errors.New("addon name can not be empty")
+	}
+	return nil
+}
+
+func (o *DeleteAddonOptions) Run() error {
+	arg := common.Argument{
+		FilePath:         o.ClusterCfgFile,
+		Strategy:         o.CommonOptions.Strategy,
+		Resume:           o.CommonOptions.Resume,
+		Tags:             o.CommonOptions.Tags,
+		SkipTags:         o.CommonOptions.SkipTags,
+		AddonName:        o.addonName,
+		SkipConfirmCheck: o.CommonOptions.SkipConfirmCheck,
+	}
+	o.CommonOptions.ApplyTo(&arg)
+	return pipelines.DeleteAddon(arg)
+}
+
+func (o *DeleteAddonOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file")
+}
@@ -30,6 +30,8 @@ type DeleteClusterOptions struct {
 	ClusterCfgFile string
 	Kubernetes     string
 	DeleteCRI      bool
+	CleanupLevel   string
+	Deprovision    bool
 }
 
 func NewDeleteClusterOptions() *DeleteClusterOptions {
@@ -57,11 +59,17 @@ func NewCmdDeleteCluster() *cobra.Command {
 func (o *DeleteClusterOptions) Run() error {
 	arg := common.Argument{
 		FilePath:          o.ClusterCfgFile,
-		Debug:             o.CommonOptions.Verbose,
+		Strategy:          o.CommonOptions.Strategy,
+		Resume:            o.CommonOptions.Resume,
+		Tags:              o.CommonOptions.Tags,
+		SkipTags:          o.CommonOptions.SkipTags,
 		KubernetesVersion: o.Kubernetes,
 		DeleteCRI:         o.DeleteCRI,
+		CleanupLevel:      o.CleanupLevel,
+		Deprovision:       o.Deprovision,
 		SkipConfirmCheck:  o.CommonOptions.SkipConfirmCheck,
 	}
+	o.CommonOptions.ApplyTo(&arg)
 	return pipelines.DeleteCluster(arg)
 }
 
@@ -69,4 +77,7 @@ func (o *DeleteClusterOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file")
 	cmd.Flags().StringVarP(&o.Kubernetes, "with-kubernetes", "", "", "Specify a supported version of kubernetes")
 	cmd.Flags().BoolVarP(&o.DeleteCRI, "all", "A", false, "Delete total cri conficutation and data directories")
+	cmd.Flags().StringVar(&o.CleanupLevel, "level", "", "How deep the nodes are cleaned up: reset resets kubeadm only, runtime also removes the container runtime and binaries, "+
+		"data also wipes the data dirs, CNI interfaces and iptables rules. By default kubeadm is reset and the data is wiped, the container runtime is kept unless --all is set")
+	cmd.Flags().BoolVar(&o.Deprovision, "provision", false, "Delete the machines provisioned for the hosts of the inventory after the cluster is deleted")
 }
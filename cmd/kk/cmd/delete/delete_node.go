@@ -73,10 +73,14 @@ func (o *DeleteNodeOptions) Validate() error {
 func (o *DeleteNodeOptions) Run() error {
 	arg := common.Argument{
 		FilePath:         o.ClusterCfgFile,
-		Debug:            o.CommonOptions.Verbose,
+		Strategy:         o.CommonOptions.Strategy,
+		Resume:           o.CommonOptions.Resume,
+		Tags:             o.CommonOptions.Tags,
+		SkipTags:         o.CommonOptions.SkipTags,
 		NodeName:         o.nodeName,
 		SkipConfirmCheck: o.CommonOptions.SkipConfirmCheck,
 	}
+	o.CommonOptions.ApplyTo(&arg)
 	return pipelines.DeleteNode(arg)
 }
 
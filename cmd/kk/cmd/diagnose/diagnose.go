@@ -0,0 +1,115 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/options"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+type DiagnoseOptions struct {
+	CommonOptions  *options.CommonOptions
+	ClusterCfgFile string
+	Nodes          []string
+	Output         string
+	MaxFileSize    string
+	MaxSize        string
+}
+
+func NewDiagnoseOptions() *DiagnoseOptions {
+	return &DiagnoseOptions{
+		CommonOptions: options.NewCommonOptions(),
+	}
+}
+
+// NewCmdDiagnose creates a new diagnose command
+func NewCmdDiagnose() *cobra.Command {
+	o := NewDiagnoseOptions()
+	cmd := &cobra.Command{
+		Use:   "diagnose",
+		Short: "Collect a support bundle of the nodes of a cluster",
+		Long: `Collect the journals of kubelet, containerd and etcd, the kubeadm and kubelet configs, the system logs and the kernel
+messages of the nodes, all of them or the ones of --nodes, and the kubectl cluster-info dump of the cluster into a
+gzip compressed tarball. The files are fetched from the nodes, capped in size and redacted as the logs, so the bundle
+can be attached to an issue.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Run())
+		},
+	}
+
+	o.CommonOptions.AddCommonFlag(cmd)
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func (o *DiagnoseOptions) Run() error {
+	maxFileSize, err := parseSize("--max-file-size", o.MaxFileSize)
+	if err != nil {
+		return err
+	}
+	maxSize, err := parseSize("--max-size", o.MaxSize)
+	if err != nil {
+		return err
+	}
+	output := o.Output
+	if output == "" {
+		output = fmt.Sprintf("kubekey-diagnose-%s.tar.gz", time.Now().Format("20060102-150405"))
+	}
+
+	arg := common.Argument{
+		FilePath:            o.ClusterCfgFile,
+		Debug:               o.CommonOptions.Verbose,
+		PolicyConfig:        o.CommonOptions.PolicyConfig,
+		RedactionConfig:     o.CommonOptions.RedactionConfig,
+		AuditLog:            o.CommonOptions.AuditLog,
+		DryRun:              o.CommonOptions.DryRun,
+		NoTUI:               o.CommonOptions.NoTUI,
+		IncludeQuarantined:  o.CommonOptions.IncludeQuarantined,
+		TransferRateLimit:   o.CommonOptions.TransferRateLimit,
+		TransferCompression: o.CommonOptions.TransferCompression,
+		HostLogs:            o.CommonOptions.HostLogs,
+		SkipConfirmCheck:    true,
+		Namespace:           o.CommonOptions.Namespace,
+	}
+	return pipelines.Diagnose(arg, o.Nodes, output, maxFileSize, maxSize)
+}
+
+// parseSize parses the size of the flag in bytes, e.g. 10Mi, the zero size is unlimited.
+func parseSize(flag, size string) (int64, error) {
+	q, err := resource.ParseQuantity(size)
+	if err != nil || q.Sign() < 0 {
+		return 0, errors.Errorf("invalid %s %q, it must be a size such as 10Mi", flag, size)
+	}
+	return q.Value(), nil
+}
+
+func (o *DiagnoseOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file")
+	cmd.Flags().StringSliceVar(&o.Nodes, "nodes", nil, "Names of the nodes to collect, all the nodes by default")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "", "Path to the support bundle (default is kubekey-diagnose-<time>.tar.gz)")
+	cmd.Flags().StringVar(&o.MaxFileSize, "max-file-size", "10Mi", "Size cap of each file of the bundle, the beginning of the larger logs is dropped, 0 is unlimited")
+	cmd.Flags().StringVar(&o.MaxSize, "max-size", "200Mi", "Size cap of all the files of the bundle, the files beyond it are skipped, 0 is unlimited")
+}
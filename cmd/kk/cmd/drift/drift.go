@@ -0,0 +1,80 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package drift
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+// NewCmdDrift creates a new drift command
+func NewCmdDrift() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "drift",
+		Short: "Report the drift between a live cluster and its config",
+	}
+
+	cmd.AddCommand(NewCmdDriftNodes())
+	return cmd
+}
+
+type DriftNodesOptions struct {
+	ClusterCfgFile string
+	KubeConfig     string
+	Fix            bool
+	Yes            bool
+	Debug          bool
+}
+
+// NewCmdDriftNodes creates a new drift nodes command
+func NewCmdDriftNodes() *cobra.Command {
+	o := &DriftNodesOptions{}
+	cmd := &cobra.Command{
+		Use:   "nodes",
+		Short: "Compare the nodes of a cluster with the hosts of its config",
+		Long: `Compare the nodes of a cluster with the control-plane and the worker hosts of its config, and report the
+unmanaged nodes, which are in the cluster but not in the config, the missing hosts, which are in the config but not
+in the cluster, and the nodes whose internal IP isn't the internalAddress of their host. With --fix, the unmanaged
+nodes are adopted into the config and the missing hosts are pruned from it, each after a confirmation.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Run())
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func (o *DriftNodesOptions) Run() error {
+	arg := common.Argument{
+		FilePath:   o.ClusterCfgFile,
+		KubeConfig: o.KubeConfig,
+		Debug:      o.Debug,
+	}
+	return pipelines.DriftNodes(arg, o.Fix, o.Yes)
+}
+
+func (o *DriftNodesOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file")
+	cmd.Flags().StringVar(&o.KubeConfig, "kubeconfig", "", "Path to a kubeconfig file, ~/.kube/config by default")
+	cmd.Flags().BoolVar(&o.Fix, "fix", false, "Adopt the unmanaged nodes into the config and prune the missing hosts from it")
+	cmd.Flags().BoolVarP(&o.Yes, "yes", "y", false, "Skip the confirmations of --fix")
+	cmd.Flags().BoolVar(&o.Debug, "debug", false, "Print detailed information")
+}
@@ -0,0 +1,68 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package history
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+type HistoryDiffOptions struct {
+	HistoryOptions
+	Path  string
+	RunID string
+	host  string
+}
+
+// NewCmdHistoryDiff creates a new history diff command
+func NewCmdHistoryDiff() *cobra.Command {
+	o := &HistoryDiffOptions{}
+	cmd := &cobra.Command{
+		Use:   "diff [host]",
+		Short: "Show what changed in the managed files on a host",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Complete(cmd, args))
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+		},
+	}
+
+	o.AddFlags(cmd)
+	cmd.Flags().StringVar(&o.Path, "path", "", "Only show the changes of the file")
+	cmd.Flags().StringVar(&o.RunID, "run", "", "Only show the changes in the run")
+	return cmd
+}
+
+func (o *HistoryDiffOptions) Complete(_ *cobra.Command, args []string) error {
+	o.host = args[0]
+	return nil
+}
+
+func (o *HistoryDiffOptions) Validate() error {
+	if o.host == "" {
+		return errors.New("host name can not be empty")
+	}
+	return nil
+}
+
+func (o *HistoryDiffOptions) Run() error {
+	return pipelines.DiffHistory(o.argument(), o.host, o.Path, o.RunID)
+}
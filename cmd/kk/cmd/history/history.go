@@ -0,0 +1,57 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package history
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+)
+
+// NewCmdHistory creates a new history command
+func NewCmdHistory() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Inspect and revert the history of the files managed by KubeKey on the hosts of a cluster",
+		Long: `Inspect and revert the history of the files managed by KubeKey on the hosts of a cluster. Each change of a
+managed file is recorded with the contents before and after it, as the run of the change, which is in the run report
+as well. The last 10 versions of each file are kept in the work dir of the cluster.`,
+	}
+
+	cmd.AddCommand(NewCmdHistoryList())
+	cmd.AddCommand(NewCmdHistoryDiff())
+	cmd.AddCommand(NewCmdHistoryShow())
+	cmd.AddCommand(NewCmdHistoryRevert())
+	return cmd
+}
+
+type HistoryOptions struct {
+	ClusterCfgFile string
+	Debug          bool
+}
+
+func (o *HistoryOptions) argument() common.Argument {
+	return common.Argument{
+		FilePath: o.ClusterCfgFile,
+		Debug:    o.Debug,
+	}
+}
+
+func (o *HistoryOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file")
+	cmd.Flags().BoolVar(&o.Debug, "debug", false, "Print detailed information")
+}
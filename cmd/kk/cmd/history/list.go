@@ -0,0 +1,49 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package history
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+type HistoryListOptions struct {
+	HistoryOptions
+	Host  string
+	Path  string
+	RunID string
+}
+
+// NewCmdHistoryList creates a new history list command
+func NewCmdHistoryList() *cobra.Command {
+	o := &HistoryListOptions{}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the versions of the managed files on the hosts of a cluster",
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(pipelines.ListHistory(o.argument(), o.Host, o.Path, o.RunID))
+		},
+	}
+
+	o.AddFlags(cmd)
+	cmd.Flags().StringVar(&o.Host, "host", "", "Only list the versions on the host")
+	cmd.Flags().StringVar(&o.Path, "path", "", "Only list the versions of the file")
+	cmd.Flags().StringVar(&o.RunID, "run", "", "Only list the versions changed in the run")
+	return cmd
+}
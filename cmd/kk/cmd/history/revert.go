@@ -0,0 +1,40 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package history
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+// NewCmdHistoryRevert creates a new history revert command
+func NewCmdHistoryRevert() *cobra.Command {
+	o := &HistoryOptions{}
+	cmd := &cobra.Command{
+		Use:   "revert [host] [path] [digest]",
+		Short: "Revert a managed file on a host to a content in its history",
+		Args:  cobra.ExactArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(pipelines.RevertHistory(o.argument(), args[0], args[1], args[2]))
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
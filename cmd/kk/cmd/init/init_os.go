@@ -56,9 +56,13 @@ func NewCmdInitOs() *cobra.Command {
 func (o *InitOsOptions) Run() error {
 	arg := common.Argument{
 		FilePath: o.ClusterCfgFile,
-		Debug:    o.CommonOptions.Verbose,
+		Strategy: o.CommonOptions.Strategy,
+		Resume:   o.CommonOptions.Resume,
+		Tags:     o.CommonOptions.Tags,
+		SkipTags: o.CommonOptions.SkipTags,
 		Artifact: o.Artifact,
 	}
+	o.CommonOptions.ApplyTo(&arg)
 	return pipelines.InitDependencies(arg)
 }
 
@@ -61,16 +61,21 @@ func (o *InitRegistryOptions) Complete(_ *cobra.Command, _ []string) error {
 
 func (o *InitRegistryOptions) Run() error {
 	arg := common.Argument{
-		FilePath: o.ClusterCfgFile,
-		Debug:    o.CommonOptions.Verbose,
-		Artifact: o.Artifact,
+		FilePath:    o.ClusterCfgFile,
+		Strategy:    o.CommonOptions.Strategy,
+		Resume:      o.CommonOptions.Resume,
+		Tags:        o.CommonOptions.Tags,
+		SkipTags:    o.CommonOptions.SkipTags,
+		Artifact:    o.Artifact,
+		DownloadCmd: o.DownloadCmd,
 	}
-	return pipelines.InitRegistry(arg, o.DownloadCmd)
+	o.CommonOptions.ApplyTo(&arg)
+	return pipelines.InitRegistry(arg)
 }
 
 func (o *InitRegistryOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file")
-	cmd.Flags().StringVarP(&o.DownloadCmd, "download-cmd", "", "curl -L -o %s %s",
-		`The user defined command to download the necessary files. The first param '%s' is output path, the second param '%s', is the URL`)
+	cmd.Flags().StringVarP(&o.DownloadCmd, "download-cmd", "", "",
+		`The user defined command to download the necessary files. The first param '%s' is output path, the second param '%s', is the URL. The built-in downloader, configured by --download-policy, is used if it is empty`)
 	cmd.Flags().StringVarP(&o.Artifact, "artifact", "a", "", "Path to a KubeKey artifact")
 }
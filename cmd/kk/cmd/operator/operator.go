@@ -0,0 +1,163 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package operator
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/klog/v2/klogr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	kubekeyv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/exporter"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/facts"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/operator"
+)
+
+type OperatorOptions struct {
+	Verbose              bool
+	RedactionConfig      string
+	AuditLog             string
+	PolicyConfig         string
+	DownloadCmd          string
+	WorkDir              string
+	MetricsAddr          string
+	HealthAddr           string
+	EnableLeaderElection bool
+	WatchNamespace       string
+	WebhookPort          int
+	WebhookCertDir       string
+}
+
+func NewOperatorOptions() *OperatorOptions {
+	return &OperatorOptions{}
+}
+
+// NewCmdOperator creates a new operator command
+func NewCmdOperator() *cobra.Command {
+	o := NewOperatorOptions()
+	cmd := &cobra.Command{
+		Use:   "operator",
+		Short: "run the operator, which reconciles the Cluster resources in a cluster by the Pipeline resources",
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Run())
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func (o *OperatorOptions) Run() error {
+	ctrl.SetLogger(klogr.New())
+
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(kubekeyv1alpha2.AddToScheme(scheme))
+
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+		Scheme:                     scheme,
+		MetricsBindAddress:         o.MetricsAddr,
+		HealthProbeBindAddress:     o.HealthAddr,
+		LeaderElection:             o.EnableLeaderElection,
+		LeaderElectionID:           "kk-operator-leader-election",
+		LeaderElectionResourceLock: resourcelock.LeasesResourceLock,
+		Namespace:                  o.WatchNamespace,
+		Port:                       o.WebhookPort,
+		CertDir:                    o.WebhookCertDir,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := (&operator.ClusterReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("cluster-controller"),
+	}).SetupWithManager(mgr, controller.Options{RecoverPanic: true}); err != nil {
+		return err
+	}
+	if err := (&operator.PipelineReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("pipeline-controller"),
+		WorkDir:  o.WorkDir,
+		Argument: common.Argument{
+			Debug:           o.Verbose,
+			RedactionConfig: o.RedactionConfig,
+			AuditLog:        o.AuditLog,
+			PolicyConfig:    o.PolicyConfig,
+			DownloadCmd:     o.DownloadCmd,
+		},
+	}).SetupWithManager(mgr, controller.Options{RecoverPanic: true}); err != nil {
+		return err
+	}
+
+	if o.WebhookPort != 0 {
+		if err := (&operator.ClusterValidator{}).SetupWebhookWithManager(mgr); err != nil {
+			return err
+		}
+	}
+
+	// the pipelines keep the state of the clusters in the work dir next to the binary
+	binDir, err := filepath.Abs(filepath.Dir(os.Args[0]))
+	if err != nil {
+		return err
+	}
+	// the pipelines run in the operator, their metrics are served with the metrics of the controllers
+	if err := exporter.Register(metrics.Registry); err != nil {
+		return err
+	}
+	if err := mgr.AddMetricsExtraHandler("/facts", facts.Handler(filepath.Join(binDir, common.KubeKey))); err != nil {
+		return err
+	}
+
+	if err := mgr.AddHealthzCheck("ping", healthz.Ping); err != nil {
+		return err
+	}
+	if err := mgr.AddReadyzCheck("ping", healthz.Ping); err != nil {
+		return err
+	}
+	return mgr.Start(ctrl.SetupSignalHandler())
+}
+
+func (o *OperatorOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&o.Verbose, "debug", false, "Print detailed information")
+	cmd.Flags().StringVar(&o.RedactionConfig, "redaction-config", "", "Path to a redaction config file, which masks the matched values in the console output and logs")
+	cmd.Flags().StringVar(&o.PolicyConfig, "policy-config", "", "Path to a policy config file, which allows or denies the commands and the remote paths the connectors touch on the hosts")
+	cmd.Flags().StringVar(&o.AuditLog, "audit-log", "", "Path to the append-only audit log of the commands and file changes on the hosts, or syslog, syslog://host:port and syslog+tcp://host:port to send the records to a syslog")
+	cmd.Flags().StringVarP(&o.DownloadCmd, "download-cmd", "", "",
+		`The user defined command to download the necessary binary files. The first param '%s' is output path, the second param '%s', is the URL. The built-in downloader, configured by --download-policy, is used if it is empty`)
+	cmd.Flags().StringVar(&o.WorkDir, "work-dir", "/var/lib/kk-operator", "Directory of the cluster configs written for the pipelines")
+	cmd.Flags().StringVar(&o.MetricsAddr, "metrics-bind-address", ":8080", "The address the metrics and the read-only facts endpoints bind to")
+	cmd.Flags().StringVar(&o.HealthAddr, "health-probe-bind-address", ":9440", "The address the probe endpoint binds to")
+	cmd.Flags().BoolVar(&o.EnableLeaderElection, "leader-elect", false, "Enable leader election, ensuring there is only one active operator")
+	cmd.Flags().StringVar(&o.WatchNamespace, "watch-namespace", "", "Namespace of the Cluster resources to reconcile (default is all namespaces)")
+	cmd.Flags().IntVar(&o.WebhookPort, "webhook-port", 0, "Port of the webhook server validating the Cluster resources, 0 disables the webhook")
+	cmd.Flags().StringVar(&o.WebhookCertDir, "webhook-cert-dir", "", "Directory of the tls.crt and tls.key of the webhook server (default is <temp-dir>/k8s-webhook-server/serving-certs)")
+}
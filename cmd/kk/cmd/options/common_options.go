@@ -18,13 +18,36 @@ package options
 
 import (
 	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
 )
 
 type CommonOptions struct {
-	Verbose          bool
-	SkipConfirmCheck bool
-	IgnoreErr        bool
-	Namespace        string
+	Verbose                 bool
+	SkipConfirmCheck        bool
+	IgnoreErr               bool
+	Namespace               string
+	ChaosConfig             string
+	PolicyConfig            string
+	RedactionConfig         string
+	AuditLog                string
+	Strict                  bool
+	DryRun                  bool
+	Report                  string
+	JUnitReport             string
+	Strategy                string
+	Serial                  string
+	MaxFailPercent          int
+	Resume                  bool
+	NoTUI                   bool
+	IncludeQuarantined      bool
+	IgnoreMaintenanceWindow bool
+	CollectDiagnostics      bool
+	HostLogs                bool
+	TransferRateLimit       string
+	TransferCompression     string
+	Tags                    []string
+	SkipTags                []string
 }
 
 func NewCommonOptions() *CommonOptions {
@@ -36,4 +59,49 @@ func (o *CommonOptions) AddCommonFlag(cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(&o.SkipConfirmCheck, "yes", "y", false, "Skip confirm check")
 	cmd.Flags().BoolVar(&o.IgnoreErr, "ignore-err", false, "Ignore the error message, remove the host which reported error and force to continue")
 	cmd.Flags().StringVar(&o.Namespace, "namespace", "kubekey-system", "KubeKey namespace to use")
+	cmd.Flags().BoolVar(&o.DryRun, "dry-run", false, "Walk through the pipeline and report the commands and file changes on each host without executing them")
+	cmd.Flags().BoolVar(&o.Strict, "strict", false, "Fail on any undefined variable in the templates instead of rendering an empty value")
+	cmd.Flags().StringVar(&o.ChaosConfig, "chaos-config", "", "Path to a chaos config file, which injects failures into the remote commands and transfers for testing")
+	cmd.Flags().StringVar(&o.Strategy, "strategy", "", "Execution strategy of the modules which don't set their own: linear, free or rolling (default is linear)")
+	cmd.Flags().StringVar(&o.Serial, "serial", "", "Batch size of the rolling strategy, a number of hosts or a percentage of the hosts, e.g. 30%")
+	cmd.Flags().IntVar(&o.MaxFailPercent, "max-fail-percentage", 0, "Percentage of the hosts, of each batch in the rolling strategy, allowed to fail before the run aborts, the failed hosts are removed")
+	cmd.Flags().BoolVar(&o.Resume, "resume", false, "Resume the failed run from its checkpoint, skipping the tasks completed on each host")
+	cmd.Flags().BoolVar(&o.NoTUI, "no-tui", false, "Print the logs instead of the interactive progress of the hosts, which is disabled anyway when the output isn't a terminal or the env CI is set")
+	cmd.Flags().BoolVar(&o.IncludeQuarantined, "include-quarantined", false, "Include the quarantined hosts, which are skipped by default, see kk quarantine")
+	cmd.Flags().BoolVar(&o.IgnoreMaintenanceWindow, "ignore-maintenance-window", false, "Only warn of the disruptive phases started outside the maintenance windows of the cluster, e.g. for an emergency")
+	cmd.Flags().BoolVar(&o.CollectDiagnostics, "collect-diagnostics", false, "Collect the last journal lines of the cluster units, the kernel messages and the state of containerd on the hosts a task failed on, into the diagnostics dir in the work dir of the cluster")
+	cmd.Flags().BoolVar(&o.HostLogs, "host-logs", false, "Write the commands executed on each host, their output and exit codes into a log file of the host in the dir of the run, runs/<run> in the work dir of the cluster")
+	cmd.Flags().StringVar(&o.TransferRateLimit, "transfer-rate-limit", "", "Rate limit of the files copied to each host in bytes per second, e.g. 10Mi, unless the host sets its transferRateLimit")
+	cmd.Flags().StringVar(&o.TransferCompression, "transfer-compression", "", "Compression of the files copied to and fetched from each host: none, gzip or zstd, unless the host sets its transferCompression (default is none)")
+	cmd.Flags().StringSliceVar(&o.Tags, "tags", nil, "Run only the modules of the tags, e.g. network or certs, and the modules tagged always, see docs/tags.md")
+	cmd.Flags().StringSliceVar(&o.SkipTags, "skip-tags", nil, "Skip the modules of the tags, including the ones tagged always")
+	cmd.Flags().StringVar(&o.Report, "report", "", "Path to the JSON report of the run, which records the result of each task on each host (default is report.json in the work dir of the cluster)")
+	cmd.Flags().StringVar(&o.JUnitReport, "junit-report", "", "Path to an additional report of the run in JUnit XML, with a test suite for each host")
+	cmd.Flags().StringVar(&o.RedactionConfig, "redaction-config", "", "Path to a redaction config file, which masks the matched values in the console output and logs")
+	cmd.Flags().StringVar(&o.PolicyConfig, "policy-config", "", "Path to a policy config file, which allows or denies the commands and the remote paths the connectors touch on the hosts")
+	cmd.Flags().StringVar(&o.AuditLog, "audit-log", "", "Path to the append-only audit log of the commands and file changes on the hosts, or syslog, syslog://host:port and syslog+tcp://host:port to send the records to a syslog")
+}
+
+// ApplyTo fills the fields of the argument honored by all the commands running the pipelines on the hosts. The
+// flags honored only by some of the commands, e.g. --ignore-err, --yes, --namespace, --strategy, --resume and the
+// tags, are filled by the commands honoring them.
+func (o *CommonOptions) ApplyTo(arg *common.Argument) {
+	arg.Debug = o.Verbose
+	arg.ChaosConfig = o.ChaosConfig
+	arg.PolicyConfig = o.PolicyConfig
+	arg.RedactionConfig = o.RedactionConfig
+	arg.AuditLog = o.AuditLog
+	arg.DryRun = o.DryRun
+	arg.Report = o.Report
+	arg.JUnitReport = o.JUnitReport
+	arg.Serial = o.Serial
+	arg.MaxFailPercent = o.MaxFailPercent
+	arg.NoTUI = o.NoTUI
+	arg.IncludeQuarantined = o.IncludeQuarantined
+	arg.IgnoreMaintenanceWindow = o.IgnoreMaintenanceWindow
+	arg.CollectDiagnostics = o.CollectDiagnostics
+	arg.TransferRateLimit = o.TransferRateLimit
+	arg.TransferCompression = o.TransferCompression
+	arg.HostLogs = o.HostLogs
+	arg.Strict = o.Strict
 }
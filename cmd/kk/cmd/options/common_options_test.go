@@ -0,0 +1,46 @@
+/*
+ Copyright 2021 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package options
+
+import (
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+)
+
+func TestCommonOptionsApplyTo(t *testing.T) {
+	o := &CommonOptions{
+		Verbose:                 true,
+		SkipConfirmCheck:        true,
+		IgnoreErr:               true,
+		Namespace:               "kubekey-system",
+		DryRun:                  true,
+		Strategy:                "free",
+		Resume:                  true,
+		Tags:                    []string{"etcd"},
+		IgnoreMaintenanceWindow: true,
+	}
+	arg := common.Argument{FilePath: "config.yaml"}
+	o.ApplyTo(&arg)
+	if !arg.Debug || !arg.DryRun || !arg.IgnoreMaintenanceWindow || arg.FilePath != "config.yaml" {
+		t.Errorf("ApplyTo() = %+v, want the shared flags filled", arg)
+	}
+	// honored only by some commands, which fill them
+	if arg.IgnoreErr || arg.SkipConfirmCheck || arg.Namespace != "" || arg.Strategy != "" || arg.Resume || arg.Tags != nil {
+		t.Errorf("ApplyTo() = %+v, want the flags of some commands left to them", arg)
+	}
+}
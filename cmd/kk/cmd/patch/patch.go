@@ -0,0 +1,102 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package patch
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/options"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/patch"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+type PatchOptions struct {
+	CommonOptions  *options.CommonOptions
+	ClusterCfgFile string
+	Nodes          []string
+	SecurityOnly   bool
+	Reboot         string
+	DrainTimeout   time.Duration
+	RebootTimeout  time.Duration
+}
+
+func NewPatchOptions() *PatchOptions {
+	return &PatchOptions{
+		CommonOptions: options.NewCommonOptions(),
+	}
+}
+
+// NewCmdPatch creates a new patch command
+func NewCmdPatch() *cobra.Command {
+	o := NewPatchOptions()
+	cmd := &cobra.Command{
+		Use:   "patch",
+		Short: "Apply the OS updates to the nodes of a cluster one by one",
+		Long: `Apply the OS updates to the nodes of a cluster, all of them or the ones of --nodes, one batch after another: each
+node is cordoned and drained, its packages are upgraded by apt, dnf, yum or zypper, it is rebooted if the updates
+require it and waited to come back and to be Ready, then it is uncordoned. The batch is a node unless --serial is set,
+the workers are patched before the control-plane nodes. A node which fails stops the patching and is left cordoned.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+		},
+	}
+
+	o.CommonOptions.AddCommonFlag(cmd)
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func (o *PatchOptions) Validate() error {
+	for _, mode := range patch.RebootModes {
+		if o.Reboot == mode {
+			return nil
+		}
+	}
+	return errors.Errorf("invalid --reboot %s, it must be one of %s", o.Reboot, strings.Join(patch.RebootModes, ", "))
+}
+
+func (o *PatchOptions) Run() error {
+	arg := common.Argument{
+		FilePath:         o.ClusterCfgFile,
+		SkipConfirmCheck: o.CommonOptions.SkipConfirmCheck,
+		Namespace:        o.CommonOptions.Namespace,
+	}
+	o.CommonOptions.ApplyTo(&arg)
+	return pipelines.PatchOS(arg, o.Nodes, patch.Options{
+		SecurityOnly:  o.SecurityOnly,
+		Reboot:        o.Reboot,
+		DrainTimeout:  o.DrainTimeout,
+		RebootTimeout: o.RebootTimeout,
+	})
+}
+
+func (o *PatchOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file")
+	cmd.Flags().StringSliceVar(&o.Nodes, "nodes", nil, "Names of the nodes to patch, all the nodes by default")
+	cmd.Flags().BoolVar(&o.SecurityOnly, "security-only", false, "Apply only the security updates")
+	cmd.Flags().StringVar(&o.Reboot, "reboot", patch.RebootAuto, "Reboot the nodes whose updates require it (auto), all the nodes (always) or none of them (never)")
+	cmd.Flags().DurationVar(&o.DrainTimeout, "drain-timeout", 5*time.Minute, "Timeout of the drain of each node")
+	cmd.Flags().DurationVar(&o.RebootTimeout, "reboot-timeout", action.DefaultRebootTimeout, "Timeout for each node to come back and to be Ready after the reboot")
+}
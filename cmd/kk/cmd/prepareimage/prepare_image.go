@@ -0,0 +1,124 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package prepareimage
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/options"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+type PrepareImageOptions struct {
+	CommonOptions    *options.CommonOptions
+	ClusterCfgFile   string
+	Kubernetes       string
+	ContainerManager string
+	SkipPullImages   bool
+	DownloadCmd      string
+	Artifact         string
+	InstallPackages  bool
+}
+
+func NewPrepareImageOptions() *PrepareImageOptions {
+	return &PrepareImageOptions{
+		CommonOptions: options.NewCommonOptions(),
+	}
+}
+
+// NewCmdPrepareImage creates a new prepare-image command
+func NewCmdPrepareImage() *cobra.Command {
+	o := NewPrepareImageOptions()
+	cmd := &cobra.Command{
+		Use:   "prepare-image",
+		Short: "Prepare a host as a golden node image",
+		Long: `Run the node preparation of a cluster on a single host, the host of the config or the local host: the os
+dependencies and the sysctls, the container runtime, the images and the kubernetes binaries. The steps baked are
+recorded in /etc/kubekey/image.json and the host is generalized, its machine-id is truncated, so it can be shut down
+and captured as an image. The nodes created from the image skip the steps baked when they are added to a cluster of
+the same kubernetes version and container runtime.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Complete(cmd, args))
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+		},
+	}
+
+	o.CommonOptions.AddCommonFlag(cmd)
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func (o *PrepareImageOptions) Complete(_ *cobra.Command, _ []string) error {
+	if o.Artifact == "" {
+		o.InstallPackages = false
+	}
+	return nil
+}
+
+func (o *PrepareImageOptions) Validate() error {
+	switch o.ContainerManager {
+	case common.Docker, common.Containerd, common.Crio, common.Isula:
+	default:
+		return fmt.Errorf("unsupport container runtime [%s]", o.ContainerManager)
+	}
+	return nil
+}
+
+func (o *PrepareImageOptions) Run() error {
+	arg := common.Argument{
+		FilePath:            o.ClusterCfgFile,
+		KubernetesVersion:   o.Kubernetes,
+		SkipPullImages:      o.SkipPullImages,
+		Debug:               o.CommonOptions.Verbose,
+		PolicyConfig:        o.CommonOptions.PolicyConfig,
+		RedactionConfig:     o.CommonOptions.RedactionConfig,
+		AuditLog:            o.CommonOptions.AuditLog,
+		DryRun:              o.CommonOptions.DryRun,
+		Report:              o.CommonOptions.Report,
+		JUnitReport:         o.CommonOptions.JUnitReport,
+		NoTUI:               o.CommonOptions.NoTUI,
+		TransferRateLimit:   o.CommonOptions.TransferRateLimit,
+		TransferCompression: o.CommonOptions.TransferCompression,
+		HostLogs:            o.CommonOptions.HostLogs,
+		Tags:                o.CommonOptions.Tags,
+		SkipTags:            o.CommonOptions.SkipTags,
+		Strict:              o.CommonOptions.Strict,
+		SkipConfirmCheck:    o.CommonOptions.SkipConfirmCheck,
+		ContainerManager:    o.ContainerManager,
+		Artifact:            o.Artifact,
+		InstallPackages:     o.InstallPackages,
+		Namespace:           o.CommonOptions.Namespace,
+		DownloadCmd:         o.DownloadCmd,
+	}
+	return pipelines.PrepareImage(arg)
+}
+
+func (o *PrepareImageOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file with the single host to prepare")
+	cmd.Flags().StringVarP(&o.Kubernetes, "with-kubernetes", "", "", "Specify a supported version of kubernetes")
+	cmd.Flags().StringVarP(&o.ContainerManager, "container-manager", "", "docker", "Container runtime: docker, crio, containerd and isula.")
+	cmd.Flags().BoolVarP(&o.SkipPullImages, "skip-pull-images", "", false, "Skip pre pull images, they aren't baked into the image")
+	cmd.Flags().StringVarP(&o.DownloadCmd, "download-cmd", "", "",
+		`The user defined command to download the necessary binary files. The first param '%s' is output path, the second param '%s', is the URL. The built-in downloader, configured by --download-policy, is used if it is empty`)
+	cmd.Flags().StringVarP(&o.Artifact, "artifact", "a", "", "Path to a KubeKey artifact")
+	cmd.Flags().BoolVarP(&o.InstallPackages, "with-packages", "", false, "install operation system packages by artifact")
+}
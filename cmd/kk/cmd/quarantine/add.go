@@ -0,0 +1,65 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package quarantine
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+type QuarantineAddOptions struct {
+	QuarantineOptions
+	Reason string
+	hosts  []string
+}
+
+// NewCmdQuarantineAdd creates a new quarantine add command
+func NewCmdQuarantineAdd() *cobra.Command {
+	o := &QuarantineAddOptions{}
+	cmd := &cobra.Command{
+		Use:   "add [host]...",
+		Short: "Quarantine hosts of a cluster by their names",
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Complete(cmd, args))
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+		},
+	}
+
+	o.AddFlags(cmd)
+	cmd.Flags().StringVar(&o.Reason, "reason", "quarantined by the user", "Why the hosts are quarantined")
+	return cmd
+}
+
+func (o *QuarantineAddOptions) Complete(_ *cobra.Command, args []string) error {
+	o.hosts = args
+	return nil
+}
+
+func (o *QuarantineAddOptions) Validate() error {
+	if len(o.hosts) == 0 {
+		return errors.New("host name can not be empty")
+	}
+	return nil
+}
+
+func (o *QuarantineAddOptions) Run() error {
+	return pipelines.QuarantineHosts(o.argument(), o.hosts, o.Reason)
+}
@@ -0,0 +1,56 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package quarantine
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+)
+
+// NewCmdQuarantine creates a new quarantine command
+func NewCmdQuarantine() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "quarantine",
+		Short: "Manage the quarantined hosts of a cluster, which the pipelines skip",
+		Long: `Manage the quarantined hosts of a cluster. The pipelines skip the quarantined hosts with a warning, unless
+--include-quarantined is set. A host is also quarantined automatically after being unreachable in 3 consecutive runs,
+and released once it is reached again.`,
+	}
+
+	cmd.AddCommand(NewCmdQuarantineList())
+	cmd.AddCommand(NewCmdQuarantineAdd())
+	cmd.AddCommand(NewCmdQuarantineRemove())
+	return cmd
+}
+
+type QuarantineOptions struct {
+	ClusterCfgFile string
+	Debug          bool
+}
+
+func (o *QuarantineOptions) argument() common.Argument {
+	return common.Argument{
+		FilePath: o.ClusterCfgFile,
+		Debug:    o.Debug,
+	}
+}
+
+func (o *QuarantineOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file")
+	cmd.Flags().BoolVar(&o.Debug, "debug", false, "Print detailed information")
+}
@@ -0,0 +1,64 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package quarantine
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+type QuarantineRemoveOptions struct {
+	QuarantineOptions
+	hosts []string
+}
+
+// NewCmdQuarantineRemove creates a new quarantine remove command
+func NewCmdQuarantineRemove() *cobra.Command {
+	o := &QuarantineRemoveOptions{}
+	cmd := &cobra.Command{
+		Use:     "remove [host]...",
+		Aliases: []string{"release"},
+		Short:   "Release hosts of a cluster from the quarantine",
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Complete(cmd, args))
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func (o *QuarantineRemoveOptions) Complete(_ *cobra.Command, args []string) error {
+	o.hosts = args
+	return nil
+}
+
+func (o *QuarantineRemoveOptions) Validate() error {
+	if len(o.hosts) == 0 {
+		return errors.New("host name can not be empty")
+	}
+	return nil
+}
+
+func (o *QuarantineRemoveOptions) Run() error {
+	return pipelines.ReleaseHosts(o.argument(), o.hosts)
+}
@@ -0,0 +1,91 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/options"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+type ReconcileOptions struct {
+	CommonOptions  *options.CommonOptions
+	ClusterCfgFile string
+}
+
+func NewReconcileOptions() *ReconcileOptions {
+	return &ReconcileOptions{
+		CommonOptions: options.NewCommonOptions(),
+	}
+}
+
+// NewCmdDiff creates a new diff command
+func NewCmdDiff() *cobra.Command {
+	o := NewReconcileOptions()
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Report the drift of the hosts of a cluster from the cluster spec",
+		Long: `Compare the state of the hosts of a cluster with the cluster spec and report the drift: the files KubeKey renders for
+each host, such as the unit files and the configs of the components, the kernel parameters KubeKey sets, and the
+versions of kubelet, kubeadm, etcd and containerd. The diffs of the files are printed with --debug.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Run(false))
+		},
+	}
+
+	o.CommonOptions.AddCommonFlag(cmd)
+	o.AddFlags(cmd)
+	return cmd
+}
+
+// NewCmdReconcile creates a new reconcile command
+func NewCmdReconcile() *cobra.Command {
+	o := NewReconcileOptions()
+	cmd := &cobra.Command{
+		Use:   "reconcile",
+		Short: "Repair the drift of the hosts of a cluster from the cluster spec",
+		Long: `Report the drift of the hosts of a cluster from the cluster spec as kk diff, then write the drifted files and set
+the drifted kernel parameters after a confirmation, without a full installation. The systemd units are reloaded, the
+services are left to be restarted. The versions are only reported, they are upgraded by kk upgrade.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Run(true))
+		},
+	}
+
+	o.CommonOptions.AddCommonFlag(cmd)
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func (o *ReconcileOptions) Run(fix bool) error {
+	arg := common.Argument{
+		FilePath:         o.ClusterCfgFile,
+		Strategy:         o.CommonOptions.Strategy,
+		IgnoreErr:        o.CommonOptions.IgnoreErr,
+		SkipConfirmCheck: o.CommonOptions.SkipConfirmCheck,
+		Namespace:        o.CommonOptions.Namespace,
+	}
+	o.CommonOptions.ApplyTo(&arg)
+	return pipelines.ReconcileCluster(arg, fix)
+}
+
+func (o *ReconcileOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file")
+}
@@ -0,0 +1,94 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package render
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/files"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+type RenderOptions struct {
+	ClusterCfgFile string
+	OutputDir      string
+	Verbose        bool
+}
+
+func NewRenderOptions() *RenderOptions {
+	return &RenderOptions{}
+}
+
+// NewCmdRender creates a new render command
+func NewCmdRender() *cobra.Command {
+	o := NewRenderOptions()
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "Render the files KubeKey would place on each node of the cluster without applying them",
+		Long: `Walk through the create cluster pipeline without connecting to the hosts, and write every file rendered for each
+node, such as the kubeadm configs, static pods, unit files and CNI manifests, to <output>/<node>/<path on the node>.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func (o *RenderOptions) Validate() error {
+	if o.ClusterCfgFile == "" {
+		return fmt.Errorf("the configuration file is required by --filename")
+	}
+	if o.OutputDir == "" {
+		return fmt.Errorf("the output directory is required by --output")
+	}
+	return nil
+}
+
+func (o *RenderOptions) Run() error {
+	dir, err := filepath.Abs(o.OutputDir)
+	if err != nil {
+		return err
+	}
+	arg := common.Argument{
+		FilePath:         o.ClusterCfgFile,
+		Debug:            o.Verbose,
+		RenderDir:        dir,
+		SkipConfirmCheck: true,
+		NoTUI:            true,
+	}
+	// the binaries are copied to the nodes but never rendered
+	files.SkipDownload()
+	if err := pipelines.CreateCluster(arg); err != nil {
+		return err
+	}
+	fmt.Printf("The rendered files are written to %s\n", dir)
+	return nil
+}
+
+func (o *RenderOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file")
+	cmd.Flags().StringVarP(&o.OutputDir, "output", "o", "", "Path to the directory the rendered files are written to")
+	cmd.Flags().BoolVar(&o.Verbose, "debug", false, "Print detailed information")
+}
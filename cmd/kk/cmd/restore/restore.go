@@ -0,0 +1,90 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package restore
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/options"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/health"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+type RestoreOptions struct {
+	CommonOptions  *options.CommonOptions
+	ClusterCfgFile string
+	From           string
+	S3Endpoint     string
+	Timeout        time.Duration
+}
+
+func NewRestoreOptions() *RestoreOptions {
+	return &RestoreOptions{
+		CommonOptions: options.NewCommonOptions(),
+	}
+}
+
+// NewCmdRestore creates a new restore command
+func NewCmdRestore() *cobra.Command {
+	o := NewRestoreOptions()
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore the etcd and the control plane of a cluster from a backup",
+		Long: `Rebuild the control plane of a cluster from a backup taken by kk backup, a local dir or an s3://<bucket>/<prefix>
+of the backup: the kubelet and the control-plane containers of the control-plane nodes are stopped, their
+/etc/kubernetes is restored, the etcd deployed by KubeKey is restored from the snapshot on all the etcd nodes, and the
+kubelet is started again until the kube-apiserver is ready. The replaced files and the data dir of the etcd are kept
+as <path>.<timestamp>.bak on the nodes.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+		},
+	}
+
+	o.CommonOptions.AddCommonFlag(cmd)
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func (o *RestoreOptions) Validate() error {
+	if o.From == "" {
+		return errors.New("--from is required")
+	}
+	return nil
+}
+
+func (o *RestoreOptions) Run() error {
+	arg := common.Argument{
+		FilePath:         o.ClusterCfgFile,
+		SkipConfirmCheck: o.CommonOptions.SkipConfirmCheck,
+		Namespace:        o.CommonOptions.Namespace,
+	}
+	o.CommonOptions.ApplyTo(&arg)
+	return pipelines.RestoreCluster(arg, o.From, o.S3Endpoint, o.Timeout)
+}
+
+func (o *RestoreOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file")
+	cmd.Flags().StringVar(&o.From, "from", "", "Local dir or s3://<bucket>/<prefix> of the backup to restore")
+	cmd.Flags().StringVar(&o.S3Endpoint, "s3-endpoint", "", "URL of the S3-compatible service of the bucket, AWS S3 by default")
+	cmd.Flags().DurationVar(&o.Timeout, "timeout", health.DefaultTimeout, "Timeout for the etcd to be healthy and the kube-apiserver to be ready")
+}
@@ -28,17 +28,42 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/add"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/adopt"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/agent"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/alpha"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/artifact"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/backup"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/cert"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/cluster"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/completion"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/config"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/connector"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/create"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/delete"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/diagnose"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/drift"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/history"
 	initOs "github.com/kubesphere/kubekey/v3/cmd/kk/cmd/init"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/operator"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/options"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/patch"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/prepareimage"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/plugin"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/quarantine"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/reconcile"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/render"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/restore"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/scale"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/token"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/upgrade"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/version"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/exporter"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/fips"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/tracing"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/files"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/version/kubernetes"
+	kkversion "github.com/kubesphere/kubekey/v3/version"
 )
 
 type KubeKeyOptions struct {
@@ -106,6 +131,65 @@ func NewKubeKeyCommand(o KubeKeyOptions) *cobra.Command {
 3. Install Kubernetes first, then deploy KubeSphere on it using https://github.com/kubesphere/ks-installer`,
 	}
 
+	var fipsMode bool
+	cmds.PersistentFlags().BoolVar(&fipsMode, "fips", fips.Enabled(),
+		"Restrict the SSH and TLS algorithms to the FIPS 140-2 approved ones and refuse weak keys, it can be enabled by the env KUBEKEY_FIPS=true too")
+	versionMatrix := os.Getenv(kubernetes.MatrixEnv)
+	cmds.PersistentFlags().StringVar(&versionMatrix, "version-matrix", versionMatrix,
+		"The file overriding the embedded version matrix which resolves the versions of etcd, containerd, runc, CNI plugins, pause and CoreDNS by the Kubernetes version, it can be set by the env KUBEKEY_VERSION_MATRIX too")
+	downloadPolicy := os.Getenv(files.DownloadPolicyEnv)
+	cmds.PersistentFlags().StringVar(&downloadPolicy, "download-policy", downloadPolicy,
+		"The file of the policy downloading the binaries through mirrors, a proxy and a CA bundle, and verifying them by the checksum files of the mirrors and the cosign or gpg signatures, it can be set by the env KUBEKEY_DOWNLOAD_POLICY too")
+	metricsAddr := os.Getenv(exporter.AddrEnv)
+	cmds.PersistentFlags().StringVar(&metricsAddr, "metrics-addr", metricsAddr,
+		"The address the Prometheus metrics of the pipelines and the connections are served on at /metrics during the run, e.g. :9090, it can be set by the env KUBEKEY_METRICS_ADDR too")
+	traceEndpoint := os.Getenv(tracing.EndpointEnv)
+	cmds.PersistentFlags().StringVar(&traceEndpoint, "trace-endpoint", traceEndpoint,
+		"The OTLP/HTTP endpoint the OpenTelemetry spans of the pipelines, the modules, the tasks and the commands are exported to, e.g. http://localhost:4318, it can be set by the env KUBEKEY_TRACE_ENDPOINT too")
+	logFormat := os.Getenv(logger.FormatEnv)
+	cmds.PersistentFlags().StringVar(&logFormat, "log-format", logFormat,
+		"The format of the logs, text or json which prints each entry as a JSON object with the fields of the pipeline, the module, the task and the host, it can be set by the env KUBEKEY_LOG_FORMAT too (default is text)")
+	var allowUnverified bool
+	cmds.PersistentFlags().BoolVar(&allowUnverified, "allow-unverified", false,
+		"Allow the downloaded binaries without any known checksum, which are refused by default")
+	clusterName := os.Getenv(cluster.ClusterEnv)
+	cmds.PersistentFlags().StringVar(&clusterName, "cluster", clusterName,
+		"The managed cluster the commands without -f are run against, with its config file and its artifact, instead of the current cluster of kk cluster use, it can be set by the env KUBEKEY_CLUSTER too")
+	cmds.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if fipsMode {
+			fips.Enable()
+		}
+		if err := logger.SetFormat(logFormat); err != nil {
+			return err
+		}
+		if versionMatrix != "" {
+			if err := kubernetes.LoadMatrixFile(versionMatrix); err != nil {
+				return err
+			}
+		}
+		if downloadPolicy != "" {
+			p, err := files.LoadDownloadPolicy(downloadPolicy)
+			if err != nil {
+				return err
+			}
+			files.SetDownloadPolicy(p)
+		}
+		if allowUnverified {
+			files.AllowUnverified()
+		}
+		if metricsAddr != "" {
+			if err := exporter.Serve(metricsAddr); err != nil {
+				return err
+			}
+		}
+		if traceEndpoint != "" {
+			if err := tracing.Setup(traceEndpoint, kkversion.Get().GitVersion); err != nil {
+				return err
+			}
+		}
+		return cluster.ApplyContext(cmd, clusterName)
+	}
+
 	cmds.AddCommand(initOs.NewCmdInit())
 
 	cmds.AddCommand(alpha.NewAlphaCmd())
@@ -113,9 +197,28 @@ func NewKubeKeyCommand(o KubeKeyOptions) *cobra.Command {
 	cmds.AddCommand(create.NewCmdCreate())
 	cmds.AddCommand(delete.NewCmdDelete())
 	cmds.AddCommand(add.NewCmdAdd())
+	cmds.AddCommand(scale.NewCmdScale())
 	cmds.AddCommand(upgrade.NewCmdUpgrade())
+	cmds.AddCommand(adopt.NewCmdAdopt())
+	cmds.AddCommand(patch.NewCmdPatch())
+	cmds.AddCommand(backup.NewCmdBackup())
+	cmds.AddCommand(restore.NewCmdRestore())
+	cmds.AddCommand(cluster.NewCmdCluster())
+	cmds.AddCommand(prepareimage.NewCmdPrepareImage())
 	cmds.AddCommand(cert.NewCmdCerts())
+	cmds.AddCommand(token.NewCmdToken())
+	cmds.AddCommand(quarantine.NewCmdQuarantine())
+	cmds.AddCommand(drift.NewCmdDrift())
+	cmds.AddCommand(diagnose.NewCmdDiagnose())
+	cmds.AddCommand(agent.NewCmdAgent())
+	cmds.AddCommand(config.NewCmdConfig())
+	cmds.AddCommand(reconcile.NewCmdDiff())
+	cmds.AddCommand(reconcile.NewCmdReconcile())
+	cmds.AddCommand(connector.NewCmdConnector())
+	cmds.AddCommand(history.NewCmdHistory())
 	cmds.AddCommand(artifact.NewCmdArtifact())
+	cmds.AddCommand(operator.NewCmdOperator())
+	cmds.AddCommand(render.NewCmdRender())
 
 	cmds.AddCommand(plugin.NewCmdPlugin(o.IOStreams))
 
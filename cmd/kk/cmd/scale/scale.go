@@ -0,0 +1,129 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scale
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/options"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+type ScaleOptions struct {
+	CommonOptions    *options.CommonOptions
+	ClusterCfgFile   string
+	Role             string
+	Add              []string
+	Remove           string
+	SkipPullImages   bool
+	ContainerManager string
+	DownloadCmd      string
+	Artifact         string
+	InstallPackages  bool
+}
+
+func NewScaleOptions(role string) *ScaleOptions {
+	return &ScaleOptions{
+		CommonOptions: options.NewCommonOptions(),
+		Role:          role,
+	}
+}
+
+// NewCmdScale creates a new scale command
+func NewCmdScale() *cobra.Command {
+	o := options.NewCommonOptions()
+	cmd := &cobra.Command{
+		Use:   "scale",
+		Short: "Add or remove the control-plane or the etcd nodes of the cluster",
+	}
+
+	o.AddCommonFlag(cmd)
+
+	cmd.AddCommand(NewCmdScaleControlPlane())
+	cmd.AddCommand(NewCmdScaleEtcd())
+	return cmd
+}
+
+func newCmdScale(o *ScaleOptions, use, short string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Complete(cmd, args))
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+		},
+	}
+
+	o.CommonOptions.AddCommonFlag(cmd)
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func (o *ScaleOptions) Complete(_ *cobra.Command, _ []string) error {
+	if o.Artifact == "" {
+		o.InstallPackages = false
+	}
+	return nil
+}
+
+func (o *ScaleOptions) Validate() error {
+	if (len(o.Add) == 0) == (o.Remove == "") {
+		return errors.New("either --add or --remove must be set")
+	}
+	return nil
+}
+
+func (o *ScaleOptions) Run() error {
+	arg := common.Argument{
+		FilePath:         o.ClusterCfgFile,
+		Strategy:         o.CommonOptions.Strategy,
+		Resume:           o.CommonOptions.Resume,
+		Tags:             o.CommonOptions.Tags,
+		SkipTags:         o.CommonOptions.SkipTags,
+		IgnoreErr:        o.CommonOptions.IgnoreErr,
+		SkipConfirmCheck: o.CommonOptions.SkipConfirmCheck,
+		SkipPullImages:   o.SkipPullImages,
+		ContainerManager: o.ContainerManager,
+		Artifact:         o.Artifact,
+		InstallPackages:  o.InstallPackages,
+		Namespace:        o.CommonOptions.Namespace,
+		Nodes:            o.Add,
+		NodeName:         o.Remove,
+		DownloadCmd:      o.DownloadCmd,
+	}
+	o.CommonOptions.ApplyTo(&arg)
+	if o.Remove != "" {
+		return pipelines.ScaleDown(arg, o.Role)
+	}
+	return pipelines.ScaleUp(arg, o.Role)
+}
+
+func (o *ScaleOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file")
+	cmd.Flags().StringSliceVar(&o.Add, "add", nil, "The new nodes to add, which must be the only hosts of the config not in the cluster")
+	cmd.Flags().StringVar(&o.Remove, "remove", "", "The node to remove")
+	cmd.Flags().BoolVarP(&o.SkipPullImages, "skip-pull-images", "", false, "Skip pre pull images")
+	cmd.Flags().StringVarP(&o.ContainerManager, "container-manager", "", "docker", "Container manager: docker, crio, containerd and isula.")
+	cmd.Flags().StringVarP(&o.DownloadCmd, "download-cmd", "", "",
+		`The user defined command to download the necessary binary files. The first param '%s' is output path, the second param '%s', is the URL. The built-in downloader, configured by --download-policy, is used if it is empty`)
+	cmd.Flags().StringVarP(&o.Artifact, "artifact", "a", "", "Path to a KubeKey artifact")
+	cmd.Flags().BoolVarP(&o.InstallPackages, "with-packages", "", false, "install operation system packages by artifact")
+}
@@ -0,0 +1,75 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package token
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/options"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstraptoken"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+type TokenCreateOptions struct {
+	CommonOptions  *options.CommonOptions
+	ClusterCfgFile string
+	Options        bootstraptoken.CreateOptions
+}
+
+func NewTokenCreateOptions() *TokenCreateOptions {
+	return &TokenCreateOptions{
+		CommonOptions: options.NewCommonOptions(),
+	}
+}
+
+// NewCmdTokenCreate creates a new token create command
+func NewCmdTokenCreate() *cobra.Command {
+	o := NewTokenCreateOptions()
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a bootstrap token on a cluster and print it",
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+		},
+	}
+
+	o.CommonOptions.AddCommonFlag(cmd)
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func (o *TokenCreateOptions) Validate() error {
+	return o.Options.Validate()
+}
+
+func (o *TokenCreateOptions) Run() error {
+	return pipelines.CreateBootstrapToken(newArgument(o.CommonOptions, o.ClusterCfgFile), o.Options)
+}
+
+func (o *TokenCreateOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file")
+	cmd.Flags().DurationVar(&o.Options.TTL, "ttl", 24*time.Hour, "Duration before the token is deleted, it never expires if it is 0")
+	cmd.Flags().StringSliceVar(&o.Options.Usages, "usages", bootstraptoken.DefaultUsages,
+		"Usages of the token, the valid usages are signing and authentication")
+	cmd.Flags().StringSliceVar(&o.Options.Groups, "groups", bootstraptoken.DefaultGroups,
+		"Extra groups of the token when it is used for authentication, they must start with system:bootstrappers:")
+	cmd.Flags().StringVar(&o.Options.Description, "description", "", "A human-friendly description of the token")
+}
@@ -0,0 +1,76 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package token
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/options"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+type TokenRevokeOptions struct {
+	CommonOptions  *options.CommonOptions
+	ClusterCfgFile string
+	ids            []string
+}
+
+func NewTokenRevokeOptions() *TokenRevokeOptions {
+	return &TokenRevokeOptions{
+		CommonOptions: options.NewCommonOptions(),
+	}
+}
+
+// NewCmdTokenRevoke creates a new token revoke command
+func NewCmdTokenRevoke() *cobra.Command {
+	o := NewTokenRevokeOptions()
+	cmd := &cobra.Command{
+		Use:   "revoke [token-id]...",
+		Short: "Revoke bootstrap tokens of a cluster by their IDs",
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.Complete(cmd, args))
+			util.CheckErr(o.Validate())
+			util.CheckErr(o.Run())
+		},
+	}
+
+	o.CommonOptions.AddCommonFlag(cmd)
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func (o *TokenRevokeOptions) Complete(_ *cobra.Command, args []string) error {
+	o.ids = args
+	return nil
+}
+
+func (o *TokenRevokeOptions) Validate() error {
+	if len(o.ids) == 0 {
+		return errors.New("token id can not be empty")
+	}
+	return nil
+}
+
+func (o *TokenRevokeOptions) Run() error {
+	return pipelines.RevokeBootstrapTokens(newArgument(o.CommonOptions, o.ClusterCfgFile), o.ids)
+}
+
+func (o *TokenRevokeOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.ClusterCfgFile, "filename", "f", "", "Path to a configuration file")
+}
@@ -0,0 +1,78 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package token
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/cmd/options"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+)
+
+type TokenOptions struct {
+	CommonOptions *options.CommonOptions
+}
+
+func NewTokenOptions() *TokenOptions {
+	return &TokenOptions{
+		CommonOptions: options.NewCommonOptions(),
+	}
+}
+
+// NewCmdToken creates a new token command
+func NewCmdToken() *cobra.Command {
+	o := NewTokenOptions()
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "Manage the bootstrap tokens of a cluster",
+	}
+
+	o.CommonOptions.AddCommonFlag(cmd)
+
+	cmd.AddCommand(NewCmdTokenList())
+	cmd.AddCommand(NewCmdTokenCreate())
+	cmd.AddCommand(NewCmdTokenRevoke())
+	return cmd
+}
+
+func newArgument(o *options.CommonOptions, clusterCfgFile string) common.Argument {
+	return common.Argument{
+		FilePath:                clusterCfgFile,
+		Debug:                   o.Verbose,
+		ChaosConfig:             o.ChaosConfig,
+		PolicyConfig:            o.PolicyConfig,
+		RedactionConfig:         o.RedactionConfig,
+		AuditLog:                o.AuditLog,
+		DryRun:                  o.DryRun,
+		Report:                  o.Report,
+		JUnitReport:             o.JUnitReport,
+		Strategy:                o.Strategy,
+		Serial:                  o.Serial,
+		MaxFailPercent:          o.MaxFailPercent,
+		Resume:                  o.Resume,
+		NoTUI:                   o.NoTUI,
+		IncludeQuarantined:      o.IncludeQuarantined,
+		IgnoreMaintenanceWindow: o.IgnoreMaintenanceWindow,
+		CollectDiagnostics:      o.CollectDiagnostics,
+		TransferRateLimit:       o.TransferRateLimit,
+		TransferCompression:     o.TransferCompression,
+		HostLogs:                o.HostLogs,
+		Tags:                    o.Tags,
+		SkipTags:                o.SkipTags,
+		Strict:                  o.Strict,
+	}
+}
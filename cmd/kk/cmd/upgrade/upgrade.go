@@ -87,13 +87,18 @@ func (o *UpgradeOptions) Run() error {
 		KsEnable:            o.EnableKubeSphere,
 		KsVersion:           o.KubeSphere,
 		SkipPullImages:      o.SkipPullImages,
-		Debug:               o.CommonOptions.Verbose,
+		Strategy:            o.CommonOptions.Strategy,
+		Resume:              o.CommonOptions.Resume,
+		Tags:                o.CommonOptions.Tags,
+		SkipTags:            o.CommonOptions.SkipTags,
 		SkipConfirmCheck:    o.CommonOptions.SkipConfirmCheck,
 		Artifact:            o.Artifact,
 		SkipDependencyCheck: o.SkipDependencyCheck,
 		EtcdUpgrade:         o.EtcdUpgrade,
+		DownloadCmd:         o.DownloadCmd,
 	}
-	return pipelines.UpgradeCluster(arg, o.DownloadCmd)
+	o.CommonOptions.ApplyTo(&arg)
+	return pipelines.UpgradeCluster(arg)
 }
 
 func (o *UpgradeOptions) AddFlags(cmd *cobra.Command) {
@@ -101,8 +106,8 @@ func (o *UpgradeOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.Kubernetes, "with-kubernetes", "", "", "Specify a supported version of kubernetes")
 	cmd.Flags().BoolVarP(&o.EnableKubeSphere, "with-kubesphere", "", false, fmt.Sprintf("Deploy a specific version of kubesphere (default %s)", kubesphere.Latest().Version))
 	cmd.Flags().BoolVarP(&o.SkipPullImages, "skip-pull-images", "", false, "Skip pre pull images")
-	cmd.Flags().StringVarP(&o.DownloadCmd, "download-cmd", "", "curl -L -o %s %s",
-		`The user defined command to download the necessary binary files. The first param '%s' is output path, the second param '%s', is the URL`)
+	cmd.Flags().StringVarP(&o.DownloadCmd, "download-cmd", "", "",
+		`The user defined command to download the necessary binary files. The first param '%s' is output path, the second param '%s', is the URL. The built-in downloader, configured by --download-policy, is used if it is empty`)
 	cmd.Flags().StringVarP(&o.Artifact, "artifact", "a", "", "Path to a KubeKey artifact")
 	cmd.Flags().BoolVarP(&o.SkipDependencyCheck, "skip-dependency-check", "", false, "Skip kubernetes and kubesphere dependency version check")
 	cmd.Flags().BoolVarP(&o.EtcdUpgrade, "with-etcd", "", false, "Upgrade etcd")
@@ -0,0 +1,79 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package access
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Merge merges the cluster, the user and the context of the current context of the kubeconfig into the config, all of
+// them under the name, replacing the ones of the same name. The server of the cluster is set to the server. The
+// current context of the config is set to the name if it has none.
+func Merge(config, kubeConfig *clientcmdapi.Config, name, server string) error {
+	ctx, ok := kubeConfig.Contexts[kubeConfig.CurrentContext]
+	if !ok {
+		return errors.Errorf("the current context %q isn't in the kubeconfig", kubeConfig.CurrentContext)
+	}
+	cluster, ok := kubeConfig.Clusters[ctx.Cluster]
+	if !ok {
+		return errors.Errorf("the cluster %q of the context %q isn't in the kubeconfig", ctx.Cluster, kubeConfig.CurrentContext)
+	}
+	user, ok := kubeConfig.AuthInfos[ctx.AuthInfo]
+	if !ok {
+		return errors.Errorf("the user %q of the context %q isn't in the kubeconfig", ctx.AuthInfo, kubeConfig.CurrentContext)
+	}
+
+	cluster = cluster.DeepCopy()
+	if server != "" {
+		cluster.Server = server
+	}
+	ctx = ctx.DeepCopy()
+	ctx.Cluster, ctx.AuthInfo = name, name
+	config.Clusters[name] = cluster
+	config.AuthInfos[name] = user.DeepCopy()
+	config.Contexts[name] = ctx
+	if config.CurrentContext == "" {
+		config.CurrentContext = name
+	}
+	return nil
+}
+
+// MergeFile merges the kubeconfig into the kubeconfig file under the name, see Merge. The file is created if it
+// doesn't exist.
+func MergeFile(file string, kubeConfig []byte, name, server string) error {
+	src, err := clientcmd.Load(kubeConfig)
+	if err != nil {
+		return errors.Wrap(err, "parse the kubeconfig of the cluster failed")
+	}
+	dst := clientcmdapi.NewConfig()
+	if _, err := os.Stat(file); err == nil {
+		if dst, err = clientcmd.LoadFromFile(file); err != nil {
+			return errors.Wrapf(err, "load the kubeconfig %s failed", file)
+		}
+	}
+	if err := Merge(dst, src, name, server); err != nil {
+		return err
+	}
+	if err := clientcmd.WriteToFile(*dst, file); err != nil {
+		return errors.Wrapf(err, "write the kubeconfig %s failed", file)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package access
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/access/templates"
+)
+
+const adminConf = `apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    certificate-authority-data: Y2E=
+    server: https://lb.kubesphere.local:6443
+  name: cluster.local
+contexts:
+- context:
+    cluster: cluster.local
+    user: kubernetes-admin
+  name: kubernetes-admin@cluster.local
+current-context: kubernetes-admin@cluster.local
+users:
+- name: kubernetes-admin
+  user:
+    client-certificate-data: Y2VydA==
+    client-key-data: a2V5
+`
+
+func TestMergeFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".kube", "config")
+	// the file is created, with the context as its current context
+	if err := MergeFile(file, []byte(adminConf), "staging", "https://192.168.0.2:6443"); err != nil {
+		t.Fatal(err)
+	}
+	if err := MergeFile(file, []byte(adminConf), "production", "https://192.168.1.2:6443"); err != nil {
+		t.Fatal(err)
+	}
+	// the context of the same name is replaced
+	if err := MergeFile(file, []byte(adminConf), "staging", "https://192.168.0.3:6443"); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := clientcmd.LoadFromFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.CurrentContext != "staging" || len(config.Contexts) != 2 || len(config.Clusters) != 2 || len(config.AuthInfos) != 2 {
+		t.Fatalf("the merged kubeconfig is %+v", config)
+	}
+	for name, server := range map[string]string{"staging": "https://192.168.0.3:6443", "production": "https://192.168.1.2:6443"} {
+		ctx := config.Contexts[name]
+		if ctx == nil || ctx.Cluster != name || ctx.AuthInfo != name {
+			t.Errorf("the context %s is %+v", name, ctx)
+			continue
+		}
+		if got := config.Clusters[name].Server; got != server {
+			t.Errorf("the server of %s is %s, want %s", name, got, server)
+		}
+		if got := string(config.AuthInfos[name].ClientKeyData); got != "key" {
+			t.Errorf("the client key of %s is %q", name, got)
+		}
+	}
+}
+
+func TestMergeWithoutCurrentContext(t *testing.T) {
+	kubeConfig, err := clientcmd.Load([]byte(adminConf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kubeConfig.CurrentContext = "missing"
+	if err := Merge(clientcmdapi.NewConfig(), kubeConfig, "staging", ""); err == nil {
+		t.Error("Merge() of the kubeconfig without its current context succeeded")
+	}
+}
+
+func TestRBACTemplate(t *testing.T) {
+	var out bytes.Buffer
+	data := map[string]interface{}{"Bindings": Bindings([]string{"oidc:platform-admins"}, nil)}
+	if err := templates.RBAC.Execute(&out, data); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	if !strings.Contains(got, "name: "+AdminBinding) || !strings.Contains(got, `name: "oidc:platform-admins"`) {
+		t.Errorf("the RBAC manifest doesn't bind the admin groups:\n%s", got)
+	}
+	if strings.Contains(got, ReadOnlyBinding) {
+		t.Errorf("the RBAC manifest has the binding without a group:\n%s", got)
+	}
+}
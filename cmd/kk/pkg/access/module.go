@@ -0,0 +1,97 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package access
+
+import (
+	"path/filepath"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/access/templates"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+// KubeConfigModule saves the admin kubeconfig of the new cluster into the work dir of the cluster, and merges it into
+// the kubeconfig of the user under the Context if it is set.
+type KubeConfigModule struct {
+	common.KubeModule
+	Context string
+}
+
+func (k *KubeConfigModule) Init() {
+	k.Name = "KubeConfigModule"
+	k.Tags = []string{"kubeconfig"}
+	k.Desc = "Save the kubeconfig of the cluster"
+
+	save := &task.RemoteTask{
+		Name:    "SaveKubeConfig",
+		Desc:    "Save the admin kubeconfig of the cluster",
+		Hosts:   k.Runtime.GetHostsByRole(common.Master),
+		Prepare: new(common.OnlyFirstMaster),
+		Action:  &SaveKubeConfig{Context: k.Context},
+	}
+
+	k.Tasks = []task.Interface{
+		save,
+	}
+}
+
+// RBACModule binds the groups of the initial RBAC of the cluster from the first control-plane node.
+type RBACModule struct {
+	common.KubeModule
+	Skip bool
+}
+
+func (r *RBACModule) IsSkip() bool {
+	return r.Skip
+}
+
+func (r *RBACModule) Init() {
+	r.Name = "RBACModule"
+	r.Tags = []string{"rbac"}
+	r.Desc = "Bootstrap the initial RBAC of the cluster"
+
+	rbac := r.KubeConf.Cluster.RBAC
+	generate := &task.RemoteTask{
+		Name:    "GenerateRBAC",
+		Desc:    "Generate the ClusterRoleBindings of the initial RBAC",
+		Hosts:   r.Runtime.GetHostsByRole(common.Master),
+		Prepare: new(common.OnlyFirstMaster),
+		Action: &action.Template{
+			Template: templates.RBAC,
+			Dst:      filepath.Join(common.KubeAddonsDir, templates.RBAC.Name()),
+			Data: util.Data{
+				"Bindings": Bindings(rbac.AdminGroups, rbac.ReadOnlyGroups),
+			},
+		},
+	}
+
+	apply := &task.RemoteTask{
+		Name:    "ApplyRBAC",
+		Desc:    "Apply the ClusterRoleBindings of the initial RBAC",
+		Hosts:   r.Runtime.GetHostsByRole(common.Master),
+		Prepare: new(common.OnlyFirstMaster),
+		Action:  new(ApplyRBAC),
+		Retry:   3,
+	}
+
+	r.Tasks = []task.Interface{
+		generate,
+		apply,
+	}
+}
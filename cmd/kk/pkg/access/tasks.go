@@ -0,0 +1,140 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package access
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/access/templates"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+const (
+	kubectl = "/usr/local/bin/kubectl"
+
+	// AdminBinding binds the admin groups of the initial RBAC to the cluster-admin ClusterRole.
+	AdminBinding = "kubekey:admin-groups"
+	// ReadOnlyBinding binds the read-only groups of the initial RBAC to the view ClusterRole.
+	ReadOnlyBinding = "kubekey:read-only-groups"
+)
+
+// Binding is a ClusterRoleBinding of the groups of the initial RBAC.
+type Binding struct {
+	Name        string
+	ClusterRole string
+	Groups      []string
+}
+
+// SaveKubeConfig fetches the admin kubeconfig of the cluster from the first control-plane node into the work dir of
+// the cluster, with its server set to the control-plane endpoint, and merges it into the kubeconfig of the user under
+// the Context if it is set.
+type SaveKubeConfig struct {
+	common.KubeAction
+	Context string
+}
+
+func (s *SaveKubeConfig) Execute(runtime connector.Runtime) error {
+	// there is no kubeconfig to fetch in the check mode
+	if connector.IsDryRun(runtime.GetConnector()) {
+		return nil
+	}
+
+	out, err := runtime.GetRunner().SudoCmd(kubectl+" config view --raw --minify --flatten", false)
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "get the kubeconfig of the cluster failed")
+	}
+	kubeConfig, err := clientcmd.Load([]byte(out))
+	if err != nil {
+		return errors.Wrap(err, "parse the kubeconfig of the cluster failed")
+	}
+	server := s.server(runtime)
+	for _, cluster := range kubeConfig.Clusters {
+		cluster.Server = server
+	}
+	data, err := clientcmd.Write(*kubeConfig)
+	if err != nil {
+		return errors.Wrap(err, "encode the kubeconfig of the cluster failed")
+	}
+	local := filepath.Join(runtime.GetClusterWorkDir(), fmt.Sprintf("config-%s", runtime.GetObjName()))
+	if err := os.WriteFile(local, data, 0600); err != nil {
+		return errors.Wrapf(err, "write the kubeconfig %s failed", local)
+	}
+
+	if s.Context == "" {
+		return nil
+	}
+	file := clientcmd.NewDefaultPathOptions().GetDefaultFilename()
+	if err := util.CreateDir(filepath.Dir(file)); err != nil {
+		return err
+	}
+	if err := MergeFile(file, data, s.Context, server); err != nil {
+		return err
+	}
+	logger.Log.Messagef(runtime.RemoteHost().GetName(), "the kubeconfig of the cluster is merged into %s as the context %s, run: kubectl config use-context %s", file, s.Context, s.Context)
+	return nil
+}
+
+// server returns the server of the control-plane endpoint reachable from the control machine, the address of the first
+// control-plane node if the endpoint has no address or its address is internal.
+func (s *SaveKubeConfig) server(runtime connector.Runtime) string {
+	endpoint := s.KubeConf.Cluster.ControlPlaneEndpoint
+	address := endpoint.Address
+	master1 := runtime.GetHostsByRole(common.Master)[0]
+	if address == master1.GetInternalAddress() || address == "" {
+		address = master1.GetAddress()
+	}
+	return fmt.Sprintf("https://%s:%d", address, endpoint.Port)
+}
+
+// ApplyRBAC applies the ClusterRoleBindings of the initial RBAC, and deletes the ones without a group, so they are
+// kept in sync with the groups.
+type ApplyRBAC struct {
+	common.KubeAction
+}
+
+func (a *ApplyRBAC) Execute(runtime connector.Runtime) error {
+	manifest := filepath.Join(common.KubeAddonsDir, templates.RBAC.Name())
+	for _, binding := range Bindings(a.KubeConf.Cluster.RBAC.AdminGroups, a.KubeConf.Cluster.RBAC.ReadOnlyGroups) {
+		if len(binding.Groups) > 0 {
+			continue
+		}
+		if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("%s delete clusterrolebinding %s --ignore-not-found", kubectl, binding.Name), false); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "delete the ClusterRoleBinding %s failed", binding.Name)
+		}
+	}
+	if out, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("%s apply -f %s", kubectl, manifest), false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "apply the initial RBAC failed: %s", strings.TrimSpace(out))
+	}
+	return nil
+}
+
+// Bindings returns the ClusterRoleBindings of the admin and the read-only groups.
+func Bindings(adminGroups, readOnlyGroups []string) []Binding {
+	return []Binding{
+		{Name: AdminBinding, ClusterRole: "cluster-admin", Groups: adminGroups},
+		{Name: ReadOnlyBinding, ClusterRole: "view", Groups: readOnlyGroups},
+	}
+}
@@ -0,0 +1,49 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package templates
+
+import (
+	"text/template"
+
+	"github.com/lithammer/dedent"
+)
+
+// RBAC defines the template of the ClusterRoleBindings of the groups of the initial RBAC, a binding is left out if it
+// has no group.
+var RBAC = template.Must(template.New("kubekey-rbac.yaml").Parse(
+	dedent.Dedent(`{{- range .Bindings }}
+{{- if .Groups }}
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: {{ .Name }}
+  labels:
+    app.kubernetes.io/managed-by: kubekey
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: {{ .ClusterRole }}
+subjects:
+{{- range .Groups }}
+  - apiGroup: rbac.authorization.k8s.io
+    kind: Group
+    name: {{ printf "%q" . }}
+{{- end }}
+{{- end }}
+{{- end }}
+`)))
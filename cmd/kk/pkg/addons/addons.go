@@ -17,50 +17,26 @@
 package addons
 
 import (
-	"net/url"
 	"os"
-	"path/filepath"
 	"strings"
 
-	"github.com/pkg/errors"
-	"helm.sh/helm/v3/pkg/cli"
-	"helm.sh/helm/v3/pkg/getter"
-
 	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
 )
 
-func InstallAddons(kubeConf *common.KubeConf, addon *kubekeyapiv1alpha2.Addon, kubeConfig string) error {
+func InstallAddons(kubeConf *common.KubeConf, addon *kubekeyapiv1alpha2.Addon, kubeConfig, chartsDir string) error {
 	// install chart
 	if addon.Sources.Chart.Name != "" {
 		_ = os.Setenv("HELM_NAMESPACE", strings.TrimSpace(addon.Namespace))
-		if err := InstallChart(kubeConf, addon, kubeConfig); err != nil {
+		if err := InstallChart(kubeConf, addon, kubeConfig, chartsDir); err != nil {
 			return err
 		}
 	}
 
-	// install yaml
-	if len(addon.Sources.Yaml.Path) != 0 {
-		var settings = cli.New()
-		p := getter.All(settings)
-		for _, yaml := range addon.Sources.Yaml.Path {
-			u, _ := url.Parse(yaml)
-			_, err := p.ByScheme(u.Scheme)
-			if err != nil {
-				fp, err := filepath.Abs(yaml)
-				if err != nil {
-					return errors.Wrap(err, "Failed to look up current directory")
-				}
-				yamlPaths := []string{fp}
-				if err := InstallYaml(yamlPaths, addon.Namespace, kubeConfig, kubeConf.Cluster.Kubernetes.Version); err != nil {
-					return err
-				}
-			} else {
-				yamlPaths := []string{yaml}
-				if err := InstallYaml(yamlPaths, addon.Namespace, kubeConfig, kubeConf.Cluster.Kubernetes.Version); err != nil {
-					return err
-				}
-			}
+	// install yaml and kustomization
+	if len(addon.Sources.Yaml.Path) != 0 || addon.Sources.Yaml.Kustomize != "" {
+		if err := ApplyManifests(kubeConfig, addon); err != nil {
+			return err
 		}
 	}
 	return nil
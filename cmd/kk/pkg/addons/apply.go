@@ -0,0 +1,216 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package addons
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+const (
+	// AddonLabel is the label of the resources applied by the manifest addons, whose value is the name of the addon.
+	AddonLabel = "kubekey.kubesphere.io/addon"
+
+	fieldManager = "kubekey"
+)
+
+// ApplyManifests applies the manifests and the kustomization directory of the addon with server-side apply, the
+// resources are labeled with AddonLabel. The resources of the addon which are no longer in the manifests are
+// deleted if Prune is set.
+func ApplyManifests(kubeConfig string, addon *kubekeyapiv1alpha2.Addon) error {
+	f := cmdutil.NewFactory(NewMatchVersionFlags(NewConfigFlags(kubeConfig, addon.Namespace)))
+	namespace, _, err := f.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+
+	builder := f.NewBuilder().Unstructured().ContinueOnError().NamespaceParam(namespace).DefaultNamespace()
+	if paths := manifestPaths(addon.Sources.Yaml.Path); len(paths) > 0 {
+		builder = builder.FilenameParam(false, &resource.FilenameOptions{Filenames: paths})
+	}
+	if addon.Sources.Yaml.Kustomize != "" {
+		builder = builder.FilenameParam(false, &resource.FilenameOptions{Kustomize: addon.Sources.Yaml.Kustomize})
+	}
+	infos, err := builder.Flatten().Do().Infos()
+	if err != nil {
+		return errors.Wrapf(err, "read the manifests of the addon %s failed", addon.Name)
+	}
+
+	applied := sets.NewString()
+	for _, info := range infos {
+		uid, err := applyInfo(info, addon.Name)
+		if err != nil {
+			return err
+		}
+		applied.Insert(uid)
+	}
+
+	if !addon.Sources.Yaml.Prune {
+		return nil
+	}
+	return deleteAddonResources(f, addon.Name, applied)
+}
+
+// manifestPaths returns the absolute paths of the local manifests, the URLs are kept.
+func manifestPaths(paths []string) []string {
+	res := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if isURL(p) {
+			res = append(res, p)
+			continue
+		}
+		if abs, err := filepath.Abs(p); err == nil {
+			p = abs
+		}
+		res = append(res, p)
+	}
+	return res
+}
+
+// applyInfo labels the resource with the addon and applies it, the UID of the applied resource is returned.
+func applyInfo(info *resource.Info, addon string) (string, error) {
+	obj, ok := info.Object.(*unstructured.Unstructured)
+	if !ok {
+		return "", errors.Errorf("unexpected object %T of %s", info.Object, info.ObjectName())
+	}
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[AddonLabel] = addon
+	obj.SetLabels(labels)
+
+	data, err := runtime.Encode(unstructured.UnstructuredJSONScheme, obj)
+	if err != nil {
+		return "", errors.Wrapf(err, "encode %s failed", info.ObjectName())
+	}
+	force := true
+	out, err := resource.NewHelper(info.Client, info.Mapping).
+		WithFieldManager(fieldManager).
+		Patch(info.Namespace, info.Name, types.ApplyPatchType, data, &metav1.PatchOptions{Force: &force})
+	if err != nil {
+		return "", errors.Wrapf(err, "apply %s failed", info.ObjectName())
+	}
+	fmt.Printf("%s serverside-applied\n", info.ObjectName())
+
+	accessor, err := meta.Accessor(out)
+	if err != nil {
+		return "", err
+	}
+	return string(accessor.GetUID()), nil
+}
+
+// DeleteManifests deletes all the resources applied by the manifest addon, which are labeled with the addon.
+func DeleteManifests(kubeConfig, addon string) error {
+	f := cmdutil.NewFactory(NewMatchVersionFlags(NewConfigFlags(kubeConfig, "")))
+	return deleteAddonResources(f, addon, sets.NewString())
+}
+
+// deleteAddonResources deletes the resources labeled with the addon except the ones of the UIDs to keep. All the
+// resource types which can be listed and deleted are looked up.
+func deleteAddonResources(f cmdutil.Factory, addon string, keep sets.String) error {
+	discoveryClient, err := f.ToDiscoveryClient()
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := f.DynamicClient()
+	if err != nil {
+		return err
+	}
+
+	resources, err := deletableResources(discoveryClient)
+	if err != nil {
+		return err
+	}
+
+	selector := fmt.Sprintf("%s=%s", AddonLabel, addon)
+	background := metav1.DeletePropagationBackground
+	for _, gvr := range resources {
+		list, err := dynamicClient.Resource(gvr).List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			if apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) {
+				continue
+			}
+			return errors.Wrapf(err, "list the %s of the addon %s failed", gvr.Resource, addon)
+		}
+		for _, item := range list.Items {
+			if keep.Has(string(item.GetUID())) {
+				continue
+			}
+			if err := deleteResource(dynamicClient, gvr, item, background); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func deleteResource(client dynamic.Interface, gvr schema.GroupVersionResource, item unstructured.Unstructured, propagation metav1.DeletionPropagation) error {
+	err := client.Resource(gvr).Namespace(item.GetNamespace()).
+		Delete(context.TODO(), item.GetName(), metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "delete %s %s failed", gvr.Resource, item.GetName())
+	}
+	name := item.GetName()
+	if item.GetNamespace() != "" {
+		name = item.GetNamespace() + "/" + name
+	}
+	logger.Log.Infof("%s %s deleted", gvr.GroupResource(), name)
+	return nil
+}
+
+// deletableResources returns the preferred versions of the resource types which can be listed and deleted.
+func deletableResources(client discovery.DiscoveryInterface) ([]schema.GroupVersionResource, error) {
+	lists, err := client.ServerPreferredResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, errors.Wrap(err, "discover the resource types failed")
+	}
+
+	var res []schema.GroupVersionResource
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if strings.Contains(r.Name, "/") || !sets.NewString(r.Verbs...).HasAll("list", "delete") {
+				continue
+			}
+			res = append(res, gv.WithResource(r.Name))
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,49 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package addons
+
+import (
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+func TestManifestPaths(t *testing.T) {
+	abs, err := filepath.Abs("addon.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := manifestPaths([]string{"addon.yaml", "/etc/addon.yaml", "https://example.com/addon.yaml"})
+	want := []string{abs, "/etc/addon.yaml", "https://example.com/addon.yaml"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("path %d: got %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestApplyInfoUnexpectedObject(t *testing.T) {
+	info := &resource.Info{Name: "cm", Object: &unstructured.UnstructuredList{}}
+	if _, err := applyInfo(info, "addon"); err == nil {
+		t.Error("expected an error for an object which isn't unstructured")
+	}
+}
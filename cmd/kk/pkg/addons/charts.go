@@ -20,7 +20,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -33,6 +35,7 @@ import (
 	"helm.sh/helm/v3/pkg/getter"
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/storage/driver"
+	versionutil "k8s.io/apimachinery/pkg/util/version"
 	"k8s.io/client-go/util/homedir"
 
 	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
@@ -47,7 +50,7 @@ func debug(format string, v ...interface{}) {
 	}
 }
 
-func InstallChart(kubeConf *common.KubeConf, addon *kubekeyapiv1alpha2.Addon, kubeConfig string) error {
+func InstallChart(kubeConf *common.KubeConf, addon *kubekeyapiv1alpha2.Addon, kubeConfig, chartsDir string) error {
 	actionConfig := new(action.Configuration)
 	var settings = cli.New()
 	helmDriver := os.Getenv("HELM_DRIVER")
@@ -85,15 +88,27 @@ func InstallChart(kubeConf *common.KubeConf, addon *kubekeyapiv1alpha2.Addon, ku
 		logger.Log.Fatalln("No chart name is specified")
 	}
 
+	repoURL, version := addon.Sources.Chart.Repo, addon.Sources.Chart.Version
+	// the chart without a repo or a path is looked up in the artifact first
+	if kubeConf.Cluster.Offline || (repoURL == "" && addon.Sources.Chart.Path == "") {
+		if bundled, ok := BundledChart(chartsDir, addon.Sources.Chart.Name, version); ok {
+			logger.Log.Infof("install the addon %s from the bundled chart %s", addon.Name, bundled)
+			chartName, repoURL, version = bundled, "", ""
+		} else if kubeConf.Cluster.Offline {
+			logger.Log.Warningf("the chart of the addon %s isn't bundled in %s, install it from %s", addon.Name, chartsDir, chartName)
+		}
+	}
+
 	args := []string{addon.Name, chartName}
 
 	client.Install = true
 	client.Namespace = namespace
-	client.Timeout = 300 * time.Second
+	client.Timeout = addon.Sources.Chart.GetTimeout()
 	client.Keyring = defaultKeyring()
-	client.RepoURL = addon.Sources.Chart.Repo
-	client.Version = addon.Sources.Chart.Version
+	client.RepoURL = repoURL
+	client.Version = version
 	client.Wait = addon.Sources.Chart.Wait
+	client.Atomic = addon.Sources.Chart.Atomic
 	//client.Force = true
 
 	if client.Version == "" && client.Devel {
@@ -103,7 +118,15 @@ func InstallChart(kubeConf *common.KubeConf, addon *kubekeyapiv1alpha2.Addon, ku
 	if client.Install {
 		histClient := action.NewHistory(actionConfig)
 		histClient.Max = 1
-		if _, err := histClient.Run(addon.Name); err == driver.ErrReleaseNotFound {
+		history, err := histClient.Run(addon.Name)
+		if err == nil {
+			if err := recoverPendingRelease(actionConfig, history, client.Timeout); err != nil {
+				return err
+			}
+			// the release deleted by the recovery is installed again
+			_, err = histClient.Run(addon.Name)
+		}
+		if err == driver.ErrReleaseNotFound {
 			fmt.Printf("Release %q does not exist. Installing it now.\n", addon.Name)
 			instClient := action.NewInstall(actionConfig)
 			instClient.CreateNamespace = true
@@ -112,6 +135,8 @@ func InstallChart(kubeConf *common.KubeConf, addon *kubekeyapiv1alpha2.Addon, ku
 			instClient.Keyring = client.Keyring
 			instClient.RepoURL = client.RepoURL
 			instClient.Version = client.Version
+			instClient.Wait = client.Wait
+			instClient.Atomic = client.Atomic
 
 			r, err := runInstall(args, instClient, valueOpts, settings)
 			if err != nil {
@@ -151,12 +176,107 @@ func InstallChart(kubeConf *common.KubeConf, addon *kubekeyapiv1alpha2.Addon, ku
 
 	r, err1 := client.Run(args[0], ch, v)
 	if err1 != nil {
+		if client.Atomic {
+			return errors.Wrapf(err1, "UPGRADE FAILED, the release %s is rolled back", args[0])
+		}
 		return errors.Wrap(err1, "UPGRADE FAILED")
 	}
 	printReleaseInfo(r)
 	return nil
 }
 
+// recoverPendingRelease recovers the release left pending by an interrupted install, upgrade or rollback, which
+// blocks any other operation. The pending install is deleted and the others are rolled back to the previous revision.
+func recoverPendingRelease(cfg *action.Configuration, history []*release.Release, timeout time.Duration) error {
+	if len(history) == 0 {
+		return nil
+	}
+	last := history[len(history)-1]
+	if !last.Info.Status.IsPending() {
+		return nil
+	}
+
+	if last.Info.Status == release.StatusPendingInstall || last.Version <= 1 {
+		logger.Log.Warningf("the release %s is %s, delete it to install again", last.Name, last.Info.Status)
+		uninstall := action.NewUninstall(cfg)
+		uninstall.Timeout = timeout
+		if _, err := uninstall.Run(last.Name); err != nil {
+			return errors.Wrapf(err, "delete the pending release %s failed", last.Name)
+		}
+		return nil
+	}
+
+	logger.Log.Warningf("the release %s is %s, roll it back to the revision %d", last.Name, last.Info.Status, last.Version-1)
+	rollback := action.NewRollback(cfg)
+	rollback.Version = last.Version - 1
+	rollback.Timeout = timeout
+	if err := rollback.Run(last.Name); err != nil {
+		return errors.Wrapf(err, "roll back the pending release %s failed", last.Name)
+	}
+	return nil
+}
+
+// UninstallChart deletes the release of the chart addon, the release not found is ignored.
+func UninstallChart(addon *kubekeyapiv1alpha2.Addon, kubeConfig string) error {
+	actionConfig := new(action.Configuration)
+	settings := cli.New()
+	settings.KubeConfig = kubeConfig
+	namespace := "default"
+	if addon.Namespace != "" {
+		namespace = addon.Namespace
+	}
+	if err := actionConfig.Init(settings.RESTClientGetter(), namespace, os.Getenv("HELM_DRIVER"), debug); err != nil {
+		return err
+	}
+
+	uninstall := action.NewUninstall(actionConfig)
+	uninstall.Timeout = addon.Sources.Chart.GetTimeout()
+	uninstall.Wait = addon.Sources.Chart.Wait
+	if _, err := uninstall.Run(addon.Name); err != nil {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			logger.Log.Infof("the release %s of the addon is not found", addon.Name)
+			return nil
+		}
+		return errors.Wrapf(err, "uninstall the release %s failed", addon.Name)
+	}
+	return nil
+}
+
+// BundledChart returns the archive of the chart of the name and the version in the dir of the charts bundled in the
+// artifact, the latest version is returned if the version is empty.
+func BundledChart(dir, name, version string) (string, bool) {
+	name = path.Base(name)
+	archives, err := filepath.Glob(filepath.Join(dir, name+"-*.tgz"))
+	if err != nil {
+		return "", false
+	}
+
+	var (
+		found  string
+		latest *versionutil.Version
+	)
+	for _, archive := range archives {
+		ch, err := helmLoader.Load(archive)
+		if err != nil || ch.Metadata.Name != name {
+			continue
+		}
+		if version != "" {
+			if strings.TrimPrefix(ch.Metadata.Version, "v") == strings.TrimPrefix(version, "v") {
+				return archive, true
+			}
+			continue
+		}
+		v, err := versionutil.ParseSemantic(ch.Metadata.Version)
+		if err != nil {
+			continue
+		}
+		if latest == nil || latest.LessThan(v) {
+			found, latest = archive, v
+		}
+	}
+	return found, found != ""
+}
+
 func runInstall(args []string, client *action.Install, valueOpts *values.Options, settings *cli.EnvSettings) (*release.Release, error) {
 	if client.Version == "" && client.Devel {
 		client.Version = ">0.0.0-0"
@@ -0,0 +1,98 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package addons
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+func TestBundledChart(t *testing.T) {
+	dir := t.TempDir()
+	for _, c := range []struct{ name, version string }{
+		{"nginx", "1.0.0"},
+		{"nginx", "1.2.0"},
+		{"nginx-ingress", "4.0.0"},
+	} {
+		ch := &chart.Chart{Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: c.name, Version: c.version}}
+		if _, err := chartutil.Save(ch, dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		version string
+		want    string
+	}{
+		{name: "nginx", version: "1.0.0", want: "nginx-1.0.0.tgz"},
+		{name: "nginx", version: "v1.0.0", want: "nginx-1.0.0.tgz"},
+		{name: "bitnami/nginx", want: "nginx-1.2.0.tgz"},
+		{name: "oci://registry.example.com/charts/nginx-ingress", want: "nginx-ingress-4.0.0.tgz"},
+		{name: "nginx", version: "2.0.0"},
+		{name: "redis"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+"-"+tt.version, func(t *testing.T) {
+			got, ok := BundledChart(dir, tt.name, tt.version)
+			if ok != (tt.want != "") {
+				t.Fatalf("BundledChart() = %s, %v, want %s", got, ok, tt.want)
+			}
+			if ok && got != filepath.Join(dir, tt.want) {
+				t.Errorf("BundledChart() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+
+	tests := []struct {
+		name     string
+		retries  int
+		failures int
+		wantRuns int
+		wantErr  bool
+	}{
+		{name: "succeeded", retries: 2, failures: 0, wantRuns: 1},
+		{name: "succeeded after a retry", retries: 2, failures: 1, wantRuns: 2},
+		{name: "failed without retries", retries: 0, failures: 1, wantRuns: 1, wantErr: true},
+		{name: "failed after the retries", retries: 2, failures: 5, wantRuns: 3, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs := 0
+			err := retry(tt.retries, 0, func() error {
+				runs++
+				if runs <= tt.failures {
+					return errors.New("failed")
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr || runs != tt.wantRuns {
+				t.Errorf("retry() = %v after %d runs, want error %v after %d runs", err, runs, tt.wantErr, tt.wantRuns)
+			}
+		})
+	}
+}
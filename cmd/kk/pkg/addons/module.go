@@ -32,6 +32,7 @@ func (a *AddonsModule) IsSkip() bool {
 
 func (a *AddonsModule) Init() {
 	a.Name = "AddonsModule"
+	a.Tags = []string{"addons"}
 	a.Desc = "Install addons"
 
 	install := &task.LocalTask{
@@ -44,3 +45,22 @@ func (a *AddonsModule) Init() {
 		install,
 	}
 }
+
+type DeleteAddonModule struct {
+	common.KubeModule
+}
+
+func (d *DeleteAddonModule) Init() {
+	d.Name = "DeleteAddonModule"
+	d.Desc = "Delete the addon"
+
+	deleteAddon := &task.LocalTask{
+		Name:   "DeleteAddon",
+		Desc:   "Delete the release and the resources of the addon",
+		Action: new(DeleteAddon),
+	}
+
+	d.Tasks = []task.Interface{
+		deleteAddon,
+	}
+}
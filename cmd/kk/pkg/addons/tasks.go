@@ -19,10 +19,15 @@ package addons
 import (
 	"fmt"
 	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
 
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/facts"
 )
 
 type Install struct {
@@ -31,11 +36,68 @@ type Install struct {
 
 func (i *Install) Execute(runtime connector.Runtime) error {
 	nums := len(i.KubeConf.Cluster.Addons)
+	var vars util.Data
 	for index, addon := range i.KubeConf.Cluster.Addons {
 		logger.Log.Messagef(runtime.RemoteHost().GetName(), "Install addon [%v-%v]: %s", nums, index, addon.Name)
-		if err := InstallAddons(i.KubeConf, &addon, filepath.Join(runtime.GetWorkDir(), fmt.Sprintf("config-%s", runtime.GetObjName()))); err != nil {
-			return err
+		kubeConfig := filepath.Join(runtime.GetClusterWorkDir(), fmt.Sprintf("config-%s", runtime.GetObjName()))
+		chartsDir := filepath.Join(runtime.GetWorkDir(), common.Charts)
+		addon := addon
+		if addon.Template {
+			// the facts of the hosts which aren't gathered by the pipeline are read from the facts file.
+			if vars == nil {
+				vars = TemplateVars(i.KubeConf.Cluster, runtime.GetObjName(), facts.Current(runtime, time.Now()))
+			}
+			rendered, err := renderAddon(&addon, vars, filepath.Join(runtime.GetClusterWorkDir(), "addons", addon.Name), util.RenderOptions{Strict: runtime.GetStrictRender()})
+			if err != nil {
+				return errors.Wrapf(err, "render addon %s failed", addon.Name)
+			}
+			addon = *rendered
+		}
+		err := retry(addon.Retries, time.Duration(addon.Delay)*time.Second, func() error {
+			return InstallAddons(i.KubeConf, &addon, kubeConfig, chartsDir)
+		})
+		if err != nil {
+			return errors.Wrapf(err, "install addon %s failed", addon.Name)
 		}
 	}
 	return nil
 }
+
+type DeleteAddon struct {
+	common.KubeAction
+}
+
+func (d *DeleteAddon) Execute(runtime connector.Runtime) error {
+	name := d.KubeConf.Arg.AddonName
+
+	kubeConfig := filepath.Join(runtime.GetClusterWorkDir(), fmt.Sprintf("config-%s", runtime.GetObjName()))
+	for _, addon := range d.KubeConf.Cluster.Addons {
+		if addon.Name != name || addon.Sources.Chart.Name == "" {
+			continue
+		}
+		addon := addon
+		if err := UninstallChart(&addon, kubeConfig); err != nil {
+			return errors.Wrapf(err, "delete addon %s failed", name)
+		}
+	}
+
+	if err := DeleteManifests(kubeConfig, name); err != nil {
+		return errors.Wrapf(err, "delete addon %s failed", name)
+	}
+	return nil
+}
+
+// retry runs the fn until it succeeds or it has been retried for the retries times, with the delay between the runs.
+func retry(retries int, delay time.Duration, fn func() error) error {
+	var err error
+	for i := 0; i <= retries; i++ {
+		if i > 0 {
+			logger.Log.Warningf("retry in %s after the failure: %v", delay, err)
+			time.Sleep(delay)
+		}
+		if err = fn(); err == nil {
+			return nil
+		}
+	}
+	return err
+}
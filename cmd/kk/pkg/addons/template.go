@@ -0,0 +1,213 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package addons
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/facts"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/utils"
+)
+
+// AllGroup is the group of the template vars which has all the hosts of the cluster.
+const AllGroup = "all"
+
+// templateFuncs are the functions of the addon templates, the functions of utils.FuncMap and the ones aggregating the
+// facts of the hosts, e.g. {{ .Groups.worker | withLabel "ingress" "true" | internalAddresses | join "," }}.
+var templateFuncs = func() template.FuncMap {
+	f := template.FuncMap{}
+	for k, v := range utils.FuncMap {
+		f[k] = v
+	}
+	f["sumCPUs"] = sumCPUs
+	f["sumMemory"] = sumMemory
+	f["addresses"] = addresses
+	f["internalAddresses"] = internalAddresses
+	f["withLabel"] = withLabel
+	return f
+}()
+
+// TemplateVars returns the variables of the addon templates: Cluster (the cluster spec), ClusterName, KubeVersion,
+// Hosts (the facts of each host by its name) and Groups (the facts of the hosts of each role, and of all the hosts by
+// AllGroup), the hosts of a group are sorted by the name.
+func TemplateVars(cluster *kubekeyapiv1alpha2.ClusterSpec, clusterName string, hosts []facts.Host) util.Data {
+	sorted := append([]facts.Host(nil), hosts...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	byName := make(map[string]facts.Host, len(sorted))
+	groups := map[string][]facts.Host{AllGroup: sorted}
+	for _, host := range sorted {
+		byName[host.Name] = host
+		for _, role := range host.Roles {
+			groups[role] = append(groups[role], host)
+		}
+	}
+	return util.Data{
+		"Cluster":     cluster,
+		"ClusterName": clusterName,
+		"KubeVersion": cluster.Kubernetes.Version,
+		"Hosts":       byName,
+		"Groups":      groups,
+	}
+}
+
+// renderAddon renders the values file, the values and the local manifests of the addon into the dir, and returns the
+// addon with the rendered ones. The URLs and the kustomization directory are kept as they are.
+func renderAddon(addon *kubekeyapiv1alpha2.Addon, vars util.Data, dir string, opts util.RenderOptions) (*kubekeyapiv1alpha2.Addon, error) {
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, errors.Wrapf(err, "clean the rendered files %s failed", dir)
+	}
+	rendered := addon.DeepCopy()
+
+	chart := &rendered.Sources.Chart
+	if chart.ValuesFile != "" && !isURL(chart.ValuesFile) {
+		dst := filepath.Join(dir, "values-"+filepath.Base(chart.ValuesFile))
+		if err := renderFile(chart.ValuesFile, dst, vars, opts); err != nil {
+			return nil, err
+		}
+		chart.ValuesFile = dst
+	}
+	for i, value := range chart.Values {
+		out, err := renderText(fmt.Sprintf("values[%d]", i), value, vars, opts)
+		if err != nil {
+			return nil, err
+		}
+		chart.Values[i] = out
+	}
+
+	for i, path := range rendered.Sources.Yaml.Path {
+		if isURL(path) {
+			continue
+		}
+		dst := filepath.Join(dir, "manifests", fmt.Sprintf("%d-%s", i, filepath.Base(path)))
+		if err := renderPath(path, dst, vars, opts); err != nil {
+			return nil, err
+		}
+		rendered.Sources.Yaml.Path[i] = dst
+	}
+	return rendered, nil
+}
+
+// renderPath renders the file, or the files under the directory, to the dst.
+func renderPath(src, dst string, vars util.Data, opts util.RenderOptions) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return errors.Wrapf(err, "stat the manifest %s failed", src)
+	}
+	if !info.IsDir() {
+		return renderFile(src, dst, vars, opts)
+	}
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		return renderFile(path, filepath.Join(dst, rel), vars, opts)
+	})
+}
+
+func renderFile(src, dst string, vars util.Data, opts util.RenderOptions) error {
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return errors.Wrapf(err, "read the template %s failed", src)
+	}
+	out, err := renderText(filepath.Base(src), string(content), vars, opts)
+	if err != nil {
+		return errors.Wrapf(err, "render the template %s failed", src)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return errors.Wrapf(err, "create the dir of %s failed", dst)
+	}
+	if err := os.WriteFile(dst, []byte(out), 0600); err != nil {
+		return errors.Wrapf(err, "write the rendered %s failed", dst)
+	}
+	return nil
+}
+
+func renderText(name, text string, vars util.Data, opts util.RenderOptions) (string, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", errors.Wrapf(err, "parse the template %s failed", name)
+	}
+	return util.RenderWith(tmpl, vars, opts)
+}
+
+func isURL(path string) bool {
+	u, err := url.Parse(path)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
+
+// sumCPUs returns the total number of the processors of the hosts.
+func sumCPUs(hosts []facts.Host) int {
+	sum := 0
+	for _, host := range hosts {
+		sum += host.CPUs
+	}
+	return sum
+}
+
+// sumMemory returns the total memory of the hosts in bytes.
+func sumMemory(hosts []facts.Host) int64 {
+	var sum int64
+	for _, host := range hosts {
+		sum += host.Memory
+	}
+	return sum
+}
+
+// addresses returns the management addresses of the hosts.
+func addresses(hosts []facts.Host) []string {
+	res := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		res = append(res, host.Address)
+	}
+	return res
+}
+
+// internalAddresses returns the internal addresses of the hosts.
+func internalAddresses(hosts []facts.Host) []string {
+	res := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		res = append(res, host.InternalAddress)
+	}
+	return res
+}
+
+// withLabel returns the hosts with the label, e.g. {{ .Groups.all | withLabel "ingress" "true" }}.
+func withLabel(key, value string, hosts []facts.Host) []facts.Host {
+	var res []facts.Host
+	for _, host := range hosts {
+		if v, ok := host.Labels[key]; ok && v == value {
+			res = append(res, host)
+		}
+	}
+	return res
+}
@@ -0,0 +1,85 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package addons
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/facts"
+)
+
+func TestRenderAddon(t *testing.T) {
+	src := t.TempDir()
+	valuesFile := filepath.Join(src, "values.yaml")
+	if err := os.WriteFile(valuesFile, []byte(
+		`ips: {{ .Groups.all | withLabel "ingress" "true" | internalAddresses | join "," }}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	manifests := filepath.Join(src, "manifests")
+	if err := os.MkdirAll(filepath.Join(manifests, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(manifests, "sub", "cm.yaml"), []byte(`cpus: "{{ sumCPUs .Groups.worker }}"`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	vars := TemplateVars(&kubekeyapiv1alpha2.ClusterSpec{}, "sample", []facts.Host{
+		{Name: "node2", InternalAddress: "10.0.0.3", Roles: []string{"worker"}, CPUs: 8, Memory: 16 << 30, Labels: map[string]string{"ingress": "true"}},
+		{Name: "node1", InternalAddress: "10.0.0.2", Roles: []string{"master", "worker"}, CPUs: 4, Memory: 8 << 30, Labels: map[string]string{"ingress": "true"}},
+	})
+	addon := &kubekeyapiv1alpha2.Addon{Name: "ingress", Sources: kubekeyapiv1alpha2.Sources{
+		Chart: kubekeyapiv1alpha2.Chart{ValuesFile: valuesFile, Values: []string{"memory={{ sumMemory .Groups.worker }}"}},
+		Yaml:  kubekeyapiv1alpha2.Yaml{Path: []string{manifests, "https://example.com/addon.yaml"}},
+	}}
+	dir := filepath.Join(t.TempDir(), "addons", "ingress")
+	rendered, err := renderAddon(addon, vars, dir, util.RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"memory=25769803776"}; !reflect.DeepEqual(rendered.Sources.Chart.Values, want) {
+		t.Errorf("values = %v, want %v", rendered.Sources.Chart.Values, want)
+	}
+	if addon.Sources.Chart.Values[0] != "memory={{ sumMemory .Groups.worker }}" {
+		t.Errorf("the addon is changed: %v", addon.Sources.Chart.Values)
+	}
+	if rendered.Sources.Yaml.Path[1] != "https://example.com/addon.yaml" {
+		t.Errorf("the URL is changed: %s", rendered.Sources.Yaml.Path[1])
+	}
+	for file, want := range map[string]string{
+		rendered.Sources.Chart.ValuesFile:                           "ips: 10.0.0.2,10.0.0.3",
+		filepath.Join(rendered.Sources.Yaml.Path[0], "sub/cm.yaml"): `cpus: "12"`,
+	} {
+		got, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", file, got, want)
+		}
+	}
+
+	addon.Sources.Chart.Values = []string{"{{ sumCPUs .Groups.worker"}
+	if _, err := renderAddon(addon, vars, dir, util.RenderOptions{}); err == nil {
+		t.Error("renderAddon() with an invalid template succeeded")
+	}
+}
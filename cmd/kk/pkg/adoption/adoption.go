@@ -0,0 +1,155 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package adoption validates the assumptions of KubeKey about a cluster it didn't create, before the upgrades and
+// the node deletions run on the cluster.
+package adoption
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+const (
+	// ConfigMapName is the name of the ConfigMap in the kube-system namespace, which records the adoption of the cluster.
+	ConfigMapName = "kubekey-adoption"
+	// KubeadmConfigPath is the kubeadm config, which KubeKey writes to the control-plane nodes of the clusters it creates.
+	KubeadmConfigPath = "/etc/kubernetes/kubeadm-config.yaml"
+
+	// RecordCommand succeeds when the adoption of the cluster is recorded.
+	RecordCommand = "/usr/local/bin/kubectl -n kube-system get configmap " + ConfigMapName
+)
+
+// Requirement is an assumption of KubeKey about a cluster, the cluster must meet it before it is adopted.
+type Requirement struct {
+	Description string
+	// Command succeeds when the host meets the requirement.
+	Command string
+	// Hint tells how to meet the requirement.
+	Hint string
+}
+
+// NodeRequirements are the requirements of all the nodes.
+func NodeRequirements() []Requirement {
+	return []Requirement{
+		{
+			Description: "kubelet is installed at /usr/local/bin/kubelet",
+			Command:     "test -x /usr/local/bin/kubelet && systemctl cat kubelet | grep -q /usr/local/bin/kubelet",
+			Hint:        "KubeKey upgrades the binaries in /usr/local/bin, move kubelet there and update the kubelet service",
+		},
+		{
+			Description: "kubelet is configured by kubeadm",
+			Command:     filesExist("/var/lib/kubelet/config.yaml", "/etc/kubernetes/kubelet.conf"),
+			Hint:        "join the node with kubeadm",
+		},
+	}
+}
+
+// ControlPlaneRequirements are the requirements of the control-plane nodes, the etcd certs are checked by the type
+// of etcd.
+func ControlPlaneRequirements(etcdType string) []Requirement {
+	requirements := []Requirement{
+		{
+			Description: "kubeadm is installed at /usr/local/bin/kubeadm",
+			Command:     "test -x /usr/local/bin/kubeadm",
+			Hint:        "KubeKey runs the upgrades with /usr/local/bin/kubeadm, install the kubeadm of the cluster version there",
+		},
+		{
+			Description: "the control plane runs as static pods",
+			Command: filesExist(
+				"/etc/kubernetes/manifests/kube-apiserver.yaml",
+				"/etc/kubernetes/manifests/kube-controller-manager.yaml",
+				"/etc/kubernetes/manifests/kube-scheduler.yaml",
+			),
+			Hint: "KubeKey only manages the control planes deployed by kubeadm in /etc/kubernetes/manifests",
+		},
+		{
+			Description: "the certs are in /etc/kubernetes/pki",
+			Command: filesExist(
+				"/etc/kubernetes/pki/ca.crt",
+				"/etc/kubernetes/pki/ca.key",
+				"/etc/kubernetes/pki/apiserver.crt",
+				"/etc/kubernetes/pki/apiserver-kubelet-client.crt",
+				"/etc/kubernetes/pki/front-proxy-ca.crt",
+				"/etc/kubernetes/pki/front-proxy-client.crt",
+				"/etc/kubernetes/pki/sa.key",
+				"/etc/kubernetes/pki/sa.pub",
+				"/etc/kubernetes/admin.conf",
+			),
+			Hint: "KubeKey renews the certs with kubeadm, which requires the kubeadm layout of /etc/kubernetes/pki, " +
+				"the control planes with an external CA can't be adopted",
+		},
+	}
+
+	switch etcdType {
+	case kubekeyapiv1alpha2.Kubeadm:
+		requirements = append(requirements, Requirement{
+			Description: "etcd runs as a static pod",
+			Command:     filesExist("/etc/kubernetes/manifests/etcd.yaml", "/etc/kubernetes/pki/etcd/ca.crt"),
+			Hint:        "set etcd.type to the type of the etcd of the cluster",
+		})
+	case kubekeyapiv1alpha2.External:
+	default:
+		requirements = append(requirements, Requirement{
+			Description: "the etcd certs are in /etc/ssl/etcd/ssl",
+			Command:     filesExist("/etc/ssl/etcd/ssl/ca.pem"),
+			Hint:        "set etcd.type to kubeadm for the stacked etcd of kubeadm, or to external for another etcd",
+		})
+	}
+	return requirements
+}
+
+// ClusterRequirements are the requirements of the cluster, they are checked on the first control-plane node.
+func ClusterRequirements() []Requirement {
+	return []Requirement{
+		{
+			Description: "the cluster is managed by kubeadm",
+			Command:     "/usr/local/bin/kubectl -n kube-system get configmap kubeadm-config",
+			Hint:        "KubeKey only adopts the clusters created by kubeadm, which have the kubeadm-config ConfigMap",
+		},
+	}
+}
+
+func filesExist(paths ...string) string {
+	tests := make([]string, 0, len(paths))
+	for _, path := range paths {
+		tests = append(tests, "test -f "+path)
+	}
+	return strings.Join(tests, " && ")
+}
+
+// UnmetError is the error of the requirements, which a host doesn't meet.
+func UnmetError(host string, unmet []Requirement) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "the host %s doesn't meet the requirements of KubeKey:", host)
+	for _, r := range unmet {
+		fmt.Fprintf(&b, "\n  - %s: %s", r.Description, r.Hint)
+	}
+	return errors.New(b.String())
+}
+
+// RecordAdoptionCommand returns the command recording the adoption of the cluster by the KubeKey version at now.
+func RecordAdoptionCommand(kkVersion string, now time.Time) string {
+	return fmt.Sprintf("/usr/local/bin/kubectl -n kube-system create configmap %s "+
+		"--from-literal=adoptedAt=%s --from-literal=kubekeyVersion=%s --dry-run=client -o yaml | "+
+		"/usr/local/bin/kubectl apply -f -",
+		ConfigMapName, now.UTC().Format(time.RFC3339), kkVersion)
+}
@@ -0,0 +1,78 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package adoption
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+func TestControlPlaneRequirements(t *testing.T) {
+	tests := []struct {
+		etcdType string
+		want     string
+	}{
+		{etcdType: "", want: "/etc/ssl/etcd/ssl/ca.pem"},
+		{etcdType: kubekeyapiv1alpha2.KubeKey, want: "/etc/ssl/etcd/ssl/ca.pem"},
+		{etcdType: kubekeyapiv1alpha2.Kubeadm, want: "/etc/kubernetes/pki/etcd/ca.crt"},
+		{etcdType: kubekeyapiv1alpha2.External},
+	}
+	for _, tt := range tests {
+		t.Run(tt.etcdType, func(t *testing.T) {
+			var etcd []string
+			for _, r := range ControlPlaneRequirements(tt.etcdType) {
+				if strings.Contains(r.Command, "etcd") {
+					etcd = append(etcd, r.Command)
+				}
+			}
+			if tt.want == "" {
+				if len(etcd) != 0 {
+					t.Errorf("ControlPlaneRequirements() checks etcd %v, want none", etcd)
+				}
+				return
+			}
+			if len(etcd) != 1 || !strings.Contains(etcd[0], tt.want) {
+				t.Errorf("ControlPlaneRequirements() checks etcd %v, want %s", etcd, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmetError(t *testing.T) {
+	err := UnmetError("node1", []Requirement{{Description: "kubeadm is installed", Hint: "install kubeadm"}})
+	want := "the host node1 doesn't meet the requirements of KubeKey:\n  - kubeadm is installed: install kubeadm"
+	if err.Error() != want {
+		t.Errorf("UnmetError() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestRecordAdoptionCommand(t *testing.T) {
+	cmd := RecordAdoptionCommand("v3.0.13", time.Date(2023, 5, 1, 8, 0, 0, 0, time.UTC))
+	for _, want := range []string{
+		"create configmap kubekey-adoption",
+		"--from-literal=adoptedAt=2023-05-01T08:00:00Z",
+		"--from-literal=kubekeyVersion=v3.0.13",
+		"| /usr/local/bin/kubectl apply -f -",
+	} {
+		if !strings.Contains(cmd, want) {
+			t.Errorf("RecordAdoptionCommand() = %s, want %s", cmd, want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package adoption
+
+import (
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+)
+
+// AdoptModule validates the requirements of KubeKey on the nodes, and records the adoption of the cluster.
+type AdoptModule struct {
+	common.KubeModule
+}
+
+func (a *AdoptModule) Init() {
+	a.Name = "AdoptModule"
+	a.Desc = "Adopt the cluster"
+
+	check := &task.RemoteTask{
+		Name:     "CheckRequirements",
+		Desc:     "Check the requirements of KubeKey on the nodes",
+		Hosts:    a.Runtime.GetHostsByRole(common.K8s),
+		Action:   new(CheckRequirements),
+		Parallel: true,
+	}
+
+	record := &task.RemoteTask{
+		Name:    "RecordAdoption",
+		Desc:    "Record the adoption of the cluster",
+		Hosts:   a.Runtime.GetHostsByRole(common.Master),
+		Prepare: new(common.OnlyFirstMaster),
+		Action:  new(RecordAdoption),
+		Retry:   3,
+	}
+
+	a.Tasks = []task.Interface{
+		check,
+		record,
+	}
+}
+
+// GateModule stops the pipeline when the cluster was neither created nor adopted by KubeKey.
+type GateModule struct {
+	common.KubeModule
+	Skip bool
+}
+
+func (g *GateModule) IsSkip() bool {
+	return g.Skip
+}
+
+func (g *GateModule) Init() {
+	g.Name = "AdoptionGateModule"
+	g.Desc = "Check the cluster is managed by KubeKey"
+
+	gate := &task.RemoteTask{
+		Name:    "CheckAdoption",
+		Desc:    "Check the cluster is created or adopted by KubeKey",
+		Hosts:   g.Runtime.GetHostsByRole(common.Master),
+		Prepare: new(common.OnlyFirstMaster),
+		Action:  new(CheckAdoption),
+	}
+
+	g.Tasks = []task.Interface{
+		gate,
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package adoption
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/version"
+)
+
+type CheckRequirements struct {
+	common.KubeAction
+}
+
+func (c *CheckRequirements) Execute(runtime connector.Runtime) error {
+	host := runtime.RemoteHost()
+	requirements := NodeRequirements()
+	if host.IsRole(common.Master) {
+		requirements = append(requirements, ControlPlaneRequirements(c.KubeConf.Cluster.Etcd.Type)...)
+		if host.GetName() == runtime.GetHostsByRole(common.Master)[0].GetName() {
+			requirements = append(requirements, ClusterRequirements()...)
+		}
+	}
+
+	var unmet []Requirement
+	for _, r := range requirements {
+		if _, err := runtime.GetRunner().SudoCmd(r.Command, false); err != nil {
+			logger.Log.Debugf("%s: %s: %v", host.GetName(), r.Description, err)
+			unmet = append(unmet, r)
+		}
+	}
+	if len(unmet) > 0 {
+		return UnmetError(host.GetName(), unmet)
+	}
+	return nil
+}
+
+type RecordAdoption struct {
+	common.KubeAction
+}
+
+func (r *RecordAdoption) Execute(runtime connector.Runtime) error {
+	if _, err := runtime.GetRunner().SudoCmd(RecordAdoptionCommand(version.Get().GitVersion, time.Now()), false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "record the adoption of the cluster failed")
+	}
+	return nil
+}
+
+type CheckAdoption struct {
+	common.KubeAction
+}
+
+func (c *CheckAdoption) Execute(runtime connector.Runtime) error {
+	// the commands of a dry run always succeed, the result of the check is unknown
+	if connector.IsDryRun(runtime.GetConnector()) {
+		return nil
+	}
+	if ok, err := runtime.GetRunner().FileExist(KubeadmConfigPath); err == nil && ok {
+		return nil
+	}
+	if _, err := runtime.GetRunner().SudoCmd(RecordCommand, false); err == nil {
+		return nil
+	}
+	return errors.Errorf("the cluster wasn't created by KubeKey, %s isn't found on %s, and the cluster isn't adopted. "+
+		"Run 'kk adopt cluster -f <config>' to validate the cluster before upgrading it or deleting its nodes",
+		KubeadmConfigPath, runtime.RemoteHost().GetName())
+}
@@ -0,0 +1,145 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package agent
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serve serves an agent of the host node1 at 127.0.0.1 with the certs of the dir.
+func serve(t *testing.T, dir string) string {
+	if err := WriteCerts(dir, []CertHost{{Name: "node1", Addresses: []string{"127.0.0.1"}}}); err != nil {
+		t.Fatal(err)
+	}
+	config, err := ServerTLSConfig(filepath.Join(dir, "node1.crt"), filepath.Join(dir, "node1.key"), filepath.Join(dir, CACertFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer(config)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func dial(t *testing.T, dir, address string, compress bool, timeout time.Duration) (*Client, error) {
+	config, err := ClientTLSConfig(dir, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return Dial(ctx, address, config, compress)
+}
+
+func TestAgent(t *testing.T) {
+	dir := t.TempDir()
+	address := serve(t, dir)
+	client, err := dial(t, dir, address, true, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	var stdout, stderr bytes.Buffer
+	code, err := client.Exec(ctx, "/bin/sh", "cat; echo err >&2; exit 3", strings.NewReader("in\n"), &stdout, &stderr)
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || code != 3 {
+		t.Errorf("Exec() = %d, %v, want the exit code 3", code, err)
+	}
+	if stdout.String() != "in\n" || stderr.String() != "err\n" {
+		t.Errorf("the outputs are %q and %q", stdout.String(), stderr.String())
+	}
+	stdout.Reset()
+	if code, err := client.Exec(ctx, "/bin/sh", "seq 1 100000 | tail -n 1", nil, &stdout, nil); err != nil || code != 0 || stdout.String() != "100000\n" {
+		t.Errorf("Exec() = %d, %v, %q", code, err, stdout.String())
+	}
+
+	content := bytes.Repeat([]byte("kubekey\n"), 100000)
+	remote := filepath.Join(t.TempDir(), "dir", "file")
+	sum, err := client.Upload(ctx, remote, 0600, bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := sha256.Sum256(content); sum != hex.EncodeToString(want[:]) {
+		t.Errorf("Upload() = %s, want the sha256 of the content", sum)
+	}
+	if info, err := os.Stat(remote); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("the uploaded file is %v, %v, want the mode 0600", info, err)
+	}
+	var downloaded bytes.Buffer
+	if err := client.Download(ctx, remote, &downloaded); err != nil || !bytes.Equal(downloaded.Bytes(), content) {
+		t.Errorf("Download() = %d bytes, %v, want the uploaded content", downloaded.Len(), err)
+	}
+	if err := client.Download(ctx, remote+".missing", &downloaded); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Download() = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestAgentUntrustedClient(t *testing.T) {
+	address := serve(t, t.TempDir())
+	// the certs of another CA
+	other := t.TempDir()
+	if err := WriteCerts(other, nil); err != nil {
+		t.Fatal(err)
+	}
+	client, err := dial(t, other, address, false, time.Second)
+	if err == nil {
+		_, err = client.Exec(context.Background(), "", "true", nil, nil, nil)
+		client.Close()
+	}
+	if err == nil {
+		t.Error("the client of another CA is trusted")
+	}
+}
+
+func TestWriteCertsKeepsCA(t *testing.T) {
+	dir := t.TempDir()
+	if err := WriteCerts(dir, []CertHost{{Name: "node1"}}); err != nil {
+		t.Fatal(err)
+	}
+	ca, err := os.ReadFile(filepath.Join(dir, CACertFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteCerts(dir, []CertHost{{Name: "node1"}, {Name: "node2"}}); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := os.ReadFile(filepath.Join(dir, CACertFile)); !bytes.Equal(ca, again) {
+		t.Error("the CA is issued again")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "node2.key")); err != nil {
+		t.Error(err)
+	}
+	if err := WriteCerts(dir, []CertHost{{Name: "client"}}); err == nil {
+		t.Error("the reserved name is issued a cert")
+	}
+}
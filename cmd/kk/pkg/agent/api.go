@@ -0,0 +1,131 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package agent implements the kk agent, a lightweight gRPC service on the hosts which runs the commands and transfers
+// the files for the agent connector instead of ssh. The agent and kk authenticate each other by mutual TLS with the
+// certs of a CA of the cluster, see WriteCerts.
+//
+// The messages are encoded by gob instead of protobuf, so the service is declared by hand without generated code.
+package agent
+
+import (
+	"bytes"
+	"encoding/gob"
+
+	"google.golang.org/grpc"
+)
+
+const (
+	// DefaultPort is the port the agent listens on.
+	DefaultPort = 9443
+	// ServiceName is the full name of the gRPC service of the agent.
+	ServiceName = "kubekey.agent.v1.Agent"
+
+	// chunkSize is the size of the chunks of the outputs, the stdin and the files in the messages.
+	chunkSize = 256 << 10
+)
+
+// ExecRequest starts a command, which is the first message of an Exec stream. The next messages carry the stdin of
+// the command in Data if Stdin is set.
+type ExecRequest struct {
+	Command string
+	// Shell runs the command by -c. [Default: /bin/bash]
+	Shell string
+	// Stdin tells the command reads the stdin, which is closed when the client closes its stream.
+	Stdin bool
+	Data  []byte
+}
+
+// ExecResponse carries the outputs of the command, and its exit code in the last message.
+type ExecResponse struct {
+	Stdout []byte
+	Stderr []byte
+	Exited bool
+	Code   int
+	// Error is why the command couldn't run, the Code is -1 then.
+	Error string
+}
+
+// FileChunk is a chunk of a file. The first chunk of an Upload stream carries the Path and the Mode of the file.
+type FileChunk struct {
+	Path string
+	Mode uint32
+	Data []byte
+}
+
+// UploadResponse is the sha256 of the file written by an Upload.
+type UploadResponse struct {
+	SHA256 string
+}
+
+// DownloadRequest requests the chunks of the file of the Path.
+type DownloadRequest struct {
+	Path string
+}
+
+// Codec encodes the messages of the agent by gob.
+type Codec struct{}
+
+func (Codec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (Codec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func (Codec) Name() string {
+	return "gob"
+}
+
+// service is the handlers of the streams of the agent.
+type service interface {
+	exec(stream grpc.ServerStream) error
+	upload(stream grpc.ServerStream) error
+	download(stream grpc.ServerStream) error
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*service)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Exec",
+			Handler:       func(srv interface{}, stream grpc.ServerStream) error { return srv.(service).exec(stream) },
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Upload",
+			Handler:       func(srv interface{}, stream grpc.ServerStream) error { return srv.(service).upload(stream) },
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Download",
+			Handler:       func(srv interface{}, stream grpc.ServerStream) error { return srv.(service).download(stream) },
+			ServerStreams: true,
+		},
+	},
+}
+
+// method returns the full method name of the stream.
+func method(desc *grpc.StreamDesc) string {
+	return "/" + ServiceName + "/" + desc.StreamName
+}
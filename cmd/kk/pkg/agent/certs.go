@@ -0,0 +1,163 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package agent
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// The files of the certs dir of kk, which are written by WriteCerts. The agent of each host has the CACertFile, and
+// the cert and the key of the host, <name>.crt and <name>.key.
+const (
+	CACertFile     = "ca.crt"
+	CAKeyFile      = "ca.key"
+	ClientCertFile = "client.crt"
+	ClientKeyFile  = "client.key"
+)
+
+// CertValidity is the validity of the certs of the agents.
+const CertValidity = 10 * 365 * 24 * time.Hour
+
+// CertHost is a host whose agent is issued a cert, for its name and its addresses.
+type CertHost struct {
+	Name      string
+	Addresses []string
+}
+
+// WriteCerts writes the certs of the agents of the hosts and of kk to the dir. The CA and the client cert of kk in the
+// dir are kept, so the agents deployed already are still trusted, while the certs of the hosts are issued again for
+// their current addresses.
+func WriteCerts(dir string, hosts []CertHost) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	ca, caKey, err := loadCA(dir)
+	if os.IsNotExist(errors.Cause(err)) {
+		ca, caKey, err = newCert(dir, "ca", &x509.Certificate{
+			Subject:               pkix.Name{CommonName: "kubekey-agent-ca"},
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}, nil, nil)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(dir, ClientCertFile)); os.IsNotExist(err) {
+		if _, _, err := newCert(dir, "client", &x509.Certificate{
+			Subject:     pkix.Name{CommonName: "kubekey"},
+			KeyUsage:    x509.KeyUsageDigitalSignature,
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}, ca, caKey); err != nil {
+			return err
+		}
+	}
+	for _, host := range hosts {
+		if host.Name == "ca" || host.Name == "client" {
+			return errors.Errorf("the host %s can't have an agent cert, its name is reserved", host.Name)
+		}
+		tmpl := &x509.Certificate{
+			Subject:     pkix.Name{CommonName: host.Name},
+			KeyUsage:    x509.KeyUsageDigitalSignature,
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}
+		for _, address := range append([]string{host.Name}, host.Addresses...) {
+			if ip := net.ParseIP(address); ip != nil {
+				tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+			} else if address != "" {
+				tmpl.DNSNames = append(tmpl.DNSNames, address)
+			}
+		}
+		if _, _, err := newCert(dir, host.Name, tmpl, ca, caKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadCA(dir string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certPEM, err := os.ReadFile(filepath.Join(dir, CACertFile))
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	keyPEM, err := os.ReadFile(filepath.Join(dir, CAKeyFile))
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	certBlock, _ := pem.Decode(certPEM)
+	keyBlock, _ := pem.Decode(keyPEM)
+	if certBlock == nil || keyBlock == nil {
+		return nil, nil, errors.Errorf("invalid CA of the agents in %s", dir)
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "parse the CA of the agents failed")
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "parse the key of the CA of the agents failed")
+	}
+	return cert, key, nil
+}
+
+// newCert issues the cert of the template by the CA, or self-signs it if the CA is nil, and writes it and its key to
+// <name>.crt and <name>.key in the dir.
+func newCert(dir, name string, tmpl *x509.Certificate, ca *x509.Certificate, caKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt64))
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl.SerialNumber = serial
+	tmpl.NotBefore = time.Now().Add(-time.Hour).UTC()
+	tmpl.NotAfter = tmpl.NotBefore.Add(CertValidity)
+	if ca == nil {
+		ca, caKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, key.Public(), caKey)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "issue the cert %s failed", name)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return nil, nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	return cert, key, err
+}
@@ -0,0 +1,234 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/status"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/fips"
+)
+
+// ExitError is the error of a command exited with a non-zero code.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("Process exited with status %d", e.Code)
+}
+
+// Client is the client of the agent on a host.
+type Client struct {
+	conn *grpc.ClientConn
+	// compress compresses the transfers by gzip.
+	compress bool
+}
+
+// ClientTLSConfig returns the TLS config of kk of the certs dir, see WriteCerts, which verifies the cert of the agent
+// for the serverName, the address of the host.
+func ClientTLSConfig(certsDir, serverName string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(filepath.Join(certsDir, ClientCertFile), filepath.Join(certsDir, ClientKeyFile))
+	if err != nil {
+		return nil, errors.Wrap(err, "load the client cert of the agent failed")
+	}
+	pool, err := loadCAPool(filepath.Join(certsDir, CACertFile))
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ServerName:   serverName,
+	}
+	fips.ConfigureTLS(config)
+	return config, nil
+}
+
+// Dial connects to the agent at the address, and fails if it can't connect before the ctx is done. The transfers are
+// compressed by gzip if compress is set.
+func Dial(ctx context.Context, address string, config *tls.Config, compress bool, opts ...grpc.DialOption) (*Client, error) {
+	opts = append([]grpc.DialOption{
+		grpc.WithTransportCredentials(credentials.NewTLS(config)),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(Codec{})),
+		grpc.WithBlock(),
+		grpc.WithReturnConnectionError(),
+	}, opts...)
+	conn, err := grpc.DialContext(ctx, address, opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "connect to the agent %s failed", address)
+	}
+	return &Client{conn: conn, compress: compress}, nil
+}
+
+// Close closes the connection, which cancels the running commands and transfers.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) transferOptions() []grpc.CallOption {
+	if c.compress {
+		return []grpc.CallOption{grpc.UseCompressor(gzip.Name)}
+	}
+	return nil
+}
+
+// Exec runs the command by the shell on the host, with the stdin if it isn't nil, and writes its outputs to the
+// stdout and the stderr. The error is an ExitError if the command exited with a non-zero code.
+func (c *Client) Exec(ctx context.Context, shell, command string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.conn.NewStream(ctx, &serviceDesc.Streams[0], method(&serviceDesc.Streams[0]))
+	if err != nil {
+		return -1, err
+	}
+	if err := stream.SendMsg(&ExecRequest{Command: command, Shell: shell, Stdin: stdin != nil}); err != nil {
+		return -1, err
+	}
+	if stdin == nil {
+		if err := stream.CloseSend(); err != nil {
+			return -1, err
+		}
+	} else {
+		go func() {
+			buf := make([]byte, chunkSize)
+			for {
+				n, err := stdin.Read(buf)
+				if n > 0 {
+					if stream.SendMsg(&ExecRequest{Data: buf[:n]}) != nil {
+						return
+					}
+				}
+				if err != nil {
+					_ = stream.CloseSend()
+					return
+				}
+			}
+		}()
+	}
+
+	for {
+		res := &ExecResponse{}
+		if err := stream.RecvMsg(res); err != nil {
+			if err == io.EOF {
+				err = errors.New("the agent closed the stream before the command exited")
+			}
+			return -1, err
+		}
+		if len(res.Stdout) > 0 && stdout != nil {
+			if _, err := stdout.Write(res.Stdout); err != nil {
+				return -1, err
+			}
+		}
+		if len(res.Stderr) > 0 && stderr != nil {
+			if _, err := stderr.Write(res.Stderr); err != nil {
+				return -1, err
+			}
+		}
+		if !res.Exited {
+			continue
+		}
+		switch {
+		case res.Error != "":
+			return res.Code, errors.New(res.Error)
+		case res.Code != 0:
+			return res.Code, &ExitError{Code: res.Code}
+		}
+		return 0, nil
+	}
+}
+
+// Upload writes the content of the reader to the file of the path with the mode on the host, and returns the sha256
+// of the written file. The parent dirs are created, and the file is replaced when it's complete.
+func (c *Client) Upload(ctx context.Context, path string, mode os.FileMode, r io.Reader) (string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.conn.NewStream(ctx, &serviceDesc.Streams[1], method(&serviceDesc.Streams[1]), c.transferOptions()...)
+	if err != nil {
+		return "", err
+	}
+	chunk := &FileChunk{Path: path, Mode: uint32(mode.Perm())}
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 || chunk.Path != "" {
+			chunk.Data = buf[:n]
+			if err := stream.SendMsg(chunk); err != nil {
+				// the error of the agent is received by RecvMsg
+				break
+			}
+			chunk = &FileChunk{}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		return "", err
+	}
+	res := &UploadResponse{}
+	if err := stream.RecvMsg(res); err != nil {
+		return "", errors.Wrapf(err, "upload %s failed", path)
+	}
+	return res.SHA256, nil
+}
+
+// Download writes the content of the file of the path on the host to the writer. The error is os.ErrNotExist if the
+// file doesn't exist.
+func (c *Client) Download(ctx context.Context, path string, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.conn.NewStream(ctx, &serviceDesc.Streams[2], method(&serviceDesc.Streams[2]), c.transferOptions()...)
+	if err != nil {
+		return err
+	}
+	if err := stream.SendMsg(&DownloadRequest{Path: path}); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	for {
+		chunk := &FileChunk{}
+		err := stream.RecvMsg(chunk)
+		if err == io.EOF {
+			return nil
+		}
+		if status.Code(err) == codes.NotFound {
+			return errors.Wrapf(os.ErrNotExist, "download %s", path)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "download %s failed", path)
+		}
+		if _, err := w.Write(chunk.Data); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,253 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package agent
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	_ "google.golang.org/grpc/encoding/gzip" // the compressor of the transfers
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/fips"
+)
+
+// Server is the agent on a host. It runs the commands as its user, so it runs as root to run the privileged ones.
+type Server struct {
+	server *grpc.Server
+}
+
+// ServerTLSConfig returns the TLS config of the agent of the cert and the key, which requires the clients to present
+// a cert signed by the CA.
+func ServerTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "load the cert of the agent failed")
+	}
+	pool, err := loadCAPool(caFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	fips.ConfigureTLS(config)
+	return config, nil
+}
+
+func loadCAPool(caFile string) (*x509.CertPool, error) {
+	ca, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "read the CA of the agent failed")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.Errorf("no cert in the CA %s", caFile)
+	}
+	return pool, nil
+}
+
+// NewServer returns the agent serving over the TLS config.
+func NewServer(config *tls.Config) *Server {
+	s := &Server{
+		server: grpc.NewServer(grpc.Creds(credentials.NewTLS(config)), grpc.ForceServerCodec(Codec{})),
+	}
+	s.server.RegisterService(&serviceDesc, s)
+	return s
+}
+
+// Serve serves the agent on the listener until it's stopped.
+func (s *Server) Serve(lis net.Listener) error {
+	return s.server.Serve(lis)
+}
+
+// Stop stops the agent after the running commands and transfers are done.
+func (s *Server) Stop() {
+	s.server.GracefulStop()
+}
+
+func (s *Server) exec(stream grpc.ServerStream) error {
+	req := &ExecRequest{}
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	shell := req.Shell
+	if shell == "" {
+		shell = "/bin/bash"
+	}
+	klog.V(2).Infof("exec %s", req.Command)
+
+	cmd := exec.CommandContext(stream.Context(), shell, "-c", req.Command)
+	out := &execWriter{stream: stream}
+	cmd.Stdout = out.writer(false)
+	cmd.Stderr = out.writer(true)
+	if req.Stdin {
+		stdin, w := io.Pipe()
+		defer stdin.Close()
+		cmd.Stdin = stdin
+		go func() {
+			for {
+				chunk := &ExecRequest{}
+				if err := stream.RecvMsg(chunk); err != nil {
+					if err == io.EOF {
+						err = nil
+					}
+					_ = w.CloseWithError(err)
+					return
+				}
+				if _, err := w.Write(chunk.Data); err != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	res := &ExecResponse{Exited: true}
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			res.Code = exitErr.ExitCode()
+		} else {
+			res.Code, res.Error = -1, err.Error()
+		}
+	}
+	return out.send(res)
+}
+
+// execWriter sends the outputs of a command, the stdout and the stderr are written concurrently.
+type execWriter struct {
+	mu     sync.Mutex
+	stream grpc.ServerStream
+}
+
+func (e *execWriter) send(res *ExecResponse) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.stream.SendMsg(res)
+}
+
+func (e *execWriter) writer(stderr bool) io.Writer {
+	return writerFunc(func(p []byte) (int, error) {
+		data := append([]byte(nil), p...)
+		res := &ExecResponse{Stdout: data}
+		if stderr {
+			res = &ExecResponse{Stderr: data}
+		}
+		if err := e.send(res); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	})
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+// upload writes the file to a temporary file next to it, which replaces the file when it's complete.
+func (s *Server) upload(stream grpc.ServerStream) error {
+	chunk := &FileChunk{}
+	if err := stream.RecvMsg(chunk); err != nil {
+		return err
+	}
+	path, mode := chunk.Path, os.FileMode(chunk.Mode).Perm()
+	if mode == 0 {
+		mode = 0644
+	}
+	klog.V(2).Infof("upload %s", path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".kk-")
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	w := io.MultiWriter(tmp, hash)
+	for {
+		if _, err := w.Write(chunk.Data); err != nil {
+			_ = tmp.Close()
+			return status.Error(codes.Internal, err.Error())
+		}
+		chunk = &FileChunk{}
+		if err := stream.RecvMsg(chunk); err == io.EOF {
+			break
+		} else if err != nil {
+			_ = tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return stream.SendMsg(&UploadResponse{SHA256: hex.EncodeToString(hash.Sum(nil))})
+}
+
+func (s *Server) download(stream grpc.ServerStream) error {
+	req := &DownloadRequest{}
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	klog.V(2).Infof("download %s", req.Path)
+	f, err := os.Open(req.Path)
+	if os.IsNotExist(err) {
+		return status.Error(codes.NotFound, err.Error())
+	} else if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer f.Close()
+
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			if err := stream.SendMsg(&FileChunk{Data: buf[:n]}); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+}
@@ -0,0 +1,109 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package artifact
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/registry"
+
+	kubekeyv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+// PullChart pulls the chart into the dir as a chart archive, with the dependencies missing in the charts dir of the
+// chart bundled, and returns the path of the archive.
+func PullChart(settings *cli.EnvSettings, c kubekeyv1alpha2.ManifestChart, dir string) (string, error) {
+	registryClient, err := registry.NewClient(registry.ClientOptCredentialsFile(settings.RegistryConfig))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create the registry client")
+	}
+
+	tmp, err := os.MkdirTemp("", "kubekey-chart-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	pull := action.NewPullWithOpts(action.WithConfig(&action.Configuration{RegistryClient: registryClient}))
+	pull.Settings = settings
+	pull.Version = c.Version
+	pull.DestDir = tmp
+	ref := c.Name
+	if registry.IsOCI(c.Repo) {
+		ref = strings.TrimSuffix(c.Repo, "/") + "/" + c.Name
+	} else {
+		pull.RepoURL = c.Repo
+	}
+	if _, err := pull.Run(ref); err != nil {
+		return "", errors.Wrapf(err, "failed to pull the chart %s", c.Name)
+	}
+
+	archives, err := filepath.Glob(filepath.Join(tmp, "*.tgz"))
+	if err != nil || len(archives) != 1 {
+		return "", errors.Errorf("failed to find the archive of the chart %s", c.Name)
+	}
+	return BundleDependencies(settings, registryClient, archives[0], dir)
+}
+
+// BundleDependencies saves the chart archive into the dir, after downloading the dependencies missing in the
+// charts dir of the chart, and returns the path of the saved archive.
+func BundleDependencies(settings *cli.EnvSettings, registryClient *registry.Client, archive, dir string) (string, error) {
+	ch, err := loader.Load(archive)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to load the chart %s", archive)
+	}
+	if ch.Metadata.Dependencies == nil || action.CheckDependencies(ch, ch.Metadata.Dependencies) == nil {
+		return chartutil.Save(ch, dir)
+	}
+
+	tmp, err := os.MkdirTemp("", "kubekey-chart-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	if err := chartutil.ExpandFile(tmp, archive); err != nil {
+		return "", errors.Wrapf(err, "failed to expand the chart %s", archive)
+	}
+
+	chartPath := filepath.Join(tmp, ch.Name())
+	man := &downloader.Manager{
+		Out:              io.Discard,
+		ChartPath:        chartPath,
+		Getters:          getter.All(settings),
+		RegistryClient:   registryClient,
+		RepositoryConfig: settings.RepositoryConfig,
+		RepositoryCache:  settings.RepositoryCache,
+	}
+	if err := man.Build(); err != nil {
+		return "", errors.Wrapf(err, "failed to download the dependencies of the chart %s", ch.Name())
+	}
+	bundled, err := loader.Load(chartPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to load the chart %s", ch.Name())
+	}
+	return chartutil.Save(bundled, dir)
+}
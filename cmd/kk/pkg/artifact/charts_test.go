@@ -0,0 +1,69 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package artifact
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+)
+
+func TestBundleDependencies(t *testing.T) {
+	src := t.TempDir()
+	// the subchart is referenced by a relative path, it isn't in the charts dir of the archive
+	sub := &chart.Chart{Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "sub", Version: "0.1.0"}}
+	if err := chartutil.SaveDir(sub, src); err != nil {
+		t.Fatal(err)
+	}
+	parent := &chart.Chart{Metadata: &chart.Metadata{
+		APIVersion:   chart.APIVersionV2,
+		Name:         "parent",
+		Version:      "1.0.0",
+		Dependencies: []*chart.Dependency{{Name: "sub", Version: "0.1.0", Repository: "file://" + filepath.Join(src, "sub")}},
+	}}
+	archive, err := chartutil.Save(parent, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settings := cli.New()
+	settings.RepositoryConfig = filepath.Join(src, "repositories.yaml")
+	settings.RepositoryCache = filepath.Join(src, "cache")
+	dir := t.TempDir()
+	got, err := BundleDependencies(settings, nil, archive, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != filepath.Join(dir, "parent-1.0.0.tgz") {
+		t.Errorf("BundleDependencies() = %s", got)
+	}
+	ch, err := loader.Load(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ch.Dependencies()) != 1 || ch.Dependencies()[0].Name() != "sub" {
+		t.Errorf("the bundled chart has the dependencies %v, want sub", ch.Dependencies())
+	}
+	if _, err := os.Stat(archive); err != nil {
+		t.Errorf("the source archive is removed: %v", err)
+	}
+}
@@ -246,6 +246,8 @@ func CreateManifestSpecifyVersion(arg common.Argument, name, version string, reg
 		// storage
 		"provisioner-localpv",
 		"linux-utils",
+		"local-path-provisioner",
+		"busybox",
 		// load balancer
 		"haproxy",
 		"kubevip",
@@ -253,6 +255,8 @@ func CreateManifestSpecifyVersion(arg common.Argument, name, version string, reg
 		"kata-deploy",
 		// node-feature-discovery
 		"node-feature-discovery",
+		// nvidia-device-plugin
+		"k8s-device-plugin",
 	}
 	var imageArr []string
 	for _, v := range k8sVersion {
@@ -30,10 +30,11 @@ func (r *RepositoryModule) Init() {
 	r.Desc = "Get OS repository ISO file"
 
 	download := &task.LocalTask{
-		Name:    "DownloadISOFile",
-		Desc:    "Download iso file into work dir",
-		Prepare: new(EnableDownload),
-		Action:  new(DownloadISOFile),
+		Name:        "DownloadISOFile",
+		Desc:        "Download iso file into work dir",
+		Prepare:     new(EnableDownload),
+		Action:      new(DownloadISOFile),
+		RunInDryRun: true,
 	}
 
 	localCopy := &task.LocalTask{
@@ -48,6 +49,53 @@ func (r *RepositoryModule) Init() {
 	}
 }
 
+type PackageRepositoryModule struct {
+	common.ArtifactModule
+	// Dir is the root of the built repositories, it defaults to the repository dir in the artifact.
+	Dir string
+}
+
+func (p *PackageRepositoryModule) Init() {
+	p.Name = "PackageRepositoryModule"
+	p.Desc = "Build the offline OS package repositories"
+
+	build := &task.LocalTask{
+		Name:    "BuildPackageRepository",
+		Desc:    "Download the OS packages and their dependencies into a local repository",
+		Prepare: new(EnablePackageRepository),
+		Action:  &BuildPackageRepository{Dir: p.Dir},
+	}
+
+	p.Tasks = []task.Interface{
+		build,
+	}
+}
+
+type ChartsModule struct {
+	common.ArtifactModule
+	Skip bool
+}
+
+func (c *ChartsModule) IsSkip() bool {
+	return c.Skip
+}
+
+func (c *ChartsModule) Init() {
+	c.Name = "ChartsModule"
+	c.Desc = "Bundle the helm charts"
+
+	download := &task.LocalTask{
+		Name:        "DownloadCharts",
+		Desc:        "Download the helm charts and their dependencies into artifact dir",
+		Action:      new(DownloadCharts),
+		RunInDryRun: true,
+	}
+
+	c.Tasks = []task.Interface{
+		download,
+	}
+}
+
 type ArchiveModule struct {
 	common.ArtifactModule
 }
@@ -81,9 +129,10 @@ func (u *UnArchiveModule) Init() {
 	u.Desc = "UnArchive the KubeKey artifact"
 
 	md5Check := &task.LocalTask{
-		Name:   "CheckArtifactMd5",
-		Desc:   "Check the KubeKey artifact md5 value",
-		Action: new(Md5Check),
+		Name:        "CheckArtifactMd5",
+		Desc:        "Check the KubeKey artifact md5 value",
+		Action:      new(Md5Check),
+		RunInDryRun: true,
 	}
 
 	unArchive := &task.LocalTask{
@@ -0,0 +1,178 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package artifact
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	kubekeyv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/os/repository"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	coreutil "github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+const (
+	debFamily = "deb"
+	rpmFamily = "rpm"
+)
+
+// builderImages are the official images the package repositories are built in by default.
+var builderImages = map[string]string{
+	"ubuntu":    "ubuntu",
+	"debian":    "debian",
+	"centos":    "centos",
+	"rocky":     "rockylinux",
+	"almalinux": "almalinux",
+	"ol":        "oraclelinux",
+	"fedora":    "fedora",
+}
+
+// packageFamily returns the package format used by the operating system.
+func packageFamily(id string) (string, error) {
+	switch strings.ToLower(id) {
+	case "ubuntu", "debian":
+		return debFamily, nil
+	case "centos", "rhel", "rocky", "almalinux", "ol", "fedora":
+		return rpmFamily, nil
+	default:
+		return "", errors.Errorf("building the package repository of %s is not supported", id)
+	}
+}
+
+// builderImage returns the image the package repository of sys is built in.
+func builderImage(sys kubekeyv1alpha2.OperatingSystem) (string, error) {
+	if sys.Repository.Packages.Image != "" {
+		return sys.Repository.Packages.Image, nil
+	}
+	name, ok := builderImages[strings.ToLower(sys.Id)]
+	if !ok {
+		return "", errors.Errorf("no default image to build the package repository of %s, set repository.packages.image", sys.Id)
+	}
+	return fmt.Sprintf("%s:%s", name, sys.Version), nil
+}
+
+// buildScript returns the shell script which downloads the packages with all
+// their dependencies into /repo and generates the repository metadata.
+func buildScript(family string, extra []string) string {
+	var pkgs []string
+	if family == debFamily {
+		pkgs = append(pkgs, repository.DebianPackages...)
+	} else {
+		pkgs = append(pkgs, repository.RPMPackages...)
+	}
+	pkgs = append(pkgs, extra...)
+	list := strings.Join(pkgs, " ")
+
+	if family == debFamily {
+		// apt-get only downloads the missing dependencies, so resolve the whole tree with apt-cache
+		return fmt.Sprintf(`set -e
+export DEBIAN_FRONTEND=noninteractive
+apt-get update
+apt-get install -y --no-install-recommends dpkg-dev
+cd /repo
+apt-get download $(apt-cache depends --recurse --no-recommends --no-suggests --no-conflicts --no-breaks --no-replaces --no-enhances %s | grep '^\w' | sort -u)
+dpkg-scanpackages . /dev/null > Packages
+gzip -9c Packages > Packages.gz
+`, list)
+	}
+	return fmt.Sprintf(`set -e
+if command -v dnf >/dev/null 2>&1; then
+  dnf install -y dnf-plugins-core createrepo_c
+  dnf download --resolve --alldeps --destdir /repo %[1]s
+else
+  yum install -y yum-utils createrepo
+  repotrack -p /repo %[1]s
+fi
+if command -v createrepo_c >/dev/null 2>&1; then
+  createrepo_c /repo
+else
+  createrepo /repo
+fi
+`, list)
+}
+
+// containerEngine returns the container engine the package repositories are built with.
+func containerEngine() (string, error) {
+	for _, engine := range []string{"docker", "podman"} {
+		if path, err := exec.LookPath(engine); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.New("building the package repository requires docker or podman")
+}
+
+// BuildPackageRepository builds an offline package repository for every operating
+// system in the manifest which enables repository.packages.build.
+type BuildPackageRepository struct {
+	common.ArtifactAction
+	// Dir is the root of the repositories, it defaults to the repository dir in the artifact.
+	Dir string
+}
+
+func (b *BuildPackageRepository) Execute(runtime connector.Runtime) error {
+	root := b.Dir
+	if root == "" {
+		root = filepath.Join(runtime.GetWorkDir(), common.Artifact, "repository")
+	}
+
+	for _, sys := range b.Manifest.Spec.OperatingSystems {
+		if !sys.Repository.Packages.Build {
+			continue
+		}
+
+		family, err := packageFamily(sys.Id)
+		if err != nil {
+			return err
+		}
+		image, err := builderImage(sys)
+		if err != nil {
+			return err
+		}
+		engine, err := containerEngine()
+		if err != nil {
+			return err
+		}
+
+		dir := filepath.Join(root, sys.Arch, sys.Id, sys.Version, common.Packages)
+		if err := os.RemoveAll(dir); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "remove %s failed", dir)
+		}
+		if err := coreutil.Mkdir(dir); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "mkdir %s failed", dir)
+		}
+
+		logger.Log.Messagef(common.LocalHost, "building the %s package repository of %s %s %s in %s", family, sys.Id, sys.Version, sys.Arch, image)
+		cmd := exec.Command(engine, "run", "--rm",
+			"--platform", "linux/"+sys.Arch,
+			"-v", dir+":/repo",
+			image, "/bin/sh", "-c", buildScript(family, sys.Repository.Packages.Extra))
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return errors.Wrapf(err, "build the package repository of %s %s %s failed", sys.Id, sys.Version, sys.Arch)
+		}
+	}
+	return nil
+}
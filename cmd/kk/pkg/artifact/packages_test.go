@@ -0,0 +1,113 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package artifact
+
+import (
+	"strings"
+	"testing"
+
+	kubekeyv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+func TestBuilderImage(t *testing.T) {
+	tests := []struct {
+		name    string
+		sys     kubekeyv1alpha2.OperatingSystem
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "ubuntu",
+			sys:  kubekeyv1alpha2.OperatingSystem{Id: "ubuntu", Version: "22.04"},
+			want: "ubuntu:22.04",
+		},
+		{
+			name: "rocky",
+			sys:  kubekeyv1alpha2.OperatingSystem{Id: "rocky", Version: "8.9"},
+			want: "rockylinux:8.9",
+		},
+		{
+			name: "image override",
+			sys: kubekeyv1alpha2.OperatingSystem{Id: "rhel", Version: "9",
+				Repository: kubekeyv1alpha2.Repository{Packages: kubekeyv1alpha2.Packages{Image: "registry.local/rhel:9"}}},
+			want: "registry.local/rhel:9",
+		},
+		{
+			name:    "no default image",
+			sys:     kubekeyv1alpha2.OperatingSystem{Id: "rhel", Version: "9"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := builderImage(tt.sys)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("builderImage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("builderImage() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildScript(t *testing.T) {
+	tests := []struct {
+		name   string
+		id     string
+		extra  []string
+		want   []string
+		absent []string
+	}{
+		{
+			name:   "deb",
+			id:     "debian",
+			extra:  []string{"nfs-common"},
+			want:   []string{"apt-cache depends --recurse", "socat conntrack ipset ebtables chrony ipvsadm nfs-common", "dpkg-scanpackages"},
+			absent: []string{"openssl", "createrepo"},
+		},
+		{
+			name:   "rpm",
+			id:     "centos",
+			want:   []string{"dnf download --resolve --alldeps", "repotrack", "openssl socat", "createrepo"},
+			absent: []string{"dpkg-scanpackages"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			family, err := packageFamily(tt.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			script := buildScript(family, tt.extra)
+			for _, s := range tt.want {
+				if !strings.Contains(script, s) {
+					t.Errorf("script doesn't contain %q:\n%s", s, script)
+				}
+			}
+			for _, s := range tt.absent {
+				if strings.Contains(script, s) {
+					t.Errorf("script contains %q:\n%s", s, script)
+				}
+			}
+		})
+	}
+
+	if _, err := packageFamily("alpine"); err == nil {
+		t.Error("packageFamily() of alpine should fail")
+	}
+}
@@ -52,3 +52,16 @@ func (m *Md5AreEqual) PreCheck(_ connector.Runtime) (bool, error) {
 	}
 	return m.Not, nil
 }
+
+type EnablePackageRepository struct {
+	common.ArtifactPrepare
+}
+
+func (e *EnablePackageRepository) PreCheck(_ connector.Runtime) (bool, error) {
+	for _, sys := range e.Manifest.Spec.OperatingSystems {
+		if sys.Repository.Packages.Build {
+			return true, nil
+		}
+	}
+	return false, nil
+}
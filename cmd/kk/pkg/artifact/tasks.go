@@ -25,11 +25,13 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/cli"
 
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
 	coreutil "github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/files"
 )
 
 type DownloadISOFile struct {
@@ -44,6 +46,13 @@ func (d *DownloadISOFile) Execute(runtime connector.Runtime) error {
 
 		fileName := fmt.Sprintf("%s-%s-%s.iso", sys.Id, sys.Version, sys.Arch)
 		filePath := filepath.Join(runtime.GetWorkDir(), fileName)
+		if d.Manifest.Arg.DownloadCommand == nil {
+			if err := files.DownloadFile(nil, filePath, sys.Repository.Iso.Url); err != nil {
+				return fmt.Errorf("Failed to download %s iso file: %w ", fileName, err)
+			}
+			d.Manifest.Spec.OperatingSystems[i].Repository.Iso.LocalPath = filePath
+			continue
+		}
 		getCmd := d.Manifest.Arg.DownloadCommand(filePath, sys.Repository.Iso.Url)
 
 		cmd := exec.Command("/bin/sh", "-c", getCmd)
@@ -99,13 +108,38 @@ func (l *LocalCopy) Execute(runtime connector.Runtime) error {
 	return nil
 }
 
+type DownloadCharts struct {
+	common.ArtifactAction
+}
+
+func (d *DownloadCharts) Execute(runtime connector.Runtime) error {
+	dir := filepath.Join(runtime.GetWorkDir(), common.Artifact, common.Charts)
+	if err := coreutil.Mkdir(dir); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "mkdir %s failed", dir)
+	}
+
+	settings := cli.New()
+	for _, c := range d.Manifest.Spec.Charts {
+		path, err := PullChart(settings, c, dir)
+		if err != nil {
+			return err
+		}
+		logger.Log.Messagef(common.LocalHost, "bundled the chart %s into %s", c.Name, path)
+	}
+	return nil
+}
+
 type ArchiveDependencies struct {
 	common.ArtifactAction
 }
 
 func (a *ArchiveDependencies) Execute(runtime connector.Runtime) error {
 	src := filepath.Join(runtime.GetWorkDir(), common.Artifact)
-	if err := coreutil.Tar(src, a.Manifest.Arg.Output, src); err != nil {
+	c, err := coreutil.ParseCompression(a.Manifest.Arg.Compression)
+	if err != nil {
+		return err
+	}
+	if err := coreutil.TarCompressed(src, a.Manifest.Arg.Output, src, c); err != nil {
 		return errors.Wrapf(errors.WithStack(err), "archive %s failed", src)
 	}
 
@@ -0,0 +1,248 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package backup backs up the control plane of a cluster: the snapshot of the etcd deployed by KubeKey, the etcd
+// certificates of the etcd nodes, and /etc/kubernetes of the control-plane nodes with the PKI, the static pod
+// manifests and the kubeconfigs. The backups are stored in a local dir or an S3-compatible bucket, and the control
+// plane is rebuilt from them by the restore.
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SnapshotFile is the snapshot of the etcd in the backup.
+	SnapshotFile = "etcd-snapshot.db"
+	// MetadataFile describes the backup.
+	MetadataFile = "backup.json"
+	// DefaultDir is the dir of the backups in the work dir of the cluster.
+	DefaultDir = "backups"
+
+	s3Scheme = "s3://"
+)
+
+// KubernetesArchive returns the archive of /etc/kubernetes of the control-plane node in the backup.
+func KubernetesArchive(node string) string {
+	return node + "-kubernetes.tar.gz"
+}
+
+// EtcdCertsArchive returns the archive of the etcd certificates of the etcd node in the backup.
+func EtcdCertsArchive(node string) string {
+	return node + "-etcd-ssl.tar.gz"
+}
+
+// Name returns the name of the backup of the cluster taken at the time.
+func Name(cluster string, t time.Time) string {
+	return fmt.Sprintf("%s-%s", cluster, t.UTC().Format("20060102150405"))
+}
+
+// Metadata describes the backup, it's written to the MetadataFile of the backup.
+type Metadata struct {
+	Cluster           string    `json:"cluster"`
+	KubernetesVersion string    `json:"kubernetesVersion"`
+	EtcdType          string    `json:"etcdType"`
+	Created           time.Time `json:"created"`
+	// Snapshot is whether the backup has the snapshot of the etcd, which isn't taken of the external etcd.
+	Snapshot bool `json:"snapshot"`
+	// ControlPlaneNodes are the nodes whose /etc/kubernetes is archived.
+	ControlPlaneNodes []string `json:"controlPlaneNodes"`
+	// EtcdNodes are the nodes whose etcd certificates are archived.
+	EtcdNodes []string `json:"etcdNodes,omitempty"`
+}
+
+// WriteMetadata writes the metadata to the dir of the backup.
+func WriteMetadata(dir string, m Metadata) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encode the metadata of the backup failed")
+	}
+	return os.WriteFile(filepath.Join(dir, MetadataFile), data, 0600)
+}
+
+// ReadMetadata reads the metadata of the backup in the dir.
+func ReadMetadata(dir string) (Metadata, error) {
+	var m Metadata
+	data, err := os.ReadFile(filepath.Join(dir, MetadataFile))
+	if err != nil {
+		return m, errors.Wrapf(err, "read the metadata of the backup in %s failed", dir)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, errors.Wrapf(err, "parse the metadata of the backup in %s failed", dir)
+	}
+	return m, nil
+}
+
+// Location is where the backups are stored, a local dir or the prefix of an S3-compatible bucket in the form of
+// s3://<bucket>/<prefix>. The Endpoint is the URL of the S3-compatible service, AWS S3 if it's empty. The credentials
+// and the region of the bucket are read from the envs and the shared config of the AWS CLI.
+type Location struct {
+	Dir      string
+	Bucket   string
+	Prefix   string
+	Endpoint string
+}
+
+// ParseLocation parses the location of a local dir or of an s3:// URL.
+func ParseLocation(location, endpoint string) (Location, error) {
+	if !strings.HasPrefix(location, s3Scheme) {
+		if location == "" {
+			return Location{}, errors.New("the location of the backups is required")
+		}
+		if endpoint != "" {
+			return Location{}, errors.New("the S3 endpoint is only used by an s3:// location")
+		}
+		return Location{Dir: location}, nil
+	}
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(location, s3Scheme), "/")
+	if bucket == "" {
+		return Location{}, errors.Errorf("the bucket of %s is required", location)
+	}
+	return Location{Bucket: bucket, Prefix: strings.Trim(prefix, "/"), Endpoint: endpoint}, nil
+}
+
+// IsS3 returns whether the location is an S3-compatible bucket.
+func (l Location) IsS3() bool {
+	return l.Bucket != ""
+}
+
+// Join returns the location of the backup of the name in the location.
+func (l Location) Join(name string) Location {
+	if l.IsS3() {
+		l.Prefix = strings.TrimPrefix(path.Join(l.Prefix, name), "/")
+		return l
+	}
+	l.Dir = filepath.Join(l.Dir, name)
+	return l
+}
+
+func (l Location) String() string {
+	if l.IsS3() {
+		return strings.TrimSuffix(s3Scheme+l.Bucket+"/"+l.Prefix, "/")
+	}
+	return l.Dir
+}
+
+func (l Location) session() (*session.Session, error) {
+	config := aws.Config{}
+	if l.Endpoint != "" {
+		// the S3-compatible services, e.g. MinIO, are addressed by the path of the bucket
+		config.Endpoint = aws.String(l.Endpoint)
+		config.S3ForcePathStyle = aws.Bool(true)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{Config: config, SharedConfigState: session.SharedConfigEnable})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the AWS session")
+	}
+	return sess, nil
+}
+
+// Upload uploads the files of the local dir to the location of the backup, it does nothing for a local dir.
+func (l Location) Upload(dir string) error {
+	if !l.IsS3() {
+		return nil
+	}
+	sess, err := l.session()
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return errors.Wrapf(err, "read the backup in %s failed", dir)
+	}
+	uploader := s3manager.NewUploader(sess)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if err := l.upload(uploader, filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l Location) upload(uploader *s3manager.Uploader, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	key := path.Join(l.Prefix, filepath.Base(file))
+	if _, err := uploader.Upload(&s3manager.UploadInput{Bucket: aws.String(l.Bucket), Key: aws.String(key), Body: f}); err != nil {
+		return errors.Wrapf(err, "upload %s to s3://%s/%s failed", file, l.Bucket, key)
+	}
+	return nil
+}
+
+// Download downloads the files of the backup to the local dir and returns it, the local dir of the backup is
+// returned as it is.
+func (l Location) Download(dir string) (string, error) {
+	if !l.IsS3() {
+		return l.Dir, nil
+	}
+	sess, err := l.session()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	client := s3.New(sess)
+	downloader := s3manager.NewDownloaderWithClient(client)
+	input := &s3.ListObjectsV2Input{Bucket: aws.String(l.Bucket), Prefix: aws.String(l.Prefix + "/")}
+	var downloadErr error
+	err = client.ListObjectsV2Pages(input, func(page *s3.ListObjectsV2Output, last bool) bool {
+		for _, object := range page.Contents {
+			name := path.Base(aws.StringValue(object.Key))
+			if downloadErr = l.download(downloader, aws.StringValue(object.Key), filepath.Join(dir, name)); downloadErr != nil {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "list the objects of %s failed", l)
+	}
+	if downloadErr != nil {
+		return "", downloadErr
+	}
+	return dir, nil
+}
+
+func (l Location) download(downloader *s3manager.Downloader, key, file string) error {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := downloader.Download(f, &s3.GetObjectInput{Bucket: aws.String(l.Bucket), Key: aws.String(key)}); err != nil {
+		return errors.Wrapf(err, "download s3://%s/%s failed", l.Bucket, key)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package backup
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		name     string
+		location string
+		endpoint string
+		want     Location
+		wantErr  string
+	}{
+		{
+			name:     "local dir",
+			location: "/var/backups",
+			want:     Location{Dir: "/var/backups"},
+		},
+		{
+			name:     "bucket",
+			location: "s3://backups",
+			want:     Location{Bucket: "backups"},
+		},
+		{
+			name:     "bucket with prefix",
+			location: "s3://backups/kubekey/",
+			endpoint: "https://minio.example.com:9000",
+			want:     Location{Bucket: "backups", Prefix: "kubekey", Endpoint: "https://minio.example.com:9000"},
+		},
+		{
+			name:    "empty",
+			wantErr: "the location of the backups is required",
+		},
+		{
+			name:     "no bucket",
+			location: "s3:///kubekey",
+			wantErr:  "the bucket of s3:///kubekey is required",
+		},
+		{
+			name:     "endpoint of a local dir",
+			location: "/var/backups",
+			endpoint: "https://minio.example.com:9000",
+			wantErr:  "the S3 endpoint is only used by an s3:// location",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLocation(tt.location, tt.endpoint)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("ParseLocation() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLocation() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLocation() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocationJoin(t *testing.T) {
+	name := Name("mycluster", time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC))
+	for location, want := range map[string]string{
+		"/var/backups":         "/var/backups/mycluster-20231001120000",
+		"s3://backups":         "s3://backups/mycluster-20231001120000",
+		"s3://backups/kubekey": "s3://backups/kubekey/mycluster-20231001120000",
+	} {
+		loc, err := ParseLocation(location, "")
+		if err != nil {
+			t.Fatalf("ParseLocation(%s) error = %v", location, err)
+		}
+		if got := loc.Join(name).String(); got != want {
+			t.Errorf("Join() of %s = %s, want %s", location, got, want)
+		}
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	dir := t.TempDir()
+	want := Metadata{
+		Cluster:           "mycluster",
+		KubernetesVersion: "v1.26.5",
+		EtcdType:          "kubekey",
+		Created:           time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC),
+		Snapshot:          true,
+		ControlPlaneNodes: []string{"node1", "node2", "node3"},
+		EtcdNodes:         []string{"node1", "node2", "node3"},
+	}
+	if err := WriteMetadata(dir, want); err != nil {
+		t.Fatalf("WriteMetadata() error = %v", err)
+	}
+	got, err := ReadMetadata(dir)
+	if err != nil {
+		t.Fatalf("ReadMetadata() error = %v", err)
+	}
+	if got.Cluster != want.Cluster || !got.Created.Equal(want.Created) || got.Snapshot != want.Snapshot ||
+		strings.Join(got.ControlPlaneNodes, ",") != strings.Join(want.ControlPlaneNodes, ",") {
+		t.Errorf("ReadMetadata() = %+v, want %+v", got, want)
+	}
+	if _, err := ReadMetadata(t.TempDir()); err == nil {
+		t.Error("ReadMetadata() of an empty dir error = nil, want an error")
+	}
+}
+
+func TestRestoreEtcdCmd(t *testing.T) {
+	env := parseEnv(`# Environment file for etcd v3.4.13
+ETCD_DATA_DIR=/var/lib/etcd
+ETCD_ADVERTISE_CLIENT_URLS=https://192.168.0.2:2379
+ETCD_INITIAL_ADVERTISE_PEER_URLS=https://192.168.0.2:2380
+ETCD_INITIAL_CLUSTER_STATE=existing
+ETCD_LISTEN_CLIENT_URLS=https://192.168.0.2:2379,https://127.0.0.1:2379
+ETCD_INITIAL_CLUSTER_TOKEN=k8s_etcd
+ETCD_NAME="etcd-node1"
+ETCD_INITIAL_CLUSTER=etcd-node1=https://192.168.0.2:2380,etcd-node2=https://192.168.0.3:2380
+`)
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	got, err := restoreEtcdCmd(env, "/tmp/kubekey/backup/etcd-snapshot.db", now)
+	if err != nil {
+		t.Fatalf("restoreEtcdCmd() error = %v", err)
+	}
+	want := "if [ -e /var/lib/etcd ]; then mv /var/lib/etcd /var/lib/etcd.20231001120000.bak; fi && " +
+		"ETCDCTL_API=3 /usr/local/bin/etcdctl snapshot restore /tmp/kubekey/backup/etcd-snapshot.db --name etcd-node1 " +
+		"--initial-cluster etcd-node1=https://192.168.0.2:2380,etcd-node2=https://192.168.0.3:2380 " +
+		"--initial-cluster-token k8s_etcd --initial-advertise-peer-urls https://192.168.0.2:2380 --data-dir /var/lib/etcd"
+	if got != want {
+		t.Errorf("restoreEtcdCmd() = %s, want %s", got, want)
+	}
+
+	delete(env, "ETCD_INITIAL_CLUSTER")
+	if _, err := restoreEtcdCmd(env, "/tmp/kubekey/backup/etcd-snapshot.db", now); err == nil ||
+		err.Error() != "ETCD_INITIAL_CLUSTER isn't set in /etc/etcd.env" {
+		t.Errorf("restoreEtcdCmd() without ETCD_INITIAL_CLUSTER error = %v", err)
+	}
+}
+
+func TestSnapshotHost(t *testing.T) {
+	masters := []connector.Host{&connector.BaseHost{Name: "master1"}, &connector.BaseHost{Name: "master2"}}
+	etcdNodes := []connector.Host{&connector.BaseHost{Name: "etcd1"}}
+	tests := []struct {
+		name      string
+		etcdType  string
+		masters   []connector.Host
+		etcdNodes []connector.Host
+		want      string
+		wantErr   bool
+	}{
+		{name: "kubekey", etcdType: kubekeyapiv1alpha2.KubeKey, masters: masters, etcdNodes: etcdNodes, want: "etcd1"},
+		{name: "kubekey without etcd nodes", etcdType: kubekeyapiv1alpha2.KubeKey, masters: masters, wantErr: true},
+		{name: "kubeadm", etcdType: kubekeyapiv1alpha2.Kubeadm, masters: masters, want: "master1"},
+		{name: "kubeadm without control-plane nodes", etcdType: kubekeyapiv1alpha2.Kubeadm, wantErr: true},
+		{name: "external", etcdType: kubekeyapiv1alpha2.External, masters: masters, etcdNodes: etcdNodes},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, err := snapshotHost(tt.etcdType, tt.masters, tt.etcdNodes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("snapshotHost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got string
+			if host != nil {
+				got = host.GetName()
+			}
+			if got != tt.want {
+				t.Errorf("snapshotHost() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKubeadmSnapshotCmd(t *testing.T) {
+	got := kubeadmSnapshotCmd("master1", "/tmp/kubekey/backup/etcd-snapshot.db")
+	want := "/usr/local/bin/kubectl -n kube-system exec etcd-master1 -- etcdctl --endpoints=https://127.0.0.1:2379 " +
+		"--cacert=/etc/kubernetes/pki/etcd/ca.crt --cert=/etc/kubernetes/pki/etcd/server.crt " +
+		"--key=/etc/kubernetes/pki/etcd/server.key snapshot save /var/lib/etcd/etcd-snapshot.db && " +
+		"mv -f /var/lib/etcd/etcd-snapshot.db /tmp/kubekey/backup/etcd-snapshot.db"
+	if got != want {
+		t.Errorf("kubeadmSnapshotCmd() = %s, want %s", got, want)
+	}
+}
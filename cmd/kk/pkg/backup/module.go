@@ -0,0 +1,194 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package backup
+
+import (
+	"time"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/health"
+)
+
+// BackupModule backs up the control plane of the cluster to the local Dir of the backup, which is uploaded to the
+// Location of the backup if it's a bucket. The etcd of KubeKey is snapshotted on the first etcd node, the stacked etcd
+// of kubeadm on the first control-plane node, the external etcd is backed up by its own tools.
+type BackupModule struct {
+	common.KubeModule
+	Dir      string
+	Location Location
+}
+
+func (b *BackupModule) Init() {
+	b.Name = "BackupModule"
+	b.Tags = []string{"backup"}
+	b.Desc = "Back up the control plane of the cluster"
+
+	cluster := b.KubeConf.Cluster
+	masters := b.Runtime.GetHostsByRole(common.Master)
+	metadata := Metadata{
+		Cluster:           b.KubeConf.ClusterName,
+		KubernetesVersion: cluster.Kubernetes.Version,
+		EtcdType:          cluster.Etcd.Type,
+		Created:           time.Now().UTC(),
+		ControlPlaneNodes: hostNames(masters),
+	}
+
+	check := &task.LocalTask{
+		Name:        "CheckHosts",
+		Desc:        "Check the cluster has the nodes to back up",
+		Action:      new(CheckHosts),
+		RunInDryRun: true,
+	}
+
+	archiveKubernetes := &task.RemoteTask{
+		Name:     "ArchiveKubernetes",
+		Desc:     "Archive the PKI, the static pod manifests and the kubeconfigs of the control-plane nodes",
+		Hosts:    masters,
+		Action:   &ArchiveDir{Dir: b.Dir, Src: kubernetesDir, Name: KubernetesArchive},
+		Parallel: true,
+	}
+	b.Tasks = []task.Interface{check, archiveKubernetes}
+
+	etcdNodes := b.Runtime.GetHostsByRole(common.ETCD)
+	// the missing host fails CheckHosts
+	if host, _ := snapshotHost(cluster.Etcd.Type, masters, etcdNodes); host != nil {
+		metadata.Snapshot = true
+		snapshot := &task.RemoteTask{
+			Name:   "SnapshotEtcd",
+			Desc:   "Take the snapshot of the etcd",
+			Hosts:  []connector.Host{host},
+			Action: &SnapshotEtcd{Dir: b.Dir},
+		}
+		b.Tasks = append(b.Tasks, snapshot)
+	}
+
+	switch cluster.Etcd.Type {
+	case kubekeyapiv1alpha2.KubeKey:
+		metadata.EtcdNodes = hostNames(etcdNodes)
+		archiveEtcdCerts := &task.RemoteTask{
+			Name:     "ArchiveEtcdCerts",
+			Desc:     "Archive the certificates of the etcd nodes",
+			Hosts:    etcdNodes,
+			Action:   &ArchiveDir{Dir: b.Dir, Src: etcdCertsDir, Name: EtcdCertsArchive},
+			Parallel: true,
+		}
+		b.Tasks = append(b.Tasks, archiveEtcdCerts)
+	case kubekeyapiv1alpha2.Kubeadm:
+		// the certificates of the stacked etcd are in /etc/kubernetes/pki/etcd, archived with /etc/kubernetes
+	default:
+		logger.Log.Warnf("the %s etcd isn't snapshotted by kk, back it up with its own tools", cluster.Etcd.Type)
+	}
+
+	save := &task.LocalTask{
+		Name:   "SaveBackup",
+		Desc:   "Save the backup",
+		Action: &SaveBackup{Dir: b.Dir, Location: b.Location, Metadata: metadata},
+	}
+	b.Tasks = append(b.Tasks, save)
+}
+
+// RestoreModule rebuilds the control plane of the cluster from the local Dir of the backup: the kubelet and the
+// control-plane containers of the control-plane nodes are stopped, their /etc/kubernetes is restored, the etcd of
+// KubeKey is restored from the snapshot on all the etcd nodes, and the kubelet is started again. The restored files
+// and dirs replace the ones on the nodes, which are kept as <path>.<timestamp>.bak.
+type RestoreModule struct {
+	common.KubeModule
+	Dir      string
+	Metadata Metadata
+	Timeout  time.Duration
+}
+
+func (r *RestoreModule) Init() {
+	r.Name = "RestoreModule"
+	r.Tags = []string{"restore"}
+	r.Desc = "Restore the control plane of the cluster"
+
+	masters := r.Runtime.GetHostsByRole(common.Master)
+	timeout := r.Timeout
+	if timeout == 0 {
+		timeout = health.DefaultTimeout
+	}
+
+	check := &task.LocalTask{
+		Name:        "CheckBackup",
+		Desc:        "Check the backup has the files of the control plane",
+		Action:      &CheckBackup{Dir: r.Dir},
+		RunInDryRun: true,
+	}
+
+	stop := &task.RemoteTask{
+		Name:     "StopControlPlane",
+		Desc:     "Stop the kubelet and the control-plane containers",
+		Hosts:    masters,
+		Action:   new(StopControlPlane),
+		Parallel: true,
+	}
+
+	restoreKubernetes := &task.RemoteTask{
+		Name:     "RestoreKubernetes",
+		Desc:     "Restore the PKI, the static pod manifests and the kubeconfigs of the control-plane nodes",
+		Hosts:    masters,
+		Action:   &RestoreKubernetes{Dir: r.Dir},
+		Parallel: true,
+	}
+	r.Tasks = []task.Interface{check, stop, restoreKubernetes}
+
+	if r.KubeConf.Cluster.Etcd.Type == kubekeyapiv1alpha2.Kubeadm && r.Metadata.Snapshot {
+		logger.Log.Warnf("the snapshot of the kubeadm etcd isn't restored by kk, restore %s with etcdutl, see docs/commands/kk-restore.md",
+			SnapshotFile)
+	}
+	if r.KubeConf.Cluster.Etcd.Type == kubekeyapiv1alpha2.KubeKey {
+		etcdNodes := r.Runtime.GetHostsByRole(common.ETCD)
+		restoreEtcd := &task.RemoteTask{
+			Name:     "RestoreEtcd",
+			Desc:     "Restore the etcd from the snapshot",
+			Hosts:    etcdNodes,
+			Action:   &RestoreEtcd{Dir: r.Dir, EtcdNodes: r.Metadata.EtcdNodes},
+			Parallel: true,
+		}
+
+		waitEtcd := &task.RemoteTask{
+			Name:     "WaitEtcdHealthy",
+			Desc:     "Wait for the etcd to be healthy",
+			Hosts:    etcdNodes,
+			Action:   &WaitEtcdHealthy{Timeout: timeout},
+			Parallel: true,
+		}
+		r.Tasks = append(r.Tasks, restoreEtcd, waitEtcd)
+	}
+
+	start := &task.RemoteTask{
+		Name:     "StartControlPlane",
+		Desc:     "Start the kubelet and wait for the kube-apiserver to be ready",
+		Hosts:    masters,
+		Action:   &StartControlPlane{Timeout: timeout},
+		Parallel: true,
+	}
+	r.Tasks = append(r.Tasks, start)
+}
+
+func hostNames(hosts []connector.Host) []string {
+	names := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		names = append(names, host.GetName())
+	}
+	return names
+}
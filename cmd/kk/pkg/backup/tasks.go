@@ -0,0 +1,388 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package backup
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/health"
+)
+
+const (
+	// kubernetesDir is the dir of the PKI, the static pod manifests and the kubeconfigs of the control-plane nodes.
+	kubernetesDir = "/etc/kubernetes"
+	// etcdCertsDir is the dir of the certificates of the etcd of KubeKey.
+	etcdCertsDir = "/etc/ssl/etcd/ssl"
+	// etcdEnvFile is the environment file of the etcd of KubeKey.
+	etcdEnvFile = "/etc/etcd.env"
+	// kubeadmEtcdCertsDir is the dir of the certificates of the stacked etcd of kubeadm.
+	kubeadmEtcdCertsDir = "/etc/kubernetes/pki/etcd"
+	// kubeadmEtcdDataDir is the data dir of the stacked etcd of kubeadm, which is mounted into its static pod.
+	kubeadmEtcdDataDir = "/var/lib/etcd"
+)
+
+// remoteDir is the dir of the files of the backup on the nodes, which is only accessible by root and the login user.
+var remoteDir = path.Join(common.TmpDir, "backup")
+
+// resetRemoteDir empties the remote dir of the node.
+func resetRemoteDir(runtime connector.Runtime) error {
+	cmd := fmt.Sprintf("rm -rf %[1]s && mkdir -p -m 700 %[1]s && chown %[2]s %[1]s", remoteDir, runtime.RemoteHost().GetUser())
+	if _, err := runtime.GetRunner().SudoCmd(cmd, false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "create %s failed", remoteDir)
+	}
+	return nil
+}
+
+// fetch fetches the file of the remote dir created by root to the local dir, the file is handed over to the login user
+// to be fetched and removed afterwards.
+func fetch(runtime connector.Runtime, dir, name string) error {
+	remote := path.Join(remoteDir, name)
+	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("chown %s %s", runtime.RemoteHost().GetUser(), remote), false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "hand over %s failed", remote)
+	}
+	defer func() {
+		if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("rm -f %s", remote), false); err != nil {
+			logger.Log.Warnf("remove %s on %s failed: %v", remote, runtime.RemoteHost().GetName(), err)
+		}
+	}()
+	if err := runtime.GetRunner().Fetch(filepath.Join(dir, name), remote); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "fetch %s failed", remote)
+	}
+	return nil
+}
+
+// etcdctl returns the environment of etcdctl with the admin certificate of the etcd node.
+func etcdctl(runtime connector.Runtime) connector.CommandOptions {
+	name := runtime.RemoteHost().GetName()
+	return connector.CommandOptions{Env: map[string]string{
+		"ETCDCTL_API":    "3",
+		"ETCDCTL_CERT":   fmt.Sprintf("%s/admin-%s.pem", etcdCertsDir, name),
+		"ETCDCTL_KEY":    fmt.Sprintf("%s/admin-%s-key.pem", etcdCertsDir, name),
+		"ETCDCTL_CACERT": etcdCertsDir + "/ca.pem",
+	}}
+}
+
+// snapshotHost returns the node the etcd of the type is snapshotted on: the first etcd node for the etcd of KubeKey
+// and the first control-plane node for the stacked etcd of kubeadm. The external etcd isn't snapshotted, nil is
+// returned for it. An error is returned if the cluster has no such node.
+func snapshotHost(etcdType string, masters, etcdNodes []connector.Host) (connector.Host, error) {
+	switch etcdType {
+	case kubekeyapiv1alpha2.KubeKey:
+		if len(etcdNodes) == 0 {
+			return nil, errors.New("the cluster has no etcd node to snapshot the etcd on")
+		}
+		return etcdNodes[0], nil
+	case kubekeyapiv1alpha2.Kubeadm:
+		if len(masters) == 0 {
+			return nil, errors.New("the cluster has no control-plane node to snapshot the etcd on")
+		}
+		return masters[0], nil
+	default:
+		return nil, nil
+	}
+}
+
+// CheckHosts checks the cluster has the control-plane nodes to archive and the node to snapshot the etcd on.
+type CheckHosts struct {
+	common.KubeAction
+}
+
+func (c *CheckHosts) Execute(runtime connector.Runtime) error {
+	masters := runtime.GetHostsByRole(common.Master)
+	if len(masters) == 0 {
+		return errors.New("the cluster has no control-plane node to back up")
+	}
+	_, err := snapshotHost(c.KubeConf.Cluster.Etcd.Type, masters, runtime.GetHostsByRole(common.ETCD))
+	return err
+}
+
+// SnapshotEtcd takes the snapshot of the etcd on the node and fetches it to the Dir of the backup.
+type SnapshotEtcd struct {
+	common.KubeAction
+	Dir string
+}
+
+func (s *SnapshotEtcd) Execute(runtime connector.Runtime) error {
+	if err := resetRemoteDir(runtime); err != nil {
+		return err
+	}
+	host := runtime.RemoteHost()
+	snapshot := path.Join(remoteDir, SnapshotFile)
+	var (
+		out string
+		err error
+	)
+	if s.KubeConf.Cluster.Etcd.Type == kubekeyapiv1alpha2.Kubeadm {
+		out, err = runtime.GetRunner().SudoCmd(kubeadmSnapshotCmd(host.GetName(), snapshot), false)
+	} else {
+		endpoint := fmt.Sprintf("https://%s:2379", host.GetInternalIPv4Address())
+		cmd := fmt.Sprintf("%s/etcdctl --endpoints=%s snapshot save %s", common.BinDir, endpoint, snapshot)
+		out, err = runtime.GetRunner().SudoCmdWith(cmd, etcdctl(runtime), false)
+	}
+	if err != nil {
+		return errors.Wrapf(errors.WithStack(err), "take the snapshot of the etcd failed: %s", strings.TrimSpace(out))
+	}
+	return fetch(runtime, s.Dir, SnapshotFile)
+}
+
+// kubeadmSnapshotCmd returns the command taking the snapshot of the stacked etcd of kubeadm on the control-plane
+// node to the snapshot file. The etcdctl of the etcd static pod saves the snapshot into the data dir mounted from
+// the node, with the certificates of /etc/kubernetes/pki/etcd, and it's moved to the snapshot file from there.
+func kubeadmSnapshotCmd(node, snapshot string) string {
+	saved := path.Join(kubeadmEtcdDataDir, SnapshotFile)
+	return fmt.Sprintf("/usr/local/bin/kubectl -n kube-system exec etcd-%[1]s -- etcdctl --endpoints=https://127.0.0.1:2379 "+
+		"--cacert=%[2]s/ca.crt --cert=%[2]s/server.crt --key=%[2]s/server.key snapshot save %[3]s && mv -f %[3]s %[4]s",
+		node, kubeadmEtcdCertsDir, saved, snapshot)
+}
+
+// ArchiveDir archives the Src dir of the node and fetches it to the Dir of the backup as the archive of the Name.
+type ArchiveDir struct {
+	common.KubeAction
+	Dir  string
+	Src  string
+	Name func(node string) string
+}
+
+func (a *ArchiveDir) Execute(runtime connector.Runtime) error {
+	if err := resetRemoteDir(runtime); err != nil {
+		return err
+	}
+	name := a.Name(runtime.RemoteHost().GetName())
+	cmd := fmt.Sprintf("tar czf %s -C %s %s", path.Join(remoteDir, name), path.Dir(a.Src), path.Base(a.Src))
+	if _, err := runtime.GetRunner().SudoCmd(cmd, false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "archive %s failed", a.Src)
+	}
+	return fetch(runtime, a.Dir, name)
+}
+
+// SaveBackup writes the Metadata to the Dir of the backup, and uploads the backup to its Location.
+type SaveBackup struct {
+	common.KubeAction
+	Dir      string
+	Location Location
+	Metadata Metadata
+}
+
+func (s *SaveBackup) Execute(runtime connector.Runtime) error {
+	if err := WriteMetadata(s.Dir, s.Metadata); err != nil {
+		return err
+	}
+	if err := s.Location.Upload(s.Dir); err != nil {
+		return err
+	}
+	logger.Log.Messagef(common.LocalHost, "The backup of the cluster %s is saved to %s", s.Metadata.Cluster, s.Location)
+	return nil
+}
+
+// StopControlPlane stops the kubelet and removes the control-plane containers of the node, so the control plane
+// doesn't run while its files and the etcd are restored.
+type StopControlPlane struct {
+	common.KubeAction
+}
+
+func (s *StopControlPlane) Execute(runtime connector.Runtime) error {
+	if _, err := runtime.GetRunner().SudoCmd("systemctl stop kubelet", false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "stop kubelet failed")
+	}
+	cmd := "/usr/local/bin/crictl pods --namespace kube-system --name 'kube-apiserver-*|kube-controller-manager-*|kube-scheduler-*|etcd-*' -q | " +
+		"xargs -r /usr/local/bin/crictl rmp -f"
+	if s.KubeConf.Cluster.Kubernetes.ContainerManager == common.Docker {
+		cmd = "docker ps -af 'name=k8s_POD_(kube-apiserver|kube-controller-manager|kube-scheduler|etcd)-*' -q | xargs -r docker rm -f"
+	}
+	if _, err := runtime.GetRunner().SudoCmd(cmd, false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "remove the control-plane containers failed")
+	}
+	return nil
+}
+
+// restoreDir moves the dst dir of the node to <dst>.<timestamp>.bak, and extracts the archive of the Dir of the
+// backup in its place.
+func restoreDir(runtime connector.Runtime, dir, name, dst string) error {
+	remote := path.Join(remoteDir, name)
+	if err := runtime.GetRunner().SudoScp(filepath.Join(dir, name), remote); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "copy %s to the node failed", name)
+	}
+	bak := fmt.Sprintf("%s.%s.bak", dst, time.Now().Format("20060102150405"))
+	cmd := fmt.Sprintf("if [ -e %[1]s ]; then mv %[1]s %[2]s; fi && mkdir -p %[3]s && tar xzf %[4]s -C %[3]s && rm -f %[4]s",
+		dst, bak, path.Dir(dst), remote)
+	if _, err := runtime.GetRunner().SudoCmd(cmd, false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "restore %s failed", dst)
+	}
+	return nil
+}
+
+// RestoreKubernetes restores /etc/kubernetes of the control-plane node from the Dir of the backup.
+type RestoreKubernetes struct {
+	common.KubeAction
+	Dir string
+}
+
+func (r *RestoreKubernetes) Execute(runtime connector.Runtime) error {
+	if err := resetRemoteDir(runtime); err != nil {
+		return err
+	}
+	return restoreDir(runtime, r.Dir, KubernetesArchive(runtime.RemoteHost().GetName()), kubernetesDir)
+}
+
+// RestoreEtcd restores the etcd of KubeKey on the etcd node from the snapshot of the Dir of the backup: the etcd is
+// stopped, its certificates are restored if they are in the backup, its data dir is moved to <data-dir>.<timestamp>.bak
+// and restored from the snapshot as the member of the node, and the etcd is started again. The members are started
+// without waiting for each other, they are healthy once the quorum is back.
+type RestoreEtcd struct {
+	common.KubeAction
+	Dir       string
+	EtcdNodes []string
+}
+
+func (r *RestoreEtcd) Execute(runtime connector.Runtime) error {
+	host := runtime.RemoteHost().GetName()
+	if err := resetRemoteDir(runtime); err != nil {
+		return err
+	}
+	if _, err := runtime.GetRunner().SudoCmd("systemctl stop etcd", false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "stop etcd failed")
+	}
+	for _, node := range r.EtcdNodes {
+		if node == host {
+			if err := restoreDir(runtime, r.Dir, EtcdCertsArchive(host), etcdCertsDir); err != nil {
+				return err
+			}
+		}
+	}
+
+	out, err := runtime.GetRunner().SudoCmd("cat "+etcdEnvFile, false)
+	if err != nil {
+		return errors.Wrapf(errors.WithStack(err), "read %s failed", etcdEnvFile)
+	}
+	cmd, err := restoreEtcdCmd(parseEnv(out), path.Join(remoteDir, SnapshotFile), time.Now())
+	if err != nil {
+		// the environment file isn't read in the dry run
+		if connector.IsDryRun(runtime.GetConnector()) {
+			return nil
+		}
+		return err
+	}
+	if err := runtime.GetRunner().SudoScp(filepath.Join(r.Dir, SnapshotFile), path.Join(remoteDir, SnapshotFile)); err != nil {
+		return errors.Wrap(errors.WithStack(err), "copy the snapshot to the node failed")
+	}
+	if out, err := runtime.GetRunner().SudoCmd(cmd, false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "restore the etcd from the snapshot failed: %s", strings.TrimSpace(out))
+	}
+	if _, err := runtime.GetRunner().SudoCmd("systemctl start --no-block etcd", false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "start etcd failed")
+	}
+	return nil
+}
+
+// parseEnv parses the KEY=VALUE lines of the environment file, the comments and the other lines are skipped.
+func parseEnv(content string) map[string]string {
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			env[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+	return env
+}
+
+// restoreEtcdCmd returns the command restoring the data dir of the etcd member of the environment from the
+// snapshot, the old data dir is moved to <data-dir>.<timestamp>.bak.
+func restoreEtcdCmd(env map[string]string, snapshot string, now time.Time) (string, error) {
+	for _, key := range []string{"ETCD_NAME", "ETCD_INITIAL_CLUSTER", "ETCD_INITIAL_ADVERTISE_PEER_URLS"} {
+		if env[key] == "" {
+			return "", errors.Errorf("%s isn't set in %s", key, etcdEnvFile)
+		}
+	}
+	dataDir := env["ETCD_DATA_DIR"]
+	if dataDir == "" {
+		dataDir = "/var/lib/etcd"
+	}
+	token := env["ETCD_INITIAL_CLUSTER_TOKEN"]
+	if token == "" {
+		token = "k8s_etcd"
+	}
+	bak := fmt.Sprintf("%s.%s.bak", dataDir, now.Format("20060102150405"))
+	return fmt.Sprintf("if [ -e %[1]s ]; then mv %[1]s %[2]s; fi && ETCDCTL_API=3 %[3]s/etcdctl snapshot restore %[4]s "+
+		"--name %[5]s --initial-cluster %[6]s --initial-cluster-token %[7]s --initial-advertise-peer-urls %[8]s --data-dir %[1]s",
+		dataDir, bak, common.BinDir, snapshot, env["ETCD_NAME"], env["ETCD_INITIAL_CLUSTER"], token,
+		env["ETCD_INITIAL_ADVERTISE_PEER_URLS"]), nil
+}
+
+// WaitEtcdHealthy waits for the etcd member of the node to be healthy.
+type WaitEtcdHealthy struct {
+	common.KubeAction
+	Timeout time.Duration
+}
+
+func (w *WaitEtcdHealthy) Execute(runtime connector.Runtime) error {
+	endpoint := fmt.Sprintf("https://%s:2379", runtime.RemoteHost().GetInternalIPv4Address())
+	return health.Wait(runtime, w.Timeout, health.Etcd(endpoint))
+}
+
+// StartControlPlane starts the kubelet of the control-plane node, which starts the restored static pods, and waits
+// for the kube-apiserver to be ready.
+type StartControlPlane struct {
+	common.KubeAction
+	Timeout time.Duration
+}
+
+func (s *StartControlPlane) Execute(runtime connector.Runtime) error {
+	if _, err := runtime.GetRunner().SudoCmd("systemctl start kubelet", false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "start kubelet failed")
+	}
+	return health.Wait(runtime, s.Timeout, health.Apiserver)
+}
+
+// CheckBackup checks the Dir of the backup has the archives of the control-plane nodes of the cluster and the
+// snapshot of the etcd of KubeKey, before the control plane is stopped.
+type CheckBackup struct {
+	common.KubeAction
+	Dir string
+}
+
+func (c *CheckBackup) Execute(runtime connector.Runtime) error {
+	files := []string{MetadataFile}
+	for _, host := range runtime.GetHostsByRole(common.Master) {
+		files = append(files, KubernetesArchive(host.GetName()))
+	}
+	if c.KubeConf.Cluster.Etcd.Type == kubekeyapiv1alpha2.KubeKey {
+		files = append(files, SnapshotFile)
+	}
+	for _, file := range files {
+		if _, err := os.Stat(filepath.Join(c.Dir, file)); err != nil {
+			return errors.Wrapf(err, "the backup in %s isn't complete", c.Dir)
+		}
+	}
+	return nil
+}
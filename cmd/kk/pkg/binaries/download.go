@@ -0,0 +1,73 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package binaries
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/files"
+)
+
+// downloadBinaries downloads the binaries which are missing or corrupted, at most the concurrency of the download
+// policy at a time. All the binaries are tried, and the errors of the failed ones are returned together.
+func downloadBinaries(arch string, binaries []*files.KubeBinary) error {
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		errs  []error
+		slots = make(chan struct{}, files.DownloadConcurrency())
+	)
+	for _, binary := range binaries {
+		if err := binary.CreateBaseDir(); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "create file %s base dir failed", binary.FileName)
+		}
+
+		if util.IsExist(binary.Path()) {
+			// download it again if it's incorrect
+			if err := binary.SHA256Check(); err != nil {
+				_ = os.Remove(binary.Path())
+			} else {
+				logger.Log.Messagef(common.LocalHost, "%s exists", binary.ID)
+				continue
+			}
+		}
+
+		wg.Add(1)
+		go func(binary *files.KubeBinary) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			logger.Log.Messagef(common.LocalHost, "downloading %s %s %s ...", arch, binary.ID, binary.Version)
+			if err := binary.Download(); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("Failed to download %s binary: %s error: %w ", binary.ID, binary.GetCmd(), err))
+				mu.Unlock()
+			}
+		}(binary)
+	}
+	wg.Wait()
+	return utilerrors.NewAggregate(errs)
+}
@@ -33,9 +33,10 @@ func (n *NodeBinariesModule) Init() {
 	n.Desc = "Download installation binaries"
 
 	download := &task.LocalTask{
-		Name:   "DownloadBinaries",
-		Desc:   "Download installation binaries",
-		Action: new(Download),
+		Name:        "DownloadBinaries",
+		Desc:        "Download installation binaries",
+		Action:      new(Download),
+		RunInDryRun: true,
 	}
 
 	n.Tasks = []task.Interface{
@@ -52,9 +53,10 @@ func (k *K3sNodeBinariesModule) Init() {
 	k.Desc = "Download installation binaries"
 
 	download := &task.LocalTask{
-		Name:   "DownloadBinaries",
-		Desc:   "Download installation binaries",
-		Action: new(K3sDownload),
+		Name:        "DownloadBinaries",
+		Desc:        "Download installation binaries",
+		Action:      new(K3sDownload),
+		RunInDryRun: true,
 	}
 
 	k.Tasks = []task.Interface{
@@ -71,9 +73,10 @@ func (k *K8eNodeBinariesModule) Init() {
 	k.Desc = "Download installation binaries"
 
 	download := &task.LocalTask{
-		Name:   "DownloadBinaries",
-		Desc:   "Download installation binaries",
-		Action: new(K8eDownload),
+		Name:        "DownloadBinaries",
+		Desc:        "Download installation binaries",
+		Action:      new(K8eDownload),
+		RunInDryRun: true,
 	}
 
 	k.Tasks = []task.Interface{
@@ -90,9 +93,10 @@ func (a *ArtifactBinariesModule) Init() {
 	a.Desc = "Download artifact binaries"
 
 	download := &task.LocalTask{
-		Name:   "DownloadBinaries",
-		Desc:   "Download manifest expect binaries",
-		Action: new(ArtifactDownload),
+		Name:        "DownloadBinaries",
+		Desc:        "Download manifest expect binaries",
+		Action:      new(ArtifactDownload),
+		RunInDryRun: true,
 	}
 
 	a.Tasks = []task.Interface{
@@ -109,9 +113,10 @@ func (a *K3sArtifactBinariesModule) Init() {
 	a.Desc = "Download artifact binaries"
 
 	download := &task.LocalTask{
-		Name:   "K3sDownloadBinaries",
-		Desc:   "Download k3s manifest expect binaries",
-		Action: new(K3sArtifactDownload),
+		Name:        "K3sDownloadBinaries",
+		Desc:        "Download k3s manifest expect binaries",
+		Action:      new(K3sArtifactDownload),
+		RunInDryRun: true,
 	}
 
 	a.Tasks = []task.Interface{
@@ -128,9 +133,10 @@ func (a *K8eArtifactBinariesModule) Init() {
 	a.Desc = "Download artifact binaries"
 
 	download := &task.LocalTask{
-		Name:   "K8eDownloadBinaries",
-		Desc:   "Download k8e manifest expect binaries",
-		Action: new(K8eArtifactDownload),
+		Name:        "K8eDownloadBinaries",
+		Desc:        "Download k8e manifest expect binaries",
+		Action:      new(K8eArtifactDownload),
+		RunInDryRun: true,
 	}
 
 	a.Tasks = []task.Interface{
@@ -151,9 +157,10 @@ func (n *RegistryPackageModule) Init() {
 	}
 
 	download := &task.LocalTask{
-		Name:   "DownloadRegistryPackage",
-		Desc:   "Download registry package",
-		Action: new(RegistryPackageDownload),
+		Name:        "DownloadRegistryPackage",
+		Desc:        "Download registry package",
+		Action:      new(RegistryPackageDownload),
+		RunInDryRun: true,
 	}
 
 	n.Tasks = []task.Interface{
@@ -181,9 +188,10 @@ func (i *CriBinariesModule) Init() {
 func CriBinaries(p *CriBinariesModule) []task.Interface {
 
 	download := &task.LocalTask{
-		Name:   "DownloadCriPackage",
-		Desc:   "Download Cri package",
-		Action: new(CriDownload),
+		Name:        "DownloadCriPackage",
+		Desc:        "Download Cri package",
+		Action:      new(CriDownload),
+		RunInDryRun: true,
 	}
 
 	p.Tasks = []task.Interface{
@@ -19,6 +19,7 @@ package binaries
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 
 	mapset "github.com/deckarep/golang-set"
 	"github.com/pkg/errors"
@@ -28,6 +29,26 @@ import (
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
 )
 
+// HostArches returns the sorted architectures of the hosts, which are gathered from the hosts when connecting to them,
+// so the binaries of each architecture of a mixed cluster are downloaded.
+func HostArches(hosts []connector.Host) ([]string, error) {
+	set := make(map[string]struct{})
+	for _, host := range hosts {
+		switch host.GetArch() {
+		case "amd64", "arm64":
+			set[host.GetArch()] = struct{}{}
+		default:
+			return nil, errors.Errorf("unsupported architecture %s of the host %s", host.GetArch(), host.GetName())
+		}
+	}
+	arches := make([]string, 0, len(set))
+	for arch := range set {
+		arches = append(arches, arch)
+	}
+	sort.Strings(arches)
+	return arches, nil
+}
+
 type Download struct {
 	common.KubeAction
 }
@@ -42,19 +63,11 @@ func (d *Download) Execute(runtime connector.Runtime) error {
 		kubeVersion = cfg.Kubernetes.Version
 	}
 
-	archMap := make(map[string]bool)
-	for _, host := range cfg.Hosts {
-		switch host.Arch {
-		case "amd64":
-			archMap["amd64"] = true
-		case "arm64":
-			archMap["arm64"] = true
-		default:
-			return errors.New(fmt.Sprintf("Unsupported architecture: %s", host.Arch))
-		}
+	arches, err := HostArches(runtime.GetAllHosts())
+	if err != nil {
+		return err
 	}
-
-	for arch := range archMap {
+	for _, arch := range arches {
 		if err := K8sFilesDownloadHTTP(d.KubeConf, runtime.GetWorkDir(), kubeVersion, arch, d.PipelineCache); err != nil {
 			return err
 		}
@@ -76,19 +89,11 @@ func (k *K3sDownload) Execute(runtime connector.Runtime) error {
 		kubeVersion = cfg.Kubernetes.Version
 	}
 
-	archMap := make(map[string]bool)
-	for _, host := range cfg.Hosts {
-		switch host.Arch {
-		case "amd64":
-			archMap["amd64"] = true
-		case "arm64":
-			archMap["arm64"] = true
-		default:
-			return errors.New(fmt.Sprintf("Unsupported architecture: %s", host.Arch))
-		}
+	arches, err := HostArches(runtime.GetAllHosts())
+	if err != nil {
+		return err
 	}
-
-	for arch := range archMap {
+	for _, arch := range arches {
 		if err := K3sFilesDownloadHTTP(k.KubeConf, runtime.GetWorkDir(), kubeVersion, arch, k.PipelineCache); err != nil {
 			return err
 		}
@@ -110,19 +115,11 @@ func (k *K8eDownload) Execute(runtime connector.Runtime) error {
 		kubeVersion = cfg.Kubernetes.Version
 	}
 
-	archMap := make(map[string]bool)
-	for _, host := range cfg.Hosts {
-		switch host.Arch {
-		case "amd64":
-			archMap["amd64"] = true
-		case "arm64":
-			archMap["arm64"] = true
-		default:
-			return errors.New(fmt.Sprintf("Unsupported architecture: %s", host.Arch))
-		}
+	arches, err := HostArches(runtime.GetAllHosts())
+	if err != nil {
+		return err
 	}
-
-	for arch := range archMap {
+	for _, arch := range arches {
 		if err := K8eFilesDownloadHTTP(k.KubeConf, runtime.GetWorkDir(), kubeVersion, arch, k.PipelineCache); err != nil {
 			return err
 		}
@@ -287,20 +284,11 @@ type CriDownload struct {
 }
 
 func (d *CriDownload) Execute(runtime connector.Runtime) error {
-	cfg := d.KubeConf.Cluster
-	archMap := make(map[string]bool)
-	for _, host := range cfg.Hosts {
-		switch host.Arch {
-		case "amd64":
-			archMap["amd64"] = true
-		case "arm64":
-			archMap["arm64"] = true
-		default:
-			return errors.New(fmt.Sprintf("Unsupported architecture: %s", host.Arch))
-		}
+	arches, err := HostArches(runtime.GetAllHosts())
+	if err != nil {
+		return err
 	}
-
-	for arch := range archMap {
+	for _, arch := range arches {
 		if err := CriDownloadHTTP(d.KubeConf, runtime.GetWorkDir(), arch, d.PipelineCache); err != nil {
 			return err
 		}
@@ -17,6 +17,8 @@
 package confirm
 
 import (
+	"time"
+
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
@@ -36,9 +38,10 @@ func (i *InstallConfirmModule) Init() {
 	i.Desc = "Display confirmation form"
 
 	display := &task.LocalTask{
-		Name:   "ConfirmForm",
-		Desc:   "Display confirmation form",
-		Action: new(InstallationConfirm),
+		Name:        "ConfirmForm",
+		Desc:        "Display confirmation form",
+		Action:      new(InstallationConfirm),
+		RunInDryRun: true,
 	}
 
 	i.Tasks = []task.Interface{
@@ -60,9 +63,10 @@ func (d *DeleteClusterConfirmModule) Init() {
 	d.Desc = "Display delete confirmation form"
 
 	display := &task.LocalTask{
-		Name:   "ConfirmForm",
-		Desc:   "Display confirmation form",
-		Action: &DeleteConfirm{Content: "cluster"},
+		Name:        "ConfirmForm",
+		Desc:        "Display confirmation form",
+		Action:      &DeleteConfirm{Content: "cluster"},
+		RunInDryRun: true,
 	}
 
 	d.Tasks = []task.Interface{
@@ -84,9 +88,10 @@ func (d *DeleteNodeConfirmModule) Init() {
 	d.Desc = "Display delete node confirmation form"
 
 	display := &task.LocalTask{
-		Name:   "ConfirmForm",
-		Desc:   "Display confirmation form",
-		Action: &DeleteConfirm{Content: "node"},
+		Name:        "ConfirmForm",
+		Desc:        "Display confirmation form",
+		Action:      &DeleteConfirm{Content: "node"},
+		RunInDryRun: true,
 	}
 
 	d.Tasks = []task.Interface{
@@ -108,9 +113,10 @@ func (u *UpgradeConfirmModule) Init() {
 	u.Desc = "Display upgrade confirmation form"
 
 	display := &task.LocalTask{
-		Name:   "ConfirmForm",
-		Desc:   "Display confirmation form",
-		Action: new(UpgradeConfirm),
+		Name:        "ConfirmForm",
+		Desc:        "Display confirmation form",
+		Action:      new(UpgradeConfirm),
+		RunInDryRun: true,
 	}
 
 	u.Tasks = []task.Interface{
@@ -118,6 +124,60 @@ func (u *UpgradeConfirmModule) Init() {
 	}
 }
 
+type PatchConfirmModule struct {
+	common.KubeModule
+	Skip   bool
+	Nodes  []string
+	Reboot string
+}
+
+func (p *PatchConfirmModule) IsSkip() bool {
+	return p.Skip
+}
+
+func (p *PatchConfirmModule) Init() {
+	p.Name = "PatchConfirmModule"
+	p.Desc = "Display patch confirmation form"
+
+	display := &task.LocalTask{
+		Name:        "ConfirmForm",
+		Desc:        "Display confirmation form",
+		Action:      &PatchConfirm{Nodes: p.Nodes, Reboot: p.Reboot},
+		RunInDryRun: true,
+	}
+
+	p.Tasks = []task.Interface{
+		display,
+	}
+}
+
+type RestoreConfirmModule struct {
+	common.KubeModule
+	Skip    bool
+	Backup  string
+	Created time.Time
+}
+
+func (r *RestoreConfirmModule) IsSkip() bool {
+	return r.Skip
+}
+
+func (r *RestoreConfirmModule) Init() {
+	r.Name = "RestoreConfirmModule"
+	r.Desc = "Display restore confirmation form"
+
+	display := &task.LocalTask{
+		Name:        "ConfirmForm",
+		Desc:        "Display confirmation form",
+		Action:      &RestoreConfirm{Backup: r.Backup, Created: r.Created},
+		RunInDryRun: true,
+	}
+
+	r.Tasks = []task.Interface{
+		display,
+	}
+}
+
 type CheckFileExistModule struct {
 	module.BaseTaskModule
 	FileName string
@@ -128,9 +188,10 @@ func (c *CheckFileExistModule) Init() {
 	c.Desc = "Check file if is existed"
 
 	check := &task.LocalTask{
-		Name:   "CheckExist",
-		Desc:   "Check output file if existed",
-		Action: &CheckFile{FileName: c.FileName},
+		Name:        "CheckExist",
+		Desc:        "Check output file if existed",
+		Action:      &CheckFile{FileName: c.FileName},
+		RunInDryRun: true,
 	}
 
 	c.Tasks = []task.Interface{
@@ -147,9 +208,10 @@ func (d *MigrateCriConfirmModule) Init() {
 	d.Desc = "Display Migrate Cri form"
 
 	display := &task.LocalTask{
-		Name:   "ConfirmForm",
-		Desc:   "Display confirmation form",
-		Action: &MigrateCri{},
+		Name:        "ConfirmForm",
+		Desc:        "Display confirmation form",
+		Action:      &MigrateCri{},
+		RunInDryRun: true,
 	}
 
 	d.Tasks = []task.Interface{
@@ -22,6 +22,7 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
@@ -120,7 +121,8 @@ func (i *InstallationConfirm) Execute(runtime connector.Runtime) error {
 		fmt.Println("")
 	}
 
-	if stopFlag {
+	// the commands aren't executed in the check mode, so the missing commands are unknown
+	if stopFlag && !connector.IsDryRun(runtime.GetConnector()) {
 		os.Exit(1)
 	}
 
@@ -179,6 +181,61 @@ func (d *DeleteConfirm) Execute(runtime connector.Runtime) error {
 	return nil
 }
 
+// RestoreConfirm asks to confirm the restore of the control plane from the backup, which replaces the files of the
+// control-plane nodes and the data of the etcd.
+type RestoreConfirm struct {
+	common.KubeAction
+	Backup  string
+	Created time.Time
+}
+
+func (r *RestoreConfirm) Execute(runtime connector.Runtime) error {
+	fmt.Printf("The control plane of the cluster %s will be stopped and restored from the backup %s taken at %s.\n",
+		r.KubeConf.ClusterName, r.Backup, r.Created.Local().Format(time.RFC3339))
+	fmt.Println("The changes to the cluster since the backup will be lost.")
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Printf("Are you sure to restore the cluster? [yes/no]: ")
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(strings.TrimSpace(input)) {
+		case "yes", "y":
+			return nil
+		case "no", "n":
+			os.Exit(0)
+		}
+	}
+}
+
+// PatchConfirm asks to confirm the patching of the nodes, which are drained and, by the reboot mode, rebooted one batch
+// after another.
+type PatchConfirm struct {
+	common.KubeAction
+	Nodes  []string
+	Reboot string
+}
+
+func (p *PatchConfirm) Execute(runtime connector.Runtime) error {
+	fmt.Printf("The nodes %s will be drained, upgraded and rebooted (%s), one batch after another.\n",
+		strings.Join(p.Nodes, ", "), p.Reboot)
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Printf("Are you sure to patch the nodes? [yes/no]: ")
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(strings.TrimSpace(input)) {
+		case "yes", "y":
+			return nil
+		case "no", "n":
+			os.Exit(0)
+		}
+	}
+}
+
 type UpgradeConfirm struct {
 	common.KubeAction
 }
@@ -0,0 +1,125 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package customscripts
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/health"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/utils"
+)
+
+// defaultWaitForThat is the expression of the command of the wait without That, the command must succeed.
+const defaultWaitForThat = "eq .Rc 0"
+
+// AssertTask evaluates the expressions of the assert with the variables of the node, and fails if any of them is
+// false. The expressions aren't evaluated in the dry run, since the commands don't return any output.
+type AssertTask struct {
+	common.KubeAction
+	script kubekeyapiv1alpha2.CustomScripts
+}
+
+func (t *AssertTask) Execute(runtime connector.Runtime) error {
+	if connector.IsDryRun(runtime.GetConnector()) {
+		return nil
+	}
+	assert := t.script.Assert
+	vars := commandVars(runtime, t.KubeConf.Cluster, assert.Command)
+	for _, expr := range assert.That {
+		ok, err := task.EvalWhen(expr, vars)
+		if err != nil {
+			return errors.Wrapf(err, "the assert %s failed", t.script.Name)
+		}
+		if ok {
+			continue
+		}
+		if assert.Msg != "" {
+			return errors.Errorf("the assert %s failed on %s: %s", t.script.Name, runtime.RemoteHost().GetName(), assert.Msg)
+		}
+		return errors.Errorf("the assert %s failed on %s: %s is false", t.script.Name, runtime.RemoteHost().GetName(), expr)
+	}
+	return nil
+}
+
+// WaitForTask waits for the condition of the script on the node, see health.WaitEvery.
+type WaitForTask struct {
+	common.KubeAction
+	script kubekeyapiv1alpha2.CustomScripts
+}
+
+func (t *WaitForTask) Execute(runtime connector.Runtime) error {
+	wait := t.script.WaitFor
+	if err := health.WaitEvery(runtime, wait.GetTimeout(), wait.GetInterval(), t.check()); err != nil {
+		return errors.Wrapf(err, "wait for %s failed", t.script.Name)
+	}
+	return nil
+}
+
+// check returns the health check of the condition of the wait.
+func (t *WaitForTask) check() health.Check {
+	wait := t.script.WaitFor
+	switch {
+	case wait.Port != 0:
+		address := wait.Host
+		if address == "" {
+			address = "127.0.0.1"
+		}
+		return health.Port(address, wait.Port)
+	case wait.Path != "":
+		return health.File(wait.Path)
+	case wait.URL != "":
+		return health.URL(wait.URL)
+	case wait.NodeReady:
+		return health.NodeReady
+	}
+
+	expr := wait.That
+	if expr == "" {
+		expr = defaultWaitForThat
+	}
+	return health.Check{Name: wait.Command, Run: func(runtime connector.Runtime) error {
+		vars := commandVars(runtime, t.KubeConf.Cluster, wait.Command)
+		ok, err := task.EvalWhen(expr, vars)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%s is false with the output: %s", expr, vars["Stdout"])
+		}
+		return nil
+	}}
+}
+
+// commandVars returns the variables of the expressions on the node: the variables of the templates of the custom
+// scripts, and the trimmed output and the exit code of the command as Stdout and Rc if the command is set.
+func commandVars(runtime connector.Runtime, cluster *kubekeyapiv1alpha2.ClusterSpec, command string) map[string]interface{} {
+	vars := map[string]interface{}(utils.HostVars(runtime, cluster))
+	if command != "" {
+		// the failure of the command is its exit code, which is checked by the expressions
+		out, code, _ := runtime.GetRunner().SudoExec(command, false)
+		vars["Stdout"] = strings.TrimSpace(out)
+		vars["Rc"] = code
+	}
+	return vars
+}
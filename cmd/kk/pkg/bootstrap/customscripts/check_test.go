@@ -0,0 +1,148 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package customscripts
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+func newCheckRuntime(t *testing.T, commands []connector.FakeCommand) (*connector.BaseRuntime, *connector.FakeDialer) {
+	dialer := connector.NewFakeDialer(&connector.FakeFixtures{Commands: commands})
+	host := connector.NewHost()
+	host.Name = "node1"
+	host.Arch = "amd64"
+	conn, err := dialer.Connect(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runtime := connector.NewBaseRuntime("test", dialer, false, false)
+	runtime.AppendHost(host)
+	runtime.SetRunner(&connector.Runner{Conn: conn, Host: host, Ctx: context.Background()})
+	return &runtime, dialer
+}
+
+func TestAssertTask(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+
+	tests := []struct {
+		name     string
+		assert   kubekeyapiv1alpha2.Assert
+		commands []connector.FakeCommand
+		wantErr  string
+	}{
+		{
+			name:   "facts",
+			assert: kubekeyapiv1alpha2.Assert{That: []string{`eq .Arch "amd64"`, `eq .Name "node1"`}},
+		},
+		{
+			name:     "command output",
+			assert:   kubekeyapiv1alpha2.Assert{Command: "getenforce", That: []string{`ne .Stdout "Enforcing"`, "eq .Rc 0"}},
+			commands: []connector.FakeCommand{{Match: "getenforce", Stdout: "Permissive\n"}},
+		},
+		{
+			name:     "false",
+			assert:   kubekeyapiv1alpha2.Assert{Command: "getenforce", That: []string{`ne .Stdout "Enforcing"`}},
+			commands: []connector.FakeCommand{{Match: "getenforce", Stdout: "Enforcing\n"}},
+			wantErr:  `the assert selinux failed on node1: ne .Stdout "Enforcing" is false`,
+		},
+		{
+			name:     "message",
+			assert:   kubekeyapiv1alpha2.Assert{Command: "test -d /data", That: []string{"eq .Rc 0"}, Msg: "/data is required"},
+			commands: []connector.FakeCommand{{Match: "test -d /data", ExitCode: 1}},
+			wantErr:  "the assert selinux failed on node1: /data is required",
+		},
+		{
+			name:    "not a boolean",
+			assert:  kubekeyapiv1alpha2.Assert{That: []string{".Name"}},
+			wantErr: "not a boolean",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runtime, _ := newCheckRuntime(t, tt.commands)
+			task := &AssertTask{script: kubekeyapiv1alpha2.CustomScripts{Name: "selinux", Assert: &tt.assert}}
+			task.KubeConf = &common.KubeConf{Cluster: &kubekeyapiv1alpha2.ClusterSpec{}}
+
+			err := task.Execute(runtime)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Execute() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWaitForTask(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+
+	tests := []struct {
+		name     string
+		wait     kubekeyapiv1alpha2.WaitFor
+		commands []connector.FakeCommand
+		wantCmd  string
+		wantErr  string
+	}{
+		{
+			name:     "port",
+			wait:     kubekeyapiv1alpha2.WaitFor{Port: 5000, Interval: "1ms"},
+			commands: []connector.FakeCommand{{Match: "/dev/tcp/", ExitCode: 1, Times: 1}},
+			wantCmd:  "/dev/tcp/127.0.0.1/5000",
+		},
+		{
+			name: "command",
+			wait: kubekeyapiv1alpha2.WaitFor{Command: "get deploy coredns", That: "ge (atoi .Stdout) 2", Interval: "1ms"},
+			commands: []connector.FakeCommand{
+				{Match: "get deploy coredns", Stdout: "1", Times: 2},
+				{Match: "get deploy coredns", Stdout: "2"},
+			},
+			wantCmd: "get deploy coredns",
+		},
+		{
+			name:     "timed out",
+			wait:     kubekeyapiv1alpha2.WaitFor{Command: "systemctl is-active registry", Timeout: "1ms", Interval: "1ms"},
+			commands: []connector.FakeCommand{{Match: "is-active", Stdout: "activating", ExitCode: 3}},
+			wantErr:  "eq .Rc 0 is false with the output: activating",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runtime, dialer := newCheckRuntime(t, tt.commands)
+			task := &WaitForTask{script: kubekeyapiv1alpha2.CustomScripts{Name: "registry", WaitFor: &tt.wait}}
+			task.KubeConf = &common.KubeConf{Cluster: &kubekeyapiv1alpha2.ClusterSpec{}}
+
+			err := task.Execute(runtime)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Execute() error = %v, want %q", err, tt.wantErr)
+			}
+			if tt.wantCmd != "" {
+				dialer.AssertCommands(t, "node1", tt.wantCmd)
+			}
+		})
+	}
+}
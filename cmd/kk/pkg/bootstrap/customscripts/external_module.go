@@ -0,0 +1,157 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package customscripts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/utils"
+)
+
+// ModuleRequest is the input of an external module, which is written as JSON to the stdin of the module on the node.
+type ModuleRequest struct {
+	// Args are the args of the module in the config.
+	Args map[string]interface{} `json:"args"`
+	// Vars are the variables of the node, the same as the ones of the templates without the Cluster.
+	Vars map[string]interface{} `json:"vars"`
+}
+
+// ModuleResult is the output of an external module, which is printed as JSON in the last line of its stdout.
+// The module fails if it exits with a non-zero code or reports failed, and the msg is the reason.
+type ModuleResult struct {
+	Changed bool   `json:"changed"`
+	Failed  bool   `json:"failed"`
+	Msg     string `json:"msg,omitempty"`
+}
+
+// LookupModule returns the path of the module of the name in the dir. The module named <name>-<arch> takes precedence
+// over the one named <name>, so a binary module can be built for each architecture of the nodes.
+func LookupModule(dir, name, arch string) (string, error) {
+	for _, file := range []string{name + "-" + arch, name} {
+		path := filepath.Join(dir, file)
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if info.Mode()&0111 == 0 {
+			return "", errors.Errorf("the module %s is not executable", path)
+		}
+		return path, nil
+	}
+	return "", errors.Errorf("the module %s is not found in %s", name, dir)
+}
+
+// ModulesDir returns the dir of the external modules, the modulesPath of the config or the modules dir in the work dir.
+func ModulesDir(runtime connector.Runtime, cluster *kubekeyapiv1alpha2.ClusterSpec) string {
+	if cluster.System.ModulesPath != "" {
+		return cluster.System.ModulesPath
+	}
+	return filepath.Join(runtime.GetWorkDir(), "modules")
+}
+
+// ExternalModuleTask copies the external module to the node through the connector, and runs it with the request
+// on stdin. The changed of the result is reported as the result of the action.
+type ExternalModuleTask struct {
+	common.KubeAction
+	taskDir string
+	script  kubekeyapiv1alpha2.CustomScripts
+}
+
+func (t *ExternalModuleTask) Execute(runtime connector.Runtime) error {
+	host := runtime.RemoteHost()
+	module, err := LookupModule(ModulesDir(runtime, t.KubeConf.Cluster), t.script.Module, host.GetArch())
+	if err != nil {
+		return err
+	}
+
+	request := ModuleRequest{Args: map[string]interface{}{}, Vars: utils.HostVars(runtime, t.KubeConf.Cluster)}
+	// the cluster spec contains the credentials of all the hosts
+	delete(request.Vars, "Cluster")
+	if len(t.script.Args.Raw) != 0 {
+		if err := yaml.Unmarshal(t.script.Args.Raw, &request.Args); err != nil {
+			return errors.Wrapf(err, "parse the args of the module %s failed", t.script.Module)
+		}
+	}
+	content, err := json.Marshal(request)
+	if err != nil {
+		return errors.Wrapf(err, "encode the request of the module %s failed", t.script.Module)
+	}
+	requestFile := filepath.Join(runtime.GetHostWorkDir(), t.taskDir+".json")
+	if err := util.WriteFile(requestFile, content); err != nil {
+		return errors.Wrapf(err, "write the request of the module %s failed", t.script.Module)
+	}
+
+	remoteTaskHome := common.TmpDir + t.taskDir
+	remoteModule := filepath.Join(remoteTaskHome, t.script.Module)
+	remoteRequest := filepath.Join(remoteTaskHome, "request.json")
+	if err := runtime.GetRunner().SudoScp(module, remoteModule); err != nil {
+		return errors.Wrapf(err, "copy the module %s to the node failed", t.script.Module)
+	}
+	if err := runtime.GetRunner().SudoScp(requestFile, remoteRequest); err != nil {
+		return errors.Wrapf(err, "copy the request of the module %s to the node failed", t.script.Module)
+	}
+	defer func() {
+		if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("rm -rf %s", remoteTaskHome), false); err != nil {
+			logger.Log.Warnf("remove %s on %s failed: %v", remoteTaskHome, host.GetName(), err)
+		}
+	}()
+
+	output, runErr := runtime.GetRunner().SudoCmd(fmt.Sprintf("chmod +x %s && %s < %s", remoteModule, remoteModule, remoteRequest), false)
+	if connector.IsDryRun(runtime.GetConnector()) {
+		return nil
+	}
+	result, err := parseModuleResult(output)
+	switch {
+	case err != nil && runErr != nil:
+		return errors.Wrapf(runErr, "run the module %s failed", t.script.Module)
+	case err != nil:
+		return errors.Wrapf(err, "run the module %s failed", t.script.Module)
+	case result.Failed || runErr != nil:
+		return errors.Errorf("the module %s failed: %s", t.script.Module, result.Msg)
+	}
+
+	if result.Changed {
+		action.Changed(runtime, result.Msg)
+	} else {
+		action.Unchanged(runtime)
+	}
+	return nil
+}
+
+// parseModuleResult parses the result in the last line of the output of the module, the lines before are its logs.
+func parseModuleResult(output string) (*ModuleResult, error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	result := &ModuleResult{}
+	if err := json.Unmarshal([]byte(last), result); err != nil {
+		return nil, errors.Errorf("the last line of the output is not the JSON result: %s", last)
+	}
+	return result, nil
+}
@@ -0,0 +1,80 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package customscripts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLookupModule(t *testing.T) {
+	dir := t.TempDir()
+	for name, mode := range map[string]os.FileMode{"tuner": 0755, "tuner-arm64": 0755, "plain": 0644} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		module  string
+		arch    string
+		want    string
+		wantErr bool
+	}{
+		{name: "arch specific", module: "tuner", arch: "arm64", want: "tuner-arm64"},
+		{name: "fallback to the name", module: "tuner", arch: "amd64", want: "tuner"},
+		{name: "not executable", module: "plain", arch: "amd64", wantErr: true},
+		{name: "not found", module: "missing", arch: "amd64", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LookupModule(dir, tt.module, tt.arch)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LookupModule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != filepath.Join(dir, tt.want) {
+				t.Errorf("LookupModule() = %v, want %v", got, filepath.Join(dir, tt.want))
+			}
+		})
+	}
+}
+
+func TestParseModuleResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    ModuleResult
+		wantErr bool
+	}{
+		{name: "result only", output: `{"changed": true, "msg": "done"}`, want: ModuleResult{Changed: true, Msg: "done"}},
+		{name: "logs before the result", output: "tuning sda\r\n{\"failed\": true, \"msg\": \"no sda\"}\r\n", want: ModuleResult{Failed: true, Msg: "no sda"}},
+		{name: "no result", output: "tuning sda", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseModuleResult(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseModuleResult() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && *got != tt.want {
+				t.Errorf("parseModuleResult() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
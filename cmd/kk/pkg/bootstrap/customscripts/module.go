@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
@@ -47,11 +48,23 @@ func (m *CustomScriptsModule) Init() {
 			hosts = m.Runtime.GetAllHosts()
 		}
 
+		var act action.Action = &CustomScriptTask{taskDir: taskDir, script: script}
+		switch {
+		case script.Assert != nil:
+			act = &AssertTask{script: script}
+		case script.WaitFor != nil:
+			act = &WaitForTask{script: script}
+		case script.TemplateFile != nil:
+			act = &TemplateFileTask{taskDir: fmt.Sprintf("%s-%d-template", m.Phase, idx), script: script}
+		case script.Module != "":
+			act = &ExternalModuleTask{taskDir: fmt.Sprintf("%s-%d-module", m.Phase, idx), script: script}
+		}
+
 		task := &task.RemoteTask{
 			Name:     taskName,
 			Desc:     taskName,
 			Hosts:    hosts,
-			Action:   &CustomScriptTask{taskDir: taskDir, script: script},
+			Action:   act,
 			Parallel: true,
 			Retry:    1,
 		}
@@ -21,19 +21,20 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/pkg/errors"
 
 	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/utils"
 )
 
 type CustomScriptTask struct {
-	action.BaseAction
+	common.KubeAction
 	taskDir string
 	script  kubekeyapiv1alpha2.CustomScripts
 }
@@ -77,6 +78,15 @@ func (t *CustomScriptTask) Execute(runtime connector.Runtime) error {
 
 	// wrap use bash file if shell has many lines.
 	RunBash := t.script.Bash
+	if t.script.Template {
+		tmpl, err := template.New(t.script.Name).Funcs(utils.FuncMap).Parse(RunBash)
+		if err != nil {
+			return errors.Wrapf(err, "parse the template of custom script %s failed", t.script.Name)
+		}
+		if RunBash, err = util.RenderWith(tmpl, utils.HostVars(runtime, t.KubeConf.Cluster), util.RenderOptions{Strict: runtime.GetStrictRender()}); err != nil {
+			return errors.Wrapf(err, "render the template of custom script %s failed", t.script.Name)
+		}
+	}
 	if strings.Index(RunBash, "\n") > 0 {
 		tmpFile, err := os.CreateTemp(os.TempDir(), t.taskDir)
 		if err != nil {
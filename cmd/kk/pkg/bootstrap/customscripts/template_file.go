@@ -0,0 +1,70 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package customscripts
+
+import (
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/utils"
+)
+
+// RenderTemplateFile renders the local template file with the vars, the functions of the templates of the custom
+// scripts are supported.
+func RenderTemplateFile(src string, vars util.Data, opts util.RenderOptions) (string, error) {
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return "", errors.Wrapf(err, "read the template %s failed", src)
+	}
+	tmpl, err := template.New(filepath.Base(src)).Funcs(utils.FuncMap).Parse(string(content))
+	if err != nil {
+		return "", errors.Wrapf(err, "parse the template %s failed", src)
+	}
+	out, err := util.RenderWith(tmpl, vars, opts)
+	if err != nil {
+		return "", errors.Wrapf(err, "render the template %s failed", src)
+	}
+	return out, nil
+}
+
+// TemplateFileTask renders the template file of the script with the variables of the node, and writes it to the dest
+// on the node only if its content changes. The diff with the old file is reported as the result of the action.
+type TemplateFileTask struct {
+	common.KubeAction
+	taskDir string
+	script  kubekeyapiv1alpha2.CustomScripts
+}
+
+func (t *TemplateFileTask) Execute(runtime connector.Runtime) error {
+	file := t.script.TemplateFile
+	content, err := RenderTemplateFile(file.Src, utils.HostVars(runtime, t.KubeConf.Cluster), util.RenderOptions{Strict: runtime.GetStrictRender()})
+	if err != nil {
+		return err
+	}
+	if file.Backup {
+		return action.WriteRemoteFileWithBackup(runtime, t.taskDir, file.Dest, content)
+	}
+	return action.WriteRemoteFile(runtime, t.taskDir, file.Dest, content)
+}
@@ -0,0 +1,59 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package customscripts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+func TestRenderTemplateFile(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"chrony.conf.tmpl": "server {{ .Name }} iburst\n# {{ .Zone | default \"default\" }}\n",
+		"broken.tmpl":      "server {{ .Name ",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		want    string
+		wantErr bool
+	}{
+		{name: "rendered with the vars", src: "chrony.conf.tmpl", want: "server node1 iburst\n# default\n"},
+		{name: "invalid template", src: "broken.tmpl", wantErr: true},
+		{name: "not found", src: "missing.tmpl", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderTemplateFile(filepath.Join(dir, tt.src), util.Data{"Name": "node1"}, util.RenderOptions{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RenderTemplateFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("RenderTemplateFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
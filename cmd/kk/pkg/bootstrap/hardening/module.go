@@ -0,0 +1,84 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package hardening
+
+import (
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+)
+
+// HardeningModule applies the baseline hardening of system.hardening to all the nodes: the sshd config, the audit
+// rules and the password policy. The controls which can't be applied with the tools on the node are skipped, and the
+// result of each control on each node is reported at the end.
+type HardeningModule struct {
+	common.KubeModule
+	Skip bool
+}
+
+func (h *HardeningModule) IsSkip() bool {
+	return h.Skip
+}
+
+func (h *HardeningModule) Init() {
+	h.Name = "HardeningModule"
+	h.Tags = []string{"os", "hardening"}
+	h.Desc = "Apply the baseline hardening of the nodes"
+
+	hardenSSHD := &task.RemoteTask{
+		Name:     "HardenSSHD",
+		Desc:     "Tighten the sshd config",
+		Hosts:    h.Runtime.GetAllHosts(),
+		Action:   new(HardenSSHD),
+		Parallel: true,
+	}
+
+	configureAuditd := &task.RemoteTask{
+		Name:     "ConfigureAuditRules",
+		Desc:     "Configure the audit rules",
+		Hosts:    h.Runtime.GetAllHosts(),
+		Action:   new(ConfigureAuditRules),
+		Parallel: true,
+	}
+
+	configurePasswordPolicy := &task.RemoteTask{
+		Name:     "ConfigurePasswordPolicy",
+		Desc:     "Configure the password policy",
+		Hosts:    h.Runtime.GetAllHosts(),
+		Action:   new(ConfigurePasswordPolicy),
+		Parallel: true,
+	}
+
+	report := &task.LocalTask{
+		Name:        "ReportHardening",
+		Desc:        "Report the applied hardening controls",
+		Action:      new(ReportHardening),
+		RunInDryRun: true,
+	}
+
+	hardening := h.KubeConf.Cluster.System.Hardening
+	if hardening.Applies(kubekeyapiv1alpha2.HardeningSSHD) {
+		h.Tasks = append(h.Tasks, hardenSSHD)
+	}
+	if hardening.Applies(kubekeyapiv1alpha2.HardeningAuditd) {
+		h.Tasks = append(h.Tasks, configureAuditd)
+	}
+	if hardening.Applies(kubekeyapiv1alpha2.HardeningPasswordPolicy) {
+		h.Tasks = append(h.Tasks, configurePasswordPolicy)
+	}
+	h.Tasks = append(h.Tasks, report)
+}
@@ -0,0 +1,308 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package hardening
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/hardening/templates"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+const (
+	controlsCacheKey = "hardeningControls"
+	// ReportFile is the report of the hardening in the work dir of the cluster.
+	ReportFile = "hardening.json"
+
+	StatusApplied   = "applied"
+	StatusCompliant = "compliant"
+	StatusSkipped   = "skipped"
+
+	sshdDropInDir = "/etc/ssh/sshd_config.d"
+	auditRulesDir = "/etc/audit/rules.d"
+	loginDefs     = "/etc/login.defs"
+	pwqualityConf = "/etc/security/pwquality.conf"
+)
+
+// ControlResult is the result of a hardening control on a node.
+type ControlResult struct {
+	Host    string `json:"host"`
+	Control string `json:"control"`
+	Status  string `json:"status"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// record records the result of the control on the node for the report.
+func record(runtime connector.Runtime, control, status, detail string) {
+	host := runtime.RemoteHost()
+	var results []ControlResult
+	if v, ok := host.GetCache().Get(controlsCacheKey); ok {
+		results = v.([]ControlResult)
+	}
+	results = append(results, ControlResult{Host: host.GetName(), Control: control, Status: status, Detail: detail})
+	host.GetCache().Set(controlsCacheKey, results)
+}
+
+// status returns the status of a control which is enforced on the node, the changes are unknown in the dry-run.
+func status(runtime connector.Runtime, changed bool) string {
+	if changed || connector.IsDryRun(runtime.GetConnector()) {
+		return StatusApplied
+	}
+	return StatusCompliant
+}
+
+// writeFile writes the content to the dst on the node, and returns whether the dst is changed.
+func writeFile(runtime connector.Runtime, name, dst, content string) (bool, error) {
+	before, _ := runtime.GetRunner().SudoCmd(fmt.Sprintf("cat %s", dst), false)
+	if err := action.WriteRemoteFile(runtime, name, dst, content); err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(before) != strings.TrimSpace(content), nil
+}
+
+// HardenSSHD writes the sshd drop-in of the hardening, and reloads sshd after the config is validated. The root login
+// is forbidden unless KubeKey logs in as root, in which case only the password login of root is forbidden.
+type HardenSSHD struct {
+	common.KubeAction
+}
+
+func (h *HardenSSHD) Execute(runtime connector.Runtime) error {
+	if _, err := runtime.GetRunner().SudoCmd("test -f /etc/ssh/sshd_config", false); err != nil {
+		record(runtime, kubekeyapiv1alpha2.HardeningSSHD, StatusSkipped, "sshd is not installed")
+		return nil
+	}
+
+	permit, detail := permitRootLogin(runtime.RemoteHost())
+	content, err := util.RenderWith(templates.SSHD, util.Data{"PermitRootLogin": permit}, util.RenderOptions{Strict: runtime.GetStrictRender()})
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "render the sshd drop-in failed")
+	}
+
+	dst := filepath.Join(sshdDropInDir, templates.SSHD.Name())
+	if detail == "" {
+		detail = dst
+	}
+	changed, err := WriteSSHDDropIn(runtime, "sshd-"+templates.SSHD.Name(), templates.SSHD.Name(), content)
+	if errors.Is(err, ErrSSHDDropInUnsupported) {
+		record(runtime, kubekeyapiv1alpha2.HardeningSSHD, StatusSkipped, err.Error())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !changed {
+		record(runtime, kubekeyapiv1alpha2.HardeningSSHD, StatusCompliant, detail)
+		return nil
+	}
+	record(runtime, kubekeyapiv1alpha2.HardeningSSHD, status(runtime, true), detail)
+	return nil
+}
+
+// ErrSSHDDropInUnsupported is returned by WriteSSHDDropIn if sshd doesn't support the drop-ins of sshd_config.d.
+var ErrSSHDDropInUnsupported = errors.New("sshd doesn't support the drop-ins of sshd_config.d")
+
+// WriteSSHDDropIn writes the content to the sshd drop-in of the file name in sshd_config.d, the name is the one of the
+// copy in the work dir of the host. The drop-ins are included in the sshd_config if they aren't, and sshd is reloaded
+// after the config is validated, a broken config is rolled back. It returns whether the config is changed.
+func WriteSSHDDropIn(runtime connector.Runtime, name, file, content string) (bool, error) {
+	// the drop-ins are only read if the sshd_config includes them, which is the default since OpenSSH 8.2
+	includeAdded := false
+	if _, err := runtime.GetRunner().SudoCmd(
+		"grep -Eq '^[[:space:]]*Include[[:space:]]+/etc/ssh/sshd_config.d/' /etc/ssh/sshd_config", false); err != nil {
+		if _, err := runtime.GetRunner().SudoCmd(
+			"sed -i '1i Include /etc/ssh/sshd_config.d/*.conf' /etc/ssh/sshd_config", false); err != nil {
+			return false, errors.Wrap(errors.WithStack(err), "include the sshd drop-ins failed")
+		}
+		includeAdded = true
+	}
+	dst := filepath.Join(sshdDropInDir, file)
+	changed, err := writeFile(runtime, name, dst, content)
+	if err != nil {
+		return false, err
+	}
+	if !changed && !includeAdded {
+		return false, nil
+	}
+
+	// a broken config would lock everyone out at the next restart of sshd
+	if _, err := runtime.GetRunner().SudoCmd("/usr/sbin/sshd -t", false); err != nil {
+		rollbackCmd := fmt.Sprintf("rm -f %s", dst)
+		if includeAdded {
+			rollbackCmd += " && sed -i '1{/^Include \\/etc\\/ssh\\/sshd_config.d\\/\\*.conf$/d}' /etc/ssh/sshd_config"
+		}
+		if _, rollbackErr := runtime.GetRunner().SudoCmd(rollbackCmd, false); rollbackErr != nil {
+			logger.Log.Warnf("roll back the sshd config on %s failed: %v", runtime.RemoteHost().GetName(), rollbackErr)
+		}
+		if includeAdded {
+			return false, ErrSSHDDropInUnsupported
+		}
+		return false, errors.Wrapf(errors.WithStack(err), "validate the sshd config with %s failed", file)
+	}
+	if _, err := runtime.GetRunner().SudoCmd(
+		"systemctl try-reload-or-restart sshd 2>/dev/null || systemctl try-reload-or-restart ssh", false); err != nil {
+		return false, errors.Wrap(errors.WithStack(err), "reload sshd failed")
+	}
+	return true, nil
+}
+
+// permitRootLogin returns the PermitRootLogin of the node, which must keep the login of KubeKey, and the reason if
+// the root login is kept.
+func permitRootLogin(host connector.Host) (string, string) {
+	switch {
+	case host.GetUser() != "root":
+		return "no", ""
+	case host.GetPassword() == "":
+		return "prohibit-password", ""
+	default:
+		return "", "PermitRootLogin is kept, KubeKey logs in as root with a password"
+	}
+}
+
+// ConfigureAuditRules writes the audit rules of the hardening and loads them, auditd is enabled if it's installed.
+type ConfigureAuditRules struct {
+	common.KubeAction
+}
+
+func (c *ConfigureAuditRules) Execute(runtime connector.Runtime) error {
+	if _, err := runtime.GetRunner().SudoCmd("command -v auditctl", false); err != nil {
+		record(runtime, kubekeyapiv1alpha2.HardeningAuditd, StatusSkipped, "auditd is not installed")
+		return nil
+	}
+
+	content, err := util.RenderWith(templates.AuditRules, util.Data{}, util.RenderOptions{Strict: runtime.GetStrictRender()})
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "render the audit rules failed")
+	}
+	dst := filepath.Join(auditRulesDir, templates.AuditRules.Name())
+	changed, err := writeFile(runtime, templates.AuditRules.Name(), dst, content)
+	if err != nil {
+		return err
+	}
+	if _, err := runtime.GetRunner().SudoCmd("systemctl is-active --quiet auditd || systemctl enable --now auditd", false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "start auditd failed")
+	}
+	detail := dst
+	if changed {
+		// the immutable rules can't be changed until the next boot
+		loadCmd := fmt.Sprintf("if auditctl -s | grep -q '^enabled 2'; then echo immutable; "+
+			"elif command -v augenrules >/dev/null; then augenrules --load; else auditctl -R %s; fi", dst)
+		output, err := runtime.GetRunner().SudoCmd(loadCmd, false)
+		if err != nil {
+			return errors.Wrap(errors.WithStack(err), "load the audit rules failed")
+		}
+		if strings.Contains(output, "immutable") {
+			detail = "the audit rules are immutable, they are loaded at the next boot"
+		}
+	}
+	record(runtime, kubekeyapiv1alpha2.HardeningAuditd, status(runtime, changed), detail)
+	return nil
+}
+
+// ConfigurePasswordPolicy sets the password aging of the new users in login.defs, and the complexity of the new
+// passwords in pwquality.conf if libpwquality is installed.
+type ConfigurePasswordPolicy struct {
+	common.KubeAction
+}
+
+func (c *ConfigurePasswordPolicy) Execute(runtime connector.Runtime) error {
+	hardening := c.KubeConf.Cluster.System.Hardening
+	var cmds []string
+	for _, kv := range [][2]string{
+		{"PASS_MAX_DAYS", fmt.Sprint(hardening.MaxDays())},
+		{"PASS_MIN_DAYS", "1"},
+		{"PASS_WARN_AGE", "7"},
+	} {
+		cmds = append(cmds, setCmd(loginDefs, kv[0], " ", kv[1]))
+	}
+
+	detail := ""
+	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("test -f %s", pwqualityConf), false); err == nil {
+		cmds = append(cmds,
+			setCmd(pwqualityConf, "minlen", " = ", fmt.Sprint(hardening.MinLength())),
+			setCmd(pwqualityConf, "minclass", " = ", "3"))
+	} else {
+		detail = "pwquality is not installed, only the password aging is set"
+	}
+
+	output, err := runtime.GetRunner().SudoCmd(strings.Join(cmds, "; "), false)
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "set the password policy failed")
+	}
+	record(runtime, kubekeyapiv1alpha2.HardeningPasswordPolicy, status(runtime, strings.Contains(output, "changed")), detail)
+	return nil
+}
+
+// setCmd returns the command which sets the key of the config file to the value unless it's set, and prints changed
+// if the file is changed. The commented defaults are kept.
+func setCmd(file, key, sep, value string) string {
+	line := key + sep + value
+	return fmt.Sprintf("if ! grep -qFx '%[3]s' %[1]s; then sed -i '/^%[2]s[[:space:]=]/d' %[1]s && echo '%[3]s' >> %[1]s && echo changed; fi",
+		file, key, line)
+}
+
+// ReportHardening prints the result of each control on each node, and writes it to the report in the work dir of
+// the cluster.
+type ReportHardening struct {
+	common.KubeAction
+}
+
+func (r *ReportHardening) Execute(runtime connector.Runtime) error {
+	var results []ControlResult
+	for _, host := range runtime.GetAllHosts() {
+		if v, ok := host.GetCache().Get(controlsCacheKey); ok {
+			results = append(results, v.([]ControlResult)...)
+		}
+	}
+
+	content, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "encode the hardening report failed")
+	}
+	reportFile := filepath.Join(runtime.GetClusterWorkDir(), ReportFile)
+	if err := util.WriteFile(reportFile, content); err != nil {
+		return errors.Wrap(errors.WithStack(err), "write the hardening report failed")
+	}
+	logger.Log.Infof("The hardening report is written to %s", reportFile)
+	return Print(os.Stdout, results)
+}
+
+// Print prints the results of the controls as a table.
+func Print(w io.Writer, results []ControlResult) error {
+	tw := tabwriter.NewWriter(w, 10, 4, 3, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "HOST\tCONTROL\tSTATUS\tDETAIL")
+	for _, result := range results {
+		detail := result.Detail
+		if detail == "" {
+			detail = "<none>"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", result.Host, result.Control, result.Status, detail)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,61 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package hardening
+
+import (
+	"bytes"
+	"testing"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+func TestPermitRootLogin(t *testing.T) {
+	tests := []struct {
+		name string
+		host *connector.BaseHost
+		want string
+	}{
+		{name: "non-root user", host: &connector.BaseHost{User: "ubuntu", Password: "secret"}, want: "no"},
+		{name: "root with a key", host: &connector.BaseHost{User: "root", PrivateKeyPath: "~/.ssh/id_ed25519"}, want: "prohibit-password"},
+		{name: "root with a password", host: &connector.BaseHost{User: "root", Password: "secret"}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := permitRootLogin(tt.host); got != tt.want {
+				t.Errorf("permitRootLogin() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrint(t *testing.T) {
+	var buf bytes.Buffer
+	results := []ControlResult{
+		{Host: "node1", Control: kubekeyapiv1alpha2.HardeningSSHD, Status: StatusApplied},
+		{Host: "node1", Control: kubekeyapiv1alpha2.HardeningAuditd, Status: StatusSkipped, Detail: "auditd is not installed"},
+	}
+	if err := Print(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	want := "HOST      CONTROL   STATUS    DETAIL\n" +
+		"node1     sshd      applied   <none>\n" +
+		"node1     auditd    skipped   auditd is not installed\n"
+	if buf.String() != want {
+		t.Errorf("Print() = \n%s, want \n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,51 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package templates
+
+import (
+	"text/template"
+
+	"github.com/lithammer/dedent"
+)
+
+// SSHD is the sshd drop-in of the baseline hardening. sshd uses the first value of a keyword, so it's named to be
+// included before the other drop-ins.
+var SSHD = template.Must(template.New("00-kubekey-hardening.conf").Parse(
+	dedent.Dedent(`# The baseline hardening of KubeKey
+PermitEmptyPasswords no
+{{- if .PermitRootLogin }}
+PermitRootLogin {{ .PermitRootLogin }}
+{{- end }}
+MaxAuthTries 4
+LoginGraceTime 60
+ClientAliveInterval 300
+ClientAliveCountMax 3
+IgnoreRhosts yes
+HostbasedAuthentication no
+X11Forwarding no
+    `)))
+
+// AuditRules are the audit rules of the baseline hardening, which record the writes to and the execs in
+// /etc/kubernetes, and the changes of the sshd config and the password policy.
+var AuditRules = template.Must(template.New("kubekey.rules").Parse(
+	dedent.Dedent(`## The baseline hardening of KubeKey
+-w /etc/kubernetes/ -p wxa -k kubekey-kubernetes
+-w /etc/ssh/sshd_config -p wa -k kubekey-sshd
+-w /etc/ssh/sshd_config.d/ -p wa -k kubekey-sshd
+-w /etc/login.defs -p wa -k kubekey-password-policy
+-w /etc/security/pwquality.conf -p wa -k kubekey-password-policy
+    `)))
@@ -0,0 +1,110 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package os
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	versionutil "k8s.io/apimachinery/pkg/util/version"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+// cgroupV2GA is the Kubernetes version since which cgroup v2 is GA.
+var cgroupV2GA = versionutil.MustParseGeneric("v1.25.0")
+
+// hostCgroups are the cgroup facts of a host.
+type hostCgroups struct {
+	Name    string
+	Version int
+	Systemd bool
+}
+
+// chooseCgroupDriver returns the cgroup driver of the container runtime and kubelet of the hosts, and the warnings of
+// the inconsistent behaviors of the hosts. The driver is systemd if systemd is the init of the hosts, which delegates
+// the cgroups of cgroup v2 to the containers, or cgroupfs otherwise. The kubelet config is shared by the nodes, so the
+// hosts with and without systemd can't be in a cluster.
+func chooseCgroupDriver(hosts []hostCgroups, kubeVersion string) (string, []string, error) {
+	var systemd, others, v1, v2 []string
+	for _, h := range hosts {
+		if h.Systemd {
+			systemd = append(systemd, h.Name)
+		} else {
+			others = append(others, h.Name)
+		}
+		if h.Version == 2 {
+			v2 = append(v2, h.Name)
+		} else {
+			v1 = append(v1, h.Name)
+		}
+	}
+	if len(systemd) > 0 && len(others) > 0 {
+		return "", nil, errors.Errorf("the hosts %s run systemd and %s don't, which require the %s and the %s cgroup drivers, "+
+			"but kubelet config is shared by the nodes", strings.Join(systemd, ", "), strings.Join(others, ", "),
+			common.CgroupDriverSystemd, common.CgroupDriverCgroupfs)
+	}
+	driver := common.CgroupDriverSystemd
+	if len(hosts) > 0 && len(systemd) == 0 {
+		driver = common.CgroupDriverCgroupfs
+	}
+
+	var warnings []string
+	if len(v1) > 0 && len(v2) > 0 {
+		warnings = append(warnings, fmt.Sprintf("the hosts %s use cgroup v1 and %s use cgroup v2, the resource accounting, "+
+			"the memory QoS and the metrics of the pods differ between them", strings.Join(v1, ", "), strings.Join(v2, ", ")))
+	}
+	if v, err := versionutil.ParseGeneric(kubeVersion); err == nil && len(v2) > 0 && v.LessThan(cgroupV2GA) {
+		warnings = append(warnings, fmt.Sprintf("the hosts %s use cgroup v2, which is GA since Kubernetes v1.25, "+
+			"Kubernetes %s supports it as beta", strings.Join(v2, ", "), kubeVersion))
+	}
+	return driver, warnings, nil
+}
+
+// ChooseCgroupDriver chooses the cgroup driver of the container runtime and kubelet by the cgroup facts of the
+// kubernetes nodes, and warns of the inconsistent behaviors of the nodes. The hosts without the facts are ignored.
+type ChooseCgroupDriver struct {
+	common.KubeAction
+}
+
+func (c *ChooseCgroupDriver) Execute(runtime connector.Runtime) error {
+	var hosts []hostCgroups
+	for _, host := range runtime.GetHostsByRole(common.K8s) {
+		version, ok := host.GetCache().GetMustInt(CgroupVersion)
+		if !ok {
+			continue
+		}
+		systemd, _ := host.GetCache().GetMustBool(Systemd)
+		hosts = append(hosts, hostCgroups{Name: host.GetName(), Version: version, Systemd: systemd})
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Name < hosts[j].Name })
+
+	driver, warnings, err := chooseCgroupDriver(hosts, c.KubeConf.Cluster.Kubernetes.Version)
+	if err != nil {
+		return err
+	}
+	for _, w := range warnings {
+		logger.Log.Warn(w)
+	}
+	logger.Log.Infof("the container runtime and kubelet use the %s cgroup driver", driver)
+	c.PipelineCache.Set(common.CgroupDriver, driver)
+	return nil
+}
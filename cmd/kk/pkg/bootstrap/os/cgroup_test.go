@@ -0,0 +1,48 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package os
+
+import "testing"
+
+func TestChooseCgroupDriver(t *testing.T) {
+	tests := []struct {
+		name     string
+		hosts    []hostCgroups
+		version  string
+		want     string
+		warnings int
+		wantErr  bool
+	}{
+		{name: "no facts", version: "v1.26.0", want: "systemd"},
+		{name: "cgroup v2 with systemd", hosts: []hostCgroups{{"node1", 2, true}, {"node2", 2, true}}, version: "v1.26.0", want: "systemd"},
+		{name: "cgroup v1 without systemd", hosts: []hostCgroups{{"node1", 1, false}}, version: "v1.26.0", want: "cgroupfs"},
+		{name: "mixed cgroup versions", hosts: []hostCgroups{{"node1", 1, true}, {"node2", 2, true}}, version: "v1.26.0", want: "systemd", warnings: 1},
+		{name: "cgroup v2 before GA", hosts: []hostCgroups{{"node1", 1, true}, {"node2", 2, true}}, version: "v1.23.10", want: "systemd", warnings: 2},
+		{name: "mixed inits", hosts: []hostCgroups{{"node1", 2, true}, {"node2", 1, false}}, version: "v1.26.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warnings, err := chooseCgroupDriver(tt.hosts, tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("chooseCgroupDriver() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want || len(warnings) != tt.warnings {
+				t.Errorf("chooseCgroupDriver() = %s, %v, want %s with %d warnings", got, warnings, tt.want, tt.warnings)
+			}
+		})
+	}
+}
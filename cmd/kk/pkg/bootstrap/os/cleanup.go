@@ -0,0 +1,147 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package os
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CleanupLevel is how deep a deleted cluster is cleaned up from the nodes, each level includes the previous ones.
+type CleanupLevel int
+
+const (
+	// CleanupDefault keeps the cleanup of the previous versions: reset kubeadm and wipe the data, the container
+	// runtime is only removed with --all.
+	CleanupDefault CleanupLevel = iota
+	// CleanupReset only resets kubeadm.
+	CleanupReset
+	// CleanupRuntime also removes the container runtime and the binaries.
+	CleanupRuntime
+	// CleanupData also wipes the data dirs, the CNI interfaces and the iptables rules.
+	CleanupData
+)
+
+var cleanupLevels = map[string]CleanupLevel{
+	"":        CleanupDefault,
+	"reset":   CleanupReset,
+	"runtime": CleanupRuntime,
+	"data":    CleanupData,
+}
+
+// ParseCleanupLevel parses the level of --level, the empty string is the default cleanup.
+func ParseCleanupLevel(s string) (CleanupLevel, error) {
+	level, ok := cleanupLevels[strings.ToLower(s)]
+	if !ok {
+		return 0, errors.Errorf("invalid cleanup level %s, supported: reset, runtime, data", s)
+	}
+	return level, nil
+}
+
+// RemovesRuntime returns whether the container runtime and the binaries are removed.
+func (l CleanupLevel) RemovesRuntime() bool {
+	return l >= CleanupRuntime
+}
+
+// WipesData returns whether the data dirs, the CNI interfaces and the iptables rules are wiped.
+func (l CleanupLevel) WipesData() bool {
+	return l == CleanupDefault || l >= CleanupData
+}
+
+var (
+	// binaryFiles are the binaries installed by KubeKey besides the container runtime.
+	binaryFiles = []string{
+		"/usr/local/bin/kubelet",
+		"/usr/local/bin/kubeadm",
+		"/usr/local/bin/kubectl",
+		"/usr/local/bin/helm",
+		"/usr/local/bin/crictl",
+		"/usr/local/bin/etcdctl",
+		"/usr/local/bin/kube-scripts",
+		"/usr/bin/kubelet",
+		"/opt/cni/bin",
+	}
+	// runtimeServices are the container runtimes KubeKey installs.
+	runtimeServices = []string{"containerd", "docker", "cri-docker"}
+	// kubePorts are the ports of the control plane and the kubelet.
+	kubePorts = []string{"6443", "10250", "10257", "10259"}
+	// cniInterfaces match the interfaces created by kube-proxy, nodelocaldns and the CNI plugins.
+	cniInterfaces = `cni0|flannel[.-].*|cilium_.*|vxlan(-v6)?\.calico|kube-ipvs0|nodelocaldns|cali[a-f0-9]+`
+	// kubeChains match the iptables chains created by kube-proxy and the CNI plugins.
+	kubeChains = `KUBE|CALI|FLANNEL|CILIUM`
+)
+
+// cleanupCheck is a command printing what is left on a node, nothing if the node is clean. The commands are run
+// by SudoCmd in double quotes, so their double quotes and dollar signs are escaped.
+type cleanupCheck struct {
+	Name string
+	Cmd  string
+}
+
+// existingFiles returns the command printing the files which exist, the patterns of the contents of a dir are
+// checked as the dir being empty.
+func existingFiles(files []string) string {
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, strings.TrimSuffix(f, "/*"))
+	}
+	return fmt.Sprintf(`for f in %s; do if [ -d \"\$f\" ]; then [ -n \"\$(ls -A \"\$f\")\" ] && echo \"\$f\"; elif [ -e \"\$f\" ]; then echo \"\$f\"; fi; done; true`,
+		strings.Join(paths, " "))
+}
+
+// listeningPorts returns the command printing which of the ports are listened on.
+func listeningPorts(ports []string) string {
+	return fmt.Sprintf(`ss -Hltn 2>/dev/null | awk '{print \$4}' | grep -E ':(%s)\$' || true`, strings.Join(ports, "|"))
+}
+
+// cleanupChecks returns the checks of the nodes cleaned up to the level.
+func cleanupChecks(level CleanupLevel) []cleanupCheck {
+	checks := []cleanupCheck{
+		{Name: "kubelet", Cmd: `systemctl is-active kubelet 2>/dev/null | grep -x active || true`},
+		{Name: "static pods", Cmd: `ls -A /etc/kubernetes/manifests 2>/dev/null || true`},
+		{Name: "ports", Cmd: listeningPorts(kubePorts)},
+	}
+	if level.RemovesRuntime() {
+		checks = append(checks,
+			cleanupCheck{Name: "container runtime", Cmd: fmt.Sprintf(`for s in %s; do systemctl is-active \"\$s\" >/dev/null 2>&1 && echo \"\$s\"; done; true`,
+				strings.Join(runtimeServices, " "))},
+			cleanupCheck{Name: "binaries", Cmd: existingFiles(binaryFiles)},
+		)
+	}
+	if level.WipesData() {
+		checks = append(checks,
+			cleanupCheck{Name: "files", Cmd: existingFiles(clusterFiles)},
+			cleanupCheck{Name: "interfaces", Cmd: fmt.Sprintf(`ip -o link show 2>/dev/null | awk -F': ' '{print \$2}' | cut -d@ -f1 | grep -E '^(%s)\$' || true`, cniInterfaces)},
+			cleanupCheck{Name: "iptables", Cmd: fmt.Sprintf(`iptables-save 2>/dev/null | grep -E '^:(%s)-' | cut -d' ' -f1 | tr -d ':' || true`, kubeChains)},
+		)
+	}
+	return checks
+}
+
+// etcdCleanupChecks returns the checks of the etcd nodes, etcd is removed with the data.
+func etcdCleanupChecks(level CleanupLevel) []cleanupCheck {
+	if !level.WipesData() {
+		return nil
+	}
+	return []cleanupCheck{
+		{Name: "etcd", Cmd: `systemctl is-active etcd 2>/dev/null | grep -x active || true`},
+		{Name: "etcd files", Cmd: existingFiles(etcdFiles)},
+		{Name: "etcd ports", Cmd: listeningPorts([]string{"2379", "2380"})},
+	}
+}
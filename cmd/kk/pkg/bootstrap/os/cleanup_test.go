@@ -0,0 +1,93 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package os
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+func TestCleanupChecks(t *testing.T) {
+	tests := []struct {
+		level string
+		want  []string
+		etcd  bool
+	}{
+		{level: "", want: []string{"kubelet", "static pods", "ports", "files", "interfaces", "iptables"}, etcd: true},
+		{level: "reset", want: []string{"kubelet", "static pods", "ports"}},
+		{level: "runtime", want: []string{"kubelet", "static pods", "ports", "container runtime", "binaries"}},
+		{level: "Data", want: []string{"kubelet", "static pods", "ports", "container runtime", "binaries", "files", "interfaces", "iptables"}, etcd: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			level, err := ParseCleanupLevel(tt.level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, check := range cleanupChecks(level) {
+				names = append(names, check.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("cleanupChecks(%s) = %v, want %v", tt.level, names, tt.want)
+			}
+			if got := len(etcdCleanupChecks(level)) > 0; got != tt.etcd {
+				t.Errorf("etcdCleanupChecks(%s) = %v, want %v", tt.level, got, tt.etcd)
+			}
+		})
+	}
+
+	if _, err := ParseCleanupLevel("all"); err == nil {
+		t.Error("ParseCleanupLevel(all) should fail")
+	}
+}
+
+func TestExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"empty", "full"} {
+		if err := os.Mkdir(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []string{"file", "full/file"} {
+		if err := os.WriteFile(filepath.Join(dir, f), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := existingFiles([]string{
+		filepath.Join(dir, "file"),
+		filepath.Join(dir, "missing"),
+		filepath.Join(dir, "empty/*"),
+		filepath.Join(dir, "full/*"),
+	})
+	// run in double quotes by bash as SudoCmd does, without sudo
+	out, err := exec.Command("/bin/bash", "-c", strings.TrimPrefix(connector.SudoPrefix(cmd), "sudo -E ")).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	want := filepath.Join(dir, "file") + "\n" + filepath.Join(dir, "full") + "\n"
+	if string(out) != want {
+		t.Errorf("existingFiles() printed %q, want %q", out, want)
+	}
+}
@@ -16,6 +16,46 @@
 
 package os
 
+import (
+	kubekeyv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/os/repository"
+)
+
 const (
 	Release = "release"
+	// SudoNoPasswd is the key of whether the login user has NOPASSWD sudo in the host cache.
+	SudoNoPasswd = "sudoNoPasswd"
+	// CPUs and Memory are the keys of the number of the processors and the total memory in bytes in the host cache.
+	CPUs   = "cpus"
+	Memory = "memory"
+	// RepositoryPath is the key of the remote path of the local repository in the host cache.
+	RepositoryPath = "repositoryPath"
+	// CgroupVersion and Systemd are the keys of the version of the cgroup hierarchy and whether systemd is the init
+	// in the host cache.
+	CgroupVersion = "cgroupVersion"
+	Systemd       = "systemd"
 )
+
+// dependencyCommands are the commands required by kubelet and kube-proxy on each node.
+var dependencyCommands = []string{"socat", "conntrack", "ebtables", "ipset"}
+
+// storagePackages are the packages required on each kubernetes node by the storage providers, by the distro family.
+var storagePackages = map[string]map[string][]string{
+	kubekeyv1alpha2.StorageLonghorn: {
+		repository.FamilyDebian: {"open-iscsi", "nfs-common"},
+		repository.FamilyRHEL:   {"iscsi-initiator-utils", "nfs-utils"},
+		repository.FamilySUSE:   {"open-iscsi", "nfs-client"},
+		repository.FamilyAlpine: {"open-iscsi", "nfs-utils"},
+	},
+	kubekeyv1alpha2.StorageNFS: {
+		repository.FamilyDebian: {"nfs-common"},
+		repository.FamilyRHEL:   {"nfs-utils"},
+		repository.FamilySUSE:   {"nfs-client"},
+		repository.FamilyAlpine: {"nfs-utils"},
+	},
+}
+
+// storageServices are the services enabled after the packages of the storage providers are installed.
+var storageServices = map[string][]string{
+	kubekeyv1alpha2.StorageLonghorn: {"iscsid"},
+}
@@ -25,6 +25,8 @@ import (
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/prepare"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/facts"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/topology"
 )
 
 type ConfigureOSModule struct {
@@ -38,14 +40,23 @@ func (c *ConfigureOSModule) IsSkip() bool {
 
 func (c *ConfigureOSModule) Init() {
 	c.Name = "ConfigureOSModule"
+	c.Tags = []string{"os"}
 	c.Desc = "Init os dependencies"
 
 	getOSData := &task.RemoteTask{
-		Name:     "GetOSData",
-		Desc:     "Get OS release",
-		Hosts:    c.Runtime.GetAllHosts(),
-		Action:   new(GetOSData),
-		Parallel: true,
+		Name:      "GetOSData",
+		Desc:      "Get OS release",
+		Hosts:     c.Runtime.GetAllHosts(),
+		Action:    new(GetOSData),
+		AlwaysRun: true,
+		Parallel:  true,
+	}
+
+	chooseCgroupDriver := &task.LocalTask{
+		Name:        "ChooseCgroupDriver",
+		Desc:        "Choose the cgroup driver by the cgroups of the nodes",
+		Action:      new(ChooseCgroupDriver),
+		RunInDryRun: true,
 	}
 
 	initOS := &task.RemoteTask{
@@ -56,6 +67,58 @@ func (c *ConfigureOSModule) Init() {
 		Parallel: true,
 	}
 
+	tagNode := &task.RemoteTask{
+		Name:  "TagNode",
+		Desc:  "Tag the node with the cluster and the KubeKey version",
+		Hosts: c.Runtime.GetAllHosts(),
+		Action: &action.Template{
+			Template: templates.OwnerTmpl,
+			Dst:      common.OwnerFile,
+			Data: util.Data{
+				"Owner": common.NewOwner(c.Runtime.GetObjName()),
+			},
+		},
+		Parallel: true,
+	}
+
+	installDependencies := &task.RemoteTask{
+		Name:  "InstallDependencies",
+		Desc:  "Install os dependencies by the distro family",
+		Hosts: c.Runtime.GetAllHosts(),
+		Prepare: &prepare.PrepareCollection{
+			new(InstallDependenciesCheck),
+			&facts.NotBaked{Step: facts.BakedDependencies},
+		},
+		Action:   new(NodeInstallDependencies),
+		Parallel: true,
+	}
+
+	installStoragePrerequisites := &task.RemoteTask{
+		Name:     "InstallStoragePrerequisites",
+		Desc:     "Install the packages required by the storage provider",
+		Hosts:    c.Runtime.GetHostsByRole(common.K8s),
+		Prepare:  new(StoragePrerequisitesCheck),
+		Action:   new(NodeInstallStoragePrerequisites),
+		Parallel: true,
+	}
+
+	configureSELinux := &task.RemoteTask{
+		Name:     "ConfigureSELinux",
+		Desc:     "Configure the SELinux mode of each node",
+		Hosts:    c.Runtime.GetAllHosts(),
+		Action:   new(NodeConfigureSELinux),
+		Parallel: true,
+	}
+
+	configureAppArmor := &task.RemoteTask{
+		Name:     "ConfigureAppArmor",
+		Desc:     "Configure AppArmor of each node",
+		Hosts:    c.Runtime.GetAllHosts(),
+		Prepare:  new(NodeConfigureAppArmorCheck),
+		Action:   new(NodeConfigureAppArmor),
+		Parallel: true,
+	}
+
 	GenerateScript := &task.RemoteTask{
 		Name:  "GenerateScript",
 		Desc:  "Generate init os script",
@@ -87,12 +150,38 @@ func (c *ConfigureOSModule) Init() {
 		Parallel: true,
 	}
 
+	configureTimezone := &task.RemoteTask{
+		Name:     "ConfigureTimezone",
+		Desc:     "configure the timezone for each node",
+		Hosts:    c.Runtime.GetAllHosts(),
+		Prepare:  new(NodeConfigureTimezoneCheck),
+		Action:   new(NodeConfigureTimezone),
+		Parallel: true,
+	}
+
+	configureLocale := &task.RemoteTask{
+		Name:     "ConfigureLocale",
+		Desc:     "configure the locale for each node",
+		Hosts:    c.Runtime.GetAllHosts(),
+		Prepare:  new(NodeConfigureLocaleCheck),
+		Action:   new(NodeConfigureLocale),
+		Parallel: true,
+	}
+
 	c.Tasks = []task.Interface{
 		getOSData,
+		chooseCgroupDriver,
 		initOS,
+		tagNode,
+		installDependencies,
+		installStoragePrerequisites,
+		configureSELinux,
+		configureAppArmor,
 		GenerateScript,
 		ExecScript,
 		ConfigureNtpServer,
+		configureTimezone,
+		configureLocale,
 	}
 }
 
@@ -149,6 +238,14 @@ func (c *ClearNodeOSModule) Init() {
 
 type ClearOSEnvironmentModule struct {
 	common.KubeModule
+	Skip bool
+	// RemoveManagedFiles removes all the files written onto the nodes by KubeKey, including the ones of the container
+	// runtime, so it is only set when the container runtime is removed.
+	RemoveManagedFiles bool
+}
+
+func (c *ClearOSEnvironmentModule) IsSkip() bool {
+	return c.Skip
 }
 
 func (c *ClearOSEnvironmentModule) Init() {
@@ -177,7 +274,7 @@ func (c *ClearOSEnvironmentModule) Init() {
 		Name:     "RemoveFiles",
 		Desc:     "Remove cluster files",
 		Hosts:    c.Runtime.GetHostsByRole(common.K8s),
-		Action:   new(RemoveFiles),
+		Action:   &RemoveFiles{RemoveManagedFiles: c.RemoveManagedFiles},
 		Parallel: true,
 	}
 
@@ -197,6 +294,71 @@ func (c *ClearOSEnvironmentModule) Init() {
 	}
 }
 
+type RemoveBinariesModule struct {
+	common.KubeModule
+	Skip bool
+}
+
+func (r *RemoveBinariesModule) IsSkip() bool {
+	return r.Skip
+}
+
+func (r *RemoveBinariesModule) Init() {
+	r.Name = "RemoveBinariesModule"
+	r.Desc = "Remove the binaries of the cluster"
+
+	remove := &task.RemoteTask{
+		Name:     "RemoveBinaries",
+		Desc:     "Remove kubelet, kubeadm, kubectl, helm, crictl and the CNI plugins",
+		Hosts:    r.Runtime.GetHostsByRole(common.K8s),
+		Action:   new(RemoveBinaries),
+		Parallel: true,
+	}
+
+	r.Tasks = []task.Interface{
+		remove,
+	}
+}
+
+// VerifyCleanupModule checks nothing of the deleted cluster is left on the nodes, down to the cleanup level.
+type VerifyCleanupModule struct {
+	common.KubeModule
+	Skip  bool
+	Level CleanupLevel
+}
+
+func (v *VerifyCleanupModule) IsSkip() bool {
+	return v.Skip
+}
+
+func (v *VerifyCleanupModule) Init() {
+	v.Name = "VerifyCleanupModule"
+	v.Desc = "Verify the nodes are clean"
+
+	verify := &task.RemoteTask{
+		Name:     "VerifyCleanup",
+		Desc:     "Verify nothing of the cluster is left on the node",
+		Hosts:    v.Runtime.GetHostsByRole(common.K8s),
+		Action:   &VerifyCleanup{Checks: cleanupChecks(v.Level)},
+		Parallel: true,
+	}
+
+	v.Tasks = []task.Interface{
+		verify,
+	}
+
+	if checks := etcdCleanupChecks(v.Level); len(checks) > 0 {
+		v.Tasks = append(v.Tasks, &task.RemoteTask{
+			Name:     "VerifyEtcdCleanup",
+			Desc:     "Verify nothing of etcd is left on the node",
+			Hosts:    v.Runtime.GetHostsByRole(common.ETCD),
+			Prepare:  new(EtcdTypeIsKubeKey),
+			Action:   &VerifyCleanup{Checks: checks},
+			Parallel: true,
+		})
+	}
+}
+
 type RepositoryOnlineModule struct {
 	common.KubeModule
 	Skip bool
@@ -208,22 +370,25 @@ func (r *RepositoryOnlineModule) IsSkip() bool {
 
 func (r *RepositoryOnlineModule) Init() {
 	r.Name = "RepositoryOnlineModule"
+	r.Tags = []string{"os", "packages"}
 
 	getOSData := &task.RemoteTask{
-		Name:     "GetOSData",
-		Desc:     "Get OS release",
-		Hosts:    r.Runtime.GetAllHosts(),
-		Action:   new(GetOSData),
-		Parallel: true,
+		Name:      "GetOSData",
+		Desc:      "Get OS release",
+		Hosts:     r.Runtime.GetAllHosts(),
+		Action:    new(GetOSData),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	newRepo := &task.RemoteTask{
-		Name:     "NewRepoClient",
-		Desc:     "New repository client",
-		Hosts:    r.Runtime.GetAllHosts(),
-		Action:   new(NewRepoClient),
-		Parallel: true,
-		Retry:    1,
+		Name:      "NewRepoClient",
+		Desc:      "New repository client",
+		Hosts:     r.Runtime.GetAllHosts(),
+		Action:    new(NewRepoClient),
+		AlwaysRun: true,
+		Parallel:  true,
+		Retry:     1,
 	}
 
 	install := &task.RemoteTask{
@@ -253,42 +418,48 @@ func (r *RepositoryModule) IsSkip() bool {
 
 func (r *RepositoryModule) Init() {
 	r.Name = "RepositoryModule"
+	r.Tags = []string{"os", "packages"}
 	r.Desc = "Install local repository"
 
 	getOSData := &task.RemoteTask{
-		Name:     "GetOSData",
-		Desc:     "Get OS release",
-		Hosts:    r.Runtime.GetAllHosts(),
-		Action:   new(GetOSData),
-		Parallel: true,
+		Name:      "GetOSData",
+		Desc:      "Get OS release",
+		Hosts:     r.Runtime.GetAllHosts(),
+		Action:    new(GetOSData),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	sync := &task.RemoteTask{
-		Name:     "SyncRepositoryISOFile",
-		Desc:     "Sync repository iso file to all nodes",
-		Hosts:    r.Runtime.GetAllHosts(),
-		Action:   new(SyncRepositoryFile),
-		Parallel: true,
-		Retry:    2,
+		Name:      "SyncRepositoryISOFile",
+		Desc:      "Sync repository package dir or iso file to all nodes",
+		Hosts:     topology.SortHosts(r.KubeConf.Cluster, r.Runtime.GetAllHosts()),
+		Throttle:  topology.Throttle(r.KubeConf.Cluster),
+		Action:    new(SyncRepositoryFile),
+		AlwaysRun: true,
+		Parallel:  true,
+		Retry:     2,
 	}
 
 	mount := &task.RemoteTask{
 		Name:     "MountISO",
 		Desc:     "Mount iso file",
 		Hosts:    r.Runtime.GetAllHosts(),
+		Prepare:  new(ISOSynced),
 		Action:   new(MountISO),
 		Parallel: true,
 		Retry:    1,
 	}
 
 	newRepo := &task.RemoteTask{
-		Name:     "NewRepoClient",
-		Desc:     "New repository client",
-		Hosts:    r.Runtime.GetAllHosts(),
-		Action:   new(NewRepoClient),
-		Parallel: true,
-		Retry:    1,
-		Rollback: new(RollbackUmount),
+		Name:      "NewRepoClient",
+		Desc:      "New repository client",
+		Hosts:     r.Runtime.GetAllHosts(),
+		Action:    new(NewRepoClient),
+		AlwaysRun: true,
+		Parallel:  true,
+		Retry:     1,
+		Rollback:  new(RollbackUmount),
 	}
 
 	backup := &task.RemoteTask{
@@ -334,6 +505,7 @@ func (r *RepositoryModule) Init() {
 		Name:     "UmountISO",
 		Desc:     "Umount ISO file",
 		Hosts:    r.Runtime.GetAllHosts(),
+		Prepare:  new(ISOSynced),
 		Action:   new(UmountISO),
 		Parallel: true,
 	}
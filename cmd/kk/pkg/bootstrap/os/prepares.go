@@ -27,12 +27,49 @@ type NodeConfigureNtpCheck struct {
 }
 
 func (n *NodeConfigureNtpCheck) PreCheck(_ connector.Runtime) (bool, error) {
-	// skip when both NtpServers and Timezone was not set in cluster config
-	if len(n.KubeConf.Cluster.System.NtpServers) == 0 && len(n.KubeConf.Cluster.System.Timezone) == 0 {
-		return false, nil
-	}
+	// skip when NtpServers was not set in cluster config
+	return len(n.KubeConf.Cluster.System.NtpServers) > 0, nil
+}
+
+type NodeConfigureTimezoneCheck struct {
+	common.KubePrepare
+}
+
+func (n *NodeConfigureTimezoneCheck) PreCheck(_ connector.Runtime) (bool, error) {
+	return n.KubeConf.Cluster.System.Timezone != "", nil
+}
+
+type NodeConfigureLocaleCheck struct {
+	common.KubePrepare
+}
+
+func (n *NodeConfigureLocaleCheck) PreCheck(_ connector.Runtime) (bool, error) {
+	return n.KubeConf.Cluster.System.Locale != "", nil
+}
+
+type NodeConfigureAppArmorCheck struct {
+	common.KubePrepare
+}
 
-	return true, nil
+func (n *NodeConfigureAppArmorCheck) PreCheck(_ connector.Runtime) (bool, error) {
+	return n.KubeConf.Cluster.System.AppArmor != "", nil
+}
+
+type InstallDependenciesCheck struct {
+	common.KubePrepare
+}
+
+func (i *InstallDependenciesCheck) PreCheck(_ connector.Runtime) (bool, error) {
+	return i.KubeConf.Cluster.System.InstallDependencies || i.KubeConf.Cluster.System.PackagesPath != "", nil
+}
+
+type StoragePrerequisitesCheck struct {
+	common.KubePrepare
+}
+
+func (s *StoragePrerequisitesCheck) PreCheck(_ connector.Runtime) (bool, error) {
+	_, ok := storagePackages[s.KubeConf.Cluster.Storage.Provider]
+	return ok, nil
 }
 
 type EtcdTypeIsKubeKey struct {
@@ -64,3 +101,13 @@ func (d *DeleteNode) PreCheck(runtime connector.Runtime) (bool, error) {
 
 	return false, nil
 }
+
+// ISOSynced checks the repository of the host is an iso file which needs to be mounted.
+type ISOSynced struct {
+	common.KubePrepare
+}
+
+func (i *ISOSynced) PreCheck(runtime connector.Runtime) (bool, error) {
+	_, ok := runtime.RemoteHost().GetCache().GetMustString("iso")
+	return ok, nil
+}
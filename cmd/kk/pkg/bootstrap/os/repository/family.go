@@ -0,0 +1,83 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kubesphere/kubekey/v3/util/osrelease"
+)
+
+const (
+	FamilyDebian = "debian"
+	FamilyRHEL   = "rhel"
+	FamilySUSE   = "suse"
+	FamilyAlpine = "alpine"
+)
+
+var familyIDs = map[string]string{
+	"ubuntu":              FamilyDebian,
+	"debian":              FamilyDebian,
+	"uos":                 FamilyDebian,
+	"centos":              FamilyRHEL,
+	"rhel":                FamilyRHEL,
+	"fedora":              FamilyRHEL,
+	"rocky":               FamilyRHEL,
+	"almalinux":           FamilyRHEL,
+	"ol":                  FamilyRHEL,
+	"amzn":                FamilyRHEL,
+	"openeuler":           FamilyRHEL,
+	"kylin":               FamilyRHEL,
+	"sles":                FamilySUSE,
+	"suse":                FamilySUSE,
+	"opensuse":            FamilySUSE,
+	"opensuse-leap":       FamilySUSE,
+	"opensuse-tumbleweed": FamilySUSE,
+	"alpine":              FamilyAlpine,
+}
+
+// Family returns the distro family of the os release, the ID is matched first and then the ID_LIKE.
+// An empty string is returned if the family is unknown.
+func Family(r *osrelease.Data) string {
+	if r == nil {
+		return ""
+	}
+	ids := append([]string{r.ID}, strings.Fields(r.IDLike)...)
+	for _, id := range ids {
+		if family, ok := familyIDs[strings.ToLower(strings.Trim(id, `"`))]; ok {
+			return family
+		}
+	}
+	return ""
+}
+
+// NewByFamily returns the package manager of the distro family.
+func NewByFamily(family string) (Interface, error) {
+	switch family {
+	case FamilyDebian:
+		return NewDeb(), nil
+	case FamilyRHEL:
+		return NewRPM(), nil
+	case FamilySUSE:
+		return NewZypper(), nil
+	case FamilyAlpine:
+		return NewApk(), nil
+	default:
+		return nil, fmt.Errorf("unsupported distro family %s", family)
+	}
+}
@@ -0,0 +1,64 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package repository
+
+import (
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/util/osrelease"
+)
+
+func TestFamily(t *testing.T) {
+	tests := []struct {
+		name    string
+		release *osrelease.Data
+		want    string
+	}{
+		{
+			name:    "ubuntu",
+			release: &osrelease.Data{ID: "ubuntu", IDLike: "debian"},
+			want:    FamilyDebian,
+		},
+		{
+			name:    "rocky by id like",
+			release: &osrelease.Data{ID: "rocky-custom", IDLike: "rhel centos fedora"},
+			want:    FamilyRHEL,
+		},
+		{
+			name:    "opensuse leap",
+			release: &osrelease.Data{ID: "opensuse-leap", IDLike: "suse opensuse"},
+			want:    FamilySUSE,
+		},
+		{
+			name:    "alpine",
+			release: &osrelease.Data{ID: "alpine"},
+			want:    FamilyAlpine,
+		},
+		{
+			name:    "unknown",
+			release: &osrelease.Data{ID: "gentoo"},
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Family(tt.release); got != tt.want {
+				t.Errorf("Family() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
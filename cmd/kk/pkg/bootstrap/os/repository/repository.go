@@ -29,6 +29,8 @@ type Interface interface {
 	Add(runtime connector.Runtime, path string) error
 	Update(runtime connector.Runtime) error
 	Install(runtime connector.Runtime, pkg ...string) error
+	// InstallOffline installs all the package files in the remote dir without any repository.
+	InstallOffline(runtime connector.Runtime, dir string) error
 	Reset(runtime connector.Runtime) error
 }
 
@@ -38,6 +40,10 @@ func New(os string) (Interface, error) {
 		return NewDeb(), nil
 	case "centos", "rhel":
 		return NewRPM(), nil
+	case "sles", "opensuse-leap", "opensuse-tumbleweed":
+		return NewZypper(), nil
+	case "alpine":
+		return NewApk(), nil
 	default:
 		return nil, fmt.Errorf("unsupported operation system %s", os)
 	}
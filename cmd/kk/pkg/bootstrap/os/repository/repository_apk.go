@@ -0,0 +1,92 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+type Apk struct {
+	backup bool
+}
+
+func NewApk() Interface {
+	return &Apk{}
+}
+
+func (a *Apk) Backup(runtime connector.Runtime) error {
+	if _, err := runtime.GetRunner().SudoCmd("mv /etc/apk/repositories /etc/apk/repositories.kubekey.bak", false); err != nil {
+		return err
+	}
+	a.backup = true
+	return nil
+}
+
+func (a *Apk) IsAlreadyBackUp() bool {
+	return a.backup
+}
+
+func (a *Apk) Add(runtime connector.Runtime, path string) error {
+	if !a.IsAlreadyBackUp() {
+		return fmt.Errorf("linux repository must be backuped before")
+	}
+
+	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("echo '%s' > /etc/apk/repositories", path), false); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (a *Apk) Update(runtime connector.Runtime) error {
+	if _, err := runtime.GetRunner().SudoCmd("apk update --allow-untrusted", true); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (a *Apk) Install(runtime connector.Runtime, pkg ...string) error {
+	defaultPkg := []string{"openssl", "socat", "conntrack-tools", "ipset", "ebtables", "chrony", "ipvsadm"}
+	if len(pkg) == 0 {
+		pkg = defaultPkg
+	} else {
+		pkg = append(pkg, defaultPkg...)
+	}
+
+	str := strings.Join(pkg, " ")
+	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("apk add --allow-untrusted %s", str), true); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (a *Apk) InstallOffline(runtime connector.Runtime, dir string) error {
+	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("apk add --allow-untrusted %s/*.apk", dir), true); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (a *Apk) Reset(runtime connector.Runtime) error {
+	if _, err := runtime.GetRunner().SudoCmd("mv /etc/apk/repositories.kubekey.bak /etc/apk/repositories", false); err != nil {
+		return err
+	}
+
+	return nil
+}
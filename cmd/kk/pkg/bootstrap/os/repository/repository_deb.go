@@ -23,6 +23,9 @@ import (
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
 )
 
+// DebianPackages are the packages KubeKey installs on every Debian based node.
+var DebianPackages = []string{"socat", "conntrack", "ipset", "ebtables", "chrony", "ipvsadm"}
+
 type Debian struct {
 	backup bool
 }
@@ -75,12 +78,7 @@ func (d *Debian) Update(runtime connector.Runtime) error {
 }
 
 func (d *Debian) Install(runtime connector.Runtime, pkg ...string) error {
-	defaultPkg := []string{"socat", "conntrack", "ipset", "ebtables", "chrony", "ipvsadm"}
-	if len(pkg) == 0 {
-		pkg = defaultPkg
-	} else {
-		pkg = append(pkg, defaultPkg...)
-	}
+	pkg = append(pkg, DebianPackages...)
 
 	str := strings.Join(pkg, " ")
 	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("apt install -y %s", str), true); err != nil {
@@ -89,6 +87,13 @@ func (d *Debian) Install(runtime connector.Runtime, pkg ...string) error {
 	return nil
 }
 
+func (d *Debian) InstallOffline(runtime connector.Runtime, dir string) error {
+	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("dpkg -i --force-depends %s/*.deb", dir), true); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (d *Debian) Reset(runtime connector.Runtime) error {
 	if _, err := runtime.GetRunner().SudoCmd("rm -rf /etc/apt/sources.list.d", false); err != nil {
 		return err
@@ -23,6 +23,9 @@ import (
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
 )
 
+// RPMPackages are the packages KubeKey installs on every RPM based node.
+var RPMPackages = []string{"openssl", "socat", "conntrack", "ipset", "ebtables", "chrony", "ipvsadm"}
+
 type RedhatPackageManager struct {
 	backup bool
 }
@@ -83,12 +86,7 @@ func (r *RedhatPackageManager) Update(runtime connector.Runtime) error {
 }
 
 func (r *RedhatPackageManager) Install(runtime connector.Runtime, pkg ...string) error {
-	defaultPkg := []string{"openssl", "socat", "conntrack", "ipset", "ebtables", "chrony", "ipvsadm"}
-	if len(pkg) == 0 {
-		pkg = defaultPkg
-	} else {
-		pkg = append(pkg, defaultPkg...)
-	}
+	pkg = append(pkg, RPMPackages...)
 
 	str := strings.Join(pkg, " ")
 	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("yum install -y %s", str), true); err != nil {
@@ -97,6 +95,13 @@ func (r *RedhatPackageManager) Install(runtime connector.Runtime, pkg ...string)
 	return nil
 }
 
+func (r *RedhatPackageManager) InstallOffline(runtime connector.Runtime, dir string) error {
+	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("rpm -Uvh --replacepkgs --nodeps %s/*.rpm", dir), true); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (r *RedhatPackageManager) Reset(runtime connector.Runtime) error {
 	if _, err := runtime.GetRunner().SudoCmd("rm -rf /etc/yum.repos.d", false); err != nil {
 		return err
@@ -0,0 +1,104 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+type Zypper struct {
+	backup bool
+}
+
+func NewZypper() Interface {
+	return &Zypper{}
+}
+
+func (z *Zypper) Backup(runtime connector.Runtime) error {
+	if _, err := runtime.GetRunner().SudoCmd("mv /etc/zypp/repos.d /etc/zypp/repos.d.kubekey.bak", false); err != nil {
+		return err
+	}
+
+	if _, err := runtime.GetRunner().SudoCmd("mkdir -p /etc/zypp/repos.d", false); err != nil {
+		return err
+	}
+	z.backup = true
+	return nil
+}
+
+func (z *Zypper) IsAlreadyBackUp() bool {
+	return z.backup
+}
+
+func (z *Zypper) Add(runtime connector.Runtime, path string) error {
+	if !z.IsAlreadyBackUp() {
+		return fmt.Errorf("linux repository must be backuped before")
+	}
+
+	if _, err := runtime.GetRunner().SudoCmd("rm -rf /etc/zypp/repos.d/*", false); err != nil {
+		return err
+	}
+
+	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("zypper addrepo --no-gpgcheck file://%s kubekey-local", path), true); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (z *Zypper) Update(runtime connector.Runtime) error {
+	if _, err := runtime.GetRunner().SudoCmd("zypper --non-interactive refresh", true); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (z *Zypper) Install(runtime connector.Runtime, pkg ...string) error {
+	defaultPkg := []string{"openssl", "socat", "conntrack-tools", "ipset", "ebtables", "chrony", "ipvsadm"}
+	if len(pkg) == 0 {
+		pkg = defaultPkg
+	} else {
+		pkg = append(pkg, defaultPkg...)
+	}
+
+	str := strings.Join(pkg, " ")
+	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("zypper --non-interactive install %s", str), true); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (z *Zypper) InstallOffline(runtime connector.Runtime, dir string) error {
+	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("zypper --non-interactive --no-gpg-checks install %s/*.rpm", dir), true); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (z *Zypper) Reset(runtime connector.Runtime) error {
+	if _, err := runtime.GetRunner().SudoCmd("rm -rf /etc/zypp/repos.d", false); err != nil {
+		return err
+	}
+
+	if _, err := runtime.GetRunner().SudoCmd("mv /etc/zypp/repos.d.kubekey.bak /etc/zypp/repos.d", false); err != nil {
+		return err
+	}
+
+	return nil
+}
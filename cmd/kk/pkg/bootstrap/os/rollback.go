@@ -33,6 +33,9 @@ type RollbackUmount struct {
 }
 
 func (r *RollbackUmount) Execute(runtime connector.Runtime, result *ending.ActionResult) error {
+	if _, ok := runtime.RemoteHost().GetCache().GetMustString("iso"); !ok {
+		return nil
+	}
 	mountPath := filepath.Join(common.TmpDir, "iso")
 	umountCmd := fmt.Sprintf("umount %s", mountPath)
 	if _, err := runtime.GetRunner().SudoCmd(umountCmd, false); err != nil {
@@ -0,0 +1,205 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package os
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	kubekeyv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/os/repository"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/util/osrelease"
+)
+
+// selinuxPackages are the packages of the SELinux policy of the containers and of semanage, by the distro family.
+var selinuxPackages = map[string][]string{
+	repository.FamilyRHEL: {"container-selinux", "policycoreutils-python-utils"},
+	repository.FamilySUSE: {"container-selinux", "policycoreutils-python-utils"},
+}
+
+// selinuxLabels are the SELinux types of the dirs on the nodes which are mounted into the containers by the control
+// plane and the network plugins.
+var selinuxLabels = []struct{ Path, Type string }{
+	{"/etc/kubernetes", "container_file_t"},
+	{"/var/lib/etcd", "container_file_t"},
+	{"/etc/cni/net.d", "container_file_t"},
+	{"/opt/cni/bin", "container_file_t"},
+	{"/var/lib/calico", "container_file_t"},
+}
+
+// apparmorPackages are the packages of the AppArmor parser and utils, by the distro family.
+var apparmorPackages = map[string][]string{
+	repository.FamilyDebian: {"apparmor", "apparmor-utils"},
+	repository.FamilySUSE:   {"apparmor-parser", "apparmor-utils"},
+	repository.FamilyAlpine: {"apparmor", "apparmor-utils"},
+}
+
+// NodeConfigureSELinux sets SELinux to the mode of system.selinux. In enforcing, the policy packages of the containers
+// are installed and the dirs of Kubernetes are labeled, a node where SELinux is disabled must be rebooted to relabel
+// its file systems before it can be enforced.
+type NodeConfigureSELinux struct {
+	common.KubeAction
+}
+
+func (n *NodeConfigureSELinux) Execute(runtime connector.Runtime) error {
+	host := runtime.RemoteHost()
+	mode := n.KubeConf.Cluster.System.SELinuxMode()
+	current := ""
+	if out, err := runtime.GetRunner().SudoCmd("if command -v getenforce > /dev/null 2>&1; then getenforce; fi", false); err == nil {
+		current = strings.ToLower(strings.TrimSpace(out))
+	}
+
+	if mode != kubekeyv1alpha2.SELinuxEnforcing {
+		if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf(
+			"if [ -f /etc/selinux/config ]; then sed -ri 's/^SELINUX=.*/SELINUX=%s/' /etc/selinux/config; fi", mode), false); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "set SELinux to %s failed", mode)
+		}
+		if current == kubekeyv1alpha2.SELinuxEnforcing {
+			if _, err := runtime.GetRunner().SudoCmd("setenforce 0", false); err != nil {
+				return errors.Wrap(errors.WithStack(err), "set SELinux to permissive failed")
+			}
+		}
+		return nil
+	}
+
+	release, ok := host.GetCache().Get(Release)
+	if !ok {
+		return errors.New("get os release failed by host cache")
+	}
+	r := release.(*osrelease.Data)
+	family := repository.Family(r)
+	pkgs, ok := selinuxPackages[family]
+	if !ok {
+		return errors.Errorf("SELinux enforcing is supported on the rhel and suse families, %s is %s", host.GetName(), r.ID)
+	}
+	if err := installMissing(runtime, family, pkgs, "rpm -q %s"); err != nil {
+		return err
+	}
+
+	if !connector.IsDryRun(runtime.GetConnector()) && current != kubekeyv1alpha2.SELinuxEnforcing &&
+		current != kubekeyv1alpha2.SELinuxPermissive {
+		if _, err := runtime.GetRunner().SudoCmd("sed -ri 's/^SELINUX=.*/SELINUX=enforcing/' /etc/selinux/config && touch /.autorelabel", false); err != nil {
+			return errors.Wrap(errors.WithStack(err), "set SELinux to enforcing failed")
+		}
+		return errors.Errorf("SELinux is disabled on %s, it's set to enforcing and the node must be rebooted to relabel the file systems", host.GetName())
+	}
+	if _, err := runtime.GetRunner().SudoCmd("sed -ri 's/^SELINUX=.*/SELINUX=enforcing/' /etc/selinux/config && setenforce 1", false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "set SELinux to enforcing failed")
+	}
+	for _, label := range selinuxLabels {
+		if _, err := runtime.GetRunner().SudoCmd(selinuxLabelCommand(label.Path, label.Type), false); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "label %s with %s failed", label.Path, label.Type)
+		}
+	}
+	return nil
+}
+
+// selinuxLabelCommand returns the command adding or modifying the file context of the dir and its files, and
+// relabeling them.
+func selinuxLabelCommand(path, typ string) string {
+	spec := path + "(/.*)?"
+	return fmt.Sprintf("mkdir -p %[1]s && "+
+		"(semanage fcontext -a -t %[2]s '%[3]s' 2>/dev/null || semanage fcontext -m -t %[2]s '%[3]s') && "+
+		"restorecon -R %[1]s", path, typ, spec)
+}
+
+// NodeConfigureAppArmor enables or disables AppArmor by system.apparmor. Disabling it unloads the profiles and stops
+// the service, the kernel keeps AppArmor until the node is booted with apparmor=0.
+type NodeConfigureAppArmor struct {
+	common.KubeAction
+}
+
+func (n *NodeConfigureAppArmor) Execute(runtime connector.Runtime) error {
+	host := runtime.RemoteHost()
+	release, ok := host.GetCache().Get(Release)
+	if !ok {
+		return errors.New("get os release failed by host cache")
+	}
+	r := release.(*osrelease.Data)
+	family := repository.Family(r)
+
+	if n.KubeConf.Cluster.System.AppArmor == kubekeyv1alpha2.AppArmorDisabled {
+		cmd := "if command -v aa-teardown > /dev/null 2>&1; then aa-teardown; fi; " +
+			"if systemctl list-unit-files apparmor.service > /dev/null 2>&1; then systemctl disable --now apparmor; fi"
+		if family == repository.FamilyAlpine {
+			cmd = "if [ -f /etc/init.d/apparmor ]; then rc-service apparmor stop; rc-update del apparmor boot; fi"
+		}
+		if _, err := runtime.GetRunner().SudoCmd(cmd, false); err != nil {
+			return errors.Wrap(errors.WithStack(err), "disable AppArmor failed")
+		}
+		logger.Log.Warnf("the AppArmor profiles are unloaded on %s, boot it with apparmor=0 to disable AppArmor in the kernel", host.GetName())
+		return nil
+	}
+
+	pkgs, ok := apparmorPackages[family]
+	if !ok {
+		return errors.Errorf("AppArmor is supported on the debian, suse and alpine families, %s is %s", host.GetName(), r.ID)
+	}
+	if !connector.IsDryRun(runtime.GetConnector()) {
+		out, _ := runtime.GetRunner().SudoCmd("cat /sys/module/apparmor/parameters/enabled", false)
+		if strings.TrimSpace(out) != "Y" {
+			return errors.Errorf("AppArmor is disabled in the kernel of %s, boot it with apparmor=1 security=apparmor", host.GetName())
+		}
+	}
+	check := "dpkg -s %s > /dev/null 2>&1"
+	switch family {
+	case repository.FamilySUSE:
+		check = "rpm -q %s"
+	case repository.FamilyAlpine:
+		check = "apk info -e %s"
+	}
+	if err := installMissing(runtime, family, pkgs, check); err != nil {
+		return err
+	}
+	cmd := "systemctl enable --now apparmor"
+	if family == repository.FamilyAlpine {
+		cmd = "rc-update add apparmor boot && rc-service apparmor start"
+	}
+	if _, err := runtime.GetRunner().SudoCmd(cmd, false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "enable AppArmor failed")
+	}
+	return nil
+}
+
+// installMissing installs the packages which the check, a command format taking the name of a package, fails for.
+func installMissing(runtime connector.Runtime, family string, pkgs []string, check string) error {
+	var missing []string
+	for _, pkg := range pkgs {
+		if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf(check, pkg), false); err != nil {
+			missing = append(missing, pkg)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	repo, err := repository.NewByFamily(family)
+	if err != nil {
+		return errors.Wrapf(errors.WithStack(err), "install the packages %v failed", missing)
+	}
+	if err := repo.Update(runtime); err != nil {
+		return errors.Wrap(errors.WithStack(err), "update repository failed")
+	}
+	if err := repo.Install(runtime, missing...); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "install the packages %v failed", missing)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package os
+
+import "testing"
+
+func TestSELinuxLabelCommand(t *testing.T) {
+	want := "mkdir -p /var/lib/etcd && " +
+		"(semanage fcontext -a -t container_file_t '/var/lib/etcd(/.*)?' 2>/dev/null || semanage fcontext -m -t container_file_t '/var/lib/etcd(/.*)?') && " +
+		"restorecon -R /var/lib/etcd"
+	if got := selinuxLabelCommand("/var/lib/etcd", "container_file_t"); got != want {
+		t.Errorf("selinuxLabelCommand() = %s, want %s", got, want)
+	}
+}
@@ -25,7 +25,10 @@ import (
 
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/os/repository"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	coreutil "github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/facts"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/utils"
 	"github.com/kubesphere/kubekey/v3/util/osrelease"
 )
@@ -169,6 +172,10 @@ var (
 		"iptables -X",
 		"iptables -F -t nat",
 		"iptables -X -t nat",
+		"iptables -F -t mangle",
+		"iptables -X -t mangle",
+		"iptables -F -t raw",
+		"iptables -X -t raw",
 		"ipvsadm -C",
 		"ip link del kube-ipvs0",
 		"ip link del nodelocaldns",
@@ -252,18 +259,56 @@ func (r *RemoveNodeFiles) Execute(runtime connector.Runtime) error {
 
 type RemoveFiles struct {
 	common.KubeAction
+	RemoveManagedFiles bool
 }
 
 func (r *RemoveFiles) Execute(runtime connector.Runtime) error {
+	if r.RemoveManagedFiles {
+		_, _ = runtime.GetRunner().SudoCmd(action.RemoveManagedFilesCmd(), true)
+	}
 	for _, file := range clusterFiles {
 		_, _ = runtime.GetRunner().SudoCmd(fmt.Sprintf("rm -rf %s", file), true)
 	}
 	// remove pki/etcd Path if it exists, otherwise it will cause the etcd reinstallation to fail if ip change
-	pkiPath := fmt.Sprintf("%s/pki/etcd", runtime.GetWorkDir())
+	pkiPath := fmt.Sprintf("%s/pki/etcd", runtime.GetClusterWorkDir())
 	_, _ = runtime.GetRunner().SudoCmd(fmt.Sprintf("rm -rf %s", pkiPath), true)
 	return nil
 }
 
+type RemoveBinaries struct {
+	common.KubeAction
+}
+
+func (r *RemoveBinaries) Execute(runtime connector.Runtime) error {
+	for _, file := range binaryFiles {
+		_, _ = runtime.GetRunner().SudoCmd(fmt.Sprintf("rm -rf %s", file), true)
+	}
+	return nil
+}
+
+// VerifyCleanup fails if anything of the cluster is left on the node, so it isn't reused unclean.
+type VerifyCleanup struct {
+	common.KubeAction
+	Checks []cleanupCheck
+}
+
+func (v *VerifyCleanup) Execute(runtime connector.Runtime) error {
+	var left []string
+	for _, check := range v.Checks {
+		out, err := runtime.GetRunner().SudoCmd(check.Cmd, false)
+		if err != nil {
+			return errors.Wrapf(errors.WithStack(err), "check the %s failed", check.Name)
+		}
+		if out = strings.TrimSpace(out); out != "" {
+			left = append(left, fmt.Sprintf("%s: %s", check.Name, strings.Join(strings.Fields(out), ", ")))
+		}
+	}
+	if len(left) > 0 {
+		return errors.Errorf("the node %s is not clean, %s", runtime.RemoteHost().GetName(), strings.Join(left, "; "))
+	}
+	return nil
+}
+
 type DaemonReload struct {
 	common.KubeAction
 }
@@ -283,15 +328,29 @@ type GetOSData struct {
 }
 
 func (g *GetOSData) Execute(runtime connector.Runtime) error {
-	osReleaseStr, err := runtime.GetRunner().SudoCmd("cat /etc/os-release", false)
+	gathered, err := facts.Gather(runtime.GetRunner())
 	if err != nil {
 		return err
 	}
-	osrData := osrelease.Parse(strings.Replace(osReleaseStr, "\r\n", "\n", -1))
 
 	host := runtime.RemoteHost()
 	// type: *osrelease.data
-	host.GetCache().Set(Release, osrData)
+	host.GetCache().Set(Release, gathered.OS)
+	host.GetCache().Set(SudoNoPasswd, gathered.SudoNoPasswd)
+	// the cgroup facts are optional as well, which choose the cgroup driver of the container runtime and kubelet.
+	if gathered.CgroupVersion > 0 {
+		host.GetCache().Set(CgroupVersion, gathered.CgroupVersion)
+		host.GetCache().Set(Systemd, gathered.Systemd)
+	}
+	// the resources are optional facts, which are absent on the hosts without nproc or /proc/meminfo.
+	if gathered.CPUs > 0 {
+		host.GetCache().Set(CPUs, gathered.CPUs)
+		host.GetCache().Set(Memory, gathered.Memory)
+	}
+	// the steps baked into the node image of the host are skipped, see kk prepare-image.
+	if gathered.Image != nil {
+		host.GetCache().Set(facts.ImageCacheKey, gathered.Image)
+	}
 	return nil
 }
 
@@ -311,14 +370,28 @@ func (s *SyncRepositoryFile) Execute(runtime connector.Runtime) error {
 	}
 	r := release.(*osrelease.Data)
 
+	dir := filepath.Join(runtime.GetWorkDir(), "repository", host.GetArch(), r.ID, r.VersionID)
+
+	// the package repository built by kk is preferred to the iso file
+	packages := filepath.Join(dir, common.Packages)
+	if coreutil.IsExist(packages) {
+		dst := filepath.Join(common.TmpDir, common.Packages)
+		if err := runtime.GetRunner().Scp(packages, dst); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "scp %s to %s failed", packages, dst)
+		}
+		host.GetCache().Set(RepositoryPath, dst)
+		return nil
+	}
+
 	fileName := fmt.Sprintf("%s-%s-%s.iso", r.ID, r.VersionID, host.GetArch())
-	src := filepath.Join(runtime.GetWorkDir(), "repository", host.GetArch(), r.ID, r.VersionID, fileName)
+	src := filepath.Join(dir, fileName)
 	dst := filepath.Join(common.TmpDir, fileName)
 	if err := runtime.GetRunner().Scp(src, dst); err != nil {
 		return errors.Wrapf(errors.WithStack(err), "scp %s to %s failed", src, dst)
 	}
 
 	host.GetCache().Set("iso", fileName)
+	host.GetCache().Set(RepositoryPath, filepath.Join(common.TmpDir, "iso"))
 	return nil
 }
 
@@ -407,7 +480,11 @@ func (a *AddLocalRepository) Execute(runtime connector.Runtime) error {
 	}
 	repo := r.(repository.Interface)
 
-	if installErr := repo.Add(runtime, filepath.Join(common.TmpDir, "iso")); installErr != nil {
+	path, ok := host.GetCache().GetMustString(RepositoryPath)
+	if !ok {
+		return errors.New("get repository path failed by host cache")
+	}
+	if installErr := repo.Add(runtime, path); installErr != nil {
 		return errors.Wrap(errors.WithStack(installErr), "add local repository failed")
 	}
 	if installErr := repo.Update(runtime); installErr != nil {
@@ -555,30 +632,142 @@ func (n *NodeConfigureNtpServer) Execute(runtime connector.Runtime) error {
 
 	}
 
-	// if Timezone was configured
-	if len(n.KubeConf.Cluster.System.Timezone) > 0 {
-		setTimeZoneCmd := fmt.Sprintf("timedatectl set-timezone %s", n.KubeConf.Cluster.System.Timezone)
-		if _, err := runtime.GetRunner().SudoCmd(setTimeZoneCmd, false); err != nil {
-			return errors.Wrapf(err, "set timezone: %s failed", n.KubeConf.Cluster.System.Timezone)
-		}
+	// ensure chronyd was enabled and work normally
+	startChronyCmd := fmt.Sprintf("systemctl enable %s && systemctl restart %s", chronyService, chronyService)
+	if _, err := runtime.GetRunner().SudoCmd(startChronyCmd, false); err != nil {
+		return errors.Wrap(err, "restart chronyd failed")
+	}
 
-		if _, err := runtime.GetRunner().SudoCmd("timedatectl set-ntp true", false); err != nil {
-			return errors.Wrap(err, "timedatectl set-ntp true failed")
-		}
+	// tells chronyd to cancel any remaining correction that was being slewed and jump the system clock by the equivalent amount, making it correct immediately.
+	if _, err := runtime.GetRunner().SudoCmd("chronyc makestep > /dev/null && chronyc sources", true); err != nil {
+		return errors.Wrap(err, "chronyc makestep failed")
 	}
 
-	// ensure chronyd was enabled and work normally
-	if len(n.KubeConf.Cluster.System.NtpServers) > 0 || len(n.KubeConf.Cluster.System.Timezone) > 0 {
-		startChronyCmd := fmt.Sprintf("systemctl enable %s && systemctl restart %s", chronyService, chronyService)
-		if _, err := runtime.GetRunner().SudoCmd(startChronyCmd, false); err != nil {
-			return errors.Wrap(err, "restart chronyd failed")
+	return nil
+}
+
+// NodeConfigureTimezone sets the timezone of the node by timedatectl, or links /etc/localtime to the zoneinfo if
+// timedatectl isn't available, e.g. on the nodes without systemd, so the logs of all the nodes are in the same timezone.
+type NodeConfigureTimezone struct {
+	common.KubeAction
+}
+
+func (n *NodeConfigureTimezone) Execute(runtime connector.Runtime) error {
+	tz := n.KubeConf.Cluster.System.Timezone
+	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("test -f /usr/share/zoneinfo/%s", tz), false); err != nil {
+		return errors.Wrapf(err, "the timezone %s isn't found in /usr/share/zoneinfo", tz)
+	}
+	setTimezoneCmd := fmt.Sprintf("if command -v timedatectl > /dev/null 2>&1 && timedatectl set-timezone %[1]s; then :; "+
+		"else ln -sf /usr/share/zoneinfo/%[1]s /etc/localtime && echo %[1]s > /etc/timezone; fi", tz)
+	if _, err := runtime.GetRunner().SudoCmd(setTimezoneCmd, false); err != nil {
+		return errors.Wrapf(err, "set timezone: %s failed", tz)
+	}
+	return nil
+}
+
+// NodeConfigureLocale sets the system locale of the node by localectl, or writes it to /etc/default/locale on the
+// Debian family and /etc/locale.conf on the others if localectl isn't available. The locale is generated first where
+// locale-gen exists.
+type NodeConfigureLocale struct {
+	common.KubeAction
+}
+
+func (n *NodeConfigureLocale) Execute(runtime connector.Runtime) error {
+	locale := n.KubeConf.Cluster.System.Locale
+	setLocaleCmd := fmt.Sprintf("if command -v locale-gen > /dev/null 2>&1; then locale-gen %[1]s > /dev/null; fi; "+
+		"if command -v localectl > /dev/null 2>&1 && localectl set-locale LANG=%[1]s; then :; "+
+		"elif [ -f /etc/debian_version ]; then echo LANG=%[1]s > /etc/default/locale; "+
+		"else echo LANG=%[1]s > /etc/locale.conf; fi", locale)
+	if _, err := runtime.GetRunner().SudoCmd(setLocaleCmd, false); err != nil {
+		return errors.Wrapf(err, "set locale: %s failed", locale)
+	}
+	return nil
+}
+
+type NodeInstallDependencies struct {
+	common.KubeAction
+}
+
+func (n *NodeInstallDependencies) Execute(runtime connector.Runtime) error {
+	host := runtime.RemoteHost()
+	release, ok := host.GetCache().Get(Release)
+	if !ok {
+		return errors.New("get os release failed by host cache")
+	}
+	r := release.(*osrelease.Data)
+
+	missing := make([]string, 0)
+	for _, cmd := range dependencyCommands {
+		if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("command -v %s", cmd), false); err != nil {
+			missing = append(missing, cmd)
 		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
 
-		// tells chronyd to cancel any remaining correction that was being slewed and jump the system clock by the equivalent amount, making it correct immediately.
-		if _, err := runtime.GetRunner().SudoCmd("chronyc makestep > /dev/null && chronyc sources", true); err != nil {
-			return errors.Wrap(err, "chronyc makestep failed")
+	family := repository.Family(r)
+	repo, err := repository.NewByFamily(family)
+	if err != nil {
+		return errors.Wrapf(errors.WithStack(err), "install the missing dependencies %v on %s failed", missing, r.ID)
+	}
+
+	if n.KubeConf.Cluster.System.PackagesPath != "" {
+		src := filepath.Join(n.KubeConf.Cluster.System.PackagesPath, family, host.GetArch())
+		dst := filepath.Join(common.TmpDir, "packages")
+		if err := runtime.GetRunner().Scp(src, dst); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "scp %s to %s failed", src, dst)
 		}
+		if err := repo.InstallOffline(runtime, dst); err != nil {
+			return errors.Wrap(errors.WithStack(err), "install offline dependency packages failed")
+		}
+		return nil
+	}
+
+	if err := repo.Update(runtime); err != nil {
+		return errors.Wrap(errors.WithStack(err), "update repository failed")
+	}
+	if err := repo.Install(runtime); err != nil {
+		return errors.Wrap(errors.WithStack(err), "install dependency packages failed")
+	}
+	return nil
+}
+
+type NodeInstallStoragePrerequisites struct {
+	common.KubeAction
+}
+
+func (n *NodeInstallStoragePrerequisites) Execute(runtime connector.Runtime) error {
+	release, ok := runtime.RemoteHost().GetCache().Get(Release)
+	if !ok {
+		return errors.New("get os release failed by host cache")
 	}
+	r := release.(*osrelease.Data)
 
+	provider := n.KubeConf.Cluster.Storage.Provider
+	family := repository.Family(r)
+	pkgs, ok := storagePackages[provider][family]
+	if !ok {
+		return errors.Errorf("the packages required by the storage provider %s are unknown on %s", provider, r.ID)
+	}
+	repo, err := repository.NewByFamily(family)
+	if err != nil {
+		return errors.Wrapf(errors.WithStack(err), "install the packages %v required by the storage provider %s on %s failed", pkgs, provider, r.ID)
+	}
+	if err := repo.Update(runtime); err != nil {
+		return errors.Wrap(errors.WithStack(err), "update repository failed")
+	}
+	if err := repo.Install(runtime, pkgs...); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "install the packages %v required by the storage provider %s failed", pkgs, provider)
+	}
+
+	if family == repository.FamilyAlpine {
+		return nil
+	}
+	for _, service := range storageServices[provider] {
+		if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("systemctl enable --now %s", service), false); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "enable the service %s failed", service)
+		}
+	}
 	return nil
 }
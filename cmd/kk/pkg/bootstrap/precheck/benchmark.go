@@ -0,0 +1,361 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package precheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+const (
+	benchmarkCacheKey = "benchmarkResults"
+	// BenchmarkReportFile is the report of the benchmarks in the work dir of the cluster.
+	BenchmarkReportFile = "benchmark.json"
+
+	BenchmarkFsyncLatency = "fsync-latency"
+	BenchmarkRTT          = "rtt"
+	BenchmarkBandwidth    = "bandwidth"
+
+	BenchmarkOK      = "ok"
+	BenchmarkWarning = "warning"
+	BenchmarkSkipped = "skipped"
+	BenchmarkFailed  = "failed"
+
+	// iperf3Port is the port of the iperf3 server started on the peer for the bandwidth benchmark.
+	iperf3Port = 5201
+)
+
+// BenchmarkResult is the result of a benchmark of a node, the Target is the dir or the peer it's run against.
+type BenchmarkResult struct {
+	Host      string `json:"host"`
+	Benchmark string `json:"benchmark"`
+	Target    string `json:"target"`
+	Value     string `json:"value,omitempty"`
+	Threshold string `json:"threshold"`
+	Status    string `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+// recordBenchmark records the result of the benchmark on the node for the report.
+func recordBenchmark(runtime connector.Runtime, result BenchmarkResult) {
+	host := runtime.RemoteHost()
+	result.Host = host.GetName()
+	var results []BenchmarkResult
+	if v, ok := host.GetCache().Get(benchmarkCacheKey); ok {
+		results = v.([]BenchmarkResult)
+	}
+	host.GetCache().Set(benchmarkCacheKey, append(results, result))
+}
+
+// fioCommand returns the fio command of the etcd disk benchmark in the dir, which writes the WAL of etcd does: a
+// fdatasync after each write of the size of the entries of a typical cluster. The dir is removed afterwards, and so is
+// the data dir if it's created by the benchmark.
+func fioCommand(dataDir string) string {
+	dir := path.Join(dataDir, ".kubekey-benchmark")
+	return fmt.Sprintf("created=; [ -d %[1]s ] || created=1; mkdir -p %[2]s && "+
+		"fio --rw=write --ioengine=sync --fdatasync=1 --directory=%[2]s --size=22m --bs=2300 --name=etcd-benchmark --output-format=json; "+
+		"rc=$?; rm -rf %[2]s; [ -z \"$created\" ] || rmdir %[1]s; exit $rc", dataDir, dir)
+}
+
+// parseFio returns the 99th percentile of the fdatasync latency of the json output of fio.
+func parseFio(output string) (time.Duration, error) {
+	// fio may print warnings before the json
+	if i := strings.Index(output, "{"); i > 0 {
+		output = output[i:]
+	}
+	var result struct {
+		Jobs []struct {
+			Sync struct {
+				LatNs struct {
+					Percentile map[string]float64 `json:"percentile"`
+				} `json:"lat_ns"`
+			} `json:"sync"`
+		} `json:"jobs"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return 0, errors.Wrap(err, "parse the output of fio failed")
+	}
+	if len(result.Jobs) == 0 {
+		return 0, errors.New("no job in the output of fio")
+	}
+	p99, ok := result.Jobs[0].Sync.LatNs.Percentile["99.000000"]
+	if !ok {
+		return 0, errors.New("no fdatasync latency in the output of fio, fio 3.5 or later is required")
+	}
+	return time.Duration(p99), nil
+}
+
+var rttPattern = regexp.MustCompile(`= [\d.]+/([\d.]+)/[\d.]+`)
+
+// parseRTT returns the average round-trip time of the summary of ping, of iputils or busybox.
+func parseRTT(output string) (time.Duration, error) {
+	match := rttPattern.FindStringSubmatch(output)
+	if match == nil {
+		return 0, errors.Errorf("no round-trip time in the output of ping: %s", strings.TrimSpace(output))
+	}
+	ms, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "parse the round-trip time failed")
+	}
+	return time.Duration(ms * float64(time.Millisecond)), nil
+}
+
+// parseIperf3 returns the bandwidth received by the server in Mbit/s of the json output of iperf3.
+func parseIperf3(output string) (int, error) {
+	var result struct {
+		End struct {
+			SumReceived struct {
+				BitsPerSecond float64 `json:"bits_per_second"`
+			} `json:"sum_received"`
+		} `json:"end"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return 0, errors.Wrap(err, "parse the output of iperf3 failed")
+	}
+	if result.Error != "" {
+		return 0, errors.New(result.Error)
+	}
+	return int(result.End.SumReceived.BitsPerSecond / 1e6), nil
+}
+
+func installed(runtime connector.Runtime, tool string) bool {
+	_, err := runtime.GetRunner().Cmd(fmt.Sprintf("command -v %s", tool), false)
+	return err == nil
+}
+
+// BenchmarkDisk benchmarks the fdatasync latency of the etcd data dir of the node by fio.
+type BenchmarkDisk struct {
+	common.KubeAction
+}
+
+func (b *BenchmarkDisk) Execute(runtime connector.Runtime) error {
+	dataDir := defaultEtcdDataDir
+	if d := b.KubeConf.Cluster.Etcd.DataDir; d != nil && *d != "" {
+		dataDir = *d
+	}
+	threshold := b.KubeConf.Cluster.Benchmark.FsyncLatencyThreshold()
+	result := BenchmarkResult{Benchmark: BenchmarkFsyncLatency, Target: dataDir, Threshold: "<= " + threshold.String()}
+	switch {
+	case connector.IsDryRun(runtime.GetConnector()):
+		result.Status, result.Detail = BenchmarkSkipped, "dry-run"
+	case !installed(runtime, "fio"):
+		result.Status, result.Detail = BenchmarkSkipped, "fio is not installed"
+	default:
+		output, err := runtime.GetRunner().SudoCmd(fioCommand(dataDir), false)
+		var p99 time.Duration
+		if err == nil {
+			p99, err = parseFio(output)
+		}
+		switch {
+		case err != nil:
+			result.Status, result.Detail = BenchmarkFailed, err.Error()
+		case p99 > threshold:
+			result.Value, result.Status = p99.String(), BenchmarkWarning
+			result.Detail = "the disk is too slow for etcd, the leader elections may time out"
+		default:
+			result.Value, result.Status = p99.String(), BenchmarkOK
+		}
+	}
+	recordBenchmark(runtime, result)
+	return nil
+}
+
+// BenchmarkNetwork benchmarks the round-trip time from the node to the other control-plane nodes by ping, and the
+// bandwidth to the next one by iperf3. The nodes are benchmarked one by one, so the iperf3 runs don't compete.
+type BenchmarkNetwork struct {
+	common.KubeAction
+}
+
+func (b *BenchmarkNetwork) Execute(runtime connector.Runtime) error {
+	benchmark := b.KubeConf.Cluster.Benchmark
+	host := runtime.RemoteHost()
+	masters := runtime.GetHostsByRole(common.Master)
+	var next connector.Host
+	for i, master := range masters {
+		if master.GetName() == host.GetName() && len(masters) > 1 {
+			next = masters[(i+1)%len(masters)]
+		}
+	}
+	dryRun := connector.IsDryRun(runtime.GetConnector())
+
+	rttThreshold := benchmark.RTTThreshold()
+	hasPing := installed(runtime, "ping")
+	for _, peer := range masters {
+		if peer.GetName() == host.GetName() {
+			continue
+		}
+		result := BenchmarkResult{Benchmark: BenchmarkRTT, Target: peer.GetName(), Threshold: "<= " + rttThreshold.String()}
+		switch {
+		case dryRun:
+			result.Status, result.Detail = BenchmarkSkipped, "dry-run"
+		case !hasPing:
+			result.Status, result.Detail = BenchmarkSkipped, "ping is not installed"
+		default:
+			output, err := runtime.GetRunner().Cmd(fmt.Sprintf("ping -c 10 -i 0.2 -q %s", peer.GetInternalAddress()), false)
+			var rtt time.Duration
+			if err == nil {
+				rtt, err = parseRTT(output)
+			}
+			switch {
+			case err != nil:
+				result.Status, result.Detail = BenchmarkFailed, err.Error()
+			case rtt > rttThreshold:
+				result.Value, result.Status = rtt.String(), BenchmarkWarning
+				result.Detail = "the latency is too high for etcd, raise its heartbeat interval and election timeout"
+			default:
+				result.Value, result.Status = rtt.String(), BenchmarkOK
+			}
+		}
+		recordBenchmark(runtime, result)
+	}
+
+	if next == nil {
+		return nil
+	}
+	bandwidthThreshold := benchmark.BandwidthThreshold()
+	result := BenchmarkResult{Benchmark: BenchmarkBandwidth, Target: next.GetName(), Threshold: fmt.Sprintf(">= %d Mbit/s", bandwidthThreshold)}
+	switch {
+	case dryRun:
+		result.Status, result.Detail = BenchmarkSkipped, "dry-run"
+	case !installed(runtime, "iperf3"):
+		result.Status, result.Detail = BenchmarkSkipped, "iperf3 is not installed"
+	default:
+		mbps, err := benchmarkBandwidth(runtime, next)
+		switch {
+		case err != nil:
+			result.Status, result.Detail = BenchmarkFailed, err.Error()
+		case mbps < bandwidthThreshold:
+			result.Value, result.Status = fmt.Sprintf("%d Mbit/s", mbps), BenchmarkWarning
+			result.Detail = "the bandwidth is too low for the replication of etcd"
+		default:
+			result.Value, result.Status = fmt.Sprintf("%d Mbit/s", mbps), BenchmarkOK
+		}
+	}
+	recordBenchmark(runtime, result)
+	return nil
+}
+
+// benchmarkBandwidth starts a one-off iperf3 server on the peer and measures the bandwidth to it from the node.
+func benchmarkBandwidth(runtime connector.Runtime, peer connector.Host) (int, error) {
+	conn, err := runtime.GetConnector().Connect(peer)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to connect to %s", peer.GetAddress())
+	}
+	peerRuntime := runtime.Copy()
+	peerRuntime.SetRunner(&connector.Runner{Conn: conn, Debug: runtime.GetRunner().Debug, Host: peer, Ctx: runtime.GetRunner().Ctx})
+	if _, err := peerRuntime.GetRunner().Cmd("command -v iperf3", false); err != nil {
+		return 0, errors.Errorf("iperf3 is not installed on %s", peer.GetName())
+	}
+	server := fmt.Sprintf("iperf3 -s -1 -D -p %d", iperf3Port)
+	if _, err := peerRuntime.GetRunner().Cmd(server, false); err != nil {
+		return 0, errors.Wrapf(err, "start the iperf3 server on %s failed", peer.GetName())
+	}
+	defer func() {
+		// the bracket keeps pkill from matching the shell running it
+		if _, err := peerRuntime.GetRunner().Cmd(fmt.Sprintf("pkill -f '[i]%s' || true", server[1:]), false); err != nil {
+			logger.Log.Warnf("stop the iperf3 server on %s failed: %v", peer.GetName(), err)
+		}
+	}()
+
+	// the server may take a moment to listen
+	output, err := runtime.GetRunner().Cmd(fmt.Sprintf("sleep 1; iperf3 -c %s -p %d -t 5 -J", peer.GetInternalAddress(), iperf3Port), false)
+	mbps, parseErr := parseIperf3(output)
+	if parseErr == nil {
+		return mbps, nil
+	}
+	// the error of iperf3 is in its json output
+	if strings.TrimSpace(output) != "" || err == nil {
+		err = parseErr
+	}
+	return 0, errors.Wrapf(err, "iperf3 to %s on the port %d failed", peer.GetName(), iperf3Port)
+}
+
+// ReportBenchmark prints the results of the benchmarks, and writes them to the report in the work dir of the cluster.
+// The results beyond their thresholds are warned of, and fail the run if the benchmark is strict.
+type ReportBenchmark struct {
+	common.KubeAction
+}
+
+func (r *ReportBenchmark) Execute(runtime connector.Runtime) error {
+	var results []BenchmarkResult
+	for _, host := range runtime.GetAllHosts() {
+		if v, ok := host.GetCache().Get(benchmarkCacheKey); ok {
+			results = append(results, v.([]BenchmarkResult)...)
+		}
+	}
+
+	content, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "encode the benchmark report failed")
+	}
+	reportFile := filepath.Join(runtime.GetClusterWorkDir(), BenchmarkReportFile)
+	if err := util.WriteFile(reportFile, content); err != nil {
+		return errors.Wrap(errors.WithStack(err), "write the benchmark report failed")
+	}
+	logger.Log.Infof("The benchmark report is written to %s", reportFile)
+	if err := PrintBenchmark(os.Stdout, results); err != nil {
+		return err
+	}
+
+	var warnings []string
+	for _, result := range results {
+		switch result.Status {
+		case BenchmarkWarning:
+			warnings = append(warnings, fmt.Sprintf("%s of %s", result.Benchmark, result.Host))
+			logger.Log.Warnf("the %s of %s to %s is %s, beyond the threshold %s: %s", result.Benchmark, result.Host,
+				result.Target, result.Value, result.Threshold, result.Detail)
+		case BenchmarkFailed:
+			logger.Log.Warnf("the %s of %s to %s failed: %s", result.Benchmark, result.Host, result.Target, result.Detail)
+		}
+	}
+	if len(warnings) > 0 && r.KubeConf.Cluster.Benchmark.Strict {
+		return errors.Errorf("the benchmarks %s are beyond their thresholds", strings.Join(warnings, ", "))
+	}
+	return nil
+}
+
+// PrintBenchmark prints the results of the benchmarks as a table.
+func PrintBenchmark(w io.Writer, results []BenchmarkResult) error {
+	tw := tabwriter.NewWriter(w, 10, 4, 3, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "HOST\tBENCHMARK\tTARGET\tVALUE\tTHRESHOLD\tSTATUS")
+	for _, result := range results {
+		value := result.Value
+		if value == "" {
+			value = "<none>"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", result.Host, result.Benchmark, result.Target, value,
+			result.Threshold, result.Status)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,104 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package precheck
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFio(t *testing.T) {
+	output := `note: both iodepth >= 1 and synchronous I/O engine are selected, queue depth will be capped at 1
+{
+  "fio version" : "fio-3.28",
+  "jobs" : [
+    {
+      "jobname" : "etcd-benchmark",
+      "sync" : {
+        "lat_ns" : {
+          "min" : 1021,
+          "max" : 23840563,
+          "percentile" : {
+            "90.000000" : 2113536,
+            "99.000000" : 8454144,
+            "99.900000" : 15007744
+          }
+        }
+      }
+    }
+  ]
+}`
+	got, err := parseFio(output)
+	if err != nil {
+		t.Fatalf("parseFio() error = %v", err)
+	}
+	if want := 8454144 * time.Nanosecond; got != want {
+		t.Errorf("parseFio() = %s, want %s", got, want)
+	}
+	if _, err := parseFio(`{"jobs": [{"sync": {"lat": {}}}]}`); err == nil {
+		t.Error("parseFio() of an old fio didn't fail")
+	}
+}
+
+func TestParseRTT(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    time.Duration
+		wantErr bool
+	}{
+		{
+			name:   "iputils",
+			output: "10 packets transmitted, 10 received, 0% packet loss, time 1808ms\nrtt min/avg/max/mdev = 0.211/0.357/0.612/0.108 ms\n",
+			want:   357 * time.Microsecond,
+		},
+		{
+			name:   "busybox",
+			output: "10 packets transmitted, 10 packets received, 0% packet loss\nround-trip min/avg/max = 12.101/60.250/98.003 ms\n",
+			want:   60250 * time.Microsecond,
+		},
+		{
+			name:    "unreachable",
+			output:  "10 packets transmitted, 0 received, 100% packet loss, time 1843ms\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRTT(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRTT() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseRTT() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseIperf3(t *testing.T) {
+	got, err := parseIperf3(`{"end": {"sum_sent": {"bits_per_second": 943718400}, "sum_received": {"bits_per_second": 939524096.5}}}`)
+	if err != nil {
+		t.Fatalf("parseIperf3() error = %v", err)
+	}
+	if got != 939 {
+		t.Errorf("parseIperf3() = %d, want 939", got)
+	}
+	if _, err := parseIperf3(`{"start": {}, "end": {}, "error": "unable to connect to server: Connection refused"}`); err == nil {
+		t.Error("parseIperf3() of a failed run didn't fail")
+	}
+}
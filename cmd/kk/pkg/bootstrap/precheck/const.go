@@ -38,6 +38,11 @@ const (
 	ceph = "ceph"
 
 	UnknownVersion = "UnknownVersion"
+
+	// ImmutableOSKey is the key of the name of the immutable OS in the host cache, which is empty if the OS is mutable.
+	ImmutableOSKey = "immutableOS"
+	// ArchitectureKey is the key of the architecture of the host in the host cache, e.g. amd64 or arm64.
+	ArchitectureKey = "architecture"
 )
 
 // defines the base software to be checked.
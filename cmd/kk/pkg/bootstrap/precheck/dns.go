@@ -0,0 +1,135 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package precheck
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+// DNSName is a name in the cluster spec which the nodes must resolve, and the field it is set in.
+type DNSName struct {
+	Name  string
+	Field string
+}
+
+// DNSNameSource returns the names of a part of the cluster spec which the nodes must resolve before the install.
+// The names which KubeKey adds to /etc/hosts of the nodes, e.g. the domain of the registry deployed by KubeKey,
+// must not be returned.
+type DNSNameSource func(cluster *kubekeyapiv1alpha2.ClusterSpec) []DNSName
+
+// DNSNameSources are the sources of the names checked by the DNSResolutionCheck, which can be extended with the
+// names of other parts of the cluster spec.
+var DNSNameSources = []DNSNameSource{
+	registryDNSNames,
+	controlPlaneEndpointDNSNames,
+	ntpServerDNSNames,
+}
+
+func registryDNSNames(cluster *kubekeyapiv1alpha2.ClusterSpec) []DNSName {
+	var names []DNSName
+	// the domain of the registry deployed by KubeKey is added to /etc/hosts.
+	if len(cluster.RoleGroups[kubekeyapiv1alpha2.Registry]) == 0 && cluster.Registry.PrivateRegistry != "" {
+		names = append(names, DNSName{Name: cluster.Registry.GetHost(), Field: "registry.privateRegistry"})
+	}
+	for _, mirror := range cluster.Registry.RegistryMirrors {
+		if u, err := url.Parse(mirror); err == nil && u.Host != "" {
+			names = append(names, DNSName{Name: u.Host, Field: "registry.registryMirrors"})
+		}
+	}
+	return names
+}
+
+func controlPlaneEndpointDNSNames(cluster *kubekeyapiv1alpha2.ClusterSpec) []DNSName {
+	endpoint := &cluster.ControlPlaneEndpoint
+	var names []DNSName
+	if endpoint.Address != "" {
+		names = append(names, DNSName{Name: endpoint.Address, Field: "controlPlaneEndpoint.address"})
+	}
+	// the domain is added to /etc/hosts unless it's resolved by the external DNS, and the records of the managed
+	// DNS are created during the install.
+	if endpoint.EnableExternalDNS() && endpoint.Address == "" && !endpoint.ManagedDNS() {
+		names = append(names, DNSName{Name: endpoint.Domain, Field: "controlPlaneEndpoint.domain"})
+	}
+	return names
+}
+
+func ntpServerDNSNames(cluster *kubekeyapiv1alpha2.ClusterSpec) []DNSName {
+	var names []DNSName
+	for _, server := range cluster.System.NtpServers {
+		names = append(names, DNSName{Name: server, Field: "system.ntpServers"})
+	}
+	return names
+}
+
+// ClusterDNSNames returns the names of all the DNSNameSources. The addresses, the hosts of the cluster, which are
+// added to /etc/hosts, and the duplicated names are skipped, and the ports are removed.
+func ClusterDNSNames(cluster *kubekeyapiv1alpha2.ClusterSpec) []DNSName {
+	skip := map[string]bool{"localhost": true}
+	for _, host := range cluster.Hosts {
+		skip[strings.ToLower(host.Name)] = true
+		for _, alias := range host.Aliases {
+			skip[strings.ToLower(alias)] = true
+		}
+	}
+
+	var names []DNSName
+	for _, source := range DNSNameSources {
+		for _, n := range source(cluster) {
+			name := strings.ToLower(n.Name)
+			if host, _, err := net.SplitHostPort(name); err == nil {
+				name = host
+			}
+			if name == "" || skip[name] || net.ParseIP(name) != nil || len(validation.IsDNS1123Subdomain(name)) != 0 {
+				continue
+			}
+			skip[name] = true
+			names = append(names, DNSName{Name: name, Field: n.Field})
+		}
+	}
+	return names
+}
+
+// DNSResolutionCheck resolves the names in the cluster spec on the node with getent, or dig if getent can't, so
+// the names the node can't resolve are reported before anything is installed.
+type DNSResolutionCheck struct {
+	common.KubeAction
+}
+
+func (d *DNSResolutionCheck) Execute(runtime connector.Runtime) error {
+	var failed []string
+	for _, n := range ClusterDNSNames(d.KubeConf.Cluster) {
+		cmd := fmt.Sprintf("getent ahosts %[1]s >/dev/null 2>&1 || dig +short %[1]s 2>/dev/null | grep -q .", n.Name)
+		if _, err := runtime.GetRunner().Cmd(cmd, false); err != nil {
+			failed = append(failed, fmt.Sprintf("%s (%s)", n.Name, n.Field))
+		}
+	}
+	if len(failed) > 0 {
+		return errors.Errorf("node %s can't resolve %s, fix the DNS servers of the node or add the names to its /etc/hosts",
+			runtime.RemoteHost().GetName(), strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package precheck
+
+import (
+	"reflect"
+	"testing"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+func TestClusterDNSNames(t *testing.T) {
+	externalDNS := true
+	tests := []struct {
+		name   string
+		modify func(cluster *kubekeyapiv1alpha2.ClusterSpec)
+		want   []DNSName
+	}{
+		{
+			name:   "all names in /etc/hosts",
+			modify: func(cluster *kubekeyapiv1alpha2.ClusterSpec) {},
+		},
+		{
+			name: "external registry, mirrors and ntp servers",
+			modify: func(cluster *kubekeyapiv1alpha2.ClusterSpec) {
+				cluster.Registry.PrivateRegistry = "Harbor.example.com:8443/library"
+				cluster.Registry.RegistryMirrors = []string{"https://mirror.example.com", "https://harbor.example.com:8443"}
+				cluster.System.NtpServers = []string{"ntp.example.com", "node1", "192.168.0.254"}
+			},
+			want: []DNSName{
+				{Name: "harbor.example.com", Field: "registry.privateRegistry"},
+				{Name: "mirror.example.com", Field: "registry.registryMirrors"},
+				{Name: "ntp.example.com", Field: "system.ntpServers"},
+			},
+		},
+		{
+			name: "registry deployed by kubekey",
+			modify: func(cluster *kubekeyapiv1alpha2.ClusterSpec) {
+				cluster.RoleGroups = map[string][]string{kubekeyapiv1alpha2.Registry: {"node1"}}
+				cluster.Registry.PrivateRegistry = "dockerhub.kubekey.local"
+			},
+		},
+		{
+			name: "control plane domain resolved by the external DNS",
+			modify: func(cluster *kubekeyapiv1alpha2.ClusterSpec) {
+				cluster.ControlPlaneEndpoint.ExternalDNS = &externalDNS
+				cluster.ControlPlaneEndpoint.Address = ""
+			},
+			want: []DNSName{{Name: "lb.kubesphere.local", Field: "controlPlaneEndpoint.domain"}},
+		},
+		{
+			name: "control plane address",
+			modify: func(cluster *kubekeyapiv1alpha2.ClusterSpec) {
+				cluster.ControlPlaneEndpoint.Address = "api.example.com"
+			},
+			want: []DNSName{{Name: "api.example.com", Field: "controlPlaneEndpoint.address"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &kubekeyapiv1alpha2.ClusterSpec{
+				Hosts:                []kubekeyapiv1alpha2.HostCfg{{Name: "node1", Address: "192.168.0.1"}},
+				ControlPlaneEndpoint: kubekeyapiv1alpha2.ControlPlaneEndpoint{Domain: "lb.kubesphere.local", Address: "192.168.0.1"},
+			}
+			tt.modify(cluster)
+			if got := ClusterDNSNames(cluster); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ClusterDNSNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
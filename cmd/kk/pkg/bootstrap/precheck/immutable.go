@@ -0,0 +1,109 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package precheck
+
+import (
+	"strings"
+
+	"github.com/kubesphere/kubekey/v3/util/osrelease"
+)
+
+// bottlerocketOsRelease is the os-release of the Bottlerocket host, which is mounted into the admin container that
+// kk logs in to. The /etc/os-release in the admin container is the one of the container image.
+const bottlerocketOsRelease = "/.bottlerocket/rootfs/etc/os-release"
+
+// ostreeBooted exists on the hosts booted from an OSTree deployment, e.g. Fedora CoreOS, RHCOS and Fedora Silverblue.
+const ostreeBooted = "/run/ostree-booted"
+
+// ImmutableOS is an immutable or container-optimized OS. Its root filesystem is read-only and it is provisioned by
+// its own declarative tooling, so the mutable-OS steps of kk, e.g. installing packages and running the init script,
+// can't be applied to it.
+type ImmutableOS struct {
+	// Name is the name of the OS.
+	Name string
+	// Bootstrap is how to bootstrap the Kubernetes node on the OS instead.
+	Bootstrap string
+}
+
+// immutableOSes are the known immutable OSes, keyed by the ID of the os-release, or by the ID and the VARIANT_ID
+// joined with "-" if the variant is immutable only.
+var immutableOSes = map[string]ImmutableOS{
+	"flatcar": {
+		Name:      "Flatcar Container Linux",
+		Bootstrap: "provision the node with an Ignition config (e.g. rendered from Butane) that installs the Kubernetes binaries as a systemd-sysext and runs kubeadm",
+	},
+	"bottlerocket": {
+		Name:      "Bottlerocket",
+		Bootstrap: "join the node through the Bottlerocket API, i.e. the settings.kubernetes.* user data or apiclient set",
+	},
+	"talos": {
+		Name:      "Talos Linux",
+		Bootstrap: "manage the cluster with talosctl and a Talos machine config",
+	},
+	"fedora-coreos": {
+		Name:      "Fedora CoreOS",
+		Bootstrap: "provision the node with an Ignition config and layer the packages with rpm-ostree",
+	},
+	"rhcos": {
+		Name:      "Red Hat Enterprise Linux CoreOS",
+		Bootstrap: "install the node with the OpenShift installer or machine config operator",
+	},
+	"opensuse-microos": {
+		Name:      "openSUSE MicroOS",
+		Bootstrap: "install the packages with transactional-update and bootstrap the node with Combustion or Ignition",
+	},
+	"sle-micro": {
+		Name:      "SUSE Linux Enterprise Micro",
+		Bootstrap: "install the packages with transactional-update and bootstrap the node with Combustion or Ignition",
+	},
+	"ubuntu-core": {
+		Name:      "Ubuntu Core",
+		Bootstrap: "install Kubernetes as a snap, e.g. MicroK8s",
+	},
+	"k3os": {
+		Name:      "k3OS",
+		Bootstrap: "configure the embedded k3s with the k3OS config file",
+	},
+}
+
+// DetectImmutableOS returns the immutable OS of the host by its os-release and whether it is booted from an OSTree
+// deployment, or nil if the OS is mutable.
+func DetectImmutableOS(release *osrelease.Data, ostree bool) *ImmutableOS {
+	if release != nil {
+		id := strings.ToLower(release.ID)
+		keys := []string{id}
+		if release.VariantID != "" {
+			keys = append([]string{id + "-" + strings.ToLower(release.VariantID)}, keys...)
+		}
+		for _, key := range keys {
+			if immutable, ok := immutableOSes[key]; ok {
+				return &immutable
+			}
+		}
+	}
+	if ostree {
+		name := "An OSTree based OS"
+		if release != nil && release.PrettyName != "" {
+			name = release.PrettyName
+		}
+		return &ImmutableOS{
+			Name:      name,
+			Bootstrap: "provision the node with its image builder or layer the packages with rpm-ostree",
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package precheck
+
+import (
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/util/osrelease"
+)
+
+func TestDetectImmutableOS(t *testing.T) {
+	tests := []struct {
+		name    string
+		release *osrelease.Data
+		ostree  bool
+		want    string
+	}{
+		{
+			name:    "ubuntu",
+			release: &osrelease.Data{ID: "ubuntu", IDLike: "debian"},
+		},
+		{
+			name:    "flatcar",
+			release: &osrelease.Data{ID: "flatcar", IDLike: "coreos"},
+			want:    "Flatcar Container Linux",
+		},
+		{
+			name:    "bottlerocket",
+			release: &osrelease.Data{ID: "bottlerocket", VariantID: "aws-k8s-1.28"},
+			want:    "Bottlerocket",
+		},
+		{
+			name:    "fedora coreos",
+			release: &osrelease.Data{ID: "fedora", VariantID: "coreos"},
+			ostree:  true,
+			want:    "Fedora CoreOS",
+		},
+		{
+			name:    "fedora server",
+			release: &osrelease.Data{ID: "fedora", VariantID: "server"},
+		},
+		{
+			name:    "unknown ostree",
+			release: &osrelease.Data{ID: "fedora", VariantID: "silverblue", PrettyName: "Fedora Linux 38 (Silverblue)"},
+			ostree:  true,
+			want:    "Fedora Linux 38 (Silverblue)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			if immutable := DetectImmutableOS(tt.release, tt.ostree); immutable != nil {
+				got = immutable.Name
+			}
+			if got != tt.want {
+				t.Errorf("DetectImmutableOS() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package precheck
+
+import (
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+)
+
+// Mode is the operation the prechecks are selected by. An upgrade checks the running cluster instead of validating
+// the prerequisites of a fresh installation again.
+type Mode string
+
+const (
+	ModeInstall Mode = "install"
+	ModeUpgrade Mode = "upgrade"
+)
+
+// rule is a precheck with the modes it applies to.
+type rule struct {
+	task  task.Interface
+	modes []Mode
+}
+
+// selectRules returns the tasks of the rules applying to the mode, the empty mode is an installation.
+func selectRules(mode Mode, rules []rule) []task.Interface {
+	if mode == "" {
+		mode = ModeInstall
+	}
+	var tasks []task.Interface
+	for _, r := range rules {
+		for _, m := range r.modes {
+			if m == mode {
+				tasks = append(tasks, r.task)
+				break
+			}
+		}
+	}
+	return tasks
+}
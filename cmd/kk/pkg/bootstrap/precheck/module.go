@@ -19,10 +19,13 @@ package precheck
 import (
 	"time"
 
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/prepare"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/health"
 )
 
 type GreetingsModule struct {
@@ -31,6 +34,7 @@ type GreetingsModule struct {
 
 func (h *GreetingsModule) Init() {
 	h.Name = "GreetingsModule"
+	h.Tags = []string{connector.AlwaysTag}
 	h.Desc = "Greetings"
 
 	var timeout int64
@@ -47,14 +51,36 @@ func (h *GreetingsModule) Init() {
 		Timeout:  time.Duration(timeout) * time.Second,
 	}
 
+	architecture := &task.RemoteTask{
+		Name:     "GatherArchitecture",
+		Desc:     "Gather the architecture of the hosts",
+		Hosts:    h.Runtime.GetAllHosts(),
+		Action:   new(GatherArchitecture),
+		Parallel: true,
+		Timeout:  time.Duration(timeout) * time.Second,
+	}
+
+	nodeIP := &task.RemoteTask{
+		Name:     "SelectNodeIP",
+		Desc:     "Select the node IP of the hosts by the node IP policy",
+		Hosts:    h.Runtime.GetAllHosts(),
+		Action:   new(SelectNodeIP),
+		Parallel: true,
+		Timeout:  time.Duration(timeout) * time.Second,
+	}
+
 	h.Tasks = []task.Interface{
 		hello,
+		architecture,
+		nodeIP,
 	}
 }
 
 type NodePreCheckModule struct {
 	common.KubeModule
 	Skip bool
+	// Mode selects the checks, the DNS resolution and the etcd placement are checked for an installation only.
+	Mode Mode
 }
 
 func (n *NodePreCheckModule) IsSkip() bool {
@@ -63,6 +89,7 @@ func (n *NodePreCheckModule) IsSkip() bool {
 
 func (n *NodePreCheckModule) Init() {
 	n.Name = "NodePreCheckModule"
+	n.Tags = []string{connector.AlwaysTag}
 	n.Desc = "Do pre-check on cluster nodes"
 
 	preCheck := &task.RemoteTask{
@@ -77,15 +104,143 @@ func (n *NodePreCheckModule) Init() {
 		//		}
 		//		return true, nil
 		//	}},
-		Action:   new(NodePreCheck),
-		Parallel: true,
+		Action:    new(NodePreCheck),
+		AlwaysRun: true,
+		Parallel:  true,
+	}
+
+	immutableOSCheck := &task.RemoteTask{
+		Name:      "ImmutableOSCheck",
+		Desc:      "Check whether the OS of nodes is immutable",
+		Hosts:     n.Runtime.GetAllHosts(),
+		Action:    new(ImmutableOSCheck),
+		AlwaysRun: true,
+		Parallel:  true,
+	}
+
+	dnsResolutionCheck := &task.RemoteTask{
+		Name:      "DNSResolutionCheck",
+		Desc:      "Check the names in the cluster spec are resolved on nodes",
+		Hosts:     n.Runtime.GetAllHosts(),
+		Action:    new(DNSResolutionCheck),
+		AlwaysRun: true,
+		Parallel:  true,
+	}
+
+	ownerCheck := &task.RemoteTask{
+		Name:      "NodeOwnerCheck",
+		Desc:      "Check the nodes aren't owned by another cluster",
+		Hosts:     n.Runtime.GetAllHosts(),
+		Action:    new(NodeOwnerCheck),
+		AlwaysRun: true,
+		Parallel:  true,
+	}
+
+	kubernetesVersionCheck := &task.LocalTask{
+		Name:        "KubernetesVersionCheck",
+		Desc:        "Check the Kubernetes version by the version matrix",
+		Action:      new(KubernetesVersionCheck),
+		RunInDryRun: true,
+	}
+
+	etcdPlacementCheck := &task.LocalTask{
+		Name:        "EtcdPlacementCheck",
+		Desc:        "Check the placement of etcd across failure domains",
+		Action:      new(EtcdPlacementCheck),
+		RunInDryRun: true,
 	}
 
-	n.Tasks = []task.Interface{
-		preCheck,
+	tlsPolicyCheck := &task.LocalTask{
+		Name:        "TLSPolicyCheck",
+		Desc:        "Check the TLS policies by the versions of the components",
+		Action:      new(TLSPolicyCheck),
+		RunInDryRun: true,
 	}
+
+	all := []Mode{ModeInstall, ModeUpgrade}
+	n.Tasks = selectRules(n.Mode, []rule{
+		{task: immutableOSCheck, modes: all},
+		{task: preCheck, modes: all},
+		{task: dnsResolutionCheck, modes: []Mode{ModeInstall}},
+		{task: ownerCheck, modes: all},
+		{task: kubernetesVersionCheck, modes: all},
+		{task: etcdPlacementCheck, modes: []Mode{ModeInstall}},
+		{task: tlsPolicyCheck, modes: all},
+	})
+}
+
+// BenchmarkModule benchmarks the disks of etcd and the network of the control plane before the installation, and
+// reports the results beyond their thresholds.
+type BenchmarkModule struct {
+	common.KubeModule
+	Skip bool
+}
+
+func (b *BenchmarkModule) IsSkip() bool {
+	return b.Skip
 }
 
+func (b *BenchmarkModule) Init() {
+	b.Name = "BenchmarkModule"
+	b.Tags = []string{"benchmark"}
+	b.Desc = "Benchmark the disks of etcd and the network of the control plane"
+
+	benchmark := b.KubeConf.Cluster.Benchmark
+	if benchmark.Runs(kubekeyapiv1alpha2.BenchmarkDisk) && b.KubeConf.Cluster.Etcd.Type != kubekeyapiv1alpha2.External {
+		b.Tasks = append(b.Tasks, &task.RemoteTask{
+			Name:     "BenchmarkDisk",
+			Desc:     "Benchmark the fdatasync latency of the etcd data dirs",
+			Hosts:    b.Runtime.GetHostsByRole(common.ETCD),
+			Action:   new(BenchmarkDisk),
+			Parallel: true,
+		})
+	}
+	if benchmark.Runs(kubekeyapiv1alpha2.BenchmarkNetwork) {
+		b.Tasks = append(b.Tasks, &task.RemoteTask{
+			Name:   "BenchmarkNetwork",
+			Desc:   "Benchmark the latency and the bandwidth between the control-plane nodes",
+			Hosts:  b.Runtime.GetHostsByRole(common.Master),
+			Action: new(BenchmarkNetwork),
+		})
+	}
+	b.Tasks = append(b.Tasks, &task.LocalTask{
+		Name:        "ReportBenchmark",
+		Desc:        "Report the results of the benchmarks",
+		Action:      new(ReportBenchmark),
+		RunInDryRun: true,
+	})
+}
+
+// OwnerCheckModule checks the nodes aren't owned by another cluster before they are changed, e.g. cleaned up.
+type OwnerCheckModule struct {
+	common.KubeModule
+	Skip bool
+}
+
+func (o *OwnerCheckModule) IsSkip() bool {
+	return o.Skip
+}
+
+func (o *OwnerCheckModule) Init() {
+	o.Name = "OwnerCheckModule"
+	o.Desc = "Check the owner of the nodes"
+
+	ownerCheck := &task.RemoteTask{
+		Name:      "NodeOwnerCheck",
+		Desc:      "Check the nodes aren't owned by another cluster",
+		Hosts:     o.Runtime.GetAllHosts(),
+		Action:    new(NodeOwnerCheck),
+		AlwaysRun: true,
+		Parallel:  true,
+	}
+
+	o.Tasks = []task.Interface{
+		ownerCheck,
+	}
+}
+
+// ClusterPreCheckModule checks the running cluster before an upgrade: the versions and their skew, the health of the
+// control plane, the removed APIs still requested, the PodDisruptionBudgets and the free space of etcd.
 type ClusterPreCheckModule struct {
 	common.KubeModule
 	SkipDependencyCheck bool
@@ -96,47 +251,52 @@ func (c *ClusterPreCheckModule) Init() {
 	c.Desc = "Do pre-check on cluster"
 
 	getKubeConfig := &task.RemoteTask{
-		Name:     "GetKubeConfig",
-		Desc:     "Get KubeConfig file",
-		Hosts:    c.Runtime.GetHostsByRole(common.Master),
-		Prepare:  new(common.OnlyFirstMaster),
-		Action:   new(GetKubeConfig),
-		Parallel: true,
+		Name:      "GetKubeConfig",
+		Desc:      "Get KubeConfig file",
+		Hosts:     c.Runtime.GetHostsByRole(common.Master),
+		Prepare:   new(common.OnlyFirstMaster),
+		Action:    new(GetKubeConfig),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	getAllNodesK8sVersion := &task.RemoteTask{
-		Name:     "GetAllNodesK8sVersion",
-		Desc:     "Get all nodes Kubernetes version",
-		Hosts:    c.Runtime.GetHostsByRole(common.K8s),
-		Action:   new(GetAllNodesK8sVersion),
-		Parallel: true,
+		Name:      "GetAllNodesK8sVersion",
+		Desc:      "Get all nodes Kubernetes version",
+		Hosts:     c.Runtime.GetHostsByRole(common.K8s),
+		Action:    new(GetAllNodesK8sVersion),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	calculateMinK8sVersion := &task.RemoteTask{
-		Name:     "CalculateMinK8sVersion",
-		Desc:     "Calculate min Kubernetes version",
-		Hosts:    c.Runtime.GetHostsByRole(common.Master),
-		Prepare:  new(common.OnlyFirstMaster),
-		Action:   new(CalculateMinK8sVersion),
-		Parallel: true,
+		Name:      "CalculateMinK8sVersion",
+		Desc:      "Calculate min Kubernetes version",
+		Hosts:     c.Runtime.GetHostsByRole(common.Master),
+		Prepare:   new(common.OnlyFirstMaster),
+		Action:    new(CalculateMinK8sVersion),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	checkDesiredK8sVersion := &task.RemoteTask{
-		Name:     "CheckDesiredK8sVersion",
-		Desc:     "Check desired Kubernetes version",
-		Hosts:    c.Runtime.GetHostsByRole(common.Master),
-		Prepare:  new(common.OnlyFirstMaster),
-		Action:   new(CheckDesiredK8sVersion),
-		Parallel: true,
+		Name:      "CheckDesiredK8sVersion",
+		Desc:      "Check desired Kubernetes version",
+		Hosts:     c.Runtime.GetHostsByRole(common.Master),
+		Prepare:   new(common.OnlyFirstMaster),
+		Action:    new(CheckDesiredK8sVersion),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	ksVersionCheck := &task.RemoteTask{
-		Name:     "KsVersionCheck",
-		Desc:     "Check KubeSphere version",
-		Hosts:    c.Runtime.GetHostsByRole(common.Master),
-		Prepare:  new(common.OnlyFirstMaster),
-		Action:   new(KsVersionCheck),
-		Parallel: true,
+		Name:      "KsVersionCheck",
+		Desc:      "Check KubeSphere version",
+		Hosts:     c.Runtime.GetHostsByRole(common.Master),
+		Prepare:   new(common.OnlyFirstMaster),
+		Action:    new(KsVersionCheck),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	dependencyCheck := &task.RemoteTask{
@@ -151,13 +311,62 @@ func (c *ClusterPreCheckModule) Init() {
 		Parallel: true,
 	}
 
+	controlPlaneHealthCheck := &task.RemoteTask{
+		Name:  "ControlPlaneHealthCheck",
+		Desc:  "Check the control plane is healthy",
+		Hosts: c.Runtime.GetHostsByRole(common.Master),
+		Action: &health.WaitHealthy{
+			Checks:  []health.Check{health.Kubelet, health.Apiserver, health.Scheduler, health.ControllerManager},
+			Timeout: time.Minute,
+		},
+		AlwaysRun: true,
+		Parallel:  true,
+	}
+
+	versionSkewCheck := &task.LocalTask{
+		Name:        "VersionSkewCheck",
+		Desc:        "Check the version skew of the nodes for the upgrade",
+		Action:      new(VersionSkewCheck),
+		RunInDryRun: true,
+	}
+
+	apiDeprecationCheck := &task.RemoteTask{
+		Name:      "APIDeprecationCheck",
+		Desc:      "Check the APIs removed in the target Kubernetes version aren't requested",
+		Hosts:     c.Runtime.GetHostsByRole(common.Master),
+		Prepare:   new(common.OnlyFirstMaster),
+		Action:    new(APIDeprecationCheck),
+		AlwaysRun: true,
+		Parallel:  true,
+	}
+
+	pdbCheck := &task.RemoteTask{
+		Name:      "PDBCheck",
+		Desc:      "Check the PodDisruptionBudgets allowing no disruption",
+		Hosts:     c.Runtime.GetHostsByRole(common.Master),
+		Prepare:   new(common.OnlyFirstMaster),
+		Action:    new(PDBCheck),
+		AlwaysRun: true,
+		Parallel:  true,
+	}
+
+	etcdSpaceCheck := &task.RemoteTask{
+		Name:      "EtcdSpaceCheck",
+		Desc:      "Check the free space of the etcd data dir",
+		Hosts:     c.Runtime.GetHostsByRole(common.ETCD),
+		Action:    new(EtcdSpaceCheck),
+		AlwaysRun: true,
+		Parallel:  true,
+	}
+
 	getKubernetesNodesStatus := &task.RemoteTask{
-		Name:     "GetKubernetesNodesStatus",
-		Desc:     "Get kubernetes nodes status",
-		Hosts:    c.Runtime.GetHostsByRole(common.Master),
-		Prepare:  new(common.OnlyFirstMaster),
-		Action:   new(GetKubernetesNodesStatus),
-		Parallel: true,
+		Name:      "GetKubernetesNodesStatus",
+		Desc:      "Get kubernetes nodes status",
+		Hosts:     c.Runtime.GetHostsByRole(common.Master),
+		Prepare:   new(common.OnlyFirstMaster),
+		Action:    new(GetKubernetesNodesStatus),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	if !c.SkipDependencyCheck {
@@ -166,8 +375,13 @@ func (c *ClusterPreCheckModule) Init() {
 			getAllNodesK8sVersion,
 			calculateMinK8sVersion,
 			checkDesiredK8sVersion,
+			versionSkewCheck,
 			ksVersionCheck,
 			dependencyCheck,
+			controlPlaneHealthCheck,
+			apiDeprecationCheck,
+			pdbCheck,
+			etcdSpaceCheck,
 			getKubernetesNodesStatus,
 		}
 	} else {
@@ -176,7 +390,12 @@ func (c *ClusterPreCheckModule) Init() {
 			getAllNodesK8sVersion,
 			calculateMinK8sVersion,
 			checkDesiredK8sVersion,
+			versionSkewCheck,
 			ksVersionCheck,
+			controlPlaneHealthCheck,
+			apiDeprecationCheck,
+			pdbCheck,
+			etcdSpaceCheck,
 			getKubernetesNodesStatus,
 		}
 	}
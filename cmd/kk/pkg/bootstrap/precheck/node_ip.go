@@ -0,0 +1,161 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package precheck
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+// virtualInterfaces are the interfaces of the container runtimes, the network plugins and kube-proxy, whose addresses
+// are never selected as the node IP by the cidr and the firstPrivate policies.
+var virtualInterfaces = []string{"docker*", "br-*", "veth*", "cni*", "cali*", "tunl*", "flannel*", "vxlan*", "cilium*",
+	"weave*", "ovn*", "genev_sys*", "kube-ipvs*", "kube-bridge", "nodelocaldns"}
+
+// SelectNodeIP selects the node IP of the host without an internalAddress by the node IP policy from the addresses of
+// its network interfaces, which takes the place of the internalAddress of the host.
+type SelectNodeIP struct {
+	common.KubeAction
+}
+
+func (s *SelectNodeIP) Execute(runtime connector.Runtime) error {
+	host := runtime.RemoteHost()
+	policy := s.KubeConf.Cluster.Network.NodeIP
+	// the internalAddress defaults to the address, the one set in the config is kept
+	if !policy.Selects() || host.GetInternalAddress() != kubekeyapiv1alpha2.TrimAddressBrackets(host.GetAddress()) {
+		return nil
+	}
+
+	output, err := runtime.GetRunner().Cmd("ip -o addr show scope global", false)
+	if err != nil {
+		return errors.Wrap(err, "get the addresses of the network interfaces failed")
+	}
+	var addrs []interfaceAddr
+	for _, addr := range parseInterfaceAddrs(output) {
+		// the VIP of the control plane is on the interface of a control-plane node
+		if addr.ip.String() != s.KubeConf.Cluster.ControlPlaneEndpoint.Address {
+			addrs = append(addrs, addr)
+		}
+	}
+	if len(addrs) == 0 {
+		// the dry-run connection has no output, the address is kept
+		return nil
+	}
+
+	dualStack := strings.Contains(s.KubeConf.Cluster.Network.KubePodsCIDR, ",")
+	nodeIP, err := selectNodeIP(policy, addrs, dualStack)
+	if err != nil {
+		return errors.Wrapf(err, "select the node IP of the host %s failed", host.GetName())
+	}
+	logger.Log.Messagef(host.GetName(), "the node IP %s is selected by the %s policy", nodeIP, policy.Policy)
+	host.SetInternalAddress(nodeIP)
+	for i := range s.KubeConf.Cluster.Hosts {
+		if s.KubeConf.Cluster.Hosts[i].Name == host.GetName() {
+			s.KubeConf.Cluster.Hosts[i].InternalAddress = nodeIP
+		}
+	}
+	return nil
+}
+
+// interfaceAddr is an address of a network interface.
+type interfaceAddr struct {
+	name string
+	ip   net.IP
+}
+
+// parseInterfaceAddrs parses the output of `ip -o addr show`, e.g.
+// 2: eth0    inet 172.16.0.2/24 brd 172.16.0.255 scope global eth0\       valid_lft forever preferred_lft forever
+func parseInterfaceAddrs(output string) []interfaceAddr {
+	var addrs []interfaceAddr
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || (fields[2] != "inet" && fields[2] != "inet6") {
+			continue
+		}
+		ip, _, err := net.ParseCIDR(fields[3])
+		if err != nil {
+			continue
+		}
+		// the vlan and the veth interfaces are named like eth0.10@eth0
+		name := strings.SplitN(fields[1], "@", 2)[0]
+		addrs = append(addrs, interfaceAddr{name: name, ip: ip})
+	}
+	return addrs
+}
+
+// selectNodeIP returns the first IPv4 address, and the first IPv6 one in a dual-stack cluster, matched by the policy.
+// The IPv6 address is returned alone if the interfaces have no matched IPv4 address.
+func selectNodeIP(policy kubekeyapiv1alpha2.NodeIPPolicy, addrs []interfaceAddr, dualStack bool) (string, error) {
+	var ipv4, ipv6 string
+	for _, addr := range addrs {
+		if !matchNodeIP(policy, addr) {
+			continue
+		}
+		if addr.ip.To4() != nil {
+			if ipv4 == "" {
+				ipv4 = addr.ip.String()
+			}
+		} else if ipv6 == "" {
+			ipv6 = addr.ip.String()
+		}
+	}
+
+	switch {
+	case ipv4 == "" && ipv6 == "":
+		return "", errors.Errorf("no address of the network interfaces matches the %s node IP policy", policy.Policy)
+	case ipv4 == "":
+		return ipv6, nil
+	case dualStack && ipv6 != "":
+		return ipv4 + "," + ipv6, nil
+	default:
+		return ipv4, nil
+	}
+}
+
+func matchNodeIP(policy kubekeyapiv1alpha2.NodeIPPolicy, addr interfaceAddr) bool {
+	if policy.Policy == kubekeyapiv1alpha2.NodeIPPolicyInterface {
+		ok, _ := filepath.Match(policy.Interface, addr.name)
+		return ok
+	}
+	for _, pattern := range virtualInterfaces {
+		if ok, _ := filepath.Match(pattern, addr.name); ok {
+			return false
+		}
+	}
+
+	switch policy.Policy {
+	case kubekeyapiv1alpha2.NodeIPPolicyCIDR:
+		for _, cidr := range policy.CIDRs {
+			if _, network, err := net.ParseCIDR(cidr); err == nil && network.Contains(addr.ip) {
+				return true
+			}
+		}
+		return false
+	case kubekeyapiv1alpha2.NodeIPPolicyFirstPrivate:
+		return addr.ip.IsPrivate()
+	default:
+		return false
+	}
+}
@@ -0,0 +1,77 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package precheck
+
+import (
+	"testing"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+func TestSelectNodeIP(t *testing.T) {
+	output := `2: eth0    inet 10.0.0.11/24 brd 10.0.0.255 scope global eth0\       valid_lft forever preferred_lft forever
+3: docker0    inet 172.17.0.1/16 brd 172.17.255.255 scope global docker0\       valid_lft forever preferred_lft forever
+4: eth1.10@eth1    inet 172.16.0.11/16 brd 172.16.255.255 scope global eth1.10\       valid_lft forever preferred_lft forever
+4: eth1.10@eth1    inet6 fd00::11/64 scope global \       valid_lft forever preferred_lft forever
+5: eth2    inet 203.0.113.11/24 brd 203.0.113.255 scope global eth2\       valid_lft forever preferred_lft forever`
+
+	tests := []struct {
+		name      string
+		policy    kubekeyapiv1alpha2.NodeIPPolicy
+		dualStack bool
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:   "interface pattern",
+			policy: kubekeyapiv1alpha2.NodeIPPolicy{Policy: kubekeyapiv1alpha2.NodeIPPolicyInterface, Interface: "eth1*"},
+			want:   "172.16.0.11",
+		},
+		{
+			name:      "interface of a dual-stack cluster",
+			policy:    kubekeyapiv1alpha2.NodeIPPolicy{Policy: kubekeyapiv1alpha2.NodeIPPolicyInterface, Interface: "eth1.10"},
+			dualStack: true,
+			want:      "172.16.0.11,fd00::11",
+		},
+		{
+			name:   "cidr skips the container runtime",
+			policy: kubekeyapiv1alpha2.NodeIPPolicy{Policy: kubekeyapiv1alpha2.NodeIPPolicyCIDR, CIDRs: []string{"172.16.0.0/12"}},
+			want:   "172.16.0.11",
+		},
+		{
+			name:   "first private",
+			policy: kubekeyapiv1alpha2.NodeIPPolicy{Policy: kubekeyapiv1alpha2.NodeIPPolicyFirstPrivate},
+			want:   "10.0.0.11",
+		},
+		{
+			name:    "no matched interface",
+			policy:  kubekeyapiv1alpha2.NodeIPPolicy{Policy: kubekeyapiv1alpha2.NodeIPPolicyInterface, Interface: "bond0"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectNodeIP(tt.policy, parseInterfaceAddrs(output), tt.dualStack)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectNodeIP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("selectNodeIP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
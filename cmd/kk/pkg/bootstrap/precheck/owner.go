@@ -0,0 +1,49 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package precheck
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+// NodeOwnerCheck fails if the node is owned by another cluster sharing the host, so the cluster doesn't overwrite
+// or clean up the files of the other one. The node is taken over by removing the OwnerFile on it.
+type NodeOwnerCheck struct {
+	common.KubeAction
+}
+
+func (n *NodeOwnerCheck) Execute(runtime connector.Runtime) error {
+	host := runtime.RemoteHost()
+	out, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("cat %s 2>/dev/null || true", common.OwnerFile), false)
+	if err != nil {
+		return errors.Wrapf(err, "get the owner of node %s failed", host.GetName())
+	}
+	owner := common.ParseOwner(out)
+	if owner.Cluster == "" || owner.Cluster == runtime.GetObjName() {
+		return nil
+	}
+	if owner.Version == "" {
+		owner.Version = "unknown"
+	}
+	return errors.Errorf("node %s is owned by the cluster %s (KubeKey %s), a host can't be shared by the clusters, "+
+		"remove %s on the node to take it over", host.GetName(), owner.Cluster, owner.Version, common.OwnerFile)
+}
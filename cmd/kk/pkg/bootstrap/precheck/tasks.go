@@ -22,14 +22,20 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	versionutil "k8s.io/apimachinery/pkg/util/version"
 
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/fips"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/topology"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/version/kubernetes"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/version/kubesphere"
+	"github.com/kubesphere/kubekey/v3/util/osrelease"
 )
 
 type GreetingsTask struct {
@@ -45,6 +51,133 @@ func (h *GreetingsTask) Execute(runtime connector.Runtime) error {
 	return nil
 }
 
+// GatherArchitecture gathers the architecture of the host by `uname -m` into the host cache, which takes the place of
+// the arch of the host in the config, so each host of a mixed amd64 and arm64 cluster gets the binaries and the images
+// of its own architecture.
+type GatherArchitecture struct {
+	action.BaseAction
+}
+
+func (g *GatherArchitecture) Execute(runtime connector.Runtime) error {
+	host := runtime.RemoteHost()
+	machine, err := runtime.GetRunner().Cmd("uname -m", false)
+	if err != nil {
+		return errors.Wrap(err, "get the architecture of the host failed")
+	}
+	machine = strings.TrimSpace(machine)
+	if machine == "" {
+		// the dry-run connection has no output, the arch in the config is kept
+		return nil
+	}
+	arch := util.Arch(machine)
+	if arch == "" {
+		return errors.Errorf("unsupported architecture %s of the host %s", machine, host.GetName())
+	}
+	host.GetCache().Set(ArchitectureKey, arch)
+	if arch != host.GetArch() {
+		logger.Log.Warningf("the arch of the host %s is %s in the config, but the architecture of the host is %s, %s is used",
+			host.GetName(), host.GetArch(), arch, arch)
+		host.SetArch(arch)
+	}
+	return nil
+}
+
+// KubernetesVersionCheck fails fast if the Kubernetes version isn't in the version matrix, since the versions of the
+// components installed with it can't be resolved.
+type KubernetesVersionCheck struct {
+	common.KubeAction
+}
+
+func (k *KubernetesVersionCheck) Execute(_ connector.Runtime) error {
+	if k.KubeConf.Cluster.Kubernetes.Type != "" && k.KubeConf.Cluster.Kubernetes.Type != common.Kubernetes {
+		return nil
+	}
+	components, err := kubernetes.ResolveComponents(k.KubeConf.Cluster.Kubernetes.Version)
+	if err != nil {
+		return err
+	}
+	logger.Log.Debugf("Kubernetes %s components: %+v", k.KubeConf.Cluster.Kubernetes.Version, components)
+	return nil
+}
+
+type EtcdPlacementCheck struct {
+	common.KubeAction
+}
+
+func (e *EtcdPlacementCheck) Execute(runtime connector.Runtime) error {
+	if e.KubeConf.Cluster.Etcd.Type != "" && e.KubeConf.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey {
+		return nil
+	}
+	if err := topology.ValidateEtcdPlacement(runtime.GetHostsByRole(common.ETCD)); err != nil {
+		return errors.Wrap(err, "invalid etcd placement")
+	}
+	return nil
+}
+
+// etcdTLSMinVersionSince is the first version of etcd which supports --tls-min-version.
+var etcdTLSMinVersionSince = versionutil.MustParseSemantic("v3.5.8")
+
+// TLSPolicyCheck checks the TLS policies of the cluster spec against the versions of the components in use and the
+// FIPS mode, which can't be validated before the spec is defaulted.
+type TLSPolicyCheck struct {
+	common.KubeAction
+}
+
+func (t *TLSPolicyCheck) Execute(_ connector.Runtime) error {
+	policies := t.KubeConf.Cluster.TLS
+	if policies.Etcd.MinVersion != "" {
+		tag := kubernetes.DefaultComponents(t.KubeConf.Cluster.Kubernetes.Version).Etcd
+		v, err := versionutil.ParseSemantic(tag)
+		if err != nil {
+			return errors.Wrapf(err, "parse the version %s of etcd failed", tag)
+		}
+		if v.LessThan(etcdTLSMinVersionSince) {
+			return errors.Errorf("tls.etcd.minVersion requires etcd %s or later, but etcd %s is used by Kubernetes %s",
+				etcdTLSMinVersionSince, tag, t.KubeConf.Cluster.Kubernetes.Version)
+		}
+	}
+	if !fips.Enabled() {
+		return nil
+	}
+	approved := sets.NewString(fips.TLSCipherSuites...)
+	for _, server := range []struct {
+		name   string
+		policy kubekeyapiv1alpha2.TLSPolicy
+	}{{"apiserver", policies.Apiserver}, {"etcd", policies.Etcd}, {"kubelet", policies.Kubelet}} {
+		for _, suite := range server.policy.CipherSuites {
+			if !approved.Has(suite) {
+				return errors.Errorf("the cipher suite %s of tls.%s isn't approved in FIPS mode, it must be one of %s",
+					suite, server.name, strings.Join(fips.TLSCipherSuites, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// ImmutableOSCheck detects the immutable OS of the node and fails early with how to bootstrap the node instead,
+// rather than half-applying the mutable-OS steps to the read-only root filesystem.
+type ImmutableOSCheck struct {
+	common.KubeAction
+}
+
+func (i *ImmutableOSCheck) Execute(runtime connector.Runtime) error {
+	host := runtime.RemoteHost()
+	out, err := runtime.GetRunner().Cmd(fmt.Sprintf("cat %s 2>/dev/null || cat %s", bottlerocketOsRelease, osrelease.EtcOsRelease), false)
+	if err != nil {
+		return errors.Wrapf(err, "get os release of node %s failed", host.GetName())
+	}
+	ostree, _ := runtime.GetRunner().Cmd(fmt.Sprintf("test -e %s && echo true || echo false", ostreeBooted), false)
+
+	immutable := DetectImmutableOS(osrelease.Parse(out), strings.TrimSpace(ostree) == "true")
+	if immutable == nil {
+		host.GetCache().Set(ImmutableOSKey, "")
+		return nil
+	}
+	host.GetCache().Set(ImmutableOSKey, immutable.Name)
+	return errors.Errorf("node %s runs %s, an immutable OS which kk can't bootstrap over SSH, %s instead",
+		host.GetName(), immutable.Name, immutable.Bootstrap)
+}
+
 type NodePreCheck struct {
 	common.KubeAction
 }
@@ -70,7 +203,7 @@ func (n *NodePreCheck) Execute(runtime connector.Runtime) error {
 		case sudo:
 			// sudo skip sudo prefix
 		default:
-			cmd = connector.SudoPrefix(cmd)
+			cmd = connector.SudoCommand(runtime.RemoteHost(), cmd)
 		}
 
 		res, err := runtime.GetRunner().Cmd(cmd, false)
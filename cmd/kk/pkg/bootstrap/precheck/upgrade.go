@@ -0,0 +1,306 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package precheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	versionutil "k8s.io/apimachinery/pkg/util/version"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+const (
+	defaultEtcdDataDir = "/var/lib/etcd"
+	// etcdSpaceFactor is how many times the size of the etcd database must be free in its data dir, for the backup
+	// and the compaction of the upgrade.
+	etcdSpaceFactor = 2
+)
+
+// VersionSkewCheck checks the upgrade keeps the versions of the nodes within the version skew policy of Kubernetes:
+// no node is downgraded, the control plane is within one minor version, and the kubelets aren't older than the
+// supported skew after the control plane is upgraded to the next minor version.
+type VersionSkewCheck struct {
+	common.KubeAction
+}
+
+func (v *VersionSkewCheck) Execute(runtime connector.Runtime) error {
+	controlPlane := make(map[string]*versionutil.Version)
+	nodes := make(map[string]*versionutil.Version)
+	for _, host := range runtime.GetHostsByRole(common.K8s) {
+		s, ok := host.GetCache().GetMustString(common.NodeK8sVersion)
+		if !ok {
+			return errors.Errorf("get node %s Kubernetes version failed by host cache", host.GetName())
+		}
+		version, err := versionutil.ParseSemantic(s)
+		if err != nil {
+			return errors.Wrapf(err, "parse the Kubernetes version of the node %s failed", host.GetName())
+		}
+		if host.IsRole(common.Master) {
+			controlPlane[host.GetName()] = version
+		} else {
+			nodes[host.GetName()] = version
+		}
+	}
+	target, err := versionutil.ParseSemantic(v.KubeConf.Cluster.Kubernetes.Version)
+	if err != nil {
+		return errors.Wrap(err, "parse the target Kubernetes version failed")
+	}
+	return checkVersionSkew(controlPlane, nodes, target)
+}
+
+// maxKubeletSkew returns the minor versions the kubelets may be older than the control plane of the version.
+func maxKubeletSkew(controlPlane *versionutil.Version) uint {
+	if controlPlane.AtLeast(versionutil.MustParseSemantic("v1.28.0")) {
+		return 3
+	}
+	return 2
+}
+
+func checkVersionSkew(controlPlane, nodes map[string]*versionutil.Version, target *versionutil.Version) error {
+	all := make(map[string]*versionutil.Version, len(controlPlane)+len(nodes))
+	for name, version := range controlPlane {
+		all[name] = version
+	}
+	for name, version := range nodes {
+		all[name] = version
+	}
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// the upgrade stops by itself when all the nodes are newer than the target
+	var newer []string
+	for _, name := range names {
+		if target.LessThan(all[name]) {
+			newer = append(newer, fmt.Sprintf("%s (v%s)", name, all[name]))
+		}
+	}
+	if len(newer) > 0 && len(newer) < len(names) {
+		return errors.Errorf("the nodes %s are newer than the target Kubernetes %s, downgrades aren't supported",
+			strings.Join(newer, ", "), target)
+	}
+
+	var oldest, newest *versionutil.Version
+	for _, version := range controlPlane {
+		if oldest == nil || version.LessThan(oldest) {
+			oldest = version
+		}
+		if newest == nil || newest.LessThan(version) {
+			newest = version
+		}
+	}
+	if newest == nil {
+		return nil
+	}
+	if newest.Minor()-oldest.Minor() > 1 {
+		return errors.Errorf("the control plane runs Kubernetes v%s to v%s, more than one minor version apart", oldest, newest)
+	}
+
+	next := newest
+	if target.Minor() > newest.Minor() {
+		next = newest.WithMinor(newest.Minor() + 1)
+	}
+	skew := maxKubeletSkew(next)
+	for _, name := range names {
+		version, ok := nodes[name]
+		if !ok || version.Minor() >= next.Minor() {
+			continue
+		}
+		if next.Minor()-version.Minor() > skew {
+			return errors.Errorf("the kubelet of the node %s is v%s, more than %d minor versions older than the control plane v%d.%d of the upgrade, upgrade the node first",
+				name, version, skew, next.Major(), next.Minor())
+		}
+	}
+	return nil
+}
+
+// APIDeprecationCheck fails if the clients still request the APIs removed in the target Kubernetes version, which
+// are reported by the apiserver_requested_deprecated_apis metric of the kube-apiserver since its start.
+type APIDeprecationCheck struct {
+	common.KubeAction
+}
+
+func (a *APIDeprecationCheck) Execute(runtime connector.Runtime) error {
+	if connector.IsDryRun(runtime.GetConnector()) {
+		return nil
+	}
+	metrics, err := runtime.GetRunner().SudoCmd(
+		"/usr/local/bin/kubectl get --raw /metrics | grep '^apiserver_requested_deprecated_apis' || true", false)
+	if err != nil {
+		return errors.Wrap(err, "get the metrics of the kube-apiserver failed")
+	}
+	target, err := versionutil.ParseSemantic(a.KubeConf.Cluster.Kubernetes.Version)
+	if err != nil {
+		return errors.Wrap(err, "parse the target Kubernetes version failed")
+	}
+	if removed := removedAPIs(metrics, target); len(removed) > 0 {
+		return errors.Errorf("the APIs removed in Kubernetes %s are still requested: %s, migrate their clients and manifests first",
+			target, strings.Join(removed, ", "))
+	}
+	return nil
+}
+
+var (
+	deprecatedAPIMetric = regexp.MustCompile(`^apiserver_requested_deprecated_apis\{([^}]*)\}`)
+	metricLabel         = regexp.MustCompile(`(\w+)="([^"]*)"`)
+)
+
+// removedAPIs returns the APIs requested in the metrics which are removed in the target version, sorted.
+func removedAPIs(metrics string, target *versionutil.Version) []string {
+	seen := make(map[string]struct{})
+	var removed []string
+	for _, line := range strings.Split(metrics, "\n") {
+		m := deprecatedAPIMetric.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		labels := make(map[string]string)
+		for _, l := range metricLabel.FindAllStringSubmatch(m[1], -1) {
+			labels[l[1]] = l[2]
+		}
+		release, err := versionutil.ParseGeneric(labels["removed_release"])
+		if err != nil || target.LessThan(release) {
+			continue
+		}
+		gv := labels["version"]
+		if labels["group"] != "" {
+			gv = labels["group"] + "/" + gv
+		}
+		api := fmt.Sprintf("%s %s (removed in %s)", gv, labels["resource"], labels["removed_release"])
+		if _, ok := seen[api]; ok {
+			continue
+		}
+		seen[api] = struct{}{}
+		removed = append(removed, api)
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+// PDBCheck warns about the PodDisruptionBudgets allowing no disruption, whose pods can't be evicted when the nodes
+// are drained for the upgrade, e.g. by a maintenance script around the restarts of the kubelets.
+type PDBCheck struct {
+	common.KubeAction
+}
+
+func (p *PDBCheck) Execute(runtime connector.Runtime) error {
+	if connector.IsDryRun(runtime.GetConnector()) {
+		return nil
+	}
+	out, err := runtime.GetRunner().SudoCmd("/usr/local/bin/kubectl get pdb -A -o json", false)
+	if err != nil {
+		return errors.Wrap(err, "get the PodDisruptionBudgets failed")
+	}
+	blocking, err := blockingPDBs([]byte(out))
+	if err != nil {
+		return err
+	}
+	if len(blocking) > 0 {
+		logger.Log.Warnf("the PodDisruptionBudgets %s allow no disruption, draining their nodes is blocked until their pods are healthy or scaled up",
+			strings.Join(blocking, ", "))
+	}
+	return nil
+}
+
+// blockingPDBs returns the namespaced names of the PodDisruptionBudgets in the list which allow no disruption of
+// their pods.
+func blockingPDBs(list []byte) ([]string, error) {
+	var pdbs struct {
+		Items []struct {
+			Metadata struct {
+				Namespace string `json:"namespace"`
+				Name      string `json:"name"`
+			} `json:"metadata"`
+			Status struct {
+				DisruptionsAllowed int `json:"disruptionsAllowed"`
+				ExpectedPods       int `json:"expectedPods"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(list, &pdbs); err != nil {
+		return nil, errors.Wrap(err, "parse the PodDisruptionBudgets failed")
+	}
+	var blocking []string
+	for _, pdb := range pdbs.Items {
+		if pdb.Status.DisruptionsAllowed == 0 && pdb.Status.ExpectedPods > 0 {
+			blocking = append(blocking, pdb.Metadata.Namespace+"/"+pdb.Metadata.Name)
+		}
+	}
+	return blocking, nil
+}
+
+// EtcdSpaceCheck fails if the data dir of etcd hasn't twice the size of the database free, which the snapshot of
+// the backup and the compaction of the upgrade need.
+type EtcdSpaceCheck struct {
+	common.KubeAction
+}
+
+func (e *EtcdSpaceCheck) Execute(runtime connector.Runtime) error {
+	if e.KubeConf.Cluster.Etcd.Type != "" && e.KubeConf.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey {
+		return nil
+	}
+	if connector.IsDryRun(runtime.GetConnector()) {
+		return nil
+	}
+	dataDir := defaultEtcdDataDir
+	if d := e.KubeConf.Cluster.Etcd.DataDir; d != nil && *d != "" {
+		dataDir = *d
+	}
+	out, err := runtime.GetRunner().SudoCmd(fmt.Sprintf(
+		"du -sb %s/member/snap/db | cut -f1 && df -B1 --output=avail %s | tail -1", dataDir, dataDir), false)
+	if err != nil {
+		return errors.Wrapf(err, "get the free space of the etcd data dir %s failed", dataDir)
+	}
+	db, free, err := parseEtcdSpace(out)
+	if err != nil {
+		return err
+	}
+	if free < etcdSpaceFactor*db {
+		return errors.Errorf("the etcd data dir %s of %s has %d MiB free, less than twice the database of %d MiB",
+			dataDir, runtime.RemoteHost().GetName(), free>>20, db>>20)
+	}
+	return nil
+}
+
+// parseEtcdSpace parses the size of the etcd database and the free space of its data dir in bytes.
+func parseEtcdSpace(out string) (int64, int64, error) {
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return 0, 0, errors.Errorf("unexpected size of the etcd database and free space: %q", out)
+	}
+	db, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "parse the size of the etcd database %q failed", fields[0])
+	}
+	free, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "parse the free space of the etcd data dir %q failed", fields[1])
+	}
+	return db, free, nil
+}
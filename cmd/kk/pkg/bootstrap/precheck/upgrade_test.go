@@ -0,0 +1,93 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package precheck
+
+import (
+	"reflect"
+	"testing"
+
+	versionutil "k8s.io/apimachinery/pkg/util/version"
+)
+
+func TestCheckVersionSkew(t *testing.T) {
+	v := versionutil.MustParseSemantic
+	tests := []struct {
+		name         string
+		controlPlane map[string]*versionutil.Version
+		nodes        map[string]*versionutil.Version
+		target       string
+		wantErr      bool
+	}{
+		{name: "next minor", controlPlane: map[string]*versionutil.Version{"master1": v("v1.25.3")},
+			nodes: map[string]*versionutil.Version{"node1": v("v1.24.10")}, target: "v1.26.5"},
+		{name: "all newer", controlPlane: map[string]*versionutil.Version{"master1": v("v1.27.3")},
+			nodes: map[string]*versionutil.Version{"node1": v("v1.27.3")}, target: "v1.26.5"},
+		{name: "partial downgrade", controlPlane: map[string]*versionutil.Version{"master1": v("v1.25.3")},
+			nodes: map[string]*versionutil.Version{"node1": v("v1.27.1")}, target: "v1.26.5", wantErr: true},
+		{name: "control plane apart", controlPlane: map[string]*versionutil.Version{"master1": v("v1.23.3"), "master2": v("v1.25.3")},
+			target: "v1.26.5", wantErr: true},
+		{name: "kubelet too old", controlPlane: map[string]*versionutil.Version{"master1": v("v1.25.3")},
+			nodes: map[string]*versionutil.Version{"node1": v("v1.23.10")}, target: "v1.26.5", wantErr: true},
+		{name: "kubelet skew of 1.28", controlPlane: map[string]*versionutil.Version{"master1": v("v1.27.3")},
+			nodes: map[string]*versionutil.Version{"node1": v("v1.25.10")}, target: "v1.28.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkVersionSkew(tt.controlPlane, tt.nodes, v(tt.target))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkVersionSkew() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRemovedAPIs(t *testing.T) {
+	metrics := `apiserver_requested_deprecated_apis{group="batch",removed_release="1.25",resource="cronjobs",subresource="",version="v1beta1"} 1
+apiserver_requested_deprecated_apis{group="policy",removed_release="1.25",resource="podsecuritypolicies",subresource="",version="v1beta1"} 1
+apiserver_requested_deprecated_apis{group="flowcontrol.apiserver.k8s.io",removed_release="1.29",resource="flowschemas",subresource="",version="v1beta2"} 1`
+	got := removedAPIs(metrics, versionutil.MustParseSemantic("v1.26.5"))
+	want := []string{"batch/v1beta1 cronjobs (removed in 1.25)", "policy/v1beta1 podsecuritypolicies (removed in 1.25)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("removedAPIs() = %v, want %v", got, want)
+	}
+	if got := removedAPIs("", versionutil.MustParseSemantic("v1.26.5")); len(got) != 0 {
+		t.Errorf("removedAPIs() of no metric = %v", got)
+	}
+}
+
+func TestBlockingPDBs(t *testing.T) {
+	got, err := blockingPDBs([]byte(`{"items":[
+{"metadata":{"namespace":"db","name":"mysql"},"status":{"disruptionsAllowed":0,"expectedPods":1}},
+{"metadata":{"namespace":"web","name":"nginx"},"status":{"disruptionsAllowed":1,"expectedPods":3}},
+{"metadata":{"namespace":"idle","name":"none"},"status":{"disruptionsAllowed":0,"expectedPods":0}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"db/mysql"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("blockingPDBs() = %v, want %v", got, want)
+	}
+}
+
+func TestParseEtcdSpace(t *testing.T) {
+	db, free, err := parseEtcdSpace("104857600\n5368709120\n")
+	if err != nil || db != 100<<20 || free != 5<<30 {
+		t.Errorf("parseEtcdSpace() = %d, %d, %v", db, free, err)
+	}
+	if _, _, err := parseEtcdSpace("du: cannot access '/var/lib/etcd/member/snap/db'"); err == nil {
+		t.Error("parseEtcdSpace() of an error succeeded")
+	}
+}
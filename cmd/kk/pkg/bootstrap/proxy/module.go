@@ -0,0 +1,60 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package proxy
+
+import (
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+)
+
+// ConfigureProxyModule sets the proxy of system.proxy for the container runtime, kubelet and the package manager of
+// each node, with the no_proxy generated from the cluster. It runs before the packages and the binaries are installed.
+type ConfigureProxyModule struct {
+	common.KubeModule
+	Skip bool
+}
+
+func (c *ConfigureProxyModule) IsSkip() bool {
+	return c.Skip
+}
+
+func (c *ConfigureProxyModule) Init() {
+	c.Name = "ConfigureProxyModule"
+	c.Tags = []string{"os", "proxy"}
+	c.Desc = "Configure the proxy of the nodes"
+
+	generateDropIns := &task.RemoteTask{
+		Name:     "GenerateProxyDropIns",
+		Desc:     "Generate the proxy drop-ins of the container runtime and kubelet",
+		Hosts:    c.Runtime.GetAllHosts(),
+		Action:   new(GenerateDropIns),
+		Parallel: true,
+	}
+
+	configurePackageManager := &task.RemoteTask{
+		Name:     "ConfigurePackageManagerProxy",
+		Desc:     "Configure the proxy of the package manager",
+		Hosts:    c.Runtime.GetAllHosts(),
+		Action:   new(ConfigurePackageManager),
+		Parallel: true,
+	}
+
+	c.Tasks = []task.Interface{
+		generateDropIns,
+		configurePackageManager,
+	}
+}
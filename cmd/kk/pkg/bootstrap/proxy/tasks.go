@@ -0,0 +1,133 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package proxy
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/proxy/templates"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+// GenerateDropIns writes the proxy drop-in of each service on the node which uses the network, the running services
+// are restarted if their proxy is changed.
+type GenerateDropIns struct {
+	common.KubeAction
+}
+
+func (g *GenerateDropIns) Execute(runtime connector.Runtime) error {
+	proxy := g.KubeConf.Cluster.System.Proxy
+	content, err := util.RenderWith(templates.DropIn, util.Data{
+		"HTTPProxy":  proxy.HTTPProxy,
+		"HTTPSProxy": proxy.HTTPSProxy,
+		"NoProxy":    g.KubeConf.Cluster.NoProxy(),
+	}, util.RenderOptions{Strict: runtime.GetStrictRender()})
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "render the proxy drop-in failed")
+	}
+
+	var restart []string
+	for _, unit := range services(g.KubeConf, runtime.RemoteHost()) {
+		dst := filepath.Join("/etc/systemd/system", unit+".service.d", templates.DropIn.Name())
+		// the drop-in is tagged with the owner after the content, so it's up to date if it starts with the content
+		before, _ := runtime.GetRunner().SudoCmd(fmt.Sprintf("cat %s", dst), false)
+		if strings.HasPrefix(strings.TrimSpace(before), strings.TrimSpace(content)) {
+			continue
+		}
+		if err := action.WriteRemoteFile(runtime, unit+"-"+templates.DropIn.Name(), dst, content); err != nil {
+			return err
+		}
+		if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("systemctl is-active --quiet %s", unit), false); err == nil {
+			restart = append(restart, unit)
+		}
+	}
+	if len(restart) == 0 {
+		return nil
+	}
+	restartCmd := fmt.Sprintf("systemctl daemon-reload && systemctl restart %s", strings.Join(restart, " "))
+	if _, err := runtime.GetRunner().SudoCmd(restartCmd, false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "restart %s with the proxy failed", strings.Join(restart, ", "))
+	}
+	return nil
+}
+
+// services returns the services of the host which pull the images through the proxy: the container runtime of the
+// cluster, and kubelet on the kubernetes nodes.
+func services(kubeConf *common.KubeConf, host connector.Host) []string {
+	var units []string
+	switch kubeConf.Cluster.Kubernetes.ContainerManager {
+	case common.Docker, "":
+		units = append(units, "docker", "containerd")
+	case common.Containerd:
+		units = append(units, "containerd")
+	case common.Crio:
+		units = append(units, "crio")
+	case common.Isula:
+		units = append(units, "isulad")
+	}
+	if host.IsRole(common.K8s) {
+		units = append(units, "kubelet")
+	}
+	return units
+}
+
+// ConfigurePackageManager sets the proxy of apt by a config in /etc/apt/apt.conf.d, of yum and dnf in the main
+// section of their configs, and of zypper in /etc/sysconfig/proxy. The no_proxy isn't supported by them.
+type ConfigurePackageManager struct {
+	common.KubeAction
+}
+
+func (c *ConfigurePackageManager) Execute(runtime connector.Runtime) error {
+	proxy := c.KubeConf.Cluster.System.Proxy
+	if _, err := runtime.GetRunner().SudoCmd("test -d /etc/apt/apt.conf.d", false); err == nil {
+		content, err := util.RenderWith(templates.AptProxy, util.Data{
+			"HTTPProxy":  proxy.HTTPProxy,
+			"HTTPSProxy": proxy.HTTPSProxy,
+		}, util.RenderOptions{Strict: runtime.GetStrictRender()})
+		if err != nil {
+			return errors.Wrap(errors.WithStack(err), "render the apt proxy failed")
+		}
+		return action.WriteRemoteFile(runtime, templates.AptProxy.Name(), filepath.Join("/etc/apt/apt.conf.d", templates.AptProxy.Name()), content)
+	}
+
+	// yum, dnf and zypper use a single proxy for both http and https
+	url := proxy.HTTPSProxy
+	if url == "" {
+		url = proxy.HTTPProxy
+	}
+	for _, conf := range []string{"/etc/yum.conf", "/etc/dnf/dnf.conf"} {
+		setCmd := fmt.Sprintf("if [ -f %[1]s ]; then sed -i '/^proxy=/d' %[1]s && sed -i '/^\\[main\\]/a proxy=%[2]s' %[1]s; fi", conf, url)
+		if _, err := runtime.GetRunner().SudoCmd(setCmd, false); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "set the proxy of %s failed", conf)
+		}
+	}
+	zypperCmd := fmt.Sprintf("if [ -f /etc/sysconfig/proxy ]; then "+
+		"sed -i -e 's|^PROXY_ENABLED=.*|PROXY_ENABLED=\\\"yes\\\"|' -e 's|^HTTP_PROXY=.*|HTTP_PROXY=\\\"%[1]s\\\"|' "+
+		"-e 's|^HTTPS_PROXY=.*|HTTPS_PROXY=\\\"%[2]s\\\"|' -e 's|^NO_PROXY=.*|NO_PROXY=\\\"%[3]s\\\"|' /etc/sysconfig/proxy; fi",
+		proxy.HTTPProxy, proxy.HTTPSProxy, c.KubeConf.Cluster.NoProxy())
+	if _, err := runtime.GetRunner().SudoCmd(zypperCmd, false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "set the proxy of /etc/sysconfig/proxy failed")
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package templates
+
+import (
+	"text/template"
+
+	"github.com/lithammer/dedent"
+)
+
+// DropIn is the systemd drop-in which sets the proxy of a service, e.g. containerd.service.d/http-proxy.conf.
+var DropIn = template.Must(template.New("http-proxy.conf").Parse(
+	dedent.Dedent(`[Service]
+{{- if .HTTPProxy }}
+Environment="HTTP_PROXY={{ .HTTPProxy }}"
+Environment="http_proxy={{ .HTTPProxy }}"
+{{- end }}
+{{- if .HTTPSProxy }}
+Environment="HTTPS_PROXY={{ .HTTPSProxy }}"
+Environment="https_proxy={{ .HTTPSProxy }}"
+{{- end }}
+Environment="NO_PROXY={{ .NoProxy }}"
+Environment="no_proxy={{ .NoProxy }}"
+    `)))
+
+// AptProxy is the apt config of the proxy in /etc/apt/apt.conf.d.
+var AptProxy = template.Must(template.New("95kubekey-proxy").Parse(
+	dedent.Dedent(`{{- if .HTTPProxy }}
+Acquire::http::Proxy "{{ .HTTPProxy }}";
+{{- end }}
+{{- if .HTTPSProxy }}
+Acquire::https::Proxy "{{ .HTTPSProxy }}";
+{{- end }}
+    `)))
@@ -76,7 +76,7 @@ type FetchCerts struct {
 
 func (f *FetchCerts) Execute(runtime connector.Runtime) error {
 	src := "/etc/ssl/registry/ssl"
-	dst := fmt.Sprintf("%s/pki/registry", runtime.GetWorkDir())
+	dst := fmt.Sprintf("%s/pki/registry", runtime.GetClusterWorkDir())
 
 	certs, err := runtime.GetRunner().SudoCmd("ls /etc/ssl/registry/ssl/ | grep .pem", false)
 	if err != nil {
@@ -101,7 +101,7 @@ type GenerateCerts struct {
 
 func (g *GenerateCerts) Execute(runtime connector.Runtime) error {
 
-	pkiPath := fmt.Sprintf("%s/pki/registry", runtime.GetWorkDir())
+	pkiPath := fmt.Sprintf("%s/pki/registry", runtime.GetClusterWorkDir())
 
 	var altName cert.AltNames
 
@@ -144,8 +144,8 @@ type StartRegistryService struct {
 }
 
 func (g *StartRegistryService) Execute(runtime connector.Runtime) error {
-	installCmd := "systemctl daemon-reload && systemctl enable registry && systemctl restart registry"
-	if _, err := runtime.GetRunner().SudoCmd(installCmd, false); err != nil {
+	opts := action.ServiceOptions{DaemonReload: true, Enabled: true, State: action.ServiceRestarted}
+	if err := action.ManageService(runtime, "registry", opts); err != nil {
 		return errors.Wrap(errors.WithStack(err), "start registry service failed")
 	}
 
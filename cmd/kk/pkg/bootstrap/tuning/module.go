@@ -0,0 +1,52 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package tuning
+
+import (
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+)
+
+// TuningModule applies the sysctl and limits tuning profile of system.tuning to all the nodes. If the kernel of any
+// node doesn't take the parameters, the previous files and parameters are restored on all of them.
+type TuningModule struct {
+	common.KubeModule
+	Skip bool
+}
+
+func (t *TuningModule) IsSkip() bool {
+	return t.Skip
+}
+
+func (t *TuningModule) Init() {
+	t.Name = "TuningModule"
+	t.Tags = []string{"os", "tuning"}
+	t.Desc = "Apply the kernel tuning profile of the nodes"
+
+	applyTuning := &task.RemoteTask{
+		Name:     "ApplyTuning",
+		Desc:     "Apply the sysctl and limits tuning profile",
+		Hosts:    t.Runtime.GetAllHosts(),
+		Action:   new(ApplyTuning),
+		Parallel: true,
+		Rollback: new(RollbackTuning),
+	}
+
+	t.Tasks = []task.Interface{
+		applyTuning,
+	}
+}
@@ -0,0 +1,256 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package tuning applies the profile of the kernel parameters and the resource limits of system.tuning to the nodes,
+// verifies the kernel took the parameters, and rolls back the previous ones if it didn't.
+package tuning
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+const (
+	// SysctlFile is the file of the kernel parameters of the profile on the nodes.
+	SysctlFile = "/etc/sysctl.d/99-kubekey-tuning.conf"
+	// LimitsFile is the file of the resource limits of the profile on the nodes.
+	LimitsFile = "/etc/security/limits.d/99-kubekey-tuning.conf"
+)
+
+// Param is a kernel parameter.
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Profile is the kernel parameters and the resource limits of the nodes, the limits are lines of limits.conf, e.g.
+// "* soft nofile 1048576".
+type Profile struct {
+	Sysctls []Param
+	Limits  []string
+}
+
+var defaultProfile = Profile{
+	Sysctls: []Param{
+		{"net.core.netdev_max_backlog", "65535"},
+		{"net.core.rmem_max", "33554432"},
+		{"net.core.wmem_max", "33554432"},
+		{"net.core.somaxconn", "32768"},
+		{"net.ipv4.tcp_max_syn_backlog", "1048576"},
+		{"net.ipv4.neigh.default.gc_thresh1", "512"},
+		{"net.ipv4.neigh.default.gc_thresh2", "2048"},
+		{"net.ipv4.neigh.default.gc_thresh3", "4096"},
+		{"net.ipv4.tcp_retries2", "15"},
+		{"net.ipv4.tcp_max_tw_buckets", "1048576"},
+		{"net.ipv4.tcp_max_orphans", "65535"},
+		{"net.ipv4.tcp_keepalive_time", "600"},
+		{"net.ipv4.tcp_keepalive_intvl", "30"},
+		{"net.ipv4.tcp_keepalive_probes", "10"},
+		{"net.ipv4.udp_rmem_min", "131072"},
+		{"net.ipv4.udp_wmem_min", "131072"},
+		{"vm.max_map_count", "262144"},
+		{"vm.swappiness", "0"},
+		{"vm.overcommit_memory", "0"},
+		{"fs.inotify.max_user_instances", "524288"},
+		{"fs.inotify.max_user_watches", "524288"},
+		{"fs.pipe-max-size", "4194304"},
+		{"fs.aio-max-nr", "262144"},
+		{"kernel.pid_max", "65535"},
+		{"kernel.watchdog_thresh", "5"},
+		{"kernel.hung_task_timeout_secs", "5"},
+	},
+	Limits: []string{
+		"* soft nofile 1048576",
+		"* hard nofile 1048576",
+		"* soft nproc 65536",
+		"* hard nproc 65536",
+		"* soft memlock unlimited",
+		"* hard memlock unlimited",
+	},
+}
+
+// profiles are the built-in profiles: high-density raises the limits of the processes, the connections and the
+// neighbours of the nodes running many pods, low-latency trades the throughput for the latency of the network.
+var profiles = map[string]Profile{
+	kubekeyapiv1alpha2.TuningDefault: defaultProfile,
+	kubekeyapiv1alpha2.TuningHighDensity: defaultProfile.with([]Param{
+		{"net.core.somaxconn", "65535"},
+		{"net.ipv4.neigh.default.gc_thresh1", "8192"},
+		{"net.ipv4.neigh.default.gc_thresh2", "32768"},
+		{"net.ipv4.neigh.default.gc_thresh3", "65536"},
+		{"net.netfilter.nf_conntrack_max", "1048576"},
+		{"vm.max_map_count", "524288"},
+		{"fs.file-max", "2097152"},
+		{"fs.inotify.max_user_watches", "1048576"},
+		{"kernel.pid_max", "4194304"},
+		{"kernel.threads-max", "4194304"},
+	}, []string{
+		"* soft nproc 1048576",
+		"* hard nproc 1048576",
+	}),
+	kubekeyapiv1alpha2.TuningLowLatency: defaultProfile.with([]Param{
+		{"net.core.busy_poll", "50"},
+		{"net.core.busy_read", "50"},
+		{"net.ipv4.tcp_fastopen", "3"},
+		{"net.ipv4.tcp_slow_start_after_idle", "0"},
+		{"vm.dirty_ratio", "10"},
+		{"vm.dirty_background_ratio", "3"},
+		{"vm.stat_interval", "10"},
+		{"kernel.numa_balancing", "0"},
+	}, nil),
+}
+
+// with returns a copy of the profile with the params and the limits replacing the ones of the same keys, and the
+// others appended.
+func (p Profile) with(params []Param, limits []string) Profile {
+	out := Profile{
+		Sysctls: append([]Param(nil), p.Sysctls...),
+		Limits:  append([]string(nil), p.Limits...),
+	}
+	for _, param := range params {
+		replaced := false
+		for i := range out.Sysctls {
+			if out.Sysctls[i].Key == param.Key {
+				out.Sysctls[i].Value = param.Value
+				replaced = true
+			}
+		}
+		if !replaced {
+			out.Sysctls = append(out.Sysctls, param)
+		}
+	}
+	for _, limit := range limits {
+		key := limitKey(limit)
+		replaced := false
+		for i := range out.Limits {
+			if limitKey(out.Limits[i]) == key {
+				out.Limits[i] = limit
+				replaced = true
+			}
+		}
+		if !replaced {
+			out.Limits = append(out.Limits, limit)
+		}
+	}
+	return out
+}
+
+// limitKey is the domain, the type and the item of the limit, which identify it.
+func limitKey(limit string) string {
+	fields := strings.Fields(limit)
+	if len(fields) > 3 {
+		fields = fields[:3]
+	}
+	return strings.Join(fields, " ")
+}
+
+// customProfile is the file of the custom profile.
+type customProfile struct {
+	// Sysctls may be numbers or strings, e.g. 65535 or "30000-32767".
+	Sysctls map[string]intstr.IntOrString `json:"sysctls"`
+	Limits  []string                      `json:"limits"`
+}
+
+// Load returns the profile of the tuning, with its sysctls overriding the ones of the profile.
+func Load(tuning kubekeyapiv1alpha2.Tuning) (Profile, error) {
+	var p Profile
+	if tuning.ProfileName() == kubekeyapiv1alpha2.TuningCustom {
+		data, err := os.ReadFile(tuning.File)
+		if err != nil {
+			return Profile{}, errors.Wrapf(err, "read the tuning profile %s failed", tuning.File)
+		}
+		var custom customProfile
+		if err := yaml.UnmarshalStrict(data, &custom); err != nil {
+			return Profile{}, errors.Wrapf(err, "parse the tuning profile %s failed", tuning.File)
+		}
+		for _, limit := range custom.Limits {
+			if len(strings.Fields(limit)) != 4 {
+				return Profile{}, errors.Errorf("the limit %q of the tuning profile %s must be <domain> <type> <item> <value>", limit, tuning.File)
+			}
+		}
+		sysctls := make(map[string]string, len(custom.Sysctls))
+		for key, value := range custom.Sysctls {
+			sysctls[key] = value.String()
+		}
+		p = Profile{}.with(sortedParams(sysctls), custom.Limits)
+	} else {
+		var ok bool
+		if p, ok = profiles[tuning.ProfileName()]; !ok {
+			return Profile{}, errors.Errorf("unknown tuning profile %s", tuning.ProfileName())
+		}
+	}
+	return p.with(sortedParams(tuning.Sysctls), nil), nil
+}
+
+func sortedParams(sysctls map[string]string) []Param {
+	params := make([]Param, 0, len(sysctls))
+	for key, value := range sysctls {
+		params = append(params, Param{Key: key, Value: value})
+	}
+	sort.Slice(params, func(i, j int) bool { return params[i].Key < params[j].Key })
+	return params
+}
+
+// SysctlConf is the content of the SysctlFile of the profile.
+func (p Profile) SysctlConf() string {
+	var b strings.Builder
+	b.WriteString("# The kernel parameters of the tuning profile, managed by KubeKey.\n")
+	for _, param := range p.Sysctls {
+		fmt.Fprintf(&b, "%s = %s\n", param.Key, param.Value)
+	}
+	return b.String()
+}
+
+// LimitsConf is the content of the LimitsFile of the profile.
+func (p Profile) LimitsConf() string {
+	var b strings.Builder
+	b.WriteString("# The resource limits of the tuning profile, managed by KubeKey.\n")
+	for _, limit := range p.Limits {
+		b.WriteString(strings.Join(strings.Fields(limit), " ") + "\n")
+	}
+	return b.String()
+}
+
+// Keys are the keys of the kernel parameters of the profile.
+func (p Profile) Keys() []string {
+	keys := make([]string, 0, len(p.Sysctls))
+	for _, param := range p.Sysctls {
+		keys = append(keys, param.Key)
+	}
+	return keys
+}
+
+// ParseSysctls parses the output of `sysctl -e <keys>`, "<key> = <value>" lines, the whitespace in the values is
+// normalized to a space, e.g. net.ipv4.ip_local_port_range.
+func ParseSysctls(out string) map[string]string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		values[strings.TrimSpace(key)] = strings.Join(strings.Fields(value), " ")
+	}
+	return values
+}
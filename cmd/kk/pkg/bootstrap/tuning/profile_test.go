@@ -0,0 +1,104 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package tuning
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	custom := filepath.Join(dir, "custom.yaml")
+	if err := os.WriteFile(custom, []byte("sysctls:\n  vm.swappiness: 10\n  net.ipv4.ip_local_port_range: \"1024 65000\"\nlimits:\n  - \"* soft nofile 65536\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(invalid, []byte("limits:\n  - \"* soft nofile\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		tuning  kubekeyapiv1alpha2.Tuning
+		want    map[string]string
+		limits  []string
+		wantErr bool
+	}{
+		{
+			name:   "default with an override",
+			tuning: kubekeyapiv1alpha2.Tuning{Sysctls: map[string]string{"vm.swappiness": "1"}},
+			want:   map[string]string{"vm.swappiness": "1", "net.core.somaxconn": "32768"},
+		},
+		{
+			name:   "high-density",
+			tuning: kubekeyapiv1alpha2.Tuning{Profile: kubekeyapiv1alpha2.TuningHighDensity},
+			want:   map[string]string{"net.core.somaxconn": "65535", "net.netfilter.nf_conntrack_max": "1048576", "vm.swappiness": "0"},
+		},
+		{
+			name:   "custom",
+			tuning: kubekeyapiv1alpha2.Tuning{Profile: kubekeyapiv1alpha2.TuningCustom, File: custom, Sysctls: map[string]string{"vm.swappiness": "20"}},
+			want:   map[string]string{"vm.swappiness": "20", "net.ipv4.ip_local_port_range": "1024 65000"},
+			limits: []string{"* soft nofile 65536"},
+		},
+		{
+			name:    "custom with an invalid limit",
+			tuning:  kubekeyapiv1alpha2.Tuning{Profile: kubekeyapiv1alpha2.TuningCustom, File: invalid},
+			wantErr: true,
+		},
+		{
+			name:    "missing custom file",
+			tuning:  kubekeyapiv1alpha2.Tuning{Profile: kubekeyapiv1alpha2.TuningCustom, File: filepath.Join(dir, "missing.yaml")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Load(tt.tuning)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := ParseSysctls(p.SysctlConf())
+			for key, value := range tt.want {
+				if got[key] != value {
+					t.Errorf("Load() %s = %q, want %q", key, got[key], value)
+				}
+			}
+			if tt.limits != nil && !reflect.DeepEqual(p.Limits, tt.limits) {
+				t.Errorf("Load() limits = %v, want %v", p.Limits, tt.limits)
+			}
+		})
+	}
+}
+
+func TestParseSysctls(t *testing.T) {
+	out := "net.core.somaxconn = 32768\nnet.ipv4.ip_local_port_range = 32768\t60999\n\n"
+	want := map[string]string{
+		"net.core.somaxconn":           "32768",
+		"net.ipv4.ip_local_port_range": "32768 60999",
+	}
+	if got := ParseSysctls(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseSysctls() = %v, want %v", got, want)
+	}
+}
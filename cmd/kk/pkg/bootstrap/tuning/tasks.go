@@ -0,0 +1,208 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package tuning
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/ending"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+const (
+	// sysctlConf is the file the kernel parameters were set in before the profiles, it's applied after the SysctlFile
+	// on some distributions, so the parameters of the profile are removed from it.
+	sysctlConf = "/etc/sysctl.conf"
+	// previousKey is the key of the host cache holding the state of the host before the tuning.
+	previousKey = "tuningPrevious"
+)
+
+// previous is the state of the host before the tuning, which is restored by the rollback.
+type previous struct {
+	// sysctls are the values of the kernel parameters of the profile which the kernel has.
+	sysctls map[string]string
+	// files are the contents of the files, nil if the file didn't exist.
+	files map[string]*string
+}
+
+// ApplyTuning writes the kernel parameters and the resource limits of the profile of system.tuning to the host,
+// loads the parameters and verifies the kernel took them. The parameters the kernel of the host doesn't have, e.g.
+// net.netfilter.nf_conntrack_max before the module is loaded, are skipped.
+type ApplyTuning struct {
+	common.KubeAction
+}
+
+func (a *ApplyTuning) Execute(runtime connector.Runtime) error {
+	profile, err := Load(a.KubeConf.Cluster.System.Tuning)
+	if err != nil {
+		return err
+	}
+	keys := profile.Keys()
+	host := runtime.RemoteHost()
+	dryRun := connector.IsDryRun(runtime.GetConnector())
+
+	prev := &previous{files: make(map[string]*string)}
+	if !dryRun {
+		out, err := runtime.GetRunner().SudoCmd("sysctl -e "+strings.Join(keys, " "), false)
+		if err != nil {
+			return errors.Wrap(errors.WithStack(err), "get the kernel parameters failed")
+		}
+		prev.sysctls = ParseSysctls(out)
+		for _, path := range []string{sysctlConf, SysctlFile, LimitsFile} {
+			content, err := readFile(runtime, path)
+			if err != nil {
+				return err
+			}
+			prev.files[path] = content
+		}
+		host.GetCache().Set(previousKey, prev)
+	}
+
+	if content := prev.files[sysctlConf]; dryRun || (content != nil && hasAnyKey(*content, keys)) {
+		var exprs []string
+		for _, key := range keys {
+			exprs = append(exprs, fmt.Sprintf("-e '/^\\s*%s\\s*=/d'", strings.ReplaceAll(key, ".", "\\.")))
+		}
+		if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("sed -r -i %s %s", strings.Join(exprs, " "), sysctlConf), false); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "remove the tuned parameters from %s failed", sysctlConf)
+		}
+		action.Changed(runtime, "")
+	}
+	if err := action.WriteRemoteFile(runtime, filepath.Base(SysctlFile), SysctlFile, profile.SysctlConf()); err != nil {
+		return err
+	}
+	if err := action.WriteRemoteFile(runtime, filepath.Base(LimitsFile), LimitsFile, profile.LimitsConf()); err != nil {
+		return err
+	}
+	if out, err := runtime.GetRunner().SudoCmd("sysctl -e -p "+SysctlFile, false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "load the kernel parameters failed: %s", strings.TrimSpace(out))
+	}
+	if dryRun {
+		return nil
+	}
+
+	out, err := runtime.GetRunner().SudoCmd("sysctl -e "+strings.Join(keys, " "), false)
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "get the kernel parameters failed")
+	}
+	got := ParseSysctls(out)
+	var skipped, mismatched, changed []string
+	for _, param := range profile.Sysctls {
+		value, ok := got[param.Key]
+		if !ok {
+			skipped = append(skipped, param.Key)
+			continue
+		}
+		if want := strings.Join(strings.Fields(param.Value), " "); value != want {
+			mismatched = append(mismatched, fmt.Sprintf("%s is %s instead of %s", param.Key, value, want))
+			continue
+		}
+		if before := prev.sysctls[param.Key]; before != value {
+			changed = append(changed, fmt.Sprintf("%s: %s -> %s", param.Key, before, value))
+		}
+	}
+	if len(skipped) > 0 {
+		logger.Log.Warnf("the kernel of %s doesn't have the parameters %s, which are skipped", host.GetName(), strings.Join(skipped, ", "))
+	}
+	if len(mismatched) > 0 {
+		return errors.Errorf("the kernel didn't take the parameters of the %s profile: %s",
+			a.KubeConf.Cluster.System.Tuning.ProfileName(), strings.Join(mismatched, "; "))
+	}
+	if len(changed) > 0 {
+		action.Changed(runtime, strings.Join(changed, "\n"))
+	} else {
+		action.Unchanged(runtime)
+	}
+	return nil
+}
+
+// RollbackTuning restores the files and the kernel parameters of the host as they were before the ApplyTuning, on all
+// the hosts once it fails on any of them, so the nodes are left with the same parameters.
+type RollbackTuning struct {
+	common.KubeRollback
+}
+
+func (r *RollbackTuning) Execute(runtime connector.Runtime, _ *ending.ActionResult) error {
+	v, ok := runtime.RemoteHost().GetCache().Get(previousKey)
+	if !ok {
+		return nil
+	}
+	prev := v.(*previous)
+
+	paths := make([]string, 0, len(prev.files))
+	for path := range prev.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		cmd := fmt.Sprintf("rm -f %s", path)
+		if content := prev.files[path]; content != nil {
+			cmd = fmt.Sprintf("echo '%s' | base64 -d > %s", base64.StdEncoding.EncodeToString([]byte(*content)), path)
+		}
+		if _, err := runtime.GetRunner().SudoCmd(cmd, false); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "restore %s failed", path)
+		}
+	}
+
+	var failed []string
+	for key, value := range prev.sysctls {
+		if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("sysctl -w '%s=%s'", key, value), false); err != nil {
+			failed = append(failed, key)
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return errors.Errorf("restore the kernel parameters %s failed", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// readFile returns the content of the remote file, or nil if it doesn't exist.
+func readFile(runtime connector.Runtime, path string) (*string, error) {
+	out, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("if [ -f %s ]; then echo exists; fi", path), false)
+	if err != nil {
+		return nil, errors.Wrapf(errors.WithStack(err), "check %s failed", path)
+	}
+	if strings.TrimSpace(out) != "exists" {
+		return nil, nil
+	}
+	content, err := action.RemoteFileContent(runtime, path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %s failed", path)
+	}
+	return &content, nil
+}
+
+// hasAnyKey reports whether the content of a sysctl.conf sets any of the keys.
+func hasAnyKey(content string, keys []string) bool {
+	for _, key := range keys {
+		if regexp.MustCompile(`(?m)^\s*` + regexp.QuoteMeta(key) + `\s*=`).MatchString(content) {
+			return true
+		}
+	}
+	return false
+}
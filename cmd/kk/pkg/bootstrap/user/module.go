@@ -0,0 +1,60 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package user
+
+import (
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+)
+
+// AutomationUserModule creates the automation user of system.automationUser on all the hosts: the user, its group,
+// its authorized keys and its sudo, and disables the password authentication of sshd if it's set.
+type AutomationUserModule struct {
+	common.KubeModule
+	Skip bool
+}
+
+func (a *AutomationUserModule) IsSkip() bool {
+	return a.Skip
+}
+
+func (a *AutomationUserModule) Init() {
+	a.Name = "AutomationUserModule"
+	a.Tags = []string{"os", "users"}
+	a.Desc = "Create the automation user of the hosts"
+
+	createUser := &task.RemoteTask{
+		Name:     "CreateAutomationUser",
+		Desc:     "Create the automation user",
+		Hosts:    a.Runtime.GetAllHosts(),
+		Action:   new(CreateAutomationUser),
+		Parallel: true,
+	}
+
+	configurePasswordAuth := &task.RemoteTask{
+		Name:     "ConfigurePasswordAuth",
+		Desc:     "Configure the password authentication of sshd",
+		Hosts:    a.Runtime.GetAllHosts(),
+		Action:   new(ConfigurePasswordAuth),
+		Parallel: true,
+	}
+
+	a.Tasks = []task.Interface{
+		createUser,
+		configurePasswordAuth,
+	}
+}
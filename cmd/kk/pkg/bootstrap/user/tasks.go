@@ -0,0 +1,167 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package user
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/hardening"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+const (
+	sudoersDir = "/etc/sudoers.d"
+	// passwordAuthDropIn is the sshd drop-in disabling the password authentication, named to be included before the
+	// drop-ins of the distributions and cloud-init, since sshd uses the first value of a keyword.
+	passwordAuthDropIn = "01-kubekey-password-auth.conf"
+	passwordAuthConfig = "PasswordAuthentication no\nChallengeResponseAuthentication no\n"
+)
+
+// createUserCommand returns the command creating the group and the user if they don't exist, the existing ones are
+// kept as they are.
+func createUserCommand(user kubekeyapiv1alpha2.AutomationUser) string {
+	return fmt.Sprintf("getent group %[2]s >/dev/null || groupadd %[2]s; "+
+		"id -u %[1]s >/dev/null 2>&1 || useradd -m -g %[2]s -s %[3]s %[1]s",
+		user.Name, user.GroupName(), user.LoginShell())
+}
+
+// authorizedKeysCommand returns the command adding the keys missing from the authorized_keys of the user, the other
+// keys of it are kept.
+func authorizedKeysCommand(user kubekeyapiv1alpha2.AutomationUser, keys []string) string {
+	encoded := base64.StdEncoding.EncodeToString([]byte(strings.Join(keys, "\n") + "\n"))
+	return fmt.Sprintf("home=$(getent passwd %[1]s | cut -d: -f6) && dir=$home/.ssh && file=$dir/authorized_keys && "+
+		"mkdir -p $dir && touch $file && "+
+		"echo %[3]s | base64 -d | while IFS= read -r key; do [ -z \"$key\" ] || grep -qxF \"$key\" $file || echo \"$key\" >> $file; done && "+
+		"chmod 700 $dir && chmod 600 $file && chown -R %[1]s:%[2]s $dir && "+
+		"(restorecon -R $dir 2>/dev/null || true)", user.Name, user.GroupName(), encoded)
+}
+
+// sudoersFile returns the sudoers drop-in of the user.
+func sudoersFile(name string) string {
+	return filepath.Join(sudoersDir, "kubekey-"+name)
+}
+
+// authorizedKeys returns the keys of the user, the ones of the config and the ones of the local key files.
+func authorizedKeys(user kubekeyapiv1alpha2.AutomationUser) ([]string, error) {
+	keys := append([]string(nil), user.AuthorizedKeys...)
+	for _, file := range user.AuthorizedKeyFiles {
+		if strings.HasPrefix(file, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, err
+			}
+			file = filepath.Join(home, file[2:])
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read the key file %s of the automation user failed", file)
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				keys = append(keys, line)
+			}
+		}
+	}
+	return keys, nil
+}
+
+// CreateAutomationUser creates the automation user and its group, adds its keys to its authorized_keys, and grants
+// it the sudo without a password by a sudoers drop-in, which is removed if the sudo isn't granted.
+type CreateAutomationUser struct {
+	common.KubeAction
+}
+
+func (c *CreateAutomationUser) Execute(runtime connector.Runtime) error {
+	user := c.KubeConf.Cluster.System.AutomationUser
+	if _, err := runtime.GetRunner().SudoCmd("command -v useradd && command -v groupadd", false); err != nil {
+		return errors.Errorf("useradd and groupadd are required to create the automation user on %s", runtime.RemoteHost().GetName())
+	}
+	if _, err := runtime.GetRunner().SudoCmd(createUserCommand(user), false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "create the user %s failed", user.Name)
+	}
+
+	keys, err := authorizedKeys(user)
+	if err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		if _, err := runtime.GetRunner().SudoCmd(authorizedKeysCommand(user, keys), false); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "add the authorized keys of the user %s failed", user.Name)
+		}
+	}
+
+	dst := sudoersFile(user.Name)
+	if !user.SudoEnabled() {
+		if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("rm -f %s", dst), false); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "remove %s failed", dst)
+		}
+		return nil
+	}
+	content := fmt.Sprintf("# The automation user of KubeKey\n%s ALL=(ALL) NOPASSWD: ALL\n", user.Name)
+	if err := action.WriteRemoteFile(runtime, "sudoers-"+user.Name, dst, content); err != nil {
+		return err
+	}
+	// a broken sudoers drop-in would break the sudo of everyone
+	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("chmod 0440 %[1]s && visudo -cf %[1]s", dst), false); err != nil {
+		if _, rmErr := runtime.GetRunner().SudoCmd(fmt.Sprintf("rm -f %s", dst), false); rmErr != nil {
+			logger.Log.Warnf("remove the sudoers drop-in %s on %s failed: %v", dst, runtime.RemoteHost().GetName(), rmErr)
+		}
+		return errors.Wrapf(errors.WithStack(err), "validate the sudoers drop-in %s failed", dst)
+	}
+	return nil
+}
+
+// ConfigurePasswordAuth disables the password authentication of sshd by a drop-in if DisablePasswordAuth is set, or
+// removes the drop-in otherwise, so the setting can be reverted. The sessions of KubeKey which are open are kept, the
+// next runs must log in to the hosts with a key.
+type ConfigurePasswordAuth struct {
+	common.KubeAction
+}
+
+func (c *ConfigurePasswordAuth) Execute(runtime connector.Runtime) error {
+	host := runtime.RemoteHost()
+	user := c.KubeConf.Cluster.System.AutomationUser
+	if !user.DisablePasswordAuth {
+		dst := filepath.Join("/etc/ssh/sshd_config.d", passwordAuthDropIn)
+		cmd := fmt.Sprintf("if [ -f %[1]s ]; then rm -f %[1]s && "+
+			"(systemctl try-reload-or-restart sshd 2>/dev/null || systemctl try-reload-or-restart ssh); fi", dst)
+		if _, err := runtime.GetRunner().SudoCmd(cmd, false); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "remove %s failed", dst)
+		}
+		return nil
+	}
+
+	changed, err := hardening.WriteSSHDDropIn(runtime, "sshd-"+passwordAuthDropIn, passwordAuthDropIn, passwordAuthConfig)
+	if err != nil {
+		return err
+	}
+	if changed && host.GetPassword() != "" {
+		logger.Log.Warnf("the password authentication of sshd is disabled on %s, log in to it as %s with a key from now on",
+			host.GetName(), user.Name)
+	}
+	return nil
+}
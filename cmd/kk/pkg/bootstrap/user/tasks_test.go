@@ -0,0 +1,69 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package user
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+func TestCreateUserCommand(t *testing.T) {
+	got := createUserCommand(kubekeyapiv1alpha2.AutomationUser{Name: "kubekey", Group: "automation"})
+	want := "getent group automation >/dev/null || groupadd automation; " +
+		"id -u kubekey >/dev/null 2>&1 || useradd -m -g automation -s /bin/bash kubekey"
+	if got != want {
+		t.Errorf("createUserCommand() = %q, want %q", got, want)
+	}
+}
+
+func TestAuthorizedKeys(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "id_ed25519.pub")
+	if err := os.WriteFile(file, []byte("# the key of the bastion\nssh-ed25519 AAAAC3Nzb bastion\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	user := kubekeyapiv1alpha2.AutomationUser{
+		Name:               "kubekey",
+		AuthorizedKeys:     []string{"ssh-rsa AAAAB3Nza ci@example.com"},
+		AuthorizedKeyFiles: []string{file},
+	}
+	keys, err := authorizedKeys(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"ssh-rsa AAAAB3Nza ci@example.com", "ssh-ed25519 AAAAC3Nzb bastion"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("authorizedKeys() = %v, want %v", keys, want)
+	}
+
+	cmd := authorizedKeysCommand(user, keys)
+	encoded := base64.StdEncoding.EncodeToString([]byte(strings.Join(want, "\n") + "\n"))
+	if !strings.Contains(cmd, "getent passwd kubekey") || !strings.Contains(cmd, "echo "+encoded+" | base64 -d") ||
+		!strings.Contains(cmd, "chown -R kubekey:kubekey $dir") {
+		t.Errorf("authorizedKeysCommand() = %q", cmd)
+	}
+
+	user.AuthorizedKeyFiles = []string{filepath.Join(t.TempDir(), "missing.pub")}
+	if _, err := authorizedKeys(user); err == nil {
+		t.Error("authorizedKeys() of a missing key file succeeded, want an error")
+	}
+}
@@ -0,0 +1,97 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package bootstraptoken
+
+import (
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+)
+
+type ListTokensModule struct {
+	common.KubeModule
+}
+
+func (l *ListTokensModule) Init() {
+	l.Name = "ListTokensModule"
+	l.Desc = "List bootstrap tokens"
+
+	list := &task.RemoteTask{
+		Name:      "ListBootstrapTokens",
+		Desc:      "List bootstrap tokens",
+		Hosts:     l.Runtime.GetHostsByRole(common.Master),
+		Prepare:   new(common.OnlyFirstMaster),
+		Action:    new(ListTokens),
+		AlwaysRun: true,
+	}
+
+	display := &task.LocalTask{
+		Name:        "DisplayBootstrapTokens",
+		Desc:        "Display bootstrap tokens",
+		Action:      new(DisplayTokens),
+		RunInDryRun: true,
+	}
+
+	l.Tasks = []task.Interface{
+		list,
+		display,
+	}
+}
+
+type CreateTokenModule struct {
+	common.KubeModule
+	Options CreateOptions
+}
+
+func (c *CreateTokenModule) Init() {
+	c.Name = "CreateTokenModule"
+	c.Desc = "Create a bootstrap token"
+
+	create := &task.RemoteTask{
+		Name:    "CreateBootstrapToken",
+		Desc:    "Create a bootstrap token",
+		Hosts:   c.Runtime.GetHostsByRole(common.Master),
+		Prepare: new(common.OnlyFirstMaster),
+		Action:  &CreateToken{Options: c.Options},
+	}
+
+	c.Tasks = []task.Interface{
+		create,
+	}
+}
+
+type RevokeTokensModule struct {
+	common.KubeModule
+	IDs []string
+}
+
+func (r *RevokeTokensModule) Init() {
+	r.Name = "RevokeTokensModule"
+	r.Desc = "Revoke bootstrap tokens"
+
+	revoke := &task.RemoteTask{
+		Name:    "RevokeBootstrapTokens",
+		Desc:    "Revoke bootstrap tokens",
+		Hosts:   r.Runtime.GetHostsByRole(common.Master),
+		Prepare: new(common.OnlyFirstMaster),
+		Action:  &RevokeTokens{IDs: r.IDs},
+		Retry:   3,
+	}
+
+	r.Tasks = []task.Interface{
+		revoke,
+	}
+}
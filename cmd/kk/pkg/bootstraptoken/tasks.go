@@ -0,0 +1,94 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package bootstraptoken
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+type ListTokens struct {
+	common.KubeAction
+}
+
+func (l *ListTokens) Execute(runtime connector.Runtime) error {
+	output, err := runtime.GetRunner().SudoCmd(ListCommand, false)
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "list bootstrap tokens failed")
+	}
+	tokens, err := ParseSecrets(output)
+	if err != nil {
+		return err
+	}
+	l.PipelineCache.Set(common.BootstrapTokens, tokens)
+	return nil
+}
+
+type DisplayTokens struct {
+	common.KubeAction
+}
+
+func (d *DisplayTokens) Execute(_ connector.Runtime) error {
+	v, ok := d.PipelineCache.Get(common.BootstrapTokens)
+	if !ok {
+		return errors.New("get bootstrap tokens by pipeline cache failed")
+	}
+	return Print(os.Stdout, v.([]Token), time.Now())
+}
+
+type CreateToken struct {
+	common.KubeAction
+	Options CreateOptions
+}
+
+func (c *CreateToken) Execute(runtime connector.Runtime) error {
+	output, err := runtime.GetRunner().SudoCmd(CreateCommand(c.Options), false)
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "create bootstrap token failed")
+	}
+	if connector.IsDryRun(runtime.GetConnector()) {
+		return nil
+	}
+	if _, err := TokenID(output); err != nil {
+		return err
+	}
+	// the token is printed to the stdout only, the logs mask it
+	fmt.Println(output)
+	return nil
+}
+
+type RevokeTokens struct {
+	common.KubeAction
+	IDs []string
+}
+
+func (r *RevokeTokens) Execute(runtime connector.Runtime) error {
+	cmd, err := DeleteCommand(r.IDs)
+	if err != nil {
+		return err
+	}
+	if _, err := runtime.GetRunner().SudoCmd(cmd, true); err != nil {
+		return errors.Wrap(errors.WithStack(err), "revoke bootstrap tokens failed")
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package bootstraptoken
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	bootstrapapi "k8s.io/cluster-bootstrap/token/api"
+	bootstraputil "k8s.io/cluster-bootstrap/token/util"
+)
+
+// JoinTokenDescription is the description of the tokens created by KubeKey to join the nodes, they are revoked once
+// the nodes joined.
+const JoinTokenDescription = "Created by KubeKey to join the nodes"
+
+// DefaultUsages and DefaultGroups are the defaults of kubeadm, a token with them can join the nodes.
+var (
+	DefaultUsages = []string{"signing", "authentication"}
+	DefaultGroups = []string{"system:bootstrappers:kubeadm:default-node-token"}
+)
+
+// ListCommand prints the bootstrap token secrets in JSON, the secrets of the tokens are read by ParseSecrets.
+const ListCommand = "/usr/local/bin/kubectl -n kube-system get secrets --field-selector type=" + string(bootstrapapi.SecretTypeBootstrapToken) + " -o json"
+
+// Token is a bootstrap token of a cluster, without its secret.
+type Token struct {
+	ID          string
+	Description string
+	// Expires is zero if the token doesn't expire.
+	Expires time.Time
+	Usages  []string
+	Groups  []string
+}
+
+// ParseSecrets returns the tokens of the bootstrap token secrets printed by ListCommand, sorted by their IDs.
+func ParseSecrets(output string) ([]Token, error) {
+	// the commands have no output in the dry run
+	if strings.TrimSpace(output) == "" {
+		return nil, nil
+	}
+	secrets := &corev1.SecretList{}
+	if err := json.Unmarshal([]byte(output), secrets); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the bootstrap token secrets")
+	}
+
+	tokens := make([]Token, 0, len(secrets.Items))
+	for _, secret := range secrets.Items {
+		if secret.Type != bootstrapapi.SecretTypeBootstrapToken {
+			continue
+		}
+		token := Token{
+			ID:          string(secret.Data[bootstrapapi.BootstrapTokenIDKey]),
+			Description: string(secret.Data[bootstrapapi.BootstrapTokenDescriptionKey]),
+		}
+		if expiration := string(secret.Data[bootstrapapi.BootstrapTokenExpirationKey]); expiration != "" {
+			expires, err := time.Parse(time.RFC3339, expiration)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse the expiration of the bootstrap token %s", token.ID)
+			}
+			token.Expires = expires
+		}
+		for key, value := range secret.Data {
+			if strings.HasPrefix(key, bootstrapapi.BootstrapTokenUsagePrefix) && string(value) == "true" {
+				token.Usages = append(token.Usages, strings.TrimPrefix(key, bootstrapapi.BootstrapTokenUsagePrefix))
+			}
+		}
+		sort.Strings(token.Usages)
+		if groups := string(secret.Data[bootstrapapi.BootstrapTokenExtraGroupsKey]); groups != "" {
+			token.Groups = strings.Split(groups, ",")
+		}
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].ID < tokens[j].ID })
+	return tokens, nil
+}
+
+// CreateOptions are the options of a new bootstrap token.
+type CreateOptions struct {
+	// TTL is how long the token is valid, it doesn't expire if it is 0.
+	TTL         time.Duration
+	Usages      []string
+	Groups      []string
+	Description string
+}
+
+func (o CreateOptions) Validate() error {
+	if o.TTL < 0 {
+		return errors.Errorf("invalid ttl %s of the bootstrap token", o.TTL)
+	}
+	if err := bootstraputil.ValidateUsages(o.Usages); err != nil {
+		return err
+	}
+	for _, group := range o.Groups {
+		if err := bootstraputil.ValidateBootstrapGroupName(group); err != nil {
+			return err
+		}
+	}
+	if strings.ContainsAny(o.Description, "\n\r") {
+		return errors.New("the description of the bootstrap token must be a single line")
+	}
+	return nil
+}
+
+// CreateCommand returns the kubeadm command creating a bootstrap token with the options, it prints the token.
+func CreateCommand(o CreateOptions) string {
+	cmd := fmt.Sprintf("/usr/local/bin/kubeadm token create --ttl %s", o.TTL)
+	if len(o.Usages) > 0 {
+		cmd += " --usages " + strings.Join(o.Usages, ",")
+	}
+	if len(o.Groups) > 0 {
+		cmd += " --groups " + strings.Join(o.Groups, ",")
+	}
+	if o.Description != "" {
+		cmd += " --description '" + strings.ReplaceAll(o.Description, "'", `'\''`) + "'"
+	}
+	return cmd
+}
+
+// DeleteCommand returns the kubeadm command revoking the bootstrap tokens of the IDs.
+func DeleteCommand(ids []string) (string, error) {
+	for _, id := range ids {
+		if !bootstraputil.IsValidBootstrapTokenID(id) {
+			return "", errors.Errorf("invalid bootstrap token id %q", id)
+		}
+	}
+	return "/usr/local/bin/kubeadm token delete " + strings.Join(ids, " "), nil
+}
+
+// TokenID returns the ID of the bootstrap token in the output of kubeadm token create.
+func TokenID(output string) (string, error) {
+	for _, field := range strings.Fields(output) {
+		if bootstraputil.IsValidBootstrapToken(field) {
+			return strings.Split(field, ".")[0], nil
+		}
+	}
+	return "", errors.New("failed to find the bootstrap token in the output of kubeadm token create")
+}
+
+// Print writes the tokens as a table, the TTL is relative to now.
+func Print(w io.Writer, tokens []Token, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 10, 4, 3, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "TOKEN ID\tTTL\tEXPIRES\tUSAGES\tDESCRIPTION\tEXTRA GROUPS")
+	for _, token := range tokens {
+		ttl, expires := "<forever>", "<never>"
+		if !token.Expires.IsZero() {
+			expires = token.Expires.Format(time.RFC3339)
+			ttl = "<invalid>"
+			if token.Expires.After(now) {
+				ttl = duration.HumanDuration(token.Expires.Sub(now))
+			}
+		}
+		description := token.Description
+		if description == "" {
+			description = "<none>"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", token.ID, ttl, expires,
+			strings.Join(token.Usages, ","), description, strings.Join(token.Groups, ","))
+	}
+	return tw.Flush()
+}
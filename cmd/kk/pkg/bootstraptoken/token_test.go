@@ -0,0 +1,146 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package bootstraptoken
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const secrets = `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {
+      "apiVersion": "v1",
+      "kind": "Secret",
+      "metadata": {"name": "bootstrap-token-x9fkca", "namespace": "kube-system"},
+      "type": "bootstrap.kubernetes.io/token",
+      "data": {
+        "token-id": "eDlma2Nh",
+        "token-secret": "MDEyMzQ1Njc4OWFiY2RlZg==",
+        "usage-bootstrap-signing": "dHJ1ZQ=="
+      }
+    },
+    {
+      "apiVersion": "v1",
+      "kind": "Secret",
+      "metadata": {"name": "bootstrap-token-abcdef", "namespace": "kube-system"},
+      "type": "bootstrap.kubernetes.io/token",
+      "data": {
+        "token-id": "YWJjZGVm",
+        "token-secret": "MDEyMzQ1Njc4OWFiY2RlZg==",
+        "description": "Q3JlYXRlZCBieSBLdWJlS2V5IHRvIGpvaW4gdGhlIG5vZGVz",
+        "expiration": "MjAyMy0wMy0wMlQwODowMDowMFo=",
+        "usage-bootstrap-signing": "dHJ1ZQ==",
+        "usage-bootstrap-authentication": "dHJ1ZQ==",
+        "auth-extra-groups": "c3lzdGVtOmJvb3RzdHJhcHBlcnM6a3ViZWFkbTpkZWZhdWx0LW5vZGUtdG9rZW4="
+      }
+    }
+  ]
+}`
+
+func TestParseSecrets(t *testing.T) {
+	tokens, err := ParseSecrets(secrets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Token{
+		{
+			ID:          "abcdef",
+			Description: JoinTokenDescription,
+			Expires:     time.Date(2023, 3, 2, 8, 0, 0, 0, time.UTC),
+			Usages:      []string{"authentication", "signing"},
+			Groups:      DefaultGroups,
+		},
+		{
+			ID:     "x9fkca",
+			Usages: []string{"signing"},
+		},
+	}
+	if !reflect.DeepEqual(tokens, want) {
+		t.Errorf("ParseSecrets() = %+v, want %+v", tokens, want)
+	}
+
+	var out bytes.Buffer
+	if err := Print(&out, tokens, time.Date(2023, 3, 1, 8, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "24h") || !strings.Contains(lines[2], "<forever>") {
+		t.Errorf("Print() =\n%s", out.String())
+	}
+}
+
+func TestCreateOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options CreateOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "default",
+			options: CreateOptions{
+				TTL:         2 * time.Hour,
+				Usages:      DefaultUsages,
+				Groups:      DefaultGroups,
+				Description: "node's token",
+			},
+			want: `/usr/local/bin/kubeadm token create --ttl 2h0m0s --usages signing,authentication --groups system:bootstrappers:kubeadm:default-node-token --description 'node'\''s token'`,
+		},
+		{
+			name:    "unknown usage",
+			options: CreateOptions{Usages: []string{"admin"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid group",
+			options: CreateOptions{Groups: []string{"system:masters"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.options.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if got := CreateCommand(tt.options); got != tt.want {
+					t.Errorf("CreateCommand() = %s, want %s", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteCommand(t *testing.T) {
+	if _, err := DeleteCommand([]string{"abcdef", "abc; rm -rf /"}); err == nil {
+		t.Error("DeleteCommand() accepted an invalid token id")
+	}
+	got, err := DeleteCommand([]string{"abcdef", "x9fkca"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/usr/local/bin/kubeadm token delete abcdef x9fkca"; got != want {
+		t.Errorf("DeleteCommand() = %s, want %s", got, want)
+	}
+}
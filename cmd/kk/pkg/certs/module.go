@@ -35,14 +35,16 @@ type CheckCertsModule struct {
 
 func (c *CheckCertsModule) Init() {
 	c.Name = "CheckCertsModule"
+	c.Tags = []string{"certs"}
 	c.Desc = "Check cluster certs"
 
 	check := &task.RemoteTask{
-		Name:     "CheckClusterCerts",
-		Desc:     "Check cluster certs",
-		Hosts:    c.Runtime.GetHostsByRole(common.Master),
-		Action:   new(ListClusterCerts),
-		Parallel: true,
+		Name:      "CheckClusterCerts",
+		Desc:      "Check cluster certs",
+		Hosts:     c.Runtime.GetHostsByRole(common.Master),
+		Action:    new(ListClusterCerts),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	c.Tasks = []task.Interface{
@@ -56,12 +58,14 @@ type PrintClusterCertsModule struct {
 
 func (p *PrintClusterCertsModule) Init() {
 	p.Name = "PrintClusterCertsModule"
+	p.Tags = []string{"certs"}
 	p.Desc = "Display cluster certs form"
 
 	display := &task.LocalTask{
-		Name:   "DisplayCertsForm",
-		Desc:   "Display cluster certs form",
-		Action: new(DisplayForm),
+		Name:        "DisplayCertsForm",
+		Desc:        "Display cluster certs form",
+		Action:      new(DisplayForm),
+		RunInDryRun: true,
 	}
 
 	p.Tasks = []task.Interface{
@@ -75,6 +79,7 @@ type RenewCertsModule struct {
 
 func (r *RenewCertsModule) Init() {
 	r.Name = "RenewCertsModule"
+	r.Tags = []string{"certs"}
 	r.Desc = "Renew control-plane certs"
 
 	renew := &task.RemoteTask{
@@ -112,6 +117,7 @@ func (a *AutoRenewCertsModule) IsSkip() bool {
 
 func (a *AutoRenewCertsModule) Init() {
 	a.Name = "AutoRenewCertsModule"
+	a.Tags = []string{"certs"}
 	a.Desc = "Install auto renew control-plane certs"
 
 	generateK8sCertsRenewScript := &task.RemoteTask{
@@ -173,6 +179,7 @@ type UninstallAutoRenewCertsModule struct {
 
 func (u *UninstallAutoRenewCertsModule) Init() {
 	u.Name = "UninstallAutoRenewCertsModule"
+	u.Tags = []string{"certs"}
 	u.Desc = "UnInstall auto renew control-plane certs"
 
 	uninstall := &task.RemoteTask{
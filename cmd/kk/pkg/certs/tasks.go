@@ -319,7 +319,7 @@ func (f *FetchKubeConfig) Execute(runtime connector.Runtime) error {
 	}
 
 	host := runtime.RemoteHost()
-	if err := runtime.GetRunner().Fetch(filepath.Join(runtime.GetWorkDir(), host.GetName(), "admin.conf"), tmpConfigFile); err != nil {
+	if err := runtime.GetRunner().Fetch(filepath.Join(runtime.GetClusterWorkDir(), host.GetName(), "admin.conf"), tmpConfigFile); err != nil {
 		return errors.Wrap(errors.WithStack(err), "fetch kube config file failed")
 	}
 	return nil
@@ -336,13 +336,13 @@ func (s *SyneKubeConfigToWorker) Execute(runtime connector.Runtime) error {
 	}
 
 	firstMaster := runtime.GetHostsByRole(common.Master)[0]
-	localFile := filepath.Join(runtime.GetWorkDir(), firstMaster.GetName(), "admin.conf")
+	localFile := filepath.Join(runtime.GetClusterWorkDir(), firstMaster.GetName(), "admin.conf")
 	if err := runtime.GetRunner().SudoScp(localFile, "/root/.kube/config"); err != nil {
 		return errors.Wrap(errors.WithStack(err), "sudo scp config file to worker /root/.kube/config failed")
 	}
 
 	// that doesn't work
-	//if err := runtime.GetRunner().SudoScp(filepath.Join(runtime.GetWorkDir(), firstMaster.GetName(), "admin.conf"), "$HOME/.kube/config"); err != nil {
+	//if err := runtime.GetRunner().SudoScp(filepath.Join(runtime.GetClusterWorkDir(), firstMaster.GetName(), "admin.conf"), "$HOME/.kube/config"); err != nil {
 	//	return errors.Wrap(errors.WithStack(err), "sudo scp config file to worker $HOME/.kube/config failed")
 	//}
 
@@ -26,14 +26,17 @@ import (
 
 	kubekeyv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/files"
 )
 
 type ArtifactArgument struct {
 	ManifestFile       string
 	Output             string
 	CriSocket          string
+	Compression        string
 	Debug              bool
 	IgnoreErr          bool
+	DownloadCmd        string
 	DownloadCommand    func(path, url string) string
 	SkipRemoveArtifact bool
 }
@@ -45,6 +48,8 @@ type ArtifactRuntime struct {
 }
 
 func NewArtifactRuntime(arg ArtifactArgument) (*ArtifactRuntime, error) {
+	// the built-in downloader is used unless the user defines the download command
+	arg.DownloadCommand = files.DownloadCommand(arg.DownloadCmd)
 	localRuntime, err := NewLocalRuntime(arg.Debug, arg.IgnoreErr)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,35 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package common
+
+import "github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/cache"
+
+const (
+	CgroupDriverSystemd  = "systemd"
+	CgroupDriverCgroupfs = "cgroupfs"
+)
+
+// CgroupDriverOf returns the cgroup driver of the container runtime and kubelet chosen by the facts of the hosts, which
+// is systemd if the facts aren't gathered, e.g. with skipConfigureOS.
+func CgroupDriverOf(pipelineCache *cache.Cache) string {
+	if pipelineCache != nil {
+		if v, ok := pipelineCache.GetMustString(CgroupDriver); ok && v != "" {
+			return v
+		}
+	}
+	return CgroupDriverSystemd
+}
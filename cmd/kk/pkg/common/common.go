@@ -51,6 +51,8 @@ const (
 	RegistryCertDir = "/etc/ssl/registry/ssl"
 
 	HaproxyDir = "/etc/kubekey/haproxy"
+	// OwnerFile records the cluster and the KubeKey version which own the node.
+	OwnerFile = "/etc/kubekey/owner"
 
 	IPv4Regexp = "[\\d]+\\.[\\d]+\\.[\\d]+\\.[\\d]+"
 	IPv6Regexp = "[a-f0-9]{1,4}(:[a-f0-9]{1,4}){7}|[a-f0-9]{1,4}(:[a-f0-9]{1,4}){0,7}::[a-f0-9]{0,4}(:[a-f0-9]{1,4}){0,7}"
@@ -97,6 +99,17 @@ const (
 	Certificate   = "certificate"
 	CaCertificate = "caCertificate"
 
+	// BootstrapTokenModule
+	BootstrapTokens = "bootstrapTokens"
+
+	// ConfigureOSModule
+	CgroupDriver = "cgroupDriver"
+
 	// Artifact pipeline
 	Artifact = "artifact"
+	// Charts is the dir of the helm charts bundled in the artifact
+	Charts = "charts"
+	// Packages is the dir of the offline OS package repository under
+	// repository/<arch>/<id>/<version> in the artifact
+	Packages = "packages"
 )
@@ -17,8 +17,23 @@
 package common
 
 import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
 	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/event"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/tui"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/files"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/ipam"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/lease"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/registry"
 )
 
 type KubeRuntime struct {
@@ -30,42 +45,169 @@ type KubeRuntime struct {
 }
 
 type Argument struct {
-	NodeName            string
-	FilePath            string
-	KubernetesVersion   string
-	KsEnable            bool
-	KsVersion           string
-	Debug               bool
-	IgnoreErr           bool
-	SkipPullImages      bool
-	SkipPushImages      bool
-	SkipDependencyCheck bool
-	SecurityEnhancement bool
-	DeployLocalStorage  *bool
-	DownloadCommand     func(path, url string) string
-	SkipConfirmCheck    bool
-	ContainerManager    string
-	FromCluster         bool
-	KubeConfig          string
-	Artifact            string
-	InstallPackages     bool
-	ImagesDir           string
-	Namespace           string
-	DeleteCRI           bool
-	Role                string
-	Type                string
-	EtcdUpgrade         bool
-	WithBuildx          bool
+	NodeName                string
+	Nodes                   []string
+	AddonName               string
+	FilePath                string
+	KubernetesVersion       string
+	KsEnable                bool
+	KsVersion               string
+	Debug                   bool
+	IgnoreErr               bool
+	SkipPullImages          bool
+	SkipPushImages          bool
+	SkipDependencyCheck     bool
+	SecurityEnhancement     bool
+	DeployLocalStorage      *bool
+	DownloadCmd             string
+	DownloadCommand         func(path, url string) string
+	SkipConfirmCheck        bool
+	ContainerManager        string
+	FromCluster             bool
+	KubeConfig              string
+	Artifact                string
+	InstallPackages         bool
+	ImagesDir               string
+	Namespace               string
+	DeleteCRI               bool
+	CleanupLevel            string
+	Role                    string
+	Type                    string
+	EtcdUpgrade             bool
+	WithBuildx              bool
+	ChaosConfig             string
+	PolicyConfig            string
+	RedactionConfig         string
+	AuditLog                string
+	Strict                  bool
+	DryRun                  bool
+	RenderDir               string
+	Report                  string
+	JUnitReport             string
+	Strategy                string
+	Serial                  string
+	MaxFailPercent          int
+	Resume                  bool
+	NoTUI                   bool
+	IncludeQuarantined      bool
+	IgnoreMaintenanceWindow bool
+	CollectDiagnostics      bool
+	HostLogs                bool
+	TransferRateLimit       string
+	TransferCompression     string
+	Provision               bool
+	Deprovision             bool
+	MergeKubeConfig         bool
+	KubeConfigContext       string
+	Tags                    []string
+	SkipTags                []string
+	// ProgressRecorder records the progress of the run on the hosts, e.g. into the status of the Cluster by the
+	// operator.
+	ProgressRecorder connector.ProgressRecorder
 }
 
 func NewKubeRuntime(flag string, arg Argument) (*KubeRuntime, error) {
+	// the built-in downloader is used unless the user defines the download command
+	arg.DownloadCommand = files.DownloadCommand(arg.DownloadCmd)
 	loader := NewLoader(flag, arg)
 	cluster, err := loader.Load()
 	if err != nil {
 		return nil, err
 	}
+	if errs := cluster.Spec.Validate(field.NewPath("spec")); len(errs) > 0 {
+		return nil, errs.ToAggregate()
+	}
+
+	if _, err := connector.ParseTransferRateLimit(arg.TransferRateLimit); err != nil {
+		return nil, errors.Wrap(err, "invalid --transfer-rate-limit")
+	}
+	if err := connector.ValidateTransferCompression(arg.TransferCompression); err != nil {
+		return nil, errors.Wrap(err, "invalid --transfer-compression")
+	}
+
+	opts := connector.ConnectorOptions{Metrics: true, DryRun: arg.DryRun, RenderDir: arg.RenderDir}
+	if arg.ChaosConfig != "" {
+		if opts.Chaos, err = connector.LoadChaosConfig(arg.ChaosConfig); err != nil {
+			return nil, err
+		}
+	}
+	if arg.PolicyConfig != "" {
+		if opts.Policy, err = connector.LoadPolicyConfig(arg.PolicyConfig); err != nil {
+			return nil, err
+		}
+	}
+	if arg.AuditLog != "" {
+		if opts.Audit, err = connector.OpenAuditSink(arg.AuditLog); err != nil {
+			return nil, err
+		}
+	}
+	dialer := connector.NewConnector(opts)
+
+	base := connector.NewBaseRuntime(cluster.Name, dialer, arg.Debug, arg.IgnoreErr)
+	if err := base.InitClusterWorkDir(); err != nil {
+		return nil, err
+	}
+	if flag == File {
+		if err := base.SaveClusterContext(arg.FilePath, arg.Artifact); err != nil {
+			return nil, err
+		}
+	}
+	base.SetReportFiles(arg.Report, arg.JUnitReport)
+	strategy := connector.Strategy{Name: arg.Strategy, Serial: arg.Serial, MaxFailPercentage: arg.MaxFailPercent}
+	if err := strategy.Validate(); err != nil {
+		return nil, err
+	}
+	base.SetStrategy(strategy)
+	base.SetResume(arg.Resume)
+	base.SetStrictRender(arg.Strict)
+	base.SetCollectDiagnostics(arg.CollectDiagnostics)
+	base.SetHostLogs(arg.HostLogs)
+	base.SetProgressRecorder(arg.ProgressRecorder)
+	base.SetTagFilter(connector.TagFilter{Tags: arg.Tags, SkipTags: arg.SkipTags})
+	base.SetDeadlines(&connector.Deadlines{
+		Start:  time.Now(),
+		Run:    cluster.Spec.Deadlines.RunTimeout(),
+		Phases: cluster.Spec.Deadlines.PhaseTimeouts(),
+	})
+	maintenance, err := maintenanceWindows(cluster.Spec.MaintenanceWindow)
+	if err != nil {
+		return nil, err
+	}
+	maintenance.Ignore = arg.IgnoreMaintenanceWindow
+	base.SetMaintenanceWindows(maintenance)
+	if err := base.InitQuarantine(); err != nil {
+		return nil, err
+	}
+	base.InitHistory()
+	base.SetTUI(!arg.NoTUI && tui.Enabled())
+
+	if arg.RedactionConfig != "" {
+		redactionCfg, err := logger.LoadRedactionConfig(arg.RedactionConfig)
+		if err != nil {
+			return nil, err
+		}
+		if err := logger.Log.Redactor.AddRules(redactionCfg.Rules...); err != nil {
+			return nil, err
+		}
+	}
+
+	// the hooks notify about the changes of the cluster, which the dry-run and the render don't make
+	if len(cluster.Spec.Hooks) > 0 && !arg.DryRun && arg.RenderDir == "" {
+		base.SetEvents(newEventDispatcher(cluster.Spec.Hooks))
+	}
 
-	base := connector.NewBaseRuntime(cluster.Name, connector.NewDialer(), arg.Debug, arg.IgnoreErr)
+	if cluster.Spec.Network.IPAM.Enabled() {
+		if err := assignCIDRs(base.GetWorkDir(), cluster, arg.DryRun || arg.RenderDir != ""); err != nil {
+			return nil, err
+		}
+	}
+
+	auths, passwords, err := registry.ResolveAuths(context.Background(), cluster.Spec.Registry.Auths)
+	if err != nil {
+		return nil, err
+	}
+	cluster.Spec.Registry.Auths = auths
+	logger.Log.Redactor.AddSecrets(passwords...)
 
 	clusterSpec := &cluster.Spec
 	defaultCluster, roleGroups := clusterSpec.SetDefaultClusterSpec()
@@ -78,12 +220,37 @@ func NewKubeRuntime(flag string, arg Argument) (*KubeRuntime, error) {
 			}
 			if _, ok := hostSet[host.GetName()]; !ok {
 				hostSet[host.GetName()] = struct{}{}
+				if err := host.LoadCredentials(context.Background()); err != nil {
+					return nil, err
+				}
+				logger.Log.Redactor.AddSecrets(host.GetPassword())
+				// the flags set the transfers of the hosts which don't set their own
+				if host.GetTransferRateLimit() == "" {
+					host.SetTransferRateLimit(arg.TransferRateLimit)
+				}
+				if host.GetTransferCompression() == "" {
+					host.SetTransferCompression(arg.TransferCompression)
+				}
+				if host.GetConnectorType() == connector.ConnectorAgent && host.GetAgentCertsDir() == "" {
+					host.SetAgentCertsDir(filepath.Join(base.GetClusterWorkDir(), connector.AgentCertsDir))
+				}
 				base.AppendHost(host)
 				base.AppendRoleMap(host)
 			}
 		}
 	}
 
+	if err := skipQuarantined(&base, arg); err != nil {
+		return nil, err
+	}
+
+	// the lease is held in the cluster, which the dry-run and the render don't change
+	if cluster.Spec.Lock.Lease && !arg.DryRun && arg.RenderDir == "" {
+		if masters := base.GetHostsByRole(Master); len(masters) > 0 {
+			base.SetClusterLock(lease.New(masters[0], cluster.Spec.Lock.Duration()))
+		}
+	}
+
 	arg.KsEnable = defaultCluster.KubeSphere.Enabled
 	arg.KsVersion = defaultCluster.KubeSphere.Version
 	r := &KubeRuntime{
@@ -96,8 +263,108 @@ func NewKubeRuntime(flag string, arg Argument) (*KubeRuntime, error) {
 	return r, nil
 }
 
+// maintenanceWindows parses the maintenance windows of the cluster in their timezone.
+func maintenanceWindows(spec kubekeyapiv1alpha2.MaintenanceWindow) (*connector.MaintenanceWindows, error) {
+	m := &connector.MaintenanceWindows{Location: time.Local, Phases: spec.DisruptivePhases()}
+	for _, s := range spec.Windows {
+		w, err := connector.ParseWindow(s)
+		if err != nil {
+			return nil, err
+		}
+		m.Windows = append(m.Windows, w)
+	}
+	if spec.Timezone != "" {
+		loc, err := time.LoadLocation(spec.Timezone)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid timezone %s of the maintenance windows", spec.Timezone)
+		}
+		m.Location = loc
+	}
+	return m, nil
+}
+
+// newEventDispatcher returns the dispatcher firing the events to the hooks of the cluster, the URLs and the headers of
+// the hooks often hold tokens so they are redacted from the logs.
+func newEventDispatcher(specs []kubekeyapiv1alpha2.Hook) *event.Dispatcher {
+	hooks := make([]event.Hook, 0, len(specs))
+	for _, spec := range specs {
+		hook := event.Hook{Events: spec.Events, Timeout: spec.DeliveryTimeout()}
+		switch {
+		case spec.Webhook != "":
+			hook.Sink = &event.Webhook{URL: spec.Webhook, Headers: spec.Headers}
+			logger.Log.Redactor.AddSecrets(spec.Webhook)
+			for _, v := range spec.Headers {
+				logger.Log.Redactor.AddSecrets(v)
+			}
+		case spec.Slack != "":
+			hook.Sink = &event.Slack{URL: spec.Slack}
+			logger.Log.Redactor.AddSecrets(spec.Slack)
+		default:
+			hook.Sink = &event.Script{Script: spec.Script}
+		}
+		hooks = append(hooks, hook)
+	}
+	return event.NewDispatcher(hooks...)
+}
+
+// assignCIDRs allocates the pods and service CIDRs of the cluster from the ranges of its IPAM config before the
+// defaults are set, the allocations aren't recorded in the dry-run and the render.
+func assignCIDRs(workDir string, cluster *kubekeyapiv1alpha2.Cluster, dryRun bool) error {
+	file := filepath.Join(workDir, ipam.File)
+	store, err := ipam.Load(file)
+	if err != nil {
+		return err
+	}
+	if err := store.Assign(cluster.Name, &cluster.Spec, time.Now()); err != nil {
+		return errors.Wrapf(err, "failed to allocate the CIDRs of the cluster %s", cluster.Name)
+	}
+	allocation, _ := store.Get(cluster.Name)
+	logger.Log.Infof("ipam: the cluster %s uses the %s", cluster.Name, allocation)
+	if dryRun {
+		return nil
+	}
+	return store.Save(file)
+}
+
 // Copy is used to create a copy for Runtime.
 func (k *KubeRuntime) Copy() connector.Runtime {
 	runtime := *k
 	return &runtime
 }
+
+// skipQuarantined removes the quarantined hosts from the runtime, unless the quarantined hosts are included. The hosts
+// quarantined for being unreachable are probed first, and released if they are reachable again. A quarantined
+// control-plane or etcd node fails the run instead.
+func skipQuarantined(base *connector.BaseRuntime, arg Argument) error {
+	q := base.GetQuarantine()
+	// the dry-run and the render don't change the quarantine
+	if !arg.DryRun && arg.RenderDir == "" {
+		if released := q.Probe(base.GetConnector(), base.GetAllHosts()); len(released) > 0 {
+			for _, host := range released {
+				logger.Log.Infof("release the host %s from the quarantine, it is reachable again", host)
+			}
+			if err := q.Save(); err != nil {
+				logger.Log.Warnf("%v", err)
+			}
+		}
+	}
+
+	for _, host := range base.GetAllHosts() {
+		h, ok := q.Get(host.GetName())
+		if !ok {
+			continue
+		}
+		if arg.IncludeQuarantined {
+			logger.Log.Warnf("the quarantined host %s is included: %s", host.GetName(), h.Reason)
+			continue
+		}
+		// the cluster can't run without its control-plane and etcd nodes, unless the node is the one being removed
+		if (host.IsRole(Master) || host.IsRole(ETCD)) && host.GetName() != arg.NodeName {
+			return errors.Errorf("the quarantined host %s is a control-plane or etcd node: %s, run with --include-quarantined "+
+				"to include it, or release it by kk quarantine remove", host.GetName(), h.Reason)
+		}
+		logger.Log.Warnf("skip the quarantined host %s: %s, run with --include-quarantined to include it", host.GetName(), h.Reason)
+		base.DeleteHost(host)
+	}
+	return nil
+}
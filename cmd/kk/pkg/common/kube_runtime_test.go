@@ -0,0 +1,79 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package common
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+func TestSkipQuarantined(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name        string
+		arg         Argument
+		quarantined []string
+		wantHosts   []string
+		wantErr     bool
+	}{
+		{name: "worker skipped", quarantined: []string{"worker1"}, wantHosts: []string{"master1", "worker2"}},
+		{name: "control-plane node refused", quarantined: []string{"master1"}, wantErr: true},
+		{name: "control-plane node being removed", arg: Argument{NodeName: "master1"}, quarantined: []string{"master1"},
+			wantHosts: []string{"worker1", "worker2"}},
+		{name: "included", arg: Argument{IncludeQuarantined: true}, quarantined: []string{"master1", "worker1"},
+			wantHosts: []string{"master1", "worker1", "worker2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := connector.NewBaseRuntime("test", connector.NewFakeDialer(&connector.FakeFixtures{}), false, false)
+			for _, h := range []struct{ name, role string }{{"master1", Master}, {"worker1", Worker}, {"worker2", Worker}} {
+				host := connector.NewHost()
+				host.SetName(h.name)
+				host.SetRole(h.role)
+				base.AppendHost(host)
+				base.AppendRoleMap(host)
+			}
+			if err := base.InitQuarantine(); err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range tt.quarantined {
+				base.GetQuarantine().Add(name, "maintenance", false, now)
+			}
+
+			err := skipQuarantined(&base, tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("skipQuarantined() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var hosts []string
+			for _, host := range base.GetAllHosts() {
+				hosts = append(hosts, host.GetName())
+			}
+			if !reflect.DeepEqual(hosts, tt.wantHosts) {
+				t.Errorf("the hosts = %v, want %v", hosts, tt.wantHosts)
+			}
+		})
+	}
+}
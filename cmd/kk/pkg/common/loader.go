@@ -18,6 +18,7 @@ package common
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -35,6 +36,9 @@ import (
 
 	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/inventory"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/provision"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/sshconfig"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/version/kubesphere"
 )
 
@@ -209,7 +213,12 @@ func (f FileLoader) Load() (*kubekeyapiv1alpha2.Cluster, error) {
 			if err != nil {
 				return nil, errors.Wrap(err, "Unable to convert configuration to json")
 			}
-			if err := json.Unmarshal(contentToJson, &clusterCfg); err != nil {
+			decoder := json.NewDecoder(bytes.NewReader(contentToJson))
+			if f.arg.Strict {
+				// catch the misspelled fields in strict mode, which are ignored silently otherwise.
+				decoder.DisallowUnknownFields()
+			}
+			if err := decoder.Decode(&clusterCfg); err != nil {
 				return nil, errors.Wrap(err, "Failed to unmarshal configuration")
 			}
 			metadata := result["metadata"].(map[string]interface{})
@@ -239,6 +248,33 @@ func (f FileLoader) Load() (*kubekeyapiv1alpha2.Cluster, error) {
 		}
 	}
 
+	if clusterCfg.Spec.Inventory != "" {
+		inv, err := LoadInventory(fp, clusterCfg.Spec.Inventory)
+		if err != nil {
+			return nil, err
+		}
+		if err := inv.Apply(&clusterCfg.Spec); err != nil {
+			return nil, errors.Wrap(err, "Failed to resolve the inventory")
+		}
+		if inv.Provision != nil {
+			// the machines aren't created in the dry-run and the render
+			create := f.arg.Provision && !f.arg.DryRun && f.arg.RenderDir == ""
+			if err := provision.Apply(*inv.Provision, clusterCfg.Spec.Hosts, create); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if sshConfigPath, ok := sshconfig.Path(clusterCfg.Spec.SSHConfig, fp); ok {
+		sshCfg, err := sshconfig.Load(sshConfigPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to load the ssh config")
+		}
+		if err := sshconfig.Apply(sshCfg, clusterCfg.Spec.Hosts); err != nil {
+			return nil, errors.Wrap(err, "Failed to apply the ssh config")
+		}
+	}
+
 	if f.KubeSphereEnable {
 		ver := normalizedBuildVersion(f.KubeSphereVersion)
 		if ver == "" {
@@ -269,6 +305,15 @@ func (f FileLoader) Load() (*kubekeyapiv1alpha2.Cluster, error) {
 	return &clusterCfg, nil
 }
 
+// LoadInventory loads the inventory of the cluster config file, the relative path of the inventory is resolved
+// against the config file.
+func LoadInventory(configFile, path string) (*inventory.Inventory, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(configFile), path)
+	}
+	return inventory.Load(path)
+}
+
 type ConfigMapLoader struct {
 }
 
@@ -0,0 +1,86 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kubesphere/kubekey/v3/version"
+)
+
+const (
+	// ClusterLabel is the label of the nodes with the name of the cluster which created them.
+	ClusterLabel = "kubekey.kubesphere.io/cluster"
+	// VersionLabel is the label of the nodes with the version of KubeKey which created them.
+	VersionLabel = "kubekey.kubesphere.io/kubekey-version"
+)
+
+// Owner is the cluster and the KubeKey version which own a node, recorded in the OwnerFile on the node.
+type Owner struct {
+	Cluster string
+	Version string
+}
+
+// NewOwner returns the owner of the nodes of the cluster created by the running KubeKey.
+func NewOwner(cluster string) Owner {
+	return Owner{Cluster: cluster, Version: version.Get().GitVersion}
+}
+
+// ParseOwner parses the content of the OwnerFile, the unknown keys are ignored.
+func ParseOwner(content string) Owner {
+	var o Owner
+	for _, line := range strings.Split(content, "\n") {
+		k, v, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "cluster":
+			o.Cluster = v
+		case "kubekeyVersion":
+			o.Version = v
+		}
+	}
+	return o
+}
+
+// String returns the content of the OwnerFile.
+func (o Owner) String() string {
+	return fmt.Sprintf("cluster=%s\nkubekeyVersion=%s\n", o.Cluster, o.Version)
+}
+
+var invalidLabelValue = regexp.MustCompile(`[^-A-Za-z0-9_.]`)
+
+// Labels returns the labels of the nodes owned, the values are sanitized into valid label values.
+func (o Owner) Labels() map[string]string {
+	return map[string]string{
+		ClusterLabel: labelValue(o.Cluster),
+		VersionLabel: labelValue(o.Version),
+	}
+}
+
+// labelValue replaces the invalid characters of the label value with "-", and trims it to 63 characters starting
+// and ending with an alphanumeric character.
+func labelValue(v string) string {
+	v = invalidLabelValue.ReplaceAllString(v, "-")
+	if len(v) > 63 {
+		v = v[:63]
+	}
+	return strings.Trim(v, "-_.")
+}
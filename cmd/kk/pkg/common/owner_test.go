@@ -0,0 +1,40 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOwner(t *testing.T) {
+	owner := Owner{Cluster: "sample", Version: "v3.1.0-alpha.1+dirty"}
+	if got := ParseOwner(owner.String()); got != owner {
+		t.Errorf("ParseOwner(%q) = %+v, want %+v", owner.String(), got, owner)
+	}
+	if got := ParseOwner(""); got != (Owner{}) {
+		t.Errorf("ParseOwner() of an empty file = %+v", got)
+	}
+
+	want := map[string]string{
+		ClusterLabel: "sample",
+		VersionLabel: "v3.1.0-alpha.1-dirty",
+	}
+	if got := owner.Labels(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Labels() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,529 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+)
+
+const (
+	controlPlaneLabel = "node-role.kubernetes.io/control-plane"
+	masterLabel       = "node-role.kubernetes.io/master"
+	workerLabel       = "node-role.kubernetes.io/worker"
+
+	// etcdCheckCommand succeeds on the hosts running the etcd installed by KubeKey.
+	etcdCheckCommand = "systemctl is-active -q etcd && test -f /etc/ssl/etcd/ssl/ca.pem"
+)
+
+// ExportOptions defines the parameters of the cluster configuration exported from a live cluster.
+type ExportOptions struct {
+	Name string
+	// The SSH credentials of the exported hosts.
+	User           string
+	Password       string
+	PrivateKeyPath string
+	Port           int
+}
+
+// NodeExec runs a command on a host over SSH and returns its output. The hosts are only inspected by the Kubernetes
+// API if it is nil.
+type NodeExec func(host kubekeyapiv1alpha2.HostCfg, cmd string) (string, error)
+
+// ClusterExport is the cluster configuration exported from a live cluster, with the notices on what the cluster
+// couldn't tell, which should be checked before KubeKey manages the cluster.
+type ClusterExport struct {
+	Cluster *kubekeyapiv1alpha2.Cluster
+	Notices []string
+}
+
+func (e *ClusterExport) notice(format string, args ...interface{}) {
+	e.Notices = append(e.Notices, fmt.Sprintf(format, args...))
+}
+
+// ExportCluster inspects the versions, the container runtime, the CNI, the etcd topology and the node roles of a live
+// cluster, and returns the cluster configuration by which KubeKey can upgrade it and add or delete its nodes. The etcd
+// endpoints which aren't nodes are inspected by exec, they are external unless they run the etcd of KubeKey.
+func ExportCluster(ctx context.Context, client kubernetes.Interface, opts ExportOptions, exec NodeExec) (*ClusterExport, error) {
+	name := opts.Name
+	if name == "" {
+		name = "config-sample"
+	}
+	cluster := &kubekeyapiv1alpha2.Cluster{
+		TypeMeta:   metav1.TypeMeta{APIVersion: kubekeyapiv1alpha2.GroupVersion.String(), Kind: "Cluster"},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+	}
+	cluster.Spec.RoleGroups = map[string][]string{}
+	export := &ClusterExport{Cluster: cluster}
+
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list the nodes")
+	}
+	if len(nodes.Items) == 0 {
+		return nil, errors.New("the cluster has no nodes")
+	}
+	sort.Slice(nodes.Items, func(i, j int) bool { return nodes.Items[i].Name < nodes.Items[j].Name })
+	exportNodes(export, nodes.Items, opts)
+
+	kubeadmConfig, err := client.CoreV1().ConfigMaps("kube-system").Get(ctx, "kubeadm-config", metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, errors.Wrap(err, "Failed to get the kubeadm config")
+	}
+	if err == nil && kubeadmConfig.Data["ClusterConfiguration"] != "" {
+		if err := exportKubeadmConfig(export, kubeadmConfig.Data["ClusterConfiguration"]); err != nil {
+			return nil, err
+		}
+	} else {
+		export.notice("the cluster has no kubeadm-config ConfigMap, it isn't managed by kubeadm and its upgrades by KubeKey may fail")
+	}
+
+	kubeProxyConfig, err := client.CoreV1().ConfigMaps("kube-system").Get(ctx, "kube-proxy", metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		cluster.Spec.Kubernetes.DisableKubeProxy = true
+	case err != nil:
+		return nil, errors.Wrap(err, "Failed to get the kube-proxy config")
+	default:
+		if err := exportKubeProxyConfig(export, kubeProxyConfig.Data["config.conf"]); err != nil {
+			return nil, err
+		}
+	}
+
+	daemonSets, err := client.AppsV1().DaemonSets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list the daemonsets")
+	}
+	names := make([]string, 0, len(daemonSets.Items))
+	for _, ds := range daemonSets.Items {
+		names = append(names, ds.Name)
+	}
+	cluster.Spec.Network.Plugin = networkPlugin(names)
+	if cluster.Spec.Network.Plugin == "none" {
+		export.notice("no known CNI is found, network.plugin is set to none")
+	}
+
+	pods, err := client.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list the pods of kube-system")
+	}
+	for _, pod := range pods.Items {
+		switch {
+		case strings.HasPrefix(pod.Name, "haproxy-"):
+			cluster.Spec.ControlPlaneEndpoint.InternalLoadbalancer = kubekeyapiv1alpha2.Haproxy
+		case strings.HasPrefix(pod.Name, "kube-vip"):
+			cluster.Spec.ControlPlaneEndpoint.InternalLoadbalancer = kubekeyapiv1alpha2.Kubevip
+		}
+	}
+	exportEtcd(export, pods.Items, opts, exec)
+
+	if opts.User == "" && opts.Password == "" && opts.PrivateKeyPath == "" {
+		export.notice("the SSH credentials of the hosts aren't set, complete them before KubeKey connects to the hosts")
+	}
+	return export, nil
+}
+
+func exportNodes(export *ClusterExport, nodes []corev1.Node, opts ExportOptions) {
+	spec := &export.Cluster.Spec
+	versions := map[string][]string{}
+	runtimes := map[string][]string{}
+	for _, node := range nodes {
+		host := kubekeyapiv1alpha2.HostCfg{
+			Name:           node.Name,
+			Port:           opts.Port,
+			User:           opts.User,
+			Password:       opts.Password,
+			PrivateKeyPath: opts.PrivateKeyPath,
+			Arch:           node.Status.NodeInfo.Architecture,
+		}
+		for _, address := range node.Status.Addresses {
+			if address.Type == corev1.NodeInternalIP && host.InternalAddress == "" {
+				host.InternalAddress = address.Address
+			}
+		}
+		host.Address = host.InternalAddress
+		spec.Hosts = append(spec.Hosts, host)
+
+		_, controlPlane := node.Labels[controlPlaneLabel]
+		if _, ok := node.Labels[masterLabel]; ok {
+			controlPlane = true
+		}
+		if controlPlane {
+			spec.RoleGroups[common.Master] = append(spec.RoleGroups[common.Master], node.Name)
+		}
+		if _, ok := node.Labels[workerLabel]; ok || !controlPlane || schedulable(node) {
+			spec.RoleGroups[common.Worker] = append(spec.RoleGroups[common.Worker], node.Name)
+		}
+
+		versions[node.Status.NodeInfo.KubeletVersion] = append(versions[node.Status.NodeInfo.KubeletVersion], node.Name)
+		runtime := containerManager(node.Status.NodeInfo.ContainerRuntimeVersion)
+		runtimes[runtime] = append(runtimes[runtime], node.Name)
+		if pods, ok := node.Status.Capacity[corev1.ResourcePods]; ok && int(pods.Value()) > spec.Kubernetes.MaxPods {
+			spec.Kubernetes.MaxPods = int(pods.Value())
+		}
+	}
+
+	// the oldest kubelet is the version of the cluster until the kubeadm config tells it
+	for version := range versions {
+		if spec.Kubernetes.Version == "" || compareVersions(version, spec.Kubernetes.Version) < 0 {
+			spec.Kubernetes.Version = version
+		}
+	}
+	if len(versions) > 1 {
+		export.notice("the nodes run different kubelet versions: %s", describe(versions))
+	}
+	if strings.Contains(spec.Kubernetes.Version, "+k3s") {
+		spec.Kubernetes.Type = "k3s"
+	}
+
+	for runtime := range runtimes {
+		if spec.Kubernetes.ContainerManager == "" || len(runtimes[runtime]) > len(runtimes[spec.Kubernetes.ContainerManager]) {
+			spec.Kubernetes.ContainerManager = runtime
+		}
+	}
+	if len(runtimes) > 1 {
+		export.notice("the nodes run different container runtimes: %s, kubernetes.containerManager is set to %s",
+			describe(runtimes), spec.Kubernetes.ContainerManager)
+	}
+	if _, ok := runtimes[""]; ok {
+		export.notice("the container runtime of the nodes %s isn't supported by KubeKey", strings.Join(runtimes[""], ", "))
+	}
+}
+
+// schedulable tells whether the workloads are scheduled to a control-plane node, which is also a worker then.
+func schedulable(node corev1.Node) bool {
+	for _, taint := range node.Spec.Taints {
+		if (taint.Key == controlPlaneLabel || taint.Key == masterLabel) && taint.Effect == corev1.TaintEffectNoSchedule {
+			return false
+		}
+	}
+	return true
+}
+
+// containerManager returns the containerManager of the runtime version of a node, such as containerd://1.6.4.
+func containerManager(runtimeVersion string) string {
+	switch strings.SplitN(runtimeVersion, "://", 2)[0] {
+	case "docker":
+		return common.Docker
+	case "containerd":
+		return common.Containerd
+	case "cri-o":
+		return common.Crio
+	case "isulad":
+		return common.Isula
+	default:
+		return ""
+	}
+}
+
+func networkPlugin(daemonSets []string) string {
+	plugins := []struct{ daemonSet, plugin string }{
+		{"calico-node", "calico"},
+		{"kube-flannel", "flannel"},
+		{"cilium", "cilium"},
+		{"kube-ovn-cni", "kubeovn"},
+		{"hybridnet", "hybridnet"},
+	}
+	for _, p := range plugins {
+		for _, name := range daemonSets {
+			if strings.HasPrefix(name, p.daemonSet) {
+				return p.plugin
+			}
+		}
+	}
+	return "none"
+}
+
+type kubeadmClusterConfiguration struct {
+	KubernetesVersion    string `json:"kubernetesVersion"`
+	ClusterName          string `json:"clusterName"`
+	ControlPlaneEndpoint string `json:"controlPlaneEndpoint"`
+	Networking           struct {
+		DNSDomain     string `json:"dnsDomain"`
+		PodSubnet     string `json:"podSubnet"`
+		ServiceSubnet string `json:"serviceSubnet"`
+	} `json:"networking"`
+	ControllerManager struct {
+		ExtraArgs map[string]string `json:"extraArgs"`
+	} `json:"controllerManager"`
+}
+
+func exportKubeadmConfig(export *ClusterExport, data string) error {
+	var cfg kubeadmClusterConfiguration
+	if err := yaml.Unmarshal([]byte(data), &cfg); err != nil {
+		return errors.Wrap(err, "Failed to parse the kubeadm config")
+	}
+	spec := &export.Cluster.Spec
+	if cfg.KubernetesVersion != "" {
+		spec.Kubernetes.Version = cfg.KubernetesVersion
+	}
+	spec.Kubernetes.ClusterName = cfg.ClusterName
+	spec.Kubernetes.DNSDomain = cfg.Networking.DNSDomain
+	spec.Network.KubePodsCIDR = cfg.Networking.PodSubnet
+	spec.Network.KubeServiceCIDR = cfg.Networking.ServiceSubnet
+	if size, err := strconv.Atoi(cfg.ControllerManager.ExtraArgs["node-cidr-mask-size"]); err == nil {
+		spec.Kubernetes.NodeCidrMaskSize = size
+	}
+
+	if cfg.ControlPlaneEndpoint != "" {
+		address, port, err := net.SplitHostPort(cfg.ControlPlaneEndpoint)
+		if err != nil {
+			address, port = cfg.ControlPlaneEndpoint, "6443"
+		}
+		if net.ParseIP(address) != nil {
+			spec.ControlPlaneEndpoint.Address = address
+		} else {
+			spec.ControlPlaneEndpoint.Domain = address
+		}
+		spec.ControlPlaneEndpoint.Port, _ = strconv.Atoi(port)
+	}
+	return nil
+}
+
+type kubeProxyConfiguration struct {
+	Mode     string `json:"mode"`
+	IPTables struct {
+		MasqueradeAll bool `json:"masqueradeAll"`
+	} `json:"iptables"`
+}
+
+func exportKubeProxyConfig(export *ClusterExport, data string) error {
+	var cfg kubeProxyConfiguration
+	if err := yaml.Unmarshal([]byte(data), &cfg); err != nil {
+		return errors.Wrap(err, "Failed to parse the kube-proxy config")
+	}
+	spec := &export.Cluster.Spec
+	if cfg.Mode == "ipvs" {
+		spec.Kubernetes.ProxyMode = "ipvs"
+	} else {
+		spec.Kubernetes.ProxyMode = "iptables"
+	}
+	spec.Kubernetes.MasqueradeAll = cfg.IPTables.MasqueradeAll
+	return nil
+}
+
+// exportEtcd sets the etcd of the config by the etcd static pods of kubeadm, or by the etcd servers of kube-apiserver.
+// The servers which are nodes run the etcd of KubeKey, the others too when exec tells so, otherwise they are external.
+func exportEtcd(export *ClusterExport, pods []corev1.Pod, opts ExportOptions, exec NodeExec) {
+	spec := &export.Cluster.Spec
+	var apiserverArgs map[string]string
+	for _, pod := range pods {
+		switch pod.Labels["component"] {
+		case "etcd":
+			spec.Etcd.Type = kubekeyapiv1alpha2.Kubeadm
+			spec.RoleGroups[common.ETCD] = appendOnce(spec.RoleGroups[common.ETCD], pod.Spec.NodeName)
+		case "kube-apiserver":
+			if apiserverArgs == nil && len(pod.Spec.Containers) > 0 {
+				apiserverArgs = parseArgs(pod.Spec.Containers[0].Command)
+			}
+		}
+	}
+	if spec.Etcd.Type == kubekeyapiv1alpha2.Kubeadm {
+		return
+	}
+	if apiserverArgs["etcd-servers"] == "" {
+		spec.Etcd.Type = kubekeyapiv1alpha2.KubeKey
+		spec.RoleGroups[common.ETCD] = append([]string(nil), spec.RoleGroups[common.Master]...)
+		export.notice("the etcd servers of kube-apiserver aren't found, the control-plane hosts are assumed to run etcd")
+		return
+	}
+
+	hostsByAddress := map[string]string{}
+	for _, host := range spec.Hosts {
+		hostsByAddress[host.InternalAddress] = host.Name
+	}
+	var members, external []string
+	for _, server := range strings.Split(apiserverArgs["etcd-servers"], ",") {
+		u, err := url.Parse(server)
+		if err != nil || u.Hostname() == "" {
+			external = append(external, server)
+			continue
+		}
+		if name, ok := hostsByAddress[u.Hostname()]; ok {
+			members = append(members, name)
+			continue
+		}
+		if exec == nil {
+			external = append(external, server)
+			continue
+		}
+		host := kubekeyapiv1alpha2.HostCfg{
+			Address:         u.Hostname(),
+			InternalAddress: u.Hostname(),
+			Port:            opts.Port,
+			User:            opts.User,
+			Password:        opts.Password,
+			PrivateKeyPath:  opts.PrivateKeyPath,
+		}
+		hostname, err := exec(host, "hostname && "+etcdCheckCommand)
+		if err != nil {
+			external = append(external, server)
+			continue
+		}
+		host.Name = strings.TrimSpace(hostname)
+		spec.Hosts = append(spec.Hosts, host)
+		hostsByAddress[host.InternalAddress] = host.Name
+		members = append(members, host.Name)
+	}
+
+	if len(external) > 0 {
+		spec.Etcd.Type = kubekeyapiv1alpha2.External
+		spec.Etcd.External = kubekeyapiv1alpha2.ExternalEtcd{
+			Endpoints: strings.Split(apiserverArgs["etcd-servers"], ","),
+			CAFile:    apiserverArgs["etcd-cafile"],
+			CertFile:  apiserverArgs["etcd-certfile"],
+			KeyFile:   apiserverArgs["etcd-keyfile"],
+		}
+		export.notice("etcd is external, copy its certs from the control-plane hosts to etcd.external on this machine")
+		return
+	}
+	spec.Etcd.Type = kubekeyapiv1alpha2.KubeKey
+	spec.RoleGroups[common.ETCD] = members
+	if exec == nil {
+		export.notice("the etcd servers are assumed to be installed by KubeKey, connect to the hosts by SSH to check it")
+		return
+	}
+	for _, name := range members {
+		for _, host := range spec.Hosts {
+			if host.Name == name {
+				if _, err := exec(host, etcdCheckCommand); err != nil {
+					export.notice("the etcd on %s isn't installed by KubeKey: %v", name, err)
+				}
+			}
+		}
+	}
+}
+
+// parseArgs returns the values of the --key=value arguments of a command.
+func parseArgs(command []string) map[string]string {
+	args := map[string]string{}
+	for _, arg := range command {
+		if !strings.HasPrefix(arg, "--") {
+			continue
+		}
+		kv := strings.SplitN(strings.TrimPrefix(arg, "--"), "=", 2)
+		if len(kv) == 2 {
+			args[kv[0]] = kv[1]
+		}
+	}
+	return args
+}
+
+func appendOnce(s []string, v string) []string {
+	for _, e := range s {
+		if e == v {
+			return s
+		}
+	}
+	return append(s, v)
+}
+
+// compareVersions compares the major, minor and patch numbers of two versions such as v1.24.3+k3s1.
+func compareVersions(a, b string) int {
+	pa, pb := versionNumbers(a), versionNumbers(b)
+	for i := range pa {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionNumbers(v string) [3]int {
+	var numbers [3]int
+	v = strings.SplitN(strings.SplitN(strings.TrimPrefix(v, "v"), "+", 2)[0], "-", 2)[0]
+	for i, part := range strings.SplitN(v, ".", 3) {
+		numbers[i], _ = strconv.Atoi(part)
+	}
+	return numbers
+}
+
+func describe(groups map[string][]string) string {
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		name := k
+		if name == "" {
+			name = "unknown"
+		}
+		parts = append(parts, fmt.Sprintf("%s on %s", name, strings.Join(groups[k], ", ")))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Marshal returns the YAML of the exported cluster configuration, the notices are comments at its top. The empty
+// fields are omitted.
+func (e *ClusterExport) Marshal() ([]byte, error) {
+	data, err := yaml.Marshal(e.Cluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to marshal the cluster config")
+	}
+	var obj map[string]interface{}
+	if err := yaml.Unmarshal(data, &obj); err != nil {
+		return nil, errors.Wrap(err, "Failed to marshal the cluster config")
+	}
+	delete(obj, "status")
+	pruneEmpty(obj)
+	if data, err = yaml.Marshal(obj); err != nil {
+		return nil, errors.Wrap(err, "Failed to marshal the cluster config")
+	}
+
+	var b strings.Builder
+	for _, notice := range e.Notices {
+		fmt.Fprintf(&b, "# NOTICE: %s\n", notice)
+	}
+	b.Write(data)
+	return []byte(b.String()), nil
+}
+
+// pruneEmpty removes the nulls, the empty strings and the empty maps of a map recursively.
+func pruneEmpty(obj map[string]interface{}) {
+	for k, v := range obj {
+		if m, ok := v.(map[string]interface{}); ok {
+			pruneEmpty(m)
+			if len(m) == 0 {
+				delete(obj, k)
+			}
+			continue
+		}
+		if v == nil || v == "" {
+			delete(obj, k)
+		}
+	}
+}
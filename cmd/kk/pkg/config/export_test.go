@@ -0,0 +1,167 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+func testNode(name, ip, kubelet, runtime string, labels map[string]string, taints ...corev1.Taint) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Spec:       corev1.NodeSpec{Taints: taints},
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: ip}},
+			Capacity:  corev1.ResourceList{corev1.ResourcePods: resource.MustParse("110")},
+			NodeInfo: corev1.NodeSystemInfo{
+				KubeletVersion:          kubelet,
+				ContainerRuntimeVersion: runtime,
+				Architecture:            "amd64",
+			},
+		},
+	}
+}
+
+func testPod(name, node, component string, command ...string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: name, Labels: map[string]string{"component": component}},
+		Spec:       corev1.PodSpec{NodeName: node, Containers: []corev1.Container{{Name: component, Command: command}}},
+	}
+}
+
+func TestExportCluster(t *testing.T) {
+	noSchedule := corev1.Taint{Key: controlPlaneLabel, Effect: corev1.TaintEffectNoSchedule}
+	objects := []runtime.Object{
+		testNode("node1", "10.0.0.1", "v1.24.3", "containerd://1.6.4", map[string]string{controlPlaneLabel: ""}, noSchedule),
+		testNode("node2", "10.0.0.2", "v1.23.10", "containerd://1.6.4", nil),
+		testNode("node3", "10.0.0.3", "v1.24.3", "docker://20.10.8", map[string]string{masterLabel: ""}),
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "kubeadm-config"},
+			Data: map[string]string{"ClusterConfiguration": `kubernetesVersion: v1.24.3
+clusterName: cluster.local
+controlPlaneEndpoint: lb.kubesphere.local:6443
+networking:
+  dnsDomain: cluster.local
+  podSubnet: 10.233.64.0/18
+  serviceSubnet: 10.233.0.0/18
+controllerManager:
+  extraArgs:
+    node-cidr-mask-size: "24"
+`},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "kube-proxy"},
+			Data:       map[string]string{"config.conf": "mode: ipvs\niptables:\n  masqueradeAll: true\n"},
+		},
+		&appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "calico-node"}},
+		testPod("haproxy-node2", "node2", ""),
+		testPod("kube-apiserver-node1", "node1", "kube-apiserver", "kube-apiserver",
+			"--etcd-servers=https://10.0.0.1:2379,https://10.0.0.9:2379", "--etcd-cafile=/etc/ssl/etcd/ssl/ca.pem"),
+	}
+
+	tests := []struct {
+		name         string
+		exec         NodeExec
+		wantEtcdType string
+		wantEtcd     []string
+		wantHosts    int
+	}{
+		{
+			name:         "the etcd servers which aren't nodes are external without SSH",
+			wantEtcdType: kubekeyapiv1alpha2.External,
+			wantHosts:    3,
+		},
+		{
+			name: "the etcd servers which aren't nodes are hosts when they run the etcd of KubeKey",
+			exec: func(host kubekeyapiv1alpha2.HostCfg, cmd string) (string, error) {
+				if host.Address == "10.0.0.9" {
+					return "etcd1\n", nil
+				}
+				return "", nil
+			},
+			wantEtcdType: kubekeyapiv1alpha2.KubeKey,
+			wantEtcd:     []string{"node1", "etcd1"},
+			wantHosts:    4,
+		},
+		{
+			name: "the etcd servers are external when they don't run the etcd of KubeKey",
+			exec: func(host kubekeyapiv1alpha2.HostCfg, cmd string) (string, error) {
+				return "", errors.New("exited with 1")
+			},
+			wantEtcdType: kubekeyapiv1alpha2.External,
+			wantHosts:    3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(objects...)
+			export, err := ExportCluster(context.Background(), client, ExportOptions{Name: "sample", User: "ubuntu"}, tt.exec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			spec := export.Cluster.Spec
+			if spec.Kubernetes.Version != "v1.24.3" || spec.Kubernetes.ContainerManager != "containerd" ||
+				spec.Kubernetes.ProxyMode != "ipvs" || !spec.Kubernetes.MasqueradeAll || spec.Kubernetes.MaxPods != 110 {
+				t.Errorf("unexpected kubernetes %+v", spec.Kubernetes)
+			}
+			if spec.Network.Plugin != "calico" || spec.Network.KubePodsCIDR != "10.233.64.0/18" {
+				t.Errorf("unexpected network %+v", spec.Network)
+			}
+			if spec.ControlPlaneEndpoint.Domain != "lb.kubesphere.local" || spec.ControlPlaneEndpoint.Port != 6443 ||
+				spec.ControlPlaneEndpoint.InternalLoadbalancer != kubekeyapiv1alpha2.Haproxy {
+				t.Errorf("unexpected control plane endpoint %+v", spec.ControlPlaneEndpoint)
+			}
+			if want := []string{"node1", "node3"}; !reflect.DeepEqual(spec.RoleGroups["master"], want) {
+				t.Errorf("master = %v, want %v", spec.RoleGroups["master"], want)
+			}
+			if want := []string{"node2", "node3"}; !reflect.DeepEqual(spec.RoleGroups["worker"], want) {
+				t.Errorf("worker = %v, want %v", spec.RoleGroups["worker"], want)
+			}
+			if spec.Etcd.Type != tt.wantEtcdType || !reflect.DeepEqual(spec.RoleGroups["etcd"], tt.wantEtcd) {
+				t.Errorf("etcd = %s %v, want %s %v", spec.Etcd.Type, spec.RoleGroups["etcd"], tt.wantEtcdType, tt.wantEtcd)
+			}
+			if len(spec.Hosts) != tt.wantHosts {
+				t.Errorf("got %d hosts, want %d", len(spec.Hosts), tt.wantHosts)
+			}
+			if len(export.Notices) == 0 {
+				t.Error("the kubelet and runtime skews aren't noticed")
+			}
+
+			data, err := export.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(string(data), "# NOTICE: ") || strings.Contains(string(data), "status:") ||
+				strings.Contains(string(data), "null") || !strings.Contains(string(data), "kind: Cluster") {
+				t.Errorf("unexpected config:\n%s", data)
+			}
+		})
+	}
+}
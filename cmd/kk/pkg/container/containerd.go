@@ -62,9 +62,9 @@ func (s *SyncContainerd) Execute(runtime connector.Runtime) error {
 		return errors.Wrap(errors.WithStack(err), fmt.Sprintf("sync containerd binaries failed"))
 	}
 
-	if _, err := runtime.GetRunner().SudoCmd(
-		fmt.Sprintf("mkdir -p /usr/bin && cd %s && tar -zxf %s && mv bin/* /usr/bin && rm -rf bin", common.TmpDir, dst),
-		false); err != nil {
+	if _, err := runtime.GetRunner().SudoCmdWith(
+		fmt.Sprintf("mkdir -p /usr/bin && tar -zxf %s && mv bin/* /usr/bin && rm -rf bin", dst),
+		connector.CommandOptions{Dir: common.TmpDir}, false); err != nil {
 		return errors.Wrap(errors.WithStack(err), fmt.Sprintf("install containerd binaries failed"))
 	}
 	return nil
@@ -387,6 +387,8 @@ func MigrateSelfNodeCriTasks(runtime connector.Runtime, kubeAction common.KubeAc
 					"Mirrors":            templates.Mirrors(kubeAction.KubeConf),
 					"InsecureRegistries": templates.InsecureRegistries(kubeAction.KubeConf),
 					"DataRoot":           templates.DataRoot(kubeAction.KubeConf),
+					"SELinux":            templates.SELinux(kubeAction.KubeConf),
+					"CgroupDriver":       common.CgroupDriverOf(kubeAction.PipelineCache),
 				},
 			},
 			Parallel: false,
@@ -471,6 +473,8 @@ func MigrateSelfNodeCriTasks(runtime connector.Runtime, kubeAction common.KubeAc
 					"SandBoxImage":       images.GetImage(runtime, kubeAction.KubeConf, "pause").ImageName(),
 					"Auths":              registry.DockerRegistryAuthEntries(kubeAction.KubeConf.Cluster.Registry.Auths),
 					"DataRoot":           templates.DataRoot(kubeAction.KubeConf),
+					"SELinux":            templates.SELinux(kubeAction.KubeConf),
+					"SystemdCgroup":      common.CgroupDriverOf(kubeAction.PipelineCache) == common.CgroupDriverSystemd,
 				},
 			},
 			Parallel: false,
@@ -30,6 +30,7 @@ import (
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/images"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/kubernetes"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/registry"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/topology"
 )
 
 type InstallContainerModule struct {
@@ -43,6 +44,7 @@ func (i *InstallContainerModule) IsSkip() bool {
 
 func (i *InstallContainerModule) Init() {
 	i.Name = "InstallContainerModule"
+	i.Tags = []string{"container-runtime"}
 	i.Desc = "Install container manager"
 
 	switch i.KubeConf.Cluster.Kubernetes.ContainerManager {
@@ -62,9 +64,10 @@ func (i *InstallContainerModule) Init() {
 func InstallDocker(m *InstallContainerModule) []task.Interface {
 
 	syncBuildxPluginBinaries := &task.RemoteTask{
-		Name:  "SyncDockerBuildxBinaries",
-		Desc:  "Sync docker buildx binaries",
-		Hosts: m.Runtime.GetHostsByRole(common.K8s),
+		Name:     "SyncDockerBuildxBinaries",
+		Desc:     "Sync docker buildx binaries",
+		Hosts:    topology.SortHosts(m.KubeConf.Cluster, m.Runtime.GetHostsByRole(common.K8s)),
+		Throttle: topology.Throttle(m.KubeConf.Cluster),
 		Prepare: &prepare.PrepareCollection{
 			&WithBuildxPlugin{},
 		},
@@ -74,9 +77,10 @@ func InstallDocker(m *InstallContainerModule) []task.Interface {
 	}
 
 	syncBinaries := &task.RemoteTask{
-		Name:  "SyncDockerBinaries",
-		Desc:  "Sync docker binaries",
-		Hosts: m.Runtime.GetHostsByRole(common.K8s),
+		Name:     "SyncDockerBinaries",
+		Desc:     "Sync docker binaries",
+		Hosts:    topology.SortHosts(m.KubeConf.Cluster, m.Runtime.GetHostsByRole(common.K8s)),
+		Throttle: topology.Throttle(m.KubeConf.Cluster),
 		Prepare: &prepare.PrepareCollection{
 			&kubernetes.NodeInCluster{Not: true},
 			&DockerExist{Not: true},
@@ -131,6 +135,8 @@ func InstallDocker(m *InstallContainerModule) []task.Interface {
 				"Mirrors":            templates.Mirrors(m.KubeConf),
 				"InsecureRegistries": templates.InsecureRegistries(m.KubeConf),
 				"DataRoot":           templates.DataRoot(m.KubeConf),
+				"SELinux":            templates.SELinux(m.KubeConf),
+				"CgroupDriver":       common.CgroupDriverOf(m.PipelineCache),
 				"BridgeIP":           templates.BridgeIP(m.KubeConf),
 			},
 		},
@@ -188,9 +194,10 @@ func InstallDocker(m *InstallContainerModule) []task.Interface {
 
 func InstallContainerd(m *InstallContainerModule) []task.Interface {
 	syncContainerd := &task.RemoteTask{
-		Name:  "SyncContainerd",
-		Desc:  "Sync containerd binaries",
-		Hosts: m.Runtime.GetHostsByRole(common.K8s),
+		Name:     "SyncContainerd",
+		Desc:     "Sync containerd binaries",
+		Hosts:    topology.SortHosts(m.KubeConf.Cluster, m.Runtime.GetHostsByRole(common.K8s)),
+		Throttle: topology.Throttle(m.KubeConf.Cluster),
 		Prepare: &prepare.PrepareCollection{
 			&kubernetes.NodeInCluster{Not: true},
 			&ContainerdExist{Not: true},
@@ -232,6 +239,8 @@ func InstallContainerd(m *InstallContainerModule) []task.Interface {
 				"SandBoxImage":       images.GetImage(m.Runtime, m.KubeConf, "pause").ImageName(),
 				"Auths":              registry.DockerRegistryAuthEntries(m.KubeConf.Cluster.Registry.Auths),
 				"DataRoot":           templates.DataRoot(m.KubeConf),
+				"SELinux":            templates.SELinux(m.KubeConf),
+				"SystemdCgroup":      common.CgroupDriverOf(m.PipelineCache) == common.CgroupDriverSystemd,
 			},
 		},
 		Parallel: true,
@@ -250,9 +259,10 @@ func InstallContainerd(m *InstallContainerModule) []task.Interface {
 	}
 
 	syncCrictlBinaries := &task.RemoteTask{
-		Name:  "SyncCrictlBinaries",
-		Desc:  "Sync crictl binaries",
-		Hosts: m.Runtime.GetHostsByRole(common.K8s),
+		Name:     "SyncCrictlBinaries",
+		Desc:     "Sync crictl binaries",
+		Hosts:    topology.SortHosts(m.KubeConf.Cluster, m.Runtime.GetHostsByRole(common.K8s)),
+		Throttle: topology.Throttle(m.KubeConf.Cluster),
 		Prepare: &prepare.PrepareCollection{
 			&kubernetes.NodeInCluster{Not: true},
 			&CrictlExist{Not: true},
@@ -301,12 +311,14 @@ func (m *InstallCriDockerdModule) IsSkip() bool {
 
 func (m *InstallCriDockerdModule) Init() {
 	m.Name = "InstallCriDockerdModule"
+	m.Tags = []string{"container-runtime"}
 	m.Desc = "Install cri-dockerd"
 
 	syncCriDockerdBinaries := &task.RemoteTask{
-		Name:  "SyncCriDockerdBinaries",
-		Desc:  "Sync cri-dockerd binaries",
-		Hosts: m.Runtime.GetHostsByRole(common.K8s),
+		Name:     "SyncCriDockerdBinaries",
+		Desc:     "Sync cri-dockerd binaries",
+		Hosts:    topology.SortHosts(m.KubeConf.Cluster, m.Runtime.GetHostsByRole(common.K8s)),
+		Throttle: topology.Throttle(m.KubeConf.Cluster),
 		Prepare: &prepare.PrepareCollection{
 			&CriDockerdExist{Not: true},
 			&common.AtLeastV124{},
@@ -347,9 +359,10 @@ func (m *InstallCriDockerdModule) Init() {
 	}
 
 	syncCrictlBinaries := &task.RemoteTask{
-		Name:  "SyncCrictlBinaries",
-		Desc:  "Sync crictl binaries",
-		Hosts: m.Runtime.GetHostsByRole(common.K8s),
+		Name:     "SyncCrictlBinaries",
+		Desc:     "Sync crictl binaries",
+		Hosts:    topology.SortHosts(m.KubeConf.Cluster, m.Runtime.GetHostsByRole(common.K8s)),
+		Throttle: topology.Throttle(m.KubeConf.Cluster),
 		Prepare: &prepare.PrepareCollection{
 			&CrictlExist{Not: true},
 			&common.AtLeastV124{},
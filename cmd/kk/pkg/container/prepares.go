@@ -41,6 +41,9 @@ type DockerExist struct {
 }
 
 func (d *DockerExist) PreCheck(runtime connector.Runtime) (bool, error) {
+	if freshNode(runtime) {
+		return d.Not, nil
+	}
 	output, err := runtime.GetRunner().SudoCmd("if [ -z $(command -v docker) ] || [ ! -e /var/run/docker.sock ]; "+
 		"then echo 'not exist'; "+
 		"fi", false)
@@ -59,6 +62,9 @@ type CriDockerdExist struct {
 }
 
 func (d *CriDockerdExist) PreCheck(runtime connector.Runtime) (bool, error) {
+	if freshNode(runtime) {
+		return d.Not, nil
+	}
 	output, err := runtime.GetRunner().SudoCmd("if [ -z $(command -v cri-dockerd) ] || [ ! -e /var/run/cri-dockerd.sock ]; "+
 		"then echo 'not exist'; "+
 		"fi", false)
@@ -97,6 +103,9 @@ type ContainerdExist struct {
 }
 
 func (c *ContainerdExist) PreCheck(runtime connector.Runtime) (bool, error) {
+	if freshNode(runtime) {
+		return c.Not, nil
+	}
 	output, err := runtime.GetRunner().SudoCmd(
 		"if [ -z $(command -v containerd) ] || [ ! -e /run/containerd/containerd.sock ]; "+
 			"then echo 'not exist'; "+
@@ -110,6 +119,11 @@ func (c *ContainerdExist) PreCheck(runtime connector.Runtime) (bool, error) {
 	return !c.Not, nil
 }
 
+// freshNode reports whether the node is rendered as a fresh one in the manifest-only mode, where nothing is installed.
+func freshNode(runtime connector.Runtime) bool {
+	return connector.RenderDir(runtime.GetConnector()) != ""
+}
+
 type PrivateRegistryAuth struct {
 	common.KubePrepare
 }
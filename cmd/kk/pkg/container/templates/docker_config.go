@@ -24,6 +24,7 @@ import (
 
 	"github.com/lithammer/dedent"
 
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
 )
 
@@ -45,7 +46,10 @@ var DockerConfig = template.Must(template.New("daemon.json").Parse(
   {{- if .BridgeIP }}
   "bip": {{ .BridgeIP }},
   {{- end}}
-  "exec-opts": ["native.cgroupdriver=systemd"]
+  {{- if .SELinux }}
+  "selinux-enabled": true,
+  {{- end}}
+  "exec-opts": ["native.cgroupdriver={{ .CgroupDriver }}"]
 }
     `)))
 
@@ -104,3 +108,8 @@ func BridgeIP(kubeConf *common.KubeConf) string {
 
 	return bip
 }
+
+// SELinux tells whether the container runtime labels the containers, when SELinux is enforcing on the nodes.
+func SELinux(kubeConf *common.KubeConf) bool {
+	return kubeConf.Cluster.System.SELinuxMode() == kubekeyapiv1alpha2.SELinuxEnforcing
+}
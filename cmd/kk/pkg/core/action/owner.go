@@ -0,0 +1,95 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/version"
+)
+
+// unitTag starts the tag appended to the systemd units.
+const unitTag = "\n[Unit]\nX-KubeKey-Cluster="
+
+// unitSuffixes are the suffixes of the systemd units tagged with the owner.
+var unitSuffixes = []string{".service", ".socket", ".timer", ".mount", ".path", ".target"}
+
+// isSystemdUnit returns whether the dst is a systemd unit or a drop-in of a unit.
+func isSystemdUnit(dst string) bool {
+	if !strings.Contains(dst, "/systemd/") {
+		return false
+	}
+	for _, suffix := range unitSuffixes {
+		if strings.HasSuffix(dst, suffix) {
+			return true
+		}
+	}
+	return strings.HasSuffix(dst, ".conf") && strings.HasSuffix(filepath.Dir(dst), ".d")
+}
+
+// TagUnit tags the content of the systemd unit or drop-in with the cluster and the KubeKey version, which systemd
+// ignores as the keys prefixed with X-. The tag of a content already tagged is replaced, and the content of the other
+// files is returned untouched.
+func TagUnit(dst, content, cluster, kkVersion string) string {
+	if !isSystemdUnit(dst) {
+		return content
+	}
+	if i := strings.Index(content, unitTag); i >= 0 {
+		content = content[:i]
+	}
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return fmt.Sprintf("%s%s%s\nX-KubeKey-Version=%s\n", content, unitTag, cluster, kkVersion)
+}
+
+// UntagUnit returns the content without the tag of TagUnit, so the units tagged by the other clusters or KubeKey
+// versions are compared by their contents.
+func UntagUnit(content string) string {
+	if i := strings.Index(content, unitTag); i >= 0 {
+		return content[:i]
+	}
+	return content
+}
+
+// recordManaged adds the dst to the list of the files managed by KubeKey on the host, a failure to record it is
+// logged and doesn't fail the action. Nothing is changed in the check mode.
+func recordManaged(runtime connector.Runtime, dst string) {
+	if connector.IsDryRun(runtime.GetConnector()) {
+		return
+	}
+	cmd := fmt.Sprintf("mkdir -p %[1]s && (grep -qxF %[2]s %[3]s 2>/dev/null || echo %[2]s >> %[3]s)",
+		filepath.Dir(common.ManagedFilesList), dst, common.ManagedFilesList)
+	if _, err := runtime.GetRunner().SudoCmd(cmd, false); err != nil {
+		logger.Log.Warnf("record the managed file %s failed: %v", dst, err)
+	}
+}
+
+// RemoveManagedFilesCmd returns the command removing the files managed by KubeKey on the host and their list.
+func RemoveManagedFilesCmd() string {
+	return fmt.Sprintf("if [ -f %[1]s ]; then xargs -r -d '\\n' rm -f < %[1]s; rm -f %[1]s; fi", common.ManagedFilesList)
+}
+
+// kubekeyVersion returns the version of KubeKey which tags the files.
+func kubekeyVersion() string {
+	return version.Get().GitVersion
+}
@@ -0,0 +1,45 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package action
+
+import "testing"
+
+func TestTagUnit(t *testing.T) {
+	const tagged = "[Service]\nExecStart=/usr/local/bin/kubelet\n\n[Unit]\nX-KubeKey-Cluster=sample\nX-KubeKey-Version=v3.1.0\n"
+	tests := []struct {
+		name    string
+		dst     string
+		content string
+		want    string
+	}{
+		{"unit", "/etc/systemd/system/kubelet.service", "[Service]\nExecStart=/usr/local/bin/kubelet", tagged},
+		{"drop-in", "/etc/systemd/system/kubelet.service.d/10-kubeadm.conf", "[Service]\nExecStart=/usr/local/bin/kubelet\n", tagged},
+		{"retagged", "/etc/systemd/system/kubelet.service", "[Service]\nExecStart=/usr/local/bin/kubelet\n\n[Unit]\nX-KubeKey-Cluster=old\nX-KubeKey-Version=v3.0.0\n", tagged},
+		{"config", "/etc/containerd/config.toml", "version = 2\n", "version = 2\n"},
+		{"conf out of systemd", "/etc/sysctl.d/kubekey.conf", "vm.swappiness = 0\n", "vm.swappiness = 0\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TagUnit(tt.dst, tt.content, "sample", "v3.1.0"); got != tt.want {
+				t.Errorf("TagUnit() = %q, want %q", got, tt.want)
+			}
+			if got, want := UntagUnit(tt.want), UntagUnit(TagUnit(tt.dst, tt.want, "other", "v3.0.0")); got != want {
+				t.Errorf("UntagUnit() = %q, want %q", got, want)
+			}
+		})
+	}
+}
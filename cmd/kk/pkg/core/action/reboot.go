@@ -0,0 +1,73 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package action
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+// DefaultRebootTimeout bounds the wait for the host to come back after a reboot.
+const DefaultRebootTimeout = 10 * time.Minute
+
+const bootIDCmd = "cat /proc/sys/kernel/random/boot_id"
+
+// rebootInterval is the interval of the attempts to connect to the host after a reboot.
+var rebootInterval = 5 * time.Second
+
+// Reboot reboots the host and waits for it to come back within the timeout, the host is back when it is connected
+// by a new boot. The runner of the runtime is connected again, so the action can go on with the host.
+func Reboot(runtime connector.Runtime, timeout time.Duration) error {
+	host := runtime.RemoteHost()
+	before, err := runtime.GetRunner().Cmd(bootIDCmd, false)
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "get the boot id failed")
+	}
+	// the reboot is delayed, so the command returns before the connection drops
+	if _, err := runtime.GetRunner().SudoCmd("nohup sh -c 'sleep 2 && reboot' >/dev/null 2>&1 &", false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "reboot failed")
+	}
+	if connector.IsDryRun(runtime.GetConnector()) {
+		return nil
+	}
+
+	start := time.Now()
+	old := runtime.GetRunner()
+	runtime.GetConnector().Close(host)
+	for {
+		time.Sleep(rebootInterval)
+		if conn, err := runtime.GetConnector().Connect(host); err == nil {
+			runner := &connector.Runner{Conn: conn, Debug: old.Debug, Host: host, Index: old.Index, Ctx: old.Ctx}
+			after, err := runner.Cmd(bootIDCmd, false)
+			if err == nil && strings.TrimSpace(after) != strings.TrimSpace(before) {
+				runtime.SetRunner(runner)
+				runner.Log().Infof("rebooted in %s", util.ShortDur(time.Since(start).Round(time.Second)))
+				return nil
+			}
+			// the host hasn't rebooted yet
+			runtime.GetConnector().Close(host)
+		}
+		if time.Since(start) > timeout {
+			return errors.Errorf("%s didn't come back within %s after the reboot", host.GetName(), timeout)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+func TestReboot(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	rebootInterval = 0
+
+	tests := []struct {
+		name     string
+		commands []connector.FakeCommand
+		wantErr  string
+	}{
+		{
+			name: "rebooted",
+			commands: []connector.FakeCommand{
+				{Match: "boot_id", Stdout: "5e2d1a4c", Times: 1},
+				{Match: "boot_id", Stdout: "9b7f03e1"},
+			},
+		},
+		{
+			name: "didn't come back",
+			commands: []connector.FakeCommand{
+				{Match: "boot_id", Stdout: "5e2d1a4c"},
+			},
+			wantErr: "didn't come back",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialer := connector.NewFakeDialer(&connector.FakeFixtures{Commands: tt.commands})
+			host := connector.NewHost()
+			host.Name = "node1"
+			conn, err := dialer.Connect(host)
+			if err != nil {
+				t.Fatal(err)
+			}
+			runtime := &connector.BaseRuntime{}
+			runtime.SetConnector(dialer)
+			runtime.SetRunner(&connector.Runner{Conn: conn, Host: host, Ctx: context.Background()})
+
+			err = Reboot(runtime, 0)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Reboot() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Reboot() error = %v, want %q", err, tt.wantErr)
+			}
+			dialer.AssertCommands(t, "node1", "sleep 2 && reboot")
+		})
+	}
+}
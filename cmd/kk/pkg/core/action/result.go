@@ -0,0 +1,72 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package action
+
+import (
+	"strings"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+const (
+	changedKey = "actionChanged"
+	diffKey    = "actionDiff"
+)
+
+// Unchanged reports the target state of the host already matches, the action didn't change anything.
+// It is overridden by Changed when an action reports both, e.g. in a loop.
+func Unchanged(runtime connector.Runtime) {
+	c := runtime.RemoteHost().GetCache()
+	if c == nil {
+		return
+	}
+	c.GetOrSet(changedKey, false)
+}
+
+// Changed reports the action changed the host, the diff describes the changes and can be empty.
+func Changed(runtime connector.Runtime, diff string) {
+	c := runtime.RemoteHost().GetCache()
+	if c == nil {
+		return
+	}
+	c.Set(changedKey, true)
+	if diff == "" {
+		return
+	}
+	if old, ok := c.GetMustString(diffKey); ok && old != "" {
+		diff = strings.Join([]string{old, diff}, "\n")
+	}
+	c.Set(diffKey, diff)
+}
+
+// TakeResult returns whether the action changed the host and the diff reported, and resets them for the next action.
+// An action which doesn't report the result is considered to have changed the host.
+func TakeResult(runtime connector.Runtime) (bool, string) {
+	c := runtime.RemoteHost().GetCache()
+	if c == nil {
+		return true, ""
+	}
+	defer c.Delete(changedKey)
+	defer c.Delete(diffKey)
+
+	changed, ok := c.GetMustBool(changedKey)
+	if !ok {
+		changed = true
+	}
+	diff, _ := c.GetMustString(diffKey)
+	return changed, diff
+}
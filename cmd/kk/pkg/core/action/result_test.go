@@ -0,0 +1,69 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package action
+
+import (
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+func TestTakeResult(t *testing.T) {
+	tests := []struct {
+		name        string
+		report      func(runtime connector.Runtime)
+		wantChanged bool
+		wantDiff    string
+	}{
+		{
+			name:        "not reported",
+			report:      func(runtime connector.Runtime) {},
+			wantChanged: true,
+		},
+		{
+			name:        "unchanged",
+			report:      Unchanged,
+			wantChanged: false,
+		},
+		{
+			name: "changed overrides unchanged",
+			report: func(runtime connector.Runtime) {
+				Changed(runtime, "-a\n+b")
+				Unchanged(runtime)
+				Changed(runtime, "-c\n+d")
+			},
+			wantChanged: true,
+			wantDiff:    "-a\n+b\n-c\n+d",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runtime := &connector.BaseRuntime{}
+			runtime.SetRunner(&connector.Runner{Host: connector.NewHost()})
+
+			tt.report(runtime)
+			changed, diff := TakeResult(runtime)
+			if changed != tt.wantChanged || diff != tt.wantDiff {
+				t.Errorf("TakeResult() = %v, %q, want %v, %q", changed, diff, tt.wantChanged, tt.wantDiff)
+			}
+			// the result is reset for the next action
+			if changed, diff := TakeResult(runtime); !changed || diff != "" {
+				t.Errorf("TakeResult() after reset = %v, %q", changed, diff)
+			}
+		})
+	}
+}
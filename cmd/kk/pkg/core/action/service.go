@@ -0,0 +1,255 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package action
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+const (
+	// ServiceStarted starts the service if it isn't running, and restarts it if its unit is changed.
+	ServiceStarted = "started"
+	// ServiceRestarted restarts the service.
+	ServiceRestarted = "restarted"
+	// ServiceStopped stops the service if it is running.
+	ServiceStopped = "stopped"
+)
+
+const (
+	InitSystemd = "systemd"
+	// InitOpenRC is the init system of Alpine.
+	InitOpenRC = "openrc"
+)
+
+const (
+	// initSystemKey is the key of the init system of the host in the host cache.
+	initSystemKey = "initSystem"
+	// serviceLogLines is the number of the lines of the logs of a failed service in the error.
+	serviceLogLines = 30
+	// serviceVerifyRetries is the number of the checks of a started service before it is considered failed.
+	serviceVerifyRetries = 5
+)
+
+// serviceVerifyInterval is the interval of the checks of a started service.
+var serviceVerifyInterval = 2 * time.Second
+
+// initSystem is the commands of an init system, the formats take the name of the service.
+type initSystem struct {
+	unitFile  string
+	install   string
+	reload    string
+	isEnabled string
+	enable    string
+	isActive  string
+	start     string
+	restart   string
+	stop      string
+	logs      string
+}
+
+var initSystems = map[string]initSystem{
+	InitSystemd: {
+		unitFile:  "/etc/systemd/system/%s.service",
+		reload:    "systemctl daemon-reload",
+		isEnabled: "systemctl is-enabled --quiet %s",
+		enable:    "systemctl enable %s",
+		isActive:  "systemctl is-active --quiet %s",
+		start:     "systemctl start %s",
+		restart:   "systemctl restart %s",
+		stop:      "systemctl stop %s",
+		logs:      fmt.Sprintf("journalctl -u %%s -n %d --no-pager", serviceLogLines),
+	},
+	InitOpenRC: {
+		unitFile:  "/etc/init.d/%s",
+		install:   "chmod 0755 /etc/init.d/%s",
+		isEnabled: "rc-update show default | grep -qw %s",
+		enable:    "rc-update add %s default",
+		isActive:  "rc-service %s status",
+		start:     "rc-service %s start",
+		restart:   "rc-service %s restart",
+		stop:      "rc-service %s stop",
+		logs:      fmt.Sprintf("tail -n %d /var/log/%%s.log /var/log/messages 2>/dev/null", serviceLogLines),
+	},
+}
+
+// ServiceOptions are the target state of a service managed by ManageService.
+type ServiceOptions struct {
+	// Unit is the systemd unit installed to /etc/systemd/system/<name>.service, the unit on the host is used if it is
+	// empty.
+	Unit string
+	// OpenRC is the init script installed to /etc/init.d/<name> on the hosts without systemd, e.g. Alpine. It is
+	// required with the Unit on them.
+	OpenRC string
+	// DaemonReload reloads systemd even if the Unit isn't changed, e.g. the unit or its drop-ins are installed by
+	// another action.
+	DaemonReload bool
+	// Enabled enables the service to start at boot.
+	Enabled bool
+	// State is one of ServiceStarted, ServiceRestarted and ServiceStopped, the service is left as it is if it is empty.
+	State string
+}
+
+// Service manages the service of the name by ManageService.
+type Service struct {
+	BaseAction
+	Name string
+	ServiceOptions
+}
+
+func (s *Service) Execute(runtime connector.Runtime) error {
+	return ManageService(runtime, s.Name, s.ServiceOptions)
+}
+
+// InitSystem returns the init system of the host, InitSystemd or InitOpenRC, which is cached in the host cache.
+// It is systemd in the dry run.
+func InitSystem(runtime connector.Runtime) (string, error) {
+	host := runtime.RemoteHost()
+	if v, ok := host.GetCache().GetMustString(initSystemKey); ok {
+		return v, nil
+	}
+	out, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("if [ -d /run/systemd/system ]; then echo %s; "+
+		"elif command -v rc-service > /dev/null 2>&1; then echo %s; fi", InitSystemd, InitOpenRC), false)
+	if err != nil {
+		return "", errors.Wrapf(err, "detect the init system of %s failed", host.GetName())
+	}
+	sys := strings.TrimSpace(out)
+	if connector.IsDryRun(runtime.GetConnector()) {
+		sys = InitSystemd
+	}
+	if _, ok := initSystems[sys]; !ok {
+		return "", errors.Errorf("neither systemd nor OpenRC is running on %s", host.GetName())
+	}
+	host.GetCache().Set(initSystemKey, sys)
+	return sys, nil
+}
+
+// ManageService installs the unit of the service, reloads systemd if it is changed, enables the service and brings it
+// to the state, by systemd or by OpenRC on the hosts without systemd. A service started or restarted is verified to be
+// active, and the error of the one which isn't contains the tail of its logs. The changes are reported as the result
+// of the action.
+func ManageService(runtime connector.Runtime, name string, opts ServiceOptions) error {
+	sysName, err := InitSystem(runtime)
+	if err != nil {
+		return err
+	}
+	sys := initSystems[sysName]
+	succeeded := func(format string) bool {
+		_, err := runtime.GetRunner().SudoCmd(fmt.Sprintf(format, name), false)
+		return err == nil
+	}
+	run := func(format, msg string) error {
+		if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf(format, name), false); err != nil {
+			return errors.Wrapf(err, "%s the service %s failed", msg, name)
+		}
+		Changed(runtime, fmt.Sprintf("%s the service %s", msg, name))
+		return nil
+	}
+
+	unit := opts.Unit
+	if sysName == InitOpenRC {
+		if opts.Unit != "" && opts.OpenRC == "" {
+			return errors.Errorf("the service %s has no OpenRC script for %s", name, runtime.RemoteHost().GetName())
+		}
+		unit = opts.OpenRC
+	}
+	unitChanged := false
+	if unit != "" {
+		dst := fmt.Sprintf(sys.unitFile, name)
+		if unitChanged, err = writeRemoteFile(runtime, filepath.Base(dst), dst, unit, false); err != nil {
+			return err
+		}
+		if unitChanged && sys.install != "" {
+			if err := run(sys.install, "install"); err != nil {
+				return err
+			}
+		}
+	}
+	if sys.reload != "" && (unitChanged || opts.DaemonReload) {
+		if _, err := runtime.GetRunner().SudoCmd(sys.reload, false); err != nil {
+			return errors.Wrapf(err, "reload %s for the service %s failed", sysName, name)
+		}
+	}
+	if opts.Enabled && !succeeded(sys.isEnabled) {
+		if err := run(sys.enable, "enable"); err != nil {
+			return err
+		}
+	}
+
+	switch opts.State {
+	case ServiceStarted:
+		switch {
+		case !succeeded(sys.isActive):
+			err = run(sys.start, "start")
+		case unitChanged:
+			err = run(sys.restart, "restart")
+		default:
+			Unchanged(runtime)
+			return nil
+		}
+	case ServiceRestarted:
+		err = run(sys.restart, "restart")
+	case ServiceStopped:
+		if succeeded(sys.isActive) {
+			return run(sys.stop, "stop")
+		}
+		Unchanged(runtime)
+		return nil
+	case "":
+		Unchanged(runtime)
+		return nil
+	default:
+		return errors.Errorf("unknown state %s of the service %s", opts.State, name)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "the last logs:\n%s", serviceLogs(runtime, sys, name))
+	}
+	return verifyService(runtime, sys, name)
+}
+
+// verifyService waits for the service to be active, e.g. a systemd service is activating, and returns the error with
+// the tail of its logs if it isn't. The service isn't verified in the dry run.
+func verifyService(runtime connector.Runtime, sys initSystem, name string) error {
+	if connector.IsDryRun(runtime.GetConnector()) {
+		return nil
+	}
+	for i := 0; i < serviceVerifyRetries; i++ {
+		if i > 0 {
+			time.Sleep(serviceVerifyInterval)
+		}
+		if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf(sys.isActive, name), false); err == nil {
+			return nil
+		}
+	}
+	return errors.Errorf("the service %s isn't active on %s, the last logs:\n%s",
+		name, runtime.RemoteHost().GetName(), serviceLogs(runtime, sys, name))
+}
+
+// serviceLogs returns the tail of the logs of the service, the journal of a systemd service.
+func serviceLogs(runtime connector.Runtime, sys initSystem, name string) string {
+	out, err := runtime.GetRunner().SudoCmd(fmt.Sprintf(sys.logs, name), false)
+	if err != nil {
+		return fmt.Sprintf("read the logs failed: %v", err)
+	}
+	return out
+}
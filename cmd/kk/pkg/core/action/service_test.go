@@ -0,0 +1,119 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package action
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+func TestManageService(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	serviceVerifyInterval = 0
+
+	tests := []struct {
+		name        string
+		commands    []connector.FakeCommand
+		opts        ServiceOptions
+		want        []string
+		notWant     string
+		wantErr     string
+		wantChanged bool
+	}{
+		{
+			name: "started and enabled",
+			commands: []connector.FakeCommand{
+				{Match: "/run/systemd/system", Stdout: "systemd"},
+				{Match: "systemctl is-enabled", ExitCode: 1},
+				{Match: "systemctl is-active", ExitCode: 3, Times: 1},
+			},
+			opts:        ServiceOptions{DaemonReload: true, Enabled: true, State: ServiceStarted},
+			want:        []string{"systemctl daemon-reload", "systemctl enable etcd", "systemctl start etcd", "systemctl is-active --quiet etcd"},
+			wantChanged: true,
+		},
+		{
+			name: "already running",
+			commands: []connector.FakeCommand{
+				{Match: "/run/systemd/system", Stdout: "systemd"},
+			},
+			opts:    ServiceOptions{Enabled: true, State: ServiceStarted},
+			notWant: "systemctl start",
+		},
+		{
+			name: "openrc",
+			commands: []connector.FakeCommand{
+				{Match: "/run/systemd/system", Stdout: "openrc"},
+				{Match: "rc-update show", ExitCode: 1},
+			},
+			opts:        ServiceOptions{Enabled: true, State: ServiceRestarted},
+			want:        []string{"rc-update add etcd default", "rc-service etcd restart", "rc-service etcd status"},
+			wantChanged: true,
+		},
+		{
+			name: "openrc without a script",
+			commands: []connector.FakeCommand{
+				{Match: "/run/systemd/system", Stdout: "openrc"},
+			},
+			opts:    ServiceOptions{Unit: "[Service]\nExecStart=/usr/local/bin/etcd\n", State: ServiceStarted},
+			wantErr: "no OpenRC script",
+		},
+		{
+			name: "failed with the journal",
+			commands: []connector.FakeCommand{
+				{Match: "/run/systemd/system", Stdout: "systemd"},
+				{Match: "systemctl is-active", ExitCode: 3},
+				{Match: "journalctl -u etcd", Stdout: "etcd: member has already been bootstrapped"},
+			},
+			opts:    ServiceOptions{State: ServiceRestarted},
+			want:    []string{"systemctl restart etcd", "journalctl -u etcd -n 30"},
+			wantErr: "member has already been bootstrapped",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialer := connector.NewFakeDialer(&connector.FakeFixtures{Commands: tt.commands})
+			host := connector.NewHost()
+			host.Name = "node1"
+			conn, err := dialer.Connect(host)
+			if err != nil {
+				t.Fatal(err)
+			}
+			runtime := &connector.BaseRuntime{}
+			runtime.SetConnector(dialer)
+			runtime.SetRunner(&connector.Runner{Conn: conn, Host: host, Ctx: context.Background()})
+
+			err = ManageService(runtime, "etcd", tt.opts)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("ManageService() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("ManageService() error = %v, want %q", err, tt.wantErr)
+			}
+			dialer.AssertCommands(t, "node1", tt.want...)
+			if tt.notWant != "" {
+				dialer.AssertNoCommand(t, "node1", tt.notWant)
+			}
+			if changed, _ := TakeResult(runtime); tt.wantErr == "" && changed != tt.wantChanged {
+				t.Errorf("ManageService() changed = %v, want %v", changed, tt.wantChanged)
+			}
+		})
+	}
+}
@@ -17,13 +17,17 @@
 package action
 
 import (
+	"encoding/base64"
 	"fmt"
 	"path/filepath"
+	"strings"
 	"text/template"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
 )
 
@@ -34,20 +38,122 @@ type Template struct {
 	Data     util.Data
 }
 
+// renderCacheKey is the key of the RenderCache of the templates in the pipeline cache.
+const renderCacheKey = "renderCache"
+
 func (t *Template) Execute(runtime connector.Runtime) error {
-	templateStr, err := util.Render(t.Template, t.Data)
+	templateStr, err := t.render(runtime)
 	if err != nil {
 		return errors.Wrap(errors.WithStack(err), fmt.Sprintf("render template %s failed", t.Template.Name()))
 	}
 
-	fileName := filepath.Join(runtime.GetHostWorkDir(), t.Template.Name())
-	if err := util.WriteFile(fileName, []byte(templateStr)); err != nil {
-		return errors.Wrap(errors.WithStack(err), fmt.Sprintf("write file %s failed", fileName))
+	return WriteRemoteFile(runtime, t.Template.Name(), t.Dst, templateStr)
+}
+
+// WriteRemoteFile writes the content to the file of the name in the work dir of the host, and copies it to the dst on
+// the host unless the dst is up to date. The change is reported with the diff and recorded in the history of the host,
+// and the dst is added to the files managed by KubeKey on the host. The systemd units are tagged with the owner.
+func WriteRemoteFile(runtime connector.Runtime, name, dst, content string) error {
+	_, err := writeRemoteFile(runtime, name, dst, content, false)
+	return err
+}
+
+// WriteRemoteFileWithBackup writes the file like WriteRemoteFile, and copies the old dst to <dst>.<timestamp>.bak on
+// the host before it is overwritten.
+func WriteRemoteFileWithBackup(runtime connector.Runtime, name, dst, content string) error {
+	_, err := writeRemoteFile(runtime, name, dst, content, true)
+	return err
+}
+
+// writeRemoteFile writes the remote file and returns whether it is changed.
+func writeRemoteFile(runtime connector.Runtime, name, dst, content string, backup bool) (bool, error) {
+	content = TagUnit(dst, content, runtime.GetObjName(), kubekeyVersion())
+	fileName := filepath.Join(runtime.GetHostWorkDir(), name)
+	if err := util.WriteFile(fileName, []byte(content)); err != nil {
+		return false, errors.Wrap(errors.WithStack(err), fmt.Sprintf("write file %s failed", fileName))
 	}
 
-	if err := runtime.GetRunner().SudoScp(fileName, t.Dst); err != nil {
-		return errors.Wrap(errors.WithStack(err), fmt.Sprintf("scp file %s to remote %s failed", fileName, t.Dst))
+	if dir := connector.RenderDir(runtime.GetConnector()); dir != "" {
+		return true, writeRendered(runtime, dir, dst, content)
 	}
 
+	// the remote file is left untouched if it is up to date, the content can't be compared if it is failed to read
+	remoteStr, readErr := RemoteFileContent(runtime, dst)
+	if readErr == nil && strings.TrimSpace(remoteStr) == strings.TrimSpace(content) {
+		Unchanged(runtime)
+		return false, nil
+	}
+
+	if backup && readErr != nil {
+		return false, errors.Wrapf(readErr, "read remote file %s to back it up failed", dst)
+	}
+	if backup && remoteStr != "" {
+		bak := fmt.Sprintf("%s.%s.bak", dst, time.Now().Format("20060102150405"))
+		if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("cp -p %s %s", dst, bak), false); err != nil {
+			return false, errors.Wrap(errors.WithStack(err), fmt.Sprintf("back up remote file %s to %s failed", dst, bak))
+		}
+	}
+	if err := runtime.GetRunner().SudoScp(fileName, dst); err != nil {
+		return false, errors.Wrap(errors.WithStack(err), fmt.Sprintf("scp file %s to remote %s failed", fileName, dst))
+	}
+
+	var before *string
+	if readErr != nil {
+		Changed(runtime, "")
+	} else {
+		before = &remoteStr
+		Changed(runtime, util.Diff(dst, remoteStr, content))
+	}
+	recordHistory(runtime, dst, before, content)
+	recordManaged(runtime, dst)
+	return true, nil
+}
+
+// recordHistory records the change of the managed file in the history of the host, a failure to record it is logged
+// and doesn't fail the action. Nothing is changed in the check mode.
+func recordHistory(runtime connector.Runtime, dst string, before *string, after string) {
+	if connector.IsDryRun(runtime.GetConnector()) {
+		return
+	}
+	if err := runtime.GetHistory().Record(runtime.RemoteHost().GetName(), dst, before, after, time.Now()); err != nil {
+		logger.Log.Warnf("record the history of %s failed: %v", dst, err)
+	}
+}
+
+// writeRendered writes the rendered file of the host to <dir>/<host>/<dst> in the manifest-only mode.
+func writeRendered(runtime connector.Runtime, dir, dst, content string) error {
+	fileName := filepath.Join(dir, runtime.RemoteHost().GetName(), dst)
+	if err := util.WriteFile(fileName, []byte(content)); err != nil {
+		return errors.Wrap(errors.WithStack(err), fmt.Sprintf("write file %s failed", fileName))
+	}
+	Changed(runtime, "")
 	return nil
 }
+
+// render renders the template with the RenderCache of the pipeline, so the template with the same data is rendered
+// once for all the hosts.
+func (t *Template) render(runtime connector.Runtime) (string, error) {
+	opts := util.RenderOptions{Strict: runtime.GetStrictRender()}
+	if t.PipelineCache == nil {
+		return util.RenderWith(t.Template, t.Data, opts)
+	}
+	v, ok := t.PipelineCache.Get(renderCacheKey)
+	if !ok {
+		v, _ = t.PipelineCache.GetOrSet(renderCacheKey, util.NewRenderCache())
+	}
+	return v.(*util.RenderCache).Render(t.Template, t.Data, opts)
+}
+
+// RemoteFileContent returns the content of the remote file, or an empty string if it doesn't exist.
+// The content is encoded by base64, so it isn't changed by the terminal.
+func RemoteFileContent(runtime connector.Runtime, path string) (string, error) {
+	out, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("if [ -f %s ]; then base64 -w 0 %s; fi", path, path), false)
+	if err != nil {
+		return "", err
+	}
+	content, err := base64.StdEncoding.DecodeString(out)
+	if err != nil {
+		return "", errors.Wrapf(err, "decode the content of remote file %s failed", path)
+	}
+	return string(content), nil
+}
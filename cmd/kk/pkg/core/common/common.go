@@ -31,6 +31,31 @@ const (
 
 	TmpDir = "/tmp/kubekey/"
 
+	// ManagedFilesList on a node lists the files written onto the node by KubeKey, which are removed when the node
+	// is cleaned up.
+	ManagedFilesList = "/etc/kubekey/files"
+
+	// ClustersDir is the dir under the work dir, which holds the work dir of each cluster.
+	ClustersDir = "clusters"
+	// LockFile is the advisory lock file in the work dir of a cluster.
+	LockFile = ".lock"
+	// ReportFile is the default file of the run report in the work dir of a cluster.
+	ReportFile = "report.json"
+	// CheckpointsDir is the dir of the checkpoint of each pipeline in the work dir of a cluster.
+	CheckpointsDir = "checkpoints"
+	// QuarantineFile is the file of the quarantined hosts in the work dir of a cluster.
+	QuarantineFile = "quarantine.json"
+	// HistoryDir is the dir of the history of the managed files of each host in the work dir of a cluster.
+	HistoryDir = "history"
+	// DiagnosticsDir is the dir of the diagnostics collected on the failed hosts in the work dir of a cluster.
+	DiagnosticsDir = "diagnostics"
+	// RunsDir is the dir of the runs in the work dir of a cluster, each run has a dir of the logs of its hosts.
+	RunsDir = "runs"
+	// ContextFile is the file of the config and the artifact of a cluster in the work dir of the cluster.
+	ContextFile = "context.json"
+	// CurrentClusterFile is the file of the name of the current cluster in the clusters dir.
+	CurrentClusterFile = "current-cluster"
+
 	// command
 	CopyCmd = "cp -r %s %s"
 	MoveCmd = "mv -f %s %s"
@@ -0,0 +1,252 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/agent"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+// AgentCertsDir is the dir of the certs of the agents in the work dir of the cluster, the default certs dir of the
+// hosts of the agent.
+const AgentCertsDir = "pki/agent"
+
+// DefaultAgentCertsDir returns the default certs dir of the hosts of the agent of the cluster, AgentCertsDir in the
+// work dir of the cluster.
+func DefaultAgentCertsDir(cluster string) (string, error) {
+	workDir, err := DefaultWorkDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(workDir, common.ClustersDir, cluster, AgentCertsDir), nil
+}
+
+// agentConnection runs the commands and transfers the files by the kk agent on the host over gRPC with mutual TLS.
+// The agent runs as root, so the commands run with privilege whether they are escalated by sudo or not.
+type agentConnection struct {
+	client  *agent.Client
+	ctx     context.Context
+	cancel  context.CancelFunc
+	limiter *rate.Limiter
+}
+
+// NewAgentConnection connects to the kk agent on the address and the agent port of the host, with the certs in the
+// agent certs dir of the host.
+func NewAgentConnection(host Host) (Connection, error) {
+	if host.GetAgentCertsDir() == "" {
+		return nil, errors.Errorf("no agent certs dir of the host %s", host.GetName())
+	}
+	port := host.GetAgentPort()
+	if port == 0 {
+		port = agent.DefaultPort
+	}
+	config, err := agent.ClientTLSConfig(host.GetAgentCertsDir(), host.GetAddress())
+	if err != nil {
+		return nil, err
+	}
+	rateLimit, err := ParseTransferRateLimit(host.GetTransferRateLimit())
+	if err != nil {
+		return nil, err
+	}
+	timeout := time.Duration(host.GetTimeout()) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	dialCtx, cancelDial := context.WithTimeout(context.Background(), timeout)
+	defer cancelDial()
+	compress := host.GetTransferCompression() != "" && host.GetTransferCompression() != TransferCompressionNone
+	client, err := agent.Dial(dialCtx, net.JoinHostPort(host.GetAddress(), strconv.Itoa(port)), config, compress)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &agentConnection{client: client, ctx: ctx, cancel: cancel, limiter: newRateLimiter(rateLimit)}, nil
+}
+
+func (c *agentConnection) Exec(cmd string, host Host) (stdout string, code int, err error) {
+	var output bytes.Buffer
+	r, w := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64<<10), 16<<20)
+		for scanner.Scan() {
+			streamOutput(host, scanner.Text())
+			output.WriteString(scanner.Text() + "\n")
+		}
+		_, _ = io.Copy(io.Discard, r)
+	}()
+	code, err = c.client.Exec(c.ctx, ShellPath(host), agentCommand(host, cmd), nil, w, w)
+	_ = w.Close()
+	<-done
+
+	outStr := strings.TrimSpace(output.String())
+	if err != nil {
+		return outStr, code, errors.Wrapf(err, "Failed to exec command: %s \n%s", cmd, outStr)
+	}
+	return outStr, 0, nil
+}
+
+func (c *agentConnection) PExec(cmd string, stdin io.Reader, stdout io.Writer, stderr io.Writer, host Host) (int, error) {
+	return c.client.Exec(c.ctx, ShellPath(host), agentCommand(host, cmd), stdin, stdout, stderr)
+}
+
+// agentCommand returns the cmd run by the agent on the host, without sudo or doas since the agent runs as root, so
+// they needn't be installed on the host.
+func agentCommand(host Host, cmd string) string {
+	cmd = strings.TrimSpace(cmd)
+	for _, become := range []string{"sudo -E ", "sudo ", "doas "} {
+		if strings.HasPrefix(cmd, become) {
+			cmd = strings.TrimPrefix(cmd, become)
+			break
+		}
+	}
+	return ShellCommand(host, cmd)
+}
+
+func (c *agentConnection) Fetch(local, remote string, _ Host) error {
+	if err := util.MkFileFullPathDir(local); err != nil {
+		return err
+	}
+	f, err := os.Create(local)
+	if err != nil {
+		return fmt.Errorf("create local file failed %v", err)
+	}
+	defer f.Close()
+	if err := c.client.Download(c.ctx, remote, f); err != nil {
+		return fmt.Errorf("open remote file failed %v, remote path: %s", err, remote)
+	}
+	return nil
+}
+
+// Scp copies the file to the dst, or the contents of the dir into the dst, as over sftp. The files of the same
+// checksum on the host are skipped.
+func (c *agentConnection) Scp(src, dst string, host Host) error {
+	f, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("get file stat failed: %s", err)
+	}
+	if !f.IsDir() {
+		return c.copyFile(src, dst, f.Mode(), host)
+	}
+	return filepath.Walk(src, func(local string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, local)
+		if err != nil {
+			return err
+		}
+		remote := path.Join(dst, filepath.ToSlash(rel))
+		if info.IsDir() {
+			return c.MkDirAll(remote, "", host)
+		}
+		return c.copyFile(local, remote, info.Mode(), host)
+	})
+}
+
+func (c *agentConnection) copyFile(src, dst string, mode os.FileMode, host Host) error {
+	srcSum, err := util.FileSHA256(src)
+	if err != nil {
+		return errors.Wrapf(err, "checksum local file %s failed", src)
+	}
+	if dstSum, err := fileChecksum(c, dst, host); err == nil && dstSum == srcSum {
+		logger.Log.Debugf("remote file %s checksum is the same as local file, skip scp", dst)
+		return nil
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if c.limiter != nil {
+		pr, pw := io.Pipe()
+		defer pr.Close()
+		go func() {
+			_, err := io.Copy(&rateLimitedWriter{ctx: c.ctx, w: pw, limiter: c.limiter}, f)
+			_ = pw.CloseWithError(err)
+		}()
+		r = pr
+	}
+	dstSum, err := c.client.Upload(c.ctx, dst, mode, r)
+	if err != nil {
+		return fmt.Errorf("copy local file %s to remote file %s failed %v", src, dst, err)
+	}
+	if srcSum != dstSum {
+		return fmt.Errorf("validate sha256 failed %s != %s", srcSum, dstSum)
+	}
+	return nil
+}
+
+func (c *agentConnection) RemoteFileExist(remote string, host Host) bool {
+	_, _, err := c.Exec(fmt.Sprintf("test -e %s", remote), host)
+	return err == nil
+}
+
+func (c *agentConnection) RemoteDirExist(remote string, host Host) (bool, error) {
+	if _, _, err := c.Exec(fmt.Sprintf("test -d %s", remote), host); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *agentConnection) MkDirAll(path string, mode string, host Host) error {
+	if mode == "" {
+		mode = "775"
+	}
+	_, _, err := c.Exec(fmt.Sprintf("mkdir -p -m %s %s", mode, path), host)
+	return err
+}
+
+func (c *agentConnection) Chmod(path string, mode os.FileMode) error {
+	var out bytes.Buffer
+	if _, err := c.client.Exec(c.ctx, "/bin/sh", fmt.Sprintf("chmod %o %s", mode.Perm(), filepath.Dir(path)), nil, &out, &out); err != nil {
+		return errors.Wrapf(err, "chmod %s failed: %s", filepath.Dir(path), strings.TrimSpace(out.String()))
+	}
+	return nil
+}
+
+// Close cancels the commands and the transfers running by the agent.
+func (c *agentConnection) Close() {
+	c.cancel()
+	_ = c.client.Close()
+}
+
+func (c *agentConnection) closed() bool {
+	return c.ctx.Err() != nil
+}
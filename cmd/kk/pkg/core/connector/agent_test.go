@@ -0,0 +1,116 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/agent"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+// newTestAgent serves a kk agent of the host on the local machine, and returns the host connecting to it.
+func newTestAgent(t *testing.T) *BaseHost {
+	dir := t.TempDir()
+	if err := agent.WriteCerts(dir, []agent.CertHost{{Name: "node1", Addresses: []string{"127.0.0.1"}}}); err != nil {
+		t.Fatal(err)
+	}
+	config, err := agent.ServerTLSConfig(filepath.Join(dir, "node1.crt"), filepath.Join(dir, "node1.key"), filepath.Join(dir, agent.CACertFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := agent.NewServer(config)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	return NewHostWithOptions(HostOptions{
+		Name:                "node1",
+		Address:             "127.0.0.1",
+		Connector:           ConnectorAgent,
+		AgentPort:           lis.Addr().(*net.TCPAddr).Port,
+		AgentCertsDir:       dir,
+		TransferRateLimit:   "10Mi",
+		TransferCompression: TransferCompressionGzip,
+	})
+}
+
+func TestAgentConnection(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	host := newTestAgent(t)
+	c, err := NewDialer().Connect(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := c.(*agentConnection)
+
+	stdout, code, err := conn.Exec(SudoCommand(host, "echo hello"), host)
+	if err != nil || code != 0 || stdout != "hello" {
+		t.Errorf("Exec() = %q, %d, %v, want hello", stdout, code, err)
+	}
+	if _, code, err := conn.Exec("exit 3", host); err == nil || code != 3 {
+		t.Errorf("Exec() code = %d, err = %v, want code 3", code, err)
+	}
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "sub", "file"), []byte("content"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	remote := filepath.Join(dir, "remote", "dst")
+	// the second copy is skipped by the checksum
+	for i := 0; i < 2; i++ {
+		if err := conn.Scp(src, remote, host); err != nil {
+			t.Fatalf("Scp() error = %v", err)
+		}
+	}
+	info, err := statFile(conn, filepath.Join(remote, "sub", "file"), host)
+	if err != nil || info.Mode().Perm() != 0o600 || info.Size() != int64(len("content")) {
+		t.Errorf("statFile() = %v, %v, want the copied file", info, err)
+	}
+	if ok, err := fileExists(conn, filepath.Join(remote, "missing"), host); ok || err != nil {
+		t.Errorf("fileExists() of a missing file = %v, %v", ok, err)
+	}
+
+	local := filepath.Join(dir, "local", "file")
+	if err := conn.Fetch(local, filepath.Join(remote, "sub", "file"), host); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if got, _ := os.ReadFile(local); string(got) != "content" {
+		t.Errorf("Fetch() = %q, want content", got)
+	}
+	if err := conn.Fetch(local, filepath.Join(remote, "missing"), host); err == nil {
+		t.Errorf("Fetch() of a missing file succeeded")
+	}
+
+	conn.Close()
+	if !conn.closed() {
+		t.Errorf("the connection isn't closed")
+	}
+	if _, _, err := conn.Exec("true", host); err == nil {
+		t.Errorf("Exec() on the closed connection succeeded")
+	}
+}
@@ -0,0 +1,252 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+const (
+	AuditExec  = "exec"
+	AuditPut   = "put"
+	AuditFetch = "fetch"
+	AuditMkdir = "mkdir"
+	AuditChmod = "chmod"
+	// AuditStat and AuditChecksum inspect the remote files, Exists is recorded as a stat.
+	AuditStat     = "stat"
+	AuditChecksum = "checksum"
+
+	// auditSyslog is the target of the local syslog, syslog://host:port and syslog+tcp://host:port are the remote ones.
+	auditSyslog = "syslog"
+)
+
+// AuditRecord records an operation on a host, the secrets of the command are masked by the redactor of the logger.
+type AuditRecord struct {
+	Time      time.Time `json:"time"`
+	Host      string    `json:"host"`
+	Address   string    `json:"address"`
+	User      string    `json:"user"`
+	Operation string    `json:"operation"`
+	Command   string    `json:"command,omitempty"`
+	Local     string    `json:"local,omitempty"`
+	Remote    string    `json:"remote,omitempty"`
+	Mode      string    `json:"mode,omitempty"`
+	ExitCode  *int      `json:"exitCode,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Duration  string    `json:"duration"`
+}
+
+// AuditSink writes the audit records.
+type AuditSink interface {
+	Write(record AuditRecord) error
+	Close() error
+}
+
+var (
+	auditSinksMu sync.Mutex
+	auditSinks   = make(map[string]AuditSink)
+)
+
+// OpenAuditSink returns the sink of the target, which is opened once and shared by the runtimes of the process, e.g.
+// the pipelines run by the operator.
+func OpenAuditSink(target string) (AuditSink, error) {
+	auditSinksMu.Lock()
+	defer auditSinksMu.Unlock()
+	if sink, ok := auditSinks[target]; ok {
+		return sink, nil
+	}
+	sink, err := NewAuditSink(target)
+	if err != nil {
+		return nil, err
+	}
+	auditSinks[target] = sink
+	return sink, nil
+}
+
+// NewAuditSink creates the sink of the target, which is the path of a JSON lines file opened in append mode, syslog
+// for the local syslog, or syslog://host:port (UDP) and syslog+tcp://host:port for a remote syslog.
+func NewAuditSink(target string) (AuditSink, error) {
+	if target == auditSyslog || strings.HasPrefix(target, auditSyslog+"://") || strings.HasPrefix(target, auditSyslog+"+tcp://") {
+		network, addr := "", ""
+		if target != auditSyslog {
+			u, err := url.Parse(target)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid syslog address %s", target)
+			}
+			network, addr = "udp", u.Host
+			if u.Scheme == auditSyslog+"+tcp" {
+				network = "tcp"
+			}
+		}
+		return newSyslogAuditSink(network, addr)
+	}
+	return NewFileAuditSink(target)
+}
+
+type fileAuditSink struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// NewFileAuditSink opens the file in append mode, the existing records are never rewritten.
+func NewFileAuditSink(path string) (AuditSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, errors.Wrapf(err, "failed to create the dir of the audit log %s", path)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open the audit log %s", path)
+	}
+	return &fileAuditSink{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+func (s *fileAuditSink) Write(record AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.enc.Encode(record); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+func (s *fileAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// AuditDialer wraps a Connector and writes an audit record for each command and file change on the hosts.
+type AuditDialer struct {
+	Connector
+	sink AuditSink
+	now  func() time.Time
+}
+
+func NewAuditDialer(connector Connector, sink AuditSink) *AuditDialer {
+	return &AuditDialer{Connector: connector, sink: sink, now: time.Now}
+}
+
+func (a *AuditDialer) Connect(host Host) (Connection, error) {
+	conn, err := a.Connector.Connect(host)
+	if err != nil {
+		return nil, err
+	}
+	return &auditConnection{Connection: conn, dialer: a, host: host}, nil
+}
+
+// Unwrap returns the wrapped connector.
+func (a *AuditDialer) Unwrap() Connector {
+	return a.Connector
+}
+
+// record writes the record of an operation started at start, the failures of the sink are logged only, so that the
+// sink never breaks the pipeline.
+func (a *AuditDialer) record(host Host, start time.Time, record AuditRecord, err error) {
+	record.Time = start.UTC()
+	record.Duration = a.now().Sub(start).String()
+	record.Host = host.GetName()
+	record.Address = host.GetAddress()
+	record.User = host.GetUser()
+	record.Command = logger.Log.Redactor.Redact(record.Command)
+	if err != nil {
+		record.Error = logger.Log.Redactor.Redact(err.Error())
+	}
+	if sinkErr := a.sink.Write(record); sinkErr != nil {
+		logger.Log.Warnf("failed to write the audit record of %s on %s: %v", record.Operation, record.Host, sinkErr)
+	}
+}
+
+type auditConnection struct {
+	Connection
+	dialer *AuditDialer
+	host   Host
+}
+
+func (c *auditConnection) Exec(cmd string, host Host) (string, int, error) {
+	start := c.dialer.now()
+	stdout, code, err := c.Connection.Exec(cmd, host)
+	c.dialer.record(host, start, AuditRecord{Operation: AuditExec, Command: cmd, ExitCode: &code}, err)
+	return stdout, code, err
+}
+
+func (c *auditConnection) PExec(cmd string, stdin io.Reader, stdout io.Writer, stderr io.Writer, host Host) (int, error) {
+	start := c.dialer.now()
+	code, err := c.Connection.PExec(cmd, stdin, stdout, stderr, host)
+	c.dialer.record(host, start, AuditRecord{Operation: AuditExec, Command: cmd, ExitCode: &code}, err)
+	return code, err
+}
+
+func (c *auditConnection) Fetch(local, remote string, host Host) error {
+	start := c.dialer.now()
+	err := c.Connection.Fetch(local, remote, host)
+	c.dialer.record(host, start, AuditRecord{Operation: AuditFetch, Local: local, Remote: remote}, err)
+	return err
+}
+
+func (c *auditConnection) Scp(local, remote string, host Host) error {
+	start := c.dialer.now()
+	err := c.Connection.Scp(local, remote, host)
+	c.dialer.record(host, start, AuditRecord{Operation: AuditPut, Local: local, Remote: remote}, err)
+	return err
+}
+
+func (c *auditConnection) MkDirAll(path string, mode string, host Host) error {
+	start := c.dialer.now()
+	err := c.Connection.MkDirAll(path, mode, host)
+	c.dialer.record(host, start, AuditRecord{Operation: AuditMkdir, Remote: path, Mode: mode}, err)
+	return err
+}
+
+func (c *auditConnection) Chmod(path string, mode os.FileMode) error {
+	start := c.dialer.now()
+	err := c.Connection.Chmod(path, mode)
+	c.dialer.record(c.host, start, AuditRecord{Operation: AuditChmod, Remote: path, Mode: mode.String()}, err)
+	return err
+}
+
+func (c *auditConnection) Stat(remote string, host Host) (os.FileInfo, error) {
+	start := c.dialer.now()
+	info, err := statFile(c.Connection, remote, host)
+	c.dialer.record(host, start, AuditRecord{Operation: AuditStat, Remote: remote}, err)
+	return info, err
+}
+
+func (c *auditConnection) Exists(remote string, host Host) (bool, error) {
+	start := c.dialer.now()
+	exist, err := fileExists(c.Connection, remote, host)
+	c.dialer.record(host, start, AuditRecord{Operation: AuditStat, Remote: remote}, err)
+	return exist, err
+}
+
+func (c *auditConnection) Checksum(remote string, host Host) (string, error) {
+	start := c.dialer.now()
+	sum, err := fileChecksum(c.Connection, remote, host)
+	c.dialer.record(host, start, AuditRecord{Operation: AuditChecksum, Remote: remote}, err)
+	return sum, err
+}
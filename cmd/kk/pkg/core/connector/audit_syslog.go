@@ -0,0 +1,51 @@
+//go:build !windows && !plan9
+
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"encoding/json"
+	"log/syslog"
+
+	"github.com/pkg/errors"
+)
+
+type syslogAuditSink struct {
+	writer *syslog.Writer
+}
+
+// newSyslogAuditSink connects the syslog, which is the local one if the network and the address are empty.
+func newSyslogAuditSink(network, addr string) (AuditSink, error) {
+	writer, err := syslog.Dial(network, addr, syslog.LOG_NOTICE|syslog.LOG_AUTH, "kubekey")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect the syslog")
+	}
+	return &syslogAuditSink{writer: writer}, nil
+}
+
+func (s *syslogAuditSink) Write(record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.writer.Notice(string(data))
+}
+
+func (s *syslogAuditSink) Close() error {
+	return s.writer.Close()
+}
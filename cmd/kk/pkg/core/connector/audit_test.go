@@ -0,0 +1,114 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+type fakeConnector struct {
+	conn Connection
+}
+
+func (f *fakeConnector) Connect(host Host) (Connection, error) {
+	return f.conn, nil
+}
+
+func (f *fakeConnector) Close(host Host) {}
+
+type fakeConnection struct {
+	Connection
+}
+
+func (f *fakeConnection) Exec(cmd string, host Host) (string, int, error) {
+	if cmd == "false" {
+		return "", 1, errors.New("exit status 1")
+	}
+	return "ok", 0, nil
+}
+
+func (f *fakeConnection) Scp(local, remote string, host Host) error {
+	return nil
+}
+
+func TestAuditDialer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.log")
+	sink, err := NewFileAuditSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	logger.Log.Redactor.AddSecrets("s3cr3t")
+	host := NewHost()
+	host.Name = "node1"
+	host.Address = "192.168.0.1"
+	host.User = "root"
+
+	dialer := NewAuditDialer(&fakeConnector{conn: &fakeConnection{}}, sink)
+	dialer.now = func() time.Time { return time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC) }
+	conn, err := dialer.Connect(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, _ = conn.Exec("echo s3cr3t", host)
+	_, _, _ = conn.Exec("false", host)
+	_ = conn.Scp("/tmp/kubeadm.yaml", "/etc/kubernetes/kubeadm-config.yaml", host)
+	// recorded once, not with the command inspecting the file
+	_, _ = fileChecksum(conn, "/etc/kubernetes/pki/ca.key", host)
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var records []AuditRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid audit record %s: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+
+	if len(records) != 4 {
+		t.Fatalf("expected 4 audit records, got %d", len(records))
+	}
+	if r := records[0]; r.Operation != AuditExec || r.Command != "echo "+logger.DefaultReplacement ||
+		r.Host != "node1" || r.Address != "192.168.0.1" || r.User != "root" || *r.ExitCode != 0 {
+		t.Errorf("unexpected record %+v", r)
+	}
+	if r := records[1]; *r.ExitCode != 1 || r.Error != "exit status 1" {
+		t.Errorf("unexpected record %+v", r)
+	}
+	if r := records[2]; r.Operation != AuditPut || r.Local != "/tmp/kubeadm.yaml" || r.Remote != "/etc/kubernetes/kubeadm-config.yaml" {
+		t.Errorf("unexpected record %+v", r)
+	}
+	if r := records[3]; r.Operation != AuditChecksum || r.Remote != "/etc/kubernetes/pki/ca.key" || r.Error == "" {
+		t.Errorf("unexpected record %+v", r)
+	}
+}
@@ -0,0 +1,231 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+const (
+	ChaosCommand     = "command"
+	ChaosTransfer    = "transfer"
+	ChaosUnreachable = "unreachable"
+)
+
+// ChaosConfig defines the failures injected into the connections, it is used to verify the rollback and retry logic.
+type ChaosConfig struct {
+	// Seed of the random failures, the current time is used if it is zero.
+	Seed  int64       `yaml:"seed"`
+	Rules []ChaosRule `yaml:"rules"`
+}
+
+// ChaosRule defines when and how a failure is injected.
+type ChaosRule struct {
+	// Type of the failure. Support: command, transfer, unreachable
+	Type string `yaml:"type"`
+	// Hosts the rule applies to, all the hosts if it is empty.
+	Hosts []string `yaml:"hosts"`
+	// Match is the substring of the command or the remote file path, any one is matched if it is empty.
+	Match string `yaml:"match"`
+	// Probability of the failure for each matched call, between 0 and 1.
+	Probability float64 `yaml:"probability"`
+	// After fails the matched calls starting from the N-th one (1-based), it is used to script the failures.
+	After int `yaml:"after"`
+	// Times is the max number of failures injected by the rule, unlimited if it is zero.
+	Times int `yaml:"times"`
+	// ExitCode of the failed command. [Default: 1]
+	ExitCode int `yaml:"exitCode"`
+
+	mu       sync.Mutex
+	matched  int
+	injected int
+}
+
+func LoadChaosConfig(path string) (*ChaosConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read chaos config %s", path)
+	}
+	cfg := &ChaosConfig{}
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal chaos config %s", path)
+	}
+	for i := range cfg.Rules {
+		switch cfg.Rules[i].Type {
+		case ChaosCommand, ChaosTransfer, ChaosUnreachable:
+		default:
+			return nil, fmt.Errorf("unsupported chaos rule type %s", cfg.Rules[i].Type)
+		}
+		if cfg.Rules[i].ExitCode == 0 {
+			cfg.Rules[i].ExitCode = 1
+		}
+	}
+	return cfg, nil
+}
+
+// inject reports whether the call should fail.
+func (r *ChaosRule) inject(host Host, target string, random func() float64) bool {
+	if len(r.Hosts) > 0 && !contains(r.Hosts, host.GetName()) {
+		return false
+	}
+	if r.Match != "" && !strings.Contains(target, r.Match) {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.matched++
+	if r.Times > 0 && r.injected >= r.Times {
+		return false
+	}
+
+	fail := false
+	if r.After > 0 && r.matched >= r.After {
+		fail = true
+	}
+	if r.Probability > 0 && random() < r.Probability {
+		fail = true
+	}
+	if fail {
+		r.injected++
+	}
+	return fail
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ChaosDialer wraps a Connector and injects the failures defined by the chaos config.
+type ChaosDialer struct {
+	Connector
+	cfg *ChaosConfig
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func NewChaosDialer(connector Connector, cfg *ChaosConfig) *ChaosDialer {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &ChaosDialer{
+		Connector: connector,
+		cfg:       cfg,
+		rand:      rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (c *ChaosDialer) Connect(host Host) (Connection, error) {
+	if rule := c.match(ChaosUnreachable, host, host.GetAddress()); rule != nil {
+		return nil, fmt.Errorf("chaos: host %s is unreachable", host.GetName())
+	}
+	conn, err := c.Connector.Connect(host)
+	if err != nil {
+		return nil, err
+	}
+	return &chaosConnection{Connection: conn, dialer: c}, nil
+}
+
+// Unwrap returns the wrapped connector.
+func (c *ChaosDialer) Unwrap() Connector {
+	return c.Connector
+}
+
+func (c *ChaosDialer) match(typ string, host Host, target string) *ChaosRule {
+	for i := range c.cfg.Rules {
+		rule := &c.cfg.Rules[i]
+		if rule.Type != typ {
+			continue
+		}
+		if rule.inject(host, target, c.random) {
+			logger.Log.Warningf("chaos: inject %s failure into %s on %s", typ, target, host.GetName())
+			return rule
+		}
+	}
+	return nil
+}
+
+func (c *ChaosDialer) random() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rand.Float64()
+}
+
+type chaosConnection struct {
+	Connection
+	dialer *ChaosDialer
+}
+
+func (c *chaosConnection) Exec(cmd string, host Host) (string, int, error) {
+	if rule := c.dialer.match(ChaosCommand, host, cmd); rule != nil {
+		return "", rule.ExitCode, fmt.Errorf("chaos: command exited with code %d", rule.ExitCode)
+	}
+	return c.Connection.Exec(cmd, host)
+}
+
+func (c *chaosConnection) PExec(cmd string, stdin io.Reader, stdout io.Writer, stderr io.Writer, host Host) (int, error) {
+	if rule := c.dialer.match(ChaosCommand, host, cmd); rule != nil {
+		return rule.ExitCode, fmt.Errorf("chaos: command exited with code %d", rule.ExitCode)
+	}
+	return c.Connection.PExec(cmd, stdin, stdout, stderr, host)
+}
+
+func (c *chaosConnection) Fetch(local, remote string, host Host) error {
+	if rule := c.dialer.match(ChaosTransfer, host, remote); rule != nil {
+		return fmt.Errorf("chaos: fetch %s failed", remote)
+	}
+	return c.Connection.Fetch(local, remote, host)
+}
+
+func (c *chaosConnection) Scp(local, remote string, host Host) error {
+	if rule := c.dialer.match(ChaosTransfer, host, remote); rule != nil {
+		return fmt.Errorf("chaos: scp %s to %s failed", local, remote)
+	}
+	return c.Connection.Scp(local, remote, host)
+}
+
+// Stat, Exists and Checksum run the commands inspecting the file by the chaos connection if the connection doesn't
+// inspect it natively, so the failures of the commands are injected into them too.
+func (c *chaosConnection) Stat(remote string, host Host) (os.FileInfo, error) {
+	return statFileBy(c.Connection, c, remote, host)
+}
+
+func (c *chaosConnection) Exists(remote string, host Host) (bool, error) {
+	return fileExistsBy(c.Connection, c, remote, host)
+}
+
+func (c *chaosConnection) Checksum(remote string, host Host) (string, error) {
+	return fileChecksumBy(c.Connection, c, remote, host)
+}
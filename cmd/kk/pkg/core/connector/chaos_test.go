@@ -0,0 +1,91 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"testing"
+)
+
+func TestChaosRuleInject(t *testing.T) {
+	node1 := NewHost()
+	node1.Name = "node1"
+	node2 := NewHost()
+	node2.Name = "node2"
+
+	tests := []struct {
+		name   string
+		rule   *ChaosRule
+		host   Host
+		target string
+		random float64
+		calls  int
+		want   []bool
+	}{
+		{
+			name:   "scripted",
+			rule:   &ChaosRule{Match: "kubeadm init", After: 2, Times: 1},
+			host:   node1,
+			target: "kubeadm init --config kubeadm.yaml",
+			calls:  3,
+			want:   []bool{false, true, false},
+		},
+		{
+			name:   "not matched",
+			rule:   &ChaosRule{Match: "kubeadm join", After: 1},
+			host:   node1,
+			target: "kubeadm init",
+			calls:  1,
+			want:   []bool{false},
+		},
+		{
+			name:   "other host",
+			rule:   &ChaosRule{Hosts: []string{"node1"}, After: 1},
+			host:   node2,
+			target: "echo",
+			calls:  1,
+			want:   []bool{false},
+		},
+		{
+			name:   "random",
+			rule:   &ChaosRule{Probability: 0.5},
+			host:   node1,
+			target: "echo",
+			random: 0.1,
+			calls:  2,
+			want:   []bool{true, true},
+		},
+		{
+			name:   "random missed",
+			rule:   &ChaosRule{Probability: 0.5},
+			host:   node1,
+			target: "echo",
+			random: 0.9,
+			calls:  1,
+			want:   []bool{false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < tt.calls; i++ {
+				got := tt.rule.inject(tt.host, tt.target, func() float64 { return tt.random })
+				if got != tt.want[i] {
+					t.Errorf("inject() call %d = %v, want %v", i+1, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
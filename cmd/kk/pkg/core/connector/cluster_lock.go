@@ -0,0 +1,27 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+// ClusterLock locks the cluster against the concurrent runs of kk from the other machines, the runs on the same
+// machine are locked by the work dir of the cluster. The pipeline acquires it before its first module, renews it after
+// every module and releases it at its end.
+type ClusterLock interface {
+	// Acquire returns an error naming the holder if the lock is held by another run.
+	Acquire(runtime Runtime) error
+	Renew(runtime Runtime) error
+	Release(runtime Runtime)
+}
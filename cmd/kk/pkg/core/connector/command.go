@@ -0,0 +1,106 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	umask   = regexp.MustCompile(`^0?[0-7]{3}$`)
+	// doubleQuoted are the characters still special in the double quotes of SudoCommand.
+	doubleQuoted = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`")
+)
+
+// CommandOptions are the environment of a command run by the Runner, so the commands aren't prefixed by the cd and
+// the exports of a shell, which differ on the hosts of the other shells.
+type CommandOptions struct {
+	// Env are the environment variables of the command, they override the env of the host.
+	Env map[string]string
+	// Dir is the working directory of the command, the command fails if it doesn't exist.
+	Dir string
+	// Umask of the files created by the command, e.g. 0027. It isn't supported by powershell.
+	Umask string
+}
+
+// IsEnvName tells whether the name is a valid name of an environment variable.
+func IsEnvName(name string) bool {
+	return envName.MatchString(name)
+}
+
+// Command returns the cmd run in the environment of the options and the env of the host by the shell of the host.
+// The privileged commands are quoted by double quotes, see SudoCommand.
+func (o CommandOptions) Command(host Host, cmd string, privileged bool) (string, error) {
+	env := make(map[string]string, len(o.Env))
+	if host != nil {
+		for k, v := range host.GetEnv() {
+			env[k] = v
+		}
+	}
+	for k, v := range o.Env {
+		env[k] = v
+	}
+	if len(env) == 0 && o.Dir == "" && o.Umask == "" {
+		return cmd, nil
+	}
+	names := make([]string, 0, len(env))
+	for k := range env {
+		if !envName.MatchString(k) {
+			return "", errors.Errorf("invalid environment variable name %q", k)
+		}
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	if o.Umask != "" && !umask.MatchString(o.Umask) {
+		return "", errors.Errorf("invalid umask %q, it must be octal like 0022", o.Umask)
+	}
+
+	var b strings.Builder
+	if host != nil && host.GetShell() == ShellPowerShell {
+		if o.Umask != "" {
+			return "", errors.Errorf("the umask of the commands isn't supported by powershell on %s", host.GetName())
+		}
+		for _, k := range names {
+			fmt.Fprintf(&b, "$env:%s = %s; ", k, QuotePowerShell(env[k]))
+		}
+		if o.Dir != "" {
+			fmt.Fprintf(&b, "Set-Location -LiteralPath %s -ErrorAction Stop; ", QuotePowerShell(o.Dir))
+		}
+		return b.String() + cmd, nil
+	}
+
+	if o.Dir != "" {
+		fmt.Fprintf(&b, "cd %s || exit 1; ", Quote(o.Dir))
+	}
+	if o.Umask != "" {
+		fmt.Fprintf(&b, "umask %s; ", o.Umask)
+	}
+	for _, k := range names {
+		fmt.Fprintf(&b, "export %s=%s; ", k, Quote(env[k]))
+	}
+	prefix := b.String()
+	if privileged {
+		prefix = doubleQuoted.Replace(prefix)
+	}
+	return prefix + cmd, nil
+}
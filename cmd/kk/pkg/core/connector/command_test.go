@@ -0,0 +1,72 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import "testing"
+
+func TestCommandOptions(t *testing.T) {
+	host := NewHost()
+	host.SetName("node1")
+	host.SetEnv(map[string]string{"HTTP_PROXY": "http://proxy:3128", "LANG": "C"})
+
+	tests := []struct {
+		name       string
+		opts       CommandOptions
+		shell      string
+		privileged bool
+		want       string
+		wantErr    bool
+	}{
+		{name: "host env", opts: CommandOptions{}, want: "export HTTP_PROXY='http://proxy:3128'; export LANG='C'; ls"},
+		{
+			name: "override and dir",
+			opts: CommandOptions{Env: map[string]string{"LANG": "en_US.UTF-8"}, Dir: "/tmp/kubekey", Umask: "0027"},
+			want: "cd '/tmp/kubekey' || exit 1; umask 0027; export HTTP_PROXY='http://proxy:3128'; export LANG='en_US.UTF-8'; ls",
+		},
+		{
+			name:       "privileged",
+			opts:       CommandOptions{Env: map[string]string{"TOKEN": `a"b$c`}},
+			privileged: true,
+			want:       `export HTTP_PROXY='http://proxy:3128'; export LANG='C'; export TOKEN='a\"b\$c'; ls`,
+		},
+		{
+			name:  "powershell",
+			opts:  CommandOptions{Dir: `C:\kubekey`},
+			shell: ShellPowerShell,
+			want:  `$env:HTTP_PROXY = 'http://proxy:3128'; $env:LANG = 'C'; Set-Location -LiteralPath 'C:\kubekey' -ErrorAction Stop; ls`,
+		},
+		{name: "powershell umask", opts: CommandOptions{Umask: "0022"}, shell: ShellPowerShell, wantErr: true},
+		{name: "invalid name", opts: CommandOptions{Env: map[string]string{"A-B": "1"}}, wantErr: true},
+		{name: "invalid umask", opts: CommandOptions{Umask: "u=rwx"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host.SetShell(tt.shell)
+			got, err := tt.opts.Command(host, "ls", tt.privileged)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Command() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Command() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got, _ := (CommandOptions{}).Command(nil, "ls", false); got != "ls" {
+		t.Errorf("Command() = %q, want the cmd unchanged", got)
+	}
+}
@@ -0,0 +1,358 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+const (
+	CompatPassed  = "passed"
+	CompatFailed  = "failed"
+	CompatSkipped = "skipped"
+
+	// DefaultCompatFileSize is the size of the large file copied to and fetched from the host.
+	DefaultCompatFileSize = 64 << 20
+	// DefaultRebootTimeout bounds the wait for the host to come back after the reboot.
+	DefaultRebootTimeout = 10 * time.Minute
+
+	// compatOutputLines is the number of the lines of the long output.
+	compatOutputLines = 100000
+	// compatUnicodeName is the name of the dir and the file of the unicode path.
+	compatUnicodeName = "ünïcødé-目录-файл"
+)
+
+// CompatOptions are the options of the compatibility suite of a connector.
+type CompatOptions struct {
+	// FileSize is the size of the large file copied to and fetched from the host.
+	FileSize int64
+	// Reboot reboots the host to check the connection survives it, the check is skipped unless it is set.
+	Reboot bool
+	// RebootTimeout bounds the wait for the host to come back after the reboot.
+	RebootTimeout time.Duration
+	// RebootInterval is the interval of the attempts to connect to the host after the reboot.
+	RebootInterval time.Duration
+}
+
+// CompatResult is the result of a check of the compatibility suite.
+type CompatResult struct {
+	Check    string        `json:"check"`
+	Status   string        `json:"status"`
+	Detail   string        `json:"detail,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// CompatReport is the capability report of a host and its connector.
+type CompatReport struct {
+	Host    string         `json:"host"`
+	Results []CompatResult `json:"results"`
+}
+
+// Failed returns the names of the failed checks.
+func (r *CompatReport) Failed() []string {
+	var failed []string
+	for _, res := range r.Results {
+		if res.Status == CompatFailed {
+			failed = append(failed, res.Check)
+		}
+	}
+	return failed
+}
+
+func (r *CompatReport) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 10, 4, 3, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "CHECK\tSTATUS\tDURATION\tDETAIL")
+	for _, res := range r.Results {
+		duration := res.Duration.Round(time.Millisecond).String()
+		if res.Duration >= time.Second {
+			duration = util.ShortDur(res.Duration.Round(time.Second))
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", res.Check, res.Status, duration, res.Detail)
+	}
+	return tw.Flush()
+}
+
+// compatSkip is returned by a check which isn't run.
+type compatSkip string
+
+func (s compatSkip) Error() string {
+	return string(s)
+}
+
+type compatCheck struct {
+	name string
+	run  func(s *compatSuite) (string, error)
+}
+
+// compatChecks are the checks of the suite, the reboot is the last one since it loses the state of the host.
+var compatChecks = []compatCheck{
+	{name: "echo", run: (*compatSuite).echo},
+	{name: "exit-codes", run: (*compatSuite).exitCodes},
+	{name: "long-output", run: (*compatSuite).longOutput},
+	{name: "unicode-path", run: (*compatSuite).unicodePath},
+	{name: "large-file", run: (*compatSuite).largeFile},
+	{name: "sudo", run: (*compatSuite).sudo},
+	{name: "reboot", run: (*compatSuite).reboot},
+}
+
+type compatSuite struct {
+	connector Connector
+	conn      Connection
+	host      Host
+	opts      CompatOptions
+	localDir  string
+	remoteDir string
+}
+
+// RunCompatSuite runs the standardized checks of the connections against the host: the commands and their output,
+// the exit codes, the file transfers and sudo, and optionally the reconnection after a reboot. It qualifies a new
+// environment or a connector before a cluster is installed with it. The files of the checks are removed from the
+// host afterwards.
+func RunCompatSuite(c Connector, host Host, opts CompatOptions) (*CompatReport, error) {
+	if opts.FileSize <= 0 {
+		opts.FileSize = DefaultCompatFileSize
+	}
+	if opts.RebootTimeout <= 0 {
+		opts.RebootTimeout = DefaultRebootTimeout
+	}
+	if opts.RebootInterval <= 0 {
+		opts.RebootInterval = 5 * time.Second
+	}
+	conn, err := c.Connect(host)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to the host %s", host.GetName())
+	}
+	localDir, err := os.MkdirTemp("", "kubekey-compat-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the local dir of the checks")
+	}
+	defer os.RemoveAll(localDir)
+
+	s := &compatSuite{
+		connector: c,
+		conn:      conn,
+		host:      host,
+		opts:      opts,
+		localDir:  localDir,
+		remoteDir: fmt.Sprintf("/tmp/kubekey-compat-%d", time.Now().UnixNano()),
+	}
+	report := &CompatReport{Host: host.GetName()}
+	for _, check := range compatChecks {
+		start := time.Now()
+		detail, err := check.run(s)
+		res := CompatResult{Check: check.name, Status: CompatPassed, Detail: detail, Duration: time.Since(start)}
+		var skip compatSkip
+		if errors.As(err, &skip) {
+			res.Status, res.Detail = CompatSkipped, skip.Error()
+		} else if err != nil {
+			res.Status, res.Detail = CompatFailed, strings.ReplaceAll(err.Error(), "\n", " ")
+		}
+		report.Results = append(report.Results, res)
+	}
+	if s.conn != nil {
+		_, _, _ = s.conn.Exec("rm -rf "+s.remoteDir, host)
+	}
+	return report, nil
+}
+
+func (s *compatSuite) exec(cmd string) (string, int, error) {
+	if s.conn == nil {
+		return "", 1, errors.New("no connection to the host")
+	}
+	return s.conn.Exec(cmd, s.host)
+}
+
+func (s *compatSuite) echo() (string, error) {
+	out, code, err := s.exec("echo kubekey")
+	if err != nil {
+		return "", err
+	}
+	if code != 0 || out != "kubekey" {
+		return "", errors.Errorf("echo returned %q with the exit code %d", out, code)
+	}
+	return "", nil
+}
+
+func (s *compatSuite) exitCodes() (string, error) {
+	for _, c := range []struct {
+		cmd  string
+		code int
+	}{{"false", 1}, {"exit 3", 3}, {"kubekey-no-such-command", 127}} {
+		_, code, err := s.exec(c.cmd)
+		if code != c.code {
+			return "", errors.Errorf("%s returned the exit code %d, want %d", c.cmd, code, c.code)
+		}
+		if err == nil {
+			return "", errors.Errorf("%s returned no error with the exit code %d", c.cmd, code)
+		}
+	}
+	return "the exit codes 1, 3 and 127 are reported", nil
+}
+
+func (s *compatSuite) longOutput() (string, error) {
+	out, code, err := s.exec(fmt.Sprintf("seq 1 %d", compatOutputLines))
+	if err != nil || code != 0 {
+		return "", errors.Errorf("seq returned the exit code %d: %v", code, err)
+	}
+	lines := strings.Split(out, "\n")
+	if len(lines) != compatOutputLines || lines[len(lines)-1] != strconv.Itoa(compatOutputLines) {
+		return "", errors.Errorf("got %d lines of the output, want %d", len(lines), compatOutputLines)
+	}
+	return fmt.Sprintf("%d lines, %d bytes", len(lines), len(out)), nil
+}
+
+func (s *compatSuite) unicodePath() (string, error) {
+	content := []byte("kubekey " + compatUnicodeName + "\n")
+	local := filepath.Join(s.localDir, "unicode")
+	if err := os.WriteFile(local, content, 0644); err != nil {
+		return "", errors.Wrap(err, "failed to write the local file")
+	}
+	remote := path.Join(s.remoteDir, compatUnicodeName, compatUnicodeName+".txt")
+	if err := s.conn.Scp(local, remote, s.host); err != nil {
+		return "", errors.Wrap(err, "failed to copy the file")
+	}
+	out, _, err := s.exec(fmt.Sprintf("cat '%s'", remote))
+	if err != nil {
+		return "", err
+	}
+	if out != strings.TrimSpace(string(content)) {
+		return "", errors.Errorf("the copied file holds %q", out)
+	}
+	fetched := filepath.Join(s.localDir, "unicode.fetched")
+	if err := s.conn.Fetch(fetched, remote, s.host); err != nil {
+		return "", errors.Wrap(err, "failed to fetch the file")
+	}
+	if data, err := os.ReadFile(fetched); err != nil || !bytes.Equal(data, content) {
+		return "", errors.Errorf("the fetched file holds %q", data)
+	}
+	return remote, nil
+}
+
+func (s *compatSuite) largeFile() (string, error) {
+	local := filepath.Join(s.localDir, "large")
+	sum, err := writeRandomFile(local, s.opts.FileSize)
+	if err != nil {
+		return "", err
+	}
+	remote := path.Join(s.remoteDir, "large")
+	start := time.Now()
+	if err := s.conn.Scp(local, remote, s.host); err != nil {
+		return "", errors.Wrap(err, "failed to copy the file")
+	}
+	copied := time.Since(start)
+	out, err := fileChecksum(s.conn, remote, s.host)
+	if err != nil {
+		return "", err
+	}
+	if out != sum {
+		return "", errors.Errorf("the sha256 of the copied file is %s, want %s", out, sum)
+	}
+
+	fetched := filepath.Join(s.localDir, "large.fetched")
+	start = time.Now()
+	if err := s.conn.Fetch(fetched, remote, s.host); err != nil {
+		return "", errors.Wrap(err, "failed to fetch the file")
+	}
+	fetchedIn := time.Since(start)
+	if got, err := util.FileSHA256(fetched); err != nil || got != sum {
+		return "", errors.Errorf("the sha256 of the fetched file is %s, want %s", got, sum)
+	}
+	return fmt.Sprintf("%d MiB, copied in %s, fetched in %s", s.opts.FileSize>>20,
+		copied.Round(time.Millisecond), fetchedIn.Round(time.Millisecond)), nil
+}
+
+func writeRandomFile(file string, size int64) (string, error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create the local file")
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.CopyN(io.MultiWriter(f, h), rand.Reader, size); err != nil {
+		return "", errors.Wrap(err, "failed to write the local file")
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (s *compatSuite) sudo() (string, error) {
+	out, code, err := s.exec(SudoCommand(s.host, "id -u"))
+	if err != nil {
+		return "", err
+	}
+	if code != 0 || out != "0" {
+		return "", errors.Errorf("id -u returned %q with the exit code %d under sudo", out, code)
+	}
+	if c, ok := s.conn.(interface {
+		SudoNoPasswd(host Host) (bool, error)
+	}); ok {
+		if nopasswd, err := c.SudoNoPasswd(s.host); err == nil && !nopasswd {
+			return "with the password", nil
+		}
+		return "without a password", nil
+	}
+	return "", nil
+}
+
+func (s *compatSuite) reboot() (string, error) {
+	if !s.opts.Reboot {
+		return "", compatSkip("run with --reboot to reboot the host")
+	}
+	before, _, err := s.exec("cat /proc/sys/kernel/random/boot_id")
+	if err != nil {
+		return "", err
+	}
+	// the reboot is delayed, so the command returns before the connection drops
+	if _, _, err := s.exec(SudoCommand(s.host, "nohup sh -c 'sleep 2 && reboot' >/dev/null 2>&1 &")); err != nil {
+		return "", errors.Wrap(err, "failed to reboot the host")
+	}
+	start := time.Now()
+	s.connector.Close(s.host)
+	s.conn = nil
+	time.Sleep(s.opts.RebootInterval)
+	for {
+		conn, err := s.connector.Connect(s.host)
+		if err == nil {
+			after, _, err := conn.Exec("cat /proc/sys/kernel/random/boot_id", s.host)
+			if err == nil && after != before {
+				s.conn = conn
+				return fmt.Sprintf("reconnected in %s", util.ShortDur(time.Since(start).Round(time.Second))), nil
+			}
+			// the host hasn't rebooted yet
+			s.connector.Close(s.host)
+		}
+		if time.Since(start) > s.opts.RebootTimeout {
+			return "", errors.Errorf("the host didn't come back within %s", s.opts.RebootTimeout)
+		}
+		time.Sleep(s.opts.RebootInterval)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// localConnection runs the commands and copies the files on the local machine. It isn't a FileInspector, as the
+// connections implemented out of kk, so its files are inspected by the commands.
+type localConnection struct {
+	Connection
+	// swallowExitCodes reports the failed commands as succeeded, as a broken connector would.
+	swallowExitCodes bool
+}
+
+func (l *localConnection) Exec(cmd string, host Host) (string, int, error) {
+	// the tests may not run as root
+	cmd = strings.TrimPrefix(cmd, "sudo -E ")
+	out, err := exec.Command("/bin/bash", "-c", cmd).Output()
+	code := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		code = exitErr.ExitCode()
+	}
+	if l.swallowExitCodes {
+		return strings.TrimSpace(string(out)), 0, nil
+	}
+	return strings.TrimSpace(string(out)), code, err
+}
+
+func (l *localConnection) Scp(local, remote string, host Host) error {
+	data, err := os.ReadFile(local)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(remote), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(remote, data, 0644)
+}
+
+func (l *localConnection) Fetch(local, remote string, host Host) error {
+	data, err := os.ReadFile(remote)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(local, data, 0644)
+}
+
+type localConnector struct {
+	conn *localConnection
+}
+
+func (l *localConnector) Connect(host Host) (Connection, error) {
+	return l.conn, nil
+}
+
+func (l *localConnector) Close(host Host) {}
+
+func TestRunCompatSuite(t *testing.T) {
+	tests := []struct {
+		name string
+		conn *localConnection
+		want map[string]string
+	}{
+		{
+			name: "compatible",
+			conn: &localConnection{},
+			want: map[string]string{
+				"echo": CompatPassed, "exit-codes": CompatPassed, "long-output": CompatPassed,
+				"unicode-path": CompatPassed, "large-file": CompatPassed, "reboot": CompatSkipped,
+			},
+		},
+		{
+			name: "exit codes swallowed",
+			conn: &localConnection{swallowExitCodes: true},
+			want: map[string]string{"echo": CompatPassed, "exit-codes": CompatFailed, "reboot": CompatSkipped},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host := NewHost()
+			host.Name = "node1"
+			report, err := RunCompatSuite(&localConnector{conn: tt.conn}, host, CompatOptions{FileSize: 1 << 20})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]string)
+			for _, res := range report.Results {
+				got[res.Check] = res.Status
+			}
+			for check, status := range tt.want {
+				if got[check] != status {
+					t.Errorf("the check %s %s, want %s: %+v", check, got[check], status, report.Results)
+				}
+			}
+		})
+	}
+}
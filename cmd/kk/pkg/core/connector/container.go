@@ -0,0 +1,244 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+// The connectors of the hosts. The hosts of the container runtimes are the running containers named by the hosts,
+// e.g. the disposable distro containers the modules are tested against in CI instead of VMs. The hosts of the agent
+// run the kk agent, see NewAgentConnection.
+const (
+	ConnectorSSH    = "ssh"
+	ConnectorDocker = "docker"
+	ConnectorPodman = "podman"
+	ConnectorAgent  = "agent"
+)
+
+// Connectors are the connectors of the hosts which can be set.
+var Connectors = []string{ConnectorSSH, ConnectorDocker, ConnectorPodman, ConnectorAgent}
+
+// IsContainerConnector returns whether the connector connects to a container instead of over ssh.
+func IsContainerConnector(connector string) bool {
+	return connector == ConnectorDocker || connector == ConnectorPodman
+}
+
+// containerConnection runs the commands in a container by the exec of its runtime, and copies the files by the cp.
+// The commands run as the user of the container, sudo is dropped if it isn't installed, since the user of the
+// distro images is root.
+type containerConnection struct {
+	bin       string
+	container string
+	ctx       context.Context
+	cancel    context.CancelFunc
+
+	sudoOnce sync.Once
+	noSudo   bool
+}
+
+// NewContainerConnection connects to the running container by the container runtime, docker or podman. The tests of
+// the modules can run their tasks on a disposable container by it:
+//
+//	conn, err := connector.NewContainerConnection("docker", "kk-test-ubuntu")
+func NewContainerConnection(runtime, container string) (Connection, error) {
+	if !IsContainerConnector(runtime) {
+		return nil, errors.Errorf("unknown container runtime %q, it must be docker or podman", runtime)
+	}
+	bin, err := exec.LookPath(runtime)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s isn't installed", runtime)
+	}
+	return newContainerConnection(bin, container)
+}
+
+func newContainerConnection(bin, container string) (*containerConnection, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &containerConnection{bin: bin, container: container, ctx: ctx, cancel: cancel}
+	out, err := c.runtime("inspect", "--format", "{{.State.Running}}", container)
+	if err != nil {
+		cancel()
+		return nil, errors.Wrapf(err, "could not find the container %s", container)
+	}
+	if strings.TrimSpace(out) != "true" {
+		cancel()
+		return nil, errors.Errorf("the container %s isn't running", container)
+	}
+	return c, nil
+}
+
+// runtime runs the container runtime with the args, and returns its combined output.
+func (c *containerConnection) runtime(args ...string) (string, error) {
+	cmd := exec.CommandContext(c.ctx, c.bin, args...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	return out.String(), err
+}
+
+// command returns the cmd run by the shell in the container, without sudo or doas if it isn't installed.
+func (c *containerConnection) command(cmd string) string {
+	cmd = strings.TrimSpace(cmd)
+	become := ""
+	switch {
+	case strings.HasPrefix(cmd, "sudo "):
+		become = BecomeSudo
+	case strings.HasPrefix(cmd, "doas "):
+		become = BecomeDoas
+	default:
+		return cmd
+	}
+	c.sudoOnce.Do(func() {
+		_, err := c.runtime("exec", c.container, "/bin/sh", "-c", "command -v "+become)
+		c.noSudo = err != nil
+	})
+	if c.noSudo {
+		cmd = strings.TrimPrefix(cmd, become+" ")
+		cmd = strings.TrimPrefix(cmd, "-E ")
+	}
+	return cmd
+}
+
+func (c *containerConnection) Exec(cmd string, host Host) (stdout string, code int, err error) {
+	run := exec.CommandContext(c.ctx, c.bin, "exec", c.container, ShellPath(host), "-c", c.command(cmd))
+	var output bytes.Buffer
+	r, w := io.Pipe()
+	run.Stdout = w
+	run.Stderr = w
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64<<10), 16<<20)
+		for scanner.Scan() {
+			streamOutput(host, scanner.Text())
+			output.WriteString(scanner.Text() + "\n")
+		}
+		_, _ = io.Copy(io.Discard, r)
+	}()
+	err = run.Run()
+	_ = w.Close()
+	<-done
+
+	outStr := strings.TrimSpace(output.String())
+	if err == nil {
+		return outStr, 0, nil
+	}
+	code = -1
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		code = exitErr.ExitCode()
+	}
+	return outStr, code, errors.Wrapf(err, "Failed to exec command: %s \n%s", cmd, outStr)
+}
+
+func (c *containerConnection) PExec(cmd string, stdin io.Reader, stdout io.Writer, stderr io.Writer, host Host) (int, error) {
+	args := []string{"exec"}
+	if stdin != nil {
+		args = append(args, "-i")
+	}
+	run := exec.CommandContext(c.ctx, c.bin, append(args, c.container, ShellPath(host), "-c", c.command(cmd))...)
+	run.Stdin = stdin
+	run.Stdout = stdout
+	run.Stderr = stderr
+	if err := run.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), err
+		}
+		return -1, err
+	}
+	return 0, nil
+}
+
+func (c *containerConnection) Fetch(local, remote string, _ Host) error {
+	if err := util.MkFileFullPathDir(local); err != nil {
+		return err
+	}
+	if out, err := c.runtime("cp", fmt.Sprintf("%s:%s", c.container, remote), local); err != nil {
+		return fmt.Errorf("open remote file failed %v, remote path: %s\n%s", err, remote, strings.TrimSpace(out))
+	}
+	return nil
+}
+
+func (c *containerConnection) Scp(src, dst string, host Host) error {
+	f, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("get file stat failed: %s", err)
+	}
+	// the contents of a dir are copied into the dst, as over sftp
+	target := filepath.Dir(dst)
+	if f.IsDir() {
+		target = dst
+		src = src + "/."
+	}
+	if err := c.MkDirAll(target, "", host); err != nil {
+		return err
+	}
+	if out, err := c.runtime("cp", src, fmt.Sprintf("%s:%s", c.container, dst)); err != nil {
+		return fmt.Errorf("copy local file %s to remote file %s failed %v\n%s", src, dst, err, strings.TrimSpace(out))
+	}
+	return nil
+}
+
+func (c *containerConnection) RemoteFileExist(remote string, host Host) bool {
+	_, _, err := c.Exec(fmt.Sprintf("test -e %s", remote), host)
+	return err == nil
+}
+
+func (c *containerConnection) RemoteDirExist(remote string, host Host) (bool, error) {
+	if _, _, err := c.Exec(fmt.Sprintf("test -d %s", remote), host); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *containerConnection) MkDirAll(path string, mode string, host Host) error {
+	if mode == "" {
+		mode = "775"
+	}
+	_, _, err := c.Exec(fmt.Sprintf("mkdir -p -m %s %s", mode, path), host)
+	return err
+}
+
+func (c *containerConnection) Chmod(path string, mode os.FileMode) error {
+	if out, err := c.runtime("exec", c.container, "chmod", fmt.Sprintf("%o", mode.Perm()), filepath.Dir(path)); err != nil {
+		return errors.Wrapf(err, "chmod %s failed: %s", filepath.Dir(path), strings.TrimSpace(out))
+	}
+	return nil
+}
+
+// Close cancels the commands running in the container.
+func (c *containerConnection) Close() {
+	c.cancel()
+}
+
+func (c *containerConnection) closed() bool {
+	return c.ctx.Err() != nil
+}
@@ -0,0 +1,108 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeRuntime is a container runtime whose container is the local machine, it logs its args to $FAKE_RUNTIME_LOG.
+const fakeRuntime = `#!/bin/sh
+echo "$@" >> "$FAKE_RUNTIME_LOG"
+case "$1" in
+inspect)
+  [ "$4" = kk-test ] && echo true || echo false ;;
+exec)
+  shift; [ "$1" = "-i" ] && shift; shift
+  [ "$3" = "command -v sudo" ] && exit 1
+  exec "$@" ;;
+cp)
+  exec cp -r "${2#kk-test:}" "${3#kk-test:}" ;;
+esac
+`
+
+func newFakeContainerConnection(t *testing.T, container string) (*containerConnection, string, error) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "docker")
+	if err := os.WriteFile(bin, []byte(fakeRuntime), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	log := filepath.Join(dir, "runtime.log")
+	t.Setenv("FAKE_RUNTIME_LOG", log)
+	conn, err := newContainerConnection(bin, container)
+	return conn, log, err
+}
+
+func TestContainerConnection(t *testing.T) {
+	if _, _, err := newFakeContainerConnection(t, "stopped"); err == nil {
+		t.Errorf("connected to the container which isn't running")
+	}
+
+	conn, log, err := newFakeContainerConnection(t, "kk-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := NewHost()
+	host.Name = "kk-test"
+
+	stdout, code, err := conn.Exec(SudoPrefix("echo hello"), host)
+	if err != nil || code != 0 || stdout != "hello" {
+		t.Errorf("Exec() = %q, %d, %v, want hello", stdout, code, err)
+	}
+	if _, code, err := conn.Exec("exit 3", host); err == nil || code != 3 {
+		t.Errorf("Exec() code = %d, err = %v, want code 3", code, err)
+	}
+	calls, _ := os.ReadFile(log)
+	if !strings.Contains(string(calls), `exec kk-test /bin/bash -c /bin/bash -c "echo hello"`) {
+		t.Errorf("sudo isn't dropped in the container without sudo:\n%s", calls)
+	}
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "sub", "file"), []byte("content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	remote := filepath.Join(dir, "remote", "dst")
+	if err := conn.Scp(src, remote, host); err != nil {
+		t.Fatalf("Scp() error = %v", err)
+	}
+	if !conn.RemoteFileExist(filepath.Join(remote, "sub", "file"), host) {
+		t.Errorf("the contents of the dir aren't copied into %s", remote)
+	}
+	if ok, err := conn.RemoteDirExist(filepath.Join(remote, "missing"), host); ok || err == nil {
+		t.Errorf("RemoteDirExist() of a missing dir = %v, %v", ok, err)
+	}
+
+	local := filepath.Join(dir, "local", "file")
+	if err := conn.Fetch(local, filepath.Join(remote, "sub", "file"), host); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if got, _ := os.ReadFile(local); string(got) != "content" {
+		t.Errorf("Fetch() = %q, want content", got)
+	}
+
+	conn.Close()
+	if !conn.closed() {
+		t.Errorf("the connection isn't closed")
+	}
+}
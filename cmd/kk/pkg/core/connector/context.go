@@ -0,0 +1,206 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+// ClusterContext is the config file and the artifact a cluster was last run with, so the commands can be run against
+// the current cluster without them, see kk cluster use.
+type ClusterContext struct {
+	Config    string    `json:"config"`
+	Artifact  string    `json:"artifact,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SaveClusterContext records the config file and the artifact of the run in the context of the cluster, by their
+// absolute paths. The artifact of the previous runs is kept if the run has none.
+func (b *BaseRuntime) SaveClusterContext(config, artifact string) error {
+	if config == "" || b.clusterWorkDir == "" {
+		return nil
+	}
+	ctx, err := LoadClusterContext(b.workDir, b.ObjName)
+	if err != nil {
+		return err
+	}
+	if ctx == nil {
+		ctx = &ClusterContext{}
+	}
+	if ctx.Config, err = filepath.Abs(config); err != nil {
+		return errors.Wrapf(err, "get the absolute path of %s failed", config)
+	}
+	if artifact != "" {
+		if ctx.Artifact, err = filepath.Abs(artifact); err != nil {
+			return errors.Wrapf(err, "get the absolute path of %s failed", artifact)
+		}
+	}
+	ctx.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(ctx, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encode the cluster context failed")
+	}
+	return util.WriteFile(filepath.Join(b.clusterWorkDir, common.ContextFile), data)
+}
+
+// LoadClusterContext returns the context of the cluster in the work dir, which is nil if the cluster has none.
+func LoadClusterContext(workDir, name string) (*ClusterContext, error) {
+	file := filepath.Join(workDir, common.ClustersDir, name, common.ContextFile)
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the cluster context %s", file)
+	}
+	ctx := &ClusterContext{}
+	if err := json.Unmarshal(data, ctx); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the cluster context %s", file)
+	}
+	return ctx, nil
+}
+
+// CurrentCluster returns the name of the current cluster of the work dir, which is empty if none is used.
+func CurrentCluster(workDir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(workDir, common.ClustersDir, common.CurrentClusterFile))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read the current cluster")
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// UseCluster makes the cluster the current one of the work dir, the commands run against its config without -f. The
+// cluster must have a context. The current cluster is unset if the name is empty.
+func UseCluster(workDir, name string) error {
+	file := filepath.Join(workDir, common.ClustersDir, common.CurrentClusterFile)
+	if name == "" {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "failed to unset the current cluster")
+		}
+		return nil
+	}
+	ctx, err := LoadClusterContext(workDir, name)
+	if err != nil {
+		return err
+	}
+	if ctx == nil {
+		return errors.Errorf("the cluster %s isn't managed from %s, run kk with its config file by -f first", name, workDir)
+	}
+	return util.WriteFile(file, []byte(name+"\n"))
+}
+
+// ClusterInfo is a cluster of the work dir with its context and its last run.
+type ClusterInfo struct {
+	Name    string `json:"name"`
+	Current bool   `json:"current,omitempty"`
+	ClusterContext
+	// KubeConfig is the kubeconfig of the cluster in its work dir, which is empty if it hasn't been fetched.
+	KubeConfig string `json:"kubeConfig,omitempty"`
+	// LastRun is the pipeline and the status of the last run, of the report in the work dir of the cluster.
+	LastRun   string    `json:"lastRun,omitempty"`
+	LastRunAt time.Time `json:"lastRunAt,omitempty"`
+}
+
+// ListClusters returns the clusters of the work dir sorted by their names.
+func ListClusters(workDir string) ([]ClusterInfo, error) {
+	dir := filepath.Join(workDir, common.ClustersDir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the clusters dir %s", dir)
+	}
+	current, err := CurrentCluster(workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var clusters []ClusterInfo
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info := ClusterInfo{Name: entry.Name(), Current: entry.Name() == current}
+		ctx, err := LoadClusterContext(workDir, info.Name)
+		if err != nil {
+			return nil, err
+		}
+		if ctx != nil {
+			info.ClusterContext = *ctx
+		}
+		kubeConfig := filepath.Join(dir, info.Name, fmt.Sprintf("config-%s", info.Name))
+		if util.IsExist(kubeConfig) {
+			info.KubeConfig = kubeConfig
+		}
+		if data, err := os.ReadFile(filepath.Join(dir, info.Name, common.ReportFile)); err == nil {
+			var report struct {
+				Pipeline string    `json:"pipeline"`
+				Status   string    `json:"status"`
+				EndTime  time.Time `json:"endTime"`
+			}
+			if json.Unmarshal(data, &report) == nil && report.Pipeline != "" {
+				info.LastRun = fmt.Sprintf("%s %s", report.Pipeline, report.Status)
+				info.LastRunAt = report.EndTime
+			}
+		}
+		clusters = append(clusters, info)
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Name < clusters[j].Name })
+	return clusters, nil
+}
+
+// PrintClusters prints the clusters as a table, the current cluster is marked by *.
+func PrintClusters(w io.Writer, clusters []ClusterInfo) error {
+	tw := tabwriter.NewWriter(w, 10, 4, 3, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "CURRENT\tNAME\tCONFIG\tKUBECONFIG\tLAST RUN")
+	for _, c := range clusters {
+		current := ""
+		if c.Current {
+			current = "*"
+		}
+		lastRun := "<none>"
+		if c.LastRun != "" {
+			lastRun = fmt.Sprintf("%s at %s", c.LastRun, c.LastRunAt.Local().Format(time.RFC3339))
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", current, c.Name, orNone(c.Config), orNone(c.KubeConfig), lastRun)
+	}
+	return tw.Flush()
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
@@ -0,0 +1,91 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/common"
+)
+
+func TestClusterContext(t *testing.T) {
+	workDir := t.TempDir()
+	newRuntime := func(name string) *BaseRuntime {
+		clusterWorkDir := filepath.Join(workDir, common.ClustersDir, name)
+		if err := os.MkdirAll(clusterWorkDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		return &BaseRuntime{ObjName: name, workDir: workDir, clusterWorkDir: clusterWorkDir}
+	}
+
+	prod := newRuntime("prod")
+	if err := prod.SaveClusterContext("/etc/kubekey/prod.yaml", "/data/kubekey.tar.gz"); err != nil {
+		t.Fatal(err)
+	}
+	// the artifact of the previous run is kept
+	if err := prod.SaveClusterContext("/etc/kubekey/prod.yaml", ""); err != nil {
+		t.Fatal(err)
+	}
+	ctx, err := LoadClusterContext(workDir, "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Config != "/etc/kubekey/prod.yaml" || ctx.Artifact != "/data/kubekey.tar.gz" {
+		t.Errorf("LoadClusterContext() = %+v", ctx)
+	}
+	if ctx, err := LoadClusterContext(workDir, "staging"); ctx != nil || err != nil {
+		t.Errorf("LoadClusterContext() of the cluster without a context = %v, %v", ctx, err)
+	}
+
+	// the cluster without a context can't be used
+	newRuntime("staging")
+	if err := UseCluster(workDir, "staging"); err == nil {
+		t.Error("UseCluster() of the cluster without a context succeeded")
+	}
+	if err := UseCluster(workDir, "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if current, err := CurrentCluster(workDir); current != "prod" || err != nil {
+		t.Errorf("CurrentCluster() = %q, %v, want prod", current, err)
+	}
+
+	clusters, err := ListClusters(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 2 || clusters[0].Name != "prod" || !clusters[0].Current || clusters[1].Name != "staging" || clusters[1].Config != "" {
+		t.Fatalf("ListClusters() = %+v", clusters)
+	}
+	var out bytes.Buffer
+	if err := PrintClusters(&out, clusters); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 3 || !strings.HasPrefix(lines[1], "*") {
+		t.Errorf("PrintClusters() = %q", out.String())
+	}
+
+	if err := UseCluster(workDir, ""); err != nil {
+		t.Fatal(err)
+	}
+	if current, err := CurrentCluster(workDir); current != "" || err != nil {
+		t.Errorf("CurrentCluster() after unset = %q, %v", current, err)
+	}
+}
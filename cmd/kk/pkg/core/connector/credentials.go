@@ -0,0 +1,170 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// PasswordKey and PrivateKeyKey are the keys of the password and the private key in the secrets of the
+	// credential providers.
+	PasswordKey   = "password"
+	PrivateKeyKey = "privateKey"
+	// UsernameKey is the key of the username in the secrets, which is only used by the registry auths, the ssh user
+	// is the user of the host.
+	UsernameKey = "username"
+	// SSHAuthPrivateKeyKey is the key of the private key in the kubernetes.io/ssh-auth secrets.
+	SSHAuthPrivateKeyKey = "ssh-privatekey"
+)
+
+// Credentials are the ssh credentials of a host, which are resolved by a CredentialProvider. They are also the
+// credentials of the registry auths, with the Username.
+type Credentials struct {
+	Username    string
+	Password    string
+	PrivateKey  string
+	AgentSocket string
+}
+
+// CredentialProvider resolves the ssh credentials referenced by "<scheme>://<ref>", the scheme selects the provider.
+type CredentialProvider interface {
+	Resolve(ctx context.Context, ref string) (Credentials, error)
+}
+
+var credentialProviders = struct {
+	mu        sync.RWMutex
+	providers map[string]CredentialProvider
+}{providers: map[string]CredentialProvider{
+	"env":       new(envCredentialProvider),
+	"ssh-agent": new(agentCredentialProvider),
+	"secret":    new(kubernetesCredentialProvider),
+	"vault":     new(vaultCredentialProvider),
+	"aws-sm":    new(awsCredentialProvider),
+}}
+
+// RegisterCredentialProvider registers the provider of the scheme, it replaces the provider registered before.
+func RegisterCredentialProvider(scheme string, provider CredentialProvider) {
+	credentialProviders.mu.Lock()
+	defer credentialProviders.mu.Unlock()
+	credentialProviders.providers[scheme] = provider
+}
+
+// ResolveCredentials resolves the ssh credentials referenced by "<scheme>://<ref>".
+func ResolveCredentials(ctx context.Context, reference string) (Credentials, error) {
+	scheme, ref, ok := strings.Cut(reference, "://")
+	if !ok {
+		return Credentials{}, errors.Errorf("invalid credentials reference %q, it must be <scheme>://<ref>", reference)
+	}
+
+	credentialProviders.mu.RLock()
+	provider, ok := credentialProviders.providers[scheme]
+	schemes := make([]string, 0, len(credentialProviders.providers))
+	for s := range credentialProviders.providers {
+		schemes = append(schemes, s)
+	}
+	credentialProviders.mu.RUnlock()
+	if !ok {
+		sort.Strings(schemes)
+		return Credentials{}, errors.Errorf("unsupported credentials provider %q, supported providers are %s",
+			scheme, strings.Join(schemes, ", "))
+	}
+
+	credentials, err := provider.Resolve(ctx, ref)
+	if err != nil {
+		return Credentials{}, errors.Wrapf(err, "failed to resolve the credentials %s", reference)
+	}
+	return credentials, nil
+}
+
+// LoadCredentials resolves the credentials of the host referenced by CredentialsFrom, the password and the
+// private key in the config take precedence over the resolved ones.
+func (b *BaseHost) LoadCredentials(ctx context.Context) error {
+	if b.CredentialsFrom == "" {
+		return nil
+	}
+	credentials, err := ResolveCredentials(ctx, b.CredentialsFrom)
+	if err != nil {
+		return errors.Wrapf(err, "host %s", b.Name)
+	}
+	if b.Password == "" {
+		b.Password = credentials.Password
+	}
+	if b.PrivateKey == "" && b.PrivateKeyPath == "" {
+		b.PrivateKey = credentials.PrivateKey
+	}
+	if b.AgentSocket == "" {
+		b.AgentSocket = credentials.AgentSocket
+	}
+	return nil
+}
+
+// credentialsFromData returns the credentials in the data of a secret, at least one of them must be set.
+func credentialsFromData(data map[string]string) (Credentials, error) {
+	credentials := Credentials{
+		Username:   data[UsernameKey],
+		Password:   data[PasswordKey],
+		PrivateKey: data[PrivateKeyKey],
+	}
+	if credentials.PrivateKey == "" {
+		credentials.PrivateKey = data[SSHAuthPrivateKeyKey]
+	}
+	if credentials.Password == "" && credentials.PrivateKey == "" {
+		return credentials, errors.Errorf("neither %s nor %s is found", PasswordKey, PrivateKeyKey)
+	}
+	return credentials, nil
+}
+
+// envCredentialProvider reads the credentials of env://<PREFIX> from the envs <PREFIX>_PASSWORD and
+// <PREFIX>_PRIVATE_KEY, and the optional <PREFIX>_USERNAME.
+type envCredentialProvider struct{}
+
+func (p *envCredentialProvider) Resolve(_ context.Context, ref string) (Credentials, error) {
+	if ref == "" {
+		return Credentials{}, errors.New("the prefix of the envs is required")
+	}
+	credentials := Credentials{
+		Username:   os.Getenv(ref + "_USERNAME"),
+		Password:   os.Getenv(ref + "_PASSWORD"),
+		PrivateKey: os.Getenv(ref + "_PRIVATE_KEY"),
+	}
+	if credentials.Password == "" && credentials.PrivateKey == "" {
+		return credentials, errors.Errorf("neither %s_PASSWORD nor %s_PRIVATE_KEY is set", ref, ref)
+	}
+	return credentials, nil
+}
+
+// agentCredentialProvider authenticates with the keys of the ssh-agent listening on the socket of
+// ssh-agent://<path>, or on SSH_AUTH_SOCK of ssh-agent://.
+type agentCredentialProvider struct{}
+
+func (p *agentCredentialProvider) Resolve(_ context.Context, ref string) (Credentials, error) {
+	if ref != "" {
+		return Credentials{AgentSocket: ref}, nil
+	}
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return Credentials{}, errors.New("SSH_AUTH_SOCK is not set, start ssh-agent or set the path of its socket")
+	}
+	return Credentials{AgentSocket: socket}, nil
+}
@@ -0,0 +1,54 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/pkg/errors"
+)
+
+// awsCredentialProvider reads the credentials of aws-sm://<secret-id> from AWS Secrets Manager, the secret-id is the
+// name or the ARN of a secret, whose value is a JSON object of the password and the privateKey. The AWS client is
+// configured by the envs and the shared config of the AWS CLI.
+type awsCredentialProvider struct{}
+
+func (p *awsCredentialProvider) Resolve(ctx context.Context, ref string) (Credentials, error) {
+	if ref == "" {
+		return Credentials{}, errors.New("the secret id is required")
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{SharedConfigState: session.SharedConfigEnable})
+	if err != nil {
+		return Credentials{}, errors.Wrap(err, "failed to create the AWS session")
+	}
+	output, err := secretsmanager.New(sess).GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(ref),
+	})
+	if err != nil {
+		return Credentials{}, errors.Wrap(err, "failed to get the secret value")
+	}
+
+	data := make(map[string]string)
+	if err := json.Unmarshal([]byte(aws.StringValue(output.SecretString)), &data); err != nil {
+		return Credentials{}, errors.Wrap(err, "the secret value must be a JSON object of strings")
+	}
+	return credentialsFromData(data)
+}
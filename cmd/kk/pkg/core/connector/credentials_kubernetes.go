@@ -0,0 +1,69 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// kubernetesCredentialProvider reads the credentials of secret://<namespace>/<name> from a Secret, the client is
+// configured by KUBECONFIG, ~/.kube/config or the service account of the pod.
+type kubernetesCredentialProvider struct {
+	// client is replaced in the tests
+	client func() (kubernetes.Interface, error)
+}
+
+func (p *kubernetesCredentialProvider) Resolve(ctx context.Context, ref string) (Credentials, error) {
+	namespace, name, ok := strings.Cut(ref, "/")
+	if !ok || namespace == "" || name == "" {
+		return Credentials{}, errors.Errorf("invalid secret %q, it must be <namespace>/<name>", ref)
+	}
+
+	newClient := p.client
+	if newClient == nil {
+		newClient = newKubernetesClient
+	}
+	client, err := newClient()
+	if err != nil {
+		return Credentials{}, err
+	}
+	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return Credentials{}, errors.Wrap(err, "failed to get the secret")
+	}
+
+	data := make(map[string]string, len(secret.Data))
+	for k, v := range secret.Data {
+		data[k] = string(v)
+	}
+	return credentialsFromData(data)
+}
+
+func newKubernetesClient() (kubernetes.Interface, error) {
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load the kubeconfig")
+	}
+	return kubernetes.NewForConfig(config)
+}
@@ -0,0 +1,97 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestResolveCredentials(t *testing.T) {
+	t.Setenv("NODE1_PASSWORD", "env-secret")
+	t.Setenv("SSH_AUTH_SOCK", "/tmp/agent.sock")
+	t.Setenv("VAULT_TOKEN", "vault-token")
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/node1" || r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"data":{"privateKey":"vault-key"}}}`))
+	}))
+	defer vault.Close()
+	t.Setenv("VAULT_ADDR", vault.URL)
+
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kubekey", Name: "node1"},
+		Type:       corev1.SecretTypeSSHAuth,
+		Data:       map[string][]byte{corev1.SSHAuthPrivateKey: []byte("secret-key")},
+	})
+	RegisterCredentialProvider("secret", &kubernetesCredentialProvider{
+		client: func() (kubernetes.Interface, error) { return client, nil },
+	})
+	defer RegisterCredentialProvider("secret", new(kubernetesCredentialProvider))
+
+	tests := []struct {
+		ref     string
+		want    Credentials
+		wantErr bool
+	}{
+		{ref: "env://NODE1", want: Credentials{Password: "env-secret"}},
+		{ref: "env://NODE2", wantErr: true},
+		{ref: "ssh-agent://", want: Credentials{AgentSocket: "/tmp/agent.sock"}},
+		{ref: "ssh-agent:///run/agent.sock", want: Credentials{AgentSocket: "/run/agent.sock"}},
+		{ref: "secret://kubekey/node1", want: Credentials{PrivateKey: "secret-key"}},
+		{ref: "secret://kubekey/node2", wantErr: true},
+		{ref: "secret://node1", wantErr: true},
+		{ref: "vault://secret/node1", want: Credentials{PrivateKey: "vault-key"}},
+		{ref: "vault://secret/node2", wantErr: true},
+		{ref: "file:///root/.ssh/id_rsa", wantErr: true},
+		{ref: "NODE1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := ResolveCredentials(context.Background(), tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveCredentials() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveCredentials() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadCredentials(t *testing.T) {
+	t.Setenv("NODE1_PASSWORD", "env-secret")
+	t.Setenv("NODE1_PRIVATE_KEY", "env-key")
+
+	host := &BaseHost{Name: "node1", PrivateKeyPath: "/root/.ssh/id_ed25519", CredentialsFrom: "env://NODE1"}
+	if err := host.LoadCredentials(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// the private key in the config takes precedence
+	if host.Password != "env-secret" || host.PrivateKey != "" {
+		t.Errorf("LoadCredentials() password = %q, private key = %q", host.Password, host.PrivateKey)
+	}
+}
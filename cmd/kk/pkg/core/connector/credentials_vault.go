@@ -0,0 +1,79 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// vaultCredentialProvider reads the credentials of vault://<mount>/<path> from the KV version 2 secrets engine of
+// HashiCorp Vault, which is addressed by VAULT_ADDR and authenticated by VAULT_TOKEN.
+type vaultCredentialProvider struct {
+	// client is replaced in the tests
+	client *http.Client
+}
+
+func (p *vaultCredentialProvider) Resolve(ctx context.Context, ref string) (Credentials, error) {
+	mount, path, ok := strings.Cut(ref, "/")
+	if !ok || mount == "" || path == "" {
+		return Credentials{}, errors.Errorf("invalid vault secret %q, it must be <mount>/<path>", ref)
+	}
+	addr, token := os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN")
+	if addr == "" || token == "" {
+		return Credentials{}, errors.New("VAULT_ADDR and VAULT_TOKEN are required")
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/data/%s", strings.TrimSuffix(addr, "/"), mount, path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Credentials{}, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	client := p.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Credentials{}, errors.Wrap(err, "failed to read the vault secret")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Credentials{}, errors.Errorf("failed to read the vault secret: %s", resp.Status)
+	}
+
+	var secret struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return Credentials{}, errors.Wrap(err, "failed to decode the vault secret")
+	}
+	return credentialsFromData(secret.Data.Data)
+}
@@ -0,0 +1,85 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+// Deadlines bounds the run and its phases, so an unattended run can't hang for hours. The phases are named by the tags
+// of the modules, a phase starts with its first module and bounds all the modules with its tag.
+type Deadlines struct {
+	// Start is the start of the run.
+	Start time.Time
+	// Run is the timeout of the whole run, there is no deadline if it is zero.
+	Run time.Duration
+	// Phases are the timeouts of the phases by their tags.
+	Phases map[string]time.Duration
+
+	phaseStarts map[string]time.Time
+}
+
+// Deadline is the deadline of the run or a phase.
+type Deadline struct {
+	// Name is "run", or "phase" followed by the tag of the phase.
+	Name    string
+	Timeout time.Duration
+	Time    time.Time
+}
+
+func (d Deadline) String() string {
+	return fmt.Sprintf("the %s deadline of %s", d.Name, util.ShortDur(d.Timeout))
+}
+
+// Next starts the phases of the module with the tags, and returns the earliest deadline of the run and the phases of
+// the module. It returns false if the module has no deadline.
+func (d *Deadlines) Next(tags []string, now time.Time) (Deadline, bool) {
+	var deadlines []Deadline
+	if d.Run > 0 {
+		deadlines = append(deadlines, Deadline{Name: "run", Timeout: d.Run, Time: d.Start.Add(d.Run)})
+	}
+	for phase, timeout := range d.Phases {
+		if timeout <= 0 || !matchTags([]string{phase}, tags) {
+			continue
+		}
+		key := strings.ToLower(phase)
+		if d.phaseStarts == nil {
+			d.phaseStarts = make(map[string]time.Time)
+		}
+		start, ok := d.phaseStarts[key]
+		if !ok {
+			start = now
+			d.phaseStarts[key] = start
+		}
+		deadlines = append(deadlines, Deadline{Name: "phase " + phase, Timeout: timeout, Time: start.Add(timeout)})
+	}
+	if len(deadlines) == 0 {
+		return Deadline{}, false
+	}
+	sort.Slice(deadlines, func(i, j int) bool {
+		if !deadlines[i].Time.Equal(deadlines[j].Time) {
+			return deadlines[i].Time.Before(deadlines[j].Time)
+		}
+		return deadlines[i].Name < deadlines[j].Name
+	})
+	return deadlines[0], true
+}
@@ -0,0 +1,75 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeadlines_Next(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := &Deadlines{
+		Start:  start,
+		Run:    2 * time.Hour,
+		Phases: map[string]time.Duration{"etcd": 20 * time.Minute, "Kubernetes": time.Hour},
+	}
+
+	tests := []struct {
+		name string
+		tags []string
+		now  time.Time
+		want Deadline
+	}{
+		{
+			name: "the run",
+			tags: []string{"os"},
+			now:  start.Add(time.Minute),
+			want: Deadline{Name: "run", Timeout: 2 * time.Hour, Time: start.Add(2 * time.Hour)},
+		},
+		{
+			name: "the phase starts with its first module",
+			tags: []string{"etcd", "certs"},
+			now:  start.Add(10 * time.Minute),
+			want: Deadline{Name: "phase etcd", Timeout: 20 * time.Minute, Time: start.Add(30 * time.Minute)},
+		},
+		{
+			name: "the later modules of the phase share its deadline",
+			tags: []string{"etcd"},
+			now:  start.Add(20 * time.Minute),
+			want: Deadline{Name: "phase etcd", Timeout: 20 * time.Minute, Time: start.Add(30 * time.Minute)},
+		},
+		{
+			name: "the run ends before the phase",
+			tags: []string{"kubernetes"},
+			now:  start.Add(90 * time.Minute),
+			want: Deadline{Name: "run", Timeout: 2 * time.Hour, Time: start.Add(2 * time.Hour)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := d.Next(tt.tags, tt.now)
+			if !ok || got != tt.want {
+				t.Errorf("Next() = %+v, %v, want %+v", got, ok, tt.want)
+			}
+		})
+	}
+
+	if _, ok := (&Deadlines{}).Next([]string{"etcd"}, start); ok {
+		t.Error("Next() returned a deadline without any deadline set")
+	}
+}
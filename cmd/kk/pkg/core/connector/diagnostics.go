@@ -0,0 +1,111 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/common"
+)
+
+const (
+	diagnosticsKey = "diagnostics"
+	// diagnosticJournalLines is the number of the last journal lines collected of each unit.
+	diagnosticJournalLines = 200
+	// diagnosticDmesgLines is the number of the last kernel messages collected.
+	diagnosticDmesgLines = 100
+)
+
+// diagnosticUnits are the units whose journal is collected when a task fails.
+var diagnosticUnits = []string{"kubelet", "containerd", "docker", "cri-docker", "etcd"}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+type diagnosticCommand struct {
+	Name string
+	Cmd  string
+}
+
+// diagnosticCommands returns the commands of the diagnostic snapshot, the units which don't exist are skipped.
+func diagnosticCommands() []diagnosticCommand {
+	cmds := make([]diagnosticCommand, 0, len(diagnosticUnits)+3)
+	for _, unit := range diagnosticUnits {
+		cmds = append(cmds, diagnosticCommand{
+			Name: "journal " + unit,
+			Cmd: fmt.Sprintf("systemctl cat %[1]s.service >/dev/null 2>&1 && journalctl -u %[1]s -n %[2]d --no-pager || echo 'no unit %[1]s'",
+				unit, diagnosticJournalLines),
+		})
+	}
+	return append(cmds,
+		diagnosticCommand{Name: "dmesg", Cmd: fmt.Sprintf("dmesg -T 2>/dev/null | tail -n %d", diagnosticDmesgLines)},
+		diagnosticCommand{Name: "containerd status", Cmd: "systemctl status containerd --no-pager -l 2>&1 | head -n 50"},
+		diagnosticCommand{Name: "containers", Cmd: "crictl ps -a 2>&1 | head -n 50"},
+	)
+}
+
+// SaveDiagnostics collects a diagnostic snapshot of the host the task failed on: the last journal lines of the
+// units of the cluster, the last kernel messages and the state of containerd. It is saved in the diagnostics dir
+// of the cluster work dir and returned, and the report of the task links it. The commands are run on the
+// connection directly, so the output of the failed command is still reported.
+func SaveDiagnostics(runtime Runtime, task string) (string, error) {
+	host := runtime.RemoteHost()
+	conn := runtime.GetRunner().Conn
+	if conn == nil {
+		return "", errors.New("no ssh connection available")
+	}
+
+	var b strings.Builder
+	for _, c := range diagnosticCommands() {
+		stdout, _, err := conn.Exec(SudoCommand(host, c.Cmd), host)
+		fmt.Fprintf(&b, "==> %s <==\n%s\n", c.Name, strings.TrimRight(stdout, "\n"))
+		if err != nil {
+			fmt.Fprintf(&b, "(%v)\n", err)
+		}
+		b.WriteString("\n")
+	}
+
+	dir := filepath.Join(runtime.GetClusterWorkDir(), common.DiagnosticsDir, host.GetName())
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", errors.Wrapf(err, "failed to create the diagnostics dir %s", dir)
+	}
+	file := filepath.Join(dir, fmt.Sprintf("%s-%s.log", time.Now().Format("20060102-150405"), unsafeFileChars.ReplaceAllString(task, "-")))
+	if err := os.WriteFile(file, []byte(redact(b.String())), 0600); err != nil {
+		return "", errors.Wrapf(err, "failed to write the diagnostics %s", file)
+	}
+	if c := host.GetCache(); c != nil {
+		c.Set(diagnosticsKey, file)
+	}
+	return file, nil
+}
+
+// TakeDiagnostics returns the diagnostics saved after the last failure on the host, and resets it for the next task.
+func TakeDiagnostics(host Host) string {
+	if host == nil || host.GetCache() == nil {
+		return ""
+	}
+	c := host.GetCache()
+	defer c.Delete(diagnosticsKey)
+	file, _ := c.GetMustString(diagnosticsKey)
+	return file
+}
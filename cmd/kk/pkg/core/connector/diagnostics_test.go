@@ -0,0 +1,60 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/common"
+)
+
+func TestSaveDiagnostics(t *testing.T) {
+	host := NewHost()
+	host.Name = "node1"
+	runtime := &BaseRuntime{clusterWorkDir: t.TempDir()}
+	runtime.SetRunner(&Runner{Conn: &fakeConnection{}, Host: host})
+
+	file, err := SaveDiagnostics(runtime, "InitKubernetesModule/GenerateKubeadmConfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir := filepath.Join(runtime.GetClusterWorkDir(), common.DiagnosticsDir, "node1"); filepath.Dir(file) != dir {
+		t.Errorf("SaveDiagnostics() = %s, want a file in %s", file, dir)
+	}
+	if !strings.HasSuffix(file, "-InitKubernetesModule-GenerateKubeadmConfig.log") {
+		t.Errorf("SaveDiagnostics() = %s, want the task in the file name", file)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"==> journal kubelet <==", "==> dmesg <==", "==> containerd status <=="} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("the diagnostics do not contain %q:\n%s", want, data)
+		}
+	}
+
+	if got := TakeDiagnostics(host); got != file {
+		t.Errorf("TakeDiagnostics() = %s, want %s", got, file)
+	}
+	if got := TakeDiagnostics(host); got != "" {
+		t.Errorf("TakeDiagnostics() after it was taken = %s, want it reset", got)
+	}
+}
@@ -41,17 +41,19 @@ func (d *Dialer) Connect(host Host) (Connection, error) {
 	defer d.lock.Unlock()
 
 	conn, ok := d.connections[host.GetName()]
+	// the connection is closed when an operation on the host times out, dial the host again
+	if c, isConn := conn.(interface{ closed() bool }); ok && isConn && c.closed() {
+		ok = false
+	}
 	if !ok {
-		opts := Cfg{
-			Username:   host.GetUser(),
-			Port:       host.GetPort(),
-			Address:    host.GetAddress(),
-			Password:   host.GetPassword(),
-			PrivateKey: host.GetPrivateKey(),
-			KeyFile:    host.GetPrivateKeyPath(),
-			Timeout:    time.Duration(host.GetTimeout()) * time.Second,
+		switch {
+		case IsContainerConnector(host.GetConnectorType()):
+			conn, err = NewContainerConnection(host.GetConnectorType(), host.GetName())
+		case host.GetConnectorType() == ConnectorAgent:
+			conn, err = NewAgentConnection(host)
+		default:
+			conn, err = dialSSH(host)
 		}
-		conn, err = NewConnection(opts)
 		if err != nil {
 			return nil, err
 		}
@@ -61,6 +63,30 @@ func (d *Dialer) Connect(host Host) (Connection, error) {
 	return conn, nil
 }
 
+func dialSSH(host Host) (Connection, error) {
+	opts := Cfg{
+		Username:    host.GetUser(),
+		Port:        host.GetPort(),
+		Address:     host.GetAddress(),
+		Password:    host.GetPassword(),
+		PrivateKey:  host.GetPrivateKey(),
+		KeyFile:     host.GetPrivateKeyPath(),
+		AgentSocket: host.GetAgentSocket(),
+		Timeout:     time.Duration(host.GetTimeout()) * time.Second,
+		Bastion:     host.GetBastion(),
+		BastionPort: host.GetBastionPort(),
+		BastionUser: host.GetBastionUser(),
+
+		TransferCompression: host.GetTransferCompression(),
+	}
+	rateLimit, err := ParseTransferRateLimit(host.GetTransferRateLimit())
+	if err != nil {
+		return nil, err
+	}
+	opts.TransferRateLimit = rateLimit
+	return NewConnection(opts)
+}
+
 func (d *Dialer) Close(host Host) {
 	conn, ok := d.connections[host.GetName()]
 	if !ok {
@@ -70,11 +96,10 @@ func (d *Dialer) Close(host Host) {
 	conn.Close()
 	logger.Log.Debugf("close connection %s", host.GetName())
 
-	c := conn.(*connection)
-	d.forgetConnection(c)
+	d.forgetConnection(conn)
 }
 
-func (d *Dialer) forgetConnection(conn *connection) {
+func (d *Dialer) forgetConnection(conn Connection) {
 	d.lock.Lock()
 	defer d.lock.Unlock()
 
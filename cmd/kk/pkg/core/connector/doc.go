@@ -0,0 +1,37 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package connector is the host-execution layer of KubeKey: it connects to the hosts over SSH or into containers,
+// runs the commands and transfers the files. Besides the pipelines of kk, it's a stable Go API for the tools which
+// embed the layer:
+//
+//	host := connector.NewHostWithOptions(connector.HostOptions{Name: "node1", Address: "192.168.0.2", KeyFile: "~/.ssh/id_rsa"})
+//	dialer := connector.NewConnector(connector.ConnectorOptions{Policy: policy, Audit: sink})
+//	defer dialer.Close(host)
+//	runner, err := connector.NewRunner(ctx, dialer, host)
+//	if err != nil {
+//		return err
+//	}
+//	out, err := runner.SudoCmd("systemctl is-active kubelet", false)
+//
+// The stable API is Host, HostOptions and NewHostWithOptions, Connector, ConnectorOptions and NewConnector,
+// Connection, Runner and NewRunner, and the configs and sinks of the layers of ConnectorOptions. It follows the
+// semantic versioning of the module github.com/kubesphere/kubekey/v3: it's only changed incompatibly in a new major
+// version of the module. The runtime of the pipelines, e.g. BaseRuntime and the runtime interfaces, is internal to kk
+// and may change in any version.
+//
+// The facts of the hosts are gathered on a runner by facts.Gather.
+package connector
@@ -0,0 +1,180 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+// DryRunDialer wraps a Connector for the check mode. The hosts are connected to verify they are reachable, but the
+// commands and file transfers are only reported instead of being executed.
+type DryRunDialer struct {
+	Connector
+	renderDir string
+}
+
+func NewDryRunDialer(connector Connector) *DryRunDialer {
+	return &DryRunDialer{Connector: connector}
+}
+
+// NewRenderDialer returns a DryRunDialer for the manifest-only mode, which doesn't connect to the hosts. The files
+// rendered for each host are written under the dir instead, see RenderDir.
+func NewRenderDialer(dir string) *DryRunDialer {
+	return &DryRunDialer{renderDir: dir}
+}
+
+func (d *DryRunDialer) Connect(host Host) (Connection, error) {
+	if d.Connector == nil {
+		return &dryRunConnection{}, nil
+	}
+	conn, err := d.Connector.Connect(host)
+	if err != nil {
+		return nil, err
+	}
+	return &dryRunConnection{conn: conn}, nil
+}
+
+func (d *DryRunDialer) Close(host Host) {
+	if d.Connector != nil {
+		d.Connector.Close(host)
+	}
+}
+
+// Unwrap returns the wrapped connector.
+func (d *DryRunDialer) Unwrap() Connector {
+	return d.Connector
+}
+
+// IsDryRun reports whether the connector is in the check mode.
+func IsDryRun(connector Connector) bool {
+	_, ok := connector.(*DryRunDialer)
+	return ok
+}
+
+// RenderDir returns the dir the rendered files are written to in the manifest-only mode, or an empty string if
+// the connector isn't in the manifest-only mode.
+func RenderDir(connector Connector) string {
+	if d, ok := connector.(*DryRunDialer); ok {
+		return d.renderDir
+	}
+	return ""
+}
+
+// maxDryRunDiffSize is the max size of the files whose content is compared with the remote files in the check mode.
+const maxDryRunDiffSize = 1 << 20
+
+// dryRunConnection reports the operations on the host, the commands always succeed with an empty output.
+// The connection to the host, nil in the manifest-only mode, is only used to read the remote files.
+type dryRunConnection struct {
+	conn Connection
+}
+
+func (c *dryRunConnection) Exec(cmd string, host Host) (string, int, error) {
+	logger.ForHost(host.GetName()).Infof("dry-run: exec: %s", cmd)
+	return "", 0, nil
+}
+
+func (c *dryRunConnection) PExec(cmd string, stdin io.Reader, stdout io.Writer, stderr io.Writer, host Host) (int, error) {
+	logger.ForHost(host.GetName()).Infof("dry-run: exec: %s", cmd)
+	return 0, nil
+}
+
+func (c *dryRunConnection) Fetch(local, remote string, host Host) error {
+	logger.ForHost(host.GetName()).Infof("dry-run: fetch: %s -> %s", remote, local)
+	return nil
+}
+
+// Scp reports the copy, with the diff of the content against the remote file for a text file.
+func (c *dryRunConnection) Scp(local, remote string, host Host) error {
+	log := logger.ForHost(host.GetName())
+	info, err := os.Stat(local)
+	if err != nil {
+		log.Infof("dry-run: copy: %s -> %s", local, remote)
+		return nil
+	}
+	log.Infof("dry-run: copy: %s (%d bytes) -> %s", local, info.Size(), remote)
+	if diff := c.diff(local, remote, info, host); diff != "" {
+		log.Infof("dry-run: diff:\n%s", diff)
+	}
+	return nil
+}
+
+// diff returns the diff from the content of the remote file to the local file. It's empty if they are the same, or
+// they can't be compared: the local file isn't a small text file, or the remote file can't be read.
+func (c *dryRunConnection) diff(local, remote string, info os.FileInfo, host Host) string {
+	if c.conn == nil || !info.Mode().IsRegular() || info.Size() > maxDryRunDiffSize {
+		return ""
+	}
+	content, err := os.ReadFile(local)
+	if err != nil || bytes.IndexByte(content, 0) >= 0 {
+		return ""
+	}
+	p := doubleQuoted.Replace(Quote(remote))
+	cmd := fmt.Sprintf("if [ -f %s ]; then base64 %s | tr -d '\\n'; fi", p, p)
+	out, _, err := c.conn.Exec(SudoCommand(host, cmd), host)
+	if err != nil {
+		return ""
+	}
+	remoteContent, err := base64.StdEncoding.DecodeString(strings.TrimSpace(out))
+	if err != nil || bytes.IndexByte(remoteContent, 0) >= 0 {
+		return ""
+	}
+	return util.Diff(remote, string(remoteContent), string(content))
+}
+
+func (c *dryRunConnection) RemoteFileExist(remote string, host Host) bool {
+	return false
+}
+
+func (c *dryRunConnection) RemoteDirExist(remote string, host Host) (bool, error) {
+	return false, nil
+}
+
+func (c *dryRunConnection) MkDirAll(path string, mode string, host Host) error {
+	logger.ForHost(host.GetName()).Infof("dry-run: mkdir: %s", path)
+	return nil
+}
+
+func (c *dryRunConnection) Chmod(path string, mode os.FileMode) error {
+	return nil
+}
+
+// Stat reports the remote files as absent, as RemoteFileExist, so the files are always copied in the dry run.
+func (c *dryRunConnection) Stat(remote string, host Host) (os.FileInfo, error) {
+	return nil, errors.Wrapf(os.ErrNotExist, "stat %s", remote)
+}
+
+func (c *dryRunConnection) Exists(remote string, host Host) (bool, error) {
+	return false, nil
+}
+
+func (c *dryRunConnection) Checksum(remote string, host Host) (string, error) {
+	return "", errors.Wrapf(os.ErrNotExist, "checksum %s", remote)
+}
+
+func (c *dryRunConnection) Close() {
+}
@@ -0,0 +1,165 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+func TestRenderDialer(t *testing.T) {
+	dialer := NewRenderDialer("/tmp/rendered")
+	if !IsDryRun(dialer) {
+		t.Errorf("IsDryRun() = false, want true in the manifest-only mode")
+	}
+	if dir := RenderDir(dialer); dir != "/tmp/rendered" {
+		t.Errorf("RenderDir() = %q, want %q", dir, "/tmp/rendered")
+	}
+	if dir := RenderDir(NewDryRunDialer(NewDialer())); dir != "" {
+		t.Errorf("RenderDir() = %q in the check mode, want empty", dir)
+	}
+
+	// the hosts aren't connected
+	host := NewHost()
+	if _, err := dialer.Connect(host); err != nil {
+		t.Errorf("Connect() error = %v", err)
+	}
+	dialer.Close(host)
+}
+
+func TestDryRunDialer(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	fake := NewFakeDialer(&FakeFixtures{
+		Commands: []FakeCommand{
+			{Match: "base64 '/etc/app.conf'", Stdout: base64.StdEncoding.EncodeToString([]byte("a\nb\nc\n"))},
+		},
+		Unreachable: []string{"node2"},
+	})
+	dialer := NewDryRunDialer(fake)
+	if !IsDryRun(dialer) {
+		t.Errorf("IsDryRun() = false, want true in the check mode")
+	}
+
+	// the hosts are connected to verify they are reachable
+	unreachable := NewHost()
+	unreachable.SetName("node2")
+	if _, err := dialer.Connect(unreachable); err == nil {
+		t.Errorf("Connect() to an unreachable host succeeded")
+	}
+
+	host := NewHost()
+	host.SetName("node1")
+	conn, err := dialer.Connect(host)
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer dialer.Close(host)
+
+	local := filepath.Join(t.TempDir(), "app.conf")
+	if err := os.WriteFile(local, []byte("a\nB\nc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if out, code, err := conn.Exec("rm -rf /var/lib/etcd", host); out != "" || code != 0 || err != nil {
+		t.Errorf("Exec() = %q, %d, %v, want it to succeed with an empty output", out, code, err)
+	}
+	if err := conn.Scp(local, "/etc/app.conf", host); err != nil {
+		t.Errorf("Scp() error = %v", err)
+	}
+	fake.AssertNoCommand(t, "node1", "rm -rf")
+	for _, call := range fake.Calls("node1") {
+		if call.Operation == AuditPut {
+			t.Errorf("the file is copied in the check mode: %s", call)
+		}
+	}
+}
+
+func TestDryRunConnection_diff(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	remote := base64.StdEncoding.EncodeToString([]byte("a\nb\nc\n"))
+	fake := NewFakeDialer(&FakeFixtures{
+		Commands: []FakeCommand{
+			{Match: "base64 '/etc/app.conf'", Stdout: remote},
+			{Match: "base64 '/etc/denied.conf'", ExitCode: 1},
+		},
+	})
+	host := NewHost()
+	host.SetName("node1")
+
+	dir := t.TempDir()
+	text := filepath.Join(dir, "app.conf")
+	if err := os.WriteFile(text, []byte("a\nB\nc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	binary := filepath.Join(dir, "app.bin")
+	if err := os.WriteFile(binary, []byte{0x7f, 'E', 'L', 'F', 0}, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		dialer *DryRunDialer
+		local  string
+		remote string
+		want   []string
+	}{
+		{name: "changed", dialer: NewDryRunDialer(fake), local: text, remote: "/etc/app.conf", want: []string{"-b", "+B"}},
+		{name: "new file", dialer: NewDryRunDialer(fake), local: text, remote: "/etc/new.conf", want: []string{"+a", "+B", "+c"}},
+		{name: "unreadable remote file", dialer: NewDryRunDialer(fake), local: text, remote: "/etc/denied.conf"},
+		{name: "binary file", dialer: NewDryRunDialer(fake), local: binary, remote: "/usr/local/bin/app"},
+		{name: "manifest-only mode", dialer: NewRenderDialer(t.TempDir()), local: text, remote: "/etc/app.conf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := tt.dialer.Connect(host)
+			if err != nil {
+				t.Fatal(err)
+			}
+			info, err := os.Stat(tt.local)
+			if err != nil {
+				t.Fatal(err)
+			}
+			diff := conn.(*dryRunConnection).diff(tt.local, tt.remote, info, host)
+			if len(tt.want) == 0 && diff != "" {
+				t.Errorf("diff() = %q, want empty", diff)
+			}
+			for _, line := range tt.want {
+				if !strings.Contains(diff, line) {
+					t.Errorf("diff() = %q, want it to contain %q", diff, line)
+				}
+			}
+		})
+	}
+
+	// the remote file is read by the shell, whatever its name
+	remoteFile := filepath.Join(dir, "it's $HOME")
+	if err := os.WriteFile(remoteFile, []byte("a\nb\nc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := &dryRunConnection{conn: &localConnection{}}
+	if diff := conn.diff(text, remoteFile, info, host); !strings.Contains(diff, "-b") || !strings.Contains(diff, "+B") {
+		t.Errorf("diff() = %q, want the diff from the remote file", diff)
+	}
+}
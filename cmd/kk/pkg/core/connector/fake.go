@@ -0,0 +1,503 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// FakeFixtures are the canned responses of the hosts replayed by a FakeDialer, so the modules can be tested without
+// real hosts. They are loaded from a YAML file by LoadFakeFixtures, or recorded from the real hosts by a
+// recording FakeDialer.
+type FakeFixtures struct {
+	// Strict fails the commands without a fixture, they succeed with an empty output otherwise.
+	Strict      bool          `yaml:"strict,omitempty"`
+	Commands    []FakeCommand `yaml:"commands,omitempty"`
+	Files       []FakeFile    `yaml:"files,omitempty"`
+	Unreachable []string      `yaml:"unreachable,omitempty"`
+}
+
+// FakeCommand is the response to the commands matching it, the first fixture matching a command is used.
+type FakeCommand struct {
+	// Hosts the fixture applies to, all the hosts if it is empty.
+	Hosts []string `yaml:"hosts,omitempty"`
+	// Match is the substring of the command, any one is matched if it is empty.
+	Match    string `yaml:"match"`
+	Stdout   string `yaml:"stdout,omitempty"`
+	ExitCode int    `yaml:"exitCode,omitempty"`
+	// Times is the number of the matched commands the fixture responds to, unlimited if it is zero. It is used to
+	// script the responses of the polled commands.
+	Times int `yaml:"times,omitempty"`
+
+	used int
+}
+
+// FakeFile is a file or a dir on the hosts, which exists for the checks and the fetches.
+type FakeFile struct {
+	// Hosts the file is on, all the hosts if it is empty.
+	Hosts   []string `yaml:"hosts,omitempty"`
+	Path    string   `yaml:"path"`
+	Content string   `yaml:"content,omitempty"`
+	Dir     bool     `yaml:"dir,omitempty"`
+}
+
+func LoadFakeFixtures(path string) (*FakeFixtures, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read fake fixtures %s", path)
+	}
+	fixtures := &FakeFixtures{}
+	if err := yaml.Unmarshal(content, fixtures); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal fake fixtures %s", path)
+	}
+	return fixtures, nil
+}
+
+// Save writes the fixtures to the file, e.g. the ones recorded from the real hosts.
+func (f *FakeFixtures) Save(path string) error {
+	content, err := yaml.Marshal(f)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal fake fixtures")
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write fake fixtures %s", path)
+	}
+	return nil
+}
+
+// FakeCall is an operation on a host, recorded by a FakeDialer.
+type FakeCall struct {
+	Host string
+	// Operation is one of AuditExec, AuditPut, AuditFetch, AuditMkdir and AuditChmod.
+	Operation string
+	Command   string
+	Local     string
+	Remote    string
+	Mode      string
+}
+
+func (c FakeCall) String() string {
+	switch c.Operation {
+	case AuditExec:
+		return fmt.Sprintf("%s: exec: %s", c.Host, c.Command)
+	case AuditPut:
+		return fmt.Sprintf("%s: put: %s -> %s", c.Host, c.Local, c.Remote)
+	case AuditFetch:
+		return fmt.Sprintf("%s: fetch: %s -> %s", c.Host, c.Remote, c.Local)
+	default:
+		return fmt.Sprintf("%s: %s: %s %s", c.Host, c.Operation, c.Remote, c.Mode)
+	}
+}
+
+// FakeDialer is a Connector for the tests, it records the operations on the hosts and replays the responses of the
+// fixtures. The files put on a host are kept in memory, so they exist for the later checks and fetches.
+//
+// A recording FakeDialer, see NewRecordingDialer, runs the operations on the real hosts instead, and records their
+// responses into the fixtures to be replayed.
+type FakeDialer struct {
+	// Connector is the connector to the real hosts of a recording dialer.
+	Connector Connector
+
+	mu       sync.Mutex
+	fixtures *FakeFixtures
+	calls    []FakeCall
+	files    map[string]map[string]*FakeFile
+}
+
+// NewFakeDialer returns a FakeDialer replaying the fixtures, the commands succeed with an empty output if it is nil.
+func NewFakeDialer(fixtures *FakeFixtures) *FakeDialer {
+	if fixtures == nil {
+		fixtures = &FakeFixtures{}
+	}
+	return &FakeDialer{fixtures: fixtures, files: make(map[string]map[string]*FakeFile)}
+}
+
+// NewRecordingDialer returns a FakeDialer recording the responses of the hosts connected to by the connector, see
+// Fixtures.
+func NewRecordingDialer(connector Connector) *FakeDialer {
+	d := NewFakeDialer(nil)
+	d.Connector = connector
+	return d
+}
+
+func (d *FakeDialer) Connect(host Host) (Connection, error) {
+	if d.Connector != nil {
+		conn, err := d.Connector.Connect(host)
+		if err != nil {
+			return nil, err
+		}
+		return &replayConnection{dialer: d, host: host.GetName(), conn: conn}, nil
+	}
+	if contains(d.fixtures.Unreachable, host.GetName()) {
+		return nil, errors.Errorf("fake: the host %s is unreachable", host.GetName())
+	}
+	return &replayConnection{dialer: d, host: host.GetName()}, nil
+}
+
+func (d *FakeDialer) Close(host Host) {
+	if d.Connector != nil {
+		d.Connector.Close(host)
+	}
+}
+
+// Fixtures returns the fixtures replayed, or the ones recorded by a recording dialer.
+func (d *FakeDialer) Fixtures() *FakeFixtures {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.fixtures
+}
+
+// Calls returns the operations on the host in order, or on all the hosts if it is empty.
+func (d *FakeDialer) Calls(host string) []FakeCall {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var calls []FakeCall
+	for _, call := range d.calls {
+		if host == "" || call.Host == host {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Commands returns the commands executed on the host in order, or on all the hosts if it is empty.
+func (d *FakeDialer) Commands(host string) []string {
+	var commands []string
+	for _, call := range d.Calls(host) {
+		if call.Operation == AuditExec {
+			commands = append(commands, call.Command)
+		}
+	}
+	return commands
+}
+
+// RemoteFile returns the content of the file on the host, put by the operations or in the fixtures.
+func (d *FakeDialer) RemoteFile(host, path string) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if f := d.file(host, path); f != nil && !f.Dir {
+		return f.Content, true
+	}
+	return "", false
+}
+
+// TestingT is the part of testing.TB used by the assertions of a FakeDialer.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertCommands asserts the commands executed on the host contain the substrings in order, the other commands may
+// be executed between them.
+func (d *FakeDialer) AssertCommands(t TestingT, host string, matches ...string) {
+	t.Helper()
+	commands := d.Commands(host)
+	i := 0
+	for _, command := range commands {
+		if i < len(matches) && strings.Contains(command, matches[i]) {
+			i++
+		}
+	}
+	if i < len(matches) {
+		t.Errorf("no command matching %q executed on %s in order, the commands are:\n%s", matches[i], host, strings.Join(commands, "\n"))
+	}
+}
+
+// AssertNoCommand asserts no command executed on the host contains the substring.
+func (d *FakeDialer) AssertNoCommand(t TestingT, host string, match string) {
+	t.Helper()
+	for _, command := range d.Commands(host) {
+		if strings.Contains(command, match) {
+			t.Errorf("the command %q executed on %s matches %q", command, host, match)
+		}
+	}
+}
+
+func (d *FakeDialer) record(call FakeCall) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.calls = append(d.calls, call)
+}
+
+// respond returns the response of the fixtures to the command, or records the response of the real host.
+func (d *FakeDialer) respond(host, cmd string) (string, int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i := range d.fixtures.Commands {
+		c := &d.fixtures.Commands[i]
+		if len(c.Hosts) > 0 && !contains(c.Hosts, host) {
+			continue
+		}
+		if c.Match != "" && !strings.Contains(cmd, c.Match) {
+			continue
+		}
+		if c.Times > 0 && c.used >= c.Times {
+			continue
+		}
+		c.used++
+		if c.ExitCode != 0 {
+			return c.Stdout, c.ExitCode, errors.Errorf("Process exited with status %d", c.ExitCode)
+		}
+		return c.Stdout, 0, nil
+	}
+	if d.fixtures.Strict {
+		return "", 1, errors.Errorf("fake: no fixture of the command %q on %s", cmd, host)
+	}
+	return "", 0, nil
+}
+
+func (d *FakeDialer) recordResponse(host, cmd, stdout string, code int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// the recorded fixtures respond once each, so the repeated commands are replayed in order
+	d.fixtures.Commands = append(d.fixtures.Commands, FakeCommand{Hosts: []string{host}, Match: cmd, Stdout: stdout, ExitCode: code, Times: 1})
+}
+
+// file returns the file or the dir at the path on the host, it must be called with the lock held.
+func (d *FakeDialer) file(host, path string) *FakeFile {
+	path = filepath.Clean(path)
+	if f, ok := d.files[host][path]; ok {
+		return f
+	}
+	for i := len(d.fixtures.Files) - 1; i >= 0; i-- {
+		f := &d.fixtures.Files[i]
+		if (len(f.Hosts) == 0 || contains(f.Hosts, host)) && filepath.Clean(f.Path) == path {
+			return f
+		}
+	}
+	return nil
+}
+
+// dirExists returns whether the dir is on the host, a dir exists if a file is in it, it must be called with the
+// lock held.
+func (d *FakeDialer) dirExists(host, path string) bool {
+	path = filepath.Clean(path)
+	if f := d.file(host, path); f != nil {
+		return f.Dir
+	}
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	for p := range d.files[host] {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	for _, f := range d.fixtures.Files {
+		if (len(f.Hosts) == 0 || contains(f.Hosts, host)) && strings.HasPrefix(filepath.Clean(f.Path), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *FakeDialer) putFile(host string, f *FakeFile) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.files[host] == nil {
+		d.files[host] = make(map[string]*FakeFile)
+	}
+	f.Path = filepath.Clean(f.Path)
+	d.files[host][f.Path] = f
+}
+
+// replayConnection is a connection to a host of a FakeDialer, conn is the real connection of a recording dialer.
+type replayConnection struct {
+	dialer *FakeDialer
+	host   string
+	conn   Connection
+}
+
+func (c *replayConnection) Exec(cmd string, host Host) (string, int, error) {
+	c.dialer.record(FakeCall{Host: c.host, Operation: AuditExec, Command: cmd})
+	if c.conn != nil {
+		stdout, code, err := c.conn.Exec(cmd, host)
+		c.dialer.recordResponse(c.host, cmd, stdout, code)
+		return stdout, code, err
+	}
+	return c.dialer.respond(c.host, cmd)
+}
+
+func (c *replayConnection) PExec(cmd string, stdin io.Reader, stdout io.Writer, stderr io.Writer, host Host) (int, error) {
+	c.dialer.record(FakeCall{Host: c.host, Operation: AuditExec, Command: cmd})
+	if c.conn != nil {
+		var out strings.Builder
+		code, err := c.conn.PExec(cmd, stdin, io.MultiWriter(stdout, &out), stderr, host)
+		c.dialer.recordResponse(c.host, cmd, out.String(), code)
+		return code, err
+	}
+	out, code, err := c.dialer.respond(c.host, cmd)
+	if stdout != nil {
+		_, _ = io.WriteString(stdout, out)
+	}
+	return code, err
+}
+
+func (c *replayConnection) Fetch(local, remote string, host Host) error {
+	c.dialer.record(FakeCall{Host: c.host, Operation: AuditFetch, Local: local, Remote: remote})
+	if c.conn != nil {
+		if err := c.conn.Fetch(local, remote, host); err != nil {
+			return err
+		}
+		content, err := os.ReadFile(local)
+		if err != nil {
+			return err
+		}
+		c.dialer.mu.Lock()
+		c.dialer.fixtures.Files = append(c.dialer.fixtures.Files, FakeFile{Hosts: []string{c.host}, Path: remote, Content: string(content)})
+		c.dialer.mu.Unlock()
+		return nil
+	}
+
+	c.dialer.mu.Lock()
+	f := c.dialer.file(c.host, remote)
+	c.dialer.mu.Unlock()
+	if f == nil || f.Dir {
+		return errors.Errorf("fake: no file %s on %s", remote, c.host)
+	}
+	if err := os.MkdirAll(filepath.Dir(local), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(local, []byte(f.Content), 0644)
+}
+
+func (c *replayConnection) Scp(local, remote string, host Host) error {
+	c.dialer.record(FakeCall{Host: c.host, Operation: AuditPut, Local: local, Remote: remote})
+	if c.conn != nil {
+		return c.conn.Scp(local, remote, host)
+	}
+
+	info, err := os.Stat(local)
+	if err != nil {
+		return errors.Wrapf(err, "fake: failed to stat %s", local)
+	}
+	if !info.IsDir() {
+		content, err := os.ReadFile(local)
+		if err != nil {
+			return err
+		}
+		c.dialer.putFile(c.host, &FakeFile{Path: remote, Content: string(content)})
+		return nil
+	}
+	var paths []string
+	err = filepath.Walk(local, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		rel, _ := filepath.Rel(local, path)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		c.dialer.putFile(c.host, &FakeFile{Path: filepath.Join(remote, rel), Content: string(content)})
+	}
+	return nil
+}
+
+func (c *replayConnection) RemoteFileExist(remote string, host Host) bool {
+	if c.conn != nil {
+		return c.conn.RemoteFileExist(remote, host)
+	}
+	c.dialer.mu.Lock()
+	defer c.dialer.mu.Unlock()
+	f := c.dialer.file(c.host, remote)
+	return f != nil && !f.Dir
+}
+
+func (c *replayConnection) RemoteDirExist(remote string, host Host) (bool, error) {
+	if c.conn != nil {
+		return c.conn.RemoteDirExist(remote, host)
+	}
+	c.dialer.mu.Lock()
+	defer c.dialer.mu.Unlock()
+	return c.dialer.dirExists(c.host, remote), nil
+}
+
+func (c *replayConnection) Stat(remote string, host Host) (os.FileInfo, error) {
+	if c.conn != nil {
+		return statFile(c.conn, remote, host)
+	}
+	c.dialer.mu.Lock()
+	defer c.dialer.mu.Unlock()
+	if f := c.dialer.file(c.host, remote); f != nil && !f.Dir {
+		return &remoteFileInfo{name: filepath.Base(remote), size: int64(len(f.Content)), mode: 0644}, nil
+	}
+	if c.dialer.dirExists(c.host, remote) {
+		return &remoteFileInfo{name: filepath.Base(remote), mode: os.ModeDir | 0755}, nil
+	}
+	return nil, errors.Wrapf(os.ErrNotExist, "fake: stat %s on %s", remote, c.host)
+}
+
+func (c *replayConnection) Exists(remote string, host Host) (bool, error) {
+	if c.conn != nil {
+		return fileExists(c.conn, remote, host)
+	}
+	_, err := c.Stat(remote, host)
+	return exists(err)
+}
+
+func (c *replayConnection) Checksum(remote string, host Host) (string, error) {
+	if c.conn != nil {
+		return fileChecksum(c.conn, remote, host)
+	}
+	c.dialer.mu.Lock()
+	f := c.dialer.file(c.host, remote)
+	c.dialer.mu.Unlock()
+	if f == nil || f.Dir {
+		return "", errors.Wrapf(os.ErrNotExist, "fake: checksum %s on %s", remote, c.host)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(f.Content))), nil
+}
+
+func (c *replayConnection) MkDirAll(path string, mode string, host Host) error {
+	c.dialer.record(FakeCall{Host: c.host, Operation: AuditMkdir, Remote: path, Mode: mode})
+	if c.conn != nil {
+		return c.conn.MkDirAll(path, mode, host)
+	}
+	c.dialer.putFile(c.host, &FakeFile{Path: path, Dir: true})
+	return nil
+}
+
+func (c *replayConnection) Chmod(path string, mode os.FileMode) error {
+	c.dialer.record(FakeCall{Host: c.host, Operation: AuditChmod, Remote: path, Mode: fmt.Sprintf("%o", mode)})
+	if c.conn != nil {
+		return c.conn.Chmod(path, mode)
+	}
+	return nil
+}
+
+func (c *replayConnection) Close() {
+	if c.conn != nil {
+		c.conn.Close()
+	}
+}
@@ -0,0 +1,178 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+// failures records the failures of the assertions.
+type failures []string
+
+func (f *failures) Helper() {}
+
+func (f *failures) Errorf(format string, args ...interface{}) {
+	*f = append(*f, fmt.Sprintf(format, args...))
+}
+
+const testFakeFixtures = `
+commands:
+- match: uname -m
+  stdout: x86_64
+- hosts: [node2]
+  match: systemctl is-active kubelet
+  stdout: activating
+  exitCode: 3
+  times: 1
+- match: systemctl is-active kubelet
+  stdout: active
+files:
+- path: /etc/os-release
+  content: ID=ubuntu
+unreachable: [node3]
+`
+
+func TestFakeDialer(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fixtures.yaml")
+	if err := os.WriteFile(path, []byte(testFakeFixtures), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fixtures, err := LoadFakeFixtures(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dialer := NewFakeDialer(fixtures)
+
+	runner := func(name string) *Runner {
+		host := NewHost()
+		host.Name = name
+		conn, err := dialer.Connect(host)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &Runner{Conn: conn, Host: host, Ctx: context.Background()}
+	}
+	node1, node2 := runner("node1"), runner("node2")
+	if _, err := dialer.Connect(&BaseHost{Name: "node3"}); err == nil {
+		t.Error("Connect() to the unreachable host succeeded")
+	}
+
+	if out, err := node1.SudoCmd("uname -m", false); err != nil || out != "x86_64" {
+		t.Errorf("SudoCmd() = %q, %v, want x86_64", out, err)
+	}
+	if _, code, err := node2.Exec("systemctl is-active kubelet", false); err == nil || code != 3 {
+		t.Errorf("Exec() = %d, %v, want the exit code 3 of the first poll", code, err)
+	}
+	if out, err := node2.Cmd("systemctl is-active kubelet", false); err != nil || out != "active" {
+		t.Errorf("Cmd() = %q, %v, want active", out, err)
+	}
+
+	local := filepath.Join(dir, "kubeadm-config.yaml")
+	if err := os.WriteFile(local, []byte("kind: InitConfiguration"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := node1.Scp(local, "/etc/kubernetes/kubeadm-config.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if content, ok := dialer.RemoteFile("node1", "/etc/kubernetes/kubeadm-config.yaml"); !ok || content != "kind: InitConfiguration" {
+		t.Errorf("RemoteFile() = %q, %v, want the file put", content, ok)
+	}
+	if ok, _ := node1.DirExist("/etc/kubernetes"); !ok {
+		t.Error("DirExist() = false, want the dir of the file put")
+	}
+	if ok, _ := node2.FileExist("/etc/kubernetes/kubeadm-config.yaml"); ok {
+		t.Error("FileExist() = true on the host the file isn't put on")
+	}
+	if info, err := node1.Stat("/etc/kubernetes/kubeadm-config.yaml"); err != nil || info.Size() != 23 {
+		t.Errorf("Stat() = %v, %v, want the size of the file put", info, err)
+	}
+	if sum, err := node1.Checksum("/etc/kubernetes/kubeadm-config.yaml"); err != nil ||
+		sum != fmt.Sprintf("%x", sha256.Sum256([]byte("kind: InitConfiguration"))) {
+		t.Errorf("Checksum() = %q, %v, want the sha256 of the file put", sum, err)
+	}
+	if ok, err := node2.Exists("/etc/kubernetes/kubeadm-config.yaml"); ok || err != nil {
+		t.Errorf("Exists() = %v, %v on the host the file isn't put on", ok, err)
+	}
+	fetched := filepath.Join(dir, "os-release")
+	if err := node2.Fetch(fetched, "/etc/os-release"); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(fetched); string(content) != "ID=ubuntu" {
+		t.Errorf("Fetch() = %q, want the content of the fixture", content)
+	}
+
+	dialer.AssertCommands(t, "node1", "uname -m")
+	dialer.AssertNoCommand(t, "node1", "systemctl")
+	var f failures
+	dialer.AssertCommands(&f, "node2", "systemctl is-active kubelet", "uname -m")
+	dialer.AssertNoCommand(&f, "node2", "is-active")
+	if len(f) != 3 {
+		t.Errorf("the assertions failed %d times, want 3: %v", len(f), f)
+	}
+}
+
+func TestFakeDialer_Strict(t *testing.T) {
+	dialer := NewFakeDialer(&FakeFixtures{Strict: true, Commands: []FakeCommand{{Match: "hostname", Stdout: "node1"}}})
+	host := &BaseHost{Name: "node1"}
+	conn, _ := dialer.Connect(host)
+	if out, _, err := conn.Exec("hostname", host); err != nil || out != "node1" {
+		t.Errorf("Exec() = %q, %v, want node1", out, err)
+	}
+	if _, code, err := conn.Exec("reboot", host); err == nil || code != 1 {
+		t.Errorf("Exec() = %d, %v, want the command without a fixture failed", code, err)
+	}
+}
+
+func TestRecordingDialer(t *testing.T) {
+	remote := NewFakeDialer(&FakeFixtures{Commands: []FakeCommand{
+		{Match: "date", Stdout: "Mon", Times: 1},
+		{Match: "date", Stdout: "Tue"},
+	}})
+	recorder := NewRecordingDialer(remote)
+	host := &BaseHost{Name: "node1"}
+	conn, err := recorder.Connect(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, _ = conn.Exec("date", host)
+	_, _, _ = conn.Exec("date", host)
+
+	path := filepath.Join(t.TempDir(), "fixtures.yaml")
+	if err := recorder.Fixtures().Save(path); err != nil {
+		t.Fatal(err)
+	}
+	fixtures, err := LoadFakeFixtures(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := NewFakeDialer(fixtures)
+	conn, _ = replay.Connect(host)
+	for _, want := range []string{"Mon", "Tue"} {
+		if out, _, _ := conn.Exec("date", host); out != want {
+			t.Errorf("Exec() = %q, want the recorded %q", out, want)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// HistoryLimit is the number of the versions of each managed file kept in the history of a host.
+	HistoryLimit = 10
+
+	historyIndex   = "index.json"
+	historyObjects = "objects"
+	// digestPrefix is the length of the digests printed, like the short hashes of git.
+	digestPrefix = 12
+)
+
+// FileVersion is a change of a managed file on a host in a run. The contents before and after the change are
+// referred by their sha256 digests, the digest before is empty if the file didn't exist or couldn't be read.
+type FileVersion struct {
+	Path   string    `json:"path"`
+	Run    string    `json:"run"`
+	Time   time.Time `json:"time"`
+	Before string    `json:"before,omitempty"`
+	After  string    `json:"after"`
+}
+
+// History is the history of the files managed by KubeKey on the hosts of a cluster, which is stored in a dir on the
+// control machine. Each host has an index of the last versions of its files, and a content-addressed store of the
+// contents before and after each change.
+type History struct {
+	mu    sync.Mutex
+	dir   string
+	run   string
+	limit int
+}
+
+// NewHistory returns the history stored in the dir, the changes are recorded as the run.
+func NewHistory(dir, run string, limit int) *History {
+	return &History{dir: dir, run: run, limit: limit}
+}
+
+// NewRunID returns the ID of a run started at the time.
+func NewRunID(now time.Time) string {
+	return now.Format("20060102-150405")
+}
+
+// Run returns the ID of the run the changes are recorded as.
+func (h *History) Run() string {
+	if h == nil {
+		return ""
+	}
+	return h.run
+}
+
+// Record records the change of the file on the host from the content before, which is nil if it is unknown, to the
+// content after. The oldest versions of the file beyond the limit are dropped with the contents no longer referred.
+func (h *History) Record(host, path string, before *string, after string, now time.Time) error {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	index, err := h.loadIndex(host)
+	if err != nil {
+		return err
+	}
+	v := FileVersion{Path: path, Run: h.run, Time: now}
+	if before != nil {
+		if v.Before, err = h.store(host, *before); err != nil {
+			return err
+		}
+	}
+	if v.After, err = h.store(host, after); err != nil {
+		return err
+	}
+
+	versions := append(index[path], v)
+	if h.limit > 0 && len(versions) > h.limit {
+		versions = versions[len(versions)-h.limit:]
+	}
+	index[path] = versions
+	if err := h.saveIndex(host, index); err != nil {
+		return err
+	}
+	return h.prune(host, index)
+}
+
+// Versions returns the versions of the files on the host from the oldest, filtered by the path and the run if they
+// aren't empty.
+func (h *History) Versions(host, path, run string) ([]FileVersion, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	index, err := h.loadIndex(host)
+	if err != nil {
+		return nil, err
+	}
+	var res []FileVersion
+	for p, versions := range index {
+		if path != "" && p != path {
+			continue
+		}
+		for _, v := range versions {
+			if run == "" || v.Run == run {
+				res = append(res, v)
+			}
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if !res[i].Time.Equal(res[j].Time) {
+			return res[i].Time.Before(res[j].Time)
+		}
+		return res[i].Path < res[j].Path
+	})
+	return res, nil
+}
+
+// Content returns the content of the digest on the host, the digest can be abbreviated to a unique prefix.
+func (h *History) Content(host, digest string) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	dir := filepath.Join(h.dir, host, historyObjects)
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", errors.Wrapf(err, "failed to read the history of the host %s", host)
+	}
+	var matches []string
+	for _, e := range entries {
+		if digest != "" && strings.HasPrefix(e.Name(), digest) {
+			matches = append(matches, e.Name())
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", errors.Errorf("the content %s is not in the history of the host %s", digest, host)
+	case 1:
+	default:
+		return "", errors.Errorf("the content %s is ambiguous in the history of the host %s", digest, host)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, matches[0]))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read the content %s of the host %s", matches[0], host)
+	}
+	return string(data), nil
+}
+
+// PrintVersions writes the versions of the hosts in order as a table, with the digests abbreviated.
+func PrintVersions(w io.Writer, hosts []string, versions map[string][]FileVersion) error {
+	tw := tabwriter.NewWriter(w, 10, 4, 3, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "HOST\tRUN\tTIME\tPATH\tBEFORE\tAFTER")
+	for _, host := range hosts {
+		for _, v := range versions[host] {
+			before := shortDigest(v.Before)
+			if before == "" {
+				before = "-"
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", host, v.Run, v.Time.Format(time.RFC3339), v.Path, before, shortDigest(v.After))
+		}
+	}
+	return tw.Flush()
+}
+
+func shortDigest(digest string) string {
+	if len(digest) > digestPrefix {
+		return digest[:digestPrefix]
+	}
+	return digest
+}
+
+// store writes the content to the store of the host by its digest, which is returned.
+func (h *History) store(host, content string) (string, error) {
+	sum := sha256.Sum256([]byte(content))
+	digest := hex.EncodeToString(sum[:])
+	file := filepath.Join(h.dir, host, historyObjects, digest)
+	if _, err := os.Stat(file); err == nil {
+		return digest, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return "", errors.Wrapf(err, "failed to create the history of the host %s", host)
+	}
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		return "", errors.Wrapf(err, "failed to write the history of the host %s", host)
+	}
+	return digest, nil
+}
+
+// prune deletes the contents of the host which no version refers to.
+func (h *History) prune(host string, index map[string][]FileVersion) error {
+	referred := make(map[string]struct{})
+	for _, versions := range index {
+		for _, v := range versions {
+			referred[v.Before] = struct{}{}
+			referred[v.After] = struct{}{}
+		}
+	}
+	dir := filepath.Join(h.dir, host, historyObjects)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the history of the host %s", host)
+	}
+	for _, e := range entries {
+		if _, ok := referred[e.Name()]; ok {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+			return errors.Wrapf(err, "failed to prune the history of the host %s", host)
+		}
+	}
+	return nil
+}
+
+func (h *History) loadIndex(host string) (map[string][]FileVersion, error) {
+	index := make(map[string][]FileVersion)
+	file := filepath.Join(h.dir, host, historyIndex)
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the history %s", file)
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the history %s", file)
+	}
+	return index, nil
+}
+
+func (h *History) saveIndex(host string, index map[string][]FileVersion) error {
+	file := filepath.Join(h.dir, host, historyIndex)
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the history")
+	}
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return errors.Wrapf(err, "failed to create the history of the host %s", host)
+	}
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write the history %s", file)
+	}
+	return nil
+}
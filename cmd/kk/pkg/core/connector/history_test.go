@@ -0,0 +1,85 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistoryRecord(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Unix(1700000000, 0)
+	const path = "/etc/kubernetes/kubeadm-config.yaml"
+
+	// the file is created in the first run, and changed in each of the next runs
+	first := NewHistory(dir, "run0", 2)
+	if err := first.Record("node1", path, nil, "v0", now); err != nil {
+		t.Fatal(err)
+	}
+	contents := []string{"v0", "v1", "v2"}
+	for i := 1; i < len(contents); i++ {
+		h := NewHistory(dir, NewRunID(now.Add(time.Duration(i)*time.Hour)), 2)
+		if err := h.Record("node1", path, &contents[i-1], contents[i], now.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := NewHistory(dir, "", 2)
+	versions, err := h.Versions("node1", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("Versions() = %d versions, want the last 2", len(versions))
+	}
+	for i, v := range versions {
+		before, err := h.Content("node1", v.Before[:digestPrefix])
+		if err != nil {
+			t.Fatal(err)
+		}
+		after, err := h.Content("node1", v.After)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if before != contents[i] || after != contents[i+1] {
+			t.Errorf("version %d changed %q to %q, want %q to %q", i, before, after, contents[i], contents[i+1])
+		}
+	}
+
+	run := versions[1].Run
+	if got, _ := h.Versions("node1", path, run); len(got) != 1 || got[0].After != versions[1].After {
+		t.Errorf("Versions(%s) = %+v", run, got)
+	}
+	if got, _ := h.Versions("node2", "", ""); len(got) != 0 {
+		t.Errorf("Versions(node2) = %+v, want none", got)
+	}
+
+	// the contents of the dropped versions are pruned
+	objects, err := os.ReadDir(filepath.Join(dir, "node1", historyObjects))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 3 {
+		t.Errorf("%d contents are kept, want 3", len(objects))
+	}
+	if _, err := h.Content("node1", "unknown"); err == nil {
+		t.Error("Content() of an unknown digest should fail")
+	}
+}
@@ -32,6 +32,21 @@ type BaseHost struct {
 	PrivateKeyPath  string `yaml:"privateKeyPath,omitempty" json:"privateKeyPath,omitempty"`
 	Arch            string `yaml:"arch,omitempty" json:"arch,omitempty"`
 	Timeout         int64  `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	TaskTimeout     int64  `yaml:"taskTimeout,omitempty" json:"taskTimeout,omitempty"`
+	Bastion         string `yaml:"bastion,omitempty" json:"bastion,omitempty"`
+	BastionPort     int    `yaml:"bastionPort,omitempty" json:"bastionPort,omitempty"`
+	BastionUser     string `yaml:"bastionUser,omitempty" json:"bastionUser,omitempty"`
+	CredentialsFrom string `yaml:"credentialsFrom,omitempty" json:"credentialsFrom,omitempty"`
+	AgentSocket     string `yaml:"agentSocket,omitempty" json:"agentSocket,omitempty"`
+
+	TransferRateLimit   string            `yaml:"transferRateLimit,omitempty" json:"transferRateLimit,omitempty"`
+	TransferCompression string            `yaml:"transferCompression,omitempty" json:"transferCompression,omitempty"`
+	ConnectorType       string            `yaml:"connector,omitempty" json:"connector,omitempty"`
+	AgentPort           int               `yaml:"agentPort,omitempty" json:"agentPort,omitempty"`
+	AgentCertsDir       string            `yaml:"agentCertsDir,omitempty" json:"agentCertsDir,omitempty"`
+	Shell               string            `yaml:"shell,omitempty" json:"shell,omitempty"`
+	Become              string            `yaml:"become,omitempty" json:"become,omitempty"`
+	Env                 map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
 
 	Roles     []string        `json:"-"`
 	RoleTable map[string]bool `json:"-"`
@@ -139,6 +154,110 @@ func (b *BaseHost) SetTimeout(timeout int64) {
 	b.Timeout = timeout
 }
 
+func (b *BaseHost) GetTaskTimeout() int64 {
+	return b.TaskTimeout
+}
+
+func (b *BaseHost) SetTaskTimeout(timeout int64) {
+	b.TaskTimeout = timeout
+}
+
+func (b *BaseHost) GetBastion() string {
+	return b.Bastion
+}
+
+func (b *BaseHost) SetBastion(bastion string) {
+	b.Bastion = bastion
+}
+
+func (b *BaseHost) GetBastionPort() int {
+	return b.BastionPort
+}
+
+func (b *BaseHost) SetBastionPort(port int) {
+	b.BastionPort = port
+}
+
+func (b *BaseHost) GetBastionUser() string {
+	return b.BastionUser
+}
+
+func (b *BaseHost) SetBastionUser(u string) {
+	b.BastionUser = u
+}
+
+func (b *BaseHost) GetAgentSocket() string {
+	return b.AgentSocket
+}
+
+func (b *BaseHost) SetAgentSocket(socket string) {
+	b.AgentSocket = socket
+}
+
+func (b *BaseHost) GetTransferRateLimit() string {
+	return b.TransferRateLimit
+}
+
+func (b *BaseHost) SetTransferRateLimit(limit string) {
+	b.TransferRateLimit = limit
+}
+
+func (b *BaseHost) GetTransferCompression() string {
+	return b.TransferCompression
+}
+
+func (b *BaseHost) SetTransferCompression(compression string) {
+	b.TransferCompression = compression
+}
+
+func (b *BaseHost) GetConnectorType() string {
+	return b.ConnectorType
+}
+
+func (b *BaseHost) SetConnectorType(connector string) {
+	b.ConnectorType = connector
+}
+
+func (b *BaseHost) GetAgentPort() int {
+	return b.AgentPort
+}
+
+func (b *BaseHost) SetAgentPort(port int) {
+	b.AgentPort = port
+}
+
+func (b *BaseHost) GetAgentCertsDir() string {
+	return b.AgentCertsDir
+}
+
+func (b *BaseHost) SetAgentCertsDir(dir string) {
+	b.AgentCertsDir = dir
+}
+
+func (b *BaseHost) GetShell() string {
+	return b.Shell
+}
+
+func (b *BaseHost) SetShell(shell string) {
+	b.Shell = shell
+}
+
+func (b *BaseHost) GetBecome() string {
+	return b.Become
+}
+
+func (b *BaseHost) SetBecome(become string) {
+	b.Become = become
+}
+
+func (b *BaseHost) GetEnv() map[string]string {
+	return b.Env
+}
+
+func (b *BaseHost) SetEnv(env map[string]string) {
+	b.Env = env
+}
+
 func (b *BaseHost) GetRoles() []string {
 	return b.Roles
 }
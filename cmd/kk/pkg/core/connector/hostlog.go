@@ -0,0 +1,103 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// HostLogs tees the commands executed on each host, their output and exit codes into a log file of the host in a
+// dir, which is kept for the post-mortem of the run.
+type HostLogs struct {
+	mu    sync.Mutex
+	dir   string
+	files map[string]*os.File
+	now   func() time.Time
+}
+
+// NewHostLogs returns the logs of the hosts written into the dir, which is created if it doesn't exist.
+func NewHostLogs(dir string) (*HostLogs, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create the dir %s of the host logs", dir)
+	}
+	return &HostLogs{dir: dir, files: map[string]*os.File{}, now: time.Now}, nil
+}
+
+// Dir returns the dir of the logs.
+func (h *HostLogs) Dir() string {
+	return h.dir
+}
+
+func (h *HostLogs) Command(host, cmd string) {
+	h.write(host, "$ "+cmd)
+}
+
+func (h *HostLogs) Output(host, line string) {
+	h.write(host, line)
+}
+
+func (h *HostLogs) Result(host string, code int, err error) {
+	if err == nil {
+		h.write(host, fmt.Sprintf("exit code %d", code))
+		return
+	}
+	// the error of a command repeats the command and its output, only its first line is written
+	msg := strings.SplitN(redact(err.Error()), "\n", 2)[0]
+	h.write(host, fmt.Sprintf("exit code %d: %s", code, strings.TrimSpace(msg)))
+}
+
+// Close closes the log files of the hosts.
+func (h *HostLogs) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var errs []string
+	for host, f := range h.files {
+		if err := f.Close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+		delete(h.files, host)
+	}
+	if len(errs) > 0 {
+		return errors.Errorf("failed to close the host logs: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// write appends the line with a timestamp to the log file of the host, the failures are ignored like the ones of the
+// other logs.
+func (h *HostLogs) write(host, line string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	f, ok := h.files[host]
+	if !ok {
+		name := strings.ReplaceAll(host, string(os.PathSeparator), "_") + ".log"
+		var err error
+		f, err = os.OpenFile(filepath.Join(h.dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return
+		}
+		h.files[host] = f
+	}
+	_, _ = fmt.Fprintf(f, "%s %s\n", h.now().Format("15:04:05.000"), line)
+}
@@ -0,0 +1,62 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+func TestHostLogs(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	logger.Log.Redactor.AddSecrets("P@ssw0rd")
+	hostLogs, err := NewHostLogs(filepath.Join(t.TempDir(), "runs", "20230102-030405"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostLogs.now = func() time.Time { return time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC) }
+	SetOutputHandler(MultiOutputHandler(hostLogs))
+	defer SetOutputHandler(nil)
+
+	node1, node2 := NewHost(), NewHost()
+	node1.Name, node2.Name = "node1", "node2"
+	r1 := &Runner{Conn: &fakeConnection{}, Host: node1}
+	r2 := &Runner{Conn: &fakeConnection{}, Host: node2}
+	_, _ = r1.Cmd("echo 'P@ssw0rd' | sudo -S true", false)
+	_, _ = r2.Cmd("false", false)
+	if err := hostLogs.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"node1": "03:04:05.000 $ echo '******' | sudo -S true\n03:04:05.000 exit code 0\n",
+		"node2": "03:04:05.000 $ false\n03:04:05.000 exit code 1: exit status 1\n",
+	}
+	for host, want := range tests {
+		data, err := os.ReadFile(filepath.Join(hostLogs.Dir(), host+".log"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("the log of %s = %q, want %q", host, data, want)
+		}
+	}
+}
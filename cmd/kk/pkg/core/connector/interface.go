@@ -17,7 +17,9 @@
 package connector
 
 import (
+	"context"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/cache"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/event"
 	"io"
 	"os"
 )
@@ -34,6 +36,18 @@ type Connection interface {
 	Close()
 }
 
+// FileInspector is implemented by the connections inspecting the remote files natively, e.g. by sftp, and by the
+// layers wrapping the connections, which check the inspections as the other operations. The files on the other
+// connections are inspected by the commands run by Exec.
+type FileInspector interface {
+	// Stat returns the info of the remote file, following the symlinks. The error is os.ErrNotExist if it doesn't exist.
+	Stat(remote string, host Host) (os.FileInfo, error)
+	// Exists returns whether the remote file or dir exists.
+	Exists(remote string, host Host) (bool, error)
+	// Checksum returns the hex of the sha256 of the remote file. The error is os.ErrNotExist if it doesn't exist.
+	Checksum(remote string, host Host) (string, error)
+}
+
 type Connector interface {
 	Connect(host Host) (Connection, error)
 	Close(host Host)
@@ -45,7 +59,25 @@ type ModuleRuntime interface {
 	GenerateWorkDir() error
 	GetHostWorkDir() string
 	GetWorkDir() string
+	GetClusterWorkDir() string
+	GetReportFiles() (string, string)
+	GetStrategy() Strategy
+	GetTagFilter() TagFilter
+	GetDeadlines() *Deadlines
+	GetMaintenanceWindows() *MaintenanceWindows
+	GetClusterLock() ClusterLock
+	GetContext() context.Context
+	SetContext(ctx context.Context)
+	GetEvents() *event.Dispatcher
+	GetProgressRecorder() ProgressRecorder
+	GetResume() bool
+	GetQuarantine() *Quarantine
+	GetHistory() *History
+	GetCollectDiagnostics() bool
+	GetHostLogs() bool
+	GetTUI() bool
 	GetIgnoreErr() bool
+	GetStrictRender() bool
 	GetAllHosts() []Host
 	SetAllHosts([]Host)
 	GetHostsByRole(role string) []Host
@@ -87,6 +119,32 @@ type Host interface {
 	SetArch(arch string)
 	GetTimeout() int64
 	SetTimeout(timeout int64)
+	GetTaskTimeout() int64
+	SetTaskTimeout(timeout int64)
+	GetBastion() string
+	SetBastion(bastion string)
+	GetBastionPort() int
+	SetBastionPort(port int)
+	GetBastionUser() string
+	SetBastionUser(u string)
+	GetAgentSocket() string
+	SetAgentSocket(socket string)
+	GetTransferRateLimit() string
+	SetTransferRateLimit(limit string)
+	GetTransferCompression() string
+	SetTransferCompression(compression string)
+	GetConnectorType() string
+	SetConnectorType(connector string)
+	GetAgentPort() int
+	SetAgentPort(port int)
+	GetAgentCertsDir() string
+	SetAgentCertsDir(dir string)
+	GetShell() string
+	SetShell(shell string)
+	GetBecome() string
+	SetBecome(become string)
+	GetEnv() map[string]string
+	SetEnv(env map[string]string)
 	GetRoles() []string
 	SetRoles(roles []string)
 	IsRole(role string) bool
@@ -0,0 +1,197 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	windowPattern = regexp.MustCompile(`^(?:([A-Za-z]{3})(?:-([A-Za-z]{3}))?\s+)?(\d{2}):(\d{2})-(\d{2}):(\d{2})$`)
+	weekdays      = map[string]time.Weekday{
+		"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+		"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+	}
+)
+
+// Window is a weekly maintenance window, which starts on its days and may end on the next day.
+type Window struct {
+	spec string
+	// days are the days the window starts on.
+	days       [7]bool
+	start, end time.Duration
+}
+
+// ParseWindow parses a window of "[<day>[-<day>]] HH:MM-HH:MM", e.g. "Sat 01:00-05:00", "Mon-Fri 22:00-02:00" or
+// "01:00-05:00" for every day. A window whose end isn't after its start ends on the next day.
+func ParseWindow(spec string) (Window, error) {
+	m := windowPattern.FindStringSubmatch(strings.TrimSpace(spec))
+	if m == nil {
+		return Window{}, errors.Errorf("invalid maintenance window %q, it must be [<day>[-<day>]] HH:MM-HH:MM, e.g. Sat 01:00-05:00", spec)
+	}
+	w := Window{spec: strings.TrimSpace(spec)}
+	var err error
+	if w.start, err = clock(m[3], m[4]); err != nil {
+		return Window{}, errors.Wrapf(err, "invalid maintenance window %q", spec)
+	}
+	if w.end, err = clock(m[5], m[6]); err != nil {
+		return Window{}, errors.Wrapf(err, "invalid maintenance window %q", spec)
+	}
+	if m[1] == "" {
+		w.days = [7]bool{true, true, true, true, true, true, true}
+		return w, nil
+	}
+	first, ok := weekdays[strings.ToLower(m[1])]
+	if !ok {
+		return Window{}, errors.Errorf("invalid maintenance window %q, unknown day %s", spec, m[1])
+	}
+	last := first
+	if m[2] != "" {
+		if last, ok = weekdays[strings.ToLower(m[2])]; !ok {
+			return Window{}, errors.Errorf("invalid maintenance window %q, unknown day %s", spec, m[2])
+		}
+	}
+	for d := first; ; d = (d + 1) % 7 {
+		w.days[d] = true
+		if d == last {
+			break
+		}
+	}
+	return w, nil
+}
+
+func clock(hour, minute string) (time.Duration, error) {
+	var h, m int
+	fmt.Sscanf(hour+" "+minute, "%d %d", &h, &m)
+	if h > 24 || m > 59 || (h == 24 && m != 0) {
+		return 0, errors.Errorf("invalid time %s:%s", hour, minute)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+func (w Window) String() string {
+	return w.spec
+}
+
+// length is the length of the window, the windows ending on the next day are longer than the end.
+func (w Window) length() time.Duration {
+	if w.end > w.start {
+		return w.end - w.start
+	}
+	return w.end + 24*time.Hour - w.start
+}
+
+// Contains tells whether the time is within the window, in the location of the time.
+func (w Window) Contains(t time.Time) bool {
+	for _, back := range []int{0, 1} {
+		day := t.AddDate(0, 0, -back)
+		if !w.days[day.Weekday()] {
+			continue
+		}
+		start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, t.Location()).Add(w.start)
+		if !t.Before(start) && t.Before(start.Add(w.length())) {
+			return true
+		}
+	}
+	return false
+}
+
+// next returns the next start of the window after the time.
+func (w Window) next(t time.Time) time.Time {
+	for d := 0; d <= 7; d++ {
+		day := t.AddDate(0, 0, d)
+		if !w.days[day.Weekday()] {
+			continue
+		}
+		start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, t.Location()).Add(w.start)
+		if start.After(t) {
+			return start
+		}
+	}
+	return time.Time{}
+}
+
+// MaintenanceWindows refuse to start the disruptive phases of the run outside the windows. The phases are named by
+// the tags of the modules as the deadlines, a phase started within a window runs to its end.
+type MaintenanceWindows struct {
+	Windows  []Window
+	Location *time.Location
+	// Phases are the tags of the disruptive modules.
+	Phases []string
+	// Ignore only warns of the phases started outside the windows, e.g. for an emergency.
+	Ignore bool
+
+	started map[string]bool
+}
+
+// Check starts the disruptive phases of the module with the tags, it returns an error if any of them isn't started
+// and the time is outside the windows. The phases are started even so, the error only warns if the windows are ignored.
+func (m *MaintenanceWindows) Check(tags []string, now time.Time) error {
+	if m == nil || len(m.Windows) == 0 {
+		return nil
+	}
+	var phases []string
+	for _, phase := range m.Phases {
+		if matchTags([]string{phase}, tags) && !m.started[strings.ToLower(phase)] {
+			phases = append(phases, phase)
+		}
+	}
+	if len(phases) == 0 {
+		return nil
+	}
+	loc := m.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	now = now.In(loc)
+	within := false
+	var next time.Time
+	for _, w := range m.Windows {
+		if w.Contains(now) {
+			within = true
+			break
+		}
+		if n := w.next(now); !n.IsZero() && (next.IsZero() || n.Before(next)) {
+			next = n
+		}
+	}
+	var err error
+	if !within {
+		err = errors.Errorf("the disruptive phases %s are outside the maintenance windows %s (%s), the next window opens at %s",
+			strings.Join(phases, ", "), m.windows(), loc, next.Format("Mon 2006-01-02 15:04"))
+	}
+	if m.started == nil {
+		m.started = make(map[string]bool)
+	}
+	for _, phase := range phases {
+		m.started[strings.ToLower(phase)] = true
+	}
+	return err
+}
+
+func (m *MaintenanceWindows) windows() string {
+	specs := make([]string, 0, len(m.Windows))
+	for _, w := range m.Windows {
+		specs = append(specs, w.String())
+	}
+	return strings.Join(specs, ", ")
+}
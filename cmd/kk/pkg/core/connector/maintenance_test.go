@@ -0,0 +1,83 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindow_Contains(t *testing.T) {
+	// 2023-01-06 is a Friday.
+	at := func(day, hour, minute int) time.Time { return time.Date(2023, 1, day, hour, minute, 0, 0, time.UTC) }
+	tests := []struct {
+		spec string
+		now  time.Time
+		want bool
+	}{
+		{spec: "Sat 01:00-05:00", now: at(7, 1, 0), want: true},
+		{spec: "Sat 01:00-05:00", now: at(7, 5, 0), want: false},
+		{spec: "Sat 01:00-05:00", now: at(6, 2, 0), want: false},
+		{spec: "Mon-Fri 22:00-02:00", now: at(6, 23, 0), want: true},
+		{spec: "Mon-Fri 22:00-02:00", now: at(7, 1, 59), want: true},
+		{spec: "Mon-Fri 22:00-02:00", now: at(7, 22, 30), want: false},
+		{spec: "Fri-Mon 00:00-24:00", now: at(8, 12, 0), want: true},
+		{spec: "Fri-Mon 00:00-24:00", now: at(4, 12, 0), want: false},
+		{spec: "01:00-05:00", now: at(4, 3, 0), want: true},
+	}
+	for _, tt := range tests {
+		w, err := ParseWindow(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseWindow(%q) error = %v", tt.spec, err)
+		}
+		if got := w.Contains(tt.now); got != tt.want {
+			t.Errorf("%q Contains(%s) = %v, want %v", tt.spec, tt.now.Format(time.RFC1123), got, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"Sat", "Sat 1:00-5:00", "Caturday 01:00-05:00", "25:00-26:00"} {
+		if _, err := ParseWindow(spec); err == nil {
+			t.Errorf("ParseWindow(%q) error = nil", spec)
+		}
+	}
+}
+
+func TestMaintenanceWindows_Check(t *testing.T) {
+	w, _ := ParseWindow("Sat 01:00-05:00")
+	m := &MaintenanceWindows{Windows: []Window{w}, Location: time.UTC, Phases: []string{"etcd", "kubernetes"}}
+	inside := time.Date(2023, 1, 7, 4, 50, 0, 0, time.UTC)
+
+	if err := m.Check([]string{"os"}, inside.Add(-time.Hour*24)); err != nil {
+		t.Errorf("Check() of a non-disruptive module error = %v", err)
+	}
+	outside := &MaintenanceWindows{Windows: []Window{w}, Location: time.UTC, Phases: []string{"etcd"}}
+	if err := outside.Check([]string{"etcd"}, inside.Add(-time.Hour*24)); err == nil {
+		t.Error("Check() outside the windows error = nil")
+	}
+	if err := m.Check([]string{"etcd"}, inside); err != nil {
+		t.Errorf("Check() within the windows error = %v", err)
+	}
+	if err := m.Check([]string{"etcd"}, inside.Add(time.Hour)); err != nil {
+		t.Errorf("Check() of a phase started within the windows error = %v", err)
+	}
+	if err := m.Check([]string{"kubernetes"}, inside.Add(time.Hour)); err == nil {
+		t.Error("Check() of a phase starting after the windows error = nil")
+	}
+	if err := m.Check([]string{"kubernetes"}, inside.Add(time.Hour)); err != nil {
+		t.Errorf("Check() of a phase started outside the windows error = %v", err)
+	}
+}
@@ -0,0 +1,368 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/exporter"
+)
+
+const (
+	// SlowHostFactor is how many times slower than the median a host must be to be reported as an outlier.
+	SlowHostFactor = 3.0
+
+	// minOutlierHosts is the number of hosts below which the median says nothing about a host.
+	minOutlierHosts = 3
+	// minTransferBytes is the volume below which the throughput of a host is dominated by the latency.
+	minTransferBytes = 1 << 20
+	// minErrorRate is the error rate below which a host isn't reported, whatever the median.
+	minErrorRate = 0.05
+)
+
+// HostMetrics are the operations on a host measured by the connector. The commands include the existence checks
+// and mkdir, the transfers are the uploads and downloads of files, whose sizes are counted in Bytes. The errors are
+// the failed connections and operations, including the commands exiting with a non-zero code.
+type HostMetrics struct {
+	Commands        int     `json:"commands"`
+	CommandSeconds  float64 `json:"commandSeconds"`
+	Transfers       int     `json:"transfers"`
+	TransferSeconds float64 `json:"transferSeconds"`
+	Bytes           int64   `json:"bytes"`
+	Errors          int     `json:"errors"`
+	// Connections and FailedConnections count the attempts to connect to the host.
+	Connections       int `json:"connections"`
+	FailedConnections int `json:"failedConnections"`
+}
+
+// Unreachable returns whether all the attempts to connect to the host failed.
+func (m HostMetrics) Unreachable() bool {
+	return m.FailedConnections > 0 && m.Connections == 0
+}
+
+// Latency returns the average duration of the commands.
+func (m HostMetrics) Latency() time.Duration {
+	if m.Commands == 0 {
+		return 0
+	}
+	return time.Duration(m.CommandSeconds / float64(m.Commands) * float64(time.Second))
+}
+
+// Throughput returns the bytes transferred per second.
+func (m HostMetrics) Throughput() float64 {
+	if m.TransferSeconds == 0 {
+		return 0
+	}
+	return float64(m.Bytes) / m.TransferSeconds
+}
+
+// ErrorRate returns the share of the operations which failed.
+func (m HostMetrics) ErrorRate() float64 {
+	if ops := m.Commands + m.Transfers; ops > 0 {
+		return math.Min(float64(m.Errors)/float64(ops), 1)
+	}
+	return 0
+}
+
+// Metrics are the metrics of each host of a runtime.
+type Metrics struct {
+	mu    sync.Mutex
+	names []string
+	hosts map[string]*HostMetrics
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{hosts: make(map[string]*HostMetrics)}
+}
+
+func (m *Metrics) observe(host string, f func(h *HostMetrics)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.hosts[host]
+	if !ok {
+		h = &HostMetrics{}
+		m.hosts[host] = h
+		m.names = append(m.names, host)
+	}
+	f(h)
+}
+
+// Snapshot returns a copy of the metrics of each host.
+func (m *Metrics) Snapshot() map[string]HostMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := make(map[string]HostMetrics, len(m.hosts))
+	for name, h := range m.hosts {
+		snapshot[name] = *h
+	}
+	return snapshot
+}
+
+// String returns the metrics of each host in the order they are first seen, e.g.
+// node1 : commands=120  latency=35ms    errors=0    transferred=120.0MiB at 40.0MiB/s
+func (m *Metrics) String() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	width := 0
+	for _, name := range m.names {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	lines := make([]string, 0, len(m.names))
+	for _, name := range m.names {
+		h := m.hosts[name]
+		line := fmt.Sprintf("%-*s : commands=%-5d latency=%-8s errors=%-4d", width, name, h.Commands,
+			h.Latency().Round(time.Millisecond), h.Errors)
+		if h.Transfers > 0 {
+			line += fmt.Sprintf(" transferred=%s at %s/s", formatBytes(float64(h.Bytes)), formatBytes(h.Throughput()))
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Outliers describes the hosts which transfer files or run commands factor times slower than the median host, or
+// fail clearly more often, e.g. "node42 transfers 10.0x slower than the median (1.2MiB/s vs 12.0MiB/s)".
+func (m *Metrics) Outliers(factor float64) []string {
+	snapshot := m.Snapshot()
+	m.mu.Lock()
+	names := append([]string(nil), m.names...)
+	m.mu.Unlock()
+
+	var throughputs, latencies, errorRates []float64
+	for _, name := range names {
+		h := snapshot[name]
+		if h.Bytes >= minTransferBytes {
+			throughputs = append(throughputs, h.Throughput())
+		}
+		if h.Commands > 0 {
+			latencies = append(latencies, h.Latency().Seconds())
+		}
+		if h.Commands+h.Transfers > 0 {
+			errorRates = append(errorRates, h.ErrorRate())
+		}
+	}
+	medianThroughput, medianLatency, medianErrorRate := median(throughputs), median(latencies), median(errorRates)
+
+	var outliers []string
+	for _, name := range names {
+		h := snapshot[name]
+		if len(throughputs) >= minOutlierHosts && h.Bytes >= minTransferBytes && h.Throughput() > 0 &&
+			medianThroughput/h.Throughput() >= factor {
+			outliers = append(outliers, fmt.Sprintf("%s transfers %.1fx slower than the median (%s/s vs %s/s)",
+				name, medianThroughput/h.Throughput(), formatBytes(h.Throughput()), formatBytes(medianThroughput)))
+		}
+		if len(latencies) >= minOutlierHosts && h.Commands > 0 && medianLatency > 0 &&
+			h.Latency().Seconds()/medianLatency >= factor {
+			outliers = append(outliers, fmt.Sprintf("%s runs commands %.1fx slower than the median (%s vs %s)",
+				name, h.Latency().Seconds()/medianLatency, h.Latency().Round(time.Millisecond),
+				time.Duration(medianLatency*float64(time.Second)).Round(time.Millisecond)))
+		}
+		if len(errorRates) >= minOutlierHosts && h.ErrorRate() >= minErrorRate && h.ErrorRate() >= factor*medianErrorRate {
+			outliers = append(outliers, fmt.Sprintf("%s failed %d of %d operations (%.0f%%, the median is %.0f%%)",
+				name, h.Errors, h.Commands+h.Transfers, h.ErrorRate()*100, medianErrorRate*100))
+		}
+	}
+	return outliers
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	if n := len(sorted); n%2 == 0 {
+		return (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	return sorted[len(sorted)/2]
+}
+
+func formatBytes(b float64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%.0fB", b)
+	}
+	exp, div := 0, float64(unit)
+	for n := b / unit; n >= unit && exp < 4; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", b/div, "KMGTP"[exp])
+}
+
+// MetricsDialer wraps a Connector and measures the operations on each host.
+type MetricsDialer struct {
+	Connector
+	metrics *Metrics
+	now     func() time.Time
+}
+
+func NewMetricsDialer(connector Connector) *MetricsDialer {
+	return &MetricsDialer{Connector: connector, metrics: NewMetrics(), now: time.Now}
+}
+
+// Connect counts the failed connections as errors, and the attempts to connect to tell the unreachable hosts.
+func (d *MetricsDialer) Connect(host Host) (Connection, error) {
+	conn, err := d.Connector.Connect(host)
+	if err != nil {
+		d.metrics.observe(host.GetName(), func(h *HostMetrics) {
+			h.Errors++
+			h.FailedConnections++
+		})
+		return nil, err
+	}
+	d.metrics.observe(host.GetName(), func(h *HostMetrics) { h.Connections++ })
+	return &metricsConnection{Connection: conn, dialer: d}, nil
+}
+
+// Unwrap returns the wrapped connector.
+func (d *MetricsDialer) Unwrap() Connector {
+	return d.Connector
+}
+
+// MetricsOf returns the metrics of the connector, or nil if it doesn't measure the operations.
+func MetricsOf(connector Connector) *Metrics {
+	for connector != nil {
+		if d, ok := connector.(*MetricsDialer); ok {
+			return d.metrics
+		}
+		u, ok := connector.(interface{ Unwrap() Connector })
+		if !ok {
+			return nil
+		}
+		connector = u.Unwrap()
+	}
+	return nil
+}
+
+type metricsConnection struct {
+	Connection
+	dialer *MetricsDialer
+}
+
+func (c *metricsConnection) command(host Host, start time.Time, failed bool) {
+	elapsed := c.dialer.now().Sub(start).Seconds()
+	exporter.CommandDuration.WithLabelValues(host.GetName()).Observe(elapsed)
+	c.dialer.metrics.observe(host.GetName(), func(h *HostMetrics) {
+		h.Commands++
+		h.CommandSeconds += elapsed
+		if failed {
+			h.Errors++
+		}
+	})
+}
+
+func (c *metricsConnection) transfer(host Host, start time.Time, local, direction string, err error) {
+	elapsed := c.dialer.now().Sub(start).Seconds()
+	var size int64
+	if err == nil {
+		if info, statErr := os.Stat(local); statErr == nil && !info.IsDir() {
+			size = info.Size()
+		}
+	}
+	exporter.TransferredBytes.WithLabelValues(host.GetName(), direction).Add(float64(size))
+	c.dialer.metrics.observe(host.GetName(), func(h *HostMetrics) {
+		h.Transfers++
+		h.TransferSeconds += elapsed
+		h.Bytes += size
+		if err != nil {
+			h.Errors++
+		}
+	})
+}
+
+func (c *metricsConnection) Exec(cmd string, host Host) (string, int, error) {
+	start := c.dialer.now()
+	stdout, code, err := c.Connection.Exec(cmd, host)
+	c.command(host, start, err != nil)
+	return stdout, code, err
+}
+
+func (c *metricsConnection) PExec(cmd string, stdin io.Reader, stdout io.Writer, stderr io.Writer, host Host) (int, error) {
+	start := c.dialer.now()
+	code, err := c.Connection.PExec(cmd, stdin, stdout, stderr, host)
+	c.command(host, start, err != nil)
+	return code, err
+}
+
+func (c *metricsConnection) Fetch(local, remote string, host Host) error {
+	start := c.dialer.now()
+	err := c.Connection.Fetch(local, remote, host)
+	c.transfer(host, start, local, exporter.Download, err)
+	return err
+}
+
+func (c *metricsConnection) Scp(local, remote string, host Host) error {
+	start := c.dialer.now()
+	err := c.Connection.Scp(local, remote, host)
+	c.transfer(host, start, local, exporter.Upload, err)
+	return err
+}
+
+func (c *metricsConnection) RemoteFileExist(remote string, host Host) bool {
+	start := c.dialer.now()
+	exist := c.Connection.RemoteFileExist(remote, host)
+	c.command(host, start, false)
+	return exist
+}
+
+func (c *metricsConnection) RemoteDirExist(remote string, host Host) (bool, error) {
+	start := c.dialer.now()
+	exist, err := c.Connection.RemoteDirExist(remote, host)
+	c.command(host, start, err != nil)
+	return exist, err
+}
+
+func (c *metricsConnection) Stat(remote string, host Host) (os.FileInfo, error) {
+	start := c.dialer.now()
+	info, err := statFile(c.Connection, remote, host)
+	c.command(host, start, err != nil && !errors.Is(err, os.ErrNotExist))
+	return info, err
+}
+
+func (c *metricsConnection) Exists(remote string, host Host) (bool, error) {
+	start := c.dialer.now()
+	exist, err := fileExists(c.Connection, remote, host)
+	c.command(host, start, err != nil)
+	return exist, err
+}
+
+func (c *metricsConnection) Checksum(remote string, host Host) (string, error) {
+	start := c.dialer.now()
+	sum, err := fileChecksum(c.Connection, remote, host)
+	c.command(host, start, err != nil && !errors.Is(err, os.ErrNotExist))
+	return sum, err
+}
+
+func (c *metricsConnection) MkDirAll(path string, mode string, host Host) error {
+	start := c.dialer.now()
+	err := c.Connection.MkDirAll(path, mode, host)
+	c.command(host, start, err != nil)
+	return err
+}
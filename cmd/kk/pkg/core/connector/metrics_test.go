@@ -0,0 +1,128 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/exporter"
+)
+
+func TestMetricsDialer(t *testing.T) {
+	local := filepath.Join(t.TempDir(), "kubeadm")
+	if err := os.WriteFile(local, make([]byte, 4096), 0644); err != nil {
+		t.Fatal(err)
+	}
+	host := NewHost()
+	host.Name = "node1"
+
+	dialer := NewMetricsDialer(&fakeConnector{conn: &fakeConnection{}})
+	clock := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	dialer.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	uploaded := testutil.ToFloat64(exporter.TransferredBytes.WithLabelValues("node1", exporter.Upload))
+	conn, err := dialer.Connect(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, _ = conn.Exec("true", host)
+	_, _, _ = conn.Exec("false", host)
+	_ = conn.Scp(local, "/usr/local/bin/kubeadm", host)
+
+	want := HostMetrics{Commands: 2, CommandSeconds: 2, Transfers: 1, TransferSeconds: 1, Bytes: 4096, Errors: 1, Connections: 1}
+	if got := dialer.metrics.Snapshot()["node1"]; got != want {
+		t.Errorf("metrics = %+v, want %+v", got, want)
+	}
+	if got := testutil.ToFloat64(exporter.TransferredBytes.WithLabelValues("node1", exporter.Upload)) - uploaded; got != 4096 {
+		t.Errorf("exported the upload of %v bytes, want 4096", got)
+	}
+
+	wrapped := NewDryRunDialer(NewAuditDialer(dialer, nil))
+	if MetricsOf(wrapped) != dialer.metrics {
+		t.Errorf("MetricsOf() didn't find the metrics through the wrappers")
+	}
+	if MetricsOf(&fakeConnector{}) != nil {
+		t.Errorf("MetricsOf() found metrics in a connector without them")
+	}
+}
+
+func TestMetricsOutliers(t *testing.T) {
+	const mib = 1 << 20
+	tests := []struct {
+		name  string
+		hosts map[string]HostMetrics
+		want  []string
+	}{
+		{
+			name: "slow transfers",
+			hosts: map[string]HostMetrics{
+				"node1":  {Commands: 10, CommandSeconds: 1, Transfers: 1, TransferSeconds: 1, Bytes: 100 * mib},
+				"node2":  {Commands: 10, CommandSeconds: 1, Transfers: 1, TransferSeconds: 1, Bytes: 100 * mib},
+				"node42": {Commands: 10, CommandSeconds: 1, Transfers: 1, TransferSeconds: 10, Bytes: 100 * mib},
+			},
+			want: []string{"node42 transfers 10.0x slower than the median (10.0MiB/s vs 100.0MiB/s)"},
+		},
+		{
+			name: "slow commands and errors",
+			hosts: map[string]HostMetrics{
+				"node1":  {Commands: 10, CommandSeconds: 1},
+				"node2":  {Commands: 10, CommandSeconds: 1},
+				"node42": {Commands: 10, CommandSeconds: 5, Errors: 2},
+			},
+			want: []string{
+				"node42 runs commands 5.0x slower than the median (500ms vs 100ms)",
+				"node42 failed 2 of 10 operations (20%, the median is 0%)",
+			},
+		},
+		{
+			name: "too few hosts",
+			hosts: map[string]HostMetrics{
+				"node1":  {Commands: 10, CommandSeconds: 1},
+				"node42": {Commands: 10, CommandSeconds: 50},
+			},
+		},
+		{
+			name: "small transfers",
+			hosts: map[string]HostMetrics{
+				"node1":  {Transfers: 1, TransferSeconds: 1, Bytes: 1024},
+				"node2":  {Transfers: 1, TransferSeconds: 1, Bytes: 1024},
+				"node42": {Transfers: 1, TransferSeconds: 10, Bytes: 1024},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMetrics()
+			for _, name := range []string{"node1", "node2", "node42"} {
+				if h, ok := tt.hosts[name]; ok {
+					m.observe(name, func(metrics *HostMetrics) { *metrics = h })
+				}
+			}
+			if got := m.Outliers(SlowHostFactor); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Outliers() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPort is the SSH port of the hosts which don't set their port.
+	DefaultPort = 22
+	// DefaultUser is the SSH user of the hosts which don't set their user.
+	DefaultUser = "root"
+	// DefaultTimeout is the timeout of the SSH connections of the hosts which don't set their timeout.
+	DefaultTimeout = 30 * time.Second
+)
+
+// HostOptions are the options of a host built by NewHostWithOptions, the zero values are defaulted.
+type HostOptions struct {
+	// Name identifies the host in the connector, the logs and the errors. [Default: Address]
+	Name            string
+	Address         string
+	InternalAddress string
+	// Port of SSH. [Default: 22]
+	Port int
+	// User of SSH. [Default: root]
+	User     string
+	Password string
+	// PrivateKey is the content of the private key of SSH, and KeyFile is its path.
+	PrivateKey string
+	KeyFile    string
+	// AgentSocket is the socket of the SSH agent holding the keys.
+	AgentSocket string
+	// Timeout of the SSH connection. [Default: 30s]
+	Timeout     time.Duration
+	Bastion     string
+	BastionPort int
+	BastionUser string
+	Arch        string
+	Roles       []string
+	// Connector is ssh, or docker or podman to run in the container named by Name, or agent to connect to the kk agent
+	// on the host. [Default: ssh]
+	Connector string
+	// AgentPort is the port of the kk agent, and AgentCertsDir is the dir of the certs of kk, see agent.WriteCerts.
+	// [Default: 9443]
+	AgentPort     int
+	AgentCertsDir string
+	// Shell of the host: sh, ash or powershell, and Become is sudo or doas. [Default: bash and sudo]
+	Shell  string
+	Become string
+	// Env are the environment variables of the commands on the host, see CommandOptions.
+	Env map[string]string
+	// TransferRateLimit and TransferCompression set the transfers to the host, see ParseTransferRateLimit.
+	TransferRateLimit   string
+	TransferCompression string
+}
+
+// NewHostWithOptions returns the host of the options.
+func NewHostWithOptions(opts HostOptions) *BaseHost {
+	host := NewHost()
+	host.Name = opts.Name
+	if host.Name == "" {
+		host.Name = opts.Address
+	}
+	host.Address = opts.Address
+	host.InternalAddress = opts.InternalAddress
+	if host.InternalAddress == "" {
+		host.InternalAddress = opts.Address
+	}
+	host.Port = opts.Port
+	if host.Port == 0 {
+		host.Port = DefaultPort
+	}
+	host.User = opts.User
+	if host.User == "" {
+		host.User = DefaultUser
+	}
+	host.Password = opts.Password
+	host.PrivateKey = opts.PrivateKey
+	host.PrivateKeyPath = opts.KeyFile
+	host.AgentSocket = opts.AgentSocket
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	host.Timeout = int64(timeout / time.Second)
+	host.Bastion = opts.Bastion
+	host.BastionPort = opts.BastionPort
+	host.BastionUser = opts.BastionUser
+	host.Arch = opts.Arch
+	for _, role := range opts.Roles {
+		host.SetRole(role)
+	}
+	host.ConnectorType = opts.Connector
+	host.AgentPort = opts.AgentPort
+	host.AgentCertsDir = opts.AgentCertsDir
+	host.Shell = opts.Shell
+	host.Become = opts.Become
+	host.Env = opts.Env
+	host.TransferRateLimit = opts.TransferRateLimit
+	host.TransferCompression = opts.TransferCompression
+	return host
+}
+
+// ConnectorOptions are the layers of the connector built by NewConnector, which wrap the dialer of the hosts in the
+// order of the fields. The layers are skipped if they aren't set.
+type ConnectorOptions struct {
+	// Chaos injects the failures into the connections, see LoadChaosConfig.
+	Chaos *ChaosConfig
+	// Metrics measures the operations on the hosts, see HostMetrics.
+	Metrics bool
+	// Policy denies the commands and the paths, see LoadPolicyConfig.
+	Policy *PolicyConfig
+	// Audit records the operations on the hosts, see OpenAuditSink.
+	Audit AuditSink
+	// DryRun only reports the changes on the hosts, and RenderDir only writes the files rendered for them into the
+	// dir without connecting to the hosts.
+	DryRun    bool
+	RenderDir string
+}
+
+// NewConnector returns the dialer of the hosts wrapped by the layers of the options.
+func NewConnector(opts ConnectorOptions) Connector {
+	var c Connector = NewDialer()
+	if opts.Chaos != nil {
+		c = NewChaosDialer(c, opts.Chaos)
+	}
+	// measured above the chaos, so the injected failures show in the recap as flaky hosts would
+	if opts.Metrics {
+		c = NewMetricsDialer(c)
+	}
+	// enforced below the audit, so the denied calls are audited too
+	if opts.Policy != nil {
+		c = NewPolicyDialer(c, opts.Policy)
+	}
+	if opts.Audit != nil {
+		c = NewAuditDialer(c, opts.Audit)
+	}
+	if opts.RenderDir != "" {
+		return NewRenderDialer(opts.RenderDir)
+	}
+	if opts.DryRun {
+		return NewDryRunDialer(c)
+	}
+	return c
+}
+
+// NewRunner connects to the host and returns the runner of the operations on it, which are bound by the ctx. The
+// connection is kept by the connector until it's closed by Close of the connector.
+func NewRunner(ctx context.Context, c Connector, host Host) (*Runner, error) {
+	type result struct {
+		conn Connection
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := c.Connect(host)
+		done <- result{conn, err}
+	}()
+	select {
+	case <-ctx.Done():
+		// the connection made after the ctx is done isn't used by anyone, close it instead of leaking it
+		go func() {
+			if r := <-done; r.err == nil {
+				c.Close(host)
+			}
+		}()
+		return nil, errors.Wrapf(ctx.Err(), "connect to %s", host.GetName())
+	case r := <-done:
+		if r.err != nil {
+			return nil, errors.Wrapf(r.err, "failed to connect to %s", host.GetAddress())
+		}
+		return &Runner{Conn: r.conn, Host: host, Ctx: ctx}, nil
+	}
+}
@@ -0,0 +1,120 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+func TestNewHostWithOptions(t *testing.T) {
+	host := NewHostWithOptions(HostOptions{Address: "192.168.0.2", Roles: []string{"master"}})
+	if host.GetName() != "192.168.0.2" || host.GetInternalAddress() != "192.168.0.2" || host.GetPort() != DefaultPort ||
+		host.GetUser() != DefaultUser || host.GetTimeout() != 30 || !host.IsRole("master") || host.GetCache() == nil {
+		t.Errorf("NewHostWithOptions() = %+v", host)
+	}
+
+	host = NewHostWithOptions(HostOptions{Name: "node1", Address: "192.168.0.2", Port: 2222, User: "ops", Timeout: time.Minute})
+	if host.GetName() != "node1" || host.GetPort() != 2222 || host.GetUser() != "ops" || host.GetTimeout() != 60 {
+		t.Errorf("NewHostWithOptions() = %+v", host)
+	}
+}
+
+// blockingConnector connects only once released.
+type blockingConnector struct {
+	release chan struct{}
+	closed  chan string
+}
+
+func (c *blockingConnector) Connect(Host) (Connection, error) {
+	<-c.release
+	return &dryRunConnection{}, nil
+}
+
+func (c *blockingConnector) Close(host Host) {
+	c.closed <- host.GetName()
+}
+
+func TestNewRunner(t *testing.T) {
+	c := &blockingConnector{release: make(chan struct{}), closed: make(chan string, 1)}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := NewRunner(ctx, c, NewHostWithOptions(HostOptions{Address: "192.168.0.2"})); err == nil {
+		t.Error("NewRunner() error = nil, want the error of the ctx")
+	}
+	// the connection made after the ctx is done is closed
+	close(c.release)
+	select {
+	case name := <-c.closed:
+		if name != "192.168.0.2" {
+			t.Errorf("Close() of %s, want 192.168.0.2", name)
+		}
+	case <-time.After(time.Second):
+		t.Error("the connection made after the ctx is done isn't closed")
+	}
+
+	dialer := NewConnector(ConnectorOptions{DryRun: true})
+	if !IsDryRun(dialer) {
+		t.Error("NewConnector() isn't in the dry run")
+	}
+}
+
+func TestConnectorLayersInspectFiles(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	sink, err := NewFileAuditSink(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	policy := &PolicyConfig{Rules: []PolicyRule{{Action: PolicyDeny, Path: "/etc/shadow"}}}
+	if err := policy.compile(); err != nil {
+		t.Fatal(err)
+	}
+	host := NewHostWithOptions(HostOptions{Name: "node1", Address: "192.168.0.2"})
+
+	// the layers inspect the files through their own checks, even if the connection they wrap doesn't
+	inner := &fakeConnector{conn: &fakeConnection{}}
+	for name, c := range map[string]Connector{
+		"chaos":   NewChaosDialer(inner, &ChaosConfig{}),
+		"metrics": NewMetricsDialer(inner),
+		"policy":  NewPolicyDialer(inner, policy),
+		"audit":   NewAuditDialer(inner, sink),
+		"dry-run": NewDryRunDialer(inner),
+	} {
+		conn, err := c.Connect(host)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := conn.(FileInspector); !ok {
+			t.Errorf("the connection of the %s layer isn't a FileInspector", name)
+		}
+	}
+
+	// the policy is enforced below the audit, as by NewConnector
+	conn, err := NewAuditDialer(NewPolicyDialer(inner, policy), sink).Connect(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Runner{Conn: conn, Host: host}
+	if _, err := r.Checksum("/etc/shadow"); !isDenied(err, AuditChecksum, 1) {
+		t.Errorf("Checksum() error = %v, want the path denied by the policy", err)
+	}
+}
@@ -0,0 +1,104 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"sync"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+// OutputHandler receives the commands executed on the hosts, and their output line by line while they are running.
+type OutputHandler interface {
+	Command(host, cmd string)
+	Output(host, line string)
+}
+
+// ResultHandler is an OutputHandler which receives the exit codes of the commands too.
+type ResultHandler interface {
+	Result(host string, code int, err error)
+}
+
+type multiOutputHandler []OutputHandler
+
+// MultiOutputHandler returns the handler passing the commands and their output to all the handlers.
+func MultiOutputHandler(handlers ...OutputHandler) OutputHandler {
+	return multiOutputHandler(handlers)
+}
+
+func (m multiOutputHandler) Command(host, cmd string) {
+	for _, h := range m {
+		h.Command(host, cmd)
+	}
+}
+
+func (m multiOutputHandler) Output(host, line string) {
+	for _, h := range m {
+		h.Output(host, line)
+	}
+}
+
+func (m multiOutputHandler) Result(host string, code int, err error) {
+	for _, h := range m {
+		if r, ok := h.(ResultHandler); ok {
+			r.Result(host, code, err)
+		}
+	}
+}
+
+var outputHandler struct {
+	sync.RWMutex
+	handler OutputHandler
+}
+
+// SetOutputHandler sets the handler of the commands of all the hosts, nil removes it.
+func SetOutputHandler(h OutputHandler) {
+	outputHandler.Lock()
+	defer outputHandler.Unlock()
+	outputHandler.handler = h
+}
+
+func streamCommand(host Host, cmd string) {
+	outputHandler.RLock()
+	defer outputHandler.RUnlock()
+	if outputHandler.handler != nil {
+		outputHandler.handler.Command(host.GetName(), redact(cmd))
+	}
+}
+
+func streamOutput(host Host, line string) {
+	outputHandler.RLock()
+	defer outputHandler.RUnlock()
+	if outputHandler.handler != nil {
+		outputHandler.handler.Output(host.GetName(), redact(line))
+	}
+}
+
+func streamResult(host Host, code int, err error) {
+	outputHandler.RLock()
+	defer outputHandler.RUnlock()
+	if r, ok := outputHandler.handler.(ResultHandler); ok {
+		r.Result(host.GetName(), code, err)
+	}
+}
+
+func redact(s string) string {
+	if logger.Log == nil {
+		return s
+	}
+	return logger.Log.Redactor.Redact(s)
+}
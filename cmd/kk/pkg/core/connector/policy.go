@@ -0,0 +1,310 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	PolicyAllow = "allow"
+	PolicyDeny  = "deny"
+)
+
+var (
+	// sudoWrapped matches the commands wrapped by SudoCommand, the policy matches the wrapped command.
+	sudoWrapped = regexp.MustCompile(`(?s)^(?:sudo -E|doas) \S+ -c "(.*)"$`)
+	// commandPath matches the absolute paths in the commands, the paths with globs and variables are skipped.
+	commandPath = regexp.MustCompile(`(?:^|[\s'"=<>|;&(])(/[^\s'"<>|;&()*?$\x60]*)`)
+)
+
+// PolicyConfig restricts the commands and the remote paths the connectors touch on the hosts, for the organizations
+// which must constrain their automation. The rules are matched in order and the first matched one decides, the calls
+// no rule matches are decided by the default action.
+type PolicyConfig struct {
+	// Default is the action of the calls no rule matches: allow or deny. [Default: allow]
+	Default string       `yaml:"default"`
+	Rules   []PolicyRule `yaml:"rules"`
+}
+
+// PolicyRule allows or denies the commands or the paths it matches.
+type PolicyRule struct {
+	// Action of the matched calls: allow or deny.
+	Action string `yaml:"action"`
+	// Hosts the rule applies to, all the hosts if it is empty.
+	Hosts []string `yaml:"hosts"`
+	// Command is the regexp of the commands, matched against the command run by sudo.
+	Command string `yaml:"command"`
+	// Path is the glob of the remote paths, * matches within a dir and ** matches any dirs. It's matched against the
+	// paths of the transfers and the absolute paths in the commands.
+	Path string `yaml:"path"`
+	// Reason is shown in the policy errors of the denied calls.
+	Reason string `yaml:"reason"`
+
+	command *regexp.Regexp
+	path    *regexp.Regexp
+}
+
+func LoadPolicyConfig(file string) (*PolicyConfig, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read policy config %s", file)
+	}
+	cfg := &PolicyConfig{}
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal policy config %s", file)
+	}
+	if err := cfg.compile(); err != nil {
+		return nil, errors.Wrapf(err, "invalid policy config %s", file)
+	}
+	return cfg, nil
+}
+
+func (c *PolicyConfig) compile() error {
+	switch c.Default {
+	case "":
+		c.Default = PolicyAllow
+	case PolicyAllow, PolicyDeny:
+	default:
+		return errors.Errorf("unsupported default action %s, it must be allow or deny", c.Default)
+	}
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if rule.Action != PolicyAllow && rule.Action != PolicyDeny {
+			return errors.Errorf("unsupported action %q of the rule %d, it must be allow or deny", rule.Action, i+1)
+		}
+		if (rule.Command == "") == (rule.Path == "") {
+			return errors.Errorf("the rule %d must match either a command or a path", i+1)
+		}
+		var err error
+		if rule.Command != "" {
+			if rule.command, err = regexp.Compile(rule.Command); err != nil {
+				return errors.Wrapf(err, "invalid command of the rule %d", i+1)
+			}
+			continue
+		}
+		if !path.IsAbs(rule.Path) {
+			return errors.Errorf("the path %s of the rule %d must be absolute", rule.Path, i+1)
+		}
+		rule.path = globRegexp(rule.Path)
+	}
+	return nil
+}
+
+// globRegexp converts the glob of the paths to a regexp, a glob ending with /** matches the dir itself too.
+func globRegexp(glob string) *regexp.Regexp {
+	glob = path.Clean(glob)
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("(/.*)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			b.WriteString("[^/]*")
+		case glob[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// PolicyError is the error of a call denied by the policy.
+type PolicyError struct {
+	Host      string
+	Operation string
+	Target    string
+	// Rule is the 1-based index of the denying rule, it is zero if the call is denied by the default action.
+	Rule   int
+	Reason string
+}
+
+func (e *PolicyError) Error() string {
+	by := "the default action"
+	if e.Rule > 0 {
+		by = fmt.Sprintf("the rule %d", e.Rule)
+	}
+	msg := fmt.Sprintf("policy: %s %q on %s is denied by %s of the policy", e.Operation, e.Target, e.Host, by)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// CheckCommand returns a PolicyError if the command, or an absolute path in it, is denied on the host. The paths in
+// the commands are only denied by the path rules, since the commands touch many paths not worth listing, e.g. the
+// binaries and the temporary files.
+func (c *PolicyConfig) CheckCommand(host Host, cmd string) error {
+	if m := sudoWrapped.FindStringSubmatch(cmd); m != nil {
+		cmd = m[1]
+	}
+	if rule, ok := c.decide(host, func(r *PolicyRule) bool { return r.command != nil && r.command.MatchString(cmd) }); !ok {
+		return c.denied(host, AuditExec, cmd, rule, "")
+	}
+	for _, m := range commandPath.FindAllStringSubmatch(cmd, -1) {
+		p := path.Clean(m[1])
+		rule, ok := c.decide(host, func(r *PolicyRule) bool { return r.path != nil && r.path.MatchString(p) })
+		if !ok && rule > 0 {
+			return c.denied(host, AuditExec, cmd, rule, p)
+		}
+	}
+	return nil
+}
+
+// CheckPath returns a PolicyError if the operation on the remote path is denied on the host.
+func (c *PolicyConfig) CheckPath(host Host, operation, remote string) error {
+	p := path.Clean(remote)
+	if rule, ok := c.decide(host, func(r *PolicyRule) bool { return r.path != nil && r.path.MatchString(p) }); !ok {
+		return c.denied(host, operation, remote, rule, "")
+	}
+	return nil
+}
+
+// decide returns whether the call is allowed, by the 1-based index of the first matched rule or by the default
+// action if the index is zero.
+func (c *PolicyConfig) decide(host Host, match func(r *PolicyRule) bool) (int, bool) {
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if len(rule.Hosts) > 0 && !contains(rule.Hosts, host.GetName()) {
+			continue
+		}
+		if match(rule) {
+			return i + 1, rule.Action == PolicyAllow
+		}
+	}
+	return 0, c.Default == PolicyAllow
+}
+
+func (c *PolicyConfig) denied(host Host, operation, target string, rule int, deniedPath string) error {
+	err := &PolicyError{Host: host.GetName(), Operation: operation, Target: target, Rule: rule}
+	if rule > 0 {
+		err.Reason = c.Rules[rule-1].Reason
+	}
+	if deniedPath != "" {
+		err.Reason = strings.TrimSuffix(fmt.Sprintf("the path %s is denied, %s", deniedPath, err.Reason), ", ")
+	}
+	return err
+}
+
+// PolicyDialer wraps a Connector and fails the calls denied by the policy before they reach the host.
+type PolicyDialer struct {
+	Connector
+	cfg *PolicyConfig
+}
+
+func NewPolicyDialer(connector Connector, cfg *PolicyConfig) *PolicyDialer {
+	return &PolicyDialer{Connector: connector, cfg: cfg}
+}
+
+func (p *PolicyDialer) Connect(host Host) (Connection, error) {
+	conn, err := p.Connector.Connect(host)
+	if err != nil {
+		return nil, err
+	}
+	return &policyConnection{Connection: conn, cfg: p.cfg, host: host}, nil
+}
+
+// Unwrap returns the wrapped connector.
+func (p *PolicyDialer) Unwrap() Connector {
+	return p.Connector
+}
+
+type policyConnection struct {
+	Connection
+	cfg  *PolicyConfig
+	host Host
+}
+
+func (c *policyConnection) Exec(cmd string, host Host) (string, int, error) {
+	if err := c.cfg.CheckCommand(host, cmd); err != nil {
+		return "", 1, err
+	}
+	return c.Connection.Exec(cmd, host)
+}
+
+func (c *policyConnection) PExec(cmd string, stdin io.Reader, stdout io.Writer, stderr io.Writer, host Host) (int, error) {
+	if err := c.cfg.CheckCommand(host, cmd); err != nil {
+		return 1, err
+	}
+	return c.Connection.PExec(cmd, stdin, stdout, stderr, host)
+}
+
+func (c *policyConnection) Fetch(local, remote string, host Host) error {
+	if err := c.cfg.CheckPath(host, AuditFetch, remote); err != nil {
+		return err
+	}
+	return c.Connection.Fetch(local, remote, host)
+}
+
+func (c *policyConnection) Scp(local, remote string, host Host) error {
+	if err := c.cfg.CheckPath(host, AuditPut, remote); err != nil {
+		return err
+	}
+	return c.Connection.Scp(local, remote, host)
+}
+
+func (c *policyConnection) MkDirAll(remote string, mode string, host Host) error {
+	if err := c.cfg.CheckPath(host, AuditMkdir, remote); err != nil {
+		return err
+	}
+	return c.Connection.MkDirAll(remote, mode, host)
+}
+
+func (c *policyConnection) Chmod(remote string, mode os.FileMode) error {
+	if err := c.cfg.CheckPath(c.host, AuditChmod, remote); err != nil {
+		return err
+	}
+	return c.Connection.Chmod(remote, mode)
+}
+
+// Stat, Exists and Checksum check the path, and the commands inspecting the file if the connection doesn't inspect
+// it natively.
+func (c *policyConnection) Stat(remote string, host Host) (os.FileInfo, error) {
+	if err := c.cfg.CheckPath(host, AuditStat, remote); err != nil {
+		return nil, err
+	}
+	return statFileBy(c.Connection, c, remote, host)
+}
+
+func (c *policyConnection) Exists(remote string, host Host) (bool, error) {
+	if err := c.cfg.CheckPath(host, AuditStat, remote); err != nil {
+		return false, err
+	}
+	return fileExistsBy(c.Connection, c, remote, host)
+}
+
+func (c *policyConnection) Checksum(remote string, host Host) (string, error) {
+	if err := c.cfg.CheckPath(host, AuditChecksum, remote); err != nil {
+		return "", err
+	}
+	return fileChecksumBy(c.Connection, c, remote, host)
+}
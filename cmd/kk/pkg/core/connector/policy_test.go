@@ -0,0 +1,137 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestPolicyConfig(t *testing.T) {
+	node1 := NewHost()
+	node1.Name = "node1"
+	node2 := NewHost()
+	node2.Name = "node2"
+
+	cfg := &PolicyConfig{Rules: []PolicyRule{
+		{Action: PolicyDeny, Command: `^curl .*\| *(ba)?sh`, Reason: "no piped installers"},
+		{Action: PolicyAllow, Path: "/etc/ssh/sshd_config.d/**", Hosts: []string{"node1"}},
+		{Action: PolicyDeny, Path: "/etc/ssh/**", Reason: "sshd is managed by puppet"},
+		{Action: PolicyDeny, Path: "/home/*/.ssh/authorized_keys"},
+	}}
+	if err := cfg.compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		host   Host
+		cmd    string
+		path   string
+		denied int
+	}{
+		{name: "allowed command", host: node1, cmd: SudoPrefix("systemctl restart kubelet")},
+		{name: "denied command", host: node1, cmd: SudoPrefix("curl -sfL https://get.example.com | sh -"), denied: 1},
+		{name: "denied path in the command", host: node2, cmd: SudoPrefix("sed -i 's/^#Port 22/Port 22/' /etc/ssh/sshd_config"), denied: 3},
+		{name: "the dir of the denied paths", host: node2, cmd: "ls /etc/ssh", denied: 3},
+		{name: "allowed path on the host", host: node1, cmd: "cp /tmp/kubekey/x.conf /etc/ssh/sshd_config.d/x.conf"},
+		{name: "path allowed on another host", host: node2, path: "/etc/ssh/sshd_config.d/x.conf", denied: 3},
+		{name: "single dir glob", host: node2, path: "/home/ops/.ssh/authorized_keys", denied: 4},
+		{name: "single dir glob not matched", host: node2, path: "/home/ops/x/.ssh/authorized_keys"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.cmd != "" {
+				err = cfg.CheckCommand(tt.host, tt.cmd)
+			} else {
+				err = cfg.CheckPath(tt.host, AuditPut, tt.path)
+			}
+			if tt.denied == 0 {
+				if err != nil {
+					t.Errorf("got error %v, want nil", err)
+				}
+				return
+			}
+			perr, ok := err.(*PolicyError)
+			if !ok || perr.Rule != tt.denied {
+				t.Errorf("got error %v, want denied by the rule %d", err, tt.denied)
+			}
+		})
+	}
+
+	deny := &PolicyConfig{Default: PolicyDeny, Rules: []PolicyRule{{Action: PolicyAllow, Command: `^systemctl `}}}
+	if err := deny.compile(); err != nil {
+		t.Fatal(err)
+	}
+	if err := deny.CheckCommand(node1, "systemctl restart kubelet"); err != nil {
+		t.Errorf("got error %v, want nil", err)
+	}
+	if err := deny.CheckCommand(node1, "reboot"); err == nil {
+		t.Error("got nil, want denied by the default action")
+	}
+	// the paths in the commands aren't denied by the default action
+	if err := deny.CheckCommand(node1, "systemctl cat /etc/systemd/system/kubelet.service"); err != nil {
+		t.Errorf("got error %v, want nil", err)
+	}
+
+	for _, invalid := range []PolicyConfig{
+		{Default: "maybe"},
+		{Rules: []PolicyRule{{Action: "audit", Command: "x"}}},
+		{Rules: []PolicyRule{{Action: PolicyDeny}}},
+		{Rules: []PolicyRule{{Action: PolicyDeny, Command: "x", Path: "/x"}}},
+		{Rules: []PolicyRule{{Action: PolicyDeny, Path: "etc/ssh"}}},
+		{Rules: []PolicyRule{{Action: PolicyDeny, Command: "("}}},
+	} {
+		if err := invalid.compile(); err == nil {
+			t.Errorf("compile(%+v) = nil, want error", invalid)
+		}
+	}
+}
+
+func TestPolicyDialerInspect(t *testing.T) {
+	host := NewHost()
+	host.Name = "node1"
+	cfg := &PolicyConfig{Rules: []PolicyRule{
+		{Action: PolicyDeny, Path: "/etc/shadow"},
+		{Action: PolicyDeny, Command: `stat -L`},
+	}}
+	if err := cfg.compile(); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := NewPolicyDialer(&fakeConnector{conn: &fakeConnection{}}, cfg).Connect(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := fileChecksum(conn, "/etc/shadow", host); !isDenied(err, AuditChecksum, 1) {
+		t.Errorf("fileChecksum() error = %v, want the path denied", err)
+	}
+	if _, err := fileExists(conn, "/etc/shadow", host); !isDenied(err, AuditStat, 1) {
+		t.Errorf("fileExists() error = %v, want the path denied", err)
+	}
+	// the connection doesn't inspect the files natively, the command inspecting the file is checked too
+	if _, err := statFile(conn, "/tmp/kubekey/kubeadm.yaml", host); !isDenied(err, AuditExec, 2) {
+		t.Errorf("statFile() error = %v, want the command denied", err)
+	}
+}
+
+func isDenied(err error, operation string, rule int) bool {
+	var perr *PolicyError
+	return errors.As(err, &perr) && perr.Operation == operation && perr.Rule == rule
+}
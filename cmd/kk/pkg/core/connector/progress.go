@@ -0,0 +1,31 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+// ProgressRecorder records the progress of the runs of kk on each host, e.g. into the status of the Cluster by the
+// operator. The pipeline starts a phase for the modules of each tag, and the tasks of the modules are recorded in the
+// running phase. The tasks on the hosts are recorded concurrently.
+type ProgressRecorder interface {
+	// StartPhase starts the phase of the next modules, the phases running on the hosts before it are complete.
+	StartPhase(phase string)
+	// StartTask records the task started on the hosts.
+	StartTask(task string, hosts []string)
+	// FinishTask records the state of the task finished on the host, changed, ok, skipped or failed, with its error.
+	FinishTask(host, task, state string, err error)
+	// Finish records the end of a pipeline, the phases running on the hosts are complete if it succeeded.
+	Finish(err error)
+}
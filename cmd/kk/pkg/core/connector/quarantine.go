@@ -0,0 +1,233 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// QuarantineThreshold is the number of consecutive runs a host must be unreachable in to be quarantined.
+const QuarantineThreshold = 3
+
+// QuarantinedHost is why and since when a host is quarantined.
+type QuarantinedHost struct {
+	Reason string    `json:"reason"`
+	Since  time.Time `json:"since"`
+	// Automatic is true if the host was quarantined for being unreachable, instead of by the user.
+	Automatic bool `json:"automatic,omitempty"`
+}
+
+// Quarantine is the list of the quarantined hosts of a cluster, which the pipelines skip with a warning. It also
+// counts the consecutive runs each host was unreachable in, to quarantine the hosts unreachable for too long.
+type Quarantine struct {
+	mu          sync.Mutex
+	file        string
+	hosts       map[string]QuarantinedHost
+	unreachable map[string]int
+}
+
+type quarantineFile struct {
+	Hosts       map[string]QuarantinedHost `json:"hosts"`
+	Unreachable map[string]int             `json:"unreachable,omitempty"`
+}
+
+// LoadQuarantine returns the quarantine saved to the file, which is empty if the file doesn't exist.
+func LoadQuarantine(file string) (*Quarantine, error) {
+	q := &Quarantine{
+		file:        file,
+		hosts:       make(map[string]QuarantinedHost),
+		unreachable: make(map[string]int),
+	}
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the quarantine %s", file)
+	}
+	var f quarantineFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the quarantine %s", file)
+	}
+	for name, h := range f.Hosts {
+		q.hosts[name] = h
+	}
+	for name, n := range f.Unreachable {
+		q.unreachable[name] = n
+	}
+	return q, nil
+}
+
+// Get returns whether the host is quarantined and why.
+func (q *Quarantine) Get(host string) (QuarantinedHost, bool) {
+	if q == nil {
+		return QuarantinedHost{}, false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	h, ok := q.hosts[host]
+	return h, ok
+}
+
+// Hosts returns the names of the quarantined hosts in order.
+func (q *Quarantine) Hosts() []string {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	names := make([]string, 0, len(q.hosts))
+	for name := range q.hosts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Add quarantines the host, the reason of a host already quarantined is updated.
+func (q *Quarantine) Add(host, reason string, automatic bool, now time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.hosts[host] = QuarantinedHost{Reason: reason, Since: now, Automatic: automatic}
+}
+
+// Remove releases the host from the quarantine and resets its count of unreachable runs. It returns false if the
+// host wasn't quarantined.
+func (q *Quarantine) Remove(host string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_, ok := q.hosts[host]
+	delete(q.hosts, host)
+	delete(q.unreachable, host)
+	return ok
+}
+
+// Record counts the runs the hosts were unreachable in by the metrics of a run. The hosts unreachable in threshold
+// consecutive runs are quarantined, while the hosts reached in the run are reset and released if they were
+// quarantined automatically. It returns the hosts quarantined and released.
+func (q *Quarantine) Record(metrics map[string]HostMetrics, threshold int, now time.Time) ([]string, []string) {
+	if q == nil {
+		return nil, nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var quarantined, released []string
+	for name, m := range metrics {
+		if !m.Unreachable() {
+			delete(q.unreachable, name)
+			if h, ok := q.hosts[name]; ok && h.Automatic && m.Connections > 0 {
+				delete(q.hosts, name)
+				released = append(released, name)
+			}
+			continue
+		}
+		q.unreachable[name]++
+		if _, ok := q.hosts[name]; ok || threshold <= 0 || q.unreachable[name] < threshold {
+			continue
+		}
+		q.hosts[name] = QuarantinedHost{
+			Reason:    fmt.Sprintf("unreachable in the last %d runs", q.unreachable[name]),
+			Since:     now,
+			Automatic: true,
+		}
+		quarantined = append(quarantined, name)
+	}
+	sort.Strings(quarantined)
+	sort.Strings(released)
+	return quarantined, released
+}
+
+// Probe connects to the hosts quarantined automatically among the hosts, and releases the ones reached. The runs skip
+// the quarantined hosts, so Record would never reach them again. The connections are kept for the run. It returns
+// the hosts released.
+func (q *Quarantine) Probe(dialer Connector, hosts []Host) []string {
+	if q == nil {
+		return nil
+	}
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		released []string
+	)
+	for _, host := range hosts {
+		if h, ok := q.Get(host.GetName()); !ok || !h.Automatic {
+			continue
+		}
+		wg.Add(1)
+		go func(host Host) {
+			defer wg.Done()
+			if _, err := dialer.Connect(host); err != nil {
+				return
+			}
+			q.Remove(host.GetName())
+			mu.Lock()
+			released = append(released, host.GetName())
+			mu.Unlock()
+		}(host)
+	}
+	wg.Wait()
+	sort.Strings(released)
+	return released
+}
+
+// Save writes the quarantine to the file.
+func (q *Quarantine) Save() error {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	f := quarantineFile{Hosts: q.hosts, Unreachable: q.unreachable}
+	data, err := json.MarshalIndent(f, "", "  ")
+	q.mu.Unlock()
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the quarantine")
+	}
+	if err := os.MkdirAll(filepath.Dir(q.file), os.ModePerm); err != nil {
+		return errors.Wrapf(err, "failed to create the dir of the quarantine %s", q.file)
+	}
+	if err := os.WriteFile(q.file, data, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write the quarantine %s", q.file)
+	}
+	return nil
+}
+
+// File returns the file of the quarantine.
+func (q *Quarantine) File() string {
+	return q.file
+}
+
+// Print writes the quarantined hosts as a table.
+func (q *Quarantine) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 10, 4, 3, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "HOST\tSINCE\tAUTOMATIC\tREASON")
+	for _, name := range q.Hosts() {
+		h, _ := q.Get(name)
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%t\t%s\n", name, h.Since.Format(time.RFC3339), h.Automatic, h.Reason)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,101 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQuarantineRecord(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "quarantine.json")
+	now := time.Unix(1700000000, 0)
+	unreachable := HostMetrics{FailedConnections: 2, Errors: 2}
+	reached := HostMetrics{Connections: 5, Commands: 5}
+
+	// node1 is unreachable in each run, node2 recovers before the threshold
+	runs := []map[string]HostMetrics{
+		{"node1": unreachable, "node2": unreachable},
+		{"node1": unreachable, "node2": reached},
+		{"node1": unreachable, "node2": unreachable},
+	}
+	var quarantined []string
+	for _, run := range runs {
+		q, err := LoadQuarantine(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		quarantined, _ = q.Record(run, 3, now)
+		if err := q.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !reflect.DeepEqual(quarantined, []string{"node1"}) {
+		t.Fatalf("Record() quarantined %v, want [node1]", quarantined)
+	}
+
+	q, err := LoadQuarantine(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, ok := q.Get("node1")
+	if !ok || !h.Automatic || h.Reason != "unreachable in the last 3 runs" {
+		t.Errorf("Get(node1) = %+v, %v", h, ok)
+	}
+	if _, ok := q.Get("node2"); ok {
+		t.Error("node2 shouldn't be quarantined")
+	}
+
+	// a host quarantined by the user stays quarantined when it is reached, unlike the automatic ones
+	q.Add("node3", "maintenance", false, now)
+	_, released := q.Record(map[string]HostMetrics{"node1": reached, "node3": reached}, 3, now)
+	if !reflect.DeepEqual(released, []string{"node1"}) {
+		t.Errorf("Record() released %v, want [node1]", released)
+	}
+	if !reflect.DeepEqual(q.Hosts(), []string{"node3"}) {
+		t.Errorf("Hosts() = %v, want [node3]", q.Hosts())
+	}
+	if !q.Remove("node3") || q.Remove("node3") {
+		t.Error("Remove(node3) should release the host only once")
+	}
+}
+
+func TestQuarantineProbe(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	q, err := LoadQuarantine(filepath.Join(t.TempDir(), "quarantine.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Add("node1", "unreachable in the last 3 runs", true, now)
+	q.Add("node2", "unreachable in the last 3 runs", true, now)
+	q.Add("node3", "maintenance", false, now)
+
+	var hosts []Host
+	for _, name := range []string{"node1", "node2", "node3", "node4"} {
+		hosts = append(hosts, &BaseHost{Name: name})
+	}
+	// node2 is still unreachable, node3 is quarantined by the user and isn't probed
+	dialer := NewFakeDialer(&FakeFixtures{Unreachable: []string{"node2"}})
+	if released := q.Probe(dialer, hosts); !reflect.DeepEqual(released, []string{"node1"}) {
+		t.Errorf("Probe() released %v, want [node1]", released)
+	}
+	if !reflect.DeepEqual(q.Hosts(), []string{"node2", "node3"}) {
+		t.Errorf("Hosts() = %v, want [node2 node3]", q.Hosts())
+	}
+}
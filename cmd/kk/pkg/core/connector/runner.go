@@ -17,47 +17,152 @@
 package connector
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/tracing"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
 )
 
+// outputKey is the host cache key of the output of the last command executed on the host.
+const outputKey = "commandOutput"
+
+// TakeOutput returns the output of the last command executed on the host, and resets it for the next action.
+func TakeOutput(host Host) string {
+	if host == nil || host.GetCache() == nil {
+		return ""
+	}
+	c := host.GetCache()
+	defer c.Delete(outputKey)
+	output, _ := c.GetMustString(outputKey)
+	return output
+}
+
 type Runner struct {
 	Conn  Connection
 	Debug bool
 	Host  Host
 	Index int
+	// Ctx bounds the operations on the host. When it is done, the running operation is abandoned and the connection
+	// is closed to kill the hung session, the connector dials the host again for the next operation.
+	Ctx context.Context
+}
+
+// do runs the operation on the connection within the context of the runner. It returns false if the operation is
+// abandoned, the error is the one of the context then. The abandoned operation still runs, so it must only set the
+// vars of its call, which are copied to the results only if it isn't abandoned.
+func (r *Runner) do(op func() error) (bool, error) {
+	if r.Ctx == nil {
+		return true, op()
+	}
+	if err := r.Ctx.Err(); err != nil {
+		return false, r.ctxErr(err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- op()
+	}()
+	select {
+	case err := <-errCh:
+		return true, err
+	case <-r.Ctx.Done():
+		r.Conn.Close()
+		return false, r.ctxErr(r.Ctx.Err())
+	}
 }
 
-func (r *Runner) Exec(cmd string, printOutput bool) (string, int, error) {
+// startSpan starts the span of an operation on the host, as a child of the span of the task in the context.
+func (r *Runner) startSpan(name string, attrs ...attribute.KeyValue) trace.Span {
+	_, span := tracing.Start(r.Ctx, name, append(attrs, attribute.String("kubekey.host", r.Host.GetName()))...)
+	return span
+}
+
+func (r *Runner) ctxErr(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("[%s] operation timed out: %w", r.Host.GetName(), err)
+	}
+	return fmt.Errorf("[%s] operation canceled: %w", r.Host.GetName(), err)
+}
+
+// Log returns the logger of the entries about the host, with the fields of the pipeline and the task running on it.
+func (r *Runner) Log() logrus.FieldLogger {
+	return logger.FromContext(r.Ctx).WithField(common.Node, r.Host.GetName())
+}
+
+func (r *Runner) Exec(cmd string, printOutput bool) (stdout string, code int, err error) {
+	return r.ExecWith(cmd, CommandOptions{}, printOutput)
+}
+
+// ExecWith runs the cmd in the environment of the options, see CommandOptions.
+func (r *Runner) ExecWith(cmd string, opts CommandOptions, printOutput bool) (string, int, error) {
+	cmd, err := opts.Command(r.Host, cmd, false)
+	if err != nil {
+		return "", 1, err
+	}
+	return r.exec(cmd, printOutput)
+}
+
+func (r *Runner) exec(cmd string, printOutput bool) (stdout string, code int, err error) {
 	if r.Conn == nil {
 		return "", 1, errors.New("no ssh connection available")
 	}
 
-	stdout, code, err := r.Conn.Exec(cmd, r.Host)
-	logger.Log.Debugf("command: [%s]\n%s", r.Host.GetName(), cmd)
+	span := r.startSpan("command", attribute.String("kubekey.command", redact(cmd)))
+	defer func() {
+		span.SetAttributes(attribute.Int("kubekey.exit_code", code))
+		tracing.End(span, err)
+	}()
+	streamCommand(r.Host, cmd)
+	var out string
+	var exitCode int
+	done, doErr := r.do(func() (err error) {
+		out, exitCode, err = r.Conn.Exec(cmd, r.Host)
+		return err
+	})
+	log := r.Log()
+	if !done {
+		streamResult(r.Host, 1, doErr)
+		log.Debugf("command:\n%s\n%v", cmd, doErr)
+		return "", 1, doErr
+	}
+	stdout, code, err = out, exitCode, doErr
+	streamResult(r.Host, code, err)
+	if c := r.Host.GetCache(); c != nil {
+		c.Set(outputKey, stdout)
+	}
+	log.Debugf("command:\n%s", cmd)
 	if stdout != "" {
-		logger.Log.Debugf("stdout: [%s]\n%s", r.Host.GetName(), stdout)
+		log.Debugf("stdout:\n%s", stdout)
 	}
 	if err != nil {
-		logger.Log.Debugf("stderr: [%s]\n%s", r.Host.GetName(), err)
+		log.Debugf("stderr:\n%s", err)
 	}
 
 	if printOutput {
 		if stdout != "" {
-			logger.Log.Infof("stdout: [%s]\n%s", r.Host.GetName(), stdout)
+			log.Infof("stdout:\n%s", stdout)
 		}
 	}
 	return stdout, code, err
 }
 
 func (r *Runner) Cmd(cmd string, printOutput bool) (string, error) {
-	stdout, _, err := r.Exec(cmd, printOutput)
+	return r.CmdWith(cmd, CommandOptions{}, printOutput)
+}
+
+// CmdWith runs the cmd in the environment of the options, see CommandOptions.
+func (r *Runner) CmdWith(cmd string, opts CommandOptions, printOutput bool) (string, error) {
+	stdout, _, err := r.ExecWith(cmd, opts, printOutput)
 	if err != nil {
 		return stdout, err
 	}
@@ -65,36 +170,86 @@ func (r *Runner) Cmd(cmd string, printOutput bool) (string, error) {
 }
 
 func (r *Runner) SudoExec(cmd string, printOutput bool) (string, int, error) {
-	return r.Exec(SudoPrefix(cmd), printOutput)
+	return r.SudoExecWith(cmd, CommandOptions{}, printOutput)
+}
+
+// SudoExecWith runs the cmd with privilege in the environment of the options, the environment is set within the
+// privileged shell, so it isn't reset by sudo.
+func (r *Runner) SudoExecWith(cmd string, opts CommandOptions, printOutput bool) (string, int, error) {
+	cmd, err := opts.Command(r.Host, cmd, true)
+	if err != nil {
+		return "", 1, err
+	}
+	return r.exec(SudoCommand(r.Host, cmd), printOutput)
 }
 
 func (r *Runner) SudoCmd(cmd string, printOutput bool) (string, error) {
-	return r.Cmd(SudoPrefix(cmd), printOutput)
+	return r.SudoCmdWith(cmd, CommandOptions{}, printOutput)
+}
+
+// SudoCmdWith runs the cmd with privilege in the environment of the options.
+func (r *Runner) SudoCmdWith(cmd string, opts CommandOptions, printOutput bool) (string, error) {
+	stdout, _, err := r.SudoExecWith(cmd, opts, printOutput)
+	if err != nil {
+		return stdout, err
+	}
+	return stdout, nil
+}
+
+// SudoNoPasswd returns whether the login user can run sudo without a password on the host.
+func (r *Runner) SudoNoPasswd() (bool, error) {
+	if r.Conn == nil {
+		return false, errors.New("no ssh connection available")
+	}
+	var ok bool
+	done, err := r.do(func() (err error) {
+		if c, isChecker := r.Conn.(sudoChecker); isChecker {
+			ok, err = c.SudoNoPasswd(r.Host)
+			return err
+		}
+		_, _, e := r.Conn.Exec("sudo -n true", r.Host)
+		ok = e == nil
+		return nil
+	})
+	if !done || err != nil {
+		return false, err
+	}
+	return ok, nil
 }
 
-func (r *Runner) Fetch(local, remote string) error {
+func (r *Runner) Fetch(local, remote string) (err error) {
 	if r.Conn == nil {
 		return errors.New("no ssh connection available")
 	}
 
-	if err := r.Conn.Fetch(local, remote, r.Host); err != nil {
-		logger.Log.Debugf("fetch remote file %s to local %s failed: %v", remote, local, err)
+	span := r.startSpan("download", attribute.String("kubekey.remote", remote))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	if _, err := r.do(func() error { return r.Conn.Fetch(local, remote, r.Host) }); err != nil {
+		r.Log().Debugf("fetch remote file %s to local %s failed: %v", remote, local, err)
 		return err
 	}
-	logger.Log.Debugf("fetch remote file %s to local %s success", remote, local)
+	r.Log().Debugf("fetch remote file %s to local %s success", remote, local)
 	return nil
 }
 
-func (r *Runner) Scp(local, remote string) error {
+func (r *Runner) Scp(local, remote string) (err error) {
 	if r.Conn == nil {
 		return errors.New("no ssh connection available")
 	}
 
-	if err := r.Conn.Scp(local, remote, r.Host); err != nil {
-		logger.Log.Debugf("scp local file %s to remote %s failed: %v", local, remote, err)
+	span := r.startSpan("upload", attribute.String("kubekey.remote", remote))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	if _, err := r.do(func() error { return r.Conn.Scp(local, remote, r.Host) }); err != nil {
+		r.Log().Debugf("scp local file %s to remote %s failed: %v", local, remote, err)
 		return err
 	}
-	logger.Log.Debugf("scp local file %s to remote %s success", local, remote)
+	r.Log().Debugf("scp local file %s to remote %s success", local, remote)
 	return nil
 }
 
@@ -114,7 +269,7 @@ func (r *Runner) SudoScp(local, remote string) error {
 	if !util.IsDir(local) {
 		baseRemotePath = filepath.Dir(remote)
 	}
-	if err := r.Conn.MkDirAll(baseRemotePath, "", r.Host); err != nil {
+	if _, err := r.do(func() error { return r.Conn.MkDirAll(baseRemotePath, "", r.Host) }); err != nil {
 		return err
 	}
 
@@ -133,8 +288,14 @@ func (r *Runner) FileExist(remote string) (bool, error) {
 		return false, errors.New("no ssh connection available")
 	}
 
-	ok := r.Conn.RemoteFileExist(remote, r.Host)
-	logger.Log.Debugf("check remote file exist: %v", ok)
+	var ok bool
+	if done, err := r.do(func() error {
+		ok = r.Conn.RemoteFileExist(remote, r.Host)
+		return nil
+	}); !done || err != nil {
+		return false, err
+	}
+	r.Log().Debugf("check remote file exist: %v", ok)
 	return ok, nil
 }
 
@@ -143,22 +304,78 @@ func (r *Runner) DirExist(remote string) (bool, error) {
 		return false, errors.New("no ssh connection available")
 	}
 
-	ok, err := r.Conn.RemoteDirExist(remote, r.Host)
-	if err != nil {
-		logger.Log.Debugf("check remote dir exist failed: %v", err)
+	var ok bool
+	done, err := r.do(func() (err error) {
+		ok, err = r.Conn.RemoteDirExist(remote, r.Host)
+		return err
+	})
+	if !done || err != nil {
+		r.Log().Debugf("check remote dir exist failed: %v", err)
 		return false, err
 	}
-	logger.Log.Debugf("check remote dir exist: %v", ok)
+	r.Log().Debugf("check remote dir exist: %v", ok)
 	return ok, nil
 }
 
+// Stat returns the info of the remote file, the error is os.ErrNotExist if it doesn't exist.
+func (r *Runner) Stat(remote string) (os.FileInfo, error) {
+	if r.Conn == nil {
+		return nil, errors.New("no ssh connection available")
+	}
+
+	var info os.FileInfo
+	if done, err := r.do(func() (err error) {
+		info, err = statFile(r.Conn, remote, r.Host)
+		return err
+	}); !done || err != nil {
+		r.Log().Debugf("stat remote file %s failed: %v", remote, err)
+		return nil, err
+	}
+	return info, nil
+}
+
+// Exists returns whether the remote file or dir exists.
+func (r *Runner) Exists(remote string) (bool, error) {
+	if r.Conn == nil {
+		return false, errors.New("no ssh connection available")
+	}
+
+	var ok bool
+	if done, err := r.do(func() (err error) {
+		ok, err = fileExists(r.Conn, remote, r.Host)
+		return err
+	}); !done || err != nil {
+		r.Log().Debugf("check remote file %s exist failed: %v", remote, err)
+		return false, err
+	}
+	r.Log().Debugf("check remote file %s exist: %v", remote, ok)
+	return ok, nil
+}
+
+// Checksum returns the hex of the sha256 of the remote file, the error is os.ErrNotExist if it doesn't exist.
+func (r *Runner) Checksum(remote string) (string, error) {
+	if r.Conn == nil {
+		return "", errors.New("no ssh connection available")
+	}
+
+	var sum string
+	if done, err := r.do(func() (err error) {
+		sum, err = fileChecksum(r.Conn, remote, r.Host)
+		return err
+	}); !done || err != nil {
+		r.Log().Debugf("checksum remote file %s failed: %v", remote, err)
+		return "", err
+	}
+	return sum, nil
+}
+
 func (r *Runner) MkDir(path string) error {
 	if r.Conn == nil {
 		return errors.New("no ssh connection available")
 	}
 
-	if err := r.Conn.MkDirAll(path, "", r.Host); err != nil {
-		logger.Log.Errorf("make remote dir %s failed: %v", path, err)
+	if _, err := r.do(func() error { return r.Conn.MkDirAll(path, "", r.Host) }); err != nil {
+		r.Log().Errorf("make remote dir %s failed: %v", path, err)
 		return err
 	}
 	return nil
@@ -169,8 +386,8 @@ func (r *Runner) Chmod(path string, mode os.FileMode) error {
 		return errors.New("no ssh connection available")
 	}
 
-	if err := r.Conn.Chmod(path, mode); err != nil {
-		logger.Log.Errorf("chmod remote path %s failed: %v", path, err)
+	if _, err := r.do(func() error { return r.Conn.Chmod(path, mode) }); err != nil {
+		r.Log().Errorf("chmod remote path %s failed: %v", path, err)
 		return err
 	}
 	return nil
@@ -182,9 +399,13 @@ func (r *Runner) FileMd5(path string) (string, error) {
 	}
 
 	cmd := fmt.Sprintf("md5sum %s | cut -d\" \" -f1", path)
-	out, _, err := r.Conn.Exec(cmd, r.Host)
-	if err != nil {
-		logger.Log.Errorf("count remote %s md5 failed: %v", path, err)
+	var out string
+	done, err := r.do(func() (err error) {
+		out, _, err = r.Conn.Exec(cmd, r.Host)
+		return err
+	})
+	if !done || err != nil {
+		r.Log().Errorf("count remote %s md5 failed: %v", path, err)
 		return "", err
 	}
 	return out, nil
@@ -0,0 +1,63 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+// hungConnection is a connection on which the commands hang until it is closed.
+type hungConnection struct {
+	Connection
+	closed chan struct{}
+}
+
+func (h *hungConnection) Exec(cmd string, host Host) (string, int, error) {
+	<-h.closed
+	return "", 1, errors.New("connection closed")
+}
+
+func (h *hungConnection) Close() {
+	close(h.closed)
+}
+
+func TestRunner_ExecTimeout(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	host := NewHost()
+	host.Name = "node1"
+	conn := &hungConnection{closed: make(chan struct{})}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	r := &Runner{Conn: conn, Host: host, Ctx: ctx}
+
+	if _, code, err := r.Exec("sleep infinity", false); !errors.Is(err, context.DeadlineExceeded) || code != 1 {
+		t.Fatalf("Exec() = %d, %v, want the deadline exceeded", code, err)
+	}
+	select {
+	case <-conn.closed:
+	default:
+		t.Error("the connection isn't closed after the timeout")
+	}
+	if _, err := r.Cmd("hostname", false); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Cmd() = %v after the timeout, want the deadline exceeded", err)
+	}
+}
@@ -17,13 +17,18 @@
 package connector
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/event"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
 )
@@ -33,8 +38,27 @@ type BaseRuntime struct {
 	connector       Connector
 	runner          *Runner
 	workDir         string
+	clusterWorkDir  string
+	lock            *os.File
+	reportFile      string
+	junitReportFile string
+	strategy        Strategy
+	tagFilter       TagFilter
+	deadlines       *Deadlines
+	maintenance     *MaintenanceWindows
+	clusterLock     ClusterLock
+	ctx             context.Context
+	events          *event.Dispatcher
+	progress        ProgressRecorder
+	resume          bool
+	quarantine      *Quarantine
+	history         *History
+	diagnostics     bool
+	hostLogs        bool
+	tui             bool
 	verbose         bool
 	ignoreErr       bool
+	strictRender    bool
 	allHosts        []Host
 	roleHosts       map[string][]Host
 	deprecatedHosts map[string]string
@@ -85,13 +109,20 @@ func (b *BaseRuntime) SetConnector(c Connector) {
 	b.connector = c
 }
 
-func (b *BaseRuntime) GenerateWorkDir() error {
+// DefaultWorkDir returns the work dir, the kubekey dir next to the kk binary.
+func DefaultWorkDir() (string, error) {
 	currentDir, err := filepath.Abs(filepath.Dir(os.Args[0]))
 	if err != nil {
-		return errors.Wrap(err, "get current dir failed")
+		return "", errors.Wrap(err, "get current dir failed")
 	}
+	return filepath.Join(currentDir, common.KubeKey), nil
+}
 
-	rootPath := filepath.Join(currentDir, common.KubeKey)
+func (b *BaseRuntime) GenerateWorkDir() error {
+	rootPath, err := DefaultWorkDir()
+	if err != nil {
+		return err
+	}
 	if err := util.CreateDir(rootPath); err != nil {
 		return errors.Wrap(err, "create work dir failed")
 	}
@@ -111,18 +142,253 @@ func (b *BaseRuntime) GenerateWorkDir() error {
 	return nil
 }
 
+// InitClusterWorkDir isolates the state, logs and temp files of the cluster in its own dir under the work dir,
+// while the downloaded files are still shared by all the clusters. It takes an advisory lock on the dir, so that
+// the concurrent invocations against the same cluster fail fast.
+func (b *BaseRuntime) InitClusterWorkDir() error {
+	if b.workDir == "" {
+		if err := b.GenerateWorkDir(); err != nil {
+			return err
+		}
+	}
+
+	clusterWorkDir := filepath.Join(b.workDir, common.ClustersDir, b.ObjName)
+	newDir := !util.IsExist(clusterWorkDir)
+	if err := util.CreateDir(clusterWorkDir); err != nil {
+		return errors.Wrap(err, "create cluster work dir failed")
+	}
+
+	lockPath := filepath.Join(clusterWorkDir, common.LockFile)
+	f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, common.FileMode0644)
+	if err != nil {
+		return errors.Wrapf(err, "open lock file %s failed", lockPath)
+	}
+	if err := lockFile(f); err != nil {
+		pid, _ := os.ReadFile(lockPath)
+		f.Close()
+		return errors.Errorf("another KubeKey process (pid %s) is running against the cluster %s, "+
+			"wait for it to finish or remove the lock file %s if the process doesn't exist", strings.TrimSpace(string(pid)), b.ObjName, lockPath)
+	}
+	if err := f.Truncate(0); err == nil {
+		_, _ = f.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	}
+	b.lock = f
+
+	// the state of the cluster was stored in the work dir before, it is copied once to be reused
+	if newDir {
+		legacyPki := filepath.Join(b.workDir, "pki")
+		if util.IsDir(legacyPki) {
+			if err := util.CopyDir(legacyPki, filepath.Join(clusterWorkDir, "pki")); err != nil {
+				return errors.Wrap(err, "copy the pki of the work dir failed")
+			}
+		}
+	}
+
+	b.clusterWorkDir = clusterWorkDir
+	return b.InitLogger()
+}
+
+// Unlock releases the lock of the cluster work dir taken by InitClusterWorkDir, so another runtime of the process can
+// take it, e.g. to run the pipelines against the cluster after it is rendered.
+func (b *BaseRuntime) Unlock() {
+	if b.lock != nil {
+		b.lock.Close()
+		b.lock = nil
+	}
+}
+
 func (b *BaseRuntime) GetHostWorkDir() string {
-	return filepath.Join(b.workDir, b.RemoteHost().GetName())
+	return filepath.Join(b.GetClusterWorkDir(), b.RemoteHost().GetName())
 }
 
 func (b *BaseRuntime) GetWorkDir() string {
 	return b.workDir
 }
 
+// GetClusterWorkDir returns the work dir of the cluster, or the work dir if it isn't isolated.
+func (b *BaseRuntime) GetClusterWorkDir() string {
+	if b.clusterWorkDir == "" {
+		return b.workDir
+	}
+	return b.clusterWorkDir
+}
+
+// SetReportFiles sets the files of the run report in JSON and JUnit XML, the JUnit report is optional.
+func (b *BaseRuntime) SetReportFiles(reportFile, junitReportFile string) {
+	b.reportFile = reportFile
+	b.junitReportFile = junitReportFile
+}
+
+// GetReportFiles returns the files of the run report in JSON and JUnit XML. The JSON report defaults to
+// report.json in the work dir of the cluster.
+func (b *BaseRuntime) GetReportFiles() (string, string) {
+	reportFile := b.reportFile
+	if reportFile == "" {
+		reportFile = filepath.Join(b.GetClusterWorkDir(), common.ReportFile)
+	}
+	return reportFile, b.junitReportFile
+}
+
+// SetStrategy sets the default execution strategy of the task modules, which don't set their own.
+func (b *BaseRuntime) SetStrategy(s Strategy) {
+	b.strategy = s
+}
+
+func (b *BaseRuntime) GetStrategy() Strategy {
+	return b.strategy
+}
+
+// SetResume sets whether the pipelines resume from the checkpoint of the previous failed run.
+func (b *BaseRuntime) SetResume(resume bool) {
+	b.resume = resume
+}
+
+func (b *BaseRuntime) GetResume() bool {
+	return b.resume
+}
+
+// InitQuarantine loads the quarantined hosts of the cluster from its work dir.
+func (b *BaseRuntime) InitQuarantine() error {
+	q, err := LoadQuarantine(filepath.Join(b.GetClusterWorkDir(), common.QuarantineFile))
+	if err != nil {
+		return err
+	}
+	b.quarantine = q
+	return nil
+}
+
+func (b *BaseRuntime) GetQuarantine() *Quarantine {
+	return b.quarantine
+}
+
+// InitHistory sets up the history of the managed files of the cluster in its work dir, the changes are recorded
+// as the run started now.
+func (b *BaseRuntime) InitHistory() {
+	b.history = NewHistory(filepath.Join(b.GetClusterWorkDir(), common.HistoryDir), NewRunID(time.Now()), HistoryLimit)
+}
+
+func (b *BaseRuntime) GetHistory() *History {
+	return b.history
+}
+
+// SetTagFilter sets the filter of the modules of the pipelines by their tags, see --tags and --skip-tags.
+func (b *BaseRuntime) SetTagFilter(f TagFilter) {
+	b.tagFilter = f
+}
+
+func (b *BaseRuntime) GetTagFilter() TagFilter {
+	return b.tagFilter
+}
+
+// SetDeadlines sets the deadlines of the run and its phases.
+func (b *BaseRuntime) SetDeadlines(d *Deadlines) {
+	b.deadlines = d
+}
+
+// GetDeadlines returns the deadlines of the run and its phases, which are empty if they aren't set.
+func (b *BaseRuntime) GetDeadlines() *Deadlines {
+	if b.deadlines == nil {
+		b.deadlines = &Deadlines{}
+	}
+	return b.deadlines
+}
+
+// SetMaintenanceWindows sets the maintenance windows restricting the disruptive phases of the run.
+func (b *BaseRuntime) SetMaintenanceWindows(m *MaintenanceWindows) {
+	b.maintenance = m
+}
+
+// GetMaintenanceWindows returns the maintenance windows of the run, which are empty if they aren't set.
+func (b *BaseRuntime) GetMaintenanceWindows() *MaintenanceWindows {
+	if b.maintenance == nil {
+		b.maintenance = &MaintenanceWindows{}
+	}
+	return b.maintenance
+}
+
+// SetClusterLock sets the lock of the cluster against the runs of kk from the other machines.
+func (b *BaseRuntime) SetClusterLock(l ClusterLock) {
+	b.clusterLock = l
+}
+
+// GetClusterLock returns the lock of the cluster, it is nil if the cluster isn't locked beyond the work dir.
+func (b *BaseRuntime) GetClusterLock() ClusterLock {
+	return b.clusterLock
+}
+
+// SetContext sets the context the tasks of the running module are executed within.
+func (b *BaseRuntime) SetContext(ctx context.Context) {
+	b.ctx = ctx
+}
+
+func (b *BaseRuntime) GetContext() context.Context {
+	if b.ctx == nil {
+		return context.Background()
+	}
+	return b.ctx
+}
+
+// SetEvents sets the dispatcher firing the events of the run to the hooks of the cluster.
+func (b *BaseRuntime) SetEvents(d *event.Dispatcher) {
+	b.events = d
+}
+
+// GetEvents returns the dispatcher of the events, it is nil and fires nothing if no hook is set.
+func (b *BaseRuntime) GetEvents() *event.Dispatcher {
+	return b.events
+}
+
+// SetProgressRecorder sets the recorder of the progress of the run on the hosts.
+func (b *BaseRuntime) SetProgressRecorder(r ProgressRecorder) {
+	b.progress = r
+}
+
+// GetProgressRecorder returns the recorder of the progress, it is nil if the progress isn't recorded.
+func (b *BaseRuntime) GetProgressRecorder() ProgressRecorder {
+	return b.progress
+}
+
+// SetCollectDiagnostics sets whether a diagnostic snapshot is collected on the hosts a task failed on.
+func (b *BaseRuntime) SetCollectDiagnostics(diagnostics bool) {
+	b.diagnostics = diagnostics
+}
+
+func (b *BaseRuntime) GetCollectDiagnostics() bool {
+	return b.diagnostics
+}
+
+// SetHostLogs sets whether the commands executed on each host and their output are written into a log file of the host.
+func (b *BaseRuntime) SetHostLogs(hostLogs bool) {
+	b.hostLogs = hostLogs
+}
+
+func (b *BaseRuntime) GetHostLogs() bool {
+	return b.hostLogs
+}
+
+// SetTUI sets whether the pipelines draw their progress in the terminal instead of printing the logs.
+func (b *BaseRuntime) SetTUI(tui bool) {
+	b.tui = tui
+}
+
+func (b *BaseRuntime) GetTUI() bool {
+	return b.tui
+}
+
 func (b *BaseRuntime) GetIgnoreErr() bool {
 	return b.ignoreErr
 }
 
+// SetStrictRender sets whether the templates fail on the undefined variables instead of rendering them as
+// "<no value>".
+func (b *BaseRuntime) SetStrictRender(strict bool) {
+	b.strictRender = strict
+}
+
+func (b *BaseRuntime) GetStrictRender() bool {
+	return b.strictRender
+}
+
 func (b *BaseRuntime) GetAllHosts() []Host {
 	hosts := make([]Host, 0, 0)
 	for i := range b.allHosts {
@@ -177,7 +443,7 @@ func (b *BaseRuntime) InitLogger() error {
 			return err
 		}
 	}
-	logDir := filepath.Join(b.GetWorkDir(), "logs")
+	logDir := filepath.Join(b.GetClusterWorkDir(), "logs")
 	logger.Log = logger.NewLogger(logDir, b.verbose)
 	return nil
 }
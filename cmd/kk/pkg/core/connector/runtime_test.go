@@ -0,0 +1,53 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBaseRuntime_InitClusterWorkDir(t *testing.T) {
+	workDir := t.TempDir()
+	first := &BaseRuntime{ObjName: "cluster1", workDir: workDir}
+	if err := first.InitClusterWorkDir(); err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(workDir, "clusters", "cluster1"); first.GetClusterWorkDir() != want {
+		t.Errorf("GetClusterWorkDir() = %s, want %s", first.GetClusterWorkDir(), want)
+	}
+
+	other := &BaseRuntime{ObjName: "cluster2", workDir: workDir}
+	if err := other.InitClusterWorkDir(); err != nil {
+		t.Errorf("InitClusterWorkDir() of another cluster failed: %v", err)
+	} else {
+		other.lock.Close()
+	}
+
+	second := &BaseRuntime{ObjName: "cluster1", workDir: workDir}
+	err := second.InitClusterWorkDir()
+	if err == nil || !strings.Contains(err.Error(), "another KubeKey process") {
+		t.Errorf("InitClusterWorkDir() of the locked cluster = %v, want the lock error", err)
+	}
+
+	first.Unlock()
+	if err := second.InitClusterWorkDir(); err != nil {
+		t.Errorf("InitClusterWorkDir() of the unlocked cluster failed: %v", err)
+	}
+	second.Unlock()
+}
@@ -0,0 +1,109 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	ShellBash       = "bash"
+	ShellSh         = "sh"
+	ShellAsh        = "ash"
+	ShellPowerShell = "powershell"
+
+	BecomeSudo = "sudo"
+	BecomeDoas = "doas"
+)
+
+// Shells are the shells running the commands on the hosts.
+var Shells = []string{ShellBash, ShellSh, ShellAsh, ShellPowerShell}
+
+// BecomeMethods are the methods escalating the privilege of the commands on the hosts.
+var BecomeMethods = []string{BecomeSudo, BecomeDoas}
+
+// ShellPath returns the path of the POSIX shell of the host, bash if it isn't set.
+func ShellPath(host Host) string {
+	if host == nil {
+		return "/bin/bash"
+	}
+	switch host.GetShell() {
+	case ShellSh:
+		return "/bin/sh"
+	case ShellAsh:
+		return "/bin/ash"
+	default:
+		return "/bin/bash"
+	}
+}
+
+// SudoCommand returns the cmd run with privilege by the shell and the become method of the host. It is the same as
+// SudoPrefix for the hosts with the defaults, so cmd is quoted by double quotes. The commands of powershell aren't
+// escalated.
+func SudoCommand(host Host, cmd string) string {
+	if host == nil {
+		return SudoPrefix(cmd)
+	}
+	switch {
+	case host.GetShell() == ShellPowerShell:
+		return cmd
+	case host.GetBecome() == BecomeDoas:
+		return fmt.Sprintf("doas %s -c \"%s\"", ShellPath(host), cmd)
+	default:
+		return fmt.Sprintf("sudo -E %s -c \"%s\"", ShellPath(host), cmd)
+	}
+}
+
+// ShellCommand returns the cmd run on the host by its shell. The commands of powershell are encoded, so they aren't
+// parsed by the default shell of the OpenSSH server first.
+func ShellCommand(host Host, cmd string) string {
+	if host == nil || host.GetShell() != ShellPowerShell {
+		return cmd
+	}
+	return "powershell -NoProfile -NonInteractive -EncodedCommand " + EncodePowerShell(cmd)
+}
+
+// EncodePowerShell encodes the script for -EncodedCommand of powershell, the base64 of its UTF-16LE.
+func EncodePowerShell(script string) string {
+	units := utf16.Encode([]rune(script))
+	b := make([]byte, 0, len(units)*2)
+	for _, u := range units {
+		b = append(b, byte(u), byte(u>>8))
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// Quote quotes s as a single word of the POSIX shells.
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// QuotePowerShell quotes s as a verbatim string of powershell.
+func QuotePowerShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// QuoteFor quotes s as a single word of the shell of the host.
+func QuoteFor(host Host, s string) string {
+	if host != nil && host.GetShell() == ShellPowerShell {
+		return QuotePowerShell(s)
+	}
+	return Quote(s)
+}
@@ -0,0 +1,66 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import "testing"
+
+func TestSudoCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		host *BaseHost
+		want string
+	}{
+		{name: "default", host: &BaseHost{}, want: `sudo -E /bin/bash -c "id -u"`},
+		{name: "ash", host: &BaseHost{Shell: ShellAsh}, want: `sudo -E /bin/ash -c "id -u"`},
+		{name: "doas", host: &BaseHost{Shell: ShellSh, Become: BecomeDoas}, want: `doas /bin/sh -c "id -u"`},
+		{name: "powershell", host: &BaseHost{Shell: ShellPowerShell}, want: "id -u"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SudoCommand(tt.host, "id -u"); got != tt.want {
+				t.Errorf("SudoCommand() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShellCommand(t *testing.T) {
+	if got := ShellCommand(&BaseHost{Shell: ShellSh}, "ls"); got != "ls" {
+		t.Errorf("ShellCommand() = %s, want ls", got)
+	}
+	want := "powershell -NoProfile -NonInteractive -EncodedCommand ZABpAHIA"
+	if got := ShellCommand(&BaseHost{Shell: ShellPowerShell}, "dir"); got != want {
+		t.Errorf("ShellCommand() = %s, want %s", got, want)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		host *BaseHost
+		s    string
+		want string
+	}{
+		{host: &BaseHost{}, s: "a b", want: `'a b'`},
+		{host: &BaseHost{Shell: ShellAsh}, s: "it's", want: `'it'\''s'`},
+		{host: &BaseHost{Shell: ShellPowerShell}, s: "it's", want: `'it''s'`},
+	}
+	for _, tt := range tests {
+		if got := QuoteFor(tt.host, tt.s); got != tt.want {
+			t.Errorf("QuoteFor(%q) = %s, want %s", tt.s, got, tt.want)
+		}
+	}
+}
@@ -18,6 +18,7 @@ package connector
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/base64"
 	"fmt"
@@ -35,8 +36,11 @@ import (
 	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/time/rate"
 
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/exporter"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/fips"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
 )
@@ -53,6 +57,11 @@ type Cfg struct {
 	Bastion     string
 	BastionPort int
 	BastionUser string
+
+	// TransferRateLimit is the rate limit of the files copied to the host in bytes per second, 0 is unlimited.
+	TransferRateLimit int64
+	// TransferCompression is the compression of the files copied to and fetched from the host.
+	TransferCompression string
 }
 
 const socketEnvPrefix = "env:"
@@ -63,6 +72,17 @@ type connection struct {
 	sshclient  *ssh.Client
 	ctx        context.Context
 	cancel     context.CancelFunc
+
+	sudoOnce     sync.Once
+	sudoNoPasswd bool
+	sudoErr      error
+	doasOnce     sync.Once
+	doasErr      error
+
+	limiter             *rate.Limiter
+	compression         string
+	compressionOnce     sync.Once
+	resolvedCompression string
 }
 
 func NewConnection(cfg Cfg) (Connection, error) {
@@ -82,6 +102,9 @@ func NewConnection(cfg Cfg) (Connection, error) {
 		if parseErr != nil {
 			return nil, errors.Wrap(parseErr, "The given SSH key could not be parsed")
 		}
+		if err := fips.CheckSigner(signer); err != nil {
+			return nil, errors.Wrap(err, "The given SSH key is not allowed")
+		}
 		authMethods = append(authMethods, ssh.PublicKeys(signer))
 	}
 
@@ -108,6 +131,13 @@ func NewConnection(cfg Cfg) (Connection, error) {
 			_ = socket.Close()
 			return nil, errors.Wrap(signersErr, "error when creating signer for SSH agent")
 		}
+		if fips.Enabled() {
+			signers = allowedSigners(signers)
+			if len(signers) == 0 {
+				_ = socket.Close()
+				return nil, errors.New("no key of the SSH agent is allowed in FIPS mode")
+			}
+		}
 
 		authMethods = append(authMethods, ssh.PublicKeys(signers...))
 	}
@@ -118,6 +148,7 @@ func NewConnection(cfg Cfg) (Connection, error) {
 		Auth:            authMethods,
 		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
 	}
+	fips.ConfigureSSH(sshConfig)
 
 	targetHost := cfg.Address
 	targetPort := strconv.Itoa(cfg.Port)
@@ -137,8 +168,10 @@ func NewConnection(cfg Cfg) (Connection, error) {
 
 	ctx, cancelFn := context.WithCancel(context.Background())
 	sshConn := &connection{
-		ctx:    ctx,
-		cancel: cancelFn,
+		ctx:         ctx,
+		cancel:      cancelFn,
+		limiter:     newRateLimiter(cfg.TransferRateLimit),
+		compression: cfg.TransferCompression,
 	}
 
 	if cfg.Bastion == "" {
@@ -173,11 +206,32 @@ func NewConnection(cfg Cfg) (Connection, error) {
 	return sshConn, nil
 }
 
+// allowedSigners drops the signers whose keys are not allowed in FIPS mode.
+func allowedSigners(signers []ssh.Signer) []ssh.Signer {
+	var allowed []ssh.Signer
+	for _, signer := range signers {
+		if err := fips.CheckSigner(signer); err != nil {
+			logger.Log.Debugf("skip the SSH agent key %s: %v", ssh.FingerprintSHA256(signer.PublicKey()), err)
+			continue
+		}
+		allowed = append(allowed, signer)
+	}
+	return allowed
+}
+
+// trimBrackets removes the brackets of an IPv6 literal address, e.g. [fe80::1%eth0] is fe80::1%eth0.
+func trimBrackets(address string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(address, "["), "]")
+}
+
 func validateOptions(cfg Cfg) (Cfg, error) {
 	if len(cfg.Username) == 0 {
 		return cfg, errors.New("No username specified for SSH connection")
 	}
 
+	// an IPv6 address is dialed by net.JoinHostPort, which adds the brackets, and a link-local one keeps its zone
+	cfg.Address = trimBrackets(cfg.Address)
+	cfg.Bastion = trimBrackets(cfg.Bastion)
 	if len(cfg.Address) == 0 {
 		return cfg, errors.New("No address specified for SSH connection")
 	}
@@ -234,6 +288,13 @@ func (c *connection) Close() {
 	}
 }
 
+// closed returns whether the connection is closed.
+func (c *connection) closed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sshclient == nil
+}
+
 func (c *connection) session() (*ssh.Session, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -267,6 +328,8 @@ func (c *connection) PExec(cmd string, stdin io.Reader, stdout io.Writer, stderr
 		return 1, errors.Wrap(err, "failed to get SSH session")
 	}
 	defer sess.Close()
+	exporter.SSHSessions.WithLabelValues(host.GetName()).Inc()
+	defer exporter.SSHSessions.WithLabelValues(host.GetName()).Dec()
 
 	sess.Stdin = stdin
 	sess.Stdout = stdout
@@ -277,7 +340,7 @@ func (c *connection) PExec(cmd string, stdin io.Reader, stdout io.Writer, stderr
 	in, _ := sess.StdinPipe()
 	out, _ := sess.StdoutPipe()
 
-	err = sess.Start(strings.TrimSpace(cmd))
+	err = sess.Start(strings.TrimSpace(ShellCommand(host, cmd)))
 	if err != nil {
 		exitCode = -1
 		if exitErr, ok := err.(*ssh.ExitError); ok {
@@ -286,9 +349,10 @@ func (c *connection) PExec(cmd string, stdin io.Reader, stdout io.Writer, stderr
 	}
 
 	var (
-		output []byte
-		line   = ""
-		r      = bufio.NewReader(out)
+		output      []byte
+		line        = ""
+		r           = bufio.NewReader(out)
+		sudoRetried bool
 	)
 
 	for {
@@ -307,11 +371,21 @@ func (c *connection) PExec(cmd string, stdin io.Reader, stdout io.Writer, stderr
 		line += string(b)
 
 		if (strings.HasPrefix(line, "[sudo] password for ") || strings.HasPrefix(line, "Password")) && strings.HasSuffix(line, ": ") {
-			_, err = in.Write([]byte(host.GetPassword() + "\n"))
+			password, pwdErr := sudoPassword(host)
+			if sudoRetried || pwdErr != nil {
+				// interrupt sudo by ctrl-c in the pty instead of letting it wait for a password, or prompt again
+				// after a wrong password
+				_, _ = in.Write([]byte{3})
+				continue
+			}
+			_, err = in.Write([]byte(password + "\n"))
 			if err != nil {
 				break
 			}
 		}
+		if strings.HasPrefix(line, "Sorry, try again") {
+			sudoRetried = true
+		}
 	}
 	err = sess.Wait()
 	if err != nil {
@@ -326,11 +400,27 @@ func (c *connection) PExec(cmd string, stdin io.Reader, stdout io.Writer, stderr
 }
 
 func (c *connection) Exec(cmd string, host Host) (stdout string, code int, err error) {
+	if strings.HasPrefix(strings.TrimSpace(cmd), "sudo ") {
+		if err := c.checkSudo(host); err != nil {
+			return "", 1, err
+		}
+	}
+	if strings.HasPrefix(strings.TrimSpace(cmd), "doas ") {
+		if err := c.checkDoas(host); err != nil {
+			return "", 1, err
+		}
+	}
+	return c.exec(ShellCommand(host, cmd), host)
+}
+
+func (c *connection) exec(cmd string, host Host) (stdout string, code int, err error) {
 	sess, err := c.session()
 	if err != nil {
 		return "", 1, errors.Wrap(err, "failed to get SSH session")
 	}
 	defer sess.Close()
+	exporter.SSHSessions.WithLabelValues(host.GetName()).Inc()
+	defer exporter.SSHSessions.WithLabelValues(host.GetName()).Dec()
 
 	exitCode := 0
 
@@ -347,9 +437,10 @@ func (c *connection) Exec(cmd string, host Host) (stdout string, code int, err e
 	}
 
 	var (
-		output []byte
-		line   = ""
-		r      = bufio.NewReader(out)
+		output      []byte
+		line        = ""
+		r           = bufio.NewReader(out)
+		sudoRetried bool
 	)
 
 	for {
@@ -361,6 +452,7 @@ func (c *connection) Exec(cmd string, host Host) (stdout string, code int, err e
 		output = append(output, b)
 
 		if b == byte('\n') {
+			streamOutput(host, line)
 			line = ""
 			continue
 		}
@@ -368,11 +460,25 @@ func (c *connection) Exec(cmd string, host Host) (stdout string, code int, err e
 		line += string(b)
 
 		if (strings.HasPrefix(line, "[sudo] password for ") || strings.HasPrefix(line, "Password")) && strings.HasSuffix(line, ": ") {
-			_, err = in.Write([]byte(host.GetPassword() + "\n"))
+			password, pwdErr := sudoPassword(host)
+			if sudoRetried || pwdErr != nil {
+				// interrupt sudo by ctrl-c in the pty instead of letting it wait for a password, or prompt again
+				// after a wrong password
+				_, _ = in.Write([]byte{3})
+				continue
+			}
+			_, err = in.Write([]byte(password + "\n"))
 			if err != nil {
 				break
 			}
 		}
+		if strings.HasPrefix(line, "Sorry, try again") {
+			sudoRetried = true
+		}
+	}
+	// the last line of the output may not end with a newline
+	if line != "" {
+		streamOutput(host, line)
 	}
 	err = sess.Wait()
 	if err != nil {
@@ -395,7 +501,12 @@ func (c *connection) Fetch(local, remote string, host Host) error {
 	//defer srcFile.Close()
 
 	// Base64 encoding is performed on the contents of the file to prevent garbled code in the target file.
-	output, _, err := c.Exec(SudoPrefix(fmt.Sprintf("cat %s | base64 -w 0", remote)), host)
+	compression := c.transferCompression(host)
+	cat := fmt.Sprintf("cat %s", remote)
+	if compression != TransferCompressionNone {
+		cat = fmt.Sprintf("cat %s | %s -c", remote, compression)
+	}
+	output, _, err := c.Exec(SudoCommand(host, fmt.Sprintf("%s | base64 -w 0", cat)), host)
 	if err != nil {
 		return fmt.Errorf("open remote file failed %v, remote path: %s", err, remote)
 	}
@@ -412,14 +523,22 @@ func (c *connection) Fetch(local, remote string, host Host) error {
 	defer dstFile.Close()
 	// copy to local file
 	//_, err = srcFile.WriteTo(dstFile)
-	if base64Str, err := base64.StdEncoding.DecodeString(output); err != nil {
+	content, err := base64.StdEncoding.DecodeString(output)
+	if err != nil {
 		return err
-	} else {
-		if _, err = dstFile.WriteString(string(base64Str)); err != nil {
-			return err
-		}
 	}
-
+	if compression == TransferCompressionNone {
+		_, err = dstFile.Write(content)
+		return err
+	}
+	r, err := newDecompressor(bytes.NewReader(content), compression)
+	if err != nil {
+		return errors.Wrapf(err, "decompress remote file %s failed", remote)
+	}
+	defer r.Close()
+	if _, err := io.Copy(dstFile, r); err != nil {
+		return errors.Wrapf(err, "decompress remote file %s failed", remote)
+	}
 	return nil
 }
 
@@ -495,17 +614,14 @@ func (c *connection) copyDirToRemote(src, dst string, scrErr *scpErr, host Host)
 }
 
 func (c *connection) copyFileToRemote(src, dst string, host Host) error {
-	// check remote file md5 first
-	var (
-		srcMd5, dstMd5 string
-	)
-	srcMd5 = util.LocalMd5Sum(src)
-	if c.RemoteFileExist(dst, host) {
-		dstMd5 = c.RemoteMd5Sum(dst, host)
-		if srcMd5 == dstMd5 {
-			logger.Log.Debug("remote file %s md5 value is the same as local file, skip scp", dst)
-			return nil
-		}
+	// check remote file checksum first
+	srcSum, err := util.FileSHA256(src)
+	if err != nil {
+		return errors.Wrapf(err, "checksum local file %s failed", src)
+	}
+	if dstSum, err := c.Checksum(dst, host); err == nil && dstSum == srcSum {
+		logger.Log.Debugf("remote file %s checksum is the same as local file, skip scp", dst)
+		return nil
 	}
 
 	srcFile, err := os.Open(src)
@@ -513,30 +629,100 @@ func (c *connection) copyFileToRemote(src, dst string, host Host) error {
 		return err
 	}
 	defer srcFile.Close()
-	// the dst file mod will be 0666
-	dstFile, err := c.sftpclient.Create(dst)
-	if err != nil {
-		return err
-	}
 	fileStat, err := srcFile.Stat()
 	if err != nil {
 		return fmt.Errorf("get file stat failed %v", err)
 	}
-	if err := dstFile.Chmod(fileStat.Mode()); err != nil {
-		return fmt.Errorf("chmod remote file failed %v", err)
+	if compression := c.transferCompression(host); compression != TransferCompressionNone && fileStat.Size() >= minCompressSize {
+		if err := c.copyCompressedToRemote(srcFile, dst, fileStat.Mode(), compression, host); err != nil {
+			return err
+		}
+	} else {
+		// the dst file mod will be 0666
+		dstFile, err := c.sftpclient.Create(dst)
+		if err != nil {
+			return err
+		}
+		if err := dstFile.Chmod(fileStat.Mode()); err != nil {
+			_ = dstFile.Close()
+			return fmt.Errorf("chmod remote file failed %v", err)
+		}
+		_, err = io.Copy(c.limitWriter(dstFile), srcFile)
+		_ = dstFile.Close()
+		if err != nil {
+			return err
+		}
 	}
-	defer dstFile.Close()
-	_, err = io.Copy(dstFile, srcFile)
+	dstSum, err := c.Checksum(dst, host)
+	if err != nil {
+		return err
+	}
+	if srcSum != dstSum {
+		return fmt.Errorf("validate sha256 failed %s != %s", srcSum, dstSum)
+	}
+	return nil
+}
+
+// copyCompressedToRemote copies the src compressed to a temporary file next to the dst, which is decompressed into the
+// dst on the host.
+func (c *connection) copyCompressedToRemote(src io.Reader, dst string, mode os.FileMode, compression string, host Host) error {
+	tmp := fmt.Sprintf("%s.kk.%s", dst, compression)
+	tmpFile, err := c.sftpclient.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = c.sftpclient.Remove(tmp) }()
+	zw, err := newCompressor(c.limitWriter(tmpFile), compression)
 	if err != nil {
+		_ = tmpFile.Close()
 		return err
 	}
-	dstMd5 = c.RemoteMd5Sum(dst, host)
-	if srcMd5 != dstMd5 {
-		return fmt.Errorf("validate md5sum failed %s != %s", srcMd5, dstMd5)
+	_, err = io.Copy(zw, src)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "copy the compressed file %s failed", tmp)
+	}
+	if _, _, err := c.exec(decompressCommand(compression, tmp, dst), host); err != nil {
+		return errors.Wrapf(err, "decompress the file %s failed", tmp)
+	}
+	if err := c.sftpclient.Chmod(dst, mode); err != nil {
+		return fmt.Errorf("chmod remote file failed %v", err)
 	}
 	return nil
 }
 
+// limitWriter limits the writes to w by the rate limit of the transfers to the host.
+func (c *connection) limitWriter(w io.Writer) io.Writer {
+	if c.limiter == nil {
+		return w
+	}
+	return &rateLimitedWriter{ctx: c.ctx, w: w, limiter: c.limiter}
+}
+
+// transferCompression returns the compression of the transfers to the host. If the tool of the compression isn't on
+// the host, it falls back to gzip, and then to none.
+func (c *connection) transferCompression(host Host) string {
+	c.compressionOnce.Do(func() {
+		for _, compression := range compressionFallbacks(c.compression) {
+			if compression == TransferCompressionNone {
+				break
+			}
+			if _, _, err := c.exec(fmt.Sprintf("command -v %s", compression), host); err == nil {
+				c.resolvedCompression = compression
+				return
+			}
+			logger.ForHost(host.GetName()).Warnf("%s isn't installed on the host to decompress the transfers", compression)
+		}
+		c.resolvedCompression = TransferCompressionNone
+	})
+	return c.resolvedCompression
+}
+
 func (c *connection) RemoteMd5Sum(dst string, host Host) string {
 	cmd := fmt.Sprintf("md5sum %s | cut -d\" \" -f1", dst)
 	remoteMd5, _, err := c.Exec(cmd, host)
@@ -550,7 +736,7 @@ func (c *connection) RemoteFileExist(dst string, host Host) bool {
 	remoteFileName := path.Base(dst)
 	remoteFileDirName := path.Dir(dst)
 
-	remoteFileCommand := fmt.Sprintf(SudoPrefix("ls -l %s/%s 2>/dev/null |wc -l"), remoteFileDirName, remoteFileName)
+	remoteFileCommand := SudoCommand(host, fmt.Sprintf("ls -l %s/%s 2>/dev/null |wc -l", remoteFileDirName, remoteFileName))
 
 	out, _, err := c.Exec(remoteFileCommand, host)
 	defer func() {
@@ -588,7 +774,7 @@ func (c *connection) MkDirAll(path string, mode string, host Host) error {
 	if strings.Contains(path, common.TmpDir) {
 		mkDstDir = fmt.Sprintf("mkdir -p  %s && chmod -R  %s  %s || true", path, mode, common.TmpDir)
 	}
-	if _, _, err := c.Exec(SudoPrefix(mkDstDir), host); err != nil {
+	if _, _, err := c.Exec(SudoCommand(host, mkDstDir), host); err != nil {
 		return err
 	}
 
@@ -603,6 +789,40 @@ func (c *connection) Chmod(path string, mode os.FileMode) error {
 	return nil
 }
 
+// Stat stats the remote file by sftp, and with privilege if the user isn't permitted to, e.g. under /etc/kubernetes/pki.
+func (c *connection) Stat(remote string, host Host) (os.FileInfo, error) {
+	info, err := c.sftpclient.Stat(remote)
+	if err == nil {
+		return info, nil
+	}
+	if errors.Is(err, os.ErrNotExist) || host.GetShell() == ShellPowerShell {
+		return nil, errors.Wrapf(err, "stat %s", remote)
+	}
+	cmd, err := statCommand(host, remote)
+	if err != nil {
+		return nil, err
+	}
+	out, _, err := c.Exec(cmd, host)
+	if err != nil {
+		return nil, errors.Wrapf(err, "stat %s failed", remote)
+	}
+	return parseStat(remote, out)
+}
+
+func (c *connection) Exists(remote string, host Host) (bool, error) {
+	_, err := c.Stat(remote, host)
+	return exists(err)
+}
+
+// Checksum computes the sha256 of the remote file on the host, so the file isn't fetched.
+func (c *connection) Checksum(remote string, host Host) (string, error) {
+	out, _, err := c.Exec(checksumCommand(host, remote), host)
+	if err != nil {
+		return "", errors.Wrapf(err, "checksum %s failed", remote)
+	}
+	return parseChecksum(remote, out)
+}
+
 func SudoPrefix(cmd string) string {
 	return fmt.Sprintf("sudo -E /bin/bash -c \"%s\"", cmd)
 }
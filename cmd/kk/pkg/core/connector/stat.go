@@ -0,0 +1,198 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// absent is printed by the commands of Stat and Checksum when the remote file doesn't exist.
+const absent = "absent"
+
+// remoteFileInfo is the os.FileInfo of a remote file stat by a command.
+type remoteFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (i *remoteFileInfo) Name() string       { return i.name }
+func (i *remoteFileInfo) Size() int64        { return i.size }
+func (i *remoteFileInfo) Mode() os.FileMode  { return i.mode }
+func (i *remoteFileInfo) ModTime() time.Time { return i.modTime }
+func (i *remoteFileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *remoteFileInfo) Sys() interface{}   { return nil }
+
+// statCommand returns the command stat the remote file with privilege, following the symlinks. It prints the size,
+// the raw mode in hex and the modification time, or absent.
+func statCommand(host Host, remote string) (string, error) {
+	if host != nil && host.GetShell() == ShellPowerShell {
+		return "", errors.Errorf("the stat of the files by a command isn't supported by powershell on %s", host.GetName())
+	}
+	p := doubleQuoted.Replace(Quote(remote))
+	return SudoCommand(host, "if [ -e "+p+" ]; then stat -L -c '%s %f %Y' "+p+"; else echo "+absent+"; fi"), nil
+}
+
+// parseStat parses the output of the statCommand of the remote file.
+func parseStat(remote, out string) (os.FileInfo, error) {
+	out = strings.TrimSpace(out)
+	if out == absent {
+		return nil, errors.Wrapf(os.ErrNotExist, "stat %s", remote)
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 3 {
+		return nil, errors.Errorf("unexpected stat of %s: %q", remote, out)
+	}
+	size, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unexpected size of %s", remote)
+	}
+	raw, err := strconv.ParseUint(fields[1], 16, 32)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unexpected mode of %s", remote)
+	}
+	mtime, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unexpected modification time of %s", remote)
+	}
+	return &remoteFileInfo{name: path.Base(remote), size: size, mode: unixMode(uint32(raw)), modTime: time.Unix(mtime, 0)}, nil
+}
+
+// unixMode converts the st_mode of unix to an os.FileMode.
+func unixMode(raw uint32) os.FileMode {
+	mode := os.FileMode(raw & 0777)
+	switch raw & 0170000 {
+	case 0040000:
+		mode |= os.ModeDir
+	case 0120000:
+		mode |= os.ModeSymlink
+	case 0010000:
+		mode |= os.ModeNamedPipe
+	case 0140000:
+		mode |= os.ModeSocket
+	case 0020000:
+		mode |= os.ModeDevice | os.ModeCharDevice
+	case 0060000:
+		mode |= os.ModeDevice
+	}
+	if raw&04000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if raw&02000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if raw&01000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
+
+// checksumCommand returns the command computing the sha256 of the remote file with privilege, it prints the hex of
+// the sum, or absent.
+func checksumCommand(host Host, remote string) string {
+	if host != nil && host.GetShell() == ShellPowerShell {
+		p := QuotePowerShell(remote)
+		return "if (Test-Path -LiteralPath " + p + ") { (Get-FileHash -Algorithm SHA256 -LiteralPath " + p +
+			").Hash.ToLower() } else { '" + absent + "' }"
+	}
+	p := doubleQuoted.Replace(Quote(remote))
+	return SudoCommand(host, "if [ -e "+p+" ]; then sha256sum "+p+"; else echo "+absent+"; fi")
+}
+
+// parseChecksum parses the output of the checksumCommand of the remote file.
+func parseChecksum(remote, out string) (string, error) {
+	out = strings.TrimSpace(out)
+	if out == absent {
+		return "", errors.Wrapf(os.ErrNotExist, "checksum %s", remote)
+	}
+	fields := strings.Fields(out)
+	if len(fields) == 0 || len(fields[0]) != 64 || strings.Trim(fields[0], "0123456789abcdef") != "" {
+		return "", errors.Errorf("unexpected sha256 of %s: %q", remote, out)
+	}
+	return fields[0], nil
+}
+
+// exists returns whether the remote file of a Stat exists, by the error of the Stat.
+func exists(err error) (bool, error) {
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// statFile stats the remote file by the FileInspector of the connection, or by the statCommand.
+func statFile(conn Connection, remote string, host Host) (os.FileInfo, error) {
+	return statFileBy(conn, conn, remote, host)
+}
+
+// statFileBy stats the remote file by the FileInspector of the connection, or by the statCommand run by exec, e.g.
+// the layer wrapping the connection so the command is checked by it.
+func statFileBy(conn, exec Connection, remote string, host Host) (os.FileInfo, error) {
+	if i, ok := conn.(FileInspector); ok {
+		return i.Stat(remote, host)
+	}
+	cmd, err := statCommand(host, remote)
+	if err != nil {
+		return nil, err
+	}
+	out, _, err := exec.Exec(cmd, host)
+	if err != nil {
+		return nil, errors.Wrapf(err, "stat %s failed", remote)
+	}
+	return parseStat(remote, out)
+}
+
+// fileExists returns whether the remote file or dir exists, by the FileInspector of the connection or by statFile.
+func fileExists(conn Connection, remote string, host Host) (bool, error) {
+	return fileExistsBy(conn, conn, remote, host)
+}
+
+// fileExistsBy returns whether the remote file or dir exists, by the FileInspector of the connection or by
+// statFileBy.
+func fileExistsBy(conn, exec Connection, remote string, host Host) (bool, error) {
+	if i, ok := conn.(FileInspector); ok {
+		return i.Exists(remote, host)
+	}
+	_, err := statFileBy(conn, exec, remote, host)
+	return exists(err)
+}
+
+// fileChecksum computes the sha256 of the remote file by the FileInspector of the connection, or by the
+// checksumCommand.
+func fileChecksum(conn Connection, remote string, host Host) (string, error) {
+	return fileChecksumBy(conn, conn, remote, host)
+}
+
+// fileChecksumBy computes the sha256 of the remote file by the FileInspector of the connection, or by the
+// checksumCommand run by exec.
+func fileChecksumBy(conn, exec Connection, remote string, host Host) (string, error) {
+	if i, ok := conn.(FileInspector); ok {
+		return i.Checksum(remote, host)
+	}
+	out, _, err := exec.Exec(checksumCommand(host, remote), host)
+	if err != nil {
+		return "", errors.Wrapf(err, "checksum %s failed", remote)
+	}
+	return parseChecksum(remote, out)
+}
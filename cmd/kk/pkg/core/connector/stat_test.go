@@ -0,0 +1,112 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestParseStat(t *testing.T) {
+	info, err := parseStat("/etc/kubernetes/pki/ca.key", "1675 8180 1700000000\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name() != "ca.key" || info.Size() != 1675 || info.Mode() != 0600 || info.ModTime().Unix() != 1700000000 {
+		t.Errorf("parseStat() = %+v", info)
+	}
+
+	info, err = parseStat("/tmp", "4096 43ff 1700000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.IsDir() || info.Mode()&os.ModeSticky == 0 || info.Mode().Perm() != 0777 {
+		t.Errorf("parseStat() mode = %v", info.Mode())
+	}
+
+	if _, err := parseStat("/nope", "absent"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("parseStat() error = %v, want os.ErrNotExist", err)
+	}
+	if _, err := parseStat("/x", "stat: cannot stat"); err == nil {
+		t.Error("parseStat() error = nil for an unexpected output")
+	}
+}
+
+func TestParseChecksum(t *testing.T) {
+	sum := fmt.Sprintf("%x", sha256.Sum256([]byte("kubekey")))
+	tests := []struct {
+		name    string
+		out     string
+		want    string
+		wantErr error
+	}{
+		{name: "sha256sum", out: sum + "  /usr/local/bin/kubelet\n", want: sum},
+		{name: "powershell", out: sum, want: sum},
+		{name: "absent", out: "absent", wantErr: os.ErrNotExist},
+		{name: "md5", out: "d41d8cd98f00b204e9800998ecf8427e  /x", wantErr: errors.New("unexpected")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChecksum("/usr/local/bin/kubelet", tt.out)
+			if (err != nil) != (tt.wantErr != nil) || (tt.wantErr == os.ErrNotExist && !errors.Is(err, os.ErrNotExist)) {
+				t.Fatalf("parseChecksum() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseChecksum() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatCommands(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "it's $HOME")
+	if err := os.WriteFile(file, []byte("kubekey"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	conn := &localConnection{}
+
+	info, err := statFile(conn, file, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 7 || info.Mode() != 0640 {
+		t.Errorf("statFile() = %+v", info)
+	}
+	sum, err := fileChecksum(conn, file, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprintf("%x", sha256.Sum256([]byte("kubekey"))); sum != want {
+		t.Errorf("fileChecksum() = %s, want %s", sum, want)
+	}
+
+	if _, err := statFile(conn, filepath.Join(dir, "absent"), nil); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("statFile() error = %v, want os.ErrNotExist", err)
+	}
+	if _, err := fileChecksum(conn, filepath.Join(dir, "absent"), nil); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("fileChecksum() error = %v, want os.ErrNotExist", err)
+	}
+	if ok, err := fileExists(conn, dir, nil); !ok || err != nil {
+		t.Errorf("fileExists() = %v, %v", ok, err)
+	}
+}
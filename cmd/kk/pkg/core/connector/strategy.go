@@ -0,0 +1,92 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The execution strategies of the task modules.
+const (
+	// LinearStrategy runs each task on all the hosts before the next task.
+	LinearStrategy = "linear"
+	// FreeStrategy lets each host run through the remote tasks without waiting for the other hosts, the local tasks
+	// wait for all the hosts.
+	FreeStrategy = "free"
+	// RollingStrategy runs all the tasks of the module on a batch of hosts before the next batch.
+	RollingStrategy = "rolling"
+)
+
+// Strategy is how the tasks of a module are executed on the hosts.
+type Strategy struct {
+	// Name is linear, free or rolling, an empty name is linear.
+	Name string
+	// Serial is the batch size of the rolling strategy, a number of hosts or a percentage of the hosts, e.g. "30%".
+	// All the hosts are in a batch if it is empty.
+	Serial string
+	// MaxFailPercentage is the percentage of the hosts, of the batch in the rolling strategy, allowed to fail. The
+	// failed hosts are removed and the run continues until it is exceeded. Any failure aborts the run if it is 0.
+	MaxFailPercentage int
+}
+
+// Validate checks the name, serial and max fail percentage of the strategy.
+func (s Strategy) Validate() error {
+	switch s.Name {
+	case "", LinearStrategy, FreeStrategy, RollingStrategy:
+	default:
+		return errors.Errorf("unknown strategy %s, it must be %s, %s or %s", s.Name, LinearStrategy, FreeStrategy, RollingStrategy)
+	}
+	if _, err := s.BatchSize(1); err != nil {
+		return err
+	}
+	if s.MaxFailPercentage < 0 || s.MaxFailPercentage > 100 {
+		return errors.Errorf("invalid max fail percentage %d, it must be between 0 and 100", s.MaxFailPercentage)
+	}
+	return nil
+}
+
+// BatchSize returns the number of hosts in a batch of the rolling strategy, out of the total number of hosts.
+func (s Strategy) BatchSize(total int) (int, error) {
+	serial := strings.TrimSpace(s.Serial)
+	if serial == "" {
+		return total, nil
+	}
+	percentage := strings.HasSuffix(serial, "%")
+	n, err := strconv.Atoi(strings.TrimSuffix(serial, "%"))
+	if err != nil || n <= 0 || (percentage && n > 100) {
+		return 0, errors.Errorf("invalid serial %s, it must be a positive number of hosts or a percentage", s.Serial)
+	}
+	if percentage {
+		n = int(math.Ceil(float64(total) * float64(n) / 100))
+	}
+	if n > total {
+		n = total
+	}
+	return n, nil
+}
+
+// Tolerates returns whether the failed hosts are within the max fail percentage of the total number of hosts.
+func (s Strategy) Tolerates(failed, total int) bool {
+	if failed == 0 {
+		return true
+	}
+	return failed < total && failed*100 <= s.MaxFailPercentage*total
+}
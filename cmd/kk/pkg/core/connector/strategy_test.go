@@ -0,0 +1,72 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import "testing"
+
+func TestStrategy_BatchSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		serial  string
+		total   int
+		want    int
+		wantErr bool
+	}{
+		{name: "all hosts", serial: "", total: 5, want: 5},
+		{name: "number", serial: "2", total: 5, want: 2},
+		{name: "number larger than hosts", serial: "10", total: 5, want: 5},
+		{name: "percentage rounds up", serial: "30%", total: 5, want: 2},
+		{name: "invalid", serial: "two", total: 5, wantErr: true},
+		{name: "zero", serial: "0", total: 5, wantErr: true},
+		{name: "percentage over 100", serial: "150%", total: 5, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Strategy{Serial: tt.serial}.BatchSize(tt.total)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BatchSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("BatchSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrategy_Tolerates(t *testing.T) {
+	tests := []struct {
+		name              string
+		maxFailPercentage int
+		failed            int
+		total             int
+		want              bool
+	}{
+		{name: "no failure", failed: 0, total: 4, want: true},
+		{name: "any failure aborts", failed: 1, total: 4, want: false},
+		{name: "within", maxFailPercentage: 25, failed: 1, total: 4, want: true},
+		{name: "exceeded", maxFailPercentage: 25, failed: 2, total: 4, want: false},
+		{name: "all hosts failed", maxFailPercentage: 100, failed: 4, total: 4, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Strategy{MaxFailPercentage: tt.maxFailPercentage}
+			if got := s.Tolerates(tt.failed, tt.total); got != tt.want {
+				t.Errorf("Tolerates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+// SudoPasswordEnv is the env of the sudo password, which is used instead of prompting for it, e.g. in CI.
+const SudoPasswordEnv = "KUBEKEY_SUDO_PASSWORD"
+
+// sudoKey is the login user on a host, the same user can have different passwords on different hosts.
+type sudoKey struct {
+	host string
+	user string
+}
+
+// sudoPasswords caches the sudo password of each login user on each host, so it is prompted once per run instead of
+// once per task.
+var sudoPasswords = struct {
+	mu        sync.Mutex
+	passwords map[sudoKey]string
+}{passwords: make(map[sudoKey]string)}
+
+// sudoPassword returns the password answering the sudo prompt on the host. It is the password of the host if it is
+// set, otherwise it is read from SudoPasswordEnv or prompted on the terminal once for each user on each host.
+func sudoPassword(host Host) (string, error) {
+	if host.GetPassword() != "" {
+		return host.GetPassword(), nil
+	}
+
+	key := sudoKey{host: host.GetName(), user: host.GetUser()}
+	sudoPasswords.mu.Lock()
+	defer sudoPasswords.mu.Unlock()
+	if password, ok := sudoPasswords.passwords[key]; ok {
+		return password, nil
+	}
+
+	password, ok := os.LookupEnv(SudoPasswordEnv)
+	if !ok {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return "", errors.Errorf("sudo requires a password for %s on %s, set the password of the host or the env %s",
+				host.GetUser(), host.GetName(), SudoPasswordEnv)
+		}
+		fmt.Fprintf(os.Stderr, "[sudo] password for %s on %s: ", host.GetUser(), host.GetName())
+		b, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to read the sudo password")
+		}
+		password = string(b)
+	}
+	if logger.Log != nil {
+		logger.Log.Redactor.AddSecrets(password)
+	}
+	sudoPasswords.passwords[key] = password
+	return password, nil
+}
+
+// sudoChecker is implemented by the connections which detect whether sudo needs a password.
+type sudoChecker interface {
+	SudoNoPasswd(host Host) (bool, error)
+}
+
+// checkSudo detects whether the login user has NOPASSWD sudo once for the connection. Otherwise, the sudo password
+// is validated once, so a missing or wrong password fails the first sudo command instead of each task.
+func (c *connection) checkSudo(host Host) error {
+	c.sudoOnce.Do(func() {
+		if _, _, err := c.exec("sudo -n true", host); err == nil {
+			c.sudoNoPasswd = true
+			return
+		}
+		if _, err := sudoPassword(host); err != nil {
+			c.sudoErr = err
+			return
+		}
+		if _, _, err := c.exec("sudo -v", host); err != nil {
+			c.sudoErr = errors.Wrapf(err, "failed to authenticate sudo for %s on %s", host.GetUser(), host.GetName())
+		}
+	})
+	return c.sudoErr
+}
+
+// SudoNoPasswd returns whether the login user can run sudo without a password on the host.
+func (c *connection) SudoNoPasswd(host Host) (bool, error) {
+	if err := c.checkSudo(host); err != nil {
+		return false, err
+	}
+	return c.sudoNoPasswd, nil
+}
+
+// checkDoas verifies once for the connection that doas runs without a password, since kk can't answer its prompt.
+func (c *connection) checkDoas(host Host) error {
+	c.doasOnce.Do(func() {
+		if _, _, err := c.exec("doas -n true", host); err != nil {
+			c.doasErr = errors.Errorf("doas requires a password for %s on %s, permit it with nopass in /etc/doas.conf",
+				host.GetUser(), host.GetName())
+		}
+	})
+	return c.doasErr
+}
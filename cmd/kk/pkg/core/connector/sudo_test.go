@@ -0,0 +1,53 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import "testing"
+
+func TestSudoPassword(t *testing.T) {
+	defer func() {
+		sudoPasswords.passwords = make(map[sudoKey]string)
+	}()
+
+	tests := []struct {
+		name string
+		env  string
+		host *BaseHost
+		want string
+	}{
+		{name: "password of the host", env: "env-secret", host: &BaseHost{Name: "node1", User: "ubuntu", Password: "host-secret"}, want: "host-secret"},
+		{name: "env", env: "env-secret", host: &BaseHost{Name: "node2", User: "ubuntu"}, want: "env-secret"},
+		{name: "cached for the user on the host", env: "other-secret", host: &BaseHost{Name: "node2", User: "ubuntu"}, want: "env-secret"},
+		{name: "not shared by the hosts", env: "other-secret", host: &BaseHost{Name: "node3", User: "ubuntu"}, want: "other-secret"},
+		{name: "not shared by the users", env: "admin-secret", host: &BaseHost{Name: "node2", User: "admin"}, want: "admin-secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(SudoPasswordEnv, tt.env)
+			got, err := sudoPassword(tt.host)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("sudoPassword() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+	if len(sudoPasswords.passwords) != 3 {
+		t.Errorf("%d sudo passwords are cached, want 3", len(sudoPasswords.passwords))
+	}
+}
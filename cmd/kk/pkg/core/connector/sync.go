@@ -0,0 +1,341 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+// syncBatch is the number of the files changed by a command of a sync, so the commands don't exceed the limit of the
+// arguments.
+const syncBatch = 100
+
+// SyncOptions select the files of a directory sync and the files removed from the destination.
+type SyncOptions struct {
+	// Include are the globs of the paths synced relative to the dir, all the files are synced if it is empty. A glob
+	// without a slash matches the base name of the files, ** matches across the dirs, e.g. *.yaml or charts/**.
+	Include []string
+	// Exclude are the globs of the paths not synced, they take precedence over Include.
+	Exclude []string
+	// Delete removes the files of the destination which aren't in the source, only the files matching the globs.
+	Delete bool
+}
+
+// SyncResult is the paths relative to the dir changed by a sync.
+type SyncResult struct {
+	// Copied are the files copied, since they were missing or their checksum differed.
+	Copied []string
+	// Chmoded are the files whose content was the same but not their permissions.
+	Chmoded []string
+	// Deleted are the files removed from the destination.
+	Deleted []string
+	// Unchanged is the number of the files already in sync.
+	Unchanged int
+}
+
+func (r *SyncResult) String() string {
+	return fmt.Sprintf("%d copied, %d chmoded, %d deleted, %d unchanged", len(r.Copied), len(r.Chmoded), len(r.Deleted), r.Unchanged)
+}
+
+// syncFile is a file of a synced dir, by its sha256 and its permissions.
+type syncFile struct {
+	sum  string
+	mode os.FileMode
+}
+
+// syncGlob is a glob of the sync, the globs without a slash match the base name.
+type syncGlob struct {
+	re   *regexp.Regexp
+	base bool
+}
+
+type syncFilter struct {
+	include, exclude []syncGlob
+}
+
+func (o SyncOptions) filter() (*syncFilter, error) {
+	f := &syncFilter{}
+	for _, globs := range []struct {
+		globs []string
+		to    *[]syncGlob
+	}{{o.Include, &f.include}, {o.Exclude, &f.exclude}} {
+		for _, glob := range globs.globs {
+			if glob == "" || path.IsAbs(glob) {
+				return nil, errors.Errorf("invalid glob %q of the sync, it must be relative to the dir", glob)
+			}
+			*globs.to = append(*globs.to, syncGlob{re: globRegexp(glob), base: !strings.Contains(glob, "/")})
+		}
+	}
+	return f, nil
+}
+
+func (f *syncFilter) match(rel string) bool {
+	matches := func(globs []syncGlob) bool {
+		for _, g := range globs {
+			if g.re.MatchString(rel) || g.base && g.re.MatchString(path.Base(rel)) {
+				return true
+			}
+		}
+		return false
+	}
+	return (len(f.include) == 0 || matches(f.include)) && !matches(f.exclude)
+}
+
+// localFiles returns the regular files under the local dir matching the filter, by their slash path relative to the
+// dir.
+func localFiles(dir string, filter *syncFilter) (map[string]syncFile, error) {
+	files := make(map[string]syncFile)
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !filter.match(rel) {
+			return nil
+		}
+		sum, err := util.FileSHA256(p)
+		if err != nil {
+			return errors.Wrapf(err, "checksum local file %s failed", p)
+		}
+		files[rel] = syncFile{sum: sum, mode: info.Mode().Perm()}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// remoteFilesCommand returns the command listing the permissions and the sha256 of the regular files under the remote
+// dir, in one round trip. It prints absent if the dir doesn't exist.
+func remoteFilesCommand(host Host, dir string) (string, error) {
+	if host != nil && host.GetShell() == ShellPowerShell {
+		return "", errors.Errorf("the sync of the dirs isn't supported by powershell on %s", host.GetName())
+	}
+	return fmt.Sprintf("if [ -d %s ]; then cd %s && find . -type f -exec stat -c '%%a %%n' {} + -exec sha256sum {} +; else echo %s; fi",
+		Quote(dir), Quote(dir), absent), nil
+}
+
+// parseRemoteFiles parses the output of the remoteFilesCommand. The files whose name is escaped by sha256sum, e.g. with
+// a newline, have no sum and are always copied.
+func parseRemoteFiles(out string, filter *syncFilter) map[string]syncFile {
+	files := make(map[string]syncFile)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), " ", 2)
+		if len(fields) != 2 || !strings.HasPrefix(strings.TrimLeft(fields[1], " *"), "./") {
+			continue
+		}
+		rel := strings.TrimPrefix(strings.TrimLeft(fields[1], " *"), "./")
+		if !filter.match(rel) {
+			continue
+		}
+		f := files[rel]
+		if len(fields[0]) == 64 {
+			f.sum = fields[0]
+		} else if mode, err := strconv.ParseUint(fields[0], 8, 32); err == nil {
+			f.mode = os.FileMode(mode).Perm()
+		} else {
+			continue
+		}
+		files[rel] = f
+	}
+	return files
+}
+
+// diff compares the files of the source with the files of the destination.
+func (r *SyncResult) diff(src, dst map[string]syncFile, del bool) {
+	for _, rel := range sortedFiles(src) {
+		d, ok := dst[rel]
+		switch {
+		case !ok || d.sum != src[rel].sum:
+			r.Copied = append(r.Copied, rel)
+		case d.mode != src[rel].mode:
+			r.Chmoded = append(r.Chmoded, rel)
+		default:
+			r.Unchanged++
+		}
+	}
+	if del {
+		for _, rel := range sortedFiles(dst) {
+			if _, ok := src[rel]; !ok {
+				r.Deleted = append(r.Deleted, rel)
+			}
+		}
+	}
+}
+
+func sortedFiles(files map[string]syncFile) []string {
+	rels := make([]string, 0, len(files))
+	for rel := range files {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+	return rels
+}
+
+// remoteFiles lists the files of the remote dir with privilege, the error is os.ErrNotExist if it doesn't exist.
+func (r *Runner) remoteFiles(dir string, filter *syncFilter) (map[string]syncFile, error) {
+	cmd, err := remoteFilesCommand(r.Host, dir)
+	if err != nil {
+		return nil, err
+	}
+	out, err := r.SudoCmd(doubleQuoted.Replace(cmd), false)
+	if err != nil {
+		return nil, errors.Wrapf(err, "list the remote dir %s failed", dir)
+	}
+	if strings.TrimSpace(out) == absent {
+		return nil, errors.Wrapf(os.ErrNotExist, "list the remote dir %s", dir)
+	}
+	return parseRemoteFiles(out, filter), nil
+}
+
+// sudoBatch runs the command of each of the remote files with privilege, in batches.
+func (r *Runner) sudoBatch(rels []string, command func(rel string) string) error {
+	for i := 0; i < len(rels); i += syncBatch {
+		batch := rels[i:]
+		if len(batch) > syncBatch {
+			batch = batch[:syncBatch]
+		}
+		cmds := make([]string, 0, len(batch))
+		for _, rel := range batch {
+			cmds = append(cmds, command(rel))
+		}
+		if _, err := r.SudoCmd(doubleQuoted.Replace(strings.Join(cmds, " && ")), false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SyncPush syncs the local dir to the remote dir with privilege. Only the files whose sha256 differs are copied, with
+// the permissions of the local files, and the permissions of the others are fixed.
+func (r *Runner) SyncPush(local, remote string, opts SyncOptions) (*SyncResult, error) {
+	filter, err := opts.filter()
+	if err != nil {
+		return nil, err
+	}
+	src, err := localFiles(local, filter)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := r.remoteFiles(remote, filter)
+	if errors.Is(err, os.ErrNotExist) {
+		dst = map[string]syncFile{}
+	} else if err != nil {
+		return nil, err
+	}
+	result := &SyncResult{}
+	result.diff(src, dst, opts.Delete)
+
+	// the files are staged in the tmp dir as the user, and installed with privilege
+	staging := path.Join(common.TmpDir, "sync", path.Base(remote))
+	for _, rel := range result.Copied {
+		if err := r.Scp(filepath.Join(local, filepath.FromSlash(rel)), path.Join(staging, rel)); err != nil {
+			return nil, err
+		}
+	}
+	install := func(rel string) string {
+		return fmt.Sprintf("install -D -m %o %s %s", src[rel].mode, Quote(path.Join(staging, rel)), Quote(path.Join(remote, rel)))
+	}
+	if err := r.sudoBatch(result.Copied, install); err != nil {
+		return nil, errors.Wrapf(err, "install the files of %s failed", remote)
+	}
+	if len(result.Copied) > 0 {
+		if _, err := r.SudoCmd(fmt.Sprintf("rm -rf %s", doubleQuoted.Replace(Quote(staging))), false); err != nil {
+			return nil, err
+		}
+	}
+	chmod := func(rel string) string {
+		return fmt.Sprintf("chmod %o %s", src[rel].mode, Quote(path.Join(remote, rel)))
+	}
+	if err := r.sudoBatch(result.Chmoded, chmod); err != nil {
+		return nil, errors.Wrapf(err, "chmod the files of %s failed", remote)
+	}
+	rm := func(rel string) string {
+		return fmt.Sprintf("rm -f %s", Quote(path.Join(remote, rel)))
+	}
+	if err := r.sudoBatch(result.Deleted, rm); err != nil {
+		return nil, errors.Wrapf(err, "delete the files of %s failed", remote)
+	}
+	r.Log().Debugf("sync %s to remote %s: %s", local, remote, result)
+	return result, nil
+}
+
+// SyncPull syncs the remote dir to the local dir, e.g. to collect the logs. Only the files whose sha256 differs are
+// fetched, with the permissions of the remote files.
+func (r *Runner) SyncPull(local, remote string, opts SyncOptions) (*SyncResult, error) {
+	filter, err := opts.filter()
+	if err != nil {
+		return nil, err
+	}
+	src, err := r.remoteFiles(remote, filter)
+	if err != nil {
+		return nil, err
+	}
+	dst := map[string]syncFile{}
+	if _, err := os.Stat(local); err == nil {
+		if dst, err = localFiles(local, filter); err != nil {
+			return nil, err
+		}
+	}
+	result := &SyncResult{}
+	result.diff(src, dst, opts.Delete)
+
+	for _, rel := range result.Copied {
+		p := filepath.Join(local, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return nil, err
+		}
+		if err := r.Fetch(p, path.Join(remote, rel)); err != nil {
+			return nil, err
+		}
+		if err := os.Chmod(p, src[rel].mode); err != nil {
+			return nil, err
+		}
+	}
+	for _, rel := range result.Chmoded {
+		if err := os.Chmod(filepath.Join(local, filepath.FromSlash(rel)), src[rel].mode); err != nil {
+			return nil, err
+		}
+	}
+	for _, rel := range result.Deleted {
+		if err := os.Remove(filepath.Join(local, filepath.FromSlash(rel))); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	r.Log().Debugf("sync remote %s to %s: %s", remote, local, result)
+	return result, nil
+}
@@ -0,0 +1,117 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+func TestSyncFilter(t *testing.T) {
+	filter, err := SyncOptions{Include: []string{"*.yaml", "charts/**"}, Exclude: []string{"charts/tmp/**", "*.bak"}}.filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rel, want := range map[string]bool{
+		"addon.yaml":          true,
+		"addons/calico.yaml":  true,
+		"charts/a/Chart.lock": true,
+		"charts/tmp/x.yaml":   false,
+		"charts/a/values.bak": false,
+		"README.md":           false,
+	} {
+		if got := filter.match(rel); got != want {
+			t.Errorf("match(%q) = %v, want %v", rel, got, want)
+		}
+	}
+	if _, err := (SyncOptions{Exclude: []string{"/etc/**"}}).filter(); err == nil {
+		t.Error("filter() error = nil for an absolute glob")
+	}
+}
+
+func TestSync(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	write := func(p string, content string, mode os.FileMode) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(p, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	local, remote := t.TempDir(), filepath.Join(t.TempDir(), "it's addons")
+	write(filepath.Join(local, "calico.yaml"), "kind: DaemonSet", 0644)
+	write(filepath.Join(local, "charts", "values.yaml"), "replicas: 1", 0600)
+	write(filepath.Join(local, "charts", "tmp", "render.yaml"), "tmp", 0644)
+	write(filepath.Join(local, "README.md"), "readme", 0644)
+	runner := &Runner{Conn: &localConnection{}, Host: NewHost()}
+	opts := SyncOptions{Include: []string{"*.yaml"}, Exclude: []string{"charts/tmp/**"}, Delete: true}
+
+	result, err := runner.SyncPush(local, remote, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"calico.yaml", "charts/values.yaml"}; !reflect.DeepEqual(result.Copied, want) {
+		t.Errorf("SyncPush() copied %v, want %v", result.Copied, want)
+	}
+	if info, err := os.Stat(filepath.Join(remote, "charts", "values.yaml")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("the pushed file is %v, %v, want the permissions 0600", info, err)
+	}
+
+	write(filepath.Join(remote, "calico.yaml"), "kind: DaemonSet", 0640)
+	write(filepath.Join(remote, "removed.yaml"), "removed", 0644)
+	write(filepath.Join(remote, "kept.txt"), "not synced", 0644)
+	result, err = runner.SyncPush(local, remote, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result, &SyncResult{Chmoded: []string{"calico.yaml"}, Deleted: []string{"removed.yaml"}, Unchanged: 1}) {
+		t.Errorf("SyncPush() = %+v, want the file chmoded and the file removed", result)
+	}
+	if _, err := os.Stat(filepath.Join(remote, "kept.txt")); err != nil {
+		t.Errorf("the file not synced is removed: %v", err)
+	}
+
+	pulled := filepath.Join(t.TempDir(), "logs")
+	result, err = runner.SyncPull(pulled, remote, SyncOptions{Exclude: []string{"*.txt"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"calico.yaml", "charts/values.yaml"}; !reflect.DeepEqual(result.Copied, want) {
+		t.Errorf("SyncPull() copied %v, want %v", result.Copied, want)
+	}
+	if info, err := os.Stat(filepath.Join(pulled, "calico.yaml")); err != nil || info.Mode().Perm() != 0644 {
+		t.Errorf("the pulled file is %v, %v, want the permissions 0644", info, err)
+	}
+
+	if _, err := runner.SyncPull(pulled, filepath.Join(remote, "absent"), SyncOptions{}); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("SyncPull() error = %v, want os.ErrNotExist", err)
+	}
+	if _, err := runner.SyncPush(filepath.Join(local, "absent"), remote, SyncOptions{Delete: true}); err == nil {
+		t.Error("SyncPush() error = nil for the local dir which doesn't exist")
+	}
+}
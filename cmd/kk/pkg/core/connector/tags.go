@@ -0,0 +1,54 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import "strings"
+
+// AlwaysTag is the tag of the modules which run unless they are skipped by the tag explicitly, e.g. the ones which
+// connect to the hosts and gather the facts the other modules depend on.
+const AlwaysTag = "always"
+
+// TagFilter selects the modules of the pipelines to run by their tags, which are their explicit tags and their names.
+type TagFilter struct {
+	// Tags are the tags of the modules to run, all the modules run if it is empty.
+	Tags []string
+	// SkipTags are the tags of the modules not to run, they take precedence over the tags.
+	SkipTags []string
+}
+
+// Selects returns whether the module of the tags is run.
+func (f TagFilter) Selects(tags []string) bool {
+	if matchTags(f.SkipTags, tags) {
+		return false
+	}
+	if len(f.Tags) == 0 {
+		return true
+	}
+	return matchTags(f.Tags, tags) || matchTags([]string{AlwaysTag}, tags)
+}
+
+// matchTags returns whether any of the tags is in the filter, the tags are matched case-insensitively.
+func matchTags(filter, tags []string) bool {
+	for _, f := range filter {
+		for _, tag := range tags {
+			if strings.EqualFold(f, tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
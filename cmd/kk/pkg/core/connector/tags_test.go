@@ -0,0 +1,44 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import "testing"
+
+func TestTagFilter_Selects(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter TagFilter
+		tags   []string
+		want   bool
+	}{
+		{name: "no filter", tags: []string{"network", "DeployNetworkPluginModule"}, want: true},
+		{name: "tag", filter: TagFilter{Tags: []string{"network"}}, tags: []string{"network", "DeployNetworkPluginModule"}, want: true},
+		{name: "module name", filter: TagFilter{Tags: []string{"deploynetworkpluginmodule"}}, tags: []string{"network", "DeployNetworkPluginModule"}, want: true},
+		{name: "other tag", filter: TagFilter{Tags: []string{"certs"}}, tags: []string{"network", "DeployNetworkPluginModule"}, want: false},
+		{name: "always", filter: TagFilter{Tags: []string{"certs"}}, tags: []string{AlwaysTag, "GreetingsModule"}, want: true},
+		{name: "skip tag", filter: TagFilter{SkipTags: []string{"network"}}, tags: []string{"network", "DeployNetworkPluginModule"}, want: false},
+		{name: "skip takes precedence", filter: TagFilter{Tags: []string{"etcd"}, SkipTags: []string{"certs"}}, tags: []string{"etcd", "certs", "CertsModule"}, want: false},
+		{name: "skip always", filter: TagFilter{SkipTags: []string{AlwaysTag}}, tags: []string{AlwaysTag, "GreetingsModule"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Selects(tt.tags); got != tt.want {
+				t.Errorf("Selects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
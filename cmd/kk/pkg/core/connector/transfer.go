@@ -0,0 +1,153 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// The compressions of the files copied to the hosts, which are decompressed by the tool of the same name on the host.
+const (
+	TransferCompressionNone = "none"
+	TransferCompressionGzip = "gzip"
+	TransferCompressionZstd = "zstd"
+)
+
+// TransferCompressions are the compressions of the transfers which can be set.
+var TransferCompressions = []string{TransferCompressionNone, TransferCompressionGzip, TransferCompressionZstd}
+
+// minCompressSize is the size of the smallest file compressed, the smaller ones aren't worth the extra command.
+const minCompressSize = 64 << 10
+
+// maxRateLimitBurst is the largest chunk written at once under the rate limit, which keeps the transfer smooth.
+const maxRateLimitBurst = 32 << 10
+
+// ParseTransferRateLimit parses the rate limit of the transfers to a host in bytes per second, e.g. 10Mi or 500k.
+// The empty limit is unlimited.
+func ParseTransferRateLimit(limit string) (int64, error) {
+	if limit == "" {
+		return 0, nil
+	}
+	q, err := resource.ParseQuantity(limit)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid transfer rate limit %q", limit)
+	}
+	if q.Sign() <= 0 {
+		return 0, errors.Errorf("invalid transfer rate limit %q, it must be positive", limit)
+	}
+	return q.Value(), nil
+}
+
+// ValidateTransferCompression validates the compression of the transfers to a host, the empty one is none.
+func ValidateTransferCompression(compression string) error {
+	if compression == "" {
+		return nil
+	}
+	for _, c := range TransferCompressions {
+		if c == compression {
+			return nil
+		}
+	}
+	return errors.Errorf("unknown transfer compression %q, it must be one of %v", compression, TransferCompressions)
+}
+
+// compressionFallbacks are the compressions tried in turn when the decompressor of the one set isn't on the host.
+func compressionFallbacks(compression string) []string {
+	switch compression {
+	case TransferCompressionZstd:
+		return []string{TransferCompressionZstd, TransferCompressionGzip, TransferCompressionNone}
+	case TransferCompressionGzip:
+		return []string{TransferCompressionGzip, TransferCompressionNone}
+	}
+	return []string{TransferCompressionNone}
+}
+
+func newCompressor(w io.Writer, compression string) (io.WriteCloser, error) {
+	switch compression {
+	case TransferCompressionGzip:
+		return gzip.NewWriter(w), nil
+	case TransferCompressionZstd:
+		return zstd.NewWriter(w)
+	}
+	return nil, errors.Errorf("unknown transfer compression %q", compression)
+}
+
+func newDecompressor(r io.Reader, compression string) (io.ReadCloser, error) {
+	switch compression {
+	case TransferCompressionGzip:
+		return gzip.NewReader(r)
+	case TransferCompressionZstd:
+		d, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	}
+	return nil, errors.Errorf("unknown transfer compression %q", compression)
+}
+
+// decompressCommand decompresses the src on the host into the dst.
+func decompressCommand(compression, src, dst string) string {
+	return fmt.Sprintf("%s -dc %s > %s", compression, src, dst)
+}
+
+// newRateLimiter returns the limiter of the transfers of bytesPerSecond, or nil if they are unlimited.
+func newRateLimiter(bytesPerSecond int64) *rate.Limiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	burst := maxRateLimitBurst
+	if bytesPerSecond < int64(burst) {
+		burst = int(bytesPerSecond)
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), burst)
+}
+
+// rateLimitedWriter writes to w no faster than the limiter allows, the limiter is shared by the transfers to a host.
+type rateLimitedWriter struct {
+	ctx     context.Context
+	w       io.Writer
+	limiter *rate.Limiter
+}
+
+func (r *rateLimitedWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := len(p)
+		if burst := r.limiter.Burst(); n > burst {
+			n = burst
+		}
+		if err := r.limiter.WaitN(r.ctx, n); err != nil {
+			return written, err
+		}
+		m, err := r.w.Write(p[:n])
+		written += m
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
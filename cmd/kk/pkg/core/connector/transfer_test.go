@@ -0,0 +1,110 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package connector
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTransferRateLimit(t *testing.T) {
+	tests := []struct {
+		limit   string
+		want    int64
+		wantErr bool
+	}{
+		{limit: "", want: 0},
+		{limit: "10Mi", want: 10 << 20},
+		{limit: "500k", want: 500000},
+		{limit: "1024", want: 1024},
+		{limit: "0", wantErr: true},
+		{limit: "-1Mi", wantErr: true},
+		{limit: "10MB/s", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.limit, func(t *testing.T) {
+			got, err := ParseTransferRateLimit(tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTransferRateLimit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTransferRateLimit() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompressorRoundTrip(t *testing.T) {
+	content := strings.Repeat("kubekey artifact ", 10000)
+	for _, compression := range []string{TransferCompressionGzip, TransferCompressionZstd} {
+		t.Run(compression, func(t *testing.T) {
+			var compressed bytes.Buffer
+			w, err := newCompressor(&compressed, compression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := io.WriteString(w, content); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if compressed.Len() >= len(content) {
+				t.Errorf("compressed %d bytes to %d bytes", len(content), compressed.Len())
+			}
+			r, err := newDecompressor(&compressed, compression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != content {
+				t.Errorf("decompressed content differs from the original")
+			}
+		})
+	}
+}
+
+func TestRateLimitedWriter(t *testing.T) {
+	var buf bytes.Buffer
+	// the burst of 1000 bytes is written at once, the rest at 1000 bytes per second
+	w := &rateLimitedWriter{ctx: context.Background(), w: &buf, limiter: newRateLimiter(1000)}
+	start := time.Now()
+	if _, err := w.Write(make([]byte, 1500)); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("wrote 1500 bytes in %s, want at least 500ms at 1000 bytes per second", elapsed)
+	}
+	if buf.Len() != 1500 {
+		t.Errorf("wrote %d bytes, want 1500", buf.Len())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w = &rateLimitedWriter{ctx: ctx, w: &buf, limiter: newRateLimiter(1000)}
+	if _, err := w.Write(make([]byte, 1500)); err == nil {
+		t.Errorf("Write() with a canceled context succeeded")
+	}
+}
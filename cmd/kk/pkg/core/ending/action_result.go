@@ -23,11 +23,15 @@ import (
 )
 
 type ActionResult struct {
-	Host      connector.Host
-	Status    ResultStatus
-	Error     error
-	StartTime time.Time
-	EndTime   time.Time
+	Host        connector.Host
+	Status      ResultStatus
+	Changed     bool
+	Diff        string
+	Output      string
+	Diagnostics string
+	Error       error
+	StartTime   time.Time
+	EndTime     time.Time
 }
 
 func (a *ActionResult) GetHost() connector.Host {
@@ -38,6 +42,35 @@ func (a *ActionResult) GetStatus() ResultStatus {
 	return a.Status
 }
 
+func (a *ActionResult) IsChanged() bool {
+	return a.Changed
+}
+
+func (a *ActionResult) GetDiff() string {
+	return a.Diff
+}
+
+// GetState returns the state of the action on the host: changed, ok, skipped or failed.
+func (a *ActionResult) GetState() string {
+	if a.Status == SUCCESS {
+		if a.Changed {
+			return StateChanged
+		}
+		return StateOK
+	}
+	return a.Status.String()
+}
+
+// GetOutput returns the output of the last command executed by the action on the host.
+func (a *ActionResult) GetOutput() string {
+	return a.Output
+}
+
+// GetDiagnostics returns the file of the diagnostic snapshot collected on the host after the failure.
+func (a *ActionResult) GetDiagnostics() string {
+	return a.Diagnostics
+}
+
 func (a *ActionResult) GetErr() error {
 	return a.Error
 }
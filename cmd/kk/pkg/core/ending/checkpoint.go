@@ -0,0 +1,165 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package ending
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Checkpoint is the progress of a pipeline, the remote tasks completed on each host. It is saved after each task, so
+// a failed run can be resumed, and the tasks completed in the previous run are skipped.
+type Checkpoint struct {
+	mu       sync.Mutex
+	file     string
+	pipeline string
+	// previous are the tasks completed in the previous run, completed also includes the tasks of this run
+	previous  map[string]map[string]struct{}
+	completed map[string]map[string]struct{}
+}
+
+type checkpointFile struct {
+	Pipeline  string              `json:"pipeline"`
+	Completed map[string][]string `json:"completed"`
+}
+
+// NewCheckpoint returns the checkpoint saved to the file. The tasks completed in the previous run are loaded from the
+// file if resume is true.
+func NewCheckpoint(file, pipeline string, resume bool) (*Checkpoint, error) {
+	c := &Checkpoint{
+		file:      file,
+		pipeline:  pipeline,
+		previous:  make(map[string]map[string]struct{}),
+		completed: make(map[string]map[string]struct{}),
+	}
+	if !resume {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the checkpoint %s", file)
+	}
+	var f checkpointFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the checkpoint %s", file)
+	}
+	if f.Pipeline != pipeline {
+		return nil, errors.Errorf("the checkpoint %s is of the pipeline %s instead of %s", file, f.Pipeline, pipeline)
+	}
+	for host, tasks := range f.Completed {
+		for _, task := range tasks {
+			add(c.previous, host, task)
+			add(c.completed, host, task)
+		}
+	}
+	return c, nil
+}
+
+func add(m map[string]map[string]struct{}, host, task string) {
+	if _, ok := m[host]; !ok {
+		m[host] = make(map[string]struct{})
+	}
+	m[host][task] = struct{}{}
+}
+
+// Resumed returns the number of the tasks completed in the previous run.
+func (c *Checkpoint) Resumed() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, tasks := range c.previous {
+		n += len(tasks)
+	}
+	return n
+}
+
+// Done returns whether the task was completed on the host in the previous run.
+func (c *Checkpoint) Done(host, task string) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.previous[host][task]
+	return ok
+}
+
+// Complete records the task is completed on the host.
+func (c *Checkpoint) Complete(host, task string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	add(c.completed, host, task)
+}
+
+// Save writes the completed tasks to the file.
+func (c *Checkpoint) Save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	f := checkpointFile{Pipeline: c.pipeline, Completed: make(map[string][]string, len(c.completed))}
+	for host, tasks := range c.completed {
+		for task := range tasks {
+			f.Completed[host] = append(f.Completed[host], task)
+		}
+		sort.Strings(f.Completed[host])
+	}
+	c.mu.Unlock()
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the checkpoint")
+	}
+	if err := os.MkdirAll(filepath.Dir(c.file), os.ModePerm); err != nil {
+		return errors.Wrapf(err, "failed to create the dir of the checkpoint %s", c.file)
+	}
+	if err := os.WriteFile(c.file, data, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write the checkpoint %s", c.file)
+	}
+	return nil
+}
+
+// File returns the file of the checkpoint.
+func (c *Checkpoint) File() string {
+	return c.file
+}
+
+// Remove deletes the file after the pipeline succeeded.
+func (c *Checkpoint) Remove() error {
+	if c == nil {
+		return nil
+	}
+	if err := os.Remove(c.file); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove the checkpoint %s", c.file)
+	}
+	return nil
+}
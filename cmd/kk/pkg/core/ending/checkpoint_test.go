@@ -0,0 +1,75 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package ending
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpoint(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "checkpoints", "CreateClusterPipeline.json")
+	c, err := NewCheckpoint(file, "CreateClusterPipeline", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Complete("node1", "3/0.GetOSData")
+	c.Complete("node1", "3/1.InitOS")
+	c.Complete("node2", "3/0.GetOSData")
+	if c.Done("node1", "3/0.GetOSData") {
+		t.Errorf("Done() of a task completed in this run = true, want false")
+	}
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		pipeline string
+		resume   bool
+		resumed  int
+		wantErr  bool
+	}{
+		{name: "resume", pipeline: "CreateClusterPipeline", resume: true, resumed: 3},
+		{name: "new run", pipeline: "CreateClusterPipeline", resume: false, resumed: 0},
+		{name: "another pipeline", pipeline: "AddNodesPipeline", resume: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewCheckpoint(file, tt.pipeline, tt.resume)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewCheckpoint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Resumed() != tt.resumed {
+				t.Errorf("Resumed() = %d, want %d", got.Resumed(), tt.resumed)
+			}
+			if done := got.Done("node1", "3/1.InitOS"); done != tt.resume {
+				t.Errorf("Done() = %v, want %v", done, tt.resume)
+			}
+		})
+	}
+
+	if err := c.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if c, err = NewCheckpoint(file, "CreateClusterPipeline", true); err != nil || c.Resumed() != 0 {
+		t.Errorf("NewCheckpoint() after Remove() = %d tasks, %v, want none", c.Resumed(), err)
+	}
+}
@@ -25,6 +25,9 @@ import (
 type Interface interface {
 	GetHost() connector.Host
 	GetStatus() ResultStatus
+	GetState() string
+	IsChanged() bool
+	GetOutput() string
 	GetErr() error
 	GetStartTime() time.Time
 	GetEndTime() time.Time
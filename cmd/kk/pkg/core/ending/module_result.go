@@ -21,18 +21,25 @@ import (
 
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/tui"
 )
 
 type ModuleResult struct {
 	HostResults   map[string]Interface
+	Summary       *Summary
+	Report        *Report
+	Checkpoint    *Checkpoint
+	Progress      *tui.Progress
 	CombineResult error
 	Status        ResultStatus
 	StartTime     time.Time
 	EndTime       time.Time
+	// Index is the index of the module in the pipeline, which identifies the tasks of the module in the checkpoint.
+	Index int
 }
 
 func NewModuleResult() *ModuleResult {
-	return &ModuleResult{HostResults: make(map[string]Interface), StartTime: time.Now(), Status: NULL}
+	return &ModuleResult{HostResults: make(map[string]Interface), Summary: NewSummary(), StartTime: time.Now(), Status: NULL}
 }
 
 func (m *ModuleResult) IsFailed() bool {
@@ -44,6 +51,7 @@ func (m *ModuleResult) AppendHostResult(p Interface) {
 		return
 	}
 	m.HostResults[p.GetHost().GetName()] = p
+	m.Summary.Append(p)
 }
 
 func (m *ModuleResult) LocalErrResult(err error) {
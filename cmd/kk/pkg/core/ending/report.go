@@ -0,0 +1,256 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package ending
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+// excerptLines is the number of the last output lines kept in the report.
+const excerptLines = 20
+
+// Report is the machine-readable result of a pipeline run, with the state of each task on each host.
+type Report struct {
+	mu        sync.Mutex
+	Pipeline  string                  `json:"pipeline"`
+	Status    string                  `json:"status"`
+	Error     string                  `json:"error,omitempty"`
+	StartTime time.Time               `json:"startTime"`
+	EndTime   time.Time               `json:"endTime"`
+	Duration  float64                 `json:"duration"`
+	Summary   map[string]*HostSummary `json:"summary,omitempty"`
+	// Run is the ID of the run the changes of the managed files are recorded as in the history of the hosts.
+	Run string `json:"run,omitempty"`
+	// Metrics are the operations on each host measured by the connector.
+	Metrics map[string]connector.HostMetrics `json:"metrics,omitempty"`
+	// SlowHosts describe the hosts much slower or failing much more often than the median host.
+	SlowHosts []string      `json:"slowHosts,omitempty"`
+	Tasks     []*TaskReport `json:"tasks"`
+}
+
+// TaskReport is the result of a task of a module.
+type TaskReport struct {
+	Module string        `json:"module"`
+	Task   string        `json:"task"`
+	Hosts  []*HostReport `json:"hosts"`
+}
+
+// HostReport is the result of a task on a host. The duration is in seconds, the output is an excerpt of the last
+// command executed on the host, the commands run in a pty so it contains the stderr as well. The diagnostics is the
+// file of the snapshot collected on the host after a failure with --collect-diagnostics.
+type HostReport struct {
+	Host        string  `json:"host"`
+	Status      string  `json:"status"`
+	Duration    float64 `json:"duration"`
+	Output      string  `json:"output,omitempty"`
+	Error       string  `json:"error,omitempty"`
+	Diagnostics string  `json:"diagnostics,omitempty"`
+}
+
+func NewReport(pipeline string) *Report {
+	return &Report{Pipeline: pipeline, StartTime: time.Now(), Tasks: make([]*TaskReport, 0)}
+}
+
+// AppendTask appends the results of the task on each host.
+func (r *Report) AppendTask(module, task string, res *TaskResult) {
+	if r == nil {
+		return
+	}
+	t := &TaskReport{Module: module, Task: task, Hosts: make([]*HostReport, 0, len(res.ActionResults))}
+	for _, ac := range res.ActionResults {
+		h := &HostReport{
+			Status:   ac.GetState(),
+			Duration: ac.GetEndTime().Sub(ac.GetStartTime()).Seconds(),
+			Output:   excerpt(ac.GetOutput()),
+		}
+		if ac.GetHost() != nil {
+			h.Host = ac.GetHost().GetName()
+		}
+		if ac.GetErr() != nil {
+			h.Error = excerpt(ac.GetErr().Error())
+		}
+		h.Diagnostics = ac.GetDiagnostics()
+		t.Hosts = append(t.Hosts, h)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Tasks = append(r.Tasks, t)
+}
+
+// Finish records the end of the pipeline run, the err is the error returned by the pipeline.
+func (r *Report) Finish(summary *Summary, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.EndTime = time.Now()
+	r.Duration = r.EndTime.Sub(r.StartTime).Seconds()
+	r.Status = SUCCESS.String()
+	if err != nil {
+		r.Status = FAILED.String()
+		r.Error = err.Error()
+	}
+	if summary != nil {
+		summary.mu.Lock()
+		r.Summary = summary.Hosts
+		summary.mu.Unlock()
+	}
+}
+
+// SetMetrics records the metrics of the hosts and the outliers among them.
+func (r *Report) SetMetrics(metrics map[string]connector.HostMetrics, slowHosts []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Metrics = metrics
+	r.SlowHosts = slowHosts
+}
+
+// WriteJSON writes the report to the file in JSON.
+func (r *Report) WriteJSON(file string) error {
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the report")
+	}
+	return writeReport(file, data)
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Name    string           `xml:"name,attr"`
+	Tests   int              `xml:"tests,attr"`
+	Fails   int              `xml:"failures,attr"`
+	Skipped int              `xml:"skipped,attr"`
+	Time    string           `xml:"time,attr"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name    string          `xml:"name,attr"`
+	Tests   int             `xml:"tests,attr"`
+	Fails   int             `xml:"failures,attr"`
+	Skipped int             `xml:"skipped,attr"`
+	Time    string          `xml:"time,attr"`
+	Cases   []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report to the file in JUnit XML, with a test suite for each host and a test case for
+// each task, so the failures are shown per node.
+func (r *Report) WriteJUnit(file string) error {
+	r.mu.Lock()
+	suites := junitTestSuites{Name: r.Pipeline, Time: seconds(r.Duration)}
+	index := make(map[string]int)
+	var durations []float64
+	for _, t := range r.Tasks {
+		for _, h := range t.Hosts {
+			i, ok := index[h.Host]
+			if !ok {
+				i = len(suites.Suites)
+				index[h.Host] = i
+				suites.Suites = append(suites.Suites, junitTestSuite{Name: h.Host})
+				durations = append(durations, 0)
+			}
+			suite := &suites.Suites[i]
+			c := junitTestCase{
+				Name:      fmt.Sprintf("[%s] %s", t.Module, t.Task),
+				ClassName: t.Module,
+				Time:      seconds(h.Duration),
+				SystemOut: h.Output,
+			}
+			switch h.Status {
+			case FAILED.String():
+				c.Failure = &junitFailure{Message: firstLine(h.Error), Text: h.Error}
+				suite.Fails++
+			case SKIPPED.String():
+				c.Skipped = &struct{}{}
+				suite.Skipped++
+			}
+			suite.Tests++
+			durations[i] += h.Duration
+			suite.Cases = append(suite.Cases, c)
+		}
+	}
+	r.mu.Unlock()
+
+	for i := range suites.Suites {
+		suites.Suites[i].Time = seconds(durations[i])
+		suites.Tests += suites.Suites[i].Tests
+		suites.Fails += suites.Suites[i].Fails
+		suites.Skipped += suites.Suites[i].Skipped
+	}
+	data, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the JUnit report")
+	}
+	return writeReport(file, append([]byte(xml.Header), data...))
+}
+
+func writeReport(file string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return errors.Wrapf(err, "failed to create the dir of the report %s", file)
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write the report %s", file)
+	}
+	return nil
+}
+
+// excerpt returns the last lines of the output.
+func excerpt(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) > excerptLines {
+		lines = append([]string{"..."}, lines[len(lines)-excerptLines:]...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return strings.TrimSpace(s[:i])
+	}
+	return s
+}
+
+func seconds(d float64) string {
+	return fmt.Sprintf("%.3f", d)
+}
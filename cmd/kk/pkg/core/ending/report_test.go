@@ -0,0 +1,88 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package ending
+
+import (
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+func TestReportWriteJUnit(t *testing.T) {
+	node1 := &connector.BaseHost{Name: "node1"}
+	node2 := &connector.BaseHost{Name: "node2"}
+
+	r := NewReport("CreateClusterPipeline")
+	res := NewTaskResult()
+	res.AppendSuccess(node1, true, "")
+	res.AppendErr(node2, errors.New("\nFailed to exec command: systemctl start kubelet\nexit status 1"))
+	r.AppendTask("InstallKubeletModule", "Start kubelet", res)
+	res = NewTaskResult()
+	res.AppendSkip(node1)
+	r.AppendTask("InitKubernetesModule", "Init cluster", res)
+	r.Finish(nil, errors.New("failed"))
+
+	file := filepath.Join(t.TempDir(), "junit.xml")
+	if err := r.WriteJUnit(file); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got junitTestSuites
+	if err := xml.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Tests != 3 || got.Fails != 1 || got.Skipped != 1 {
+		t.Errorf("tests=%d failures=%d skipped=%d, want 3, 1 and 1", got.Tests, got.Fails, got.Skipped)
+	}
+	if len(got.Suites) != 2 || got.Suites[0].Name != "node1" || got.Suites[1].Name != "node2" {
+		t.Fatalf("suites = %+v, want node1 and node2", got.Suites)
+	}
+	failure := got.Suites[1].Cases[0].Failure
+	if failure == nil || failure.Message != "Failed to exec command: systemctl start kubelet" {
+		t.Errorf("failure = %+v", failure)
+	}
+	if r.Status != FAILED.String() {
+		t.Errorf("status = %s, want failed", r.Status)
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		lines int
+	}{
+		{name: "short", s: "a\nb\n", lines: 2},
+		{name: "long", s: strings.Repeat("line\n", 30), lines: excerptLines + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Count(excerpt(tt.s), "\n") + 1; got != tt.lines {
+				t.Errorf("excerpt() has %d lines, want %d", got, tt.lines)
+			}
+		})
+	}
+}
@@ -25,6 +25,12 @@ const (
 	FAILED
 )
 
+// The states of a successful action, which tell whether it changed the host.
+const (
+	StateOK      = "ok"
+	StateChanged = "changed"
+)
+
 var EnumList = []ResultStatus{
 	NULL,
 	SKIPPED,
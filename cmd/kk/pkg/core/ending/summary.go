@@ -0,0 +1,101 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package ending
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// HostSummary counts the states of the actions executed on a host.
+type HostSummary struct {
+	OK      int `json:"ok"`
+	Changed int `json:"changed"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
+
+// Summary counts the states of the actions executed on each host in a pipeline, it tells whether a re-run
+// changed anything.
+type Summary struct {
+	mu    sync.Mutex
+	names []string
+	Hosts map[string]*HostSummary
+}
+
+func NewSummary() *Summary {
+	return &Summary{Hosts: make(map[string]*HostSummary)}
+}
+
+func (s *Summary) Append(r Interface) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := r.GetHost().GetName()
+	h, ok := s.Hosts[name]
+	if !ok {
+		h = &HostSummary{}
+		s.Hosts[name] = h
+		s.names = append(s.names, name)
+	}
+	switch r.GetState() {
+	case StateOK:
+		h.OK++
+	case StateChanged:
+		h.Changed++
+	case SKIPPED.String():
+		h.Skipped++
+	case FAILED.String():
+		h.Failed++
+	}
+}
+
+// Changed returns the number of the actions which changed the hosts.
+func (s *Summary) Changed() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := 0
+	for _, h := range s.Hosts {
+		changed += h.Changed
+	}
+	return changed
+}
+
+// String returns the counts of each host in the order they are first seen, e.g.
+// node1    : ok=12  changed=3  skipped=1  failed=0
+func (s *Summary) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	width := 0
+	for _, name := range s.names {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	lines := make([]string, 0, len(s.names))
+	for _, name := range s.names {
+		h := s.Hosts[name]
+		lines = append(lines, fmt.Sprintf("%-*s : ok=%-4d changed=%-4d skipped=%-4d failed=%d",
+			width, name, h.OK, h.Changed, h.Skipped, h.Failed))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -69,6 +69,7 @@ func (t *TaskResult) AppendSkip(host connector.Host) {
 	e := &ActionResult{
 		Host:      host,
 		Status:    SKIPPED,
+		Output:    connector.TakeOutput(host),
 		Error:     nil,
 		StartTime: t.StartTime,
 		EndTime:   now,
@@ -78,13 +79,16 @@ func (t *TaskResult) AppendSkip(host connector.Host) {
 	t.EndTime = now
 }
 
-func (t *TaskResult) AppendSuccess(host connector.Host) {
+func (t *TaskResult) AppendSuccess(host connector.Host, changed bool, diff string) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	now := time.Now()
 	e := &ActionResult{
 		Host:      host,
 		Status:    SUCCESS,
+		Output:    connector.TakeOutput(host),
+		Changed:   changed,
+		Diff:      diff,
 		Error:     nil,
 		StartTime: t.StartTime,
 		EndTime:   now,
@@ -99,11 +103,13 @@ func (t *TaskResult) AppendErr(host connector.Host, err error) {
 	defer t.mu.Unlock()
 	now := time.Now()
 	e := &ActionResult{
-		Host:      host,
-		Status:    FAILED,
-		Error:     err,
-		StartTime: t.StartTime,
-		EndTime:   now,
+		Host:        host,
+		Status:      FAILED,
+		Output:      connector.TakeOutput(host),
+		Diagnostics: connector.TakeDiagnostics(host),
+		Error:       err,
+		StartTime:   t.StartTime,
+		EndTime:     now,
 	}
 
 	t.ActionResults = append(t.ActionResults, e)
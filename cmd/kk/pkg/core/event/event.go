@@ -0,0 +1,134 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package event
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+const (
+	// PipelineStart fires when a pipeline starts.
+	PipelineStart = "pipeline.start"
+	// PipelineFinish fires when a pipeline succeeds.
+	PipelineFinish = "pipeline.finish"
+	// PipelineFailure fires when a pipeline fails.
+	PipelineFailure = "pipeline.failure"
+	// PhaseComplete fires when the modules of a phase, i.e. the modules with the same tag, are complete.
+	PhaseComplete = "phase.complete"
+)
+
+// Types are the types of the events.
+var Types = []string{PipelineStart, PipelineFinish, PipelineFailure, PhaseComplete}
+
+// DefaultTimeout bounds the delivery of an event to a sink.
+const DefaultTimeout = 10 * time.Second
+
+// Event is an event of a run of kk, it is delivered to the sinks as JSON.
+type Event struct {
+	Type     string    `json:"type"`
+	Cluster  string    `json:"cluster"`
+	Pipeline string    `json:"pipeline"`
+	Run      string    `json:"run,omitempty"`
+	Phase    string    `json:"phase,omitempty"`
+	Modules  []string  `json:"modules,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Duration string    `json:"duration,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// Sink delivers the events to an external system.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, e Event) error
+}
+
+// Hook delivers the events of the given types to a sink.
+type Hook struct {
+	// Events are the types of the events delivered, all of them if empty.
+	Events  []string
+	Sink    Sink
+	Timeout time.Duration
+}
+
+// Matches returns true if the hook delivers the events of the type.
+func (h Hook) Matches(typ string) bool {
+	if len(h.Events) == 0 {
+		return true
+	}
+	for _, e := range h.Events {
+		if e == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Dispatcher fires the events to the hooks. A nil dispatcher fires nothing.
+type Dispatcher struct {
+	hooks []Hook
+}
+
+// NewDispatcher returns a dispatcher firing the events to the hooks.
+func NewDispatcher(hooks ...Hook) *Dispatcher {
+	return &Dispatcher{hooks: hooks}
+}
+
+// Fire delivers the event to the hooks matching its type one by one. A failed delivery is logged and doesn't fail
+// the run, the hooks notify about the run rather than take part in it. The trace context of the ctx is sent with the
+// event.
+func (d *Dispatcher) Fire(ctx context.Context, e Event) {
+	if d == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	for _, h := range d.hooks {
+		if !h.Matches(e.Type) {
+			continue
+		}
+		timeout := h.Timeout
+		if timeout <= 0 {
+			timeout = DefaultTimeout
+		}
+		sendCtx, cancel := context.WithTimeout(ctx, timeout)
+		if err := h.Sink.Send(sendCtx, e); err != nil {
+			logger.FromContext(ctx).Warnf("failed to send the event %s to the hook %s: %v", e.Type, h.Sink.Name(), err)
+		}
+		cancel()
+	}
+}
+
+// Message returns the event as a line of text, e.g. for a chat.
+func (e Event) Message() string {
+	subject := fmt.Sprintf("Pipeline[%s] of the cluster %s", e.Pipeline, e.Cluster)
+	switch e.Type {
+	case PipelineStart:
+		return subject + " started"
+	case PipelineFinish:
+		return fmt.Sprintf("%s finished in %s", subject, e.Duration)
+	case PipelineFailure:
+		return fmt.Sprintf("%s failed after %s: %s", subject, e.Duration, e.Error)
+	case PhaseComplete:
+		return fmt.Sprintf("%s completed the phase %s in %s", subject, e.Phase, e.Duration)
+	}
+	return fmt.Sprintf("%s: %s", subject, e.Type)
+}
@@ -0,0 +1,102 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+func TestDispatcher_Fire(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	var (
+		mu       sync.Mutex
+		webhook  []Event
+		messages []string
+		auth     string
+	)
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e Event
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		webhook = append(webhook, e)
+		auth = r.Header.Get("Authorization")
+	}))
+	defer webhookServer.Close()
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		messages = append(messages, msg["text"])
+	}))
+	defer slackServer.Close()
+	failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failingServer.Close()
+	out := filepath.Join(t.TempDir(), "events")
+
+	d := NewDispatcher(
+		Hook{Sink: &Webhook{URL: failingServer.URL}},
+		Hook{Sink: &Webhook{URL: webhookServer.URL, Headers: map[string]string{"Authorization": "Bearer token"}}},
+		Hook{Events: []string{PipelineFailure}, Sink: &Slack{URL: slackServer.URL}},
+		Hook{Events: []string{PhaseComplete}, Sink: &Script{Script: `echo "$KUBEKEY_EVENT $KUBEKEY_PHASE" >> ` + out}},
+	)
+	events := []Event{
+		{Type: PipelineStart, Cluster: "sample", Pipeline: "CreateClusterPipeline"},
+		{Type: PhaseComplete, Cluster: "sample", Pipeline: "CreateClusterPipeline", Phase: "etcd", Duration: "2m"},
+		{Type: PipelineFailure, Cluster: "sample", Pipeline: "CreateClusterPipeline", Duration: "5m", Error: "boom"},
+	}
+	for _, e := range events {
+		d.Fire(context.Background(), e)
+	}
+
+	if len(webhook) != 3 || webhook[1].Phase != "etcd" || webhook[2].Error != "boom" || webhook[0].Time.IsZero() {
+		t.Errorf("webhook received %+v", webhook)
+	}
+	if auth != "Bearer token" {
+		t.Errorf("webhook Authorization = %q, want %q", auth, "Bearer token")
+	}
+	want := ":x: Pipeline[CreateClusterPipeline] of the cluster sample failed after 5m: boom"
+	if len(messages) != 1 || messages[0] != want {
+		t.Errorf("slack received %q, want %q", messages, want)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "phase.complete etcd" {
+		t.Errorf("script wrote %q, want %q", got, "phase.complete etcd")
+	}
+}
@@ -0,0 +1,142 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package event
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/tracing"
+)
+
+// Webhook posts the events as JSON to a URL.
+type Webhook struct {
+	URL     string
+	Headers map[string]string
+	Client  *http.Client
+}
+
+// Name returns the host of the URL, the rest of it may hold a token.
+func (w *Webhook) Name() string {
+	if u, err := url.Parse(w.URL); err == nil && u.Host != "" {
+		return "webhook " + u.Host
+	}
+	return "webhook"
+}
+
+func (w *Webhook) Send(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the event")
+	}
+	return post(ctx, w.Client, w.URL, w.Headers, body)
+}
+
+// Slack posts the events as messages to a Slack incoming webhook.
+type Slack struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s *Slack) Name() string {
+	return "slack"
+}
+
+func (s *Slack) Send(ctx context.Context, e Event) error {
+	icon := ""
+	switch e.Type {
+	case PipelineFinish:
+		icon = ":white_check_mark: "
+	case PipelineFailure:
+		icon = ":x: "
+	}
+	body, err := json.Marshal(map[string]string{"text": icon + e.Message()})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the message")
+	}
+	return post(ctx, s.Client, s.URL, nil, body)
+}
+
+func post(ctx context.Context, client *http.Client, url string, headers map[string]string, body []byte) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create the request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(ctx, req.Header)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// the error holds the URL
+		return errors.New("failed to post the event")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// Script runs a shell script on the machine running kk with the event as JSON on its stdin, and its fields in the
+// environment variables KUBEKEY_EVENT, KUBEKEY_CLUSTER, KUBEKEY_PIPELINE, KUBEKEY_PHASE and KUBEKEY_ERROR, and the
+// trace context in TRACEPARENT when the run is traced.
+type Script struct {
+	Script string
+}
+
+func (s *Script) Name() string {
+	return "script"
+}
+
+func (s *Script) Send(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the event")
+	}
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", s.Script)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"KUBEKEY_EVENT="+e.Type,
+		"KUBEKEY_CLUSTER="+e.Cluster,
+		"KUBEKEY_PIPELINE="+e.Pipeline,
+		"KUBEKEY_PHASE="+e.Phase,
+		"KUBEKEY_ERROR="+e.Error,
+	)
+	header := make(http.Header)
+	tracing.Inject(ctx, header)
+	if traceparent := header.Get("traceparent"); traceparent != "" {
+		cmd.Env = append(cmd.Env, "TRACEPARENT="+traceparent)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "the script failed: %s", bytes.TrimSpace(out))
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package exporter exposes the metrics of the pipelines and the connections to the hosts to Prometheus.
+package exporter
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "kubekey"
+
+	// AddrEnv is the env of the address the metrics are served on, as --metrics-addr.
+	AddrEnv = "KUBEKEY_METRICS_ADDR"
+
+	Upload   = "upload"
+	Download = "download"
+)
+
+var (
+	// Pipelines counts the pipelines run by their result, succeeded or failed.
+	Pipelines = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pipelines_total",
+		Help:      "The pipelines run by their result.",
+	}, []string{"pipeline", "result"})
+	// PipelineDuration is the duration of the pipelines.
+	PipelineDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "pipeline_duration_seconds",
+		Help:      "The duration of the pipelines.",
+		Buckets:   prometheus.ExponentialBuckets(30, 2, 10),
+	}, []string{"pipeline"})
+	// Tasks counts the tasks executed on the hosts by their module and the state of their result, e.g. changed.
+	Tasks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tasks_total",
+		Help:      "The tasks executed on the hosts by the module and the state of the result.",
+	}, []string{"module", "state"})
+	// ModuleFailures counts the failed modules.
+	ModuleFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "module_failures_total",
+		Help:      "The failed modules of the pipelines.",
+	}, []string{"pipeline", "module"})
+	// CommandDuration is the latency of the commands on each host, including the existence checks and mkdir.
+	CommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "command_duration_seconds",
+		Help:      "The duration of the commands on the hosts.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 4, 8),
+	}, []string{"host"})
+	// TransferredBytes counts the bytes of the files uploaded to and downloaded from each host.
+	TransferredBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transferred_bytes_total",
+		Help:      "The bytes of the files transferred to and from the hosts by the direction, upload or download.",
+	}, []string{"host", "direction"})
+	// SSHSessions is the number of the SSH sessions open on each host, one for each running command.
+	SSHSessions = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ssh_sessions_active",
+		Help:      "The SSH sessions open on the hosts.",
+	}, []string{"host"})
+)
+
+// Collectors returns the collectors of the metrics of kk.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{Pipelines, PipelineDuration, Tasks, ModuleFailures, CommandDuration, TransferredBytes, SSHSessions}
+}
+
+// Register registers the collectors to the registerer, e.g. the registry of the metrics of the operator.
+func Register(r prometheus.Registerer) error {
+	for _, c := range Collectors() {
+		if err := r.Register(c); err != nil {
+			if _, ok := err.(prometheus.AlreadyRegisteredError); ok {
+				continue
+			}
+			return errors.Wrap(err, "failed to register the metrics")
+		}
+	}
+	return nil
+}
+
+// Serve serves the metrics of kk and of the process at /metrics on the address in the background, until the process
+// exits. It fails if the address can't be listened on.
+func Serve(addr string) error {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	if err := Register(registry); err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen on %s for the metrics", addr)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	go func() {
+		_ = http.Serve(ln, mux)
+	}()
+	return nil
+}
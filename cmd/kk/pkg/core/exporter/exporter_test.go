@@ -0,0 +1,65 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package exporter
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRegister(t *testing.T) {
+	r := prometheus.NewRegistry()
+	if err := Register(r); err != nil {
+		t.Fatal(err)
+	}
+	// the operator may register the collectors again, e.g. in the tests
+	if err := Register(r); err != nil {
+		t.Errorf("Register() again = %v", err)
+	}
+}
+
+func TestServe(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	if err := Serve(addr); err != nil {
+		t.Fatal(err)
+	}
+	if err := Serve(addr); err == nil {
+		t.Error("Serve() on the address in use succeeded")
+	}
+
+	Tasks.WithLabelValues("InstallETCDBinaryModule", "changed").Inc()
+	resp, err := http.Get("http://" + addr + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	want := `kubekey_tasks_total{module="InstallETCDBinaryModule",state="changed"} 1`
+	if !strings.Contains(string(body), want) {
+		t.Errorf("the metrics don't contain %s:\n%s", want, body)
+	}
+}
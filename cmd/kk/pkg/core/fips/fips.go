@@ -0,0 +1,177 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package fips implements the hardened crypto mode of kk, which restricts the SSH and TLS algorithms to the FIPS 140-2
+// approved ones and refuses the weak keys. The mode is enabled by the fips build tag, the --fips flag or the
+// KUBEKEY_FIPS env.
+package fips
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// EnvName is the env enabling the mode when its value is true.
+const EnvName = "KUBEKEY_FIPS"
+
+// MinRSAKeySize is the minimum size of the RSA keys in bits.
+const MinRSAKeySize = 2048
+
+var (
+	// SSHCiphers are the approved ciphers of the SSH connections.
+	SSHCiphers = []string{
+		"aes128-gcm@openssh.com", "aes256-gcm@openssh.com",
+		"aes128-ctr", "aes192-ctr", "aes256-ctr",
+	}
+	// SSHKeyExchanges are the approved key exchange algorithms of the SSH connections.
+	SSHKeyExchanges = []string{
+		"ecdh-sha2-nistp256", "ecdh-sha2-nistp384", "ecdh-sha2-nistp521",
+		"diffie-hellman-group14-sha256", "diffie-hellman-group16-sha512",
+	}
+	// SSHMACs are the approved MAC algorithms of the SSH connections.
+	SSHMACs = []string{
+		"hmac-sha2-256-etm@openssh.com", "hmac-sha2-512-etm@openssh.com",
+		"hmac-sha2-256", "hmac-sha2-512",
+	}
+	// SSHHostKeyAlgorithms are the approved host key algorithms of the SSH connections.
+	SSHHostKeyAlgorithms = []string{
+		ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521,
+		ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSASHA512,
+	}
+	// TLSCipherSuites are the approved cipher suites of the TLS 1.2 servers of the cluster, TLS 1.3 uses the
+	// approved AES-GCM suites only with the FIPS builds of Go.
+	TLSCipherSuites = []string{
+		"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+		"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+		"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+		"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+	}
+	// TLSMinVersion is the minimum TLS version of the servers of the cluster.
+	TLSMinVersion = "VersionTLS12"
+)
+
+var enabled atomic.Bool
+
+func init() {
+	if v, err := strconv.ParseBool(os.Getenv(EnvName)); buildEnabled || (err == nil && v) {
+		enabled.Store(true)
+	}
+}
+
+// Enabled reports whether the mode is enabled.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Enable enables the mode for the current process.
+func Enable() {
+	enabled.Store(true)
+}
+
+// CheckPublicKey returns an error if the key is not allowed in the mode: RSA keys shorter than MinRSAKeySize,
+// ECDSA keys on curves other than P-256, P-384 and P-521, and Ed25519 keys. Any key is allowed if the mode is disabled.
+func CheckPublicKey(key crypto.PublicKey) error {
+	if !Enabled() {
+		return nil
+	}
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < MinRSAKeySize {
+			return errors.Errorf("the %d bits RSA key is weaker than the minimum %d bits allowed in FIPS mode", k.N.BitLen(), MinRSAKeySize)
+		}
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256(), elliptic.P384(), elliptic.P521():
+		default:
+			return errors.Errorf("the ECDSA curve %s is not allowed in FIPS mode", k.Curve.Params().Name)
+		}
+	case ed25519.PublicKey:
+		return errors.New("Ed25519 keys are not allowed in FIPS mode")
+	default:
+		return errors.Errorf("the %T key is not allowed in FIPS mode", key)
+	}
+	return nil
+}
+
+// CheckCertificate returns an error if the key or the signature algorithm of the certificate is not allowed in the mode.
+func CheckCertificate(cert *x509.Certificate) error {
+	if !Enabled() {
+		return nil
+	}
+	if err := CheckPublicKey(cert.PublicKey); err != nil {
+		return errors.Wrapf(err, "certificate %s", cert.Subject.CommonName)
+	}
+	switch cert.SignatureAlgorithm {
+	case x509.SHA256WithRSA, x509.SHA384WithRSA, x509.SHA512WithRSA,
+		x509.SHA256WithRSAPSS, x509.SHA384WithRSAPSS, x509.SHA512WithRSAPSS,
+		x509.ECDSAWithSHA256, x509.ECDSAWithSHA384, x509.ECDSAWithSHA512:
+	default:
+		return errors.Errorf("certificate %s is signed with %s, which is not allowed in FIPS mode",
+			cert.Subject.CommonName, cert.SignatureAlgorithm)
+	}
+	return nil
+}
+
+// CheckSigner returns an error if the key of the SSH signer is not allowed in the mode.
+func CheckSigner(signer ssh.Signer) error {
+	if !Enabled() {
+		return nil
+	}
+	key, ok := signer.PublicKey().(ssh.CryptoPublicKey)
+	if !ok {
+		return errors.Errorf("the %s key is not allowed in FIPS mode", signer.PublicKey().Type())
+	}
+	return CheckPublicKey(key.CryptoPublicKey())
+}
+
+// ConfigureSSH restricts the algorithms of the SSH client config to the approved ones if the mode is enabled.
+func ConfigureSSH(config *ssh.ClientConfig) {
+	if !Enabled() {
+		return
+	}
+	config.Ciphers = SSHCiphers
+	config.KeyExchanges = SSHKeyExchanges
+	config.MACs = SSHMACs
+	config.HostKeyAlgorithms = SSHHostKeyAlgorithms
+}
+
+// ConfigureTLS sets the minimum version of the TLS config to TLS 1.2, and restricts its cipher suites to the approved
+// ones if the mode is enabled.
+func ConfigureTLS(config *tls.Config) {
+	config.MinVersion = tls.VersionTLS12
+	if !Enabled() {
+		return
+	}
+	config.CipherSuites = nil
+	for _, suite := range tls.CipherSuites() {
+		for _, name := range TLSCipherSuites {
+			if suite.Name == name {
+				config.CipherSuites = append(config.CipherSuites, suite.ID)
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package fips
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func withMode(t *testing.T, on bool) {
+	old := enabled.Load()
+	enabled.Store(on)
+	t.Cleanup(func() { enabled.Store(old) })
+}
+
+func TestCheckPublicKey(t *testing.T) {
+	rsa1024, _ := rsa.GenerateKey(rand.Reader, 1024)
+	rsa2048, _ := rsa.GenerateKey(rand.Reader, 2048)
+	p224, _ := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPub, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []struct {
+		name    string
+		key     crypto.PublicKey
+		wantErr bool
+	}{
+		{name: "rsa 1024", key: &rsa1024.PublicKey, wantErr: true},
+		{name: "rsa 2048", key: &rsa2048.PublicKey},
+		{name: "ecdsa p224", key: &p224.PublicKey, wantErr: true},
+		{name: "ecdsa p256", key: &p256.PublicKey},
+		{name: "ed25519", key: edPub, wantErr: true},
+	}
+	withMode(t, true)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckPublicKey(tt.key); (err != nil) != tt.wantErr {
+				t.Errorf("CheckPublicKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	withMode(t, false)
+	if err := CheckPublicKey(&rsa1024.PublicKey); err != nil {
+		t.Errorf("any key is allowed if the mode is disabled, got %v", err)
+	}
+}
+
+func TestCheckCertificate(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "etcd-ca"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withMode(t, true)
+	if err := CheckCertificate(cert); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	cert.SignatureAlgorithm = x509.ECDSAWithSHA1
+	if err := CheckCertificate(cert); err == nil {
+		t.Error("expected an error for the SHA-1 signature")
+	}
+}
+
+func TestConfigureSSH(t *testing.T) {
+	withMode(t, true)
+	config := &ssh.ClientConfig{}
+	ConfigureSSH(config)
+	if len(config.Ciphers) == 0 || len(config.KeyExchanges) == 0 || len(config.MACs) == 0 || len(config.HostKeyAlgorithms) == 0 {
+		t.Errorf("the algorithms are not restricted: %+v", config)
+	}
+
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	signer, err := ssh.NewSignerFromKey(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckSigner(signer); err == nil {
+		t.Error("expected an error for the ed25519 signer")
+	}
+}
+
+func TestConfigureTLS(t *testing.T) {
+	withMode(t, false)
+	config := &tls.Config{}
+	ConfigureTLS(config)
+	if config.MinVersion != tls.VersionTLS12 || config.CipherSuites != nil {
+		t.Errorf("the config is %+v, want TLS 1.2 with the default suites", config)
+	}
+
+	withMode(t, true)
+	ConfigureTLS(config)
+	if len(config.CipherSuites) != len(TLSCipherSuites) {
+		t.Errorf("the cipher suites are %v, want %v", config.CipherSuites, TLSCipherSuites)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package logger
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/common"
+)
+
+type fieldsKey struct{}
+
+// hostFields are the fields of the task running on each host, a host runs one task at a time.
+var hostFields = struct {
+	sync.RWMutex
+	fields map[string]logrus.Fields
+}{fields: map[string]logrus.Fields{}}
+
+// WithFields returns a copy of the context carrying the fields on top of the ones of the context, e.g. the pipeline,
+// the task and the host the logs are about.
+func WithFields(ctx context.Context, fields logrus.Fields) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	merged := logrus.Fields{}
+	for k, v := range fieldsOf(ctx) {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// FromContext returns the logger of the entries with the fields carried by the context.
+func FromContext(ctx context.Context) logrus.FieldLogger {
+	fields := fieldsOf(ctx)
+	if len(fields) == 0 {
+		return Log
+	}
+	return Log.WithFields(fields)
+}
+
+// SetHostContext sets the context of the task running on the host, the entries about the host logged without the
+// context, e.g. by Messagef, get its fields. A nil context clears them when the task is done.
+func SetHostContext(host string, ctx context.Context) {
+	hostFields.Lock()
+	defer hostFields.Unlock()
+	if ctx == nil {
+		delete(hostFields.fields, host)
+		return
+	}
+	hostFields.fields[host] = fieldsOf(ctx)
+}
+
+// ForHost returns the logger of the entries about the host, with the fields of the task running on it.
+func ForHost(host string) logrus.FieldLogger {
+	hostFields.RLock()
+	fields := hostFields.fields[host]
+	hostFields.RUnlock()
+	return Log.WithFields(fields).WithField(common.Node, host)
+}
+
+func fieldsOf(ctx context.Context) logrus.Fields {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey{}).(logrus.Fields)
+	return fields
+}
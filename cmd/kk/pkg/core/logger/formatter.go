@@ -45,6 +45,8 @@ type Formatter struct {
 	CallerFirst bool
 	// CustomCallerFormatter - set custom formatter for caller info
 	CustomCallerFormatter func(*runtime.Frame) string
+	// Redactor - mask the secrets of the message and fields
+	Redactor *Redactor
 }
 
 func (f *Formatter) Format(entry *logrus.Entry) ([]byte, error) {
@@ -96,7 +98,7 @@ func (f *Formatter) Format(entry *logrus.Entry) ([]byte, error) {
 		f.writeOrderedFields(b, entry)
 	}
 
-	b.WriteString(entry.Message)
+	b.WriteString(f.Redactor.Redact(entry.Message))
 
 	if !f.CallerFirst {
 		f.writeCaller(b, entry)
@@ -128,62 +130,54 @@ func getColorByLevel(level logrus.Level) int {
 }
 
 func (f *Formatter) writeFields(b *bytes.Buffer, entry *logrus.Entry) {
-	if len(entry.Data) != 0 {
-		fields := make([]string, 0, len(entry.Data))
-		for field := range entry.Data {
-			fields = append(fields, field)
-		}
-
-		sort.Strings(fields)
-
-		b.WriteString("[")
-		for i, field := range fields {
-			f.writeField(b, entry, field, i)
-		}
-		b.WriteString("]")
+	fields := make([]string, 0, len(entry.Data))
+	for field := range entry.Data {
+		fields = append(fields, field)
 	}
+	sort.Strings(fields)
+	f.writeFieldList(b, entry, fields)
 }
 
 func (f *Formatter) writeOrderedFields(b *bytes.Buffer, entry *logrus.Entry) {
-	if len(entry.Data) != 0 {
-		b.WriteString("[")
-		length := len(entry.Data)
-		foundFieldsMap := map[string]bool{}
-		for i, field := range f.FieldsDisplayWithOrder {
-			if _, ok := entry.Data[field]; ok {
-				foundFieldsMap[field] = true
-				length--
-				f.writeField(b, entry, field, i)
-			}
+	fields := make([]string, 0, len(entry.Data))
+	found := map[string]bool{}
+	for _, field := range f.FieldsDisplayWithOrder {
+		if _, ok := entry.Data[field]; ok {
+			found[field] = true
+			fields = append(fields, field)
 		}
+	}
 
-		if length > 0 {
-			notFoundFields := make([]string, 0, length)
-			for field := range entry.Data {
-				if foundFieldsMap[field] == false {
-					notFoundFields = append(notFoundFields, field)
-				}
-			}
-
-			sort.Strings(notFoundFields)
+	notFound := make([]string, 0, len(entry.Data)-len(fields))
+	for field := range entry.Data {
+		if !found[field] {
+			notFound = append(notFound, field)
+		}
+	}
+	sort.Strings(notFound)
+	f.writeFieldList(b, entry, append(fields, notFound...))
+}
 
-			for i, field := range notFoundFields {
-				f.writeField(b, entry, field, i)
-			}
+// writeFieldList writes the fields like [value1 | value2] before the message.
+func (f *Formatter) writeFieldList(b *bytes.Buffer, entry *logrus.Entry, fields []string) {
+	if len(fields) == 0 {
+		return
+	}
+	b.WriteString("[")
+	for i, field := range fields {
+		if i > 0 {
+			b.WriteString(" | ")
 		}
-		b.WriteString("]")
+		f.writeField(b, entry, field)
 	}
+	b.WriteString("] ")
 }
 
-func (f *Formatter) writeField(b *bytes.Buffer, entry *logrus.Entry, field string, i int) {
+func (f *Formatter) writeField(b *bytes.Buffer, entry *logrus.Entry, field string) {
 	if f.HideKeys {
-		fmt.Fprintf(b, "%v", entry.Data[field])
+		fmt.Fprintf(b, "%v", f.Redactor.Redact(fmt.Sprint(entry.Data[field])))
 	} else {
-		fmt.Fprintf(b, "%s:%v", field, entry.Data[field])
-	}
-
-	if i != len(entry.Data) && len(entry.Data) != 1 {
-		b.WriteString(" | ")
+		fmt.Fprintf(b, "%s:%v", field, f.Redactor.Redact(fmt.Sprint(entry.Data[field])))
 	}
 }
 
@@ -202,3 +196,27 @@ func (f *Formatter) writeCaller(b *bytes.Buffer, entry *logrus.Entry) {
 		}
 	}
 }
+
+// JSONFormatter prints each entry as a JSON object on a line, the secrets in the message and the fields are masked.
+type JSONFormatter struct {
+	logrus.JSONFormatter
+	Redactor *Redactor
+}
+
+func (f *JSONFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	// the entry is formatted by the console and the hook of the log file, it is copied instead of being masked in place
+	masked := *entry
+	masked.Message = f.Redactor.Redact(entry.Message)
+	masked.Data = make(logrus.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		switch value := v.(type) {
+		case string:
+			masked.Data[k] = f.Redactor.Redact(value)
+		case error:
+			masked.Data[k] = f.Redactor.Redact(value.Error())
+		default:
+			masked.Data[k] = v
+		}
+	}
+	return f.JSONFormatter.Format(&masked)
+}
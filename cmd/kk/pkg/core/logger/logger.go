@@ -18,34 +18,58 @@ package logger
 
 import (
 	"fmt"
+	"io"
 	"path/filepath"
 	"time"
 
 	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
+	"github.com/pkg/errors"
 	"github.com/rifflock/lfshook"
 	"github.com/sirupsen/logrus"
 
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/common"
 )
 
+const (
+	// FormatEnv sets the format of the logs if the flag --log-format isn't set.
+	FormatEnv = "KUBEKEY_LOG_FORMAT"
+
+	// FormatText prints the logs for humans, the fields of the entries are prefixed to the messages.
+	FormatText = "text"
+	// FormatJSON prints each entry as a JSON object with its fields, for the log collectors.
+	FormatJSON = "json"
+)
+
 var Log *KubeKeyLog
 
+// format of the loggers created, the logger is created again when the work dir of the cluster is known.
+var format = FormatText
+
+// SetFormat sets the format of the loggers created after, text or json.
+func SetFormat(f string) error {
+	switch f {
+	case "", FormatText:
+		format = FormatText
+	case FormatJSON:
+		format = FormatJSON
+	default:
+		return errors.Errorf("unknown log format %q, it must be text or json", f)
+	}
+	return nil
+}
+
 type KubeKeyLog struct {
 	logrus.FieldLogger
 	OutputPath string
 	Verbose    bool
+	Redactor   *Redactor
 }
 
 func NewLogger(outputPath string, verbose bool) *KubeKeyLog {
 	logger := logrus.New()
+	redactor := NewRedactor()
 
-	formatter := &Formatter{
-		HideKeys:               true,
-		TimestampFormat:        "15:04:05 MST",
-		NoColors:               true,
-		ShowLevel:              logrus.WarnLevel,
-		FieldsDisplayWithOrder: []string{common.Pipeline, common.Module, common.Task, common.Node},
-	}
+	formatter := newFormatter(redactor)
 	logger.SetFormatter(formatter)
 
 	path := filepath.Join(outputPath, "./kubekey.log")
@@ -71,13 +95,34 @@ func NewLogger(outputPath string, verbose bool) *KubeKeyLog {
 	}
 
 	logger.Hooks.Add(lfshook.NewHook(logWriters, formatter))
-	return &KubeKeyLog{logger, outputPath, verbose}
+	return &KubeKeyLog{logger, outputPath, verbose, redactor}
+}
+
+func newFormatter(redactor *Redactor) logrus.Formatter {
+	if format == FormatJSON {
+		return &JSONFormatter{Redactor: redactor}
+	}
+	return &Formatter{
+		HideKeys:               true,
+		TimestampFormat:        "15:04:05 MST",
+		NoColors:               true,
+		ShowLevel:              logrus.WarnLevel,
+		FieldsDisplayWithOrder: []string{common.Pipeline, common.Module, common.Task, common.Node},
+		Redactor:               redactor,
+	}
+}
+
+// SetOutput sets where the logs are printed, the log file isn't changed.
+func (k *KubeKeyLog) SetOutput(w io.Writer) {
+	if l, ok := k.FieldLogger.(*logrus.Logger); ok {
+		l.SetOutput(w)
+	}
 }
 
 func (k *KubeKeyLog) Message(node, str string) {
-	Log.Infof("message: [%s]\n%s", node, str)
+	ForHost(node).Infof("message:\n%s", str)
 }
 
 func (k *KubeKeyLog) Messagef(node, format string, args ...interface{}) {
-	Log.Infof("message: [%s]\n%s", node, fmt.Sprintf(format, args...))
+	ForHost(node).Infof("message:\n%s", fmt.Sprintf(format, args...))
 }
@@ -17,8 +17,16 @@
 package logger
 
 import (
+	"context"
+	"encoding/json"
+	"strings"
 	"sync"
 	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/common"
 )
 
 var log = NewLogger("", true)
@@ -40,3 +48,95 @@ func TestKubeKey_Print(t *testing.T) {
 	}
 
 }
+
+func TestFromContext(t *testing.T) {
+	Log = NewLogger(t.TempDir(), false)
+	ctx := WithFields(context.Background(), logrus.Fields{common.Pipeline: "CreateClusterPipeline", common.Task: "Init"})
+	ctx = WithFields(ctx, logrus.Fields{common.Task: "Join", common.Node: "node1"})
+
+	entry, ok := FromContext(ctx).(*logrus.Entry)
+	if !ok {
+		t.Fatalf("FromContext() = %T, want an entry with the fields", FromContext(ctx))
+	}
+	want := logrus.Fields{common.Pipeline: "CreateClusterPipeline", common.Task: "Join", common.Node: "node1"}
+	if len(entry.Data) != len(want) {
+		t.Fatalf("fields = %v, want %v", entry.Data, want)
+	}
+	for k, v := range want {
+		if entry.Data[k] != v {
+			t.Errorf("field %s = %v, want %v", k, entry.Data[k], v)
+		}
+	}
+	if _, ok := FromContext(context.Background()).(*KubeKeyLog); !ok {
+		t.Errorf("FromContext() of a context without fields should be the logger")
+	}
+
+	SetHostContext("node1", ctx)
+	if got := ForHost("node1").(*logrus.Entry).Data[common.Task]; got != "Join" {
+		t.Errorf("the task of the host = %v, want Join", got)
+	}
+	SetHostContext("node1", nil)
+	if got := ForHost("node1").(*logrus.Entry).Data; len(got) != 1 || got[common.Node] != "node1" {
+		t.Errorf("the fields of the host after the task = %v, want only the host", got)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	redactor := NewRedactor()
+	redactor.AddSecrets("P@ssw0rd")
+	entry := &logrus.Entry{
+		Logger:  logrus.New(),
+		Time:    time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+		Level:   logrus.InfoLevel,
+		Message: "echo 'P@ssw0rd' | sudo -S true",
+		Data:    logrus.Fields{common.Node: "node1", common.Pipeline: "CreateClusterPipeline", "sudo": "P@ssw0rd"},
+	}
+
+	text := &Formatter{
+		HideKeys:               true,
+		TimestampFormat:        "15:04:05 MST",
+		NoColors:               true,
+		ShowLevel:              logrus.WarnLevel,
+		FieldsDisplayWithOrder: []string{common.Pipeline, common.Module, common.Task, common.Node},
+		Redactor:               redactor,
+	}
+	b, err := text.Format(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "03:04:05 UTC [CreateClusterPipeline | node1 | ******] echo '******' | sudo -S true\n"; string(b) != want {
+		t.Errorf("text = %q, want %q", b, want)
+	}
+
+	b, err = (&JSONFormatter{Redactor: redactor}).Format(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("invalid JSON %s: %v", b, err)
+	}
+	for k, v := range map[string]string{"msg": "echo '******' | sudo -S true", "level": "info", common.Node: "node1", "sudo": "******"} {
+		if got[k] != v {
+			t.Errorf("json %s = %q, want %q", k, got[k], v)
+		}
+	}
+	if !strings.Contains(entry.Message, "P@ssw0rd") {
+		t.Errorf("the entry is masked in place, the other formatters get the masked message")
+	}
+}
+
+func TestSetFormat(t *testing.T) {
+	defer func() {
+		_ = SetFormat(FormatText)
+	}()
+	if err := SetFormat("yaml"); err == nil {
+		t.Errorf("SetFormat(yaml) should fail")
+	}
+	if err := SetFormat(FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := newFormatter(nil).(*JSONFormatter); !ok {
+		t.Errorf("the formatter of the json format = %T", newFormatter(nil))
+	}
+}
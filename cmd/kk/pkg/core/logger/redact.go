@@ -0,0 +1,223 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// DefaultReplacement replaces the redacted values if the rule doesn't define one.
+const DefaultReplacement = "******"
+
+// builtinRules mask the secrets which commonly appear in the commands and outputs.
+var builtinRules = []RedactionRule{
+	{Name: "private-key", Regex: `(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----.*?-----END [A-Z ]*PRIVATE KEY-----`},
+	{Name: "kubeadm-token", Regex: `\b[a-z0-9]{6}\.[a-z0-9]{16}\b`},
+	{Name: "certificate-key", Regex: `(--certificate-key[ =]|certificateKey:\s*|certificate key:\s*)[a-f0-9]{64}`, Replacement: "${1}" + DefaultReplacement},
+	{Name: "credential", Regex: `(?i)((?:password|passwd|secret|token|access[_-]?key)"?\s*[:=]\s*"?)[^\s",]+`, Replacement: "${1}" + DefaultReplacement},
+}
+
+// RedactionConfig defines the redaction rules applied to the console output and logs, on top of the built-in rules.
+type RedactionConfig struct {
+	Rules []RedactionRule `yaml:"rules"`
+}
+
+// RedactionRule masks the values matched by a regex or selected by a JSONPath.
+type RedactionRule struct {
+	Name string `yaml:"name"`
+	// Regex matches the values to mask, the capture groups can be referenced in the replacement, e.g. ${1}.
+	Regex string `yaml:"regex"`
+	// JSONPath selects the values to mask in the JSON documents printed, e.g. {.data.license}.
+	JSONPath string `yaml:"jsonPath"`
+	// Replacement of the masked values. [Default: ******]
+	Replacement string `yaml:"replacement"`
+
+	re   *regexp.Regexp
+	path *jsonpath.JSONPath
+}
+
+func LoadRedactionConfig(path string) (*RedactionConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read redaction config %s", path)
+	}
+	cfg := &RedactionConfig{}
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal redaction config %s", path)
+	}
+	return cfg, nil
+}
+
+func (r *RedactionRule) compile() error {
+	if r.Replacement == "" {
+		r.Replacement = DefaultReplacement
+	}
+	switch {
+	case r.Regex != "" && r.JSONPath != "":
+		return errors.Errorf("redaction rule %q can only define one of regex and jsonPath", r.Name)
+	case r.Regex != "":
+		re, err := regexp.Compile(r.Regex)
+		if err != nil {
+			return errors.Wrapf(err, "invalid regex of redaction rule %q", r.Name)
+		}
+		r.re = re
+	case r.JSONPath != "":
+		expr := r.JSONPath
+		if !strings.HasPrefix(expr, "{") {
+			expr = fmt.Sprintf("{%s}", expr)
+		}
+		path := jsonpath.New(r.Name).AllowMissingKeys(true)
+		if err := path.Parse(expr); err != nil {
+			return errors.Wrapf(err, "invalid jsonPath of redaction rule %q", r.Name)
+		}
+		r.path = path
+	default:
+		return errors.Errorf("redaction rule %q must define one of regex and jsonPath", r.Name)
+	}
+	return nil
+}
+
+// Redactor masks the secrets of the messages with the built-in rules, the user defined rules and the known secret values.
+type Redactor struct {
+	mu      sync.RWMutex
+	rules   []*RedactionRule
+	secrets []string
+}
+
+func NewRedactor() *Redactor {
+	r := &Redactor{}
+	for i := range builtinRules {
+		rule := builtinRules[i]
+		if err := rule.compile(); err != nil {
+			panic(err)
+		}
+		r.rules = append(r.rules, &rule)
+	}
+	return r
+}
+
+// AddRules appends the rules, which are applied after the built-in rules in order.
+func (r *Redactor) AddRules(rules ...RedactionRule) error {
+	compiled := make([]*RedactionRule, 0, len(rules))
+	for i := range rules {
+		rule := rules[i]
+		if err := rule.compile(); err != nil {
+			return err
+		}
+		compiled = append(compiled, &rule)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = append(r.rules, compiled...)
+	return nil
+}
+
+// AddSecrets masks the literal values wherever they appear, such as the passwords of the hosts.
+func (r *Redactor) AddSecrets(secrets ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range secrets {
+		if s != "" {
+			r.secrets = append(r.secrets, s)
+		}
+	}
+	// mask the longer secrets first, so that a secret containing another one is masked entirely
+	sort.Slice(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+}
+
+func (r *Redactor) Redact(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, DefaultReplacement)
+	}
+
+	var doc interface{}
+	parsed := false
+	for _, rule := range r.rules {
+		if rule.re != nil {
+			s = rule.re.ReplaceAllString(s, rule.Replacement)
+			continue
+		}
+		if !parsed {
+			doc, parsed = parseJSON(s), true
+		}
+		if doc == nil {
+			continue
+		}
+		for _, value := range selectValues(rule.path, doc) {
+			s = strings.ReplaceAll(s, value, rule.Replacement)
+		}
+	}
+	return s
+}
+
+// parseJSON returns the JSON document in the message, the message may start with a prefix such as "message: [node1]".
+func parseJSON(s string) interface{} {
+	for i := strings.IndexAny(s, "{["); i >= 0; {
+		var doc interface{}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(s[i:])), &doc); err == nil {
+			return doc
+		}
+		next := strings.IndexAny(s[i+1:], "{[")
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return nil
+}
+
+// selectValues returns the scalar values selected by the JSONPath, they are masked wherever they appear in the message.
+func selectValues(path *jsonpath.JSONPath, doc interface{}) []string {
+	results, err := path.FindResults(doc)
+	if err != nil {
+		return nil
+	}
+	var values []string
+	for _, result := range results {
+		for _, v := range result {
+			if !v.IsValid() || !v.CanInterface() {
+				continue
+			}
+			switch value := v.Interface().(type) {
+			case string:
+				if value != "" {
+					values = append(values, value)
+				}
+			case float64, bool:
+				values = append(values, fmt.Sprint(value))
+			}
+		}
+	}
+	return values
+}
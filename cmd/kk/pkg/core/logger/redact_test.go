@@ -0,0 +1,83 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package logger
+
+import (
+	"testing"
+)
+
+func TestRedactor_Redact(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []RedactionRule
+		secrets []string
+		message string
+		want    string
+	}{
+		{
+			name:    "builtin kubeadm token",
+			message: "kubeadm join lb.kubesphere.local:6443 --token abcdef.0123456789abcdef",
+			want:    "kubeadm join lb.kubesphere.local:6443 --token ******",
+		},
+		{
+			name:    "builtin certificate key",
+			message: "[upload-certs] Using certificate key:\n7e7e4ff1c1d3e1e3c7d0e0c3a3a8a9e2c9f2b7d9d5c4a1b8e6f3a2d1c0b9a8f7",
+			want:    "[upload-certs] Using certificate key:\n******",
+		},
+		{
+			name:    "builtin credential",
+			message: `{"username": "admin", "password": "Qcloud@123"}`,
+			want:    `{"username": "admin", "password": "******"}`,
+		},
+		{
+			name:    "secret of host",
+			secrets: []string{"P@ssw0rd"},
+			message: "echo 'P@ssw0rd' | sudo -S true",
+			want:    "echo '******' | sudo -S true",
+		},
+		{
+			name:    "regex rule",
+			rules:   []RedactionRule{{Name: "hostname", Regex: `[a-z0-9-]+\.corp\.internal`, Replacement: "<host>"}},
+			message: "ping node1.corp.internal failed",
+			want:    "ping <host> failed",
+		},
+		{
+			name:    "jsonPath rule",
+			rules:   []RedactionRule{{Name: "license", JSONPath: ".data.license"}},
+			message: "message: [node1]\n{\"data\": {\"license\": \"XK2-99F\"}}",
+			want:    "message: [node1]\n{\"data\": {\"license\": \"******\"}}",
+		},
+		{
+			name:    "jsonPath rule without json",
+			rules:   []RedactionRule{{Name: "license", JSONPath: "{.data.license}"}},
+			message: "license XK2-99F",
+			want:    "license XK2-99F",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRedactor()
+			if err := r.AddRules(tt.rules...); err != nil {
+				t.Fatal(err)
+			}
+			r.AddSecrets(tt.secrets...)
+			if got := r.Redact(tt.message); got != tt.want {
+				t.Errorf("Redact() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
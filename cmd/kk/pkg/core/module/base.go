@@ -33,6 +33,8 @@ type BaseModule struct {
 	PipelineCache *cache.Cache
 	Runtime       connector.ModuleRuntime
 	PostHook      []PostHookInterface
+	// Tags select the module by --tags and --skip-tags besides its name, e.g. network or certs.
+	Tags []string
 }
 
 func (b *BaseModule) IsSkip() bool {
@@ -59,6 +61,15 @@ func (b *BaseModule) Run(result *ending.ModuleResult) {
 	panic("implement me")
 }
 
+func (b *BaseModule) GetName() string {
+	return b.Name
+}
+
+// GetTags returns the tags of the module, which include its name.
+func (b *BaseModule) GetTags() []string {
+	return append(append([]string{}, b.Tags...), b.Name)
+}
+
 func (b *BaseModule) Until() (*bool, error) {
 	return nil, nil
 }
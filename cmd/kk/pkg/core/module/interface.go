@@ -27,6 +27,8 @@ type Module interface {
 	Default(runtime connector.Runtime, pipelineCache *cache.Cache, moduleCache *cache.Cache)
 	Init()
 	Is() string
+	GetName() string
+	GetTags() []string
 	Run(result *ending.ModuleResult)
 	Until() (*bool, error)
 	Slogan()
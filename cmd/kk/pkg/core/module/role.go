@@ -0,0 +1,75 @@
+/*
+ Copyright 2021 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package module
+
+import (
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/cache"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+// Role composes the modules of a reusable step of the pipelines, e.g. the etcd shared by the create cluster, the add
+// nodes and the scale pipelines, which include its modules instead of repeating them. A role can include the modules
+// of other roles.
+type Role struct {
+	// Name selects all the modules of the role by --tags and --skip-tags, as the name of a module does.
+	Name string
+	// Tags are added to the tags of each module of the role.
+	Tags []string
+	// Vars are set in the module cache of each module of the role, so they are only visible to the modules of the
+	// role. The vars set by the Default of a module take precedence over the ones of its roles, and the vars of an
+	// included role over the ones of the role including it.
+	Vars    map[string]interface{}
+	Modules []Module
+}
+
+// Include returns the modules of the role, to be added to the modules of a pipeline.
+func (r *Role) Include() []Module {
+	modules := make([]Module, 0, len(r.Modules))
+	for _, m := range r.Modules {
+		modules = append(modules, &roleModule{Module: m, role: r})
+	}
+	return modules
+}
+
+// roleModule is a module included by a role.
+type roleModule struct {
+	Module
+	role *Role
+}
+
+func (m *roleModule) Default(runtime connector.Runtime, pipelineCache *cache.Cache, moduleCache *cache.Cache) {
+	m.Module.Default(runtime, pipelineCache, moduleCache)
+	for k, v := range m.role.Vars {
+		moduleCache.GetOrSet(k, v)
+	}
+}
+
+// GetTags returns the tags of the module with the name and the tags of the role.
+func (m *roleModule) GetTags() []string {
+	tags := m.Module.GetTags()
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		seen[tag] = true
+	}
+	for _, tag := range append([]string{m.role.Name}, m.role.Tags...) {
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,134 @@
+/*
+ Copyright 2021 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package module
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/cache"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+func TestRole(t *testing.T) {
+	certs := &BaseTaskModule{BaseModule: BaseModule{Name: "CertsModule", Tags: []string{"certs"}}}
+	install := &BaseTaskModule{BaseModule: BaseModule{Name: "InstallModule", Tags: []string{"etcd"}}}
+	inner := &Role{Name: "etcd-certs", Vars: map[string]interface{}{"dir": "/etc/ssl/etcd"}, Modules: []Module{certs}}
+	outer := &Role{
+		Name:    "etcd",
+		Tags:    []string{"control-plane"},
+		Vars:    map[string]interface{}{"dir": "/etc/etcd", "version": "v3.5.6"},
+		Modules: append(inner.Include(), install),
+	}
+	modules := outer.Include()
+
+	wantTags := [][]string{
+		{"certs", "CertsModule", "etcd-certs", "etcd", "control-plane"},
+		{"etcd", "InstallModule", "control-plane"},
+	}
+	wantVars := []map[string]interface{}{
+		{"dir": "/etc/ssl/etcd", "version": "v3.5.6"},
+		{"dir": "/etc/etcd", "version": "v3.5.6"},
+	}
+	base := connector.NewBaseRuntime("test", &fakeConnector{}, false, false)
+	for i, m := range modules {
+		if got := m.GetTags(); !reflect.DeepEqual(got, wantTags[i]) {
+			t.Errorf("GetTags() of %s = %v, want %v", m.GetName(), got, wantTags[i])
+		}
+
+		moduleCache := cache.NewCache()
+		m.Default(&base, cache.NewCache(), moduleCache)
+		got := make(map[string]interface{})
+		moduleCache.Range(func(key, value interface{}) bool {
+			got[key.(string)] = value
+			return true
+		})
+		if !reflect.DeepEqual(got, wantVars[i]) {
+			t.Errorf("the vars of %s = %v, want %v", m.GetName(), got, wantVars[i])
+		}
+	}
+}
+
+func TestRoleModule_GetTags(t *testing.T) {
+	tests := []struct {
+		name string
+		role *Role
+		tags []string
+		want []string
+	}{
+		{
+			name: "name and tags of the role",
+			role: &Role{Name: "etcd", Tags: []string{"control-plane"}},
+			tags: []string{"certs"},
+			want: []string{"certs", "CertsModule", "etcd", "control-plane"},
+		},
+		{
+			name: "no duplicated tags",
+			role: &Role{Name: "etcd", Tags: []string{"certs", "etcd", "control-plane"}},
+			tags: []string{"certs", "etcd"},
+			want: []string{"certs", "etcd", "CertsModule", "control-plane"},
+		},
+		{
+			name: "role without a name",
+			role: &Role{Tags: []string{"control-plane"}},
+			want: []string{"CertsModule", "control-plane"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.role.Modules = []Module{&BaseTaskModule{BaseModule: BaseModule{Name: "CertsModule", Tags: tt.tags}}}
+			if got := tt.role.Include()[0].GetTags(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// varsModule sets its vars in the module cache by Default.
+type varsModule struct {
+	BaseTaskModule
+	vars map[string]interface{}
+}
+
+func (v *varsModule) Default(runtime connector.Runtime, pipelineCache *cache.Cache, moduleCache *cache.Cache) {
+	v.BaseTaskModule.Default(runtime, pipelineCache, moduleCache)
+	for k, val := range v.vars {
+		moduleCache.Set(k, val)
+	}
+}
+
+func TestRoleModule_Default(t *testing.T) {
+	m := &varsModule{BaseTaskModule: BaseTaskModule{BaseModule: BaseModule{Name: "CertsModule"}}, vars: map[string]interface{}{"mode": "0600"}}
+	inner := &Role{Name: "etcd-certs", Vars: map[string]interface{}{"dir": "/etc/ssl/etcd", "mode": "0644"}, Modules: []Module{m}}
+	outer := &Role{
+		Name:    "etcd",
+		Vars:    map[string]interface{}{"dir": "/etc/etcd", "mode": "0755", "version": "v3.5.6"},
+		Modules: inner.Include(),
+	}
+
+	base := connector.NewBaseRuntime("test", &fakeConnector{}, false, false)
+	moduleCache := cache.NewCache()
+	outer.Include()[0].Default(&base, cache.NewCache(), moduleCache)
+
+	// the vars set by the module win over the ones of its roles, and the inner role wins over the outer one
+	want := map[string]interface{}{"dir": "/etc/ssl/etcd", "mode": "0600", "version": "v3.5.6"}
+	for k, v := range want {
+		if got, ok := moduleCache.Get(k); !ok || got != v {
+			t.Errorf("the var %s = %v, want %v", k, got, v)
+		}
+	}
+}
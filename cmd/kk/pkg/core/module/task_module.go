@@ -17,10 +17,17 @@
 package module
 
 import (
+	"fmt"
+	"strings"
+	"sync"
+
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/ending"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/exporter"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
 )
@@ -28,6 +35,8 @@ import (
 type BaseTaskModule struct {
 	BaseModule
 	Tasks []task.Interface
+	// Strategy is how the tasks are executed on the hosts, the strategy of the runtime is used if its name is empty.
+	Strategy connector.Strategy
 }
 
 func (b *BaseTaskModule) Init() {
@@ -36,22 +45,89 @@ func (b *BaseTaskModule) Init() {
 	}
 }
 
+// log returns the logger of the entries about the module, with the fields of the pipeline it runs in.
+func (b *BaseTaskModule) log() logrus.FieldLogger {
+	return logger.FromContext(b.Runtime.GetContext()).WithField(common.Module, b.Name)
+}
+
 func (b *BaseTaskModule) Is() string {
 	return TaskModuleType
 }
 
 func (b *BaseTaskModule) Run(result *ending.ModuleResult) {
+	s := b.Strategy
+	if s.Name == "" {
+		s = b.Runtime.GetStrategy()
+	}
+	if err := s.Validate(); err != nil {
+		result.ErrResult(errors.Wrapf(err, "Module[%s] exec failed", b.Name))
+		return
+	}
+
+	planned := make(map[string]int)
+	for _, t := range b.Tasks {
+		if rt, ok := t.(*task.RemoteTask); ok {
+			for _, h := range rt.Hosts {
+				if h != nil {
+					planned[h.GetName()]++
+				}
+			}
+		}
+	}
+	result.Progress.PlanTasks(planned)
+
+	var err error
+	switch s.Name {
+	case connector.FreeStrategy:
+		err = b.runFree(result, s)
+	case connector.RollingStrategy:
+		err = b.runRolling(result, s)
+	default:
+		err = b.runTasks(result, s, nil, true)
+	}
+	if err != nil {
+		result.ErrResult(err)
+		return
+	}
+	result.NormalResult()
+}
+
+// runTasks runs each task on all its hosts before the next task. The remote tasks run only on the hosts in the batch
+// if it isn't nil, the other tasks run only if local is true.
+func (b *BaseTaskModule) runTasks(result *ending.ModuleResult, s connector.Strategy, batch []connector.Host, local bool) error {
+	total := len(batch)
+	if batch == nil {
+		total = len(b.hosts(b.Tasks))
+	}
+	failed := make(map[string]struct{})
 	for i := range b.Tasks {
 		t := b.Tasks[i]
+		rt, remote := t.(*task.RemoteTask)
+		if !remote && !local {
+			continue
+		}
 		t.Init(b.Runtime.(connector.Runtime), b.ModuleCache, b.PipelineCache)
+		var key string
+		if remote {
+			if batch != nil {
+				rt = rt.WithHosts(batch)
+				if len(rt.Hosts) == 0 {
+					continue
+				}
+			}
+			key = checkpointKey(result, i, rt)
+			t = b.resume(result, rt, key)
+			result.Progress.StartTask(rt.GetDesc(), names(rt.Hosts))
+			if recorder := b.Runtime.GetProgressRecorder(); recorder != nil {
+				recorder.StartTask(rt.GetDesc(), names(rt.Hosts))
+			}
+		}
 
-		logger.Log.Infof("[%s] %s", b.Name, t.GetDesc())
+		b.log().Info(t.GetDesc())
 		res := t.Execute()
+		b.appendResults(result, t, res, key)
 		for j := range res.ActionResults {
 			ac := res.ActionResults[j]
-			logger.Log.Infof("%s: [%s]", ac.Status.String(), ac.Host.GetName())
-			result.AppendHostResult(ac)
-
 			if _, ok := t.(*task.RemoteTask); ok {
 				if b.Runtime.GetIgnoreErr() {
 					if len(b.Runtime.GetAllHosts()) > 0 {
@@ -60,18 +136,285 @@ func (b *BaseTaskModule) Run(result *ending.ModuleResult) {
 							b.Runtime.DeleteHost(ac.Host)
 						}
 					} else {
-						result.ErrResult(errors.Wrapf(res.CombineErr(), "Module[%s] exec failed", b.Name))
-						return
+						return errors.Wrapf(res.CombineErr(), "Module[%s] exec failed", b.Name)
 					}
+				} else if s.MaxFailPercentage > 0 && ac.GetStatus() == ending.FAILED {
+					failed[ac.Host.GetName()] = struct{}{}
 				}
 			}
 		}
 
+		if res.IsFailed() && len(failed) > 0 && s.Tolerates(len(failed), total) {
+			b.removeFailedHosts(res, len(failed), total)
+			res.Status = ending.SUCCESS
+		}
 		if res.IsFailed() {
 			t.ExecuteRollback()
-			result.ErrResult(errors.Wrapf(res.CombineErr(), "Module[%s] exec failed", b.Name))
-			return
+			return b.failedErr(res, s, len(failed), total)
 		}
 	}
-	result.NormalResult()
+	return nil
+}
+
+// runRolling runs all the tasks on a batch of hosts before the next batch, the max fail percentage applies to each batch.
+// The tasks which aren't remote run once, along with the first batch.
+func (b *BaseTaskModule) runRolling(result *ending.ModuleResult, s connector.Strategy) error {
+	hosts := b.hosts(b.Tasks)
+	size, err := s.BatchSize(len(hosts))
+	if err != nil {
+		return errors.Wrapf(err, "Module[%s] exec failed", b.Name)
+	}
+	if size == 0 {
+		return b.runTasks(result, s, hosts, true)
+	}
+	batches := (len(hosts) + size - 1) / size
+	for i := 0; i < len(hosts); i += size {
+		end := i + size
+		if end > len(hosts) {
+			end = len(hosts)
+		}
+		batch := hosts[i:end]
+		b.log().Infof("batch %d/%d: %s", i/size+1, batches, hostNames(batch))
+		if err := b.runTasks(result, s, batch, i == 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runFree lets each host run through the consecutive remote tasks without waiting for the other hosts, a failed host
+// stops there. The other tasks wait for all the hosts, and run as in the linear strategy.
+func (b *BaseTaskModule) runFree(result *ending.ModuleResult, s connector.Strategy) error {
+	total := len(b.hosts(b.Tasks))
+	failed := make(map[string]struct{})
+	for i := 0; i < len(b.Tasks); {
+		var (
+			segment []*task.RemoteTask
+			keys    []string
+		)
+		for ; i < len(b.Tasks); i++ {
+			rt, ok := b.Tasks[i].(*task.RemoteTask)
+			if !ok {
+				break
+			}
+			segment = append(segment, rt)
+			keys = append(keys, checkpointKey(result, i, rt))
+		}
+		if len(segment) == 0 {
+			t := b.Tasks[i]
+			i++
+			t.Init(b.Runtime.(connector.Runtime), b.ModuleCache, b.PipelineCache)
+			b.log().Info(t.GetDesc())
+			res := t.Execute()
+			b.appendResults(result, t, res, "")
+			if res.IsFailed() {
+				t.ExecuteRollback()
+				return errors.Wrapf(res.CombineErr(), "Module[%s] exec failed", b.Name)
+			}
+			continue
+		}
+		if err := b.runFreeSegment(result, s, segment, keys, failed, total); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *BaseTaskModule) runFreeSegment(result *ending.ModuleResult, s connector.Strategy, segment []*task.RemoteTask,
+	keys []string, failed map[string]struct{}, total int) error {
+
+	tasks := make([]task.Interface, 0, len(segment))
+	// the tasks which aren't parallel still run on one host at a time
+	locks := make([]sync.Mutex, len(segment))
+	for _, t := range segment {
+		t.Init(b.Runtime.(connector.Runtime), b.ModuleCache, b.PipelineCache)
+		tasks = append(tasks, t)
+	}
+
+	var (
+		mu       sync.Mutex
+		abortErr error
+		wg       sync.WaitGroup
+	)
+	for _, host := range b.hosts(tasks) {
+		wg.Add(1)
+		go func(host connector.Host) {
+			defer wg.Done()
+			for i, t := range segment {
+				mu.Lock()
+				aborted := abortErr != nil
+				mu.Unlock()
+				if aborted {
+					return
+				}
+
+				rt := t.WithHosts([]connector.Host{host})
+				if len(rt.Hosts) == 0 {
+					continue
+				}
+				rt = b.resume(result, rt, keys[i])
+				if !t.Parallel {
+					locks[i].Lock()
+				}
+				b.log().WithField(common.Node, host.GetName()).Info(rt.GetDesc())
+				result.Progress.StartTask(rt.GetDesc(), []string{host.GetName()})
+				if recorder := b.Runtime.GetProgressRecorder(); recorder != nil {
+					recorder.StartTask(rt.GetDesc(), []string{host.GetName()})
+				}
+				res := rt.Execute()
+				if !t.Parallel {
+					locks[i].Unlock()
+				}
+
+				mu.Lock()
+				b.appendResults(result, rt, res, keys[i])
+				if !res.IsFailed() {
+					mu.Unlock()
+					continue
+				}
+				failed[host.GetName()] = struct{}{}
+				abort := !b.Runtime.GetIgnoreErr() && (s.MaxFailPercentage == 0 || !s.Tolerates(len(failed), total))
+				if abort && abortErr == nil {
+					abortErr = b.failedErr(res, s, len(failed), total)
+				}
+				mu.Unlock()
+				if abort {
+					rt.ExecuteRollback()
+				}
+				return
+			}
+		}(host)
+	}
+	wg.Wait()
+
+	if abortErr != nil {
+		return abortErr
+	}
+	for _, host := range b.Runtime.GetAllHosts() {
+		if _, ok := failed[host.GetName()]; ok && !b.Runtime.HostIsDeprecated(host) {
+			b.log().WithField(common.Node, host.GetName()).Warning("remove the failed host")
+			b.Runtime.DeleteHost(host)
+		}
+	}
+	return nil
+}
+
+// appendResults logs and appends the results of the task, and records the hosts which completed the task in the
+// checkpoint by the key of the task.
+func (b *BaseTaskModule) appendResults(result *ending.ModuleResult, t task.Interface, res *ending.TaskResult, key string) {
+	for j := range res.ActionResults {
+		ac := res.ActionResults[j]
+		log := b.log().WithField(common.Node, ac.Host.GetName())
+		log.Info(ac.GetState())
+		if ac.GetDiff() != "" {
+			// the diffs are the file changes reported by the check mode
+			if connector.IsDryRun(b.Runtime.(connector.Runtime).GetConnector()) {
+				log.Infof("diff:\n%s", ac.GetDiff())
+			} else {
+				log.Debugf("diff:\n%s", ac.GetDiff())
+			}
+		}
+		result.AppendHostResult(ac)
+		result.Progress.FinishTask(ac.Host.GetName(), ac.GetState())
+		if recorder := b.Runtime.GetProgressRecorder(); recorder != nil {
+			recorder.FinishTask(ac.Host.GetName(), t.GetDesc(), ac.GetState(), ac.GetErr())
+		}
+		exporter.Tasks.WithLabelValues(b.Name, ac.GetState()).Inc()
+		if key != "" && ac.GetStatus() == ending.SUCCESS {
+			result.Checkpoint.Complete(ac.Host.GetName(), key)
+		}
+	}
+	result.Report.AppendTask(b.Name, t.GetDesc(), res)
+	if key != "" {
+		if err := result.Checkpoint.Save(); err != nil {
+			b.log().Warnf("%v", err)
+		}
+	}
+}
+
+// resume returns the task without the hosts, which completed it in the previous run, they are reported as skipped.
+func (b *BaseTaskModule) resume(result *ending.ModuleResult, t *task.RemoteTask, key string) *task.RemoteTask {
+	if t.AlwaysRun || result.Checkpoint.Resumed() == 0 {
+		return t
+	}
+	var pending, done []connector.Host
+	for _, h := range t.Hosts {
+		if h == nil {
+			continue
+		}
+		if result.Checkpoint.Done(h.GetName(), key) {
+			done = append(done, h)
+		} else {
+			pending = append(pending, h)
+		}
+	}
+	if len(done) == 0 {
+		return t
+	}
+	b.log().Infof("%s: completed in the previous run: %s", t.GetDesc(), hostNames(done))
+	resumed := t.WithHosts(pending)
+	for _, h := range done {
+		resumed.TaskResult.AppendSkip(h)
+	}
+	return resumed
+}
+
+// checkpointKey identifies the task in the checkpoint by the indexes of the module and the task, and the task name.
+func checkpointKey(result *ending.ModuleResult, index int, t *task.RemoteTask) string {
+	return fmt.Sprintf("%d/%d.%s", result.Index, index, t.Name)
+}
+
+// removeFailedHosts removes the failed hosts of the task, which are within the max fail percentage.
+func (b *BaseTaskModule) removeFailedHosts(res *ending.TaskResult, failed, total int) {
+	for _, ac := range res.ActionResults {
+		if ac.GetStatus() != ending.FAILED {
+			continue
+		}
+		b.log().WithField(common.Node, ac.Host.GetName()).Warningf("remove the failed host, %d of %d hosts failed", failed, total)
+		b.Runtime.DeleteHost(ac.Host)
+	}
+}
+
+func (b *BaseTaskModule) failedErr(res *ending.TaskResult, s connector.Strategy, failed, total int) error {
+	if s.MaxFailPercentage > 0 && failed > 0 {
+		return errors.Wrapf(res.CombineErr(), "Module[%s] exec failed, %d of %d hosts failed, exceeding the max fail percentage %d%%",
+			b.Name, failed, total, s.MaxFailPercentage)
+	}
+	return errors.Wrapf(res.CombineErr(), "Module[%s] exec failed", b.Name)
+}
+
+// hosts returns the hosts of the remote tasks in order, without the removed hosts.
+func (b *BaseTaskModule) hosts(tasks []task.Interface) []connector.Host {
+	var hosts []connector.Host
+	seen := make(map[string]struct{})
+	for _, t := range tasks {
+		rt, ok := t.(*task.RemoteTask)
+		if !ok {
+			continue
+		}
+		for _, h := range rt.Hosts {
+			if h == nil || b.Runtime.HostIsDeprecated(h) {
+				continue
+			}
+			if _, ok := seen[h.GetName()]; !ok {
+				seen[h.GetName()] = struct{}{}
+				hosts = append(hosts, h)
+			}
+		}
+	}
+	return hosts
+}
+
+func hostNames(hosts []connector.Host) string {
+	return "[" + strings.Join(names(hosts), " ") + "]"
+}
+
+func names(hosts []connector.Host) []string {
+	list := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		if h != nil {
+			list = append(list, h.GetName())
+		}
+	}
+	return list
 }
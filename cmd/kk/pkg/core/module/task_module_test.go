@@ -0,0 +1,265 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package module
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/cache"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/ending"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+)
+
+type fakeConnector struct{}
+
+func (f *fakeConnector) Connect(host connector.Host) (connector.Connection, error) {
+	return nil, nil
+}
+
+func (f *fakeConnector) Close(host connector.Host) {}
+
+// recordAction records the host and the name of each execution, and fails on the hosts in fail.
+type recordAction struct {
+	action.BaseAction
+	name string
+	mu   *sync.Mutex
+	runs *[]string
+	fail map[string]bool
+}
+
+func (r *recordAction) Execute(runtime connector.Runtime) error {
+	host := runtime.RemoteHost().GetName()
+	r.mu.Lock()
+	*r.runs = append(*r.runs, fmt.Sprintf("%s:%s", r.name, host))
+	r.mu.Unlock()
+	if r.fail[host] {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func TestBaseTaskModule_Run(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy connector.Strategy
+		fail     map[string]bool
+		wantRuns []string
+		wantErr  bool
+	}{
+		{
+			name:     "linear",
+			wantRuns: []string{"t1:node1", "t1:node2", "t1:node3", "t2:node1", "t2:node2", "t2:node3"},
+		},
+		{
+			name:     "rolling",
+			strategy: connector.Strategy{Name: connector.RollingStrategy, Serial: "2"},
+			wantRuns: []string{"t1:node1", "t1:node2", "t2:node1", "t2:node2", "t1:node3", "t2:node3"},
+		},
+		{
+			name:     "any failure aborts",
+			strategy: connector.Strategy{Name: connector.RollingStrategy, Serial: "50%"},
+			fail:     map[string]bool{"node2": true},
+			wantRuns: []string{"t1:node1", "t1:node2"},
+			wantErr:  true,
+		},
+		{
+			name:     "failed host within max fail percentage is removed",
+			strategy: connector.Strategy{MaxFailPercentage: 40},
+			fail:     map[string]bool{"node2": true},
+			wantRuns: []string{"t1:node1", "t1:node2", "t1:node3", "t2:node1", "t2:node3"},
+		},
+		{
+			name:     "max fail percentage exceeded",
+			strategy: connector.Strategy{MaxFailPercentage: 40},
+			fail:     map[string]bool{"node1": true, "node2": true},
+			wantRuns: []string{"t1:node1", "t1:node2", "t1:node3"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := connector.NewBaseRuntime("test", &fakeConnector{}, false, false)
+			var hosts []connector.Host
+			for _, name := range []string{"node1", "node2", "node3"} {
+				h := &connector.BaseHost{Name: name, Cache: cache.NewCache()}
+				hosts = append(hosts, h)
+				base.AppendHost(h)
+			}
+			base.SetStrategy(tt.strategy)
+
+			var (
+				mu   sync.Mutex
+				runs []string
+			)
+			newTask := func(name string) task.Interface {
+				return &task.RemoteTask{
+					Name:   name,
+					Hosts:  hosts,
+					Action: &recordAction{name: name, mu: &mu, runs: &runs, fail: tt.fail},
+					Retry:  1,
+				}
+			}
+			m := &BaseTaskModule{Tasks: []task.Interface{newTask("t1"), newTask("t2")}}
+			m.Default(&base, cache.NewCache(), cache.NewCache())
+			m.Init()
+
+			result := ending.NewModuleResult()
+			m.Run(result)
+			if result.IsFailed() != tt.wantErr {
+				t.Errorf("Run() failed = %v, want %v: %v", result.IsFailed(), tt.wantErr, result.CombineResult)
+			}
+			if !reflect.DeepEqual(runs, tt.wantRuns) {
+				t.Errorf("Run() runs = %v, want %v", runs, tt.wantRuns)
+			}
+		})
+	}
+}
+
+func TestBaseTaskModule_RunRollingLocalTask(t *testing.T) {
+	base := connector.NewBaseRuntime("test", &fakeConnector{}, false, false)
+	var hosts []connector.Host
+	for _, name := range []string{"node1", "node2", "node3"} {
+		h := &connector.BaseHost{Name: name, Cache: cache.NewCache()}
+		hosts = append(hosts, h)
+		base.AppendHost(h)
+	}
+	base.SetStrategy(connector.Strategy{Name: connector.RollingStrategy, Serial: "1"})
+
+	var (
+		mu   sync.Mutex
+		runs []string
+	)
+	m := &BaseTaskModule{Tasks: []task.Interface{
+		&task.RemoteTask{Name: "t1", Hosts: hosts, Action: &recordAction{name: "t1", mu: &mu, runs: &runs}, Retry: 1},
+		&task.LocalTask{Name: "local", Action: &recordAction{name: "local", mu: &mu, runs: &runs}, Retry: 1},
+		&task.RemoteTask{Name: "t2", Hosts: hosts, Action: &recordAction{name: "t2", mu: &mu, runs: &runs}, Retry: 1},
+	}}
+	m.Default(&base, cache.NewCache(), cache.NewCache())
+	m.Init()
+
+	result := ending.NewModuleResult()
+	m.Run(result)
+	if result.IsFailed() {
+		t.Fatal(result.CombineResult)
+	}
+	want := []string{"t1:node1", "local:LocalHost", "t2:node1", "t1:node2", "t2:node2", "t1:node3", "t2:node3"}
+	if !reflect.DeepEqual(runs, want) {
+		t.Errorf("Run() runs = %v, want %v", runs, want)
+	}
+}
+
+func TestBaseTaskModule_RunResume(t *testing.T) {
+	base := connector.NewBaseRuntime("test", &fakeConnector{}, false, false)
+	var hosts []connector.Host
+	for _, name := range []string{"node1", "node2"} {
+		h := &connector.BaseHost{Name: name, Cache: cache.NewCache()}
+		hosts = append(hosts, h)
+		base.AppendHost(h)
+	}
+
+	file := filepath.Join(t.TempDir(), "checkpoint.json")
+	previous, err := ending.NewCheckpoint(file, "test", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous.Complete("node1", "0/0.t1")
+	previous.Complete("node1", "0/1.t2")
+	previous.Complete("node2", "0/0.t1")
+	if err := previous.Save(); err != nil {
+		t.Fatal(err)
+	}
+	checkpoint, err := ending.NewCheckpoint(file, "test", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu   sync.Mutex
+		runs []string
+	)
+	newTask := func(name string, alwaysRun bool) task.Interface {
+		return &task.RemoteTask{
+			Name:      name,
+			Hosts:     hosts,
+			Action:    &recordAction{name: name, mu: &mu, runs: &runs},
+			AlwaysRun: alwaysRun,
+			Retry:     1,
+		}
+	}
+	m := &BaseTaskModule{Tasks: []task.Interface{newTask("t1", false), newTask("t2", false), newTask("t3", true)}}
+	m.Default(&base, cache.NewCache(), cache.NewCache())
+	m.Init()
+
+	result := ending.NewModuleResult()
+	result.Checkpoint = checkpoint
+	m.Run(result)
+	if result.IsFailed() {
+		t.Fatalf("Run() failed: %v", result.CombineResult)
+	}
+	if want := []string{"t2:node2", "t3:node1", "t3:node2"}; !reflect.DeepEqual(runs, want) {
+		t.Errorf("Run() runs = %v, want %v", runs, want)
+	}
+}
+
+func TestBaseTaskModule_RunFree(t *testing.T) {
+	base := connector.NewBaseRuntime("test", &fakeConnector{}, false, false)
+	var hosts []connector.Host
+	for _, name := range []string{"node1", "node2", "node3"} {
+		h := &connector.BaseHost{Name: name, Cache: cache.NewCache()}
+		hosts = append(hosts, h)
+		base.AppendHost(h)
+	}
+
+	var (
+		mu   sync.Mutex
+		runs []string
+	)
+	newTask := func(name string) task.Interface {
+		return &task.RemoteTask{
+			Name:     name,
+			Hosts:    hosts,
+			Action:   &recordAction{name: name, mu: &mu, runs: &runs, fail: map[string]bool{"node2": true}},
+			Parallel: true,
+			Retry:    1,
+		}
+	}
+	m := &BaseTaskModule{
+		Tasks:    []task.Interface{newTask("t1"), newTask("t2")},
+		Strategy: connector.Strategy{Name: connector.FreeStrategy, MaxFailPercentage: 50},
+	}
+	m.Default(&base, cache.NewCache(), cache.NewCache())
+	m.Init()
+
+	result := ending.NewModuleResult()
+	m.Run(result)
+	if result.IsFailed() {
+		t.Fatalf("Run() failed: %v", result.CombineResult)
+	}
+	if len(runs) != 5 {
+		t.Errorf("Run() runs = %v, want t1 on all the hosts and t2 on node1 and node3", runs)
+	}
+	if !base.HostIsDeprecated(hosts[1]) {
+		t.Errorf("the failed host node2 isn't removed")
+	}
+}
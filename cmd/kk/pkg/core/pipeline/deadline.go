@@ -0,0 +1,101 @@
+/*
+ Copyright 2021 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/ending"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+// moduleTiming is the time a module of the pipeline took.
+type moduleTiming struct {
+	module   string
+	tags     []string
+	duration time.Duration
+	aborted  bool
+}
+
+// runModuleWithDeadline runs the module within the earliest deadline of the run and its phases, it returns an error
+// if the deadline is exceeded. The tasks of the module are executed within the ctx.
+func (p *Pipeline) runModuleWithDeadline(ctx context.Context, index int, m module.Module) (*ending.ModuleResult, error) {
+	start := time.Now()
+	p.Runtime.SetContext(ctx)
+	defer p.Runtime.SetContext(nil)
+	deadline, ok := p.Runtime.GetDeadlines().Next(m.GetTags(), start)
+	if !ok {
+		res := p.RunModule(index, m)
+		p.recordTiming(m, time.Since(start), false)
+		return res, nil
+	}
+	if !start.Before(deadline.Time) {
+		p.recordTiming(m, 0, true)
+		return nil, p.deadlineErr(deadline)
+	}
+
+	ctx, cancel := context.WithDeadline(ctx, deadline.Time)
+	defer cancel()
+	p.Runtime.SetContext(ctx)
+
+	res := p.RunModule(index, m)
+	exceeded := res.IsFailed() && errors.Is(ctx.Err(), context.DeadlineExceeded)
+	p.recordTiming(m, time.Since(start), exceeded)
+	if exceeded {
+		return res, p.deadlineErr(deadline)
+	}
+	return res, nil
+}
+
+func (p *Pipeline) recordTiming(m module.Module, d time.Duration, aborted bool) {
+	tags := make([]string, 0, len(m.GetTags()))
+	for _, tag := range m.GetTags() {
+		if tag != m.GetName() {
+			tags = append(tags, tag)
+		}
+	}
+	p.timings = append(p.timings, moduleTiming{module: m.GetName(), tags: tags, duration: d, aborted: aborted})
+}
+
+// deadlineErr logs the timing of the modules run, and returns the error of the exceeded deadline.
+func (p *Pipeline) deadlineErr(deadline connector.Deadline) error {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 10, 4, 3, ' ', 0)
+	fmt.Fprintln(w, "MODULE\tTAGS\tDURATION")
+	for _, t := range p.timings {
+		duration := util.ShortDur(t.duration.Round(time.Second))
+		if t.duration < time.Second {
+			duration = t.duration.Round(time.Millisecond).String()
+		}
+		if t.aborted {
+			duration += " (aborted)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", t.module, strings.Join(t.tags, ","), duration)
+	}
+	_ = w.Flush()
+	p.log().Errorf("exceeded %s, the timing of the modules:\n%s", deadline, buf.String())
+	return errors.Errorf("Pipeline[%s] aborted: exceeded %s", p.Name, deadline)
+}
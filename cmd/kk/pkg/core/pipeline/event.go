@@ -0,0 +1,105 @@
+/*
+ Copyright 2021 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/event"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+// phase is a phase of the pipeline, i.e. the consecutive modules with the same tag.
+type phase struct {
+	start   time.Time
+	modules []string
+}
+
+func (p *Pipeline) fireEvent(e event.Event) {
+	e.Cluster = p.Runtime.GetObjName()
+	e.Pipeline = p.Name
+	e.Run = p.Report.Run
+	p.Runtime.GetEvents().Fire(p.ctx, e)
+}
+
+func (p *Pipeline) startEvents() {
+	p.started = time.Now()
+	if p.Runtime.GetEvents() == nil {
+		return
+	}
+	p.phases = make(map[string]*phase)
+	p.fireEvent(event.Event{Type: event.PipelineStart, Time: p.started})
+}
+
+// startPhases completes the phases the module doesn't belong to, and starts the phases of its tags.
+func (p *Pipeline) startPhases(m module.Module) {
+	if p.phases == nil {
+		return
+	}
+	tags := make(map[string]bool)
+	for _, tag := range m.GetTags() {
+		if tag != m.GetName() && tag != connector.AlwaysTag {
+			tags[tag] = true
+		}
+	}
+	p.completePhases(tags)
+	now := time.Now()
+	for tag := range tags {
+		if p.phases[tag] == nil {
+			p.phases[tag] = &phase{start: now}
+		}
+		p.phases[tag].modules = append(p.phases[tag].modules, m.GetName())
+	}
+}
+
+// completePhases fires the completion of the phases except the given ones, by their names.
+func (p *Pipeline) completePhases(except map[string]bool) {
+	names := make([]string, 0, len(p.phases))
+	for name := range p.phases {
+		if !except[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ph := p.phases[name]
+		delete(p.phases, name)
+		p.fireEvent(event.Event{
+			Type:     event.PhaseComplete,
+			Phase:    name,
+			Modules:  ph.modules,
+			Duration: util.ShortDur(time.Since(ph.start).Round(time.Second)),
+		})
+	}
+}
+
+// finishEvents fires the end of the pipeline, the phases running when the pipeline failed aren't complete.
+func (p *Pipeline) finishEvents(err error) {
+	if p.phases == nil {
+		return
+	}
+	duration := util.ShortDur(time.Since(p.started).Round(time.Second))
+	if err != nil {
+		p.fireEvent(event.Event{Type: event.PipelineFailure, Error: err.Error(), Duration: duration})
+		return
+	}
+	p.completePhases(nil)
+	p.fireEvent(event.Event{Type: event.PipelineFinish, Duration: duration})
+}
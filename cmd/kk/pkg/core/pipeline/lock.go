@@ -0,0 +1,73 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
+)
+
+// acquireClusterLock acquires the lock of the cluster against the runs of kk from the other machines, if it's set.
+func (p *Pipeline) acquireClusterLock() error {
+	l := p.Runtime.GetClusterLock()
+	if l == nil {
+		return nil
+	}
+	if err := l.Acquire(p.Runtime); err != nil {
+		return errors.Wrapf(err, "Pipeline[%s] can't lock the cluster", p.Name)
+	}
+	return nil
+}
+
+// renewClusterLock renews the lock of the cluster after a module, the run goes on if it fails since the lock only
+// expires after its duration.
+func (p *Pipeline) renewClusterLock() {
+	if l := p.Runtime.GetClusterLock(); l != nil {
+		if err := l.Renew(p.Runtime); err != nil {
+			p.log().Warnf("failed to renew the lock of the cluster: %v", err)
+		}
+	}
+}
+
+func (p *Pipeline) releaseClusterLock() {
+	if l := p.Runtime.GetClusterLock(); l != nil {
+		l.Release(p.Runtime)
+	}
+}
+
+// checkMaintenanceWindow refuses to start the disruptive phases of the module outside the maintenance windows. The
+// check mode and --ignore-maintenance-window only warn of them.
+func (p *Pipeline) checkMaintenanceWindow(m module.Module) error {
+	windows := p.Runtime.GetMaintenanceWindows()
+	err := windows.Check(m.GetTags(), time.Now())
+	if err == nil {
+		return nil
+	}
+	if connector.IsDryRun(p.Runtime.GetConnector()) {
+		p.log().Warnf("the run would be refused: %v", err)
+		return nil
+	}
+	if windows.Ignore {
+		p.log().Warnf("%v, which is ignored by --ignore-maintenance-window", err)
+		return nil
+	}
+	return errors.Wrapf(err, "Pipeline[%s] refused to start the module %s", p.Name, m.GetName())
+}
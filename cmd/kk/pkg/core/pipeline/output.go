@@ -0,0 +1,69 @@
+/*
+ Copyright 2021 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+// log returns the logger of the entries about the pipeline.
+func (p *Pipeline) log() logrus.FieldLogger {
+	return logger.Log.WithField(common.Pipeline, p.Name)
+}
+
+// startOutput passes the commands executed on the hosts and their output to the terminal UI, and to the log file of
+// each host in the dir of the run if the host logs are enabled.
+func (p *Pipeline) startOutput() {
+	var handlers []connector.OutputHandler
+	if p.Progress != nil {
+		handlers = append(handlers, p.Progress)
+	}
+	if p.Runtime.GetHostLogs() {
+		run := p.Report.Run
+		if run == "" {
+			run = connector.NewRunID(time.Now())
+		}
+		hostLogs, err := connector.NewHostLogs(filepath.Join(p.Runtime.GetClusterWorkDir(), common.RunsDir, run))
+		if err != nil {
+			p.log().Warnf("%v", err)
+		} else {
+			p.hostLogs = hostLogs
+			handlers = append(handlers, hostLogs)
+		}
+	}
+	if len(handlers) > 0 {
+		connector.SetOutputHandler(connector.MultiOutputHandler(handlers...))
+	}
+}
+
+func (p *Pipeline) stopOutput() {
+	connector.SetOutputHandler(nil)
+	if p.hostLogs == nil {
+		return
+	}
+	if err := p.hostLogs.Close(); err != nil {
+		p.log().Warnf("%v", err)
+	}
+	p.log().Infof("the commands executed on the hosts and their output are logged in %s", p.hostLogs.Dir())
+}
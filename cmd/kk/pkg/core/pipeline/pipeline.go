@@ -17,17 +17,24 @@
 package pipeline
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/cache"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/ending"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/exporter"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/tui"
 )
 
 var logo = `
@@ -51,12 +58,48 @@ type Pipeline struct {
 	PipelineCache   *cache.Cache
 	ModuleCachePool sync.Pool
 	ModulePostHooks []module.PostHookInterface
+	Summary         *ending.Summary
+	Report          *ending.Report
+	Checkpoint      *ending.Checkpoint
+	Progress        *tui.Progress
+
+	timings []moduleTiming
+	started time.Time
+	phases  map[string]*phase
+	// phase is the phase of the running module in the progress recorder.
+	phase string
+	// ctx carries the span of the pipeline, the spans of the modules are its children.
+	ctx      context.Context
+	span     trace.Span
+	hostLogs *connector.HostLogs
 }
 
 func (p *Pipeline) Init() error {
 	fmt.Print(logo)
 	p.PipelineCache = cache.NewCache()
 	p.SpecHosts = len(p.Runtime.GetAllHosts())
+	p.Summary = ending.NewSummary()
+	p.Report = ending.NewReport(p.Name)
+	p.Report.Run = p.Runtime.GetHistory().Run()
+	// the check mode doesn't complete any task
+	if !connector.IsDryRun(p.Runtime.GetConnector()) {
+		file := filepath.Join(p.Runtime.GetClusterWorkDir(), common.CheckpointsDir, p.Name+".json")
+		checkpoint, err := ending.NewCheckpoint(file, p.Name, p.Runtime.GetResume())
+		if err != nil {
+			return err
+		}
+		if n := checkpoint.Resumed(); n > 0 {
+			p.log().Infof("resume from the checkpoint %s, skip %d tasks completed in the previous run", file, n)
+		}
+		p.Checkpoint = checkpoint
+	}
+	if p.Runtime.GetTUI() {
+		hosts := make([]string, 0, p.SpecHosts)
+		for _, host := range p.Runtime.GetAllHosts() {
+			hosts = append(hosts, host.GetName())
+		}
+		p.Progress = tui.New(p.Name, len(p.Modules), hosts)
+	}
 	//if err := p.Runtime.GenerateWorkDir(); err != nil {
 	//	return err
 	//}
@@ -66,10 +109,33 @@ func (p *Pipeline) Init() error {
 	return nil
 }
 
-func (p *Pipeline) Start() error {
+func (p *Pipeline) Start() (err error) {
 	if err := p.Init(); err != nil {
 		return errors.Wrapf(err, "Pipeline[%s] execute failed", p.Name)
 	}
+	if err := p.acquireClusterLock(); err != nil {
+		return err
+	}
+	defer p.releaseClusterLock()
+	p.startTrace()
+	p.startProgress()
+	p.startOutput()
+	p.startEvents()
+	defer func() {
+		p.stopOutput()
+		p.stopProgress(err)
+		if summary := p.Summary.String(); summary != "" {
+			p.log().Infof("summary:\n%s", summary)
+		}
+		p.recapMetrics()
+		p.recordQuarantine()
+		p.writeReport(err)
+		p.finishCheckpoint(err)
+		p.finishEvents(err)
+		p.finishProgress(err)
+		p.exportMetrics(err)
+		p.finishTrace(err)
+	}()
 	for i := range p.Modules {
 		m := p.Modules[i]
 		if m.IsSkip() {
@@ -80,19 +146,36 @@ func (p *Pipeline) Start() error {
 		m.Default(p.Runtime, p.PipelineCache, moduleCache)
 		m.AutoAssert()
 		m.Init()
+		// the tags are set by the init of the module
+		if filter := p.Runtime.GetTagFilter(); !filter.Selects(m.GetTags()) {
+			p.log().Debugf("skip the module %s by the tags", m.GetName())
+			p.releaseModuleCache(moduleCache)
+			continue
+		}
 		for j := range p.ModulePostHooks {
 			m.AppendPostHook(p.ModulePostHooks[j])
 		}
+		if err := p.checkMaintenanceWindow(m); err != nil {
+			return err
+		}
 
-		res := p.RunModule(m)
-		err := m.CallPostHook(res)
+		p.startPhases(m)
+		p.recordPhase(m)
+		res, err := p.runModuleTraced(i, m)
+		if err != nil {
+			exporter.ModuleFailures.WithLabelValues(p.Name, m.GetName()).Inc()
+			return err
+		}
+		err = m.CallPostHook(res)
 		if res.IsFailed() {
+			exporter.ModuleFailures.WithLabelValues(p.Name, m.GetName()).Inc()
 			return errors.Wrapf(res.CombineResult, "Pipeline[%s] execute failed", p.Name)
 		}
 		if err != nil {
 			return errors.Wrapf(err, "Pipeline[%s] execute failed", p.Name)
 		}
 		p.releaseModuleCache(moduleCache)
+		p.renewClusterLock()
 	}
 	p.releasePipelineCache()
 
@@ -104,14 +187,21 @@ func (p *Pipeline) Start() error {
 	if p.SpecHosts != len(p.Runtime.GetAllHosts()) {
 		return errors.Errorf("Pipeline[%s] execute failed: there are some error in your spec hosts", p.Name)
 	}
-	logger.Log.Infof("Pipeline[%s] execute successfully", p.Name)
+	p.log().Info("execute successfully")
 	return nil
 }
 
-func (p *Pipeline) RunModule(m module.Module) *ending.ModuleResult {
+// RunModule runs the module, the index of the module in the pipeline identifies its tasks in the checkpoint.
+func (p *Pipeline) RunModule(index int, m module.Module) *ending.ModuleResult {
 	m.Slogan()
 
 	result := ending.NewModuleResult()
+	result.Summary = p.Summary
+	result.Report = p.Report
+	result.Checkpoint = p.Checkpoint
+	result.Progress = p.Progress
+	result.Index = index
+	p.Progress.StartModule(index, m.GetName())
 	for {
 		switch m.Is() {
 		case module.TaskModuleType:
@@ -146,6 +236,112 @@ func (p *Pipeline) RunModule(m module.Module) *ending.ModuleResult {
 	return result
 }
 
+// startProgress draws the progress of the pipeline in the terminal, the logs and the output of the commands are
+// printed in its log pane.
+func (p *Pipeline) startProgress() {
+	if p.Progress == nil {
+		return
+	}
+	if err := p.Progress.Start(); err != nil {
+		p.log().Warnf("failed to start the terminal UI: %v", err)
+		p.Progress = nil
+		return
+	}
+	logger.Log.SetOutput(p.Progress)
+}
+
+func (p *Pipeline) stopProgress(err error) {
+	if p.Progress == nil {
+		return
+	}
+	p.Progress.Finish(err == nil)
+	p.Progress.Stop()
+	logger.Log.SetOutput(os.Stderr)
+}
+
+// recapMetrics logs the operations measured on each host and warns about the hosts much slower than the others,
+// which often point at flaky infrastructure rather than at kk.
+func (p *Pipeline) recapMetrics() {
+	metrics := connector.MetricsOf(p.Runtime.GetConnector())
+	if metrics == nil {
+		return
+	}
+	if recap := metrics.String(); recap != "" {
+		p.log().Infof("host metrics:\n%s", recap)
+	}
+	outliers := metrics.Outliers(connector.SlowHostFactor)
+	for _, outlier := range outliers {
+		p.log().Warnf("slow host: %s", outlier)
+	}
+	p.Report.SetMetrics(metrics.Snapshot(), outliers)
+}
+
+// exportMetrics counts the run of the pipeline and its duration in the metrics exposed to Prometheus.
+func (p *Pipeline) exportMetrics(err error) {
+	result := "succeeded"
+	if err != nil {
+		result = "failed"
+	}
+	exporter.Pipelines.WithLabelValues(p.Name, result).Inc()
+	exporter.PipelineDuration.WithLabelValues(p.Name).Observe(time.Since(p.started).Seconds())
+}
+
+// recordQuarantine counts the runs each host was unreachable in, quarantining the hosts unreachable for too long
+// and releasing the ones quarantined automatically which are reached again.
+func (p *Pipeline) recordQuarantine() {
+	q := p.Runtime.GetQuarantine()
+	metrics := connector.MetricsOf(p.Runtime.GetConnector())
+	if q == nil || metrics == nil || connector.IsDryRun(p.Runtime.GetConnector()) {
+		return
+	}
+	quarantined, released := q.Record(metrics.Snapshot(), connector.QuarantineThreshold, time.Now())
+	for _, host := range quarantined {
+		p.log().Warnf("quarantine the host %s unreachable in the last %d runs, the next runs skip it unless --include-quarantined is set",
+			host, connector.QuarantineThreshold)
+	}
+	for _, host := range released {
+		p.log().Infof("release the host %s from the quarantine, it is reachable again", host)
+	}
+	if err := q.Save(); err != nil {
+		p.log().Warnf("%v", err)
+	}
+}
+
+// writeReport writes the run report, a failure to write it is logged and doesn't fail the pipeline.
+func (p *Pipeline) writeReport(err error) {
+	p.Report.Finish(p.Summary, err)
+	reportFile, junitReportFile := p.Runtime.GetReportFiles()
+	if err := p.Report.WriteJSON(reportFile); err != nil {
+		p.log().Warnf("%v", err)
+	} else {
+		p.log().Debugf("report: %s", reportFile)
+	}
+	if junitReportFile == "" {
+		return
+	}
+	if err := p.Report.WriteJUnit(junitReportFile); err != nil {
+		p.log().Warnf("%v", err)
+	}
+}
+
+// finishCheckpoint removes the checkpoint after the pipeline succeeded, or tells how to resume after it failed.
+func (p *Pipeline) finishCheckpoint(err error) {
+	if p.Checkpoint == nil {
+		return
+	}
+	if err == nil {
+		if err := p.Checkpoint.Remove(); err != nil {
+			p.log().Warnf("%v", err)
+		}
+		return
+	}
+	if err := p.Checkpoint.Save(); err != nil {
+		p.log().Warnf("%v", err)
+		return
+	}
+	p.log().Infof("the progress is saved to %s, run the command again with --resume to continue", p.Checkpoint.File())
+}
+
 func (p *Pipeline) newModuleCache() *cache.Cache {
 	moduleCache, ok := p.ModuleCachePool.Get().(*cache.Cache)
 	if ok {
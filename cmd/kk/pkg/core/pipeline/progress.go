@@ -0,0 +1,51 @@
+/*
+ Copyright 2021 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
+)
+
+// modulePhase returns the phase the module is recorded in, its first tag other than its name and always, e.g. etcd,
+// or its name if it has no other tag.
+func modulePhase(m module.Module) string {
+	for _, tag := range m.GetTags() {
+		if tag != m.GetName() && tag != connector.AlwaysTag {
+			return tag
+		}
+	}
+	return m.GetName()
+}
+
+// recordPhase starts the phase of the module in the progress recorder, unless it is the running one.
+func (p *Pipeline) recordPhase(m module.Module) {
+	recorder := p.Runtime.GetProgressRecorder()
+	if recorder == nil {
+		return
+	}
+	if phase := modulePhase(m); phase != p.phase {
+		p.phase = phase
+		recorder.StartPhase(phase)
+	}
+}
+
+func (p *Pipeline) finishProgress(err error) {
+	if recorder := p.Runtime.GetProgressRecorder(); recorder != nil {
+		recorder.Finish(err)
+	}
+}
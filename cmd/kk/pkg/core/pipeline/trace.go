@@ -0,0 +1,63 @@
+/*
+ Copyright 2021 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/ending"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/tracing"
+)
+
+func (p *Pipeline) startTrace() {
+	ctx := logger.WithFields(context.Background(), logrus.Fields{common.Pipeline: p.Name})
+	p.ctx, p.span = tracing.Start(ctx, "pipeline "+p.Name,
+		attribute.String("kubekey.pipeline", p.Name),
+		attribute.String("kubekey.cluster", p.Runtime.GetObjName()),
+		attribute.String("kubekey.run", p.Report.Run),
+		attribute.Int("kubekey.hosts", p.SpecHosts),
+	)
+}
+
+// finishTrace ends the span of the pipeline and exports the spans, kk may exit right after the pipeline.
+func (p *Pipeline) finishTrace(err error) {
+	tracing.End(p.span, err)
+	tracing.Flush()
+}
+
+// runModuleTraced runs the module within its span, the spans of its tasks are the children. The context carries the
+// fields of the logs of the tasks too.
+func (p *Pipeline) runModuleTraced(index int, m module.Module) (*ending.ModuleResult, error) {
+	ctx := logger.WithFields(p.ctx, logrus.Fields{common.Module: m.GetName()})
+	ctx, span := tracing.Start(ctx, "module "+m.GetName(),
+		attribute.String("kubekey.module", m.GetName()),
+		attribute.StringSlice("kubekey.tags", m.GetTags()),
+	)
+	res, err := p.runModuleWithDeadline(ctx, index, m)
+	if err == nil && res.IsFailed() {
+		tracing.End(span, res.CombineResult)
+	} else {
+		tracing.End(span, err)
+	}
+	return res, err
+}
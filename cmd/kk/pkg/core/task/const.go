@@ -16,9 +16,18 @@
 
 package task
 
+import "time"
+
 const (
 	DefaultTimeout = 120
 	DefaultCon     = 10
 
+	// timeoutGrace is how long to wait for a task to report its error after its timeout expires on a host.
+	timeoutGrace = 10 * time.Second
+
 	DefaultTaskName = "DefaultTask"
+
+	// LoopItem and LoopIndex are the keys of the current loop item and its index in the host cache.
+	LoopItem  = "item"
+	LoopIndex = "itemIndex"
 )
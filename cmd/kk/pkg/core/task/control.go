@@ -0,0 +1,75 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package task
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+// DefaultVars returns the variables of the host used to evaluate the when expression if the task has no Vars:
+// the facts stored in the host cache, and Name, Address, InternalAddress, Arch and Roles of the host.
+func DefaultVars(runtime connector.Runtime) map[string]interface{} {
+	vars := make(map[string]interface{})
+	host := runtime.RemoteHost()
+	if host.GetCache() != nil {
+		host.GetCache().Range(func(key, value interface{}) bool {
+			if k, ok := key.(string); ok {
+				vars[k] = value
+			}
+			return true
+		})
+	}
+	vars["Name"] = host.GetName()
+	vars["Address"] = host.GetAddress()
+	vars["InternalAddress"] = host.GetInternalIPv4Address()
+	vars["Arch"] = host.GetArch()
+	vars["Roles"] = host.GetRoles()
+	return vars
+}
+
+// EvalWhen evaluates the when expression with the variables, e.g. `eq .Arch "arm64"` or `{{ has "etcd" .Roles }}`.
+// The expression is wrapped with the delimiters if it has none, and it must be rendered as true or false.
+func EvalWhen(expr string, vars map[string]interface{}) (bool, error) {
+	if !strings.Contains(expr, "{{") {
+		expr = fmt.Sprintf("{{ %s }}", expr)
+	}
+	tmpl, err := template.New("when").Funcs(sprig.TxtFuncMap()).Parse(expr)
+	if err != nil {
+		return false, errors.Wrapf(err, "parse when expression %s failed", expr)
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return false, errors.Wrapf(err, "evaluate when expression %s failed", expr)
+	}
+
+	switch strings.TrimSpace(buf.String()) {
+	case "true":
+		return true, nil
+	case "false", "":
+		return false, nil
+	default:
+		return false, fmt.Errorf("when expression %s is evaluated as %q, which is not a boolean", expr, buf.String())
+	}
+}
@@ -0,0 +1,51 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package task
+
+import "testing"
+
+func TestEvalWhen(t *testing.T) {
+	vars := map[string]interface{}{
+		"Arch":  "arm64",
+		"Roles": []string{"master", "etcd"},
+		"Count": 3,
+	}
+	tests := []struct {
+		name    string
+		expr    string
+		want    bool
+		wantErr bool
+	}{
+		{name: "without delimiters", expr: `eq .Arch "arm64"`, want: true},
+		{name: "with delimiters", expr: `{{ eq .Arch "amd64" }}`, want: false},
+		{name: "sprig function", expr: `has "etcd" .Roles`, want: true},
+		{name: "compare", expr: `and (gt .Count 1) (ne .Arch "amd64")`, want: true},
+		{name: "not a boolean", expr: `.Arch`, wantErr: true},
+		{name: "invalid", expr: `{{ eq .Arch }`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvalWhen(tt.expr, vars)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EvalWhen() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("EvalWhen() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -17,9 +17,12 @@
 package task
 
 import (
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/cache"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/ending"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/tracing"
 )
 
 type Interface interface {
@@ -28,3 +31,12 @@ type Interface interface {
 	Execute() *ending.TaskResult
 	ExecuteRollback()
 }
+
+// endSpan ends the span of the task, with the errors of the hosts if it failed.
+func endSpan(span trace.Span, res *ending.TaskResult) {
+	if res.IsFailed() {
+		tracing.End(span, res.CombineErr())
+		return
+	}
+	tracing.End(span, nil)
+}
@@ -22,6 +22,8 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/cache"
@@ -31,6 +33,7 @@ import (
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/prepare"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/rollback"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/tracing"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
 )
 
@@ -43,6 +46,10 @@ type LocalTask struct {
 	Retry    int
 	Delay    time.Duration
 	Timeout  time.Duration
+	// RunInDryRun runs the action in the check mode (--dry-run) too. It's set for the actions which change nothing
+	// but the caches and the work dir, e.g. the checks, the confirmations and the downloads. The other actions are
+	// skipped in the check mode.
+	RunInDryRun bool
 
 	PipelineCache *cache.Cache
 	ModuleCache   *cache.Cache
@@ -97,11 +104,20 @@ func (l *LocalTask) Execute() *ending.TaskResult {
 	}
 
 	host := &connector.BaseHost{
-		Name: common.LocalHost,
+		Name:  common.LocalHost,
+		Cache: cache.NewCache(),
 	}
 
+	ctx := logger.WithFields(l.Runtime.GetContext(), logrus.Fields{common.Task: l.Name, common.Node: host.GetName()})
+	ctx, span := tracing.Start(ctx, "task "+l.Name, attribute.String("kubekey.task", l.Name))
+	defer func() {
+		endSpan(span, l.TaskResult)
+	}()
 	selfRuntime := l.Runtime.Copy()
+	selfRuntime.SetContext(ctx)
+	logger.SetHostContext(host.GetName(), ctx)
 	l.RunWithTimeout(selfRuntime, host)
+	logger.SetHostContext(host.GetName(), nil)
 
 	if l.TaskResult.IsFailed() {
 		l.TaskResult.ErrResult()
@@ -113,14 +129,18 @@ func (l *LocalTask) Execute() *ending.TaskResult {
 }
 
 func (l *LocalTask) RunWithTimeout(runtime connector.Runtime, host connector.Host) {
-	ctx, cancel := context.WithTimeout(context.Background(), l.Timeout)
+	ctx, cancel := context.WithTimeout(runtime.GetContext(), l.Timeout)
 	defer cancel()
 
-	resCh := make(chan error)
+	resCh := make(chan error, 1)
 
 	go l.Run(runtime, host, resCh)
 	select {
 	case <-ctx.Done():
+		if err := runtime.GetContext().Err(); err != nil {
+			l.TaskResult.AppendErr(host, fmt.Errorf("execute task aborted: %w", err))
+			break
+		}
 		l.TaskResult.AppendErr(host, fmt.Errorf("execute task timeout, Timeout=%s", util.ShortDur(l.Timeout)))
 	case e := <-resCh:
 		if e != nil {
@@ -154,13 +174,21 @@ func (l *LocalTask) Run(runtime connector.Runtime, host connector.Host, resCh ch
 		}
 	}
 
+	if !l.RunInDryRun && connector.IsDryRun(runtime.GetConnector()) {
+		logger.FromContext(runtime.GetContext()).Infof("dry-run: skip local task %s", l.Name)
+		l.TaskResult.AppendSkip(host)
+		return
+	}
+
 	l.Action.Init(l.ModuleCache, l.PipelineCache)
 	l.Action.AutoAssert(runtime)
-	if err := l.ExecuteWithRetry(runtime, host); err != nil {
+	err := l.ExecuteWithRetry(runtime, host)
+	changed, diff := action.TakeResult(runtime)
+	if err != nil {
 		res = err
 		return
 	}
-	l.TaskResult.AppendSuccess(host)
+	l.TaskResult.AppendSuccess(host, changed, diff)
 }
 
 func (l *LocalTask) WhenWithRetry(runtime connector.Runtime, host connector.Host) (bool, error) {
@@ -168,13 +196,13 @@ func (l *LocalTask) WhenWithRetry(runtime connector.Runtime, host connector.Host
 	err := fmt.Errorf("pre-check exec failed after %d retries", l.Retry)
 	for i := 0; i < l.Retry; i++ {
 		if res, e := l.When(runtime); e != nil {
-			logger.Log.Messagef(host.GetName(), e.Error())
+			logger.FromContext(runtime.GetContext()).Infof("message:\n%s", e.Error())
 
 			if i == l.Retry-1 {
 				err = errors.New(err.Error() + e.Error())
 				continue
 			}
-			logger.Log.Infof("retry: [%s]", host.GetName())
+			logger.FromContext(runtime.GetContext()).Info("retry")
 			time.Sleep(l.Delay)
 			continue
 		} else {
@@ -204,13 +232,13 @@ func (l *LocalTask) ExecuteWithRetry(runtime connector.Runtime, host connector.H
 	for i := 0; i < l.Retry; i++ {
 		e := l.Action.Execute(runtime)
 		if e != nil {
-			logger.Log.Messagef(host.GetName(), e.Error())
+			logger.FromContext(runtime.GetContext()).Infof("message:\n%s", e.Error())
 
 			if i == l.Retry-1 {
 				err = errors.New(err.Error() + e.Error())
 				continue
 			}
-			logger.Log.Infof("retry: [%s]", host.GetName())
+			logger.FromContext(runtime.GetContext()).Info("retry")
 			time.Sleep(l.Delay)
 			continue
 		} else {
@@ -0,0 +1,75 @@
+/*
+ Copyright 2021 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package task
+
+import (
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/cache"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/ending"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+// countAction counts its executions.
+type countAction struct {
+	action.BaseAction
+	count int
+}
+
+func (c *countAction) Execute(runtime connector.Runtime) error {
+	c.count++
+	return nil
+}
+
+func TestLocalTask_DryRun(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	dialer := connector.NewFakeDialer(&connector.FakeFixtures{})
+
+	tests := []struct {
+		name        string
+		connector   connector.Connector
+		runInDryRun bool
+		wantCount   int
+		wantStatus  ending.ResultStatus
+	}{
+		{name: "executed", connector: dialer, wantCount: 1, wantStatus: ending.SUCCESS},
+		{name: "skipped in the check mode", connector: connector.NewDryRunDialer(dialer), wantStatus: ending.SKIPPED},
+		{name: "skipped in the manifest-only mode", connector: connector.NewRenderDialer(t.TempDir()), wantStatus: ending.SKIPPED},
+		{name: "executed in the check mode", connector: connector.NewDryRunDialer(dialer), runInDryRun: true, wantCount: 1, wantStatus: ending.SUCCESS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runtime := connector.NewBaseRuntime("test", tt.connector, false, false)
+			act := &countAction{}
+			task := &LocalTask{Name: "test", Action: act, RunInDryRun: tt.runInDryRun}
+			task.Init(&runtime, cache.NewCache(), cache.NewCache())
+
+			res := task.Execute()
+			if res.IsFailed() {
+				t.Fatal(res.CombineErr())
+			}
+			if act.count != tt.wantCount {
+				t.Errorf("the action is executed %d times, want %d", act.count, tt.wantCount)
+			}
+			if len(res.ActionResults) != 1 || res.ActionResults[0].Status != tt.wantStatus {
+				t.Errorf("ActionResults = %v, want a result of %s", res.ActionResults, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -19,18 +19,23 @@ package task
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/cache"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/ending"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/prepare"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/rollback"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/tracing"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
 )
 
@@ -46,6 +51,23 @@ type RemoteTask struct {
 	Delay       time.Duration
 	Timeout     time.Duration
 	Concurrency float64
+	// Condition is the when expression, a go template evaluated on each host, the task is skipped on the host if it is false.
+	Condition string
+	// Vars returns the variables of the host used by Condition, DefaultVars is used if it is nil.
+	Vars func(runtime connector.Runtime) map[string]interface{}
+	// Loop executes the action once for each item on the host, the current item and its index are
+	// stored in the host cache with the keys LoopItem and LoopIndex.
+	Loop []interface{}
+	// Creates and Removes are the paths on the host guarding one-time operations, the task is ok without executing on
+	// the host if Creates exists or Removes doesn't exist, e.g. the file created by the command.
+	Creates string
+	Removes string
+	// Throttle bounds the hosts of each group running the task at the same time, e.g. the hosts of a network segment
+	// sharing a link while the artifacts are copied to them.
+	Throttle *Throttle
+	// AlwaysRun marks the task which gathers the state of the hosts for the later tasks, it isn't skipped on the
+	// hosts which completed it in the previous run when resuming the pipeline.
+	AlwaysRun bool
 
 	PipelineCache *cache.Cache
 	ModuleCache   *cache.Cache
@@ -63,6 +85,31 @@ func (t *RemoteTask) Init(runtime connector.Runtime, moduleCache *cache.Cache, p
 	t.PipelineCache = pipelineCache
 	t.Runtime = runtime
 	t.Default()
+	// the result of the check mode doesn't change by retrying
+	if connector.IsDryRun(runtime.GetConnector()) {
+		t.Retry = 1
+	}
+}
+
+// WithHosts returns a copy of the task with a new result, which runs only on the hosts of the task in the given hosts.
+// The execution strategies use it to run the task on a batch of hosts or on each host independently.
+func (t *RemoteTask) WithHosts(hosts []connector.Host) *RemoteTask {
+	names := make(map[string]struct{}, len(hosts))
+	for _, h := range hosts {
+		names[h.GetName()] = struct{}{}
+	}
+	c := *t
+	c.Hosts = make([]connector.Host, 0, len(hosts))
+	for _, h := range t.Hosts {
+		if h == nil {
+			continue
+		}
+		if _, ok := names[h.GetName()]; ok {
+			c.Hosts = append(c.Hosts, h)
+		}
+	}
+	c.TaskResult = ending.NewTaskResult()
+	return &c
 }
 
 func (t *RemoteTask) Execute() *ending.TaskResult {
@@ -72,9 +119,15 @@ func (t *RemoteTask) Execute() *ending.TaskResult {
 
 	routinePool := make(chan struct{}, DefaultCon)
 	defer close(routinePool)
+	groups := t.Throttle.pools(t.Hosts)
 
-	ctx, cancel := context.WithTimeout(context.Background(), t.Timeout)
-	defer cancel()
+	// the context of the module is done when a deadline of the run is exceeded
+	ctx := logger.WithFields(t.Runtime.GetContext(), logrus.Fields{common.Task: t.Name})
+	ctx, span := tracing.Start(ctx, "task "+t.Name,
+		attribute.String("kubekey.task", t.Name), attribute.Int("kubekey.hosts", len(t.Hosts)))
+	defer func() {
+		endSpan(span, t.TaskResult)
+	}()
 	wg := &sync.WaitGroup{}
 	for i := range t.Hosts {
 		if t.Hosts[i] == nil || t.Runtime.HostIsDeprecated(t.Hosts[i]) {
@@ -84,7 +137,7 @@ func (t *RemoteTask) Execute() *ending.TaskResult {
 
 		wg.Add(1)
 		if t.Parallel {
-			go t.RunWithTimeout(ctx, selfRuntime, t.Hosts[i], i, wg, routinePool)
+			go t.runThrottled(ctx, selfRuntime, t.Hosts[i], i, wg, routinePool, groups)
 		} else {
 			t.RunWithTimeout(ctx, selfRuntime, t.Hosts[i], i, wg, routinePool)
 		}
@@ -100,17 +153,43 @@ func (t *RemoteTask) Execute() *ending.TaskResult {
 	return t.TaskResult
 }
 
+// runThrottled runs the task on the host once its group of the Throttle has a free slot. The slot is taken before the
+// host gets its turn in the pool, so the hosts waiting for their group don't hold the turns of the other groups.
+func (t *RemoteTask) runThrottled(ctx context.Context, runtime connector.Runtime, host connector.Host, index int,
+	wg *sync.WaitGroup, pool chan struct{}, groups map[string]chan struct{}) {
+
+	if len(groups) != 0 {
+		if group, ok := groups[t.Throttle.Group(host)]; ok {
+			group <- struct{}{}
+			defer func() { <-group }()
+		}
+	}
+	t.RunWithTimeout(ctx, runtime, host, index, wg, pool)
+}
+
+// RunWithTimeout runs the task on the host within the Timeout of the task, which starts when the host gets its turn in
+// the pool, so a hung host fails by itself instead of stalling the other hosts.
 func (t *RemoteTask) RunWithTimeout(ctx context.Context, runtime connector.Runtime, host connector.Host, index int,
 	wg *sync.WaitGroup, pool chan struct{}) {
 
 	pool <- struct{}{}
 
-	resCh := make(chan error)
-	go t.Run(runtime, host, index, resCh)
+	ctx, cancel := context.WithTimeout(logger.WithFields(ctx, logrus.Fields{common.Node: host.GetName()}), t.Timeout)
+	defer cancel()
+	resCh := make(chan error, 1)
+	go t.Run(ctx, runtime, host, index, resCh)
 
 	select {
 	case <-ctx.Done():
-		t.TaskResult.AppendErr(host, fmt.Errorf("execute task timeout, Timeout=%s", util.ShortDur(t.Timeout)))
+		// the operations on the host are canceled with the context, wait for the task to report its error
+		select {
+		case e := <-resCh:
+			if e != nil {
+				t.TaskResult.AppendErr(host, e)
+			}
+		case <-time.After(timeoutGrace):
+			t.TaskResult.AppendErr(host, fmt.Errorf("execute task timeout, Timeout=%s", util.ShortDur(t.Timeout)))
+		}
 	case e := <-resCh:
 		if e != nil {
 			t.TaskResult.AppendErr(host, e)
@@ -121,7 +200,7 @@ func (t *RemoteTask) RunWithTimeout(ctx context.Context, runtime connector.Runti
 	wg.Done()
 }
 
-func (t *RemoteTask) Run(runtime connector.Runtime, host connector.Host, index int, resCh chan error) {
+func (t *RemoteTask) Run(ctx context.Context, runtime connector.Runtime, host connector.Host, index int, resCh chan error) {
 	var res error
 	defer func() {
 		//runtime.GetConnector().Close(host)
@@ -134,12 +213,38 @@ func (t *RemoteTask) Run(runtime connector.Runtime, host connector.Host, index i
 		res = err
 		return
 	}
+	runtime.GetRunner().Ctx = ctx
+	logger.SetHostContext(host.GetName(), ctx)
+	defer logger.SetHostContext(host.GetName(), nil)
+	defer func() {
+		if res != nil {
+			t.collectDiagnostics(runtime, host)
+		}
+	}()
+
+	if ok, err := t.evalWhen(runtime); !ok {
+		if err != nil {
+			res = err
+			return
+		}
+		t.TaskResult.AppendSkip(host)
+		return
+	}
+
+	if ok, err := t.guard(runtime); !ok {
+		if err != nil {
+			res = err
+			return
+		}
+		t.TaskResult.AppendSuccess(host, false, "")
+		return
+	}
 
 	t.Prepare.Init(t.ModuleCache, t.PipelineCache)
 	t.Prepare.AutoAssert(runtime)
 	if ok, err := t.WhenWithRetry(runtime); !ok {
 		if err != nil {
-			res = err
+			res = t.dryRunErr(runtime, host, err)
 			return
 		} else {
 			t.TaskResult.AppendSkip(host)
@@ -149,15 +254,108 @@ func (t *RemoteTask) Run(runtime connector.Runtime, host connector.Host, index i
 
 	t.Action.Init(t.ModuleCache, t.PipelineCache)
 	t.Action.AutoAssert(runtime)
-	if err := t.ExecuteWithRetry(runtime); err != nil {
-		res = err
+	changed, diff, err := t.executeLoop(runtime)
+	if err != nil {
+		res = t.dryRunErr(runtime, host, err)
 		return
 	}
 
-	t.TaskResult.AppendSuccess(host)
+	t.TaskResult.AppendSuccess(host, changed, diff)
 	return
 }
 
+// collectDiagnostics saves a diagnostic snapshot of the host the task failed on, if it is enabled.
+func (t *RemoteTask) collectDiagnostics(runtime connector.Runtime, host connector.Host) {
+	if !runtime.GetCollectDiagnostics() || connector.IsDryRun(runtime.GetConnector()) {
+		return
+	}
+	file, err := connector.SaveDiagnostics(runtime, t.Name)
+	if err != nil {
+		runtime.GetRunner().Log().Warnf("failed to collect the diagnostics: %v", err)
+		return
+	}
+	runtime.GetRunner().Log().Infof("the diagnostics of the failed task %s are saved to %s", t.Name, file)
+}
+
+// dryRunErr returns the error of the task. In the check mode, the error is reported and the host is skipped instead,
+// because the commands don't return the real output which the actions may depend on.
+func (t *RemoteTask) dryRunErr(runtime connector.Runtime, host connector.Host, err error) error {
+	if !connector.IsDryRun(runtime.GetConnector()) {
+		return err
+	}
+	runtime.GetRunner().Log().Warningf("dry-run: the result is unknown, it depends on the state of the host: %v", err)
+	t.TaskResult.AppendSkip(host)
+	return nil
+}
+
+func (t *RemoteTask) evalWhen(runtime connector.Runtime) (bool, error) {
+	if t.Condition == "" {
+		return true, nil
+	}
+	vars := t.Vars
+	if vars == nil {
+		vars = DefaultVars
+	}
+	return EvalWhen(t.Condition, vars(runtime))
+}
+
+// guard returns false if the Creates path exists or the Removes path doesn't exist on the host.
+func (t *RemoteTask) guard(runtime connector.Runtime) (bool, error) {
+	if t.Creates != "" {
+		exist, err := runtime.GetRunner().FileExist(t.Creates)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to check the path %s", t.Creates)
+		}
+		if exist {
+			runtime.GetRunner().Log().Debugf("%s exists, skip %s", t.Creates, t.Name)
+			return false, nil
+		}
+	}
+	if t.Removes != "" {
+		exist, err := runtime.GetRunner().FileExist(t.Removes)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to check the path %s", t.Removes)
+		}
+		if !exist {
+			runtime.GetRunner().Log().Debugf("%s doesn't exist, skip %s", t.Removes, t.Name)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// executeLoop executes the action for each item of the loop, or once if there is no loop. It returns whether
+// any execution changed the host and the diffs reported.
+func (t *RemoteTask) executeLoop(runtime connector.Runtime) (bool, string, error) {
+	if len(t.Loop) == 0 {
+		err := t.ExecuteWithRetry(runtime)
+		changed, diff := action.TakeResult(runtime)
+		return changed, diff, err
+	}
+
+	c := runtime.RemoteHost().GetCache()
+	defer c.Delete(LoopItem)
+	defer c.Delete(LoopIndex)
+	var (
+		changed bool
+		diffs   []string
+	)
+	for i, item := range t.Loop {
+		c.Set(LoopItem, item)
+		c.Set(LoopIndex, i)
+		err := t.ExecuteWithRetry(runtime)
+		itemChanged, diff := action.TakeResult(runtime)
+		if err != nil {
+			return changed, strings.Join(diffs, "\n"), errors.Wrapf(err, "loop item %d: %v", i, item)
+		}
+		changed = changed || itemChanged
+		if diff != "" {
+			diffs = append(diffs, diff)
+		}
+	}
+	return changed, strings.Join(diffs, "\n"), nil
+}
+
 func (t *RemoteTask) ConfigureSelfRuntime(runtime connector.Runtime, host connector.Host, index int) error {
 	conn, err := runtime.GetConnector().Connect(host)
 	if err != nil {
@@ -191,13 +389,13 @@ func (t *RemoteTask) WhenWithRetry(runtime connector.Runtime) (bool, error) {
 	err := fmt.Errorf("pre-check exec failed after %d retries", t.Retry)
 	for i := 0; i < t.Retry; i++ {
 		if res, e := t.When(runtime); e != nil {
-			logger.Log.Messagef(runtime.RemoteHost().GetName(), e.Error())
+			runtime.GetRunner().Log().Infof("message:\n%s", e.Error())
 
 			if i == t.Retry-1 {
 				err = errors.New(err.Error() + e.Error())
 				continue
 			}
-			logger.Log.Infof("retry: [%s]", runtime.GetRunner().Host.GetName())
+			runtime.GetRunner().Log().Info("retry")
 			time.Sleep(t.Delay)
 			continue
 		} else {
@@ -213,15 +411,16 @@ func (t *RemoteTask) WhenWithRetry(runtime connector.Runtime) (bool, error) {
 func (t *RemoteTask) ExecuteWithRetry(runtime connector.Runtime) error {
 	err := fmt.Errorf("[%s] exec failed after %d retries: ", t.Name, t.Retry)
 	for i := 0; i < t.Retry; i++ {
-		e := t.Action.Execute(runtime)
+		e := t.executeAttempt(runtime)
 		if e != nil {
-			logger.Log.Messagef(runtime.RemoteHost().GetName(), e.Error())
+			runtime.GetRunner().Log().Infof("message:\n%s", e.Error())
 
-			if i == t.Retry-1 {
+			// no retry is possible once the timeout of the task on the host expires
+			if ctx := runtime.GetRunner().Ctx; i == t.Retry-1 || ctx != nil && ctx.Err() != nil {
 				err = errors.New(err.Error() + e.Error())
-				continue
+				break
 			}
-			logger.Log.Infof("retry: [%s]", runtime.GetRunner().Host.GetName())
+			runtime.GetRunner().Log().Info("retry")
 			time.Sleep(t.Delay)
 			continue
 		} else {
@@ -232,6 +431,33 @@ func (t *RemoteTask) ExecuteWithRetry(runtime connector.Runtime) error {
 	return err
 }
 
+// executeAttempt executes the action once, within the task timeout of the host if it is set.
+func (t *RemoteTask) executeAttempt(runtime connector.Runtime) error {
+	r := runtime.GetRunner()
+	timeout := time.Duration(r.Host.GetTaskTimeout()) * time.Second
+	if timeout <= 0 {
+		return t.Action.Execute(runtime)
+	}
+
+	parent := r.Ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	r.Ctx = ctx
+	defer func() {
+		cancel()
+		r.Ctx = parent
+	}()
+	if err := t.Action.Execute(runtime); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && parent.Err() == nil {
+			return errors.Wrapf(err, "the attempt exceeded the task timeout %s of the host", util.ShortDur(timeout))
+		}
+		return err
+	}
+	return nil
+}
+
 func (t *RemoteTask) ExecuteRollback() {
 	if t.Rollback == nil {
 		return
@@ -267,17 +493,15 @@ func (t *RemoteTask) RollbackWithTimeout(ctx context.Context, runtime connector.
 
 	pool <- struct{}{}
 
-	resCh := make(chan error)
+	resCh := make(chan error, 1)
 	go t.RunRollback(runtime, host, index, result, resCh)
 
 	select {
 	case <-ctx.Done():
-		logger.Log.Errorf("rollback-failed: [%s]", runtime.GetRunner().Host.GetName())
-		logger.Log.Messagef(runtime.RemoteHost().GetName(), fmt.Sprintf("execute task timeout, Timeout=%d", t.Timeout))
+		runtime.GetRunner().Log().Errorf("rollback-failed: execute task timeout, Timeout=%s", util.ShortDur(t.Timeout))
 	case e := <-resCh:
 		if e != nil {
-			logger.Log.Errorf("rollback-failed: [%s]", runtime.GetRunner().Host.GetName())
-			logger.Log.Messagef(runtime.RemoteHost().GetName(), e.Error())
+			runtime.GetRunner().Log().Errorf("rollback-failed:\n%s", e.Error())
 		}
 	}
 
@@ -299,7 +523,7 @@ func (t *RemoteTask) RunRollback(runtime connector.Runtime, host connector.Host,
 		return
 	}
 
-	logger.Log.Infof("rollback: [%s]", runtime.GetRunner().Host.GetName())
+	runtime.GetRunner().Log().Info("rollback")
 
 	t.Rollback.Init(t.ModuleCache, t.PipelineCache)
 	t.Rollback.AutoAssert(runtime)
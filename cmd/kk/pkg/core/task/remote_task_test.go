@@ -17,11 +17,130 @@
 package task
 
 import (
+	"context"
+	"errors"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
 )
 
+// fileConnection is a connection on which only the paths in files exist.
+type fileConnection struct {
+	connector.Connection
+	files map[string]bool
+}
+
+func (f *fileConnection) RemoteFileExist(remote string, host connector.Host) bool {
+	return f.files[remote]
+}
+
+func TestRemoteTask_guard(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	runtime := &connector.BaseRuntime{}
+	runtime.SetRunner(&connector.Runner{
+		Conn: &fileConnection{files: map[string]bool{"/etc/kubernetes/admin.conf": true}},
+		Host: &connector.BaseHost{Name: "node1"},
+	})
+
+	tests := []struct {
+		name    string
+		creates string
+		removes string
+		want    bool
+	}{
+		{name: "no guard", want: true},
+		{name: "creates exists", creates: "/etc/kubernetes/admin.conf", want: false},
+		{name: "creates doesn't exist", creates: "/etc/kubernetes/kubelet.conf", want: true},
+		{name: "removes exists", removes: "/etc/kubernetes/admin.conf", want: true},
+		{name: "removes doesn't exist", removes: "/etc/kubernetes/kubelet.conf", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &RemoteTask{Name: "test", Creates: tt.creates, Removes: tt.removes}
+			got, err := task.guard(runtime)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("guard() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// hungConnection is a connection on which the commands hang until it is closed.
+type hungConnection struct {
+	connector.Connection
+	once   sync.Once
+	closed chan struct{}
+}
+
+func (h *hungConnection) Exec(cmd string, host connector.Host) (string, int, error) {
+	<-h.closed
+	return "", 1, errors.New("connection closed")
+}
+
+func (h *hungConnection) Close() {
+	h.once.Do(func() { close(h.closed) })
+}
+
+// cmdAction runs a command on the host and counts its executions.
+type cmdAction struct {
+	action.BaseAction
+	executions int
+}
+
+func (c *cmdAction) Execute(runtime connector.Runtime) error {
+	c.executions++
+	_, err := runtime.GetRunner().Cmd("sleep infinity", false)
+	return err
+}
+
+func TestRemoteTask_ExecuteWithRetryTimeout(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	expired, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		taskTimeout int64
+		want        int
+	}{
+		{name: "the attempts time out and are retried", ctx: context.Background(), taskTimeout: 1, want: 2},
+		{name: "no retry after the task timeout", ctx: expired, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host := connector.NewHost()
+			host.Name = "node1"
+			host.TaskTimeout = tt.taskTimeout
+			runtime := &connector.BaseRuntime{}
+			runtime.SetRunner(&connector.Runner{
+				Conn: &hungConnection{closed: make(chan struct{})},
+				Host: host,
+				Ctx:  tt.ctx,
+			})
+			a := &cmdAction{}
+			task := &RemoteTask{Name: "test", Action: a, Retry: 2, Delay: time.Millisecond}
+
+			if err := task.ExecuteWithRetry(runtime); err == nil {
+				t.Fatal("ExecuteWithRetry() succeeded on the hung host")
+			}
+			if a.executions != tt.want {
+				t.Errorf("executions = %d, want %d", a.executions, tt.want)
+			}
+			if runtime.GetRunner().Ctx != tt.ctx {
+				t.Error("the context of the runner isn't restored after the attempts")
+			}
+		})
+	}
+}
+
 func TestTask_calculateConcurrency(t1 *testing.T) {
 	type fields struct {
 		Hosts       []connector.BaseHost
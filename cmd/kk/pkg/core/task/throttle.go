@@ -0,0 +1,48 @@
+/*
+ Copyright 2021 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package task
+
+import (
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+// Throttle bounds the hosts of the same group running a parallel task at the same time, the hosts of different
+// groups run independently. The hosts out of any group, whose Group is empty, aren't bounded.
+type Throttle struct {
+	// Group returns the group of the host, e.g. its network segment.
+	Group func(host connector.Host) string
+	// Limit is the max number of the hosts of a group running the task at the same time.
+	Limit int
+}
+
+// pools returns a pool of the Limit for each group of the hosts, nil if the task isn't throttled.
+func (t *Throttle) pools(hosts []connector.Host) map[string]chan struct{} {
+	if t == nil || t.Group == nil || t.Limit <= 0 {
+		return nil
+	}
+	pools := make(map[string]chan struct{})
+	for _, host := range hosts {
+		if host == nil {
+			continue
+		}
+		group := t.Group(host)
+		if _, ok := pools[group]; !ok && group != "" {
+			pools[group] = make(chan struct{}, t.Limit)
+		}
+	}
+	return pools
+}
@@ -0,0 +1,104 @@
+/*
+ Copyright 2021 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package task
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/cache"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+// concurrencyAction records the max number of the hosts of each group and of all the groups running it at once.
+type concurrencyAction struct {
+	action.BaseAction
+	group func(host connector.Host) string
+
+	mu       sync.Mutex
+	running  map[string]int
+	total    int
+	maxGroup map[string]int
+	maxTotal int
+}
+
+func (c *concurrencyAction) Execute(runtime connector.Runtime) error {
+	group := c.group(runtime.RemoteHost())
+	c.mu.Lock()
+	c.running[group]++
+	c.total++
+	if c.running[group] > c.maxGroup[group] {
+		c.maxGroup[group] = c.running[group]
+	}
+	if c.total > c.maxTotal {
+		c.maxTotal = c.total
+	}
+	c.mu.Unlock()
+
+	time.Sleep(50 * time.Millisecond)
+
+	c.mu.Lock()
+	c.running[group]--
+	c.total--
+	c.mu.Unlock()
+	return nil
+}
+
+func TestRemoteTask_Throttle(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	segment := func(host connector.Host) string { return host.GetName()[:len("segment1")] }
+
+	tests := []struct {
+		name     string
+		throttle *Throttle
+		maxGroup int
+		maxTotal int
+	}{
+		{name: "not throttled", maxGroup: 3, maxTotal: 6},
+		{name: "a host of each segment at once", throttle: &Throttle{Group: segment, Limit: 1}, maxGroup: 1, maxTotal: 2},
+		{name: "two hosts of each segment at once", throttle: &Throttle{Group: segment, Limit: 2}, maxGroup: 2, maxTotal: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hosts []connector.Host
+			for _, name := range []string{"segment1-node1", "segment1-node2", "segment1-node3", "segment2-node1", "segment2-node2", "segment2-node3"} {
+				host := connector.NewHost()
+				host.Name = name
+				hosts = append(hosts, host)
+			}
+			runtime := connector.NewBaseRuntime("test", connector.NewFakeDialer(&connector.FakeFixtures{}), false, false)
+			a := &concurrencyAction{group: segment, running: map[string]int{}, maxGroup: map[string]int{}}
+			task := &RemoteTask{Name: "test", Hosts: hosts, Action: a, Parallel: true, Throttle: tt.throttle}
+			task.Init(&runtime, cache.NewCache(), cache.NewCache())
+
+			if res := task.Execute(); res.IsFailed() {
+				t.Fatalf("Execute() failed: %v", res.CombineErr())
+			}
+			for group, max := range a.maxGroup {
+				if max != tt.maxGroup {
+					t.Errorf("max hosts of %s at once = %d, want %d", group, max, tt.maxGroup)
+				}
+			}
+			if a.maxTotal != tt.maxTotal {
+				t.Errorf("max hosts at once = %d, want %d", a.maxTotal, tt.maxTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSpans(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	ctx, pipeline := Start(context.Background(), "pipeline CreateClusterPipeline")
+	taskCtx, task := Start(ctx, "task GetOSData")
+	_, command := Start(taskCtx, "command")
+	End(command, errors.New("exit status 1"))
+
+	header := make(http.Header)
+	Inject(taskCtx, header)
+	if header.Get("traceparent") == "" {
+		t.Error("no traceparent injected")
+	}
+	End(task, nil)
+	End(pipeline, nil)
+
+	spans := exp.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	commandSpan, taskSpan, pipelineSpan := spans[0], spans[1], spans[2]
+	if commandSpan.Parent.SpanID() != taskSpan.SpanContext.SpanID() || taskSpan.Parent.SpanID() != pipelineSpan.SpanContext.SpanID() {
+		t.Error("the spans aren't nested")
+	}
+	if commandSpan.Status.Code != codes.Error || taskSpan.Status.Code == codes.Error {
+		t.Errorf("status = %v and %v, want the error on the command only", commandSpan.Status, taskSpan.Status)
+	}
+}
+
+func TestSetup(t *testing.T) {
+	for _, endpoint := range []string{"localhost:4318", "grpc://localhost:4317", "http://"} {
+		if err := Setup(endpoint, ""); err == nil {
+			t.Errorf("Setup(%s) succeeded", endpoint)
+		}
+	}
+}
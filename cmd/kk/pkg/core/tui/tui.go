@@ -0,0 +1,433 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+const (
+	// logLines is the height of the rolling log pane.
+	logLines = 10
+	// promptIdle is how long an output line without a newline waits before it is considered a prompt, e.g. the
+	// confirmation of the installation or the sudo password.
+	promptIdle = 500 * time.Millisecond
+	refresh    = 100 * time.Millisecond
+	barWidth   = 20
+)
+
+var escapes = regexp.MustCompile(`\x1b\[[0-9;?]*[a-zA-Z]|\x1b\][^\a]*\a`)
+
+// Enabled returns whether the terminal UI can be drawn: the stdout is a terminal and it doesn't run in CI.
+func Enabled() bool {
+	if _, ok := os.LookupEnv("CI"); ok {
+		return false
+	}
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// Progress draws the progress of a pipeline in the terminal: a progress bar, the current task and the last output
+// line of each host, and a rolling pane of the logs. The stdout and the stderr of the process are redirected to the
+// log pane while it is running. The methods of a nil Progress do nothing.
+type Progress struct {
+	mu         sync.Mutex
+	pipeline   string
+	start      time.Time
+	modules    int
+	module     int
+	moduleName string
+	hosts      []*hostProgress
+	byName     map[string]*hostProgress
+	logs       []string
+	partial    string
+	partialAt  time.Time
+	paused     bool
+	drawn      int
+
+	term           *os.File
+	stdout, stderr *os.File
+	pipe           *os.File
+	stop           chan struct{}
+	wg             sync.WaitGroup
+}
+
+type hostProgress struct {
+	name     string
+	module   int
+	planned  int
+	done     int
+	task     string
+	output   string
+	ok       int
+	changed  int
+	skipped  int
+	failed   int
+	finished bool
+}
+
+// New returns the progress of the pipeline with the count of its modules, on the hosts.
+func New(pipeline string, modules int, hosts []string) *Progress {
+	p := &Progress{
+		pipeline: pipeline,
+		start:    time.Now(),
+		modules:  modules,
+		byName:   make(map[string]*hostProgress, len(hosts)),
+	}
+	for _, name := range hosts {
+		h := &hostProgress{name: name}
+		p.hosts = append(p.hosts, h)
+		p.byName[name] = h
+	}
+	return p
+}
+
+// Start redirects the stdout and the stderr to the log pane and starts drawing.
+func (p *Progress) Start() error {
+	if p == nil {
+		return nil
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	p.term, p.stdout, p.stderr, p.pipe = os.Stdout, os.Stdout, os.Stderr, w
+	os.Stdout, os.Stderr = w, w
+	p.stop = make(chan struct{})
+
+	p.wg.Add(2)
+	go func() {
+		defer p.wg.Done()
+		p.read(r)
+	}()
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(refresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				p.mu.Lock()
+				p.draw()
+				p.mu.Unlock()
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop draws the last frame and restores the stdout and the stderr.
+func (p *Progress) Stop() {
+	if p == nil || p.stop == nil {
+		return
+	}
+	close(p.stop)
+	os.Stdout, os.Stderr = p.stdout, p.stderr
+	_ = p.pipe.Close()
+	p.wg.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.resume()
+	p.draw()
+	p.stop = nil
+}
+
+// read appends the lines written to the stdout and the stderr to the log pane, the output without a newline is kept
+// to detect the prompts.
+func (p *Progress) read(r io.ReadCloser) {
+	defer r.Close()
+	buf := make([]byte, 4096)
+	for {
+		n, err := r.Read(buf)
+		p.mu.Lock()
+		lines := strings.Split(p.partial+string(buf[:n]), "\n")
+		p.partial = lines[len(lines)-1]
+		if len(lines) > 1 {
+			p.resume()
+			p.appendLog(strings.Join(lines[:len(lines)-1], "\n"))
+		}
+		if n > 0 {
+			p.partialAt = time.Now()
+		}
+		p.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Write appends the output to the log pane, so the Progress can be the output of a logger.
+func (p *Progress) Write(b []byte) (int, error) {
+	if p == nil {
+		return len(b), nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.resume()
+	p.appendLog(strings.TrimSuffix(string(b), "\n"))
+	return len(b), nil
+}
+
+// resume appends the pending output line to the log pane. The drawing continues below the prompt if it was paused,
+// the output after a prompt means it was answered.
+func (p *Progress) resume() {
+	if p.partial != "" {
+		p.appendLog(p.partial)
+		p.partial = ""
+	}
+	if p.paused {
+		p.paused, p.drawn = false, 0
+	}
+}
+
+func (p *Progress) appendLog(lines string) {
+	p.logs = append(p.logs, strings.Split(lines, "\n")...)
+	if len(p.logs) > logLines {
+		p.logs = p.logs[len(p.logs)-logLines:]
+	}
+}
+
+// StartModule sets the module running in the pipeline by its index.
+func (p *Progress) StartModule(index int, name string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.module, p.moduleName = index, name
+	for _, h := range p.hosts {
+		h.module, h.planned, h.done, h.task = index, 0, 0, ""
+	}
+}
+
+// PlanTasks sets the count of the tasks of the running module on each host.
+func (p *Progress) PlanTasks(tasks map[string]int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for name, n := range tasks {
+		if h, ok := p.byName[name]; ok {
+			h.planned = n
+		}
+	}
+}
+
+// StartTask sets the task running on the hosts.
+func (p *Progress) StartTask(task string, hosts []string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, name := range hosts {
+		if h, ok := p.byName[name]; ok {
+			h.task, h.output = task, ""
+		}
+	}
+}
+
+// FinishTask counts the state of the task on the host: changed, ok, skipped or failed.
+func (p *Progress) FinishTask(host, state string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	h, ok := p.byName[host]
+	if !ok {
+		return
+	}
+	h.done++
+	switch state {
+	case "changed":
+		h.changed++
+	case "ok":
+		h.ok++
+	case "skipped":
+		h.skipped++
+	case "failed":
+		h.failed++
+	}
+}
+
+// Finish marks the end of the pipeline, the bars of the hosts which didn't fail are completed.
+func (p *Progress) Finish(success bool) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, h := range p.hosts {
+		h.finished = success && h.failed == 0
+		h.task, h.output = "", ""
+	}
+}
+
+// Command shows the command running on the host.
+func (p *Progress) Command(host, cmd string) {
+	p.Output(host, "$ "+cmd)
+}
+
+// Output shows the last output line of the command running on the host.
+func (p *Progress) Output(host, line string) {
+	if p == nil {
+		return
+	}
+	line = strings.TrimSpace(escapes.ReplaceAllString(line, ""))
+	if line == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if h, ok := p.byName[host]; ok {
+		h.output = line
+	}
+}
+
+// draw redraws the frame over the previous one. An output line without a newline for a while is a prompt, the
+// drawing is paused until it is answered.
+func (p *Progress) draw() {
+	if p.paused {
+		return
+	}
+	width, height := 100, 40
+	if w, h, err := term.GetSize(int(p.term.Fd())); err == nil && w > 0 && h > 0 {
+		width, height = w, h
+	}
+
+	var b strings.Builder
+	if p.drawn > 0 {
+		fmt.Fprintf(&b, "\x1b[%dF", p.drawn)
+	}
+	frame := p.frame(width, height)
+	if p.partial != "" && time.Since(p.partialAt) > promptIdle {
+		frame = append(frame, p.partial)
+		p.paused = true
+	}
+	for i, line := range frame {
+		b.WriteString("\x1b[2K")
+		b.WriteString(line)
+		if i < len(frame)-1 || !p.paused {
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString("\x1b[J")
+	_, _ = io.WriteString(p.term, b.String())
+	p.drawn = len(frame)
+}
+
+// frame returns the lines of the frame within the width and the height of the terminal.
+func (p *Progress) frame(width, height int) []string {
+	lines := []string{fit(fmt.Sprintf("Pipeline[%s]  module %d/%d %s  %s", p.pipeline, p.module+1, p.modules, p.moduleName,
+		time.Since(p.start).Round(time.Second)), width)}
+
+	hosts := p.hosts
+	if max := height - logLines - 4; len(hosts) > max && max > 0 {
+		hosts = hosts[:max]
+	}
+	nameWidth := 0
+	for _, h := range hosts {
+		if len(h.name) > nameWidth {
+			nameWidth = len(h.name)
+		}
+	}
+	for _, h := range hosts {
+		lines = append(lines, fit(p.hostLine(h, nameWidth), width))
+	}
+	if n := len(p.hosts) - len(hosts); n > 0 {
+		lines = append(lines, fmt.Sprintf("... %d more hosts", n))
+	}
+
+	lines = append(lines, strings.Repeat("-", min(width, 80)))
+	for _, l := range p.logs {
+		lines = append(lines, fit(escapes.ReplaceAllString(strings.ReplaceAll(l, "\r", ""), ""), width))
+	}
+	return lines
+}
+
+func (p *Progress) hostLine(h *hostProgress, nameWidth int) string {
+	fraction := 1.0
+	if !h.finished && p.modules > 0 {
+		module := 0.0
+		if h.planned > 0 {
+			module = float64(min(h.done, h.planned)) / float64(h.planned)
+		}
+		fraction = (float64(h.module) + module) / float64(p.modules)
+	}
+	filled := int(fraction * barWidth)
+	bar := strings.Repeat("#", filled) + strings.Repeat("-", barWidth-filled)
+
+	line := fmt.Sprintf("%-*s [%s] %3d%%  ok=%d changed=%d skipped=%d failed=%d", nameWidth, h.name, bar,
+		int(fraction*100), h.ok, h.changed, h.skipped, h.failed)
+	if h.task != "" {
+		line += "  " + h.task
+	}
+	if h.output != "" {
+		line += ": " + h.output
+	}
+	if h.failed > 0 {
+		return "\x1b[31m" + line + "\x1b[0m"
+	}
+	return line
+}
+
+// fit truncates the line to the width, the escape sequences of colors aren't counted.
+func fit(line string, width int) string {
+	line = strings.ReplaceAll(line, "\t", "    ")
+	if utf8.RuneCountInString(escapes.ReplaceAllString(line, "")) <= width {
+		return line
+	}
+	var (
+		b strings.Builder
+		n int
+	)
+	for i := 0; i < len(line) && n < width; {
+		if loc := escapes.FindStringIndex(line[i:]); loc != nil && loc[0] == 0 {
+			b.WriteString(line[i : i+loc[1]])
+			i += loc[1]
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(line[i:])
+		b.WriteRune(r)
+		i += size
+		n++
+	}
+	if strings.Contains(line, "\x1b[") {
+		b.WriteString("\x1b[0m")
+	}
+	return b.String()
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
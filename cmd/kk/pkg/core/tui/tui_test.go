@@ -0,0 +1,81 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestProgress_frame(t *testing.T) {
+	p := New("CreateClusterPipeline", 4, []string{"node1", "node10"})
+	p.StartModule(2, "InstallKubeBinariesModule")
+	p.PlanTasks(map[string]int{"node1": 4, "node10": 2})
+	p.StartTask("Synchronize kubernetes binaries", []string{"node1", "node10"})
+	p.FinishTask("node1", "changed")
+	p.FinishTask("node1", "ok")
+	p.FinishTask("node10", "failed")
+	p.Output("node1", "\x1b[32mkubelet.service\x1b[0m enabled\r")
+	for i := 0; i < logLines+2; i++ {
+		_, _ = fmt.Fprintf(p, "log %d\n", i)
+	}
+
+	frame := p.frame(200, 40)
+	tests := []struct {
+		name string
+		line int
+		want string
+	}{
+		{name: "pipeline", line: 0, want: "Pipeline[CreateClusterPipeline]  module 3/4 InstallKubeBinariesModule"},
+		{name: "host", line: 1, want: "node1  [############--------]  62%  ok=1 changed=1 skipped=0 failed=0  Synchronize kubernetes binaries: kubelet.service enabled"},
+		{name: "failed host", line: 2, want: "\x1b[31mnode10 [############--------]  62%  ok=0 changed=0 skipped=0 failed=1  Synchronize kubernetes binaries\x1b[0m"},
+		{name: "first log", line: 4, want: "log 2"},
+		{name: "last log", line: 4 + logLines - 1, want: fmt.Sprintf("log %d", logLines+1)},
+	}
+	if len(frame) != 4+logLines {
+		t.Fatalf("frame() = %d lines, want %d:\n%s", len(frame), 4+logLines, strings.Join(frame, "\n"))
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.HasPrefix(frame[tt.line], tt.want) {
+				t.Errorf("frame()[%d] = %q, want %q", tt.line, frame[tt.line], tt.want)
+			}
+		})
+	}
+}
+
+func Test_fit(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		width int
+		want  string
+	}{
+		{name: "short", line: "node1", width: 10, want: "node1"},
+		{name: "long", line: "node1 kubeadm init", width: 5, want: "node1"},
+		{name: "colors", line: "\x1b[31mnode1 failed\x1b[0m", width: 5, want: "\x1b[31mnode1\x1b[0m"},
+		{name: "runes", line: "节点一节点二", width: 3, want: "节点一"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fit(tt.line, tt.width); got != tt.want {
+				t.Errorf("fit() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
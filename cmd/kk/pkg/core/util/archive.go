@@ -0,0 +1,318 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package util
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/klauspost/pgzip"
+	"github.com/pkg/errors"
+)
+
+// Compression is the codec an artifact archive is compressed with.
+type Compression string
+
+const (
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+)
+
+const (
+	// copyBufferSize bounds the memory a single file copy into or out of an archive takes.
+	copyBufferSize = 1 << 20
+	// compressBlockSize is the size of the blocks the parallel gzip codec works on.
+	compressBlockSize = 1 << 20
+	// zstdWindowSize bounds the zstd match window, and with it the memory of both ends.
+	zstdWindowSize = 8 << 20
+	// maxCompressWorkers caps the blocks in flight, whatever the number of CPUs.
+	maxCompressWorkers = 4
+)
+
+// SourceDateEpochEnv overrides the modification time recorded for the archive entries, as
+// in https://reproducible-builds.org/specs/source-date-epoch/.
+const SourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+	copyBuffers = sync.Pool{New: func() interface{} {
+		buf := make([]byte, copyBufferSize)
+		return &buf
+	}}
+)
+
+// ParseCompression returns the Compression named by s, gzip when s is empty.
+func ParseCompression(s string) (Compression, error) {
+	switch c := Compression(strings.ToLower(s)); c {
+	case "", CompressionGzip:
+		return CompressionGzip, nil
+	case CompressionZstd:
+		return c, nil
+	default:
+		return "", errors.Errorf("unsupported compression %q, expected %s or %s", s, CompressionGzip, CompressionZstd)
+	}
+}
+
+// Extension returns the file name extension of a tarball compressed with c.
+func (c Compression) Extension() string {
+	if c == CompressionZstd {
+		return ".tar.zst"
+	}
+	return ".tar.gz"
+}
+
+// Tar packs src into the gzip compressed tarball dst.
+func Tar(src, dst, trimPrefix string) error {
+	return TarCompressed(src, dst, trimPrefix, CompressionGzip)
+}
+
+// TarCompressed packs the regular files under src into the tarball dst, compressed with c.
+// The archive is reproducible: entries are added in lexical order with normalized owners,
+// permissions and modification times, so packing the same files twice gives the same bytes.
+// Files are streamed through fixed-size buffers and a bounded number of compression blocks,
+// so the memory used doesn't grow with the size of the files or of the archive. The archive
+// is written next to dst and only renamed into place once complete.
+func TarCompressed(src, dst, trimPrefix string, c Compression) (err error) {
+	tmp := dst + ".part"
+	fw, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	modTime, err := archiveModTime()
+	if err != nil {
+		fw.Close()
+		return err
+	}
+
+	bw := bufio.NewWriterSize(fw, copyBufferSize)
+	cw, err := newCompressWriter(bw, c)
+	if err != nil {
+		fw.Close()
+		return err
+	}
+	tw := tar.NewWriter(cw)
+
+	walkErr := filepath.Walk(src, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		name := strings.TrimPrefix(strings.TrimPrefix(path, trimPrefix), string(filepath.Separator))
+		hdr := reproducibleHeader(filepath.ToSlash(name), info, modTime)
+		fmt.Println(hdr.Name)
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		return copyFileTo(tw, path)
+	})
+
+	for _, closeErr := range []error{walkErr, tw.Close(), cw.Close(), bw.Flush(), fw.Close()} {
+		if closeErr != nil {
+			return errors.Wrapf(closeErr, "failed to write archive %s", dst)
+		}
+	}
+	return os.Rename(tmp, dst)
+}
+
+// reproducibleHeader returns the tar header of a regular file, leaving out everything which
+// depends on the machine or the time the file was written on rather than on its content.
+func reproducibleHeader(name string, info fs.FileInfo, modTime time.Time) *tar.Header {
+	mode := int64(0644)
+	if info.Mode().Perm()&0111 != 0 {
+		mode = 0755
+	}
+	return &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     mode,
+		Size:     info.Size(),
+		ModTime:  modTime,
+		Format:   tar.FormatPAX,
+	}
+}
+
+// archiveModTime returns the modification time of the archive entries, the Unix epoch unless
+// SOURCE_DATE_EPOCH is set.
+func archiveModTime() (time.Time, error) {
+	epoch := os.Getenv(SourceDateEpochEnv)
+	if epoch == "" {
+		return time.Unix(0, 0).UTC(), nil
+	}
+	sec, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "invalid %s %q", SourceDateEpochEnv, epoch)
+	}
+	return time.Unix(sec, 0).UTC(), nil
+}
+
+// Untar unpacks the tarball src into dst. The compression, gzip or zstd, is detected from
+// the content. Entries that would land outside dst are rejected.
+func Untar(src, dst string) error {
+	fr, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer fr.Close()
+
+	cr, err := newDecompressReader(bufio.NewReaderSize(fr, copyBufferSize))
+	if err != nil {
+		return errors.Wrapf(err, "failed to read archive %s", src)
+	}
+	defer cr.Close()
+
+	tr := tar.NewReader(cr)
+	for {
+		hdr, err := tr.Next()
+
+		switch {
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return errors.Wrapf(err, "failed to read archive %s", src)
+		case hdr == nil:
+			continue
+		}
+
+		dstPath, err := archiveTarget(dst, hdr.Name)
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(dstPath, os.ModePerm); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(dstPath), os.ModePerm); err != nil {
+				return err
+			}
+			if err := copyToFile(dstPath, tr, os.FileMode(hdr.Mode)); err != nil {
+				return err
+			}
+			fmt.Println(dstPath)
+		}
+	}
+}
+
+// archiveTarget joins the archive entry name onto dst, refusing names that escape it.
+func archiveTarget(dst, name string) (string, error) {
+	target := filepath.Join(dst, name)
+	rel, err := filepath.Rel(dst, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.Errorf("archive entry %s escapes the target directory %s", name, dst)
+	}
+	return target, nil
+}
+
+func copyFileTo(w io.Writer, path string) error {
+	fr, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fr.Close()
+
+	buf := copyBuffers.Get().(*[]byte)
+	defer copyBuffers.Put(buf)
+	_, err = io.CopyBuffer(w, fr, *buf)
+	return err
+}
+
+func copyToFile(path string, r io.Reader, mode os.FileMode) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	buf := copyBuffers.Get().(*[]byte)
+	defer copyBuffers.Put(buf)
+	if _, err := io.CopyBuffer(file, r, *buf); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func compressWorkers() int {
+	if n := runtime.GOMAXPROCS(0); n < maxCompressWorkers {
+		return n
+	}
+	return maxCompressWorkers
+}
+
+func newCompressWriter(w io.Writer, c Compression) (io.WriteCloser, error) {
+	switch c {
+	case CompressionGzip, "":
+		gw := pgzip.NewWriter(w)
+		if err := gw.SetConcurrency(compressBlockSize, compressWorkers()); err != nil {
+			return nil, err
+		}
+		return gw, nil
+	case CompressionZstd:
+		return zstd.NewWriter(w,
+			zstd.WithEncoderConcurrency(compressWorkers()),
+			zstd.WithWindowSize(zstdWindowSize))
+	default:
+		return nil, errors.Errorf("unsupported compression %q", c)
+	}
+}
+
+func newDecompressReader(r *bufio.Reader) (io.ReadCloser, error) {
+	magic, err := r.Peek(len(zstdMagic))
+	if err != nil && !(err == io.EOF && len(magic) >= len(gzipMagic)) {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return pgzip.NewReaderN(r, compressBlockSize, compressWorkers())
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(r,
+			zstd.WithDecoderConcurrency(compressWorkers()),
+			zstd.WithDecoderLowmem(true))
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return nil, errors.New("unknown archive compression, expected gzip or zstd")
+	}
+}
@@ -0,0 +1,161 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package util
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTarCompressed(t *testing.T) {
+	files := map[string][]byte{
+		"kube/v1.26.5/amd64/kubeadm": bytes.Repeat([]byte("kubeadm"), 1<<18),
+		"images/index.json":          []byte(`{"schemaVersion":2}`),
+		"empty":                      {},
+	}
+
+	for _, c := range []Compression{CompressionGzip, CompressionZstd} {
+		t.Run(string(c), func(t *testing.T) {
+			src := filepath.Join(t.TempDir(), "kubekey")
+			for name, content := range files {
+				path := filepath.Join(src, name)
+				if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, content, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			archive := filepath.Join(t.TempDir(), "artifact"+c.Extension())
+			if err := TarCompressed(src, archive, src, c); err != nil {
+				t.Fatalf("TarCompressed() error = %v", err)
+			}
+			if _, err := os.Stat(archive + ".part"); !os.IsNotExist(err) {
+				t.Errorf("TarCompressed() left the partial archive behind")
+			}
+
+			dst := t.TempDir()
+			if err := Untar(archive, dst); err != nil {
+				t.Fatalf("Untar() error = %v", err)
+			}
+			for name, want := range files {
+				got, err := os.ReadFile(filepath.Join(dst, name))
+				if err != nil {
+					t.Fatalf("Untar() missing %s: %v", name, err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("Untar() %s has %d bytes, want %d", name, len(got), len(want))
+				}
+			}
+		})
+	}
+}
+
+func TestTarCompressedReproducible(t *testing.T) {
+	pack := func(mtime time.Time, perm os.FileMode) []byte {
+		src := filepath.Join(t.TempDir(), "kubekey")
+		for _, name := range []string{"b/kubelet", "a/kubeadm", "c"} {
+			path := filepath.Join(src, name)
+			if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(name), perm); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(path, mtime, mtime); err != nil {
+				t.Fatal(err)
+			}
+		}
+		archive := filepath.Join(t.TempDir(), "artifact.tar.zst")
+		if err := TarCompressed(src, archive, src, CompressionZstd); err != nil {
+			t.Fatal(err)
+		}
+		content, err := os.ReadFile(archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return content
+	}
+
+	first := pack(time.Now(), 0644)
+	second := pack(time.Now().Add(-time.Hour), 0600)
+	if !bytes.Equal(first, second) {
+		t.Errorf("TarCompressed() packed the same files into different archives")
+	}
+
+	t.Setenv(SourceDateEpochEnv, "1700000000")
+	if bytes.Equal(first, pack(time.Now(), 0644)) {
+		t.Errorf("TarCompressed() ignored %s", SourceDateEpochEnv)
+	}
+}
+
+func TestUntarRejectsEscapingEntries(t *testing.T) {
+	tests := []string{"../evil", "a/../../evil", "/../evil"}
+	for _, name := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			gw := gzip.NewWriter(&buf)
+			tw := tar.NewWriter(gw)
+			if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: 4, Typeflag: tar.TypeReg}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tw.Write([]byte("evil")); err != nil {
+				t.Fatal(err)
+			}
+			tw.Close()
+			gw.Close()
+
+			dir := t.TempDir()
+			archive := filepath.Join(dir, "evil.tar.gz")
+			if err := os.WriteFile(archive, buf.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+			dst := filepath.Join(dir, "dst")
+			if err := Untar(archive, dst); err == nil {
+				t.Errorf("Untar() accepted the entry %s", name)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "evil")); !os.IsNotExist(err) {
+				t.Errorf("Untar() wrote outside the target directory")
+			}
+		})
+	}
+}
+
+func TestParseCompression(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Compression
+		wantErr bool
+	}{
+		{in: "", want: CompressionGzip},
+		{in: "gzip", want: CompressionGzip},
+		{in: "ZSTD", want: CompressionZstd},
+		{in: "xz", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCompression(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseCompression(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	diffContext  = 3
+	maxDiffCells = 4 << 20
+)
+
+// Diff returns the line based diff from the old content to the new content, the unchanged lines far from the
+// changes are omitted. It returns an empty string if the contents are the same.
+func Diff(name, oldContent, newContent string) string {
+	if oldContent == newContent {
+		return ""
+	}
+	a := strings.Split(strings.TrimSuffix(oldContent, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(newContent, "\n"), "\n")
+	if oldContent == "" {
+		a = nil
+	}
+	if newContent == "" {
+		b = nil
+	}
+
+	lines := diffLines(a, b)
+	out := []string{fmt.Sprintf("--- %s", name), fmt.Sprintf("+++ %s", name)}
+	skipped := false
+	for i, line := range lines {
+		if line[0] == ' ' && !nearChange(lines, i) {
+			skipped = true
+			continue
+		}
+		if skipped {
+			out = append(out, "...")
+			skipped = false
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+// diffLines returns the lines prefixed by "-" (removed), "+" (added) or " " (unchanged), based on the longest
+// common subsequence. The large contents are diffed as a whole replacement to bound the memory.
+func diffLines(a, b []string) []string {
+	lines := make([]string, 0, len(a)+len(b))
+	if len(a)*len(b) > maxDiffCells {
+		for _, l := range a {
+			lines = append(lines, "-"+l)
+		}
+		for _, l := range b {
+			lines = append(lines, "+"+l)
+		}
+		return lines
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, " "+a[i])
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, "-"+a[i])
+			i++
+		default:
+			lines = append(lines, "+"+b[j])
+			j++
+		}
+	}
+	return lines
+}
+
+func nearChange(lines []string, i int) bool {
+	for k := i - diffContext; k <= i+diffContext; k++ {
+		if k >= 0 && k < len(lines) && lines[k][0] != ' ' {
+			return true
+		}
+	}
+	return false
+}
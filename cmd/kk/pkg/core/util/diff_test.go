@@ -0,0 +1,62 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name       string
+		oldContent string
+		newContent string
+		want       string
+	}{
+		{
+			name:       "same",
+			oldContent: "a\nb\n",
+			newContent: "a\nb\n",
+			want:       "",
+		},
+		{
+			name:       "new file",
+			oldContent: "",
+			newContent: "a\nb\n",
+			want:       "--- f\n+++ f\n+a\n+b",
+		},
+		{
+			name:       "changed line",
+			oldContent: "a\nb\nc\n",
+			newContent: "a\nB\nc\n",
+			want:       "--- f\n+++ f\n a\n-b\n+B\n c",
+		},
+		{
+			name:       "unchanged lines omitted",
+			oldContent: "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			newContent: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			want:       "--- f\n+++ f\n...\n 7\n 8\n 9\n+10",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff("f", tt.oldContent, tt.newContent); got != tt.want {
+				t.Errorf("Diff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
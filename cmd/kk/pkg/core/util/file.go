@@ -17,15 +17,12 @@
 package util
 
 import (
-	"archive/tar"
-	"compress/gzip"
 	"crypto/md5"
+	"crypto/sha256"
 	"fmt"
 	"io"
-	"io/fs"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
@@ -98,6 +95,21 @@ func FileMD5(path string) (string, error) {
 	return fileMd5, nil
 }
 
+// FileSHA256 returns the hex of the sha256 of the file.
+func FileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
 func LocalMd5Sum(src string) string {
 	md5Str, err := FileMD5(src)
 	if err != nil {
@@ -136,108 +148,24 @@ func WriteFile(fileName string, content []byte) error {
 	return nil
 }
 
-func Tar(src, dst, trimPrefix string) error {
-	fw, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-	defer fw.Close()
-
-	gw := gzip.NewWriter(fw)
-	defer gw.Close()
-
-	tw := tar.NewWriter(gw)
-	defer tw.Close()
-
-	return filepath.Walk(src, func(path string, info fs.FileInfo, err error) error {
+// CopyDir copies the files of the src dir to the dst dir recursively, the existing files are overwritten.
+func CopyDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-
-		hdr, err := tar.FileInfoHeader(info, "")
+		rel, err := filepath.Rel(src, path)
 		if err != nil {
 			return err
 		}
-
-		if !info.Mode().IsRegular() {
-			return nil
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode())
 		}
-
-		fr, err := os.Open(path)
-		defer fr.Close()
+		content, err := os.ReadFile(path)
 		if err != nil {
 			return err
 		}
-
-		path = strings.TrimPrefix(path, trimPrefix)
-		fmt.Println(strings.TrimPrefix(path, string(filepath.Separator)))
-
-		hdr.Name = strings.TrimPrefix(path, string(filepath.Separator))
-		if err := tw.WriteHeader(hdr); err != nil {
-			return err
-		}
-
-		if _, err := io.Copy(tw, fr); err != nil {
-			return err
-		}
-
-		return nil
+		return os.WriteFile(target, content, info.Mode())
 	})
 }
-
-func Untar(src, dst string) error {
-	fr, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer fr.Close()
-
-	gr, err := gzip.NewReader(fr)
-	if err != nil {
-		return err
-	}
-	defer gr.Close()
-
-	tr := tar.NewReader(gr)
-	for {
-		hdr, err := tr.Next()
-
-		switch {
-		case err == io.EOF:
-			return nil
-		case err != nil:
-			return err
-		case hdr == nil:
-			continue
-		}
-
-		dstPath := filepath.Join(dst, hdr.Name)
-
-		switch hdr.Typeflag {
-		case tar.TypeDir:
-			if !IsExist(dstPath) && IsDir(dstPath) {
-				if err := CreateDir(dstPath); err != nil {
-					return err
-				}
-			}
-		case tar.TypeReg:
-			if dir := filepath.Dir(dstPath); !IsExist(dir) {
-				if err := CreateDir(dir); err != nil {
-					return err
-				}
-			}
-
-			file, err := os.OpenFile(dstPath, os.O_CREATE|os.O_RDWR, os.FileMode(hdr.Mode))
-			if err != nil {
-				return err
-			}
-
-			if _, err = io.Copy(file, tr); err != nil {
-				return err
-			}
-
-			fmt.Println(dstPath)
-			file.Close()
-		}
-	}
-}
@@ -18,6 +18,7 @@ package util
 
 import (
 	"encoding/binary"
+	"math/big"
 	"net"
 	"os"
 	"strconv"
@@ -48,6 +49,30 @@ func ParseIp(ip string) []string {
 	return availableIPs
 }
 
+// CIDRAddress returns the n-th address of the CIDR, e.g. 10.233.0.3 of 10.233.0.0/18 and 3, or an empty string if
+// the CIDR is invalid or too small. Unlike ParseIp, it doesn't list the addresses, so it works for the IPv6 CIDRs.
+func CIDRAddress(cidr string, n int64) string {
+	_, ipnet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+	if err != nil {
+		return ""
+	}
+	ip := ipnet.IP.To4()
+	if ip == nil {
+		ip = ipnet.IP.To16()
+	}
+	sum := new(big.Int).Add(new(big.Int).SetBytes(ip), big.NewInt(n))
+	b := sum.Bytes()
+	if len(b) > len(ip) {
+		return ""
+	}
+	addr := make(net.IP, len(ip))
+	copy(addr[len(ip)-len(b):], b)
+	if !ipnet.Contains(addr) {
+		return ""
+	}
+	return addr.String()
+}
+
 func GetAvailableIPRange(ipStart, ipEnd string) []string {
 	var availableIPs []string
 
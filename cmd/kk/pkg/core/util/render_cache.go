@@ -0,0 +1,245 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package util
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+	"text/template"
+)
+
+// RenderCache caches the rendered templates within a run, keyed by the hash of the template and the hash of the
+// variables, so a template rendered with the same variables for many hosts is rendered only once. It is safe for
+// concurrent use, the hosts rendering the same template with the same variables wait for the first one, and the
+// different templates or variables are rendered concurrently.
+type RenderCache struct {
+	mu        sync.Mutex
+	entries   map[renderKey]*renderEntry
+	templates sync.Map
+}
+
+type renderKey struct {
+	template  [sha256.Size]byte
+	variables [sha256.Size]byte
+	strict    bool
+}
+
+type renderEntry struct {
+	once sync.Once
+	out  string
+	err  error
+}
+
+// NewRenderCache returns an empty RenderCache.
+func NewRenderCache() *RenderCache {
+	return &RenderCache{entries: make(map[renderKey]*renderEntry)}
+}
+
+// Render renders the template with the variables like RenderWith, the result is cached if the variables can be
+// hashed. The variables containing funcs, channels or unsafe pointers, or referring to themselves, are rendered every
+// time.
+func (c *RenderCache) Render(tmpl *template.Template, variables map[string]interface{}, opts RenderOptions) (string, error) {
+	variablesHash, ok := hashVariables(variables)
+	if !ok {
+		return RenderWith(tmpl, variables, opts)
+	}
+	key := renderKey{template: c.templateHash(tmpl), variables: variablesHash, strict: opts.Strict}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = new(renderEntry)
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	entry.once.Do(func() {
+		entry.out, entry.err = RenderWith(tmpl, variables, opts)
+	})
+	return entry.out, entry.err
+}
+
+// Len returns the number of the cached templates.
+func (c *RenderCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// templateHash returns the hash of the parse trees of the template and its associated templates. It is computed
+// once for each template, since the templates are parsed once and shared by the tasks.
+func (c *RenderCache) templateHash(tmpl *template.Template) [sha256.Size]byte {
+	if h, ok := c.templates.Load(tmpl); ok {
+		return h.([sha256.Size]byte)
+	}
+	associated := tmpl.Templates()
+	sort.Slice(associated, func(i, j int) bool { return associated[i].Name() < associated[j].Name() })
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00", tmpl.Name())
+	for _, t := range associated {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+		fmt.Fprintf(h, "%s\x00%s\x00", t.Name(), t.Tree.Root.String())
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	c.templates.Store(tmpl, sum)
+	return sum
+}
+
+// hashVariables returns the hash of the variables, which walks the values deeply including the unexported fields,
+// so the variables producing different outputs have different hashes. It returns false if they can't be hashed.
+func hashVariables(variables map[string]interface{}) ([sha256.Size]byte, bool) {
+	var sum [sha256.Size]byte
+	h := sha256.New()
+	if !hashValue(h, reflect.ValueOf(variables), make(map[uintptr]struct{})) {
+		return sum, false
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, true
+}
+
+// enter adds the address of a pointer, map or slice to the path of the values being hashed, it returns false if the
+// address is already in the path, i.e. the value refers to itself.
+func enter(path map[uintptr]struct{}, p uintptr) bool {
+	if _, ok := path[p]; ok {
+		return false
+	}
+	path[p] = struct{}{}
+	return true
+}
+
+func hashValue(h hash.Hash, v reflect.Value, path map[uintptr]struct{}) bool {
+	if !v.IsValid() {
+		h.Write([]byte{0})
+		return true
+	}
+	fmt.Fprintf(h, "%s:", v.Type())
+	var buf [8]byte
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			h.Write([]byte{1})
+		} else {
+			h.Write([]byte{0})
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		binary.LittleEndian.PutUint64(buf[:], uint64(v.Int()))
+		h.Write(buf[:])
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		binary.LittleEndian.PutUint64(buf[:], v.Uint())
+		h.Write(buf[:])
+	case reflect.Float32, reflect.Float64:
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v.Float()))
+		h.Write(buf[:])
+	case reflect.Complex64, reflect.Complex128:
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(real(v.Complex())))
+		h.Write(buf[:])
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(imag(v.Complex())))
+		h.Write(buf[:])
+	case reflect.String:
+		binary.LittleEndian.PutUint64(buf[:], uint64(v.Len()))
+		h.Write(buf[:])
+		h.Write([]byte(v.String()))
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if !hashValue(h, v.Index(i), path) {
+				return false
+			}
+		}
+	case reflect.Slice:
+		if v.IsNil() {
+			h.Write([]byte{0})
+			return true
+		}
+		if !enter(path, v.Pointer()) {
+			return false
+		}
+		defer delete(path, v.Pointer())
+		binary.LittleEndian.PutUint64(buf[:], uint64(v.Len()))
+		h.Write(buf[:])
+		for i := 0; i < v.Len(); i++ {
+			if !hashValue(h, v.Index(i), path) {
+				return false
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			h.Write([]byte{0})
+			return true
+		}
+		if !enter(path, v.Pointer()) {
+			return false
+		}
+		defer delete(path, v.Pointer())
+		// the entries are hashed in the order of the hashes of their keys, which is independent of the map order.
+		type entry struct {
+			key  []byte
+			elem reflect.Value
+		}
+		entries := make([]entry, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			kh := sha256.New()
+			if !hashValue(kh, iter.Key(), path) {
+				return false
+			}
+			entries = append(entries, entry{key: kh.Sum(nil), elem: iter.Value()})
+		}
+		sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].key, entries[j].key) < 0 })
+		for _, e := range entries {
+			h.Write(e.key)
+			if !hashValue(h, e.elem, path) {
+				return false
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !hashValue(h, v.Field(i), path) {
+				return false
+			}
+		}
+	case reflect.Ptr:
+		if v.IsNil() {
+			h.Write([]byte{0})
+			return true
+		}
+		if !enter(path, v.Pointer()) {
+			return false
+		}
+		defer delete(path, v.Pointer())
+		return hashValue(h, v.Elem(), path)
+	case reflect.Interface:
+		if v.IsNil() {
+			h.Write([]byte{0})
+			return true
+		}
+		return hashValue(h, v.Elem(), path)
+	default:
+		// funcs, channels and unsafe pointers may produce different outputs with the same hash.
+		return false
+	}
+	return true
+}
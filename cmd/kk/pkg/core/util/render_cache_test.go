@@ -0,0 +1,151 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package util
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"text/template"
+)
+
+func TestRenderCache(t *testing.T) {
+	var renders int32
+	tmpl := template.Must(template.New("hosts").Funcs(template.FuncMap{
+		"count": func() string {
+			atomic.AddInt32(&renders, 1)
+			return ""
+		},
+	}).Parse("{{ count }}{{ range .Hosts }}{{ . }}\n{{ end }}"))
+
+	type host struct {
+		name    string
+		address *string
+	}
+	address := "192.168.0.2"
+	tests := []struct {
+		name        string
+		variables   map[string]interface{}
+		wantRenders int32
+	}{
+		{
+			name:        "render",
+			variables:   map[string]interface{}{"Hosts": []string{"node1", "node2"}},
+			wantRenders: 1,
+		},
+		{
+			name:        "same variables",
+			variables:   map[string]interface{}{"Hosts": []string{"node1", "node2"}},
+			wantRenders: 1,
+		},
+		{
+			name:        "different variables",
+			variables:   map[string]interface{}{"Hosts": []string{"node1", "node3"}},
+			wantRenders: 2,
+		},
+		{
+			name:        "unexported fields",
+			variables:   map[string]interface{}{"Hosts": []host{{name: "node1", address: &address}}},
+			wantRenders: 3,
+		},
+		{
+			name:        "same unexported fields",
+			variables:   map[string]interface{}{"Hosts": []host{{name: "node1", address: &address}}},
+			wantRenders: 3,
+		},
+		{
+			name:        "func",
+			variables:   map[string]interface{}{"Hosts": []string{"node1"}, "Func": func() {}},
+			wantRenders: 4,
+		},
+		{
+			name:        "func again",
+			variables:   map[string]interface{}{"Hosts": []string{"node1"}, "Func": func() {}},
+			wantRenders: 5,
+		},
+	}
+
+	c := NewRenderCache()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := c.Render(tmpl, tt.variables, RenderOptions{}); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if got := atomic.LoadInt32(&renders); got != tt.wantRenders {
+				t.Errorf("rendered %d times, want %d", got, tt.wantRenders)
+			}
+		})
+	}
+}
+
+func TestRenderCacheConcurrent(t *testing.T) {
+	var renders int32
+	tmpl := template.Must(template.New("script").Funcs(template.FuncMap{
+		"count": func() string {
+			atomic.AddInt32(&renders, 1)
+			return ""
+		},
+	}).Parse("{{ count }}{{ .Name }}"))
+
+	c := NewRenderCache()
+	wg := sync.WaitGroup{}
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out, err := c.Render(tmpl, map[string]interface{}{"Name": "node1"}, RenderOptions{})
+			if err != nil || out != "node1" {
+				t.Errorf("Render() got = %q, error = %v", out, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if renders != 1 {
+		t.Errorf("rendered %d times, want 1", renders)
+	}
+}
+
+func TestRenderCacheStrict(t *testing.T) {
+	tmpl := template.Must(template.New("script").Parse("{{ .Name }}"))
+
+	c := NewRenderCache()
+	wg := sync.WaitGroup{}
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Render(tmpl, map[string]interface{}{}, RenderOptions{Strict: true}); err == nil {
+				t.Errorf("strict Render() of an undefined variable succeeded")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if out, err := c.Render(tmpl, map[string]interface{}{}, RenderOptions{}); err != nil || out != "<no value>" {
+				t.Errorf("Render() got = %q, error = %v", out, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestHashVariablesCycle(t *testing.T) {
+	m := map[string]interface{}{}
+	m["self"] = m
+	if _, ok := hashVariables(m); ok {
+		t.Errorf("hashVariables() of a self-referring map succeeded")
+	}
+}
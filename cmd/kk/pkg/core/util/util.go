@@ -33,17 +33,53 @@ import (
 
 type Data map[string]interface{}
 
+// RenderOptions are the options of RenderWith.
+type RenderOptions struct {
+	// Strict fails the rendering on the undefined variables instead of rendering them as "<no value>".
+	Strict bool
+}
+
 // Render text template with given `variables` Render-context
 func Render(tmpl *template.Template, variables map[string]interface{}) (string, error) {
+	return RenderWith(tmpl, variables, RenderOptions{})
+}
+
+// RenderWith renders the template with the variables and the options.
+func RenderWith(tmpl *template.Template, variables map[string]interface{}, opts RenderOptions) (string, error) {
 
 	var buf strings.Builder
 
+	if opts.Strict {
+		// the templates are shared by the parallel tasks, so the option is set on a copy.
+		strictTmpl, err := tmpl.Clone()
+		if err != nil {
+			return "", errors.Wrap(err, "Failed to clone template")
+		}
+		tmpl = strictTmpl.Option("missingkey=error")
+	}
+
 	if err := tmpl.Execute(&buf, variables); err != nil {
 		return "", errors.Wrap(err, "Failed to render template")
 	}
+
+	if opts.Strict {
+		if err := checkNoValue(tmpl.Name(), buf.String()); err != nil {
+			return "", err
+		}
+	}
 	return buf.String(), nil
 }
 
+// checkNoValue returns the location of the first variable which is defined but rendered as "<no value>", e.g. a nil value.
+func checkNoValue(name, content string) error {
+	for i, line := range strings.Split(content, "\n") {
+		if col := strings.Index(line, "<no value>"); col >= 0 {
+			return fmt.Errorf("template: %s:%d:%d: undefined variable rendered as <no value> in the output", name, i+1, col+1)
+		}
+	}
+	return nil
+}
+
 // Home returns the home directory for the executing user.
 func Home() (string, error) {
 	u, err := user.Current()
@@ -136,3 +172,17 @@ func ArchAlias(arch string) string {
 		return ""
 	}
 }
+
+// Arch returns the architecture of the machine hardware name printed by `uname -m`, it's the reverse of ArchAlias.
+// x86_64: amd64
+// aarch64: arm64
+func Arch(machine string) string {
+	switch machine {
+	case "x86_64", "amd64":
+		return "amd64"
+	case "aarch64", "arm64":
+		return "arm64"
+	default:
+		return ""
+	}
+}
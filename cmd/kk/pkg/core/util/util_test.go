@@ -16,7 +16,10 @@
 
 package util
 
-import "testing"
+import (
+	"testing"
+	"text/template"
+)
 
 func TestRound(t *testing.T) {
 	type args struct {
@@ -53,3 +56,88 @@ func TestRound(t *testing.T) {
 		})
 	}
 }
+
+func TestRender(t *testing.T) {
+	tmpl := template.Must(template.New("test").Parse("name: {{ .Name }}\nport: {{ .Port }}"))
+	tests := []struct {
+		name    string
+		strict  bool
+		data    Data
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "defined",
+			data: Data{"Name": "node1", "Port": 22},
+			want: "name: node1\nport: 22",
+		},
+		{
+			name: "undefined",
+			data: Data{"Name": "node1"},
+			want: "name: node1\nport: <no value>",
+		},
+		{
+			name:    "undefined in strict mode",
+			strict:  true,
+			data:    Data{"Name": "node1"},
+			wantErr: true,
+		},
+		{
+			name:    "nil in strict mode",
+			strict:  true,
+			data:    Data{"Name": "node1", "Port": nil},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderWith(tmpl, tt.data, RenderOptions{Strict: tt.strict})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Render() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArch(t *testing.T) {
+	tests := []struct {
+		machine string
+		want    string
+	}{
+		{machine: "x86_64", want: "amd64"},
+		{machine: "aarch64", want: "arm64"},
+		{machine: "arm64", want: "arm64"},
+		{machine: "armv7l", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.machine, func(t *testing.T) {
+			if got := Arch(tt.machine); got != tt.want {
+				t.Errorf("Arch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCIDRAddress(t *testing.T) {
+	tests := []struct {
+		cidr string
+		n    int64
+		want string
+	}{
+		{cidr: "10.233.0.0/18", n: 1, want: "10.233.0.1"},
+		{cidr: "10.233.0.0/18", n: 3, want: "10.233.0.3"},
+		{cidr: "10.233.0.0/30", n: 4, want: ""},
+		{cidr: "fd00:10:96::/108", n: 10, want: "fd00:10:96::a"},
+		{cidr: "fd00:10:96:", n: 1, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cidr, func(t *testing.T) {
+			if got := CIDRAddress(tt.cidr, tt.n); got != tt.want {
+				t.Errorf("CIDRAddress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+// SkippedFile lists the files of the bundle skipped since the bundle reached its size cap.
+const SkippedFile = "SKIPPED.txt"
+
+// Bundle is the support bundle collected from the nodes into a dir, with a dir for each node, before it is archived.
+// The files are redacted and capped in size, so the bundle can be shared.
+type Bundle struct {
+	Dir string
+	// MaxFileSize caps each file, the beginning of the larger files is dropped up to a line since the end of the logs
+	// is the most recent. It is unlimited if it is zero.
+	MaxFileSize int64
+	// MaxSize caps all the files, the files beyond it are skipped. It is unlimited if it is zero.
+	MaxSize int64
+
+	mu      sync.Mutex
+	size    int64
+	files   int
+	skipped []string
+}
+
+func NewBundle(dir string, maxFileSize, maxSize int64) *Bundle {
+	return &Bundle{Dir: dir, MaxFileSize: maxFileSize, MaxSize: maxSize}
+}
+
+// Add redacts the content of the file of the node and writes it to the bundle. The content is truncated to the
+// MaxFileSize, and skipped if the bundle would exceed the MaxSize.
+func (b *Bundle) Add(node, name string, content []byte) error {
+	if b.MaxFileSize > 0 && int64(len(content)) > b.MaxFileSize {
+		// the partial first line is dropped, so a secret cut in half isn't left unredacted
+		content = content[int64(len(content))-b.MaxFileSize:]
+		if i := bytes.IndexByte(content, '\n'); i >= 0 {
+			content = content[i+1:]
+		}
+	}
+	redacted := []byte(logger.Log.Redactor.Redact(string(content)))
+
+	b.mu.Lock()
+	if b.MaxSize > 0 && b.size+int64(len(redacted)) > b.MaxSize {
+		b.skipped = append(b.skipped, path.Join(node, name))
+		b.mu.Unlock()
+		logger.Log.Warnf("skip %s of %s, the bundle reached its size cap", name, node)
+		return nil
+	}
+	b.size += int64(len(redacted))
+	b.files++
+	b.mu.Unlock()
+
+	fileName := filepath.Join(b.Dir, node, name)
+	if err := util.WriteFile(fileName, redacted); err != nil {
+		return errors.Wrapf(err, "write %s to the bundle failed", fileName)
+	}
+	return nil
+}
+
+// Skipped returns the files skipped since the bundle reached its size cap, as <node>/<name>.
+func (b *Bundle) Skipped() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	skipped := append([]string(nil), b.skipped...)
+	sort.Strings(skipped)
+	return skipped
+}
+
+func (b *Bundle) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return fmt.Sprintf("%d files, %d bytes, %d skipped", b.files, b.size, len(b.skipped))
+}
+
+// Archive packs the bundle into the gzip compressed tarball dst, under the base name of its dir. The skipped files
+// are listed in the SkippedFile of the bundle.
+func (b *Bundle) Archive(dst string) error {
+	if skipped := b.Skipped(); len(skipped) > 0 {
+		content := "The files skipped since the bundle reached its size cap:\n" + strings.Join(skipped, "\n") + "\n"
+		if err := util.WriteFile(filepath.Join(b.Dir, SkippedFile), []byte(content)); err != nil {
+			return err
+		}
+	}
+	if err := util.Tar(b.Dir, dst, filepath.Dir(b.Dir)); err != nil {
+		return errors.Wrapf(err, "archive the bundle to %s failed", dst)
+	}
+	return nil
+}
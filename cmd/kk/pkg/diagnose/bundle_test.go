@@ -0,0 +1,83 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+func TestBundle(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	dir := filepath.Join(t.TempDir(), "kubekey-diagnose")
+	bundle := NewBundle(dir, 32, 64)
+
+	if err := bundle.Add("node1", "kubeadm-config.yaml", []byte("token: abcdef.0123456789abcdef\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := bundle.Add("node1", "kubelet.log", []byte(strings.Repeat("old\n", 100)+"last line\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := bundle.Add("node2", "kubelet.log", []byte(strings.Repeat("x", 30))); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "node1", "kubeadm-config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "abcdef.0123456789abcdef") {
+		t.Errorf("the token isn't redacted: %s", content)
+	}
+	content, err = os.ReadFile(filepath.Join(dir, "node1", "kubelet.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content) > 32 || !strings.HasPrefix(string(content), "old\n") || !strings.HasSuffix(string(content), "last line\n") {
+		t.Errorf("the log is %q, want its last lines within 32 bytes", content)
+	}
+	if want := []string{"node2/kubelet.log"}; !reflect.DeepEqual(bundle.Skipped(), want) {
+		t.Errorf("Skipped() = %v, want %v", bundle.Skipped(), want)
+	}
+
+	dst := filepath.Join(t.TempDir(), "kubekey-diagnose.tar.gz")
+	if err := bundle.Archive(dst); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := os.ReadFile(filepath.Join(dir, SkippedFile)); err != nil || !strings.Contains(string(content), "node2/kubelet.log") {
+		t.Errorf("the skipped files are %q, %v", content, err)
+	}
+	if info, err := os.Stat(dst); err != nil || info.Size() == 0 {
+		t.Errorf("the archive is %v, %v", info, err)
+	}
+}
+
+func TestStageCommand(t *testing.T) {
+	got := stageCommand("/tmp/kubekey/diagnose", []File{{Name: "etcd.log", Cmd: "journalctl -u etcd --no-pager"}}, 1024)
+	want := "rm -rf /tmp/kubekey/diagnose && mkdir -p /tmp/kubekey/diagnose; " +
+		"(journalctl -u etcd --no-pager) 2>/dev/null | tail -c 1025 > /tmp/kubekey/diagnose/etcd.log; " +
+		"[ -s /tmp/kubekey/diagnose/etcd.log ] || rm -f /tmp/kubekey/diagnose/etcd.log; " +
+		"chmod -R a+rX /tmp/kubekey/diagnose"
+	if got != want {
+		t.Errorf("stageCommand() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+)
+
+// CollectModule collects the support bundle of the Nodes, or of all the nodes if it is empty, into the Bundle. A node
+// which fails doesn't fail the module, so the bundle has the others.
+type CollectModule struct {
+	common.KubeModule
+	Nodes  []string
+	Bundle *Bundle
+}
+
+func (c *CollectModule) Init() {
+	c.Name = "DiagnoseModule"
+	c.Desc = "Collect the support bundle of the nodes"
+
+	collectNodeFiles := &task.RemoteTask{
+		Name:     "CollectNodeFiles",
+		Desc:     "Collect the journals, the configs and the logs of the nodes",
+		Hosts:    c.hosts(),
+		Action:   &CollectNodeFiles{Bundle: c.Bundle},
+		Parallel: true,
+	}
+
+	dumpClusterInfo := &task.RemoteTask{
+		Name:    "DumpClusterInfo",
+		Desc:    "Dump the cluster info by kubectl",
+		Hosts:   c.Runtime.GetHostsByRole(common.Master),
+		Prepare: new(common.OnlyFirstMaster),
+		Action:  &DumpClusterInfo{Bundle: c.Bundle},
+	}
+
+	c.Tasks = []task.Interface{
+		collectNodeFiles,
+		dumpClusterInfo,
+	}
+}
+
+func (c *CollectModule) hosts() []connector.Host {
+	if len(c.Nodes) == 0 {
+		return c.Runtime.GetAllHosts()
+	}
+	var hosts []connector.Host
+	for _, host := range c.Runtime.GetAllHosts() {
+		for _, name := range c.Nodes {
+			if host.GetName() == name {
+				hosts = append(hosts, host)
+			}
+		}
+	}
+	return hosts
+}
@@ -0,0 +1,139 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package diagnose
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+// File is a file of the bundle of a node, the output of the command run with privilege. The files whose output is
+// empty, e.g. the logs which don't exist on the node, are left out.
+type File struct {
+	Name string
+	Cmd  string
+}
+
+// NodeFiles are the files collected from each node.
+var NodeFiles = []File{
+	{Name: "kubelet.log", Cmd: "journalctl -u kubelet --no-pager"},
+	{Name: "containerd.log", Cmd: "journalctl -u containerd --no-pager"},
+	{Name: "etcd.log", Cmd: "journalctl -u etcd --no-pager"},
+	{Name: "kubeadm-config.yaml", Cmd: "cat /etc/kubernetes/kubeadm-config.yaml"},
+	{Name: "kubelet-config.yaml", Cmd: "cat /var/lib/kubelet/config.yaml"},
+	{Name: "kubeadm-flags.env", Cmd: "cat /var/lib/kubelet/kubeadm-flags.env"},
+	{Name: "etcd.env", Cmd: "cat /etc/etcd.env"},
+	{Name: "messages", Cmd: "cat /var/log/messages"},
+	{Name: "syslog", Cmd: "cat /var/log/syslog"},
+	{Name: "dmesg", Cmd: "dmesg -T"},
+}
+
+// ClusterInfoFile is the dump of the cluster, collected from the first control-plane node.
+var ClusterInfoFile = File{Name: "cluster-info.dump", Cmd: "/usr/local/bin/kubectl cluster-info dump --all-namespaces"}
+
+// stageDir is the dir the files of the bundle are staged in on the nodes.
+var stageDir = path.Join(common.TmpDir, "diagnose")
+
+// stageCommand returns the command writing the files to the dir, so the larger files aren't transferred, each one is
+// capped to one more byte than the maxFileSize, so the bundle knows it is truncated.
+func stageCommand(dir string, files []File, maxFileSize int64) string {
+	cmds := []string{fmt.Sprintf("rm -rf %[1]s && mkdir -p %[1]s", dir)}
+	for _, f := range files {
+		out := path.Join(dir, f.Name)
+		tail := "cat"
+		if maxFileSize > 0 {
+			tail = fmt.Sprintf("tail -c %d", maxFileSize+1)
+		}
+		cmds = append(cmds, fmt.Sprintf("(%s) 2>/dev/null | %s > %s; [ -s %[3]s ] || rm -f %[3]s", f.Cmd, tail, out))
+	}
+	cmds = append(cmds, fmt.Sprintf("chmod -R a+rX %s", dir))
+	return strings.Join(cmds, "; ")
+}
+
+// collect stages the files on the node, fetches them and adds them to the bundle. A file failed to fetch is logged,
+// so the bundle still has the others, and so is a node failed to collect.
+func collect(runtime connector.Runtime, bundle *Bundle, files []File) error {
+	if err := collectFiles(runtime, bundle, files); err != nil {
+		logger.Log.Warnf("collect the support bundle of %s failed: %v", runtime.RemoteHost().GetName(), err)
+	}
+	return nil
+}
+
+func collectFiles(runtime connector.Runtime, bundle *Bundle, files []File) error {
+	host := runtime.RemoteHost()
+	if _, err := runtime.GetRunner().SudoCmd(stageCommand(stageDir, files, bundle.MaxFileSize), false); err != nil {
+		return errors.Wrapf(err, "stage the files of the bundle on %s failed", host.GetName())
+	}
+	defer func() {
+		if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("rm -rf %s", stageDir), false); err != nil {
+			logger.Log.Warnf("remove %s on %s failed: %v", stageDir, host.GetName(), err)
+		}
+	}()
+
+	out, err := runtime.GetRunner().Cmd(fmt.Sprintf("ls %s", stageDir), false)
+	if err != nil {
+		return errors.Wrapf(err, "list the files of the bundle on %s failed", host.GetName())
+	}
+	for _, name := range strings.Fields(out) {
+		local := filepath.Join(runtime.GetHostWorkDir(), "diagnose", name)
+		if err := os.MkdirAll(filepath.Dir(local), 0755); err != nil {
+			return err
+		}
+		if err := runtime.GetRunner().Fetch(local, path.Join(stageDir, name)); err != nil {
+			logger.Log.Warnf("fetch %s of %s failed: %v", name, host.GetName(), err)
+			continue
+		}
+		content, err := os.ReadFile(local)
+		_ = os.Remove(local)
+		if err != nil {
+			return err
+		}
+		if err := bundle.Add(host.GetName(), name, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CollectNodeFiles collects the journals, the configs and the logs of the node into the bundle.
+type CollectNodeFiles struct {
+	common.KubeAction
+	Bundle *Bundle
+}
+
+func (c *CollectNodeFiles) Execute(runtime connector.Runtime) error {
+	return collect(runtime, c.Bundle, NodeFiles)
+}
+
+// DumpClusterInfo collects the kubectl cluster-info dump of the cluster into the bundle.
+type DumpClusterInfo struct {
+	common.KubeAction
+	Bundle *Bundle
+}
+
+func (d *DumpClusterInfo) Execute(runtime connector.Runtime) error {
+	return collect(runtime, d.Bundle, []File{ClusterInfoFile})
+}
@@ -0,0 +1,102 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package distribution
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/os"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
+)
+
+// Operation is the operation a pipeline does on the cluster.
+type Operation int
+
+const (
+	// Create creates the cluster.
+	Create Operation = iota
+	// AddNodes joins the new nodes to the existing cluster.
+	AddNodes
+)
+
+// Distribution is a Kubernetes distribution, selected by the kubernetes.type of the cluster. The create, add nodes and
+// delete pipelines are shared, each distribution provides the modules of its binaries, config templates and join logic.
+type Distribution interface {
+	// Name is the kubernetes.type of the distribution.
+	Name() string
+	// PreCheckModules check the nodes and confirm the installation.
+	PreCheckModules(runtime *common.KubeRuntime) []module.Module
+	// BinariesModule downloads the binaries of the distribution.
+	BinariesModule() module.Module
+	// NodeModules get the status of the cluster and prepare the nodes, e.g. install the container runtime.
+	NodeModules(runtime *common.KubeRuntime, op Operation) []module.Module
+	// ControlPlaneModules install the binaries, init the cluster on create, join the nodes and deploy the load balancer
+	// of the control plane.
+	ControlPlaneModules(runtime *common.KubeRuntime, op Operation) []module.Module
+	// ClusterModules configure the cluster once the nodes are ready, e.g. save the kubeconfig.
+	ClusterModules(runtime *common.KubeRuntime, op Operation) []module.Module
+	// CleanupLevels reports whether the delete supports the cleanup levels other than the default one.
+	CleanupLevels() bool
+	// DeleteModules remove the distribution from the nodes, the OS environment is cleared afterwards.
+	DeleteModules(runtime *common.KubeRuntime, level os.CleanupLevel) []module.Module
+}
+
+var (
+	mu            sync.RWMutex
+	distributions = make(map[string]Distribution)
+)
+
+// Register makes the distribution available by its name, a registered name is replaced.
+func Register(d Distribution) {
+	mu.Lock()
+	defer mu.Unlock()
+	distributions[d.Name()] = d
+}
+
+// Get returns the distribution of the kubernetes.type, the empty type is the kubernetes one.
+func Get(name string) (Distribution, error) {
+	if name == "" {
+		name = common.Kubernetes
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	d, ok := distributions[name]
+	if !ok {
+		return nil, errors.Errorf("unsupported kubernetes type %q, supported types: %v", name, names())
+	}
+	return d, nil
+}
+
+// Names returns the sorted names of the registered distributions.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return names()
+}
+
+func names() []string {
+	s := make([]string, 0, len(distributions))
+	for name := range distributions {
+		s = append(s, name)
+	}
+	sort.Strings(s)
+	return s
+}
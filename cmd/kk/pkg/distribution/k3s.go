@@ -0,0 +1,85 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package distribution
+
+import (
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/binaries"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/os"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/precheck"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/k3s"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/loadbalancer"
+)
+
+func init() {
+	Register(k3sDistribution{})
+}
+
+// k3sDistribution is K3s, a single binary with the embedded control plane.
+type k3sDistribution struct{}
+
+func (k3sDistribution) Name() string {
+	return common.K3s
+}
+
+func (k3sDistribution) PreCheckModules(runtime *common.KubeRuntime) []module.Module {
+	return []module.Module{&precheck.BenchmarkModule{Skip: !runtime.Cluster.Benchmark.Enabled()}}
+}
+
+func (k3sDistribution) BinariesModule() module.Module {
+	return &binaries.K3sNodeBinariesModule{}
+}
+
+func (k3sDistribution) NodeModules(_ *common.KubeRuntime, _ Operation) []module.Module {
+	return []module.Module{&k3s.StatusModule{}}
+}
+
+func (k3sDistribution) ControlPlaneModules(runtime *common.KubeRuntime, op Operation) []module.Module {
+	endpoint := runtime.Cluster.ControlPlaneEndpoint
+	haproxy := &loadbalancer.K3sHaproxyModule{Skip: !endpoint.IsInternalLBEnabled()}
+	if op == AddNodes {
+		return []module.Module{
+			&k3s.InstallKubeBinariesModule{},
+			&k3s.JoinNodesModule{},
+			haproxy,
+		}
+	}
+	return []module.Module{
+		&loadbalancer.K3sKubevipModule{Skip: !endpoint.IsInternalLBEnabledVip()},
+		&k3s.InstallKubeBinariesModule{},
+		&k3s.InitClusterModule{},
+		&k3s.StatusModule{},
+		&k3s.JoinNodesModule{},
+		haproxy,
+	}
+}
+
+func (k3sDistribution) ClusterModules(_ *common.KubeRuntime, op Operation) []module.Module {
+	if op == AddNodes {
+		return nil
+	}
+	return []module.Module{&k3s.SaveKubeConfigModule{}}
+}
+
+func (k3sDistribution) CleanupLevels() bool {
+	return false
+}
+
+func (k3sDistribution) DeleteModules(_ *common.KubeRuntime, _ os.CleanupLevel) []module.Module {
+	return []module.Module{&k3s.DeleteClusterModule{}}
+}
@@ -0,0 +1,85 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package distribution
+
+import (
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/binaries"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/os"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/precheck"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/k8e"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/loadbalancer"
+)
+
+func init() {
+	Register(k8eDistribution{})
+}
+
+// k8eDistribution is K8e, which is built on K3s and shares its load balancer of the control plane.
+type k8eDistribution struct{}
+
+func (k8eDistribution) Name() string {
+	return common.K8e
+}
+
+func (k8eDistribution) PreCheckModules(runtime *common.KubeRuntime) []module.Module {
+	return []module.Module{&precheck.BenchmarkModule{Skip: !runtime.Cluster.Benchmark.Enabled()}}
+}
+
+func (k8eDistribution) BinariesModule() module.Module {
+	return &binaries.K8eNodeBinariesModule{}
+}
+
+func (k8eDistribution) NodeModules(_ *common.KubeRuntime, _ Operation) []module.Module {
+	return []module.Module{&k8e.StatusModule{}}
+}
+
+func (k8eDistribution) ControlPlaneModules(runtime *common.KubeRuntime, op Operation) []module.Module {
+	endpoint := runtime.Cluster.ControlPlaneEndpoint
+	haproxy := &loadbalancer.K3sHaproxyModule{Skip: !endpoint.IsInternalLBEnabled()}
+	if op == AddNodes {
+		return []module.Module{
+			&k8e.InstallKubeBinariesModule{},
+			&k8e.JoinNodesModule{},
+			haproxy,
+		}
+	}
+	return []module.Module{
+		&loadbalancer.K3sKubevipModule{Skip: !endpoint.IsInternalLBEnabledVip()},
+		&k8e.InstallKubeBinariesModule{},
+		&k8e.InitClusterModule{},
+		&k8e.StatusModule{},
+		&k8e.JoinNodesModule{},
+		haproxy,
+	}
+}
+
+func (k8eDistribution) ClusterModules(_ *common.KubeRuntime, op Operation) []module.Module {
+	if op == AddNodes {
+		return nil
+	}
+	return []module.Module{&k8e.SaveKubeConfigModule{}}
+}
+
+func (k8eDistribution) CleanupLevels() bool {
+	return false
+}
+
+func (k8eDistribution) DeleteModules(_ *common.KubeRuntime, _ os.CleanupLevel) []module.Module {
+	return []module.Module{&k8e.DeleteClusterModule{}}
+}
@@ -0,0 +1,125 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package distribution
+
+import (
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/binaries"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/confirm"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/os"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/precheck"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/registry"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/container"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/images"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/kubernetes"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/loadbalancer"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/plugins"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/plugins/dns"
+)
+
+func init() {
+	Register(kubeadm{})
+}
+
+// kubeadm is the vanilla Kubernetes installed by kubeadm.
+type kubeadm struct{}
+
+func (kubeadm) Name() string {
+	return common.Kubernetes
+}
+
+func (kubeadm) PreCheckModules(runtime *common.KubeRuntime) []module.Module {
+	return []module.Module{
+		&precheck.NodePreCheckModule{},
+		&precheck.BenchmarkModule{Skip: !runtime.Cluster.Benchmark.Enabled()},
+		&confirm.InstallConfirmModule{},
+	}
+}
+
+func (kubeadm) BinariesModule() module.Module {
+	return &binaries.NodeBinariesModule{}
+}
+
+func (kubeadm) NodeModules(runtime *common.KubeRuntime, op Operation) []module.Module {
+	var m []module.Module
+	if op == AddNodes {
+		m = append(m,
+			&registry.RegistryCertsModule{Skip: len(runtime.GetHostsByRole(common.Registry)) == 0},
+			//for one master to multi master kube-vip
+			&loadbalancer.KubevipModule{Skip: !runtime.Cluster.ControlPlaneEndpoint.IsInternalLBEnabledVip()},
+			&kubernetes.RestartKubeletModule{},
+		)
+	}
+	return append(m,
+		&kubernetes.StatusModule{},
+		&container.InstallContainerModule{},
+		&container.InstallCriDockerdModule{Skip: runtime.Cluster.Kubernetes.ContainerManager != "docker"},
+		&images.PullModule{Skip: runtime.Arg.SkipPullImages},
+	)
+}
+
+func (kubeadm) ControlPlaneModules(runtime *common.KubeRuntime, op Operation) []module.Module {
+	endpoint := runtime.Cluster.ControlPlaneEndpoint
+	if op == AddNodes {
+		return []module.Module{
+			&kubernetes.InstallKubeBinariesModule{},
+			&kubernetes.JoinNodesModule{},
+			&loadbalancer.DNSRecordsModule{Skip: !endpoint.ManagedDNS()},
+			&loadbalancer.HaproxyModule{Skip: !endpoint.IsInternalLBEnabled()},
+		}
+	}
+	return []module.Module{
+		&kubernetes.InstallKubeBinariesModule{},
+		// init kubeVip on first master
+		&loadbalancer.KubevipModule{Skip: !endpoint.IsInternalLBEnabledVip()},
+		&loadbalancer.DNSRecordsModule{Skip: !endpoint.ManagedDNS(), FirstMasterOnly: true},
+		&kubernetes.InitKubernetesModule{},
+		&dns.ClusterDNSModule{},
+		&kubernetes.StatusModule{},
+		&kubernetes.JoinNodesModule{},
+		&loadbalancer.DNSRecordsModule{Skip: !endpoint.ManagedDNS()},
+		// deploy kubeVip on other masters
+		&loadbalancer.KubevipModule{Skip: !endpoint.IsInternalLBEnabledVip()},
+		&loadbalancer.HaproxyModule{Skip: !endpoint.IsInternalLBEnabled()},
+	}
+}
+
+func (kubeadm) ClusterModules(runtime *common.KubeRuntime, op Operation) []module.Module {
+	gpu := &plugins.GPUModule{Skip: !runtime.Cluster.Kubernetes.EnableGPU()}
+	if op == AddNodes {
+		return []module.Module{gpu}
+	}
+	return []module.Module{
+		&kubernetes.SecurityEnhancementModule{Skip: !runtime.Arg.SecurityEnhancement},
+		&kubernetes.SaveKubeConfigModule{},
+		&plugins.DeployPluginsModule{},
+		gpu,
+	}
+}
+
+func (kubeadm) CleanupLevels() bool {
+	return true
+}
+
+func (kubeadm) DeleteModules(_ *common.KubeRuntime, level os.CleanupLevel) []module.Module {
+	return []module.Module{
+		&kubernetes.ResetClusterModule{},
+		&container.UninstallContainerModule{Skip: !level.RemovesRuntime()},
+		&os.RemoveBinariesModule{Skip: !level.RemovesRuntime()},
+	}
+}
@@ -0,0 +1,193 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package drift
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	roleControlPlane = "control-plane"
+	roleMaster       = "master"
+	roleWorker       = "worker"
+	roleEtcd         = "etcd"
+)
+
+var hostRange = regexp.MustCompile(`\[(\d+):(\d+)\]`)
+
+// FixConfig adopts the nodes into the config as the hosts of their roles, and prunes the hosts from the config with
+// their roles. The config is edited in place, so the other documents and the comments of the file are kept. The hosts
+// which can't be pruned, as a range of the roleGroups covers them, are returned in the warnings.
+func FixConfig(content []byte, adopt []Node, prune []string) ([]byte, []string, error) {
+	var docs []*yaml.Node
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		doc := &yaml.Node{}
+		if err := decoder.Decode(doc); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, nil, errors.Wrap(err, "failed to parse the config")
+		}
+		docs = append(docs, doc)
+	}
+
+	var spec *yaml.Node
+	for _, doc := range docs {
+		if len(doc.Content) == 0 {
+			continue
+		}
+		if kind := mappingValue(doc.Content[0], "kind"); kind != nil && kind.Value == "Cluster" {
+			spec = mappingValue(doc.Content[0], "spec")
+			break
+		}
+	}
+	if spec == nil || spec.Kind != yaml.MappingNode {
+		return nil, nil, errors.New("no spec of the Cluster in the config")
+	}
+	hosts := mappingValue(spec, "hosts")
+	roleGroups := mappingValue(spec, "roleGroups")
+	if hosts == nil || hosts.Kind != yaml.SequenceNode || roleGroups == nil || roleGroups.Kind != yaml.MappingNode {
+		return nil, nil, errors.New("no hosts or roleGroups of the Cluster in the config")
+	}
+
+	var warnings []string
+	for _, name := range prune {
+		if entry, role, ok := coveringRange(roleGroups, name); ok {
+			warnings = append(warnings, fmt.Sprintf("%s is covered by %s of the %s group, remove it by hand", name, entry, role))
+			continue
+		}
+		hosts.Content = removeItems(hosts.Content, func(n *yaml.Node) bool {
+			v := mappingValue(n, "name")
+			return v != nil && v.Value == name
+		})
+		for i := 0; i+1 < len(roleGroups.Content); i += 2 {
+			group := roleGroups.Content[i+1]
+			before := len(group.Content)
+			group.Content = removeItems(group.Content, func(n *yaml.Node) bool { return n.Value == name })
+			if roleGroups.Content[i].Value == roleEtcd && len(group.Content) != before {
+				warnings = append(warnings, fmt.Sprintf("%s is pruned from the etcd group, remove its etcd member if it is still in the cluster", name))
+			}
+		}
+	}
+
+	for _, node := range adopt {
+		host := &yaml.Node{Kind: yaml.MappingNode, Style: yaml.FlowStyle, LineComment: "adopted from the cluster, set its ssh settings"}
+		host.Content = append(host.Content, scalar("name"), scalar(node.Name))
+		if node.InternalIP != "" {
+			host.Content = append(host.Content, scalar("address"), scalar(node.InternalIP), scalar("internalAddress"), scalar(node.InternalIP))
+		}
+		hosts.Content = append(hosts.Content, host)
+		if node.ControlPlane {
+			role := roleControlPlane
+			if mappingValue(roleGroups, roleControlPlane) == nil && mappingValue(roleGroups, roleMaster) != nil {
+				role = roleMaster
+			}
+			appendToGroup(roleGroups, role, node.Name)
+		}
+		if node.Worker {
+			appendToGroup(roleGroups, roleWorker, node.Name)
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for _, doc := range docs {
+		if err := encoder.Encode(doc); err != nil {
+			return nil, nil, errors.Wrap(err, "failed to write the config")
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to write the config")
+	}
+	return buf.Bytes(), warnings, nil
+}
+
+// mappingValue returns the value of the key of the mapping node, or nil.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+func removeItems(items []*yaml.Node, match func(*yaml.Node) bool) []*yaml.Node {
+	kept := items[:0]
+	for _, item := range items {
+		if !match(item) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+func appendToGroup(roleGroups *yaml.Node, role, name string) {
+	group := mappingValue(roleGroups, role)
+	if group == nil {
+		group = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		roleGroups.Content = append(roleGroups.Content, scalar(role), group)
+	}
+	// an empty group is written as null
+	if group.Kind != yaml.SequenceNode {
+		*group = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	}
+	group.Content = append(group.Content, scalar(name))
+}
+
+// coveringRange returns the range entry of the roleGroups, as node[1:3], which covers the host.
+func coveringRange(roleGroups *yaml.Node, name string) (string, string, bool) {
+	for i := 0; i+1 < len(roleGroups.Content); i += 2 {
+		for _, entry := range roleGroups.Content[i+1].Content {
+			m := hostRange.FindStringSubmatch(entry.Value)
+			if m == nil {
+				continue
+			}
+			prefix := strings.Split(entry.Value, m[0])[0]
+			if !strings.HasPrefix(name, prefix) {
+				continue
+			}
+			n, err := strconv.Atoi(strings.TrimPrefix(name, prefix))
+			if err != nil {
+				continue
+			}
+			start, _ := strconv.Atoi(m[1])
+			end, _ := strconv.Atoi(m[2])
+			if n >= start && n <= end {
+				return entry.Value, roleGroups.Content[i].Value, true
+			}
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,184 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package drift compares the nodes of a live cluster with the hosts of its config, so the long-lived clusters and
+// their configs are kept in sync.
+package drift
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// The kinds of the drift of a node.
+const (
+	// Unmanaged is a node of the cluster which isn't a host of the config.
+	Unmanaged = "unmanaged"
+	// Missing is a kubernetes host of the config which isn't a node of the cluster.
+	Missing = "missing"
+	// Address is a node whose internal IP isn't the internalAddress of its host.
+	Address = "address"
+)
+
+const (
+	labelControlPlane = "node-role.kubernetes.io/control-plane"
+	labelMaster       = "node-role.kubernetes.io/master"
+	labelWorker       = "node-role.kubernetes.io/worker"
+)
+
+// Node is a node of the live cluster.
+type Node struct {
+	Name         string
+	InternalIP   string
+	ControlPlane bool
+	Worker       bool
+}
+
+// NodeOf returns the node of the kubernetes node object. The control-plane nodes are also workers only if they are
+// labeled so, as the clusters created by KubeKey label them.
+func NodeOf(node corev1.Node) Node {
+	n := Node{Name: node.Name}
+	for _, address := range node.Status.Addresses {
+		if address.Type == corev1.NodeInternalIP && n.InternalIP == "" {
+			n.InternalIP = address.Address
+		}
+	}
+	_, cp := node.Labels[labelControlPlane]
+	_, master := node.Labels[labelMaster]
+	_, worker := node.Labels[labelWorker]
+	n.ControlPlane = cp || master
+	n.Worker = worker || !n.ControlPlane
+	return n
+}
+
+// Host is a kubernetes host of the config, a control-plane or a worker host. The InternalAddress has no brackets.
+type Host struct {
+	Name            string
+	Aliases         []string
+	InternalAddress string
+}
+
+func (h Host) is(name string) bool {
+	if strings.EqualFold(h.Name, name) {
+		return true
+	}
+	for _, alias := range h.Aliases {
+		if strings.EqualFold(alias, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Item is the drift of a node.
+type Item struct {
+	Kind string
+	Name string
+	// Address is the internal IP of the node, or the internalAddress of the missing host.
+	Address string
+	// HostAddress is the internalAddress of the host of the node of the Address kind.
+	HostAddress string
+	Node        Node
+}
+
+// Suggestion tells how to resolve the drift.
+func (i Item) Suggestion() string {
+	switch i.Kind {
+	case Unmanaged:
+		return "adopt it into the config, or delete it by kk delete node"
+	case Missing:
+		return "join it by kk add nodes, or prune it from the config"
+	case Address:
+		return fmt.Sprintf("the internalAddress of the host is %s, correct the config", i.HostAddress)
+	}
+	return ""
+}
+
+// Report is the drift between the nodes of a cluster and the hosts of its config.
+type Report struct {
+	Items []Item
+}
+
+// Compare compares the nodes of the cluster with the kubernetes hosts of the config. A node is the host of the same
+// name or alias, case-insensitively, since kubelet lowercases the name of the node.
+func Compare(hosts []Host, nodes []Node) Report {
+	var report Report
+	matched := make(map[string]bool, len(hosts))
+	for _, node := range nodes {
+		var host *Host
+		for i := range hosts {
+			if hosts[i].is(node.Name) {
+				host = &hosts[i]
+				break
+			}
+		}
+		if host == nil {
+			report.Items = append(report.Items, Item{Kind: Unmanaged, Name: node.Name, Address: node.InternalIP, Node: node})
+			continue
+		}
+		matched[host.Name] = true
+		if node.InternalIP != "" && host.InternalAddress != "" && node.InternalIP != host.InternalAddress {
+			report.Items = append(report.Items, Item{Kind: Address, Name: node.Name, Address: node.InternalIP, HostAddress: host.InternalAddress, Node: node})
+		}
+	}
+	for _, host := range hosts {
+		if !matched[host.Name] {
+			report.Items = append(report.Items, Item{Kind: Missing, Name: host.Name, Address: host.InternalAddress})
+		}
+	}
+	sort.SliceStable(report.Items, func(i, j int) bool {
+		if report.Items[i].Kind != report.Items[j].Kind {
+			return report.Items[i].Kind > report.Items[j].Kind
+		}
+		return report.Items[i].Name < report.Items[j].Name
+	})
+	return report
+}
+
+// Empty returns whether the nodes of the cluster match the hosts of the config.
+func (r Report) Empty() bool {
+	return len(r.Items) == 0
+}
+
+// Of returns the items of the kind.
+func (r Report) Of(kind string) []Item {
+	var items []Item
+	for _, item := range r.Items {
+		if item.Kind == kind {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Print prints the drift as a table with the suggestions.
+func (r Report) Print(w io.Writer) error {
+	if r.Empty() {
+		_, err := fmt.Fprintln(w, "The nodes of the cluster match the hosts of the config.")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 10, 4, 3, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "DRIFT\tNODE\tADDRESS\tSUGGESTION")
+	for _, item := range r.Items {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", item.Kind, item.Name, item.Address, item.Suggestion())
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,144 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package drift
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	hosts := []Host{
+		{Name: "Master1", InternalAddress: "172.16.0.2"},
+		{Name: "node1", Aliases: []string{"node1.mgmt"}, InternalAddress: "172.16.0.3"},
+		{Name: "node2", InternalAddress: "172.16.0.4"},
+	}
+	tests := []struct {
+		name  string
+		nodes []Node
+		want  []string
+	}{
+		{
+			name: "in sync",
+			nodes: []Node{
+				{Name: "master1", InternalIP: "172.16.0.2"},
+				{Name: "node1.mgmt", InternalIP: "172.16.0.3"},
+				{Name: "node2", InternalIP: "172.16.0.4"},
+			},
+		},
+		{
+			name: "drift",
+			nodes: []Node{
+				{Name: "master1", InternalIP: "172.16.0.2"},
+				{Name: "node1", InternalIP: "172.16.0.9"},
+				{Name: "node3", InternalIP: "172.16.0.5"},
+			},
+			want: []string{"unmanaged/node3", "missing/node2", "address/node1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, item := range Compare(hosts, tt.nodes).Items {
+				got = append(got, item.Kind+"/"+item.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+const testConfig = `apiVersion: kubekey.kubesphere.io/v1alpha2
+kind: Cluster
+metadata:
+  name: sample
+spec:
+  hosts:
+  # the control plane
+  - {name: master1, address: 172.16.0.2, internalAddress: 172.16.0.2}
+  - {name: node1, address: 172.16.0.3, internalAddress: 172.16.0.3}
+  - {name: node2, address: 172.16.0.4, internalAddress: 172.16.0.4}
+  roleGroups:
+    etcd:
+    - master1
+    master:
+    - master1
+    worker:
+    - node[1:2]
+---
+apiVersion: v1
+kind: ConfigMap
+`
+
+func TestFixConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		adopt    []Node
+		prune    []string
+		contains []string
+		excludes []string
+		warnings int
+	}{
+		{
+			name:  "adopt",
+			adopt: []Node{{Name: "master2", InternalIP: "172.16.0.5", ControlPlane: true}, {Name: "node3", InternalIP: "172.16.0.6", Worker: true}},
+			contains: []string{
+				"# the control plane",
+				"- {name: master2, address: 172.16.0.5, internalAddress: 172.16.0.5} # adopted from the cluster",
+				"master:\n      - master1\n      - master2",
+				"worker:\n      - node[1:2]\n      - node3",
+				"kind: ConfigMap",
+			},
+			excludes: []string{"control-plane:"},
+		},
+		{
+			name:     "prune",
+			prune:    []string{"master1"},
+			excludes: []string{"name: master1", "- master1"},
+			warnings: 1,
+		},
+		{
+			name:     "covered by a range",
+			prune:    []string{"node2"},
+			contains: []string{"name: node2", "- node[1:2]"},
+			warnings: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warnings, err := FixConfig([]byte(testConfig), tt.adopt, tt.prune)
+			if err != nil {
+				t.Fatalf("FixConfig() error = %v", err)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(string(got), s) {
+					t.Errorf("FixConfig() = %s, want it to contain %q", got, s)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(string(got), s) {
+					t.Errorf("FixConfig() = %s, want it not to contain %q", got, s)
+				}
+			}
+			if len(warnings) != tt.warnings {
+				t.Errorf("FixConfig() warnings = %v, want %d", warnings, tt.warnings)
+			}
+		})
+	}
+}
@@ -110,7 +110,7 @@ type FetchCerts struct {
 
 func (f *FetchCerts) Execute(runtime connector.Runtime) error {
 	src := "/etc/ssl/etcd/ssl"
-	dst := fmt.Sprintf("%s/pki/etcd", runtime.GetWorkDir())
+	dst := fmt.Sprintf("%s/pki/etcd", runtime.GetClusterWorkDir())
 
 	v, ok := f.PipelineCache.Get(common.ETCDCluster)
 	if !ok {
@@ -144,7 +144,7 @@ type GenerateCerts struct {
 
 func (g *GenerateCerts) Execute(runtime connector.Runtime) error {
 
-	pkiPath := fmt.Sprintf("%s/pki/etcd", runtime.GetWorkDir())
+	pkiPath := fmt.Sprintf("%s/pki/etcd", runtime.GetClusterWorkDir())
 
 	altName := GenerateAltName(g.KubeConf, &runtime)
 
@@ -221,7 +221,7 @@ type FetchCertsForExternalEtcd struct {
 
 func (f *FetchCertsForExternalEtcd) Execute(runtime connector.Runtime) error {
 
-	pkiPath := fmt.Sprintf("%s/pki/etcd", runtime.GetWorkDir())
+	pkiPath := fmt.Sprintf("%s/pki/etcd", runtime.GetClusterWorkDir())
 
 	if err := util.CreateDir(pkiPath); err != nil {
 		return errors.Wrap(err, fmt.Sprintf("failed to create dir %s", pkiPath))
@@ -25,6 +25,7 @@ import (
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/etcd/templates"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/topology"
 )
 
 type PreCheckModule struct {
@@ -38,15 +39,17 @@ func (p *PreCheckModule) IsSkip() bool {
 
 func (p *PreCheckModule) Init() {
 	p.Name = "ETCDPreCheckModule"
+	p.Tags = []string{"etcd"}
 	p.Desc = "Get ETCD cluster status"
 
 	getStatus := &task.RemoteTask{
-		Name:     "GetETCDStatus",
-		Desc:     "Get etcd status",
-		Hosts:    p.Runtime.GetHostsByRole(common.ETCD),
-		Action:   new(GetStatus),
-		Parallel: false,
-		Retry:    0,
+		Name:      "GetETCDStatus",
+		Desc:      "Get etcd status",
+		Hosts:     p.Runtime.GetHostsByRole(common.ETCD),
+		Action:    new(GetStatus),
+		AlwaysRun: true,
+		Parallel:  false,
+		Retry:     0,
 	}
 	p.Tasks = []task.Interface{
 		getStatus,
@@ -64,6 +67,7 @@ func (p *CertsModule) IsSkip() bool {
 
 func (c *CertsModule) Init() {
 	c.Name = "CertsModule"
+	c.Tags = []string{"etcd", "certs"}
 	c.Desc = "Sign ETCD cluster certs"
 
 	switch c.KubeConf.Cluster.Etcd.Type {
@@ -151,12 +155,14 @@ func (p *InstallETCDBinaryModule) IsSkip() bool {
 
 func (i *InstallETCDBinaryModule) Init() {
 	i.Name = "InstallETCDBinaryModule"
+	i.Tags = []string{"etcd"}
 	i.Desc = "Install ETCD cluster"
 
 	installETCDBinary := &task.RemoteTask{
 		Name:     "InstallETCDBinary",
 		Desc:     "Install etcd using binary",
-		Hosts:    i.Runtime.GetHostsByRole(common.ETCD),
+		Hosts:    topology.SortHosts(i.KubeConf.Cluster, i.Runtime.GetHostsByRole(common.ETCD)),
+		Throttle: topology.Throttle(i.KubeConf.Cluster),
 		Prepare:  new(InstallOrUpgradeETCD),
 		Action:   new(InstallETCDBinary),
 		Parallel: true,
@@ -177,13 +183,14 @@ func (i *InstallETCDBinaryModule) Init() {
 	}
 
 	accessAddress := &task.RemoteTask{
-		Name:     "GenerateAccessAddress",
-		Desc:     "Generate access address",
-		Hosts:    i.Runtime.GetHostsByRole(common.ETCD),
-		Prepare:  new(FirstETCDNode),
-		Action:   new(GenerateAccessAddress),
-		Parallel: true,
-		Retry:    1,
+		Name:      "GenerateAccessAddress",
+		Desc:      "Generate access address",
+		Hosts:     i.Runtime.GetHostsByRole(common.ETCD),
+		Prepare:   new(FirstETCDNode),
+		Action:    new(GenerateAccessAddress),
+		AlwaysRun: true,
+		Parallel:  true,
+		Retry:     1,
 	}
 
 	i.Tasks = []task.Interface{
@@ -204,6 +211,7 @@ func (p *ConfigureModule) IsSkip() bool {
 
 func (e *ConfigureModule) Init() {
 	e.Name = "ETCDConfigureModule"
+	e.Tags = []string{"etcd"}
 	e.Desc = "Configure ETCD cluster"
 
 	if v, ok := e.PipelineCache.Get(common.ETCDCluster); ok {
@@ -229,12 +237,13 @@ func handleNewCluster(c *ConfigureModule) []task.Interface {
 	}
 
 	generateETCDConfig := &task.RemoteTask{
-		Name:     "GenerateETCDConfig",
-		Desc:     "Generate etcd.env config on new etcd",
-		Hosts:    c.Runtime.GetHostsByRole(common.ETCD),
-		Prepare:  &NodeETCDExist{Not: true},
-		Action:   new(GenerateConfig),
-		Parallel: false,
+		Name:      "GenerateETCDConfig",
+		Desc:      "Generate etcd.env config on new etcd",
+		Hosts:     c.Runtime.GetHostsByRole(common.ETCD),
+		Prepare:   &NodeETCDExist{Not: true},
+		Action:    new(GenerateConfig),
+		AlwaysRun: true,
+		Parallel:  false,
 	}
 
 	allRefreshETCDConfig := &task.RemoteTask{
@@ -296,12 +305,13 @@ func handleExistCluster(c *ConfigureModule) []task.Interface {
 	}
 
 	generateETCDConfig := &task.RemoteTask{
-		Name:     "GenerateETCDConfig",
-		Desc:     "Generate etcd.env config on new etcd",
-		Hosts:    c.Runtime.GetHostsByRole(common.ETCD),
-		Prepare:  &NodeETCDExist{Not: true},
-		Action:   new(GenerateConfig),
-		Parallel: false,
+		Name:      "GenerateETCDConfig",
+		Desc:      "Generate etcd.env config on new etcd",
+		Hosts:     c.Runtime.GetHostsByRole(common.ETCD),
+		Prepare:   &NodeETCDExist{Not: true},
+		Action:    new(GenerateConfig),
+		AlwaysRun: true,
+		Parallel:  false,
 	}
 
 	joinMember := &task.RemoteTask{
@@ -383,6 +393,7 @@ func (p *BackupModule) IsSkip() bool {
 
 func (b *BackupModule) Init() {
 	b.Name = "ETCDBackupModule"
+	b.Tags = []string{"etcd"}
 	b.Desc = "Backup ETCD cluster data"
 
 	backupETCD := &task.RemoteTask{
@@ -23,14 +23,17 @@ import (
 
 	"github.com/pkg/errors"
 
-	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	kubekeyv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/fips"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/etcd/templates"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/files"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/health"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/utils"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/version/kubernetes"
 )
 
 type EtcdNode struct {
@@ -200,17 +203,7 @@ func (h *HealthCheck) Execute(runtime connector.Runtime) error {
 }
 
 func healthCheck(runtime connector.Runtime, cluster *EtcdCluster) error {
-	host := runtime.RemoteHost()
-	checkHealthCmd := fmt.Sprintf("export ETCDCTL_API=2;"+
-		"export ETCDCTL_CERT_FILE='/etc/ssl/etcd/ssl/admin-%s.pem';"+
-		"export ETCDCTL_KEY_FILE='/etc/ssl/etcd/ssl/admin-%s-key.pem';"+
-		"export ETCDCTL_CA_FILE='/etc/ssl/etcd/ssl/ca.pem';"+
-		"%s/etcdctl --endpoints=%s cluster-health | grep -q 'cluster is healthy'",
-		host.GetName(), host.GetName(), common.BinDir, cluster.accessAddresses)
-	if _, err := runtime.GetRunner().SudoCmd(checkHealthCmd, false); err != nil {
-		return errors.Wrap(errors.WithStack(err), "etcd health check failed")
-	}
-	return nil
+	return health.Probe(runtime, health.Etcd(cluster.accessAddresses))
 }
 
 type GenerateConfig struct {
@@ -305,7 +298,7 @@ func refreshConfig(KubeConf *common.KubeConf, runtime connector.Runtime, endpoin
 		Template: templates.EtcdEnv,
 		Dst:      filepath.Join("/etc/", templates.EtcdEnv.Name()),
 		Data: util.Data{
-			"Tag":                 kubekeyapiv1alpha2.DefaultEtcdVersion,
+			"Tag":                 kubernetes.DefaultComponents(KubeConf.Cluster.Kubernetes.Version).Etcd,
 			"Name":                etcdName,
 			"Ip":                  host.GetInternalIPv4Address(),
 			"Hostname":            host.GetName(),
@@ -324,6 +317,8 @@ func refreshConfig(KubeConf *common.KubeConf, runtime connector.Runtime, endpoin
 			"MaxWals":             KubeConf.Cluster.Etcd.MaxWals,
 			"ElectionTimeout":     KubeConf.Cluster.Etcd.ElectionTimeout,
 			"HeartbeatInterval":   KubeConf.Cluster.Etcd.HeartbeatInterval,
+			"CipherSuites":        etcdCipherSuites(KubeConf.Cluster.TLS.Etcd),
+			"TLSMinVersion":       KubeConf.Cluster.TLS.Etcd.EtcdMinVersion(),
 		},
 	}
 
@@ -347,15 +342,10 @@ func (j *JoinMember) Execute(runtime connector.Runtime) error {
 
 	if v, ok := j.PipelineCache.Get(common.ETCDCluster); ok {
 		cluster := v.(*EtcdCluster)
-		joinMemberCmd := fmt.Sprintf("export ETCDCTL_API=2;"+
-			"export ETCDCTL_CERT_FILE='/etc/ssl/etcd/ssl/admin-%s.pem';"+
-			"export ETCDCTL_KEY_FILE='/etc/ssl/etcd/ssl/admin-%s-key.pem';"+
-			"export ETCDCTL_CA_FILE='/etc/ssl/etcd/ssl/ca.pem';"+
-			"%s/etcdctl --endpoints=%s member add %s %s",
-			host.GetName(), host.GetName(), common.BinDir, cluster.accessAddresses, etcdName,
-			fmt.Sprintf("https://%s:2380", host.GetInternalIPv4Address()))
-
-		if _, err := runtime.GetRunner().SudoCmd(joinMemberCmd, true); err != nil {
+		joinMemberCmd := fmt.Sprintf("%s/etcdctl --endpoints=%s member add %s %s",
+			common.BinDir, cluster.accessAddresses, etcdName, fmt.Sprintf("https://%s:2380", host.GetInternalIPv4Address()))
+
+		if _, err := runtime.GetRunner().SudoCmdWith(joinMemberCmd, etcdctlV2(host), true); err != nil {
 			return errors.Wrap(errors.WithStack(err), "add etcd member failed")
 		}
 	} else {
@@ -363,13 +353,23 @@ func (j *JoinMember) Execute(runtime connector.Runtime) error {
 	}
 
 	// After adding a new member for etcd, it is necessary to start the new member as the etcd cluster may experience abnormal behavior.
-	if _, err := runtime.GetRunner().SudoCmd("systemctl daemon-reload && systemctl restart etcd && systemctl enable etcd", true); err != nil {
+	if err := action.ManageService(runtime, "etcd", etcdService); err != nil {
 		return errors.Wrap(errors.WithStack(err), "start etcd failed")
 	}
 
 	return nil
 }
 
+// etcdctlV2 is the environment of the etcdctl of the v2 API on the host, with the admin certificate of the host.
+func etcdctlV2(host connector.Host) connector.CommandOptions {
+	return connector.CommandOptions{Env: map[string]string{
+		"ETCDCTL_API":       "2",
+		"ETCDCTL_CERT_FILE": fmt.Sprintf("/etc/ssl/etcd/ssl/admin-%s.pem", host.GetName()),
+		"ETCDCTL_KEY_FILE":  fmt.Sprintf("/etc/ssl/etcd/ssl/admin-%s-key.pem", host.GetName()),
+		"ETCDCTL_CA_FILE":   "/etc/ssl/etcd/ssl/ca.pem",
+	}}
+}
+
 type CheckMember struct {
 	common.KubeAction
 }
@@ -378,12 +378,8 @@ func (c *CheckMember) Execute(runtime connector.Runtime) error {
 	host := runtime.RemoteHost()
 	if v, ok := c.PipelineCache.Get(common.ETCDCluster); ok {
 		cluster := v.(*EtcdCluster)
-		checkMemberCmd := fmt.Sprintf("export ETCDCTL_API=2;"+
-			"export ETCDCTL_CERT_FILE='/etc/ssl/etcd/ssl/admin-%s.pem';"+
-			"export ETCDCTL_KEY_FILE='/etc/ssl/etcd/ssl/admin-%s-key.pem';"+
-			"export ETCDCTL_CA_FILE='/etc/ssl/etcd/ssl/ca.pem';"+
-			"%s/etcdctl --no-sync --endpoints=%s member list", host.GetName(), host.GetName(), common.BinDir, cluster.accessAddresses)
-		memberList, err := runtime.GetRunner().SudoCmd(checkMemberCmd, true)
+		checkMemberCmd := fmt.Sprintf("%s/etcdctl --no-sync --endpoints=%s member list", common.BinDir, cluster.accessAddresses)
+		memberList, err := runtime.GetRunner().SudoCmdWith(checkMemberCmd, etcdctlV2(host), true)
 		if err != nil {
 			return errors.Wrap(errors.WithStack(err), "list etcd member failed")
 		}
@@ -396,12 +392,15 @@ func (c *CheckMember) Execute(runtime connector.Runtime) error {
 	return nil
 }
 
+// etcdService restarts etcd with the unit and the env file installed by the ETCDConfigureModule.
+var etcdService = action.ServiceOptions{DaemonReload: true, Enabled: true, State: action.ServiceRestarted}
+
 type RestartETCD struct {
 	common.KubeAction
 }
 
 func (r *RestartETCD) Execute(runtime connector.Runtime) error {
-	if _, err := runtime.GetRunner().SudoCmd("systemctl daemon-reload && systemctl restart etcd && systemctl enable etcd", true); err != nil {
+	if err := action.ManageService(runtime, "etcd", etcdService); err != nil {
 		return errors.Wrap(errors.WithStack(err), "start etcd failed")
 	}
 	return nil
@@ -447,3 +446,15 @@ func (e *EnableBackupETCDService) Execute(runtime connector.Runtime) error {
 	}
 	return nil
 }
+
+// etcdCipherSuites returns the TLS cipher suites of etcd, which are set by the TLS policy of the cluster spec, or
+// restricted to the approved ones in FIPS mode. They can't be set if only TLS 1.3 is enabled.
+func etcdCipherSuites(policy kubekeyv1alpha2.TLSPolicy) string {
+	switch {
+	case len(policy.CipherSuites) > 0:
+		return policy.CipherSuitesArg()
+	case !fips.Enabled() || policy.MinVersion == kubekeyv1alpha2.TLSVersion13:
+		return ""
+	}
+	return strings.Join(fips.TLSCipherSuites, ",")
+}
@@ -93,6 +93,12 @@ ETCD_PEER_TRUSTED_CA_FILE=/etc/ssl/etcd/ssl/ca.pem
 ETCD_PEER_CERT_FILE=/etc/ssl/etcd/ssl/member-{{ .Hostname }}.pem
 ETCD_PEER_KEY_FILE=/etc/ssl/etcd/ssl/member-{{ .Hostname }}-key.pem
 ETCD_PEER_CLIENT_CERT_AUTH=true
+{{- if .CipherSuites }}
+ETCD_CIPHER_SUITES={{ .CipherSuites }}
+{{- end }}
+{{- if .TLSMinVersion }}
+ETCD_TLS_MIN_VERSION={{ .TLSMinVersion }}
+{{- end }}
 
 # CLI settings
 ETCDCTL_ENDPOINTS=https://127.0.0.1:2379
@@ -0,0 +1,224 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package facts
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/utils"
+	"github.com/kubesphere/kubekey/v3/util/osrelease"
+)
+
+// File is the facts of the hosts in the work dir of the cluster, which is updated after the pipelines.
+const File = "facts.json"
+
+// OS is the os release of a host.
+type OS struct {
+	ID         string `json:"id,omitempty"`
+	VersionID  string `json:"versionID,omitempty"`
+	PrettyName string `json:"prettyName,omitempty"`
+}
+
+// Host is the facts of a host of a cluster managed by KubeKey. The facts which aren't gathered by the last pipeline
+// are kept from the previous ones.
+type Host struct {
+	Cluster         string            `json:"cluster"`
+	Name            string            `json:"name"`
+	Address         string            `json:"address"`
+	InternalAddress string            `json:"internalAddress"`
+	Aliases         []string          `json:"aliases,omitempty"`
+	Arch            string            `json:"arch,omitempty"`
+	Roles           []string          `json:"roles,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	Region          string            `json:"region,omitempty"`
+	Zone            string            `json:"zone,omitempty"`
+	Rack            string            `json:"rack,omitempty"`
+	OS              *OS               `json:"os,omitempty"`
+	SudoNoPasswd    *bool             `json:"sudoNoPasswd,omitempty"`
+	ImmutableOS     string            `json:"immutableOS,omitempty"`
+	NvidiaGPU       *bool             `json:"nvidiaGPU,omitempty"`
+	CPUs            int               `json:"cpus,omitempty"`
+	Memory          int64             `json:"memory,omitempty"`
+	CgroupVersion   int               `json:"cgroupVersion,omitempty"`
+	Systemd         *bool             `json:"systemd,omitempty"`
+	Image           *Image            `json:"image,omitempty"`
+	UpdatedAt       time.Time         `json:"updatedAt"`
+}
+
+// Collect returns the facts of the hosts of the runtime.
+func Collect(runtime connector.Runtime, now time.Time) []Host {
+	hosts := make([]Host, 0, len(runtime.GetAllHosts()))
+	for _, h := range runtime.GetAllHosts() {
+		host := Host{
+			Cluster:         runtime.GetObjName(),
+			Name:            h.GetName(),
+			Address:         h.GetAddress(),
+			InternalAddress: h.GetInternalAddress(),
+			Arch:            h.GetArch(),
+			Roles:           h.GetRoles(),
+			UpdatedAt:       now,
+		}
+		if kubeHost, ok := h.(*kubekeyapiv1alpha2.KubeHost); ok {
+			host.Aliases = kubeHost.Aliases
+			host.Labels = kubeHost.Labels
+			host.Region = kubeHost.Region
+			host.Zone = kubeHost.Zone
+			host.Rack = kubeHost.Rack
+		}
+		facts := utils.HostFacts(h)
+		if release, ok := facts["OS"].(*osrelease.Data); ok && release != nil {
+			host.OS = &OS{ID: release.ID, VersionID: release.VersionID, PrettyName: release.PrettyName}
+		}
+		if noPasswd, ok := facts["SudoNoPasswd"].(bool); ok {
+			host.SudoNoPasswd = &noPasswd
+		}
+		if immutableOS, ok := facts["ImmutableOS"].(string); ok {
+			host.ImmutableOS = immutableOS
+		}
+		if gpu, ok := facts["NvidiaGPU"].(bool); ok {
+			host.NvidiaGPU = &gpu
+		}
+		if cpus, ok := facts["CPUs"].(int); ok {
+			host.CPUs = cpus
+		}
+		if memory, ok := facts["Memory"].(int64); ok {
+			host.Memory = memory
+		}
+		if version, ok := facts["CgroupVersion"].(int); ok {
+			host.CgroupVersion = version
+		}
+		if systemd, ok := facts["Systemd"].(bool); ok {
+			host.Systemd = &systemd
+		}
+		if image, ok := facts["Image"].(*Image); ok {
+			host.Image = image
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// Current returns the facts of the hosts of the runtime, the facts which aren't gathered by the pipeline are kept
+// from the facts file of the cluster.
+func Current(runtime connector.Runtime, now time.Time) []Host {
+	hosts := Collect(runtime, now)
+	keep(filepath.Join(runtime.GetClusterWorkDir(), File), hosts)
+	return hosts
+}
+
+// Save writes the facts of the hosts to the file. The hosts removed from the cluster are dropped, and the facts of
+// the hosts which aren't gathered are kept from the file.
+func Save(file string, hosts []Host) error {
+	keep(file, hosts)
+
+	content, err := json.MarshalIndent(hosts, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encode the facts failed")
+	}
+	if err := util.WriteFile(file, content); err != nil {
+		return errors.Wrapf(err, "write the facts %s failed", file)
+	}
+	return nil
+}
+
+// Load returns the facts of the hosts of all the clusters in the work dir, sorted by the cluster and the host.
+func Load(workDir string) ([]Host, error) {
+	files, err := filepath.Glob(filepath.Join(workDir, common.ClustersDir, "*", File))
+	if err != nil {
+		return nil, errors.Wrap(err, "list the facts failed")
+	}
+	var hosts []Host
+	for _, file := range files {
+		clusterHosts, err := read(file)
+		if err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, clusterHosts...)
+	}
+	sort.SliceStable(hosts, func(i, j int) bool {
+		if hosts[i].Cluster != hosts[j].Cluster {
+			return hosts[i].Cluster < hosts[j].Cluster
+		}
+		return hosts[i].Name < hosts[j].Name
+	})
+	return hosts, nil
+}
+
+// keep fills the facts of the hosts which aren't gathered with the ones in the file.
+func keep(file string, hosts []Host) {
+	previous := make(map[string]Host)
+	if old, err := read(file); err == nil {
+		for _, host := range old {
+			previous[host.Name] = host
+		}
+	}
+	for i := range hosts {
+		old, ok := previous[hosts[i].Name]
+		if !ok {
+			continue
+		}
+		if hosts[i].OS == nil {
+			hosts[i].OS = old.OS
+		}
+		if hosts[i].SudoNoPasswd == nil {
+			hosts[i].SudoNoPasswd = old.SudoNoPasswd
+		}
+		if hosts[i].ImmutableOS == "" {
+			hosts[i].ImmutableOS = old.ImmutableOS
+		}
+		if hosts[i].NvidiaGPU == nil {
+			hosts[i].NvidiaGPU = old.NvidiaGPU
+		}
+		if hosts[i].CPUs == 0 {
+			hosts[i].CPUs = old.CPUs
+		}
+		if hosts[i].Memory == 0 {
+			hosts[i].Memory = old.Memory
+		}
+		if hosts[i].CgroupVersion == 0 {
+			hosts[i].CgroupVersion = old.CgroupVersion
+		}
+		if hosts[i].Systemd == nil {
+			hosts[i].Systemd = old.Systemd
+		}
+		if hosts[i].Image == nil {
+			hosts[i].Image = old.Image
+		}
+	}
+}
+
+func read(file string) ([]Host, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read the facts %s failed", file)
+	}
+	var hosts []Host
+	if err := json.Unmarshal(content, &hosts); err != nil {
+		return nil, errors.Wrapf(err, "parse the facts %s failed", file)
+	}
+	return hosts, nil
+}
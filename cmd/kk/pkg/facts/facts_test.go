@@ -0,0 +1,113 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package facts
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSave(t *testing.T) {
+	file := filepath.Join(t.TempDir(), File)
+	noPasswd := true
+	if err := Save(file, []Host{
+		{Cluster: "c1", Name: "node1", OS: &OS{ID: "ubuntu", VersionID: "22.04"}, SudoNoPasswd: &noPasswd, CPUs: 4, Memory: 8 << 30,
+			CgroupVersion: 2, Systemd: &noPasswd},
+		{Cluster: "c1", Name: "node2"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// node1 isn't gathered and node2 is removed
+	if err := Save(file, []Host{{Cluster: "c1", Name: "node1", Arch: "arm64"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := read(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Host{{Cluster: "c1", Name: "node1", Arch: "arm64", OS: &OS{ID: "ubuntu", VersionID: "22.04"}, SudoNoPasswd: &noPasswd,
+		CPUs: 4, Memory: 8 << 30, CgroupVersion: 2, Systemd: &noPasswd}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Save() = %+v, want %+v", got, want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	workDir := t.TempDir()
+	for cluster, hosts := range map[string][]Host{
+		"c1": {
+			{Cluster: "c1", Name: "node1", Address: "172.16.0.2", Roles: []string{"master", "etcd"}, Labels: map[string]string{"rack": "a"}},
+			{Cluster: "c1", Name: "node2", Address: "172.16.0.3", Aliases: []string{"node2-mgmt"}, Roles: []string{"worker"}, Arch: "arm64"},
+		},
+		"c2": {
+			{Cluster: "c2", Name: "node1", Address: "172.17.0.2", Roles: []string{"worker"}, Labels: map[string]string{"rack": "b"}},
+		},
+	} {
+		if err := Save(filepath.Join(workDir, "clusters", cluster, File), hosts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		query  string
+		code   int
+		want   []map[string]interface{}
+	}{
+		{name: "role and fields", query: "role=worker&fields=cluster,name", code: http.StatusOK,
+			want: []map[string]interface{}{{"cluster": "c1", "name": "node2"}, {"cluster": "c2", "name": "node1"}}},
+		{name: "cluster and label selector", query: "cluster=c1&labelSelector=rack%3Da&fields=name", code: http.StatusOK,
+			want: []map[string]interface{}{{"name": "node1"}}},
+		{name: "host by alias", query: "host=node2-mgmt&fields=address,arch", code: http.StatusOK,
+			want: []map[string]interface{}{{"address": "172.16.0.3", "arch": "arm64"}}},
+		{name: "no match", query: "arch=s390x", code: http.StatusOK, want: []map[string]interface{}{}},
+		{name: "unknown field", query: "fields=password", code: http.StatusBadRequest},
+		{name: "invalid label selector", query: "labelSelector=rack%3D%3D%3D", code: http.StatusBadRequest},
+		{name: "read-only", method: http.MethodPost, code: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			rec := httptest.NewRecorder()
+			Handler(workDir).ServeHTTP(rec, httptest.NewRequest(method, "/facts?"+tt.query, nil))
+			if rec.Code != tt.code {
+				t.Fatalf("code = %d, want %d: %s", rec.Code, tt.code, rec.Body.String())
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			var got struct {
+				Items []map[string]interface{} `json:"items"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Items, tt.want) {
+				t.Errorf("items = %v, want %v", got.Items, tt.want)
+			}
+		})
+	}
+}
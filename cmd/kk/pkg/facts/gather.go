@@ -0,0 +1,116 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package facts
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/util/osrelease"
+)
+
+// Gathered are the facts gathered on a host by Gather. The optional facts are zero if the host lacks the commands
+// gathering them.
+type Gathered struct {
+	OS *osrelease.Data
+	// SudoNoPasswd is whether the login user can run sudo without a password.
+	SudoNoPasswd bool
+	// CgroupVersion is the version of the cgroup hierarchy, 1 or 2, and Systemd is whether systemd is the init.
+	CgroupVersion int
+	Systemd       bool
+	CPUs          int
+	// Memory is the total memory in bytes.
+	Memory int64
+	// Image is the node image the host is booted from, which is nil unless it is baked by kk prepare-image.
+	Image *Image
+}
+
+// Gather gathers the facts of the host of the runner, they are gathered by the pipelines of kk too. The os release
+// and sudo are required, the other facts are optional.
+func Gather(runner *connector.Runner) (*Gathered, error) {
+	out, err := runner.SudoCmd("cat /etc/os-release", false)
+	if err != nil {
+		return nil, errors.Wrap(err, "get the os release failed")
+	}
+	g := &Gathered{OS: osrelease.Parse(strings.Replace(out, "\r\n", "\n", -1))}
+
+	if g.SudoNoPasswd, err = runner.SudoNoPasswd(); err != nil {
+		return nil, errors.Wrap(err, "check the sudo of the user failed")
+	}
+
+	name := runner.Host.GetName()
+	if out, err := runner.Cmd("stat -fc %T /sys/fs/cgroup; if [ -d /run/systemd/system ]; then echo systemd; fi", false); err != nil {
+		logger.Log.Debugf("get the cgroup version of %s failed: %v", name, err)
+	} else if version, systemd, ok := parseCgroups(out); ok {
+		g.CgroupVersion, g.Systemd = version, systemd
+	}
+
+	if out, err := runner.Cmd("nproc && awk '/^MemTotal:/ {print $2}' /proc/meminfo", false); err != nil {
+		logger.Log.Debugf("get the resources of %s failed: %v", name, err)
+	} else if cpus, memory, ok := parseResources(out); ok {
+		g.CPUs, g.Memory = cpus, memory
+	}
+
+	if out, err := runner.SudoCmd(fmt.Sprintf("cat %s 2>/dev/null || true", ImageFile), false); err != nil {
+		logger.Log.Debugf("get the node image of %s failed: %v", name, err)
+	} else {
+		g.Image = ParseImage(out)
+	}
+	return g, nil
+}
+
+// parseResources parses the number of the processors and the total memory in kB printed by nproc and
+// /proc/meminfo, the memory is returned in bytes.
+func parseResources(out string) (int, int64, bool) {
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return 0, 0, false
+	}
+	cpus, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	memory, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return cpus, memory * 1024, true
+}
+
+// parseCgroups parses the file system type of /sys/fs/cgroup, cgroup2fs on the unified hierarchy of cgroup v2 and tmpfs
+// on cgroup v1, followed by systemd if systemd is the init.
+func parseCgroups(out string) (int, bool, bool) {
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
+		return 0, false, false
+	}
+	version := 0
+	switch fields[0] {
+	case "cgroup2fs":
+		version = 2
+	case "tmpfs":
+		version = 1
+	default:
+		return 0, false, false
+	}
+	return version, len(fields) > 1 && fields[1] == "systemd", true
+}
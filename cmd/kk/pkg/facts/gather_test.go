@@ -0,0 +1,38 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package facts
+
+import "testing"
+
+func TestParseCgroups(t *testing.T) {
+	tests := []struct {
+		out     string
+		version int
+		systemd bool
+		ok      bool
+	}{
+		{out: "cgroup2fs\nsystemd\n", version: 2, systemd: true, ok: true},
+		{out: "tmpfs\n", version: 1, ok: true},
+		{out: "", ok: false},
+	}
+	for _, tt := range tests {
+		version, systemd, ok := parseCgroups(tt.out)
+		if version != tt.version || systemd != tt.systemd || ok != tt.ok {
+			t.Errorf("parseCgroups(%q) = %d, %v, %v, want %d, %v, %v", tt.out, version, systemd, ok, tt.version, tt.systemd, tt.ok)
+		}
+	}
+}
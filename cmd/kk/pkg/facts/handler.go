@@ -0,0 +1,163 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package facts
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// fieldNames are the JSON names of the fields of Host, which can be selected by the fields of the query.
+var fieldNames = func() map[string]struct{} {
+	names := make(map[string]struct{})
+	t := reflect.TypeOf(Host{})
+	for i := 0; i < t.NumField(); i++ {
+		names[strings.Split(t.Field(i).Tag.Get("json"), ",")[0]] = struct{}{}
+	}
+	return names
+}()
+
+// Query selects the hosts and their fields from the facts. The empty conditions match all the hosts, and all the
+// fields are returned if the fields are empty.
+type Query struct {
+	Cluster string
+	// Host matches the name, an alias or the address of the host.
+	Host     string
+	Role     string
+	Arch     string
+	Selector labels.Selector
+	Fields   []string
+}
+
+// ParseQuery parses the query of the request: cluster, host, role, arch, labelSelector and fields, which is a comma
+// separated list of the fields, e.g. fields=name,address,os.
+func ParseQuery(values url.Values) (*Query, error) {
+	q := &Query{
+		Cluster:  values.Get("cluster"),
+		Host:     values.Get("host"),
+		Role:     values.Get("role"),
+		Arch:     values.Get("arch"),
+		Selector: labels.Everything(),
+	}
+	if s := values.Get("labelSelector"); s != "" {
+		selector, err := labels.Parse(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid labelSelector %q", s)
+		}
+		q.Selector = selector
+	}
+	if fields := values.Get("fields"); fields != "" {
+		for _, field := range strings.Split(fields, ",") {
+			field = strings.TrimSpace(field)
+			if _, ok := fieldNames[field]; !ok {
+				return nil, errors.Errorf("unknown field %q", field)
+			}
+			q.Fields = append(q.Fields, field)
+		}
+	}
+	return q, nil
+}
+
+// Match returns whether the host matches the conditions of the query.
+func (q *Query) Match(host Host) bool {
+	if q.Cluster != "" && host.Cluster != q.Cluster {
+		return false
+	}
+	if q.Host != "" && host.Name != q.Host && host.Address != q.Host && !contains(host.Aliases, q.Host) {
+		return false
+	}
+	if q.Role != "" && !contains(host.Roles, q.Role) {
+		return false
+	}
+	if q.Arch != "" && host.Arch != q.Arch {
+		return false
+	}
+	return q.Selector.Matches(labels.Set(host.Labels))
+}
+
+// Select returns the fields of the hosts matching the query.
+func (q *Query) Select(hosts []Host) ([]map[string]interface{}, error) {
+	items := make([]map[string]interface{}, 0, len(hosts))
+	for _, host := range hosts {
+		if !q.Match(host) {
+			continue
+		}
+		content, err := json.Marshal(host)
+		if err != nil {
+			return nil, errors.Wrapf(err, "encode the facts of %s failed", host.Name)
+		}
+		item := make(map[string]interface{})
+		if err := json.Unmarshal(content, &item); err != nil {
+			return nil, errors.Wrapf(err, "decode the facts of %s failed", host.Name)
+		}
+		if len(q.Fields) != 0 {
+			selected := make(map[string]interface{}, len(q.Fields))
+			for _, field := range q.Fields {
+				if v, ok := item[field]; ok {
+					selected[field] = v
+				}
+			}
+			item = selected
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Handler serves the facts of the hosts of the clusters in the work dir read-only, e.g. GET /facts?role=worker.
+// The response is {"items": [...]}, the invalid queries are rejected with 400.
+func Handler(workDir string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "the facts are read-only", http.StatusMethodNotAllowed)
+			return
+		}
+		q, err := ParseQuery(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		hosts, err := Load(workDir)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		items, err := q.Select(hosts)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+	})
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package facts
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+const (
+	// ImageFile records the steps baked into the node image by kk prepare-image on the host.
+	ImageFile = "/etc/kubekey/image.json"
+	// ImageCacheKey is the key of the Image baked into the host in the host cache, which is set by the GetOSData task.
+	ImageCacheKey = "image"
+
+	// BakedDependencies is the step of the os dependencies.
+	BakedDependencies = "dependencies"
+	// BakedImages is the step of the images pulled for the KubeVersion and the ContainerManager.
+	BakedImages = "images"
+	// BakedKubernetes is the step of the kubelet, kubeadm, kubectl, helm and CNI binaries of the KubeVersion and the
+	// kubelet service.
+	BakedKubernetes = "kubernetes"
+)
+
+// Image is the node image baked by kk prepare-image, the joins of the hosts booted from it skip the steps baked for
+// the same kubernetes version and container manager as the cluster's.
+type Image struct {
+	KubeKeyVersion   string    `json:"kubekeyVersion,omitempty"`
+	KubeVersion      string    `json:"kubeVersion"`
+	ContainerManager string    `json:"containerManager"`
+	Steps            []string  `json:"steps"`
+	BakedAt          time.Time `json:"bakedAt"`
+}
+
+// ParseImage parses the ImageFile, the host isn't booted from a node image if it is empty or invalid.
+func ParseImage(content string) *Image {
+	if strings.TrimSpace(content) == "" {
+		return nil
+	}
+	image := &Image{}
+	if err := json.Unmarshal([]byte(content), image); err != nil {
+		return nil
+	}
+	return image
+}
+
+// Baked returns whether the step is baked into the image for the kubernetes version and the container manager.
+func (i *Image) Baked(step, kubeVersion, containerManager string) bool {
+	if i == nil {
+		return false
+	}
+	baked := false
+	for _, s := range i.Steps {
+		baked = baked || s == step
+	}
+	switch step {
+	case BakedImages:
+		return baked && i.KubeVersion == kubeVersion && i.ContainerManager == containerManager
+	case BakedKubernetes:
+		return baked && i.KubeVersion == kubeVersion
+	}
+	return baked
+}
+
+// NotBaked is true on the hosts whose node image hasn't baked the Step for the cluster, so the steps baked by kk
+// prepare-image are skipped by the joins of the hosts booted from it.
+type NotBaked struct {
+	common.KubePrepare
+	Step string
+}
+
+func (n *NotBaked) PreCheck(runtime connector.Runtime) (bool, error) {
+	v, ok := runtime.RemoteHost().GetCache().Get(ImageCacheKey)
+	if !ok {
+		return true, nil
+	}
+	image, _ := v.(*Image)
+	kubernetes := n.KubeConf.Cluster.Kubernetes
+	return !image.Baked(n.Step, kubernetes.Version, kubernetes.ContainerManager), nil
+}
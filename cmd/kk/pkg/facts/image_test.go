@@ -0,0 +1,56 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package facts
+
+import (
+	"testing"
+)
+
+func TestParseImage(t *testing.T) {
+	for _, content := range []string{"", "\n", "not json"} {
+		if image := ParseImage(content); image != nil {
+			t.Errorf("ParseImage(%q) = %+v, want nil", content, image)
+		}
+	}
+	image := ParseImage(`{"kubeVersion": "v1.26.5", "containerManager": "containerd", "steps": ["dependencies", "images"]}`)
+	if image == nil || image.KubeVersion != "v1.26.5" || len(image.Steps) != 2 {
+		t.Errorf("ParseImage() = %+v", image)
+	}
+}
+
+func TestBaked(t *testing.T) {
+	image := &Image{KubeVersion: "v1.26.5", ContainerManager: "containerd", Steps: []string{BakedDependencies, BakedImages, BakedKubernetes}}
+	for _, tt := range []struct {
+		image            *Image
+		step             string
+		kubeVersion      string
+		containerManager string
+		want             bool
+	}{
+		{image: nil, step: BakedDependencies, want: false},
+		{image: image, step: BakedDependencies, kubeVersion: "v1.27.2", containerManager: "docker", want: true},
+		{image: image, step: BakedImages, kubeVersion: "v1.26.5", containerManager: "containerd", want: true},
+		{image: image, step: BakedImages, kubeVersion: "v1.26.5", containerManager: "docker", want: false},
+		{image: image, step: BakedKubernetes, kubeVersion: "v1.26.5", containerManager: "docker", want: true},
+		{image: image, step: BakedKubernetes, kubeVersion: "v1.27.2", containerManager: "containerd", want: false},
+		{image: &Image{KubeVersion: "v1.26.5", Steps: []string{BakedDependencies}}, step: BakedKubernetes, kubeVersion: "v1.26.5", want: false},
+	} {
+		if got := tt.image.Baked(tt.step, tt.kubeVersion, tt.containerManager); got != tt.want {
+			t.Errorf("%+v.Baked(%s, %s, %s) = %v, want %v", tt.image, tt.step, tt.kubeVersion, tt.containerManager, got, tt.want)
+		}
+	}
+}
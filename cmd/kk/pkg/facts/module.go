@@ -0,0 +1,56 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package facts
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+)
+
+// SaveFactsModule saves the facts of the hosts gathered by the pipeline to the work dir of the cluster, which are
+// served by the operator for the CMDB and the asset systems.
+type SaveFactsModule struct {
+	common.KubeModule
+}
+
+func (s *SaveFactsModule) Init() {
+	s.Name = "SaveFactsModule"
+	s.Desc = "Save the facts of the hosts"
+
+	save := &task.LocalTask{
+		Name:   "SaveFacts",
+		Desc:   "Save the facts of the hosts",
+		Action: new(SaveFacts),
+	}
+
+	s.Tasks = []task.Interface{
+		save,
+	}
+}
+
+// SaveFacts writes the facts of the hosts to the facts file, the dry-run doesn't gather any fact.
+type SaveFacts struct {
+	common.KubeAction
+}
+
+func (s *SaveFacts) Execute(runtime connector.Runtime) error {
+	return Save(filepath.Join(runtime.GetClusterWorkDir(), File), Collect(runtime, time.Now()))
+}
@@ -0,0 +1,297 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package files
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+const (
+	defaultDownloadConcurrency = 4
+	defaultDownloadAttempts    = 3
+
+	// partialSuffix is the suffix of the file a download is written to until it completes. A download resumes
+	// from the partial file left by an interrupted one.
+	partialSuffix = ".part"
+)
+
+// retryInterval is the wait before retrying a URL, multiplied by the attempt.
+var retryInterval = 2 * time.Second
+
+// Downloader downloads files over HTTP(S) with the mirrors, proxy and CA bundle of a download policy. Interrupted
+// downloads are resumed by range requests, and failed ones fail over to the next mirror.
+type Downloader struct {
+	client      *http.Client
+	mirrors     map[string][]string
+	attempts    int
+	concurrency int
+}
+
+// statusError is an unexpected HTTP status of a download.
+type statusError struct {
+	url    string
+	status string
+	code   int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("get %s failed: %s", e.url, e.status)
+}
+
+// permanentError is an error which trying the URL again won't fix.
+type permanentError struct {
+	error
+}
+
+// retryable reports whether the URL may succeed when tried again. Client errors, but timeouts and throttling,
+// won't, so the next mirror is tried right away.
+func retryable(err error) bool {
+	var pe permanentError
+	if errors.As(err, &pe) {
+		return false
+	}
+	var se *statusError
+	if !errors.As(err, &se) {
+		return true
+	}
+	return se.code >= 500 || se.code == http.StatusRequestTimeout || se.code == http.StatusTooManyRequests
+}
+
+func newDownloader(p *DownloadPolicy) (*Downloader, error) {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: time.Minute,
+		ExpectContinueTimeout: time.Second,
+	}
+	if p.Proxy != "" {
+		proxy, err := url.Parse(p.Proxy)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid proxy %s", p.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if p.CABundle != "" {
+		pem, err := os.ReadFile(p.CABundle)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read CA bundle %s failed", p.CABundle)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificate found in the CA bundle %s", p.CABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	d := &Downloader{
+		client:      &http.Client{Transport: transport},
+		mirrors:     p.Mirrors,
+		attempts:    p.Attempts,
+		concurrency: p.Concurrency,
+	}
+	if d.attempts <= 0 {
+		d.attempts = defaultDownloadAttempts
+	}
+	if d.concurrency <= 0 {
+		d.concurrency = defaultDownloadConcurrency
+	}
+	return d, nil
+}
+
+// URLs returns the mirrors of the URL in order, followed by the URL itself.
+func (d *Downloader) URLs(u string) []string {
+	var prefix string
+	for p := range d.mirrors {
+		if strings.HasPrefix(u, p) && len(p) > len(prefix) {
+			prefix = p
+		}
+	}
+	urls := make([]string, 0, len(d.mirrors[prefix])+1)
+	if prefix != "" {
+		for _, mirror := range d.mirrors[prefix] {
+			urls = append(urls, mirror+strings.TrimPrefix(u, prefix))
+		}
+	}
+	return append(urls, u)
+}
+
+// Download downloads the URL, or one of its mirrors, to dst.
+func (d *Downloader) Download(dst, u string) error {
+	var errs []string
+	for _, candidate := range d.URLs(u) {
+		for attempt := 1; attempt <= d.attempts; attempt++ {
+			err := d.fetch(dst, candidate)
+			if err == nil {
+				return nil
+			}
+			logger.Log.Warningf("download %s failed (attempt %d/%d): %v", candidate, attempt, d.attempts, err)
+			if !retryable(err) {
+				errs = append(errs, err.Error())
+				break
+			}
+			if attempt == d.attempts {
+				errs = append(errs, err.Error())
+				break
+			}
+			time.Sleep(time.Duration(attempt) * retryInterval)
+		}
+	}
+	return errors.Errorf("download %s failed: %s", u, strings.Join(errs, "; "))
+}
+
+// fetch downloads the URL to dst through the partial file, resuming from the partial file if it exists.
+func (d *Downloader) fetch(dst, u string) error {
+	part := dst + partialSuffix
+	var offset int64
+	if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return permanentError{err}
+	}
+	req.Header.Set("User-Agent", "kubekey")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusOK:
+		flags |= os.O_TRUNC
+		offset = 0
+	case http.StatusPartialContent:
+		if start, ok := rangeStart(resp.Header.Get("Content-Range")); !ok || start != offset {
+			_ = os.Remove(part)
+			return errors.Errorf("get %s failed: unexpected content range %q", u, resp.Header.Get("Content-Range"))
+		}
+		flags |= os.O_APPEND
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the partial file doesn't belong to the file served, start over
+		_ = os.Remove(part)
+		return errors.Errorf("get %s failed: %s", u, resp.Status)
+	default:
+		return &statusError{url: u, status: resp.Status, code: resp.StatusCode}
+	}
+
+	file, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return permanentError{err}
+	}
+	n, err := io.Copy(file, resp.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "get %s failed after %d bytes", u, offset+n)
+	}
+	if resp.ContentLength >= 0 && n != resp.ContentLength {
+		return errors.Errorf("get %s failed: got %d of %d bytes", u, n, resp.ContentLength)
+	}
+	if err := os.Rename(part, dst); err != nil {
+		return permanentError{err}
+	}
+	logger.Log.Debugf("downloaded %s to %s (%d bytes)", u, dst, offset+n)
+	return nil
+}
+
+// rangeStart returns the first byte of the Content-Range "bytes <start>-<end>/<size>".
+func rangeStart(contentRange string) (int64, bool) {
+	r := strings.TrimPrefix(contentRange, "bytes ")
+	i := strings.Index(r, "-")
+	if r == contentRange || i < 0 {
+		return 0, false
+	}
+	start, err := strconv.ParseInt(r[:i], 10, 64)
+	return start, err == nil
+}
+
+// DownloadConcurrency returns the number of binaries downloaded at a time by the download policy in use.
+func DownloadConcurrency() int {
+	return getDownloadPolicy().getDownloader().concurrency
+}
+
+// DownloadFile downloads the URL to dst with the user defined download command, or with the downloader of the
+// download policy in use if getCmd is nil.
+func DownloadFile(getCmd func(path, url string) string, dst, u string) error {
+	if getCmd == nil {
+		return getDownloadPolicy().getDownloader().Download(dst, u)
+	}
+	cmd := getCmd(dst, u)
+	if output, err := exec.Command("/bin/sh", "-c", cmd).CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "%s failed: %s", cmd, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// DownloadCommand returns the function building the user defined download command cmd, in which the first %s is
+// the path and the second one the URL. It returns nil if cmd is empty, so the built-in downloader is used.
+func DownloadCommand(cmd string) func(path, url string) string {
+	if cmd == "" {
+		return nil
+	}
+	return func(path, url string) string {
+		return fmt.Sprintf(cmd, path, url)
+	}
+}
+
+func (p *DownloadPolicy) getDownloader() *Downloader {
+	if p.downloader != nil {
+		return p.downloader
+	}
+	// the downloader of a policy without a proxy or CA bundle can't fail
+	d, _ := newDownloader(p)
+	return d
+}
+
+// defaultDownloadPolicy returns the policy in use without --download-policy.
+func defaultDownloadPolicy() *DownloadPolicy {
+	p := &DownloadPolicy{}
+	p.downloader = p.getDownloader()
+	return p
+}
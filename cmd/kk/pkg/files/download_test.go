@@ -0,0 +1,145 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package files
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+func TestDownloaderURLs(t *testing.T) {
+	d, err := newDownloader(&DownloadPolicy{Mirrors: map[string][]string{
+		"https://github.com/":                      {"https://m1.example.com/gh/", "https://m2.example.com/gh/"},
+		"https://github.com/containerd/":           {"https://m3.example.com/containerd/"},
+		"https://storage.googleapis.com/unrelated": {"https://m4.example.com/"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url  string
+		want []string
+	}{
+		{
+			url:  "https://github.com/etcd-io/etcd/releases/download/v3.5.13/etcd.tar.gz",
+			want: []string{"https://m1.example.com/gh/etcd-io/etcd/releases/download/v3.5.13/etcd.tar.gz", "https://m2.example.com/gh/etcd-io/etcd/releases/download/v3.5.13/etcd.tar.gz", "https://github.com/etcd-io/etcd/releases/download/v3.5.13/etcd.tar.gz"},
+		},
+		{
+			url:  "https://github.com/containerd/containerd/releases/download/v1.7.13/containerd.tar.gz",
+			want: []string{"https://m3.example.com/containerd/containerd/releases/download/v1.7.13/containerd.tar.gz", "https://github.com/containerd/containerd/releases/download/v1.7.13/containerd.tar.gz"},
+		},
+		{
+			url:  "https://dl.k8s.io/release/v1.29.3/bin/linux/amd64/kubeadm",
+			want: []string{"https://dl.k8s.io/release/v1.29.3/bin/linux/amd64/kubeadm"},
+		},
+	}
+	for _, tt := range tests {
+		if got := d.URLs(tt.url); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("URLs(%s) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestDownloaderDownload(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	retryInterval = time.Millisecond
+	defer func() { retryInterval = 2 * time.Second }()
+
+	content := bytes.Repeat([]byte("kubeadm"), 4096)
+	var flaky, ranged int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/missing/", http.NotFound)
+	mux.HandleFunc("/flaky/", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&flaky, 1) == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		http.ServeContent(w, r, "kubeadm", time.Time{}, bytes.NewReader(content))
+	})
+	mux.HandleFunc("/ok/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.Header.Get("Range"), "bytes=1000-") {
+			atomic.AddInt32(&ranged, 1)
+		}
+		http.ServeContent(w, r, "kubeadm", time.Time{}, bytes.NewReader(content))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		mirrors []string
+		url     string
+		partial []byte
+		wantErr bool
+	}{
+		{name: "upstream", url: server.URL + "/ok/kubeadm"},
+		{name: "fail over the missing mirror", mirrors: []string{server.URL + "/missing/"}, url: server.URL + "/ok/kubeadm"},
+		{name: "retry the unavailable mirror", mirrors: []string{server.URL + "/flaky/"}, url: server.URL + "/missing/kubeadm"},
+		{name: "resume the partial file", url: server.URL + "/ok/kubeadm", partial: content[:1000]},
+		{name: "missing everywhere", mirrors: []string{server.URL + "/missing/"}, url: server.URL + "/missing/kubeadm", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &DownloadPolicy{Attempts: 2}
+			if tt.mirrors != nil {
+				p.Mirrors = map[string][]string{server.URL + "/ok/": tt.mirrors, server.URL + "/missing/": tt.mirrors}
+			}
+			d, err := newDownloader(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dst := filepath.Join(t.TempDir(), "kubeadm")
+			if tt.partial != nil {
+				if err := os.WriteFile(dst+partialSuffix, tt.partial, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err = d.Download(dst, tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Download() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, err := os.ReadFile(dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, content) {
+				t.Errorf("Download() got %d bytes, want %d", len(got), len(content))
+			}
+			if _, err := os.Stat(dst + partialSuffix); !os.IsNotExist(err) {
+				t.Errorf("Download() left the partial file")
+			}
+			if tt.partial != nil && atomic.LoadInt32(&ranged) != 1 {
+				t.Errorf("Download() didn't resume the partial file")
+			}
+		})
+	}
+}
@@ -17,13 +17,14 @@
 package files
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"crypto/sha256"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
 
 	"github.com/pkg/errors"
 
@@ -253,49 +254,37 @@ func (b *KubeBinary) Path() string {
 	return filepath.Join(b.BaseDir, b.FileName)
 }
 
+// GetCmd returns the user defined command downloading the binary, or its URL if it's downloaded by the built-in
+// downloader.
 func (b *KubeBinary) GetCmd() string {
+	if b.getCmd == nil {
+		return b.Url
+	}
 	cmd := b.getCmd(b.Path(), b.Url)
 
 	if b.ID == helm && b.Zone != "cn" {
-		get := b.getCmd(filepath.Join(b.BaseDir, fmt.Sprintf("helm-%s-linux-%s.tar.gz", b.Version, b.Arch)), b.Url)
+		get := b.getCmd(filepath.Join(b.BaseDir, b.helmTarball()), b.Url)
 		cmd = fmt.Sprintf("%s && cd %s && tar -zxf helm-%s-linux-%s.tar.gz && mv linux-%s/helm . && rm -rf *linux-%s*",
 			get, b.BaseDir, b.Version, b.Arch, b.Arch, b.Arch)
 	}
 	return cmd
 }
 
+func (b *KubeBinary) helmTarball() string {
+	return fmt.Sprintf("helm-%s-linux-%s.tar.gz", b.Version, b.Arch)
+}
+
 func (b *KubeBinary) GetSha256() string {
 	s := FileSha256[b.ID][b.Arch][b.Version]
 	return s
 }
 
 func (b *KubeBinary) Download() error {
+	if getDownloadPolicy().skip {
+		return nil
+	}
 	for i := 5; i > 0; i-- {
-		cmd := exec.Command("/bin/sh", "-c", b.GetCmd())
-		stdout, err := cmd.StdoutPipe()
-		if err != nil {
-			return err
-		}
-		cmd.Stderr = cmd.Stdout
-
-		if err = cmd.Start(); err != nil {
-			return err
-		}
-		for {
-			tmp := make([]byte, 1024)
-			_, err := stdout.Read(tmp)
-			fmt.Print(string(tmp)) // Get the output from the pipeline in real time and print it to the terminal
-			if errors.Is(err, io.EOF) {
-				break
-			} else if err != nil {
-				logger.Log.Errorln(err)
-				break
-			}
-		}
-		if err = cmd.Wait(); err != nil {
-			if os.Getenv("KKZONE") != "cn" {
-				logger.Log.Warningln("Having a problem with accessing https://storage.googleapis.com? You can try again after setting environment 'export KKZONE=cn'")
-			}
+		if err := b.fetch(); err != nil {
 			return err
 		}
 
@@ -303,27 +292,130 @@ func (b *KubeBinary) Download() error {
 			if i == 1 {
 				return err
 			}
-			path := b.Path()
-			_ = exec.Command("/bin/sh", "-c", fmt.Sprintf("rm -f %s", path)).Run()
+			_ = os.Remove(b.Path())
 			continue
 		}
 		break
 	}
+	// the binary failing the verification is removed, so it isn't used by the next run
+	if err := b.VerifySignature(); err != nil {
+		_ = os.Remove(b.Path())
+		return err
+	}
 	return nil
 }
 
+// fetch downloads the binary with the built-in downloader, or the user defined command if there is one.
+func (b *KubeBinary) fetch() error {
+	if b.getCmd != nil {
+		return b.runCmd()
+	}
+
+	d := getDownloadPolicy().getDownloader()
+	if b.ID == helm && b.Zone != "cn" {
+		tarball := filepath.Join(b.BaseDir, b.helmTarball())
+		defer os.Remove(tarball)
+		if err := d.Download(tarball, b.Url); err != nil {
+			return err
+		}
+		return extractFile(tarball, fmt.Sprintf("linux-%s/helm", b.Arch), b.Path())
+	}
+	return d.Download(b.Path(), b.Url)
+}
+
+func (b *KubeBinary) runCmd() error {
+	cmd := exec.Command("/bin/sh", "-c", b.GetCmd())
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	cmd.Stderr = cmd.Stdout
+
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+	for {
+		tmp := make([]byte, 1024)
+		_, err := stdout.Read(tmp)
+		fmt.Print(string(tmp)) // Get the output from the pipeline in real time and print it to the terminal
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			logger.Log.Errorln(err)
+			break
+		}
+	}
+	if err = cmd.Wait(); err != nil {
+		if os.Getenv("KKZONE") != "cn" {
+			logger.Log.Warningln("Having a problem with accessing https://storage.googleapis.com? You can try again after setting environment 'export KKZONE=cn'")
+		}
+		return err
+	}
+	return nil
+}
+
+// extractFile extracts the file name of the gzip compressed tarball to dst.
+func extractFile(tarball, name, dst string) error {
+	f, err := os.Open(tarball)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return errors.Wrapf(err, "read %s failed", tarball)
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return errors.Errorf("%s not found in %s", name, tarball)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "read %s failed", tarball)
+		}
+		if hdr.Name != name {
+			continue
+		}
+		out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, tr); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	}
+}
+
 // SHA256Check is used to hash checks on downloaded binary. (sha256)
+// The SHA256 must match all the checksums known by the checksum files of the download policy and kk.
 func (b *KubeBinary) SHA256Check() error {
 	output, err := sha256sum(b.Path())
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("Failed to check SHA256 of %s", b.Path()))
 	}
 
-	if strings.TrimSpace(b.GetSha256()) == "" {
-		return errors.New(fmt.Sprintf("No SHA256 found for %s. %s is not supported.", b.ID, b.Version))
+	p := getDownloadPolicy()
+	sums, err := b.expectedSha256(p)
+	if err != nil {
+		return err
 	}
-	if output != b.GetSha256() {
-		return errors.New(fmt.Sprintf("SHA256 no match. %s not equal %s", b.GetSha256(), output))
+	if len(sums) == 0 {
+		if p.AllowUnverified {
+			logger.Log.Warningf("No SHA256 found for %s %s, it is used unverified.", b.ID, b.Version)
+			return nil
+		}
+		return errors.New(fmt.Sprintf("No SHA256 found for %s. %s is not supported, "+
+			"add its checksum to a checksum file of the download policy or allow unverified binaries with --allow-unverified.", b.ID, b.Version))
+	}
+	for source, sum := range sums {
+		if output != sum {
+			return errors.New(fmt.Sprintf("SHA256 no match. %s (%s) not equal %s", sum, source, output))
+		}
 	}
 	return nil
 }
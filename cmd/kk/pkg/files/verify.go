@@ -0,0 +1,370 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+const (
+	// DownloadPolicyEnv is the env of the download policy file, the same as the flag --download-policy.
+	DownloadPolicyEnv = "KUBEKEY_DOWNLOAD_POLICY"
+
+	// AnyZone is the key of the checksum file which applies to the mirrors of all the zones.
+	AnyZone = "*"
+
+	// SignatureCosign verifies the signature <url>.sig with cosign verify-blob, either by the public key or by the
+	// certificate <url>.cert of the keyless signing.
+	SignatureCosign = "cosign"
+	// SignatureGPG verifies the detached signature <url>.asc with gpg by the keyring.
+	SignatureGPG = "gpg"
+)
+
+// defaultSignedBinaries are the binaries whose signatures are verified if the binaries of the policy are empty.
+var defaultSignedBinaries = []string{kubeadm, kubelet, etcd, containerd, kubecni}
+
+// DownloadPolicy is how the binaries are downloaded and verified. The SHA256 of a binary is checked against the
+// checksum files of its mirror and the checksums embedded in kk, all of which must match. A binary without any known
+// checksum is refused unless AllowUnverified is set.
+type DownloadPolicy struct {
+	// Mirrors are the URL prefixes tried in order before the upstream one, keyed by the upstream prefix, e.g.
+	// "https://github.com/": ["https://mirror.example.com/github/"]. The longest matching key applies.
+	Mirrors map[string][]string `json:"mirrors,omitempty"`
+	// Proxy is the URL of the HTTP(S) proxy, the env HTTPS_PROXY, HTTP_PROXY and NO_PROXY are used if it is empty.
+	Proxy string `json:"proxy,omitempty"`
+	// CABundle is the path of the PEM certificates trusted in addition to the system ones, e.g. of a TLS
+	// intercepting proxy or an internal mirror.
+	CABundle string `json:"caBundle,omitempty"`
+	// Concurrency is the number of binaries downloaded at a time, 4 by default.
+	Concurrency int `json:"concurrency,omitempty"`
+	// Attempts is the number of times a URL is tried before failing over to the next one, 3 by default.
+	Attempts int `json:"attempts,omitempty"`
+
+	// ChecksumFiles are the paths or URLs of the checksum files in the sha256sum format, keyed by the zone of the
+	// mirror (the env KKZONE, "" for the upstream) or "*" for all the mirrors. An entry of the file applies to the
+	// binary whose download URL ends with its name, e.g. "v1.29.3/bin/linux/amd64/kubeadm".
+	ChecksumFiles map[string]string `json:"checksumFiles,omitempty"`
+	// Signature verifies the signatures of the binaries, no signature is verified if it is nil.
+	Signature *SignaturePolicy `json:"signature,omitempty"`
+	// AllowUnverified allows the binaries without any known checksum, which are refused by default.
+	AllowUnverified bool `json:"allowUnverified,omitempty"`
+
+	checksums  map[string][]checksumEntry
+	downloader *Downloader
+	// skip skips the downloads, the binaries aren't needed in the manifest-only mode.
+	skip bool
+}
+
+// SignaturePolicy is how the signatures of the binaries are verified.
+type SignaturePolicy struct {
+	// Type is cosign or gpg.
+	Type string `json:"type"`
+	// Binaries are the binaries whose signatures are verified, e.g. kubeadm, kubelet, etcd, containerd and kubecni,
+	// which are the default ones.
+	Binaries []string `json:"binaries,omitempty"`
+	// Key is the public key of cosign. The keyless signatures are verified by the certificates with
+	// CertificateIdentity and CertificateOIDCIssuer if it is empty.
+	Key                   string `json:"key,omitempty"`
+	CertificateIdentity   string `json:"certificateIdentity,omitempty"`
+	CertificateOIDCIssuer string `json:"certificateOIDCIssuer,omitempty"`
+	// Keyring is the keyring of gpg containing the public keys of the signers.
+	Keyring string `json:"keyring,omitempty"`
+}
+
+type checksumEntry struct {
+	name string
+	sum  string
+}
+
+var (
+	policyMu       sync.RWMutex
+	downloadPolicy = defaultDownloadPolicy()
+)
+
+// LoadDownloadPolicy loads the download policy in yaml from the file, and the checksum files referenced by it.
+func LoadDownloadPolicy(file string) (*DownloadPolicy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read download policy %s failed", file)
+	}
+	p := &DownloadPolicy{}
+	if err := yaml.UnmarshalStrict(data, p); err != nil {
+		return nil, errors.Wrapf(err, "parse download policy %s failed", file)
+	}
+	if err := p.init(); err != nil {
+		return nil, errors.Wrapf(err, "load download policy %s failed", file)
+	}
+	return p, nil
+}
+
+// init validates the policy, sets up its downloader and loads the checksum files.
+func (p *DownloadPolicy) init() error {
+	d, err := newDownloader(p)
+	if err != nil {
+		return err
+	}
+	p.downloader = d
+
+	if s := p.Signature; s != nil {
+		switch s.Type {
+		case SignatureCosign:
+			if s.Key == "" && (s.CertificateIdentity == "" || s.CertificateOIDCIssuer == "") {
+				return errors.New("the key, or the certificateIdentity and certificateOIDCIssuer of cosign are required")
+			}
+		case SignatureGPG:
+			if s.Keyring == "" {
+				return errors.New("the keyring of gpg is required")
+			}
+		default:
+			return errors.Errorf("unsupported signature type %q, it must be %s or %s", s.Type, SignatureCosign, SignatureGPG)
+		}
+		if len(s.Binaries) == 0 {
+			s.Binaries = defaultSignedBinaries
+		}
+	}
+
+	p.checksums = make(map[string][]checksumEntry, len(p.ChecksumFiles))
+	for zone, file := range p.ChecksumFiles {
+		data, err := readChecksumFile(d.client, file)
+		if err != nil {
+			return err
+		}
+		entries, err := parseChecksums(data)
+		if err != nil {
+			return errors.Wrapf(err, "parse checksum file %s failed", file)
+		}
+		p.checksums[zone] = entries
+	}
+	return nil
+}
+
+// SetDownloadPolicy sets the policy downloading and verifying the binaries.
+func SetDownloadPolicy(p *DownloadPolicy) {
+	policyMu.Lock()
+	defer policyMu.Unlock()
+	downloadPolicy = p
+}
+
+// AllowUnverified allows the binaries without any known checksum in the policy in use.
+func AllowUnverified() {
+	policyMu.Lock()
+	defer policyMu.Unlock()
+	p := *downloadPolicy
+	p.AllowUnverified = true
+	downloadPolicy = &p
+}
+
+// SkipDownload skips downloading the binaries, whose files are left missing.
+func SkipDownload() {
+	policyMu.Lock()
+	defer policyMu.Unlock()
+	p := *downloadPolicy
+	p.skip = true
+	downloadPolicy = &p
+}
+
+func getDownloadPolicy() *DownloadPolicy {
+	policyMu.RLock()
+	defer policyMu.RUnlock()
+	return downloadPolicy
+}
+
+func readChecksumFile(client *http.Client, file string) ([]byte, error) {
+	u, err := url.Parse(file)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		data, err := os.ReadFile(file)
+		return data, errors.Wrapf(err, "read checksum file %s failed", file)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, file, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get checksum file %s failed", file)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get checksum file %s failed", file)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("get checksum file %s failed: %s", file, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	return data, errors.Wrapf(err, "get checksum file %s failed", file)
+}
+
+// parseChecksums parses the checksums in the format of sha256sum, i.e. "<sha256>  <name>" or "<sha256> *<name>",
+// or the BSD format "SHA256 (<name>) = <sha256>".
+func parseChecksums(data []byte) ([]checksumEntry, error) {
+	var entries []checksumEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var name, sum string
+		if strings.HasPrefix(line, "SHA256 (") {
+			i := strings.LastIndex(line, ") = ")
+			if i < 0 {
+				return nil, errors.Errorf("invalid checksum at line %d", n)
+			}
+			name, sum = line[len("SHA256 ("):i], line[i+len(") = "):]
+		} else {
+			fields := strings.Fields(line)
+			if len(fields) != 2 {
+				return nil, errors.Errorf("invalid checksum at line %d", n)
+			}
+			sum, name = fields[0], strings.TrimPrefix(fields[1], "*")
+		}
+		if len(sum) != 64 {
+			return nil, errors.Errorf("invalid SHA256 %s at line %d", sum, n)
+		}
+		entries = append(entries, checksumEntry{name: strings.TrimPrefix(path.Clean("/"+name), "/"), sum: strings.ToLower(sum)})
+	}
+	return entries, scanner.Err()
+}
+
+// lookupChecksum returns the SHA256 of the entry whose name is the longest suffix of the url path.
+func lookupChecksum(entries []checksumEntry, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid url %s", rawURL)
+	}
+	var match checksumEntry
+	for _, e := range entries {
+		if u.Path != "/"+e.name && !strings.HasSuffix(u.Path, "/"+e.name) {
+			continue
+		}
+		switch {
+		case len(e.name) > len(match.name):
+			match = e
+		case len(e.name) == len(match.name) && e.sum != match.sum:
+			return "", errors.Errorf("ambiguous checksums of %s for %s", e.name, rawURL)
+		}
+	}
+	return match.sum, nil
+}
+
+// expectedSha256 returns the SHA256s of the binary known by the checksum files of its mirror and kk, keyed by
+// their sources.
+func (b *KubeBinary) expectedSha256(p *DownloadPolicy) (map[string]string, error) {
+	sums := make(map[string]string)
+	// helm is extracted from the downloaded tarball, whose checksum doesn't apply to it.
+	if !(b.ID == helm && b.Zone != "cn") {
+		for _, zone := range []string{b.Zone, AnyZone} {
+			entries, ok := p.checksums[zone]
+			if !ok {
+				continue
+			}
+			sum, err := lookupChecksum(entries, b.Url)
+			if err != nil {
+				return nil, err
+			}
+			if sum != "" {
+				sums[p.ChecksumFiles[zone]] = sum
+			}
+		}
+	}
+	if sum := strings.TrimSpace(b.GetSha256()); sum != "" {
+		sums["kk"] = sum
+	}
+	return sums, nil
+}
+
+// signed returns whether the signature of the binary is verified by the policy.
+func (s *SignaturePolicy) signed(id string) bool {
+	for _, b := range s.Binaries {
+		if b == id {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifySignature verifies the signature of the downloaded binary if it is required by the download policy. The
+// signature, and the certificate of the keyless signing of cosign, are downloaded next to the binary.
+func (b *KubeBinary) VerifySignature() error {
+	s := getDownloadPolicy().Signature
+	if s == nil || !s.signed(b.ID) {
+		return nil
+	}
+	if b.ID == helm && b.Zone != "cn" {
+		return errors.Errorf("the signature of %s can't be verified, since it is extracted from the downloaded tarball", b.ID)
+	}
+
+	var cmd *exec.Cmd
+	switch s.Type {
+	case SignatureCosign:
+		sig, err := b.downloadSibling(".sig")
+		if err != nil {
+			return err
+		}
+		args := []string{"verify-blob", "--signature", sig}
+		if s.Key != "" {
+			args = append(args, "--key", s.Key)
+		} else {
+			cert, err := b.downloadSibling(".cert")
+			if err != nil {
+				return err
+			}
+			args = append(args, "--certificate", cert, "--certificate-identity", s.CertificateIdentity,
+				"--certificate-oidc-issuer", s.CertificateOIDCIssuer)
+		}
+		cmd = exec.Command("cosign", append(args, b.Path())...)
+	case SignatureGPG:
+		sig, err := b.downloadSibling(".asc")
+		if err != nil {
+			return err
+		}
+		cmd = exec.Command("gpg", "--no-default-keyring", "--keyring", s.Keyring, "--verify", sig, b.Path())
+	default:
+		return errors.Errorf("unsupported signature type %q", s.Type)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "verify the %s signature of %s %s failed: %s", s.Type, b.ID, b.Version, strings.TrimSpace(string(output)))
+	}
+	logger.Log.Debugf("verified the %s signature of %s %s", s.Type, b.ID, b.Version)
+	return nil
+}
+
+// downloadSibling downloads the file next to the binary in the mirror, e.g. the signature <url>.sig.
+func (b *KubeBinary) downloadSibling(suffix string) (string, error) {
+	dst := filepath.Join(b.BaseDir, b.FileName+suffix)
+	if err := DownloadFile(b.getCmd, dst, b.Url+suffix); err != nil {
+		return "", errors.Wrapf(err, "download %s%s failed", b.Url, suffix)
+	}
+	return dst, nil
+}
@@ -0,0 +1,119 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package files
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+func TestLookupChecksum(t *testing.T) {
+	sum := func(s string) string { return fmt.Sprintf("%x", sha256.Sum256([]byte(s))) }
+	data := fmt.Sprintf("# kubeadm\n%s  v1.29.3/bin/linux/amd64/kubeadm\n%s *./v1.29.3/bin/linux/arm64/kubeadm\n"+
+		"SHA256 (etcd-v3.5.13-linux-amd64.tar.gz) = %s\n%s  kubelet\n%s  kubelet\n",
+		sum("amd64"), sum("arm64"), sum("etcd"), sum("kubelet1"), sum("kubelet2"))
+	entries, err := parseChecksums([]byte(data))
+	if err != nil {
+		t.Fatalf("parseChecksums() error = %v", err)
+	}
+
+	tests := []struct {
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{url: "https://dl.k8s.io/release/v1.29.3/bin/linux/amd64/kubeadm", want: sum("amd64")},
+		{url: "https://mirror.local/k8s/v1.29.3/bin/linux/arm64/kubeadm", want: sum("arm64")},
+		{url: "https://github.com/coreos/etcd/releases/download/v3.5.13/etcd-v3.5.13-linux-amd64.tar.gz", want: sum("etcd")},
+		{url: "https://dl.k8s.io/release/v1.28.8/bin/linux/amd64/kubeadm"},
+		{url: "https://dl.k8s.io/release/v1.29.3/bin/linux/amd64/kubelet", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			got, err := lookupChecksum(entries, tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("lookupChecksum() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("lookupChecksum() got = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSHA256CheckPolicy(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	t.Setenv("KKZONE", "")
+	defer SetDownloadPolicy(&DownloadPolicy{})
+
+	b := NewKubeBinary("kubeadm", "amd64", "v0.0.1", t.TempDir(), nil)
+	if err := b.CreateBaseDir(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b.Path(), []byte("kubeadm"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := fmt.Sprintf("%x", sha256.Sum256([]byte("kubeadm")))
+
+	tests := []struct {
+		name    string
+		policy  *DownloadPolicy
+		wantErr bool
+	}{
+		{
+			name:    "unverified",
+			policy:  &DownloadPolicy{},
+			wantErr: true,
+		},
+		{
+			name:   "allow unverified",
+			policy: &DownloadPolicy{AllowUnverified: true},
+		},
+		{
+			name: "checksum file",
+			policy: &DownloadPolicy{checksums: map[string][]checksumEntry{
+				AnyZone: {{name: "v0.0.1/bin/linux/amd64/kubeadm", sum: sum}},
+			}},
+		},
+		{
+			name: "checksum file of another zone",
+			policy: &DownloadPolicy{checksums: map[string][]checksumEntry{
+				"cn": {{name: "v0.0.1/bin/linux/amd64/kubeadm", sum: sum}},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "mismatch",
+			policy: &DownloadPolicy{AllowUnverified: true, checksums: map[string][]checksumEntry{
+				"": {{name: "kubeadm", sum: fmt.Sprintf("%x", sha256.Sum256([]byte("other")))}},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetDownloadPolicy(tt.policy)
+			if err := b.SHA256Check(); (err != nil) != tt.wantErr {
+				t.Errorf("SHA256Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
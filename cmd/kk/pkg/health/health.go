@@ -0,0 +1,219 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package health checks the health of the components of the cluster on the remote hosts. The checks are shared by
+// the install, upgrade and status tasks, which wait for the components with Wait instead of sleeping.
+package health
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+// DefaultTimeout is how long Wait waits for the components by default.
+const DefaultTimeout = 5 * time.Minute
+
+// pollInterval is the interval between the runs of the checks in Wait.
+var pollInterval = 5 * time.Second
+
+// Check is the health check of a component of the cluster. It's run on the remote host, since the components
+// listen on the localhost or on the internal network of the cluster.
+type Check struct {
+	Name string
+	Run  func(runtime connector.Runtime) error
+}
+
+var (
+	// Apiserver checks the /readyz of the kube-apiserver on the host.
+	Apiserver = Check{Name: "kube-apiserver", Run: func(runtime connector.Runtime) error {
+		return get(runtime, fmt.Sprintf("https://127.0.0.1:%d/readyz", kubekeyapiv1alpha2.DefaultApiserverPort))
+	}}
+
+	// Kubelet checks the /healthz of the kubelet on the host.
+	Kubelet = Check{Name: "kubelet", Run: func(runtime connector.Runtime) error {
+		return get(runtime, "http://127.0.0.1:10248/healthz")
+	}}
+
+	// Scheduler and ControllerManager check the leader election leases of the kube-scheduler and the
+	// kube-controller-manager are held and renewed, with the kubectl of the host which must be a control-plane node.
+	Scheduler         = leaseCheck("kube-scheduler")
+	ControllerManager = leaseCheck("kube-controller-manager")
+)
+
+// Etcd checks the health of the etcd endpoints, separated by commas, with the admin certificate of the host which
+// must be an etcd node.
+func Etcd(endpoints string) Check {
+	return Check{Name: "etcd", Run: func(runtime connector.Runtime) error {
+		name := runtime.RemoteHost().GetName()
+		env := connector.CommandOptions{Env: map[string]string{
+			"ETCDCTL_API":    "3",
+			"ETCDCTL_CERT":   fmt.Sprintf("/etc/ssl/etcd/ssl/admin-%s.pem", name),
+			"ETCDCTL_KEY":    fmt.Sprintf("/etc/ssl/etcd/ssl/admin-%s-key.pem", name),
+			"ETCDCTL_CACERT": "/etc/ssl/etcd/ssl/ca.pem",
+		}}
+		cmd := fmt.Sprintf("%s/etcdctl --endpoints=%s endpoint health", common.BinDir, endpoints)
+		if out, err := runtime.GetRunner().SudoCmdWith(cmd, env, false); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "etcd endpoints %s aren't healthy: %s", endpoints, strings.TrimSpace(out))
+		}
+		return nil
+	}}
+}
+
+// Port checks the TCP port of the address accepts the connections from the host.
+func Port(address string, port int) Check {
+	endpoint := net.JoinHostPort(address, strconv.Itoa(port))
+	return Check{Name: endpoint, Run: func(runtime connector.Runtime) error {
+		cmd := fmt.Sprintf("timeout 5 bash -c 'printf \"\" >/dev/tcp/%s/%d'", address, port)
+		if _, err := runtime.GetRunner().SudoCmd(cmd, false); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "%s isn't open", endpoint)
+		}
+		return nil
+	}}
+}
+
+// File checks the path exists on the host.
+func File(path string) Check {
+	return Check{Name: path, Run: func(runtime connector.Runtime) error {
+		if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("test -e %s", path), false); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "%s doesn't exist", path)
+		}
+		return nil
+	}}
+}
+
+// URL checks the http or https endpoint responds with a status below 400 to the host.
+func URL(url string) Check {
+	return Check{Name: url, Run: func(runtime connector.Runtime) error {
+		return get(runtime, url)
+	}}
+}
+
+// NodeReady checks the Kubernetes node of the host is Ready, with the kubeconfig of the kubelet of the host.
+var NodeReady = Check{Name: "node", Run: func(runtime connector.Runtime) error {
+	name := runtime.RemoteHost().GetName()
+	out, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("/usr/local/bin/kubectl --kubeconfig /etc/kubernetes/kubelet.conf "+
+		"get node %s -o jsonpath='{.status.conditions[?(@.type==\"Ready\")].status}'", name), false)
+	if err != nil {
+		return errors.Wrapf(errors.WithStack(err), "get the node %s failed", name)
+	}
+	if status := strings.TrimSpace(out); status != "True" {
+		return errors.Errorf("the node %s isn't Ready: %s", name, status)
+	}
+	return nil
+}}
+
+func get(runtime connector.Runtime, url string) error {
+	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("curl -fsk --max-time 5 %s", url), false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "%s isn't ready", url)
+	}
+	return nil
+}
+
+func leaseCheck(name string) Check {
+	return Check{Name: name, Run: func(runtime connector.Runtime) error {
+		out, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("/usr/local/bin/kubectl -n kube-system get lease %s "+
+			"-o jsonpath='{.spec.holderIdentity} {.spec.renewTime} {.spec.leaseDurationSeconds}' && echo && date -u +%%s", name), false)
+		if err != nil {
+			return errors.Wrapf(errors.WithStack(err), "get the lease %s failed", name)
+		}
+		return checkLease(name, out)
+	}}
+}
+
+// checkLease checks the output of the lease check: the holder, the renew time and the duration of the lease, and the
+// current time of the host in seconds on the next line. The lease is expired if it isn't renewed in its duration.
+func checkLease(name, out string) error {
+	lines := strings.Fields(out)
+	if len(lines) != 4 {
+		return errors.Errorf("the lease %s isn't held by any %s", name, name)
+	}
+	renew, err := time.Parse(time.RFC3339Nano, lines[1])
+	if err != nil {
+		return errors.Wrapf(err, "parse the renew time of the lease %s failed", name)
+	}
+	duration, err := strconv.Atoi(lines[2])
+	if err != nil {
+		return errors.Wrapf(err, "parse the duration of the lease %s failed", name)
+	}
+	now, err := strconv.ParseInt(lines[3], 10, 64)
+	if err != nil {
+		return errors.Wrapf(err, "parse the time of the host failed")
+	}
+	if age := time.Unix(now, 0).Sub(renew); age > time.Duration(duration)*time.Second {
+		return errors.Errorf("the lease %s held by %s expired %s ago", name, lines[0], (age - time.Duration(duration)*time.Second).Round(time.Second))
+	}
+	return nil
+}
+
+// Probe runs the checks on the host once and returns the error of the first unhealthy component.
+func Probe(runtime connector.Runtime, checks ...Check) error {
+	for _, c := range checks {
+		if err := c.Run(runtime); err != nil {
+			return errors.Wrapf(err, "%s on %s isn't healthy", c.Name, runtime.RemoteHost().GetName())
+		}
+	}
+	return nil
+}
+
+// Wait runs the checks on the host until they all pass, and returns the last error if they don't in the timeout.
+// The checks aren't run in the dry run, since the commands don't return any output.
+func Wait(runtime connector.Runtime, timeout time.Duration, checks ...Check) error {
+	return WaitEvery(runtime, timeout, pollInterval, checks...)
+}
+
+// WaitEvery is Wait with the interval between the runs of the checks.
+func WaitEvery(runtime connector.Runtime, timeout, interval time.Duration, checks ...Check) error {
+	if connector.IsDryRun(runtime.GetConnector()) {
+		return nil
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		err := Probe(runtime, checks...)
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Wrapf(err, "timed out after %s", timeout)
+		}
+		logger.Log.Debugf("waiting for the components: %v", err)
+		time.Sleep(interval)
+	}
+}
+
+// WaitHealthy is the action of Wait, the timeout is DefaultTimeout if it's not set.
+type WaitHealthy struct {
+	common.KubeAction
+	Checks  []Check
+	Timeout time.Duration
+}
+
+func (w *WaitHealthy) Execute(runtime connector.Runtime) error {
+	timeout := w.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	return Wait(runtime, timeout, w.Checks...)
+}
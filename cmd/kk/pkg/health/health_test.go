@@ -0,0 +1,133 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package health
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+func TestCheckLease(t *testing.T) {
+	tests := []struct {
+		name    string
+		out     string
+		wantErr string
+	}{
+		{
+			name: "renewed",
+			out:  "node1_4f1c2e3a 2023-01-01T00:00:10.123456Z 15\r\n1672531215\r\n",
+		},
+		{
+			name:    "expired",
+			out:     "node1_4f1c2e3a 2023-01-01T00:00:10.123456Z 15\n1672531285\n",
+			wantErr: "the lease kube-scheduler held by node1_4f1c2e3a expired 1m0s ago",
+		},
+		{
+			name:    "not held",
+			out:     "  \n1672531215\n",
+			wantErr: "the lease kube-scheduler isn't held by any kube-scheduler",
+		},
+		{
+			name:    "bad renew time",
+			out:     "node1_4f1c2e3a yesterday 15\n1672531215\n",
+			wantErr: "parse the renew time of the lease kube-scheduler failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkLease("kube-scheduler", tt.out)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkLease() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkLease() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWaitEvery(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+
+	tests := []struct {
+		name     string
+		check    Check
+		commands []connector.FakeCommand
+		timeout  time.Duration
+		wantCmd  string
+		wantErr  string
+	}{
+		{
+			name:  "port opened",
+			check: Port("127.0.0.1", 6443),
+			commands: []connector.FakeCommand{
+				{Match: "/dev/tcp/127.0.0.1/6443", ExitCode: 1, Times: 2},
+			},
+			timeout: time.Minute,
+			wantCmd: "/dev/tcp/127.0.0.1/6443",
+		},
+		{
+			name:  "node ready",
+			check: NodeReady,
+			commands: []connector.FakeCommand{
+				{Match: "get node node1", Stdout: "False", Times: 1},
+				{Match: "get node node1", Stdout: "True"},
+			},
+			timeout: time.Minute,
+			wantCmd: "kubelet.conf get node node1",
+		},
+		{
+			name:     "file missing",
+			check:    File("/data/.mounted"),
+			commands: []connector.FakeCommand{{Match: "test -e /data/.mounted", ExitCode: 1}},
+			wantErr:  "/data/.mounted doesn't exist",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialer := connector.NewFakeDialer(&connector.FakeFixtures{Commands: tt.commands})
+			host := connector.NewHost()
+			host.Name = "node1"
+			conn, err := dialer.Connect(host)
+			if err != nil {
+				t.Fatal(err)
+			}
+			runtime := &connector.BaseRuntime{}
+			runtime.SetConnector(dialer)
+			runtime.SetRunner(&connector.Runner{Conn: conn, Host: host, Ctx: context.Background()})
+
+			err = WaitEvery(runtime, tt.timeout, time.Millisecond, tt.check)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("WaitEvery() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("WaitEvery() error = %v, want %q", err, tt.wantErr)
+			}
+			if tt.wantCmd != "" {
+				dialer.AssertCommands(t, "node1", tt.wantCmd)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package history
+
+import (
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+)
+
+// RevertModule reverts a managed file on a host to a content in the history of the host.
+type RevertModule struct {
+	common.KubeModule
+	Host   string
+	Path   string
+	Digest string
+}
+
+func (r *RevertModule) Init() {
+	r.Name = "RevertModule"
+	r.Desc = "Revert a managed file"
+
+	var hosts []connector.Host
+	for _, host := range r.Runtime.GetAllHosts() {
+		if host.GetName() == r.Host {
+			hosts = append(hosts, host)
+		}
+	}
+
+	revert := &task.RemoteTask{
+		Name:   "RevertFile",
+		Desc:   "Revert the managed file to the content in the history",
+		Hosts:  hosts,
+		Action: &RevertFile{Path: r.Path, Digest: r.Digest},
+	}
+
+	r.Tasks = []task.Interface{
+		revert,
+	}
+}
@@ -0,0 +1,44 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package history
+
+import (
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+type RevertFile struct {
+	common.KubeAction
+	Path   string
+	Digest string
+}
+
+func (r *RevertFile) Execute(runtime connector.Runtime) error {
+	content, err := runtime.GetHistory().Content(runtime.RemoteHost().GetName(), r.Digest)
+	if err != nil {
+		return err
+	}
+	if err := action.WriteRemoteFile(runtime, filepath.Base(r.Path)+".revert", r.Path, content); err != nil {
+		return errors.Wrapf(err, "revert %s failed", r.Path)
+	}
+	return nil
+}
@@ -130,6 +130,11 @@ func (images *Images) PullImages(runtime connector.Runtime, kubeConf *common.Kub
 	}
 
 	host := runtime.RemoteHost()
+	// docker pulls the image of the platform, crictl and isula always pull the one of the host from a multi-arch image
+	var platform string
+	if pullCmd == "docker" {
+		platform = " --platform linux/" + host.GetArch()
+	}
 
 	for _, image := range images.Images {
 		switch {
@@ -139,7 +144,7 @@ func (images *Images) PullImages(runtime connector.Runtime, kubeConf *common.Kub
 			host.IsRole(common.ETCD) && image.Group == kubekeyapiv1alpha2.Etcd && image.Enable:
 
 			logger.Log.Messagef(host.GetName(), "downloading image: %s", image.ImageName())
-			if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("env PATH=$PATH %s pull %s --platform %s", pullCmd, image.ImageName(), host.GetArch()), false); err != nil {
+			if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("env PATH=$PATH %s pull %s%s", pullCmd, image.ImageName(), platform), false); err != nil {
 				return errors.Wrap(err, "pull image failed")
 			}
 		default:
@@ -0,0 +1,134 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package images
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+// blobReferences holds the descriptors an image manifest or index points at. Docker and OCI
+// manifests share these fields.
+type blobReferences struct {
+	Config    *ocispec.Descriptor  `json:"config,omitempty"`
+	Layers    []ocispec.Descriptor `json:"layers,omitempty"`
+	Manifests []ocispec.Descriptor `json:"manifests,omitempty"`
+}
+
+// NormalizeLayout makes the OCI image layout in dir independent of the order the images were
+// copied in and of the copies that failed on the way: index.json lists the images sorted by
+// reference name and digest, and the blobs no image references are removed.
+func NormalizeLayout(dir string) error {
+	indexPath := filepath.Join(dir, "index.json")
+	content, err := os.ReadFile(indexPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", indexPath)
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(content, &index); err != nil {
+		return errors.Wrapf(err, "failed to parse %s", indexPath)
+	}
+
+	sort.SliceStable(index.Manifests, func(i, j int) bool {
+		ri, rj := index.Manifests[i].Annotations[ocispec.AnnotationRefName], index.Manifests[j].Annotations[ocispec.AnnotationRefName]
+		if ri != rj {
+			return ri < rj
+		}
+		return index.Manifests[i].Digest < index.Manifests[j].Digest
+	})
+
+	referenced := make(map[string]bool)
+	for _, desc := range index.Manifests {
+		if err := collectBlobs(dir, desc, referenced); err != nil {
+			return err
+		}
+	}
+	if err := pruneBlobs(dir, referenced); err != nil {
+		return err
+	}
+
+	content, err = json.Marshal(index)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s", indexPath)
+	}
+	return os.WriteFile(indexPath, content, 0644)
+}
+
+func blobPath(dir string, desc ocispec.Descriptor) string {
+	return filepath.Join(dir, "blobs", desc.Digest.Algorithm().String(), desc.Digest.Encoded())
+}
+
+// collectBlobs marks the blob of desc and, for manifests and indexes, every blob below it.
+func collectBlobs(dir string, desc ocispec.Descriptor, referenced map[string]bool) error {
+	if err := desc.Digest.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid digest %q in the image layout %s", desc.Digest, dir)
+	}
+	path := blobPath(dir, desc)
+	if referenced[path] {
+		return nil
+	}
+	referenced[path] = true
+
+	switch desc.MediaType {
+	case ocispec.MediaTypeImageManifest, ocispec.MediaTypeImageIndex,
+		"application/vnd.docker.distribution.manifest.v2+json",
+		"application/vnd.docker.distribution.manifest.list.v2+json":
+	default:
+		return nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the manifest %s", desc.Digest)
+	}
+	var refs blobReferences
+	if err := json.Unmarshal(content, &refs); err != nil {
+		return errors.Wrapf(err, "failed to parse the manifest %s", desc.Digest)
+	}
+
+	children := append(append([]ocispec.Descriptor{}, refs.Layers...), refs.Manifests...)
+	if refs.Config != nil {
+		children = append(children, *refs.Config)
+	}
+	for _, child := range children {
+		if err := collectBlobs(dir, child, referenced); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pruneBlobs removes the blobs which aren't in referenced.
+func pruneBlobs(dir string, referenced map[string]bool) error {
+	return filepath.Walk(filepath.Join(dir, "blobs"), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || referenced[path] {
+			return nil
+		}
+		return os.Remove(path)
+	})
+}
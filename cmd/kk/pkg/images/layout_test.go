@@ -0,0 +1,97 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package images
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func writeBlob(t *testing.T, dir, mediaType string, content []byte) v1.Descriptor {
+	t.Helper()
+	var desc v1.Descriptor
+	raw := fmt.Sprintf(`{"mediaType":%q,"digest":"sha256:%x","size":%d}`, mediaType, sha256.Sum256(content), len(content))
+	if err := json.Unmarshal([]byte(raw), &desc); err != nil {
+		t.Fatal(err)
+	}
+	path := blobPath(dir, desc)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return desc
+}
+
+func TestNormalizeLayout(t *testing.T) {
+	dir := t.TempDir()
+
+	var index v1.Index
+	for _, ref := range []string{"kubesphere:pause:3.9-amd64", "calico:cni:v3.26.1-amd64"} {
+		config := writeBlob(t, dir, v1.MediaTypeImageConfig, []byte(ref))
+		layer := writeBlob(t, dir, v1.MediaTypeImageLayerGzip, []byte("layer of "+ref))
+		manifest, err := json.Marshal(v1.Manifest{MediaType: v1.MediaTypeImageManifest, Config: config, Layers: []v1.Descriptor{layer}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		desc := writeBlob(t, dir, v1.MediaTypeImageManifest, manifest)
+		desc.Annotations = map[string]string{v1.AnnotationRefName: ref}
+		index.Manifests = append(index.Manifests, desc)
+	}
+	orphan := writeBlob(t, dir, v1.MediaTypeImageLayerGzip, []byte("left by a failed copy"))
+
+	content, err := json.Marshal(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NormalizeLayout(dir); err != nil {
+		t.Fatalf("NormalizeLayout() error = %v", err)
+	}
+
+	content, err = os.ReadFile(filepath.Join(dir, "index.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got v1.Index
+	if err := json.Unmarshal(content, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Manifests) != 2 || got.Manifests[0].Annotations[v1.AnnotationRefName] != "calico:cni:v3.26.1-amd64" {
+		t.Errorf("NormalizeLayout() didn't sort index.json: %s", content)
+	}
+	if _, err := os.Stat(blobPath(dir, orphan)); !os.IsNotExist(err) {
+		t.Errorf("NormalizeLayout() kept the unreferenced blob")
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "blobs", "sha256"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 6 {
+		t.Errorf("NormalizeLayout() left %d blobs, want 6", len(entries))
+	}
+}
@@ -19,6 +19,7 @@ package images
 import (
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/facts"
 )
 
 type PullModule struct {
@@ -32,12 +33,14 @@ func (p *PullModule) IsSkip() bool {
 
 func (p *PullModule) Init() {
 	p.Name = "PullModule"
+	p.Tags = []string{"images"}
 	p.Desc = "Pull images on all nodes"
 
 	pull := &task.RemoteTask{
 		Name:     "PullImages",
 		Desc:     "Start to pull images on all nodes",
 		Hosts:    p.Runtime.GetAllHosts(),
+		Prepare:  &facts.NotBaked{Step: facts.BakedImages},
 		Action:   new(PullImage),
 		Parallel: true,
 	}
@@ -78,6 +81,7 @@ func (c *CopyImagesToRegistryModule) IsSkip() bool {
 
 func (c *CopyImagesToRegistryModule) Init() {
 	c.Name = "CopyImagesToRegistryModule"
+	c.Tags = []string{"images"}
 	c.Desc = "Copy images to a private registry from an artifact OCI path"
 
 	copyImage := &task.LocalTask{
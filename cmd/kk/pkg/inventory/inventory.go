@@ -0,0 +1,317 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package inventory
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/provision"
+)
+
+var rangeRegex = regexp.MustCompile(`\[(\d+):(\d+)\]`)
+
+// Inventory defines the hosts of the cluster organized by groups.
+// The groups discovered by the dynamic sources are appended to the static groups when the inventory is loaded.
+// The connection variables of a host are merged in order of precedence: the global vars, the vars of
+// each group the host belongs to (in the order the groups are declared), and the vars of the host itself.
+// The hosts without an address are the machines provisioned by the Provision config, if it is set.
+type Inventory struct {
+	Vars      kubekeyapiv1alpha2.HostCfg `yaml:"vars"`
+	Groups    Groups                     `yaml:"groups"`
+	Sources   []SourceConfig             `yaml:"sources"`
+	Provision *provision.Config          `yaml:"provision"`
+}
+
+// Group defines a group of hosts, the name of the group is used as the role of its hosts,
+// e.g. control-plane, worker, etcd, registry.
+type Group struct {
+	Name  string
+	Vars  kubekeyapiv1alpha2.HostCfg `yaml:"vars"`
+	Hosts Hosts                      `yaml:"hosts"`
+}
+
+// Host defines a host or a host pattern like node[01:20] with its variables.
+type Host struct {
+	Pattern string
+	Vars    kubekeyapiv1alpha2.HostCfg
+}
+
+// Groups keeps the declaration order of the groups.
+type Groups []Group
+
+func (g *Groups) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.MappingNode {
+		return errors.New("groups must be a mapping")
+	}
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		group := Group{Name: value.Content[i].Value}
+		if err := value.Content[i+1].Decode(&group); err != nil {
+			return errors.Wrapf(err, "failed to decode group %s", group.Name)
+		}
+		*g = append(*g, group)
+	}
+	return nil
+}
+
+// Hosts keeps the declaration order of the hosts.
+type Hosts []Host
+
+func (h *Hosts) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.MappingNode {
+		return errors.New("hosts must be a mapping")
+	}
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		host := Host{Pattern: value.Content[i].Value}
+		if err := value.Content[i+1].Decode(&host.Vars); err != nil {
+			return errors.Wrapf(err, "failed to decode host %s", host.Pattern)
+		}
+		*h = append(*h, host)
+	}
+	return nil
+}
+
+// Load reads the inventory from the given file.
+func Load(path string) (*Inventory, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read inventory file %s", path)
+	}
+	inventory := &Inventory{}
+	if err := yaml.Unmarshal(content, inventory); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal inventory file %s", path)
+	}
+	if err := inventory.Refresh(); err != nil {
+		return nil, err
+	}
+	return inventory, nil
+}
+
+// Refresh discovers the hosts from the dynamic sources and appends them to the groups.
+func (i *Inventory) Refresh() error {
+	for _, source := range i.Sources {
+		groups, err := source.Discover()
+		if err != nil {
+			return errors.Wrapf(err, "failed to discover hosts from %s inventory source", source.Type)
+		}
+		i.Groups = append(i.Groups, groups...)
+	}
+	return nil
+}
+
+// Resolve expands the host patterns and merges the variables, it returns the flat host list and role groups of the cluster.
+func (i *Inventory) Resolve() ([]kubekeyapiv1alpha2.HostCfg, map[string][]string, error) {
+	names := make([]string, 0)
+	hostGroups := make(map[string][]Group)
+	hostVars := make(map[string]kubekeyapiv1alpha2.HostCfg)
+	roleGroups := make(map[string][]string)
+
+	for _, group := range i.Groups {
+		for _, host := range group.Hosts {
+			expanded, err := expand(host)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "invalid host %s in group %s", host.Pattern, group.Name)
+			}
+			for _, h := range expanded {
+				if _, ok := hostGroups[h.Name]; !ok {
+					names = append(names, h.Name)
+					hostVars[h.Name] = h
+				} else {
+					merged := hostVars[h.Name]
+					merge(&merged, h)
+					hostVars[h.Name] = merged
+				}
+				hostGroups[h.Name] = append(hostGroups[h.Name], group)
+				roleGroups[group.Name] = append(roleGroups[group.Name], h.Name)
+			}
+		}
+	}
+
+	hosts := make([]kubekeyapiv1alpha2.HostCfg, 0, len(names))
+	for _, name := range names {
+		host := kubekeyapiv1alpha2.HostCfg{}
+		merge(&host, i.Vars)
+		for _, group := range hostGroups[name] {
+			merge(&host, group.Vars)
+		}
+		merge(&host, hostVars[name])
+		host.Name = name
+		hosts = append(hosts, host)
+	}
+	return hosts, roleGroups, nil
+}
+
+// Apply replaces the hosts and role groups of the cluster with the inventory.
+func (i *Inventory) Apply(cluster *kubekeyapiv1alpha2.ClusterSpec) error {
+	hosts, roleGroups, err := i.Resolve()
+	if err != nil {
+		return err
+	}
+	cluster.Hosts = hosts
+	cluster.RoleGroups = roleGroups
+	return nil
+}
+
+// expand expands the host pattern like node[01:20], the address and internalAddress can contain a range
+// with the same length, e.g. 172.16.0.[11:30], which is expanded along with the name.
+func expand(host Host) ([]kubekeyapiv1alpha2.HostCfg, error) {
+	names, err := expandRange(host.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := expandField(host.Vars.Address, len(names))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid address")
+	}
+	internalAddresses, err := expandField(host.Vars.InternalAddress, len(names))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid internalAddress")
+	}
+
+	hosts := make([]kubekeyapiv1alpha2.HostCfg, 0, len(names))
+	for j, name := range names {
+		h := host.Vars
+		h.Name = name
+		h.Address = addresses[j]
+		h.InternalAddress = internalAddresses[j]
+		hosts = append(hosts, h)
+	}
+	return hosts, nil
+}
+
+func expandField(value string, count int) ([]string, error) {
+	values := make([]string, 0, count)
+	if !rangeRegex.MatchString(value) {
+		for j := 0; j < count; j++ {
+			values = append(values, value)
+		}
+		return values, nil
+	}
+
+	values, err := expandRange(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != count {
+		return nil, fmt.Errorf("the range of %s has %d items, but %d hosts are expected", value, len(values), count)
+	}
+	return values, nil
+}
+
+// expandRange expands the first range like [01:20] in the pattern, the leading zeros of the start are kept.
+func expandRange(pattern string) ([]string, error) {
+	loc := rangeRegex.FindStringSubmatchIndex(pattern)
+	if loc == nil {
+		return []string{pattern}, nil
+	}
+
+	startStr, endStr := pattern[loc[2]:loc[3]], pattern[loc[4]:loc[5]]
+	start, _ := strconv.Atoi(startStr)
+	end, _ := strconv.Atoi(endStr)
+	if start > end {
+		return nil, fmt.Errorf("the start of range %s is greater than the end", pattern[loc[0]:loc[1]])
+	}
+
+	format := "%d"
+	if len(startStr) > 1 && strings.HasPrefix(startStr, "0") {
+		format = fmt.Sprintf("%%0%dd", len(startStr))
+	}
+
+	result := make([]string, 0, end-start+1)
+	for j := start; j <= end; j++ {
+		result = append(result, pattern[:loc[0]]+fmt.Sprintf(format, j)+pattern[loc[1]:])
+	}
+	return result, nil
+}
+
+// merge overrides the fields of dst with the non-empty fields of src.
+func merge(dst *kubekeyapiv1alpha2.HostCfg, src kubekeyapiv1alpha2.HostCfg) {
+	if src.Name != "" {
+		dst.Name = src.Name
+	}
+	if len(src.Aliases) > 0 {
+		dst.Aliases = src.Aliases
+	}
+	if src.Address != "" {
+		dst.Address = src.Address
+	}
+	if src.InternalAddress != "" {
+		dst.InternalAddress = src.InternalAddress
+	}
+	if src.Port != 0 {
+		dst.Port = src.Port
+	}
+	if src.User != "" {
+		dst.User = src.User
+	}
+	if src.Password != "" {
+		dst.Password = src.Password
+	}
+	if src.PrivateKey != "" {
+		dst.PrivateKey = src.PrivateKey
+	}
+	if src.PrivateKeyPath != "" {
+		dst.PrivateKeyPath = src.PrivateKeyPath
+	}
+	if src.Arch != "" {
+		dst.Arch = src.Arch
+	}
+	if src.Timeout != nil {
+		dst.Timeout = src.Timeout
+	}
+	if src.Bastion != "" {
+		dst.Bastion = src.Bastion
+	}
+	if src.BastionPort != 0 {
+		dst.BastionPort = src.BastionPort
+	}
+	if src.BastionUser != "" {
+		dst.BastionUser = src.BastionUser
+	}
+	if src.CredentialsFrom != "" {
+		dst.CredentialsFrom = src.CredentialsFrom
+	}
+	if src.Region != "" {
+		dst.Region = src.Region
+	}
+	if src.Zone != "" {
+		dst.Zone = src.Zone
+	}
+	if src.Rack != "" {
+		dst.Rack = src.Rack
+	}
+	if len(src.Labels) > 0 {
+		labels := make(map[string]string, len(dst.Labels)+len(src.Labels))
+		for k, v := range dst.Labels {
+			labels[k] = v
+		}
+		for k, v := range src.Labels {
+			labels[k] = v
+		}
+		dst.Labels = labels
+	}
+	if len(src.Taints) > 0 {
+		dst.Taints = kubekeyapiv1alpha2.MergeTaints(dst.Taints, src.Taints)
+	}
+}
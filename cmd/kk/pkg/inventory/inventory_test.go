@@ -0,0 +1,127 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package inventory
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+const testInventory = `
+vars:
+  user: ubuntu
+  privateKeyPath: ~/.ssh/id_rsa
+groups:
+  control-plane:
+    hosts:
+      master1: {address: 172.16.0.2}
+  etcd:
+    hosts:
+      master1:
+  worker:
+    vars:
+      port: 2222
+      bastion: 10.0.0.1
+      taints: [{key: dedicated, value: general, effect: NoSchedule}]
+    hosts:
+      node[01:03]: {address: "172.16.1.[11:13]", zone: zone-a}
+      node04: {address: 172.16.1.14, user: root, taints: [{key: dedicated, value: gpu, effect: NoSchedule}]}
+`
+
+func TestResolve(t *testing.T) {
+	inv := &Inventory{}
+	if err := yaml.Unmarshal([]byte(testInventory), inv); err != nil {
+		t.Fatal(err)
+	}
+	hosts, roleGroups, err := inv.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantRoleGroups := map[string][]string{
+		"control-plane": {"master1"},
+		"etcd":          {"master1"},
+		"worker":        {"node01", "node02", "node03", "node04"},
+	}
+	if !reflect.DeepEqual(roleGroups, wantRoleGroups) {
+		t.Errorf("Resolve() roleGroups = %v, want %v", roleGroups, wantRoleGroups)
+	}
+
+	tests := []struct {
+		name    string
+		index   int
+		address string
+		user    string
+		port    int
+		bastion string
+		zone    string
+		taint   string
+	}{
+		{name: "master1", index: 0, address: "172.16.0.2", user: "ubuntu"},
+		{name: "node01", index: 1, address: "172.16.1.11", user: "ubuntu", port: 2222, bastion: "10.0.0.1", zone: "zone-a", taint: "dedicated=general:NoSchedule"},
+		{name: "node03", index: 3, address: "172.16.1.13", user: "ubuntu", port: 2222, bastion: "10.0.0.1", zone: "zone-a", taint: "dedicated=general:NoSchedule"},
+		{name: "node04", index: 4, address: "172.16.1.14", user: "root", port: 2222, bastion: "10.0.0.1", taint: "dedicated=gpu:NoSchedule"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := hosts[tt.index]
+			if h.Name != tt.name || h.Address != tt.address || h.User != tt.user || h.Port != tt.port ||
+				h.Bastion != tt.bastion || h.Zone != tt.zone || h.PrivateKeyPath != "~/.ssh/id_rsa" || taints(h) != tt.taint {
+				t.Errorf("Resolve() host = %+v", h)
+			}
+		})
+	}
+}
+
+func taints(h kubekeyapiv1alpha2.HostCfg) string {
+	s := make([]string, 0, len(h.Taints))
+	for _, taint := range h.Taints {
+		s = append(s, taint.String())
+	}
+	return strings.Join(s, ",")
+}
+
+func TestExpandRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+		wantErr bool
+	}{
+		{name: "no range", pattern: "node1", want: []string{"node1"}},
+		{name: "range", pattern: "node[1:3]", want: []string{"node1", "node2", "node3"}},
+		{name: "zero padding", pattern: "node[08:10]", want: []string{"node08", "node09", "node10"}},
+		{name: "address", pattern: "10.0.0.[1:2]", want: []string{"10.0.0.1", "10.0.0.2"}},
+		{name: "invalid", pattern: "node[3:1]", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandRange(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expandRange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
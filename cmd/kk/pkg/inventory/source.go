@@ -0,0 +1,363 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+const (
+	SourceScript    = "script"
+	SourceAWSEC2    = "aws-ec2"
+	SourceOpenStack = "openstack"
+	SourceVSphere   = "vsphere"
+	SourceTerraform = "terraform"
+)
+
+// execCommand runs the command and returns its stdout, it is replaced in unit tests.
+var execCommand = func(name string, args ...string) ([]byte, error) {
+	out, err := exec.Command(name, args...).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return nil, errors.Wrapf(err, "%s: %s", name, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return out, err
+}
+
+// SourceConfig defines a dynamic inventory source, the hosts are discovered every time the inventory is loaded.
+type SourceConfig struct {
+	// Type of the source. Support: script, aws-ec2, openstack, vsphere, terraform
+	Type string `yaml:"type"`
+	// Command is the script which prints the inventory in JSON or YAML, only for the script source.
+	Command string `yaml:"command"`
+	// Region of AWS, only for the aws-ec2 source.
+	Region string `yaml:"region"`
+	// Cloud is the name of the cloud in clouds.yaml, only for the openstack source.
+	Cloud string `yaml:"cloud"`
+	// Folder is the inventory path of the virtual machines, only for the vsphere source.
+	Folder string `yaml:"folder"`
+	// State is the path of the terraform state file, only for the terraform source. The outputs printed by
+	// terraform output -json in the Dir are used if it is empty.
+	State string `yaml:"state"`
+	// Dir is the terraform working dir, only for the terraform source.
+	Dir string `yaml:"dir"`
+	// Rules map the resources and the outputs of the terraform state to hosts, only for the terraform source.
+	Rules []TerraformRule `yaml:"rules"`
+	// Filters selects the instances by tags (aws-ec2) or metadata (openstack).
+	Filters map[string]string `yaml:"filters"`
+	// Groups which all the discovered hosts are added to.
+	Groups []string `yaml:"groups"`
+	// GroupBy is the tag (aws-ec2) or metadata (openstack) key, its comma separated value lists the groups of the host.
+	GroupBy string `yaml:"groupBy"`
+	// PublicAddress uses the public ip as the ssh address instead of the private ip.
+	PublicAddress bool `yaml:"publicAddress"`
+	// Vars are applied to all the discovered hosts.
+	Vars kubekeyapiv1alpha2.HostCfg `yaml:"vars"`
+}
+
+// Discover runs the source and returns the discovered groups.
+func (s SourceConfig) Discover() (Groups, error) {
+	switch s.Type {
+	case SourceScript:
+		return s.discoverScript()
+	case SourceAWSEC2:
+		return s.discoverAWSEC2()
+	case SourceOpenStack:
+		return s.discoverOpenStack()
+	case SourceVSphere:
+		return s.discoverVSphere()
+	case SourceTerraform:
+		return s.discoverTerraform()
+	default:
+		return nil, fmt.Errorf("unsupported inventory source type %s", s.Type)
+	}
+}
+
+func (s SourceConfig) discoverScript() (Groups, error) {
+	if s.Command == "" {
+		return nil, errors.New("the command of script source is required")
+	}
+	out, err := execCommand("/bin/sh", "-c", s.Command)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to run inventory script %s", s.Command)
+	}
+
+	// JSON is a subset of YAML, so the output can be any of them.
+	inv := &Inventory{}
+	if err := yaml.Unmarshal(out, inv); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the output of inventory script %s", s.Command)
+	}
+	for i := range inv.Groups {
+		vars := s.Vars
+		merge(&vars, inv.Vars)
+		merge(&vars, inv.Groups[i].Vars)
+		inv.Groups[i].Vars = vars
+	}
+	return inv.Groups, nil
+}
+
+type ec2Output struct {
+	Reservations []struct {
+		Instances []struct {
+			InstanceID       string `json:"InstanceId"`
+			PrivateIPAddress string `json:"PrivateIpAddress"`
+			PublicIPAddress  string `json:"PublicIpAddress"`
+			Architecture     string `json:"Architecture"`
+			Placement        struct {
+				AvailabilityZone string `json:"AvailabilityZone"`
+			} `json:"Placement"`
+			Tags []struct {
+				Key   string `json:"Key"`
+				Value string `json:"Value"`
+			} `json:"Tags"`
+		} `json:"Instances"`
+	} `json:"Reservations"`
+}
+
+func (s SourceConfig) discoverAWSEC2() (Groups, error) {
+	args := []string{"ec2", "describe-instances", "--output", "json", "--filters", "Name=instance-state-name,Values=running"}
+	if s.Region != "" {
+		args = append(args, "--region", s.Region)
+	}
+	for k, v := range s.Filters {
+		args = append(args, fmt.Sprintf("Name=tag:%s,Values=%s", k, v))
+	}
+	out, err := execCommand("aws", args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to describe aws ec2 instances")
+	}
+
+	result := ec2Output{}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return nil, errors.Wrap(err, "failed to parse aws ec2 instances")
+	}
+
+	hosts := make([]discoveredHost, 0)
+	for _, r := range result.Reservations {
+		for _, i := range r.Instances {
+			tags := make(map[string]string, len(i.Tags))
+			for _, t := range i.Tags {
+				tags[t.Key] = t.Value
+			}
+			name := i.InstanceID
+			if tags["Name"] != "" {
+				name = tags["Name"]
+			}
+			h := discoveredHost{name: name, metadata: tags}
+			h.vars.InternalAddress = i.PrivateIPAddress
+			h.vars.Address = s.address(i.PrivateIPAddress, i.PublicIPAddress)
+			h.vars.Region = s.Region
+			h.vars.Zone = i.Placement.AvailabilityZone
+			h.vars.Arch = util.Arch(i.Architecture)
+			hosts = append(hosts, h)
+		}
+	}
+	return s.group(hosts), nil
+}
+
+type openstackServer struct {
+	Name             string          `json:"Name"`
+	Status           string          `json:"Status"`
+	Networks         json.RawMessage `json:"Networks"`
+	AvailabilityZone string          `json:"Availability Zone"`
+	Properties       json.RawMessage `json:"Properties"`
+}
+
+func (s SourceConfig) discoverOpenStack() (Groups, error) {
+	args := []string{"server", "list", "--long", "-f", "json", "--status", "ACTIVE"}
+	if s.Cloud != "" {
+		args = append([]string{"--os-cloud", s.Cloud}, args...)
+	}
+	out, err := execCommand("openstack", args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list openstack servers")
+	}
+
+	servers := make([]openstackServer, 0)
+	if err := json.Unmarshal(out, &servers); err != nil {
+		return nil, errors.Wrap(err, "failed to parse openstack servers")
+	}
+
+	hosts := make([]discoveredHost, 0)
+	for _, server := range servers {
+		metadata := parseOpenStackProperties(server.Properties)
+		if !matchFilters(metadata, s.Filters) {
+			continue
+		}
+		addresses := parseOpenStackNetworks(server.Networks)
+		if len(addresses) == 0 {
+			continue
+		}
+		h := discoveredHost{name: server.Name, metadata: metadata}
+		h.vars.InternalAddress = addresses[0]
+		public := ""
+		if len(addresses) > 1 {
+			public = addresses[len(addresses)-1]
+		}
+		h.vars.Address = s.address(addresses[0], public)
+		h.vars.Zone = server.AvailabilityZone
+		hosts = append(hosts, h)
+	}
+	return s.group(hosts), nil
+}
+
+type govcOutput struct {
+	VirtualMachines []struct {
+		Name  string `json:"name"`
+		Guest struct {
+			IPAddress string `json:"ipAddress"`
+		} `json:"guest"`
+		Runtime struct {
+			PowerState string `json:"powerState"`
+		} `json:"runtime"`
+	} `json:"virtualMachines"`
+}
+
+func (s SourceConfig) discoverVSphere() (Groups, error) {
+	if s.Folder == "" {
+		return nil, errors.New("the folder of vsphere source is required")
+	}
+	out, err := execCommand("govc", "vm.info", "-json", strings.TrimSuffix(s.Folder, "/")+"/*")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get vsphere virtual machines")
+	}
+
+	// encoding/json matches the keys case-insensitively, so both the old and new output of govc are supported.
+	result := govcOutput{}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return nil, errors.Wrap(err, "failed to parse vsphere virtual machines")
+	}
+
+	hosts := make([]discoveredHost, 0)
+	for _, vm := range result.VirtualMachines {
+		if vm.Runtime.PowerState != "poweredOn" || vm.Guest.IPAddress == "" {
+			continue
+		}
+		h := discoveredHost{name: vm.Name}
+		h.vars.Address = vm.Guest.IPAddress
+		h.vars.InternalAddress = vm.Guest.IPAddress
+		hosts = append(hosts, h)
+	}
+	return s.group(hosts), nil
+}
+
+type discoveredHost struct {
+	name     string
+	metadata map[string]string
+	vars     kubekeyapiv1alpha2.HostCfg
+}
+
+// group puts the discovered hosts into the configured groups and the groups listed by the groupBy key.
+func (s SourceConfig) group(hosts []discoveredHost) Groups {
+	groups := make(Groups, 0)
+	index := make(map[string]int)
+	add := func(group string, h discoveredHost) {
+		i, ok := index[group]
+		if !ok {
+			i = len(groups)
+			index[group] = i
+			groups = append(groups, Group{Name: group, Vars: s.Vars})
+		}
+		groups[i].Hosts = append(groups[i].Hosts, Host{Pattern: h.name, Vars: h.vars})
+	}
+
+	for _, h := range hosts {
+		for _, group := range s.Groups {
+			add(group, h)
+		}
+		if s.GroupBy == "" {
+			continue
+		}
+		for _, group := range strings.Split(h.metadata[s.GroupBy], ",") {
+			if group = strings.TrimSpace(group); group != "" {
+				add(group, h)
+			}
+		}
+	}
+	return groups
+}
+
+func (s SourceConfig) address(private, public string) string {
+	if s.PublicAddress && public != "" {
+		return public
+	}
+	return private
+}
+
+func matchFilters(metadata, filters map[string]string) bool {
+	for k, v := range filters {
+		if metadata[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// parseOpenStackNetworks supports both {"net": ["10.0.0.5", "172.24.4.10"]} and "net=10.0.0.5, 172.24.4.10".
+func parseOpenStackNetworks(raw json.RawMessage) []string {
+	addresses := make([]string, 0)
+	networks := make(map[string][]string)
+	if err := json.Unmarshal(raw, &networks); err == nil {
+		for _, addrs := range networks {
+			addresses = append(addresses, addrs...)
+		}
+		return addresses
+	}
+
+	var str string
+	if err := json.Unmarshal(raw, &str); err != nil {
+		return addresses
+	}
+	for _, network := range strings.Split(str, ";") {
+		kv := strings.SplitN(network, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		for _, addr := range strings.Split(kv[1], ",") {
+			addresses = append(addresses, strings.TrimSpace(addr))
+		}
+	}
+	return addresses
+}
+
+// parseOpenStackProperties supports both {"k": "v"} and "k='v', k2='v2'".
+func parseOpenStackProperties(raw json.RawMessage) map[string]string {
+	properties := make(map[string]string)
+	if err := json.Unmarshal(raw, &properties); err == nil {
+		return properties
+	}
+
+	var str string
+	if err := json.Unmarshal(raw, &str); err != nil {
+		return properties
+	}
+	for _, property := range strings.Split(str, ", ") {
+		kv := strings.SplitN(property, "=", 2)
+		if len(kv) == 2 {
+			properties[strings.TrimSpace(kv[0])] = strings.Trim(kv[1], "'")
+		}
+	}
+	return properties
+}
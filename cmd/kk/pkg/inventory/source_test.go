@@ -0,0 +1,203 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package inventory
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+func TestDiscover(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     SourceConfig
+		output     string
+		wantGroups map[string][]string
+	}{
+		{
+			name:   "script",
+			source: SourceConfig{Type: SourceScript, Command: "./hosts.sh"},
+			output: `{"vars": {"user": "ubuntu"}, "groups": {"worker": {"hosts": {"node1": {"address": "10.0.0.1"}}}}}`,
+			wantGroups: map[string][]string{
+				"worker": {"node1"},
+			},
+		},
+		{
+			name:   "aws ec2",
+			source: SourceConfig{Type: SourceAWSEC2, Region: "us-east-1", Groups: []string{"etcd"}, GroupBy: "kubekey-role"},
+			output: `{"Reservations": [{"Instances": [{"InstanceId": "i-01", "PrivateIpAddress": "10.0.0.1", "PublicIpAddress": "3.3.3.3",
+				"Architecture": "x86_64", "Placement": {"AvailabilityZone": "us-east-1a"},
+				"Tags": [{"Key": "Name", "Value": "master1"}, {"Key": "kubekey-role", "Value": "control-plane,worker"}]}]}]}`,
+			wantGroups: map[string][]string{
+				"etcd":          {"master1"},
+				"control-plane": {"master1"},
+				"worker":        {"master1"},
+			},
+		},
+		{
+			name:   "openstack",
+			source: SourceConfig{Type: SourceOpenStack, Filters: map[string]string{"cluster": "sample"}, GroupBy: "role"},
+			output: `[{"Name": "node1", "Networks": {"private": ["10.0.0.5"]}, "Availability Zone": "nova", "Properties": {"cluster": "sample", "role": "worker"}},
+				{"Name": "other", "Networks": "private=10.0.0.6", "Properties": "cluster='other', role='worker'"}]`,
+			wantGroups: map[string][]string{
+				"worker": {"node1"},
+			},
+		},
+		{
+			name:   "vsphere",
+			source: SourceConfig{Type: SourceVSphere, Folder: "/dc/vm/k8s/", Groups: []string{"worker"}},
+			output: `{"VirtualMachines": [{"Name": "vm1", "Guest": {"IpAddress": "10.0.0.7"}, "Runtime": {"PowerState": "poweredOn"}},
+				{"Name": "vm2", "Guest": {"IpAddress": ""}, "Runtime": {"PowerState": "poweredOff"}}]}`,
+			wantGroups: map[string][]string{
+				"worker": {"vm1"},
+			},
+		},
+	}
+
+	defer func(f func(name string, args ...string) ([]byte, error)) { execCommand = f }(execCommand)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			execCommand = func(name string, args ...string) ([]byte, error) {
+				return []byte(tt.output), nil
+			}
+			groups, err := tt.source.Discover()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string][]string)
+			for _, g := range groups {
+				for _, h := range g.Hosts {
+					got[g.Name] = append(got[g.Name], h.Pattern)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wantGroups) {
+				t.Errorf("Discover() = %v, want %v", got, tt.wantGroups)
+			}
+		})
+	}
+}
+
+func TestDiscoverAWSEC2Vars(t *testing.T) {
+	defer func(f func(name string, args ...string) ([]byte, error)) { execCommand = f }(execCommand)
+	execCommand = func(name string, args ...string) ([]byte, error) {
+		return []byte(`{"Reservations": [{"Instances": [{"InstanceId": "i-01", "PrivateIpAddress": "10.0.0.1", "PublicIpAddress": "3.3.3.3",
+			"Architecture": "arm64", "Placement": {"AvailabilityZone": "us-east-1a"}}]}]}`), nil
+	}
+
+	source := SourceConfig{Type: SourceAWSEC2, Region: "us-east-1", Groups: []string{"worker"}, PublicAddress: true}
+	groups, err := source.Discover()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := groups[0].Hosts[0]
+	if h.Pattern != "i-01" || h.Vars.Address != "3.3.3.3" || h.Vars.InternalAddress != "10.0.0.1" ||
+		h.Vars.Arch != "arm64" || h.Vars.Region != "us-east-1" || h.Vars.Zone != "us-east-1a" {
+		t.Errorf("Discover() host = %+v", h)
+	}
+}
+
+const testTerraformState = `{
+  "version": 4,
+  "outputs": {"bastion": {"value": "3.3.3.3", "type": "string"}},
+  "resources": [
+    {"mode": "managed", "type": "aws_instance", "name": "master", "instances": [
+      {"index_key": 0, "attributes": {"private_ip": "10.0.0.1", "public_ip": "3.3.3.1", "tags": {"Name": "master1", "role": "control-plane,etcd"}}}
+    ]},
+    {"mode": "managed", "type": "aws_instance", "name": "worker", "instances": [
+      {"index_key": 0, "attributes": {"private_ip": "10.0.1.1", "tags": {"role": "worker"}}},
+      {"index_key": 1, "attributes": {"private_ip": "", "tags": {"role": "worker"}}}
+    ]},
+    {"mode": "data", "type": "aws_instance", "name": "other", "instances": [{"attributes": {"private_ip": "10.0.2.1"}}]}
+  ]
+}`
+
+func TestDiscoverTerraform(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "terraform.tfstate")
+	if err := os.WriteFile(state, []byte(testTerraformState), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(f func(name string, args ...string) ([]byte, error)) { execCommand = f }(execCommand)
+	execCommand = func(name string, args ...string) ([]byte, error) {
+		if name != "terraform" || strings.Join(args, " ") != "-chdir=infra output -json" {
+			t.Errorf("unexpected command %s %v", name, args)
+		}
+		return []byte(`{"workers": {"value": {"node1": "10.0.3.1", "node2": "10.0.3.2"}},
+			"masters": {"value": [{"name": "master1", "address": "3.3.3.1", "internal_address": "10.0.0.1"}]}}`), nil
+	}
+
+	tests := []struct {
+		name       string
+		source     SourceConfig
+		wantGroups map[string][]string
+		wantHost   Host
+	}{
+		{
+			name: "state",
+			source: SourceConfig{Type: SourceTerraform, State: state, PublicAddress: true, Rules: []TerraformRule{
+				{Type: "aws_instance", Name: "master*", GroupBy: "tags.role"},
+				{Type: "aws_instance", Name: "worker", Groups: []string{"worker"}, Vars: kubekeyapiv1alpha2.HostCfg{User: "ubuntu"}},
+				{Output: "bastion", Groups: []string{"registry"}},
+			}},
+			wantGroups: map[string][]string{
+				"control-plane": {"master1"},
+				"etcd":          {"master1"},
+				"worker":        {"worker-0"},
+				"registry":      {"bastion"},
+			},
+			wantHost: Host{Pattern: "master1", Vars: kubekeyapiv1alpha2.HostCfg{Address: "3.3.3.1", InternalAddress: "10.0.0.1"}},
+		},
+		{
+			name: "outputs",
+			source: SourceConfig{Type: SourceTerraform, Dir: "infra", Rules: []TerraformRule{
+				{Output: "masters", Groups: []string{"control-plane", "etcd"}},
+				{Output: "workers", Groups: []string{"worker"}},
+			}},
+			wantGroups: map[string][]string{
+				"control-plane": {"master1"},
+				"etcd":          {"master1"},
+				"worker":        {"node1", "node2"},
+			},
+			wantHost: Host{Pattern: "master1", Vars: kubekeyapiv1alpha2.HostCfg{Address: "3.3.3.1", InternalAddress: "10.0.0.1"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups, err := tt.source.Discover()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string][]string)
+			for _, g := range groups {
+				for _, h := range g.Hosts {
+					got[g.Name] = append(got[g.Name], h.Pattern)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wantGroups) {
+				t.Errorf("Discover() = %v, want %v", got, tt.wantGroups)
+			}
+			if h := groups[0].Hosts[0]; h.Pattern != tt.wantHost.Pattern || h.Vars.Address != tt.wantHost.Vars.Address ||
+				h.Vars.InternalAddress != tt.wantHost.Vars.InternalAddress {
+				t.Errorf("Discover() host = %+v, want %+v", h, tt.wantHost)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+// TerraformRule maps the resources of a type, or an output, of the terraform state to hosts.
+type TerraformRule struct {
+	// Type of the resources, e.g. aws_instance, only for the state file.
+	Type string `yaml:"type"`
+	// Name is the glob of the names of the resources, e.g. worker*, all the resources of the type if it is empty.
+	Name string `yaml:"name"`
+	// Output is the name of the output holding the hosts.
+	Output string `yaml:"output"`
+	// Groups which the hosts are added to.
+	Groups []string `yaml:"groups"`
+	// GroupBy is the attribute, e.g. tags.role, whose comma separated value lists the groups of the host.
+	GroupBy string `yaml:"groupBy"`
+	// HostName, Address and InternalAddress are the attributes of the name and the addresses of the hosts, e.g.
+	// tags.Name or network_interface.0.network_ip. They default by the type of the resources, see terraformTypes.
+	HostName        string `yaml:"hostName"`
+	Address         string `yaml:"address"`
+	InternalAddress string `yaml:"internalAddress"`
+	// Vars are applied to the hosts of the rule.
+	Vars kubekeyapiv1alpha2.HostCfg `yaml:"vars"`
+}
+
+// terraformAttributes are the attributes of the name, the private and the public address of the hosts of a type.
+type terraformAttributes struct {
+	name, private, public string
+}
+
+var terraformTypes = map[string]terraformAttributes{
+	"aws_instance":                  {"tags.Name", "private_ip", "public_ip"},
+	"azurerm_linux_virtual_machine": {"name", "private_ip_address", "public_ip_address"},
+	"digitalocean_droplet":          {"name", "ipv4_address_private", "ipv4_address"},
+	"google_compute_instance":       {"name", "network_interface.0.network_ip", "network_interface.0.access_config.0.nat_ip"},
+	"hcloud_server":                 {"name", "ipv4_address", "ipv4_address"},
+	"libvirt_domain":                {"name", "network_interface.0.addresses.0", ""},
+	"openstack_compute_instance_v2": {"name", "access_ip_v4", ""},
+	"vsphere_virtual_machine":       {"name", "default_ip_address", ""},
+	// the objects of the outputs
+	"": {"name", "internal_address", ""},
+}
+
+type terraformState struct {
+	Outputs   map[string]terraformOutput `json:"outputs"`
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			IndexKey   interface{}            `json:"index_key"`
+			Attributes map[string]interface{} `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+type terraformOutput struct {
+	Value interface{} `json:"value"`
+}
+
+// discoverTerraform maps the resources and the outputs of the state file to hosts by the rules, or the outputs
+// printed by terraform output -json in the dir if the state file isn't set.
+func (s SourceConfig) discoverTerraform() (Groups, error) {
+	if len(s.Rules) == 0 {
+		return nil, errors.New("the rules of terraform source are required")
+	}
+	state := terraformState{}
+	if s.State != "" {
+		content, err := os.ReadFile(s.State)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read terraform state %s", s.State)
+		}
+		if err := json.Unmarshal(content, &state); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse terraform state %s", s.State)
+		}
+	} else {
+		args := []string{"output", "-json"}
+		if s.Dir != "" {
+			args = append([]string{"-chdir=" + s.Dir}, args...)
+		}
+		out, err := execCommand("terraform", args...)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get terraform outputs")
+		}
+		if err := json.Unmarshal(out, &state.Outputs); err != nil {
+			return nil, errors.Wrap(err, "failed to parse terraform outputs")
+		}
+	}
+
+	groups := make(Groups, 0)
+	for _, rule := range s.Rules {
+		var hosts []discoveredHost
+		switch {
+		case rule.Type != "":
+			for _, resource := range state.Resources {
+				if resource.Mode != "managed" || resource.Type != rule.Type {
+					continue
+				}
+				if ok, _ := path.Match(rule.Name, resource.Name); rule.Name != "" && !ok {
+					continue
+				}
+				for _, instance := range resource.Instances {
+					name := resource.Name
+					if instance.IndexKey != nil {
+						name = fmt.Sprintf("%s-%v", resource.Name, instance.IndexKey)
+					}
+					if h, ok := s.terraformHost(rule, name, flatten(instance.Attributes)); ok {
+						hosts = append(hosts, h)
+					}
+				}
+			}
+		case rule.Output != "":
+			output, ok := state.Outputs[rule.Output]
+			if !ok {
+				return nil, errors.Errorf("no terraform output %s", rule.Output)
+			}
+			hosts = s.terraformOutputHosts(rule, output.Value)
+		default:
+			return nil, errors.New("either the type or the output of a terraform rule is required")
+		}
+
+		rs := s
+		rs.Groups = append(append([]string{}, s.Groups...), rule.Groups...)
+		if rule.GroupBy != "" {
+			rs.GroupBy = rule.GroupBy
+		}
+		merge(&rs.Vars, rule.Vars)
+		groups = append(groups, rs.group(hosts)...)
+	}
+	return groups, nil
+}
+
+// terraformOutputHosts maps the value of the output to hosts: a string is the address of the host named after the
+// output, a list or a map of strings holds the addresses of the hosts named after the output and the indexes or
+// named by the keys, and a list or a map of objects holds the attributes of the hosts.
+func (s SourceConfig) terraformOutputHosts(rule TerraformRule, value interface{}) []discoveredHost {
+	var hosts []discoveredHost
+	add := func(name string, v interface{}) {
+		var attributes map[string]string
+		switch v := v.(type) {
+		case string:
+			attributes = map[string]string{"address": v}
+		case map[string]interface{}:
+			attributes = flatten(v)
+		default:
+			return
+		}
+		if h, ok := s.terraformHost(rule, name, attributes); ok {
+			hosts = append(hosts, h)
+		}
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		for i, item := range v {
+			add(fmt.Sprintf("%s-%d", rule.Output, i), item)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			add(k, v[k])
+		}
+	default:
+		add(rule.Output, v)
+	}
+	return hosts
+}
+
+// terraformHost returns the host of the attributes, named by the default name if the attributes have no name. The
+// hosts without an address, e.g. the stopped instances, are skipped.
+func (s SourceConfig) terraformHost(rule TerraformRule, name string, attributes map[string]string) (discoveredHost, bool) {
+	defaults := terraformTypes[rule.Type]
+	if v := attributes[pick(rule.HostName, defaults.name)]; v != "" {
+		name = v
+	}
+	h := discoveredHost{name: name, metadata: attributes}
+	private := attributes[pick(rule.InternalAddress, defaults.private)]
+	if rule.Type == "" {
+		// the address of the objects of the outputs is the ssh address, and the internal address defaults to it
+		private = pick(private, attributes["address"])
+		h.vars.Address = attributes[pick(rule.Address, "address")]
+	} else if rule.Address != "" {
+		h.vars.Address = attributes[rule.Address]
+	} else {
+		h.vars.Address = s.address(private, attributes[defaults.public])
+	}
+	h.vars.Address = pick(h.vars.Address, private)
+	h.vars.InternalAddress = private
+	return h, private != ""
+}
+
+func pick(value, defaultValue string) string {
+	if value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// flatten returns the scalar attributes by their paths, e.g. network_interface.0.network_ip.
+func flatten(attributes map[string]interface{}) map[string]string {
+	flat := make(map[string]string)
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, item := range v {
+				walk(join(prefix, k), item)
+			}
+		case []interface{}:
+			for i, item := range v {
+				walk(join(prefix, strconv.Itoa(i)), item)
+			}
+		case string:
+			flat[prefix] = v
+		case nil:
+		default:
+			flat[prefix] = fmt.Sprint(v)
+		}
+	}
+	walk("", attributes)
+	return flat
+}
+
+func join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
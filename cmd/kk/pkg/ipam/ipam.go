@@ -0,0 +1,253 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package ipam
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+// File is the allocations of the CIDRs in the work dir, shared by the clusters managed from it.
+const File = "ipam.json"
+
+// Allocation is the CIDRs of a cluster with the IPAM, allocated from the ranges or set in the config of the cluster.
+type Allocation struct {
+	Cluster     string    `json:"cluster"`
+	PodsCIDR    string    `json:"podsCIDR,omitempty"`
+	ServiceCIDR string    `json:"serviceCIDR,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Store is the allocations of the clusters, sorted by the cluster.
+type Store struct {
+	Allocations []Allocation `json:"allocations"`
+}
+
+// Load reads the allocations from the file, the store is empty if the file doesn't exist.
+func Load(file string) (*Store, error) {
+	s := &Store{}
+	content, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "read the ipam allocations %s failed", file)
+	}
+	if err := json.Unmarshal(content, s); err != nil {
+		return nil, errors.Wrapf(err, "parse the ipam allocations %s failed", file)
+	}
+	return s, nil
+}
+
+// Save writes the allocations to the file.
+func (s *Store) Save(file string) error {
+	sort.Slice(s.Allocations, func(i, j int) bool {
+		return s.Allocations[i].Cluster < s.Allocations[j].Cluster
+	})
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encode the ipam allocations failed")
+	}
+	if err := util.WriteFile(file, content); err != nil {
+		return errors.Wrapf(err, "write the ipam allocations %s failed", file)
+	}
+	return nil
+}
+
+// Get returns the allocation of the cluster.
+func (s *Store) Get(cluster string) (Allocation, bool) {
+	for _, a := range s.Allocations {
+		if a.Cluster == cluster {
+			return a, true
+		}
+	}
+	return Allocation{}, false
+}
+
+// Release removes the allocation of the cluster, it returns false if the cluster has no allocation.
+func (s *Store) Release(cluster string) bool {
+	for i, a := range s.Allocations {
+		if a.Cluster == cluster {
+			s.Allocations = append(s.Allocations[:i], s.Allocations[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// usedCIDR is a CIDR allocated to a cluster.
+type usedCIDR struct {
+	cidr    *net.IPNet
+	cluster string
+}
+
+// Assign sets the kubePodsCIDR and kubeServiceCIDR of the cluster which aren't set from the ranges of its IPAM
+// config, and records them. The previous allocation of the cluster is kept while it is in the range. The CIDRs set in
+// the config are checked against the allocations of the other clusters and recorded too. The addresses of the hosts
+// are never allocated.
+func (s *Store) Assign(cluster string, spec *kubekeyapiv1alpha2.ClusterSpec, now time.Time) error {
+	var used []usedCIDR
+	for _, a := range s.Allocations {
+		if a.Cluster == cluster {
+			continue
+		}
+		for _, cidr := range parseCIDRs(a.PodsCIDR + "," + a.ServiceCIDR) {
+			used = append(used, usedCIDR{cidr: cidr, cluster: a.Cluster})
+		}
+	}
+	reserved := hostAddresses(spec.Hosts)
+	previous, _ := s.Get(cluster)
+	network := &spec.Network
+
+	// the pods CIDR is allocated out of the service CIDR set in the config
+	podsUsed := used[:len(used):len(used)]
+	for _, cidr := range parseCIDRs(network.KubeServiceCIDR) {
+		podsUsed = append(podsUsed, usedCIDR{cidr: cidr, cluster: cluster})
+	}
+	pods, err := assign(network.KubePodsCIDR, previous.PodsCIDR, network.IPAM.PodsRange, network.IPAM.PodsPrefix(), podsUsed, reserved)
+	if err != nil {
+		return errors.Wrap(err, "assign the pods CIDR failed")
+	}
+	for _, cidr := range parseCIDRs(pods) {
+		used = append(used, usedCIDR{cidr: cidr, cluster: cluster})
+	}
+	services, err := assign(network.KubeServiceCIDR, previous.ServiceCIDR, network.IPAM.ServiceRange, network.IPAM.ServicePrefix(), used, reserved)
+	if err != nil {
+		return errors.Wrap(err, "assign the service CIDR failed")
+	}
+
+	network.KubePodsCIDR = pods
+	network.KubeServiceCIDR = services
+	s.Release(cluster)
+	s.Allocations = append(s.Allocations, Allocation{
+		Cluster:     cluster,
+		PodsCIDR:    pods,
+		ServiceCIDR: services,
+		UpdatedAt:   now,
+	})
+	return nil
+}
+
+// assign returns the CIDR set in the config if it doesn't overlap with the used ones, or else the previous CIDR if it
+// is still free in the range, or else the first free CIDR of the prefix length in the range.
+func assign(value, previous, rangeValue string, prefix int, used []usedCIDR, reserved []net.IP) (string, error) {
+	if value != "" {
+		for _, cidr := range parseCIDRs(value) {
+			if u, ok := overlaps(cidr, used); ok {
+				return "", errors.Errorf("%s overlaps with %s of the cluster %s", cidr, u.cidr, u.cluster)
+			}
+		}
+		return value, nil
+	}
+	if rangeValue == "" {
+		return "", nil
+	}
+	_, r, err := net.ParseCIDR(rangeValue)
+	if err != nil {
+		return "", errors.Wrapf(err, "parse the range %s failed", rangeValue)
+	}
+
+	if _, cidr, err := net.ParseCIDR(previous); err == nil && isFree(cidr, used, reserved) {
+		if ones, _ := cidr.Mask.Size(); ones == prefix && r.Contains(cidr.IP) {
+			return cidr.String(), nil
+		}
+	}
+
+	ones, bits := r.Mask.Size()
+	if prefix < ones || prefix > bits {
+		return "", errors.Errorf("the prefix length %d is out of the range %s", prefix, r)
+	}
+	base := new(big.Int).SetBytes(r.IP)
+	step := new(big.Int).Lsh(big.NewInt(1), uint(bits-prefix))
+	count := new(big.Int).Lsh(big.NewInt(1), uint(prefix-ones))
+	for i := new(big.Int); i.Cmp(count) < 0; i.Add(i, big.NewInt(1)) {
+		ip := new(big.Int).Add(base, new(big.Int).Mul(i, step))
+		cidr := &net.IPNet{IP: toIP(ip, len(r.IP)), Mask: net.CIDRMask(prefix, bits)}
+		if isFree(cidr, used, reserved) {
+			return cidr.String(), nil
+		}
+	}
+	return "", errors.Errorf("no free /%d CIDR is left in the range %s", prefix, r)
+}
+
+func isFree(cidr *net.IPNet, used []usedCIDR, reserved []net.IP) bool {
+	if _, ok := overlaps(cidr, used); ok {
+		return false
+	}
+	for _, ip := range reserved {
+		if cidr.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func overlaps(cidr *net.IPNet, used []usedCIDR) (usedCIDR, bool) {
+	for _, u := range used {
+		if cidr.Contains(u.cidr.IP) || u.cidr.Contains(cidr.IP) {
+			return u, true
+		}
+	}
+	return usedCIDR{}, false
+}
+
+func toIP(i *big.Int, size int) net.IP {
+	b := i.Bytes()
+	ip := make(net.IP, size)
+	copy(ip[size-len(b):], b)
+	return ip
+}
+
+// parseCIDRs returns the CIDRs in the comma separated list, skipping the invalid ones.
+func parseCIDRs(value string) []*net.IPNet {
+	var cidrs []*net.IPNet
+	for _, s := range strings.Split(value, ",") {
+		if _, cidr, err := net.ParseCIDR(strings.TrimSpace(s)); err == nil {
+			cidrs = append(cidrs, cidr)
+		}
+	}
+	return cidrs
+}
+
+// hostAddresses returns the addresses and the internal addresses of the hosts.
+func hostAddresses(hosts []kubekeyapiv1alpha2.HostCfg) []net.IP {
+	var ips []net.IP
+	for _, host := range hosts {
+		for _, address := range strings.Split(host.Address+","+host.InternalAddress, ",") {
+			if ip := net.ParseIP(kubekeyapiv1alpha2.TrimAddressBrackets(strings.TrimSpace(address))); ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+	}
+	return ips
+}
+
+// String returns the allocation in a line of the log.
+func (a Allocation) String() string {
+	return fmt.Sprintf("pods CIDR %s, service CIDR %s", a.PodsCIDR, a.ServiceCIDR)
+}
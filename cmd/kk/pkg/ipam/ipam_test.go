@@ -0,0 +1,137 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package ipam
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+func TestStore_Assign(t *testing.T) {
+	ipam := kubekeyapiv1alpha2.IPAMConfig{PodsRange: "10.128.0.0/16", ServiceRange: "10.96.0.0/16", ServicePrefixLength: 20}
+	site1 := Allocation{Cluster: "site1", PodsCIDR: "10.128.0.0/18", ServiceCIDR: "10.96.0.0/20"}
+	tests := []struct {
+		name        string
+		allocations []Allocation
+		network     kubekeyapiv1alpha2.NetworkConfig
+		hosts       []kubekeyapiv1alpha2.HostCfg
+		wantPods    string
+		wantService string
+		wantErr     bool
+	}{
+		{
+			name:        "first cluster",
+			network:     kubekeyapiv1alpha2.NetworkConfig{IPAM: ipam},
+			wantPods:    "10.128.0.0/18",
+			wantService: "10.96.0.0/20",
+		},
+		{
+			name:        "the CIDRs of the other clusters are skipped",
+			allocations: []Allocation{site1},
+			network:     kubekeyapiv1alpha2.NetworkConfig{IPAM: ipam},
+			wantPods:    "10.128.64.0/18",
+			wantService: "10.96.16.0/20",
+		},
+		{
+			name:        "the previous allocation is kept",
+			allocations: []Allocation{site1, {Cluster: "site2", PodsCIDR: "10.128.128.0/18", ServiceCIDR: "10.96.32.0/20"}},
+			network:     kubekeyapiv1alpha2.NetworkConfig{IPAM: ipam},
+			wantPods:    "10.128.128.0/18",
+			wantService: "10.96.32.0/20",
+		},
+		{
+			name:        "the addresses of the hosts are skipped",
+			network:     kubekeyapiv1alpha2.NetworkConfig{IPAM: ipam},
+			hosts:       []kubekeyapiv1alpha2.HostCfg{{Name: "node1", Address: "10.128.0.2", InternalAddress: "10.96.0.2"}},
+			wantPods:    "10.128.64.0/18",
+			wantService: "10.96.16.0/20",
+		},
+		{
+			name:        "ipv6 range",
+			allocations: []Allocation{{Cluster: "site1", PodsCIDR: "fd00:10:233::/64"}},
+			network:     kubekeyapiv1alpha2.NetworkConfig{IPAM: kubekeyapiv1alpha2.IPAMConfig{PodsRange: "fd00:10:233::/48", PodsPrefixLength: 64}},
+			wantPods:    "fd00:10:233:1::/64",
+		},
+		{
+			name:        "the CIDR set in the config is recorded",
+			allocations: []Allocation{site1},
+			network:     kubekeyapiv1alpha2.NetworkConfig{KubeServiceCIDR: "10.96.16.0/20", IPAM: kubekeyapiv1alpha2.IPAMConfig{PodsRange: "10.128.0.0/16"}},
+			wantPods:    "10.128.64.0/18",
+			wantService: "10.96.16.0/20",
+		},
+		{
+			name:        "the CIDR set in the config overlaps with another cluster",
+			allocations: []Allocation{site1},
+			network:     kubekeyapiv1alpha2.NetworkConfig{KubePodsCIDR: "10.128.0.0/17", IPAM: ipam},
+			wantErr:     true,
+		},
+		{
+			name:        "the range is exhausted",
+			allocations: []Allocation{site1},
+			network:     kubekeyapiv1alpha2.NetworkConfig{IPAM: kubekeyapiv1alpha2.IPAMConfig{PodsRange: "10.128.0.0/18"}},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Store{Allocations: append([]Allocation{}, tt.allocations...)}
+			spec := &kubekeyapiv1alpha2.ClusterSpec{Hosts: tt.hosts, Network: tt.network}
+			err := s.Assign("site2", spec, time.Now())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Assign() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if spec.Network.KubePodsCIDR != tt.wantPods || spec.Network.KubeServiceCIDR != tt.wantService {
+				t.Errorf("Assign() = %s, %s, want %s, %s", spec.Network.KubePodsCIDR, spec.Network.KubeServiceCIDR, tt.wantPods, tt.wantService)
+			}
+			if a, ok := s.Get("site2"); !ok || a.PodsCIDR != tt.wantPods || a.ServiceCIDR != tt.wantService {
+				t.Errorf("the allocation = %+v, want %s, %s", a, tt.wantPods, tt.wantService)
+			}
+		})
+	}
+}
+
+func TestStore_SaveRelease(t *testing.T) {
+	file := filepath.Join(t.TempDir(), File)
+	s, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Allocations = []Allocation{{Cluster: "site2", PodsCIDR: "fd00:10:233::/64"}, {Cluster: "site1", PodsCIDR: "10.128.0.0/18"}}
+	if err := s.Save(file); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Allocations) != 2 || loaded.Allocations[0].Cluster != "site1" {
+		t.Fatalf("Load() = %+v, want the allocations sorted by the cluster", loaded.Allocations)
+	}
+	if !loaded.Release("site1") || loaded.Release("site3") {
+		t.Error("Release() released a wrong cluster")
+	}
+	if _, ok := loaded.Get("site1"); ok {
+		t.Error("the released cluster is still allocated")
+	}
+}
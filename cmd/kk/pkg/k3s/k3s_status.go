@@ -111,7 +111,7 @@ func (k *K3sStatus) SearchNodesInfo(_ connector.Runtime) error {
 }
 
 func (k *K3sStatus) LoadKubeConfig(runtime connector.Runtime, kubeConf *common.KubeConf) error {
-	kubeConfigPath := filepath.Join(runtime.GetWorkDir(), fmt.Sprintf("config-%s", runtime.GetObjName()))
+	kubeConfigPath := filepath.Join(runtime.GetClusterWorkDir(), fmt.Sprintf("config-%s", runtime.GetObjName()))
 
 	oldServer := "server: https://127.0.0.1:6443"
 	newServer := fmt.Sprintf("server: https://%s:%d", kubeConf.Cluster.ControlPlaneEndpoint.Address, kubeConf.Cluster.ControlPlaneEndpoint.Port)
@@ -24,6 +24,7 @@ import (
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/prepare"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/k3s/templates"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/topology"
 )
 
 type StatusModule struct {
@@ -38,11 +39,12 @@ func (s *StatusModule) Init() {
 	s.PipelineCache.GetOrSet(common.ClusterStatus, cluster)
 
 	clusterStatus := &task.RemoteTask{
-		Name:     "GetClusterStatus",
-		Desc:     "Get k3s cluster status",
-		Hosts:    s.Runtime.GetHostsByRole(common.Master),
-		Action:   new(GetClusterStatus),
-		Parallel: false,
+		Name:      "GetClusterStatus",
+		Desc:      "Get k3s cluster status",
+		Hosts:     s.Runtime.GetHostsByRole(common.Master),
+		Action:    new(GetClusterStatus),
+		AlwaysRun: true,
+		Parallel:  false,
 	}
 
 	s.Tasks = []task.Interface{
@@ -61,7 +63,8 @@ func (i *InstallKubeBinariesModule) Init() {
 	syncBinary := &task.RemoteTask{
 		Name:     "SyncKubeBinary",
 		Desc:     "Synchronize k3s binaries",
-		Hosts:    i.Runtime.GetHostsByRole(common.K8s),
+		Hosts:    topology.SortHosts(i.KubeConf.Cluster, i.Runtime.GetHostsByRole(common.K8s)),
+		Throttle: topology.Throttle(i.KubeConf.Cluster),
 		Prepare:  &NodeInCluster{Not: true},
 		Action:   new(SyncKubeBinary),
 		Parallel: true,
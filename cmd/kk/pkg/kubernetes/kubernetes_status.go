@@ -22,13 +22,24 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstraptoken"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
 )
 
+// certificateKeyTTL is how long the certs uploaded by kubeadm to the kubeadm-certs secret are kept, and
+// certificateKeyRenewBefore is how long before that the certs are uploaded again for the control-plane nodes
+// joining later, e.g. in the later batches of a rolling join.
+const (
+	certificateKeyTTL         = 2 * time.Hour
+	certificateKeyRenewBefore = 15 * time.Minute
+)
+
 type KubernetesStatus struct {
 	Version        string
 	BootstrapToken string
@@ -36,6 +47,11 @@ type KubernetesStatus struct {
 	ClusterInfo    string
 	KubeConfig     string
 	NodesInfo      map[string]string
+
+	// CertsUploadedAt is when the certs encrypted by the CertificateKey were uploaded.
+	CertsUploadedAt time.Time
+	// JoinTokens are the IDs of the bootstrap tokens created to join the nodes, they are revoked once the nodes joined.
+	JoinTokens []string
 }
 
 func NewKubernetesStatus() *KubernetesStatus {
@@ -65,27 +81,117 @@ func (k *KubernetesStatus) SearchJoinInfo(runtime connector.Runtime) error {
 		return nil
 	}
 
+	if err := k.uploadCerts(runtime); err != nil {
+		return err
+	}
+
+	tokenCreateMasterCmd := bootstraptoken.CreateCommand(bootstraptoken.CreateOptions{
+		TTL:         24 * time.Hour,
+		Usages:      bootstraptoken.DefaultUsages,
+		Groups:      bootstraptoken.DefaultGroups,
+		Description: bootstraptoken.JoinTokenDescription,
+	})
+	token, err := runtime.GetRunner().SudoCmd(tokenCreateMasterCmd, true)
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "Failed to get join node cmd")
+	}
+
+	reg := regexp.MustCompile("[0-9|a-z]{6}.[0-9|a-z]{16}")
+	k.BootstrapToken = reg.FindAllString(token, -1)[0]
+	k.JoinTokens = append(k.JoinTokens, strings.Split(k.BootstrapToken, ".")[0])
+	return nil
+}
+
+// uploadCerts uploads the control-plane certs to the kubeadm-certs secret, encrypted by a new certificate key.
+// The output isn't printed, it contains the key.
+func (k *KubernetesStatus) uploadCerts(runtime connector.Runtime) error {
 	uploadCertsCmd := "/usr/local/bin/kubeadm init phase upload-certs --upload-certs --config /etc/kubernetes/kubeadm-config.yaml"
-	output, err := runtime.GetRunner().SudoCmd(uploadCertsCmd, true)
+	output, err := runtime.GetRunner().SudoCmd(uploadCertsCmd, false)
 	if err != nil {
 		return errors.Wrap(errors.WithStack(err), "Failed to upload kubeadm certs")
 	}
 	reg := regexp.MustCompile("[0-9|a-z]{64}")
-	k.CertificateKey = reg.FindAllString(output, -1)[0]
+	keys := reg.FindAllString(output, -1)
+	if len(keys) == 0 {
+		return errors.New("Failed to find the certificate key in the output of kubeadm upload-certs")
+	}
+	k.CertificateKey = keys[0]
+	k.CertsUploadedAt = time.Now()
 
-	if err := patchKubeadmSecret(runtime); err != nil {
-		return err
+	return patchKubeadmSecret(runtime)
+}
+
+// certificateKeyExpiring returns whether the uploaded certs are deleted within certificateKeyRenewBefore.
+func (k *KubernetesStatus) certificateKeyExpiring(now time.Time) bool {
+	return now.After(k.CertsUploadedAt.Add(certificateKeyTTL - certificateKeyRenewBefore))
+}
+
+// expiredJoinCredentials are the errors of kubeadm join when the bootstrap token, or the
+// certificate key of the kubeadm-certs secret, has expired.
+var expiredJoinCredentials = []string{
+	"could not find a JWS signature",
+	"is invalid for this cluster or it has expired",
+	"might have expired",
+	"message authentication failed",
+}
+
+func joinCredentialsExpired(output string) bool {
+	for _, msg := range expiredJoinCredentials {
+		if strings.Contains(output, msg) {
+			return true
+		}
 	}
+	return false
+}
 
-	tokenCreateMasterCmd := "/usr/local/bin/kubeadm token create"
-	token, err := runtime.GetRunner().SudoCmd(tokenCreateMasterCmd, true)
+var refreshJoinInfoLock sync.Mutex
+
+// RefreshJoinInfo regenerates the bootstrap token and the certificate key on the control-plane node master.
+// The nodes joining in parallel share the refresh: it is skipped if the token isn't the expired one anymore.
+func (k *KubernetesStatus) RefreshJoinInfo(runtime connector.Runtime, master connector.Host, expired string) error {
+	refreshJoinInfoLock.Lock()
+	defer refreshJoinInfoLock.Unlock()
+
+	if k.BootstrapToken != expired {
+		return nil
+	}
+
+	conn, err := runtime.GetConnector().Connect(master)
 	if err != nil {
-		return errors.Wrap(errors.WithStack(err), "Failed to get join node cmd")
+		return errors.Wrapf(err, "failed to connect to %s", master.GetAddress())
 	}
+	masterRuntime := runtime.Copy()
+	masterRuntime.SetRunner(&connector.Runner{Conn: conn, Host: master})
 
-	reg = regexp.MustCompile("[0-9|a-z]{6}.[0-9|a-z]{16}")
-	k.BootstrapToken = reg.FindAllString(token, -1)[0]
-	return nil
+	k.BootstrapToken, k.CertificateKey = "", ""
+	return k.SearchJoinInfo(masterRuntime)
+}
+
+// RenewCertificateKey uploads the certs again on the control-plane node master, if they are deleted soon. The
+// control-plane nodes joining in parallel share the upload.
+func (k *KubernetesStatus) RenewCertificateKey(runtime connector.Runtime, master connector.Host) error {
+	refreshJoinInfoLock.Lock()
+	defer refreshJoinInfoLock.Unlock()
+
+	if !k.certificateKeyExpiring(time.Now()) {
+		return nil
+	}
+
+	conn, err := runtime.GetConnector().Connect(master)
+	if err != nil {
+		return errors.Wrapf(err, "failed to connect to %s", master.GetAddress())
+	}
+	masterRuntime := runtime.Copy()
+	masterRuntime.SetRunner(&connector.Runner{Conn: conn, Host: master})
+
+	return k.uploadCerts(masterRuntime)
+}
+
+// JoinInfo returns the bootstrap token and the certificate key.
+func (k *KubernetesStatus) JoinInfo() (string, string) {
+	refreshJoinInfoLock.Lock()
+	defer refreshJoinInfoLock.Unlock()
+	return k.BootstrapToken, k.CertificateKey
 }
 
 func (k *KubernetesStatus) SearchClusterInfo(runtime connector.Runtime) error {
@@ -138,7 +244,7 @@ func (k *KubernetesStatus) SearchKubeConfig(runtime connector.Runtime) error {
 }
 
 func (k *KubernetesStatus) LoadKubeConfig(runtime connector.Runtime, kubeConf *common.KubeConf) error {
-	kubeConfigPath := filepath.Join(runtime.GetWorkDir(), fmt.Sprintf("config-%s", runtime.GetObjName()))
+	kubeConfigPath := filepath.Join(runtime.GetClusterWorkDir(), fmt.Sprintf("config-%s", runtime.GetObjName()))
 	kubeConfigStr := k.KubeConfig
 
 	oldServer := fmt.Sprintf("server: https://%s:%d", kubeConf.Cluster.ControlPlaneEndpoint.Domain, kubeConf.Cluster.ControlPlaneEndpoint.Port)
@@ -0,0 +1,91 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_joinCredentialsExpired(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   bool
+	}{
+		{
+			name:   "token expired",
+			output: `error execution phase preflight: couldn't validate the identity of the API Server: could not find a JWS signature in the cluster-info ConfigMap for token ID "abcdef"`,
+			want:   true,
+		},
+		{
+			name:   "certificate key expired",
+			output: "error execution phase control-plane-prepare/download-certs: error downloading certs: error downloading the secret: Secret \"kubeadm-certs\" was not found in the \"kube-system\" Namespace. This Secret might have expired.",
+			want:   true,
+		},
+		{
+			name:   "wrong certificate key",
+			output: "error execution phase control-plane-prepare/download-certs: error downloading certs: error decoding secret data with provided key: cipher: message authentication failed",
+			want:   true,
+		},
+		{
+			name:   "port in use",
+			output: "[ERROR Port-10250]: Port 10250 is in use",
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := joinCredentialsExpired(tt.output); got != tt.want {
+				t.Errorf("joinCredentialsExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKubernetesStatus_certificateKeyExpiring(t *testing.T) {
+	uploaded := time.Date(2023, 3, 1, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{
+			name: "just uploaded",
+			now:  uploaded.Add(time.Minute),
+			want: false,
+		},
+		{
+			name: "deleted soon",
+			now:  uploaded.Add(time.Hour + 50*time.Minute),
+			want: true,
+		},
+		{
+			name: "deleted",
+			now:  uploaded.Add(3 * time.Hour),
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &KubernetesStatus{CertsUploadedAt: uploaded}
+			if got := k.certificateKeyExpiring(tt.now); got != tt.want {
+				t.Errorf("certificateKeyExpiring() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"path/filepath"
 
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/plugins/dns"
 
@@ -30,9 +31,12 @@ import (
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/prepare"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/facts"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/health"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/images"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/kubernetes/templates"
 	dnsTemplates "github.com/kubesphere/kubekey/v3/cmd/kk/pkg/plugins/dns/templates"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/topology"
 )
 
 type StatusModule struct {
@@ -41,6 +45,7 @@ type StatusModule struct {
 
 func (k *StatusModule) Init() {
 	k.Name = "KubernetesStatusModule"
+	k.Tags = []string{connector.AlwaysTag}
 	k.Desc = "Get kubernetes cluster status"
 
 	cluster := NewKubernetesStatus()
@@ -51,8 +56,9 @@ func (k *StatusModule) Init() {
 		Desc:  "Get kubernetes cluster status",
 		Hosts: k.Runtime.GetHostsByRole(common.Master),
 		//Prepare:  new(NoClusterInfo),
-		Action:   new(GetClusterStatus),
-		Parallel: false,
+		Action:    new(GetClusterStatus),
+		AlwaysRun: true,
+		Parallel:  false,
 	}
 
 	k.Tasks = []task.Interface{
@@ -66,33 +72,44 @@ type InstallKubeBinariesModule struct {
 
 func (i *InstallKubeBinariesModule) Init() {
 	i.Name = "InstallKubeBinariesModule"
+	i.Tags = []string{"kubernetes"}
 	i.Desc = "Install kubernetes cluster"
 
 	syncBinary := &task.RemoteTask{
 		Name:     "SyncKubeBinary",
 		Desc:     "Synchronize kubernetes binaries",
-		Hosts:    i.Runtime.GetHostsByRole(common.K8s),
-		Prepare:  &NodeInCluster{Not: true},
+		Hosts:    topology.SortHosts(i.KubeConf.Cluster, i.Runtime.GetHostsByRole(common.K8s)),
+		Throttle: topology.Throttle(i.KubeConf.Cluster),
+		Prepare: &prepare.PrepareCollection{
+			&NodeInCluster{Not: true},
+			&facts.NotBaked{Step: facts.BakedKubernetes},
+		},
 		Action:   new(SyncKubeBinary),
 		Parallel: true,
 		Retry:    2,
 	}
 
 	chmodKubelet := &task.RemoteTask{
-		Name:     "ChmodKubelet",
-		Desc:     "Change kubelet mode",
-		Hosts:    i.Runtime.GetHostsByRole(common.K8s),
-		Prepare:  &NodeInCluster{Not: true},
+		Name:  "ChmodKubelet",
+		Desc:  "Change kubelet mode",
+		Hosts: i.Runtime.GetHostsByRole(common.K8s),
+		Prepare: &prepare.PrepareCollection{
+			&NodeInCluster{Not: true},
+			&facts.NotBaked{Step: facts.BakedKubernetes},
+		},
 		Action:   new(ChmodKubelet),
 		Parallel: true,
 		Retry:    2,
 	}
 
 	generateKubeletService := &task.RemoteTask{
-		Name:    "GenerateKubeletService",
-		Desc:    "Generate kubelet service",
-		Hosts:   i.Runtime.GetHostsByRole(common.K8s),
-		Prepare: &NodeInCluster{Not: true},
+		Name:  "GenerateKubeletService",
+		Desc:  "Generate kubelet service",
+		Hosts: i.Runtime.GetHostsByRole(common.K8s),
+		Prepare: &prepare.PrepareCollection{
+			&NodeInCluster{Not: true},
+			&facts.NotBaked{Step: facts.BakedKubernetes},
+		},
 		Action: &action.Template{
 			Template: templates.KubeletService,
 			Dst:      filepath.Join("/etc/systemd/system/", templates.KubeletService.Name()),
@@ -102,10 +119,13 @@ func (i *InstallKubeBinariesModule) Init() {
 	}
 
 	enableKubelet := &task.RemoteTask{
-		Name:     "EnableKubelet",
-		Desc:     "Enable kubelet service",
-		Hosts:    i.Runtime.GetHostsByRole(common.K8s),
-		Prepare:  &NodeInCluster{Not: true},
+		Name:  "EnableKubelet",
+		Desc:  "Enable kubelet service",
+		Hosts: i.Runtime.GetHostsByRole(common.K8s),
+		Prepare: &prepare.PrepareCollection{
+			&NodeInCluster{Not: true},
+			&facts.NotBaked{Step: facts.BakedKubernetes},
+		},
 		Action:   new(EnableKubelet),
 		Parallel: true,
 		Retry:    5,
@@ -136,6 +156,7 @@ type InitKubernetesModule struct {
 
 func (i *InitKubernetesModule) Init() {
 	i.Name = "InitKubernetesModule"
+	i.Tags = []string{"kubernetes"}
 	i.Desc = "Init kubernetes cluster"
 
 	generateKubeadmConfig := &task.RemoteTask{
@@ -196,6 +217,7 @@ func (i *InitKubernetesModule) Init() {
 			&ClusterIsExist{Not: true},
 		},
 		Action:   new(KubeadmInit),
+		Creates:  "/etc/kubernetes/admin.conf",
 		Retry:    3,
 		Parallel: true,
 	}
@@ -212,6 +234,20 @@ func (i *InitKubernetesModule) Init() {
 		Parallel: true,
 	}
 
+	waitControlPlane := &task.RemoteTask{
+		Name:  "WaitForControlPlane",
+		Desc:  "Wait for the control plane to be healthy",
+		Hosts: i.Runtime.GetHostsByRole(common.Master),
+		Prepare: &prepare.PrepareCollection{
+			new(common.OnlyFirstMaster),
+			&ClusterIsExist{Not: true},
+		},
+		Action: &health.WaitHealthy{
+			Checks: []health.Check{health.Apiserver, health.Scheduler, health.ControllerManager},
+		},
+		Parallel: true,
+	}
+
 	removeMasterTaint := &task.RemoteTask{
 		Name:  "RemoveMasterTaint",
 		Desc:  "Remove master taint",
@@ -232,6 +268,7 @@ func (i *InitKubernetesModule) Init() {
 		generateAuditWebhook,
 		kubeadmInit,
 		copyKubeConfig,
+		waitControlPlane,
 		removeMasterTaint,
 	}
 }
@@ -242,6 +279,7 @@ type JoinNodesModule struct {
 
 func (j *JoinNodesModule) Init() {
 	j.Name = "JoinNodesModule"
+	j.Tags = []string{"kubernetes"}
 	j.Desc = "Join kubernetes nodes"
 
 	j.PipelineCache.Set(common.ClusterExist, true)
@@ -317,6 +355,17 @@ func (j *JoinNodesModule) Init() {
 		Retry:    5,
 	}
 
+	waitNodes := &task.RemoteTask{
+		Name:  "WaitForJoinedNodes",
+		Desc:  "Wait for the joined nodes to be healthy",
+		Hosts: j.Runtime.GetHostsByRole(common.K8s),
+		Prepare: &prepare.PrepareCollection{
+			&NodeInCluster{Not: true},
+		},
+		Action:   &health.WaitHealthy{Checks: []health.Check{health.Kubelet}},
+		Parallel: true,
+	}
+
 	copyKubeConfig := &task.RemoteTask{
 		Name:  "copyKubeConfig",
 		Desc:  "Copy admin.conf to ~/.kube/config",
@@ -351,15 +400,26 @@ func (j *JoinNodesModule) Init() {
 		Retry:   3,
 	}
 
+	revokeJoinTokens := &task.RemoteTask{
+		Name:    "RevokeJoinTokens",
+		Desc:    "Revoke the bootstrap tokens created to join the nodes",
+		Hosts:   j.Runtime.GetHostsByRole(common.Master),
+		Prepare: new(common.OnlyFirstMaster),
+		Action:  new(RevokeJoinTokens),
+		Retry:   3,
+	}
+
 	j.Tasks = []task.Interface{
 		generateKubeadmConfig,
 		generateAuditPolicy,
 		generateAuditWebhook,
 		joinMasterNode,
 		joinWorkerNode,
+		waitNodes,
 		copyKubeConfig,
 		removeMasterTaint,
 		addWorkerLabelToNode,
+		revokeJoinTokens,
 	}
 }
 
@@ -398,7 +458,8 @@ func (c *CompareConfigAndClusterInfoModule) Init() {
 		Hosts:   c.Runtime.GetHostsByRole(common.Master),
 		Prepare: new(common.OnlyFirstMaster),
 		//Action:  new(FindNode),
-		Action: new(FilterFirstMaster),
+		Action:    new(FilterFirstMaster),
+		AlwaysRun: true,
 	}
 
 	c.Tasks = []task.Interface{
@@ -468,9 +529,10 @@ func (s *SetUpgradePlanModule) Init() {
 	s.Desc = "Set upgrade plan"
 
 	plan := &task.LocalTask{
-		Name:   "SetUpgradePlan",
-		Desc:   "Set upgrade plan",
-		Action: &SetUpgradePlan{Step: s.Step},
+		Name:        "SetUpgradePlan",
+		Desc:        "Set upgrade plan",
+		Action:      &SetUpgradePlan{Step: s.Step},
+		RunInDryRun: true,
 	}
 
 	generateKubeadmConfigInit := &task.RemoteTask{
@@ -503,18 +565,20 @@ func (p *ProgressiveUpgradeModule) Init() {
 	p.Desc = fmt.Sprintf("Progressive upgrade %d/%d", p.Step, len(UpgradeStepList))
 
 	nextVersion := &task.LocalTask{
-		Name:    "CalculateNextVersion",
-		Desc:    "Calculate next upgrade version",
-		Prepare: new(NotEqualPlanVersion),
-		Action:  new(CalculateNextVersion),
+		Name:        "CalculateNextVersion",
+		Desc:        "Calculate next upgrade version",
+		Prepare:     new(NotEqualPlanVersion),
+		Action:      new(CalculateNextVersion),
+		RunInDryRun: true,
 	}
 
 	// prepare
 	download := &task.LocalTask{
-		Name:    "DownloadBinaries",
-		Desc:    "Download installation binaries",
-		Prepare: new(NotEqualPlanVersion),
-		Action:  new(binaries.Download),
+		Name:        "DownloadBinaries",
+		Desc:        "Download installation binaries",
+		Prepare:     new(NotEqualPlanVersion),
+		Action:      new(binaries.Download),
+		RunInDryRun: true,
 	}
 
 	pull := &task.RemoteTask{
@@ -550,12 +614,13 @@ func (p *ProgressiveUpgradeModule) Init() {
 	p.PipelineCache.GetOrSet(common.ClusterStatus, cluster)
 
 	clusterStatus := &task.RemoteTask{
-		Name:     "GetClusterStatus",
-		Desc:     "Get kubernetes cluster status",
-		Hosts:    p.Runtime.GetHostsByRole(common.Master),
-		Prepare:  new(NotEqualPlanVersion),
-		Action:   new(GetClusterStatus),
-		Parallel: false,
+		Name:      "GetClusterStatus",
+		Desc:      "Get kubernetes cluster status",
+		Hosts:     p.Runtime.GetHostsByRole(common.Master),
+		Prepare:   new(NotEqualPlanVersion),
+		Action:    new(GetClusterStatus),
+		AlwaysRun: true,
+		Parallel:  false,
 	}
 
 	generateCoreDNS := &task.RemoteTask{
@@ -628,10 +693,11 @@ func (p *ProgressiveUpgradeModule) Init() {
 	}
 
 	currentVersion := &task.LocalTask{
-		Name:    "SetCurrentK8sVersion",
-		Desc:    "Set current k8s version",
-		Prepare: new(NotEqualPlanVersion),
-		Action:  new(SetCurrentK8sVersion),
+		Name:        "SetCurrentK8sVersion",
+		Desc:        "Set current k8s version",
+		Prepare:     new(NotEqualPlanVersion),
+		Action:      new(SetCurrentK8sVersion),
+		RunInDryRun: true,
 	}
 
 	p.Tasks = []task.Interface{
@@ -700,6 +766,7 @@ type ConfigureKubernetesModule struct {
 
 func (c *ConfigureKubernetesModule) Init() {
 	c.Name = "ConfigureKubernetesModule"
+	c.Tags = []string{"kubernetes"}
 	c.Desc = "Configure kubernetes"
 
 	configure := &task.RemoteTask{
@@ -24,7 +24,6 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
-	"time"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
@@ -35,6 +34,7 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 
 	kubekeyv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstraptoken"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
@@ -43,6 +43,7 @@ import (
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/etcd"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/files"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/health"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/images"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/kubernetes/templates"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/utils"
@@ -207,10 +208,21 @@ type GenerateKubeadmConfig struct {
 	WithSecurityEnhancement bool
 }
 
+// registerTaints returns the taints the worker is registered with when it joins, so no pod is scheduled to it before
+// it's tainted. The control-plane nodes are tainted after they join, since their taints replace the default ones of
+// kubeadm.
+func registerTaints(host connector.Host) []kubekeyv1alpha2.Taint {
+	kubeHost, ok := host.(*kubekeyv1alpha2.KubeHost)
+	if !ok || host.IsRole(common.Master) {
+		return nil
+	}
+	return kubeHost.Taints
+}
+
 func (g *GenerateKubeadmConfig) Execute(runtime connector.Runtime) error {
 	host := runtime.RemoteHost()
 
-	localConfig := filepath.Join(runtime.GetWorkDir(), "kubeadm-config.yaml")
+	localConfig := filepath.Join(runtime.GetClusterWorkDir(), "kubeadm-config.yaml")
 	if util.IsExist(localConfig) {
 		// todo: if it is necessary?
 		if err := runtime.GetRunner().SudoScp(localConfig, "/etc/kubernetes/kubeadm-config.yaml"); err != nil {
@@ -248,11 +260,12 @@ func (g *GenerateKubeadmConfig) Execute(runtime connector.Runtime) error {
 			}
 		}
 
-		_, ApiServerArgs := util.GetArgs(templates.GetApiServerArgs(g.WithSecurityEnhancement, g.KubeConf.Cluster.Kubernetes.EnableAudit()), g.KubeConf.Cluster.Kubernetes.ApiServerArgs)
-		_, ControllerManagerArgs := util.GetArgs(templates.GetControllermanagerArgs(g.KubeConf.Cluster.Kubernetes.Version, g.WithSecurityEnhancement), g.KubeConf.Cluster.Kubernetes.ControllerManagerArgs)
-		_, SchedulerArgs := util.GetArgs(templates.GetSchedulerArgs(g.WithSecurityEnhancement), g.KubeConf.Cluster.Kubernetes.SchedulerArgs)
+		ipv6Only := g.KubeConf.Cluster.Network.IPv6Only()
+		_, ApiServerArgs := util.GetArgs(templates.WithIPv6BindAddress(templates.WithTLSPolicyArgs(templates.GetApiServerArgs(g.WithSecurityEnhancement, g.KubeConf.Cluster.Kubernetes.EnableAudit()), g.KubeConf.Cluster.TLS.Apiserver), ipv6Only), g.KubeConf.Cluster.Kubernetes.ApiServerArgs)
+		_, ControllerManagerArgs := util.GetArgs(templates.WithIPv6BindAddress(templates.GetControllermanagerArgs(g.KubeConf.Cluster.Kubernetes.Version, g.WithSecurityEnhancement), ipv6Only), g.KubeConf.Cluster.Kubernetes.ControllerManagerArgs)
+		_, SchedulerArgs := util.GetArgs(templates.WithIPv6BindAddress(templates.GetSchedulerArgs(g.WithSecurityEnhancement), ipv6Only), g.KubeConf.Cluster.Kubernetes.SchedulerArgs)
 
-		checkCgroupDriver, err := templates.GetKubeletCgroupDriver(runtime, g.KubeConf)
+		checkCgroupDriver, err := templates.GetKubeletCgroupDriver(runtime, g.KubeConf, common.CgroupDriverOf(g.PipelineCache))
 		if err != nil {
 			return err
 		}
@@ -264,60 +277,113 @@ func (g *GenerateKubeadmConfig) Execute(runtime connector.Runtime) error {
 		if !g.IsInitConfiguration {
 			if v, ok := g.PipelineCache.Get(common.ClusterStatus); ok {
 				cluster := v.(*KubernetesStatus)
-				bootstrapToken = cluster.BootstrapToken
-				certificateKey = cluster.CertificateKey
+				bootstrapToken, certificateKey = cluster.JoinInfo()
 			} else {
 				return errors.New("get kubernetes cluster status by pipeline cache failed")
 			}
 		}
 
-		templateAction := action.Template{
-			Template: templates.KubeadmConfig,
-			Dst:      filepath.Join(common.KubeConfigDir, templates.KubeadmConfig.Name()),
-			Data: util.Data{
-				"IsInitCluster":          g.IsInitConfiguration,
-				"ImageRepo":              strings.TrimSuffix(images.GetImage(runtime, g.KubeConf, "kube-apiserver").ImageRepo(), "/kube-apiserver"),
-				"EtcdTypeIsKubeadm":      g.KubeConf.Cluster.Etcd.Type == kubekeyv1alpha2.Kubeadm,
-				"EtcdCertSANs":           etcdCertSANs,
-				"EtcdRepo":               strings.TrimSuffix(images.GetImage(runtime, g.KubeConf, "etcd").ImageRepo(), "/etcd"),
-				"EtcdTag":                images.GetImage(runtime, g.KubeConf, "etcd").Tag,
-				"CorednsRepo":            strings.TrimSuffix(images.GetImage(runtime, g.KubeConf, "coredns").ImageRepo(), "/coredns"),
-				"CorednsTag":             images.GetImage(runtime, g.KubeConf, "coredns").Tag,
-				"Version":                g.KubeConf.Cluster.Kubernetes.Version,
-				"ClusterName":            g.KubeConf.Cluster.Kubernetes.ClusterName,
-				"DNSDomain":              g.KubeConf.Cluster.Kubernetes.DNSDomain,
-				"AdvertiseAddress":       host.GetInternalIPv4Address(),
-				"BindPort":               kubekeyv1alpha2.DefaultApiserverPort,
-				"ControlPlaneEndpoint":   fmt.Sprintf("%s:%d", g.KubeConf.Cluster.ControlPlaneEndpoint.Domain, g.KubeConf.Cluster.ControlPlaneEndpoint.Port),
-				"PodSubnet":              g.KubeConf.Cluster.Network.KubePodsCIDR,
-				"ServiceSubnet":          g.KubeConf.Cluster.Network.KubeServiceCIDR,
-				"CertSANs":               g.KubeConf.Cluster.GenerateCertSANs(),
-				"ExternalEtcd":           externalEtcd,
-				"NodeCidrMaskSize":       g.KubeConf.Cluster.Kubernetes.NodeCidrMaskSize,
-				"CriSock":                g.KubeConf.Cluster.Kubernetes.ContainerRuntimeEndpoint,
-				"ApiServerArgs":          templates.UpdateFeatureGatesConfiguration(ApiServerArgs, g.KubeConf),
-				"EnableAudit":            g.KubeConf.Cluster.Kubernetes.EnableAudit(),
-				"ControllerManagerArgs":  templates.UpdateFeatureGatesConfiguration(ControllerManagerArgs, g.KubeConf),
-				"SchedulerArgs":          templates.UpdateFeatureGatesConfiguration(SchedulerArgs, g.KubeConf),
-				"KubeletConfiguration":   templates.GetKubeletConfiguration(runtime, g.KubeConf, g.KubeConf.Cluster.Kubernetes.ContainerRuntimeEndpoint, g.WithSecurityEnhancement),
-				"KubeProxyConfiguration": templates.GetKubeProxyConfiguration(g.KubeConf),
-				"IsV1beta3":              versionutil.MustParseSemantic(g.KubeConf.Cluster.Kubernetes.Version).AtLeast(versionutil.MustParseSemantic("v1.22.0")),
-				"IsControlPlane":         host.IsRole(common.Master),
-				"CgroupDriver":           checkCgroupDriver,
-				"BootstrapToken":         bootstrapToken,
-				"CertificateKey":         certificateKey,
-				"IPv6Support":            host.GetInternalIPv6Address() != "",
-			},
+		var patchesDir string
+		if len(g.KubeConf.Cluster.Kubernetes.KubeadmPatches) != 0 {
+			patchesDir = kubekeyv1alpha2.KubeadmPatchesDir
 		}
 
-		templateAction.Init(nil, nil)
-		if err := templateAction.Execute(runtime); err != nil {
+		content, err := util.RenderWith(templates.KubeadmConfig, util.Data{
+			"IsInitCluster":          g.IsInitConfiguration,
+			"ImageRepo":              strings.TrimSuffix(images.GetImage(runtime, g.KubeConf, "kube-apiserver").ImageRepo(), "/kube-apiserver"),
+			"EtcdTypeIsKubeadm":      g.KubeConf.Cluster.Etcd.Type == kubekeyv1alpha2.Kubeadm,
+			"EtcdCertSANs":           etcdCertSANs,
+			"EtcdRepo":               strings.TrimSuffix(images.GetImage(runtime, g.KubeConf, "etcd").ImageRepo(), "/etcd"),
+			"EtcdTag":                images.GetImage(runtime, g.KubeConf, "etcd").Tag,
+			"EtcdArgs":               templates.GetEtcdArgs(g.KubeConf.Cluster.TLS.Etcd),
+			"CorednsRepo":            strings.TrimSuffix(images.GetImage(runtime, g.KubeConf, "coredns").ImageRepo(), "/coredns"),
+			"CorednsTag":             images.GetImage(runtime, g.KubeConf, "coredns").Tag,
+			"Version":                g.KubeConf.Cluster.Kubernetes.Version,
+			"ClusterName":            g.KubeConf.Cluster.Kubernetes.ClusterName,
+			"DNSDomain":              g.KubeConf.Cluster.Kubernetes.DNSDomain,
+			"AdvertiseAddress":       host.GetInternalIPv4Address(),
+			"BindPort":               kubekeyv1alpha2.DefaultApiserverPort,
+			"ControlPlaneEndpoint":   fmt.Sprintf("%s:%d", g.KubeConf.Cluster.ControlPlaneEndpoint.Domain, g.KubeConf.Cluster.ControlPlaneEndpoint.Port),
+			"PodSubnet":              g.KubeConf.Cluster.Network.KubePodsCIDR,
+			"ServiceSubnet":          g.KubeConf.Cluster.Network.KubeServiceCIDR,
+			"CertSANs":               g.KubeConf.Cluster.GenerateCertSANs(),
+			"ExternalEtcd":           externalEtcd,
+			"NodeCidrMaskSize":       g.KubeConf.Cluster.Kubernetes.NodeCidrMaskSize,
+			"CriSock":                g.KubeConf.Cluster.Kubernetes.ContainerRuntimeEndpoint,
+			"ApiServerArgs":          templates.UpdateFeatureGatesConfiguration(ApiServerArgs, g.KubeConf),
+			"EnableAudit":            g.KubeConf.Cluster.Kubernetes.EnableAudit(),
+			"ControllerManagerArgs":  templates.UpdateFeatureGatesConfiguration(ControllerManagerArgs, g.KubeConf),
+			"SchedulerArgs":          templates.UpdateFeatureGatesConfiguration(SchedulerArgs, g.KubeConf),
+			"KubeletConfiguration":   templates.GetKubeletConfiguration(runtime, g.KubeConf, g.KubeConf.Cluster.Kubernetes.ContainerRuntimeEndpoint, checkCgroupDriver, g.WithSecurityEnhancement),
+			"KubeProxyConfiguration": templates.GetKubeProxyConfiguration(g.KubeConf),
+			"IsV1beta3":              versionutil.MustParseSemantic(g.KubeConf.Cluster.Kubernetes.Version).AtLeast(versionutil.MustParseSemantic("v1.22.0")),
+			"IsControlPlane":         host.IsRole(common.Master),
+			"CgroupDriver":           checkCgroupDriver,
+			"BootstrapToken":         bootstrapToken,
+			"CertificateKey":         certificateKey,
+			"IPv6Support":            g.KubeConf.Cluster.Network.DualStack(),
+			"IPv6Only":               ipv6Only,
+			"PatchesDir":             patchesDir,
+			"Taints":                 registerTaints(host),
+		}, util.RenderOptions{Strict: runtime.GetStrictRender()})
+		if err != nil {
+			return errors.Wrap(errors.WithStack(err), fmt.Sprintf("render template %s failed", templates.KubeadmConfig.Name()))
+		}
+		if g.IsInitConfiguration {
+			if content, err = templates.MergeClusterConfiguration(content, g.KubeConf.Cluster.Kubernetes.ClusterConfiguration.Raw); err != nil {
+				return err
+			}
+		}
+		if err := action.WriteRemoteFile(runtime, templates.KubeadmConfig.Name(), filepath.Join(common.KubeConfigDir, templates.KubeadmConfig.Name()), content); err != nil {
 			return err
 		}
 	}
+
+	if err := writeKubeadmPatches(runtime, g.KubeConf); err != nil {
+		return err
+	}
+
+	// the join config contains the bootstrap token and the certificate key
+	if _, err := runtime.GetRunner().SudoCmd("chmod 600 /etc/kubernetes/kubeadm-config.yaml", false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "chmod kubeadm config failed")
+	}
+	return nil
+}
+
+// writeKubeadmPatches writes the kubeadm patches to the patches dir of the node, and removes the stale patches.
+// The patch files are named <target><index>+<type>.yaml, so kubeadm applies them in the order of the config.
+func writeKubeadmPatches(runtime connector.Runtime, kubeConf *common.KubeConf) error {
+	patches := kubeConf.Cluster.Kubernetes.KubeadmPatches
+	if len(patches) == 0 {
+		return nil
+	}
+
+	findCmd := fmt.Sprintf("find %s -maxdepth 1 -type f", kubekeyv1alpha2.KubeadmPatchesDir)
+	for i, patch := range patches {
+		patchType := patch.Type
+		if patchType == "" {
+			patchType = "strategic"
+		}
+		name := fmt.Sprintf("%s%d+%s.yaml", patch.Target, i, patchType)
+		if err := action.WriteRemoteFile(runtime, "kubeadm-patch-"+name, filepath.Join(kubekeyv1alpha2.KubeadmPatchesDir, name), patch.Patch); err != nil {
+			return errors.Wrapf(err, "write the kubeadm patch %s failed", name)
+		}
+		findCmd += fmt.Sprintf(" ! -name '%s'", name)
+	}
+	if _, err := runtime.GetRunner().SudoCmd(findCmd+" -delete", false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "remove the stale kubeadm patches failed")
+	}
 	return nil
 }
 
+// patchesFlag returns the flag of kubeadm to apply the patches, or an empty string without the patches.
+func patchesFlag(kubeConf *common.KubeConf) string {
+	if len(kubeConf.Cluster.Kubernetes.KubeadmPatches) == 0 {
+		return ""
+	}
+	return " --patches=" + kubekeyv1alpha2.KubeadmPatchesDir
+}
+
 type KubeadmInit struct {
 	common.KubeAction
 }
@@ -421,15 +487,131 @@ type JoinNode struct {
 }
 
 func (j *JoinNode) Execute(runtime connector.Runtime) error {
-	if _, err := runtime.GetRunner().SudoCmd("/usr/local/bin/kubeadm join --config=/etc/kubernetes/kubeadm-config.yaml --ignore-preflight-errors=FileExisting-crictl,ImagePull",
-		true); err != nil {
+	v, ok := j.PipelineCache.Get(common.ClusterStatus)
+	if !ok {
+		return errors.New("get kubernetes cluster status by pipeline cache failed")
+	}
+	cluster := v.(*KubernetesStatus)
+
+	if runtime.RemoteHost().IsRole(common.Master) {
+		// The certs are deleted two hours after they were uploaded, which a long join, e.g. in batches, exceeds.
+		// Upload them again if they are deleted soon, and write the current certificate key to the kubeadm config,
+		// it might have been renewed for another node since the config was generated.
+		master := j.controlPlane(runtime, cluster)
+		if err := cluster.RenewCertificateKey(runtime, master); err != nil {
+			return errors.Wrapf(err, "upload the certs again on %s failed", master.GetName())
+		}
+		if err := j.generateConfig(runtime); err != nil {
+			return err
+		}
+	}
+	token, _ := cluster.JoinInfo()
+
+	output, err := j.join(runtime)
+	if err == nil {
+		return nil
+	}
+	if !joinCredentialsExpired(output + err.Error()) {
+		return errors.Wrap(errors.WithStack(err), "join node failed")
+	}
+
+	// The token or the certificate key expired, e.g. when the nodes are added hours after the cluster status
+	// was gathered, regenerate them on a control-plane node already in the cluster and join again.
+	logger.Log.Messagef(runtime.RemoteHost().GetName(), "the bootstrap token or the certificate key has expired, regenerating them")
+	master := j.controlPlane(runtime, cluster)
+	if err := cluster.RefreshJoinInfo(runtime, master, token); err != nil {
+		return errors.Wrapf(err, "regenerate the bootstrap token and the certificate key on %s failed", master.GetName())
+	}
+
+	if err := j.generateConfig(runtime); err != nil {
+		return err
+	}
+
+	if _, err := j.join(runtime); err != nil {
+		return errors.Wrap(errors.WithStack(err), "join node failed")
+	}
+	return nil
+}
+
+// generateConfig generates the kubeadm config of the node again with the current join info.
+func (j *JoinNode) generateConfig(runtime connector.Runtime) error {
+	generateConfig := &GenerateKubeadmConfig{
+		IsInitConfiguration:     false,
+		WithSecurityEnhancement: j.KubeConf.Arg.SecurityEnhancement,
+	}
+	generateConfig.KubeConf = j.KubeConf
+	generateConfig.Init(j.ModuleCache, j.PipelineCache)
+	if err := generateConfig.Execute(runtime); err != nil {
+		return errors.Wrap(err, "regenerate kubeadm config failed")
+	}
+	return nil
+}
+
+// join runs kubeadm join, the node is reset if it fails.
+func (j *JoinNode) join(runtime connector.Runtime) (string, error) {
+	output, err := runtime.GetRunner().SudoCmd("/usr/local/bin/kubeadm join --config=/etc/kubernetes/kubeadm-config.yaml --ignore-preflight-errors=FileExisting-crictl,ImagePull",
+		true)
+	if err != nil {
 		resetCmd := "/usr/local/bin/kubeadm reset -f"
 		if j.KubeConf.Cluster.Kubernetes.ContainerRuntimeEndpoint != "" {
 			resetCmd = resetCmd + " --cri-socket " + j.KubeConf.Cluster.Kubernetes.ContainerRuntimeEndpoint
 		}
 		_, _ = runtime.GetRunner().SudoCmd(resetCmd, true)
-		return errors.Wrap(errors.WithStack(err), "join node failed")
 	}
+	return output, err
+}
+
+// controlPlane returns the first control-plane node already in the cluster.
+func (j *JoinNode) controlPlane(runtime connector.Runtime, cluster *KubernetesStatus) connector.Host {
+	masters := runtime.GetHostsByRole(common.Master)
+	for _, host := range masters {
+		if version, ok := cluster.NodesInfo[host.GetName()]; ok && version != "" {
+			return host
+		}
+	}
+	return masters[0]
+}
+
+type RevokeJoinTokens struct {
+	common.KubeAction
+}
+
+func (r *RevokeJoinTokens) Execute(runtime connector.Runtime) error {
+	v, ok := r.PipelineCache.Get(common.ClusterStatus)
+	if !ok {
+		return errors.New("get kubernetes cluster status by pipeline cache failed")
+	}
+	cluster := v.(*KubernetesStatus)
+
+	// the expired tokens, e.g. the ones regenerated during the joins, have been deleted by the cluster already
+	output, err := runtime.GetRunner().SudoCmd(bootstraptoken.ListCommand, false)
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "list bootstrap tokens failed")
+	}
+	tokens, err := bootstraptoken.ParseSecrets(output)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, token := range tokens {
+		for _, id := range cluster.JoinTokens {
+			if token.ID == id {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	cmd, err := bootstraptoken.DeleteCommand(ids)
+	if err != nil {
+		return err
+	}
+	if _, err := runtime.GetRunner().SudoCmd(cmd, true); err != nil {
+		return errors.Wrap(errors.WithStack(err), "revoke join tokens failed")
+	}
+	cluster.JoinTokens = nil
 	return nil
 }
 
@@ -696,8 +878,7 @@ func (r *RestartKubelet) Execute(runtime connector.Runtime) error {
 	if _, err := runtime.GetRunner().SudoCmd("systemctl daemon-reload && systemctl restart kubelet", true); err != nil {
 		return errors.Wrap(errors.WithStack(err), fmt.Sprintf("restart kubelet failed: %s", host.GetName()))
 	}
-	time.Sleep(10 * time.Second)
-	return nil
+	return health.Wait(runtime, health.DefaultTimeout, health.Kubelet)
 }
 
 type UpgradeKubeMaster struct {
@@ -728,9 +909,7 @@ func (u *UpgradeKubeMaster) Execute(runtime connector.Runtime) error {
 	if _, err := runtime.GetRunner().SudoCmd("systemctl daemon-reload && systemctl restart kubelet", true); err != nil {
 		return errors.Wrap(errors.WithStack(err), fmt.Sprintf("restart kubelet failed: %s", host.GetName()))
 	}
-
-	time.Sleep(10 * time.Second)
-	return nil
+	return health.Wait(runtime, health.DefaultTimeout, health.Kubelet, health.Apiserver, health.Scheduler, health.ControllerManager)
 }
 
 type UpgradeKubeWorker struct {
@@ -741,7 +920,10 @@ type UpgradeKubeWorker struct {
 func (u *UpgradeKubeWorker) Execute(runtime connector.Runtime) error {
 	host := runtime.RemoteHost()
 
-	if _, err := runtime.GetRunner().SudoCmd("/usr/local/bin/kubeadm upgrade node", true); err != nil {
+	if err := writeKubeadmPatches(runtime, u.KubeConf); err != nil {
+		return err
+	}
+	if _, err := runtime.GetRunner().SudoCmd("/usr/local/bin/kubeadm upgrade node"+patchesFlag(u.KubeConf), true); err != nil {
 		return errors.Wrap(errors.WithStack(err), fmt.Sprintf("upgrade node using kubeadm failed: %s", host.GetName()))
 	}
 	if _, err := runtime.GetRunner().SudoCmd("systemctl stop kubelet", true); err != nil {
@@ -758,8 +940,7 @@ func (u *UpgradeKubeWorker) Execute(runtime connector.Runtime) error {
 	if _, err := runtime.GetRunner().SudoCmd("systemctl daemon-reload && systemctl restart kubelet", true); err != nil {
 		return errors.Wrap(errors.WithStack(err), fmt.Sprintf("restart kubelet failed: %s", host.GetName()))
 	}
-	time.Sleep(10 * time.Second)
-	return nil
+	return health.Wait(runtime, health.DefaultTimeout, health.Kubelet)
 }
 
 func KubeadmUpgradeTasks(runtime connector.Runtime, u *UpgradeKubeMaster) error {
@@ -807,13 +988,16 @@ type KubeadmUpgrade struct {
 func (k *KubeadmUpgrade) Execute(runtime connector.Runtime) error {
 	host := runtime.RemoteHost()
 	fmt.Println(k.KubeConf.Cluster.Kubernetes.Version)
+	if err := writeKubeadmPatches(runtime, k.KubeConf); err != nil {
+		return err
+	}
 	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf(
 		"timeout -k 600s 600s /usr/local/bin/kubeadm upgrade apply %s -y "+
 			"--ignore-preflight-errors=all "+
 			"--allow-experimental-upgrades "+
 			"--allow-release-candidate-upgrades "+
 			"--etcd-upgrade=false "+
-			"--certificate-renewal=true ",
+			"--certificate-renewal=true"+patchesFlag(k.KubeConf),
 		k.KubeConf.Cluster.Kubernetes.Version), false); err != nil {
 		return errors.Wrap(errors.WithStack(err), fmt.Sprintf("upgrade master failed: %s", host.GetName()))
 	}
@@ -1084,13 +1268,25 @@ func (c *ConfigureKubernetes) Execute(runtime connector.Runtime) error {
 
 	for j := 0; j < len(hosts); j++ {
 		kubeHost := hosts[j].(*kubekeyv1alpha2.KubeHost)
-		for k, v := range kubeHost.Labels {
+		// the nodes are labeled with the owner, which can be overridden by the labels of the host
+		labels := common.NewOwner(runtime.GetObjName()).Labels()
+		for k, v := range kubeHost.NodeLabels() {
+			labels[k] = v
+		}
+		for k, v := range labels {
 			labelCmd := fmt.Sprintf("/usr/local/bin/kubectl label --overwrite node %s %s=%s", hosts[j].GetName(), k, v)
 			_, err := runtime.GetRunner().SudoCmd(labelCmd, true)
 			if err != nil {
 				return err
 			}
 		}
+		// the taints removed from the config are left on the node, they are removed by kubectl taint
+		for _, taint := range kubeHost.Taints {
+			taintCmd := fmt.Sprintf("/usr/local/bin/kubectl taint nodes %s %s --overwrite", hosts[j].GetName(), taint)
+			if _, err := runtime.GetRunner().SudoCmd(taintCmd, true); err != nil {
+				return errors.Wrap(errors.WithStack(err), fmt.Sprintf("taint the node %s with %s failed", hosts[j].GetName(), taint))
+			}
+		}
 	}
 	return nil
 }
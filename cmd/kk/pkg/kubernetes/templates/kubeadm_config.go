@@ -17,6 +17,7 @@
 package templates
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
 	"text/template"
@@ -26,8 +27,10 @@ import (
 	"gopkg.in/yaml.v3"
 	versionutil "k8s.io/apimachinery/pkg/util/version"
 
+	kubekeyv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/fips"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/utils"
 )
@@ -45,6 +48,10 @@ etcd:
   local:
     imageRepository: {{ .EtcdRepo }}
     imageTag: {{ .EtcdTag }}
+{{- if .EtcdArgs }}
+    extraArgs:
+{{ toYaml .EtcdArgs | indent 6 }}
+{{- end }}
     serverCertSANs:
     {{- range .ExternalEtcd.Endpoints }}
     - {{ . }}
@@ -99,6 +106,8 @@ controllerManager:
 {{- if .IPv6Support }}
     node-cidr-mask-size-ipv4: "{{ .NodeCidrMaskSize }}"
     node-cidr-mask-size-ipv6: "64"
+{{- else if .IPv6Only }}
+    node-cidr-mask-size: "64"
 {{- else }}
     node-cidr-mask-size: "{{ .NodeCidrMaskSize }}"
 {{- end }}
@@ -124,6 +133,10 @@ nodeRegistration:
 {{- end }}
   kubeletExtraArgs:
     cgroup-driver: {{ .CgroupDriver }}
+{{- if .PatchesDir }}
+patches:
+  directory: {{ .PatchesDir }}
+{{- end }}
 ---
 apiVersion: kubeproxy.config.k8s.io/v1alpha1
 kind: KubeProxyConfiguration
@@ -156,6 +169,14 @@ nodeRegistration:
 {{- end }}
   kubeletExtraArgs:
     cgroup-driver: {{ .CgroupDriver }}
+{{- if .Taints }}
+  taints:
+{{ toYaml .Taints | indent 4 }}
+{{- end }}
+{{- if .PatchesDir }}
+patches:
+  directory: {{ .PatchesDir }}
+{{- end }}
 
 {{- end }}
     `)))
@@ -222,7 +243,7 @@ func GetApiServerArgs(securityEnhancement bool, enableAudit bool) map[string]str
 				ApiServerSecurityArgs[k] = v
 			}
 		}
-		return ApiServerSecurityArgs
+		return withFIPSArgs(ApiServerSecurityArgs)
 	}
 
 	if enableAudit {
@@ -231,7 +252,7 @@ func GetApiServerArgs(securityEnhancement bool, enableAudit bool) map[string]str
 		}
 	}
 
-	return ApiServerArgs
+	return withFIPSArgs(ApiServerArgs)
 }
 
 func GetControllermanagerArgs(version string, securityEnhancement bool) map[string]string {
@@ -246,14 +267,66 @@ func GetControllermanagerArgs(version string, securityEnhancement bool) map[stri
 		delete(args, "cluster-signing-duration")
 		args["experimental-cluster-signing-duration"] = "87600h"
 	}
-	return args
+	return withFIPSArgs(args)
 }
 
 func GetSchedulerArgs(securityEnhancement bool) map[string]string {
 	if securityEnhancement {
-		return SchedulerSecurityArgs
+		return withFIPSArgs(SchedulerSecurityArgs)
+	}
+	return withFIPSArgs(SchedulerArgs)
+}
+
+// withFIPSArgs restricts the TLS of the component to the FIPS approved cipher suites in FIPS mode.
+func withFIPSArgs(args map[string]string) map[string]string {
+	if !fips.Enabled() {
+		return args
+	}
+	args = copyStringMap(args)
+	args["tls-cipher-suites"] = strings.Join(fips.TLSCipherSuites, ",")
+	args["tls-min-version"] = fips.TLSMinVersion
+	return args
+}
+
+// WithTLSPolicyArgs applies the TLS policy of the cluster spec to the args of kube-apiserver, which overrides the
+// defaults and the FIPS mode.
+func WithTLSPolicyArgs(args map[string]string, policy kubekeyv1alpha2.TLSPolicy) map[string]string {
+	if !policy.IsSet() {
+		return args
+	}
+	args = copyStringMap(args)
+	if policy.MinVersion != "" {
+		args["tls-min-version"] = policy.MinVersion
 	}
-	return SchedulerArgs
+	if len(policy.CipherSuites) > 0 {
+		args["tls-cipher-suites"] = policy.CipherSuitesArg()
+	} else if policy.MinVersion == kubekeyv1alpha2.TLSVersion13 {
+		delete(args, "tls-cipher-suites")
+	}
+	return args
+}
+
+// WithIPv6BindAddress replaces the default bind address 0.0.0.0 of the args by :: on the IPv6-only clusters, since
+// 0.0.0.0 only listens on IPv4.
+func WithIPv6BindAddress(args map[string]string, ipv6Only bool) map[string]string {
+	if !ipv6Only || args["bind-address"] != "0.0.0.0" {
+		return args
+	}
+	args = copyStringMap(args)
+	args["bind-address"] = "::"
+	return args
+}
+
+// GetEtcdArgs returns the extra args of etcd installed by kubeadm from the TLS policy of the cluster spec.
+func GetEtcdArgs(policy kubekeyv1alpha2.TLSPolicy) map[string]string {
+	args := make(map[string]string)
+	if v := policy.EtcdMinVersion(); v != "" {
+		args["tls-min-version"] = v
+	}
+	if len(policy.CipherSuites) > 0 {
+		args["cipher-suites"] = policy.CipherSuitesArg()
+	}
+	return args
 }
 
 func UpdateFeatureGatesConfiguration(args map[string]string, kubeConf *common.KubeConf) map[string]string {
@@ -284,7 +357,58 @@ func UpdateFeatureGatesConfiguration(args map[string]string, kubeConf *common.Ku
 	return args
 }
 
-func GetKubeletConfiguration(runtime connector.Runtime, kubeConf *common.KubeConf, criSock string, securityEnhancement bool) map[string]interface{} {
+// MergeClusterConfiguration merges the overlay into the ClusterConfiguration of the kubeadm config. The maps are
+// merged, the lists and the other values of the overlay replace the generated ones.
+func MergeClusterConfiguration(config string, overlay []byte) (string, error) {
+	if len(overlay) == 0 {
+		return config, nil
+	}
+	custom := make(map[string]interface{})
+	if err := yaml.Unmarshal(overlay, &custom); err != nil {
+		return "", errors.Wrap(err, "parse the cluster configuration failed")
+	}
+
+	docs := strings.Split(config, "\n---\n")
+	for i, doc := range docs {
+		generated := make(map[string]interface{})
+		if err := yaml.Unmarshal([]byte(doc), &generated); err != nil {
+			return "", errors.Wrap(err, "parse the kubeadm config failed")
+		}
+		if generated["kind"] != "ClusterConfiguration" {
+			continue
+		}
+
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(mergeMaps(generated, custom)); err != nil {
+			return "", errors.Wrap(err, "encode the cluster configuration failed")
+		}
+		merged := strings.TrimSuffix(buf.String(), "\n")
+		if strings.HasPrefix(doc, "---\n") {
+			merged = "---\n" + merged
+		}
+		docs[i] = merged
+		return strings.Join(docs, "\n---\n"), nil
+	}
+	return config, nil
+}
+
+// mergeMaps merges the src into the dst recursively, the values of the src which aren't maps replace the dst ones.
+func mergeMaps(dst, src map[string]interface{}) map[string]interface{} {
+	for k, v := range src {
+		srcMap, ok := v.(map[string]interface{})
+		dstMap, isMap := dst[k].(map[string]interface{})
+		if ok && isMap {
+			dst[k] = mergeMaps(dstMap, srcMap)
+			continue
+		}
+		dst[k] = v
+	}
+	return dst
+}
+
+func GetKubeletConfiguration(runtime connector.Runtime, kubeConf *common.KubeConf, criSock, cgroupDriver string, securityEnhancement bool) map[string]interface{} {
 	// When kubernetes version is less than 1.21,`CSIStorageCapacity` should not be set.
 	cmp, _ := versionutil.MustParseSemantic(kubeConf.Cluster.Kubernetes.Version).Compare("v1.21.0")
 	if cmp == -1 {
@@ -319,6 +443,10 @@ func GetKubeletConfiguration(runtime connector.Runtime, kubeConf *common.KubeCon
 		"evictionPressureTransitionPeriod": "30s",
 		"featureGates":                     FeatureGatesDefaultConfiguration,
 	}
+	// 0.0.0.0 only listens on IPv4
+	if kubeConf.Cluster.Network.IPv6Only() {
+		defaultKubeletConfiguration["address"] = "::"
+	}
 
 	if securityEnhancement {
 		defaultKubeletConfiguration["readOnlyPort"] = 0
@@ -333,11 +461,21 @@ func GetKubeletConfiguration(runtime connector.Runtime, kubeConf *common.KubeCon
 		}
 		defaultKubeletConfiguration["featureGates"] = FeatureGatesSecurityDefaultConfiguration
 	}
-
-	cgroupDriver, err := GetKubeletCgroupDriver(runtime, kubeConf)
-	if err != nil {
-		logger.Log.Fatal(err)
+	if fips.Enabled() {
+		defaultKubeletConfiguration["tlsCipherSuites"] = fips.TLSCipherSuites
+		defaultKubeletConfiguration["tlsMinVersion"] = fips.TLSMinVersion
+	}
+	if policy := kubeConf.Cluster.TLS.Kubelet; policy.IsSet() {
+		if policy.MinVersion != "" {
+			defaultKubeletConfiguration["tlsMinVersion"] = policy.MinVersion
+		}
+		if len(policy.CipherSuites) > 0 {
+			defaultKubeletConfiguration["tlsCipherSuites"] = policy.CipherSuites
+		} else if policy.MinVersion == kubekeyv1alpha2.TLSVersion13 {
+			delete(defaultKubeletConfiguration, "tlsCipherSuites")
+		}
 	}
+
 	if len(cgroupDriver) == 0 {
 		defaultKubeletConfiguration["cgroupDriver"] = "systemd"
 	}
@@ -397,7 +535,9 @@ func GetKubeletConfiguration(runtime connector.Runtime, kubeConf *common.KubeCon
 	return kubeletConfiguration
 }
 
-func GetKubeletCgroupDriver(runtime connector.Runtime, kubeConf *common.KubeConf) (string, error) {
+// GetKubeletCgroupDriver returns the cgroup driver of the container runtime of the host, which kubelet uses as well.
+// In the check mode, the configured driver is returned, which is chosen by the facts of the hosts.
+func GetKubeletCgroupDriver(runtime connector.Runtime, kubeConf *common.KubeConf, configured string) (string, error) {
 	var cmd, kubeletCgroupDriver string
 	switch kubeConf.Cluster.Kubernetes.ContainerManager {
 	case common.Docker, "":
@@ -412,6 +552,11 @@ func GetKubeletCgroupDriver(runtime connector.Runtime, kubeConf *common.KubeConf
 		kubeletCgroupDriver = ""
 	}
 
+	// the container runtime configured by KubeKey uses the configured cgroup driver, which can't be checked in the check mode
+	if connector.IsDryRun(runtime.GetConnector()) {
+		return configured, nil
+	}
+
 	checkResult, err := runtime.GetRunner().SudoCmd(cmd, false)
 	if err != nil {
 		return "", errors.Wrap(errors.WithStack(err), "Failed to get container runtime cgroup driver.")
@@ -437,6 +582,9 @@ func GetKubeProxyConfiguration(kubeConf *common.KubeConf) map[string]interface{}
 			"syncPeriod":    "30s",
 		},
 	}
+	if kubeConf.Cluster.Network.IPv6Only() {
+		defaultKubeProxyConfiguration["bindAddress"] = "::"
+	}
 
 	customKubeProxyConfiguration := make(map[string]interface{})
 	if len(kubeConf.Cluster.Kubernetes.KubeProxyConfiguration.Raw) != 0 {
@@ -0,0 +1,146 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package templates
+
+import (
+	"reflect"
+	"testing"
+
+	kubekeyv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+func TestMergeClusterConfiguration(t *testing.T) {
+	config := `---
+apiVersion: kubeadm.k8s.io/v1beta3
+kind: ClusterConfiguration
+apiServer:
+  extraArgs:
+    bind-address: 0.0.0.0
+  certSANs:
+    - "lb.kubesphere.local"
+---
+apiVersion: kubeadm.k8s.io/v1beta3
+kind: InitConfiguration
+nodeRegistration:
+  kubeletExtraArgs:
+    cgroup-driver: systemd`
+
+	tests := []struct {
+		name    string
+		overlay string
+		want    string
+	}{
+		{
+			name: "no overlay",
+			want: config,
+		},
+		{
+			name:    "merge the maps and replace the lists",
+			overlay: `{"apiServer":{"extraArgs":{"oidc-issuer-url":"https://dex.example.com"},"certSANs":["api.example.com"]}}`,
+			want: `---
+apiServer:
+  certSANs:
+    - api.example.com
+  extraArgs:
+    bind-address: 0.0.0.0
+    oidc-issuer-url: https://dex.example.com
+apiVersion: kubeadm.k8s.io/v1beta3
+kind: ClusterConfiguration
+---
+apiVersion: kubeadm.k8s.io/v1beta3
+kind: InitConfiguration
+nodeRegistration:
+  kubeletExtraArgs:
+    cgroup-driver: systemd`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergeClusterConfiguration(config, []byte(tt.overlay))
+			if err != nil {
+				t.Fatalf("MergeClusterConfiguration() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("MergeClusterConfiguration() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithTLSPolicyArgs(t *testing.T) {
+	args := map[string]string{
+		"bind-address":      "0.0.0.0",
+		"tls-cipher-suites": "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+	}
+	tests := []struct {
+		name   string
+		policy kubekeyv1alpha2.TLSPolicy
+		want   map[string]string
+	}{
+		{
+			name: "no policy",
+			want: args,
+		},
+		{
+			name: "override the cipher suites",
+			policy: kubekeyv1alpha2.TLSPolicy{
+				MinVersion:   kubekeyv1alpha2.TLSVersion12,
+				CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384", "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+			},
+			want: map[string]string{
+				"bind-address":      "0.0.0.0",
+				"tls-min-version":   "VersionTLS12",
+				"tls-cipher-suites": "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+			},
+		},
+		{
+			name:   "tls 1.3 only",
+			policy: kubekeyv1alpha2.TLSPolicy{MinVersion: kubekeyv1alpha2.TLSVersion13},
+			want: map[string]string{
+				"bind-address":    "0.0.0.0",
+				"tls-min-version": "VersionTLS13",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WithTLSPolicyArgs(args, tt.policy); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WithTLSPolicyArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithIPv6BindAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     map[string]string
+		ipv6Only bool
+		want     map[string]string
+	}{
+		{name: "ipv4", args: map[string]string{"bind-address": "0.0.0.0"}, want: map[string]string{"bind-address": "0.0.0.0"}},
+		{name: "ipv6 only", args: map[string]string{"bind-address": "0.0.0.0"}, ipv6Only: true, want: map[string]string{"bind-address": "::"}},
+		{name: "loopback", args: map[string]string{"bind-address": "127.0.0.1"}, ipv6Only: true, want: map[string]string{"bind-address": "127.0.0.1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WithIPv6BindAddress(tt.args, tt.ipv6Only); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WithIPv6BindAddress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -41,6 +41,7 @@ func (d *DeployModule) IsSkip() bool {
 
 func (d *DeployModule) Init() {
 	d.Name = "DeployKubeSphereModule"
+	d.Tags = []string{"kubesphere"}
 	d.Desc = "Deploy KubeSphere"
 
 	generateManifests := &task.RemoteTask{
@@ -170,6 +171,7 @@ func (c *CheckResultModule) IsSkip() bool {
 
 func (c *CheckResultModule) Init() {
 	c.Name = "CheckResultModule"
+	c.Tags = []string{"kubesphere"}
 	c.Desc = "Check deploy KubeSphere result"
 
 	check := &task.RemoteTask{
@@ -200,6 +202,7 @@ func (c *CleanClusterConfigurationModule) IsSkip() bool {
 
 func (c *CleanClusterConfigurationModule) Init() {
 	c.Name = "CleanClusterConfigurationModule"
+	c.Tags = []string{"kubesphere"}
 	c.Desc = "Clean redundant ClusterConfiguration config"
 
 	// ensure there is no cc config, and prevent to reset cc config when upgrade the cluster
@@ -225,6 +228,7 @@ func (c *ConvertModule) IsSkip() bool {
 
 func (c *ConvertModule) Init() {
 	c.Name = "ConvertModule"
+	c.Tags = []string{"kubesphere"}
 	c.Desc = "Convert ks-installer config v2 to v3"
 
 	convert := &task.RemoteTask{
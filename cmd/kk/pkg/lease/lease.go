@@ -0,0 +1,204 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package lease locks the cluster against the concurrent runs of kk from different machines, by the kubekey Lease in
+// kube-system. The lease is managed with the kubectl of the first control-plane node, so the cluster is only locked
+// once it's installed, and it expires if the run holding it crashes.
+package lease
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+const (
+	// Name is the name of the lease in kube-system.
+	Name = "kubekey"
+
+	kubectl = "/usr/local/bin/kubectl --kubeconfig /etc/kubernetes/admin.conf -n kube-system"
+	// microTime is the format of the times of the leases.
+	microTime = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// Lease is the lock of the cluster held in the kubekey Lease.
+type Lease struct {
+	host     connector.Host
+	holder   string
+	duration time.Duration
+	held     bool
+}
+
+// New returns the lease of the cluster managed on the control-plane node, it's held by user@hostname/pid of kk.
+func New(host connector.Host, duration time.Duration) *Lease {
+	return &Lease{host: host, holder: Holder(), duration: duration}
+}
+
+// Holder is the identity of the running kk, user@hostname/pid.
+func Holder() string {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s@%s/%d", name, hostname, os.Getpid())
+}
+
+// Acquire creates the lease, or takes over the expired one. The takeover replaces the lease at the version it was
+// read, so only one of the runs taking over the lease at the same time succeeds.
+func (l *Lease) Acquire(runtime connector.Runtime) error {
+	runner, err := l.runner(runtime)
+	if err != nil {
+		return err
+	}
+	if ok, err := runner.FileExist("/etc/kubernetes/admin.conf"); err != nil || !ok {
+		logger.Log.Debugf("skip the lease of the cluster, which isn't installed on %s", l.host.GetName())
+		return nil
+	}
+	out, err := runner.SudoCmd(fmt.Sprintf("%s get lease %s --ignore-not-found "+
+		"-o jsonpath='{.spec.holderIdentity} {.spec.renewTime} {.spec.leaseDurationSeconds} {.metadata.resourceVersion}' "+
+		"&& echo && date -u +%%s", kubectl, Name), false)
+	if err != nil {
+		return errors.Wrapf(errors.WithStack(err), "get the lease %s failed", Name)
+	}
+	current, now, err := parse(out)
+	if err != nil {
+		return err
+	}
+	verb := "create"
+	if current != nil {
+		if current.holder != l.holder && !current.expired(now) {
+			return errors.Errorf("the cluster is locked by another KubeKey run %s until %s, wait for it to finish "+
+				"or delete the lease with kubectl -n kube-system delete lease %s if it crashed",
+				current.holder, current.renew.Add(current.duration).Local().Format(time.RFC1123), Name)
+		}
+		if current.holder != l.holder {
+			logger.Log.Warnf("take over the lease %s of the KubeKey run %s, which expired", Name, current.holder)
+		}
+		verb = "replace"
+	}
+	if _, err := runner.SudoCmd(fmt.Sprintf("cat <<'EOF' | %s %s -f -\n%s\nEOF", kubectl, verb, l.manifest(current, now)), false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "%s the lease %s failed, another KubeKey run may hold it", verb, Name)
+	}
+	l.held = true
+	logger.Log.Infof("locked the cluster by the lease %s as %s", Name, l.holder)
+	return nil
+}
+
+// Renew renews the held lease.
+func (l *Lease) Renew(runtime connector.Runtime) error {
+	if !l.held {
+		return nil
+	}
+	runner, err := l.runner(runtime)
+	if err != nil {
+		return err
+	}
+	// the commands are run in double quotes by sudo
+	patch := fmt.Sprintf(`{\"spec\":{\"renewTime\":\"'$(date -u +%%Y-%%m-%%dT%%H:%%M:%%S.000000Z)'\",\"leaseDurationSeconds\":%d}}`,
+		int(l.duration.Seconds()))
+	if _, err := runner.SudoCmd(fmt.Sprintf("%s patch lease %s --type merge -p '%s'", kubectl, Name, patch), false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "renew the lease %s failed", Name)
+	}
+	return nil
+}
+
+// Release deletes the held lease, if it's still held by the run.
+func (l *Lease) Release(runtime connector.Runtime) {
+	if !l.held {
+		return
+	}
+	runner, err := l.runner(runtime)
+	if err == nil {
+		_, err = runner.SudoCmd(fmt.Sprintf("%s get lease %s -o jsonpath='{.spec.holderIdentity}' | grep -qxF '%s' && %s delete lease %s || true",
+			kubectl, Name, l.holder, kubectl, Name), false)
+	}
+	if err != nil {
+		logger.Log.Warnf("failed to release the lease %s, it expires in %s: %v", Name, l.duration, err)
+		return
+	}
+	l.held = false
+}
+
+func (l *Lease) runner(runtime connector.Runtime) (*connector.Runner, error) {
+	return connector.NewRunner(runtime.GetContext(), runtime.GetConnector(), l.host)
+}
+
+// manifest is the lease held by the run, it's quoted in single quotes since the commands are run in double quotes.
+func (l *Lease) manifest(current *record, now time.Time) string {
+	version := ""
+	if current != nil {
+		version = fmt.Sprintf("\n  resourceVersion: '%s'", current.version)
+	}
+	stamp := now.UTC().Format(microTime)
+	return fmt.Sprintf(`apiVersion: coordination.k8s.io/v1
+kind: Lease
+metadata:
+  name: %s
+  namespace: kube-system%s
+spec:
+  holderIdentity: '%s'
+  leaseDurationSeconds: %d
+  acquireTime: '%s'
+  renewTime: '%s'`, Name, version, l.holder, int(l.duration.Seconds()), stamp, stamp)
+}
+
+// record is the lease read from the cluster.
+type record struct {
+	holder   string
+	renew    time.Time
+	duration time.Duration
+	version  string
+}
+
+func (r *record) expired(now time.Time) bool {
+	return now.After(r.renew.Add(r.duration))
+}
+
+// parse parses the output of the get of the lease: the holder, the renew time, the duration and the version of the
+// lease, and the current time of the host in seconds on the next line. The lease is nil if it doesn't exist.
+func parse(out string) (*record, time.Time, error) {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	now, err := strconv.ParseInt(strings.TrimSpace(lines[len(lines)-1]), 10, 64)
+	if err != nil {
+		return nil, time.Time{}, errors.Wrap(err, "parse the time of the host failed")
+	}
+	if len(lines) == 1 || strings.TrimSpace(lines[0]) == "" {
+		return nil, time.Unix(now, 0), nil
+	}
+	fields := strings.Fields(lines[0])
+	if len(fields) != 4 {
+		return nil, time.Time{}, errors.Errorf("the lease %s is malformed: %s", Name, lines[0])
+	}
+	r := &record{holder: fields[0], version: fields[3]}
+	if r.renew, err = time.Parse(time.RFC3339Nano, fields[1]); err != nil {
+		return nil, time.Time{}, errors.Wrapf(err, "parse the renew time of the lease %s failed", Name)
+	}
+	seconds, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, time.Time{}, errors.Wrapf(err, "parse the duration of the lease %s failed", Name)
+	}
+	r.duration = time.Duration(seconds) * time.Second
+	return r, time.Unix(now, 0), nil
+}
@@ -0,0 +1,69 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lease
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		out         string
+		wantHolder  string
+		wantExpired bool
+		wantErr     bool
+	}{
+		{name: "no lease", out: "\n1672531200\n"},
+		{
+			name:       "held",
+			out:        "root@ci/42 2023-01-01T00:00:00.000000Z 600 1234\n1672531260\n",
+			wantHolder: "root@ci/42",
+		},
+		{
+			name:        "expired",
+			out:         "root@ci/42 2023-01-01T00:00:00.000000Z 600 1234\n1672531900\n",
+			wantHolder:  "root@ci/42",
+			wantExpired: true,
+		},
+		{name: "malformed", out: "root@ci/42 600\n1672531200\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, now, err := parse(tt.out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if tt.wantHolder == "" {
+				if r != nil {
+					t.Errorf("parse() = %+v, want no lease", r)
+				}
+				return
+			}
+			if r.holder != tt.wantHolder || r.duration != 10*time.Minute || r.version != "1234" {
+				t.Errorf("parse() = %+v", r)
+			}
+			if got := r.expired(now); got != tt.wantExpired {
+				t.Errorf("expired() = %v, want %v", got, tt.wantExpired)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dnsprovider
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+// coreDNSProvider manages the records in the etcd backend of the etcd plugin of CoreDNS. The records are stored in the
+// SkyDNS layout, one key under the path of the domain for each address, and written with the JSON API of etcd v3.
+type coreDNSProvider struct {
+	client    *http.Client
+	endpoints []string
+	prefix    string
+}
+
+type skyDNSRecord struct {
+	Host string `json:"host"`
+	TTL  int64  `json:"ttl,omitempty"`
+}
+
+type etcdKeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+}
+
+type etcdRangeRequest struct {
+	Key      string `json:"key"`
+	RangeEnd string `json:"range_end"`
+	KeysOnly bool   `json:"keys_only"`
+}
+
+type etcdRangeResponse struct {
+	Kvs []etcdKeyValue `json:"kvs"`
+}
+
+type etcdRequestOp struct {
+	RequestPut         *etcdKeyValue `json:"request_put,omitempty"`
+	RequestDeleteRange *etcdKeyValue `json:"request_delete_range,omitempty"`
+}
+
+type etcdTxnRequest struct {
+	Success []etcdRequestOp `json:"success"`
+}
+
+func newCoreDNSProvider(cfg *kubekeyapiv1alpha2.ControlPlaneDNS) (Provider, error) {
+	if cfg.CoreDNS == nil || len(cfg.CoreDNS.Endpoints) == 0 {
+		return nil, errors.New("the etcd endpoints of coredns are required")
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CoreDNS.CAFile != "" {
+		ca, err := os.ReadFile(cfg.CoreDNS.CAFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read the CA file %s", cfg.CoreDNS.CAFile)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, errors.Errorf("no certificate is found in the CA file %s", cfg.CoreDNS.CAFile)
+		}
+	}
+	if cfg.CoreDNS.CertFile != "" || cfg.CoreDNS.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CoreDNS.CertFile, cfg.CoreDNS.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load the client certificate of etcd")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	prefix := cfg.CoreDNS.Prefix
+	if prefix == "" {
+		prefix = kubekeyapiv1alpha2.DefaultCoreDNSPrefix
+	}
+	return &coreDNSProvider{
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+		endpoints: cfg.CoreDNS.Endpoints,
+		prefix:    prefix,
+	}, nil
+}
+
+// skyDNSPath returns the path of the domain in etcd, whose labels are reversed, e.g. the path of api.example.com is
+// /skydns/com/example/api.
+func skyDNSPath(prefix, domain string) string {
+	labels := strings.Split(strings.TrimSuffix(strings.ToLower(domain), "."), ".")
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.Join(labels, "/")
+}
+
+func (p *coreDNSProvider) Sync(ctx context.Context, domain string, addresses []string, ttl int64) error {
+	path := skyDNSPath(p.prefix, domain)
+	ipv4, ipv6 := SplitAddresses(addresses)
+
+	desired := make(map[string]string)
+	for _, address := range append(ipv4, ipv6...) {
+		value, err := json.Marshal(skyDNSRecord{Host: address, TTL: ttl})
+		if err != nil {
+			return err
+		}
+		desired[path+"/kubekey-"+strings.NewReplacer(".", "-", ":", "-").Replace(address)] = string(value)
+	}
+
+	var existing etcdRangeResponse
+	if err := p.post(ctx, "/v3/kv/range", etcdRangeRequest{
+		Key:      encodeKey(path),
+		RangeEnd: encodeKey(path + "0"),
+		KeysOnly: true,
+	}, &existing); err != nil {
+		return errors.Wrapf(err, "failed to get the records of %s", domain)
+	}
+
+	var txn etcdTxnRequest
+	for _, kv := range existing.Kvs {
+		key, err := base64.StdEncoding.DecodeString(kv.Key)
+		if err != nil {
+			return errors.Wrap(err, "failed to decode the key returned by etcd")
+		}
+		if _, ok := desired[string(key)]; ok || (string(key) != path && !strings.HasPrefix(string(key), path+"/")) {
+			continue
+		}
+		txn.Success = append(txn.Success, etcdRequestOp{RequestDeleteRange: &etcdKeyValue{Key: kv.Key}})
+	}
+	for _, key := range sortedKeys(desired) {
+		txn.Success = append(txn.Success, etcdRequestOp{RequestPut: &etcdKeyValue{
+			Key:   encodeKey(key),
+			Value: base64.StdEncoding.EncodeToString([]byte(desired[key])),
+		}})
+	}
+	if len(txn.Success) == 0 {
+		return nil
+	}
+	if err := p.post(ctx, "/v3/kv/txn", txn, nil); err != nil {
+		return errors.Wrapf(err, "failed to update the records of %s", domain)
+	}
+	return nil
+}
+
+// post sends the request to the endpoints in order until one of them succeeds.
+func (p *coreDNSProvider) post(ctx context.Context, api string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	var lastErr error
+	for _, endpoint := range p.endpoints {
+		if lastErr = p.postEndpoint(ctx, strings.TrimSuffix(endpoint, "/")+api, body, out); lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
+
+func (p *coreDNSProvider) postEndpoint(ctx context.Context, url string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s: %s", url, resp.Status, strings.TrimSpace(string(data)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+func encodeKey(key string) string {
+	return base64.StdEncoding.EncodeToString([]byte(key))
+}
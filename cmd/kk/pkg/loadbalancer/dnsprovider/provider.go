@@ -0,0 +1,105 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dnsprovider
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+// Provider manages the records of a domain in a DNS service.
+type Provider interface {
+	// Sync replaces the A and AAAA records of the domain with the addresses.
+	Sync(ctx context.Context, domain string, addresses []string, ttl int64) error
+}
+
+// Factory creates the Provider from the DNS config of the control-plane endpoint.
+type Factory func(cfg *kubekeyapiv1alpha2.ControlPlaneDNS) (Provider, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{
+		kubekeyapiv1alpha2.DNSProviderRoute53: newRoute53Provider,
+		kubekeyapiv1alpha2.DNSProviderCoreDNS: newCoreDNSProvider,
+		kubekeyapiv1alpha2.DNSProviderRFC2136: newRFC2136Provider,
+	}
+)
+
+// Register registers the factory of a provider, the registered one is replaced if the name is the same.
+func Register(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[name] = factory
+}
+
+// New creates the provider named in the DNS config.
+func New(cfg *kubekeyapiv1alpha2.ControlPlaneDNS) (Provider, error) {
+	if cfg == nil || cfg.Provider == "" {
+		return nil, errors.New("the DNS provider is not set")
+	}
+	factoriesMu.RLock()
+	factory, ok := factories[cfg.Provider]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("unsupported DNS provider %s", cfg.Provider)
+	}
+	return factory(cfg)
+}
+
+// SplitAddresses splits the addresses into the sorted IPv4 and IPv6 ones, which are the values of the A and AAAA
+// records, the duplicate and the invalid addresses are dropped.
+func SplitAddresses(addresses []string) (ipv4 []string, ipv6 []string) {
+	seen := make(map[string]struct{})
+	for _, address := range addresses {
+		ip := net.ParseIP(strings.TrimSpace(address))
+		if ip == nil {
+			continue
+		}
+		if _, ok := seen[ip.String()]; ok {
+			continue
+		}
+		seen[ip.String()] = struct{}{}
+		if ip.To4() != nil {
+			ipv4 = append(ipv4, ip.String())
+		} else {
+			ipv6 = append(ipv6, ip.String())
+		}
+	}
+	sort.Strings(ipv4)
+	sort.Strings(ipv6)
+	return ipv4, ipv6
+}
+
+func fqdn(domain string) string {
+	return strings.TrimSuffix(domain, ".") + "."
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
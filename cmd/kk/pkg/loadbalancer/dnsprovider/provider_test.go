@@ -0,0 +1,176 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dnsprovider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+func TestSplitAddresses(t *testing.T) {
+	ipv4, ipv6 := SplitAddresses([]string{"192.168.0.2", "fd00::1", "192.168.0.1", "192.168.0.2", "node1"})
+	if !reflect.DeepEqual(ipv4, []string{"192.168.0.1", "192.168.0.2"}) {
+		t.Errorf("unexpected ipv4 addresses %v", ipv4)
+	}
+	if !reflect.DeepEqual(ipv6, []string{"fd00::1"}) {
+		t.Errorf("unexpected ipv6 addresses %v", ipv6)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(&kubekeyapiv1alpha2.ControlPlaneDNS{Provider: "cloudflare"}); err == nil {
+		t.Error("expected an error for the unsupported provider")
+	}
+	if _, err := New(&kubekeyapiv1alpha2.ControlPlaneDNS{Provider: kubekeyapiv1alpha2.DNSProviderRFC2136}); err == nil {
+		t.Error("expected an error without the rfc2136 server")
+	}
+}
+
+func TestCoreDNSSync(t *testing.T) {
+	decode := func(s string) string {
+		b, _ := base64.StdEncoding.DecodeString(s)
+		return string(b)
+	}
+	var ops []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/kv/range":
+			var req etcdRangeRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if decode(req.Key) != "/skydns/com/example/api" {
+				t.Errorf("unexpected range key %s", decode(req.Key))
+			}
+			_ = json.NewEncoder(w).Encode(etcdRangeResponse{Kvs: []etcdKeyValue{
+				{Key: encodeKey("/skydns/com/example/api/kubekey-192-168-0-1")},
+				{Key: encodeKey("/skydns/com/example/api/kubekey-192-168-0-3")},
+				{Key: encodeKey("/skydns/com/example/api-internal")},
+			}})
+		case "/v3/kv/txn":
+			var req etcdTxnRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			for _, op := range req.Success {
+				if op.RequestDeleteRange != nil {
+					ops = append(ops, "delete "+decode(op.RequestDeleteRange.Key))
+				}
+				if op.RequestPut != nil {
+					ops = append(ops, "put "+decode(op.RequestPut.Key)+" "+decode(op.RequestPut.Value))
+				}
+			}
+			_, _ = w.Write([]byte("{}"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	p, err := New(&kubekeyapiv1alpha2.ControlPlaneDNS{
+		Provider: kubekeyapiv1alpha2.DNSProviderCoreDNS,
+		CoreDNS:  &kubekeyapiv1alpha2.CoreDNSEtcd{Endpoints: []string{"http://127.0.0.1:1", server.URL}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Sync(context.Background(), "api.example.com", []string{"192.168.0.2", "192.168.0.1"}, 60); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"delete /skydns/com/example/api/kubekey-192-168-0-3",
+		`put /skydns/com/example/api/kubekey-192-168-0-1 {"host":"192.168.0.1","ttl":60}`,
+		`put /skydns/com/example/api/kubekey-192-168-0-2 {"host":"192.168.0.2","ttl":60}`,
+	}
+	if !reflect.DeepEqual(ops, expected) {
+		t.Errorf("unexpected txn ops:\n%s", strings.Join(ops, "\n"))
+	}
+}
+
+func TestRFC2136Sync(t *testing.T) {
+	var gotArgs []string
+	var gotScript string
+	p := &rfc2136Provider{
+		cfg: kubekeyapiv1alpha2.RFC2136DNS{Server: "192.168.0.53", Zone: "example.com", TSIGKeyName: "kubekey", TSIGSecret: "c2VjcmV0"},
+		nsupdate: func(ctx context.Context, args []string, script string) error {
+			gotArgs, gotScript = args, script
+			return nil
+		},
+	}
+	if err := p.Sync(context.Background(), "api.example.com", []string{"192.168.0.1", "fd00::1"}, 30); err != nil {
+		t.Fatal(err)
+	}
+	if len(gotArgs) != 2 || gotArgs[0] != "-k" {
+		t.Errorf("unexpected nsupdate args %v", gotArgs)
+	}
+	expected := "server 192.168.0.53 53\n" +
+		"zone example.com.\n" +
+		"update delete api.example.com. A\n" +
+		"update delete api.example.com. AAAA\n" +
+		"update add api.example.com. 30 A 192.168.0.1\n" +
+		"update add api.example.com. 30 AAAA fd00::1\n" +
+		"send\n"
+	if gotScript != expected {
+		t.Errorf("unexpected nsupdate script:\n%s", gotScript)
+	}
+}
+
+type fakeRoute53 struct {
+	route53iface.Route53API
+	existing []*route53.ResourceRecordSet
+	changes  []*route53.Change
+}
+
+func (f *fakeRoute53) ListResourceRecordSetsWithContext(aws.Context, *route53.ListResourceRecordSetsInput, ...request.Option) (*route53.ListResourceRecordSetsOutput, error) {
+	return &route53.ListResourceRecordSetsOutput{ResourceRecordSets: f.existing}, nil
+}
+
+func (f *fakeRoute53) ChangeResourceRecordSetsWithContext(_ aws.Context, input *route53.ChangeResourceRecordSetsInput, _ ...request.Option) (*route53.ChangeResourceRecordSetsOutput, error) {
+	f.changes = input.ChangeBatch.Changes
+	return &route53.ChangeResourceRecordSetsOutput{}, nil
+}
+
+func TestRoute53Sync(t *testing.T) {
+	client := &fakeRoute53{existing: []*route53.ResourceRecordSet{
+		{Name: aws.String("api.example.com."), Type: aws.String(route53.RRTypeAaaa)},
+		{Name: aws.String("www.example.com."), Type: aws.String(route53.RRTypeA)},
+	}}
+	p := &route53Provider{client: client, hostedZoneID: "Z1"}
+	if err := p.Sync(context.Background(), "api.example.com", []string{"192.168.0.1"}, 60); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d", len(client.changes))
+	}
+	if upsert := client.changes[0]; aws.StringValue(upsert.Action) != route53.ChangeActionUpsert ||
+		aws.StringValue(upsert.ResourceRecordSet.ResourceRecords[0].Value) != "192.168.0.1" {
+		t.Errorf("unexpected change %v", upsert)
+	}
+	if del := client.changes[1]; aws.StringValue(del.Action) != route53.ChangeActionDelete ||
+		aws.StringValue(del.ResourceRecordSet.Type) != route53.RRTypeAaaa {
+		t.Errorf("unexpected change %v", del)
+	}
+}
@@ -0,0 +1,116 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dnsprovider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+const defaultTSIGAlgorithm = "hmac-sha256"
+
+// rfc2136Provider manages the records with the dynamic updates of RFC 2136, which are sent by nsupdate on the
+// machine running kk, so the bind-utils (or dnsutils) package is required.
+type rfc2136Provider struct {
+	cfg kubekeyapiv1alpha2.RFC2136DNS
+	// nsupdate runs nsupdate with the args and the script as the stdin.
+	nsupdate func(ctx context.Context, args []string, script string) error
+}
+
+func newRFC2136Provider(cfg *kubekeyapiv1alpha2.ControlPlaneDNS) (Provider, error) {
+	if cfg.RFC2136 == nil || cfg.RFC2136.Server == "" || cfg.RFC2136.Zone == "" {
+		return nil, errors.New("the server and the zone of rfc2136 are required")
+	}
+	return &rfc2136Provider{cfg: *cfg.RFC2136, nsupdate: runNsupdate}, nil
+}
+
+func (p *rfc2136Provider) Sync(ctx context.Context, domain string, addresses []string, ttl int64) error {
+	var args []string
+	if p.cfg.TSIGKeyName != "" {
+		keyFile, err := os.CreateTemp("", "kubekey-tsig-*.key")
+		if err != nil {
+			return errors.Wrap(err, "failed to create the TSIG key file")
+		}
+		defer os.Remove(keyFile.Name())
+		_, err = keyFile.WriteString(p.tsigKey())
+		if closeErr := keyFile.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to write the TSIG key file")
+		}
+		args = append(args, "-k", keyFile.Name())
+	}
+
+	if err := p.nsupdate(ctx, args, p.script(domain, addresses, ttl)); err != nil {
+		return errors.Wrapf(err, "failed to update the records of %s on %s", domain, p.cfg.Server)
+	}
+	return nil
+}
+
+// script returns the nsupdate script which replaces the A and AAAA records of the domain in one update.
+func (p *rfc2136Provider) script(domain string, addresses []string, ttl int64) string {
+	host, port, err := net.SplitHostPort(p.cfg.Server)
+	if err != nil {
+		host, port = p.cfg.Server, "53"
+	}
+	name := fqdn(domain)
+	ipv4, ipv6 := SplitAddresses(addresses)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "server %s %s\n", host, port)
+	fmt.Fprintf(&b, "zone %s\n", fqdn(p.cfg.Zone))
+	fmt.Fprintf(&b, "update delete %s A\n", name)
+	fmt.Fprintf(&b, "update delete %s AAAA\n", name)
+	for _, address := range ipv4 {
+		fmt.Fprintf(&b, "update add %s %d A %s\n", name, ttl, address)
+	}
+	for _, address := range ipv6 {
+		fmt.Fprintf(&b, "update add %s %d AAAA %s\n", name, ttl, address)
+	}
+	b.WriteString("send\n")
+	return b.String()
+}
+
+func (p *rfc2136Provider) tsigKey() string {
+	algorithm := p.cfg.TSIGAlgorithm
+	if algorithm == "" {
+		algorithm = defaultTSIGAlgorithm
+	}
+	return fmt.Sprintf("key \"%s\" {\n\talgorithm %s;\n\tsecret \"%s\";\n};\n",
+		strings.TrimSuffix(p.cfg.TSIGKeyName, "."), strings.TrimSuffix(algorithm, "."), p.cfg.TSIGSecret)
+}
+
+func runNsupdate(ctx context.Context, args []string, script string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "nsupdate", args...)
+	cmd.Stdin = strings.NewReader(script)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "nsupdate failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dnsprovider
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+// route53Provider manages the records in a hosted zone of AWS Route 53. The AWS client is configured by the envs and
+// the shared config of the AWS CLI.
+type route53Provider struct {
+	client       route53iface.Route53API
+	hostedZoneID string
+}
+
+func newRoute53Provider(cfg *kubekeyapiv1alpha2.ControlPlaneDNS) (Provider, error) {
+	if cfg.Route53 == nil || cfg.Route53.HostedZoneID == "" {
+		return nil, errors.New("the hosted zone id of route53 is required")
+	}
+	config := aws.NewConfig()
+	if cfg.Route53.Region != "" {
+		config = config.WithRegion(cfg.Route53.Region)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *config,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the AWS session")
+	}
+	return &route53Provider{client: route53.New(sess), hostedZoneID: cfg.Route53.HostedZoneID}, nil
+}
+
+func (p *route53Provider) Sync(ctx context.Context, domain string, addresses []string, ttl int64) error {
+	name := fqdn(domain)
+	output, err := p.client.ListResourceRecordSetsWithContext(ctx, &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(p.hostedZoneID),
+		StartRecordName: aws.String(name),
+		MaxItems:        aws.String("10"),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the records of %s", domain)
+	}
+	existing := make(map[string]*route53.ResourceRecordSet)
+	for _, set := range output.ResourceRecordSets {
+		if aws.StringValue(set.Name) == name {
+			existing[aws.StringValue(set.Type)] = set
+		}
+	}
+
+	ipv4, ipv6 := SplitAddresses(addresses)
+	var changes []*route53.Change
+	for _, records := range []struct {
+		recordType string
+		values     []string
+	}{{route53.RRTypeA, ipv4}, {route53.RRTypeAaaa, ipv6}} {
+		recordType, values := records.recordType, records.values
+		if len(values) == 0 {
+			if set, ok := existing[recordType]; ok {
+				changes = append(changes, &route53.Change{Action: aws.String(route53.ChangeActionDelete), ResourceRecordSet: set})
+			}
+			continue
+		}
+		set := &route53.ResourceRecordSet{
+			Name: aws.String(name),
+			Type: aws.String(recordType),
+			TTL:  aws.Int64(ttl),
+		}
+		for _, value := range values {
+			set.ResourceRecords = append(set.ResourceRecords, &route53.ResourceRecord{Value: aws.String(value)})
+		}
+		changes = append(changes, &route53.Change{Action: aws.String(route53.ChangeActionUpsert), ResourceRecordSet: set})
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if _, err := p.client.ChangeResourceRecordSetsWithContext(ctx, &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: aws.String(p.hostedZoneID),
+		ChangeBatch: &route53.ChangeBatch{
+			Comment: aws.String("Managed by KubeKey"),
+			Changes: changes,
+		},
+	}); err != nil {
+		return errors.Wrapf(err, "failed to change the records of %s", domain)
+	}
+	return nil
+}
@@ -40,6 +40,7 @@ func (h *HaproxyModule) IsSkip() bool {
 
 func (h *HaproxyModule) Init() {
 	h.Name = "InternalLoadbalancerModule"
+	h.Tags = []string{"loadbalancer"}
 	h.Desc = "Install internal load balancer"
 
 	haproxyCfg := &task.RemoteTask{
@@ -63,12 +64,13 @@ func (h *HaproxyModule) Init() {
 	// Calculation config md5 as the checksum.
 	// It will make load balancer reload when config changes.
 	getMd5Sum := &task.RemoteTask{
-		Name:     "GetChecksumFromConfig",
-		Desc:     "Calculate the MD5 value according to haproxy.cfg",
-		Hosts:    h.Runtime.GetHostsByRole(common.Worker),
-		Prepare:  new(common.OnlyWorker),
-		Action:   new(GetChecksum),
-		Parallel: true,
+		Name:      "GetChecksumFromConfig",
+		Desc:      "Calculate the MD5 value according to haproxy.cfg",
+		Hosts:     h.Runtime.GetHostsByRole(common.Worker),
+		Prepare:   new(common.OnlyWorker),
+		Action:    new(GetChecksum),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	haproxyManifestK8s := &task.RemoteTask{
@@ -148,6 +150,7 @@ func (k *KubevipModule) IsSkip() bool {
 
 func (k *KubevipModule) Init() {
 	k.Name = "InternalLoadbalancerModule"
+	k.Tags = []string{"loadbalancer"}
 	k.Desc = "Install internal load balancer"
 
 	checkVIPAddress := &task.RemoteTask{
@@ -160,11 +163,12 @@ func (k *KubevipModule) Init() {
 	}
 
 	getInterface := &task.RemoteTask{
-		Name:     "GetNodeInterface",
-		Desc:     "Get Node Interface",
-		Hosts:    k.Runtime.GetHostsByRole(common.Master),
-		Action:   new(GetInterfaceName),
-		Parallel: true,
+		Name:      "GetNodeInterface",
+		Desc:      "Get Node Interface",
+		Hosts:     k.Runtime.GetHostsByRole(common.Master),
+		Action:    new(GetInterfaceName),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	kubevipManifestOnlyFirstMaster := &task.RemoteTask{
@@ -211,6 +215,7 @@ func (k *K3sHaproxyModule) IsSkip() bool {
 
 func (k *K3sHaproxyModule) Init() {
 	k.Name = "InternalLoadbalancerModule"
+	k.Tags = []string{"loadbalancer"}
 	k.Name = "Install internal load balancer"
 
 	haproxyCfg := &task.RemoteTask{
@@ -234,12 +239,13 @@ func (k *K3sHaproxyModule) Init() {
 	// Calculation config md5 as the checksum.
 	// It will make load balancer reload when config changes.
 	getMd5Sum := &task.RemoteTask{
-		Name:     "GetChecksumFromConfig",
-		Desc:     "Calculate the MD5 value according to haproxy.cfg",
-		Hosts:    k.Runtime.GetHostsByRole(common.Worker),
-		Prepare:  new(common.OnlyWorker),
-		Action:   new(GetChecksum),
-		Parallel: true,
+		Name:      "GetChecksumFromConfig",
+		Desc:      "Calculate the MD5 value according to haproxy.cfg",
+		Hosts:     k.Runtime.GetHostsByRole(common.Worker),
+		Prepare:   new(common.OnlyWorker),
+		Action:    new(GetChecksum),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	haproxyManifestK3s := &task.RemoteTask{
@@ -298,6 +304,7 @@ func (k *K3sKubevipModule) IsSkip() bool {
 
 func (k *K3sKubevipModule) Init() {
 	k.Name = "InternalLoadbalancerModule"
+	k.Tags = []string{"loadbalancer"}
 	k.Name = "Install internal load balancer"
 
 	checkVIPAddress := &task.RemoteTask{
@@ -319,12 +326,13 @@ func (k *K3sKubevipModule) Init() {
 	}
 
 	getInterface := &task.RemoteTask{
-		Name:     "GetNodeInterface",
-		Desc:     "Get Node Interface",
-		Hosts:    k.Runtime.GetHostsByRole(common.Master),
-		Prepare:  new(common.OnlyFirstMaster),
-		Action:   new(GetInterfaceName),
-		Parallel: true,
+		Name:      "GetNodeInterface",
+		Desc:      "Get Node Interface",
+		Hosts:     k.Runtime.GetHostsByRole(common.Master),
+		Prepare:   new(common.OnlyFirstMaster),
+		Action:    new(GetInterfaceName),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	kubevipDaemonsetK3s := &task.RemoteTask{
@@ -358,11 +366,12 @@ func (k *DeleteVIPModule) Init() {
 	k.Desc = "Delete VIP"
 
 	getInterface := &task.RemoteTask{
-		Name:     "GetNodeInterface",
-		Desc:     "Get Node Interface",
-		Hosts:    k.Runtime.GetHostsByRole(common.Master),
-		Action:   new(GetInterfaceName),
-		Parallel: true,
+		Name:      "GetNodeInterface",
+		Desc:      "Get Node Interface",
+		Hosts:     k.Runtime.GetHostsByRole(common.Master),
+		Action:    new(GetInterfaceName),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	DeleteVIP := &task.RemoteTask{
@@ -378,3 +387,36 @@ func (k *DeleteVIPModule) Init() {
 		DeleteVIP,
 	}
 }
+
+// DNSRecordsModule points the managed records of the control-plane endpoint domain to the control-plane nodes.
+type DNSRecordsModule struct {
+	common.KubeModule
+	Skip bool
+	// FirstMasterOnly points the records to the first master only, which is the only one serving before the others join.
+	FirstMasterOnly bool
+	// Exclude is the name of the node being deleted, whose addresses are removed from the records.
+	Exclude string
+}
+
+func (d *DNSRecordsModule) IsSkip() bool {
+	return d.Skip
+}
+
+func (d *DNSRecordsModule) Init() {
+	d.Name = "DNSRecordsModule"
+	d.Desc = "Update the DNS records of the control-plane endpoint"
+
+	syncRecords := &task.LocalTask{
+		Name: "SyncDNSRecords",
+		Desc: "Point the control-plane endpoint domain to the control-plane nodes",
+		Action: &SyncDNSRecords{
+			FirstMasterOnly: d.FirstMasterOnly,
+			Exclude:         d.Exclude,
+		},
+		Retry: 3,
+	}
+
+	d.Tasks = []task.Interface{
+		syncRecords,
+	}
+}
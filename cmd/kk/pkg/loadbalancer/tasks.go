@@ -17,18 +17,22 @@
 package loadbalancer
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/images"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/loadbalancer/dnsprovider"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/loadbalancer/templates"
 )
 
@@ -322,3 +326,39 @@ func (g *DeleteVIP) Execute(runtime connector.Runtime) error {
 	runtime.GetRunner().SudoCmd(cmd, false)
 	return nil
 }
+
+type SyncDNSRecords struct {
+	common.KubeAction
+	FirstMasterOnly bool
+	Exclude         string
+}
+
+func (s *SyncDNSRecords) Execute(runtime connector.Runtime) error {
+	masters := runtime.GetHostsByRole(common.Master)
+	if s.FirstMasterOnly && len(masters) > 0 {
+		masters = masters[:1]
+	}
+	var addresses []string
+	for _, host := range masters {
+		if host.GetName() == s.Exclude {
+			continue
+		}
+		addresses = append(addresses, host.GetInternalIPv4Address())
+		if host.GetInternalIPv6Address() != "" {
+			addresses = append(addresses, host.GetInternalIPv6Address())
+		}
+	}
+	if len(addresses) == 0 {
+		return errors.New("no control-plane node is left for the records of the control-plane endpoint")
+	}
+
+	endpoint := s.KubeConf.Cluster.ControlPlaneEndpoint
+	logger.Log.Infof("Point %s to %s", endpoint.Domain, strings.Join(addresses, ", "))
+	provider, err := dnsprovider.New(endpoint.DNS)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	return provider.Sync(ctx, endpoint.Domain, addresses, endpoint.DNS.TTL)
+}
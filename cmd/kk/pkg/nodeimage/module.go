@@ -0,0 +1,54 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package nodeimage
+
+import (
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+)
+
+// BakeModule records the steps baked into the node image on the host prepared by kk prepare-image, and generalizes
+// the host before it is captured as an image.
+type BakeModule struct {
+	common.KubeModule
+	Steps []string
+}
+
+func (b *BakeModule) Init() {
+	b.Name = "BakeModule"
+	b.Tags = []string{"node-image"}
+	b.Desc = "Bake the node image"
+
+	record := &task.RemoteTask{
+		Name:   "RecordImage",
+		Desc:   "Record the steps baked into the node image",
+		Hosts:  b.Runtime.GetAllHosts(),
+		Action: &RecordImage{Steps: b.Steps},
+	}
+
+	generalize := &task.RemoteTask{
+		Name:   "GeneralizeImage",
+		Desc:   "Remove the owner, the temporary files and the machine id of the host",
+		Hosts:  b.Runtime.GetAllHosts(),
+		Action: new(GeneralizeImage),
+	}
+
+	b.Tasks = []task.Interface{
+		record,
+		generalize,
+	}
+}
@@ -0,0 +1,82 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package nodeimage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/facts"
+	"github.com/kubesphere/kubekey/v3/version"
+)
+
+// RecordImage writes the steps baked into the node image to the ImageFile of the host, the joins of the hosts booted
+// from the image skip them.
+type RecordImage struct {
+	common.KubeAction
+	Steps []string
+}
+
+func (r *RecordImage) Execute(runtime connector.Runtime) error {
+	kubernetes := r.KubeConf.Cluster.Kubernetes
+	image := facts.Image{
+		KubeKeyVersion:   version.Get().GitVersion,
+		KubeVersion:      kubernetes.Version,
+		ContainerManager: kubernetes.ContainerManager,
+		Steps:            r.Steps,
+		BakedAt:          time.Now().UTC(),
+	}
+	content, err := json.MarshalIndent(image, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encode the node image failed")
+	}
+	cmd := fmt.Sprintf("mkdir -p %s && echo %s | base64 -d > %s", path.Dir(facts.ImageFile),
+		base64.StdEncoding.EncodeToString(content), facts.ImageFile)
+	if _, err := runtime.GetRunner().SudoCmd(cmd, false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "write %s failed", facts.ImageFile)
+	}
+	return nil
+}
+
+// generalizeCommands remove what identifies the host the image is baked on: the owner of the node, the temporary
+// files of KubeKey, and the machine id, which is generated again on the first boot of each host of the image.
+var generalizeCommands = []string{
+	fmt.Sprintf("rm -f %s", common.OwnerFile),
+	fmt.Sprintf("rm -rf %s", common.TmpDir),
+	"truncate -s 0 /etc/machine-id",
+	"rm -f /var/lib/dbus/machine-id",
+}
+
+// GeneralizeImage removes what identifies the host, so the hosts booted from the image are told apart.
+type GeneralizeImage struct {
+	common.KubeAction
+}
+
+func (g *GeneralizeImage) Execute(runtime connector.Runtime) error {
+	if _, err := runtime.GetRunner().SudoCmd(strings.Join(generalizeCommands, " && "), false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "generalize the node image failed")
+	}
+	return nil
+}
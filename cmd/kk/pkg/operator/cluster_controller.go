@@ -0,0 +1,242 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package operator reconciles the Cluster and Pipeline custom resources in the cluster, by running the KubeKey
+// pipelines from the operator.
+package operator
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	kubekeyv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+const (
+	// ClusterLabel is the label of the pipelines with the name of their cluster.
+	ClusterLabel = "kubekey.kubesphere.io/cluster"
+
+	// ReadyCondition is true when the cluster is reconciled to the generation of its spec.
+	ReadyCondition = "Ready"
+	// ProgressingCondition is true when a pipeline is running on the cluster.
+	ProgressingCondition = "Progressing"
+)
+
+var hostsRange = regexp.MustCompile(`\[(\d+):(\d+)\]`)
+
+// +kubebuilder:rbac:groups=kubekey.kubesphere.io,resources=clusters,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=kubekey.kubesphere.io,resources=clusters/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=kubekey.kubesphere.io,resources=pipelines,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=kubekey.kubesphere.io,resources=pipelines/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// ClusterReconciler reconciles a Cluster to its spec by creating a Pipeline for each difference with its status:
+// create the cluster, add the new nodes, delete the nodes removed from the role groups and upgrade the version. One
+// pipeline runs on a cluster at a time.
+type ClusterReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+func (r *ClusterReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(options).
+		For(&kubekeyv1alpha2.Cluster{}).
+		Owns(&kubekeyv1alpha2.Pipeline{}).
+		Complete(r)
+}
+
+func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	cluster := &kubekeyv1alpha2.Cluster{}
+	if err := r.Get(ctx, req.NamespacedName, cluster); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	pipelines := &kubekeyv1alpha2.PipelineList{}
+	if err := r.List(ctx, pipelines, client.InNamespace(cluster.Namespace), client.MatchingLabels{ClusterLabel: cluster.Name}); err != nil {
+		return ctrl.Result{}, err
+	}
+	existing := make(map[string]*kubekeyv1alpha2.Pipeline, len(pipelines.Items))
+	for i := range pipelines.Items {
+		p := &pipelines.Items[i]
+		if !p.IsFinished() {
+			// the cluster is reconciled again when the pipeline finishes
+			return ctrl.Result{}, nil
+		}
+		existing[p.Name] = p
+	}
+
+	// the result of the last pipeline is the observed state of the cluster
+	if last, ok := existing[cluster.Status.Pipeline]; ok && last.Status.Phase == kubekeyv1alpha2.PipelineSucceeded {
+		cluster.Status.Version = last.Status.Version
+		cluster.Status.Nodes = last.Status.Nodes
+	}
+
+	op, node := NextOperation(cluster)
+	if op == "" {
+		cluster.Status.ObservedGeneration = cluster.Generation
+		setCondition(cluster, ReadyCondition, metav1.ConditionTrue, "Reconciled", "")
+		setCondition(cluster, ProgressingCondition, metav1.ConditionFalse, "Reconciled", "")
+		return ctrl.Result{}, r.Status().Update(ctx, cluster)
+	}
+
+	name := pipelineName(cluster, op, node)
+	if p, ok := existing[name]; ok {
+		// the pipeline isn't run again until the spec changes or the pipeline is deleted
+		message := fmt.Sprintf("pipeline %s failed: %s", p.Name, p.Status.Message)
+		if p.Status.Phase == kubekeyv1alpha2.PipelineSucceeded {
+			message = fmt.Sprintf("pipeline %s succeeded, but the cluster still differs from its spec", p.Name)
+		}
+		setCondition(cluster, ReadyCondition, metav1.ConditionFalse, "PipelineFailed", message)
+		setCondition(cluster, ProgressingCondition, metav1.ConditionFalse, "PipelineFailed", "")
+		return ctrl.Result{}, r.Status().Update(ctx, cluster)
+	}
+
+	p := &kubekeyv1alpha2.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cluster.Namespace,
+			Labels:    map[string]string{ClusterLabel: cluster.Name},
+		},
+		Spec: kubekeyv1alpha2.PipelineSpec{
+			Cluster:   cluster.Name,
+			Operation: op,
+			Node:      node,
+		},
+	}
+	if err := controllerutil.SetControllerReference(cluster, p, r.Scheme); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.Create(ctx, p); err != nil {
+		return ctrl.Result{}, err
+	}
+	log.Info("created pipeline", "pipeline", name, "operation", op)
+	r.Recorder.Eventf(cluster, corev1.EventTypeNormal, "PipelineCreated", "Created pipeline %s to run %s", name, op)
+
+	cluster.Status.Pipeline = name
+	setCondition(cluster, ReadyCondition, metav1.ConditionFalse, string(op), "")
+	setCondition(cluster, ProgressingCondition, metav1.ConditionTrue, string(op), fmt.Sprintf("pipeline %s is running", name))
+	return ctrl.Result{}, r.Status().Update(ctx, cluster)
+}
+
+// NextOperation returns the operation, which reconciles the cluster to its spec, and the node of DeleteNode. It is
+// empty when the status of the cluster matches with its spec.
+func NextOperation(cluster *kubekeyv1alpha2.Cluster) (kubekeyv1alpha2.PipelineOperation, string) {
+	if cluster.Status.Version == "" {
+		return kubekeyv1alpha2.CreateClusterOperation, ""
+	}
+
+	desired := NodeNames(&cluster.Spec)
+	current := make(map[string]struct{}, len(cluster.Status.Nodes))
+	for _, name := range cluster.Status.Nodes {
+		current[name] = struct{}{}
+	}
+	for _, name := range desired {
+		if _, ok := current[name]; !ok {
+			return kubekeyv1alpha2.AddNodesOperation, ""
+		}
+		delete(current, name)
+	}
+	for _, name := range cluster.Status.Nodes {
+		if _, ok := current[name]; ok {
+			return kubekeyv1alpha2.DeleteNodeOperation, name
+		}
+	}
+
+	if DesiredVersion(&cluster.Spec) != cluster.Status.Version {
+		return kubekeyv1alpha2.UpgradeClusterOperation, ""
+	}
+	return "", ""
+}
+
+// DesiredVersion returns the kubernetes version of the spec, or the default version if it isn't set.
+func DesiredVersion(spec *kubekeyv1alpha2.ClusterSpec) string {
+	if spec.Kubernetes.Version == "" {
+		return kubekeyv1alpha2.DefaultKubeVersion
+	}
+	return spec.Kubernetes.Version
+}
+
+// NodeNames returns the sorted names of the hosts in the master, control-plane and worker role groups, with the
+// ranges like node[1:3] expanded.
+func NodeNames(spec *kubekeyv1alpha2.ClusterSpec) []string {
+	seen := make(map[string]struct{})
+	var names []string
+	for _, role := range []string{kubekeyv1alpha2.Master, kubekeyv1alpha2.ControlPlane, kubekeyv1alpha2.Worker} {
+		for _, host := range spec.RoleGroups[role] {
+			for _, name := range expandHosts(host) {
+				if _, ok := seen[name]; !ok {
+					seen[name] = struct{}{}
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func expandHosts(host string) []string {
+	m := hostsRange.FindStringSubmatch(host)
+	if m == nil {
+		return []string{host}
+	}
+	prefix := strings.Split(host, m[0])[0]
+	start, _ := strconv.Atoi(m[1])
+	end, _ := strconv.Atoi(m[2])
+	names := make([]string, 0, end-start+1)
+	for i := start; i <= end; i++ {
+		names = append(names, fmt.Sprintf("%s%d", prefix, i))
+	}
+	return names
+}
+
+// pipelineName is unique for the operation on a generation of the cluster, so a failed operation isn't retried
+// until the spec changes.
+func pipelineName(cluster *kubekeyv1alpha2.Cluster, op kubekeyv1alpha2.PipelineOperation, node string) string {
+	name := fmt.Sprintf("%s-%d-%s", cluster.Name, cluster.Generation, strings.ToLower(string(op)))
+	if node != "" {
+		name += "-" + node
+	}
+	return name
+}
+
+func setCondition(cluster *kubekeyv1alpha2.Cluster, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: cluster.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
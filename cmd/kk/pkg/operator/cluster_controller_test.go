@@ -0,0 +1,168 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kubekeyv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+)
+
+func TestNodeNames(t *testing.T) {
+	spec := &kubekeyv1alpha2.ClusterSpec{
+		RoleGroups: map[string][]string{
+			kubekeyv1alpha2.Etcd:   {"etcd1"},
+			kubekeyv1alpha2.Master: {"node1"},
+			kubekeyv1alpha2.Worker: {"node1", "node[2:3]"},
+		},
+	}
+	if got, want := NodeNames(spec), []string{"node1", "node2", "node3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("NodeNames() = %v, want %v", got, want)
+	}
+}
+
+func TestNextOperation(t *testing.T) {
+	spec := kubekeyv1alpha2.ClusterSpec{
+		RoleGroups: map[string][]string{
+			kubekeyv1alpha2.Master: {"node1"},
+			kubekeyv1alpha2.Worker: {"node2"},
+		},
+		Kubernetes: kubekeyv1alpha2.Kubernetes{Version: "v1.24.9"},
+	}
+	tests := []struct {
+		name     string
+		status   kubekeyv1alpha2.ClusterStatus
+		wantOp   kubekeyv1alpha2.PipelineOperation
+		wantNode string
+	}{
+		{
+			name:   "not created",
+			wantOp: kubekeyv1alpha2.CreateClusterOperation,
+		},
+		{
+			name:   "new node",
+			status: kubekeyv1alpha2.ClusterStatus{Version: "v1.23.10", Nodes: []string{"node1"}},
+			wantOp: kubekeyv1alpha2.AddNodesOperation,
+		},
+		{
+			name:     "removed node",
+			status:   kubekeyv1alpha2.ClusterStatus{Version: "v1.24.9", Nodes: []string{"node1", "node2", "node3"}},
+			wantOp:   kubekeyv1alpha2.DeleteNodeOperation,
+			wantNode: "node3",
+		},
+		{
+			name:   "new version",
+			status: kubekeyv1alpha2.ClusterStatus{Version: "v1.23.10", Nodes: []string{"node1", "node2"}},
+			wantOp: kubekeyv1alpha2.UpgradeClusterOperation,
+		},
+		{
+			name:   "reconciled",
+			status: kubekeyv1alpha2.ClusterStatus{Version: "v1.24.9", Nodes: []string{"node1", "node2"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op, node := NextOperation(&kubekeyv1alpha2.Cluster{Spec: spec, Status: tt.status})
+			if op != tt.wantOp || node != tt.wantNode {
+				t.Errorf("NextOperation() = %q, %q, want %q, %q", op, node, tt.wantOp, tt.wantNode)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := kubekeyv1alpha2.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	cluster := &kubekeyv1alpha2.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default", Generation: 1},
+		Spec: kubekeyv1alpha2.ClusterSpec{
+			Hosts:      []kubekeyv1alpha2.HostCfg{{Name: "node1", Address: "192.168.0.2"}},
+			RoleGroups: map[string][]string{kubekeyv1alpha2.Master: {"node1"}, kubekeyv1alpha2.Worker: {"node1"}},
+			Kubernetes: kubekeyv1alpha2.Kubernetes{Version: "v1.24.9"},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build()
+
+	var ran []kubekeyv1alpha2.PipelineOperation
+	clusters := &ClusterReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+	pipelines := &PipelineReconciler{
+		Client:   c,
+		Scheme:   scheme,
+		Recorder: record.NewFakeRecorder(10),
+		WorkDir:  t.TempDir(),
+		Run: func(op kubekeyv1alpha2.PipelineOperation, arg common.Argument) error {
+			if !arg.SkipConfirmCheck || !arg.NoTUI {
+				t.Errorf("pipeline argument %+v isn't unattended", arg)
+			}
+			ran = append(ran, op)
+			return nil
+		},
+	}
+
+	ctx := context.Background()
+	key := types.NamespacedName{Namespace: "default", Name: "sample"}
+	if _, err := clusters.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(ctx, key, cluster); err != nil {
+		t.Fatal(err)
+	}
+	if cluster.Status.Pipeline != "sample-1-createcluster" {
+		t.Fatalf("pipeline = %q, want sample-1-createcluster", cluster.Status.Pipeline)
+	}
+
+	pipelineKey := types.NamespacedName{Namespace: "default", Name: cluster.Status.Pipeline}
+	if _, err := pipelines.Reconcile(ctx, ctrl.Request{NamespacedName: pipelineKey}); err != nil {
+		t.Fatal(err)
+	}
+	p := &kubekeyv1alpha2.Pipeline{}
+	if err := c.Get(ctx, pipelineKey, p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Status.Phase != kubekeyv1alpha2.PipelineSucceeded || p.Status.Attempts != 1 {
+		t.Errorf("pipeline status = %+v, want succeeded on the first attempt", p.Status)
+	}
+
+	if _, err := clusters.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(ctx, key, cluster); err != nil {
+		t.Fatal(err)
+	}
+	if !meta.IsStatusConditionTrue(cluster.Status.Conditions, ReadyCondition) {
+		t.Errorf("conditions = %+v, want ready", cluster.Status.Conditions)
+	}
+	if cluster.Status.Version != "v1.24.9" || !reflect.DeepEqual(cluster.Status.Nodes, []string{"node1"}) {
+		t.Errorf("status = %s %v, want v1.24.9 [node1]", cluster.Status.Version, cluster.Status.Nodes)
+	}
+	if !reflect.DeepEqual(ran, []kubekeyv1alpha2.PipelineOperation{kubekeyv1alpha2.CreateClusterOperation}) {
+		t.Errorf("ran %v, want [CreateCluster]", ran)
+	}
+}
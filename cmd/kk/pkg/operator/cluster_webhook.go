@@ -0,0 +1,110 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kubekeyv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/version/kubernetes"
+)
+
+// +kubebuilder:webhook:path=/validate-kubekey-kubesphere-io-v1alpha2-cluster,mutating=false,failurePolicy=fail,sideEffects=None,groups=kubekey.kubesphere.io,resources=clusters,verbs=create;update,versions=v1alpha2,name=validation.cluster.kubekey.kubesphere.io,admissionReviewVersions=v1
+
+// ClusterValidator rejects the Cluster resources with an invalid spec, before a pipeline runs them.
+type ClusterValidator struct{}
+
+var _ admission.CustomValidator = &ClusterValidator{}
+
+// SetupWebhookWithManager registers the validating webhook of the Cluster resources.
+func (v *ClusterValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&kubekeyv1alpha2.Cluster{}).
+		WithValidator(v).
+		Complete()
+}
+
+// ValidateCreate implements admission.CustomValidator.
+func (v *ClusterValidator) ValidateCreate(_ context.Context, obj runtime.Object) error {
+	cluster, ok := obj.(*kubekeyv1alpha2.Cluster)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a Cluster but got a %T", obj))
+	}
+
+	allErrs := validateSpec(cluster)
+	return aggregateObjErrors(cluster, allErrs)
+}
+
+// ValidateUpdate implements admission.CustomValidator.
+func (v *ClusterValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) error {
+	oldCluster, ok := oldObj.(*kubekeyv1alpha2.Cluster)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a Cluster but got a %T", oldObj))
+	}
+	cluster, ok := newObj.(*kubekeyv1alpha2.Cluster)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a Cluster but got a %T", newObj))
+	}
+
+	allErrs := validateSpec(cluster)
+	// the cluster is upgraded from the version it runs, or from the version it was created with
+	current := oldCluster.Status.Version
+	if current == "" {
+		current = oldCluster.Spec.Kubernetes.Version
+	}
+	if current != "" && cluster.Spec.Kubernetes.Version != "" {
+		allErrs = append(allErrs, kubekeyv1alpha2.ValidateVersionSkew(current, cluster.Spec.Kubernetes.Version,
+			field.NewPath("spec", "kubernetes", "version"))...)
+	}
+	return aggregateObjErrors(cluster, allErrs)
+}
+
+// ValidateDelete implements admission.CustomValidator.
+func (v *ClusterValidator) ValidateDelete(_ context.Context, _ runtime.Object) error {
+	return nil
+}
+
+func validateSpec(cluster *kubekeyv1alpha2.Cluster) field.ErrorList {
+	allErrs := cluster.Spec.Validate(field.NewPath("spec"))
+
+	version := cluster.Spec.Kubernetes.Version
+	if version == "" || (cluster.Spec.Kubernetes.Type != "" && cluster.Spec.Kubernetes.Type != common.Kubernetes) {
+		return allErrs
+	}
+	for _, supported := range kubernetes.SupportedK8sVersionList() {
+		if supported == version {
+			return allErrs
+		}
+	}
+	return append(allErrs, field.NotSupported(field.NewPath("spec", "kubernetes", "version"), version,
+		kubernetes.SupportedK8sVersionList()))
+}
+
+func aggregateObjErrors(cluster *kubekeyv1alpha2.Cluster, allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(kubekeyv1alpha2.GroupVersion.WithKind("Cluster").GroupKind(), cluster.Name, allErrs)
+}
@@ -0,0 +1,74 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubekeyv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+func TestClusterValidator(t *testing.T) {
+	newCluster := func(version string) *kubekeyv1alpha2.Cluster {
+		return &kubekeyv1alpha2.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+			Spec: kubekeyv1alpha2.ClusterSpec{
+				Hosts: []kubekeyv1alpha2.HostCfg{{Name: "node1", Address: "192.168.0.2"}},
+				RoleGroups: map[string][]string{
+					kubekeyv1alpha2.Etcd:   {"node1"},
+					kubekeyv1alpha2.Master: {"node1"},
+					kubekeyv1alpha2.Worker: {"node1"},
+				},
+				Kubernetes: kubekeyv1alpha2.Kubernetes{Version: version},
+			},
+		}
+	}
+	upgraded := func(current, desired string) *kubekeyv1alpha2.Cluster {
+		cluster := newCluster(desired)
+		cluster.Status.Version = current
+		return cluster
+	}
+
+	tests := []struct {
+		name    string
+		old     *kubekeyv1alpha2.Cluster
+		cluster *kubekeyv1alpha2.Cluster
+		wantErr bool
+	}{
+		{name: "create", cluster: newCluster("v1.24.9")},
+		{name: "create with an unsupported version", cluster: newCluster("v1.24.99"), wantErr: true},
+		{name: "upgrade", old: upgraded("v1.23.15", "v1.23.15"), cluster: upgraded("v1.23.15", "v1.24.9")},
+		{name: "downgrade", old: upgraded("v1.24.9", "v1.24.9"), cluster: upgraded("v1.24.9", "v1.23.15"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &ClusterValidator{}
+			var err error
+			if tt.old == nil {
+				err = v.ValidateCreate(context.Background(), tt.cluster)
+			} else {
+				err = v.ValidateUpdate(context.Background(), tt.old, tt.cluster)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validate error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
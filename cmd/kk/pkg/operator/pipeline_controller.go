@@ -0,0 +1,223 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/yaml"
+
+	kubekeyv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/pipelines"
+)
+
+// PipelineReconciler runs the pipelines, one at a time. A pipeline, which was running when the operator restarted,
+// is resumed from its checkpoint.
+type PipelineReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// WorkDir is where the cluster configs of the pipelines are written.
+	WorkDir string
+	// Argument is the base argument of the pipelines, e.g. the debug.
+	Argument common.Argument
+	// Run runs the operation with the argument, RunPipeline by default.
+	Run func(op kubekeyv1alpha2.PipelineOperation, arg common.Argument) error
+}
+
+func (r *PipelineReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
+	// the pipelines share the global state of KubeKey, e.g. the logger, so they can't run concurrently
+	options.MaxConcurrentReconciles = 1
+	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(options).
+		For(&kubekeyv1alpha2.Pipeline{}).
+		Complete(r)
+}
+
+func (r *PipelineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := ctrl.LoggerFrom(ctx)
+
+	p := &kubekeyv1alpha2.Pipeline{}
+	if err := r.Get(ctx, req.NamespacedName, p); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if p.IsFinished() {
+		return ctrl.Result{}, nil
+	}
+
+	cluster := &kubekeyv1alpha2.Cluster{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: p.Namespace, Name: p.Spec.Cluster}, cluster); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.finish(ctx, p, errors.Errorf("cluster %s not found", p.Spec.Cluster))
+	}
+
+	// the pipeline was interrupted by a restart of the operator
+	resume := p.Status.Phase == kubekeyv1alpha2.PipelineRunning
+	p.Status.Phase = kubekeyv1alpha2.PipelineRunning
+	p.Status.Attempts++
+	if p.Status.StartTime == nil {
+		now := metav1.Now()
+		p.Status.StartTime = &now
+	}
+	p.Status.Version, p.Status.Nodes = result(cluster, p)
+	if err := r.Status().Update(ctx, p); err != nil {
+		return ctrl.Result{}, err
+	}
+	if resume {
+		r.Recorder.Eventf(p, corev1.EventTypeNormal, "Resumed", "Resumed %s on cluster %s", p.Spec.Operation, cluster.Name)
+	} else {
+		r.Recorder.Eventf(p, corev1.EventTypeNormal, "Started", "Started %s on cluster %s", p.Spec.Operation, cluster.Name)
+	}
+	log.Info("running pipeline", "operation", p.Spec.Operation, "cluster", cluster.Name, "resume", resume)
+
+	err := r.run(ctx, cluster, p, resume)
+	return ctrl.Result{}, r.finish(ctx, p, err)
+}
+
+// run writes the cluster config of the pipeline and runs it, with its progress recorded into the status of the
+// cluster.
+func (r *PipelineReconciler) run(ctx context.Context, cluster *kubekeyv1alpha2.Cluster, p *kubekeyv1alpha2.Pipeline, resume bool) error {
+	file, err := r.writeConfig(cluster, p)
+	if err != nil {
+		return err
+	}
+
+	arg := r.Argument
+	arg.FilePath = file
+	arg.NodeName = p.Spec.Node
+	arg.Resume = resume
+	arg.SkipConfirmCheck = true
+	arg.NoTUI = true
+	recorder := NewStatusRecorder(ctx, r.Client, cluster, p.Name)
+	defer recorder.Stop()
+	arg.ProgressRecorder = recorder
+
+	run := r.Run
+	if run == nil {
+		run = RunPipeline
+	}
+	return run(p.Spec.Operation, arg)
+}
+
+// writeConfig writes the cluster config file of KubeKey from the spec of the cluster. The node deleted by the
+// pipeline is added back to the worker role group, so its OS is cleared if it is still in the hosts.
+func (r *PipelineReconciler) writeConfig(cluster *kubekeyv1alpha2.Cluster, p *kubekeyv1alpha2.Pipeline) (string, error) {
+	spec := cluster.Spec.DeepCopy()
+	if p.Spec.Operation == kubekeyv1alpha2.DeleteNodeOperation {
+		if spec.RoleGroups == nil {
+			spec.RoleGroups = make(map[string][]string)
+		}
+		spec.RoleGroups[kubekeyv1alpha2.Worker] = append(spec.RoleGroups[kubekeyv1alpha2.Worker], p.Spec.Node)
+	}
+	cfg := &kubekeyv1alpha2.Cluster{
+		TypeMeta:   metav1.TypeMeta{APIVersion: kubekeyv1alpha2.GroupVersion.String(), Kind: "Cluster"},
+		ObjectMeta: metav1.ObjectMeta{Name: cluster.Name},
+		Spec:       *spec,
+	}
+	content, err := yaml.Marshal(cfg)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal the cluster config")
+	}
+
+	dir := filepath.Join(r.WorkDir, cluster.Namespace)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", errors.Wrap(err, "failed to create the config dir")
+	}
+	// the config contains the credentials of the hosts
+	file := filepath.Join(dir, cluster.Name+".yaml")
+	if err := os.WriteFile(file, content, 0600); err != nil {
+		return "", errors.Wrap(err, "failed to write the cluster config")
+	}
+	return file, nil
+}
+
+// finish records the result of the pipeline, retrying on the conflicts with the changes made while it was running.
+func (r *PipelineReconciler) finish(ctx context.Context, p *kubekeyv1alpha2.Pipeline, runErr error) error {
+	phase, message := kubekeyv1alpha2.PipelineSucceeded, ""
+	if runErr != nil {
+		phase, message = kubekeyv1alpha2.PipelineFailed, runErr.Error()
+		r.Recorder.Eventf(p, corev1.EventTypeWarning, "Failed", "%s failed: %v", p.Spec.Operation, runErr)
+	} else {
+		r.Recorder.Eventf(p, corev1.EventTypeNormal, "Succeeded", "%s succeeded", p.Spec.Operation)
+	}
+
+	key := client.ObjectKeyFromObject(p)
+	status := p.Status
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := r.Get(ctx, key, p); err != nil {
+			return err
+		}
+		now := metav1.NewTime(time.Now())
+		p.Status = status
+		p.Status.Phase = phase
+		p.Status.Message = message
+		p.Status.CompletionTime = &now
+		return r.Status().Update(ctx, p)
+	})
+}
+
+// result returns the version and the nodes of the cluster after the pipeline succeeds.
+func result(cluster *kubekeyv1alpha2.Cluster, p *kubekeyv1alpha2.Pipeline) (string, []string) {
+	switch p.Spec.Operation {
+	case kubekeyv1alpha2.CreateClusterOperation:
+		return DesiredVersion(&cluster.Spec), NodeNames(&cluster.Spec)
+	case kubekeyv1alpha2.AddNodesOperation:
+		return cluster.Status.Version, NodeNames(&cluster.Spec)
+	case kubekeyv1alpha2.DeleteNodeOperation:
+		nodes := make([]string, 0, len(cluster.Status.Nodes))
+		for _, name := range cluster.Status.Nodes {
+			if name != p.Spec.Node {
+				nodes = append(nodes, name)
+			}
+		}
+		return cluster.Status.Version, nodes
+	default:
+		return DesiredVersion(&cluster.Spec), cluster.Status.Nodes
+	}
+}
+
+// RunPipeline runs the KubeKey pipeline of the operation.
+func RunPipeline(op kubekeyv1alpha2.PipelineOperation, arg common.Argument) error {
+	switch op {
+	case kubekeyv1alpha2.CreateClusterOperation:
+		return pipelines.CreateCluster(arg)
+	case kubekeyv1alpha2.AddNodesOperation:
+		return pipelines.AddNodes(arg)
+	case kubekeyv1alpha2.DeleteNodeOperation:
+		return pipelines.DeleteNode(arg)
+	case kubekeyv1alpha2.UpgradeClusterOperation:
+		return pipelines.UpgradeCluster(arg)
+	default:
+		return errors.Errorf("unsupported operation %s", op)
+	}
+}
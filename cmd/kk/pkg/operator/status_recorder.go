@@ -0,0 +1,258 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kubekeyv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/ending"
+)
+
+// ProgressInterval is the min interval of the updates of the progress in the status of the cluster. The phases and
+// the failures are updated at once.
+var ProgressInterval = 5 * time.Second
+
+// taskRunning is the state of the task running on a host.
+const taskRunning = "running"
+
+// StatusRecorder records the progress of a pipeline into the status of its cluster. The tasks on the hosts are
+// recorded concurrently into the progress in memory, which is written to the status in the background, with the
+// conflicts with the other writers of the status retried.
+type StatusRecorder struct {
+	client client.Client
+	key    client.ObjectKey
+	ctx    context.Context
+
+	mu       sync.Mutex
+	progress *kubekeyv1alpha2.ClusterProgress
+	dirty    bool
+
+	notify chan struct{}
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewStatusRecorder returns the recorder of the pipeline on the cluster, and starts writing its progress. The progress
+// of the same pipeline in the status is kept, so the progress of a pipeline resumed after a restart of the operator
+// goes on from where it stopped.
+func NewStatusRecorder(ctx context.Context, c client.Client, cluster *kubekeyv1alpha2.Cluster, pipeline string) *StatusRecorder {
+	progress := &kubekeyv1alpha2.ClusterProgress{Pipeline: pipeline}
+	if last := cluster.Status.Progress; last != nil && last.Pipeline == pipeline {
+		progress = last.DeepCopy()
+	}
+	progress.ObservedGeneration = cluster.Generation
+
+	r := &StatusRecorder{
+		client:   c,
+		key:      client.ObjectKeyFromObject(cluster),
+		ctx:      ctx,
+		progress: progress,
+		dirty:    true,
+		notify:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	r.wg.Add(1)
+	go r.loop()
+	return r
+}
+
+func (r *StatusRecorder) StartPhase(phase string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := metav1.Now()
+	r.progress.Phase = phase
+	for i := range r.progress.Hosts {
+		completePhases(&r.progress.Hosts[i], phase, now)
+	}
+	r.changed(true)
+}
+
+func (r *StatusRecorder) StartTask(task string, hosts []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := metav1.Now()
+	for _, name := range hosts {
+		h := r.host(name)
+		h.Task, h.State = task, taskRunning
+		r.runPhase(h, now)
+	}
+	r.changed(false)
+}
+
+func (r *StatusRecorder) FinishTask(host, task, state string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := metav1.Now()
+	h := r.host(host)
+	h.Task, h.State = task, state
+	phase := r.runPhase(h, now)
+	if state != ending.FAILED.String() {
+		r.changed(false)
+		return
+	}
+	phase.State, phase.CompletionTime = kubekeyv1alpha2.PhaseFailed, &now
+	h.LastError, h.LastErrorTime = fmt.Sprintf("%s failed", task), &now
+	if err != nil {
+		h.LastError = err.Error()
+	}
+	r.changed(true)
+}
+
+func (r *StatusRecorder) Finish(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil {
+		now := metav1.Now()
+		for i := range r.progress.Hosts {
+			completePhases(&r.progress.Hosts[i], "", now)
+		}
+	}
+	r.changed(true)
+}
+
+// Stop writes the last progress to the status and stops the recorder.
+func (r *StatusRecorder) Stop() {
+	close(r.done)
+	r.wg.Wait()
+}
+
+// host returns the progress of the host, which is added in the order of the names if the pipeline hasn't run on it.
+// The progress is valid until the next host is added.
+func (r *StatusRecorder) host(name string) *kubekeyv1alpha2.HostProgress {
+	hosts := r.progress.Hosts
+	i := sort.Search(len(hosts), func(i int) bool { return hosts[i].Name >= name })
+	if i == len(hosts) || hosts[i].Name != name {
+		hosts = append(hosts, kubekeyv1alpha2.HostProgress{})
+		copy(hosts[i+1:], hosts[i:])
+		hosts[i] = kubekeyv1alpha2.HostProgress{Name: name}
+		r.progress.Hosts = hosts
+	}
+	return &r.progress.Hosts[i]
+}
+
+// runPhase returns the progress of the running phase on the host, which is started if it isn't running. A phase
+// failed in a previous attempt runs again.
+func (r *StatusRecorder) runPhase(h *kubekeyv1alpha2.HostProgress, now metav1.Time) *kubekeyv1alpha2.PhaseProgress {
+	phase := h.Phase(r.progress.Phase)
+	if phase == nil {
+		h.Phases = append(h.Phases, kubekeyv1alpha2.PhaseProgress{Name: r.progress.Phase})
+		phase = &h.Phases[len(h.Phases)-1]
+	}
+	if phase.State != kubekeyv1alpha2.PhaseRunning {
+		phase.State, phase.StartTime, phase.CompletionTime = kubekeyv1alpha2.PhaseRunning, &now, nil
+	}
+	return phase
+}
+
+// completePhases completes the phases running on the host except the given one.
+func completePhases(h *kubekeyv1alpha2.HostProgress, except string, now metav1.Time) {
+	for i := range h.Phases {
+		if phase := &h.Phases[i]; phase.Name != except && phase.State == kubekeyv1alpha2.PhaseRunning {
+			phase.State, phase.CompletionTime = kubekeyv1alpha2.PhaseSucceeded, &now
+		}
+	}
+	if h.State == taskRunning && except == "" {
+		h.State = ending.StateOK
+	}
+}
+
+// changed marks the progress to write, at once if it is urgent or by the next tick otherwise.
+func (r *StatusRecorder) changed(urgent bool) {
+	r.dirty = true
+	if !urgent {
+		return
+	}
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (r *StatusRecorder) loop() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(ProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.done:
+			r.write()
+			return
+		case <-r.notify:
+		case <-ticker.C:
+		}
+		r.write()
+	}
+}
+
+// write writes the progress to the status of the cluster, it is written again by the next tick if it failed.
+func (r *StatusRecorder) write() {
+	r.mu.Lock()
+	if !r.dirty {
+		r.mu.Unlock()
+		return
+	}
+	now := metav1.Now()
+	r.progress.UpdateTime = &now
+	progress := r.progress.DeepCopy()
+	r.dirty = false
+	r.mu.Unlock()
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cluster := &kubekeyv1alpha2.Cluster{}
+		if err := r.client.Get(r.ctx, r.key, cluster); err != nil {
+			return err
+		}
+		cluster.Status.Progress = progress
+		if c := meta.FindStatusCondition(cluster.Status.Conditions, ProgressingCondition); c != nil && c.Status == metav1.ConditionTrue {
+			setCondition(cluster, ProgressingCondition, metav1.ConditionTrue, c.Reason, progressMessage(progress))
+		}
+		return r.client.Status().Update(r.ctx, cluster)
+	})
+	if err != nil {
+		ctrl.LoggerFrom(r.ctx).Error(err, "failed to write the progress to the status", "cluster", r.key)
+		r.mu.Lock()
+		r.dirty = true
+		r.mu.Unlock()
+	}
+}
+
+// progressMessage tells the running phase of the pipeline and the hosts it failed on.
+func progressMessage(progress *kubekeyv1alpha2.ClusterProgress) string {
+	message := fmt.Sprintf("pipeline %s is running the phase %s", progress.Pipeline, progress.Phase)
+	var failed []string
+	for _, h := range progress.Hosts {
+		if h.State == ending.FAILED.String() {
+			failed = append(failed, h.Name)
+		}
+	}
+	if len(failed) > 0 {
+		message += fmt.Sprintf(", failed on %s", strings.Join(failed, ", "))
+	}
+	return message
+}
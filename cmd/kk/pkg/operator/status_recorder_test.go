@@ -0,0 +1,118 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kubekeyv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/ending"
+)
+
+func TestStatusRecorder(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := kubekeyv1alpha2.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	cluster := &kubekeyv1alpha2.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "sample", Generation: 2}}
+	setCondition(cluster, ProgressingCondition, metav1.ConditionTrue, "CreateCluster", "pipeline sample-create is running")
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build()
+	ctx := context.Background()
+
+	recorder := NewStatusRecorder(ctx, c, cluster, "sample-create")
+	hosts := []string{"node3", "node1", "node2"}
+	recorder.StartPhase("Init")
+	recorder.StartTask("Get OS release", hosts)
+	var wg sync.WaitGroup
+	for _, host := range hosts {
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			recorder.FinishTask(host, "Get OS release", ending.StateOK, nil)
+		}(host)
+	}
+	wg.Wait()
+	recorder.StartPhase("Etcd")
+	recorder.StartTask("Install etcd", hosts)
+	recorder.FinishTask("node1", "Install etcd", ending.StateChanged, nil)
+	recorder.FinishTask("node3", "Install etcd", ending.FAILED.String(), errors.New("disk full"))
+	recorder.Finish(errors.New("failed"))
+	recorder.Stop()
+
+	got := &kubekeyv1alpha2.Cluster{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(cluster), got); err != nil {
+		t.Fatal(err)
+	}
+	progress := got.Status.Progress
+	if progress == nil || progress.Pipeline != "sample-create" || progress.Phase != "Etcd" || progress.ObservedGeneration != 2 {
+		t.Fatalf("the progress is %+v", progress)
+	}
+	var names []string
+	for _, h := range progress.Hosts {
+		names = append(names, h.Name)
+	}
+	if fmt.Sprint(names) != "[node1 node2 node3]" {
+		t.Errorf("the hosts are %v, want them sorted", names)
+	}
+	if phase := progress.Host("node1").Phase("Init"); phase == nil || phase.State != kubekeyv1alpha2.PhaseSucceeded || phase.CompletionTime == nil {
+		t.Errorf("the phase Init of node1 is %+v, want succeeded", phase)
+	}
+	if phase := progress.Host("node2").Phase("Etcd"); phase == nil || phase.State != kubekeyv1alpha2.PhaseRunning {
+		t.Errorf("the phase Etcd of node2 is %+v, want running", phase)
+	}
+	node3 := progress.Host("node3")
+	if phase := node3.Phase("Etcd"); phase == nil || phase.State != kubekeyv1alpha2.PhaseFailed {
+		t.Errorf("the phase Etcd of node3 is %+v, want failed", phase)
+	}
+	if node3.LastError != "disk full" || node3.LastErrorTime == nil {
+		t.Errorf("the last error of node3 is %q at %v", node3.LastError, node3.LastErrorTime)
+	}
+	condition := meta.FindStatusCondition(got.Status.Conditions, ProgressingCondition)
+	if want := "pipeline sample-create is running the phase Etcd, failed on node3"; condition == nil || condition.Message != want {
+		t.Errorf("the condition is %+v, want the message %q", condition, want)
+	}
+
+	// the resumed pipeline goes on from the progress
+	recorder = NewStatusRecorder(ctx, c, got, "sample-create")
+	recorder.StartTask("Install etcd", []string{"node3"})
+	recorder.FinishTask("node3", "Install etcd", ending.StateChanged, nil)
+	recorder.Finish(nil)
+	recorder.Stop()
+	if err := c.Get(ctx, client.ObjectKeyFromObject(cluster), got); err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range got.Status.Progress.Hosts {
+		for _, phase := range h.Phases {
+			if phase.State != kubekeyv1alpha2.PhaseSucceeded {
+				t.Errorf("the phase %s of %s is %s, want succeeded", phase.Name, h.Name, phase.State)
+			}
+		}
+	}
+	if phase := got.Status.Progress.Host("node1").Phase("Init"); phase == nil {
+		t.Errorf("the phase Init of node1 isn't kept on resume")
+	}
+}
@@ -0,0 +1,134 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package patch
+
+import (
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+)
+
+// PatchModule patches the OS of the Nodes, or of all the nodes if it is empty, one batch after another: each node is
+// drained, upgraded, rebooted by the reboot mode, waited to be Ready and uncordoned before the next batch. The batch
+// is a node unless the serial of the strategy is set, and a node which fails stops the patching with the node left
+// cordoned, unless the max fail percentage tolerates it.
+type PatchModule struct {
+	common.KubeModule
+	Nodes   []string
+	Options Options
+}
+
+func (p *PatchModule) Init() {
+	p.Name = "PatchModule"
+	p.Tags = []string{"os-patch"}
+	p.Desc = "Patch the OS of the nodes one batch after another"
+
+	s := p.Runtime.GetStrategy()
+	p.Strategy = connector.Strategy{Name: connector.RollingStrategy, Serial: s.Serial, MaxFailPercentage: s.MaxFailPercentage}
+	if p.Strategy.Serial == "" {
+		p.Strategy.Serial = "1"
+	}
+
+	hosts := p.hosts()
+	drain := &task.RemoteTask{
+		Name:    "DrainNode",
+		Desc:    "Cordon and drain the node",
+		Hosts:   hosts,
+		Prepare: new(IsKubeNode),
+		Action:  &DrainNode{Timeout: p.Options.DrainTimeout},
+	}
+
+	upgrade := &task.RemoteTask{
+		Name:     "UpgradePackages",
+		Desc:     "Upgrade the packages of the node",
+		Hosts:    hosts,
+		Action:   &UpgradePackages{SecurityOnly: p.Options.SecurityOnly},
+		Parallel: true,
+	}
+
+	reboot := &task.RemoteTask{
+		Name:     "RebootNode",
+		Desc:     "Reboot the node and wait for it to come back",
+		Hosts:    hosts,
+		Action:   &RebootNode{Reboot: p.Options.Reboot, Timeout: p.Options.RebootTimeout},
+		Parallel: true,
+	}
+
+	waitEtcd := &task.RemoteTask{
+		Name:     "WaitEtcdHealthy",
+		Desc:     "Wait for the etcd member of the node to be healthy",
+		Hosts:    hosts,
+		Prepare:  new(IsKubeKeyEtcd),
+		Action:   &WaitEtcdHealthy{Timeout: p.Options.RebootTimeout},
+		Parallel: true,
+	}
+
+	waitReady := &task.RemoteTask{
+		Name:     "WaitNodeReady",
+		Desc:     "Wait for the node to be Ready",
+		Hosts:    hosts,
+		Prepare:  new(IsKubeNode),
+		Action:   &WaitNodeReady{Timeout: p.Options.RebootTimeout},
+		Parallel: true,
+	}
+
+	uncordon := &task.RemoteTask{
+		Name:    "UncordonNode",
+		Desc:    "Uncordon the node",
+		Hosts:   hosts,
+		Prepare: new(IsKubeNode),
+		Action:  new(UncordonNode),
+	}
+
+	p.Tasks = []task.Interface{
+		drain,
+		upgrade,
+		reboot,
+		waitEtcd,
+		waitReady,
+		uncordon,
+	}
+}
+
+// hosts returns the nodes to patch, the workers before the control-plane nodes so the control plane is patched once
+// the workloads have been moved around.
+func (p *PatchModule) hosts() []connector.Host {
+	var workers, masters []connector.Host
+	for _, host := range p.Runtime.GetAllHosts() {
+		if !p.selected(host.GetName()) {
+			continue
+		}
+		if host.IsRole(common.Master) {
+			masters = append(masters, host)
+		} else {
+			workers = append(workers, host)
+		}
+	}
+	return append(workers, masters...)
+}
+
+func (p *PatchModule) selected(name string) bool {
+	if len(p.Nodes) == 0 {
+		return true
+	}
+	for _, node := range p.Nodes {
+		if node == name {
+			return true
+		}
+	}
+	return false
+}
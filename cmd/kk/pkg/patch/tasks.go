@@ -0,0 +1,217 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package patch
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/health"
+)
+
+const (
+	// RebootAuto reboots the nodes whose updates require it.
+	RebootAuto = "auto"
+	// RebootAlways reboots all the nodes.
+	RebootAlways = "always"
+	// RebootNever doesn't reboot the nodes, the updates which require a reboot apply at the next boot.
+	RebootNever = "never"
+)
+
+// RebootModes are the reboot modes of the patching.
+var RebootModes = []string{RebootAuto, RebootAlways, RebootNever}
+
+// Options are the options of the patching of the nodes.
+type Options struct {
+	// SecurityOnly applies only the security updates, where the package manager tells them apart.
+	SecurityOnly bool
+	// Reboot is the reboot mode, one of RebootModes.
+	Reboot string
+	// DrainTimeout bounds the drain of a node.
+	DrainTimeout time.Duration
+	// RebootTimeout bounds the wait for a node to come back and to be Ready after the reboot.
+	RebootTimeout time.Duration
+}
+
+// upgradeCommand returns the command upgrading the packages by the package manager of the node, apt, dnf, yum or
+// zypper. The security updates of apt are applied by unattended-upgrade, which must be installed.
+func upgradeCommand(securityOnly bool) string {
+	if securityOnly {
+		return "if command -v apt-get >/dev/null 2>&1; then " +
+			"command -v unattended-upgrade >/dev/null 2>&1 || { echo 'unattended-upgrades is required to apply only the security updates' >&2; exit 1; }; " +
+			"apt-get update -qq && unattended-upgrade -v; " +
+			"elif command -v dnf >/dev/null 2>&1; then dnf -y upgrade --security; " +
+			"elif command -v yum >/dev/null 2>&1; then yum -y update --security; " +
+			"elif command -v zypper >/dev/null 2>&1; then zypper -n patch --category security; " +
+			"else echo 'no supported package manager' >&2; exit 1; fi"
+	}
+	return "if command -v apt-get >/dev/null 2>&1; then " +
+		"apt-get update -qq && DEBIAN_FRONTEND=noninteractive apt-get -y -o Dpkg::Options::=--force-confold upgrade; " +
+		"elif command -v dnf >/dev/null 2>&1; then dnf -y upgrade; " +
+		"elif command -v yum >/dev/null 2>&1; then yum -y update; " +
+		"elif command -v zypper >/dev/null 2>&1; then zypper -n update; " +
+		"else echo 'no supported package manager' >&2; exit 1; fi"
+}
+
+// rebootRequiredCmd prints reboot if the updates of the node require a reboot: by the flag file of apt, by
+// needs-restarting of dnf and yum, or by zypper.
+const rebootRequiredCmd = "if [ -f /var/run/reboot-required ]; then echo reboot; " +
+	"elif command -v needs-restarting >/dev/null 2>&1; then needs-restarting -r >/dev/null 2>&1 || echo reboot; " +
+	"elif command -v zypper >/dev/null 2>&1; then zypper needs-rebooting >/dev/null 2>&1; [ $? -eq 102 ] && echo reboot; fi; true"
+
+// controlPlane returns the runtime of the control-plane node the kubectl commands on the node are run on: the first
+// control-plane node other than the node, so it isn't rebooting, or the node itself if it's the only one.
+func controlPlane(runtime connector.Runtime) (connector.Runtime, error) {
+	node := runtime.RemoteHost()
+	for _, master := range runtime.GetHostsByRole(common.Master) {
+		if master.GetName() == node.GetName() {
+			continue
+		}
+		conn, err := runtime.GetConnector().Connect(master)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to connect to %s", master.GetAddress())
+		}
+		masterRuntime := runtime.Copy()
+		masterRuntime.SetRunner(&connector.Runner{Conn: conn, Debug: runtime.GetRunner().Debug, Host: master, Ctx: runtime.GetRunner().Ctx})
+		return masterRuntime, nil
+	}
+	return runtime, nil
+}
+
+func kubectl(runtime connector.Runtime, format string, a ...interface{}) error {
+	cp, err := controlPlane(runtime)
+	if err != nil {
+		return err
+	}
+	cmd := "/usr/local/bin/kubectl " + fmt.Sprintf(format, a...)
+	if out, err := cp.GetRunner().SudoCmd(cmd, false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "%s on %s failed: %s", cmd, cp.RemoteHost().GetName(), strings.TrimSpace(out))
+	}
+	return nil
+}
+
+// DrainNode cordons the node and evicts its pods, the DaemonSets are left.
+type DrainNode struct {
+	common.KubeAction
+	Timeout time.Duration
+}
+
+func (d *DrainNode) Execute(runtime connector.Runtime) error {
+	return kubectl(runtime, "drain %s --delete-emptydir-data --ignore-daemonsets --force --timeout=%s",
+		runtime.RemoteHost().GetName(), d.Timeout)
+}
+
+// UpgradePackages applies the updates of the packages of the node.
+type UpgradePackages struct {
+	common.KubeAction
+	SecurityOnly bool
+}
+
+func (u *UpgradePackages) Execute(runtime connector.Runtime) error {
+	if out, err := runtime.GetRunner().SudoCmd(upgradeCommand(u.SecurityOnly), false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "upgrade the packages failed: %s", strings.TrimSpace(out))
+	}
+	return nil
+}
+
+// RebootNode reboots the node by the reboot mode, and waits for it to come back.
+type RebootNode struct {
+	common.KubeAction
+	Reboot  string
+	Timeout time.Duration
+}
+
+func (r *RebootNode) Execute(runtime connector.Runtime) error {
+	switch r.Reboot {
+	case RebootNever:
+		action.Unchanged(runtime)
+		return nil
+	case RebootAuto:
+		out, err := runtime.GetRunner().SudoCmd(rebootRequiredCmd, false)
+		if err != nil {
+			return errors.Wrap(errors.WithStack(err), "check whether a reboot is required failed")
+		}
+		if !strings.Contains(out, "reboot") && !connector.IsDryRun(runtime.GetConnector()) {
+			runtime.GetRunner().Log().Info("no reboot is required")
+			action.Unchanged(runtime)
+			return nil
+		}
+	}
+	if err := action.Reboot(runtime, r.Timeout); err != nil {
+		return err
+	}
+	action.Changed(runtime, "rebooted")
+	return nil
+}
+
+// WaitNodeReady waits for the kubelet of the node and for the node to be Ready.
+type WaitNodeReady struct {
+	common.KubeAction
+	Timeout time.Duration
+}
+
+func (w *WaitNodeReady) Execute(runtime connector.Runtime) error {
+	if err := health.Wait(runtime, w.Timeout, health.Kubelet); err != nil {
+		return err
+	}
+	return kubectl(runtime, "wait --for=condition=Ready node/%s --timeout=%s", runtime.RemoteHost().GetName(), w.Timeout)
+}
+
+// WaitEtcdHealthy waits for the etcd member of the node to be healthy, so the quorum is kept by the next batch.
+type WaitEtcdHealthy struct {
+	common.KubeAction
+	Timeout time.Duration
+}
+
+func (w *WaitEtcdHealthy) Execute(runtime connector.Runtime) error {
+	endpoint := fmt.Sprintf("https://%s:2379", runtime.RemoteHost().GetInternalIPv4Address())
+	return health.Wait(runtime, w.Timeout, health.Etcd(endpoint))
+}
+
+// UncordonNode lets the pods be scheduled to the node again.
+type UncordonNode struct {
+	common.KubeAction
+}
+
+func (u *UncordonNode) Execute(runtime connector.Runtime) error {
+	return kubectl(runtime, "uncordon %s", runtime.RemoteHost().GetName())
+}
+
+// IsKubeNode selects the nodes of kubernetes, the other ones, e.g. the etcd nodes, aren't drained.
+type IsKubeNode struct {
+	common.KubePrepare
+}
+
+func (i *IsKubeNode) PreCheck(runtime connector.Runtime) (bool, error) {
+	return runtime.RemoteHost().IsRole(common.K8s), nil
+}
+
+// IsKubeKeyEtcd selects the etcd nodes of the etcd installed by KubeKey.
+type IsKubeKeyEtcd struct {
+	common.KubePrepare
+}
+
+func (i *IsKubeKeyEtcd) PreCheck(runtime connector.Runtime) (bool, error) {
+	return runtime.RemoteHost().IsRole(common.ETCD) && i.KubeConf.Cluster.Etcd.Type == kubekeyapiv1alpha2.KubeKey, nil
+}
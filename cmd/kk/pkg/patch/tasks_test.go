@@ -0,0 +1,88 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package patch
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+func TestUpgradeCommand(t *testing.T) {
+	for _, securityOnly := range []bool{false, true} {
+		cmd := upgradeCommand(securityOnly)
+		for _, manager := range []string{"apt-get", "dnf", "yum", "zypper"} {
+			if !strings.Contains(cmd, "command -v "+manager) {
+				t.Errorf("upgradeCommand(%v) doesn't detect %s: %s", securityOnly, manager, cmd)
+			}
+		}
+		if got := strings.Contains(cmd, "--security"); got != securityOnly {
+			t.Errorf("upgradeCommand(%v) applies only the security updates = %v", securityOnly, got)
+		}
+	}
+}
+
+func TestKubectl(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+
+	tests := []struct {
+		name    string
+		masters []string
+		node    string
+		want    string
+	}{
+		{name: "worker", masters: []string{"master1", "master2"}, node: "worker1", want: "master1"},
+		{name: "control plane", masters: []string{"master1", "master2"}, node: "master1", want: "master2"},
+		{name: "only control plane", masters: []string{"master1"}, node: "master1", want: "master1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialer := connector.NewFakeDialer(&connector.FakeFixtures{})
+			base := connector.NewBaseRuntime("test", dialer, false, false)
+			var node connector.Host
+			for _, name := range append(tt.masters, "worker1") {
+				host := connector.NewHost()
+				host.Name = name
+				if strings.HasPrefix(name, "master") {
+					host.SetRole(common.Master)
+				}
+				base.AppendHost(host)
+				base.AppendRoleMap(host)
+				if name == tt.node {
+					node = host
+				}
+			}
+			conn, err := dialer.Connect(node)
+			if err != nil {
+				t.Fatal(err)
+			}
+			base.SetRunner(&connector.Runner{Conn: conn, Host: node, Ctx: context.Background()})
+
+			if err := kubectl(&base, "uncordon %s", tt.node); err != nil {
+				t.Fatalf("kubectl() error = %v", err)
+			}
+			dialer.AssertCommands(t, tt.want, "kubectl uncordon "+tt.node)
+			if tt.want != tt.node {
+				dialer.AssertNoCommand(t, tt.node, "kubectl")
+			}
+		})
+	}
+}
@@ -6,10 +6,12 @@ import (
 	"github.com/pkg/errors"
 
 	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/binaries"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/cache"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/files"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/version/kubernetes"
 )
 
 type GetBinaryPath struct {
@@ -18,21 +20,11 @@ type GetBinaryPath struct {
 }
 
 func (g *GetBinaryPath) Execute(runtime connector.Runtime) error {
-	cfg := g.KubeConf.Cluster
-
-	archMap := make(map[string]bool)
-	for _, host := range cfg.Hosts {
-		switch host.Arch {
-		case "amd64":
-			archMap["amd64"] = true
-		case "arm64":
-			archMap["arm64"] = true
-		default:
-			return errors.New(fmt.Sprintf("Unsupported architecture: %s", host.Arch))
-		}
+	arches, err := binaries.HostArches(runtime.GetAllHosts())
+	if err != nil {
+		return err
 	}
-
-	for arch := range archMap {
+	for _, arch := range arches {
 		if err := setBinaryPath(g.KubeConf, runtime.GetWorkDir(), arch, g.Binaries, g.PipelineCache); err != nil {
 			return err
 		}
@@ -42,21 +34,22 @@ func (g *GetBinaryPath) Execute(runtime connector.Runtime) error {
 
 func setBinaryPath(kubeConf *common.KubeConf, path, arch string, binaries []string, pipelineCache *cache.Cache) error {
 	binariesMap := make(map[string]*files.KubeBinary)
+	components := kubernetes.DefaultComponents(kubeConf.Cluster.Kubernetes.Version)
 	var kubeBinary *files.KubeBinary
 	for _, binary := range binaries {
 		switch binary {
 		case "etcd":
-			kubeBinary = files.NewKubeBinary(binary, arch, kubekeyapiv1alpha2.DefaultEtcdVersion, path, kubeConf.Arg.DownloadCommand)
+			kubeBinary = files.NewKubeBinary(binary, arch, components.Etcd, path, kubeConf.Arg.DownloadCommand)
 		case "docker":
 			kubeBinary = files.NewKubeBinary(binary, arch, kubekeyapiv1alpha2.DefaultDockerVersion, path, kubeConf.Arg.DownloadCommand)
 		case "containerd":
-			kubeBinary = files.NewKubeBinary(binary, arch, kubekeyapiv1alpha2.DefaultContainerdVersion, path, kubeConf.Arg.DownloadCommand)
+			kubeBinary = files.NewKubeBinary(binary, arch, components.Containerd, path, kubeConf.Arg.DownloadCommand)
 		case "helm":
 			kubeBinary = files.NewKubeBinary(binary, arch, kubekeyapiv1alpha2.DefaultHelmVersion, path, kubeConf.Arg.DownloadCommand)
 		case "crictl":
 			kubeBinary = files.NewKubeBinary("crictl", arch, kubekeyapiv1alpha2.DefaultCrictlVersion, path, kubeConf.Arg.DownloadCommand)
 		case "runc":
-			kubeBinary = files.NewKubeBinary("runc", arch, kubekeyapiv1alpha2.DefaultRuncVersion, path, kubeConf.Arg.DownloadCommand)
+			kubeBinary = files.NewKubeBinary("runc", arch, components.Runc, path, kubeConf.Arg.DownloadCommand)
 		default:
 			return errors.New(fmt.Sprintf("Unsupported binary name: %s", binary))
 		}
@@ -14,9 +14,10 @@ func (u *UpgradeK8sConfirmModule) Init() {
 	u.Desc = "Display upgrade kubesphere confirmation form"
 
 	display := &task.LocalTask{
-		Name:   "ConfirmForm",
-		Desc:   "Display confirmation form",
-		Action: new(UpgradeK8sConfirm),
+		Name:        "ConfirmForm",
+		Desc:        "Display confirmation form",
+		Action:      new(UpgradeK8sConfirm),
+		RunInDryRun: true,
 	}
 
 	u.Tasks = []task.Interface{
@@ -33,9 +34,10 @@ func (u *UpgradeKsConfirmModule) Init() {
 	u.Desc = "Display upgrade kubesphere confirmation form"
 
 	display := &task.LocalTask{
-		Name:   "ConfirmForm",
-		Desc:   "Display confirmation form",
-		Action: new(UpgradeKsConfirm),
+		Name:        "ConfirmForm",
+		Desc:        "Display confirmation form",
+		Action:      new(UpgradeKsConfirm),
+		RunInDryRun: true,
 	}
 
 	u.Tasks = []task.Interface{
@@ -52,9 +54,10 @@ func (u *CreateK8sConfirmModule) Init() {
 	u.Desc = "Display Create kubesphere confirmation form"
 
 	display := &task.LocalTask{
-		Name:   "ConfirmForm",
-		Desc:   "Display confirmation form",
-		Action: new(CreateK8sConfirm),
+		Name:        "ConfirmForm",
+		Desc:        "Display confirmation form",
+		Action:      new(CreateK8sConfirm),
+		RunInDryRun: true,
 	}
 
 	u.Tasks = []task.Interface{
@@ -71,9 +74,10 @@ func (u *CreateKsConfirmModule) Init() {
 	u.Desc = "Display Create kubesphere confirmation form"
 
 	display := &task.LocalTask{
-		Name:   "ConfirmForm",
-		Desc:   "Display confirmation form",
-		Action: new(CreateKsConfirm),
+		Name:        "ConfirmForm",
+		Desc:        "Display confirmation form",
+		Action:      new(CreateKsConfirm),
+		RunInDryRun: true,
 	}
 
 	u.Tasks = []task.Interface{
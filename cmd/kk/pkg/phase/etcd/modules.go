@@ -21,18 +21,20 @@ func (p *PreCheckModule) Init() {
 	p.Desc = "Get ETCD cluster status"
 
 	getStatus := &task.RemoteTask{
-		Name:     "GetETCDStatus",
-		Desc:     "Get etcd status",
-		Hosts:    p.Runtime.GetHostsByRole(common.ETCD),
-		Action:   new(etcd.GetStatus),
-		Parallel: false,
-		Retry:    0,
+		Name:      "GetETCDStatus",
+		Desc:      "Get etcd status",
+		Hosts:     p.Runtime.GetHostsByRole(common.ETCD),
+		Action:    new(etcd.GetStatus),
+		AlwaysRun: true,
+		Parallel:  false,
+		Retry:     0,
 	}
 
 	setBinaryCache := &task.LocalTask{
-		Name:   "SetEtcdBinaryCache",
-		Desc:   "Set Etcd Binary Path in PipelineCache",
-		Action: &binary.GetBinaryPath{Binaries: []string{"etcd"}},
+		Name:        "SetEtcdBinaryCache",
+		Desc:        "Set Etcd Binary Path in PipelineCache",
+		Action:      &binary.GetBinaryPath{Binaries: []string{"etcd"}},
+		RunInDryRun: true,
 	}
 
 	p.Tasks = []task.Interface{
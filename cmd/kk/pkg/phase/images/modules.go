@@ -55,9 +55,10 @@ func (p *setBinaryCacheModule) Init() {
 	p.Desc = "set the docker and containerd binary paths in cache"
 
 	setBinaryCache := &task.LocalTask{
-		Name:   "SetBinaryCache",
-		Desc:   "Set Binary Path in PipelineCache",
-		Action: &binary.GetBinaryPath{Binaries: []string{"docker", "containerd", "runc", "crictl"}},
+		Name:        "SetBinaryCache",
+		Desc:        "Set Binary Path in PipelineCache",
+		Action:      &binary.GetBinaryPath{Binaries: []string{"docker", "containerd", "runc", "crictl"}},
+		RunInDryRun: true,
 	}
 
 	p.Tasks = []task.Interface{
@@ -39,6 +39,8 @@ func NewCreateConfigureKubernetesPipeline(runtime *common.KubeRuntime) error {
 	} else if runtime.Cluster.KubeSphere.Enabled {
 		skipLocalStorage = false
 	}
+	// The provider of storage.provider takes the place of the OpenEBS local PV.
+	skipLocalStorage = skipLocalStorage || runtime.Cluster.Storage.Provider != ""
 	m := []module.Module{
 		&precheck.NodePreCheckModule{},
 		&kubernetes.StatusModule{},
@@ -50,6 +52,7 @@ func NewCreateConfigureKubernetesPipeline(runtime *common.KubeRuntime) error {
 		&plugins.DeployPluginsModule{},
 		&addons.AddonsModule{},
 		&storage.DeployLocalVolumeModule{Skip: skipLocalStorage},
+		&storage.DeployStorageModule{Skip: runtime.Cluster.Storage.Provider == ""},
 	}
 
 	p := pipeline.Pipeline{
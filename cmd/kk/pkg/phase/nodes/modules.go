@@ -44,12 +44,13 @@ func (p *UpgradeNodesModule) Init() {
 	p.PipelineCache.GetOrSet(common.ClusterStatus, cluster)
 
 	clusterStatus := &task.RemoteTask{
-		Name:     "GetClusterStatus",
-		Desc:     "Get kubernetes cluster status",
-		Hosts:    p.Runtime.GetHostsByRole(common.Master),
-		Prepare:  new(kubernetes.NotEqualPlanVersion),
-		Action:   new(kubernetes.GetClusterStatus),
-		Parallel: false,
+		Name:      "GetClusterStatus",
+		Desc:      "Get kubernetes cluster status",
+		Hosts:     p.Runtime.GetHostsByRole(common.Master),
+		Prepare:   new(kubernetes.NotEqualPlanVersion),
+		Action:    new(kubernetes.GetClusterStatus),
+		AlwaysRun: true,
+		Parallel:  false,
 	}
 
 	upgradeNodes := &task.RemoteTask{
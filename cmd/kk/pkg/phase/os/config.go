@@ -21,6 +21,7 @@ import (
 
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/os"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/precheck"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/tuning"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/pipeline"
@@ -32,6 +33,7 @@ func NewConfigOSPipeline(runtime *common.KubeRuntime) error {
 		&precheck.NodePreCheckModule{},
 		&os.RepositoryModule{Skip: !runtime.Arg.InstallPackages},
 		&os.ConfigureOSModule{Skip: runtime.Cluster.System.SkipConfigureOS},
+		&tuning.TuningModule{Skip: runtime.Cluster.System.SkipConfigureOS},
 	}
 
 	p := pipeline.Pipeline{
@@ -32,70 +32,79 @@ func (c *UpgradePreCheckModule) Init() {
 	c.Desc = "Do pre-check on for upgrade phase"
 
 	nodePreCheck := &task.RemoteTask{
-		Name:     "NodePreCheck",
-		Desc:     "A pre-check on nodes",
-		Hosts:    c.Runtime.GetAllHosts(),
-		Action:   new(precheck.NodePreCheck),
-		Parallel: true,
+		Name:      "NodePreCheck",
+		Desc:      "A pre-check on nodes",
+		Hosts:     c.Runtime.GetAllHosts(),
+		Action:    new(precheck.NodePreCheck),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	getKubeConfig := &task.RemoteTask{
-		Name:     "GetKubeConfig",
-		Desc:     "Get KubeConfig file",
-		Hosts:    c.Runtime.GetHostsByRole(common.Master),
-		Prepare:  new(common.OnlyFirstMaster),
-		Action:   new(precheck.GetKubeConfig),
-		Parallel: true,
+		Name:      "GetKubeConfig",
+		Desc:      "Get KubeConfig file",
+		Hosts:     c.Runtime.GetHostsByRole(common.Master),
+		Prepare:   new(common.OnlyFirstMaster),
+		Action:    new(precheck.GetKubeConfig),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	getAllNodesK8sVersion := &task.RemoteTask{
-		Name:     "GetAllNodesK8sVersion",
-		Desc:     "Get all nodes Kubernetes version",
-		Hosts:    c.Runtime.GetHostsByRole(common.K8s),
-		Action:   new(GetAllNodesK8sVersion),
-		Parallel: true,
+		Name:      "GetAllNodesK8sVersion",
+		Desc:      "Get all nodes Kubernetes version",
+		Hosts:     c.Runtime.GetHostsByRole(common.K8s),
+		Action:    new(GetAllNodesK8sVersion),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	calculateMinK8sVersion := &task.LocalTask{
-		Name:   "CalculateMinK8sVersion",
-		Desc:   "Calculate min Kubernetes version",
-		Action: new(precheck.CalculateMinK8sVersion),
+		Name:        "CalculateMinK8sVersion",
+		Desc:        "Calculate min Kubernetes version",
+		Action:      new(precheck.CalculateMinK8sVersion),
+		RunInDryRun: true,
 	}
 
 	calculateMaxK8sVersion := &task.LocalTask{
-		Name:   "CalculateMaxK8sVersion",
-		Desc:   "Calculate max Kubernetes version",
-		Action: new(CalculateMaxK8sVersion),
+		Name:        "CalculateMaxK8sVersion",
+		Desc:        "Calculate max Kubernetes version",
+		Action:      new(CalculateMaxK8sVersion),
+		RunInDryRun: true,
 	}
 
 	checkDesiredK8sVersion := &task.LocalTask{
-		Name:   "CheckDesiredK8sVersion",
-		Desc:   "Check desired Kubernetes version",
-		Action: new(precheck.CheckDesiredK8sVersion),
+		Name:        "CheckDesiredK8sVersion",
+		Desc:        "Check desired Kubernetes version",
+		Action:      new(precheck.CheckDesiredK8sVersion),
+		RunInDryRun: true,
 	}
 
 	checkUpgradeK8sVersion := &task.LocalTask{
-		Name:   "checkUpgradeK8sVersion",
-		Desc:   "Check the Kubernetes version can correctly upgrade",
-		Action: new(CheckUpgradeK8sVersion),
+		Name:        "checkUpgradeK8sVersion",
+		Desc:        "Check the Kubernetes version can correctly upgrade",
+		Action:      new(CheckUpgradeK8sVersion),
+		RunInDryRun: true,
 	}
 
 	ksVersionCheck := &task.RemoteTask{
-		Name:     "KsVersionCheck",
-		Desc:     "Check KubeSphere version",
-		Hosts:    c.Runtime.GetHostsByRole(common.Master),
-		Prepare:  new(common.OnlyFirstMaster),
-		Action:   new(precheck.KsVersionCheck),
-		Parallel: true,
+		Name:      "KsVersionCheck",
+		Desc:      "Check KubeSphere version",
+		Hosts:     c.Runtime.GetHostsByRole(common.Master),
+		Prepare:   new(common.OnlyFirstMaster),
+		Action:    new(precheck.KsVersionCheck),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	getKubernetesNodesStatus := &task.RemoteTask{
-		Name:     "GetKubernetesNodesStatus",
-		Desc:     "Get kubernetes nodes status",
-		Hosts:    c.Runtime.GetHostsByRole(common.Master),
-		Prepare:  new(common.OnlyFirstMaster),
-		Action:   new(precheck.GetKubernetesNodesStatus),
-		Parallel: true,
+		Name:      "GetKubernetesNodesStatus",
+		Desc:      "Get kubernetes nodes status",
+		Hosts:     c.Runtime.GetHostsByRole(common.Master),
+		Prepare:   new(common.OnlyFirstMaster),
+		Action:    new(precheck.GetKubernetesNodesStatus),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	c.Tasks = []task.Interface{
@@ -120,37 +129,41 @@ func (c *UpgradeKubeSpherePreCheckModule) Init() {
 	c.Desc = "Do pre-check on for upgrade kubesphere phase"
 
 	nodePreCheck := &task.RemoteTask{
-		Name:     "NodePreCheck",
-		Desc:     "A pre-check on nodes",
-		Hosts:    c.Runtime.GetAllHosts(),
-		Action:   new(precheck.NodePreCheck),
-		Parallel: true,
+		Name:      "NodePreCheck",
+		Desc:      "A pre-check on nodes",
+		Hosts:     c.Runtime.GetAllHosts(),
+		Action:    new(precheck.NodePreCheck),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	getKubeConfig := &task.RemoteTask{
-		Name:     "GetKubeConfig",
-		Desc:     "Get KubeConfig file",
-		Hosts:    c.Runtime.GetHostsByRole(common.Master),
-		Prepare:  new(common.OnlyFirstMaster),
-		Action:   new(precheck.GetKubeConfig),
-		Parallel: true,
+		Name:      "GetKubeConfig",
+		Desc:      "Get KubeConfig file",
+		Hosts:     c.Runtime.GetHostsByRole(common.Master),
+		Prepare:   new(common.OnlyFirstMaster),
+		Action:    new(precheck.GetKubeConfig),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	getMasterK8sVersion := &task.RemoteTask{
-		Name:    "GetMasterK8sVersion",
-		Desc:    "get the master Kubernetes version",
-		Hosts:   c.Runtime.GetHostsByRole(common.Master),
-		Prepare: new(common.OnlyFirstMaster),
-		Action:  new(GetMasterK8sVersion),
+		Name:      "GetMasterK8sVersion",
+		Desc:      "get the master Kubernetes version",
+		Hosts:     c.Runtime.GetHostsByRole(common.Master),
+		Prepare:   new(common.OnlyFirstMaster),
+		Action:    new(GetMasterK8sVersion),
+		AlwaysRun: true,
 	}
 
 	ksVersionCheck := &task.RemoteTask{
-		Name:     "KsVersionCheck",
-		Desc:     "Check KubeSphere version",
-		Hosts:    c.Runtime.GetHostsByRole(common.Master),
-		Prepare:  new(common.OnlyFirstMaster),
-		Action:   new(precheck.KsVersionCheck),
-		Parallel: true,
+		Name:      "KsVersionCheck",
+		Desc:      "Check KubeSphere version",
+		Hosts:     c.Runtime.GetHostsByRole(common.Master),
+		Prepare:   new(common.OnlyFirstMaster),
+		Action:    new(precheck.KsVersionCheck),
+		AlwaysRun: true,
+		Parallel:  true,
 	}
 
 	c.Tasks = []task.Interface{
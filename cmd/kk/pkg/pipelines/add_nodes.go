@@ -17,63 +17,28 @@
 package pipelines
 
 import (
-	"fmt"
-
-	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/artifact"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/binaries"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/confirm"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/customscripts"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/hardening"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/os"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/precheck"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/registry"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/proxy"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/tuning"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/user"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/certs"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/container"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/pipeline"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/etcd"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/distribution"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/facts"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/filesystem"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/images"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/k3s"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/k8e"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/kubernetes"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/loadbalancer"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/registryauth"
 )
 
-func NewAddNodesPipeline(runtime *common.KubeRuntime) error {
-	noArtifact := runtime.Arg.Artifact == ""
-
-	m := []module.Module{
-		&precheck.GreetingsModule{},
-		&customscripts.CustomScriptsModule{Phase: "PreInstall", Scripts: runtime.Cluster.System.PreInstall},
-		&precheck.NodePreCheckModule{},
-		&confirm.InstallConfirmModule{},
-		&artifact.UnArchiveModule{Skip: noArtifact},
-		&os.RepositoryModule{Skip: noArtifact || !runtime.Arg.InstallPackages},
-		&binaries.NodeBinariesModule{},
-		&os.ConfigureOSModule{Skip: runtime.Cluster.System.SkipConfigureOS},
-		&registry.RegistryCertsModule{Skip: len(runtime.GetHostsByRole(common.Registry)) == 0},
-		//for one master to multi master kube-vip
-		&loadbalancer.KubevipModule{Skip: !runtime.Cluster.ControlPlaneEndpoint.IsInternalLBEnabledVip()},
-		&kubernetes.RestartKubeletModule{},
-		&kubernetes.StatusModule{},
-		&container.InstallContainerModule{},
-		&container.InstallCriDockerdModule{Skip: runtime.Cluster.Kubernetes.ContainerManager != "docker"},
-		&images.PullModule{Skip: runtime.Arg.SkipPullImages},
-		&etcd.PreCheckModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&etcd.CertsModule{},
-		&etcd.InstallETCDBinaryModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&etcd.ConfigureModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&etcd.BackupModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&kubernetes.InstallKubeBinariesModule{},
-		&kubernetes.JoinNodesModule{},
-		&loadbalancer.HaproxyModule{Skip: !runtime.Cluster.ControlPlaneEndpoint.IsInternalLBEnabled()},
-		&kubernetes.ConfigureKubernetesModule{},
-		&filesystem.ChownModule{},
-		&certs.AutoRenewCertsModule{Skip: !runtime.Cluster.Kubernetes.EnableAutoRenewCerts()},
-		&customscripts.CustomScriptsModule{Phase: "PostInstall", Scripts: runtime.Cluster.System.PostInstall},
-	}
+// NewAddNodesPipeline joins the new nodes to the cluster with the modules of the distribution of the kubernetes.type.
+func NewAddNodesPipeline(runtime *common.KubeRuntime, d distribution.Distribution) error {
+	m := append([]module.Module{&precheck.GreetingsModule{}}, addNodesModules(runtime, d)...)
 
 	p := pipeline.Pipeline{
 		Name:    "AddNodesPipeline",
@@ -87,87 +52,44 @@ func NewAddNodesPipeline(runtime *common.KubeRuntime) error {
 	return nil
 }
 
-func NewK3sAddNodesPipeline(runtime *common.KubeRuntime) error {
+// addNodesModules are the modules joining the nodes which aren't in the cluster yet, shared by the scaling of the
+// control plane.
+func addNodesModules(runtime *common.KubeRuntime, d distribution.Distribution) []module.Module {
 	noArtifact := runtime.Arg.Artifact == ""
 
 	m := []module.Module{
-		&precheck.GreetingsModule{},
-		&artifact.UnArchiveModule{Skip: noArtifact},
-		&os.RepositoryModule{Skip: noArtifact || !runtime.Arg.InstallPackages},
-		&binaries.K3sNodeBinariesModule{},
-		&os.ConfigureOSModule{Skip: runtime.Cluster.System.SkipConfigureOS},
 		&customscripts.CustomScriptsModule{Phase: "PreInstall", Scripts: runtime.Cluster.System.PreInstall},
-		&k3s.StatusModule{},
-		&etcd.PreCheckModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&etcd.CertsModule{},
-		&etcd.InstallETCDBinaryModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&etcd.ConfigureModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&etcd.BackupModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&k3s.InstallKubeBinariesModule{},
-		&k3s.JoinNodesModule{},
-		&loadbalancer.K3sHaproxyModule{Skip: !runtime.Cluster.ControlPlaneEndpoint.IsInternalLBEnabled()},
-		&kubernetes.ConfigureKubernetesModule{},
-		&filesystem.ChownModule{},
-		&certs.AutoRenewCertsModule{Skip: !runtime.Cluster.Kubernetes.EnableAutoRenewCerts()},
-		&customscripts.CustomScriptsModule{Phase: "PostInstall", Scripts: runtime.Cluster.System.PostInstall},
-	}
-
-	p := pipeline.Pipeline{
-		Name:    "AddNodesPipeline",
-		Modules: m,
-		Runtime: runtime,
 	}
-	if err := p.Start(); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func NewK8eAddNodesPipeline(runtime *common.KubeRuntime) error {
-	noArtifact := runtime.Arg.Artifact == ""
-
-	m := []module.Module{
-		&precheck.GreetingsModule{},
+	m = append(m, d.PreCheckModules(runtime)...)
+	m = append(m,
 		&artifact.UnArchiveModule{Skip: noArtifact},
 		&os.RepositoryModule{Skip: noArtifact || !runtime.Arg.InstallPackages},
-		&binaries.K8eNodeBinariesModule{},
+		d.BinariesModule(),
+		&proxy.ConfigureProxyModule{Skip: !runtime.Cluster.System.Proxy.Enabled()},
 		&os.ConfigureOSModule{Skip: runtime.Cluster.System.SkipConfigureOS},
-
-		&k8e.StatusModule{},
-		&etcd.PreCheckModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&etcd.CertsModule{},
-		&etcd.InstallETCDBinaryModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&etcd.ConfigureModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&etcd.BackupModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&k8e.InstallKubeBinariesModule{},
-		&k8e.JoinNodesModule{},
-		&loadbalancer.K3sHaproxyModule{Skip: !runtime.Cluster.ControlPlaneEndpoint.IsInternalLBEnabled()},
+		&tuning.TuningModule{Skip: runtime.Cluster.System.SkipConfigureOS},
+		&hardening.HardeningModule{Skip: !runtime.Cluster.System.Hardening.Enabled},
+		&user.AutomationUserModule{Skip: !runtime.Cluster.System.AutomationUser.Enabled()},
+		&registryauth.NodeAuthModule{},
+	)
+	m = append(m, d.NodeModules(runtime, distribution.AddNodes)...)
+	m = append(m, etcdRole(runtime).Include()...)
+	m = append(m, d.ControlPlaneModules(runtime, distribution.AddNodes)...)
+	m = append(m,
 		&kubernetes.ConfigureKubernetesModule{},
 		&filesystem.ChownModule{},
 		&certs.AutoRenewCertsModule{Skip: !runtime.Cluster.Kubernetes.EnableAutoRenewCerts()},
-	}
-
-	p := pipeline.Pipeline{
-		Name:    "AddNodesPipeline",
-		Modules: m,
-		Runtime: runtime,
-	}
-	if err := p.Start(); err != nil {
-		return err
-	}
-
-	return nil
+	)
+	m = append(m, d.ClusterModules(runtime, distribution.AddNodes)...)
+	m = append(m,
+		&registryauth.PullSecretModule{Skip: !runtime.Cluster.Registry.PullSecret.Enabled()},
+		&customscripts.CustomScriptsModule{Phase: "PostInstall", Scripts: runtime.Cluster.System.PostInstall},
+		&facts.SaveFactsModule{},
+	)
+	return m
 }
 
-func AddNodes(args common.Argument, downloadCmd string) error {
-	args.DownloadCommand = func(path, url string) string {
-		// this is an extension point for downloading tools, for example users can set the timeout, proxy or retry under
-		// some poor network environment. Or users even can choose another cli, it might be wget.
-		// perhaps we should have a build-in download function instead of totally rely on the external one
-		return fmt.Sprintf(downloadCmd, path, url)
-	}
-
+func AddNodes(args common.Argument) error {
 	var loaderType string
 	if args.FilePath != "" {
 		loaderType = common.File
@@ -180,21 +102,9 @@ func AddNodes(args common.Argument, downloadCmd string) error {
 		return err
 	}
 
-	switch runtime.Cluster.Kubernetes.Type {
-	case common.K3s:
-		if err := NewK3sAddNodesPipeline(runtime); err != nil {
-			return err
-		}
-	case common.K8e:
-		if err := NewK8eAddNodesPipeline(runtime); err != nil {
-			return err
-		}
-	case common.Kubernetes:
-		fallthrough
-	default:
-		if err := NewAddNodesPipeline(runtime); err != nil {
-			return err
-		}
+	d, err := distribution.Get(runtime.Cluster.Kubernetes.Type)
+	if err != nil {
+		return err
 	}
-	return nil
+	return NewAddNodesPipeline(runtime, d)
 }
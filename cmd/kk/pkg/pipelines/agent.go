@@ -0,0 +1,50 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/agent"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+// AgentCerts issues the certs of the agents of all the hosts of the cluster into the dir, pki/agent in the work dir of
+// the cluster if it's empty.
+func AgentCerts(args common.Argument, dir string) error {
+	runtime, err := common.NewKubeRuntime(loaderType(args), args)
+	if err != nil {
+		return err
+	}
+	if dir == "" {
+		dir = filepath.Join(runtime.GetClusterWorkDir(), connector.AgentCertsDir)
+	}
+	var hosts []agent.CertHost
+	for _, host := range runtime.GetAllHosts() {
+		hosts = append(hosts, agent.CertHost{
+			Name:      host.GetName(),
+			Addresses: []string{host.GetAddress(), host.GetInternalAddress()},
+		})
+	}
+	if err := agent.WriteCerts(dir, hosts); err != nil {
+		return err
+	}
+	fmt.Printf("The certs of the agents of %d hosts are written to %s.\n", len(hosts), dir)
+	return nil
+}
@@ -17,10 +17,9 @@
 package pipelines
 
 import (
-	"fmt"
-
 	"github.com/pkg/errors"
 
+	kubekeyv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/artifact"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/binaries"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/confirm"
@@ -29,6 +28,7 @@ import (
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/pipeline"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/filesystem"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/images"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/version/kubernetes"
 )
 
 func NewArtifactExportPipeline(runtime *common.ArtifactRuntime) error {
@@ -37,6 +37,8 @@ func NewArtifactExportPipeline(runtime *common.ArtifactRuntime) error {
 		&images.CopyImagesToLocalModule{},
 		&binaries.ArtifactBinariesModule{},
 		&artifact.RepositoryModule{},
+		&artifact.PackageRepositoryModule{},
+		&artifact.ChartsModule{Skip: len(runtime.Spec.Charts) == 0},
 		&artifact.ArchiveModule{},
 		&filesystem.ChownOutputModule{},
 		&filesystem.ChownWorkDirModule{},
@@ -61,6 +63,8 @@ func NewK3sArtifactExportPipeline(runtime *common.ArtifactRuntime) error {
 		&images.CopyImagesToLocalModule{},
 		&binaries.K3sArtifactBinariesModule{},
 		&artifact.RepositoryModule{},
+		&artifact.PackageRepositoryModule{},
+		&artifact.ChartsModule{Skip: len(runtime.Spec.Charts) == 0},
 		&artifact.ArchiveModule{},
 		&filesystem.ChownOutputModule{},
 		&filesystem.ChownWorkDirModule{},
@@ -85,6 +89,8 @@ func NewK8eArtifactExportPipeline(runtime *common.ArtifactRuntime) error {
 		&images.CopyImagesToLocalModule{},
 		&binaries.K8eArtifactBinariesModule{},
 		&artifact.RepositoryModule{},
+		&artifact.PackageRepositoryModule{},
+		&artifact.ChartsModule{Skip: len(runtime.Spec.Charts) == 0},
 		&artifact.ArchiveModule{},
 		&filesystem.ChownOutputModule{},
 		&filesystem.ChownWorkDirModule{},
@@ -103,14 +109,7 @@ func NewK8eArtifactExportPipeline(runtime *common.ArtifactRuntime) error {
 	return nil
 }
 
-func ArtifactExport(args common.ArtifactArgument, downloadCmd string) error {
-	args.DownloadCommand = func(path, url string) string {
-		// this is an extension point for downloading tools, for example users can set the timeout, proxy or retry under
-		// some poor network environment. Or users even can choose another cli, it might be wget.
-		// perhaps we should have a build-in download function instead of totally rely on the external one
-		return fmt.Sprintf(downloadCmd, path, url)
-	}
-
+func ArtifactExport(args common.ArtifactArgument) error {
 	runtime, err := common.NewArtifactRuntime(args)
 	if err != nil {
 		return err
@@ -139,6 +138,9 @@ func ArtifactExport(args common.ArtifactArgument, downloadCmd string) error {
 	case common.Kubernetes:
 		fallthrough
 	default:
+		if err := checkArtifactComponents(runtime.Spec); err != nil {
+			return err
+		}
 		if err := NewArtifactExportPipeline(runtime); err != nil {
 			return err
 		}
@@ -146,3 +148,26 @@ func ArtifactExport(args common.ArtifactArgument, downloadCmd string) error {
 
 	return nil
 }
+
+// checkArtifactComponents checks the versions of etcd, cni and containerd in the manifest are compatible with each
+// Kubernetes version by the version matrix, so an unusable artifact isn't exported.
+func checkArtifactComponents(spec *kubekeyv1alpha2.ManifestSpec) error {
+	components := kubernetes.Components{
+		Etcd: spec.Components.ETCD.Version,
+		CNI:  spec.Components.CNI.Version,
+	}
+	for _, k := range spec.KubernetesDistributions {
+		if err := kubernetes.CheckComponents(k.Version, components); err != nil {
+			return errors.Wrap(err, "invalid manifest")
+		}
+		for _, c := range spec.Components.ContainerRuntimes {
+			if c.Type != common.Containerd {
+				continue
+			}
+			if err := kubernetes.CheckComponents(k.Version, kubernetes.Components{Containerd: c.Version}); err != nil {
+				return errors.Wrap(err, "invalid manifest")
+			}
+		}
+	}
+	return nil
+}
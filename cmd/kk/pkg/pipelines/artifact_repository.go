@@ -0,0 +1,73 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/artifact"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/pipeline"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/filesystem"
+)
+
+func NewArtifactRepositoryPipeline(runtime *common.ArtifactRuntime) error {
+	m := []module.Module{
+		&artifact.PackageRepositoryModule{Dir: runtime.Arg.Output},
+		&filesystem.ChownOutputModule{},
+		&filesystem.ChownWorkDirModule{},
+	}
+
+	p := pipeline.Pipeline{
+		Name:            "ArtifactRepositoryPipeline",
+		Modules:         m,
+		Runtime:         runtime,
+		ModulePostHooks: nil,
+	}
+	if err := p.Start(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ArtifactRepository builds the offline package repository of every operating system
+// in the manifest into the output dir, laid out as the repository dir of an artifact.
+func ArtifactRepository(args common.ArtifactArgument) error {
+	output, err := filepath.Abs(args.Output)
+	if err != nil {
+		return errors.Wrap(err, "Failed to look up current directory")
+	}
+	args.Output = output
+
+	runtime, err := common.NewArtifactRuntime(args)
+	if err != nil {
+		return err
+	}
+
+	if len(runtime.Spec.OperatingSystems) == 0 {
+		return errors.New("no operating system is defined in the manifest")
+	}
+	for i := range runtime.Spec.OperatingSystems {
+		runtime.Spec.OperatingSystems[i].Repository.Packages.Build = true
+	}
+
+	return NewArtifactRepositoryPipeline(runtime)
+}
@@ -0,0 +1,124 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/backup"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/confirm"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/precheck"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/pipeline"
+)
+
+// BackupCluster backs up the control plane of the cluster to the location, a local dir or an s3:// bucket of the
+// S3-compatible endpoint, the backups dir in the work dir of the cluster by default. The backup is a dir of the
+// location named after the cluster and the time.
+func BackupCluster(args common.Argument, location, endpoint string) error {
+	var loaderType string
+	if args.FilePath != "" {
+		loaderType = common.File
+	} else {
+		loaderType = common.AllInOne
+	}
+
+	runtime, err := common.NewKubeRuntime(loaderType, args)
+	if err != nil {
+		return err
+	}
+	if location == "" {
+		location = filepath.Join(runtime.GetClusterWorkDir(), backup.DefaultDir)
+	}
+	loc, err := backup.ParseLocation(location, endpoint)
+	if err != nil {
+		return err
+	}
+
+	name := backup.Name(runtime.ClusterName, time.Now())
+	loc = loc.Join(name)
+	// the backup to a bucket is staged in the work dir of the cluster
+	dir := loc.Dir
+	if loc.IsS3() {
+		dir = filepath.Join(runtime.GetClusterWorkDir(), backup.DefaultDir, name)
+	}
+	if !connector.IsDryRun(runtime.GetConnector()) {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return errors.Wrapf(err, "create the dir of the backup %s failed", dir)
+		}
+	}
+
+	p := pipeline.Pipeline{
+		Name: "BackupClusterPipeline",
+		Modules: []module.Module{
+			&precheck.GreetingsModule{},
+			&backup.BackupModule{Dir: dir, Location: loc},
+		},
+		Runtime: runtime,
+	}
+	return p.Start()
+}
+
+// RestoreCluster rebuilds the control plane of the cluster from the backup at the location, a local dir or an s3://
+// bucket of the S3-compatible endpoint, after a confirmation. The backup in a bucket is downloaded to the work dir of
+// the cluster first.
+func RestoreCluster(args common.Argument, location, endpoint string, timeout time.Duration) error {
+	loc, err := backup.ParseLocation(location, endpoint)
+	if err != nil {
+		return err
+	}
+	var loaderType string
+	if args.FilePath != "" {
+		loaderType = common.File
+	} else {
+		loaderType = common.AllInOne
+	}
+
+	runtime, err := common.NewKubeRuntime(loaderType, args)
+	if err != nil {
+		return err
+	}
+	dir, err := loc.Download(filepath.Join(runtime.GetClusterWorkDir(), backup.DefaultDir, path.Base(loc.Prefix)))
+	if err != nil {
+		return err
+	}
+	metadata, err := backup.ReadMetadata(dir)
+	if err != nil {
+		return err
+	}
+	if metadata.Cluster != runtime.ClusterName {
+		return errors.Errorf("the backup %s is of the cluster %s, not of %s", loc, metadata.Cluster, runtime.ClusterName)
+	}
+
+	p := pipeline.Pipeline{
+		Name: "RestoreClusterPipeline",
+		Modules: []module.Module{
+			&precheck.GreetingsModule{},
+			&confirm.RestoreConfirmModule{Skip: args.SkipConfirmCheck, Backup: loc.String(), Created: metadata.Created},
+			&backup.RestoreModule{Dir: dir, Metadata: metadata, Timeout: timeout},
+		},
+		Runtime: runtime,
+	}
+	return p.Start()
+}
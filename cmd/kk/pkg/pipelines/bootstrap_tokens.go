@@ -0,0 +1,70 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/precheck"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstraptoken"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/pipeline"
+)
+
+func runBootstrapTokenPipeline(name string, args common.Argument, m module.Module) error {
+	// the tokens are printed to the stdout, which the progress of the hosts would take over
+	args.NoTUI = true
+
+	var loaderType string
+	if args.FilePath != "" {
+		loaderType = common.File
+	} else {
+		loaderType = common.AllInOne
+	}
+
+	runtime, err := common.NewKubeRuntime(loaderType, args)
+	if err != nil {
+		return err
+	}
+
+	p := pipeline.Pipeline{
+		Name: name,
+		Modules: []module.Module{
+			&precheck.GreetingsModule{},
+			m,
+		},
+		Runtime: runtime,
+	}
+	return p.Start()
+}
+
+func ListBootstrapTokens(args common.Argument) error {
+	return runBootstrapTokenPipeline("ListBootstrapTokensPipeline", args, &bootstraptoken.ListTokensModule{})
+}
+
+func CreateBootstrapToken(args common.Argument, options bootstraptoken.CreateOptions) error {
+	if err := options.Validate(); err != nil {
+		return err
+	}
+	return runBootstrapTokenPipeline("CreateBootstrapTokenPipeline", args, &bootstraptoken.CreateTokenModule{Options: options})
+}
+
+func RevokeBootstrapTokens(args common.Argument, ids []string) error {
+	if _, err := bootstraptoken.DeleteCommand(ids); err != nil {
+		return err
+	}
+	return runBootstrapTokenPipeline("RevokeBootstrapTokensPipeline", args, &bootstraptoken.RevokeTokensModule{IDs: ids})
+}
@@ -0,0 +1,54 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+// ListClusters prints the clusters managed from the work dir.
+func ListClusters() error {
+	workDir, err := connector.DefaultWorkDir()
+	if err != nil {
+		return err
+	}
+	clusters, err := connector.ListClusters(workDir)
+	if err != nil {
+		return err
+	}
+	return connector.PrintClusters(os.Stdout, clusters)
+}
+
+// UseCluster makes the cluster the current one of the work dir, or unsets the current cluster if the name is empty.
+func UseCluster(name string) error {
+	workDir, err := connector.DefaultWorkDir()
+	if err != nil {
+		return err
+	}
+	if err := connector.UseCluster(workDir, name); err != nil {
+		return err
+	}
+	if name == "" {
+		fmt.Println("The current cluster is unset.")
+		return nil
+	}
+	fmt.Printf("Switched to the cluster %s.\n", name)
+	return nil
+}
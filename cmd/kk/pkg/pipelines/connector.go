@@ -0,0 +1,86 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+// TestConnector runs the compatibility suite of the connector against the host of the cluster and prints its
+// capability report, it fails if any check fails. The quarantined hosts can be tested too, e.g. before they are
+// released.
+func TestConnector(args common.Argument, name string, opts connector.CompatOptions) error {
+	var loaderType string
+	if args.FilePath != "" {
+		loaderType = common.File
+	} else {
+		loaderType = common.AllInOne
+	}
+	cluster, err := common.NewLoader(loaderType, args).Load()
+	if err != nil {
+		return err
+	}
+
+	dialer := connector.NewDialer()
+	base := connector.NewBaseRuntime(cluster.Name, dialer, args.Debug, false)
+	_, roleGroups := cluster.Spec.SetDefaultClusterSpec()
+	var host *kubekeyapiv1alpha2.KubeHost
+	for _, hosts := range roleGroups {
+		for _, h := range hosts {
+			if h.GetName() == name {
+				host = h
+			}
+		}
+	}
+	if host == nil {
+		return errors.Errorf("the host %s is not in the cluster", name)
+	}
+	if err := host.LoadCredentials(context.Background()); err != nil {
+		return err
+	}
+	logger.Log.Redactor.AddSecrets(host.GetPassword())
+	if host.GetConnectorType() == connector.ConnectorAgent && host.GetAgentCertsDir() == "" {
+		dir, err := connector.DefaultAgentCertsDir(cluster.Name)
+		if err != nil {
+			return err
+		}
+		host.SetAgentCertsDir(dir)
+	}
+
+	logger.Log.Infof("run the connector checks against the host %s", name)
+	defer dialer.Close(host)
+	report, err := connector.RunCompatSuite(base.GetConnector(), host, opts)
+	if err != nil {
+		return err
+	}
+	if err := report.Print(os.Stdout); err != nil {
+		return err
+	}
+	if failed := report.Failed(); len(failed) > 0 {
+		return errors.Errorf("the host %s failed the checks: %s", name, strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -20,33 +20,36 @@ import (
 	"fmt"
 
 	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/access"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/addons"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/artifact"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/binaries"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/confirm"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/customscripts"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/hardening"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/os"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/precheck"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/proxy"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/tuning"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/user"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/certs"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/container"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/pipeline"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/distribution"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/etcd"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/facts"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/filesystem"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/images"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/k3s"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/k8e"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/kubernetes"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/kubesphere"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/loadbalancer"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/plugins"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/plugins/dns"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/plugins/network"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/plugins/storage"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/registryauth"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/verification"
 )
 
-func NewCreateClusterPipeline(runtime *common.KubeRuntime) error {
+// NewCreateClusterPipeline creates the cluster, the modules of the binaries, the control plane and the join are
+// provided by the distribution of the kubernetes.type.
+func NewCreateClusterPipeline(runtime *common.KubeRuntime, d distribution.Distribution) error {
 	noArtifact := runtime.Arg.Artifact == ""
 	skipPushImages := runtime.Arg.SkipPushImages || noArtifact || (!noArtifact && runtime.Cluster.Registry.PrivateRegistry == "")
 	skipLocalStorage := true
@@ -55,49 +58,56 @@ func NewCreateClusterPipeline(runtime *common.KubeRuntime) error {
 	} else if runtime.Cluster.KubeSphere.Enabled {
 		skipLocalStorage = false
 	}
+	// The provider of storage.provider takes the place of the OpenEBS local PV.
+	skipLocalStorage = skipLocalStorage || runtime.Cluster.Storage.Provider != ""
+	kubeConfigContext := ""
+	if runtime.Arg.MergeKubeConfig {
+		kubeConfigContext = runtime.Arg.KubeConfigContext
+		if kubeConfigContext == "" {
+			kubeConfigContext = runtime.GetObjName()
+		}
+	}
 
 	m := []module.Module{
 		&precheck.GreetingsModule{},
 		&customscripts.CustomScriptsModule{Phase: "PreInstall", Scripts: runtime.Cluster.System.PreInstall},
-		&precheck.NodePreCheckModule{},
-		&confirm.InstallConfirmModule{},
+	}
+	m = append(m, d.PreCheckModules(runtime)...)
+	m = append(m,
 		&artifact.UnArchiveModule{Skip: noArtifact},
 		&os.RepositoryModule{Skip: noArtifact || !runtime.Arg.InstallPackages},
-		&binaries.NodeBinariesModule{},
+		d.BinariesModule(),
+		&proxy.ConfigureProxyModule{Skip: !runtime.Cluster.System.Proxy.Enabled()},
 		&os.ConfigureOSModule{Skip: runtime.Cluster.System.SkipConfigureOS},
-		&kubernetes.StatusModule{},
-		&container.InstallContainerModule{},
-		&container.InstallCriDockerdModule{Skip: runtime.Cluster.Kubernetes.ContainerManager != "docker"},
+		&tuning.TuningModule{Skip: runtime.Cluster.System.SkipConfigureOS},
+		&hardening.HardeningModule{Skip: !runtime.Cluster.System.Hardening.Enabled},
+		&user.AutomationUserModule{Skip: !runtime.Cluster.System.AutomationUser.Enabled()},
+		&registryauth.NodeAuthModule{},
 		&images.CopyImagesToRegistryModule{Skip: skipPushImages},
-		&images.PullModule{Skip: runtime.Arg.SkipPullImages},
-		&etcd.PreCheckModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&etcd.CertsModule{},
-		&etcd.InstallETCDBinaryModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&etcd.ConfigureModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&etcd.BackupModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&kubernetes.InstallKubeBinariesModule{},
-		// init kubeVip on first master
-		&loadbalancer.KubevipModule{Skip: !runtime.Cluster.ControlPlaneEndpoint.IsInternalLBEnabledVip()},
-		&kubernetes.InitKubernetesModule{},
-		&dns.ClusterDNSModule{},
-		&kubernetes.StatusModule{},
-		&kubernetes.JoinNodesModule{},
-		// deploy kubeVip on other masters
-		&loadbalancer.KubevipModule{Skip: !runtime.Cluster.ControlPlaneEndpoint.IsInternalLBEnabledVip()},
-		&loadbalancer.HaproxyModule{Skip: !runtime.Cluster.ControlPlaneEndpoint.IsInternalLBEnabled()},
+	)
+	m = append(m, d.NodeModules(runtime, distribution.Create)...)
+	m = append(m, etcdRole(runtime).Include()...)
+	m = append(m, d.ControlPlaneModules(runtime, distribution.Create)...)
+	m = append(m,
 		&network.DeployNetworkPluginModule{},
 		&kubernetes.ConfigureKubernetesModule{},
 		&filesystem.ChownModule{},
 		&certs.AutoRenewCertsModule{Skip: !runtime.Cluster.Kubernetes.EnableAutoRenewCerts()},
-		&kubernetes.SecurityEnhancementModule{Skip: !runtime.Arg.SecurityEnhancement},
-		&kubernetes.SaveKubeConfigModule{},
-		&plugins.DeployPluginsModule{},
+	)
+	m = append(m, d.ClusterModules(runtime, distribution.Create)...)
+	m = append(m,
+		&access.RBACModule{Skip: !runtime.Cluster.RBAC.Enabled()},
+		&registryauth.PullSecretModule{Skip: !runtime.Cluster.Registry.PullSecret.Enabled()},
 		&addons.AddonsModule{},
 		&storage.DeployLocalVolumeModule{Skip: skipLocalStorage},
+		&storage.DeployStorageModule{Skip: runtime.Cluster.Storage.Provider == ""},
 		&kubesphere.DeployModule{Skip: !runtime.Cluster.KubeSphere.Enabled},
 		&kubesphere.CheckResultModule{Skip: !runtime.Cluster.KubeSphere.Enabled},
 		&customscripts.CustomScriptsModule{Phase: "PostInstall", Scripts: runtime.Cluster.System.PostInstall},
-	}
+		&facts.SaveFactsModule{},
+		&access.KubeConfigModule{Context: kubeConfigContext},
+		&verification.VerificationModule{Skip: !runtime.Cluster.Verification.Enabled()},
+	)
 
 	p := pipeline.Pipeline{
 		Name:    "CreateClusterPipeline",
@@ -107,79 +117,8 @@ func NewCreateClusterPipeline(runtime *common.KubeRuntime) error {
 	if err := p.Start(); err != nil {
 		return err
 	}
-
-	if runtime.Cluster.KubeSphere.Enabled {
-
-		fmt.Print(`Installation is complete.
-
-Please check the result using the command:
-
-	kubectl logs -n kubesphere-system $(kubectl get pod -n kubesphere-system -l 'app in (ks-install, ks-installer)' -o jsonpath='{.items[0].metadata.name}') -f
-
-`)
-	} else {
-		fmt.Print(`Installation is complete.
-
-Please check the result using the command:
-		
-	kubectl get pod -A
-
-`)
-
-	}
-
-	return nil
-}
-
-func NewK3sCreateClusterPipeline(runtime *common.KubeRuntime) error {
-	noArtifact := runtime.Arg.Artifact == ""
-	skipPushImages := runtime.Arg.SkipPushImages || noArtifact || (!noArtifact && runtime.Cluster.Registry.PrivateRegistry == "")
-	skipLocalStorage := true
-	if runtime.Arg.DeployLocalStorage != nil {
-		skipLocalStorage = !*runtime.Arg.DeployLocalStorage
-	} else if runtime.Cluster.KubeSphere.Enabled {
-		skipLocalStorage = false
-	}
-
-	m := []module.Module{
-		&precheck.GreetingsModule{},
-		&artifact.UnArchiveModule{Skip: noArtifact},
-		&os.RepositoryModule{Skip: noArtifact || !runtime.Arg.InstallPackages},
-		&binaries.K3sNodeBinariesModule{},
-		&os.ConfigureOSModule{Skip: runtime.Cluster.System.SkipConfigureOS},
-		&customscripts.CustomScriptsModule{Phase: "PreInstall", Scripts: runtime.Cluster.System.PreInstall},
-		&k3s.StatusModule{},
-		&etcd.PreCheckModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&etcd.CertsModule{},
-		&etcd.InstallETCDBinaryModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&etcd.ConfigureModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&etcd.BackupModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&loadbalancer.K3sKubevipModule{Skip: !runtime.Cluster.ControlPlaneEndpoint.IsInternalLBEnabledVip()},
-		&k3s.InstallKubeBinariesModule{},
-		&k3s.InitClusterModule{},
-		&k3s.StatusModule{},
-		&k3s.JoinNodesModule{},
-		&images.CopyImagesToRegistryModule{Skip: skipPushImages},
-		&loadbalancer.K3sHaproxyModule{Skip: !runtime.Cluster.ControlPlaneEndpoint.IsInternalLBEnabled()},
-		&network.DeployNetworkPluginModule{},
-		&kubernetes.ConfigureKubernetesModule{},
-		&filesystem.ChownModule{},
-		&certs.AutoRenewCertsModule{Skip: !runtime.Cluster.Kubernetes.EnableAutoRenewCerts()},
-		&k3s.SaveKubeConfigModule{},
-		&addons.AddonsModule{},
-		&storage.DeployLocalVolumeModule{Skip: skipLocalStorage},
-		&kubesphere.DeployModule{Skip: !runtime.Cluster.KubeSphere.Enabled},
-		&kubesphere.CheckResultModule{Skip: !runtime.Cluster.KubeSphere.Enabled},
-		&customscripts.CustomScriptsModule{Phase: "PostInstall", Scripts: runtime.Cluster.System.PostInstall},
-	}
-
-	p := pipeline.Pipeline{
-		Name:    "K3sCreateClusterPipeline",
-		Modules: m,
-		Runtime: runtime,
-	}
-	if err := p.Start(); err != nil {
-		return err
+	if runtime.Arg.RenderDir != "" {
+		return nil
 	}
 
 	if runtime.Cluster.KubeSphere.Enabled {
@@ -188,7 +127,7 @@ func NewK3sCreateClusterPipeline(runtime *common.KubeRuntime) error {
 
 Please check the result using the command:
 
-	kubectl logs -n kubesphere-system $(kubectl get pod -n kubesphere-system -l 'app in (ks-install, ks-installer)' -o jsonpath='{.items[0].metadata.name}') -f   
+	kubectl logs -n kubesphere-system $(kubectl get pod -n kubesphere-system -l 'app in (ks-install, ks-installer)' -o jsonpath='{.items[0].metadata.name}') -f
 
 `)
 	} else {
@@ -205,88 +144,22 @@ Please check the result using the command:
 	return nil
 }
 
-func NewK8eCreateClusterPipeline(runtime *common.KubeRuntime) error {
-	noArtifact := runtime.Arg.Artifact == ""
-	skipPushImages := runtime.Arg.SkipPushImages || noArtifact || (!noArtifact && runtime.Cluster.Registry.PrivateRegistry == "")
-	skipLocalStorage := true
-	if runtime.Arg.DeployLocalStorage != nil {
-		skipLocalStorage = !*runtime.Arg.DeployLocalStorage
-	} else if runtime.Cluster.KubeSphere.Enabled {
-		skipLocalStorage = false
-	}
-
-	m := []module.Module{
-		&precheck.GreetingsModule{},
-		&artifact.UnArchiveModule{Skip: noArtifact},
-		&os.RepositoryModule{Skip: noArtifact || !runtime.Arg.InstallPackages},
-		&binaries.K8eNodeBinariesModule{},
-		&os.ConfigureOSModule{Skip: runtime.Cluster.System.SkipConfigureOS},
-		&customscripts.CustomScriptsModule{Phase: "PreInstall", Scripts: runtime.Cluster.System.PreInstall},
-		&k8e.StatusModule{},
-		&etcd.PreCheckModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&etcd.CertsModule{},
-		&etcd.InstallETCDBinaryModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&etcd.ConfigureModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&etcd.BackupModule{Skip: runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey},
-		&loadbalancer.K3sKubevipModule{Skip: !runtime.Cluster.ControlPlaneEndpoint.IsInternalLBEnabledVip()},
-		&k8e.InstallKubeBinariesModule{},
-		&k8e.InitClusterModule{},
-		&k8e.StatusModule{},
-		&k8e.JoinNodesModule{},
-		&images.CopyImagesToRegistryModule{Skip: skipPushImages},
-		&loadbalancer.K3sHaproxyModule{Skip: !runtime.Cluster.ControlPlaneEndpoint.IsInternalLBEnabled()},
-		&network.DeployNetworkPluginModule{},
-		&kubernetes.ConfigureKubernetesModule{},
-		&filesystem.ChownModule{},
-		&certs.AutoRenewCertsModule{Skip: !runtime.Cluster.Kubernetes.EnableAutoRenewCerts()},
-		&k8e.SaveKubeConfigModule{},
-		&addons.AddonsModule{},
-		&storage.DeployLocalVolumeModule{Skip: skipLocalStorage},
-		&kubesphere.DeployModule{Skip: !runtime.Cluster.KubeSphere.Enabled},
-		&kubesphere.CheckResultModule{Skip: !runtime.Cluster.KubeSphere.Enabled},
-		&customscripts.CustomScriptsModule{Phase: "PostInstall", Scripts: runtime.Cluster.System.PostInstall},
-	}
-
-	p := pipeline.Pipeline{
-		Name:    "K8eCreateClusterPipeline",
-		Modules: m,
-		Runtime: runtime,
-	}
-	if err := p.Start(); err != nil {
-		return err
+// etcdRole installs the etcd of the kubekey type, it is shared by the distributions.
+func etcdRole(runtime *common.KubeRuntime) *module.Role {
+	skip := runtime.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey
+	return &module.Role{
+		Name: "etcd",
+		Modules: []module.Module{
+			&etcd.PreCheckModule{Skip: skip},
+			&etcd.CertsModule{},
+			&etcd.InstallETCDBinaryModule{Skip: skip},
+			&etcd.ConfigureModule{Skip: skip},
+			&etcd.BackupModule{Skip: skip},
+		},
 	}
-
-	if runtime.Cluster.KubeSphere.Enabled {
-
-		fmt.Print(`Installation is complete.
-
-Please check the result using the command:
-
-	kubectl logs -n kubesphere-system $(kubectl get pod -n kubesphere-system -l 'app in (ks-install, ks-installer)' -o jsonpath='{.items[0].metadata.name}') -f   
-
-`)
-	} else {
-		fmt.Print(`Installation is complete.
-
-Please check the result using the command:
-		
-	kubectl get pod -A
-
-`)
-
-	}
-
-	return nil
 }
 
-func CreateCluster(args common.Argument, downloadCmd string) error {
-	args.DownloadCommand = func(path, url string) string {
-		// this is an extension point for downloading tools, for example users can set the timeout, proxy or retry under
-		// some poor network environment. Or users even can choose another cli, it might be wget.
-		// perhaps we should have a build-in download function instead of totally rely on the external one
-		return fmt.Sprintf(downloadCmd, path, url)
-	}
-
+func CreateCluster(args common.Argument) error {
 	var loaderType string
 	if args.FilePath != "" {
 		loaderType = common.File
@@ -299,21 +172,9 @@ func CreateCluster(args common.Argument, downloadCmd string) error {
 		return err
 	}
 
-	switch runtime.Cluster.Kubernetes.Type {
-	case common.K3s:
-		if err := NewK3sCreateClusterPipeline(runtime); err != nil {
-			return err
-		}
-	case common.K8e:
-		if err := NewK8eCreateClusterPipeline(runtime); err != nil {
-			return err
-		}
-	case common.Kubernetes:
-		fallthrough
-	default:
-		if err := NewCreateClusterPipeline(runtime); err != nil {
-			return err
-		}
+	d, err := distribution.Get(runtime.Cluster.Kubernetes.Type)
+	if err != nil {
+		return err
 	}
-	return nil
+	return NewCreateClusterPipeline(runtime, d)
 }
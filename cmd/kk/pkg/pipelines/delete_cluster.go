@@ -17,30 +17,38 @@
 package pipelines
 
 import (
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/confirm"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/os"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/precheck"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/certs"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/container"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/pipeline"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/k3s"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/k8e"
-	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/kubernetes"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/distribution"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/ipam"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/loadbalancer"
 )
 
-func NewDeleteClusterPipeline(runtime *common.KubeRuntime) error {
+// NewDeleteClusterPipeline deletes the cluster, the distribution of the kubernetes.type is removed from the nodes
+// before the OS environment is cleared.
+func NewDeleteClusterPipeline(runtime *common.KubeRuntime, d distribution.Distribution, level os.CleanupLevel) error {
 	m := []module.Module{
 		&precheck.GreetingsModule{},
+		&precheck.OwnerCheckModule{},
 		&confirm.DeleteClusterConfirmModule{Skip: runtime.Arg.SkipConfirmCheck},
-		&kubernetes.ResetClusterModule{},
-		&container.UninstallContainerModule{Skip: !runtime.Arg.DeleteCRI},
-		&os.ClearOSEnvironmentModule{},
+	}
+	m = append(m, d.DeleteModules(runtime, level)...)
+	m = append(m,
+		&os.ClearOSEnvironmentModule{Skip: !level.WipesData(), RemoveManagedFiles: level.RemovesRuntime()},
 		&certs.UninstallAutoRenewCertsModule{},
 		&loadbalancer.DeleteVIPModule{Skip: !runtime.Cluster.ControlPlaneEndpoint.IsInternalLBEnabledVip()},
-	}
+		&os.VerifyCleanupModule{Skip: !d.CleanupLevels(), Level: level},
+	)
 
 	p := pipeline.Pipeline{
 		Name:    "DeleteClusterPipeline",
@@ -53,75 +61,76 @@ func NewDeleteClusterPipeline(runtime *common.KubeRuntime) error {
 	return nil
 }
 
-func NewK3sDeleteClusterPipeline(runtime *common.KubeRuntime) error {
-	m := []module.Module{
-		&precheck.GreetingsModule{},
-		&confirm.DeleteClusterConfirmModule{},
-		&k3s.DeleteClusterModule{},
-		&os.ClearOSEnvironmentModule{},
-		&certs.UninstallAutoRenewCertsModule{},
-		&loadbalancer.DeleteVIPModule{Skip: !runtime.Cluster.ControlPlaneEndpoint.IsInternalLBEnabledVip()},
+// deleteClusterCleanupLevel returns the cleanup level of the delete, --all is the data level if it isn't set.
+func deleteClusterCleanupLevel(args common.Argument) (os.CleanupLevel, error) {
+	level, err := os.ParseCleanupLevel(args.CleanupLevel)
+	if err != nil {
+		return level, err
 	}
-
-	p := pipeline.Pipeline{
-		Name:    "K3sDeleteClusterPipeline",
-		Modules: m,
-		Runtime: runtime,
+	if !args.DeleteCRI {
+		return level, nil
 	}
-	if err := p.Start(); err != nil {
-		return err
+	switch level {
+	case os.CleanupDefault:
+		return os.CleanupData, nil
+	case os.CleanupReset:
+		return level, errors.New("--all removes the container runtime, which the cleanup level reset keeps")
 	}
-	return nil
+	return level, nil
 }
 
-func NewK8eDeleteClusterPipeline(runtime *common.KubeRuntime) error {
-	m := []module.Module{
-		&precheck.GreetingsModule{},
-		&confirm.DeleteClusterConfirmModule{},
-		&k8e.DeleteClusterModule{},
-		&os.ClearOSEnvironmentModule{},
-		&certs.UninstallAutoRenewCertsModule{},
-	}
-
-	p := pipeline.Pipeline{
-		Name:    "K8eDeleteClusterPipeline",
-		Modules: m,
-		Runtime: runtime,
-	}
-	if err := p.Start(); err != nil {
+func DeleteCluster(args common.Argument) error {
+	level, err := deleteClusterCleanupLevel(args)
+	if err != nil {
 		return err
 	}
-	return nil
-}
 
-func DeleteCluster(args common.Argument) error {
 	var loaderType string
 	if args.FilePath != "" {
 		loaderType = common.File
 	} else {
 		loaderType = common.AllInOne
 	}
+	if args.Deprovision && loaderType != common.File {
+		return errors.New("--provision requires a config file, set it by -f")
+	}
 
 	runtime, err := common.NewKubeRuntime(loaderType, args)
 	if err != nil {
 		return err
 	}
 
-	switch runtime.Cluster.Kubernetes.Type {
-	case common.K3s:
-		if err := NewK3sDeleteClusterPipeline(runtime); err != nil {
-			return err
-		}
-	case common.K8e:
-		if err := NewK8eDeleteClusterPipeline(runtime); err != nil {
-			return err
-		}
-	case common.Kubernetes:
-		fallthrough
-	default:
-		if err := NewDeleteClusterPipeline(runtime); err != nil {
+	d, err := distribution.Get(runtime.Cluster.Kubernetes.Type)
+	if err != nil {
+		return err
+	}
+	if args.CleanupLevel != "" && !d.CleanupLevels() {
+		return errors.Errorf("--level isn't supported by the %s clusters", d.Name())
+	}
+	// the provisioned machines are deleted instead of being cleaned up, even if they are broken
+	if args.Deprovision && !args.DryRun {
+		if err := deprovision(args); err != nil {
 			return err
 		}
+	} else if err := NewDeleteClusterPipeline(runtime, d, level); err != nil {
+		return err
+	}
+	if runtime.Cluster.Network.IPAM.Enabled() && !args.DryRun {
+		return releaseCIDRs(runtime)
 	}
 	return nil
 }
+
+// releaseCIDRs releases the CIDRs allocated to the deleted cluster by the IPAM, so other clusters can use them.
+func releaseCIDRs(runtime *common.KubeRuntime) error {
+	file := filepath.Join(runtime.GetWorkDir(), ipam.File)
+	store, err := ipam.Load(file)
+	if err != nil {
+		return err
+	}
+	if !store.Release(runtime.ClusterName) {
+		return nil
+	}
+	logger.Log.Infof("ipam: the CIDRs of the cluster %s are released", runtime.ClusterName)
+	return store.Save(file)
+}
@@ -17,6 +17,7 @@
 package pipelines
 
 import (
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/adoption"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/confirm"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/os"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/precheck"
@@ -30,8 +31,11 @@ import (
 func DeleteNodePipeline(runtime *common.KubeRuntime) error {
 	m := []module.Module{
 		&precheck.GreetingsModule{},
+		&precheck.OwnerCheckModule{},
+		&adoption.GateModule{Skip: runtime.Cluster.Kubernetes.Type != common.Kubernetes},
 		&confirm.DeleteNodeConfirmModule{Skip: runtime.Arg.SkipConfirmCheck},
 		&kubernetes.CompareConfigAndClusterInfoModule{},
+		&loadbalancer.DNSRecordsModule{Skip: !runtime.Cluster.ControlPlaneEndpoint.ManagedDNS(), Exclude: runtime.Arg.NodeName},
 		&kubernetes.DeleteKubeNodeModule{},
 		&os.ClearNodeOSModule{},
 		&loadbalancer.DeleteVIPModule{Skip: !runtime.Cluster.ControlPlaneEndpoint.IsInternalLBEnabledVip()},
@@ -0,0 +1,76 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/pipeline"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/diagnose"
+)
+
+// Diagnose collects the support bundle of the nodes of the cluster, all of them or the ones of the names, into the
+// gzip compressed tarball output.
+func Diagnose(args common.Argument, nodes []string, output string, maxFileSize, maxSize int64) error {
+	runtime, err := common.NewKubeRuntime(loaderType(args), args)
+	if err != nil {
+		return err
+	}
+	for _, name := range nodes {
+		found := false
+		for _, host := range runtime.GetAllHosts() {
+			found = found || host.GetName() == name
+		}
+		if !found {
+			return errors.Errorf("the node %s is not in the cluster", name)
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "kubekey-diagnose")
+	if err != nil {
+		return errors.Wrap(err, "create the dir of the bundle failed")
+	}
+	defer os.RemoveAll(dir)
+	name := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(output), ".gz"), ".tar")
+	bundle := diagnose.NewBundle(filepath.Join(dir, name), maxFileSize, maxSize)
+
+	p := pipeline.Pipeline{
+		Name: "DiagnosePipeline",
+		Modules: []module.Module{
+			&diagnose.CollectModule{Nodes: nodes, Bundle: bundle},
+		},
+		Runtime: runtime,
+	}
+	if err := p.Start(); err != nil {
+		return err
+	}
+	if args.DryRun {
+		return nil
+	}
+	if err := bundle.Archive(output); err != nil {
+		return err
+	}
+	fmt.Printf("The support bundle is written to %s: %s.\n", output, bundle)
+	return nil
+}
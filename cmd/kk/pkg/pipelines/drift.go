@@ -0,0 +1,149 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/drift"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/utils"
+)
+
+// DriftNodes reports the drift between the nodes of the cluster and the hosts of its config. With fix, the unmanaged
+// nodes are adopted into the config and the missing hosts are pruned from it, each confirmed unless assumeYes is set.
+func DriftNodes(args common.Argument, fix, assumeYes bool) error {
+	var loaderType string
+	if args.FilePath != "" {
+		loaderType = common.File
+	} else {
+		loaderType = common.AllInOne
+	}
+	cluster, err := common.NewLoader(loaderType, args).Load()
+	if err != nil {
+		return err
+	}
+
+	var hosts []drift.Host
+	seen := make(map[string]struct{})
+	_, roleGroups := cluster.Spec.SetDefaultClusterSpec()
+	for _, role := range []string{common.Master, common.Worker} {
+		for _, host := range roleGroups[role] {
+			if _, ok := seen[host.GetName()]; ok {
+				continue
+			}
+			seen[host.GetName()] = struct{}{}
+			hosts = append(hosts, drift.Host{
+				Name:            host.GetName(),
+				Aliases:         host.Aliases,
+				InternalAddress: kubekeyapiv1alpha2.TrimAddressBrackets(host.GetInternalAddress()),
+			})
+		}
+	}
+
+	client, err := utils.NewClient(args.KubeConfig)
+	if err != nil {
+		return err
+	}
+	list, err := client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to list the nodes of the cluster")
+	}
+	nodes := make([]drift.Node, 0, len(list.Items))
+	for _, node := range list.Items {
+		nodes = append(nodes, drift.NodeOf(node))
+	}
+
+	report := drift.Compare(hosts, nodes)
+	if err := report.Print(os.Stdout); err != nil {
+		return err
+	}
+	if !fix || report.Empty() {
+		return nil
+	}
+	if loaderType != common.File {
+		return errors.New("the drift can only be fixed in a config file, set it by -f")
+	}
+	if cluster.Spec.Inventory != "" {
+		logger.Log.Warnf("the hosts of the cluster are in the inventory %s, fix them there by the suggestions", cluster.Spec.Inventory)
+		return nil
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	var adopt []drift.Node
+	for _, item := range report.Of(drift.Unmanaged) {
+		if assumeYes || promptYesNo(reader, fmt.Sprintf("Adopt the node %s into the config?", item.Name)) {
+			adopt = append(adopt, item.Node)
+		}
+	}
+	var prune []string
+	for _, item := range report.Of(drift.Missing) {
+		if assumeYes || promptYesNo(reader, fmt.Sprintf("Prune the host %s from the config?", item.Name)) {
+			prune = append(prune, item.Name)
+		}
+	}
+	if len(adopt) == 0 && len(prune) == 0 {
+		return nil
+	}
+
+	info, err := os.Stat(args.FilePath)
+	if err != nil {
+		return errors.Wrap(err, "failed to stat the config")
+	}
+	content, err := os.ReadFile(args.FilePath)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the config")
+	}
+	fixed, warnings, err := drift.FixConfig(content, adopt, prune)
+	if err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		logger.Log.Warn(warning)
+	}
+	if err := os.WriteFile(args.FilePath, fixed, info.Mode()); err != nil {
+		return errors.Wrap(err, "failed to write the config")
+	}
+	logger.Log.Infof("adopted %d nodes into and pruned %d hosts from %s", len(adopt), len(prune), args.FilePath)
+	return nil
+}
+
+// promptYesNo asks the question until it is answered by yes or no.
+func promptYesNo(reader *bufio.Reader, question string) bool {
+	for {
+		fmt.Printf("%s [yes/no]: ", question)
+		input, err := reader.ReadString('\n')
+		switch strings.TrimSpace(input) {
+		case "yes":
+			return true
+		case "no":
+			return false
+		}
+		if err != nil {
+			return false
+		}
+	}
+}
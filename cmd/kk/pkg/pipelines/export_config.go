@@ -0,0 +1,91 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/config"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/utils"
+)
+
+// ExportConfig exports the cluster configuration of the live cluster of the kubeconfig to the output file, or to the
+// stdout if it is empty. The hosts are inspected by SSH when sshHosts is set.
+func ExportConfig(args common.Argument, opts config.ExportOptions, sshHosts bool, output string) error {
+	client, err := utils.NewClient(args.KubeConfig)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	var exec config.NodeExec
+	if sshHosts {
+		dialer := connector.NewDialer()
+		exec = func(cfg kubekeyapiv1alpha2.HostCfg, cmd string) (string, error) {
+			host := connector.NewHostWithOptions(connector.HostOptions{
+				Address:         cfg.Address,
+				InternalAddress: cfg.InternalAddress,
+				Port:            cfg.Port,
+				User:            cfg.User,
+				Password:        cfg.Password,
+				KeyFile:         cfg.PrivateKeyPath,
+			})
+			defer dialer.Close(host)
+
+			runner, err := connector.NewRunner(ctx, dialer, host)
+			if err != nil {
+				return "", err
+			}
+			stdout, code, err := runner.Exec(cmd, false)
+			if err != nil {
+				return "", err
+			}
+			if code != 0 {
+				return "", errors.Errorf("%s exited with %d", cmd, code)
+			}
+			return stdout, nil
+		}
+	}
+
+	export, err := config.ExportCluster(ctx, client, opts, exec)
+	if err != nil {
+		return err
+	}
+	data, err := export.Marshal()
+	if err != nil {
+		return err
+	}
+	for _, notice := range export.Notices {
+		fmt.Fprintf(os.Stderr, "Notice: %s\n", notice)
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(output, data, 0600); err != nil {
+		return errors.Wrapf(err, "Failed to write the cluster config to %s", output)
+	}
+	fmt.Printf("the cluster config is exported to %s, check its hosts and notices before KubeKey manages the cluster\n", output)
+	return nil
+}
@@ -0,0 +1,174 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/pipeline"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/history"
+)
+
+// loadHistory returns the history of the managed files of the cluster and the names of its hosts in order, without
+// connecting to them.
+func loadHistory(args common.Argument) (*connector.History, []string, error) {
+	var loaderType string
+	if args.FilePath != "" {
+		loaderType = common.File
+	} else {
+		loaderType = common.AllInOne
+	}
+	cluster, err := common.NewLoader(loaderType, args).Load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	base := connector.NewBaseRuntime(cluster.Name, nil, args.Debug, false)
+	if err := base.InitClusterWorkDir(); err != nil {
+		return nil, nil, err
+	}
+	base.InitHistory()
+
+	hosts := make([]string, 0, len(cluster.Spec.Hosts))
+	for _, host := range cluster.Spec.Hosts {
+		hosts = append(hosts, host.Name)
+	}
+	return base.GetHistory(), hosts, nil
+}
+
+// selectHosts returns the host if it isn't empty, or all the hosts.
+func selectHosts(hosts []string, host string) ([]string, error) {
+	if host == "" {
+		return hosts, nil
+	}
+	for _, h := range hosts {
+		if h == host {
+			return []string{host}, nil
+		}
+	}
+	return nil, errors.Errorf("the host %s is not in the cluster", host)
+}
+
+// ListHistory prints the versions of the managed files of the host, or of all the hosts if it is empty, filtered by
+// the path and the run if they aren't empty.
+func ListHistory(args common.Argument, host, path, run string) error {
+	h, hosts, err := loadHistory(args)
+	if err != nil {
+		return err
+	}
+	hosts, err = selectHosts(hosts, host)
+	if err != nil {
+		return err
+	}
+	versions := make(map[string][]connector.FileVersion, len(hosts))
+	for _, name := range hosts {
+		if versions[name], err = h.Versions(name, path, run); err != nil {
+			return err
+		}
+	}
+	return connector.PrintVersions(os.Stdout, hosts, versions)
+}
+
+// DiffHistory prints the diffs of the changes of the managed files on the host in the run, filtered by the path if
+// it isn't empty. The changes of all the runs are printed if the run is empty.
+func DiffHistory(args common.Argument, host, path, run string) error {
+	h, hosts, err := loadHistory(args)
+	if err != nil {
+		return err
+	}
+	if _, err := selectHosts(hosts, host); err != nil {
+		return err
+	}
+	versions, err := h.Versions(host, path, run)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		var before string
+		if v.Before != "" {
+			if before, err = h.Content(host, v.Before); err != nil {
+				return err
+			}
+		}
+		after, err := h.Content(host, v.After)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("# %s %s\n%s\n", v.Run, v.Time.Format("2006-01-02 15:04:05"), util.Diff(v.Path, before, after))
+	}
+	return nil
+}
+
+// ShowHistory prints the content of the digest in the history of the host.
+func ShowHistory(args common.Argument, host, digest string) error {
+	h, hosts, err := loadHistory(args)
+	if err != nil {
+		return err
+	}
+	if _, err := selectHosts(hosts, host); err != nil {
+		return err
+	}
+	content, err := h.Content(host, digest)
+	if err != nil {
+		return err
+	}
+	fmt.Print(content)
+	return nil
+}
+
+// RevertHistory reverts the managed file on the host to the content of the digest in the history of the host, the
+// revert is recorded in the history as well.
+func RevertHistory(args common.Argument, host, path, digest string) error {
+	var loaderType string
+	if args.FilePath != "" {
+		loaderType = common.File
+	} else {
+		loaderType = common.AllInOne
+	}
+	runtime, err := common.NewKubeRuntime(loaderType, args)
+	if err != nil {
+		return err
+	}
+
+	hosts := make([]string, 0, len(runtime.GetAllHosts()))
+	for _, h := range runtime.GetAllHosts() {
+		hosts = append(hosts, h.GetName())
+	}
+	if _, err := selectHosts(hosts, host); err != nil {
+		return err
+	}
+	// fail before connecting to the host if the content isn't in the history
+	if _, err := runtime.GetHistory().Content(host, digest); err != nil {
+		return err
+	}
+
+	p := pipeline.Pipeline{
+		Name: "RevertHistoryPipeline",
+		Modules: []module.Module{
+			&history.RevertModule{Host: host, Path: path, Digest: digest},
+		},
+		Runtime: runtime,
+	}
+	return p.Start()
+}
@@ -17,12 +17,12 @@
 package pipelines
 
 import (
-	"fmt"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/artifact"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/binaries"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/os"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/precheck"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/registry"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/tuning"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
@@ -39,6 +39,7 @@ func NewInitRegistryPipeline(runtime *common.KubeRuntime) error {
 		&artifact.UnArchiveModule{Skip: noArtifact},
 		&binaries.RegistryPackageModule{},
 		&os.ConfigureOSModule{Skip: runtime.Cluster.System.SkipConfigureOS},
+		&tuning.TuningModule{Skip: runtime.Cluster.System.SkipConfigureOS},
 		&registry.RegistryCertsModule{},
 		&registry.InstallRegistryModule{},
 		&filesystem.ChownWorkDirModule{},
@@ -55,14 +56,7 @@ func NewInitRegistryPipeline(runtime *common.KubeRuntime) error {
 	return nil
 }
 
-func InitRegistry(args common.Argument, downloadCmd string) error {
-	args.DownloadCommand = func(path, url string) string {
-		// this is an extension point for downloading tools, for example users can set the timeout, proxy or retry under
-		// some poor network environment. Or users even can choose another cli, it might be wget.
-		// perhaps we should have a build-in download function instead of totally rely on the external one
-		return fmt.Sprintf(downloadCmd, path, url)
-	}
-
+func InitRegistry(args common.Argument) error {
 	var loaderType string
 	if args.FilePath != "" {
 		loaderType = common.File
@@ -47,10 +47,7 @@ func MigrateCriPipeline(runtime *common.KubeRuntime) error {
 	return nil
 }
 
-func MigrateCri(args common.Argument, downloadCmd string) error {
-	args.DownloadCommand = func(path, url string) string {
-		return fmt.Sprintf(downloadCmd, path, url)
-	}
+func MigrateCri(args common.Argument) error {
 	var loaderType string
 	if args.FilePath != "" {
 		loaderType = common.File
@@ -0,0 +1,64 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/confirm"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/precheck"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/pipeline"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/patch"
+)
+
+// PatchOS applies the OS updates to the nodes of the cluster, all of them or the ones of the names, one batch of the
+// serial after another, after a confirmation.
+func PatchOS(args common.Argument, nodes []string, options patch.Options) error {
+	runtime, err := common.NewKubeRuntime(loaderType(args), args)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, host := range runtime.GetAllHosts() {
+		names = append(names, host.GetName())
+	}
+	for _, name := range nodes {
+		found := false
+		for _, host := range runtime.GetAllHosts() {
+			found = found || host.GetName() == name
+		}
+		if !found {
+			return errors.Errorf("the node %s is not in the cluster", name)
+		}
+	}
+	if len(nodes) > 0 {
+		names = nodes
+	}
+
+	p := pipeline.Pipeline{
+		Name: "PatchOSPipeline",
+		Modules: []module.Module{
+			&precheck.GreetingsModule{},
+			&confirm.PatchConfirmModule{Skip: args.SkipConfirmCheck, Nodes: names, Reboot: options.Reboot},
+			&patch.PatchModule{Nodes: nodes, Options: options},
+		},
+		Runtime: runtime,
+	}
+	return p.Start()
+}
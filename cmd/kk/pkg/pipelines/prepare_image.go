@@ -0,0 +1,95 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/artifact"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/binaries"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/os"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/precheck"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/tuning"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/container"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/pipeline"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/facts"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/images"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/kubernetes"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/nodeimage"
+)
+
+// NewPrepareImagePipeline runs the node preparation of the kubernetes type on the single host of the runtime: the os
+// dependencies and the sysctls, the container runtime, the images and the kubernetes binaries, then records the
+// steps baked and generalizes the host, so it can be captured as a golden image.
+func NewPrepareImagePipeline(runtime *common.KubeRuntime) error {
+	noArtifact := runtime.Arg.Artifact == ""
+
+	steps := []string{facts.BakedKubernetes}
+	if !runtime.Cluster.System.SkipConfigureOS {
+		steps = append(steps, facts.BakedDependencies)
+	}
+	if !runtime.Arg.SkipPullImages {
+		steps = append(steps, facts.BakedImages)
+	}
+
+	p := pipeline.Pipeline{
+		Name: "PrepareImagePipeline",
+		Modules: []module.Module{
+			&precheck.GreetingsModule{},
+			&precheck.NodePreCheckModule{},
+			&artifact.UnArchiveModule{Skip: noArtifact},
+			&os.RepositoryModule{Skip: noArtifact || !runtime.Arg.InstallPackages},
+			&binaries.NodeBinariesModule{},
+			&os.ConfigureOSModule{Skip: runtime.Cluster.System.SkipConfigureOS},
+			&tuning.TuningModule{Skip: runtime.Cluster.System.SkipConfigureOS},
+			&kubernetes.StatusModule{},
+			&container.InstallContainerModule{},
+			&container.InstallCriDockerdModule{Skip: runtime.Cluster.Kubernetes.ContainerManager != common.Docker},
+			&images.PullModule{Skip: runtime.Arg.SkipPullImages},
+			&kubernetes.InstallKubeBinariesModule{},
+			&nodeimage.BakeModule{Steps: steps},
+		},
+		Runtime: runtime,
+	}
+	if err := p.Start(); err != nil {
+		return err
+	}
+	if runtime.Arg.DryRun {
+		return nil
+	}
+	fmt.Printf("The node image of %s is prepared, shut it down and capture it as an image.\n", runtime.GetAllHosts()[0].GetName())
+	return nil
+}
+
+// PrepareImage prepares the single host of the config as a golden node image.
+func PrepareImage(args common.Argument) error {
+	runtime, err := common.NewKubeRuntime(loaderType(args), args)
+	if err != nil {
+		return err
+	}
+	if t := runtime.Cluster.Kubernetes.Type; t != "" && t != common.Kubernetes {
+		return errors.Errorf("kk prepare-image only prepares the images of kubernetes.type %s, not %s", common.Kubernetes, t)
+	}
+	if n := len(runtime.GetAllHosts()); n != 1 {
+		return errors.Errorf("kk prepare-image prepares a single host, the config has %d hosts", n)
+	}
+	return NewPrepareImagePipeline(runtime)
+}
@@ -0,0 +1,54 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"bufio"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/provision"
+)
+
+// deprovision deletes the machines provisioned for the hosts of the inventory of the cluster.
+func deprovision(args common.Argument) error {
+	cluster, err := common.NewLoader(common.File, args).Load()
+	if err != nil {
+		return err
+	}
+	if cluster.Spec.Inventory == "" {
+		return errors.New("--provision requires the hosts of the cluster in an inventory")
+	}
+	inv, err := common.LoadInventory(args.FilePath, cluster.Spec.Inventory)
+	if err != nil {
+		return err
+	}
+	if inv.Provision == nil {
+		return errors.Errorf("no provision config in the inventory %s", cluster.Spec.Inventory)
+	}
+	// the hosts are resolved again, as the loader sets the addresses of the provisioned ones
+	hosts, _, err := inv.Resolve()
+	if err != nil {
+		return err
+	}
+	if !args.SkipConfirmCheck && !promptYesNo(bufio.NewReader(os.Stdin), "Delete the provisioned machines of the cluster?") {
+		os.Exit(0)
+	}
+	return provision.Destroy(*inv.Provision, hosts)
+}
@@ -0,0 +1,97 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+// loadQuarantine returns the quarantine of the cluster and the names of its hosts, without connecting to them.
+func loadQuarantine(args common.Argument) (*connector.Quarantine, map[string]struct{}, error) {
+	var loaderType string
+	if args.FilePath != "" {
+		loaderType = common.File
+	} else {
+		loaderType = common.AllInOne
+	}
+	cluster, err := common.NewLoader(loaderType, args).Load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	base := connector.NewBaseRuntime(cluster.Name, nil, args.Debug, false)
+	if err := base.InitClusterWorkDir(); err != nil {
+		return nil, nil, err
+	}
+	if err := base.InitQuarantine(); err != nil {
+		return nil, nil, err
+	}
+
+	hosts := make(map[string]struct{}, len(cluster.Spec.Hosts))
+	for _, host := range cluster.Spec.Hosts {
+		hosts[host.Name] = struct{}{}
+	}
+	return base.GetQuarantine(), hosts, nil
+}
+
+// ListQuarantine prints the quarantined hosts of the cluster.
+func ListQuarantine(args common.Argument) error {
+	q, _, err := loadQuarantine(args)
+	if err != nil {
+		return err
+	}
+	return q.Print(os.Stdout)
+}
+
+// QuarantineHosts quarantines the hosts of the cluster, so the pipelines skip them until they are released.
+func QuarantineHosts(args common.Argument, names []string, reason string) error {
+	q, hosts, err := loadQuarantine(args)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, ok := hosts[name]; !ok {
+			return errors.Errorf("the host %s is not in the cluster", name)
+		}
+		q.Add(name, reason, false, time.Now())
+		logger.Log.Infof("quarantine the host %s", name)
+	}
+	return q.Save()
+}
+
+// ReleaseHosts releases the hosts of the cluster from the quarantine.
+func ReleaseHosts(args common.Argument, names []string) error {
+	q, _, err := loadQuarantine(args)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if !q.Remove(name) {
+			logger.Log.Warnf("the host %s is not quarantined", name)
+			continue
+		}
+		logger.Log.Infof("release the host %s from the quarantine", name)
+	}
+	return q.Save()
+}
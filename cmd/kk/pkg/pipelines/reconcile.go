@@ -0,0 +1,130 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/pipeline"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/distribution"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/files"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/reconcile"
+)
+
+// ReconcileCluster reports the drift of the hosts of the cluster from the cluster spec: the files rendered by
+// kk render, the kernel parameters and the installed versions. With fix, the drift of the files and the kernel
+// parameters is repaired after a confirmation, unless in the check mode.
+func ReconcileCluster(args common.Argument, fix bool) error {
+	dir, err := os.MkdirTemp("", "kubekey-reconcile")
+	if err != nil {
+		return errors.Wrap(err, "create the render dir failed")
+	}
+	defer os.RemoveAll(dir)
+
+	if err := renderCluster(args, dir); err != nil {
+		return errors.Wrap(err, "render the files of the cluster failed")
+	}
+
+	// the hosts are only read by the diff, so it runs in the check mode too
+	diffArgs := args
+	diffArgs.DryRun = false
+	report := &reconcile.Report{}
+	if err := runDiff(diffArgs, dir, report, false); err != nil {
+		return err
+	}
+	if err := report.Print(os.Stdout, args.Debug); err != nil {
+		return err
+	}
+	if !fix || report.Repairable() == 0 {
+		return nil
+	}
+	if args.DryRun {
+		fmt.Println("The drift isn't repaired in the check mode.")
+		return nil
+	}
+
+	if !args.SkipConfirmCheck {
+		question := fmt.Sprintf("Repair the %d drifts of the files and the kernel parameters?", report.Repairable())
+		if !promptYesNo(bufio.NewReader(os.Stdin), question) {
+			os.Exit(0)
+		}
+	}
+	repaired := &reconcile.Report{}
+	if err := runDiff(args, dir, repaired, true); err != nil {
+		return err
+	}
+	return repaired.Print(os.Stdout, false)
+}
+
+// renderCluster renders the files of the create cluster pipeline for each host under the dir as kk render, the lock of
+// the cluster is released afterwards for the pipelines on the hosts.
+func renderCluster(args common.Argument, dir string) error {
+	renderArgs := common.Argument{
+		FilePath:         args.FilePath,
+		Debug:            args.Debug,
+		Namespace:        args.Namespace,
+		RenderDir:        dir,
+		SkipConfirmCheck: true,
+		NoTUI:            true,
+	}
+	// the binaries are copied to the nodes but never rendered
+	files.SkipDownload()
+
+	runtime, err := common.NewKubeRuntime(loaderType(args), renderArgs)
+	if err != nil {
+		return err
+	}
+	defer runtime.Unlock()
+	d, err := distribution.Get(runtime.Cluster.Kubernetes.Type)
+	if err != nil {
+		return err
+	}
+	return NewCreateClusterPipeline(runtime, d)
+}
+
+func loaderType(args common.Argument) string {
+	if args.FilePath != "" {
+		return common.File
+	}
+	return common.AllInOne
+}
+
+func runDiff(args common.Argument, dir string, report *reconcile.Report, fix bool) error {
+	runtime, err := common.NewKubeRuntime(loaderType(args), args)
+	if err != nil {
+		return err
+	}
+
+	name := "DiffPipeline"
+	if fix {
+		name = "ReconcilePipeline"
+	}
+	p := pipeline.Pipeline{
+		Name: name,
+		Modules: []module.Module{
+			&reconcile.DiffModule{Dir: dir, Report: report, Fix: fix},
+		},
+		Runtime: runtime,
+	}
+	return p.Start()
+}
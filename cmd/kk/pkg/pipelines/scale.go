@@ -0,0 +1,192 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package pipelines
+
+import (
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/adoption"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/artifact"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/confirm"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/os"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/precheck"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/proxy"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/tuning"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/module"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/pipeline"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/distribution"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/loadbalancer"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/scale"
+)
+
+// NewScaleUpPipeline joins the new control-plane or etcd nodes named by the arguments, and refuses to join any other
+// node which isn't in the cluster yet.
+func NewScaleUpPipeline(runtime *common.KubeRuntime, d distribution.Distribution, role string) error {
+	m := []module.Module{
+		&precheck.GreetingsModule{},
+		&scale.CheckScaleUpModule{Role: role},
+	}
+	if role == common.Master {
+		m = append(m, addNodesModules(runtime, d)...)
+	} else {
+		noArtifact := runtime.Arg.Artifact == ""
+		m = append(m, d.PreCheckModules(runtime)...)
+		m = append(m,
+			&artifact.UnArchiveModule{Skip: noArtifact},
+			&os.RepositoryModule{Skip: noArtifact || !runtime.Arg.InstallPackages},
+			d.BinariesModule(),
+			&proxy.ConfigureProxyModule{Skip: !runtime.Cluster.System.Proxy.Enabled()},
+			&os.ConfigureOSModule{Skip: runtime.Cluster.System.SkipConfigureOS},
+			&tuning.TuningModule{Skip: runtime.Cluster.System.SkipConfigureOS},
+		)
+		m = append(m, etcdRole(runtime).Include()...)
+		m = append(m, &scale.RefreshEtcdServersModule{})
+	}
+
+	p := pipeline.Pipeline{
+		Name:    "ScaleUpPipeline",
+		Modules: m,
+		Runtime: runtime,
+	}
+	if err := p.Start(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// NewScaleDownPipeline removes the node from the control plane or from etcd. The node is dropped from the roles of the
+// runtime first, so the load balancer, the DNS records and the etcd servers of the kube-apiservers are rebuilt
+// without it. A quarantined node is removed from the cluster but isn't cleared.
+func NewScaleDownPipeline(runtime *common.KubeRuntime, role string, node connector.Host, quarantined bool) error {
+	runtime.RoleMapDelete(node)
+
+	endpoint := runtime.Cluster.ControlPlaneEndpoint
+	m := []module.Module{
+		&precheck.GreetingsModule{},
+		&precheck.OwnerCheckModule{},
+		&adoption.GateModule{},
+		&confirm.DeleteNodeConfirmModule{Skip: runtime.Arg.SkipConfirmCheck},
+		&scale.CheckScaleDownModule{Role: role, Node: node},
+	}
+	if role == common.Master {
+		m = append(m,
+			&scale.DeleteNodeModule{Drain: !quarantined},
+			&scale.RemoveMemberModule{Skip: !scale.RemovesMember(role, runtime.Cluster), Role: role},
+			&scale.ClearNodeModule{Role: role},
+			&loadbalancer.DNSRecordsModule{Skip: !endpoint.ManagedDNS(), Exclude: node.GetName()},
+			&loadbalancer.HaproxyModule{Skip: !endpoint.IsInternalLBEnabled()},
+		)
+	} else {
+		m = append(m,
+			&scale.RefreshEtcdServersModule{},
+			&scale.RemoveMemberModule{Role: role},
+			&scale.ClearNodeModule{Role: role},
+		)
+	}
+
+	p := pipeline.Pipeline{
+		Name:    "ScaleDownPipeline",
+		Modules: m,
+		Runtime: runtime,
+	}
+	if err := p.Start(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ScaleUp adds the nodes of the args to the control plane or to etcd.
+func ScaleUp(args common.Argument, role string) error {
+	runtime, err := newScaleRuntime(args, role)
+	if err != nil {
+		return err
+	}
+	d, err := distribution.Get(runtime.Cluster.Kubernetes.Type)
+	if err != nil {
+		return err
+	}
+	return NewScaleUpPipeline(runtime, d, role)
+}
+
+// ScaleDown removes the node of the args from the control plane or from etcd.
+func ScaleDown(args common.Argument, role string) error {
+	runtime, err := newScaleRuntime(args, role)
+	if err != nil {
+		return err
+	}
+
+	name := args.NodeName
+	for _, host := range runtime.GetHostsByRole(role) {
+		if host.GetName() == name {
+			return NewScaleDownPipeline(runtime, role, host, false)
+		}
+	}
+	// the quarantined hosts, e.g. a dead node, are only in the spec of the cluster
+	for _, host := range runtime.Cluster.GroupHosts()[role] {
+		if host.GetName() == name && runtime.HostIsDeprecated(host) {
+			return NewScaleDownPipeline(runtime, role, host, true)
+		}
+	}
+	return errors.Errorf("%s isn't a %s host of the config", name, role)
+}
+
+func newScaleRuntime(args common.Argument, role string) (*common.KubeRuntime, error) {
+	var loaderType string
+	if args.FilePath != "" {
+		loaderType = common.File
+	} else {
+		loaderType = common.AllInOne
+	}
+
+	runtime, err := common.NewKubeRuntime(loaderType, args)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster := runtime.Cluster
+	if cluster.Kubernetes.Type != "" && cluster.Kubernetes.Type != common.Kubernetes {
+		return nil, errors.Errorf("the scaling isn't supported by the kubernetes type %s", cluster.Kubernetes.Type)
+	}
+	if role == common.ETCD && cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey {
+		return nil, errors.Errorf("only the kubekey etcd can be scaled, the %s etcd follows its own nodes", cluster.Etcd.Type)
+	}
+	if args.NodeName == "" {
+		return runtime, nil
+	}
+	groups := cluster.GroupHosts()
+	left := 0
+	for _, host := range groups[role] {
+		if host.GetName() != args.NodeName {
+			left++
+		}
+	}
+	if left == 0 {
+		return nil, errors.Errorf("the last %s host can't be removed", role)
+	}
+	if role == common.Master && cluster.Etcd.Type == kubekeyapiv1alpha2.KubeKey {
+		for _, host := range groups[common.ETCD] {
+			if host.GetName() == args.NodeName {
+				return nil, errors.Errorf("%s is an etcd host, remove it with kk scale etcd --remove and from the etcd "+
+					"role group first", args.NodeName)
+			}
+		}
+	}
+	return runtime, nil
+}
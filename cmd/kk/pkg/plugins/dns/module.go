@@ -34,6 +34,7 @@ type ClusterDNSModule struct {
 
 func (c *ClusterDNSModule) Init() {
 	c.Name = "ClusterDNSModule"
+	c.Tags = []string{"dns"}
 	c.Desc = "Deploy cluster dns"
 
 	generateCorednsConfigMap := &task.RemoteTask{
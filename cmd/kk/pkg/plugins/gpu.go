@@ -0,0 +1,397 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package plugins
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/lithammer/dedent"
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/addons"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/images"
+)
+
+// NvidiaGPU is the key of whether the host has an NVIDIA GPU in the host cache, which is set by the DetectNvidiaGPU
+// task from the output of lspci.
+const NvidiaGPU = "nvidiaGPU"
+
+// NvidiaGPULabel is the label of the nodes with an NVIDIA GPU, which the device plugin and the GPU operator
+// are scheduled on.
+const NvidiaGPULabel = "nvidia.com/gpu.present"
+
+const (
+	nvidiaToolkitKeyring = "/usr/share/keyrings/nvidia-container-toolkit-keyring.gpg"
+	nvidiaToolkitRepo    = "https://nvidia.github.io/libnvidia-container"
+	gpuOperatorRepo      = "https://helm.ngc.nvidia.com/nvidia"
+)
+
+var (
+	NvidiaDevicePlugin = template.Must(template.New("nvidia-device-plugin.yaml").Parse(
+		dedent.Dedent(`---
+apiVersion: node.k8s.io/v1
+kind: RuntimeClass
+metadata:
+  name: nvidia
+handler: nvidia
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: nvidia-device-plugin-daemonset
+  namespace: kube-system
+spec:
+  selector:
+    matchLabels:
+      name: nvidia-device-plugin-ds
+  updateStrategy:
+    type: RollingUpdate
+  template:
+    metadata:
+      labels:
+        name: nvidia-device-plugin-ds
+    spec:
+      runtimeClassName: nvidia
+      nodeSelector:
+        nvidia.com/gpu.present: "true"
+      tolerations:
+      - key: nvidia.com/gpu
+        operator: Exists
+        effect: NoSchedule
+      priorityClassName: system-node-critical
+      containers:
+      - image: {{ .DevicePluginImage }}
+        name: nvidia-device-plugin-ctr
+        env:
+        - name: FAIL_ON_INIT_ERROR
+          value: "false"
+        securityContext:
+          allowPrivilegeEscalation: false
+          capabilities:
+            drop: ["ALL"]
+        volumeMounts:
+        - name: device-plugin
+          mountPath: /var/lib/kubelet/device-plugins
+      volumes:
+      - name: device-plugin
+        hostPath:
+          path: /var/lib/kubelet/device-plugins
+    `)))
+
+	// lspciGPU matches the display controllers of NVIDIA in the output of lspci -nn, e.g.
+	// 01:00.0 3D controller [0302]: NVIDIA Corporation GA100 [A100 SXM4 40GB] [10de:20b0] (rev a1)
+	lspciGPU = regexp.MustCompile(`^\S+ .*\[03[0-9a-f]{2}\]: (.*) \[10de:[0-9a-f]{4}\]`)
+)
+
+// GPUModule enables the nodes with an NVIDIA GPU when kubernetes.gpu is enabled. The GPUs are detected with lspci
+// and the nodes are labeled with nvidia.com/gpu.present=true. In the toolkit mode the driver and the NVIDIA
+// container toolkit are installed on the nodes, the container runtime is configured with the runtime nvidia, and
+// the runtime class nvidia and the device plugin are deployed. In the operator mode the GPU operator is deployed,
+// which manages the driver, the toolkit and the device plugin itself.
+type GPUModule struct {
+	common.KubeModule
+	Skip bool
+}
+
+func (g *GPUModule) IsSkip() bool {
+	return g.Skip
+}
+
+func (g *GPUModule) Init() {
+	g.Name = "GPUModule"
+	g.Desc = "Enable the NVIDIA GPU nodes"
+
+	detect := &task.RemoteTask{
+		Name:     "DetectNvidiaGPU",
+		Desc:     "Detect the NVIDIA GPUs of the nodes",
+		Hosts:    g.Runtime.GetHostsByRole(common.K8s),
+		Action:   new(DetectNvidiaGPU),
+		Parallel: true,
+	}
+
+	label := &task.RemoteTask{
+		Name:    "LabelGPUNodes",
+		Desc:    "Label the NVIDIA GPU nodes",
+		Hosts:   g.Runtime.GetHostsByRole(common.Master),
+		Prepare: new(common.OnlyFirstMaster),
+		Action:  new(LabelGPUNodes),
+	}
+
+	if g.KubeConf.Cluster.Kubernetes.GPUMode() == kubekeyapiv1alpha2.GPUModeOperator {
+		installOperator := &task.LocalTask{
+			Name:   "InstallGPUOperator",
+			Desc:   "Install the NVIDIA GPU operator",
+			Action: new(InstallGPUOperator),
+		}
+
+		g.Tasks = []task.Interface{
+			detect,
+			label,
+			installOperator,
+		}
+		return
+	}
+
+	installDriver := &task.RemoteTask{
+		Name:     "InstallNvidiaDriver",
+		Desc:     "Install the NVIDIA driver",
+		Hosts:    g.Runtime.GetHostsByRole(common.K8s),
+		Prepare:  new(NvidiaGPUDetected),
+		Action:   new(InstallNvidiaDriver),
+		Parallel: true,
+	}
+
+	installToolkit := &task.RemoteTask{
+		Name:     "InstallNvidiaContainerToolkit",
+		Desc:     "Install the NVIDIA container toolkit",
+		Hosts:    g.Runtime.GetHostsByRole(common.K8s),
+		Prepare:  new(NvidiaGPUDetected),
+		Action:   new(InstallNvidiaContainerToolkit),
+		Parallel: true,
+		Retry:    2,
+	}
+
+	configureRuntime := &task.RemoteTask{
+		Name:     "ConfigureNvidiaContainerRuntime",
+		Desc:     "Configure the container runtime nvidia",
+		Hosts:    g.Runtime.GetHostsByRole(common.K8s),
+		Prepare:  new(NvidiaGPUDetected),
+		Action:   new(ConfigureNvidiaContainerRuntime),
+		Parallel: true,
+	}
+
+	generateDevicePlugin := &task.RemoteTask{
+		Name:    "GenerateNvidiaDevicePluginManifests",
+		Desc:    "Generate the NVIDIA device plugin manifests",
+		Hosts:   g.Runtime.GetHostsByRole(common.Master),
+		Prepare: new(common.OnlyFirstMaster),
+		Action: &action.Template{
+			Template: NvidiaDevicePlugin,
+			Data: util.Data{
+				"DevicePluginImage": images.GetImage(g.Runtime, g.KubeConf, "k8s-device-plugin").ImageName(),
+			},
+			Dst: filepath.Join(common.KubeAddonsDir, NvidiaDevicePlugin.Name()),
+		},
+	}
+
+	deployDevicePlugin := &task.RemoteTask{
+		Name:    "ApplyNvidiaDevicePluginManifests",
+		Desc:    "Apply the NVIDIA device plugin manifests",
+		Hosts:   g.Runtime.GetHostsByRole(common.Master),
+		Prepare: new(common.OnlyFirstMaster),
+		Action:  new(ApplyNvidiaDevicePluginManifests),
+	}
+
+	g.Tasks = []task.Interface{
+		detect,
+		installDriver,
+		installToolkit,
+		configureRuntime,
+		label,
+		generateDevicePlugin,
+		deployDevicePlugin,
+	}
+}
+
+type NvidiaGPUDetected struct {
+	common.KubePrepare
+}
+
+func (n *NvidiaGPUDetected) PreCheck(runtime connector.Runtime) (bool, error) {
+	gpu, _ := runtime.RemoteHost().GetCache().GetMustBool(NvidiaGPU)
+	return gpu, nil
+}
+
+type DetectNvidiaGPU struct {
+	common.KubeAction
+}
+
+func (d *DetectNvidiaGPU) Execute(runtime connector.Runtime) error {
+	host := runtime.RemoteHost()
+	host.GetCache().Set(NvidiaGPU, false)
+	if _, err := runtime.GetRunner().SudoCmd("command -v lspci", false); err != nil {
+		logger.Log.Warningf("lspci isn't found on %s, install pciutils to detect its NVIDIA GPUs", host.GetName())
+		return nil
+	}
+	out, err := runtime.GetRunner().SudoCmd("lspci -nn", false)
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "list the PCI devices failed")
+	}
+	gpus := nvidiaGPUs(out)
+	if len(gpus) == 0 {
+		return nil
+	}
+	host.GetCache().Set(NvidiaGPU, true)
+	logger.Log.Messagef(host.GetName(), "found %d NVIDIA GPUs: %s", len(gpus), strings.Join(gpus, ", "))
+	return nil
+}
+
+// nvidiaGPUs returns the names of the NVIDIA GPUs in the output of lspci -nn. The other NVIDIA devices, e.g. the
+// audio controllers of the graphics cards, aren't GPUs.
+func nvidiaGPUs(lspci string) []string {
+	var gpus []string
+	for _, line := range strings.Split(lspci, "\n") {
+		if m := lspciGPU.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			gpus = append(gpus, m[1])
+		}
+	}
+	return gpus
+}
+
+// installPackageCmd returns the command installing the packages with the package manager of the host.
+func installPackageCmd(pkg ...string) string {
+	p := strings.Join(pkg, " ")
+	return fmt.Sprintf("if command -v apt-get >/dev/null 2>&1; then apt-get update -qq && DEBIAN_FRONTEND=noninteractive apt-get install -y %s; "+
+		"elif command -v dnf >/dev/null 2>&1; then dnf install -y %s; else yum install -y %s; fi", p, p, p)
+}
+
+type InstallNvidiaDriver struct {
+	common.KubeAction
+}
+
+func (i *InstallNvidiaDriver) Execute(runtime connector.Runtime) error {
+	if _, err := runtime.GetRunner().SudoCmd("nvidia-smi -L", false); err == nil {
+		return nil
+	}
+
+	cmd := installPackageCmd("nvidia-driver")
+	if pkg := i.KubeConf.Cluster.Kubernetes.GPU.DriverPackage; pkg != "" {
+		cmd = installPackageCmd(pkg)
+	} else if _, err := runtime.GetRunner().SudoCmd("command -v ubuntu-drivers", false); err == nil {
+		cmd = "ubuntu-drivers install"
+	}
+	if _, err := runtime.GetRunner().SudoCmd(cmd, true); err != nil {
+		return errors.Wrap(errors.WithStack(err), "install the NVIDIA driver failed")
+	}
+	if _, err := runtime.GetRunner().SudoCmd("modprobe nvidia && nvidia-smi -L", true); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "the NVIDIA driver is installed on %s but isn't loaded, reboot the node and run again",
+			runtime.RemoteHost().GetName())
+	}
+	return nil
+}
+
+type InstallNvidiaContainerToolkit struct {
+	common.KubeAction
+}
+
+func (i *InstallNvidiaContainerToolkit) Execute(runtime connector.Runtime) error {
+	if _, err := runtime.GetRunner().SudoCmd("command -v nvidia-ctk", false); err == nil {
+		return nil
+	}
+
+	// the offline nodes install the toolkit from the repositories configured on them.
+	if !i.KubeConf.Cluster.Offline {
+		addRepo := fmt.Sprintf("if command -v apt-get >/dev/null 2>&1; then "+
+			"curl -fsSL %[1]s/gpgkey | gpg --dearmor --yes -o %[2]s && "+
+			"curl -fsSL %[1]s/stable/deb/nvidia-container-toolkit.list | sed 's#deb https://#deb [signed-by=%[2]s] https://#g' > /etc/apt/sources.list.d/nvidia-container-toolkit.list; "+
+			"else curl -fsSL %[1]s/stable/rpm/nvidia-container-toolkit.repo -o /etc/yum.repos.d/nvidia-container-toolkit.repo; fi",
+			nvidiaToolkitRepo, nvidiaToolkitKeyring)
+		if _, err := runtime.GetRunner().SudoCmd(addRepo, false); err != nil {
+			return errors.Wrap(errors.WithStack(err), "add the NVIDIA container toolkit repository failed")
+		}
+	}
+	if _, err := runtime.GetRunner().SudoCmd(installPackageCmd("nvidia-container-toolkit"), true); err != nil {
+		return errors.Wrap(errors.WithStack(err), "install the NVIDIA container toolkit failed")
+	}
+	return nil
+}
+
+type ConfigureNvidiaContainerRuntime struct {
+	common.KubeAction
+}
+
+func (c *ConfigureNvidiaContainerRuntime) Execute(runtime connector.Runtime) error {
+	manager := c.KubeConf.Cluster.Kubernetes.ContainerManager
+	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf(
+		"nvidia-ctk runtime configure --runtime=%s && systemctl restart %s", manager, manager), true); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "configure the runtime nvidia of %s failed", manager)
+	}
+	return nil
+}
+
+type LabelGPUNodes struct {
+	common.KubeAction
+}
+
+func (l *LabelGPUNodes) Execute(runtime connector.Runtime) error {
+	for _, host := range runtime.GetHostsByRole(common.K8s) {
+		if gpu, _ := host.GetCache().GetMustBool(NvidiaGPU); !gpu {
+			continue
+		}
+		if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf(
+			"/usr/local/bin/kubectl label --overwrite node %s %s=true", host.GetName(), NvidiaGPULabel), true); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "label the GPU node %s failed", host.GetName())
+		}
+	}
+	return nil
+}
+
+type ApplyNvidiaDevicePluginManifests struct {
+	common.KubeAction
+}
+
+func (a *ApplyNvidiaDevicePluginManifests) Execute(runtime connector.Runtime) error {
+	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("/usr/local/bin/kubectl apply -f %s",
+		filepath.Join(common.KubeAddonsDir, NvidiaDevicePlugin.Name())), true); err != nil {
+		return errors.Wrap(errors.WithStack(err), "apply the NVIDIA device plugin manifests failed")
+	}
+	return nil
+}
+
+type InstallGPUOperator struct {
+	common.KubeAction
+}
+
+func (i *InstallGPUOperator) Execute(runtime connector.Runtime) error {
+	addon := gpuOperatorAddon(&i.KubeConf.Cluster.Kubernetes)
+	logger.Log.Messagef(runtime.RemoteHost().GetName(), "Install the NVIDIA GPU operator")
+	kubeConfig := filepath.Join(runtime.GetClusterWorkDir(), fmt.Sprintf("config-%s", runtime.GetObjName()))
+	return addons.InstallAddons(i.KubeConf, addon, kubeConfig, filepath.Join(runtime.GetWorkDir(), common.Charts))
+}
+
+// gpuOperatorAddon returns the chart addon of the GPU operator. The operator deploys its own node-feature-discovery
+// unless it's deployed by KubeKey, and the values of kubernetes.gpu.operatorValues override the defaults.
+func gpuOperatorAddon(k *kubekeyapiv1alpha2.Kubernetes) *kubekeyapiv1alpha2.Addon {
+	values := []string{fmt.Sprintf("operator.defaultRuntime=%s", k.ContainerManager)}
+	if k.EnableNodeFeatureDiscovery() {
+		values = append(values, "nfd.enabled=false")
+	}
+	return &kubekeyapiv1alpha2.Addon{
+		Name:      "gpu-operator",
+		Namespace: "gpu-operator",
+		Sources: kubekeyapiv1alpha2.Sources{
+			Chart: kubekeyapiv1alpha2.Chart{
+				Name:    "gpu-operator",
+				Repo:    gpuOperatorRepo,
+				Version: k.GPU.OperatorVersion,
+				Values:  append(values, k.GPU.OperatorValues...),
+				Wait:    true,
+			},
+		},
+	}
+}
@@ -0,0 +1,74 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package plugins
+
+import (
+	"reflect"
+	"testing"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+func TestNvidiaGPUs(t *testing.T) {
+	tests := []struct {
+		name  string
+		lspci string
+		want  []string
+	}{
+		{
+			name: "data center GPUs",
+			lspci: `00:02.0 VGA compatible controller [0300]: Matrox Electronics Systems Ltd. Integrated Matrox G200eW3 Graphics Controller [102b:0536] (rev 04)
+17:00.0 3D controller [0302]: NVIDIA Corporation GA100 [A100 PCIe 40GB] [10de:20f1] (rev a1)
+65:00.0 3D controller [0302]: NVIDIA Corporation GA100 [A100 PCIe 40GB] [10de:20f1] (rev a1)`,
+			want: []string{"NVIDIA Corporation GA100 [A100 PCIe 40GB]", "NVIDIA Corporation GA100 [A100 PCIe 40GB]"},
+		},
+		{
+			name: "graphics card with an audio controller",
+			lspci: `01:00.0 VGA compatible controller [0300]: NVIDIA Corporation TU104 [GeForce RTX 2080] [10de:1e82] (rev a1)
+01:00.1 Audio device [0403]: NVIDIA Corporation TU104 HD Audio Controller [10de:10f8] (rev a1)`,
+			want: []string{"NVIDIA Corporation TU104 [GeForce RTX 2080]"},
+		},
+		{
+			name:  "no GPU",
+			lspci: `00:1f.2 SATA controller [0106]: Intel Corporation C600/X79 series chipset 6-Port SATA AHCI Controller [8086:1d02] (rev 06)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nvidiaGPUs(tt.lspci); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("nvidiaGPUs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGPUOperatorAddon(t *testing.T) {
+	enabled := true
+	k := &kubekeyapiv1alpha2.Kubernetes{
+		ContainerManager:     "containerd",
+		NodeFeatureDiscovery: kubekeyapiv1alpha2.NodeFeatureDiscovery{Enabled: &enabled},
+		GPU:                  kubekeyapiv1alpha2.GPU{OperatorVersion: "v23.9.1", OperatorValues: []string{"driver.enabled=false"}},
+	}
+	addon := gpuOperatorAddon(k)
+	want := []string{"operator.defaultRuntime=containerd", "nfd.enabled=false", "driver.enabled=false"}
+	if !reflect.DeepEqual(addon.Sources.Chart.Values, want) {
+		t.Errorf("gpuOperatorAddon() values = %v, want %v", addon.Sources.Chart.Values, want)
+	}
+	if addon.Sources.Chart.Version != "v23.9.1" || addon.Namespace != "gpu-operator" {
+		t.Errorf("gpuOperatorAddon() = %+v, want the chart v23.9.1 in the namespace gpu-operator", addon)
+	}
+}
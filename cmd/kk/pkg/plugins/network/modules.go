@@ -37,6 +37,7 @@ type DeployNetworkPluginModule struct {
 
 func (d *DeployNetworkPluginModule) Init() {
 	d.Name = "DeployNetworkPluginModule"
+	d.Tags = []string{"network"}
 	d.Desc = "Deploy cluster network plugin"
 
 	switch d.KubeConf.Cluster.Network.Plugin {
@@ -121,6 +122,7 @@ func deployCalico(d *DeployNetworkPluginModule) []task.Interface {
 }
 
 func deployFlannel(d *DeployNetworkPluginModule) []task.Interface {
+	podsV4CIDR, podsV6CIDR := d.KubeConf.Cluster.Network.PodsCIDRs()
 	generateFlannelPSP := &task.RemoteTask{
 		Name:    "GenerateFlannel",
 		Desc:    "Generate flannel",
@@ -130,7 +132,8 @@ func deployFlannel(d *DeployNetworkPluginModule) []task.Interface {
 			Template: templates.FlannelPSP,
 			Dst:      filepath.Join(common.KubeConfigDir, templates.FlannelPSP.Name()),
 			Data: util.Data{
-				"KubePodsCIDR":       d.KubeConf.Cluster.Network.KubePodsCIDR,
+				"KubePodsV4CIDR":     podsV4CIDR,
+				"KubePodsV6CIDR":     podsV6CIDR,
 				"FlannelImage":       images.GetImage(d.Runtime, d.KubeConf, "flannel").ImageName(),
 				"FlannelPluginImage": images.GetImage(d.Runtime, d.KubeConf, "flannel-cni-plugin").ImageName(),
 				"BackendMode":        d.KubeConf.Cluster.Network.Flannel.BackendMode,
@@ -147,7 +150,8 @@ func deployFlannel(d *DeployNetworkPluginModule) []task.Interface {
 			Template: templates.FlannelPS,
 			Dst:      filepath.Join(common.KubeConfigDir, templates.FlannelPS.Name()),
 			Data: util.Data{
-				"KubePodsCIDR":       d.KubeConf.Cluster.Network.KubePodsCIDR,
+				"KubePodsV4CIDR":     podsV4CIDR,
+				"KubePodsV6CIDR":     podsV6CIDR,
 				"FlannelImage":       images.GetImage(d.Runtime, d.KubeConf, "flannel").ImageName(),
 				"FlannelPluginImage": images.GetImage(d.Runtime, d.KubeConf, "flannel-cni-plugin").ImageName(),
 				"BackendMode":        d.KubeConf.Cluster.Network.Flannel.BackendMode,
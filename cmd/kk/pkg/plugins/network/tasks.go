@@ -93,8 +93,18 @@ func (d *DeployCilium) Execute(runtime connector.Runtime) error {
 	cmd := fmt.Sprintf("/usr/local/bin/helm upgrade --install cilium /etc/kubernetes/cilium.tgz --namespace kube-system "+
 		"--set operator.image.override=%s "+
 		"--set operator.replicas=1 "+
-		"--set image.override=%s "+
-		"--set \"ipam.operator.clusterPoolIPv4PodCIDRList={%s}\"", ciliumOperatorImage, ciliumImage, d.KubeConf.Cluster.Network.KubePodsCIDR)
+		"--set image.override=%s", ciliumOperatorImage, ciliumImage)
+
+	// the pools of the dual-stack are set by the family
+	podsV4CIDR, podsV6CIDR := d.KubeConf.Cluster.Network.PodsCIDRs()
+	if podsV4CIDR != "" {
+		cmd = fmt.Sprintf("%s --set \"ipam.operator.clusterPoolIPv4PodCIDRList={%s}\"", cmd, podsV4CIDR)
+	} else {
+		cmd = fmt.Sprintf("%s --set ipv4.enabled=false", cmd)
+	}
+	if podsV6CIDR != "" {
+		cmd = fmt.Sprintf("%s --set ipv6.enabled=true --set \"ipam.operator.clusterPoolIPv6PodCIDRList={%s}\"", cmd, podsV6CIDR)
+	}
 
 	if d.KubeConf.Cluster.Kubernetes.DisableKubeProxy {
 		cmd = fmt.Sprintf("%s --set kubeProxyReplacement=strict --set k8sServiceHost=%s --set k8sServicePort=%d", cmd, d.KubeConf.Cluster.ControlPlaneEndpoint.Address, d.KubeConf.Cluster.ControlPlaneEndpoint.Port)
@@ -441,19 +451,13 @@ func (g *GenerateCalicoManifests) Execute(runtime connector.Runtime) error {
 	}
 	calico := template.Must(template.New("network-plugin.yaml").Funcs(utils.FuncMap).Parse(string(calicoContent)))
 
-	IPv6Support := false
-	kubePodsV6CIDR := ""
-	kubePodsCIDR := strings.Split(g.KubeConf.Cluster.Network.KubePodsCIDR, ",")
-	if len(kubePodsCIDR) == 2 {
-		IPv6Support = true
-		kubePodsV6CIDR = kubePodsCIDR[1]
-	}
+	kubePodsV4CIDR, kubePodsV6CIDR := g.KubeConf.Cluster.Network.PodsCIDRs()
 
 	templateAction := action.Template{
 		Template: calico,
 		Dst:      filepath.Join(common.KubeConfigDir, calico.Name()),
 		Data: util.Data{
-			"KubePodsV4CIDR":          strings.Split(g.KubeConf.Cluster.Network.KubePodsCIDR, ",")[0],
+			"KubePodsV4CIDR":          kubePodsV4CIDR,
 			"KubePodsV6CIDR":          kubePodsV6CIDR,
 			"CalicoCniImage":          images.GetImage(runtime, g.KubeConf, "calico-cni").ImageName(),
 			"CalicoNodeImage":         images.GetImage(runtime, g.KubeConf, "calico-node").ImageName(),
@@ -468,7 +472,8 @@ func (g *GenerateCalicoManifests) Execute(runtime connector.Runtime) error {
 			"ConatinerManagerIsIsula": g.KubeConf.Cluster.Kubernetes.ContainerManager == "isula",
 			"IPV4POOLNATOUTGOING":     g.KubeConf.Cluster.Network.Calico.EnableIPV4POOL_NAT_OUTGOING(),
 			"DefaultIPPOOL":           g.KubeConf.Cluster.Network.Calico.EnableDefaultIPPOOL(),
-			"IPv6Support":             IPv6Support,
+			"IPv4Support":             kubePodsV4CIDR != "",
+			"IPv6Support":             kubePodsV6CIDR != "",
 			"Replicas":                g.KubeConf.Cluster.Network.Calico.Replicas,
 			"NodeSelector":            g.KubeConf.Cluster.Network.Calico.NodeSelector,
 		},
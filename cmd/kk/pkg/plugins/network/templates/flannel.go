@@ -162,7 +162,15 @@ data:
     }
   net-conf.json: |
     {
-      "Network": "{{ .KubePodsCIDR }}",
+{{- if .KubePodsV4CIDR }}
+      "Network": "{{ .KubePodsV4CIDR }}",
+{{- else }}
+      "EnableIPv4": false,
+{{- end }}
+{{- if .KubePodsV6CIDR }}
+      "EnableIPv6": true,
+      "IPv6Network": "{{ .KubePodsV6CIDR }}",
+{{- end }}
       "Backend": {
         "Type": "{{ .BackendMode }}"
       }
@@ -381,7 +389,15 @@ data:
     }
   net-conf.json: |
     {
-      "Network": "{{ .KubePodsCIDR }}",
+{{- if .KubePodsV4CIDR }}
+      "Network": "{{ .KubePodsV4CIDR }}",
+{{- else }}
+      "EnableIPv4": false,
+{{- end }}
+{{- if .KubePodsV6CIDR }}
+      "EnableIPv6": true,
+      "IPv6Network": "{{ .KubePodsV6CIDR }}",
+{{- end }}
       "Backend": {
         "Type": "{{ .BackendMode }}"
       }
@@ -21,6 +21,7 @@ import (
 
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/prepare"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
@@ -39,12 +40,17 @@ func (d *DeployLocalVolumeModule) IsSkip() bool {
 
 func (d *DeployLocalVolumeModule) Init() {
 	d.Name = "DeployStorageClassModule"
+	d.Tags = []string{"storage"}
 	d.Desc = "Deploy cluster storage-class"
 
+	d.Tasks = openEBSTasks(d.Runtime, d.KubeConf)
+}
+
+func openEBSTasks(runtime connector.ModuleRuntime, kubeConf *common.KubeConf) []task.Interface {
 	generate := &task.RemoteTask{
 		Name:  "GenerateOpenEBSManifest",
 		Desc:  "Generate OpenEBS manifest",
-		Hosts: d.Runtime.GetHostsByRole(common.Master),
+		Hosts: runtime.GetHostsByRole(common.Master),
 		Prepare: &prepare.PrepareCollection{
 			new(common.OnlyFirstMaster),
 			new(CheckDefaultStorageClass),
@@ -53,9 +59,9 @@ func (d *DeployLocalVolumeModule) Init() {
 			Template: templates.OpenEBS,
 			Dst:      filepath.Join(common.KubeAddonsDir, templates.OpenEBS.Name()),
 			Data: util.Data{
-				"ProvisionerLocalPVImage": images.GetImage(d.Runtime, d.KubeConf, "provisioner-localpv").ImageName(),
-				"LinuxUtilsImage":         images.GetImage(d.Runtime, d.KubeConf, "linux-utils").ImageName(),
-				"BasePath":                d.KubeConf.Cluster.Storage.OpenEBS.BasePath,
+				"ProvisionerLocalPVImage": images.GetImage(runtime, kubeConf, "provisioner-localpv").ImageName(),
+				"LinuxUtilsImage":         images.GetImage(runtime, kubeConf, "linux-utils").ImageName(),
+				"BasePath":                kubeConf.Cluster.Storage.OpenEBS.BasePath,
 			},
 		},
 		Parallel: true,
@@ -64,7 +70,7 @@ func (d *DeployLocalVolumeModule) Init() {
 	deploy := &task.RemoteTask{
 		Name:  "DeployOpenEBS",
 		Desc:  "Deploy OpenEBS as cluster default StorageClass",
-		Hosts: d.Runtime.GetHostsByRole(common.Master),
+		Hosts: runtime.GetHostsByRole(common.Master),
 		Prepare: &prepare.PrepareCollection{
 			new(common.OnlyFirstMaster),
 			new(CheckDefaultStorageClass),
@@ -73,8 +79,77 @@ func (d *DeployLocalVolumeModule) Init() {
 		Parallel: true,
 	}
 
-	d.Tasks = []task.Interface{
+	return []task.Interface{
 		generate,
 		deploy,
 	}
 }
+
+// DeployStorageModule deploys the provider of storage.provider as the default StorageClass, unless the cluster
+// already has one, and checks it by binding a PVC.
+type DeployStorageModule struct {
+	common.KubeModule
+	Skip bool
+}
+
+func (d *DeployStorageModule) IsSkip() bool {
+	return d.Skip
+}
+
+func (d *DeployStorageModule) Init() {
+	d.Name = "DeployStorageModule"
+	d.Tags = []string{"storage"}
+	d.Desc = "Deploy the default StorageClass provider"
+
+	provider, ok := GetProvider(d.KubeConf.Cluster.Storage.Provider)
+	if !ok {
+		return
+	}
+
+	check := &task.RemoteTask{
+		Name:  "CheckDefaultStorageClass",
+		Desc:  "Check the default StorageClass of the cluster",
+		Hosts: d.Runtime.GetHostsByRole(common.Master),
+		Prepare: &prepare.PrepareCollection{
+			new(common.OnlyFirstMaster),
+			new(CheckDefaultStorageClass),
+		},
+		Action:   new(MarkDefaultStorageClassMissing),
+		Parallel: true,
+	}
+
+	generateSmokeTest := &task.RemoteTask{
+		Name:  "GenerateStorageSmokeTest",
+		Desc:  "Generate the storage smoke test manifest",
+		Hosts: d.Runtime.GetHostsByRole(common.Master),
+		Prepare: &prepare.PrepareCollection{
+			new(common.OnlyFirstMaster),
+			new(DefaultStorageClassMissing),
+		},
+		Action: &action.Template{
+			Template: templates.StorageSmokeTest,
+			Dst:      filepath.Join(common.KubeAddonsDir, templates.StorageSmokeTest.Name()),
+			Data: util.Data{
+				"Name":         smokeTestName,
+				"StorageClass": provider.StorageClass(),
+				"Image":        images.GetImage(d.Runtime, d.KubeConf, "busybox").ImageName(),
+			},
+		},
+		Parallel: true,
+	}
+
+	smokeTest := &task.RemoteTask{
+		Name:  "StorageSmokeTest",
+		Desc:  "Check the default StorageClass by binding a PVC",
+		Hosts: d.Runtime.GetHostsByRole(common.Master),
+		Prepare: &prepare.PrepareCollection{
+			new(common.OnlyFirstMaster),
+			new(DefaultStorageClassMissing),
+		},
+		Action:   &StorageSmokeTest{StorageClass: provider.StorageClass()},
+		Parallel: true,
+	}
+
+	d.Tasks = append([]task.Interface{check}, provider.Tasks(d.Runtime, d.KubeConf)...)
+	d.Tasks = append(d.Tasks, generateSmokeTest, smokeTest)
+}
@@ -46,3 +46,14 @@ func (c *CheckDefaultStorageClass) PreCheck(runtime connector.Runtime) (bool, er
 	logger.Log.Messagef(host.GetName(), "Default storageClass in cluster is not unique!")
 	return false, nil
 }
+
+// DefaultStorageClassMissing checks whether the cluster had no default StorageClass, which is cached by
+// the CheckDefaultStorageClass task of the DeployStorageModule.
+type DefaultStorageClassMissing struct {
+	common.KubePrepare
+}
+
+func (d *DefaultStorageClassMissing) PreCheck(_ connector.Runtime) (bool, error) {
+	missing, _ := d.ModuleCache.GetMustBool(noDefaultStorageClass)
+	return missing, nil
+}
@@ -0,0 +1,198 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/prepare"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/images"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/plugins/storage/templates"
+)
+
+const (
+	longhornRepo = "https://charts.longhorn.io"
+	nfsRepo      = "https://kubernetes-sigs.github.io/nfs-subdir-external-provisioner"
+
+	defaultLocalPath = "/opt/local-path-provisioner"
+)
+
+// Provider deploys a default StorageClass of the cluster.
+type Provider interface {
+	// StorageClass returns the name of the StorageClass created by the provider.
+	StorageClass() string
+	// Tasks returns the tasks deploying the provider, which are run on the first master.
+	Tasks(runtime connector.ModuleRuntime, kubeConf *common.KubeConf) []task.Interface
+}
+
+var providers = map[string]Provider{}
+
+// Register makes a storage provider available by the name in storage.provider of the cluster spec.
+func Register(name string, provider Provider) {
+	providers[name] = provider
+}
+
+// GetProvider returns the storage provider registered with the name.
+func GetProvider(name string) (Provider, bool) {
+	provider, ok := providers[name]
+	return provider, ok
+}
+
+func init() {
+	Register(kubekeyapiv1alpha2.StorageOpenEBS, new(openEBS))
+	Register(kubekeyapiv1alpha2.StorageLocalPath, new(localPath))
+	Register(kubekeyapiv1alpha2.StorageLonghorn, new(longhorn))
+	Register(kubekeyapiv1alpha2.StorageNFS, new(nfs))
+}
+
+type openEBS struct{}
+
+func (o *openEBS) StorageClass() string {
+	return "local"
+}
+
+func (o *openEBS) Tasks(runtime connector.ModuleRuntime, kubeConf *common.KubeConf) []task.Interface {
+	return openEBSTasks(runtime, kubeConf)
+}
+
+type localPath struct{}
+
+func (l *localPath) StorageClass() string {
+	return "local-path"
+}
+
+func (l *localPath) Tasks(runtime connector.ModuleRuntime, kubeConf *common.KubeConf) []task.Interface {
+	path := kubeConf.Cluster.Storage.LocalPath.Path
+	if path == "" {
+		path = defaultLocalPath
+	}
+
+	generate := &task.RemoteTask{
+		Name:  "GenerateLocalPathManifest",
+		Desc:  "Generate local-path-provisioner manifest",
+		Hosts: runtime.GetHostsByRole(common.Master),
+		Prepare: &prepare.PrepareCollection{
+			new(common.OnlyFirstMaster),
+			new(CheckDefaultStorageClass),
+		},
+		Action: &action.Template{
+			Template: templates.LocalPath,
+			Dst:      filepath.Join(common.KubeAddonsDir, templates.LocalPath.Name()),
+			Data: util.Data{
+				"ProvisionerImage": images.GetImage(runtime, kubeConf, "local-path-provisioner").ImageName(),
+				"HelperImage":      images.GetImage(runtime, kubeConf, "busybox").ImageName(),
+				"Path":             path,
+			},
+		},
+		Parallel: true,
+	}
+
+	deploy := &task.RemoteTask{
+		Name:  "DeployLocalPath",
+		Desc:  "Deploy local-path-provisioner as cluster default StorageClass",
+		Hosts: runtime.GetHostsByRole(common.Master),
+		Prepare: &prepare.PrepareCollection{
+			new(common.OnlyFirstMaster),
+			new(CheckDefaultStorageClass),
+		},
+		Action:   &ApplyManifest{Name: templates.LocalPath.Name()},
+		Parallel: true,
+	}
+
+	return []task.Interface{generate, deploy}
+}
+
+type longhorn struct{}
+
+func (l *longhorn) StorageClass() string {
+	return "longhorn"
+}
+
+func (l *longhorn) Tasks(runtime connector.ModuleRuntime, kubeConf *common.KubeConf) []task.Interface {
+	return []task.Interface{installChartTask(runtime, "DeployLonghorn", "Deploy Longhorn as cluster default StorageClass",
+		longhornAddon(&kubeConf.Cluster.Storage.Longhorn))}
+}
+
+// longhornAddon returns the chart addon of Longhorn, whose default StorageClass keeps the number of replicas
+// of storage.longhorn.replicas.
+func longhornAddon(cfg *kubekeyapiv1alpha2.LonghornCfg) *kubekeyapiv1alpha2.Addon {
+	var values []string
+	if cfg.Replicas > 0 {
+		values = append(values, fmt.Sprintf("persistence.defaultClassReplicaCount=%d", cfg.Replicas))
+	}
+	return &kubekeyapiv1alpha2.Addon{
+		Name:      "longhorn",
+		Namespace: "longhorn-system",
+		Sources: kubekeyapiv1alpha2.Sources{
+			Chart: kubekeyapiv1alpha2.Chart{
+				Name:    "longhorn",
+				Repo:    longhornRepo,
+				Version: cfg.Version,
+				Values:  values,
+				Wait:    true,
+			},
+		},
+	}
+}
+
+type nfs struct{}
+
+func (n *nfs) StorageClass() string {
+	return "nfs-client"
+}
+
+func (n *nfs) Tasks(runtime connector.ModuleRuntime, kubeConf *common.KubeConf) []task.Interface {
+	return []task.Interface{installChartTask(runtime, "DeployNFSProvisioner", "Deploy nfs-subdir-external-provisioner as cluster default StorageClass",
+		nfsAddon(&kubeConf.Cluster.Storage.NFS))}
+}
+
+// nfsAddon returns the chart addon of the nfs-subdir-external-provisioner of the export storage.nfs.path
+// on storage.nfs.server.
+func nfsAddon(cfg *kubekeyapiv1alpha2.NFSCfg) *kubekeyapiv1alpha2.Addon {
+	return &kubekeyapiv1alpha2.Addon{
+		Name:      "nfs-subdir-external-provisioner",
+		Namespace: "kube-system",
+		Sources: kubekeyapiv1alpha2.Sources{
+			Chart: kubekeyapiv1alpha2.Chart{
+				Name: "nfs-subdir-external-provisioner",
+				Repo: nfsRepo,
+				Values: []string{
+					fmt.Sprintf("nfs.server=%s", cfg.Server),
+					fmt.Sprintf("nfs.path=%s", cfg.Path),
+					"storageClass.defaultClass=true",
+				},
+				Wait: true,
+			},
+		},
+	}
+}
+
+func installChartTask(runtime connector.ModuleRuntime, name, desc string, addon *kubekeyapiv1alpha2.Addon) task.Interface {
+	return &task.LocalTask{
+		Name:    name,
+		Desc:    desc,
+		Prepare: new(DefaultStorageClassMissing),
+		Action:  &InstallChart{Addon: addon},
+	}
+}
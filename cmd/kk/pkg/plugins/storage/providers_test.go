@@ -0,0 +1,67 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package storage
+
+import (
+	"reflect"
+	"testing"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+func TestProviders(t *testing.T) {
+	for _, name := range []string{
+		kubekeyapiv1alpha2.StorageOpenEBS,
+		kubekeyapiv1alpha2.StorageLocalPath,
+		kubekeyapiv1alpha2.StorageLonghorn,
+		kubekeyapiv1alpha2.StorageNFS,
+	} {
+		if _, ok := GetProvider(name); !ok {
+			t.Errorf("the storage provider %s isn't registered", name)
+		}
+	}
+}
+
+func TestChartValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		addon *kubekeyapiv1alpha2.Addon
+		want  []string
+	}{
+		{
+			name:  "longhorn with the default replicas",
+			addon: longhornAddon(&kubekeyapiv1alpha2.LonghornCfg{}),
+		},
+		{
+			name:  "longhorn with 2 replicas",
+			addon: longhornAddon(&kubekeyapiv1alpha2.LonghornCfg{Replicas: 2}),
+			want:  []string{"persistence.defaultClassReplicaCount=2"},
+		},
+		{
+			name:  "nfs",
+			addon: nfsAddon(&kubekeyapiv1alpha2.NFSCfg{Server: "192.168.0.10", Path: "/exports/k8s"}),
+			want:  []string{"nfs.server=192.168.0.10", "nfs.path=/exports/k8s", "storageClass.defaultClass=true"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.addon.Sources.Chart.Values; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Values = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -19,11 +19,25 @@ package storage
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/addons"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/plugins/storage/templates"
+)
+
+const (
+	// noDefaultStorageClass is the key of whether the cluster had no default StorageClass in the module cache.
+	noDefaultStorageClass = "noDefaultStorageClass"
+
+	smokeTestName    = "kubekey-storage-smoke-test"
+	smokeTestTimeout = 5 * time.Minute
 )
 
 type DeployLocalVolume struct {
@@ -37,3 +51,83 @@ func (d *DeployLocalVolume) Execute(runtime connector.Runtime) error {
 	}
 	return nil
 }
+
+type MarkDefaultStorageClassMissing struct {
+	common.KubeAction
+}
+
+func (m *MarkDefaultStorageClassMissing) Execute(_ connector.Runtime) error {
+	m.ModuleCache.Set(noDefaultStorageClass, true)
+	return nil
+}
+
+type ApplyManifest struct {
+	common.KubeAction
+	Name string
+}
+
+func (a *ApplyManifest) Execute(runtime connector.Runtime) error {
+	cmd := fmt.Sprintf("/usr/local/bin/kubectl apply -f %s", filepath.Join(common.KubeAddonsDir, a.Name))
+	if _, err := runtime.GetRunner().SudoCmd(cmd, false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "deploy %s failed", a.Name)
+	}
+	return nil
+}
+
+type InstallChart struct {
+	common.KubeAction
+	Addon *kubekeyapiv1alpha2.Addon
+}
+
+func (i *InstallChart) Execute(runtime connector.Runtime) error {
+	logger.Log.Messagef(runtime.RemoteHost().GetName(), "Install the %s chart", i.Addon.Name)
+	kubeConfig := filepath.Join(runtime.GetClusterWorkDir(), fmt.Sprintf("config-%s", runtime.GetObjName()))
+	return addons.InstallAddons(i.KubeConf, i.Addon, kubeConfig, filepath.Join(runtime.GetWorkDir(), common.Charts))
+}
+
+// StorageSmokeTest binds a PVC of the deployed StorageClass to a pod writing to it, and fails if the pod doesn't
+// succeed in time. The PVC and the pod are always removed.
+type StorageSmokeTest struct {
+	common.KubeAction
+	StorageClass string
+}
+
+func (s *StorageSmokeTest) Execute(runtime connector.Runtime) error {
+	manifest := filepath.Join(common.KubeAddonsDir, templates.StorageSmokeTest.Name())
+	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("/usr/local/bin/kubectl apply -f %s", manifest), false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "create the storage smoke test failed")
+	}
+	defer func() {
+		_, _ = runtime.GetRunner().SudoCmd(
+			fmt.Sprintf("/usr/local/bin/kubectl delete -f %s --ignore-not-found --wait=false", manifest), false)
+	}()
+
+	if connector.IsDryRun(runtime.GetConnector()) {
+		return nil
+	}
+
+	phaseCmd := fmt.Sprintf("/usr/local/bin/kubectl get pod %s -n default -o jsonpath='{.status.phase}'", smokeTestName)
+	deadline := time.Now().Add(smokeTestTimeout)
+	for {
+		phase, err := runtime.GetRunner().SudoCmd(phaseCmd, false)
+		if err == nil {
+			switch strings.TrimSpace(phase) {
+			case "Succeeded":
+				logger.Log.Messagef(runtime.RemoteHost().GetName(), "StorageClass %s passed the smoke test", s.StorageClass)
+				return nil
+			case "Failed":
+				return s.failed(runtime, "the smoke test pod failed")
+			}
+		}
+		if time.Now().After(deadline) {
+			return s.failed(runtime, fmt.Sprintf("the smoke test pod didn't succeed in %s", smokeTestTimeout))
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
+
+func (s *StorageSmokeTest) failed(runtime connector.Runtime, reason string) error {
+	describe, _ := runtime.GetRunner().SudoCmd(
+		fmt.Sprintf("/usr/local/bin/kubectl describe pvc %s -n default", smokeTestName), false)
+	return errors.Errorf("StorageClass %s failed the smoke test: %s\n%s", s.StorageClass, reason, describe)
+}
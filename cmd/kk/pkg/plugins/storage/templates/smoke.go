@@ -0,0 +1,62 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package templates
+
+import (
+	"text/template"
+
+	"github.com/lithammer/dedent"
+)
+
+// StorageSmokeTest defines the template of the PVC and the pod writing to it, which check the default StorageClass.
+var StorageSmokeTest = template.Must(template.New("storage-smoke-test.yaml").Parse(
+	dedent.Dedent(`---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: {{ .Name }}
+  namespace: default
+spec:
+  storageClassName: {{ .StorageClass }}
+  accessModes:
+    - ReadWriteOnce
+  resources:
+    requests:
+      storage: 64Mi
+
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: {{ .Name }}
+  namespace: default
+spec:
+  restartPolicy: Never
+  containers:
+    - name: smoke-test
+      image: {{ .Image }}
+      imagePullPolicy: IfNotPresent
+      command: ["sh", "-c", "echo kubekey > /data/smoke-test && grep -q kubekey /data/smoke-test"]
+      volumeMounts:
+        - name: data
+          mountPath: /data
+  volumes:
+    - name: data
+      persistentVolumeClaim:
+        claimName: {{ .Name }}
+
+    `)))
@@ -0,0 +1,111 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package provision
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ec2 runs the instances by the aws cli, they are tagged by the name of the machine.
+type ec2 struct {
+	cfg Config
+}
+
+type ec2Instances struct {
+	Reservations []struct {
+		Instances []ec2Instance `json:"Instances"`
+	} `json:"Reservations"`
+}
+
+type ec2Instance struct {
+	InstanceID       string `json:"InstanceId"`
+	PrivateIPAddress string `json:"PrivateIpAddress"`
+	PublicIPAddress  string `json:"PublicIpAddress"`
+}
+
+func (e *ec2) Address(name string) (string, error) {
+	instance, err := e.instance(name)
+	if err != nil || instance == nil {
+		return "", err
+	}
+	if e.cfg.PublicAddress {
+		return instance.PublicIPAddress, nil
+	}
+	return instance.PrivateIPAddress, nil
+}
+
+func (e *ec2) Create(name string) (string, error) {
+	args := e.args("ec2", "run-instances",
+		"--image-id", e.cfg.Image,
+		"--instance-type", e.cfg.InstanceType,
+		"--count", "1",
+		"--tag-specifications", fmt.Sprintf("ResourceType=instance,Tags=[{Key=Name,Value=%s}]", name))
+	if e.cfg.KeyName != "" {
+		args = append(args, "--key-name", e.cfg.KeyName)
+	}
+	if e.cfg.Network != "" {
+		args = append(args, "--subnet-id", e.cfg.Network)
+	}
+	if e.cfg.PublicAddress {
+		args = append(args, "--associate-public-ip-address")
+	}
+	if _, err := execCommand(nil, "aws", args...); err != nil {
+		return "", errors.Wrapf(err, "failed to run the instance %s", name)
+	}
+	return waitAddress(e, name, e.cfg.Timeout)
+}
+
+func (e *ec2) Delete(name string) error {
+	instance, err := e.instance(name)
+	if err != nil || instance == nil {
+		return err
+	}
+	if _, err := execCommand(nil, "aws", e.args("ec2", "terminate-instances", "--instance-ids", instance.InstanceID)...); err != nil {
+		return errors.Wrapf(err, "failed to terminate the instance %s", name)
+	}
+	return nil
+}
+
+// instance returns the pending or running instance tagged by the name, or nil.
+func (e *ec2) instance(name string) (*ec2Instance, error) {
+	out, err := execCommand(nil, "aws", e.args("ec2", "describe-instances",
+		"--filters", "Name=tag:Name,Values="+name, "Name=instance-state-name,Values=pending,running")...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to describe aws ec2 instances")
+	}
+	result := &ec2Instances{}
+	if err := json.Unmarshal(out, result); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the aws ec2 instances")
+	}
+	for _, reservation := range result.Reservations {
+		if len(reservation.Instances) > 0 {
+			return &reservation.Instances[0], nil
+		}
+	}
+	return nil, nil
+}
+
+func (e *ec2) args(args ...string) []string {
+	args = append(args, "--output", "json")
+	if e.cfg.Region != "" {
+		args = append(args, "--region", e.cfg.Region)
+	}
+	return args
+}
@@ -0,0 +1,112 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package provision
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+// libvirt creates the domains by virt-install, their disks are backed by the cloud image and the ssh key is
+// authorized by cloud-init.
+type libvirt struct {
+	cfg Config
+}
+
+func (l *libvirt) Address(name string) (string, error) {
+	if exists, err := l.exists(name); err != nil || !exists {
+		return "", err
+	}
+	out, err := execCommand(nil, "virsh", "domifaddr", name)
+	if err != nil {
+		// the stopped domains have no interface
+		return "", nil
+	}
+	// Name       MAC address          Protocol     Address
+	// vnet0      52:54:00:6b:3c:58    ipv4         192.168.122.45/24
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 4 || fields[2] != "ipv4" {
+			continue
+		}
+		if ip, _, err := net.ParseCIDR(fields[3]); err == nil {
+			return ip.String(), nil
+		}
+	}
+	return "", nil
+}
+
+func (l *libvirt) Create(name string) (string, error) {
+	args := []string{
+		"--name", name,
+		"--vcpus", fmt.Sprint(l.cfg.CPUs),
+		"--memory", fmt.Sprint(l.cfg.Memory),
+		"--disk", fmt.Sprintf("size=%d,backing_store=%s,backing_format=qcow2", l.cfg.Disk, l.cfg.Image),
+		"--network", "network=" + l.cfg.Network,
+		"--os-variant", "detect=on,require=off",
+		"--import",
+		"--noautoconsole",
+	}
+	if l.cfg.SSHKey != "" {
+		key := l.cfg.SSHKey
+		if strings.HasPrefix(key, "~/") {
+			home, err := util.Home()
+			if err != nil {
+				return "", err
+			}
+			key = filepath.Join(home, key[2:])
+		}
+		args = append(args, "--cloud-init", "clouduser-ssh-key="+key)
+	} else {
+		args = append(args, "--cloud-init")
+	}
+	if _, err := execCommand(nil, "virt-install", args...); err != nil {
+		return "", errors.Wrapf(err, "failed to create the domain %s", name)
+	}
+	return waitAddress(l, name, l.cfg.Timeout)
+}
+
+func (l *libvirt) Delete(name string) error {
+	if exists, err := l.exists(name); err != nil || !exists {
+		return err
+	}
+	// the domain is already stopped if it fails
+	_, _ = execCommand(nil, "virsh", "destroy", name)
+	if _, err := execCommand(nil, "virsh", "undefine", name, "--remove-all-storage"); err != nil {
+		return errors.Wrapf(err, "failed to undefine the domain %s", name)
+	}
+	return nil
+}
+
+func (l *libvirt) exists(name string) (bool, error) {
+	out, err := execCommand(nil, "virsh", "list", "--all", "--name")
+	if err != nil {
+		return false, errors.Wrap(err, "failed to list the domains")
+	}
+	for _, domain := range strings.Fields(string(out)) {
+		if domain == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,238 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package provision creates the machines of the hosts of an inventory before the installation, so a lab cluster is
+// created by one command. The machines are named after the hosts, and their addresses are looked up every time the
+// inventory is loaded instead of being recorded.
+package provision
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+const (
+	DriverLibvirt = "libvirt"
+	DriverAWSEC2  = "aws-ec2"
+	DriverScript  = "script"
+
+	defaultCPUs    = 2
+	defaultMemory  = 4096
+	defaultDisk    = 40
+	defaultNetwork = "default"
+	defaultTimeout = 300
+)
+
+// execCommand runs the command and returns its stdout, it is replaced in unit tests.
+var execCommand = func(env []string, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	if len(env) > 0 {
+		cmd.Env = append(cmd.Environ(), env...)
+	}
+	out, err := cmd.Output()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return nil, errors.Wrapf(err, "%s: %s", name, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return out, err
+}
+
+// sleep waits between the polls of the address of a created machine, it is replaced in unit tests.
+var sleep = time.Sleep
+
+// Config defines how the machines of the hosts without an address are provisioned.
+type Config struct {
+	// Driver of the machines. Support: libvirt, aws-ec2, script
+	Driver string `yaml:"driver"`
+	// Prefix of the names of the machines, which are the names of the hosts prefixed.
+	Prefix string `yaml:"prefix"`
+	// Image is the path of the cloud image the disks of the machines are backed by (libvirt), or the AMI (aws-ec2).
+	Image string `yaml:"image"`
+	// CPUs of the machines, only for libvirt. [Default: 2]
+	CPUs int `yaml:"cpus"`
+	// Memory of the machines in MiB, only for libvirt. [Default: 4096]
+	Memory int `yaml:"memory"`
+	// Disk of the machines in GiB, only for libvirt. [Default: 40]
+	Disk int `yaml:"disk"`
+	// Network is the libvirt network of the machines, or the subnet id of aws-ec2. [Default: default] for libvirt.
+	Network string `yaml:"network"`
+	// SSHKey is the path of the public key authorized for the default user of the image by cloud-init, only for
+	// libvirt.
+	SSHKey string `yaml:"sshKey"`
+	// Region of AWS, only for aws-ec2.
+	Region string `yaml:"region"`
+	// InstanceType of the instances, only for aws-ec2.
+	InstanceType string `yaml:"instanceType"`
+	// KeyName is the name of the key pair of the instances, only for aws-ec2.
+	KeyName string `yaml:"keyName"`
+	// PublicAddress uses the public ip as the ssh address instead of the private ip, only for aws-ec2.
+	PublicAddress bool `yaml:"publicAddress"`
+	// Command is the script managing the machines, only for the script driver. It is run with the action (create,
+	// address or delete) in KUBEKEY_PROVISION_ACTION and the name of the machine in KUBEKEY_PROVISION_NAME, and
+	// prints the address of the machine for create and address, or nothing if the machine doesn't exist.
+	Command string `yaml:"command"`
+	// Timeout in seconds of waiting for the address of a created machine. [Default: 300]
+	Timeout int `yaml:"timeout"`
+}
+
+// Driver manages the machines.
+type Driver interface {
+	// Address returns the address of the machine, or an empty string if it doesn't exist.
+	Address(name string) (string, error)
+	// Create creates the machine and returns its address once it has one.
+	Create(name string) (string, error)
+	// Delete deletes the machine and its disks, if it exists.
+	Delete(name string) error
+}
+
+// NewDriver returns the driver of the config.
+func NewDriver(cfg Config) (Driver, error) {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	switch cfg.Driver {
+	case DriverLibvirt:
+		if cfg.Image == "" {
+			return nil, errors.New("the image of the libvirt driver is required")
+		}
+		if cfg.CPUs == 0 {
+			cfg.CPUs = defaultCPUs
+		}
+		if cfg.Memory == 0 {
+			cfg.Memory = defaultMemory
+		}
+		if cfg.Disk == 0 {
+			cfg.Disk = defaultDisk
+		}
+		if cfg.Network == "" {
+			cfg.Network = defaultNetwork
+		}
+		return &libvirt{cfg: cfg}, nil
+	case DriverAWSEC2:
+		if cfg.Image == "" || cfg.InstanceType == "" {
+			return nil, errors.New("the image and the instanceType of the aws-ec2 driver are required")
+		}
+		return &ec2{cfg: cfg}, nil
+	case DriverScript:
+		if cfg.Command == "" {
+			return nil, errors.New("the command of the script driver is required")
+		}
+		return &script{cfg: cfg}, nil
+	default:
+		return nil, fmt.Errorf("unsupported provision driver %s", cfg.Driver)
+	}
+}
+
+// Apply sets the addresses of the hosts without one to those of their machines, it runs as the config is loaded,
+// before the logger is set up. The missing machines are created
+// in parallel if create is set, or fail the hosts otherwise.
+func Apply(cfg Config, hosts []kubekeyapiv1alpha2.HostCfg, create bool) error {
+	driver, err := NewDriver(cfg)
+	if err != nil {
+		return err
+	}
+
+	var missing []int
+	for i := range hosts {
+		if hosts[i].Address != "" {
+			continue
+		}
+		name := cfg.Prefix + hosts[i].Name
+		address, err := driver.Address(name)
+		if err != nil {
+			return errors.Wrapf(err, "failed to look up the machine %s", name)
+		}
+		if address != "" {
+			hosts[i].Address = address
+			continue
+		}
+		if !create {
+			return errors.Errorf("the machine %s of the host %s isn't provisioned, create it by kk create cluster --provision", name, hosts[i].Name)
+		}
+		missing = append(missing, i)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []string
+	)
+	for _, i := range missing {
+		wg.Add(1)
+		go func(host *kubekeyapiv1alpha2.HostCfg) {
+			defer wg.Done()
+			name := cfg.Prefix + host.Name
+			fmt.Printf("provision the machine %s of the host %s by %s\n", name, host.Name, cfg.Driver)
+			address, err := driver.Create(name)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+				return
+			}
+			fmt.Printf("the machine %s is provisioned at %s\n", name, address)
+			host.Address = address
+		}(&hosts[i])
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return errors.Errorf("failed to provision the machines: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Destroy deletes the machines of the hosts without an address, which are the provisioned ones.
+func Destroy(cfg Config, hosts []kubekeyapiv1alpha2.HostCfg) error {
+	driver, err := NewDriver(cfg)
+	if err != nil {
+		return err
+	}
+	for _, host := range hosts {
+		if host.Address != "" {
+			continue
+		}
+		name := cfg.Prefix + host.Name
+		fmt.Printf("delete the machine %s of the host %s\n", name, host.Name)
+		if err := driver.Delete(name); err != nil {
+			return errors.Wrapf(err, "failed to delete the machine %s", name)
+		}
+	}
+	return nil
+}
+
+// waitAddress polls the address of the created machine until it has one or the timeout.
+func waitAddress(driver Driver, name string, timeout int) (string, error) {
+	deadline := time.Now().Add(time.Duration(timeout) * time.Second)
+	for {
+		address, err := driver.Address(name)
+		if err != nil {
+			return "", err
+		}
+		if address != "" {
+			return address, nil
+		}
+		if time.Now().After(deadline) {
+			return "", errors.Errorf("the machine %s has no address after %ds", name, timeout)
+		}
+		sleep(5 * time.Second)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package provision
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+func TestApply(t *testing.T) {
+	defer func(f func(time.Duration)) { sleep = f }(sleep)
+	sleep = func(time.Duration) {}
+	dir := t.TempDir()
+	// the machines are files holding their addresses, a created machine gets its address on the first poll
+	command := fmt.Sprintf(`f=%s/$KUBEKEY_PROVISION_NAME
+case $KUBEKEY_PROVISION_ACTION in
+create) touch $f.pending ;;
+address) if [ -f $f.pending ]; then echo 10.0.0.$(ls %s | wc -l) > $f; rm $f.pending; elif [ -f $f ]; then cat $f; fi ;;
+delete) rm -f $f ;;
+esac`, dir, dir)
+	cfg := Config{Driver: DriverScript, Prefix: "lab-", Command: command}
+	if err := os.WriteFile(filepath.Join(dir, "lab-master1"), []byte("10.0.0.9\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	hosts := []kubekeyapiv1alpha2.HostCfg{{Name: "master1"}, {Name: "node1"}, {Name: "registry", Address: "172.16.0.2"}}
+	if err := Apply(cfg, hosts, false); err == nil || !strings.Contains(err.Error(), "lab-node1") {
+		t.Fatalf("Apply() = %v, want the machine lab-node1 isn't provisioned", err)
+	}
+	if err := Apply(cfg, hosts, true); err != nil {
+		t.Fatal(err)
+	}
+	if hosts[0].Address != "10.0.0.9" || hosts[1].Address != "10.0.0.2" || hosts[2].Address != "172.16.0.2" {
+		t.Errorf("Apply() addresses = %s, %s, %s", hosts[0].Address, hosts[1].Address, hosts[2].Address)
+	}
+
+	unprovisioned := []kubekeyapiv1alpha2.HostCfg{{Name: "master1"}, {Name: "node1"}, {Name: "registry", Address: "172.16.0.2"}}
+	if err := Destroy(cfg, unprovisioned); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Destroy() left %d machines", len(entries))
+	}
+}
+
+func TestLibvirt(t *testing.T) {
+	defer func(f func(time.Duration)) { sleep = f }(sleep)
+	sleep = func(time.Duration) {}
+	defer func(f func(env []string, name string, args ...string) ([]byte, error)) { execCommand = f }(execCommand)
+	var calls []string
+	polls := 0
+	execCommand = func(env []string, name string, args ...string) ([]byte, error) {
+		call := name + " " + strings.Join(args, " ")
+		calls = append(calls, call)
+		switch {
+		case strings.HasPrefix(call, "virsh list"):
+			if len(calls) == 1 {
+				return []byte("other\n"), nil
+			}
+			return []byte("other\nnode1\n"), nil
+		case strings.HasPrefix(call, "virsh domifaddr"):
+			polls++
+			if polls == 1 {
+				return []byte(" Name       MAC address          Protocol     Address\n-------------------------------------------------\n"), nil
+			}
+			return []byte(" Name       MAC address          Protocol     Address\n-------------------------------------------------\n" +
+				" vnet0      52:54:00:6b:3c:58    ipv4         192.168.122.45/24\n"), nil
+		}
+		return nil, nil
+	}
+
+	driver, err := NewDriver(Config{Driver: DriverLibvirt, Image: "/var/lib/libvirt/images/jammy.qcow2", SSHKey: "/root/.ssh/id_rsa.pub"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address, err := driver.Address("node1"); err != nil || address != "" {
+		t.Fatalf("Address() = %q, %v, want no machine", address, err)
+	}
+	address, err := driver.Create("node1")
+	if err != nil || address != "192.168.122.45" {
+		t.Fatalf("Create() = %q, %v, want 192.168.122.45", address, err)
+	}
+	install := calls[1]
+	for _, arg := range []string{"--name node1", "--vcpus 2", "--memory 4096", "size=40,backing_store=/var/lib/libvirt/images/jammy.qcow2",
+		"network=default", "clouduser-ssh-key=/root/.ssh/id_rsa.pub"} {
+		if !strings.Contains(install, arg) {
+			t.Errorf("virt-install %s, want %s", install, arg)
+		}
+	}
+}
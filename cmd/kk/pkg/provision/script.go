@@ -0,0 +1,60 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package provision
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	scriptCreate  = "create"
+	scriptAddress = "address"
+	scriptDelete  = "delete"
+)
+
+// script runs the command of the config to manage the machines, e.g. by vagrant or the cli of a cloud.
+type script struct {
+	cfg Config
+}
+
+func (s *script) Address(name string) (string, error) {
+	return s.run(scriptAddress, name)
+}
+
+func (s *script) Create(name string) (string, error) {
+	address, err := s.run(scriptCreate, name)
+	if err != nil || address != "" {
+		return address, err
+	}
+	return waitAddress(s, name, s.cfg.Timeout)
+}
+
+func (s *script) Delete(name string) error {
+	_, err := s.run(scriptDelete, name)
+	return err
+}
+
+func (s *script) run(action, name string) (string, error) {
+	env := []string{"KUBEKEY_PROVISION_ACTION=" + action, "KUBEKEY_PROVISION_NAME=" + name}
+	out, err := execCommand(env, "/bin/sh", "-c", s.cfg.Command)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to %s the machine %s by %s", action, name, s.cfg.Command)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,86 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package reconcile
+
+import (
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/version/kubernetes"
+)
+
+// DiffModule compares the hosts with the files rendered under the Dir and the cluster spec into the Report, and
+// repairs the files and the kernel parameters if Fix is set.
+type DiffModule struct {
+	common.KubeModule
+	Dir    string
+	Report *Report
+	Fix    bool
+}
+
+func (d *DiffModule) Init() {
+	d.Name = "DiffModule"
+	d.Desc = "Compare the hosts with the cluster spec"
+	if d.Fix {
+		d.Desc = "Repair the drift of the hosts from the cluster spec"
+	}
+
+	checkFiles := &task.RemoteTask{
+		Name:     "CheckFiles",
+		Desc:     "Compare the files with the rendered ones",
+		Hosts:    d.Runtime.GetAllHosts(),
+		Action:   &CheckFiles{Dir: d.Dir, Report: d.Report, Fix: d.Fix},
+		Parallel: true,
+	}
+
+	checkSysctls := &task.RemoteTask{
+		Name:     "CheckSysctls",
+		Desc:     "Compare the kernel parameters",
+		Hosts:    d.Runtime.GetAllHosts(),
+		Action:   &CheckSysctls{Report: d.Report, Fix: d.Fix},
+		Parallel: true,
+	}
+
+	d.Tasks = []task.Interface{
+		checkFiles,
+		checkSysctls,
+	}
+
+	cluster := d.KubeConf.Cluster
+	components := kubernetes.DefaultComponents(cluster.Kubernetes.Version)
+	if cluster.Kubernetes.Type == "" || cluster.Kubernetes.Type == common.Kubernetes {
+		d.Tasks = append(d.Tasks,
+			d.checkVersion("kubelet", "/usr/local/bin/kubelet --version", cluster.Kubernetes.Version, common.K8s),
+			d.checkVersion("kubeadm", "/usr/local/bin/kubeadm version -o short", cluster.Kubernetes.Version, common.K8s))
+	}
+	if cluster.Etcd.Type == "" || cluster.Etcd.Type == kubekeyapiv1alpha2.KubeKey {
+		d.Tasks = append(d.Tasks, d.checkVersion("etcd", "/usr/local/bin/etcd --version", components.Etcd, common.ETCD))
+	}
+	if cluster.Kubernetes.ContainerManager == common.Containerd {
+		d.Tasks = append(d.Tasks, d.checkVersion("containerd", "/usr/bin/containerd --version", components.Containerd, common.K8s))
+	}
+}
+
+func (d *DiffModule) checkVersion(component, command, want, role string) task.Interface {
+	return &task.RemoteTask{
+		Name:     "CheckVersion",
+		Desc:     "Compare the version of " + component,
+		Hosts:    d.Runtime.GetHostsByRole(role),
+		Action:   &CheckVersion{Component: component, Command: command, Want: want, Report: d.Report},
+		Parallel: true,
+	}
+}
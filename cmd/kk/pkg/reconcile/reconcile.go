@@ -0,0 +1,221 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package reconcile compares the state of the hosts of a cluster, the files rendered by KubeKey, the kernel
+// parameters and the installed versions, with the cluster spec, and repairs the drift of the files and the kernel
+// parameters without a full installation.
+package reconcile
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// File is a file whose content isn't the one KubeKey renders.
+	File = "file"
+	// Sysctl is a kernel parameter whose value isn't the one KubeKey sets.
+	Sysctl = "sysctl"
+	// Version is a component whose installed version isn't the one of the cluster spec.
+	Version = "version"
+)
+
+// Ignored are the files rendered by KubeKey which are only read once by kubeadm or run once, their contents on the
+// hosts are allowed to differ, e.g. by the bootstrap tokens.
+var Ignored = []string{
+	"/etc/kubernetes/kubeadm-config.yaml",
+	"/etc/kubekey/owner",
+	"/usr/local/bin/kube-scripts/initOS.sh",
+}
+
+// Sysctls are the kernel parameters set by KubeKey, which Kubernetes relies on. The ones of the tuning profile are
+// checked as well.
+var Sysctls = []struct{ Key, Value string }{
+	{"net.ipv4.ip_forward", "1"},
+	{"net.bridge.bridge-nf-call-iptables", "1"},
+	{"net.bridge.bridge-nf-call-ip6tables", "1"},
+	{"net.ipv4.ip_local_reserved_ports", "30000-32767"},
+}
+
+// IPv6Sysctls are the kernel parameters the clusters with an IPv6 pod CIDR also rely on.
+var IPv6Sysctls = []struct{ Key, Value string }{
+	{"net.ipv6.conf.all.forwarding", "1"},
+	{"net.ipv6.conf.default.forwarding", "1"},
+}
+
+// Drift is the difference of a host from the cluster spec.
+type Drift struct {
+	Host string
+	Kind string
+	// Name is the path of the file, the key of the kernel parameter or the name of the component.
+	Name string
+	Want string
+	Got  string
+	// Diff is the diff of the file from the rendered one.
+	Diff string
+	// Repaired is set when the drift is repaired.
+	Repaired bool
+}
+
+// Repairable tells whether the drift is repaired by the reconcile, the versions are upgraded by kk upgrade instead.
+func (d Drift) Repairable() bool {
+	return d.Kind != Version
+}
+
+// Suggestion tells how to resolve the drift.
+func (d Drift) Suggestion() string {
+	switch {
+	case d.Repaired:
+		return "repaired"
+	case d.Kind == Version:
+		return fmt.Sprintf("%s is installed, upgrade it to %s by kk upgrade", d.Got, d.Want)
+	case d.Kind == Sysctl:
+		return fmt.Sprintf("the value is %s, set it to %s by kk reconcile", d.Got, d.Want)
+	case d.Got == "":
+		return "the file is missing, write it by kk reconcile"
+	default:
+		return "the content differs, write it by kk reconcile"
+	}
+}
+
+// Report collects the drift of the hosts, it is safe for the concurrent tasks.
+type Report struct {
+	mu     sync.Mutex
+	Drifts []Drift
+}
+
+// Add adds the drift to the report.
+func (r *Report) Add(d Drift) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Drifts = append(r.Drifts, d)
+}
+
+// Sorted returns the drift sorted by the host, the kind and the name.
+func (r *Report) Sorted() []Drift {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	drifts := append([]Drift(nil), r.Drifts...)
+	sort.Slice(drifts, func(i, j int) bool {
+		a, b := drifts[i], drifts[j]
+		if a.Host != b.Host {
+			return a.Host < b.Host
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	return drifts
+}
+
+// Repairable returns the number of the drift repaired by the reconcile.
+func (r *Report) Repairable() int {
+	n := 0
+	for _, d := range r.Sorted() {
+		if d.Repairable() {
+			n++
+		}
+	}
+	return n
+}
+
+// Print prints the drift as a table, with the diffs of the files if verbose is set.
+func (r *Report) Print(w io.Writer, verbose bool) error {
+	drifts := r.Sorted()
+	if len(drifts) == 0 {
+		_, err := fmt.Fprintln(w, "The hosts of the cluster match the cluster spec.")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 10, 4, 3, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "HOST\tDRIFT\tNAME\tSUGGESTION")
+	for _, d := range drifts {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", d.Host, d.Kind, d.Name, d.Suggestion())
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if !verbose {
+		return nil
+	}
+	for _, d := range drifts {
+		if d.Diff != "" {
+			if _, err := fmt.Fprintf(w, "\n# %s\n%s\n", d.Host, d.Diff); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RenderedFiles returns the files rendered for the host under the dir by kk render, by their paths on the host. The
+// Ignored files are skipped.
+func RenderedFiles(dir, host string) (map[string]string, error) {
+	root := filepath.Join(dir, host)
+	files := map[string]string{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		dst := "/" + filepath.ToSlash(strings.TrimPrefix(path, root+string(filepath.Separator)))
+		for _, ignored := range Ignored {
+			if dst == ignored {
+				return nil
+			}
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[dst] = string(content)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "read the rendered files of %s failed", host)
+	}
+	return files, nil
+}
+
+var versionPattern = regexp.MustCompile(`v?\d+\.\d+\.\d+`)
+
+// ParseVersion returns the version in the output of the version command of a component, such as
+// "Kubernetes v1.24.3" or "etcd Version: 3.5.13", without the prefix v.
+func ParseVersion(output string) string {
+	return strings.TrimPrefix(versionPattern.FindString(output), "v")
+}
+
+// SysctlCommand returns the command setting the kernel parameter and persisting it in /etc/sysctl.conf.
+func SysctlCommand(key, value string) string {
+	pattern := strings.ReplaceAll(key, ".", "\\.")
+	return fmt.Sprintf("sysctl -w %[1]s=%[2]q && "+
+		"if grep -Eq '^#?\\s*%[3]s\\s*=' /etc/sysctl.conf; then sed -r -i 's@^#?\\s*%[3]s\\s*=.*@%[1]s = %[2]s@' /etc/sysctl.conf; "+
+		"else echo '%[1]s = %[2]s' >> /etc/sysctl.conf; fi", key, value, pattern)
+}
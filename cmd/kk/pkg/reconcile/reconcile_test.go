@@ -0,0 +1,116 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"Kubernetes v1.24.3", "1.24.3"},
+		{"v1.24.3\n", "1.24.3"},
+		{"etcd Version: 3.5.13\nGit SHA: c9063a0dc\nGo Version: go1.21.8", "3.5.13"},
+		{"containerd github.com/containerd/containerd v1.7.13 7c3aca7a610df76212171d200ca3811ff6096eb8", "1.7.13"},
+		{"command not found", ""},
+	}
+	for _, tt := range tests {
+		if got := ParseVersion(tt.output); got != tt.want {
+			t.Errorf("ParseVersion(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestRenderedFiles(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"node1/etc/systemd/system/kubelet.service":   "[Service]\n",
+		"node1/etc/kubernetes/kubeadm-config.yaml":   "token: abc\n",
+		"node1/usr/local/bin/kube-scripts/initOS.sh": "#!/bin/bash\n",
+		"node2/etc/docker/daemon.json":               "{}\n",
+	} {
+		name := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := RenderedFiles(dir, "node1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"/etc/systemd/system/kubelet.service": "[Service]\n"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("RenderedFiles() = %v, want %v", files, want)
+	}
+	if files, err := RenderedFiles(dir, "node3"); err != nil || len(files) != 0 {
+		t.Errorf("RenderedFiles() of a host without files = %v, %v", files, err)
+	}
+}
+
+func TestReportPrint(t *testing.T) {
+	report := &Report{}
+	var b bytes.Buffer
+	if err := report.Print(&b, false); err != nil || !strings.Contains(b.String(), "match the cluster spec") {
+		t.Errorf("Print() of an empty report = %q, %v", b.String(), err)
+	}
+
+	report.Add(Drift{Host: "node2", Kind: Version, Name: "kubelet", Want: "1.24.3", Got: "1.23.10"})
+	report.Add(Drift{Host: "node1", Kind: Sysctl, Name: "vm.swappiness", Want: "0", Got: "60"})
+	report.Add(Drift{Host: "node1", Kind: File, Name: "/etc/docker/daemon.json", Got: "modified", Diff: "--- /etc/docker/daemon.json"})
+	if got := report.Repairable(); got != 2 {
+		t.Errorf("Repairable() = %d, want 2", got)
+	}
+
+	b.Reset()
+	if err := report.Print(&b, true); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(b.String(), "\n")
+	for i, want := range []string{
+		"HOST      DRIFT     NAME                      SUGGESTION",
+		"node1     file      /etc/docker/daemon.json   the content differs, write it by kk reconcile",
+		"node1     sysctl    vm.swappiness             the value is 60, set it to 0 by kk reconcile",
+		"node2     version   kubelet                   1.23.10 is installed, upgrade it to 1.24.3 by kk upgrade",
+	} {
+		if lines[i] != want {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want)
+		}
+	}
+	if !strings.Contains(b.String(), "# node1\n--- /etc/docker/daemon.json") {
+		t.Errorf("the diff isn't printed:\n%s", b.String())
+	}
+}
+
+func TestSysctlCommand(t *testing.T) {
+	want := `sysctl -w vm.swappiness="0" && if grep -Eq '^#?\s*vm\.swappiness\s*=' /etc/sysctl.conf; ` +
+		`then sed -r -i 's@^#?\s*vm\.swappiness\s*=.*@vm.swappiness = 0@' /etc/sysctl.conf; ` +
+		`else echo 'vm.swappiness = 0' >> /etc/sysctl.conf; fi`
+	if got := SysctlCommand("vm.swappiness", "0"); got != want {
+		t.Errorf("SysctlCommand() = %s, want %s", got, want)
+	}
+}
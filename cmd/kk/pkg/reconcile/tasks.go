@@ -0,0 +1,175 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/tuning"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+// CheckFiles compares the files on the host with the ones rendered for it under the Dir, and writes the rendered
+// ones if Fix is set. The systemd units are reloaded after they are written.
+type CheckFiles struct {
+	common.KubeAction
+	Dir    string
+	Report *Report
+	Fix    bool
+}
+
+func (c *CheckFiles) Execute(runtime connector.Runtime) error {
+	host := runtime.RemoteHost().GetName()
+	files, err := RenderedFiles(c.Dir, host)
+	if err != nil {
+		return err
+	}
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var drifted, units bool
+	for _, path := range paths {
+		want := files[path]
+		got, err := action.RemoteFileContent(runtime, path)
+		if err != nil {
+			return errors.Wrapf(err, "read %s failed", path)
+		}
+		if strings.TrimSpace(action.UntagUnit(got)) == strings.TrimSpace(action.UntagUnit(want)) {
+			continue
+		}
+		drifted = true
+		d := Drift{Host: host, Kind: File, Name: path, Diff: util.Diff(path, got, want)}
+		if got != "" {
+			d.Got = "modified"
+		}
+		if c.Fix {
+			if err := action.WriteRemoteFile(runtime, filepath.Base(path)+".reconcile", path, want); err != nil {
+				return errors.Wrapf(err, "write %s failed", path)
+			}
+			d.Repaired = true
+			units = units || strings.Contains(path, "/systemd/")
+		}
+		c.Report.Add(d)
+	}
+
+	if units {
+		if _, err := runtime.GetRunner().SudoCmd("systemctl daemon-reload", false); err != nil {
+			return errors.Wrap(err, "reload the systemd units failed")
+		}
+	}
+	if !drifted {
+		action.Unchanged(runtime)
+	}
+	return nil
+}
+
+// CheckSysctls compares the kernel parameters of the host with the Sysctls and the ones of the tuning profile, and sets
+// and persists them if Fix is set. The parameters of the profile the kernel doesn't have are skipped as ApplyTuning does.
+type CheckSysctls struct {
+	common.KubeAction
+	Report *Report
+	Fix    bool
+}
+
+func (c *CheckSysctls) Execute(runtime connector.Runtime) error {
+	host := runtime.RemoteHost().GetName()
+	drifted := false
+	sysctls := Sysctls
+	if _, ipv6 := c.KubeConf.Cluster.Network.PodsCIDRs(); ipv6 != "" {
+		sysctls = append(append(sysctls[:0:0], Sysctls...), IPv6Sysctls...)
+	}
+	profile, err := tuning.Load(c.KubeConf.Cluster.System.Tuning)
+	if err != nil {
+		return err
+	}
+	tuned := make(map[string]bool, len(profile.Sysctls))
+	for _, param := range profile.Sysctls {
+		tuned[param.Key] = true
+		sysctls = append(sysctls[:len(sysctls):len(sysctls)], struct{ Key, Value string }{param.Key, param.Value})
+	}
+	for _, s := range sysctls {
+		got := "unavailable"
+		if out, err := runtime.GetRunner().SudoCmd("sysctl -n "+s.Key, false); err == nil {
+			got = strings.Join(strings.Fields(out), " ")
+		}
+		if got == strings.Join(strings.Fields(s.Value), " ") || (got == "unavailable" && tuned[s.Key]) {
+			continue
+		}
+		drifted = true
+		d := Drift{Host: host, Kind: Sysctl, Name: s.Key, Want: s.Value, Got: got}
+		if c.Fix {
+			cmd := SysctlCommand(s.Key, s.Value)
+			if tuned[s.Key] {
+				// persisted by the file of the profile, which is checked with the managed files
+				cmd = fmt.Sprintf("sysctl -w '%s=%s'", s.Key, s.Value)
+			}
+			if strings.HasPrefix(s.Key, "net.bridge.") {
+				cmd = "modprobe br_netfilter; " + cmd
+			}
+			if _, err := runtime.GetRunner().SudoCmd(cmd, false); err != nil {
+				return errors.Wrapf(err, "set %s failed", s.Key)
+			}
+			d.Repaired = true
+		}
+		c.Report.Add(d)
+	}
+	if !drifted {
+		action.Unchanged(runtime)
+	}
+	return nil
+}
+
+// CheckVersion compares the version of the component installed on the host with the Want version, the versions are
+// only reported as they are upgraded by kk upgrade.
+type CheckVersion struct {
+	common.KubeAction
+	Component string
+	// Command prints the version of the component.
+	Command string
+	Want    string
+	Report  *Report
+}
+
+func (c *CheckVersion) Execute(runtime connector.Runtime) error {
+	got := "missing"
+	if out, err := runtime.GetRunner().SudoCmd(c.Command, false); err == nil {
+		got = ParseVersion(out)
+	}
+	if got == strings.TrimPrefix(c.Want, "v") {
+		action.Unchanged(runtime)
+		return nil
+	}
+	c.Report.Add(Drift{
+		Host: runtime.RemoteHost().GetName(),
+		Kind: Version,
+		Name: c.Component,
+		Want: strings.TrimPrefix(c.Want, "v"),
+		Got:  got,
+	})
+	return nil
+}
@@ -0,0 +1,109 @@
+/*
+ Copyright 2022 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+// ResolveAuths resolves the credentials of the auths referenced by CredentialsFrom, the username and the password in
+// the config take precedence over the resolved ones. It returns the auths with the credentials, and the passwords to
+// be redacted from the logs. The auths are resolved once by the runtime, so a rotation of the credentials in the
+// provider is picked up by the next run.
+func ResolveAuths(ctx context.Context, auths runtime.RawExtension) (runtime.RawExtension, []string, error) {
+	if len(auths.Raw) == 0 {
+		return auths, nil, nil
+	}
+	var entries map[string]*DockerRegistryEntry
+	if err := json.Unmarshal(auths.Raw, &entries); err != nil {
+		return auths, nil, errors.Wrap(err, "failed to parse the registry auths")
+	}
+
+	var passwords []string
+	resolved := false
+	for repo, entry := range entries {
+		if entry == nil {
+			continue
+		}
+		if entry.CredentialsFrom != "" {
+			credentials, err := connector.ResolveCredentials(ctx, entry.CredentialsFrom)
+			if err != nil {
+				return auths, nil, errors.Wrapf(err, "registry %s", repo)
+			}
+			if entry.Username == "" {
+				entry.Username = credentials.Username
+			}
+			if entry.Password == "" {
+				entry.Password = credentials.Password
+			}
+			resolved = true
+		}
+		if entry.Password != "" {
+			passwords = append(passwords, entry.Password)
+		}
+	}
+	if !resolved {
+		return auths, passwords, nil
+	}
+	raw, err := json.Marshal(entries)
+	if err != nil {
+		return auths, nil, errors.Wrap(err, "failed to encode the registry auths")
+	}
+	return runtime.RawExtension{Raw: raw}, passwords, nil
+}
+
+// dockerConfigAuth is an auth of the .dockerconfigjson of the image pull secrets.
+type dockerConfigAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Auth     string `json:"auth"`
+}
+
+// DockerConfigJSON returns the .dockerconfigjson of the auths with a username and a password, and the registries of
+// them sorted, which are none if the auths have no credentials.
+func DockerConfigJSON(entries map[string]*DockerRegistryEntry) ([]byte, []string, error) {
+	auths := make(map[string]dockerConfigAuth)
+	var repos []string
+	for repo, entry := range entries {
+		if entry == nil || entry.Username == "" || entry.Password == "" {
+			continue
+		}
+		auths[repo] = dockerConfigAuth{
+			Username: entry.Username,
+			Password: entry.Password,
+			Auth:     base64.StdEncoding.EncodeToString([]byte(entry.Username + ":" + entry.Password)),
+		}
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+	if len(repos) == 0 {
+		return nil, nil, nil
+	}
+	content, err := json.Marshal(map[string]interface{}{"auths": auths})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to encode the docker config of the registry auths")
+	}
+	return content, repos, nil
+}
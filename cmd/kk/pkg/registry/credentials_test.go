@@ -0,0 +1,85 @@
+/*
+ Copyright 2022 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestResolveAuths(t *testing.T) {
+	t.Setenv("HARBOR_USERNAME", "robot$kubekey")
+	t.Setenv("HARBOR_PASSWORD", "env-secret")
+	auths := runtime.RawExtension{Raw: []byte(`{
+		"harbor.example.com": {"credentialsFrom": "env://HARBOR", "caFile": "/etc/harbor/ca.crt"},
+		"quay.example.com": {"username": "admin", "password": "config-secret", "credentialsFrom": "env://HARBOR"},
+		"mirror.example.com": {"plainHTTP": true}
+	}`)}
+
+	resolved, passwords, err := ResolveAuths(context.Background(), auths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries map[string]*DockerRegistryEntry
+	if err := json.Unmarshal(resolved.Raw, &entries); err != nil {
+		t.Fatal(err)
+	}
+	if e := entries["harbor.example.com"]; e.Username != "robot$kubekey" || e.Password != "env-secret" || e.CAFile != "/etc/harbor/ca.crt" {
+		t.Errorf("the auth of harbor.example.com is %+v", e)
+	}
+	if e := entries["quay.example.com"]; e.Username != "admin" || e.Password != "config-secret" {
+		t.Errorf("the auth of quay.example.com is %+v, want the credentials of the config", e)
+	}
+	if e := entries["mirror.example.com"]; !e.PlainHTTP || e.Username != "" {
+		t.Errorf("the auth of mirror.example.com is %+v", e)
+	}
+	if len(passwords) != 2 {
+		t.Errorf("the passwords to redact are %d, want 2", len(passwords))
+	}
+
+	if _, _, err := ResolveAuths(context.Background(), runtime.RawExtension{Raw: []byte(`{"r": {"credentialsFrom": "env://MISSING"}}`)}); err == nil {
+		t.Error("ResolveAuths() of the missing envs succeeded, want an error")
+	}
+	if resolved, _, err := ResolveAuths(context.Background(), runtime.RawExtension{}); err != nil || len(resolved.Raw) != 0 {
+		t.Errorf("ResolveAuths() of no auths = %s, %v", resolved.Raw, err)
+	}
+}
+
+func TestDockerConfigJSON(t *testing.T) {
+	content, repos, err := DockerConfigJSON(map[string]*DockerRegistryEntry{
+		"harbor.example.com": {Username: "admin", Password: "Harbor12345"},
+		"mirror.example.com": {SkipTLSVerify: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"harbor.example.com"}; !reflect.DeepEqual(repos, want) {
+		t.Errorf("the registries are %v, want %v", repos, want)
+	}
+	want := `{"auths":{"harbor.example.com":{"username":"admin","password":"Harbor12345","auth":"YWRtaW46SGFyYm9yMTIzNDU="}}}`
+	if string(content) != want {
+		t.Errorf("DockerConfigJSON() = %s, want %s", content, want)
+	}
+
+	if content, repos, err := DockerConfigJSON(map[string]*DockerRegistryEntry{"mirror.example.com": {}}); content != nil || repos != nil || err != nil {
+		t.Errorf("DockerConfigJSON() without credentials = %s, %v, %v", content, repos, err)
+	}
+}
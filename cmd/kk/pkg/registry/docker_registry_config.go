@@ -40,6 +40,12 @@ type DockerRegistryEntry struct {
 	CertFile string `yaml:"certFile" json:"certFile,omitempty"`
 	// KeyFile is an SSL key file used to secure etcd communication.
 	KeyFile string `yaml:"keyFile" json:"keyFile,omitempty"`
+	// CredentialsFrom references the username and the password in a credential provider of the connector, e.g.
+	// vault://secret/kubekey/registry, the Username and the Password take precedence over them.
+	CredentialsFrom string `json:"credentialsFrom,omitempty"`
+	// TrustCA adds the CAFile to the trust store of the OS of the hosts, so the tools other than the container runtime
+	// trust the registry.
+	TrustCA bool `json:"trustCA,omitempty"`
 }
 
 func DockerRegistryAuthEntries(auths runtime.RawExtension) (entries map[string]*DockerRegistryEntry) {
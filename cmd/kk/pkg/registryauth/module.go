@@ -0,0 +1,115 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package registryauth
+
+import (
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/container"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/prepare"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+)
+
+// NodeAuthModule distributes the registry auths to the nodes: the certs of the registries, and the credentials of the
+// container runtime already installed, which are rotated by a run with the auths changed. The container runtime
+// installed by the run gets the auths from its own config.
+type NodeAuthModule struct {
+	common.KubeModule
+	Skip bool
+}
+
+func (n *NodeAuthModule) IsSkip() bool {
+	return n.Skip
+}
+
+func (n *NodeAuthModule) Init() {
+	n.Name = "NodeAuthModule"
+	n.Tags = []string{"registry-auth"}
+	n.Desc = "Distribute the registry auths to the nodes"
+
+	syncCerts := &task.RemoteTask{
+		Name:     "SyncRegistryCerts",
+		Desc:     "Sync the certs of the registries",
+		Hosts:    n.Runtime.GetHostsByRole(common.K8s),
+		Prepare:  new(HasRegistryCerts),
+		Action:   new(SyncRegistryCerts),
+		Parallel: true,
+	}
+	n.Tasks = []task.Interface{
+		syncCerts,
+	}
+
+	// the distributions other than kubeadm configure the registries of their own container runtime
+	if t := n.KubeConf.Cluster.Kubernetes.Type; t != "" && t != common.Kubernetes {
+		return
+	}
+	switch n.KubeConf.Cluster.Kubernetes.ContainerManager {
+	case common.Containerd:
+		n.Tasks = append(n.Tasks, &task.RemoteTask{
+			Name:  "UpdateContainerdAuths",
+			Desc:  "Update the registry auths of containerd",
+			Hosts: n.Runtime.GetHostsByRole(common.K8s),
+			Prepare: &prepare.PrepareCollection{
+				&container.ContainerdExist{},
+				&container.PrivateRegistryAuth{},
+			},
+			Action:   new(UpdateContainerdAuths),
+			Parallel: true,
+		})
+	case common.Docker:
+		n.Tasks = append(n.Tasks, &task.RemoteTask{
+			Name:  "UpdateDockerAuths",
+			Desc:  "Update the registry auths of docker",
+			Hosts: n.Runtime.GetHostsByRole(common.K8s),
+			Prepare: &prepare.PrepareCollection{
+				&container.DockerExist{},
+				&container.PrivateRegistryAuth{},
+			},
+			Action:   new(container.DockerLoginRegistry),
+			Parallel: true,
+		})
+	}
+}
+
+// PullSecretModule applies the image pull secret of the registry auths in the cluster from the first control-plane
+// node.
+type PullSecretModule struct {
+	common.KubeModule
+	Skip bool
+}
+
+func (p *PullSecretModule) IsSkip() bool {
+	return p.Skip
+}
+
+func (p *PullSecretModule) Init() {
+	p.Name = "PullSecretModule"
+	p.Tags = []string{"registry-auth"}
+	p.Desc = "Apply the image pull secret of the registry auths"
+
+	apply := &task.RemoteTask{
+		Name:    "ApplyPullSecret",
+		Desc:    "Apply the image pull secret",
+		Hosts:   p.Runtime.GetHostsByRole(common.Master),
+		Prepare: new(common.OnlyFirstMaster),
+		Action:  new(ApplyPullSecret),
+		Retry:   3,
+	}
+
+	p.Tasks = []task.Interface{
+		apply,
+	}
+}
@@ -0,0 +1,236 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package registryauth
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/container/templates"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/images"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/registry"
+)
+
+const (
+	kubectl = "/usr/local/bin/kubectl"
+
+	// dockerCertsDir is the dir of the certs of the registries of docker, a dir for each registry.
+	dockerCertsDir = "/etc/docker/certs.d"
+	// containerdConfig is the config of containerd with the auths of the registries.
+	containerdConfig = "/etc/containerd/config.toml"
+)
+
+// trustStoreCommand returns the command adding the CA of the registry to the trust store of the OS, the one of
+// update-ca-certificates on Debian, Ubuntu and SUSE, or the one of update-ca-trust on the distributions of RHEL.
+func trustStoreCommand(repo, ca string) string {
+	name := "kubekey-" + strings.NewReplacer(":", "_", "/", "_").Replace(repo) + ".crt"
+	return fmt.Sprintf("if command -v update-ca-certificates >/dev/null 2>&1; then "+
+		"mkdir -p /usr/local/share/ca-certificates && cp -f %[1]s /usr/local/share/ca-certificates/%[2]s && update-ca-certificates; "+
+		"elif command -v update-ca-trust >/dev/null 2>&1; then "+
+		"cp -f %[1]s /etc/pki/ca-trust/source/anchors/%[2]s && update-ca-trust extract; "+
+		"else echo 'no trust store is found' >&2; exit 1; fi", ca, name)
+}
+
+// certFile is a cert of a registry, which is copied from the local path to the same path on the hosts, and to the
+// file of docker of the registry.
+type certFile struct {
+	path   string
+	docker string
+}
+
+func certFiles(entry *registry.DockerRegistryEntry) []certFile {
+	var files []certFile
+	for _, f := range []certFile{{entry.CAFile, "ca.crt"}, {entry.CertFile, "client.cert"}, {entry.KeyFile, "client.key"}} {
+		if f.path != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// SyncRegistryCerts copies the CA, the client cert and the key of each registry auth found on the control machine to
+// the same paths on the host, which the containerd config references, and into the certs dir of docker. The certs
+// which aren't found locally are expected to be on the hosts. The CA of the auths with TrustCA is added to the trust
+// store of the OS.
+type SyncRegistryCerts struct {
+	common.KubeAction
+}
+
+func (s *SyncRegistryCerts) Execute(runtime connector.Runtime) error {
+	auths := registry.DockerRegistryAuthEntries(s.KubeConf.Cluster.Registry.Auths)
+	repos := make([]string, 0, len(auths))
+	for repo := range auths {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
+	docker := s.KubeConf.Cluster.Kubernetes.ContainerManager == common.Docker
+	for _, repo := range repos {
+		entry := auths[repo]
+		for _, f := range certFiles(entry) {
+			if _, err := os.Stat(f.path); err != nil {
+				logger.Log.Debugf("the cert %s of the registry %s isn't found locally, it is expected on the hosts", f.path, repo)
+				continue
+			}
+			if err := runtime.GetRunner().SudoScp(f.path, f.path); err != nil {
+				return errors.Wrapf(errors.WithStack(err), "copy the cert %s of the registry %s failed", f.path, repo)
+			}
+			if docker {
+				dst := filepath.Join(dockerCertsDir, repo, f.docker)
+				if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("mkdir -p %s && cp -f %s %s", filepath.Dir(dst), f.path, dst), false); err != nil {
+					return errors.Wrapf(errors.WithStack(err), "copy the cert %s of the registry %s to docker failed", f.path, repo)
+				}
+			}
+		}
+		if entry.TrustCA && entry.CAFile != "" {
+			if _, err := runtime.GetRunner().SudoCmd(trustStoreCommand(repo, entry.CAFile), false); err != nil {
+				return errors.Wrapf(errors.WithStack(err), "add the CA of the registry %s to the trust store failed", repo)
+			}
+		}
+	}
+	return nil
+}
+
+// UpdateContainerdAuths renders the containerd config of the installed containerd again with the current auths, and
+// restarts containerd if the config is changed, so the credentials rotated are used by the next pulls. The running
+// containers aren't stopped by the restart. The cgroup driver is kept from the current config.
+type UpdateContainerdAuths struct {
+	common.KubeAction
+}
+
+func (u *UpdateContainerdAuths) Execute(runtime connector.Runtime) error {
+	current, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("cat %s", containerdConfig), false)
+	if err != nil {
+		return errors.Wrapf(errors.WithStack(err), "read %s failed", containerdConfig)
+	}
+	content, err := util.RenderWith(templates.ContainerdConfig, util.Data{
+		"Mirrors":            templates.Mirrors(u.KubeConf),
+		"InsecureRegistries": u.KubeConf.Cluster.Registry.InsecureRegistries,
+		"SandBoxImage":       images.GetImage(runtime, u.KubeConf, "pause").ImageName(),
+		"Auths":              registry.DockerRegistryAuthEntries(u.KubeConf.Cluster.Registry.Auths),
+		"DataRoot":           templates.DataRoot(u.KubeConf),
+		"SELinux":            templates.SELinux(u.KubeConf),
+		"SystemdCgroup":      strings.Contains(current, "SystemdCgroup = true"),
+	}, util.RenderOptions{Strict: runtime.GetStrictRender()})
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "render the containerd config failed")
+	}
+	if strings.TrimSpace(content) == strings.TrimSpace(current) {
+		action.Unchanged(runtime)
+		return nil
+	}
+	if err := action.WriteRemoteFileWithBackup(runtime, templates.ContainerdConfig.Name(), containerdConfig, content); err != nil {
+		return err
+	}
+	if _, err := runtime.GetRunner().SudoCmd("systemctl restart containerd", false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "restart containerd failed")
+	}
+	return nil
+}
+
+// pullSecretManifest returns the namespaces and the kubernetes.io/dockerconfigjson Secrets of the docker config in
+// them.
+func pullSecretManifest(name string, namespaces []string, dockerConfig []byte) string {
+	data := base64.StdEncoding.EncodeToString(dockerConfig)
+	var docs []string
+	for _, ns := range namespaces {
+		docs = append(docs, fmt.Sprintf(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: %[2]s
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: %[1]s
+  namespace: %[2]s
+  labels:
+    app.kubernetes.io/managed-by: kubekey
+type: kubernetes.io/dockerconfigjson
+data:
+  .dockerconfigjson: %[3]s
+`, name, ns, data))
+	}
+	return strings.Join(docs, "---\n")
+}
+
+// ApplyPullSecret applies the image pull secret of the registry auths with a username and a password in the namespaces
+// of the PullSecret, and adds it to the default ServiceAccount of them if PatchServiceAccounts is set. The Secret is
+// updated by each run, so the rotated credentials reach the cluster.
+type ApplyPullSecret struct {
+	common.KubeAction
+}
+
+func (a *ApplyPullSecret) Execute(runtime connector.Runtime) error {
+	pullSecret := a.KubeConf.Cluster.Registry.PullSecret
+	dockerConfig, repos, err := registry.DockerConfigJSON(registry.DockerRegistryAuthEntries(a.KubeConf.Cluster.Registry.Auths))
+	if err != nil {
+		return err
+	}
+	if len(repos) == 0 {
+		logger.Log.Warnf("none of the registry auths has a username and a password, the image pull secret isn't created")
+		return nil
+	}
+	manifest := base64.StdEncoding.EncodeToString([]byte(pullSecretManifest(pullSecret.SecretName(), pullSecret.Namespaces, dockerConfig)))
+	logger.Log.Redactor.AddSecrets(manifest)
+	if out, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("echo %s | base64 -d | %s apply -f -", manifest, kubectl), false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "apply the image pull secret failed: %s", strings.TrimSpace(out))
+	}
+	if !pullSecret.PatchServiceAccounts {
+		return nil
+	}
+	for _, ns := range pullSecret.Namespaces {
+		if err := patchServiceAccount(runtime, ns, pullSecret.SecretName()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// patchServiceAccount adds the secret to the imagePullSecrets of the default ServiceAccount of the namespace, the
+// other secrets of it are kept.
+func patchServiceAccount(runtime connector.Runtime, ns, secret string) error {
+	out, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("%s -n %s get serviceaccount default -o jsonpath='{.imagePullSecrets[*].name}'", kubectl, ns), false)
+	if err != nil {
+		return errors.Wrapf(errors.WithStack(err), "get the default ServiceAccount of %s failed", ns)
+	}
+	names := strings.Fields(out)
+	for _, name := range names {
+		if name == secret {
+			return nil
+		}
+	}
+	patch := fmt.Sprintf(`--type json -p '[{"op":"add","path":"/imagePullSecrets/-","value":{"name":"%s"}}]'`, secret)
+	if len(names) == 0 {
+		patch = fmt.Sprintf(`--type merge -p '{"imagePullSecrets":[{"name":"%s"}]}'`, secret)
+	}
+	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("%s -n %s patch serviceaccount default %s", kubectl, ns, patch), false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "add the image pull secret to the default ServiceAccount of %s failed", ns)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scale
+
+import (
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/bootstrap/os"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/kubernetes"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/loadbalancer"
+)
+
+// CheckScaleUpModule checks the nodes to add are exactly the new hosts of the Role, from the first host of the Role
+// already in the cluster.
+type CheckScaleUpModule struct {
+	common.KubeModule
+	Role string
+}
+
+func (c *CheckScaleUpModule) Init() {
+	c.Name = "CheckScaleUpModule"
+	c.Desc = "Check the nodes to add"
+
+	check := &task.RemoteTask{
+		Name:    "CheckScaleUp",
+		Desc:    "Check the nodes to add are the new " + c.Role + " hosts",
+		Hosts:   c.Runtime.GetHostsByRole(c.Role),
+		Prepare: &FirstOfRole{Role: c.Role, Exclude: c.KubeConf.Arg.Nodes},
+		Action:  &CheckScaleUp{Role: c.Role},
+	}
+
+	c.Tasks = []task.Interface{
+		check,
+	}
+}
+
+// CheckScaleDownModule checks the Node can be removed from the Role without losing the quorum of etcd. The Node is no
+// longer in the roles of the runtime, so the check runs on the first host left.
+type CheckScaleDownModule struct {
+	common.KubeModule
+	Role string
+	Node connector.Host
+}
+
+func (c *CheckScaleDownModule) Init() {
+	c.Name = "CheckScaleDownModule"
+	c.Desc = "Check the node to remove"
+
+	check := &task.RemoteTask{
+		Name:    "CheckScaleDown",
+		Desc:    "Check the quorum is kept once the node is removed",
+		Hosts:   c.Runtime.GetHostsByRole(c.Role),
+		Prepare: &FirstOfRole{Role: c.Role},
+		Action:  &CheckScaleDown{Role: c.Role, Node: c.Node},
+	}
+
+	c.Tasks = []task.Interface{
+		check,
+	}
+}
+
+// DeleteNodeModule deletes the control-plane node from the cluster. The node isn't drained if it's quarantined, since
+// its pods can't be evicted without its kubelet.
+type DeleteNodeModule struct {
+	common.KubeModule
+	Drain bool
+}
+
+func (d *DeleteNodeModule) Init() {
+	d.Name = "DeleteNodeModule"
+	d.Desc = "Delete the control-plane node"
+
+	drain := &task.RemoteTask{
+		Name:    "DrainNode",
+		Desc:    "Node safely evict all pods",
+		Hosts:   d.Runtime.GetHostsByRole(common.Master),
+		Prepare: new(common.OnlyFirstMaster),
+		Action:  new(kubernetes.DrainNode),
+		Retry:   2,
+	}
+
+	deleteNode := &task.RemoteTask{
+		Name:    "DeleteNode",
+		Desc:    "Delete the node using kubectl",
+		Hosts:   d.Runtime.GetHostsByRole(common.Master),
+		Prepare: new(common.OnlyFirstMaster),
+		Action:  new(kubernetes.KubectlDeleteNode),
+		Retry:   5,
+	}
+
+	if d.Drain {
+		d.Tasks = append(d.Tasks, drain)
+	}
+	d.Tasks = append(d.Tasks, deleteNode)
+}
+
+// RemoveMemberModule removes the etcd member of the node from the first host of the Role left.
+type RemoveMemberModule struct {
+	common.KubeModule
+	Skip bool
+	Role string
+}
+
+func (r *RemoveMemberModule) IsSkip() bool {
+	return r.Skip
+}
+
+func (r *RemoveMemberModule) Init() {
+	r.Name = "RemoveMemberModule"
+	r.Desc = "Remove the etcd member"
+
+	remove := &task.RemoteTask{
+		Name:    "RemoveMember",
+		Desc:    "Remove the etcd member of the node",
+		Hosts:   r.Runtime.GetHostsByRole(r.Role),
+		Prepare: &FirstOfRole{Role: r.Role},
+		Action:  new(RemoveMember),
+	}
+
+	r.Tasks = []task.Interface{
+		remove,
+	}
+}
+
+// RefreshEtcdServersModule points the kube-apiservers to the etcd hosts, one control-plane node after another so
+// that the api stays available.
+type RefreshEtcdServersModule struct {
+	common.KubeModule
+	Skip bool
+}
+
+func (r *RefreshEtcdServersModule) IsSkip() bool {
+	return r.Skip
+}
+
+func (r *RefreshEtcdServersModule) Init() {
+	r.Name = "RefreshEtcdServersModule"
+	r.Desc = "Refresh the etcd servers of the kube-apiservers"
+
+	refresh := &task.RemoteTask{
+		Name:   "RefreshEtcdServers",
+		Desc:   "Refresh the etcd servers of the kube-apiserver",
+		Hosts:  r.Runtime.GetHostsByRole(common.Master),
+		Action: new(RefreshEtcdServers),
+	}
+
+	r.Tasks = []task.Interface{
+		refresh,
+	}
+}
+
+// ClearNodeModule removes the Role from the node being removed: a control-plane node is reset and its files are
+// removed, an etcd node has its etcd uninstalled.
+type ClearNodeModule struct {
+	common.KubeModule
+	Role string
+}
+
+func (c *ClearNodeModule) Init() {
+	c.Name = "ClearNodeModule"
+	c.Desc = "Clear the removed node"
+
+	if c.Role == common.ETCD {
+		c.Tasks = []task.Interface{
+			c.task("UninstallETCD", "Uninstall etcd", new(os.UninstallETCD)),
+			c.task("RemoveETCDUnits", "Remove the etcd units", new(RemoveETCDUnits)),
+		}
+		return
+	}
+
+	c.Tasks = []task.Interface{
+		c.task("KubeadmReset", "Reset the node using kubeadm", new(kubernetes.KubeadmReset)),
+		c.task("StopKubelet", "Stop Kubelet", new(os.StopKubelet)),
+		c.task("ResetNetworkConfig", "Reset os network config", new(os.ResetNetworkConfig)),
+		c.task("RemoveFiles", "Remove node files", new(os.RemoveNodeFiles)),
+		c.task("DaemonReload", "Systemd daemon reload", new(os.DaemonReload)),
+	}
+	if c.KubeConf.Cluster.ControlPlaneEndpoint.IsInternalLBEnabledVip() {
+		getInterface := c.task("GetNodeInterface", "Get Node Interface", new(loadbalancer.GetInterfaceName))
+		getInterface.AlwaysRun = true
+		c.Tasks = append(c.Tasks,
+			getInterface,
+			c.task("DeleteVIP", "Delete the VIP", new(loadbalancer.DeleteVIP)))
+	}
+}
+
+func (c *ClearNodeModule) task(name, desc string, act action.Action) *task.RemoteTask {
+	return &task.RemoteTask{
+		Name:    name,
+		Desc:    desc,
+		Hosts:   c.Runtime.GetAllHosts(),
+		Prepare: new(IsNode),
+		Action:  act,
+	}
+}
+
+// RemovesMember reports whether removing a node of the role removes its etcd member: the etcd nodes do, and so do the
+// control-plane nodes of the kubeadm etcd, which runs on them.
+func RemovesMember(role string, cluster *kubekeyapiv1alpha2.ClusterSpec) bool {
+	return role == common.ETCD || cluster.Etcd.Type == kubekeyapiv1alpha2.Kubeadm
+}
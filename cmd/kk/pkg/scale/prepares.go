@@ -0,0 +1,52 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scale
+
+import (
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+)
+
+// FirstOfRole runs the task on the first host of the Role which isn't one of the Exclude ones, e.g. the first master
+// already in the cluster when the new masters are listed before it.
+type FirstOfRole struct {
+	common.KubePrepare
+	Role    string
+	Exclude []string
+}
+
+func (f *FirstOfRole) PreCheck(runtime connector.Runtime) (bool, error) {
+	exclude := make(map[string]bool, len(f.Exclude))
+	for _, name := range f.Exclude {
+		exclude[name] = true
+	}
+	for _, host := range runtime.GetHostsByRole(f.Role) {
+		if !exclude[host.GetName()] {
+			return runtime.RemoteHost().GetName() == host.GetName(), nil
+		}
+	}
+	return false, nil
+}
+
+// IsNode runs the task on the node being removed only.
+type IsNode struct {
+	common.KubePrepare
+}
+
+func (i *IsNode) PreCheck(runtime connector.Runtime) (bool, error) {
+	return runtime.RemoteHost().GetName() == i.KubeConf.Arg.NodeName, nil
+}
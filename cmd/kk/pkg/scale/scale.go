@@ -0,0 +1,157 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package scale adds and removes the control-plane and the etcd nodes of a cluster without touching the workers. The
+// removals are refused when the etcd cluster would lose its quorum.
+package scale
+
+import (
+	"encoding/json"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Member is a member of the etcd cluster.
+type Member struct {
+	ID         uint64   `json:"ID"`
+	Name       string   `json:"name"`
+	PeerURLs   []string `json:"peerURLs"`
+	ClientURLs []string `json:"clientURLs"`
+	Healthy    bool     `json:"-"`
+}
+
+// ParseMembers parses the output of `etcdctl member list -w json`, and marks the members whose client URLs are healthy
+// in the output of `etcdctl endpoint health --cluster -w json`.
+func ParseMembers(list, health string) ([]Member, error) {
+	var members struct {
+		Members []Member `json:"members"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(list)), &members); err != nil {
+		return nil, errors.Wrap(err, "parse the etcd members failed")
+	}
+
+	var endpoints []struct {
+		Endpoint string `json:"endpoint"`
+		Health   bool   `json:"health"`
+	}
+	// the output is empty if none of the endpoints answers
+	if health = strings.TrimSpace(health); health != "" {
+		if err := json.Unmarshal([]byte(health), &endpoints); err != nil {
+			return nil, errors.Wrap(err, "parse the health of the etcd endpoints failed")
+		}
+	}
+	healthy := make(map[string]bool, len(endpoints))
+	for _, e := range endpoints {
+		healthy[e.Endpoint] = e.Health
+	}
+
+	for i := range members.Members {
+		for _, u := range members.Members[i].ClientURLs {
+			if healthy[u] {
+				members.Members[i].Healthy = true
+			}
+		}
+	}
+	return members.Members, nil
+}
+
+// FindMember returns the member whose peer URL is on one of the addresses of the internal address, which are
+// separated by a comma for a dual-stack host, or nil if there is none.
+func FindMember(members []Member, internalAddress string) *Member {
+	for i := range members {
+		for _, peer := range members[i].PeerURLs {
+			u, err := url.Parse(peer)
+			if err != nil {
+				continue
+			}
+			ip := net.ParseIP(u.Hostname())
+			for _, address := range strings.Split(internalAddress, ",") {
+				address = strings.TrimSpace(address)
+				if u.Hostname() == address || ip != nil && ip.Equal(net.ParseIP(address)) {
+					return &members[i]
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// CheckQuorum checks the etcd cluster keeps its quorum once the member is removed, that is the healthy members left
+// are a majority of the members left. An unhealthy member can be removed as long as the others keep the quorum.
+func CheckQuorum(members []Member, remove uint64) error {
+	left, healthy := 0, 0
+	for _, m := range members {
+		if m.ID == remove {
+			continue
+		}
+		left++
+		if m.Healthy {
+			healthy++
+		}
+	}
+	if left == len(members) {
+		return errors.Errorf("%x isn't a member of the etcd cluster", remove)
+	}
+	if left == 0 {
+		return errors.New("the last member of the etcd cluster can't be removed")
+	}
+	if quorum := left/2 + 1; healthy < quorum {
+		return errors.Errorf("the etcd cluster would lose its quorum: %d of the %d members left are healthy, "+
+			"at least %d are required", healthy, left, quorum)
+	}
+	return nil
+}
+
+// CheckNewNodes checks the nodes to add are exactly the hosts of the role which aren't in the cluster yet, so that the
+// scaling doesn't join any other node, e.g. a worker added to the config but not by kk add nodes.
+func CheckNewNodes(role string, nodes, missing, roleHosts []string) error {
+	want := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		want[n] = true
+	}
+	inRole := make(map[string]bool, len(roleHosts))
+	for _, h := range roleHosts {
+		inRole[h] = true
+	}
+
+	var errs []string
+	for _, n := range nodes {
+		if !inRole[n] {
+			errs = append(errs, n+" isn't a "+role+" host of the config")
+		}
+	}
+	isMissing := make(map[string]bool, len(missing))
+	for _, m := range missing {
+		isMissing[m] = true
+		if !want[m] {
+			errs = append(errs, m+" isn't in the cluster but isn't one of the nodes to add")
+		}
+	}
+	for _, n := range nodes {
+		if inRole[n] && !isMissing[n] {
+			errs = append(errs, n+" is already in the cluster")
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
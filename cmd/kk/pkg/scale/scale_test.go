@@ -0,0 +1,109 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scale
+
+import (
+	"testing"
+)
+
+const memberList = `{"header":{"cluster_id":1,"member_id":2,"raft_term":3},"members":[
+{"ID":12350000000000000001,"name":"etcd-node1","peerURLs":["https://10.0.0.1:2380"],"clientURLs":["https://10.0.0.1:2379"]},
+{"ID":2,"name":"etcd-node2","peerURLs":["https://10.0.0.2:2380"],"clientURLs":["https://10.0.0.2:2379"]},
+{"ID":3,"name":"etcd-node3","peerURLs":["https://10.0.0.3:2380"],"clientURLs":["https://10.0.0.3:2379"]}]}`
+
+func TestParseMembers(t *testing.T) {
+	health := `[{"endpoint":"https://10.0.0.1:2379","health":true,"took":"9ms"},
+{"endpoint":"https://10.0.0.3:2379","health":false,"took":"5s","error":"context deadline exceeded"}]`
+	members, err := ParseMembers(memberList, health)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 3 {
+		t.Fatalf("got %d members, want 3", len(members))
+	}
+	if members[0].ID != 12350000000000000001 || !members[0].Healthy || members[1].Healthy || members[2].Healthy {
+		t.Errorf("got the members %+v", members)
+	}
+	if m := FindMember(members, "10.0.0.2"); m == nil || m.Name != "etcd-node2" {
+		t.Errorf("FindMember(10.0.0.2) = %+v", m)
+	}
+	if m := FindMember(members, "10.0.0.4"); m != nil {
+		t.Errorf("FindMember(10.0.0.4) = %+v, want nil", m)
+	}
+
+	// the IPv6-only and the dual-stack hosts
+	members = []Member{{ID: 1, PeerURLs: []string{"https://[fd00::1]:2380"}}, {ID: 2, PeerURLs: []string{"https://10.0.0.2:2380"}}}
+	if m := FindMember(members, "fd00:0:0::1"); m == nil || m.ID != 1 {
+		t.Errorf("FindMember(fd00:0:0::1) = %+v", m)
+	}
+	if m := FindMember(members, "10.0.0.1,fd00::1"); m == nil || m.ID != 1 {
+		t.Errorf("FindMember(10.0.0.1,fd00::1) = %+v", m)
+	}
+	if m := FindMember(members, "fd00::2"); m != nil {
+		t.Errorf("FindMember(fd00::2) = %+v, want nil", m)
+	}
+}
+
+func TestCheckQuorum(t *testing.T) {
+	members := func(healthy ...bool) []Member {
+		var m []Member
+		for i, h := range healthy {
+			m = append(m, Member{ID: uint64(i + 1), Healthy: h})
+		}
+		return m
+	}
+	tests := []struct {
+		name    string
+		members []Member
+		remove  uint64
+		wantErr bool
+	}{
+		{"healthy of three", members(true, true, true), 1, false},
+		{"unhealthy of three", members(false, true, true), 1, false},
+		{"healthy of three with an unhealthy one", members(true, false, true), 1, true},
+		{"of two", members(true, true), 2, false},
+		{"the last", members(true), 1, true},
+		{"healthy of five with an unhealthy one", members(true, false, true, true, true), 1, false},
+		{"healthy of five with two unhealthy ones", members(true, false, false, true, true), 1, true},
+		{"not a member", members(true, true, true), 4, true},
+	}
+	for _, tt := range tests {
+		if err := CheckQuorum(tt.members, tt.remove); (err != nil) != tt.wantErr {
+			t.Errorf("%s: CheckQuorum() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCheckNewNodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodes   []string
+		missing []string
+		wantErr bool
+	}{
+		{"new masters", []string{"node4", "node5"}, []string{"node4", "node5"}, false},
+		{"a new worker", []string{"node4"}, []string{"node4", "node6"}, true},
+		{"not a master", []string{"node6"}, []string{"node6"}, true},
+		{"already in the cluster", []string{"node1"}, nil, true},
+	}
+	roleHosts := []string{"node1", "node2", "node3", "node4", "node5"}
+	for _, tt := range tests {
+		if err := CheckNewNodes("master", tt.nodes, tt.missing, roleHosts); (err != nil) != tt.wantErr {
+			t.Errorf("%s: CheckNewNodes() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
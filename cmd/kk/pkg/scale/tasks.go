@@ -0,0 +1,196 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scale
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/health"
+)
+
+// memberKey is the key of the pipeline cache holding the ID of the etcd member of the node being removed.
+const memberKey = "scaleEtcdMember"
+
+// etcdctl returns the etcdctl command run on the host. The kubekey etcd is reached with the admin certificate of the
+// etcd host, the kubeadm one with the etcdctl in the etcd static pod of the control-plane host.
+func etcdctl(kubeConf *common.KubeConf, host connector.Host, args string) string {
+	if kubeConf.Cluster.Etcd.Type == kubekeyapiv1alpha2.Kubeadm {
+		return fmt.Sprintf("/usr/local/bin/kubectl -n kube-system exec etcd-%s -- etcdctl "+
+			"--endpoints=https://127.0.0.1:2379 --cacert=/etc/kubernetes/pki/etcd/ca.crt "+
+			"--cert=/etc/kubernetes/pki/etcd/server.crt --key=/etc/kubernetes/pki/etcd/server.key %s",
+			host.GetName(), args)
+	}
+	name := host.GetName()
+	return fmt.Sprintf("export ETCDCTL_API=3;"+
+		"export ETCDCTL_CERT='/etc/ssl/etcd/ssl/admin-%s.pem';"+
+		"export ETCDCTL_KEY='/etc/ssl/etcd/ssl/admin-%s-key.pem';"+
+		"export ETCDCTL_CACERT='/etc/ssl/etcd/ssl/ca.pem';"+
+		"%s/etcdctl --endpoints=https://%s %s",
+		name, name, common.BinDir, net.JoinHostPort(host.GetInternalIPv4Address(), "2379"), args)
+}
+
+// listMembers lists the members of the etcd cluster with their health from the host.
+func listMembers(runtime connector.Runtime, kubeConf *common.KubeConf) ([]Member, error) {
+	host := runtime.RemoteHost()
+	list, err := runtime.GetRunner().SudoCmd(etcdctl(kubeConf, host, "member list -w json")+" 2>/dev/null", false)
+	if err != nil {
+		return nil, errors.Wrap(errors.WithStack(err), "list the etcd members failed")
+	}
+	// etcdctl fails if any endpoint is unhealthy, the health of the others is still printed
+	health, _ := runtime.GetRunner().SudoCmd(etcdctl(kubeConf, host, "endpoint health --cluster -w json")+" 2>/dev/null", false)
+	return ParseMembers(list, health)
+}
+
+// CheckScaleUp checks the nodes to add are exactly the hosts of the Role which aren't in the cluster yet.
+type CheckScaleUp struct {
+	common.KubeAction
+	Role string
+}
+
+func (c *CheckScaleUp) Execute(runtime connector.Runtime) error {
+	var missing []string
+	if c.Role == common.ETCD {
+		members, err := listMembers(runtime, c.KubeConf)
+		if err != nil {
+			return err
+		}
+		for _, host := range runtime.GetHostsByRole(common.ETCD) {
+			if FindMember(members, host.GetInternalAddress()) == nil {
+				missing = append(missing, host.GetName())
+			}
+		}
+	} else {
+		out, err := runtime.GetRunner().SudoCmd(
+			"/usr/local/bin/kubectl get nodes -o jsonpath='{.items[*].metadata.name}'", false)
+		if err != nil {
+			return errors.Wrap(errors.WithStack(err), "get the nodes of the cluster failed")
+		}
+		nodes := make(map[string]bool)
+		for _, name := range strings.Fields(out) {
+			nodes[name] = true
+		}
+		for _, host := range runtime.GetHostsByRole(common.K8s) {
+			if !nodes[host.GetName()] {
+				missing = append(missing, host.GetName())
+			}
+		}
+	}
+
+	var roleHosts []string
+	for _, host := range runtime.GetHostsByRole(c.Role) {
+		roleHosts = append(roleHosts, host.GetName())
+	}
+	return CheckNewNodes(c.Role, c.KubeConf.Arg.Nodes, missing, roleHosts)
+}
+
+// CheckScaleDown checks the Node can be removed from the Role: a control-plane node must be a node of the cluster,
+// and the etcd cluster must keep its quorum once the etcd member of the node is removed.
+type CheckScaleDown struct {
+	common.KubeAction
+	Role string
+	Node connector.Host
+}
+
+func (c *CheckScaleDown) Execute(runtime connector.Runtime) error {
+	name := c.Node.GetName()
+	if c.Role == common.Master {
+		if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("/usr/local/bin/kubectl get node %s", name), false); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "%s isn't a node of the cluster", name)
+		}
+		c.PipelineCache.Set("dstNode", name)
+		if c.KubeConf.Cluster.Etcd.Type != kubekeyapiv1alpha2.Kubeadm {
+			return nil
+		}
+	}
+
+	members, err := listMembers(runtime, c.KubeConf)
+	if err != nil {
+		return err
+	}
+	// the quorum can't be checked without the member, the removal is refused
+	member := FindMember(members, c.Node.GetInternalAddress())
+	if member == nil {
+		return errors.Errorf("%s isn't a member of the etcd cluster", name)
+	}
+	if err := CheckQuorum(members, member.ID); err != nil {
+		return err
+	}
+	if left := len(members) - 1; left%2 == 0 {
+		logger.Log.Warnf("%d etcd members are left, which tolerate no more failures than %d members", left, left-1)
+	}
+	c.PipelineCache.Set(memberKey, member.ID)
+	return nil
+}
+
+// RemoveMember removes the etcd member of the node being removed, which is found by CheckScaleDown.
+type RemoveMember struct {
+	common.KubeAction
+}
+
+func (r *RemoveMember) Execute(runtime connector.Runtime) error {
+	v, ok := r.PipelineCache.Get(memberKey)
+	if !ok {
+		return nil
+	}
+	cmd := etcdctl(r.KubeConf, runtime.RemoteHost(), fmt.Sprintf("member remove %x", v.(uint64)))
+	if _, err := runtime.GetRunner().SudoCmd(cmd, true); err != nil {
+		return errors.Wrap(errors.WithStack(err), "remove the etcd member failed")
+	}
+	return nil
+}
+
+// RefreshEtcdServers points the kube-apiserver of the control-plane host to the etcd hosts, and waits for it to be
+// ready again.
+type RefreshEtcdServers struct {
+	common.KubeAction
+}
+
+func (r *RefreshEtcdServers) Execute(runtime connector.Runtime) error {
+	var servers []string
+	for _, host := range runtime.GetHostsByRole(common.ETCD) {
+		servers = append(servers, "https://"+net.JoinHostPort(host.GetInternalIPv4Address(), "2379"))
+	}
+	cmd := fmt.Sprintf("sed -i 's#--etcd-servers=.*#--etcd-servers=%s#' /etc/kubernetes/manifests/kube-apiserver.yaml",
+		strings.Join(servers, ","))
+	if _, err := runtime.GetRunner().SudoCmd(cmd, false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "update the etcd servers of the kube-apiserver failed")
+	}
+	return health.Wait(runtime, health.DefaultTimeout, health.Apiserver)
+}
+
+// RemoveETCDUnits stops and removes the systemd units of etcd and its backup.
+type RemoveETCDUnits struct {
+	common.KubeAction
+}
+
+func (r *RemoveETCDUnits) Execute(runtime connector.Runtime) error {
+	_, _ = runtime.GetRunner().SudoCmd("systemctl disable --now etcd backup-etcd.timer", false)
+	_, _ = runtime.GetRunner().SudoCmd("rm -f /etc/systemd/system/etcd.service "+
+		"/etc/systemd/system/backup-etcd.service /etc/systemd/system/backup-etcd.timer", false)
+	if _, err := runtime.GetRunner().SudoCmd("systemctl daemon-reload", false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "reload the systemd units failed")
+	}
+	return nil
+}
@@ -0,0 +1,340 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package sshconfig
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+// Disabled is the value of the sshConfig field which disables the ssh config, same as leaving it empty.
+const Disabled = "none"
+
+// Path resolves the sshConfig field of the cluster config file, the relative path is resolved against the directory
+// of the cluster config file. The ssh config is opt-in, false is returned if the field is empty or Disabled, since
+// a "Host *" block of the user's ssh config would otherwise override the default user and port of every host.
+func Path(sshConfig, clusterConfigFile string) (string, bool) {
+	if sshConfig == "" || sshConfig == Disabled {
+		return "", false
+	}
+	if !filepath.IsAbs(sshConfig) && !strings.HasPrefix(sshConfig, "~") {
+		return filepath.Join(filepath.Dir(clusterConfigFile), sshConfig), true
+	}
+	return sshConfig, true
+}
+
+// Config is the parsed ssh_config. Only the Host blocks and the directives used by KubeKey are supported:
+// HostName, User, Port, IdentityFile and ProxyJump. The Match blocks are ignored.
+type Config struct {
+	home   string
+	blocks []*block
+}
+
+type block struct {
+	patterns []string
+	// match is false for the unsupported Match blocks
+	match      bool
+	directives [][2]string
+}
+
+// Params are the connection parameters of a host alias.
+type Params struct {
+	HostName      string
+	User          string
+	Port          int
+	IdentityFiles []string
+	ProxyJump     string
+}
+
+// Load parses the ssh config file, the included files are resolved relative to ~/.ssh.
+// An empty config is returned if the file doesn't exist.
+func Load(file string) (*Config, error) {
+	home, err := util.Home()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the home dir")
+	}
+	c := &Config{home: home, blocks: []*block{{patterns: []string{"*"}, match: true}}}
+	if err := c.parseFile(expandHome(file, home), 0); err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			return c, nil
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Config) parseFile(file string, depth int) error {
+	if depth > 16 {
+		return errors.Errorf("too many nested includes in ssh config %s", file)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keyword, args := splitLine(line)
+		if len(args) == 0 {
+			return errors.Errorf("%s:%d: missing argument of %s", file, n, keyword)
+		}
+
+		switch keyword {
+		case "host":
+			c.blocks = append(c.blocks, &block{patterns: args, match: true})
+		case "match":
+			c.blocks = append(c.blocks, &block{})
+		case "include":
+			for _, pattern := range args {
+				pattern = expandHome(pattern, c.home)
+				if !filepath.IsAbs(pattern) {
+					pattern = filepath.Join(c.home, ".ssh", pattern)
+				}
+				files, err := filepath.Glob(pattern)
+				if err != nil {
+					return errors.Wrapf(err, "%s:%d: invalid include pattern", file, n)
+				}
+				for _, included := range files {
+					if err := c.parseFile(included, depth+1); err != nil {
+						return err
+					}
+				}
+			}
+		default:
+			last := c.blocks[len(c.blocks)-1]
+			last.directives = append(last.directives, [2]string{keyword, strings.Join(args, " ")})
+		}
+	}
+	return scanner.Err()
+}
+
+// splitLine splits the line into the lowercase keyword and the arguments, the keyword can be followed by "=".
+func splitLine(line string) (string, []string) {
+	i := strings.IndexAny(line, " \t=")
+	if i < 0 {
+		return strings.ToLower(line), nil
+	}
+	keyword := strings.ToLower(line[:i])
+	rest := strings.TrimLeft(line[i:], " \t")
+	rest = strings.TrimLeft(strings.TrimPrefix(rest, "="), " \t")
+
+	var args []string
+	for rest != "" {
+		var arg string
+		if rest[0] == '"' {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			arg, rest = rest[1:end+1], rest[min(end+2, len(rest)):]
+		} else if end := strings.IndexAny(rest, " \t"); end >= 0 {
+			arg, rest = rest[:end], rest[end:]
+		} else {
+			arg, rest = rest, ""
+		}
+		args = append(args, arg)
+		rest = strings.TrimLeft(rest, " \t")
+	}
+	return keyword, args
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func (b *block) matches(alias string) bool {
+	if !b.match {
+		return false
+	}
+	alias = strings.ToLower(alias)
+	matched := false
+	for _, pattern := range b.patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		ok, _ := path.Match(strings.ToLower(strings.TrimPrefix(pattern, "!")), alias)
+		if ok && negated {
+			return false
+		}
+		if ok {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// Lookup returns the parameters of the alias, the first obtained value of each directive is used as ssh does.
+func (c *Config) Lookup(alias string) Params {
+	var p Params
+	seen := make(map[string]bool)
+	for _, b := range c.blocks {
+		if !b.matches(alias) {
+			continue
+		}
+		for _, d := range b.directives {
+			keyword, value := d[0], d[1]
+			if keyword == "identityfile" {
+				p.IdentityFiles = append(p.IdentityFiles, value)
+				continue
+			}
+			if seen[keyword] {
+				continue
+			}
+			seen[keyword] = true
+			switch keyword {
+			case "hostname":
+				p.HostName = strings.ReplaceAll(value, "%h", alias)
+			case "user":
+				p.User = value
+			case "port":
+				p.Port, _ = strconv.Atoi(value)
+			case "proxyjump":
+				p.ProxyJump = value
+			}
+		}
+	}
+	for i, file := range p.IdentityFiles {
+		p.IdentityFiles[i] = c.expandTokens(file, alias, p)
+	}
+	return p
+}
+
+func (c *Config) expandTokens(s, alias string, p Params) string {
+	hostName := p.HostName
+	if hostName == "" {
+		hostName = alias
+	}
+	s = expandHome(s, c.home)
+	r := strings.NewReplacer("%%", "%", "%d", c.home, "%h", hostName, "%n", alias, "%r", p.User)
+	return r.Replace(s)
+}
+
+func expandHome(s, home string) string {
+	if s == "~" {
+		return home
+	}
+	if strings.HasPrefix(s, "~/") {
+		return filepath.Join(home, s[2:])
+	}
+	return s
+}
+
+// Apply fills the connection parameters of the hosts which are not set in the cluster config, from the ssh config
+// of the host name. The parameters set in the cluster config take precedence.
+func Apply(c *Config, hosts []kubekeyapiv1alpha2.HostCfg) error {
+	for i := range hosts {
+		host := &hosts[i]
+		p := c.Lookup(host.Name)
+
+		if p.HostName != "" && (host.Address == "" || host.Address == host.Name) {
+			host.Address = p.HostName
+		}
+		if host.User == "" {
+			host.User = p.User
+		}
+		if host.Port == 0 {
+			host.Port = p.Port
+		}
+		if host.Password == "" && host.PrivateKey == "" && host.PrivateKeyPath == "" && host.CredentialsFrom == "" {
+			for _, file := range p.IdentityFiles {
+				if util.IsExist(file) {
+					host.PrivateKeyPath = file
+					break
+				}
+			}
+		}
+		if host.Bastion == "" && p.ProxyJump != "" && !strings.EqualFold(p.ProxyJump, Disabled) {
+			if err := c.applyProxyJump(host, p.ProxyJump); err != nil {
+				return errors.Wrapf(err, "invalid ProxyJump of host %s", host.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// applyProxyJump sets the bastion of the host from the jump host [user@]host[:port], which can also be an alias.
+func (c *Config) applyProxyJump(host *kubekeyapiv1alpha2.HostCfg, jump string) error {
+	if strings.Contains(jump, ",") {
+		return fmt.Errorf("multiple jump hosts %s are not supported", jump)
+	}
+	jump = strings.TrimPrefix(jump, "ssh://")
+
+	var user string
+	if i := strings.LastIndex(jump, "@"); i >= 0 {
+		user, jump = jump[:i], jump[i+1:]
+	}
+	jump, port, err := splitJumpHost(jump)
+	if err != nil {
+		return err
+	}
+
+	p := c.Lookup(jump)
+	host.Bastion = jump
+	if p.HostName != "" {
+		host.Bastion = p.HostName
+	}
+	host.BastionUser = user
+	if host.BastionUser == "" {
+		host.BastionUser = p.User
+	}
+	host.BastionPort = port
+	if host.BastionPort == 0 {
+		host.BastionPort = p.Port
+	}
+	return nil
+}
+
+// splitJumpHost splits the host[:port] of a jump host. The port of an IPv6 address is only told apart in the brackets,
+// e.g. [2001:db8::1]:2200, an IPv6 address without the brackets has no port, e.g. fe80::1%eth0.
+func splitJumpHost(jump string) (string, int, error) {
+	var portStr string
+	switch {
+	case strings.HasPrefix(jump, "["):
+		i := strings.LastIndex(jump, "]")
+		if i < 0 {
+			return "", 0, fmt.Errorf("invalid jump host %s", jump)
+		}
+		jump, portStr = jump[1:i], strings.TrimPrefix(jump[i+1:], ":")
+	case strings.Count(jump, ":") == 1:
+		i := strings.Index(jump, ":")
+		jump, portStr = jump[:i], jump[i+1:]
+	}
+	if portStr == "" {
+		return jump, 0, nil
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port of jump host %s", jump)
+	}
+	return jump, port, nil
+}
@@ -0,0 +1,205 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package sshconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+)
+
+const testConfig = `
+# cluster nodes
+Host bastion
+  HostName 203.0.113.10
+  User jump
+
+Host node1
+  HostName=10.0.0.11
+  IdentityFile "%d/id_node1"
+
+Host node* !node3
+  User ubuntu
+  Port 2222
+  IdentityFile %d/id_cluster
+  ProxyJump bastion:2200
+
+Match host node2
+  User ignored
+
+Host *
+  User root
+  IdentityFile %d/id_missing
+`
+
+func loadTestConfig(t *testing.T) (*Config, string) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config")
+	if err := os.WriteFile(file, []byte(testConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.home = dir
+	return c, dir
+}
+
+func TestLookup(t *testing.T) {
+	c, dir := loadTestConfig(t)
+	tests := []struct {
+		name  string
+		alias string
+		want  Params
+	}{
+		{
+			name:  "first obtained value wins",
+			alias: "node1",
+			want: Params{
+				HostName:      "10.0.0.11",
+				User:          "ubuntu",
+				Port:          2222,
+				IdentityFiles: []string{dir + "/id_node1", dir + "/id_cluster", dir + "/id_missing"},
+				ProxyJump:     "bastion:2200",
+			},
+		},
+		{
+			name:  "negated pattern",
+			alias: "node3",
+			want:  Params{User: "root", IdentityFiles: []string{dir + "/id_missing"}},
+		},
+		{
+			name:  "case insensitive alias",
+			alias: "BASTION",
+			want:  Params{HostName: "203.0.113.10", User: "jump", IdentityFiles: []string{dir + "/id_missing"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Lookup(tt.alias); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lookup() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	c, dir := loadTestConfig(t)
+	if err := os.WriteFile(filepath.Join(dir, "id_cluster"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	hosts := []kubekeyapiv1alpha2.HostCfg{
+		{Name: "node1"},
+		{Name: "node2", Address: "10.0.0.12", User: "admin", Password: "secret"},
+		{Name: "node3", Address: "10.0.0.13"},
+	}
+	if err := Apply(c, hosts); err != nil {
+		t.Fatal(err)
+	}
+	want := []kubekeyapiv1alpha2.HostCfg{
+		{Name: "node1", Address: "10.0.0.11", User: "ubuntu", Port: 2222, PrivateKeyPath: dir + "/id_cluster",
+			Bastion: "203.0.113.10", BastionUser: "jump", BastionPort: 2200},
+		{Name: "node2", Address: "10.0.0.12", User: "admin", Port: 2222, Password: "secret",
+			Bastion: "203.0.113.10", BastionUser: "jump", BastionPort: 2200},
+		{Name: "node3", Address: "10.0.0.13", User: "root"},
+	}
+	if !reflect.DeepEqual(hosts, want) {
+		t.Errorf("Apply() = %+v, want %+v", hosts, want)
+	}
+}
+
+func TestApplyWildcard(t *testing.T) {
+	c, _ := loadTestConfig(t)
+	hosts := []kubekeyapiv1alpha2.HostCfg{
+		{Name: "master1", Address: "10.0.0.21", User: "root", Port: 22},
+		{Name: "master2", Address: "10.0.0.22"},
+	}
+	if err := Apply(c, hosts); err != nil {
+		t.Fatal(err)
+	}
+	want := []kubekeyapiv1alpha2.HostCfg{
+		{Name: "master1", Address: "10.0.0.21", User: "root", Port: 22},
+		{Name: "master2", Address: "10.0.0.22", User: "root"},
+	}
+	if !reflect.DeepEqual(hosts, want) {
+		t.Errorf("Apply() = %+v, want %+v", hosts, want)
+	}
+}
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		sshConfig string
+		want      string
+		wantOk    bool
+	}{
+		{sshConfig: ""},
+		{sshConfig: Disabled},
+		{sshConfig: "~/.ssh/config", want: "~/.ssh/config", wantOk: true},
+		{sshConfig: "/etc/ssh/ssh_config", want: "/etc/ssh/ssh_config", wantOk: true},
+		{sshConfig: "./ssh_config", want: "/work/ssh_config", wantOk: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sshConfig, func(t *testing.T) {
+			got, ok := Path(tt.sshConfig, "/work/config-sample.yaml")
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("Path() = %s, %v, want %s, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestSplitJumpHost(t *testing.T) {
+	tests := []struct {
+		jump     string
+		wantHost string
+		wantPort int
+		wantErr  bool
+	}{
+		{jump: "bastion", wantHost: "bastion"},
+		{jump: "203.0.113.10:2200", wantHost: "203.0.113.10", wantPort: 2200},
+		{jump: "2001:db8::1", wantHost: "2001:db8::1"},
+		{jump: "fe80::1%eth0", wantHost: "fe80::1%eth0"},
+		{jump: "[2001:db8::1]:2200", wantHost: "2001:db8::1", wantPort: 2200},
+		{jump: "[fe80::1%eth0]", wantHost: "fe80::1%eth0"},
+		{jump: "bastion:ssh", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.jump, func(t *testing.T) {
+			host, port, err := splitJumpHost(tt.jump)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitJumpHost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if host != tt.wantHost || port != tt.wantPort {
+				t.Errorf("splitJumpHost() = %s, %d, want %s, %d", host, port, tt.wantHost, tt.wantPort)
+			}
+		})
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	c, err := Load(filepath.Join(t.TempDir(), "config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Lookup("node1"); !reflect.DeepEqual(got, Params{}) {
+		t.Errorf("Lookup() = %+v, want empty", got)
+	}
+}
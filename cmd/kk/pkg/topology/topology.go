@@ -0,0 +1,179 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package topology
+
+import (
+	"fmt"
+	"net"
+	"sort"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+)
+
+const ipv6SegmentMaskSize = 64
+
+type segment struct {
+	key   string
+	local bool
+	hosts []connector.Host
+}
+
+// SegmentKey returns the key of the network segment which the host belongs to.
+func SegmentKey(cluster *kubekeyapiv1alpha2.ClusterSpec, host connector.Host) string {
+	if cluster.Topology.DistributionStrategy == kubekeyapiv1alpha2.DistributionStrategyRack {
+		if kubeHost, ok := host.(*kubekeyapiv1alpha2.KubeHost); ok && kubeHost.FailureDomain() != "" {
+			return kubeHost.FailureDomain()
+		}
+	}
+	return subnet(host.GetInternalIPv4Address(), cluster.Topology.SubnetMaskSize)
+}
+
+// SortHosts is used to order the hosts by the topology distribution strategy.
+// The hosts in the same segment are kept together, and the segments which can be reached from the
+// local machine without crossing a router come first, so the artifact transfers prefer the local L2 segment.
+// The original order is kept in each segment. The order alone doesn't bound the bandwidth of a segment, see Throttle.
+func SortHosts(cluster *kubekeyapiv1alpha2.ClusterSpec, hosts []connector.Host) []connector.Host {
+	switch cluster.Topology.DistributionStrategy {
+	case kubekeyapiv1alpha2.DistributionStrategySubnet, kubekeyapiv1alpha2.DistributionStrategyRack:
+	default:
+		return hosts
+	}
+
+	localNets := localNetworks()
+	segments := make([]*segment, 0)
+	index := make(map[string]*segment)
+	for _, host := range hosts {
+		key := SegmentKey(cluster, host)
+		s, ok := index[key]
+		if !ok {
+			s = &segment{key: key}
+			index[key] = s
+			segments = append(segments, s)
+		}
+		if !s.local && isLocal(localNets, host.GetInternalIPv4Address()) {
+			s.local = true
+		}
+		s.hosts = append(s.hosts, host)
+	}
+
+	sort.SliceStable(segments, func(i, j int) bool {
+		return segments[i].local && !segments[j].local
+	})
+
+	sorted := make([]connector.Host, 0, len(hosts))
+	for _, s := range segments {
+		logger.Log.Debugf("topology segment [%s] local: %v, hosts: %d", s.key, s.local, len(s.hosts))
+		sorted = append(sorted, s.hosts...)
+	}
+	return sorted
+}
+
+// Throttle returns the throttle of the artifact transfers by the topology distribution strategy, which bounds the
+// hosts of each segment receiving the artifacts at the same time, so the transfers share the link of a segment
+// instead of all its hosts saturating it at once. It's nil if the hosts aren't grouped.
+func Throttle(cluster *kubekeyapiv1alpha2.ClusterSpec) *task.Throttle {
+	switch cluster.Topology.DistributionStrategy {
+	case kubekeyapiv1alpha2.DistributionStrategySubnet, kubekeyapiv1alpha2.DistributionStrategyRack:
+	default:
+		return nil
+	}
+	limit := cluster.Topology.MaxTransfersPerSegment
+	if limit <= 0 {
+		limit = kubekeyapiv1alpha2.DefaultMaxTransfersPerSegment
+	}
+	return &task.Throttle{
+		Group: func(host connector.Host) string { return SegmentKey(cluster, host) },
+		Limit: limit,
+	}
+}
+
+func subnet(address string, maskSize int) string {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return address
+	}
+
+	bits := 32
+	if ip.To4() == nil {
+		bits = 128
+		maskSize = ipv6SegmentMaskSize
+	} else {
+		ip = ip.To4()
+	}
+	if maskSize <= 0 || maskSize > bits {
+		maskSize = bits
+	}
+
+	ipNet := net.IPNet{IP: ip.Mask(net.CIDRMask(maskSize, bits)), Mask: net.CIDRMask(maskSize, bits)}
+	return ipNet.String()
+}
+
+func localNetworks() []*net.IPNet {
+	nets := make([]*net.IPNet, 0)
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		logger.Log.Warningf("get local interface addresses failed: %v", err)
+		return nets
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() {
+			nets = append(nets, ipNet)
+		}
+	}
+	return nets
+}
+
+func isLocal(nets []*net.IPNet, address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateEtcdPlacement checks that the etcd cluster survives the loss of any single failure domain.
+// The check is skipped if any etcd host has no failure domain declared, or the members are spread across less
+// than three failure domains, in which case there is no placement keeping the quorum.
+func ValidateEtcdPlacement(hosts []connector.Host) error {
+	domains := make(map[string]int)
+	for _, host := range hosts {
+		kubeHost, ok := host.(*kubekeyapiv1alpha2.KubeHost)
+		if !ok || kubeHost.FailureDomain() == "" {
+			return nil
+		}
+		domains[kubeHost.FailureDomain()]++
+	}
+	if len(domains) < 3 {
+		return nil
+	}
+
+	quorum := len(hosts)/2 + 1
+	for domain, members := range domains {
+		if len(hosts)-members < quorum {
+			return fmt.Errorf("%d of %d etcd members are placed in the failure domain %s, the etcd cluster loses quorum if it fails", members, len(hosts), domain)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package topology
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/cache"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+)
+
+func TestSegmentKey(t *testing.T) {
+	newHost := func(address, rack string) *kubekeyapiv1alpha2.KubeHost {
+		base := connector.NewHost()
+		base.InternalAddress = address
+		return &kubekeyapiv1alpha2.KubeHost{BaseHost: base, Rack: rack}
+	}
+
+	tests := []struct {
+		name     string
+		topology kubekeyapiv1alpha2.Topology
+		host     *kubekeyapiv1alpha2.KubeHost
+		want     string
+	}{
+		{
+			name:     "subnet",
+			topology: kubekeyapiv1alpha2.Topology{DistributionStrategy: "subnet", SubnetMaskSize: 24},
+			host:     newHost("192.168.10.23", "rack-a"),
+			want:     "192.168.10.0/24",
+		},
+		{
+			name:     "subnet with custom mask size",
+			topology: kubekeyapiv1alpha2.Topology{DistributionStrategy: "subnet", SubnetMaskSize: 16},
+			host:     newHost("192.168.10.23,2022::3", ""),
+			want:     "192.168.0.0/16",
+		},
+		{
+			name:     "rack",
+			topology: kubekeyapiv1alpha2.Topology{DistributionStrategy: "rack", SubnetMaskSize: 24},
+			host:     newHost("192.168.10.23", "rack-a"),
+			want:     "rack-a",
+		},
+		{
+			name:     "rack with region and zone",
+			topology: kubekeyapiv1alpha2.Topology{DistributionStrategy: "rack", SubnetMaskSize: 24},
+			host: func() *kubekeyapiv1alpha2.KubeHost {
+				h := newHost("192.168.10.23", "rack-a")
+				h.Region, h.Zone = "region-a", "zone-b"
+				return h
+			}(),
+			want: "region-a/zone-b/rack-a",
+		},
+		{
+			name:     "rack fallback to subnet",
+			topology: kubekeyapiv1alpha2.Topology{DistributionStrategy: "rack", SubnetMaskSize: 24},
+			host:     newHost("10.0.1.5", ""),
+			want:     "10.0.1.0/24",
+		},
+		{
+			name:     "ipv6",
+			topology: kubekeyapiv1alpha2.Topology{DistributionStrategy: "subnet", SubnetMaskSize: 24},
+			host:     newHost("2022:0:0:1::3", ""),
+			want:     "2022:0:0:1::/64",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &kubekeyapiv1alpha2.ClusterSpec{Topology: tt.topology}
+			if got := SegmentKey(cluster, tt.host); got != tt.want {
+				t.Errorf("SegmentKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// transferAction records the max number of the hosts of each segment receiving the artifacts at once.
+type transferAction struct {
+	action.BaseAction
+	cluster *kubekeyapiv1alpha2.ClusterSpec
+
+	mu      sync.Mutex
+	running map[string]int
+	max     map[string]int
+}
+
+func (a *transferAction) Execute(runtime connector.Runtime) error {
+	segment := SegmentKey(a.cluster, runtime.RemoteHost())
+	a.mu.Lock()
+	a.running[segment]++
+	if a.running[segment] > a.max[segment] {
+		a.max[segment] = a.running[segment]
+	}
+	a.mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	a.mu.Lock()
+	a.running[segment]--
+	a.mu.Unlock()
+	return nil
+}
+
+func TestThrottle(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	var hosts []connector.Host
+	for _, address := range []string{"10.0.1.11", "10.0.1.12", "10.0.1.13", "10.0.1.14", "10.0.2.11", "10.0.2.12", "10.0.2.13"} {
+		host := connector.NewHost()
+		host.Name = address
+		host.InternalAddress = address
+		hosts = append(hosts, host)
+	}
+
+	tests := []struct {
+		name     string
+		topology kubekeyapiv1alpha2.Topology
+		want     map[string]int
+	}{
+		{
+			name:     "not grouped",
+			topology: kubekeyapiv1alpha2.Topology{DistributionStrategy: "none", SubnetMaskSize: 24, MaxTransfersPerSegment: 1},
+			want:     map[string]int{"10.0.1.0/24": 4, "10.0.2.0/24": 3},
+		},
+		{
+			name:     "a transfer per segment",
+			topology: kubekeyapiv1alpha2.Topology{DistributionStrategy: "subnet", SubnetMaskSize: 24, MaxTransfersPerSegment: 1},
+			want:     map[string]int{"10.0.1.0/24": 1, "10.0.2.0/24": 1},
+		},
+		{
+			name:     "default transfers per segment",
+			topology: kubekeyapiv1alpha2.Topology{DistributionStrategy: "subnet", SubnetMaskSize: 24},
+			want:     map[string]int{"10.0.1.0/24": 2, "10.0.2.0/24": 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &kubekeyapiv1alpha2.ClusterSpec{Topology: tt.topology}
+			runtime := connector.NewBaseRuntime("test", connector.NewFakeDialer(&connector.FakeFixtures{}), false, false)
+			a := &transferAction{cluster: cluster, running: map[string]int{}, max: map[string]int{}}
+			distribute := &task.RemoteTask{
+				Name:     "SyncBinaries",
+				Hosts:    SortHosts(cluster, hosts),
+				Action:   a,
+				Parallel: true,
+				Throttle: Throttle(cluster),
+			}
+			distribute.Init(&runtime, cache.NewCache(), cache.NewCache())
+			if res := distribute.Execute(); res.IsFailed() {
+				t.Fatalf("Execute() failed: %v", res.CombineErr())
+			}
+			for segment, want := range tt.want {
+				if a.max[segment] != want {
+					t.Errorf("max transfers to %s at once = %d, want %d", segment, a.max[segment], want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateEtcdPlacement(t *testing.T) {
+	newHosts := func(zones ...string) []connector.Host {
+		hosts := make([]connector.Host, 0, len(zones))
+		for _, zone := range zones {
+			hosts = append(hosts, &kubekeyapiv1alpha2.KubeHost{BaseHost: connector.NewHost(), Zone: zone})
+		}
+		return hosts
+	}
+
+	tests := []struct {
+		name    string
+		hosts   []connector.Host
+		wantErr bool
+	}{
+		{
+			name:  "no failure domain",
+			hosts: newHosts("", "", ""),
+		},
+		{
+			name:  "spread across three zones",
+			hosts: newHosts("zone-a", "zone-b", "zone-c"),
+		},
+		{
+			name:  "two zones",
+			hosts: newHosts("zone-a", "zone-a", "zone-b"),
+		},
+		{
+			name:    "quorum in one zone",
+			hosts:   newHosts("zone-a", "zone-a", "zone-a", "zone-b", "zone-c"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateEtcdPlacement(tt.hosts); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateEtcdPlacement() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -30,6 +30,7 @@ import (
 	certutil "k8s.io/client-go/util/cert"
 
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/fips"
 )
 
 const (
@@ -183,6 +184,9 @@ func LoadCertificateAuthority(pkiDir string, baseName string) (*x509.Certificate
 	if !caCert.IsCA {
 		return nil, nil, errors.Errorf("%s certificate is not a certificate authority", baseName)
 	}
+	if err := fips.CheckCertificate(caCert); err != nil {
+		return nil, nil, errors.Wrapf(err, "%s certificate authority", baseName)
+	}
 
 	return caCert, caKey, nil
 }
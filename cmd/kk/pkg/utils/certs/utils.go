@@ -30,6 +30,8 @@ import (
 	"github.com/pkg/errors"
 	certutil "k8s.io/client-go/util/cert"
 	"k8s.io/client-go/util/keyutil"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/fips"
 )
 
 // CertOrKeyExist returns a boolean whether the cert or the key exists
@@ -80,6 +82,9 @@ func TryLoadKeyFromDisk(pkiPath, name string) (crypto.Signer, error) {
 	default:
 		return nil, errors.Errorf("the private key file %s is neither in RSA nor ECDSA format", privateKeyPath)
 	}
+	if err := fips.CheckPublicKey(key.Public()); err != nil {
+		return nil, errors.Wrapf(err, "the private key file %s", privateKeyPath)
+	}
 
 	return key, nil
 }
@@ -18,10 +18,13 @@ package utils
 
 import (
 	"fmt"
+	"math/big"
+	"net"
 	"regexp"
 	"strings"
 	"text/template"
 
+	"github.com/Masterminds/sprig/v3"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
 
@@ -29,7 +32,17 @@ import (
 	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
 )
 
-var FuncMap = template.FuncMap{"toYaml": ToYAML, "indent": Indent}
+// FuncMap is the function library of the templates, it contains the sprig functions, e.g. default, ternary, b64enc, semverCompare,
+// and the functions of KubeKey.
+var FuncMap = funcMap()
+
+func funcMap() template.FuncMap {
+	f := sprig.TxtFuncMap()
+	f["toYaml"] = ToYAML
+	f["indent"] = Indent
+	f["ipMath"] = IPMath
+	return f
+}
 
 func ResetTmpDir(runtime connector.Runtime) error {
 	_, err := runtime.GetRunner().SudoCmd(fmt.Sprintf(
@@ -50,6 +63,28 @@ func ToYAML(v interface{}) string {
 	return strings.TrimSuffix(string(data), "\n")
 }
 
+// IPMath adds the offset to the ip address, e.g. {{ .InternalAddress | ipMath 10 }}.
+// An empty string is returned if the ip address is invalid.
+func IPMath(offset int, ip string) string {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return ""
+	}
+	if v4 := addr.To4(); v4 != nil {
+		addr = v4
+	}
+
+	n := new(big.Int).SetBytes(addr)
+	n.Add(n, big.NewInt(int64(offset)))
+	if n.Sign() < 0 || n.BitLen() > len(addr)*8 {
+		return ""
+	}
+	b := n.Bytes()
+	result := make(net.IP, len(addr))
+	copy(result[len(result)-len(b):], b)
+	return result.String()
+}
+
 func Indent(n int, text string) string {
 	startOfLine := regexp.MustCompile(`(?m)^`)
 	indentation := strings.Repeat(" ", n)
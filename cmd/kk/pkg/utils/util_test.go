@@ -0,0 +1,75 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package utils
+
+import (
+	"testing"
+	"text/template"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+func TestIPMath(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int
+		ip     string
+		want   string
+	}{
+		{name: "ipv4", offset: 10, ip: "10.233.0.1", want: "10.233.0.11"},
+		{name: "ipv4 carry", offset: 1, ip: "10.233.0.255", want: "10.233.1.0"},
+		{name: "ipv4 negative", offset: -1, ip: "10.233.1.0", want: "10.233.0.255"},
+		{name: "ipv4 overflow", offset: 1, ip: "255.255.255.255", want: ""},
+		{name: "ipv6", offset: 2, ip: "fd00::1", want: "fd00::3"},
+		{name: "invalid", offset: 1, ip: "node1", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IPMath(tt.offset, tt.ip); got != tt.want {
+				t.Errorf("IPMath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuncMap(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl string
+		data util.Data
+		want string
+	}{
+		{name: "default", tmpl: `{{ .Port | default 22 }}`, data: util.Data{}, want: "22"},
+		{name: "ternary", tmpl: `{{ ternary "yes" "no" .Enabled }}`, data: util.Data{"Enabled": true}, want: "yes"},
+		{name: "b64enc", tmpl: `{{ .Name | b64enc }}`, data: util.Data{"Name": "node1"}, want: "bm9kZTE="},
+		{name: "semverCompare", tmpl: `{{ semverCompare ">=1.24.0" .KubeVersion }}`, data: util.Data{"KubeVersion": "v1.26.0"}, want: "true"},
+		{name: "ipMath", tmpl: `{{ .InternalAddress | ipMath 1 }}`, data: util.Data{"InternalAddress": "192.168.0.1"}, want: "192.168.0.2"},
+		{name: "toYaml", tmpl: `{{ toYaml .Labels }}`, data: util.Data{"Labels": map[string]string{"disk": "ssd"}}, want: "disk: ssd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := template.Must(template.New(tt.name).Funcs(FuncMap).Parse(tt.tmpl))
+			got, err := util.Render(tmpl, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Render() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
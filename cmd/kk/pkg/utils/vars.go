@@ -0,0 +1,148 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package utils
+
+import (
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+)
+
+// releaseCacheKey and sudoNoPasswdCacheKey are the keys of the os release and whether the login user has NOPASSWD
+// sudo in the host cache, which are set by the GetOSData task. immutableOSCacheKey is the key of the name of the
+// immutable OS, which is set by the ImmutableOSCheck task. nvidiaGPUCacheKey is the key of whether the host has an
+// NVIDIA GPU, which is set by the DetectNvidiaGPU task. cpusCacheKey and memoryCacheKey are the keys of the number of
+// the processors and the total memory in bytes, and cgroupVersionCacheKey and systemdCacheKey are the keys of the
+// version of the cgroup hierarchy and whether systemd is the init, which are set by the GetOSData task. imageCacheKey
+// is the key of the node image baked by kk prepare-image, which is set by the GetOSData task too.
+const (
+	releaseCacheKey       = "release"
+	sudoNoPasswdCacheKey  = "sudoNoPasswd"
+	immutableOSCacheKey   = "immutableOS"
+	nvidiaGPUCacheKey     = "nvidiaGPU"
+	cpusCacheKey          = "cpus"
+	memoryCacheKey        = "memory"
+	cgroupVersionCacheKey = "cgroupVersion"
+	systemdCacheKey       = "systemd"
+	imageCacheKey         = "image"
+)
+
+// HostVars returns the variables of the remote host, which are used to render the task args and file templates.
+// The variables are merged in order of precedence, the latter overrides the former with the same key:
+//  1. cluster vars: Cluster (the cluster spec), ClusterName, KubeVersion, ContainerManager.
+//  2. group vars: Groups (the roles of the host), GroupHosts (the host names of each role), Hosts (the Address,
+//     InternalAddress, InternalIPv6Address and Aliases of each host by its name).
+//  3. host vars: Name, Aliases, Address (the management address), InternalAddress and InternalIPv6Address (the
+//     data-plane addresses), Arch, Region, Zone, Rack, and the labels of the host.
+//  4. facts: gathered from the host at runtime, e.g. OS (the os release), SudoNoPasswd, ImmutableOS, NvidiaGPU, CPUs,
+//     Memory (the total memory in bytes), CgroupVersion (1 or 2), Systemd (whether systemd is the init) and Image (the
+//     node image baked by kk prepare-image).
+func HostVars(runtime connector.Runtime, cluster *kubekeyapiv1alpha2.ClusterSpec) util.Data {
+	vars := util.Data{}
+
+	vars["Cluster"] = cluster
+	vars["ClusterName"] = runtime.GetObjName()
+	vars["KubeVersion"] = cluster.Kubernetes.Version
+	vars["ContainerManager"] = cluster.Kubernetes.ContainerManager
+
+	host := runtime.RemoteHost()
+	groupHosts := make(map[string][]string)
+	hosts := make(map[string]util.Data)
+	for _, h := range runtime.GetAllHosts() {
+		for _, role := range h.GetRoles() {
+			groupHosts[role] = append(groupHosts[role], h.GetName())
+		}
+		hosts[h.GetName()] = addressVars(h)
+	}
+	vars["Groups"] = host.GetRoles()
+	vars["GroupHosts"] = groupHosts
+	vars["Hosts"] = hosts
+
+	vars["Name"] = host.GetName()
+	for k, v := range addressVars(host) {
+		vars[k] = v
+	}
+	vars["Arch"] = host.GetArch()
+	if kubeHost, ok := host.(*kubekeyapiv1alpha2.KubeHost); ok {
+		vars["Region"] = kubeHost.Region
+		vars["Zone"] = kubeHost.Zone
+		vars["Rack"] = kubeHost.Rack
+		for k, v := range kubeHost.Labels {
+			vars[k] = v
+		}
+	}
+
+	for k, v := range HostFacts(host) {
+		vars[k] = v
+	}
+	return vars
+}
+
+// HostFacts returns the facts gathered from the host at runtime: OS, SudoNoPasswd, ImmutableOS, NvidiaGPU, CPUs,
+// Memory, CgroupVersion, Systemd and Image. The facts which aren't gathered by the pipeline are absent.
+func HostFacts(host connector.Host) util.Data {
+	facts := util.Data{}
+	if release, ok := host.GetCache().Get(releaseCacheKey); ok {
+		facts["OS"] = release
+	}
+	if noPasswd, ok := host.GetCache().Get(sudoNoPasswdCacheKey); ok {
+		facts["SudoNoPasswd"] = noPasswd
+	}
+	if immutableOS, ok := host.GetCache().Get(immutableOSCacheKey); ok {
+		facts["ImmutableOS"] = immutableOS
+	}
+	if gpu, ok := host.GetCache().Get(nvidiaGPUCacheKey); ok {
+		facts["NvidiaGPU"] = gpu
+	}
+	if cpus, ok := host.GetCache().Get(cpusCacheKey); ok {
+		facts["CPUs"] = cpus
+	}
+	if memory, ok := host.GetCache().Get(memoryCacheKey); ok {
+		facts["Memory"] = memory
+	}
+	if version, ok := host.GetCache().Get(cgroupVersionCacheKey); ok {
+		facts["CgroupVersion"] = version
+	}
+	if systemd, ok := host.GetCache().Get(systemdCacheKey); ok {
+		facts["Systemd"] = systemd
+	}
+	if image, ok := host.GetCache().Get(imageCacheKey); ok {
+		facts["Image"] = image
+	}
+	return facts
+}
+
+// addressVars returns the names and the addresses of the host.
+func addressVars(host connector.Host) util.Data {
+	vars := util.Data{
+		"Address":             host.GetAddress(),
+		"InternalAddress":     host.GetInternalIPv4Address(),
+		"InternalIPv6Address": host.GetInternalIPv6Address(),
+		"Aliases":             []string{},
+	}
+	if kubeHost, ok := host.(*kubekeyapiv1alpha2.KubeHost); ok && kubeHost.Aliases != nil {
+		vars["Aliases"] = kubeHost.Aliases
+	}
+	return vars
+}
+
+// HostVarsFunc returns the Vars of a task, which evaluates the when expression of the task with HostVars.
+func HostVarsFunc(cluster *kubekeyapiv1alpha2.ClusterSpec) func(runtime connector.Runtime) map[string]interface{} {
+	return func(runtime connector.Runtime) map[string]interface{} {
+		return HostVars(runtime, cluster)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package verification
+
+import (
+	"path/filepath"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/action"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/task"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/images"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/verification/templates"
+)
+
+// VerificationModule verifies the created cluster by the smoke tests and the conformance tests of the verification
+// from the first control-plane node. A failed test doesn't stop the others, the result of each test is reported at
+// the end.
+type VerificationModule struct {
+	common.KubeModule
+	Skip bool
+}
+
+func (v *VerificationModule) IsSkip() bool {
+	return v.Skip
+}
+
+func (v *VerificationModule) Init() {
+	v.Name = "VerificationModule"
+	v.Tags = []string{"verification"}
+	v.Desc = "Verify the cluster by the smoke and the conformance tests"
+
+	verification := v.KubeConf.Cluster.Verification
+	generate := &task.RemoteTask{
+		Name:    "GenerateSmokeTests",
+		Desc:    "Generate the smoke tests manifest",
+		Hosts:   v.Runtime.GetHostsByRole(common.Master),
+		Prepare: new(common.OnlyFirstMaster),
+		Action: &action.Template{
+			Template: templates.SmokeTests,
+			Dst:      filepath.Join(common.KubeAddonsDir, templates.SmokeTests.Name()),
+			Data: util.Data{
+				"Namespace":    Namespace,
+				"Image":        images.GetImage(v.Runtime, v.KubeConf, "busybox").ImageName(),
+				"PVC":          verification.Runs(kubekeyapiv1alpha2.SmokeTestPVC),
+				"LoadBalancer": verification.Runs(kubekeyapiv1alpha2.SmokeTestLoadBalancer),
+				"Ingress":      verification.Runs(kubekeyapiv1alpha2.SmokeTestIngress),
+				"IngressHost":  ingressHost,
+			},
+		},
+	}
+
+	smokeTests := &task.RemoteTask{
+		Name:    "RunSmokeTests",
+		Desc:    "Run the smoke tests of the cluster",
+		Hosts:   v.Runtime.GetHostsByRole(common.Master),
+		Prepare: new(common.OnlyFirstMaster),
+		Action:  new(RunSmokeTests),
+	}
+
+	conformance := &task.RemoteTask{
+		Name:    "RunConformance",
+		Desc:    "Run the conformance tests of sonobuoy",
+		Hosts:   v.Runtime.GetHostsByRole(common.Master),
+		Prepare: new(common.OnlyFirstMaster),
+		Action:  new(RunConformance),
+	}
+
+	report := &task.LocalTask{
+		Name:        "ReportVerification",
+		Desc:        "Report the results of the tests",
+		Action:      new(ReportVerification),
+		RunInDryRun: true,
+	}
+
+	if len(verification.SmokeTests) > 0 {
+		v.Tasks = append(v.Tasks, generate, smokeTests)
+	}
+	if verification.Conformance.Enabled {
+		v.Tasks = append(v.Tasks, conformance)
+	}
+	v.Tasks = append(v.Tasks, report)
+}
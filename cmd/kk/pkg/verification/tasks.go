@@ -0,0 +1,461 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package verification
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+
+	kubekeyapiv1alpha2 "github.com/kubesphere/kubekey/v3/cmd/kk/apis/kubekey/v1alpha2"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/common"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/util"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/verification/templates"
+)
+
+const (
+	resultsCacheKey = "verificationResults"
+	// ReportFile is the report of the verification in the work dir of the cluster.
+	ReportFile = "verification.json"
+	// ConformanceResultsFile is the results tarball of sonobuoy in the work dir of the cluster.
+	ConformanceResultsFile = "sonobuoy-results.tar.gz"
+	// ConformanceTest is the name of the conformance tests in the report.
+	ConformanceTest = "conformance"
+
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+
+	// Namespace is the namespace of the smoke tests, it is removed after the tests.
+	Namespace   = "kubekey-verification"
+	ingressHost = "kubekey-verification.local"
+	kubectl     = "/usr/local/bin/kubectl"
+	sonobuoy    = "/usr/local/bin/sonobuoy"
+)
+
+// pollInterval is the interval between the checks of a smoke test until it passes.
+var pollInterval = 5 * time.Second
+
+// Result is the result of a test of the verification.
+type Result struct {
+	Test     string  `json:"test"`
+	Status   string  `json:"status"`
+	Duration float64 `json:"duration"`
+	Detail   string  `json:"detail,omitempty"`
+}
+
+// record records the result of the test on the node for the report.
+func record(runtime connector.Runtime, test, status string, start time.Time, detail string) {
+	host := runtime.RemoteHost()
+	var results []Result
+	if v, ok := host.GetCache().Get(resultsCacheKey); ok {
+		results = v.([]Result)
+	}
+	results = append(results, Result{Test: test, Status: status, Duration: time.Since(start).Round(time.Second).Seconds(), Detail: detail})
+	host.GetCache().Set(resultsCacheKey, results)
+}
+
+// poll runs the check until it passes, and returns its last error if it doesn't in the timeout.
+func poll(timeout time.Duration, check func() error) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := check()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Wrapf(err, "timed out after %s", timeout)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+func kubectlCmd(runtime connector.Runtime, format string, a ...interface{}) (string, error) {
+	out, err := runtime.GetRunner().SudoCmd(kubectl+" "+fmt.Sprintf(format, a...), false)
+	out = strings.TrimSpace(out)
+	if err != nil && out != "" {
+		return "", errors.Wrap(errors.WithStack(err), out)
+	}
+	return out, errors.WithStack(err)
+}
+
+// RunSmokeTests deploys the servers of the smoke tests and runs the tests of the verification one by one, so a failed
+// test doesn't stop the others. The namespace of the tests is always removed.
+type RunSmokeTests struct {
+	common.KubeAction
+}
+
+func (r *RunSmokeTests) Execute(runtime connector.Runtime) error {
+	verification := r.KubeConf.Cluster.Verification
+	start := time.Now()
+	manifest := filepath.Join(common.KubeAddonsDir, templates.SmokeTests.Name())
+	if _, err := kubectlCmd(runtime, "apply -f %s", manifest); err != nil {
+		return errors.Wrap(err, "deploy the smoke tests failed")
+	}
+	defer func() {
+		if _, err := kubectlCmd(runtime, "delete namespace %s --ignore-not-found --wait=false", Namespace); err != nil {
+			logger.Log.Warnf("remove the namespace %s failed: %v", Namespace, err)
+		}
+	}()
+
+	if connector.IsDryRun(runtime.GetConnector()) {
+		for _, test := range verification.SmokeTests {
+			record(runtime, test, StatusSkipped, start, "the tests aren't run in the dry run")
+		}
+		return nil
+	}
+
+	timeout := verification.TestTimeout()
+	var ready error
+	if _, err := kubectlCmd(runtime, "-n %s rollout status daemonset/server --timeout=%s", Namespace, timeout); err != nil {
+		ready = errors.Wrap(err, "the servers of the smoke tests aren't ready")
+	}
+	tests := map[string]func(connector.Runtime, time.Duration) error{
+		kubekeyapiv1alpha2.SmokeTestDNS:          r.testDNS,
+		kubekeyapiv1alpha2.SmokeTestPodNetwork:   testPodNetwork,
+		kubekeyapiv1alpha2.SmokeTestService:      r.testService,
+		kubekeyapiv1alpha2.SmokeTestPVC:          testPVC,
+		kubekeyapiv1alpha2.SmokeTestLoadBalancer: testLoadBalancer,
+		kubekeyapiv1alpha2.SmokeTestIngress:      testIngress,
+	}
+	for _, test := range kubekeyapiv1alpha2.SmokeTests {
+		if !verification.Runs(test) {
+			continue
+		}
+		start := time.Now()
+		err := ready
+		// the PVC doesn't depend on the servers
+		if err == nil || test == kubekeyapiv1alpha2.SmokeTestPVC {
+			err = tests[test](runtime, timeout)
+		}
+		if err != nil {
+			logger.Log.Warnf("the smoke test %s failed: %v", test, err)
+			record(runtime, test, StatusFailed, start, err.Error())
+			continue
+		}
+		logger.Log.Messagef(runtime.RemoteHost().GetName(), "the smoke test %s passed", test)
+		record(runtime, test, StatusPassed, start, "")
+	}
+	return nil
+}
+
+// server is a server pod of the smoke tests.
+type server struct {
+	Name string
+	Node string
+	IP   string
+}
+
+// servers returns the server pods of the smoke tests, one on each node.
+func servers(runtime connector.Runtime) ([]server, error) {
+	out, err := kubectlCmd(runtime, `-n %s get pods -l app=server -o jsonpath='{range .items[*]}{.metadata.name} {.spec.nodeName} {.status.podIP}{"\n"}{end}'`, Namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "get the server pods failed")
+	}
+	return parseServers(out), nil
+}
+
+func parseServers(out string) []server {
+	var pods []server
+	for _, line := range strings.Split(out, "\n") {
+		if fields := strings.Fields(line); len(fields) == 3 {
+			pods = append(pods, server{Name: fields[0], Node: fields[1], IP: fields[2]})
+		}
+	}
+	return pods
+}
+
+// get requests the url from the server pod and returns the name of the pod which answered.
+func get(runtime connector.Runtime, from server, url string) (string, error) {
+	return kubectlCmd(runtime, "-n %s exec %s -- wget -qO- -T 5 %s", Namespace, from.Name, url)
+}
+
+// testDNS resolves the kubernetes Service from a server pod.
+func (r *RunSmokeTests) testDNS(runtime connector.Runtime, timeout time.Duration) error {
+	pods, err := servers(runtime)
+	if err != nil || len(pods) == 0 {
+		return errors.Errorf("no server pod: %v", err)
+	}
+	name := fmt.Sprintf("kubernetes.default.svc.%s", r.KubeConf.Cluster.Kubernetes.DNSDomain)
+	return poll(timeout, func() error {
+		if _, err := kubectlCmd(runtime, "-n %s exec %s -- nslookup %s", Namespace, pods[0].Name, name); err != nil {
+			return errors.Wrapf(err, "resolve %s from %s on %s failed", name, pods[0].Name, pods[0].Node)
+		}
+		return nil
+	})
+}
+
+// testPodNetwork requests each server pod from the server pod of the previous node, so each node sends to and
+// receives from another node.
+func testPodNetwork(runtime connector.Runtime, timeout time.Duration) error {
+	pods, err := servers(runtime)
+	if err != nil || len(pods) == 0 {
+		return errors.Errorf("no server pod: %v", err)
+	}
+	for i, to := range pods {
+		from := pods[(i+len(pods)-1)%len(pods)]
+		url := fmt.Sprintf("http://%s/", net.JoinHostPort(to.IP, "8080"))
+		if err := poll(timeout, func() error {
+			answer, err := get(runtime, from, url)
+			if err == nil && answer != to.Name {
+				err = errors.Errorf("answered by %s", answer)
+			}
+			return err
+		}); err != nil {
+			return errors.Wrapf(err, "pod %s on %s can't reach pod %s on %s", from.Name, from.Node, to.Name, to.Node)
+		}
+	}
+	return nil
+}
+
+// testService requests the Service of the servers by its name from a server pod.
+func (r *RunSmokeTests) testService(runtime connector.Runtime, timeout time.Duration) error {
+	pods, err := servers(runtime)
+	if err != nil || len(pods) == 0 {
+		return errors.Errorf("no server pod: %v", err)
+	}
+	url := fmt.Sprintf("http://server.%s.svc.%s:8080/", Namespace, r.KubeConf.Cluster.Kubernetes.DNSDomain)
+	return poll(timeout, func() error {
+		if _, err := get(runtime, pods[0], url); err != nil {
+			return errors.Wrapf(err, "request %s from %s on %s failed", url, pods[0].Name, pods[0].Node)
+		}
+		return nil
+	})
+}
+
+// testPVC waits for the pod writing to the PVC of the default StorageClass to succeed.
+func testPVC(runtime connector.Runtime, timeout time.Duration) error {
+	err := poll(timeout, func() error {
+		phase, err := kubectlCmd(runtime, "-n %s get pod pvc -o jsonpath='{.status.phase}'", Namespace)
+		if err != nil {
+			return err
+		}
+		switch phase {
+		case "Succeeded":
+			return nil
+		case "Failed":
+			return errors.New("the pod of the PVC failed")
+		}
+		return errors.Errorf("the pod of the PVC is %s", phase)
+	})
+	if err != nil {
+		describe, _ := kubectlCmd(runtime, "-n %s describe pvc pvc", Namespace)
+		return errors.Wrapf(err, "the PVC of the default StorageClass isn't writable\n%s", describe)
+	}
+	return nil
+}
+
+// testLoadBalancer waits for the address of the LoadBalancer Service of the servers and requests it from the node.
+func testLoadBalancer(runtime connector.Runtime, timeout time.Duration) error {
+	var address string
+	if err := poll(timeout, func() error {
+		out, err := kubectlCmd(runtime, "-n %s get service server-lb -o jsonpath='{.status.loadBalancer.ingress[0].ip}{.status.loadBalancer.ingress[0].hostname}'", Namespace)
+		if err == nil && out == "" {
+			err = errors.New("the LoadBalancer Service has no address")
+		}
+		address = out
+		return err
+	}); err != nil {
+		return err
+	}
+	url := fmt.Sprintf("http://%s/", net.JoinHostPort(address, "8080"))
+	return poll(timeout, func() error {
+		if _, err := runtime.GetRunner().Cmd(fmt.Sprintf("curl -fs --max-time 5 %s", url), false); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "request %s failed", url)
+		}
+		return nil
+	})
+}
+
+// testIngress waits for the address of the Ingress of the servers and requests its host from the node.
+func testIngress(runtime connector.Runtime, timeout time.Duration) error {
+	var address string
+	if err := poll(timeout, func() error {
+		out, err := kubectlCmd(runtime, "-n %s get ingress server -o jsonpath='{.status.loadBalancer.ingress[0].ip}{.status.loadBalancer.ingress[0].hostname}'", Namespace)
+		if err == nil && out == "" {
+			err = errors.New("the Ingress has no address, is an ingress controller installed?")
+		}
+		address = out
+		return err
+	}); err != nil {
+		return err
+	}
+	url := fmt.Sprintf("http://%s/", address)
+	if net.ParseIP(address) != nil {
+		url = fmt.Sprintf("http://%s/", net.JoinHostPort(address, "80"))
+	}
+	return poll(timeout, func() error {
+		if _, err := runtime.GetRunner().Cmd(fmt.Sprintf("curl -fs --max-time 5 -H 'Host: %s' %s", ingressHost, url), false); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "request %s of %s failed", ingressHost, url)
+		}
+		return nil
+	})
+}
+
+// RunConformance runs the conformance tests of sonobuoy, which is downloaded to the node unless it's installed, and
+// fetches the results tarball to the work dir of the cluster.
+type RunConformance struct {
+	common.KubeAction
+}
+
+func (r *RunConformance) Execute(runtime connector.Runtime) error {
+	conformance := r.KubeConf.Cluster.Verification.Conformance
+	start := time.Now()
+	version := conformance.SonobuoyVersion()
+	install := fmt.Sprintf("test -x %[1]s && %[1]s version --short | grep -q %[2]s || "+
+		"curl -fsSL https://github.com/vmware-tanzu/sonobuoy/releases/download/%[2]s/sonobuoy_%[3]s_linux_%[4]s.tar.gz | tar -xz -C %[5]s sonobuoy",
+		sonobuoy, version, strings.TrimPrefix(version, "v"), runtime.RemoteHost().GetArch(), path.Dir(sonobuoy))
+	if _, err := runtime.GetRunner().SudoCmd(install, false); err != nil {
+		record(runtime, ConformanceTest, StatusFailed, start, fmt.Sprintf("install sonobuoy %s failed: %v", version, err))
+		return nil
+	}
+	if connector.IsDryRun(runtime.GetConnector()) {
+		record(runtime, ConformanceTest, StatusSkipped, start, "the tests aren't run in the dry run")
+		return nil
+	}
+
+	defer func() {
+		if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("%s delete --wait", sonobuoy), false); err != nil {
+			logger.Log.Warnf("remove sonobuoy from the cluster failed: %v", err)
+		}
+	}()
+	minutes := int(conformance.TestTimeout().Minutes())
+	logger.Log.Messagef(runtime.RemoteHost().GetName(), "Run the %s conformance tests of sonobuoy, it may take up to %d minutes",
+		conformance.ModeName(), minutes)
+	run := fmt.Sprintf("%[1]s delete --wait; %[1]s run --mode %[2]s --wait=%[3]d", sonobuoy, conformance.ModeName(), minutes)
+	if out, err := runtime.GetRunner().SudoCmd(run, false); err != nil {
+		record(runtime, ConformanceTest, StatusFailed, start, fmt.Sprintf("run sonobuoy failed: %v: %s", err, out))
+		return nil
+	}
+
+	dir := path.Join(common.TmpDir, "sonobuoy")
+	tarball, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("rm -rf %[2]s && mkdir -p %[2]s && %[1]s retrieve %[2]s && chmod -R a+rX %[2]s", sonobuoy, dir), false)
+	if err != nil {
+		record(runtime, ConformanceTest, StatusFailed, start, fmt.Sprintf("retrieve the results of sonobuoy failed: %v", err))
+		return nil
+	}
+	tarball = strings.TrimSpace(tarball)
+	results, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("%s results %s", sonobuoy, tarball), false)
+	if err != nil {
+		record(runtime, ConformanceTest, StatusFailed, start, fmt.Sprintf("read the results of sonobuoy failed: %v", err))
+		return nil
+	}
+	local := filepath.Join(runtime.GetClusterWorkDir(), ConformanceResultsFile)
+	if err := runtime.GetRunner().Fetch(local, tarball); err != nil {
+		logger.Log.Warnf("fetch the results of sonobuoy failed: %v", err)
+	} else {
+		logger.Log.Infof("The results of sonobuoy are written to %s", local)
+	}
+	status, detail := conformanceStatus(results)
+	record(runtime, ConformanceTest, status, start, detail)
+	return nil
+}
+
+// conformanceStatus returns the status of the conformance tests from the output of sonobuoy results, they failed if
+// any plugin failed, and the detail is the summary of the tests.
+func conformanceStatus(results string) (string, string) {
+	status := StatusPassed
+	var summary []string
+	for _, line := range strings.Split(results, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "Status:"):
+			if strings.TrimSpace(strings.TrimPrefix(line, "Status:")) != StatusPassed {
+				status = StatusFailed
+			}
+		case strings.HasPrefix(line, "Total:"), strings.HasPrefix(line, "Passed:"), strings.HasPrefix(line, "Failed:"),
+			strings.HasPrefix(line, "Skipped:"):
+			summary = append(summary, line)
+		}
+	}
+	if len(summary) == 0 && status == StatusPassed {
+		return StatusFailed, "no result of sonobuoy"
+	}
+	return status, strings.Join(summary, ", ")
+}
+
+// ReportVerification prints the result of each test, and writes it to the report in the work dir of the cluster. It
+// fails if any test failed, unless the failures are ignored.
+type ReportVerification struct {
+	common.KubeAction
+}
+
+func (r *ReportVerification) Execute(runtime connector.Runtime) error {
+	var results []Result
+	for _, host := range runtime.GetAllHosts() {
+		if v, ok := host.GetCache().Get(resultsCacheKey); ok {
+			results = append(results, v.([]Result)...)
+		}
+	}
+
+	content, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return errors.Wrap(errors.WithStack(err), "encode the verification report failed")
+	}
+	reportFile := filepath.Join(runtime.GetClusterWorkDir(), ReportFile)
+	if err := util.WriteFile(reportFile, content); err != nil {
+		return errors.Wrap(errors.WithStack(err), "write the verification report failed")
+	}
+	logger.Log.Infof("The verification report is written to %s", reportFile)
+	if err := Print(os.Stdout, results); err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, result := range results {
+		if result.Status == StatusFailed {
+			failed = append(failed, result.Test)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	if r.KubeConf.Cluster.Verification.IgnoreFailures {
+		logger.Log.Warnf("the tests %s failed, the failures are ignored", strings.Join(failed, ", "))
+		return nil
+	}
+	return errors.Errorf("the verification of the cluster failed, the tests %s failed, see %s", strings.Join(failed, ", "), reportFile)
+}
+
+// Print prints the results of the tests as a table.
+func Print(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 10, 4, 3, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "TEST\tSTATUS\tDURATION\tDETAIL")
+	for _, result := range results {
+		detail := result.Detail
+		if detail == "" {
+			detail = "<none>"
+		}
+		// the details of the failures may have the output of the commands, only the first line is printed
+		if i := strings.IndexByte(detail, '\n'); i >= 0 {
+			detail = detail[:i] + " ..."
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", result.Test, result.Status, time.Duration(result.Duration)*time.Second, detail)
+	}
+	return tw.Flush()
+}
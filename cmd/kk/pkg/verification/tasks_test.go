@@ -0,0 +1,141 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package verification
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/connector"
+	"github.com/kubesphere/kubekey/v3/cmd/kk/pkg/core/logger"
+)
+
+func TestParseServers(t *testing.T) {
+	got := parseServers("server-a node1 10.233.64.2\nserver-b node2 fd00::2\nserver-c node3\n")
+	want := []server{{Name: "server-a", Node: "node1", IP: "10.233.64.2"}, {Name: "server-b", Node: "node2", IP: "fd00::2"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseServers() = %v, want %v", got, want)
+	}
+}
+
+func TestPodNetwork(t *testing.T) {
+	logger.Log = logger.NewLogger(t.TempDir(), false)
+	pollInterval = 0
+
+	tests := []struct {
+		name     string
+		commands []connector.FakeCommand
+		want     []string
+		wantErr  string
+	}{
+		{
+			name: "reachable",
+			commands: []connector.FakeCommand{
+				{Match: "get pods -l app=server", Stdout: "server-a node1 10.233.64.2\nserver-b node2 fd00::2\n"},
+				{Match: "exec server-a", Stdout: "server-b"},
+				{Match: "exec server-b", Stdout: "server-a"},
+			},
+			want: []string{"exec server-b -- wget -qO- -T 5 http://10.233.64.2:8080/", "exec server-a -- wget -qO- -T 5 http://[fd00::2]:8080/"},
+		},
+		{
+			name: "unreachable",
+			commands: []connector.FakeCommand{
+				{Match: "get pods -l app=server", Stdout: "server-a node1 10.233.64.2\nserver-b node2 10.233.65.2\n"},
+				{Match: "exec server-b", ExitCode: 1, Stdout: "wget: download timed out"},
+			},
+			wantErr: "pod server-b on node2 can't reach pod server-a on node1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialer := connector.NewFakeDialer(&connector.FakeFixtures{Commands: tt.commands})
+			host := connector.NewHost()
+			host.Name = "node1"
+			conn, err := dialer.Connect(host)
+			if err != nil {
+				t.Fatal(err)
+			}
+			runtime := &connector.BaseRuntime{}
+			runtime.SetConnector(dialer)
+			runtime.SetRunner(&connector.Runner{Conn: conn, Host: host, Ctx: context.Background()})
+
+			err = testPodNetwork(runtime, 0)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("testPodNetwork() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("testPodNetwork() error = %v, want %q", err, tt.wantErr)
+			}
+			dialer.AssertCommands(t, "node1", tt.want...)
+		})
+	}
+}
+
+func TestConformanceStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		results    string
+		wantStatus string
+		wantDetail string
+	}{
+		{
+			name:       "passed",
+			results:    "Plugin: e2e\nStatus: passed\nTotal: 7213\nPassed: 1\nFailed: 0\nSkipped: 7212\n",
+			wantStatus: StatusPassed,
+			wantDetail: "Total: 7213, Passed: 1, Failed: 0, Skipped: 7212",
+		},
+		{
+			name:       "failed",
+			results:    "Plugin: e2e\nStatus: failed\nTotal: 7213\nPassed: 380\nFailed: 2\nSkipped: 6831\n\nPlugin: systemd-logs\nStatus: passed\n",
+			wantStatus: StatusFailed,
+			wantDetail: "Total: 7213, Passed: 380, Failed: 2, Skipped: 6831",
+		},
+		{
+			name:       "no result",
+			wantStatus: StatusFailed,
+			wantDetail: "no result of sonobuoy",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, detail := conformanceStatus(tt.results)
+			if status != tt.wantStatus || detail != tt.wantDetail {
+				t.Errorf("conformanceStatus() = %q, %q, want %q, %q", status, detail, tt.wantStatus, tt.wantDetail)
+			}
+		})
+	}
+}
+
+func TestPrint(t *testing.T) {
+	var buf bytes.Buffer
+	results := []Result{
+		{Test: "dns", Status: StatusPassed, Duration: 3},
+		{Test: "pvc", Status: StatusFailed, Duration: 300, Detail: "the PVC of the default StorageClass isn't writable\nEvents: <none>"},
+	}
+	if err := Print(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	want := "TEST      STATUS    DURATION   DETAIL\n" +
+		"dns       passed    3s         <none>\n" +
+		"pvc       failed    5m0s       the PVC of the default StorageClass isn't writable ...\n"
+	if buf.String() != want {
+		t.Errorf("Print() = \n%s, want \n%s", buf.String(), want)
+	}
+}
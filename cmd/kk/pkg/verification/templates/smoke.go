@@ -0,0 +1,146 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package templates
+
+import (
+	"text/template"
+
+	"github.com/lithammer/dedent"
+)
+
+// SmokeTests defines the template of the namespace of the smoke tests: the DaemonSet of the servers answering their
+// pod names on each node, the Service of the servers, and the PVC, the LoadBalancer Service and the Ingress of the
+// smoke tests which are run.
+var SmokeTests = template.Must(template.New("verification.yaml").Parse(
+	dedent.Dedent(`---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: {{ .Namespace }}
+
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: server
+  namespace: {{ .Namespace }}
+spec:
+  selector:
+    matchLabels:
+      app: server
+  template:
+    metadata:
+      labels:
+        app: server
+    spec:
+      tolerations:
+        - operator: Exists
+      containers:
+        - name: server
+          image: {{ .Image }}
+          imagePullPolicy: IfNotPresent
+          command: ["sh", "-c", "mkdir -p /www && hostname > /www/index.html && exec httpd -f -p 8080 -h /www"]
+          ports:
+            - containerPort: 8080
+
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: server
+  namespace: {{ .Namespace }}
+spec:
+  selector:
+    app: server
+  ports:
+    - port: 8080
+      targetPort: 8080
+{{- if .LoadBalancer }}
+
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: server-lb
+  namespace: {{ .Namespace }}
+spec:
+  type: LoadBalancer
+  selector:
+    app: server
+  ports:
+    - port: 8080
+      targetPort: 8080
+{{- end }}
+{{- if .Ingress }}
+
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: server
+  namespace: {{ .Namespace }}
+spec:
+  rules:
+    - host: {{ .IngressHost }}
+      http:
+        paths:
+          - path: /
+            pathType: Prefix
+            backend:
+              service:
+                name: server
+                port:
+                  number: 8080
+{{- end }}
+{{- if .PVC }}
+
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: pvc
+  namespace: {{ .Namespace }}
+spec:
+  accessModes:
+    - ReadWriteOnce
+  resources:
+    requests:
+      storage: 64Mi
+
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pvc
+  namespace: {{ .Namespace }}
+spec:
+  restartPolicy: Never
+  containers:
+    - name: pvc
+      image: {{ .Image }}
+      imagePullPolicy: IfNotPresent
+      command: ["sh", "-c", "echo kubekey > /data/smoke-test && grep -q kubekey /data/smoke-test"]
+      volumeMounts:
+        - name: data
+          mountPath: /data
+  volumes:
+    - name: data
+      persistentVolumeClaim:
+        claimName: pvc
+{{- end }}
+
+    `)))
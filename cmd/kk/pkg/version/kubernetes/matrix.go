@@ -0,0 +1,352 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	versionutil "k8s.io/apimachinery/pkg/util/version"
+	"sigs.k8s.io/yaml"
+)
+
+// MatrixEnv is the env of the file overriding the embedded version matrix, the same as the flag --version-matrix.
+const MatrixEnv = "KUBEKEY_VERSION_MATRIX"
+
+//go:embed matrix.yaml
+var embeddedMatrix []byte
+
+// Component is the version of a component installed with a Kubernetes minor version.
+type Component struct {
+	// Version is the version installed by default.
+	Version string `json:"version,omitempty"`
+	// MinVersion and MaxVersion are the inclusive bounds of the versions compatible with the Kubernetes minor version,
+	// an empty bound is unlimited.
+	MinVersion string `json:"minVersion,omitempty"`
+	MaxVersion string `json:"maxVersion,omitempty"`
+}
+
+// MatrixEntry is the versions of the components of a Kubernetes minor version.
+type MatrixEntry struct {
+	// Kubernetes is the Kubernetes minor version, e.g. v1.29.
+	Kubernetes string    `json:"kubernetes"`
+	Etcd       Component `json:"etcd,omitempty"`
+	Containerd Component `json:"containerd,omitempty"`
+	Runc       Component `json:"runc,omitempty"`
+	CNI        Component `json:"cni,omitempty"`
+	Pause      Component `json:"pause,omitempty"`
+	CoreDNS    Component `json:"coredns,omitempty"`
+}
+
+// components returns the components of the entry with their names.
+func (e *MatrixEntry) components() map[string]*Component {
+	return map[string]*Component{
+		"etcd":       &e.Etcd,
+		"containerd": &e.Containerd,
+		"runc":       &e.Runc,
+		"cni":        &e.CNI,
+		"pause":      &e.Pause,
+		"coredns":    &e.CoreDNS,
+	}
+}
+
+// Components are the versions of the components of a Kubernetes version.
+type Components struct {
+	Etcd       string
+	Containerd string
+	Runc       string
+	CNI        string
+	Pause      string
+	CoreDNS    string
+}
+
+func (c Components) versions() map[string]string {
+	return map[string]string{
+		"etcd":       c.Etcd,
+		"containerd": c.Containerd,
+		"runc":       c.Runc,
+		"cni":        c.CNI,
+		"pause":      c.Pause,
+		"coredns":    c.CoreDNS,
+	}
+}
+
+// Matrix is the support matrix of the Kubernetes minor versions and the versions of their components.
+type Matrix []MatrixEntry
+
+// ParseMatrix parses and validates the version matrix in yaml.
+func ParseMatrix(data []byte) (Matrix, error) {
+	var m Matrix
+	if err := yaml.UnmarshalStrict(data, &m); err != nil {
+		return nil, errors.Wrap(err, "parse version matrix failed")
+	}
+	seen := make(map[string]struct{}, len(m))
+	for i := range m {
+		minor, err := minorVersion(m[i].Kubernetes)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[minor]; ok {
+			return nil, errors.Errorf("duplicate Kubernetes %s in version matrix", minor)
+		}
+		seen[minor] = struct{}{}
+		m[i].Kubernetes = minor
+	}
+	return m, nil
+}
+
+// Merge returns the matrix overridden by the other one. The entries of the same Kubernetes minor version are merged by
+// field, the fields set in the other entry replace the ones of the matrix, and the new entries are appended.
+func (m Matrix) Merge(other Matrix) Matrix {
+	merged := append(Matrix{}, m...)
+	for _, o := range other {
+		i := merged.index(o.Kubernetes)
+		if i < 0 {
+			merged = append(merged, o)
+			continue
+		}
+		components := merged[i].components()
+		for name, c := range o.components() {
+			components[name].merge(*c)
+		}
+	}
+	return merged
+}
+
+// Validate checks the default version of each component is in its bounds.
+func (m Matrix) Validate() error {
+	for i := range m {
+		for name, c := range m[i].components() {
+			if c.Version == "" {
+				return errors.Errorf("the version of %s of Kubernetes %s is missing in version matrix", name, m[i].Kubernetes)
+			}
+			if err := c.check(name, c.Version, m[i].Kubernetes); err != nil {
+				return errors.Wrap(err, "invalid version matrix")
+			}
+		}
+	}
+	return nil
+}
+
+// Resolve returns the versions of the components installed with the Kubernetes version.
+func (m Matrix) Resolve(version string) (Components, error) {
+	minor, err := minorVersion(version)
+	if err != nil {
+		return Components{}, err
+	}
+	i := m.index(minor)
+	if i < 0 {
+		return Components{}, errors.Errorf("Kubernetes %s is not supported, the supported minor versions are %s",
+			version, strings.Join(m.minors(), ", "))
+	}
+	e := m[i]
+	return Components{
+		Etcd:       e.Etcd.Version,
+		Containerd: e.Containerd.Version,
+		Runc:       e.Runc.Version,
+		CNI:        e.CNI.Version,
+		Pause:      e.Pause.Version,
+		CoreDNS:    e.CoreDNS.Version,
+	}, nil
+}
+
+// Check checks the versions of the components are compatible with the Kubernetes version, the empty ones are skipped.
+func (m Matrix) Check(version string, c Components) error {
+	minor, err := minorVersion(version)
+	if err != nil {
+		return err
+	}
+	i := m.index(minor)
+	if i < 0 {
+		return errors.Errorf("Kubernetes %s is not supported, the supported minor versions are %s",
+			version, strings.Join(m.minors(), ", "))
+	}
+	components := m[i].components()
+	for name, v := range c.versions() {
+		if v == "" {
+			continue
+		}
+		if err := components[name].check(name, v, version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Nearest returns the versions of the components of the nearest Kubernetes minor version in the matrix which isn't
+// newer than the version, or of the oldest one if the version is older than all of them. It is used for the
+// distributions whose versions aren't in the matrix, e.g. k3s.
+func (m Matrix) Nearest(version string) Components {
+	v, err := versionutil.ParseGeneric(version)
+	var nearest, oldest *versionutil.Version
+	var nearestMinor, oldestMinor string
+	for _, e := range m {
+		minor := versionutil.MustParseGeneric(e.Kubernetes)
+		if oldest == nil || minor.LessThan(oldest) {
+			oldest, oldestMinor = minor, e.Kubernetes
+		}
+		if err != nil || !v.LessThan(minor) {
+			if nearest == nil || nearest.LessThan(minor) {
+				nearest, nearestMinor = minor, e.Kubernetes
+			}
+		}
+	}
+	if nearest == nil {
+		nearestMinor = oldestMinor
+	}
+	c, _ := m.Resolve(nearestMinor)
+	return c
+}
+
+func (m Matrix) index(minor string) int {
+	for i := range m {
+		if m[i].Kubernetes == minor {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m Matrix) minors() []string {
+	minors := make([]string, 0, len(m))
+	for _, e := range m {
+		minors = append(minors, e.Kubernetes)
+	}
+	return minors
+}
+
+// merge replaces the fields of the component with the ones set in the other component.
+func (c *Component) merge(other Component) {
+	if other.Version != "" {
+		c.Version = other.Version
+	}
+	if other.MinVersion != "" {
+		c.MinVersion = other.MinVersion
+	}
+	if other.MaxVersion != "" {
+		c.MaxVersion = other.MaxVersion
+	}
+}
+
+// check checks the version of the component is in the bounds.
+func (c *Component) check(name, version, kubeVersion string) error {
+	v, err := versionutil.ParseGeneric(version)
+	if err != nil {
+		return errors.Wrapf(err, "invalid %s version %s", name, version)
+	}
+	if c.MinVersion != "" {
+		minVersion, err := versionutil.ParseGeneric(c.MinVersion)
+		if err != nil {
+			return errors.Wrapf(err, "invalid min %s version %s", name, c.MinVersion)
+		}
+		if v.LessThan(minVersion) {
+			return errors.Errorf("%s %s is not compatible with Kubernetes %s, which requires %s >= %s",
+				name, version, kubeVersion, name, c.MinVersion)
+		}
+	}
+	if c.MaxVersion != "" {
+		maxVersion, err := versionutil.ParseGeneric(c.MaxVersion)
+		if err != nil {
+			return errors.Wrapf(err, "invalid max %s version %s", name, c.MaxVersion)
+		}
+		if maxVersion.LessThan(v) {
+			return errors.Errorf("%s %s is not compatible with Kubernetes %s, which requires %s <= %s",
+				name, version, kubeVersion, name, c.MaxVersion)
+		}
+	}
+	return nil
+}
+
+// minorVersion returns the minor version of the Kubernetes version, e.g. v1.29 of v1.29.3.
+func minorVersion(version string) (string, error) {
+	v, err := versionutil.ParseGeneric(version)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid Kubernetes version %s", version)
+	}
+	return fmt.Sprintf("v%d.%d", v.Major(), v.Minor()), nil
+}
+
+var (
+	matrixMu sync.RWMutex
+	matrix   Matrix
+)
+
+func init() {
+	m, err := ParseMatrix(embeddedMatrix)
+	if err == nil {
+		err = m.Validate()
+	}
+	if err != nil {
+		panic(errors.Wrap(err, "the embedded version matrix is broken"))
+	}
+	matrix = m
+}
+
+// LoadMatrixFile overrides the embedded version matrix with the one in the file, which only needs to contain the
+// components to be changed.
+func LoadMatrixFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "read version matrix %s failed", path)
+	}
+	override, err := ParseMatrix(data)
+	if err != nil {
+		return errors.Wrapf(err, "load version matrix %s failed", path)
+	}
+
+	matrixMu.Lock()
+	defer matrixMu.Unlock()
+	merged := matrix.Merge(override)
+	if err := merged.Validate(); err != nil {
+		return errors.Wrapf(err, "load version matrix %s failed", path)
+	}
+	matrix = merged
+	return nil
+}
+
+// GetMatrix returns the version matrix in use.
+func GetMatrix() Matrix {
+	matrixMu.RLock()
+	defer matrixMu.RUnlock()
+	return matrix
+}
+
+// ResolveComponents returns the versions of the components installed with the Kubernetes version by the version
+// matrix, and fails if the Kubernetes version isn't in the matrix.
+func ResolveComponents(version string) (Components, error) {
+	return GetMatrix().Resolve(version)
+}
+
+// DefaultComponents returns the versions of the components resolved by ResolveComponents, or the nearest ones in the
+// version matrix if the Kubernetes version isn't in it.
+func DefaultComponents(version string) Components {
+	m := GetMatrix()
+	if c, err := m.Resolve(version); err == nil {
+		return c
+	}
+	return m.Nearest(version)
+}
+
+// CheckComponents checks the versions of the components are compatible with the Kubernetes version by the version
+// matrix, the empty ones are skipped.
+func CheckComponents(version string, c Components) error {
+	return GetMatrix().Check(version, c)
+}
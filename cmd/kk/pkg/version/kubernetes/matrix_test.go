@@ -0,0 +1,134 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveComponents(t *testing.T) {
+	tests := []struct {
+		version string
+		want    Components
+		wantErr bool
+	}{
+		{
+			version: "v1.23.15",
+			want:    Components{Etcd: "v3.5.13", Containerd: "1.7.13", Runc: "v1.1.12", CNI: "v1.2.0", Pause: "3.6", CoreDNS: "1.8.6"},
+		},
+		{
+			version: "v1.29.3",
+			want:    Components{Etcd: "v3.5.13", Containerd: "1.7.13", Runc: "v1.1.12", CNI: "v1.2.0", Pause: "3.9", CoreDNS: "1.9.3"},
+		},
+		{
+			version: "v1.18.8",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, err := ResolveComponents(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveComponents() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveComponents() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckComponents(t *testing.T) {
+	tests := []struct {
+		name       string
+		version    string
+		components Components
+		wantErr    bool
+	}{
+		{
+			name:       "compatible",
+			version:    "v1.21.14",
+			components: Components{Etcd: "v3.4.13", Containerd: "1.6.4"},
+		},
+		{
+			name:       "etcd too old",
+			version:    "v1.24.17",
+			components: Components{Etcd: "v3.4.13"},
+			wantErr:    true,
+		},
+		{
+			name:       "containerd too old",
+			version:    "v1.26.5",
+			components: Components{Containerd: "1.5.13"},
+			wantErr:    true,
+		},
+		{
+			name:    "unsupported kubernetes",
+			version: "v1.31.0",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckComponents(tt.version, tt.components); (err != nil) != tt.wantErr {
+				t.Errorf("CheckComponents() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatrixNearest(t *testing.T) {
+	m := GetMatrix()
+	if got := m.Nearest("v1.18.20").Pause; got != "3.2" {
+		t.Errorf("Nearest() of an older version got pause %s, want 3.2", got)
+	}
+	if got := m.Nearest("v1.40.0").Pause; got != "3.9" {
+		t.Errorf("Nearest() of a newer version got pause %s, want 3.9", got)
+	}
+}
+
+func TestLoadMatrixFile(t *testing.T) {
+	origin := GetMatrix()
+	defer func() { matrix = origin }()
+
+	path := filepath.Join(t.TempDir(), "matrix.yaml")
+	override := "- kubernetes: v1.29\n  pause: {version: \"3.9.1\"}\n- kubernetes: v1.31\n" +
+		"  etcd: {version: v3.5.13}\n  containerd: {version: 1.7.13}\n  runc: {version: v1.1.12}\n" +
+		"  cni: {version: v1.2.0}\n  pause: {version: \"3.10\"}\n  coredns: {version: 1.11.1}\n"
+	if err := os.WriteFile(path, []byte(override), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadMatrixFile(path); err != nil {
+		t.Fatalf("LoadMatrixFile() error = %v", err)
+	}
+	if c, _ := ResolveComponents("v1.29.3"); c.Pause != "3.9.1" || c.CoreDNS != "1.9.3" {
+		t.Errorf("ResolveComponents() of the merged entry got %+v", c)
+	}
+	if c, err := ResolveComponents("v1.31.0"); err != nil || c.Pause != "3.10" {
+		t.Errorf("ResolveComponents() of the appended entry got %+v, error %v", c, err)
+	}
+
+	invalid := "- kubernetes: v1.29\n  etcd: {version: v3.4.13}\n"
+	if err := os.WriteFile(path, []byte(invalid), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadMatrixFile(path); err == nil {
+		t.Errorf("LoadMatrixFile() of an incompatible etcd succeeded")
+	}
+}
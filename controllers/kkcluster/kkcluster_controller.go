@@ -25,6 +25,7 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/google/go-cmp/cmp"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -99,6 +100,30 @@ func (r *Reconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, opt
 		return errors.Wrap(err, "error creating controller")
 	}
 
+	if err := c.Watch(
+		&source.Kind{Type: &corev1.Secret{}},
+		handler.EnqueueRequestsFromMapFunc(r.secretToKKClusters(ctx, log)),
+		secretDataChanged(),
+	); err != nil {
+		return errors.Wrap(err, "error watching secrets")
+	}
+
+	if err := c.Watch(
+		&source.Kind{Type: &corev1.ConfigMap{}},
+		handler.EnqueueRequestsFromMapFunc(r.configMapToKKClusters(ctx, log)),
+		configMapDataChanged(),
+	); err != nil {
+		return errors.Wrap(err, "error watching configmaps")
+	}
+
+	files := newFilesWatcher(mgr.GetClient())
+	if err := mgr.Add(files); err != nil {
+		return errors.Wrap(err, "error adding the private key files watcher")
+	}
+	if err := c.Watch(&source.Channel{Source: files.events}, &handler.EnqueueRequestForObject{}); err != nil {
+		return errors.Wrap(err, "error watching private key files")
+	}
+
 	return c.Watch(
 		&source.Kind{Type: &clusterv1.Cluster{}},
 		handler.EnqueueRequestsFromMapFunc(r.requeueKKClusterForUnpausedCluster(ctx, log)),
@@ -150,6 +175,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Re
 			kkCluster,
 			patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
 				infrav1.PrincipalPreparedCondition,
+				infrav1.SpecValidatedCondition,
 			}})
 		if e != nil {
 			fmt.Println(e.Error())
@@ -214,6 +240,14 @@ func (r *Reconciler) reconcileNormal(ctx context.Context, clusterScope *scope.Cl
 		}
 	}
 
+	if res, err := r.reconcileSpecValidation(ctx, clusterScope); !res.IsZero() || err != nil {
+		return res, err
+	}
+	if !conditions.IsTrue(kkCluster, infrav1.SpecValidatedCondition) {
+		// The KKCluster is reconciled again when it or the resources it references change.
+		return ctrl.Result{}, nil
+	}
+
 	if _, err := net.LookupIP(kkCluster.Spec.ControlPlaneLoadBalancer.Host); err != nil {
 		conditions.MarkFalse(kkCluster, infrav1.ExternalLoadBalancerReadyCondition, infrav1.WaitForDNSNameResolveReason, clusterv1.ConditionSeverityInfo, "")
 		clusterScope.Info("Waiting on API server DNS name to resolve")
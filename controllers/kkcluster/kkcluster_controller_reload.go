@@ -0,0 +1,457 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package kkcluster
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/imdario/mergo"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	infrav1 "github.com/kubesphere/kubekey/v3/api/v1beta1"
+	"github.com/kubesphere/kubekey/v3/pkg/scope"
+	"github.com/kubesphere/kubekey/v3/util/collections"
+)
+
+// filesPollInterval is the interval of polling the private key files referenced by the KKClusters.
+const filesPollInterval = 30 * time.Second
+
+// reconcileSpecValidation validates the KKCluster against the secrets, the instances ConfigMap and the private key
+// files it references, and re-reconciles the KKInstances when they changed if the reload policy is "reconcile".
+// The SpecValidated condition is false if the KKCluster isn't valid.
+func (r *Reconciler) reconcileSpecValidation(ctx context.Context, clusterScope *scope.ClusterScope) (ctrl.Result, error) {
+	kkCluster := clusterScope.KKCluster
+
+	configMap, err := scope.InstancesConfigMap(ctx, r.Client, kkCluster)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			conditions.MarkFalse(kkCluster, infrav1.SpecValidatedCondition, infrav1.ConfigMapNotFoundReason,
+				clusterv1.ConditionSeverityWarning, "ConfigMap %s is not found", kkCluster.Spec.Nodes.InstancesConfigMap)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, errors.Wrapf(err, "failed to get configmap %s", kkCluster.Spec.Nodes.InstancesConfigMap)
+	}
+	if err := clusterScope.SetInstancesConfigMap(configMap); err != nil {
+		r.markInvalidSpec(kkCluster, err)
+		return ctrl.Result{}, nil
+	}
+	instances := clusterScope.AllInstancesInfo()
+
+	secrets := make(map[string]*corev1.Secret)
+	for _, name := range referencedSecrets(kkCluster, instances) {
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: kkCluster.Namespace, Name: name}, secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				conditions.MarkFalse(kkCluster, infrav1.SpecValidatedCondition, infrav1.SecretNotFoundReason,
+					clusterv1.ConditionSeverityWarning, "Secret %s is not found", name)
+				return ctrl.Result{}, nil
+			}
+			return ctrl.Result{}, errors.Wrapf(err, "failed to get secret %s", name)
+		}
+		secrets[name] = secret
+	}
+	files := readFiles(referencedFiles(kkCluster, instances))
+
+	if err := validateKKCluster(kkCluster, instances, secrets, files); err != nil {
+		r.markInvalidSpec(kkCluster, err)
+		return ctrl.Result{}, nil
+	}
+	conditions.MarkTrue(kkCluster, infrav1.SpecValidatedCondition)
+
+	hash := referencesHash(secrets, configMap, files)
+	if kkCluster.Annotations[infrav1.SecretsHashAnnotation] != hash {
+		clusterScope.Info("Resources referenced by the KKCluster changed", "hash", hash)
+		if kkCluster.Annotations == nil {
+			kkCluster.Annotations = make(map[string]string)
+		}
+		kkCluster.Annotations[infrav1.SecretsHashAnnotation] = hash
+	}
+
+	if kkCluster.Annotations[infrav1.ReloadPolicyAnnotation] != infrav1.ReloadPolicyReconcile {
+		return ctrl.Result{}, nil
+	}
+	return r.reconcileKKInstanceSecretsHash(ctx, clusterScope, hash)
+}
+
+func (r *Reconciler) markInvalidSpec(kkCluster *infrav1.KKCluster, err error) {
+	conditions.MarkFalse(kkCluster, infrav1.SpecValidatedCondition, infrav1.InvalidSpecReason,
+		clusterv1.ConditionSeverityWarning, err.Error())
+	r.Recorder.Eventf(kkCluster, corev1.EventTypeWarning, "InvalidSpec", "Invalid KKCluster spec: %v", err)
+}
+
+// reconcileKKInstanceSecretsHash sets the hash of the secrets on the KKInstances, the changed annotation triggers
+// the reconciliation of the KKInstances.
+func (r *Reconciler) reconcileKKInstanceSecretsHash(ctx context.Context, clusterScope *scope.ClusterScope, hash string) (ctrl.Result, error) {
+	kkCluster := clusterScope.KKCluster
+	kkInstances, err := collections.GetFilteredKKInstancesForKKCluster(ctx, r.Client, kkCluster, collections.ActiveKKInstances,
+		collections.OwnedKKInstances(kkCluster))
+	if err != nil {
+		clusterScope.Error(err, "failed to get active kkInstances for kkCluster")
+		return ctrl.Result{}, err
+	}
+
+	for _, kkInstance := range kkInstances {
+		if kkInstance.Annotations[infrav1.SecretsHashAnnotation] == hash {
+			continue
+		}
+		kkiCopy := kkInstance.DeepCopy()
+		if kkiCopy.Annotations == nil {
+			kkiCopy.Annotations = make(map[string]string)
+		}
+		kkiCopy.Annotations[infrav1.SecretsHashAnnotation] = hash
+
+		clusterScope.Info("Reload the KKInstance", "KKInstance", kkiCopy.Name)
+		if err := r.Client.Update(ctx, kkiCopy); err != nil {
+			r.Recorder.Eventf(kkCluster, corev1.EventTypeWarning, "FailedUpdateKKInstance",
+				"Failed to update kkInstance %s annotation: %v", kkiCopy.Name, err)
+			return ctrl.Result{RequeueAfter: 15 * time.Second}, err
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+// validateKKCluster checks the instances have unique names and addresses, and each of them has a password or
+// private key to connect with, either in the spec, in the secret or in the private key file.
+func validateKKCluster(kkCluster *infrav1.KKCluster, instances []infrav1.InstanceInfo, secrets map[string]*corev1.Secret,
+	files map[string][]byte) error {
+	names := make(map[string]struct{})
+	addresses := make(map[string]struct{})
+	for _, instance := range instances {
+		if _, ok := names[instance.Name]; ok {
+			return errors.Errorf("duplicate instance name %s", instance.Name)
+		}
+		names[instance.Name] = struct{}{}
+		if _, ok := addresses[instance.Address]; ok {
+			return errors.Errorf("duplicate instance address %s", instance.Address)
+		}
+		addresses[instance.Address] = struct{}{}
+
+		auth := instance.Auth.DeepCopy()
+		if err := mergo.Merge(auth, kkCluster.Spec.Nodes.Auth.DeepCopy()); err != nil {
+			return errors.Wrapf(err, "failed to merge the auth of instance %s", instance.Name)
+		}
+		if auth.Password != "" || auth.PrivateKey != "" {
+			continue
+		}
+		if auth.PrivateKeyPath != "" {
+			if len(files[auth.PrivateKeyPath]) > 0 {
+				continue
+			}
+			return errors.Errorf("private key file %s of instance %s isn't readable", auth.PrivateKeyPath, instance.Name)
+		}
+		if secret, ok := secrets[auth.Secret]; ok && (len(secret.Data["password"]) > 0 || len(secret.Data["privateKey"]) > 0) {
+			continue
+		}
+		return errors.Errorf("instance %s has no password or private key", instance.Name)
+	}
+	return nil
+}
+
+// referencedSecrets returns the sorted names of the secrets referenced by the KKCluster and its instances.
+func referencedSecrets(kkCluster *infrav1.KKCluster, instances []infrav1.InstanceInfo) []string {
+	set := make(map[string]struct{})
+	if kkCluster.Spec.Nodes.Auth.Secret != "" {
+		set[kkCluster.Spec.Nodes.Auth.Secret] = struct{}{}
+	}
+	for _, instance := range instances {
+		if instance.Auth.Secret != "" {
+			set[instance.Auth.Secret] = struct{}{}
+		}
+	}
+	return sortedNames(set)
+}
+
+// referencedFiles returns the sorted paths of the private key files referenced by the KKCluster and its instances.
+func referencedFiles(kkCluster *infrav1.KKCluster, instances []infrav1.InstanceInfo) []string {
+	set := make(map[string]struct{})
+	if kkCluster.Spec.Nodes.Auth.PrivateKeyPath != "" {
+		set[kkCluster.Spec.Nodes.Auth.PrivateKeyPath] = struct{}{}
+	}
+	for _, instance := range instances {
+		if instance.Auth.PrivateKeyPath != "" {
+			set[instance.Auth.PrivateKeyPath] = struct{}{}
+		}
+	}
+	return sortedNames(set)
+}
+
+func sortedNames(set map[string]struct{}) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// readFiles returns the content of the files, the files which can't be read are left out.
+func readFiles(paths []string) map[string][]byte {
+	files := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		files[path] = data
+	}
+	return files
+}
+
+func secretsHash(secrets map[string]*corev1.Secret) string {
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		data := secrets[name].Data
+		keys := make([]string, 0, len(data))
+		for k := range data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(h, "%s/%s=%x;", name, k, data[k])
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// referencesHash returns the hash of the secrets, the instances ConfigMap and the private key files referenced by the
+// KKCluster. It's the hash of the secrets alone if there is no ConfigMap or file, so it doesn't change for the
+// KKClusters which only reference secrets.
+func referencesHash(secrets map[string]*corev1.Secret, configMap *corev1.ConfigMap, files map[string][]byte) string {
+	if configMap == nil && len(files) == 0 {
+		return secretsHash(secrets)
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "secrets=%s;", secretsHash(secrets))
+	if configMap != nil {
+		fmt.Fprintf(h, "configmap/%s=%x;", configMap.Name, configMap.Data["instances"])
+	}
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		fmt.Fprintf(h, "file/%s=%x;", path, files[path])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// allInstances returns the instances of the KKCluster and the ones in its instances ConfigMap, which are left out if
+// the ConfigMap can't be read.
+func allInstances(ctx context.Context, c client.Client, kkCluster *infrav1.KKCluster) []infrav1.InstanceInfo {
+	configMap, err := scope.InstancesConfigMap(ctx, c, kkCluster)
+	if err != nil {
+		return kkCluster.Spec.Nodes.Instances
+	}
+	instances, err := scope.ParseInstancesConfigMap(configMap)
+	if err != nil || len(instances) == 0 {
+		return kkCluster.Spec.Nodes.Instances
+	}
+	return append(append([]infrav1.InstanceInfo{}, kkCluster.Spec.Nodes.Instances...), instances...)
+}
+
+// secretToKKClusters maps a secret to the KKClusters in the same namespace which reference it.
+func (r *Reconciler) secretToKKClusters(ctx context.Context, log logr.Logger) handler.MapFunc {
+	return func(o client.Object) []ctrl.Request {
+		kkClusters := &infrav1.KKClusterList{}
+		if err := r.List(ctx, kkClusters, client.InNamespace(o.GetNamespace())); err != nil {
+			log.V(4).Error(err, "Failed to list KubeKey clusters")
+			return nil
+		}
+
+		var requests []ctrl.Request
+		for i := range kkClusters.Items {
+			kkCluster := &kkClusters.Items[i]
+			for _, name := range referencedSecrets(kkCluster, allInstances(ctx, r.Client, kkCluster)) {
+				if name == o.GetName() {
+					log.V(4).Info("Adding request.", "secret", o.GetName(), "kkCluster", kkCluster.Name)
+					requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(kkCluster)})
+					break
+				}
+			}
+		}
+		return requests
+	}
+}
+
+// secretDataChanged filters the updates of the secrets which don't change the data.
+func secretDataChanged() predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldSecret, ok := e.ObjectOld.(*corev1.Secret)
+			if !ok {
+				return true
+			}
+			newSecret, ok := e.ObjectNew.(*corev1.Secret)
+			if !ok {
+				return true
+			}
+			return !reflect.DeepEqual(oldSecret.Data, newSecret.Data)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// configMapToKKClusters maps a ConfigMap to the KKClusters in the same namespace which use it as the instances ConfigMap.
+func (r *Reconciler) configMapToKKClusters(ctx context.Context, log logr.Logger) handler.MapFunc {
+	return func(o client.Object) []ctrl.Request {
+		kkClusters := &infrav1.KKClusterList{}
+		if err := r.List(ctx, kkClusters, client.InNamespace(o.GetNamespace())); err != nil {
+			log.V(4).Error(err, "Failed to list KubeKey clusters")
+			return nil
+		}
+
+		var requests []ctrl.Request
+		for i := range kkClusters.Items {
+			kkCluster := &kkClusters.Items[i]
+			if kkCluster.Spec.Nodes.InstancesConfigMap == o.GetName() {
+				log.V(4).Info("Adding request.", "configMap", o.GetName(), "kkCluster", kkCluster.Name)
+				requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(kkCluster)})
+			}
+		}
+		return requests
+	}
+}
+
+// configMapDataChanged filters the updates of the ConfigMaps which don't change the data.
+func configMapDataChanged() predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldConfigMap, ok := e.ObjectOld.(*corev1.ConfigMap)
+			if !ok {
+				return true
+			}
+			newConfigMap, ok := e.ObjectNew.(*corev1.ConfigMap)
+			if !ok {
+				return true
+			}
+			return !reflect.DeepEqual(oldConfigMap.Data, newConfigMap.Data)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// filesWatcher polls the private key files referenced by the KKClusters, and sends an event of the KKClusters which
+// reference a file whose content changed. The files are read by the controller, e.g. mounted from a secret, so there
+// is no object to watch.
+type filesWatcher struct {
+	client   client.Client
+	interval time.Duration
+	events   chan event.GenericEvent
+	// hashes are the hashes of the content of the files at the last poll, empty for a file which can't be read.
+	hashes map[string]string
+}
+
+func newFilesWatcher(c client.Client) *filesWatcher {
+	return &filesWatcher{
+		client:   c,
+		interval: filesPollInterval,
+		events:   make(chan event.GenericEvent),
+	}
+}
+
+// Start polls the files until the context is done.
+func (w *filesWatcher) Start(ctx context.Context) error {
+	log := ctrl.LoggerFrom(ctx)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := w.poll(ctx); err != nil {
+				log.V(4).Error(err, "Failed to poll the private key files")
+			}
+		}
+	}
+}
+
+func (w *filesWatcher) poll(ctx context.Context) error {
+	kkClusters := &infrav1.KKClusterList{}
+	if err := w.client.List(ctx, kkClusters); err != nil {
+		return errors.Wrap(err, "failed to list KubeKey clusters")
+	}
+
+	hashes := make(map[string]string)
+	var changed []*infrav1.KKCluster
+	for i := range kkClusters.Items {
+		kkCluster := &kkClusters.Items[i]
+		modified := false
+		for _, path := range referencedFiles(kkCluster, allInstances(ctx, w.client, kkCluster)) {
+			hash, ok := hashes[path]
+			if !ok {
+				hash = fileHash(path)
+				hashes[path] = hash
+			}
+			if last, ok := w.hashes[path]; ok && last != hash {
+				modified = true
+			}
+		}
+		if modified {
+			changed = append(changed, kkCluster)
+		}
+	}
+	w.hashes = hashes
+
+	for _, kkCluster := range changed {
+		select {
+		case w.events <- event.GenericEvent{Object: kkCluster}:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return nil
+}
+
+func fileHash(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
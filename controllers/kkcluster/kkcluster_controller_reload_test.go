@@ -0,0 +1,231 @@
+/*
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package kkcluster
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	infrav1 "github.com/kubesphere/kubekey/v3/api/v1beta1"
+)
+
+func newKKCluster(auth infrav1.Auth, instances ...infrav1.InstanceInfo) *infrav1.KKCluster {
+	return &infrav1.KKCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "capkk-1", Namespace: "default"},
+		Spec: infrav1.KKClusterSpec{
+			Nodes: infrav1.Nodes{Auth: auth, Instances: instances},
+		},
+	}
+}
+
+func newSecret(name string, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}, Data: data}
+}
+
+func TestValidateKKCluster(t *testing.T) {
+	node1 := infrav1.InstanceInfo{Name: "node1", Address: "192.168.0.1"}
+	node2 := infrav1.InstanceInfo{Name: "node2", Address: "192.168.0.2"}
+
+	tests := []struct {
+		name      string
+		kkCluster *infrav1.KKCluster
+		instances []infrav1.InstanceInfo
+		secrets   map[string]*corev1.Secret
+		files     map[string][]byte
+		wantErr   string
+	}{
+		{
+			name:      "password of the global auth",
+			kkCluster: newKKCluster(infrav1.Auth{Password: "p@ssw0rd"}),
+			instances: []infrav1.InstanceInfo{node1, node2},
+		},
+		{
+			name:      "duplicate name",
+			kkCluster: newKKCluster(infrav1.Auth{Password: "p@ssw0rd"}),
+			instances: []infrav1.InstanceInfo{node1, {Name: "node1", Address: "192.168.0.2"}},
+			wantErr:   "duplicate instance name node1",
+		},
+		{
+			name:      "duplicate address",
+			kkCluster: newKKCluster(infrav1.Auth{Password: "p@ssw0rd"}),
+			instances: []infrav1.InstanceInfo{node1, {Name: "node2", Address: "192.168.0.1"}},
+			wantErr:   "duplicate instance address 192.168.0.1",
+		},
+		{
+			name:      "no credentials",
+			kkCluster: newKKCluster(infrav1.Auth{User: "ubuntu"}),
+			instances: []infrav1.InstanceInfo{node1},
+			wantErr:   "instance node1 has no password or private key",
+		},
+		{
+			name:      "private key in the secret",
+			kkCluster: newKKCluster(infrav1.Auth{Secret: "ssh"}),
+			instances: []infrav1.InstanceInfo{node1},
+			secrets:   map[string]*corev1.Secret{"ssh": newSecret("ssh", map[string][]byte{"privateKey": []byte("key")})},
+		},
+		{
+			name:      "secret without credentials",
+			kkCluster: newKKCluster(infrav1.Auth{Secret: "ssh"}),
+			instances: []infrav1.InstanceInfo{node1},
+			secrets:   map[string]*corev1.Secret{"ssh": newSecret("ssh", map[string][]byte{"user": []byte("ubuntu")})},
+			wantErr:   "instance node1 has no password or private key",
+		},
+		{
+			name:      "secret of the instance",
+			kkCluster: newKKCluster(infrav1.Auth{}),
+			instances: []infrav1.InstanceInfo{{Name: "node1", Address: "192.168.0.1", Auth: infrav1.Auth{Secret: "node1"}}},
+			secrets:   map[string]*corev1.Secret{"node1": newSecret("node1", map[string][]byte{"password": []byte("p@ssw0rd")})},
+		},
+		{
+			name:      "readable private key file",
+			kkCluster: newKKCluster(infrav1.Auth{PrivateKeyPath: "/root/.ssh/id_rsa"}),
+			instances: []infrav1.InstanceInfo{node1},
+			files:     map[string][]byte{"/root/.ssh/id_rsa": []byte("key")},
+		},
+		{
+			name:      "unreadable private key file",
+			kkCluster: newKKCluster(infrav1.Auth{PrivateKeyPath: "/root/.ssh/id_rsa"}),
+			instances: []infrav1.InstanceInfo{node1},
+			wantErr:   "private key file /root/.ssh/id_rsa of instance node1 isn't readable",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			err := validateKKCluster(tt.kkCluster, tt.instances, tt.secrets, tt.files)
+			if tt.wantErr == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(MatchError(tt.wantErr))
+		})
+	}
+}
+
+func TestReferencedSecrets(t *testing.T) {
+	g := NewWithT(t)
+
+	kkCluster := newKKCluster(infrav1.Auth{Secret: "global", PrivateKeyPath: "/etc/capkk/id_rsa"})
+	instances := []infrav1.InstanceInfo{
+		{Name: "node1", Auth: infrav1.Auth{Secret: "node1", PrivateKeyPath: "/etc/capkk/node1"}},
+		{Name: "node2", Auth: infrav1.Auth{Secret: "global"}},
+		{Name: "node3"},
+	}
+
+	g.Expect(referencedSecrets(kkCluster, instances)).To(Equal([]string{"global", "node1"}))
+	g.Expect(referencedSecrets(newKKCluster(infrav1.Auth{}), nil)).To(BeEmpty())
+	g.Expect(referencedFiles(kkCluster, instances)).To(Equal([]string{"/etc/capkk/id_rsa", "/etc/capkk/node1"}))
+}
+
+func TestSecretsHash(t *testing.T) {
+	g := NewWithT(t)
+
+	secrets := map[string]*corev1.Secret{
+		"a": newSecret("a", map[string][]byte{"password": []byte("1"), "privateKey": []byte("2")}),
+		"b": newSecret("b", map[string][]byte{"password": []byte("3")}),
+	}
+	hash := secretsHash(secrets)
+	g.Expect(hash).To(HaveLen(16))
+	for i := 0; i < 10; i++ {
+		g.Expect(secretsHash(secrets)).To(Equal(hash))
+	}
+
+	rotated := map[string]*corev1.Secret{
+		"a": secrets["a"],
+		"b": newSecret("b", map[string][]byte{"password": []byte("4")}),
+	}
+	g.Expect(secretsHash(rotated)).NotTo(Equal(hash))
+
+	renamed := map[string]*corev1.Secret{
+		"a": secrets["a"],
+		"c": newSecret("c", secrets["b"].Data),
+	}
+	g.Expect(secretsHash(renamed)).NotTo(Equal(hash))
+
+	g.Expect(referencesHash(secrets, nil, nil)).To(Equal(hash))
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "inventory"}, Data: map[string]string{"instances": "- name: node3"}}
+	withConfigMap := referencesHash(secrets, configMap, nil)
+	g.Expect(withConfigMap).NotTo(Equal(hash))
+	withFile := referencesHash(secrets, configMap, map[string][]byte{"/etc/capkk/id_rsa": []byte("key")})
+	g.Expect(withFile).NotTo(Equal(withConfigMap))
+}
+
+func TestSecretDataChanged(t *testing.T) {
+	g := NewWithT(t)
+
+	p := secretDataChanged()
+	old := newSecret("ssh", map[string][]byte{"password": []byte("1")})
+
+	relabeled := old.DeepCopy()
+	relabeled.Labels = map[string]string{"foo": "bar"}
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: relabeled})).To(BeFalse())
+
+	rotated := newSecret("ssh", map[string][]byte{"password": []byte("2")})
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: rotated})).To(BeTrue())
+
+	g.Expect(p.Create(event.CreateEvent{Object: old})).To(BeTrue())
+	g.Expect(p.Delete(event.DeleteEvent{Object: old})).To(BeTrue())
+	g.Expect(p.Generic(event.GenericEvent{Object: old})).To(BeFalse())
+
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "inventory"}, Data: map[string]string{"instances": "[]"}}
+	changed := configMap.DeepCopy()
+	changed.Data["instances"] = "- name: node1"
+	g.Expect(configMapDataChanged().Update(event.UpdateEvent{ObjectOld: configMap, ObjectNew: configMap.DeepCopy()})).To(BeFalse())
+	g.Expect(configMapDataChanged().Update(event.UpdateEvent{ObjectOld: configMap, ObjectNew: changed})).To(BeTrue())
+}
+
+func TestFilesWatcher(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(infrav1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+	key := filepath.Join(t.TempDir(), "id_rsa")
+	g.Expect(os.WriteFile(key, []byte("key"), 0o600)).To(Succeed())
+	kkCluster := newKKCluster(infrav1.Auth{PrivateKeyPath: key}, infrav1.InstanceInfo{Name: "node1"})
+	other := newKKCluster(infrav1.Auth{Password: "p@ssw0rd"}, infrav1.InstanceInfo{Name: "node1"})
+	other.Name = "capkk-2"
+
+	w := newFilesWatcher(fake.NewClientBuilder().WithScheme(scheme).WithObjects(kkCluster, other).Build())
+	w.events = make(chan event.GenericEvent, 2)
+	ctx := context.Background()
+
+	g.Expect(w.poll(ctx)).To(Succeed())
+	g.Expect(w.events).To(BeEmpty())
+	g.Expect(w.poll(ctx)).To(Succeed())
+	g.Expect(w.events).To(BeEmpty())
+
+	g.Expect(os.WriteFile(key, []byte("rotated"), 0o600)).To(Succeed())
+	g.Expect(w.poll(ctx)).To(Succeed())
+	g.Expect(w.events).To(HaveLen(1))
+	e := <-w.events
+	g.Expect(e.Object.GetName()).To(Equal("capkk-1"))
+
+	g.Expect(os.Remove(key)).To(Succeed())
+	g.Expect(w.poll(ctx)).To(Succeed())
+	g.Expect(w.events).To(HaveLen(1))
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/jinzhu/copier"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2/klogr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
@@ -30,6 +31,7 @@ import (
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
 
 	infrav1 "github.com/kubesphere/kubekey/v3/api/v1beta1"
 	"github.com/kubesphere/kubekey/v3/pkg/rootfs"
@@ -91,6 +93,9 @@ type ClusterScope struct {
 	controllerName string
 
 	rootFs rootfs.Interface
+
+	// configMapInstances are the instances in the instances ConfigMap of the KKCluster, set by SetInstancesConfigMap.
+	configMapInstances []infrav1.InstanceInfo
 }
 
 // Name returns the CAPI cluster name.
@@ -165,14 +170,66 @@ func (s *ClusterScope) GlobalAuth() *infrav1.Auth {
 
 // AllInstancesInfo returns the all instance specs.
 func (s *ClusterScope) AllInstancesInfo() []infrav1.InstanceInfo {
-	return s.KKCluster.Spec.Nodes.Instances
+	if len(s.configMapInstances) == 0 {
+		return s.KKCluster.Spec.Nodes.Instances
+	}
+	instances := make([]infrav1.InstanceInfo, 0, len(s.KKCluster.Spec.Nodes.Instances)+len(s.configMapInstances))
+	instances = append(instances, s.KKCluster.Spec.Nodes.Instances...)
+	return append(instances, s.configMapInstances...)
+}
+
+// LoadInstancesConfigMap loads the instances in the instances ConfigMap of the KKCluster, if it references one.
+// The error of getting the ConfigMap is returned as is, so a missing ConfigMap can be told by apierrors.IsNotFound.
+func (s *ClusterScope) LoadInstancesConfigMap(ctx context.Context) error {
+	cm, err := InstancesConfigMap(ctx, s.client, s.KKCluster)
+	if err != nil {
+		return err
+	}
+	return s.SetInstancesConfigMap(cm)
+}
+
+// SetInstancesConfigMap sets the instances in data.instances of the instances ConfigMap, which is nil if the KKCluster
+// references none.
+func (s *ClusterScope) SetInstancesConfigMap(cm *corev1.ConfigMap) error {
+	instances, err := ParseInstancesConfigMap(cm)
+	if err != nil {
+		return err
+	}
+	s.configMapInstances = instances
+	return nil
+}
+
+// ParseInstancesConfigMap parses the instances in data.instances of the instances ConfigMap, nil if it's nil.
+func ParseInstancesConfigMap(cm *corev1.ConfigMap) ([]infrav1.InstanceInfo, error) {
+	if cm == nil {
+		return nil, nil
+	}
+	var instances []infrav1.InstanceInfo
+	if err := yaml.UnmarshalStrict([]byte(cm.Data["instances"]), &instances); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the instances of ConfigMap %s", cm.Name)
+	}
+	return instances, nil
+}
+
+// InstancesConfigMap returns the instances ConfigMap of the KKCluster, nil if it references none.
+func InstancesConfigMap(ctx context.Context, c client.Client, kkCluster *infrav1.KKCluster) (*corev1.ConfigMap, error) {
+	name := kkCluster.Spec.Nodes.InstancesConfigMap
+	if name == "" {
+		return nil, nil
+	}
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: kkCluster.Namespace, Name: name}, cm); err != nil {
+		return nil, err
+	}
+	return cm, nil
 }
 
 // GetInstancesSpecByRole returns the KKInstance spec for the given role.
 func (s *ClusterScope) GetInstancesSpecByRole(role infrav1.Role) []infrav1.KKInstanceSpec {
 	var arr []infrav1.KKInstanceSpec
-	for i := range s.KKCluster.Spec.Nodes.Instances {
-		instance := s.KKCluster.Spec.Nodes.Instances[i]
+	instances := s.AllInstancesInfo()
+	for i := range instances {
+		instance := instances[i]
 		for _, r := range instance.Roles {
 			if r == role {
 				spec := infrav1.KKInstanceSpec{}
@@ -246,6 +303,7 @@ func (s *ClusterScope) PatchObject() error {
 			infrav1.HostReadyCondition,
 			infrav1.ExternalLoadBalancerReadyCondition,
 			infrav1.PrincipalPreparedCondition,
+			infrav1.SpecValidatedCondition,
 		}})
 }
 
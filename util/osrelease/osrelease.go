@@ -48,6 +48,7 @@ type Data struct {
 	PrettyName string
 	Version    string
 	VersionID  string
+	VariantID  string
 }
 
 // Parse is to parse a os release file content.
@@ -71,6 +72,7 @@ func Parse(content string) (data *Data) {
 	data.PrettyName = info["PRETTY_NAME"]
 	data.Version = info["VERSION"]
 	data.VersionID = info["VERSION_ID"]
+	data.VariantID = info["VARIANT_ID"]
 	return
 }
 
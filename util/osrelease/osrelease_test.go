@@ -73,6 +73,17 @@ var testResult = []struct {
 			VersionID:  "7",
 		},
 	},
+	{
+		name: "fcos38",
+		data: &Data{
+			ID:         "fedora",
+			Name:       "Fedora Linux",
+			PrettyName: "Fedora CoreOS 38.20230819.3.0",
+			Version:    "38.20230819.3.0 (CoreOS)",
+			VersionID:  "38",
+			VariantID:  "coreos",
+		},
+	},
 }
 
 func TestParse(t *testing.T) {
@@ -21,6 +21,7 @@ import (
 	"context"
 
 	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
@@ -55,6 +56,9 @@ func GetInfraCluster(ctx context.Context, c client.Client, log logr.Logger, clus
 	if err != nil {
 		return nil, err
 	}
+	if err := clusterScope.LoadInstancesConfigMap(ctx); err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
 
 	return clusterScope, nil
 }